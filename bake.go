@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/df07/go-progressive-raytracer/pkg/bake"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// runBake bakes the scene's light for --bake: a lightmap of each mesh with UVs, or a grid of
+// irradiance probes over the scene, saved in the scene's output directory
+func runBake(config Config) error {
+	sceneObj, err := loadScene(config)
+	if err != nil {
+		return fmt.Errorf("failed to create scene: %w", err)
+	}
+	if err := sceneObj.Preprocess(); err != nil {
+		return fmt.Errorf("failed to preprocess scene: %w", err)
+	}
+	opts := bake.Options{Samples: config.MaxSamples, Workers: config.NumWorkers, Seed: config.Seed}
+	outputDir := createOutputDir(config.SceneType)
+
+	switch config.Bake {
+	case "lightmap":
+		return bakeLightmaps(sceneObj, config.BakeSize, opts, outputDir)
+	case "probes":
+		counts, err := parseCoords(config.BakeProbes, 3)
+		if err != nil {
+			return fmt.Errorf("probe grid %q should be nx,ny,nz: %v", config.BakeProbes, err)
+		}
+		return bakeProbes(sceneObj, counts, opts, outputDir)
+	default:
+		return fmt.Errorf("unknown bake %q (expected lightmap or probes)", config.Bake)
+	}
+}
+
+// bakeLightmaps saves a lightmap of each mesh with UVs as lightmap_<shape>.hdr, with a PNG to view
+func bakeLightmaps(sceneObj *scene.Scene, size int, opts bake.Options, outputDir string) error {
+	baked := 0
+	for i, shape := range sceneObj.Shapes {
+		if visibility, ok := shape.(*geometry.VisibilityShape); ok {
+			shape = visibility.Shape
+		}
+		mesh, ok := shape.(*geometry.TriangleMesh)
+		if !ok {
+			continue
+		}
+		lightmap, err := bake.RenderLightmap(sceneObj, mesh, size, size, opts)
+		if err != nil {
+			fmt.Printf("Skipping shape %d: %v\n", i+1, err)
+			continue
+		}
+
+		base := filepath.Join(outputDir, fmt.Sprintf("lightmap_%d", i+1))
+		file, err := os.Create(base + ".hdr")
+		if err != nil {
+			return err
+		}
+		if err := lightmap.WriteHDR(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if err := saveImageToFile(lightmap.Image(), base+".png", nil); err != nil {
+			return err
+		}
+		fmt.Printf("Lightmap of shape %d saved as %s.hdr\n", i+1, base)
+		baked++
+	}
+	if baked == 0 {
+		return fmt.Errorf("the scene has no meshes with UVs to bake lightmaps for")
+	}
+	return nil
+}
+
+// bakeProbes saves a grid of probes filling the scene's bounds as probes.json
+func bakeProbes(sceneObj *scene.Scene, counts []int, opts bake.Options, outputDir string) error {
+	if counts[0] <= 0 || counts[1] <= 0 || counts[2] <= 0 {
+		return fmt.Errorf("probe grid %dx%dx%d must have probes along each axis", counts[0], counts[1], counts[2])
+	}
+	points := bake.GridPoints(sceneObj.Intersector.BoundingBox(), counts[0], counts[1], counts[2])
+	probes, err := bake.RenderProbes(sceneObj, points, opts)
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(outputDir, "probes.json")
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := bake.WriteProbesJSON(file, probes); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("%d probes saved as %s\n", len(probes), filename)
+	return nil
+}
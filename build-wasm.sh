@@ -0,0 +1,19 @@
+#!/bin/bash
+
+# Builds the WebAssembly renderer for in-browser rendering in the web UI
+# Usage: ./build-wasm.sh
+#   Writes web/static/raytracer.wasm and copies Go's wasm_exec.js support file next to it
+
+set -e
+
+GOOS=js GOARCH=wasm go build -o web/static/raytracer.wasm ./web/wasm
+
+# wasm_exec.js must match the Go version that built the module; its location moved in Go 1.24
+GOROOT=$(go env GOROOT)
+if [ -f "$GOROOT/lib/wasm/wasm_exec.js" ]; then
+    cp "$GOROOT/lib/wasm/wasm_exec.js" web/static/js/
+else
+    cp "$GOROOT/misc/wasm/wasm_exec.js" web/static/js/
+fi
+
+echo "Built web/static/raytracer.wasm ($(du -h web/static/raytracer.wasm | cut -f1))"
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Cross-validation settings: every integrator renders each scene several times with different
+// seeds, splitting the sample budget, so that each pixel has independent means to compare
+const (
+	crossValidationReplicas = 4    // Independent renders per integrator and scene
+	crossValidationAlpha    = 0.01 // Significance level of the per-pixel and image mean tests
+	crossValidationMaxZ     = 3.0  // Allowed excess of rejected pixels over alpha, in binomial standard deviations
+)
+
+// crossValidationRender is one integrator's renders of a scene
+type crossValidationRender struct {
+	Integrator string
+	Replicas   []*renderer.Accumulation
+	Merged     *renderer.Accumulation // All replicas together, for the report image
+	Time       time.Duration
+}
+
+// pixelMeans returns each replica's mean luminance of one pixel
+func (r *crossValidationRender) pixelMeans(i int) []float64 {
+	means := make([]float64, len(r.Replicas))
+	for k, acc := range r.Replicas {
+		means[k] = acc.Pixels[i].GetColor().Luminance()
+	}
+	return means
+}
+
+// imageMeans returns each replica's mean luminance over the whole image
+func (r *crossValidationRender) imageMeans() []float64 {
+	means := make([]float64, len(r.Replicas))
+	for k, acc := range r.Replicas {
+		for i := range acc.Pixels {
+			means[k] += acc.Pixels[i].GetColor().Luminance()
+		}
+		means[k] /= float64(len(acc.Pixels))
+	}
+	return means
+}
+
+// crossValidationComparison is the statistical comparison of an integrator against the reference
+// (the first integrator) on one scene
+type crossValidationComparison struct {
+	Reference, Integrator string
+	ReferenceMean, Mean   float64 // Image mean luminance
+	MeanT, MeanP          float64 // Welch's t-test on the replicas' image means
+	Pixels                int     // Pixels tested (pixels identical in every replica are skipped)
+	Rejected              int     // Pixels whose means differ at crossValidationAlpha
+	PixelT                []float64
+}
+
+// RejectedFraction returns the fraction of tested pixels whose means differ significantly
+func (c crossValidationComparison) RejectedFraction() float64 {
+	if c.Pixels == 0 {
+		return 0
+	}
+	return float64(c.Rejected) / float64(c.Pixels)
+}
+
+// ExcessZ returns how many binomial standard deviations more pixels were rejected than the
+// crossValidationAlpha false positives expected of equivalent integrators
+func (c crossValidationComparison) ExcessZ() float64 {
+	expected := float64(c.Pixels) * crossValidationAlpha
+	if expected == 0 {
+		return 0
+	}
+	return (float64(c.Rejected) - expected) / math.Sqrt(expected*(1-crossValidationAlpha))
+}
+
+// Passed reports whether the integrators are statistically indistinguishable on the scene
+func (c crossValidationComparison) Passed() bool {
+	return c.MeanP >= crossValidationAlpha && c.ExcessZ() <= crossValidationMaxZ
+}
+
+// compareRenders runs the per-pixel and image mean t-tests of two integrators' renders
+func compareRenders(reference, other *crossValidationRender) crossValidationComparison {
+	c := crossValidationComparison{
+		Reference:  reference.Integrator,
+		Integrator: other.Integrator,
+		PixelT:     make([]float64, len(reference.Merged.Pixels)),
+	}
+	refMeans, means := reference.imageMeans(), other.imageMeans()
+	c.ReferenceMean, c.Mean = mean(refMeans), mean(means)
+	c.MeanT, _, c.MeanP = welchTTest(means, refMeans)
+
+	for i := range c.PixelT {
+		t, _, p := welchTTest(other.pixelMeans(i), reference.pixelMeans(i))
+		c.PixelT[i] = t
+		if math.IsNaN(t) {
+			continue
+		}
+		c.Pixels++
+		if p < crossValidationAlpha {
+			c.Rejected++
+		}
+	}
+	return c
+}
+
+// crossValidationScene holds one scene's renders and comparisons
+type crossValidationScene struct {
+	Scene       string
+	Width       int
+	Height      int
+	Renders     []*crossValidationRender
+	Comparisons []crossValidationComparison
+	Err         error
+}
+
+// runCrossValidation renders each scene with every integrator at the same sample budget, compares
+// the integrators to the first one and writes an HTML report. Returns false if any differ.
+func runCrossValidation(config Config, sceneTypes, integratorTypes []string, timestamp string) bool {
+	if len(integratorTypes) < 2 {
+		fmt.Println("Error: cross-validation needs at least two integrators")
+		return false
+	}
+	samples := max(1, config.MaxSamples/crossValidationReplicas)
+	fmt.Printf("Cross-validating %s on %d scenes: %d renders of %d samples per pixel each\n",
+		strings.Join(integratorTypes, ", "), len(sceneTypes), crossValidationReplicas, samples)
+
+	var results []*crossValidationScene
+	allPassed := true
+	for _, sceneType := range sceneTypes {
+		result := crossValidateScene(sceneType, integratorTypes, samples, config)
+		results = append(results, result)
+		if result.Err != nil {
+			fmt.Printf("  %-16s ERROR: %v\n", sceneType, result.Err)
+			allPassed = false
+			continue
+		}
+		for _, c := range result.Comparisons {
+			status := "PASS"
+			if !c.Passed() {
+				status = "FAIL"
+				allPassed = false
+			}
+			fmt.Printf("  %-16s %s vs %s: mean %.5f vs %.5f (p=%.3f), %d/%d pixels differ (%.2f%%, z=%.1f)  %s\n",
+				sceneType, c.Integrator, c.Reference, c.Mean, c.ReferenceMean, c.MeanP,
+				c.Rejected, c.Pixels, c.RejectedFraction()*100, c.ExcessZ(), status)
+		}
+	}
+
+	outputDir := filepath.Join("output", "cross-validation")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		return false
+	}
+	filename := filepath.Join(outputDir, fmt.Sprintf("report_%s.html", timestamp))
+	if err := writeCrossValidationReport(filename, results, samples); err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+		return false
+	}
+	fmt.Printf("Report saved as %s\n", filename)
+
+	if allPassed {
+		fmt.Println("All integrators agree")
+	} else {
+		fmt.Println("Cross-validation FAILED")
+	}
+	return allPassed
+}
+
+// crossValidateScene renders one scene with every integrator and compares them to the first
+func crossValidateScene(sceneType string, integratorTypes []string, samples int, config Config) *crossValidationScene {
+	result := &crossValidationScene{Scene: sceneType}
+	sceneObj, err := createScene(sceneType, false)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	sceneObj.SamplingConfig.AdaptiveMinSamples = 1.0 // Every pixel takes the full budget
+
+	for i, integratorType := range integratorTypes {
+		// Every render gets its own seed, so an integrator compared with itself calibrates the tests
+		firstSeed := config.Seed + uint64(i*crossValidationReplicas)
+		render, err := renderReplicas(sceneObj, integratorType, samples, firstSeed, config)
+		if err != nil {
+			result.Err = fmt.Errorf("%s: %v", integratorType, err)
+			return result
+		}
+		result.Renders = append(result.Renders, render)
+	}
+	result.Width, result.Height = result.Renders[0].Merged.Width, result.Renders[0].Merged.Height
+	for _, render := range result.Renders[1:] {
+		result.Comparisons = append(result.Comparisons, compareRenders(result.Renders[0], render))
+	}
+	return result
+}
+
+// renderReplicas renders a scene crossValidationReplicas times with consecutive seeds
+func renderReplicas(sceneObj *scene.Scene, integratorType string, samples int, firstSeed uint64, config Config) (*crossValidationRender, error) {
+	render := &crossValidationRender{Integrator: integratorType}
+	start := time.Now()
+	for k := 0; k < crossValidationReplicas; k++ {
+		progressiveConfig := renderer.DefaultProgressiveConfig()
+		progressiveConfig.MaxPasses = 1
+		progressiveConfig.MaxSamplesPerPixel = samples
+		progressiveConfig.NumWorkers = config.NumWorkers
+		progressiveConfig.Seed = firstSeed + uint64(k)
+
+		progressiveRT, err := renderQuietly(sceneObj, integratorType, progressiveConfig)
+		if err != nil {
+			return nil, err
+		}
+		acc := progressiveRT.Accumulation()
+		render.Replicas = append(render.Replicas, acc)
+		if render.Merged == nil {
+			render.Merged = progressiveRT.Accumulation() // A copy, since merging adds to it
+		} else if err := render.Merged.Merge(acc); err != nil {
+			return nil, err
+		}
+	}
+	render.Time = time.Since(start)
+	return render, nil
+}
+
+// welchTTest compares the means of two samples without assuming equal variances. It returns the t
+// statistic, the Welch-Satterthwaite degrees of freedom and the two-sided p-value. Samples without
+// any variance have t = ±Inf (p = 0) if their means differ, and t = NaN if they are identical.
+func welchTTest(a, b []float64) (t, df, p float64) {
+	meanA, meanB := mean(a), mean(b)
+	seA := variance(a, meanA) / float64(len(a)) // Squared standard errors
+	seB := variance(b, meanB) / float64(len(b))
+	se := seA + seB
+	if se == 0 {
+		if meanA == meanB {
+			return math.NaN(), 0, 1
+		}
+		return math.Copysign(math.Inf(1), meanA-meanB), 0, 0
+	}
+
+	t = (meanA - meanB) / math.Sqrt(se)
+	df = se * se / (seA*seA/float64(len(a)-1) + seB*seB/float64(len(b)-1))
+	return t, df, studentTwoSidedP(t, df)
+}
+
+// mean returns the average of the values
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance returns the unbiased sample variance of the values around their mean m
+func variance(values []float64, m float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// studentTwoSidedP returns P(|T| >= |t|) for Student's t distribution with df degrees of freedom
+func studentTwoSidedP(t, df float64) float64 {
+	return regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), evaluated with the continued fraction of Numerical
+// Recipes (section 6.4), using the symmetry I_x(a, b) = 1 - I_1-x(b, a) where it converges slowly
+func regularizedIncompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the incomplete beta function's continued fraction with the
+// modified Lentz method
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		// Even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// Odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// tStatisticImage maps per-pixel t statistics to colors: red where the integrator is brighter than
+// the reference, blue where it is darker, saturating at |t| = 10, and gray for pixels that weren't
+// tested
+func tStatisticImage(pixelT []float64, width, height int) *image.RGBA {
+	const saturation = 10.0
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, t := range pixelT {
+		c := color.RGBA{128, 128, 128, 255}
+		if !math.IsNaN(t) {
+			v := uint8(255 * (1 - math.Min(math.Abs(t)/saturation, 1)))
+			if t > 0 {
+				c = color.RGBA{255, v, v, 255}
+			} else {
+				c = color.RGBA{v, v, 255, 255}
+			}
+		}
+		img.SetRGBA(i%width, i/width, c)
+	}
+	return img
+}
+
+// pngDataURL encodes an image as a data URL for embedding in the report
+func pngDataURL(img image.Image) (template.URL, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// crossValidationReportTemplate lays out the report: a summary table, then each scene's renders
+// and t statistic maps
+var crossValidationReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Integrator cross-validation</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.pass { color: #080; } .fail { color: #c00; font-weight: bold; }
+figure { display: inline-block; margin: 0 1em 1em 0; }
+img { width: 256px; image-rendering: pixelated; }
+</style>
+</head>
+<body>
+<h1>Integrator cross-validation</h1>
+<p>{{.Replicas}} renders of {{.Samples}} samples per pixel per integrator and scene, each with its own seed.
+Each pixel's replica means are compared to the reference integrator's with Welch's t-test at &alpha; = {{.Alpha}};
+a scene passes if the image means don't differ significantly and no more pixels differ than the false
+positives expected at &alpha; (within {{.MaxZ}} standard deviations).</p>
+<table>
+<tr><th>Scene</th><th>Integrator</th><th>Reference</th><th>Mean</th><th>Reference mean</th><th>p (mean)</th><th>Pixels differing</th><th>z</th><th>Result</th></tr>
+{{range .Scenes}}{{$scene := .Scene}}{{if .Err}}<tr><td>{{$scene}}</td><td colspan="7">{{.Err}}</td><td class="fail">ERROR</td></tr>
+{{else}}{{range .Comparisons}}<tr><td>{{$scene}}</td><td>{{.Integrator}}</td><td>{{.Reference}}</td>
+<td>{{printf "%.5f" .Mean}}</td><td>{{printf "%.5f" .ReferenceMean}}</td><td>{{printf "%.3f" .MeanP}}</td>
+<td>{{.Rejected}} / {{.Pixels}} ({{printf "%.2f" .RejectedPercent}}%)</td><td>{{printf "%.1f" .ExcessZ}}</td>
+<td>{{if .Passed}}<span class="pass">PASS</span>{{else}}<span class="fail">FAIL</span>{{end}}</td></tr>
+{{end}}{{end}}{{end}}
+</table>
+{{range .Scenes}}{{if not .Err}}
+<h2>{{.Scene}}</h2>
+{{range .Images}}<figure><img src="{{.URL}}" alt="{{.Caption}}"><figcaption>{{.Caption}}</figcaption></figure>
+{{end}}{{end}}{{end}}
+<p>t maps: red where the integrator is brighter than the reference, blue where it is darker, gray where every render agreed exactly.</p>
+</body>
+</html>
+`))
+
+// crossValidationReportImage is one captioned image of the report
+type crossValidationReportImage struct {
+	URL     template.URL
+	Caption string
+}
+
+// writeCrossValidationReport writes the HTML report of the cross-validation results
+func writeCrossValidationReport(filename string, results []*crossValidationScene, samples int) error {
+	type comparisonRow struct {
+		crossValidationComparison
+		RejectedPercent float64
+	}
+	type sceneSection struct {
+		Scene       string
+		Err         error
+		Comparisons []comparisonRow
+		Images      []crossValidationReportImage
+	}
+	data := struct {
+		Replicas, Samples int
+		Alpha, MaxZ       float64
+		Scenes            []sceneSection
+	}{Replicas: crossValidationReplicas, Samples: samples, Alpha: crossValidationAlpha, MaxZ: crossValidationMaxZ}
+
+	for _, result := range results {
+		section := sceneSection{Scene: result.Scene, Err: result.Err}
+		for _, c := range result.Comparisons {
+			section.Comparisons = append(section.Comparisons, comparisonRow{c, c.RejectedFraction() * 100})
+		}
+		for _, render := range result.Renders {
+			img, _ := render.Merged.Image()
+			url, err := pngDataURL(img)
+			if err != nil {
+				return err
+			}
+			section.Images = append(section.Images, crossValidationReportImage{url,
+				fmt.Sprintf("%s (%.1fs)", render.Integrator, render.Time.Seconds())})
+		}
+		for _, c := range result.Comparisons {
+			url, err := pngDataURL(tStatisticImage(c.PixelT, result.Width, result.Height))
+			if err != nil {
+				return err
+			}
+			section.Images = append(section.Images, crossValidationReportImage{url,
+				fmt.Sprintf("t: %s vs %s", c.Integrator, c.Reference)})
+		}
+		data.Scenes = append(data.Scenes, section)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := crossValidationReportTemplate.Execute(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+func TestStudentTwoSidedP(t *testing.T) {
+	tests := []struct {
+		t, df, expected float64
+	}{
+		{0, 5, 1},
+		{2.0, 10, 0.07339},
+		{2.228, 10, 0.05},
+		{5.841, 3, 0.01},
+		{1.96, 1e6, 0.05},
+		{-2.0, 10, 0.07339},
+	}
+	for _, tt := range tests {
+		if p := studentTwoSidedP(tt.t, tt.df); math.Abs(p-tt.expected) > 1e-3 {
+			t.Errorf("p(t=%v, df=%v) = %.5f, expected %.5f", tt.t, tt.df, p, tt.expected)
+		}
+	}
+}
+
+func TestWelchTTest(t *testing.T) {
+	tStat, df, p := welchTTest([]float64{1, 2, 3, 4}, []float64{2, 4, 6, 8})
+	if math.Abs(tStat-(-1.7321)) > 1e-3 || math.Abs(df-4.412) > 1e-3 {
+		t.Errorf("Expected t=-1.732 df=4.412, got t=%.4f df=%.4f", tStat, df)
+	}
+	if p < 0.1 || p > 0.2 {
+		t.Errorf("Expected p around 0.15, got %.4f", p)
+	}
+
+	if tStat, _, p := welchTTest([]float64{1, 1}, []float64{1, 1}); !math.IsNaN(tStat) || p != 1 {
+		t.Errorf("Identical constant samples: expected t=NaN p=1, got t=%v p=%v", tStat, p)
+	}
+	if tStat, _, p := welchTTest([]float64{2, 2}, []float64{1, 1}); !math.IsInf(tStat, 1) || p != 0 {
+		t.Errorf("Different constant samples: expected t=+Inf p=0, got t=%v p=%v", tStat, p)
+	}
+}
+
+// constantRender makes replicas whose pixels all have the given luminances, one value per replica
+func constantRender(name string, pixels int, values ...float64) *crossValidationRender {
+	render := &crossValidationRender{Integrator: name}
+	for _, v := range values {
+		acc := &renderer.Accumulation{Width: pixels, Height: 1, Pixels: make([]renderer.PixelStats, pixels)}
+		for i := range acc.Pixels {
+			acc.Pixels[i] = renderer.PixelStats{ColorAccum: core.NewVec3(v, v, v), WeightSum: 1, SampleCount: 1}
+		}
+		render.Replicas = append(render.Replicas, acc)
+	}
+	render.Merged = render.Replicas[0]
+	return render
+}
+
+func TestCompareRenders(t *testing.T) {
+	reference := constantRender("reference", 100, 1.0, 1.1, 0.9, 1.0)
+
+	same := compareRenders(reference, constantRender("same", 100, 1.05, 0.95, 1.0, 1.0))
+	if !same.Passed() || same.Rejected != 0 || same.Pixels != 100 {
+		t.Errorf("Expected equivalent renders to pass, got %d/%d rejected, mean p %.3f", same.Rejected, same.Pixels, same.MeanP)
+	}
+
+	brighter := compareRenders(reference, constantRender("brighter", 100, 2.0, 2.1, 1.9, 2.0))
+	if brighter.Passed() || brighter.Rejected != 100 {
+		t.Errorf("Expected a twice as bright render to fail, got %d/%d rejected, mean p %.3f", brighter.Rejected, brighter.Pixels, brighter.MeanP)
+	}
+	if brighter.PixelT[0] <= 0 {
+		t.Errorf("Expected positive t for a brighter render, got %v", brighter.PixelT[0])
+	}
+}
+
+func TestCrossValidateScene(t *testing.T) {
+	sceneFile := filepath.Join(t.TempDir(), "test.micro")
+	source := "camera width 16\nsampling depth 3\nsphere at 0 0 -2 radius 0.8\nsky emit 1 1 1\n"
+	if err := os.WriteFile(sceneFile, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An integrator compared with itself (with other seeds) must pass
+	result := crossValidateScene(sceneFile, []string{"path-tracing", "path-tracing"}, 4, Config{Seed: 1})
+	if result.Err != nil {
+		t.Fatalf("Cross-validation failed: %v", result.Err)
+	}
+	if len(result.Comparisons) != 1 || !result.Comparisons[0].Passed() {
+		t.Errorf("Expected path tracing to agree with itself, got %+v", result.Comparisons)
+	}
+
+	report := filepath.Join(t.TempDir(), "report.html")
+	if err := writeCrossValidationReport(report, []*crossValidationScene{result}, 4); err != nil {
+		t.Fatalf("Failed to write report: %v", err)
+	}
+	html, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "PASS") || !strings.Contains(string(html), "data:image/png;base64,") {
+		t.Error("Expected the report to show the result and embed the images")
+	}
+}
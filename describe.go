@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// runDescribe prints statistics about the scene instead of rendering it, as text or JSON
+func runDescribe(w io.Writer, sceneObj *scene.Scene, asJSON bool) error {
+	stats, err := scene.Describe(sceneObj)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	printSceneStats(w, stats)
+	return nil
+}
+
+// printSceneStats writes a human-readable summary of the scene statistics
+func printSceneStats(w io.Writer, stats scene.SceneStats) {
+	fmt.Fprintln(w, "Shapes:")
+	shapeTypes := make([]string, 0, len(stats.Shapes))
+	for shapeType := range stats.Shapes {
+		shapeTypes = append(shapeTypes, shapeType)
+	}
+	sort.Strings(shapeTypes)
+	for _, shapeType := range shapeTypes {
+		fmt.Fprintf(w, "  %-16s %d\n", shapeType, stats.Shapes[shapeType])
+	}
+	fmt.Fprintf(w, "  Triangles        %d\n", stats.Triangles)
+	fmt.Fprintf(w, "  Primitives       %d\n", stats.TotalPrimitives)
+
+	fmt.Fprintln(w, "BVH:")
+	fmt.Fprintf(w, "  Nodes            %d (%d leaves)\n", stats.BVH.TotalNodes, stats.BVH.LeafNodes)
+	fmt.Fprintf(w, "  Depth            max %d, average leaf %.1f\n", stats.BVH.MaxDepth, stats.BVH.AvgDepth)
+	fmt.Fprintf(w, "  SAH cost         %.2f (sibling overlap %.2f)\n", stats.BVH.SAHCost, stats.BVH.Overlap)
+
+	fmt.Fprintf(w, "Lights: %d\n", len(stats.Lights))
+	for i, light := range stats.Lights {
+		fmt.Fprintf(w, "  [%d] %-16s %-8s power %s\n", i, light.Type, light.Kind, formatPower(light.Power))
+	}
+	fmt.Fprintf(w, "  Total power      %s\n", formatPower(stats.TotalLightPower))
+
+	fmt.Fprintln(w, "Textures:")
+	fmt.Fprintf(w, "  Images           %d (%.1f MB)\n", stats.Textures, float64(stats.TextureBytes)/(1024*1024))
+
+	camera := stats.Camera
+	fmt.Fprintln(w, "Camera:")
+	fmt.Fprintf(w, "  Position         %v\n", camera.Center)
+	fmt.Fprintf(w, "  Look at          %v\n", camera.LookAt)
+	fmt.Fprintf(w, "  Resolution       %dx%d\n", camera.Width, stats.Height)
+	fmt.Fprintf(w, "  Vertical FOV     %.1f°\n", camera.VFov)
+	fmt.Fprintf(w, "  Aperture         %g (focus distance %g)\n", camera.Aperture, camera.FocusDistance)
+
+	sampling := stats.Sampling
+	fmt.Fprintln(w, "Sampling:")
+	fmt.Fprintf(w, "  Samples/pixel    %d\n", sampling.SamplesPerPixel)
+	fmt.Fprintf(w, "  Max depth        %d (Russian roulette after %d bounces)\n", sampling.MaxDepth, sampling.RussianRouletteMinBounces)
+	if sampling.MaxDiffuseDepth > 0 || sampling.MaxGlossyDepth > 0 || sampling.MaxTransmissionDepth > 0 {
+		fmt.Fprintf(w, "  Lobe depths      diffuse %d, glossy %d, transmission %d (0 = max depth)\n",
+			sampling.MaxDiffuseDepth, sampling.MaxGlossyDepth, sampling.MaxTransmissionDepth)
+	}
+}
+
+// formatPower formats an RGB power with its luminance, which is the most useful single number
+func formatPower(power core.Vec3) string {
+	return fmt.Sprintf("%.4g (RGB %.4g, %.4g, %.4g)", power.Luminance(), power.X, power.Y, power.Z)
+}
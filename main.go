@@ -5,13 +5,16 @@ import (
 	"flag"
 	"fmt"
 	"image"
-	"image/png"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/integrator"
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"github.com/df07/go-progressive-raytracer/pkg/loaders"
@@ -22,19 +25,76 @@ import (
 // Config holds all the configuration for the raytracer
 type Config struct {
 	SceneType      string
+	Camera         string
+	Panorama       string
+	PanoramaAt     string
+	PanoramaSize   int
+	FocusAt        string
 	MaxPasses      int
 	MaxSamples     int
 	NumWorkers     int
+	PinWorkers     bool
+	Seed           uint64
+	PyramidLevels  int
+	MaxTime        time.Duration
+	TargetError    float64
+	Preview        time.Duration
+	PreviewProfile bool
+	SpatialSplits  bool
+	LightGrid      int
+	LightPower     bool
+	LightReservoir int
+	PrimaryLights  int
+	GuidingPasses  int
+	IrradianceGI   float64
+	SampleMask     string
+	Filter         string
+	FilterRadius   float64
+	CachePoints    bool
+	Float32Meshes  bool
+	AOVs           bool
+	DebugAOVs      string
+	Overlays       string
+	StrategyGrid   bool
+	BDPTTrace      string
+	TraceRegion    string
+	TraceSample    string
+	Accumulation   bool
+	Deep           bool
+	IDs            bool
+	Transparent    bool
+	Merge          bool
+	Metadata       bool
+	Describe       bool
+	DescribeJSON   bool
+	ExportPBRT     string
+	Bake           string
+	BakeSize       int
+	BakeProbes     string
+	Watch          bool
+	Progress       bool
+	Validate       bool
+	CrossValidate  string
+	Integrators    string
 	IntegratorType string
+	DebugShading   string
 	Help           bool
 	CPUProfile     string
+	StatsJSON      string
+	LogLevel       string
+	LogJSON        bool
+	FromRecipe     string
+	Recipe         *Recipe // Recipe being replayed, if any
 }
 
 // RenderResult holds the final image and statistics
 type RenderResult struct {
-	Image     *image.RGBA
-	Stats     renderer.RenderStats
-	Timestamp string
+	Image       *image.RGBA
+	Stats       renderer.RenderStats
+	Config      renderer.ProgressiveConfig // Settings the image was rendered with
+	Timestamp   string
+	Interrupted bool                     // Cancelled before the last pass, whose image wasn't saved as the final one
+	Metadata    []renderer.MetadataEntry // Provenance saved in the image's PNG text chunks
 }
 
 func main() {
@@ -44,6 +104,55 @@ func main() {
 		return
 	}
 
+	level, subsystems, err := core.ParseLogLevels(config.LogLevel)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	core.ConfigureLogging(core.LogConfig{Level: level, Subsystems: subsystems, JSON: config.LogJSON})
+
+	if config.FromRecipe != "" {
+		recipe, err := loadRecipe(config.FromRecipe)
+		if err != nil {
+			fmt.Printf("Error loading recipe: %v\n", err)
+			os.Exit(1)
+		}
+		recipe.apply(&config)
+		fmt.Printf("Replaying recipe %s (scene %s, integrator %s, seed %d)\n",
+			config.FromRecipe, recipe.Scene, recipe.Integrator, recipe.Progressive.Seed)
+	}
+
+	if config.Validate {
+		if !runPhotometricValidation(config) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.CrossValidate != "" {
+		if !runCrossValidation(config, strings.Split(config.CrossValidate, ","), strings.Split(config.Integrators, ","),
+			time.Now().Format("20060102_150405")) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.Metadata {
+		if err := printMetadata(flag.Args()); err != nil {
+			fmt.Printf("Error reading metadata: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.Merge {
+		if err := runMerge(flag.Args(), time.Now().Format("20060102_150405")); err != nil {
+			fmt.Printf("Error merging renders: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Start CPU profiling if requested
 	if config.CPUProfile != "" {
 		f, err := os.Create(config.CPUProfile)
@@ -59,39 +168,237 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	if config.Describe || config.DescribeJSON {
+		describeScene(config)
+		return
+	}
+
+	if config.ExportPBRT != "" {
+		exportScene(config)
+		return
+	}
+
+	if config.Bake != "" {
+		if err := runBake(config); err != nil {
+			fmt.Printf("Error baking scene: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.Watch {
+		if err := runWatch(config); err != nil {
+			fmt.Printf("Error watching scene: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("Starting Progressive Raytracer...")
 	startTime := time.Now()
 
-	sceneObj, err := createScene(config.SceneType)
+	sceneObj, err := loadScene(config)
 	if err != nil {
 		fmt.Printf("Error creating scene: %v\n", err)
 		os.Exit(1)
 	}
+	if config.PrimaryLights > 0 {
+		sceneObj.SamplingConfig.PrimaryLightSamples = config.PrimaryLights
+	}
+	if config.GuidingPasses > 0 {
+		sceneObj.SamplingConfig.GuidingPasses = config.GuidingPasses
+	}
+	if config.IrradianceGI > 0 {
+		sceneObj.SamplingConfig.IrradianceCache = config.IrradianceGI
+		sceneObj.SamplingConfig.IrradianceCachePoints = config.CachePoints
+	}
+	if config.SampleMask != "" {
+		sceneObj.SamplingConfig.SampleMask = config.SampleMask
+	}
+	if config.Filter != "" {
+		sceneObj.SamplingConfig.Filter = config.Filter
+		sceneObj.SamplingConfig.FilterRadius = config.FilterRadius
+	}
+	if config.Recipe != nil {
+		for _, warning := range config.Recipe.checkScene(sceneObj) {
+			fmt.Printf("Warning: %s; the render may not match\n", warning)
+		}
+	}
+	if config.TraceSample != "" {
+		traceSample(config, sceneObj)
+		return
+	}
 	outputDir := createOutputDir(config.SceneType)
-	result := renderProgressive(config, sceneObj)
+
+	// Ctrl+C stops the render between pixels and keeps its last complete pass
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	result := renderProgressive(ctx, config, sceneObj)
+	stop()
+	imageFile := fmt.Sprintf("render_%s.png", result.Timestamp)
+	if result.Interrupted {
+		if result.Image == nil {
+			fmt.Println("Render interrupted before the first pass completed")
+			os.Exit(1)
+		}
+		if err := saveImageToFile(result.Image, filepath.Join(outputDir, imageFile), result.Metadata); err != nil {
+			fmt.Printf("Error saving final image: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	renderTime := time.Since(startTime)
-	fmt.Printf("Render completed in %v\n", renderTime)
+	if result.Interrupted {
+		fmt.Printf("Render interrupted after %v, keeping the last complete pass\n", renderTime)
+	} else {
+		fmt.Printf("Render completed in %v\n", renderTime)
+	}
 	fmt.Printf("Samples per pixel: %.1f (range %d - %d)\n",
 		result.Stats.AverageSamples, result.Stats.MinSamples, result.Stats.MaxSamplesUsed)
 
 	// Calculate and print average luminosity
 	avgLum := renderer.CalculateAverageLuminance(result.Image)
 	fmt.Printf("Average Luminosity: %.4f\n", avgLum)
+	printRayStats(result.Stats)
+
+	fmt.Printf("Render saved as %s\n", filepath.Join(outputDir, imageFile))
+	if result.Interrupted {
+		return // A recipe would render every pass, not reproduce this image
+	}
+
+	// Record how to reproduce this render
+	recipe := newRecipe(config, sceneObj, imageFile, result)
+	recipeFile := filepath.Join(outputDir, fmt.Sprintf("render_%s.recipe.json", result.Timestamp))
+	if err := saveRecipe(recipe, recipeFile); err != nil {
+		fmt.Printf("Error saving recipe: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Recipe saved as %s\n", recipeFile)
+
+	if config.StatsJSON != "" {
+		if err := saveRenderStats(result.Stats, config.StatsJSON); err != nil {
+			fmt.Printf("Error saving render statistics: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Render statistics saved as %s\n", config.StatsJSON)
+	}
+
+	if config.Recipe != nil {
+		if recipe.ImageSHA256 == config.Recipe.ImageSHA256 {
+			fmt.Printf("Reproduced %s exactly\n", config.Recipe.Image)
+		} else {
+			fmt.Printf("Warning: image differs from %s in the recipe\n", config.Recipe.Image)
+		}
+	}
+}
+
+// describeScene loads the scene and prints its statistics without rendering
+func describeScene(config Config) {
+	// Scene loading reports progress on stdout; keep it out of the JSON so the output can be piped
+	stdout := os.Stdout
+	if config.DescribeJSON {
+		os.Stdout = os.Stderr
+	}
+	sceneObj, err := loadScene(config)
+	os.Stdout = stdout
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating scene: %v\n", err)
+		os.Exit(1)
+	}
 
-	fmt.Printf("Render saved as %s\n", filepath.Join(outputDir, fmt.Sprintf("render_%s.png", result.Timestamp)))
+	if err := runDescribe(os.Stdout, sceneObj, config.DescribeJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "Error describing scene: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportScene loads the scene and writes it as a PBRT file instead of rendering it
+func exportScene(config Config) {
+	sceneObj, err := loadScene(config)
+	if err != nil {
+		fmt.Printf("Error creating scene: %v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := os.Create(config.ExportPBRT)
+	if err != nil {
+		fmt.Printf("Error creating PBRT file: %v\n", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	warnings, err := scene.ExportPBRT(sceneObj, file)
+	if err != nil {
+		fmt.Printf("Error exporting scene: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	fmt.Printf("Scene exported as %s\n", config.ExportPBRT)
 }
 
 // parseFlags parses command line flags and returns configuration
 func parseFlags() Config {
 	config := Config{}
 	flag.StringVar(&config.SceneType, "scene", "default", "Scene type or PBRT file path")
+	flag.StringVar(&config.FocusAt, "focus-at", "", "Focus the camera on what pixel x,y shows, given as x,y (the depth of field blur needs a scene with an aperture)")
+	flag.StringVar(&config.Camera, "camera", "", "Render from one of the scene's named cameras (PBRT Camera statements with a \"string name\", or a scene file's cameras) instead of its default one")
+	flag.StringVar(&config.Panorama, "panorama", "", "Render a 360° panorama instead of the camera's view: 'equirectangular' (2:1) or 'cubemap' (six square views side by side: right, left, up, down, front, back), also saved as a linear .hdr environment map")
+	flag.StringVar(&config.PanoramaAt, "panorama-at", "", "With --panorama, the point to see the scene from, given as x,y,z (default: the camera's position)")
+	flag.IntVar(&config.PanoramaSize, "panorama-size", 512, "With --panorama, the image height in pixels")
 	flag.IntVar(&config.MaxPasses, "max-passes", 5, "Maximum number of progressive passes")
 	flag.IntVar(&config.MaxSamples, "max-samples", 50, "Maximum samples per pixel")
 	flag.IntVar(&config.NumWorkers, "workers", 0, "Number of parallel workers (0 = auto-detect CPU count)")
-	flag.StringVar(&config.IntegratorType, "integrator", "path-tracing", "Integrator type: 'path-tracing' or 'bdpt'")
+	flag.BoolVar(&config.PinWorkers, "pin-workers", false, "Run each worker on its own CPU (Linux only), which can steady render times on machines with several sockets or NUMA nodes")
+	flag.Uint64Var(&config.Seed, "seed", 0, "Random seed (same seed gives identical images regardless of worker count)")
+	flag.DurationVar(&config.MaxTime, "max-time", 0, "Stop when the next pass would run past this wall-clock budget, e.g. 5m (0 = no limit)")
+	flag.Float64Var(&config.TargetError, "target-error", 0, "Stop once the estimated relative error of the image falls below this, e.g. 0.01 for 1% (0 = no target)")
+	flag.DurationVar(&config.Preview, "preview-interval", 0, "Save the pass in progress as <render>_preview.png this often, e.g. 30s, its completed tiles over the previous pass (0 = only whole passes)")
+	flag.BoolVar(&config.PreviewProfile, "preview-profile", false, "Render the passes before the last with the preview sampling profile (no Russian roulette, at most 4 bounces, one light sample per hit) for stable look development previews; the last pass renders the final image with the full profile")
+	flag.IntVar(&config.PyramidLevels, "pyramid", 0, "Number of reduced resolution previews (1/2, 1/4, 1/8, ...) to render coarsest first before the full resolution passes")
+	flag.BoolVar(&config.SpatialSplits, "spatial-splits", false, "Build the BVH with spatial splits (SBVH): slower to build, faster to trace for scenes of long or overlapping triangles")
+	flag.IntVar(&config.LightGrid, "light-grid", 0, "Choose lights by their importance in a grid of this many cells along the scene's longest axis (0 = uniform light selection)")
+	flag.IntVar(&config.LightReservoir, "light-reservoirs", 0, "With --light-grid, cache this many resampled light reservoirs per cell instead of every light's probability (ReGIR), for scenes of hundreds of lights")
+	flag.BoolVar(&config.LightPower, "light-power", false, "Choose lights in proportion to their emitted power rather than uniformly (ignored with --light-grid)")
+	flag.IntVar(&config.PrimaryLights, "primary-light-samples", 0, "Path tracing: at primary hits, sample each of this many most important lights once plus one of the rest (0 = one light per hit)")
+	flag.IntVar(&config.GuidingPasses, "guiding-passes", 0, "Path tracing: learn where light comes from over this many passes and guide scatter directions with it in later ones (0 = no path guiding)")
+	flag.Float64Var(&config.IrradianceGI, "irradiance-cache", 0, "Path tracing: interpolate indirect diffuse light at primary hits from an irradiance cache of this accuracy, e.g. 0.2; smaller is more accurate and slower (0 = no cache)")
+	flag.BoolVar(&config.CachePoints, "irradiance-cache-points", false, "With --irradiance-cache, show the cache's records as green dots")
+	flag.StringVar(&config.SampleMask, "sample-mask", "", "Grayscale PNG or JPEG importance mask, stretched over the image, scaling each pixel's samples: white takes all, mid-gray half, black one")
+	flag.StringVar(&config.Filter, "filter", "", "Pixel reconstruction filter: "+strings.Join(scene.PixelFilters, ", ")+" (default: the scene's, box unless it says otherwise)")
+	flag.Float64Var(&config.FilterRadius, "filter-radius", 0, "With --filter, the filter's radius in pixels (0 = the filter's default)")
+	flag.BoolVar(&config.Float32Meshes, "float32-meshes", false, "Store large meshes (the dragon) in float32, using a fraction of the memory at float32 vertex precision")
+	flag.BoolVar(&config.AOVs, "aov", false, "Also write AOV images (depth, normal, albedo, direct, indirect, per-BDPT-strategy) as PNGs for each pass")
+	flag.StringVar(&config.DebugAOVs, "debug-aov", "", "Also write false-color heatmaps for each pass: comma-separated samples, variance, pathlength, bvh (intersection time), or all")
+	flag.StringVar(&config.Overlays, "overlay", "", "Also write each pass with lines drawn over it: comma-separated wireframe (triangle edges), bvh (BVH node bounds), or all")
+	flag.BoolVar(&config.StrategyGrid, "strategy-grid", false, "Also write a grid of the MIS-weighted BDPT (s,t) strategy images (Veach style) as a PNG for each pass")
+	flag.StringVar(&config.BDPTTrace, "bdpt-trace", "", "Write a JSON line per BDPT strategy evaluation (s, t, contribution, MIS weight and its PDFs) to this file")
+	flag.StringVar(&config.TraceRegion, "trace-region", "", "Pixels to trace with --bdpt-trace as x0,y0,x1,y1 (x1 and y1 exclusive; default: every pixel)")
+	flag.StringVar(&config.TraceSample, "trace-sample", "", "Trace sample n of pixel (x, y) again, given as x,y,n, and save its paths (vertices, PDFs, throughputs, BDPT strategies and MIS weights) as JSON instead of rendering")
+	flag.BoolVar(&config.Accumulation, "accumulation", false, "Also save the final pass's per-pixel sample sums and counts (.accum) for merging with --merge")
+	flag.BoolVar(&config.Deep, "deep", false, "Also save a deep image of the final pass (per-pixel color and alpha samples at each depth, .exr) for compositing with correct occlusion")
+	flag.BoolVar(&config.IDs, "ids", false, "Also write object and material ID AOVs as PNGs for each pass, and save the final pass's coverage mattes as a Cryptomatte .exr")
+	flag.BoolVar(&config.Transparent, "transparent", false, "Render the background transparent (PNG alpha), and shadowcatcher materials as only the shadows and reflections they receive, for compositing onto photographs")
+	flag.BoolVar(&config.Merge, "merge", false, "Merge the .accum files given as arguments (independent renders of one scene, each with its own --seed) into one image")
+	flag.BoolVar(&config.Metadata, "metadata", false, "Print the render metadata (scene, integrator, samples, seed, version, render time) embedded in the PNG files given as arguments")
+	flag.BoolVar(&config.Describe, "describe", false, "Print scene statistics (primitives, BVH, light power, textures, camera) instead of rendering")
+	flag.BoolVar(&config.DescribeJSON, "describe-json", false, "Like --describe, but print the statistics as JSON")
+	flag.StringVar(&config.ExportPBRT, "export-pbrt", "", "Write the scene to this PBRT file (for comparing with pbrt-v4) instead of rendering")
+	flag.StringVar(&config.Bake, "bake", "", "Bake the scene's light instead of rendering: 'lightmap' (an HDR lightmap of each mesh with UVs) or 'probes' (a grid of spherical harmonic irradiance probes, as JSON), gathering --max-samples rays per texel or probe")
+	flag.IntVar(&config.BakeSize, "bake-size", 256, "With --bake=lightmap, the lightmaps' width and height in texels")
+	flag.StringVar(&config.BakeProbes, "bake-probes", "4,4,4", "With --bake=probes, the probes along x, y and z of the scene's bounds, given as nx,ny,nz")
+	flag.BoolVar(&config.Progress, "progress", true, "Show a progress bar with the ETA, samples/s and rays/s while rendering, when stderr is a terminal")
+	flag.BoolVar(&config.Watch, "watch", false, "Render a PBRT scene file again each time it's saved, reusing the BVH when only the camera changed (Ctrl+C to stop)")
+	flag.BoolVar(&config.Validate, "validate", false, "Run the photometric validation (sphere light over a plane at several scales) instead of rendering a scene")
+	flag.StringVar(&config.CrossValidate, "cross-validate", "", "Render these comma-separated scenes with each of --integrators at the same sample budget and test that they agree (HTML report)")
+	flag.StringVar(&config.Integrators, "integrators", "path-tracing,bdpt", "Integrators to cross-validate, compared to the first")
+	flag.StringVar(&config.IntegratorType, "integrator", "path-tracing", "Integrator type: 'path-tracing', 'bdpt', or the quick previews 'ao' (ambient occlusion) and 'direct' (direct lighting only), or 'debug' (see --debug-shading)")
+	flag.StringVar(&config.DebugShading, "debug-shading", integrator.DebugShadingNormal, "With --integrator=debug, what to shade surfaces by: "+strings.Join(integrator.DebugShadingModes, ", "))
 	flag.BoolVar(&config.Help, "help", false, "Show help information")
 	flag.StringVar(&config.CPUProfile, "cpuprofile", "", "Write CPU profile to file")
+	flag.StringVar(&config.StatsJSON, "stats-json", "", "Write the render statistics (rays by kind; BVH node visits, path lengths and BDPT strategies with -tags rtstats) to this JSON file")
+	flag.StringVar(&config.LogLevel, "log-level", "info", "Log level (debug, info, warn, error), optionally followed by levels for subsystems such as 'warn,bdpt=debug' (subsystems: renderer, scene, path-tracing, bdpt, camera)")
+	flag.BoolVar(&config.LogJSON, "log-json", false, "Write the log as JSON lines (time, level, subsystem, msg)")
+	flag.StringVar(&config.FromRecipe, "from-recipe", "", "Rerun the render described by a recipe file (its settings replace the other render flags)")
 	flag.Parse()
 	return config
 }
@@ -113,29 +420,155 @@ func showHelp() {
 	fmt.Println("  trianglemesh - Scene showcasing triangle mesh geometry (boxes, pyramids, icosahedrons)")
 	fmt.Println("  dragon       - Dragon PLY mesh from PBRT book")
 	fmt.Println("  caustic-glass - Glass caustic geometry scene")
+	fmt.Println("  photometric  - Sphere light over a diffuse plane with a known analytic solution")
+	fmt.Println("  smoke        - Smoke plume volume over a ground plane in sunlight")
 	fmt.Println()
 	fmt.Println("PBRT scenes:")
 	fmt.Println("  cornell-empty - Cornell box without objects (from scenes/cornell-empty.pbrt)")
 	fmt.Println("  simple-sphere - Basic sphere scene (from scenes/simple-sphere.pbrt)")
 	fmt.Println("  test         - Test scene (from scenes/test.pbrt)")
 	fmt.Println("  Or use direct file path: scenes/my-custom-scene.pbrt")
+	fmt.Println("  Or a micro scene file (a few lines: camera, spheres, quads, lights): repro.micro")
+	fmt.Println("  Or a JSON or YAML scene description (all shapes, materials and lights): scene.yaml")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  raytracer.exe --max-passes=5 --max-samples=100")
 	fmt.Println("  raytracer.exe --scene=cornell --workers=4")
+	fmt.Println("  raytracer.exe --scene=spheregrid --spatial-splits")
+	fmt.Println("  raytracer.exe --scene=cornell --light-grid=8")
+	fmt.Println("  raytracer.exe --scene=caustic-glass --primary-light-samples=2")
+	fmt.Println("  raytracer.exe --scene=cornell --max-passes=50 --max-samples=5000 --max-time=5m --target-error=0.01")
+	fmt.Println("  raytracer.exe --scene=dragon --pyramid=3")
+	fmt.Println("  raytracer.exe --scene=dragon --max-samples=1000 --preview-interval=30s")
+	fmt.Println("  raytracer.exe --scene=cornell --max-passes=10 --preview-profile")
+	fmt.Println("  raytracer.exe --scene=scenes/still-life.yaml --camera=top")
+	fmt.Println("  raytracer.exe --scene=default --focus-at=200,150")
+	fmt.Println("  raytracer.exe --scene=cornell --panorama=equirectangular --panorama-at=278,273,0 --panorama-size=1024")
+	fmt.Println("  raytracer.exe --scene=default --panorama=cubemap")
+	fmt.Println("  raytracer.exe --scene=dragon --max-samples=1000 --sample-mask=dragon_head.png")
+	fmt.Println("  raytracer.exe --scene=dragon --float32-meshes")
+	fmt.Println("  raytracer.exe --scene=cornell --filter=mitchell")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --seed=1234")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --aov")
+	fmt.Println("  raytracer.exe --scene=dragon --debug-aov=samples,bvh")
+	fmt.Println("  raytracer.exe --scene=trianglemesh --overlay=wireframe,bvh")
+	fmt.Println("  raytracer.exe --scene=dragon --integrator=debug --debug-shading=normal")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --strategy-grid")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --bdpt-trace=trace.jsonl --trace-region=200,200,202,202")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --seed=1234 --trace-sample=200,200,17")
+	fmt.Println("  raytracer.exe --scene=cornell --integrator=bdpt --max-samples=1 --log-level=warn,bdpt=debug")
+	fmt.Println("  raytracer.exe --validate --max-samples=64")
+	fmt.Println("  raytracer.exe --cross-validate=default,cornell --max-samples=64")
+	fmt.Println("  raytracer.exe --scene=default --deep")
+	fmt.Println("  raytracer.exe --scene=cornell --ids")
+	fmt.Println("  raytracer.exe --scene=scenes/product-shot.yaml --transparent")
+	fmt.Println("  raytracer.exe --scene=cornell --seed=2 --accumulation")
+	fmt.Println("  raytracer.exe --merge output/cornell/render_A.accum output/cornell/render_B.accum")
+	fmt.Println("  raytracer.exe --scene=dragon --describe")
+	fmt.Println("  raytracer.exe --scene=cornell --export-pbrt=cornell.pbrt")
+	fmt.Println("  raytracer.exe --scene=level.yaml --bake=lightmap --bake-size=512 --max-samples=1024")
+	fmt.Println("  raytracer.exe --scene=cornell --bake=probes --bake-probes=8,8,8 --max-samples=1024")
+	fmt.Println("  raytracer.exe --scene=scenes/cornell-empty.pbrt --watch")
+	fmt.Println("  raytracer.exe --from-recipe=output/cornell/render_20250101_120000.recipe.json")
 	fmt.Println("  raytracer.exe --scene=cornell-empty --max-samples=100")
 	fmt.Println("  raytracer.exe --scene=scenes/simple-sphere.pbrt --integrator=bdpt")
 	fmt.Println("  raytracer.exe --scene=caustic-glass --integrator=bdpt --max-samples=100")
 	fmt.Println()
 	fmt.Println("Output will be saved to output/<scene_type>/render_<timestamp>.png")
+	fmt.Println("A recipe to reproduce the render is saved alongside as render_<timestamp>.recipe.json")
+	fmt.Println("AOVs are saved alongside as render_<timestamp>[_pass_NN]_<aov>.png")
+	fmt.Println("Deep images are saved as render_<timestamp>.exr (OpenEXR deep scanlines)")
+	fmt.Println("ID mattes are saved as render_<timestamp>.cryptomatte.exr (objects as <Shape>.<n> in scene order)")
+	fmt.Println("Panoramas are also saved as render_<timestamp>.hdr, usable as a scene file sky's file")
+	fmt.Println("Lightmaps are saved as lightmap_<shape>.hdr (and .png), probes as probes.json")
+	fmt.Println("The strategy grid is saved as render_<timestamp>[_pass_NN]_bdpt_strategies.png (row n: paths of n vertices, column: s)")
+}
+
+// loadScene creates the scene and switches it to the camera selected with --camera, if any, and
+// then to the panorama selected with --panorama
+func loadScene(config Config) (*scene.Scene, error) {
+	sceneObj, err := createScene(config.SceneType, config.Float32Meshes)
+	if err != nil {
+		return nil, err
+	}
+	if config.Camera != "" {
+		if err := sceneObj.UseCamera(config.Camera); err != nil {
+			return nil, err
+		}
+	}
+	if config.Panorama != "" {
+		center := sceneObj.CameraConfig.Center
+		if config.PanoramaAt != "" {
+			if center, err = parsePoint(config.PanoramaAt); err != nil {
+				return nil, fmt.Errorf("panorama point %q should be x,y,z: %v", config.PanoramaAt, err)
+			}
+		}
+		if err := sceneObj.UsePanorama(geometry.Projection(config.Panorama), center, config.PanoramaSize); err != nil {
+			return nil, err
+		}
+	}
+	return sceneObj, nil
+}
+
+// parsePoint parses a point given as x,y,z
+func parsePoint(spec string) (core.Vec3, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return core.Vec3{}, fmt.Errorf("expected 3 numbers")
+	}
+	var coords [3]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return core.Vec3{}, fmt.Errorf("%q is not a number", part)
+		}
+		coords[i] = value
+	}
+	return core.NewVec3(coords[0], coords[1], coords[2]), nil
+}
+
+// focusCamera focuses the scene's camera on what the pixel "x,y" shows, for --focus-at. The scene
+// must be preprocessed.
+func focusCamera(spec string, sceneObj *scene.Scene) error {
+	coords, err := parseCoords(spec, 2)
+	if err != nil {
+		return fmt.Errorf("focus pixel %q should be x,y: %v", spec, err)
+	}
+	distance, err := sceneObj.FocusDistanceAt(coords[0], coords[1])
+	if err != nil {
+		return err
+	}
+	sceneObj.SetFocusDistance(distance)
+	fmt.Printf("Focused on pixel (%d, %d) at distance %.4g\n", coords[0], coords[1], distance)
+	if sceneObj.CameraConfig.Aperture == 0 {
+		fmt.Println("Warning: the camera has no aperture, so everything is in focus anyway")
+	}
+	return nil
 }
 
 // createScene creates the appropriate scene based on scene type
-func createScene(sceneType string) (*scene.Scene, error) {
+func createScene(sceneType string, float32Meshes bool) (*scene.Scene, error) {
 	var sceneObj *scene.Scene
 
-	// First, try to load as PBRT scene (direct path or scene name)
-	if pbrtScene := tryLoadPBRTScene(sceneType); pbrtScene != nil {
+	// Micro scene files (see scene.NewMicroScene), as shared for repro cases
+	if strings.HasSuffix(sceneType, ".micro") {
+		fmt.Printf("Using micro scene %s...\n", sceneType)
+		source, err := loaders.ReadSceneFile(sceneType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read micro scene: %v", err)
+		}
+		if sceneObj, err = scene.NewMicroScene(string(source), nil); err != nil {
+			return nil, fmt.Errorf("failed to create micro scene %s: %v", sceneType, err)
+		}
+	} else if ext := filepath.Ext(sceneType); ext == ".json" || ext == ".yaml" || ext == ".yml" {
+		// Declarative scene descriptions (see scene.SceneFile)
+		fmt.Printf("Using scene file %s...\n", sceneType)
+		var err error
+		if sceneObj, err = scene.LoadSceneFile(sceneType); err != nil {
+			return nil, err
+		}
+	} else if pbrtScene := tryLoadPBRTScene(sceneType); pbrtScene != nil {
+		// Otherwise, try to load as PBRT scene (direct path or scene name)
 		sceneObj = pbrtScene
 	} else {
 		// Fall back to built-in scenes
@@ -154,10 +587,10 @@ func createScene(sceneType string) (*scene.Scene, error) {
 			sceneObj = scene.NewTriangleMeshScene(32) // Default complexity
 		case "dragon":
 			fmt.Println("Using dragon PLY mesh scene...")
-			sceneObj = scene.NewDragonScene(true, "gold", renderer.NewDefaultLogger()) // Default to gold material
+			sceneObj = scene.NewDragonScene(true, "gold", float32Meshes, core.NewLog("scene")) // Default to gold material
 		case "caustic-glass":
 			fmt.Println("Using caustic glass scene...")
-			sceneObj = scene.NewCausticGlassScene(true, lights.LightTypeArea, renderer.NewDefaultLogger())
+			sceneObj = scene.NewCausticGlassScene(true, lights.LightTypeArea, core.NewLog("scene"))
 		case "cylinder-test":
 			fmt.Println("Using cylinder test scene...")
 			sceneObj = scene.NewCylinderTestScene()
@@ -167,6 +600,12 @@ func createScene(sceneType string) (*scene.Scene, error) {
 		case "texture-test":
 			fmt.Println("Using texture test scene...")
 			sceneObj = scene.NewTextureTestScene()
+		case "photometric":
+			fmt.Println("Using photometric validation scene...")
+			sceneObj = scene.NewPhotometricScene(1.0)
+		case "smoke":
+			fmt.Println("Using smoke volume scene...")
+			sceneObj = scene.NewSmokeScene()
 		case "cornell-pbrt":
 			fmt.Println("Using PBRT Cornell scene...")
 			pbrtScene, err := loaders.LoadPBRT("scenes/cornell-empty.pbrt")
@@ -195,22 +634,28 @@ func createScene(sceneType string) (*scene.Scene, error) {
 	return sceneObj, nil
 }
 
-// tryLoadPBRTScene attempts to load a PBRT scene from various possible paths
-func tryLoadPBRTScene(sceneType string) *scene.Scene {
-	// List of possible PBRT file paths to try
+// pbrtScenePaths lists the PBRT files a scene type may name, in the order to try them
+func pbrtScenePaths(sceneType string) []string {
 	possiblePaths := []string{
 		sceneType, // Direct path (e.g., "scenes/my-scene.pbrt")
 		filepath.Join("scenes", sceneType+".pbrt"), // Scene name + .pbrt (e.g., "cornell-empty" → "scenes/cornell-empty.pbrt")
 		filepath.Join("scenes", sceneType),         // Scene name as direct file (e.g., "scenes/cornell-empty")
 	}
 
+	// Only files with the .pbrt extension are PBRT scenes
+	var paths []string
 	for _, path := range possiblePaths {
-		// Check if file exists and has .pbrt extension
-		if !strings.HasSuffix(path, ".pbrt") {
-			continue
+		if strings.HasSuffix(path, ".pbrt") {
+			paths = append(paths, path)
 		}
+	}
+	return paths
+}
 
-		if _, err := os.Stat(path); err == nil {
+// tryLoadPBRTScene attempts to load a PBRT scene from various possible paths
+func tryLoadPBRTScene(sceneType string) *scene.Scene {
+	for _, path := range pbrtScenePaths(sceneType) {
+		if loaders.SceneFileExists(path) {
 			fmt.Printf("Loading PBRT scene: %s...\n", path)
 			pbrtScene, err := loaders.LoadPBRT(path)
 			if err != nil {
@@ -235,13 +680,12 @@ func createOutputDir(sceneType string) string {
 	dirName := sceneType
 
 	// If it's a file path, extract the filename without extension
-	if strings.Contains(sceneType, "/") || strings.HasSuffix(sceneType, ".pbrt") {
-		base := filepath.Base(sceneType)
-		dirName = strings.TrimSuffix(base, ".pbrt")
+	if ext := filepath.Ext(sceneType); strings.Contains(sceneType, "/") || ext == ".pbrt" || ext == ".micro" || ext == ".json" || ext == ".yaml" || ext == ".yml" {
+		dirName = strings.TrimSuffix(filepath.Base(sceneType), ext)
 	}
 
 	// Use known scene types or default
-	knownScenes := []string{"cornell", "cornell-boxes", "default", "spheregrid", "trianglemesh", "dragon", "caustic-glass", "cornell-pbrt", "cornell-empty", "simple-sphere", "test", "texture-test"}
+	knownScenes := []string{"cornell", "cornell-boxes", "default", "spheregrid", "trianglemesh", "dragon", "caustic-glass", "cornell-pbrt", "cornell-empty", "simple-sphere", "test", "texture-test", "photometric", "smoke"}
 	found := false
 	for _, known := range knownScenes {
 		if dirName == known {
@@ -266,28 +710,113 @@ func createOutputDir(sceneType string) string {
 	return outputDir
 }
 
-// renderProgressive handles progressive rendering with immediate file saving
-func renderProgressive(config Config, sceneObj *scene.Scene) RenderResult {
-	timestamp := time.Now().Format("20060102_150405")
-	fmt.Println("Using progressive rendering...")
-
+// newProgressiveConfig returns the progressive render settings selected by the flags, or those of
+// the recipe being replayed
+func newProgressiveConfig(config Config) renderer.ProgressiveConfig {
 	progressiveConfig := renderer.DefaultProgressiveConfig()
 	progressiveConfig.MaxPasses = config.MaxPasses
 	progressiveConfig.MaxSamplesPerPixel = config.MaxSamples
 	progressiveConfig.NumWorkers = config.NumWorkers
+	progressiveConfig.PinWorkers = config.PinWorkers
+	progressiveConfig.Seed = config.Seed
+	progressiveConfig.PyramidLevels = config.PyramidLevels
+	progressiveConfig.MaxTime = config.MaxTime
+	progressiveConfig.TargetError = config.TargetError
+	progressiveConfig.AOVs = config.AOVs || config.StrategyGrid // The grid is assembled from the strategy AOVs
+	debugAOVs, err := renderer.ParseDebugAOVs(config.DebugAOVs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	progressiveConfig.DebugAOVs = debugAOVs
+	progressiveConfig.Deep = config.Deep
+	progressiveConfig.IDs = config.IDs
+	progressiveConfig.Transparent = config.Transparent
+	overlays, err := renderer.ParseOverlays(config.Overlays)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	progressiveConfig.Overlays = overlays
+	if config.Recipe != nil {
+		progressiveConfig = config.Recipe.Progressive // Also restores settings without flags, such as the tile size
+	}
+	return progressiveConfig
+}
 
-	// Create the appropriate integrator based on config
-	var selectedIntegrator integrator.Integrator
-	switch config.IntegratorType {
-	case "bdpt":
-		fmt.Println("Using BDPT integrator...")
-		selectedIntegrator = integrator.NewBDPTIntegrator(sceneObj.SamplingConfig)
-	case "path-tracing":
-		fmt.Println("Using path tracing integrator...")
-		selectedIntegrator = integrator.NewPathTracingIntegrator(sceneObj.SamplingConfig)
-	default:
-		fmt.Printf("Unknown integrator type: %s. Using path tracing.\n", config.IntegratorType)
-		selectedIntegrator = integrator.NewPathTracingIntegrator(sceneObj.SamplingConfig)
+// newIntegrator creates the integrator selected by the flags
+func newIntegrator(config Config, sceneObj *scene.Scene) integrator.Integrator {
+	if config.IntegratorType != "debug" {
+		return createIntegrator(config.IntegratorType, sceneObj.SamplingConfig)
+	}
+	debug, err := integrator.NewDebugIntegrator(config.DebugShading)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Using debug integrator (%s shading)...\n", config.DebugShading)
+	return debug
+}
+
+// configureAcceleration sets up the scene's BVH builder and light sampler as the flags select
+func configureAcceleration(config Config, sceneObj *scene.Scene) {
+	if config.SpatialSplits {
+		sceneObj.IntersectorBuilder = geometry.NewSBVHIntersector
+	}
+	if config.LightGrid > 0 && config.LightReservoir > 0 {
+		sceneObj.LightSampler = lights.NewReservoirLightSampler(sceneObj.Lights, config.LightGrid, config.LightReservoir)
+	} else if config.LightGrid > 0 {
+		sceneObj.LightSampler = lights.NewGridLightSampler(sceneObj.Lights, config.LightGrid)
+	} else if config.LightPower {
+		sceneObj.LightSampler = lights.NewPowerLightSampler(sceneObj.Lights)
+	}
+}
+
+// renderProgressive handles progressive rendering with immediate file saving. Cancelling ctx
+// stops the render early, returning the last complete pass (nil Image if there was none).
+func renderProgressive(ctx context.Context, config Config, sceneObj *scene.Scene) RenderResult {
+	timestamp := time.Now().Format("20060102_150405")
+	fmt.Println("Using progressive rendering...")
+
+	progressiveConfig := newProgressiveConfig(config)
+	if config.StrategyGrid && config.IntegratorType != "bdpt" {
+		fmt.Println("Warning: --strategy-grid only has strategy images with --integrator=bdpt")
+	}
+
+	selectedIntegrator := newIntegrator(config, sceneObj)
+	if config.BDPTTrace != "" {
+		bdpt, ok := selectedIntegrator.(*integrator.BDPTIntegrator)
+		if !ok {
+			fmt.Println("Error: --bdpt-trace requires --integrator=bdpt")
+			os.Exit(1)
+		}
+		region, err := parseTraceRegion(config.TraceRegion)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		tracer, closeTrace, err := openBDPTTrace(config.BDPTTrace, region)
+		if err != nil {
+			fmt.Printf("Error creating BDPT trace: %v\n", err)
+			os.Exit(1)
+		}
+		bdpt.Tracer = tracer
+		defer func() {
+			if err := closeTrace(); err != nil {
+				fmt.Printf("Error writing BDPT trace: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("BDPT trace saved as %s\n", config.BDPTTrace)
+		}()
+	}
+	configureAcceleration(config, sceneObj)
+
+	// The log goes through the progress bar, so the bar isn't drawn over it
+	renderOptions := renderer.RenderOptions{TileUpdates: false} // No tile updates for the command line
+	if bar := newProgressBar(); config.Progress && bar != nil {
+		defer core.SetLogOutput(core.SetLogOutput(bar))
+		renderOptions.OnProgress = bar.Update
+		defer bar.Clear()
 	}
 
 	progressiveRT, err := renderer.NewProgressiveRaytracer(sceneObj, progressiveConfig, selectedIntegrator, renderer.NewDefaultLogger())
@@ -295,17 +824,37 @@ func renderProgressive(config Config, sceneObj *scene.Scene) RenderResult {
 		fmt.Printf("Error creating progressive raytracer: %v\n", err)
 		os.Exit(1)
 	}
+	if config.FocusAt != "" {
+		if err := focusCamera(config.FocusAt, sceneObj); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if config.PreviewProfile && config.IntegratorType != "debug" {
+		fmt.Println("Passes before the last use the preview sampling profile:")
+		progressiveRT.EnablePreviewProfile(createIntegrator(config.IntegratorType, sceneObj.SamplingConfig.PreviewProfile()))
+	}
 
 	// Create output directory
 	outputDir := createOutputDir(config.SceneType)
 	baseFilename := fmt.Sprintf("render_%s", timestamp)
+	if config.Preview > 0 {
+		previewFilename := filepath.Join(outputDir, baseFilename+"_preview.png")
+		renderOptions.PreviewInterval = config.Preview
+		renderOptions.OnPreview = func(preview renderer.Preview) {
+			if err := saveImageToFile(preview.Image, previewFilename, nil); err != nil {
+				fmt.Printf("Error saving preview image: %v\n", err)
+			}
+		}
+	}
 
 	var finalImage *image.RGBA
 	var finalStats renderer.RenderStats
+	var finalMetadata []renderer.MetadataEntry
 
-	// Start rendering and get event channels (disable tile updates for command-line)
-	renderOptions := renderer.RenderOptions{TileUpdates: false}
-	passChan, _, errChan := progressiveRT.RenderProgressive(context.Background(), renderOptions)
+	// Start rendering and get event channels
+	renderStart := time.Now()
+	passChan, _, errChan := progressiveRT.RenderProgressive(ctx, renderOptions)
 
 	// Listen to events from channels
 renderLoop:
@@ -318,20 +867,47 @@ renderLoop:
 			}
 
 			// Save intermediate passes (not the final one)
-			filename := filepath.Join(outputDir, fmt.Sprintf("%s.png", baseFilename))
+			passFilename := baseFilename
 			if !passResult.IsLast {
-				filename = filepath.Join(outputDir, fmt.Sprintf("%s_pass_%02d.png", baseFilename, passResult.PassNumber))
+				passFilename = fmt.Sprintf("%s_pass_%02d", baseFilename, passResult.PassNumber)
 			}
-			if err := saveImageToFile(passResult.Image, filename); err != nil {
+			filename := filepath.Join(outputDir, passFilename+".png")
+			metadata := renderMetadata(config, progressiveConfig, passResult, time.Since(renderStart))
+			if err := saveImageToFile(passResult.Image, filename, metadata); err != nil {
 				fmt.Printf("Error saving final image: %v\n", err)
 				os.Exit(1)
 			}
 
+			// Save AOVs, debug heatmaps and overlays next to the pass image they belong to
+			for name, aovImage := range passResult.AOVs {
+				if config.AOVs || strings.HasPrefix(name, renderer.DebugAOVPrefix) || strings.HasPrefix(name, renderer.OverlayPrefix) ||
+					name == renderer.AOVObjectID || name == renderer.AOVMaterialID {
+					aovFilename := filepath.Join(outputDir, fmt.Sprintf("%s_%s.png", passFilename, name))
+					if err := saveImageToFile(aovImage, aovFilename, metadata); err != nil {
+						fmt.Printf("Error saving %s AOV: %v\n", name, err)
+						os.Exit(1)
+					}
+				}
+			}
+			if config.StrategyGrid {
+				if grid := renderer.StrategyGrid(passResult.AOVs); grid != nil {
+					gridFilename := filepath.Join(outputDir, passFilename+"_bdpt_strategies.png")
+					if err := saveImageToFile(grid, gridFilename, metadata); err != nil {
+						fmt.Printf("Error saving strategy grid: %v\n", err)
+						os.Exit(1)
+					}
+				}
+			}
+
 			// Keep track of final result
 			finalImage = passResult.Image
 			finalStats = passResult.Stats
+			finalMetadata = metadata
 
 		case err := <-errChan:
+			if err != nil && ctx.Err() != nil {
+				return RenderResult{Image: finalImage, Stats: finalStats, Config: progressiveConfig, Timestamp: timestamp, Interrupted: true, Metadata: finalMetadata}
+			}
 			if err != nil {
 				fmt.Printf("Error during progressive rendering: %v\n", err)
 				os.Exit(1)
@@ -351,15 +927,75 @@ renderLoop:
 		os.Exit(1)
 	}
 
+	if config.Accumulation {
+		filename := filepath.Join(outputDir, baseFilename+".accum")
+		if err := saveAccumulation(progressiveRT.Accumulation(), filename); err != nil {
+			fmt.Printf("Error saving accumulation: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Accumulation saved as %s\n", filename)
+	}
+	if config.Deep {
+		filename := filepath.Join(outputDir, baseFilename+".exr")
+		if err := saveDeepImage(progressiveRT.DeepImage(), filename); err != nil {
+			fmt.Printf("Error saving deep image: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deep image saved as %s\n", filename)
+	}
+	if config.IDs {
+		filename := filepath.Join(outputDir, baseFilename+".cryptomatte.exr")
+		if err := saveCryptomatte(progressiveRT.Cryptomatte(), filename); err != nil {
+			fmt.Printf("Error saving ID mattes: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("ID mattes saved as %s\n", filename)
+	}
+	if config.Panorama != "" {
+		filename := filepath.Join(outputDir, baseFilename+".hdr")
+		if err := savePanorama(progressiveRT.Accumulation(), filename); err != nil {
+			fmt.Printf("Error saving panorama: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Panorama saved as %s\n", filename)
+	}
+
 	return RenderResult{
 		Image:     finalImage,
 		Stats:     finalStats,
+		Config:    progressiveConfig,
 		Timestamp: timestamp,
+		Metadata:  finalMetadata,
+	}
+}
+
+// createIntegrator creates the appropriate integrator based on the integrator type
+func createIntegrator(integratorType string, samplingConfig scene.SamplingConfig) integrator.Integrator {
+	switch integratorType {
+	case "bdpt":
+		fmt.Println("Using BDPT integrator...")
+		return integrator.NewBDPTIntegrator(samplingConfig)
+	case "path-tracing":
+		fmt.Println("Using path tracing integrator...")
+		return integrator.NewPathTracingIntegrator(samplingConfig)
+	case "ao":
+		fmt.Println("Using ambient occlusion preview integrator...")
+		return integrator.NewAmbientOcclusionIntegrator(samplingConfig)
+	case "direct":
+		fmt.Println("Using direct lighting preview integrator...")
+		return integrator.NewDirectLightingIntegrator(samplingConfig)
+	default:
+		if registered, err := integrator.New(integratorType, samplingConfig); err == nil {
+			fmt.Printf("Using the registered %s integrator...\n", integratorType)
+			return registered
+		}
+		fmt.Printf("Unknown integrator type: %s. Using path tracing.\n", integratorType)
+		return integrator.NewPathTracingIntegrator(samplingConfig)
 	}
 }
 
-// saveImageToFile saves an image to the specified file path
-func saveImageToFile(img *image.RGBA, filename string) error {
+// saveImageToFile saves an image to the specified file path, with the metadata in its PNG text chunks
+func saveImageToFile(img *image.RGBA, filename string, metadata []renderer.MetadataEntry) error {
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(filename)
 	err := os.MkdirAll(dir, 0755)
@@ -374,5 +1010,47 @@ func saveImageToFile(img *image.RGBA, filename string) error {
 	}
 	defer file.Close()
 
-	return png.Encode(file, img)
+	if err := renderer.EncodePNG(file, img, metadata); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// saveDeepImage saves a deep image as an OpenEXR deep scanline file
+func saveDeepImage(img *loaders.DeepImage, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := loaders.WriteDeepEXR(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// saveCryptomatte saves ID mattes as a Cryptomatte OpenEXR file
+func saveCryptomatte(matte *loaders.Cryptomatte, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := loaders.WriteCryptomatteEXR(file, matte); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// savePanorama saves a panorama's linear radiance as an HDR image, for use as an environment map
+func savePanorama(acc *renderer.Accumulation, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := loaders.WriteHDR(file, &loaders.ImageData{Width: acc.Width, Height: acc.Height, Pixels: acc.Colors()}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
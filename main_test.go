@@ -29,15 +29,23 @@ func TestCreateScene(t *testing.T) {
 		{"direct PBRT path", "scenes/cornell-empty.pbrt", false},
 		{"direct PBRT path 2", "scenes/simple-sphere.pbrt", false},
 
+		// Micro scenes (by path)
+		{"micro scene path", "scenes/sphere-light.micro", false},
+
+		// Scene descriptions (by path)
+		{"YAML scene path", "scenes/still-life.yaml", false},
+
 		// Invalid scenes
 		{"unknown scene", "nonexistent", true},
 		{"invalid PBRT path", "scenes/nonexistent.pbrt", true},
+		{"invalid micro scene path", "scenes/nonexistent.micro", true},
+		{"invalid scene description path", "scenes/nonexistent.json", true},
 		{"empty scene name", "", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			scene, err := createScene(tt.sceneType)
+			scene, err := createScene(tt.sceneType, false)
 
 			if tt.expectError {
 				if err == nil {
@@ -122,6 +130,8 @@ func TestCreateOutputDir(t *testing.T) {
 		// PBRT scenes by path
 		{"PBRT file path", "scenes/cornell-empty.pbrt", "cornell-empty"},
 		{"nested PBRT path", "scenes/subdir/my-scene.pbrt", "my-scene"},
+		{"micro scene path", "scenes/sphere-light.micro", "sphere-light"},
+		{"scene description path", "scenes/still-life.yaml", "still-life"},
 
 		// Unknown scenes
 		{"unknown scene", "unknown", "pbrt-scene"},
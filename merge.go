@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// saveAccumulation writes a render's accumulated samples, for merging with --merge
+func saveAccumulation(acc *renderer.Accumulation, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := renderer.WriteAccumulation(file, acc); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadAccumulation reads an accumulation written by saveAccumulation
+func loadAccumulation(filename string) (*renderer.Accumulation, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	acc, err := renderer.ReadAccumulation(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return acc, nil
+}
+
+// mergeAccumulations loads and adds up the accumulations of independent renders of one scene
+func mergeAccumulations(filenames []string) (*renderer.Accumulation, error) {
+	if len(filenames) < 2 {
+		return nil, fmt.Errorf("need at least two accumulation files to merge, got %d", len(filenames))
+	}
+
+	var merged *renderer.Accumulation
+	for _, filename := range filenames {
+		acc, err := loadAccumulation(filename)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = acc
+		} else if err := merged.Merge(acc); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return merged, nil
+}
+
+// checkMergeRecipes compares the recipes saved next to the accumulation files, when there are any.
+// Renders of different scenes don't belong together, and renders with the same seed took the same
+// samples, so merging them only looks like more samples.
+func checkMergeRecipes(filenames []string) []string {
+	var warnings []string
+	var first *Recipe
+	seeds := make(map[uint64]string)
+	for _, filename := range filenames {
+		recipeFile := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".recipe.json"
+		recipe, err := loadRecipe(recipeFile)
+		if err != nil {
+			continue // Accumulations from other tools, or with the recipe deleted
+		}
+
+		if first == nil {
+			first = &recipe
+		} else if recipe.Scene != first.Scene || recipe.Integrator != first.Integrator {
+			warnings = append(warnings, fmt.Sprintf("%s is a %s render of %s, not a %s render of %s",
+				filename, recipe.Integrator, recipe.Scene, first.Integrator, first.Scene))
+		}
+		if other, exists := seeds[recipe.Progressive.Seed]; exists {
+			warnings = append(warnings, fmt.Sprintf("%s and %s were rendered with the same seed (%d), so their samples are identical",
+				other, filename, recipe.Progressive.Seed))
+		}
+		seeds[recipe.Progressive.Seed] = filename
+	}
+	return warnings
+}
+
+// runMerge merges the accumulation files and saves the result, as an image and as an accumulation
+// that can itself be merged later, next to the first file
+func runMerge(filenames []string, timestamp string) error {
+	for _, warning := range checkMergeRecipes(filenames) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+
+	merged, err := mergeAccumulations(filenames)
+	if err != nil {
+		return err
+	}
+	img, stats := merged.Image()
+
+	base := filepath.Join(filepath.Dir(filenames[0]), fmt.Sprintf("merged_%s", timestamp))
+	if err := saveImageToFile(img, base+".png", nil); err != nil {
+		return fmt.Errorf("failed to save merged image: %w", err)
+	}
+	if err := saveAccumulation(merged, base+".accum"); err != nil {
+		return fmt.Errorf("failed to save merged accumulation: %w", err)
+	}
+
+	fmt.Printf("Merged %d renders: %.1f samples per pixel (range %d - %d)\n",
+		len(filenames), stats.AverageSamples, stats.MinSamples, stats.MaxSamplesUsed)
+	fmt.Printf("Merged image saved as %s.png\n", base)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// writeTestAccumulation saves a 1x1 accumulation of one sample, with a recipe for the seed unless
+// the seed is zero
+func writeTestAccumulation(t *testing.T, dir, name string, sample core.Vec3, seed uint64) string {
+	t.Helper()
+	acc := &renderer.Accumulation{Width: 1, Height: 1, Pixels: make([]renderer.PixelStats, 1)}
+	acc.Pixels[0].AddSample(sample)
+
+	filename := filepath.Join(dir, name+".accum")
+	if err := saveAccumulation(acc, filename); err != nil {
+		t.Fatalf("saveAccumulation failed: %v", err)
+	}
+	if seed != 0 {
+		recipe := Recipe{FormatVersion: recipeFormatVersion, Scene: "cornell", Integrator: "bdpt"}
+		recipe.Progressive.Seed = seed
+		if err := saveRecipe(recipe, filepath.Join(dir, name+".recipe.json")); err != nil {
+			t.Fatalf("saveRecipe failed: %v", err)
+		}
+	}
+	return filename
+}
+
+func TestRunMerge(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeTestAccumulation(t, dir, "render_a", core.NewVec3(1, 1, 1), 1),
+		writeTestAccumulation(t, dir, "render_b", core.NewVec3(0, 0, 0), 2),
+	}
+	if err := runMerge(files, "test"); err != nil {
+		t.Fatalf("runMerge failed: %v", err)
+	}
+
+	// The merged accumulation can be merged again, and holds both renders' samples
+	merged, err := loadAccumulation(filepath.Join(dir, "merged_test.accum"))
+	if err != nil {
+		t.Fatalf("Failed to load the merged accumulation: %v", err)
+	}
+	if merged.Pixels[0].SampleCount != 2 || !merged.Pixels[0].GetColor().Equals(core.NewVec3(0.5, 0.5, 0.5)) {
+		t.Errorf("Merged pixel has %d samples averaging %v", merged.Pixels[0].SampleCount, merged.Pixels[0].GetColor())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "merged_test.png")); err != nil {
+		t.Errorf("Expected a merged image: %v", err)
+	}
+
+	if err := runMerge(files[:1], "single"); err == nil {
+		t.Error("Expected an error merging a single render")
+	}
+}
+
+func TestCheckMergeRecipes(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestAccumulation(t, dir, "render_a", core.NewVec3(1, 1, 1), 7)
+	b := writeTestAccumulation(t, dir, "render_b", core.NewVec3(1, 1, 1), 8)
+	same := writeTestAccumulation(t, dir, "render_c", core.NewVec3(1, 1, 1), 7)
+	noRecipe := writeTestAccumulation(t, dir, "render_d", core.NewVec3(1, 1, 1), 0)
+
+	if warnings := checkMergeRecipes([]string{a, b, noRecipe}); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for different seeds, got %v", warnings)
+	}
+
+	warnings := checkMergeRecipes([]string{a, b, same})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "same seed") {
+		t.Errorf("Expected a warning about the repeated seed, got %v", warnings)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// renderMetadata returns the provenance saved in the text chunks of a pass's images, so a PNG
+// says how it was made even apart from its recipe. PNG's standard Software and Creation Time
+// keywords come first.
+func renderMetadata(config Config, progressiveConfig renderer.ProgressiveConfig, pass renderer.PassResult, renderTime time.Duration) []renderer.MetadataEntry {
+	metadata := []renderer.MetadataEntry{
+		{Key: "Software", Value: "go-progressive-raytracer " + codeVersion()},
+		{Key: "Creation Time", Value: time.Now().UTC().Format(time.RFC3339)},
+		{Key: "Scene", Value: config.SceneType},
+	}
+	if config.Camera != "" {
+		metadata = append(metadata, renderer.MetadataEntry{Key: "Camera", Value: config.Camera})
+	}
+	return append(metadata,
+		renderer.MetadataEntry{Key: "Integrator", Value: config.IntegratorType},
+		renderer.MetadataEntry{Key: "Pass", Value: strconv.Itoa(pass.PassNumber)},
+		renderer.MetadataEntry{Key: "Samples", Value: fmt.Sprintf("%.1f per pixel (range %d - %d, max %d)",
+			pass.Stats.AverageSamples, pass.Stats.MinSamples, pass.Stats.MaxSamplesUsed, progressiveConfig.MaxSamplesPerPixel)},
+		renderer.MetadataEntry{Key: "Seed", Value: strconv.FormatUint(progressiveConfig.Seed, 10)},
+		renderer.MetadataEntry{Key: "Render Time", Value: renderTime.Round(time.Millisecond).String()},
+	)
+}
+
+// printMetadata prints the metadata embedded in each PNG file, for --metadata
+func printMetadata(filenames []string) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("no PNG files given")
+	}
+	for i, filename := range filenames {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		metadata, err := renderer.ReadPNGMetadata(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s:\n", filename)
+		if len(metadata) == 0 {
+			fmt.Println("  (no metadata)")
+		}
+		for _, entry := range metadata {
+			fmt.Printf("  %s: %s\n", entry.Key, entry.Value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+func TestRenderMetadata_SavedWithImage(t *testing.T) {
+	config := Config{SceneType: "scenes/still-life.yaml", Camera: "top", IntegratorType: "bdpt"}
+	progressiveConfig := renderer.DefaultProgressiveConfig()
+	progressiveConfig.Seed = 42
+	pass := renderer.PassResult{PassNumber: 3, Stats: renderer.RenderStats{AverageSamples: 12.5, MinSamples: 4, MaxSamplesUsed: 50}}
+	metadata := renderMetadata(config, progressiveConfig, pass, 1500*time.Millisecond)
+
+	filename := filepath.Join(t.TempDir(), "render.png")
+	if err := saveImageToFile(image.NewRGBA(image.Rect(0, 0, 4, 4)), filename, metadata); err != nil {
+		t.Fatalf("saveImageToFile failed: %v", err)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	read, err := renderer.ReadPNGMetadata(file)
+	if err != nil {
+		t.Fatalf("ReadPNGMetadata failed: %v", err)
+	}
+
+	values := make(map[string]string)
+	for _, entry := range read {
+		values[entry.Key] = entry.Value
+	}
+	want := map[string]string{
+		"Scene":       "scenes/still-life.yaml",
+		"Camera":      "top",
+		"Integrator":  "bdpt",
+		"Pass":        "3",
+		"Samples":     "12.5 per pixel (range 4 - 50, max 50)",
+		"Seed":        "42",
+		"Render Time": "1.5s",
+	}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("%s = %q, want %q", key, values[key], value)
+		}
+	}
+	if values["Software"] == "" || values["Creation Time"] == "" {
+		t.Errorf("Expected the software and creation time, got %v", values)
+	}
+}
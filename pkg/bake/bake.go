@@ -0,0 +1,89 @@
+// Package bake renders a scene's light into the forms games look it up in at runtime, for baked
+// global illumination: lightmaps, textures of the light falling on a mesh laid out by its UVs,
+// and irradiance probes, spherical harmonics of the light arriving at points in space. Both
+// gather radiance with the path tracer, so they include every bounce a render would.
+package bake
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// defaultSamples is the number of rays gathered per texel or probe when Options leaves it unset
+const defaultSamples = 256
+
+// Options controls a bake. The zero value gathers 256 rays per texel or probe on every CPU.
+type Options struct {
+	Samples int    // Rays gathered per lightmap texel or probe (0 = 256)
+	Workers int    // Parallel workers (0 = one per CPU)
+	Seed    uint64 // Bakes with the same seed are identical, however many workers run them
+}
+
+// baker gathers the radiance arriving at points of a scene
+type baker struct {
+	scene   *scene.Scene
+	tracer  *integrator.PathTracingIntegrator
+	samples int
+	workers int
+	seed    uint64
+}
+
+// newBaker preprocesses the scene if that hasn't been done and creates its path tracer. The
+// irradiance cache and path guiding are off: both learn from camera rays, which a bake has none of.
+func newBaker(s *scene.Scene, opts Options) (*baker, error) {
+	if s.Intersector == nil {
+		if err := s.Preprocess(); err != nil {
+			return nil, err
+		}
+	}
+	config := s.SamplingConfig
+	config.IrradianceCache = 0
+	config.IrradianceCachePoints = false
+	config.GuidingPasses = 0
+
+	b := &baker{scene: s, tracer: integrator.NewPathTracingIntegrator(config), samples: opts.Samples, workers: opts.Workers, seed: opts.Seed}
+	if b.samples <= 0 {
+		b.samples = defaultSamples
+	}
+	if b.workers <= 0 {
+		b.workers = runtime.NumCPU()
+	}
+	return b, nil
+}
+
+// radiance returns the radiance arriving at a ray's origin along its direction
+func (b *baker) radiance(ray core.Ray, sampler core.Sampler) core.Vec3 {
+	color, _ := b.tracer.RayColor(ray, b.scene, sampler)
+	return color
+}
+
+// sampler returns the sampler of the ith texel or probe, seeded from its index alone so results
+// don't depend on which worker gathers it
+func (b *baker) sampler(i int) *core.SeededSampler {
+	return core.NewSeededSampler(core.MixBits(b.seed ^ uint64(i+1)))
+}
+
+// parallel calls gather for 0 to n-1 on the baker's workers
+func (b *baker) parallel(n int, gather func(i int)) {
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(b.workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				gather(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,113 @@
+package bake
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// skyRadiance is the radiance of the uniform sky the test scenes are lit by
+const skyRadiance = 0.5
+
+// floorMesh returns a 2x2 floor at y = 0 facing up, its UVs covering [0, uMax] × [0, 1]
+func floorMesh(uMax float64) *geometry.TriangleMesh {
+	vertices := []core.Vec3{core.NewVec3(-1, 0, 1), core.NewVec3(1, 0, 1), core.NewVec3(1, 0, -1), core.NewVec3(-1, 0, -1)}
+	uvs := []core.Vec2{core.NewVec2(0, 0), core.NewVec2(uMax, 0), core.NewVec2(uMax, 1), core.NewVec2(0, 1)}
+	return geometry.NewTriangleMesh(vertices, []int{0, 1, 2, 0, 2, 3}, material.NewLambertian(core.NewVec3(0, 0, 0)),
+		&geometry.TriangleMeshOptions{VertexUVs: uvs})
+}
+
+// skyScene returns a scene of a black floor under a uniform sky
+func skyScene(floor geometry.Shape) *scene.Scene {
+	s := &scene.Scene{SamplingConfig: scene.SamplingConfig{MaxDepth: 4, RussianRouletteMinBounces: 4}}
+	s.Shapes = append(s.Shapes, floor)
+	s.AddUniformInfiniteLight(core.NewVec3(skyRadiance, skyRadiance, skyRadiance))
+	return s
+}
+
+func TestRenderLightmap(t *testing.T) {
+	// The floor's UVs cover the left half of the lightmap, which sees only the sky
+	floor := floorMesh(0.5)
+	lightmap, err := RenderLightmap(skyScene(floor), floor, 16, 8, Options{Samples: 16})
+	if err != nil {
+		t.Fatalf("RenderLightmap failed: %v", err)
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			texel, covered := lightmap.Texels[y*16+x], lightmap.Covered[y*16+x]
+			switch {
+			case x < 8+lightmapPadding:
+				// On the floor, or in its padding
+				if !covered || math.Abs(texel.X-skyRadiance) > 1e-9 {
+					t.Errorf("Expected texel (%d, %d) covered with %g, got %v (covered %v)", x, y, skyRadiance, texel, covered)
+				}
+			case covered || !texel.IsZero():
+				t.Errorf("Expected texel (%d, %d) empty, got %v (covered %v)", x, y, texel, covered)
+			}
+		}
+	}
+
+	// A wall beside the floor shades the texels near it
+	s := skyScene(floor)
+	s.Shapes = append(s.Shapes, geometry.NewQuad(core.NewVec3(1, 0, -1), core.NewVec3(0, 0, 2), core.NewVec3(0, 2, 0), material.NewLambertian(core.NewVec3(0, 0, 0))))
+	if lightmap, err = RenderLightmap(s, floor, 16, 8, Options{Samples: 64}); err != nil {
+		t.Fatalf("RenderLightmap failed: %v", err)
+	}
+	if near, far := lightmap.Texels[4*16+7].X, lightmap.Texels[4*16].X; near >= 0.8*far {
+		t.Errorf("Expected the texel by the wall darker than the one far from it, got %g and %g", near, far)
+	}
+
+	if _, err := RenderLightmap(s, geometry.NewTriangleMesh(
+		[]core.Vec3{core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1)}, []int{0, 1, 2}, nil, nil), 16, 16, Options{}); err == nil {
+		t.Error("Expected an error for a mesh without UVs")
+	}
+}
+
+func TestRenderLightmap_Deterministic(t *testing.T) {
+	floor := floorMesh(1)
+	s := skyScene(floor)
+	s.Shapes = append(s.Shapes, geometry.NewSphere(core.NewVec3(0, 0.5, 0), 0.4, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))))
+
+	one, err := RenderLightmap(s, floor, 8, 8, Options{Samples: 8, Workers: 1, Seed: 3})
+	if err != nil {
+		t.Fatalf("RenderLightmap failed: %v", err)
+	}
+	many, err := RenderLightmap(s, floor, 8, 8, Options{Samples: 8, Workers: 4, Seed: 3})
+	if err != nil {
+		t.Fatalf("RenderLightmap failed: %v", err)
+	}
+	if !slices.Equal(one.Texels, many.Texels) {
+		t.Error("Expected the same lightmap from one worker and four")
+	}
+}
+
+func TestRenderProbes(t *testing.T) {
+	floor := floorMesh(1)
+	probes, err := RenderProbes(skyScene(floor), []core.Vec3{core.NewVec3(0, 0.01, 0)}, Options{Samples: 4096})
+	if err != nil {
+		t.Fatalf("RenderProbes failed: %v", err)
+	}
+
+	// The sky gives a surface facing up irradiance π L; the black floor gives one facing down none,
+	// which order 2 harmonics only approximate
+	probe := probes[0]
+	if up, expected := probe.Irradiance(core.NewVec3(0, 1, 0)).X, math.Pi*skyRadiance; math.Abs(up-expected)/expected > 0.05 {
+		t.Errorf("Expected irradiance %g facing up, got %g", expected, up)
+	}
+	if down := probe.Irradiance(core.NewVec3(0, -1, 0)).X; down > 0.15*math.Pi*skyRadiance {
+		t.Errorf("Expected little irradiance facing down, got %g", down)
+	}
+}
+
+func TestGridPoints(t *testing.T) {
+	points := GridPoints(geometry.NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(4, 2, 2)), 2, 1, 2)
+	expected := []core.Vec3{core.NewVec3(1, 1, 0.5), core.NewVec3(3, 1, 0.5), core.NewVec3(1, 1, 1.5), core.NewVec3(3, 1, 1.5)}
+	if !slices.Equal(points, expected) {
+		t.Errorf("Expected %v, got %v", expected, points)
+	}
+}
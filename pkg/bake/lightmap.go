@@ -0,0 +1,191 @@
+package bake
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// lightmapPadding is how many texels each UV chart is grown by, so that bilinear filtering at
+// its edges doesn't blend in the empty texels around it
+const lightmapPadding = 2
+
+// Lightmap is the light falling on a mesh, by its UVs. Each texel holds the cosine weighted mean
+// radiance arriving at the surface, the irradiance over π: what a white diffuse surface there
+// reflects, so shading multiplies it by the surface's albedo.
+type Lightmap struct {
+	Width, Height int
+	Texels        []core.Vec3 // Row by row, the top row at v = 1 as in images
+	Covered       []bool      // Whether each texel is on the mesh (after padding); the rest are black
+}
+
+// RenderLightmap bakes a lightmap of the given size for a mesh of the scene. The mesh needs UVs,
+// laid out without overlaps within [0, 1]²; texels are gathered at the front of its faces.
+func RenderLightmap(s *scene.Scene, mesh *geometry.TriangleMesh, width, height int, opts Options) (*Lightmap, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("lightmap size %dx%d must be positive", width, height)
+	}
+	faces := make([]*geometry.Triangle, 0, mesh.GetTriangleCount())
+	for _, shape := range mesh.GetTriangles() {
+		if triangle, ok := shape.(*geometry.Triangle); ok && triangle.HasUVs() {
+			faces = append(faces, triangle)
+		}
+	}
+	if len(faces) == 0 {
+		return nil, fmt.Errorf("mesh has no UVs to lay out a lightmap")
+	}
+	b, err := newBaker(s, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the face each texel's center is on
+	owner := make([]*geometry.Triangle, width*height)
+	for _, face := range faces {
+		rasterizeUVs(face, width, height, owner)
+	}
+	var texels []int
+	for i, face := range owner {
+		if face != nil {
+			texels = append(texels, i)
+		}
+	}
+
+	lightmap := &Lightmap{Width: width, Height: height, Texels: make([]core.Vec3, width*height), Covered: make([]bool, width*height)}
+	b.parallel(len(texels), func(n int) {
+		i := texels[n]
+		lightmap.Texels[i] = b.gatherTexel(owner[i], i%width, i/width, width, height, b.sampler(i))
+		lightmap.Covered[i] = true
+	})
+	lightmap.pad(lightmapPadding)
+	return lightmap, nil
+}
+
+// whiteDiffuse is the material lightmap texels are shaded with: the light a white diffuse surface
+// reflects is its irradiance over π
+var whiteDiffuse = material.NewLambertian(core.NewVec3(1, 1, 1))
+
+// gatherTexel averages the light a white diffuse surface reflects over points of a face within
+// texel (x, y), path traced from each point with light sampling
+func (b *baker) gatherTexel(face *geometry.Triangle, x, y, width, height int, sampler core.Sampler) core.Vec3 {
+	faceNormal := face.GetNormal()
+	center, _ := texelBarycentrics(face, float64(x)+0.5, float64(y)+0.5, width, height)
+
+	var sum core.Vec3
+	for i := 0; i < b.samples; i++ {
+		// Jitter within the texel, keeping to the face where the texel covers its edge
+		jitter := sampler.Get2D()
+		weights, inside := texelBarycentrics(face, float64(x)+jitter.X, float64(y)+jitter.Y, width, height)
+		if !inside {
+			weights = center
+		}
+		point := face.V0.Multiply(weights[0]).Add(face.V1.Multiply(weights[1])).Add(face.V2.Multiply(weights[2]))
+
+		normal := faceNormal
+		if face.HasVertexNormals() {
+			shading := face.N0.Multiply(weights[0]).Add(face.N1.Multiply(weights[1])).Add(face.N2.Multiply(weights[2]))
+			if shading.Dot(faceNormal) < 0 {
+				shading = shading.Multiply(-1)
+			}
+			if shading.LengthSquared() > 0 {
+				normal = shading.Normalize()
+			}
+		}
+
+		// Seen from straight in front, as a diffuse surface looks the same from anywhere
+		hit := &material.SurfaceInteraction{Point: point, Normal: normal, FrontFace: true, Material: whiteDiffuse}
+		sum = sum.Add(b.tracer.ShadeHit(core.NewRay(point.Add(normal), normal.Negate()), hit, b.scene, sampler))
+	}
+	return sum.Multiply(1 / float64(b.samples))
+}
+
+// rasterizeUVs marks the texels whose centers a face's UVs cover as the face's
+func rasterizeUVs(face *geometry.Triangle, width, height int, owner []*geometry.Triangle) {
+	xs := []float64{face.UV0.X * float64(width), face.UV1.X * float64(width), face.UV2.X * float64(width)}
+	ys := []float64{(1 - face.UV0.Y) * float64(height), (1 - face.UV1.Y) * float64(height), (1 - face.UV2.Y) * float64(height)}
+	x0 := max(0, int(math.Floor(min(xs[0], xs[1], xs[2]))))
+	x1 := min(width-1, int(math.Ceil(max(xs[0], xs[1], xs[2]))))
+	y0 := max(0, int(math.Floor(min(ys[0], ys[1], ys[2]))))
+	y1 := min(height-1, int(math.Ceil(max(ys[0], ys[1], ys[2]))))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if _, inside := texelBarycentrics(face, float64(x)+0.5, float64(y)+0.5, width, height); inside {
+				owner[y*width+x] = face
+			}
+		}
+	}
+}
+
+// texelBarycentrics returns the barycentric weights of the point of a face at texel coordinates
+// (x, y), and whether the point is on the face
+func texelBarycentrics(face *geometry.Triangle, x, y float64, width, height int) ([3]float64, bool) {
+	u, v := x/float64(width), 1-y/float64(height)
+	e1 := face.UV1.Add(face.UV0.Multiply(-1))
+	e2 := face.UV2.Add(face.UV0.Multiply(-1))
+	det := e1.X*e2.Y - e1.Y*e2.X
+	if math.Abs(det) < 1e-12 {
+		return [3]float64{}, false // No area in UV space
+	}
+	du, dv := u-face.UV0.X, v-face.UV0.Y
+	w1 := (du*e2.Y - dv*e2.X) / det
+	w2 := (e1.X*dv - e1.Y*du) / det
+	w0 := 1 - w1 - w2
+	const tolerance = 1e-9
+	return [3]float64{w0, w1, w2}, w0 >= -tolerance && w1 >= -tolerance && w2 >= -tolerance
+}
+
+// pad grows the covered texels by the given number of rings of texels, each new texel the mean
+// of the covered ones around it
+func (l *Lightmap) pad(rings int) {
+	for range rings {
+		texels := append([]core.Vec3(nil), l.Texels...)
+		covered := append([]bool(nil), l.Covered...)
+		for y := 0; y < l.Height; y++ {
+			for x := 0; x < l.Width; x++ {
+				if l.Covered[y*l.Width+x] {
+					continue
+				}
+				var sum core.Vec3
+				n := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						nx, ny := x+dx, y+dy
+						if nx >= 0 && nx < l.Width && ny >= 0 && ny < l.Height && l.Covered[ny*l.Width+nx] {
+							sum = sum.Add(l.Texels[ny*l.Width+nx])
+							n++
+						}
+					}
+				}
+				if n > 0 {
+					texels[y*l.Width+x] = sum.Multiply(1 / float64(n))
+					covered[y*l.Width+x] = true
+				}
+			}
+		}
+		l.Texels, l.Covered = texels, covered
+	}
+}
+
+// Image returns the lightmap for viewing, gamma corrected and clamped like rendered images
+func (l *Lightmap) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, l.Width, l.Height))
+	for i, texel := range l.Texels {
+		c := texel.GammaCorrect(2.0).Clamp(0.0, 1.0)
+		img.SetRGBA(i%l.Width, i/l.Width, color.RGBA{R: uint8(255 * c.X), G: uint8(255 * c.Y), B: uint8(255 * c.Z), A: 255})
+	}
+	return img
+}
+
+// WriteHDR writes the lightmap as a Radiance HDR (.hdr) image, which keeps its full range for
+// game engines to import
+func (l *Lightmap) WriteHDR(w io.Writer) error {
+	return loaders.WriteHDR(w, &loaders.ImageData{Width: l.Width, Height: l.Height, Pixels: l.Texels})
+}
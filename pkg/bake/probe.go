@@ -0,0 +1,112 @@
+package bake
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Probe is the light arriving at a point from every direction, as the order 2 spherical harmonics
+// of its radiance: 9 RGB coefficients for the real basis functions Y00, Y1-1, Y10, Y11, Y2-2, Y2-1,
+// Y20, Y21, Y22, of the direction in scene coordinates. Irradiance turns them into the light
+// falling on a surface there.
+type Probe struct {
+	Position core.Vec3
+	SH       [9]core.Vec3
+}
+
+// shBasis returns the 9 real spherical harmonic basis functions of order 2 at a unit direction
+func shBasis(d core.Vec3) [9]float64 {
+	return [9]float64{
+		0.282095,
+		0.488603 * d.Y,
+		0.488603 * d.Z,
+		0.488603 * d.X,
+		1.092548 * d.X * d.Y,
+		1.092548 * d.Y * d.Z,
+		0.315392 * (3*d.Z*d.Z - 1),
+		1.092548 * d.X * d.Z,
+		0.546274 * (d.X*d.X - d.Y*d.Y),
+	}
+}
+
+// shBandScale is the cosine lobe's convolution factor of each coefficient's band (Ramamoorthi and
+// Hanrahan), which turns radiance into irradiance
+var shBandScale = [9]float64{math.Pi, 2 * math.Pi / 3, 2 * math.Pi / 3, 2 * math.Pi / 3, math.Pi / 4, math.Pi / 4, math.Pi / 4, math.Pi / 4, math.Pi / 4}
+
+// Irradiance returns the irradiance the probe's light gives a surface facing along a unit normal
+func (p *Probe) Irradiance(normal core.Vec3) core.Vec3 {
+	var e core.Vec3
+	for i, y := range shBasis(normal) {
+		e = e.Add(p.SH[i].Multiply(shBandScale[i] * y))
+	}
+	return core.NewVec3(math.Max(0, e.X), math.Max(0, e.Y), math.Max(0, e.Z))
+}
+
+// RenderProbes bakes a probe at each point of the scene
+func RenderProbes(s *scene.Scene, points []core.Vec3, opts Options) ([]Probe, error) {
+	b, err := newBaker(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	probes := make([]Probe, len(points))
+	b.parallel(len(points), func(i int) {
+		probes[i] = b.gatherProbe(points[i], b.sampler(i))
+	})
+	return probes, nil
+}
+
+// gatherProbe projects the radiance arriving at a point from uniformly sampled directions onto
+// the basis functions
+func (b *baker) gatherProbe(point core.Vec3, sampler core.Sampler) Probe {
+	probe := Probe{Position: point}
+	for i := 0; i < b.samples; i++ {
+		direction := core.SampleOnUnitSphere(sampler.Get2D())
+		radiance := b.radiance(core.NewRay(point, direction), sampler)
+		for j, y := range shBasis(direction) {
+			probe.SH[j] = probe.SH[j].Add(radiance.Multiply(y))
+		}
+	}
+	for j := range probe.SH {
+		probe.SH[j] = probe.SH[j].Multiply(4 * math.Pi / float64(b.samples))
+	}
+	return probe
+}
+
+// GridPoints returns the points of an nx by ny by nz grid filling a box, x varying fastest. Points
+// sit at the centers of the grid's cells, so none is on the box's surface.
+func GridPoints(box geometry.AABB, nx, ny, nz int) []core.Vec3 {
+	size := box.Max.Subtract(box.Min)
+	points := make([]core.Vec3, 0, max(0, nx*ny*nz))
+	for z := 0; z < nz; z++ {
+		for y := 0; y < ny; y++ {
+			for x := 0; x < nx; x++ {
+				points = append(points, box.Min.Add(core.NewVec3(
+					size.X*(float64(x)+0.5)/float64(nx),
+					size.Y*(float64(y)+0.5)/float64(ny),
+					size.Z*(float64(z)+0.5)/float64(nz))))
+			}
+		}
+	}
+	return points
+}
+
+// WriteProbesJSON writes probes as a JSON array of {"position": [x, y, z], "sh": [[r, g, b] × 9]}
+func WriteProbesJSON(w io.Writer, probes []Probe) error {
+	type probeJSON struct {
+		Position [3]float64    `json:"position"`
+		SH       [9][3]float64 `json:"sh"`
+	}
+	out := make([]probeJSON, len(probes))
+	for i, p := range probes {
+		out[i].Position = [3]float64{p.Position.X, p.Position.Y, p.Position.Z}
+		for j, c := range p.SH {
+			out[i].SH[j] = [3]float64{c.X, c.Y, c.Z}
+		}
+	}
+	return json.NewEncoder(w).Encode(out)
+}
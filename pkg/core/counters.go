@@ -0,0 +1,55 @@
+package core
+
+import "fmt"
+
+// Counter identifies one of the render statistics counters. The counters are only compiled in
+// with the rtstats build tag (go build -tags rtstats); otherwise counting does nothing and
+// ReadCounters returns nil, so the instrumented code runs at full speed.
+type Counter int
+
+const (
+	BVHNodeVisits Counter = iota // Bounding boxes tested while traversing BVHs, the mesh BVHs included
+	ShapeTests                   // Ray-shape intersection tests in BVH leaves
+	numCounters
+)
+
+const (
+	MaxCountedPathLength = 32 // Paths with more bounces are counted as this many
+	MaxCountedStrategy   = 16 // BDPT strategies with more light or camera vertices aren't counted
+)
+
+// Counters is a snapshot of the render statistics counters. They count for the whole process,
+// so renders running at the same time add to each other's.
+type Counters struct {
+	BVHNodeVisits int64            `json:"bvhNodeVisits"`
+	ShapeTests    int64            `json:"shapeTests"`
+	PathLengths   []int64          `json:"pathLengths"` // Camera paths by their number of bounces (the last entry includes longer ones)
+	Strategies    map[string]int64 `json:"strategies"`  // Contributing BDPT strategies by name, such as "s1_t2"
+}
+
+// Since returns the counts added after an earlier snapshot. start may be nil, for all counts.
+func (c *Counters) Since(start *Counters) *Counters {
+	if c == nil || start == nil {
+		return c
+	}
+	since := &Counters{
+		BVHNodeVisits: c.BVHNodeVisits - start.BVHNodeVisits,
+		ShapeTests:    c.ShapeTests - start.ShapeTests,
+		PathLengths:   make([]int64, len(c.PathLengths)),
+		Strategies:    make(map[string]int64),
+	}
+	for i, count := range c.PathLengths {
+		since.PathLengths[i] = count - start.PathLengths[i]
+	}
+	for name, count := range c.Strategies {
+		if count -= start.Strategies[name]; count > 0 {
+			since.Strategies[name] = count
+		}
+	}
+	return since
+}
+
+// StrategyName returns the Counters.Strategies key of the strategy with s light and t camera vertices
+func StrategyName(s, t int) string {
+	return fmt.Sprintf("s%d_t%d", s, t)
+}
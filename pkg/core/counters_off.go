@@ -0,0 +1,20 @@
+//go:build !rtstats
+
+package core
+
+// CountersEnabled reports whether the render statistics counters are compiled in
+const CountersEnabled = false
+
+// Count adds n to a counter
+func Count(c Counter, n int) {}
+
+// CountPathLength counts a finished camera path with the given number of bounces
+func CountPathLength(bounces int) {}
+
+// CountStrategy counts a contribution of the BDPT strategy with s light and t camera vertices
+func CountStrategy(s, t int) {}
+
+// ReadCounters returns a snapshot of the counters, nil as they aren't compiled in
+func ReadCounters() *Counters {
+	return nil
+}
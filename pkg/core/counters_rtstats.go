@@ -0,0 +1,52 @@
+//go:build rtstats
+
+package core
+
+import "sync/atomic"
+
+// CountersEnabled reports whether the render statistics counters are compiled in
+const CountersEnabled = true
+
+var (
+	counters    [numCounters]atomic.Int64
+	pathLengths [MaxCountedPathLength + 1]atomic.Int64
+	strategies  [MaxCountedStrategy + 1][MaxCountedStrategy + 1]atomic.Int64
+)
+
+// Count adds n to a counter
+func Count(c Counter, n int) {
+	counters[c].Add(int64(n))
+}
+
+// CountPathLength counts a finished camera path with the given number of bounces
+func CountPathLength(bounces int) {
+	pathLengths[min(max(bounces, 0), MaxCountedPathLength)].Add(1)
+}
+
+// CountStrategy counts a contribution of the BDPT strategy with s light and t camera vertices
+func CountStrategy(s, t int) {
+	if s <= MaxCountedStrategy && t <= MaxCountedStrategy {
+		strategies[s][t].Add(1)
+	}
+}
+
+// ReadCounters returns a snapshot of the counters
+func ReadCounters() *Counters {
+	c := &Counters{
+		BVHNodeVisits: counters[BVHNodeVisits].Load(),
+		ShapeTests:    counters[ShapeTests].Load(),
+		PathLengths:   make([]int64, len(pathLengths)),
+		Strategies:    make(map[string]int64),
+	}
+	for i := range pathLengths {
+		c.PathLengths[i] = pathLengths[i].Load()
+	}
+	for s := range strategies {
+		for t := range strategies[s] {
+			if count := strategies[s][t].Load(); count > 0 {
+				c.Strategies[StrategyName(s, t)] = count
+			}
+		}
+	}
+	return c
+}
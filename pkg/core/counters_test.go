@@ -0,0 +1,62 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCounters_Since(t *testing.T) {
+	start := &Counters{
+		BVHNodeVisits: 10, ShapeTests: 4,
+		PathLengths: []int64{1, 2, 0},
+		Strategies:  map[string]int64{"s0_t2": 3, "s1_t2": 5},
+	}
+	now := &Counters{
+		BVHNodeVisits: 25, ShapeTests: 4,
+		PathLengths: []int64{1, 5, 2},
+		Strategies:  map[string]int64{"s0_t2": 3, "s1_t2": 9, "s2_t1": 1},
+	}
+	want := &Counters{
+		BVHNodeVisits: 15, ShapeTests: 0,
+		PathLengths: []int64{0, 3, 2},
+		Strategies:  map[string]int64{"s1_t2": 4, "s2_t1": 1},
+	}
+	if got := now.Since(start); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if now.Since(nil) != now {
+		t.Error("Expected the counts since no snapshot to be all the counts")
+	}
+	if (*Counters)(nil).Since(start) != nil {
+		t.Error("Expected no counts when the counters aren't compiled in")
+	}
+}
+
+func TestCounters_Count(t *testing.T) {
+	if !CountersEnabled {
+		if ReadCounters() != nil {
+			t.Error("Expected no counters without the rtstats build tag")
+		}
+		t.Skip("Counters are compiled in with -tags rtstats")
+	}
+
+	start := ReadCounters()
+	Count(BVHNodeVisits, 3)
+	Count(ShapeTests, 2)
+	CountPathLength(1)
+	CountPathLength(MaxCountedPathLength + 10) // Counted as the longest length
+	CountStrategy(1, 2)
+	CountStrategy(MaxCountedStrategy+1, 2) // Not counted
+
+	got := ReadCounters().Since(start)
+	if got.BVHNodeVisits != 3 || got.ShapeTests != 2 {
+		t.Errorf("Expected 3 node visits and 2 shape tests, got %+v", got)
+	}
+	if got.PathLengths[1] != 1 || got.PathLengths[MaxCountedPathLength] != 1 {
+		t.Errorf("Expected a path of 1 bounce and one of the longest length, got %v", got.PathLengths)
+	}
+	if !reflect.DeepEqual(got.Strategies, map[string]int64{"s1_t2": 1}) {
+		t.Errorf("Expected one s1_t2 contribution, got %v", got.Strategies)
+	}
+}
@@ -4,3 +4,12 @@ package core
 type Logger interface {
 	Printf(format string, args ...interface{})
 }
+
+// Params are a component's parameters as a scene file gives them, for the factories that build
+// registered materials, shapes and lights. Each lookup reports whether the parameter was given
+// as that kind of value.
+type Params interface {
+	Float(name string) (float64, bool)
+	Vec3(name string) (Vec3, bool)
+	String(name string) (string, bool)
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log levels, from the most verbose. A subsystem's log drops messages below its level.
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+)
+
+// LevelLogger is a Logger whose messages can carry a level
+type LevelLogger interface {
+	Logger
+	Logf(level slog.Level, format string, args ...interface{})
+}
+
+// Logf logs a message at a level. Loggers without levels print info messages as they are, and
+// warnings and errors with their level in front; they drop debug messages.
+func Logf(logger Logger, level slog.Level, format string, args ...interface{}) {
+	if leveled, ok := logger.(LevelLogger); ok {
+		leveled.Logf(level, format, args...)
+		return
+	}
+	switch {
+	case level < LevelInfo:
+	case level == LevelInfo:
+		logger.Printf(format, args...)
+	default:
+		logger.Printf(level.String()+": "+format, args...)
+	}
+}
+
+// LogConfig selects which messages the subsystems' logs write, and how
+type LogConfig struct {
+	Level      slog.Level            // Level of the subsystems not in Subsystems
+	Subsystems map[string]slog.Level // Levels of particular subsystems, such as "bdpt" at debug
+	JSON       bool                  // Write JSON lines (time, level, subsystem, msg) instead of text
+	Output     io.Writer             // Where to write, stdout if nil
+}
+
+// Log is the leveled logger of one subsystem: "renderer", "scene", "path-tracing", "camera", "web"
+// and so on. Every NewLog for a subsystem returns the same Log, so ConfigureLogging reaches them
+// all. In text, info messages are written as they are and other levels are marked with the level
+// and subsystem. Enabled is a single atomic load, cheap enough to guard debug messages on hot paths.
+type Log struct {
+	subsystem string
+	level     atomic.Int64
+}
+
+var logging struct {
+	mu     sync.Mutex
+	config LogConfig
+	logs   map[string]*Log
+	output atomic.Pointer[logOutput]
+}
+
+// logOutput writes log records, as text or through a JSON handler
+type logOutput struct {
+	mu   sync.Mutex
+	w    io.Writer // nil for stdout, looked up when writing so that redirecting it takes effect
+	json bool
+}
+
+// NewLog returns the log of a subsystem
+func NewLog(subsystem string) *Log {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	if l, ok := logging.logs[subsystem]; ok {
+		return l
+	}
+	if logging.logs == nil {
+		logging.logs = make(map[string]*Log)
+	}
+	l := &Log{subsystem: subsystem}
+	l.level.Store(int64(logging.config.levelOf(subsystem)))
+	logging.logs[subsystem] = l
+	return l
+}
+
+// ConfigureLogging applies a configuration to every subsystem's log. Without one, logs write info
+// and above as text on stdout.
+func ConfigureLogging(config LogConfig) {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	logging.config = config
+	for name, l := range logging.logs {
+		l.level.Store(int64(config.levelOf(name)))
+	}
+	logging.output.Store(&logOutput{w: config.Output, json: config.JSON})
+}
+
+// SetLogOutput redirects every log to w (stdout if nil), returning the previous destination so
+// it can be restored
+func SetLogOutput(w io.Writer) io.Writer {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	previous := logging.config.Output
+	logging.config.Output = w
+	logging.output.Store(&logOutput{w: w, json: logging.config.JSON})
+	return previous
+}
+
+// LogSubsystems lists the subsystems that have logs, sorted
+func LogSubsystems() []string {
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	names := make([]string, 0, len(logging.logs))
+	for name := range logging.logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// levelOf returns the level a subsystem logs at
+func (c LogConfig) levelOf(subsystem string) slog.Level {
+	if level, ok := c.Subsystems[subsystem]; ok {
+		return level
+	}
+	return c.Level
+}
+
+// ParseLogLevels parses a level, optionally followed by levels for particular subsystems, such as
+// "warn,bdpt=debug". Levels are debug, info, warn or error.
+func ParseLogLevels(spec string) (slog.Level, map[string]slog.Level, error) {
+	level := LevelInfo
+	var subsystems map[string]slog.Level
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		name, value, perSubsystem := strings.Cut(part, "=")
+		if !perSubsystem {
+			value = name
+		}
+		var parsed slog.Level
+		if err := parsed.UnmarshalText([]byte(value)); err != nil || value == "" {
+			return 0, nil, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", value)
+		}
+		switch {
+		case !perSubsystem && i == 0:
+			level = parsed
+		case !perSubsystem:
+			return 0, nil, fmt.Errorf("log level %q should be subsystem=level", part)
+		default:
+			if subsystems == nil {
+				subsystems = make(map[string]slog.Level)
+			}
+			subsystems[strings.TrimSpace(name)] = parsed
+		}
+	}
+	return level, subsystems, nil
+}
+
+// Enabled reports whether the log writes messages at a level
+func (l *Log) Enabled(level slog.Level) bool {
+	return int64(level) >= l.level.Load()
+}
+
+// Logf writes a message at a level, if the log is enabled for it
+func (l *Log) Logf(level slog.Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	out := logging.output.Load()
+	if out == nil {
+		out = &logOutput{}
+	}
+	out.write(level, l.subsystem, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// Printf writes an info message, so that a Log is a Logger
+func (l *Log) Printf(format string, args ...interface{}) {
+	l.Logf(LevelInfo, format, args...)
+}
+
+// write writes one record
+func (o *logOutput) write(level slog.Level, subsystem, message string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	w := o.w
+	if w == nil {
+		w = os.Stdout
+	}
+	if o.json {
+		record := slog.NewRecord(time.Now(), level, message, 0)
+		record.AddAttrs(slog.String("subsystem", subsystem))
+		slog.NewJSONHandler(w, nil).Handle(context.Background(), record)
+		return
+	}
+	if level == LevelInfo {
+		fmt.Fprintln(w, message)
+		return
+	}
+	fmt.Fprintf(w, "%s [%s] %s\n", level, subsystem, message)
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// captureLogs configures logging to write to a buffer for the rest of the test
+func captureLogs(t *testing.T, config LogConfig) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	config.Output = &buf
+	ConfigureLogging(config)
+	t.Cleanup(func() { ConfigureLogging(LogConfig{}) })
+	return &buf
+}
+
+func TestLog_LevelsAndText(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: LevelInfo, Subsystems: map[string]slog.Level{"test-bdpt": LevelDebug}})
+	renderer, bdpt := NewLog("test-renderer"), NewLog("test-bdpt")
+	if NewLog("test-renderer") != renderer {
+		t.Error("Expected one log per subsystem")
+	}
+
+	renderer.Logf(LevelDebug, "dropped")
+	renderer.Printf("Pass %d completed\n", 1)
+	renderer.Logf(LevelWarn, "mesh not found")
+	bdpt.Logf(LevelDebug, "(s=%d,t=%d) weight", 1, 2)
+
+	want := "Pass 1 completed\nWARN [test-renderer] mesh not found\nDEBUG [test-bdpt] (s=1,t=2) weight\n"
+	if buf.String() != want {
+		t.Errorf("Expected log\n%q, got\n%q", want, buf.String())
+	}
+	if renderer.Enabled(LevelDebug) || !bdpt.Enabled(LevelDebug) {
+		t.Error("Expected debug enabled for test-bdpt only")
+	}
+}
+
+func TestLog_JSON(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: LevelInfo, JSON: true})
+	NewLog("test-scene").Logf(LevelWarn, "Failed to parse %s", "a.pbrt")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["subsystem"] != "test-scene" || record["msg"] != "Failed to parse a.pbrt" || record["time"] == nil {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+func TestSetLogOutput(t *testing.T) {
+	buf := captureLogs(t, LogConfig{})
+	var redirected bytes.Buffer
+	previous := SetLogOutput(&redirected)
+	NewLog("test-renderer").Printf("redirected")
+	SetLogOutput(previous)
+	NewLog("test-renderer").Printf("restored")
+	if redirected.String() != "redirected\n" || buf.String() != "restored\n" {
+		t.Errorf("Expected the message redirected and the output restored, got %q and %q", redirected.String(), buf.String())
+	}
+}
+
+// plainLogger is a Logger without levels
+type plainLogger struct{ lines []string }
+
+func (l *plainLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLogf_PlainLogger(t *testing.T) {
+	logger := &plainLogger{}
+	Logf(logger, LevelDebug, "dropped")
+	Logf(logger, LevelInfo, "loaded %d", 3)
+	Logf(logger, LevelError, "failed")
+	want := []string{"loaded 3", "ERROR: failed"}
+	if !reflect.DeepEqual(logger.lines, want) {
+		t.Errorf("Expected %q, got %q", want, logger.lines)
+	}
+}
+
+func TestParseLogLevels(t *testing.T) {
+	level, subsystems, err := ParseLogLevels("warn, bdpt=debug,web=info")
+	if err != nil {
+		t.Fatalf("ParseLogLevels failed: %v", err)
+	}
+	want := map[string]slog.Level{"bdpt": LevelDebug, "web": LevelInfo}
+	if level != LevelWarn || !reflect.DeepEqual(subsystems, want) {
+		t.Errorf("Expected warn and %v, got %v and %v", want, level, subsystems)
+	}
+
+	if level, _, err := ParseLogLevels("bdpt=debug"); err != nil || level != LevelInfo {
+		t.Errorf("Expected info for subsystems not given, got %v (%v)", level, err)
+	}
+	for _, spec := range []string{"", "loud", "info,debug", "bdpt="} {
+		if _, _, err := ParseLogLevels(spec); err == nil || !strings.Contains(err.Error(), "log level") {
+			t.Errorf("Expected an error parsing %q, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import "math"
+
+// Matrix4 is a 4x4 matrix of an affine transformation, in row-major order (M[row][column]),
+// applied to column vectors: M.Multiply(N) transforms by N first, then by M
+type Matrix4 [4][4]float64
+
+// IdentityMatrix returns the identity transformation
+func IdentityMatrix() Matrix4 {
+	return Matrix4{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+// TranslationMatrix returns a translation by offset
+func TranslationMatrix(offset Vec3) Matrix4 {
+	return Matrix4{{1, 0, 0, offset.X}, {0, 1, 0, offset.Y}, {0, 0, 1, offset.Z}, {0, 0, 0, 1}}
+}
+
+// ScalingMatrix returns a scaling by the given factor along each axis
+func ScalingMatrix(scale Vec3) Matrix4 {
+	return Matrix4{{scale.X, 0, 0, 0}, {0, scale.Y, 0, 0}, {0, 0, scale.Z, 0}, {0, 0, 0, 1}}
+}
+
+// RotationMatrix returns a counter-clockwise rotation by angle radians around axis (looking down
+// the axis towards the origin)
+func RotationMatrix(angle float64, axis Vec3) Matrix4 {
+	a := axis.Normalize()
+	sin, cos := math.Sincos(angle)
+	return Matrix4{
+		{a.X*a.X + (1-a.X*a.X)*cos, a.X*a.Y*(1-cos) - a.Z*sin, a.X*a.Z*(1-cos) + a.Y*sin, 0},
+		{a.X*a.Y*(1-cos) + a.Z*sin, a.Y*a.Y + (1-a.Y*a.Y)*cos, a.Y*a.Z*(1-cos) - a.X*sin, 0},
+		{a.X*a.Z*(1-cos) - a.Y*sin, a.Y*a.Z*(1-cos) + a.X*sin, a.Z*a.Z + (1-a.Z*a.Z)*cos, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Multiply returns the product m*other, the transformation applying other and then m
+func (m Matrix4) Multiply(other Matrix4) Matrix4 {
+	var result Matrix4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * other[k][j]
+			}
+		}
+	}
+	return result
+}
+
+// Transpose returns the matrix with rows and columns swapped
+func (m Matrix4) Transpose() Matrix4 {
+	var result Matrix4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+	return result
+}
+
+// Inverse returns the inverse matrix, or false when m is singular. It uses Gauss-Jordan
+// elimination with partial pivoting.
+func (m Matrix4) Inverse() (Matrix4, bool) {
+	a, inverse := m, IdentityMatrix()
+	for column := 0; column < 4; column++ {
+		pivot := column
+		for row := column + 1; row < 4; row++ {
+			if math.Abs(a[row][column]) > math.Abs(a[pivot][column]) {
+				pivot = row
+			}
+		}
+		if a[pivot][column] == 0 {
+			return Matrix4{}, false
+		}
+		a[column], a[pivot] = a[pivot], a[column]
+		inverse[column], inverse[pivot] = inverse[pivot], inverse[column]
+
+		scale := 1 / a[column][column]
+		for j := 0; j < 4; j++ {
+			a[column][j] *= scale
+			inverse[column][j] *= scale
+		}
+		for row := 0; row < 4; row++ {
+			if row == column || a[row][column] == 0 {
+				continue
+			}
+			factor := a[row][column]
+			for j := 0; j < 4; j++ {
+				a[row][j] -= factor * a[column][j]
+				inverse[row][j] -= factor * inverse[column][j]
+			}
+		}
+	}
+	return inverse, true
+}
+
+// IsIdentity reports whether m is exactly the identity
+func (m Matrix4) IsIdentity() bool {
+	return m == IdentityMatrix()
+}
+
+// TransformPoint applies the transformation to a point, dividing by w for projective matrices
+func (m Matrix4) TransformPoint(p Vec3) Vec3 {
+	x := m[0][0]*p.X + m[0][1]*p.Y + m[0][2]*p.Z + m[0][3]
+	y := m[1][0]*p.X + m[1][1]*p.Y + m[1][2]*p.Z + m[1][3]
+	z := m[2][0]*p.X + m[2][1]*p.Y + m[2][2]*p.Z + m[2][3]
+	w := m[3][0]*p.X + m[3][1]*p.Y + m[3][2]*p.Z + m[3][3]
+	if w != 1 && w != 0 {
+		return NewVec3(x/w, y/w, z/w)
+	}
+	return NewVec3(x, y, z)
+}
+
+// TransformVector applies the transformation to a direction, ignoring translation
+func (m Matrix4) TransformVector(v Vec3) Vec3 {
+	return NewVec3(
+		m[0][0]*v.X+m[0][1]*v.Y+m[0][2]*v.Z,
+		m[1][0]*v.X+m[1][1]*v.Y+m[1][2]*v.Z,
+		m[2][0]*v.X+m[2][1]*v.Y+m[2][2]*v.Z,
+	)
+}
+
+// TransformNormal applies the transformation to a surface normal, using the inverse transpose so
+// the normal stays perpendicular to transformed tangents. The result isn't normalized.
+func (m Matrix4) TransformNormal(n Vec3) Vec3 {
+	inverse, ok := m.Inverse()
+	if !ok {
+		return n
+	}
+	return inverse.Transpose().TransformVector(n)
+}
+
+// Determinant3 returns the determinant of the upper-left 3x3 (linear) part: the factor volumes
+// scale by, negative for transformations that mirror
+func (m Matrix4) Determinant3() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// UniformScale returns the scale factor of a similarity transformation (a rotation, possibly
+// mirrored, with the same scale along every axis, plus translation), or false for transformations
+// that scale unevenly or shear
+func (m Matrix4) UniformScale() (float64, bool) {
+	columns := [3]Vec3{
+		NewVec3(m[0][0], m[1][0], m[2][0]),
+		NewVec3(m[0][1], m[1][1], m[2][1]),
+		NewVec3(m[0][2], m[1][2], m[2][2]),
+	}
+	scale := columns[0].Length()
+	if scale == 0 || m[3] != [4]float64{0, 0, 0, 1} {
+		return 0, false
+	}
+	const tolerance = 1e-6
+	for i, column := range columns {
+		if math.Abs(column.Length()-scale) > tolerance*scale {
+			return 0, false
+		}
+		for _, other := range columns[i+1:] {
+			if math.Abs(column.Dot(other)) > tolerance*scale*scale {
+				return 0, false
+			}
+		}
+	}
+	return scale, true
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func vec3Near(a, b Vec3) bool {
+	return a.Subtract(b).Length() < 1e-9
+}
+
+func TestMatrix4_Transforms(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix Matrix4
+		point  Vec3
+		want   Vec3
+	}{
+		{"identity", IdentityMatrix(), NewVec3(1, 2, 3), NewVec3(1, 2, 3)},
+		{"translation", TranslationMatrix(NewVec3(1, -1, 2)), NewVec3(1, 2, 3), NewVec3(2, 1, 5)},
+		{"scaling", ScalingMatrix(NewVec3(2, 3, -1)), NewVec3(1, 2, 3), NewVec3(2, 6, -3)},
+		{"rotation around z", RotationMatrix(math.Pi/2, NewVec3(0, 0, 1)), NewVec3(1, 0, 0), NewVec3(0, 1, 0)},
+		{"rotation around y", RotationMatrix(math.Pi/2, NewVec3(0, 2, 0)), NewVec3(1, 0, 0), NewVec3(0, 0, -1)},
+		{"rotation around a diagonal", RotationMatrix(2*math.Pi/3, NewVec3(1, 1, 1)), NewVec3(1, 0, 0), NewVec3(0, 1, 0)},
+		// Translating then rotating: the right-hand matrix applies first
+		{"composition", RotationMatrix(math.Pi/2, NewVec3(0, 0, 1)).Multiply(TranslationMatrix(NewVec3(1, 0, 0))), NewVec3(0, 0, 0), NewVec3(0, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matrix.TransformPoint(tt.point); !vec3Near(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+			if got := tt.matrix.TransformVector(NewVec3(0, 0, 0)); got != (Vec3{}) {
+				t.Errorf("Vectors shouldn't translate, got %v", got)
+			}
+		})
+	}
+
+	// Rotations around the axes match Vec3.Rotate's
+	for _, axis := range []Vec3{NewVec3(1, 0, 0), NewVec3(0, 1, 0), NewVec3(0, 0, 1)} {
+		rotation := axis.Multiply(0.7)
+		if got, want := RotationMatrix(0.7, axis).TransformVector(NewVec3(0.3, -0.5, 0.8)), NewVec3(0.3, -0.5, 0.8).Rotate(rotation); !vec3Near(got, want) {
+			t.Errorf("Rotation around %v: expected %v like Vec3.Rotate, got %v", axis, want, got)
+		}
+	}
+}
+
+func TestMatrix4_Inverse(t *testing.T) {
+	m := TranslationMatrix(NewVec3(1, 2, 3)).
+		Multiply(RotationMatrix(0.4, NewVec3(1, 2, -1))).
+		Multiply(ScalingMatrix(NewVec3(2, 0.5, 3)))
+	inverse, ok := m.Inverse()
+	if !ok {
+		t.Fatal("Expected an invertible matrix")
+	}
+	product := m.Multiply(inverse)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(product[i][j]-IdentityMatrix()[i][j]) > 1e-12 {
+				t.Fatalf("Expected m*inverse to be the identity, got %v", product)
+			}
+		}
+	}
+
+	if _, ok := ScalingMatrix(NewVec3(1, 0, 1)).Inverse(); ok {
+		t.Error("Expected a flattening matrix to be singular")
+	}
+}
+
+func TestMatrix4_TransformNormal(t *testing.T) {
+	// Stretching a 45 degree slope along x tilts its normal towards y
+	m := ScalingMatrix(NewVec3(2, 1, 1))
+	tangent := m.TransformVector(NewVec3(1, -1, 0))
+	normal := m.TransformNormal(NewVec3(1, 1, 0))
+	if math.Abs(tangent.Dot(normal)) > 1e-12 {
+		t.Errorf("Expected the normal %v to stay perpendicular to the tangent %v", normal, tangent)
+	}
+}
+
+func TestMatrix4_UniformScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix Matrix4
+		scale  float64
+		ok     bool
+	}{
+		{"identity", IdentityMatrix(), 1, true},
+		{"rotated, scaled and moved", TranslationMatrix(NewVec3(5, 0, 0)).Multiply(RotationMatrix(1, NewVec3(1, 1, 0))).Multiply(ScalingMatrix(NewVec3(3, 3, 3))), 3, true},
+		{"mirrored", ScalingMatrix(NewVec3(-2, 2, 2)), 2, true},
+		{"uneven", ScalingMatrix(NewVec3(1, 2, 1)), 0, false},
+		{"sheared", Matrix4{{1, 1, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}, 0, false},
+	}
+	for _, tt := range tests {
+		scale, ok := tt.matrix.UniformScale()
+		if ok != tt.ok || math.Abs(scale-tt.scale) > 1e-9 {
+			t.Errorf("%s: expected %v, %v; got %v, %v", tt.name, tt.scale, tt.ok, scale, ok)
+		}
+	}
+	if det := ScalingMatrix(NewVec3(-2, 2, 2)).Determinant3(); det != -8 {
+		t.Errorf("Expected determinant -8, got %v", det)
+	}
+}
@@ -38,6 +38,45 @@ func (r *RandomSampler) Get3D() Vec3 {
 	return NewVec3(r.random.Float64(), r.random.Float64(), r.random.Float64())
 }
 
+// SeededSampler is a cheap, allocation-free generator (SplitMix64) for per-pixel seeding
+// Unlike RandomSampler it can be constructed for every pixel and pass without measurable cost
+type SeededSampler struct {
+	state uint64
+}
+
+// NewSeededSampler creates a sampler whose sequence is fully determined by seed
+func NewSeededSampler(seed uint64) *SeededSampler {
+	return &SeededSampler{state: seed}
+}
+
+// next advances the SplitMix64 state and returns the mixed output
+func (s *SeededSampler) next() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return MixBits(s.state)
+}
+
+// Get1D returns a deterministic float64 in [0, 1)
+func (s *SeededSampler) Get1D() float64 {
+	return float64(s.next()>>11) / (1 << 53)
+}
+
+// Get2D returns two deterministic float64 values in [0, 1)
+func (s *SeededSampler) Get2D() Vec2 {
+	return NewVec2(s.Get1D(), s.Get1D())
+}
+
+// Get3D returns three deterministic float64 values in [0, 1)
+func (s *SeededSampler) Get3D() Vec3 {
+	return NewVec3(s.Get1D(), s.Get1D(), s.Get1D())
+}
+
+// MixBits is the SplitMix64 finalizer, a fast 64-bit hash used to derive independent seeds
+func MixBits(v uint64) uint64 {
+	v = (v ^ (v >> 30)) * 0xbf58476d1ce4e5b9
+	v = (v ^ (v >> 27)) * 0x94d049bb133111eb
+	return v ^ (v >> 31)
+}
+
 // SampleCosineHemisphere generates a cosine-weighted random direction in hemisphere around normal
 func SampleCosineHemisphere(normal Vec3, sample Vec2) Vec3 {
 	// Generate point in unit disk using uniform random sampling
@@ -77,3 +77,44 @@ func TestRandomCosineDirection_OrthonormalBasis(t *testing.T) {
 		}
 	}
 }
+
+func TestSeededSampler_Deterministic(t *testing.T) {
+	a := NewSeededSampler(1234)
+	b := NewSeededSampler(1234)
+	c := NewSeededSampler(1235)
+
+	differs := false
+	for i := 0; i < 100; i++ {
+		va, vb, vc := a.Get1D(), b.Get1D(), c.Get1D()
+		if va != vb {
+			t.Fatalf("Same seed produced different values at %d: %f vs %f", i, va, vb)
+		}
+		if va < 0 || va >= 1 {
+			t.Fatalf("Value %f outside [0,1)", va)
+		}
+		if va != vc {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("Different seeds produced identical sequences")
+	}
+}
+
+func TestSeededSampler_Uniform(t *testing.T) {
+	sampler := NewSeededSampler(7)
+	const numSamples = 100000
+	const numBins = 10
+
+	bins := make([]int, numBins)
+	for i := 0; i < numSamples; i++ {
+		bins[int(sampler.Get1D()*numBins)]++
+	}
+
+	expected := float64(numSamples) / numBins
+	for i, count := range bins {
+		if math.Abs(float64(count)-expected) > 0.05*expected {
+			t.Errorf("Bin %d has %d samples, expected ~%.0f", i, count, expected)
+		}
+	}
+}
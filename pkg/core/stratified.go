@@ -0,0 +1,77 @@
+package core
+
+import "math"
+
+// CMJSample returns sample s of an N-sample correlated multi-jittered pattern (Kensler 2013)
+// The pattern is stratified on an m×n grid and in both 1D projections (N-rooks), and
+// different pattern seeds produce independent scrambles of the same layout.
+// Samples are returned in a scrambled order, so any subset is still uniformly distributed.
+func CMJSample(s, N int, pattern uint32) Vec2 {
+	if N <= 1 {
+		return NewVec2(cmjRandFloat(0, pattern*0x967a889b), cmjRandFloat(0, pattern*0x368cc8b7))
+	}
+
+	m := int(math.Sqrt(float64(N)))
+	n := (N + m - 1) / m
+
+	idx := cmjPermute(uint32(s), uint32(N), pattern*0x51633e2d)
+	sx := cmjPermute(idx%uint32(m), uint32(m), pattern*0x68bc21eb)
+	sy := cmjPermute(idx/uint32(m), uint32(n), pattern*0x02e5be93)
+	jx := cmjRandFloat(idx, pattern*0x967a889b)
+	jy := cmjRandFloat(idx, pattern*0x368cc8b7)
+
+	x := (float64(sx) + (float64(sy)+jx)/float64(n)) / float64(m)
+	y := (float64(idx) + jy) / float64(N)
+	return NewVec2(x, y)
+}
+
+// cmjPermute computes the i-th element of a pseudo-random permutation of [0, l) selected by p
+func cmjPermute(i, l, p uint32) uint32 {
+	w := l - 1
+	w |= w >> 1
+	w |= w >> 2
+	w |= w >> 4
+	w |= w >> 8
+	w |= w >> 16
+
+	// Cycle-walk until the hashed index falls inside [0, l)
+	for {
+		i ^= p
+		i *= 0xe170893d
+		i ^= p >> 16
+		i ^= (i & w) >> 4
+		i ^= p >> 8
+		i *= 0x0929eb3f
+		i ^= p >> 23
+		i ^= (i & w) >> 1
+		i *= 1 | p>>27
+		i *= 0x6935fa69
+		i ^= (i & w) >> 11
+		i *= 0x74dcb303
+		i ^= (i & w) >> 2
+		i *= 0x9e501cc3
+		i ^= (i & w) >> 2
+		i *= 0xc860a3df
+		i &= w
+		i ^= i >> 5
+		if i < l {
+			break
+		}
+	}
+	return (i + p) % l
+}
+
+// cmjRandFloat hashes (i, p) to a float64 in [0, 1)
+func cmjRandFloat(i, p uint32) float64 {
+	i ^= p
+	i ^= i >> 17
+	i ^= i >> 10
+	i *= 0xb36534e5
+	i ^= i >> 12
+	i ^= i >> 21
+	i *= 0x93fc4795
+	i ^= 0xdf6e307f
+	i ^= i >> 17
+	i *= 1 | p>>18
+	return float64(i) * (1.0 / 4294967808.0)
+}
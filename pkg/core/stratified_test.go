@@ -0,0 +1,78 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCMJSample_Stratification(t *testing.T) {
+	sampleCounts := []int{4, 9, 16, 25, 64}
+
+	for _, N := range sampleCounts {
+		m := int(math.Sqrt(float64(N)))
+		n := N / m
+
+		for pattern := uint32(0); pattern < 8; pattern++ {
+			xStrata := make([]int, N)
+			yStrata := make([]int, N)
+			cells := make([]int, N)
+
+			for s := 0; s < N; s++ {
+				sample := CMJSample(s, N, pattern)
+				if sample.X < 0 || sample.X >= 1 || sample.Y < 0 || sample.Y >= 1 {
+					t.Fatalf("N=%d pattern=%d: sample %v outside [0,1)²", N, pattern, sample)
+				}
+
+				xStrata[int(sample.X*float64(N))]++
+				yStrata[int(sample.Y*float64(N))]++
+				cells[int(sample.Y*float64(n))*m+int(sample.X*float64(m))]++
+			}
+
+			// N-rooks: every 1D stratum and every 2D cell holds exactly one sample
+			for k := 0; k < N; k++ {
+				if xStrata[k] != 1 || yStrata[k] != 1 || cells[k] != 1 {
+					t.Fatalf("N=%d pattern=%d: stratum %d has x=%d y=%d cell=%d samples, expected 1 each",
+						N, pattern, k, xStrata[k], yStrata[k], cells[k])
+				}
+			}
+		}
+	}
+}
+
+func TestCMJSample_PatternsDiffer(t *testing.T) {
+	a := CMJSample(3, 16, 1)
+	b := CMJSample(3, 16, 2)
+	if a == b {
+		t.Errorf("Expected different patterns to produce different samples, both got %v", a)
+	}
+
+	// Same inputs must be deterministic
+	if CMJSample(3, 16, 1) != a {
+		t.Error("CMJSample is not deterministic")
+	}
+}
+
+func TestCMJSample_LowerVarianceThanRandom(t *testing.T) {
+	// Integrate f(x,y) = x*y (exact 0.25) with 16 samples over many patterns
+	// Stratified estimates should have far lower variance than the 1/(144N) of independent sampling
+	const N = 16
+	const trials = 2000
+
+	sumSq := 0.0
+	for trial := 0; trial < trials; trial++ {
+		estimate := 0.0
+		for s := 0; s < N; s++ {
+			sample := CMJSample(s, N, uint32(trial)*7919+1)
+			estimate += sample.X * sample.Y
+		}
+		estimate /= N
+		sumSq += (estimate - 0.25) * (estimate - 0.25)
+	}
+	variance := sumSq / trials
+
+	// Var[xy] = 1/9 - 1/16 = 7/144 for independent uniform samples
+	randomVariance := (7.0 / 144.0) / N
+	if variance > randomVariance/4 {
+		t.Errorf("CMJ variance %g not substantially lower than random variance %g", variance, randomVariance)
+	}
+}
@@ -35,6 +35,11 @@ func (v Vec2) Multiply(scalar float64) Vec2 {
 	return Vec2{v.X * scalar, v.Y * scalar}
 }
 
+// Length returns the length of the Vec2
+func (v Vec2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
 func (v Vec3) String() string {
 	return fmt.Sprintf("{%.3g, %.3g, %.3g}", v.X, v.Y, v.Z)
 }
@@ -135,6 +140,11 @@ func (v Vec3) Luminance() float64 {
 	return 0.2126*v.X + 0.7152*v.Y + 0.0722*v.Z
 }
 
+// MaxComponent returns the largest of the three components
+func (v Vec3) MaxComponent() float64 {
+	return math.Max(v.X, math.Max(v.Y, v.Z))
+}
+
 // IsZero returns true if the vector is zero
 func (v Vec3) IsZero() bool {
 	return v.X == 0 && v.Y == 0 && v.Z == 0
@@ -201,6 +211,18 @@ func (v Vec3) Rotate(rotation Vec3) Vec3 {
 type Ray struct {
 	Origin    Vec3
 	Direction Vec3
+	Channel   int // Color channel the path carries after dispersive refraction (1 R, 2 G, 3 B), 0 = all
+
+	// Rays through the neighboring pixels, for filtering textures over the ray's footprint. Camera
+	// rays have them, and specular bounces carry them on; other rays have none (nil).
+	Differential *RayDifferential
+}
+
+// RayDifferential holds the rays offset by one pixel in x and y from a ray, as origins and
+// directions (see Ray.Differential)
+type RayDifferential struct {
+	RxOrigin, RxDirection Vec3
+	RyOrigin, RyDirection Vec3
 }
 
 // NewRay creates a new ray
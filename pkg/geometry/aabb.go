@@ -41,6 +41,12 @@ func NewAABBFromPoints(points ...core.Vec3) AABB {
 
 // Hit tests if a ray intersects with this AABB using the slab method
 func (aabb AABB) Hit(ray core.Ray, tMin, tMax float64) bool {
+	_, _, hit := aabb.HitInterval(ray, tMin, tMax)
+	return hit
+}
+
+// HitInterval returns the range of ray parameters [tEnter, tExit] inside this AABB, clipped to [tMin, tMax]
+func (aabb AABB) HitInterval(ray core.Ray, tMin, tMax float64) (float64, float64, bool) {
 	for axis := 0; axis < 3; axis++ {
 		var min, max, origin, direction float64
 
@@ -66,7 +72,7 @@ func (aabb AABB) Hit(ray core.Ray, tMin, tMax float64) bool {
 		if math.Abs(direction) < 1e-8 {
 			// Ray is parallel to this axis
 			if origin < min || origin > max {
-				return false // Ray origin outside slab
+				return 0, 0, false // Ray origin outside slab
 			}
 			continue
 		}
@@ -87,11 +93,11 @@ func (aabb AABB) Hit(ray core.Ray, tMin, tMax float64) bool {
 
 		// No intersection if tMin > tMax
 		if tMin > tMax {
-			return false
+			return 0, 0, false
 		}
 	}
 
-	return true
+	return tMin, tMax, true
 }
 
 // Union returns an AABB that bounds both this AABB and another
@@ -114,6 +120,12 @@ func (aabb AABB) Center() core.Vec3 {
 	return aabb.Min.Add(aabb.Max).Multiply(0.5)
 }
 
+// BoundingSphere returns the center and radius of the sphere through the box's corners
+func (aabb AABB) BoundingSphere() (core.Vec3, float64) {
+	center := aabb.Center()
+	return center, aabb.Max.Subtract(center).Length()
+}
+
 // Size returns the size (extent) of the AABB along each axis
 func (aabb AABB) Size() core.Vec3 {
 	return aabb.Max.Subtract(aabb.Min)
@@ -0,0 +1,188 @@
+package geometry
+
+import (
+	"math"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// BatchIntersector is implemented by intersection backends that trace many rays together. Rays that
+// take similar paths (such as a pixel's camera rays) then share the traversal, and each shape is
+// called once per batch rather than once per ray.
+type BatchIntersector interface {
+	Intersector
+
+	// HitMany finds the closest intersection with tMin < t < tMax of each ray, storing it in hits[i]
+	// (nil when ray i misses). The results are exactly those of calling Hit for each ray.
+	HitMany(rays []core.Ray, tMin, tMax float64, hits []*material.SurfaceInteraction)
+}
+
+// BatchShape is implemented by shapes that test a packet of rays in one call
+type BatchShape interface {
+	Shape
+
+	// HitMany tests rays[i] for each i in active, each up to its own closest[i]. Where ray i hits
+	// the shape closer, closest[i] and hits[i] are updated.
+	HitMany(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction)
+}
+
+// HitMany finds the closest intersection of each ray with the intersector's HitMany, or with Hit one
+// ray at a time for backends that don't trace batches
+func HitMany(intersector Intersector, rays []core.Ray, tMin, tMax float64, hits []*material.SurfaceInteraction) {
+	if batch, ok := intersector.(BatchIntersector); ok {
+		batch.HitMany(rays, tMin, tMax, hits)
+		return
+	}
+	for i, ray := range rays {
+		hits[i], _ = intersector.Hit(ray, tMin, tMax)
+	}
+}
+
+// hitShapeMany tests a packet of rays against one shape
+func hitShapeMany(shape Shape, rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	if batch, ok := shape.(BatchShape); ok {
+		core.Count(core.ShapeTests, len(active))
+		batch.HitMany(rays, active, tMin, closest, hits)
+		return
+	}
+	for _, i := range active {
+		if hit, isHit := hitShape(shape, rays[i], tMin, closest[i]); isHit {
+			closest[i] = hit.T
+			hits[i] = hit
+		}
+	}
+}
+
+// HitMany traces a batch of rays through the BVH as a packet (see BatchIntersector)
+func (bvh *BVH) HitMany(rays []core.Ray, tMin, tMax float64, hits []*material.SurfaceInteraction) {
+	buffers := packetBufferPool.Get().(*packetBuffers)
+	defer packetBufferPool.Put(buffers)
+
+	closest := buffers.closest[:0]
+	active := buffers.active[:0]
+	for i := range rays {
+		hits[i] = nil
+		closest = append(closest, tMax)
+		active = append(active, i)
+	}
+	buffers.closest, buffers.active = closest, active
+	bvh.hitPacket(rays, active, tMin, closest, hits)
+}
+
+// packetRay is a ray prepared for slab tests against many boxes
+type packetRay struct {
+	origin   [3]float64
+	invDir   [3]float64
+	parallel [3]bool // Axes the ray runs parallel to, which AABB.HitInterval tests separately
+}
+
+// packetBuffers are the working memory of a packet traversal, reused through packetBufferPool
+type packetBuffers struct {
+	closest []float64
+	active  []int
+	scratch []int       // The active rays of every level of the traversal, one level after another
+	rays    []packetRay // Indexed like the packet's rays
+	before  []float64   // Indexed like the packet's rays: closest before a leaf's shape was tested
+}
+
+var packetBufferPool = sync.Pool{New: func() any { return new(packetBuffers) }}
+
+// hitPacket traces the active rays through the BVH, updating closest and hits where they find
+// closer intersections
+func (bvh *BVH) hitPacket(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	if bvh.Root == nil || len(active) == 0 {
+		return
+	}
+	buffers := packetBufferPool.Get().(*packetBuffers)
+	defer packetBufferPool.Put(buffers)
+
+	// Prepare the rays once for all the box tests, with the arithmetic of AABB.HitInterval
+	if cap(buffers.rays) < len(rays) {
+		buffers.rays = make([]packetRay, len(rays))
+		buffers.before = make([]float64, len(rays))
+	}
+	prepared := buffers.rays[:len(rays)]
+	for _, i := range active {
+		ray := rays[i]
+		r := &prepared[i]
+		r.origin = [3]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z}
+		for axis, d := range [3]float64{ray.Direction.X, ray.Direction.Y, ray.Direction.Z} {
+			r.parallel[axis] = math.Abs(d) < 1e-8
+			r.invDir[axis] = 1.0 / d
+		}
+	}
+
+	scratch := buffers.scratch[:0]
+	bvh.hitPacketNode(bvh.Root, rays, prepared, active, tMin, closest, hits, &scratch, buffers.before[:len(rays)])
+	buffers.scratch = scratch
+}
+
+// hitPacketNode traces the active rays through a node. The rays visit the children in the same
+// order, and the shapes with the same tMax, as Hit would use for each ray alone, so the results
+// are identical.
+func (bvh *BVH) hitPacketNode(node *BVHNode, rays []core.Ray, prepared []packetRay, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction, scratch *[]int, before []float64) {
+	// Keep the rays that enter the node's box before their closest hit so far
+	start := len(*scratch)
+	box := node.BoundingBox
+	core.Count(core.BVHNodeVisits, len(active))
+	for _, i := range active {
+		if hitBoxPrepared(&box, &prepared[i], tMin, closest[i]) {
+			*scratch = append(*scratch, i)
+		}
+	}
+	inside := (*scratch)[start:]
+
+	switch {
+	case len(inside) == 0:
+	case node.Shapes != nil:
+		for _, shape := range node.Shapes {
+			for _, i := range inside {
+				before[i] = closest[i]
+			}
+			hitShapeMany(shape, rays, inside, tMin, closest, hits)
+			for _, i := range inside {
+				if closest[i] != before[i] {
+					hits[i].Object = shape // As Hit marks them
+				}
+			}
+		}
+	default:
+		if node.Left != nil {
+			bvh.hitPacketNode(node.Left, rays, prepared, inside, tMin, closest, hits, scratch, before)
+		}
+		if node.Right != nil {
+			bvh.hitPacketNode(node.Right, rays, prepared, inside, tMin, closest, hits, scratch, before)
+		}
+	}
+	*scratch = (*scratch)[:start]
+}
+
+// hitBoxPrepared is AABB.Hit for a prepared ray, giving the same answers
+func hitBoxPrepared(box *AABB, r *packetRay, tMin, tMax float64) bool {
+	return slab(box.Min.X, box.Max.X, r, 0, &tMin, &tMax) &&
+		slab(box.Min.Y, box.Max.Y, r, 1, &tMin, &tMax) &&
+		slab(box.Min.Z, box.Max.Z, r, 2, &tMin, &tMax)
+}
+
+// slab narrows [tMin, tMax] to the ray's interval between two planes along an axis, reporting
+// whether anything is left
+func slab(lo, hi float64, r *packetRay, axis int, tMin, tMax *float64) bool {
+	origin := r.origin[axis]
+	if r.parallel[axis] {
+		return origin >= lo && origin <= hi
+	}
+	t1 := (lo - origin) * r.invDir[axis]
+	t2 := (hi - origin) * r.invDir[axis]
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+	if t1 > *tMin {
+		*tMin = t1
+	}
+	if t2 < *tMax {
+		*tMax = t2
+	}
+	return *tMin <= *tMax
+}
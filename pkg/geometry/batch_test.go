@@ -0,0 +1,118 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// batchTestShapes mixes shapes with and without HitMany, including a mesh with its own BVH
+func batchTestShapes(sampler core.Sampler) []Shape {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	randomPoint := func() core.Vec3 {
+		return core.NewVec3(sampler.Get1D()*10-5, sampler.Get1D()*10-5, sampler.Get1D()*10-5)
+	}
+	var shapes []Shape
+	for i := 0; i < 40; i++ {
+		shapes = append(shapes, NewSphere(randomPoint(), 0.2+sampler.Get1D()*0.5, mat))
+		a := randomPoint()
+		shapes = append(shapes, NewTriangle(a, a.Add(core.NewVec3(1, 0, 0)), a.Add(core.NewVec3(0, 1, 0.5)), mat))
+	}
+	shapes = append(shapes, NewQuad(core.NewVec3(-5, -5, -6), core.NewVec3(10, 0, 0), core.NewVec3(0, 10, 0), mat))
+	shapes = append(shapes, NewAxisAlignedBox(core.NewVec3(1, 1, 1), core.NewVec3(0.5, 0.5, 0.5), mat))
+
+	vertices := []core.Vec3{randomPoint(), randomPoint(), randomPoint(), randomPoint()}
+	shapes = append(shapes, NewTriangleMesh(vertices, []int{0, 1, 2, 0, 2, 3, 0, 3, 1}, mat, nil))
+	return shapes
+}
+
+func TestBVH_HitManyMatchesHit(t *testing.T) {
+	sampler := core.NewSeededSampler(5)
+	shapes := batchTestShapes(sampler)
+	bvh := NewBVH(shapes)
+	isShape := func(object any) bool {
+		for _, shape := range shapes {
+			if object == shape {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Coherent packets (like a pixel's camera rays) and incoherent ones
+	origin := core.NewVec3(0, 0, 8)
+	for packet := 0; packet < 50; packet++ {
+		rays := make([]core.Ray, 16)
+		center := core.NewVec3(sampler.Get1D()-0.5, sampler.Get1D()-0.5, -1)
+		for i := range rays {
+			if packet%2 == 0 {
+				jitter := core.NewVec3(sampler.Get1D()-0.5, sampler.Get1D()-0.5, 0).Multiply(0.02)
+				rays[i] = core.NewRay(origin, center.Add(jitter).Normalize())
+			} else {
+				rays[i] = core.NewRay(origin, core.SampleOnUnitSphere(sampler.Get2D()))
+			}
+		}
+
+		hits := make([]*material.SurfaceInteraction, len(rays))
+		bvh.HitMany(rays, 0.001, math.Inf(1), hits)
+		for i, ray := range rays {
+			hit, isHit := bvh.Hit(ray, 0.001, math.Inf(1))
+			if isHit != (hits[i] != nil) {
+				t.Fatalf("Packet %d ray %d: Hit found %v, HitMany %v", packet, i, isHit, hits[i] != nil)
+			}
+			if isHit && (hit.T != hits[i].T || hit.Point != hits[i].Point || hit.Normal != hits[i].Normal) {
+				t.Fatalf("Packet %d ray %d: Hit at t=%v, HitMany at t=%v", packet, i, hit.T, hits[i].T)
+			}
+			// Both mark the hit with the shape the BVH holds, the mesh rather than its triangle
+			if isHit && (hit.Object != hits[i].Object || !isShape(hit.Object)) {
+				t.Fatalf("Packet %d ray %d: Hit marked %T, HitMany %T", packet, i, hit.Object, hits[i].Object)
+			}
+		}
+	}
+}
+
+func TestHitMany_RespectsTMax(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	bvh := NewBVH([]Shape{NewSphere(core.NewVec3(0, 0, -5), 1, mat)})
+	rays := []core.Ray{
+		core.NewRay(core.Vec3{}, core.NewVec3(0, 0, -1)),
+		core.NewRay(core.Vec3{}, core.NewVec3(0, 1, 0)),
+	}
+
+	hits := make([]*material.SurfaceInteraction, 2)
+	HitMany(bvh, rays, 0.001, math.Inf(1), hits)
+	if hits[0] == nil || math.Abs(hits[0].T-4) > 1e-9 || hits[1] != nil {
+		t.Errorf("Expected the first ray to hit at t=4 and the second to miss, got %v, %v", hits[0], hits[1])
+	}
+
+	HitMany(bvh, rays, 0.001, 3, hits)
+	if hits[0] != nil {
+		t.Errorf("Expected no hit before tMax=3, got t=%v", hits[0].T)
+	}
+}
+
+func BenchmarkBVH_HitMany(b *testing.B) {
+	sampler := core.NewSeededSampler(5)
+	bvh := NewBVH(batchTestShapes(sampler))
+	rays := make([]core.Ray, 8)
+	for i := range rays {
+		jitter := core.NewVec3(sampler.Get1D()-0.5, sampler.Get1D()-0.5, 0).Multiply(0.02)
+		rays[i] = core.NewRay(core.NewVec3(0, 0, 8), core.NewVec3(0.1, 0.1, -1).Add(jitter).Normalize())
+	}
+	hits := make([]*material.SurfaceInteraction, len(rays))
+
+	b.Run("Hit", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i, ray := range rays {
+				hits[i], _ = bvh.Hit(ray, 0.001, math.Inf(1))
+			}
+		}
+	})
+	b.Run("HitMany", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			bvh.HitMany(rays, 0.001, math.Inf(1), hits)
+		}
+	})
+}
@@ -139,6 +139,11 @@ func (b *Box) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteractio
 	return closestHit, closestHit != nil
 }
 
+// Faces returns the box's six faces, their normals pointing out of the box
+func (b *Box) Faces() []*Quad {
+	return b.faces[:]
+}
+
 // BoundingBox returns the axis-aligned bounding box for this box
 func (b *Box) BoundingBox() AABB {
 	return b.bbox
@@ -1,7 +1,7 @@
 package geometry
 
 import (
-	"sort"
+	"math"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
@@ -20,163 +20,407 @@ type BVH struct {
 	Root   *BVHNode
 	Center core.Vec3 // Precomputed finite scene center for infinite light calculations
 	Radius float64   // Precomputed world radius for infinite light PDF calculations
+
+	options BVHOptions // How it was built, for the subtrees Insert rebuilds
+}
+
+// BVHOptions controls how a BVH is built
+type BVHOptions struct {
+	// SpatialSplits lets the builder split nodes with a plane through the shapes (SBVH), putting a
+	// reference to each straddling shape on both sides. It costs build time and memory, but helps
+	// scenes of long or unevenly sized triangles whose bounding boxes overlap a lot.
+	SpatialSplits bool
 }
 
 // NewBVH constructs a BVH from a slice of shapes
 func NewBVH(shapes []Shape) *BVH {
+	return NewBVHWithOptions(shapes, BVHOptions{})
+}
+
+// NewBVHWithOptions constructs a BVH from a slice of shapes using the surface area heuristic
+func NewBVHWithOptions(shapes []Shape, options BVHOptions) *BVH {
 	if len(shapes) == 0 {
-		return &BVH{Root: nil, Center: core.Vec3{}, Radius: 0}
+		return &BVH{Root: nil, Center: core.Vec3{}, Radius: 0, options: options}
 	}
 
-	// Make a copy of the shapes slice to avoid modifying the original
-	// This is crucial for thread safety when multiple workers build BVHs concurrently
-	shapesCopy := make([]Shape, len(shapes))
-	copy(shapesCopy, shapes)
+	// Build from references rather than the shapes slice itself, so the caller's slice is never
+	// modified. This is crucial for thread safety when multiple workers build BVHs concurrently.
+	refs := make([]bvhRef, len(shapes))
+	for i, shape := range shapes {
+		refs[i] = bvhRef{shape: shape, bounds: shape.BoundingBox()}
+	}
 
-	root := buildBVH(shapesCopy, 0)
+	builder := &bvhBuilder{spatialSplits: options.SpatialSplits}
+	bounds := refBounds(refs)
+	builder.rootArea = bounds.SurfaceArea()
+	root := builder.build(refs, bounds, 0)
 
 	// Use the root BVH node's bounding box for world bounds (no need to recalculate)
-	var worldCenter core.Vec3
-	var worldRadius float64
-	if root != nil {
-		worldCenter = root.BoundingBox.Center()
-		worldRadius = root.BoundingBox.Max.Subtract(worldCenter).Length()
-	} else {
-		// Empty scene fallback
-		worldCenter = core.Vec3{}
-		worldRadius = 100.0
-	}
+	worldCenter, worldRadius := root.BoundingBox.BoundingSphere()
 
 	return &BVH{
-		Root:   root,
-		Center: worldCenter,
-		Radius: worldRadius,
-	}
-}
-
-// Leaf threshold: if we have this many or fewer shapes, store them in a leaf node
-const leafThreshold = 8
-
-// buildBVH recursively builds the BVH using fast median splitting
-// This approach avoids the expensive O(n² log n) sorting bottleneck by using
-// simple median splits along the longest axis, providing ~7-8x speedup over
-// the previous sorting-based approach while maintaining good ray intersection performance.
-func buildBVH(shapes []Shape, depth int) *BVHNode {
-	// Calculate bounding box for all shapes
-	var boundingBox AABB
-	if len(shapes) > 0 {
-		boundingBox = shapes[0].BoundingBox()
-		for i := 1; i < len(shapes); i++ {
-			boundingBox = boundingBox.Union(shapes[i].BoundingBox())
-		}
+		Root:    root,
+		Center:  worldCenter,
+		Radius:  worldRadius,
+		options: options,
 	}
+}
 
-	// Base case: few shapes - create leaf node with all shapes
-	if len(shapes) <= leafThreshold {
-		return &BVHNode{
-			BoundingBox: boundingBox,
-			Shapes:      shapes,
-		}
+const (
+	// Leaf threshold: if we have this many or fewer shapes, store them in a leaf node
+	leafThreshold = 8
+
+	sahBins          = 16  // Candidate split planes per axis are the boundaries between this many bins
+	sahTraversalCost = 1.0 // Cost of visiting a node, relative to intersecting one shape
+	maxBVHDepth      = 64  // Nodes this deep become leaves whatever their size
+
+	// spatialSplitAlpha is how much the children of the best object split must overlap, as a fraction
+	// of the root's surface area, before spatial splits are tried. Small overlaps aren't worth the
+	// duplicated references.
+	spatialSplitAlpha = 1e-5
+)
+
+// bvhRef is a shape being placed in the BVH. With spatial splits a shape can have several references,
+// each bounding only the part of the shape on its side of the split planes above it.
+type bvhRef struct {
+	shape  Shape
+	bounds AABB
+}
+
+// bvhBin accumulates the references falling in one bin of a split search
+type bvhBin struct {
+	bounds AABB
+	filled bool // Whether anything has been added to bounds
+	count  int  // References in the bin (object splits) or starting in it (spatial splits)
+	exits  int  // References ending in the bin (spatial splits only)
+}
+
+// add grows the bin's bounds to include box
+func (bin *bvhBin) add(box AABB) {
+	if bin.filled {
+		bin.bounds = bin.bounds.Union(box)
+	} else {
+		bin.bounds, bin.filled = box, true
 	}
+}
 
-	// Find best split using simplified binned approach (much faster than sorting)
-	bestAxis, splitPos := findBestSplitSimple(shapes, boundingBox)
+// bvhSplit is a candidate split: references go left if they're in bins up to and including bin
+type bvhSplit struct {
+	axis    int
+	bin     int
+	cost    float64
+	spatial bool
+	left    AABB
+	right   AABB
+}
 
-	// If we couldn't find a good split, create a leaf
-	if bestAxis == -1 {
-		return &BVHNode{
-			BoundingBox: boundingBox,
-			Shapes:      shapes,
-		}
+// bvhBuilder builds BVH nodes top down, choosing each split by the surface area heuristic
+type bvhBuilder struct {
+	spatialSplits bool
+	rootArea      float64
+}
+
+// build creates the node for refs, whose union is bounds
+func (b *bvhBuilder) build(refs []bvhRef, bounds AABB, depth int) *BVHNode {
+	if len(refs) <= leafThreshold || depth >= maxBVHDepth {
+		return newLeafNode(refs, bounds)
+	}
+
+	var centroids bvhBin
+	for _, ref := range refs {
+		centroids.add(NewAABBFromPoints(ref.bounds.Center()))
 	}
+	centroidBounds := centroids.bounds
 
-	// Partition shapes based on the best split
-	leftShapes, rightShapes := partitionShapesSimple(shapes, bestAxis, splitPos)
+	var left, right []bvhRef
+	split, found := findObjectSplit(refs, bounds, centroidBounds)
+	if b.spatialSplits && (!found || overlapArea(split.left, split.right) > spatialSplitAlpha*b.rootArea) {
+		if spatial, ok := findSpatialSplit(refs, bounds); ok && (!found || spatial.cost < split.cost) {
+			left, right = partitionSpatial(refs, bounds, spatial)
 
-	// Ensure we don't create empty partitions
-	if len(leftShapes) == 0 || len(rightShapes) == 0 {
-		return &BVHNode{
-			BoundingBox: boundingBox,
-			Shapes:      shapes,
+			// A split duplicating every reference makes no progress
+			if len(left) == len(refs) && len(right) == len(refs) {
+				left, right = nil, nil
+			}
 		}
 	}
+	if left == nil && found {
+		left, right = partitionObjects(refs, centroidBounds, split)
+	}
+
+	// Without a split (all centroids coincide) the shapes stay together in an oversized leaf
+	if len(left) == 0 || len(right) == 0 {
+		return newLeafNode(refs, bounds)
+	}
 
 	return &BVHNode{
-		BoundingBox: boundingBox,
-		Left:        buildBVH(leftShapes, depth+1),
-		Right:       buildBVH(rightShapes, depth+1),
+		BoundingBox: bounds,
+		Left:        b.build(left, refBounds(left), depth+1),
+		Right:       b.build(right, refBounds(right), depth+1),
 	}
 }
 
-// findBestSplitSimple finds the best axis and split position using simple binned median
-func findBestSplitSimple(shapes []Shape, boundingBox AABB) (bestAxis int, splitPos float64) {
-	bestAxis = boundingBox.LongestAxis()
+// findObjectSplit bins the references by centroid along each axis and returns the cheapest split
+// between bins. The split is forced: nodes above the leaf threshold are split even when the
+// heuristic would rather keep them whole, so leaves stay small.
+func findObjectSplit(refs []bvhRef, bounds, centroidBounds AABB) (bvhSplit, bool) {
+	best := bvhSplit{cost: math.Inf(1)}
+	found := false
+	for axis := 0; axis < 3; axis++ {
+		lo, hi := axisValue(centroidBounds.Min, axis), axisValue(centroidBounds.Max, axis)
+		if hi <= lo {
+			continue
+		}
+
+		var bins [sahBins]bvhBin
+		for _, ref := range refs {
+			bin := &bins[binIndex(axisValue(ref.bounds.Center(), axis), lo, hi)]
+			bin.add(ref.bounds)
+			bin.count++
+		}
 
-	// Get the extent along the best axis
-	var minVal, maxVal float64
-	switch bestAxis {
-	case 0:
-		minVal, maxVal = boundingBox.Min.X, boundingBox.Max.X
-	case 1:
-		minVal, maxVal = boundingBox.Min.Y, boundingBox.Max.Y
-	case 2:
-		minVal, maxVal = boundingBox.Min.Z, boundingBox.Max.Z
+		if split, ok := bestBinSplit(bins[:], axis, bounds, false); ok && split.cost < best.cost {
+			best, found = split, true
+		}
 	}
+	return best, found
+}
 
-	// Skip if no extent along this axis
-	if maxVal <= minVal {
-		return -1, 0
-	}
+// findSpatialSplit bins the references' clipped extents along each axis of the node bounds and
+// returns the cheapest split plane, references straddling it counting on both sides
+func findSpatialSplit(refs []bvhRef, bounds AABB) (bvhSplit, bool) {
+	best := bvhSplit{cost: math.Inf(1)}
+	found := false
+	for axis := 0; axis < 3; axis++ {
+		lo, hi := axisValue(bounds.Min, axis), axisValue(bounds.Max, axis)
+		if hi <= lo {
+			continue
+		}
+		width := (hi - lo) / sahBins
+
+		var bins [sahBins]bvhBin
+		for _, ref := range refs {
+			first := binIndex(axisValue(ref.bounds.Min, axis), lo, hi)
+			last := binIndex(axisValue(ref.bounds.Max, axis), lo, hi)
+			for i := first; i <= last; i++ {
+				slabMin, slabMax := lo+float64(i)*width, lo+float64(i+1)*width
+				if clipped, ok := clipRef(ref, axis, slabMin, slabMax); ok {
+					bins[i].add(clipped)
+				}
+			}
+			bins[first].count++
+			bins[last].exits++
+		}
 
-	// Use simple median split
-	splitPos = (minVal + maxVal) * 0.5
-	return bestAxis, splitPos
+		if split, ok := bestBinSplit(bins[:], axis, bounds, true); ok && split.cost < best.cost {
+			best, found = split, true
+		}
+	}
+	return best, found
 }
 
-// partitionShapesSimple partitions shapes based on the chosen axis and split position
-func partitionShapesSimple(shapes []Shape, axis int, splitPos float64) ([]Shape, []Shape) {
-	var leftShapes, rightShapes []Shape
+// bestBinSplit sweeps the bins from both ends and returns the split between bins with the lowest
+// SAH cost. For spatial splits a bin counts towards the left child when references start in it
+// and towards the right when they end in it.
+func bestBinSplit(bins []bvhBin, axis int, bounds AABB, spatial bool) (bvhSplit, bool) {
+	n := len(bins)
+	rightBounds := make([]AABB, n)
+	rightCounts := make([]int, n)
+	var acc bvhBin
+	accCount := 0
+	for i := n - 1; i > 0; i-- {
+		if bins[i].filled {
+			acc.add(bins[i].bounds)
+		}
+		if spatial {
+			accCount += bins[i].exits
+		} else {
+			accCount += bins[i].count
+		}
+		rightBounds[i], rightCounts[i] = acc.bounds, accCount
+	}
 
-	for _, shape := range shapes {
-		center := shape.BoundingBox().Center()
-		var centerVal float64
-		switch axis {
-		case 0:
-			centerVal = center.X
-		case 1:
-			centerVal = center.Y
-		case 2:
-			centerVal = center.Z
+	best := bvhSplit{cost: math.Inf(1)}
+	found := false
+	nodeArea := bounds.SurfaceArea()
+	acc, accCount = bvhBin{}, 0
+	for i := 0; i < n-1; i++ {
+		if bins[i].filled {
+			acc.add(bins[i].bounds)
+		}
+		accCount += bins[i].count
+		leftCount, rightCount := accCount, rightCounts[i+1]
+		if leftCount == 0 || rightCount == 0 {
+			continue
 		}
 
-		if centerVal < splitPos {
-			leftShapes = append(leftShapes, shape)
+		cost := sahTraversalCost
+		if nodeArea > 0 {
+			cost += (acc.bounds.SurfaceArea()*float64(leftCount) + rightBounds[i+1].SurfaceArea()*float64(rightCount)) / nodeArea
 		} else {
-			rightShapes = append(rightShapes, shape)
+			cost += float64(leftCount + rightCount)
+		}
+		if cost < best.cost {
+			best = bvhSplit{axis: axis, bin: i, cost: cost, spatial: spatial, left: acc.bounds, right: rightBounds[i+1]}
+			found = true
 		}
 	}
-
-	return leftShapes, rightShapes
+	return best, found
 }
 
-// sortShapesByAxis sorts shapes by their bounding box center along the specified axis
-// This function is kept for compatibility but should no longer be used in the main BVH construction
-func sortShapesByAxis(shapes []Shape, axis int) {
-	sort.Slice(shapes, func(i, j int) bool {
-		centerI := shapes[i].BoundingBox().Center()
-		centerJ := shapes[j].BoundingBox().Center()
+// partitionObjects sends each reference to the side of the split its centroid's bin is on
+func partitionObjects(refs []bvhRef, centroidBounds AABB, split bvhSplit) ([]bvhRef, []bvhRef) {
+	lo, hi := axisValue(centroidBounds.Min, split.axis), axisValue(centroidBounds.Max, split.axis)
+	var left, right []bvhRef
+	for _, ref := range refs {
+		if binIndex(axisValue(ref.bounds.Center(), split.axis), lo, hi) <= split.bin {
+			left = append(left, ref)
+		} else {
+			right = append(right, ref)
+		}
+	}
+	return left, right
+}
 
-		switch axis {
-		case 0:
-			return centerI.X < centerJ.X
-		case 1:
-			return centerI.Y < centerJ.Y
-		case 2:
-			return centerI.Z < centerJ.Z
+// partitionSpatial splits the references at the split plane, clipping the ones straddling it into
+// a reference on each side
+func partitionSpatial(refs []bvhRef, bounds AABB, split bvhSplit) ([]bvhRef, []bvhRef) {
+	lo, hi := axisValue(bounds.Min, split.axis), axisValue(bounds.Max, split.axis)
+	plane := lo + float64(split.bin+1)*(hi-lo)/sahBins
+	var left, right []bvhRef
+	for _, ref := range refs {
+		first := binIndex(axisValue(ref.bounds.Min, split.axis), lo, hi)
+		last := binIndex(axisValue(ref.bounds.Max, split.axis), lo, hi)
+		switch {
+		case last <= split.bin:
+			left = append(left, ref)
+		case first > split.bin:
+			right = append(right, ref)
 		default:
-			return false
+			if clipped, ok := clipRef(ref, split.axis, math.Inf(-1), plane); ok {
+				left = append(left, bvhRef{shape: ref.shape, bounds: clipped})
+			}
+			if clipped, ok := clipRef(ref, split.axis, plane, math.Inf(1)); ok {
+				right = append(right, bvhRef{shape: ref.shape, bounds: clipped})
+			}
+		}
+	}
+	return left, right
+}
+
+// clipRef returns the bounds of the part of a reference between two planes along an axis
+// Triangles are clipped exactly; other shapes just have their bounding box clipped to the slab.
+func clipRef(ref bvhRef, axis int, slabMin, slabMax float64) (AABB, bool) {
+	box := ref.bounds
+	if triangle, ok := ref.shape.(*Triangle); ok {
+		points := clipPolygon([]core.Vec3{triangle.V0, triangle.V1, triangle.V2}, axis, slabMin, slabMax)
+		if len(points) > 0 {
+			box = intersectBounds(box, NewAABBFromPoints(points...))
+		}
+	}
+	box = setAxis(box, axis, math.Max(axisValue(box.Min, axis), slabMin), math.Min(axisValue(box.Max, axis), slabMax))
+	return box, box.IsValid()
+}
+
+// clipPolygon clips a convex polygon to slabMin <= p[axis] <= slabMax (Sutherland-Hodgman)
+func clipPolygon(points []core.Vec3, axis int, slabMin, slabMax float64) []core.Vec3 {
+	points = clipPolygonPlane(points, axis, slabMin, 1)
+	return clipPolygonPlane(points, axis, slabMax, -1)
+}
+
+// clipPolygonPlane keeps the part of a polygon where sign*(p[axis]-plane) >= 0
+func clipPolygonPlane(points []core.Vec3, axis int, plane, sign float64) []core.Vec3 {
+	if math.IsInf(plane, 0) {
+		return points
+	}
+	var clipped []core.Vec3
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		dp := sign * (axisValue(p, axis) - plane)
+		dq := sign * (axisValue(q, axis) - plane)
+		if dp >= 0 {
+			clipped = append(clipped, p)
 		}
-	})
+		if (dp >= 0) != (dq >= 0) {
+			t := dp / (dp - dq)
+			crossing := p.Add(q.Subtract(p).Multiply(t))
+			clipped = append(clipped, setAxisValue(crossing, axis, plane)) // Exactly on the plane
+		}
+	}
+	return clipped
+}
+
+// newLeafNode creates a leaf holding the references' shapes
+func newLeafNode(refs []bvhRef, bounds AABB) *BVHNode {
+	shapes := make([]Shape, len(refs))
+	for i, ref := range refs {
+		shapes[i] = ref.shape
+	}
+	return &BVHNode{BoundingBox: bounds, Shapes: shapes}
+}
+
+// refBounds returns the union of the references' bounds
+func refBounds(refs []bvhRef) AABB {
+	bounds := refs[0].bounds
+	for _, ref := range refs[1:] {
+		bounds = bounds.Union(ref.bounds)
+	}
+	return bounds
+}
+
+// intersectBounds returns the box where two boxes overlap (invalid if they don't)
+func intersectBounds(a, b AABB) AABB {
+	return AABB{
+		Min: core.NewVec3(math.Max(a.Min.X, b.Min.X), math.Max(a.Min.Y, b.Min.Y), math.Max(a.Min.Z, b.Min.Z)),
+		Max: core.NewVec3(math.Min(a.Max.X, b.Max.X), math.Min(a.Max.Y, b.Max.Y), math.Min(a.Max.Z, b.Max.Z)),
+	}
+}
+
+// overlapArea returns the surface area of the box where two boxes overlap, zero if they don't
+func overlapArea(a, b AABB) float64 {
+	overlap := intersectBounds(a, b)
+	if !overlap.IsValid() {
+		return 0
+	}
+	return overlap.SurfaceArea()
+}
+
+// binIndex returns the bin of sahBins equal bins spanning [lo, hi] that value falls in
+func binIndex(value, lo, hi float64) int {
+	bin := int((value - lo) / (hi - lo) * sahBins)
+	return max(0, min(bin, sahBins-1))
+}
+
+// axisValue returns the component of v along an axis (0=X, 1=Y, 2=Z)
+func axisValue(v core.Vec3, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
+}
+
+// setAxisValue returns v with its component along an axis replaced
+func setAxisValue(v core.Vec3, axis int, value float64) core.Vec3 {
+	switch axis {
+	case 0:
+		v.X = value
+	case 1:
+		v.Y = value
+	default:
+		v.Z = value
+	}
+	return v
+}
+
+// setAxis returns the box with its extent along an axis replaced
+func setAxis(box AABB, axis int, lo, hi float64) AABB {
+	box.Min = setAxisValue(box.Min, axis, lo)
+	box.Max = setAxisValue(box.Max, axis, hi)
+	return box
 }
 
 // Hit tests if a ray intersects any shape in the BVH
@@ -190,6 +434,7 @@ func (bvh *BVH) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteract
 // hitNode recursively tests ray intersection with BVH nodes
 func (bvh *BVH) hitNode(node *BVHNode, ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
 	// First check if ray hits the bounding box
+	core.Count(core.BVHNodeVisits, 1)
 	if !node.BoundingBox.Hit(ray, tMin, tMax) {
 		return nil, false
 	}
@@ -197,18 +442,25 @@ func (bvh *BVH) hitNode(node *BVHNode, ray core.Ray, tMin, tMax float64) (*mater
 	// If this is a leaf node, test against all shapes using linear search
 	if node.Shapes != nil {
 		var closestHit *material.SurfaceInteraction
+		var closestShape Shape
 		hitAnything := false
 		closestSoFar := tMax
 
 		// Linear search through all shapes in the leaf
 		for _, shape := range node.Shapes {
-			if hit, isHit := shape.Hit(ray, tMin, closestSoFar); isHit {
+			if hit, isHit := hitShape(shape, ray, tMin, closestSoFar); isHit {
 				hitAnything = true
 				closestSoFar = hit.T
 				closestHit = hit
+				closestShape = shape
 			}
 		}
 
+		// Shapes holding BVHs of their own (meshes) mark their parts' hits first; the scene's
+		// BVH marks them last, with the shape the scene holds
+		if hitAnything {
+			closestHit.Object = closestShape
+		}
 		return closestHit, hitAnything
 	}
 
@@ -238,6 +490,81 @@ func (bvh *BVH) hitNode(node *BVHNode, ray core.Ray, tMin, tMax float64) (*mater
 	return closestHit, hitAnything
 }
 
+// HitAny tests if a ray intersects any shape in the BVH, stopping at the first intersection found
+func (bvh *BVH) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	if bvh.Root == nil {
+		return false
+	}
+	return bvh.hitAnyNode(bvh.Root, ray, tMin, tMax)
+}
+
+// hitAnyNode recursively looks for any intersection below a BVH node
+func (bvh *BVH) hitAnyNode(node *BVHNode, ray core.Ray, tMin, tMax float64) bool {
+	core.Count(core.BVHNodeVisits, 1)
+	if !node.BoundingBox.Hit(ray, tMin, tMax) {
+		return false
+	}
+
+	if node.Shapes != nil {
+		for _, shape := range node.Shapes {
+			if hitShapeAny(shape, ray, tMin, tMax) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return (node.Left != nil && bvh.hitAnyNode(node.Left, ray, tMin, tMax)) ||
+		(node.Right != nil && bvh.hitAnyNode(node.Right, ray, tMin, tMax))
+}
+
+// hitShapeAny tests a shadow ray against one shape, with its occlusion test if it has one
+// Occlusion tests don't look at materials, so alpha-masked shapes are tested with hitShape.
+func hitShapeAny(shape Shape, ray core.Ray, tMin, tMax float64) bool {
+	if occluder, ok := shape.(Occluder); ok && !alphaMasked(shape) {
+		core.Count(core.ShapeTests, 1)
+		return occluder.HitAny(ray, tMin, tMax)
+	}
+	_, isHit := hitShape(shape, ray, tMin, tMax)
+	return isHit
+}
+
+// maxCutoutLayers bounds the transparent hits on one shape that a ray passes through
+const maxCutoutLayers = 64
+
+// hitShape tests a ray against one shape, continuing past hits on the transparent parts of
+// alpha-masked materials (see material.AlphaMasked)
+func hitShape(shape Shape, ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	core.Count(core.ShapeTests, 1)
+	for layer := 0; layer < maxCutoutLayers; layer++ {
+		hit, isHit := shape.Hit(ray, tMin, tMax)
+		if !isHit || material.IsOpaque(hit) {
+			return hit, isHit
+		}
+		tMin = math.Nextafter(hit.T, math.Inf(1))
+	}
+	return nil, false
+}
+
+// alphaMasked reports whether a shape may have holes its occlusion test misses: shapes say so
+// with MaskedShape, and those that don't are assumed to
+func alphaMasked(shape Shape) bool {
+	masked, ok := shape.(MaskedShape)
+	return !ok || masked.AlphaMasked()
+}
+
+// hasAlphaMask reports whether a material cuts holes in its surface
+func hasAlphaMask(mat material.Material) bool {
+	_, masked := mat.(material.AlphaMasked)
+	return masked
+}
+
+// AlphaMasked implements the MaskedShape interface: shadow rays test a BVH's shapes one by one,
+// each by its own occlusion test or Hit
+func (bvh *BVH) AlphaMasked() bool {
+	return false
+}
+
 // BoundingBox implements the Shape interface - returns the overall bounding box of the BVH
 func (bvh *BVH) BoundingBox() AABB {
 	if bvh.Root == nil {
@@ -247,45 +574,59 @@ func (bvh *BVH) BoundingBox() AABB {
 }
 
 // getStats returns statistics about the BVH structure
-func (bvh *BVH) getStats() bvhStats {
+func (bvh *BVH) Stats() BVHStats {
 	if bvh.Root == nil {
-		return bvhStats{}
+		return BVHStats{}
 	}
 
-	stats := bvhStats{}
+	stats := BVHStats{}
 	bvh.collectStats(bvh.Root, 0, &stats)
 
 	// Calculate average depth after collecting all data
-	if stats.leafNodes > 0 {
-		stats.avgDepth = stats.avgDepth / float64(stats.leafNodes)
+	if stats.LeafNodes > 0 {
+		stats.AvgDepth = stats.AvgDepth / float64(stats.LeafNodes)
+	}
+
+	// Areas are relative to the root, making them the probability a random ray through the scene
+	// visits the node
+	if rootArea := bvh.Root.BoundingBox.SurfaceArea(); rootArea > 0 {
+		stats.Overlap /= rootArea
+		stats.SAHCost /= rootArea
 	}
 
 	return stats
 }
 
-// bvhStats contains statistics about the BVH structure
-type bvhStats struct {
-	totalNodes  int
-	leafNodes   int
-	maxDepth    int
-	avgDepth    float64
-	totalShapes int
+// BVHStats contains statistics about the BVH structure
+type BVHStats struct {
+	TotalNodes  int     `json:"totalNodes"`
+	LeafNodes   int     `json:"leafNodes"`
+	MaxDepth    int     `json:"maxDepth"`
+	AvgDepth    float64 `json:"avgDepth"`    // Average depth of leaf nodes
+	TotalShapes int     `json:"totalShapes"` // Shapes stored in leaves (with spatial splits, a shape can be in several)
+	Overlap     float64 `json:"overlap"`     // Surface area of sibling overlaps, relative to the root
+	SAHCost     float64 `json:"sahCost"`     // Expected traversal and intersection cost of a ray through the root
 }
 
 // collectStats recursively collects statistics about the BVH
-func (bvh *BVH) collectStats(node *BVHNode, depth int, stats *bvhStats) {
-	stats.totalNodes++
+func (bvh *BVH) collectStats(node *BVHNode, depth int, stats *BVHStats) {
+	stats.TotalNodes++
 
-	if depth > stats.maxDepth {
-		stats.maxDepth = depth
+	if depth > stats.MaxDepth {
+		stats.MaxDepth = depth
 	}
 
 	if node.Shapes != nil {
 		// Leaf node
-		stats.leafNodes++
-		stats.totalShapes += len(node.Shapes)
-		stats.avgDepth += float64(depth) // Accumulate depth for average calculation
+		stats.LeafNodes++
+		stats.TotalShapes += len(node.Shapes)
+		stats.AvgDepth += float64(depth) // Accumulate depth for average calculation
+		stats.SAHCost += node.BoundingBox.SurfaceArea() * float64(len(node.Shapes))
 	} else {
+		stats.SAHCost += node.BoundingBox.SurfaceArea() * sahTraversalCost
+		if node.Left != nil && node.Right != nil {
+			stats.Overlap += overlapArea(node.Left.BoundingBox, node.Right.BoundingBox)
+		}
 		// Internal node
 		if node.Left != nil {
 			bvh.collectStats(node.Left, depth+1, stats)
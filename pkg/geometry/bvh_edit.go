@@ -0,0 +1,121 @@
+package geometry
+
+import "github.com/df07/go-progressive-raytracer/pkg/core"
+
+// Insert adds a shape to the BVH, changing only the nodes on its way down: it goes to the child
+// whose bounds grow least, the leaf it reaches is rebuilt by the surface area heuristic once it
+// outgrows the leaf threshold, and the bounds above it are refit. Trees edited a lot are slower
+// than ones built anew. Neither Insert nor Remove is safe while rays traverse the BVH.
+func (bvh *BVH) Insert(shape Shape) {
+	ref := bvhRef{shape: shape, bounds: shape.BoundingBox()}
+	if bvh.Root == nil {
+		bvh.Root = newLeafNode([]bvhRef{ref}, ref.bounds)
+	} else {
+		bvh.Root = bvh.insert(bvh.Root, ref, 0)
+	}
+	bvh.updateWorldBounds()
+}
+
+// insert adds a reference below node and returns the node that replaces it
+func (bvh *BVH) insert(node *BVHNode, ref bvhRef, depth int) *BVHNode {
+	bounds := node.BoundingBox.Union(ref.bounds)
+	if node.Shapes == nil {
+		if boundsGrowth(node.Left.BoundingBox, ref.bounds) <= boundsGrowth(node.Right.BoundingBox, ref.bounds) {
+			node.Left = bvh.insert(node.Left, ref, depth+1)
+		} else {
+			node.Right = bvh.insert(node.Right, ref, depth+1)
+		}
+		node.BoundingBox = bounds
+		return node
+	}
+
+	// The leaf's shapes are placed again by their whole bounds, even those spatial splits clipped
+	refs := make([]bvhRef, 0, len(node.Shapes)+1)
+	for _, shape := range node.Shapes {
+		refs = append(refs, bvhRef{shape: shape, bounds: intersectBounds(shape.BoundingBox(), node.BoundingBox)})
+	}
+	refs = append(refs, ref)
+	builder := &bvhBuilder{spatialSplits: bvh.options.SpatialSplits, rootArea: bvh.Root.BoundingBox.Union(ref.bounds).SurfaceArea()}
+	return builder.build(refs, bounds, depth)
+}
+
+// Remove takes a shape out of the BVH, with all the references spatial splits made to it, and
+// reports whether it was there. Only subtrees overlapping the shape's bounds are searched, so the
+// shape must still have the bounds it was inserted with. Nodes left empty are dropped, their
+// siblings taking their parents' places, and the bounds above are refit.
+func (bvh *BVH) Remove(shape Shape) bool {
+	if bvh.Root == nil {
+		return false
+	}
+	box := shape.BoundingBox()
+	box = box.Expand(1e-9 * (1 + box.Size().MaxComponent())) // Clipped references may round outside
+	root, removed := bvh.remove(bvh.Root, shape, box)
+	if removed {
+		bvh.Root = root
+		bvh.updateWorldBounds()
+	}
+	return removed
+}
+
+// remove takes a shape out of the subtree at node and returns the node that replaces it, nil if
+// the subtree is left empty
+func (bvh *BVH) remove(node *BVHNode, shape Shape, box AABB) (*BVHNode, bool) {
+	if !intersectBounds(node.BoundingBox, box).IsValid() {
+		return node, false
+	}
+
+	if node.Shapes != nil {
+		kept := make([]Shape, 0, len(node.Shapes))
+		bounds := AABB{}
+		for _, s := range node.Shapes {
+			if s == shape {
+				continue
+			}
+			if len(kept) == 0 {
+				bounds = s.BoundingBox()
+			} else {
+				bounds = bounds.Union(s.BoundingBox())
+			}
+			kept = append(kept, s)
+		}
+		if len(kept) == len(node.Shapes) {
+			return node, false
+		}
+		if len(kept) == 0 {
+			return nil, true
+		}
+		// The remaining references lie within the old bounds, however spatial splits clipped them
+		node.Shapes = kept
+		node.BoundingBox = intersectBounds(bounds, node.BoundingBox)
+		return node, true
+	}
+
+	left, removedLeft := bvh.remove(node.Left, shape, box)
+	right, removedRight := bvh.remove(node.Right, shape, box)
+	if !removedLeft && !removedRight {
+		return node, false
+	}
+	if left == nil {
+		return right, true
+	}
+	if right == nil {
+		return left, true
+	}
+	node.Left, node.Right = left, right
+	node.BoundingBox = left.BoundingBox.Union(right.BoundingBox)
+	return node, true
+}
+
+// updateWorldBounds recomputes the bounding sphere of the shapes after an edit
+func (bvh *BVH) updateWorldBounds() {
+	if bvh.Root == nil {
+		bvh.Center, bvh.Radius = core.Vec3{}, 0
+		return
+	}
+	bvh.Center, bvh.Radius = bvh.Root.BoundingBox.BoundingSphere()
+}
+
+// boundsGrowth returns how much a box's surface area grows to take in another
+func boundsGrowth(box, other AABB) float64 {
+	return box.Union(other).SurfaceArea() - box.SurfaceArea()
+}
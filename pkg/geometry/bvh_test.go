@@ -37,14 +37,14 @@ func TestBVH_LeafThresholdBoundary(t *testing.T) {
 	}
 
 	bvh := NewBVH(shapes)
-	stats := bvh.getStats()
+	stats := bvh.Stats()
 
 	// Should have exactly 1 node (single leaf)
-	if stats.totalNodes != 1 {
-		t.Errorf("Expected 1 node for %d shapes, got %d", len(shapes), stats.totalNodes)
+	if stats.TotalNodes != 1 {
+		t.Errorf("Expected 1 node for %d shapes, got %d", len(shapes), stats.TotalNodes)
 	}
-	if stats.leafNodes != 1 {
-		t.Errorf("Expected 1 leaf node for %d shapes, got %d", len(shapes), stats.leafNodes)
+	if stats.LeafNodes != 1 {
+		t.Errorf("Expected 1 leaf node for %d shapes, got %d", len(shapes), stats.LeafNodes)
 	}
 
 	// Test with leafThreshold + 1 shapes - should split
@@ -56,14 +56,14 @@ func TestBVH_LeafThresholdBoundary(t *testing.T) {
 	})
 
 	bvh = NewBVH(shapes)
-	stats = bvh.getStats()
+	stats = bvh.Stats()
 
 	// Should have more than 1 node (split occurred)
-	if stats.totalNodes == 1 {
+	if stats.TotalNodes == 1 {
 		t.Errorf("Expected split for %d shapes, but got single node", len(shapes))
 	}
-	if stats.leafNodes < 2 {
-		t.Errorf("Expected at least 2 leaf nodes after split, got %d", stats.leafNodes)
+	if stats.LeafNodes < 2 {
+		t.Errorf("Expected at least 2 leaf nodes after split, got %d", stats.LeafNodes)
 	}
 }
 
@@ -92,13 +92,13 @@ func TestBVH_EmptyAndSingleShape(t *testing.T) {
 	}
 
 	bvh = NewBVH([]Shape{shape})
-	stats := bvh.getStats()
+	stats := bvh.Stats()
 
-	if stats.totalNodes != 1 {
-		t.Errorf("Expected 1 node for single shape, got %d", stats.totalNodes)
+	if stats.TotalNodes != 1 {
+		t.Errorf("Expected 1 node for single shape, got %d", stats.TotalNodes)
 	}
-	if stats.leafNodes != 1 {
-		t.Errorf("Expected 1 leaf node for single shape, got %d", stats.leafNodes)
+	if stats.LeafNodes != 1 {
+		t.Errorf("Expected 1 leaf node for single shape, got %d", stats.LeafNodes)
 	}
 }
 
@@ -186,27 +186,27 @@ func TestBVH_StatsCollection(t *testing.T) {
 	}
 
 	bvh := NewBVH(shapes)
-	stats := bvh.getStats()
+	stats := bvh.Stats()
 
 	// Verify basic properties
-	if stats.totalShapes != 20 {
-		t.Errorf("Expected 20 total shapes, got %d", stats.totalShapes)
+	if stats.TotalShapes != 20 {
+		t.Errorf("Expected 20 total shapes, got %d", stats.TotalShapes)
 	}
 
-	if stats.leafNodes == 0 {
+	if stats.LeafNodes == 0 {
 		t.Error("Expected at least one leaf node")
 	}
 
-	if stats.totalNodes < stats.leafNodes {
+	if stats.TotalNodes < stats.LeafNodes {
 		t.Error("Total nodes should be >= leaf nodes")
 	}
 
-	if stats.maxDepth < 0 {
+	if stats.MaxDepth < 0 {
 		t.Error("Max depth should be non-negative")
 	}
 
 	// For 20 shapes with leaf threshold 8, we should have multiple levels
-	if stats.maxDepth == 0 {
+	if stats.MaxDepth == 0 {
 		t.Error("Expected max depth > 0 for 20 shapes")
 	}
 }
@@ -348,3 +348,434 @@ func TestBVHWorldRadius(t *testing.T) {
 			bvh.Radius, expectedRadius, tolerance)
 	}
 }
+
+func TestBVH_HitAny(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+
+	// A row of spheres along X, enough for several levels of internal nodes
+	var shapes []Shape
+	for i := 0; i < 40; i++ {
+		shapes = append(shapes, NewSphere(core.NewVec3(float64(i)*3, 0, 0), 1, mat))
+	}
+	bvh := NewBVH(shapes)
+
+	sampler := core.NewSeededSampler(7)
+	for i := 0; i < 500; i++ {
+		origin := core.NewVec3(sampler.Get1D()*120-3, sampler.Get1D()*6-3, sampler.Get1D()*6-3)
+		direction := core.SampleOnUnitSphere(sampler.Get2D())
+		tMax := sampler.Get1D() * 20
+		ray := core.NewRay(origin, direction)
+
+		_, isHit := bvh.Hit(ray, 0.001, tMax)
+		if anyHit := bvh.HitAny(ray, 0.001, tMax); anyHit != isHit {
+			t.Fatalf("HitAny(%v, tMax=%f) = %v, but Hit reports %v", ray, tMax, anyHit, isHit)
+		}
+	}
+
+	if NewBVH(nil).HitAny(core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0)), 0.001, math.Inf(1)) {
+		t.Error("Empty BVH should never report a hit")
+	}
+}
+
+func TestBVH_HitAnyStopsEarly(t *testing.T) {
+	// Every shape in the ray's path is hit; HitAny only needs to test until the first one
+	tests := 0
+	shapes := make([]Shape, 32)
+	for i := range shapes {
+		shapes[i] = MockShape{
+			boundingBox: NewAABB(core.NewVec3(float64(i), -1, -1), core.NewVec3(float64(i)+1, 1, 1)),
+			hitFn: func(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+				tests++
+				return &material.SurfaceInteraction{T: 1}, true
+			},
+		}
+	}
+	bvh := NewBVH(shapes)
+	ray := core.NewRay(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0))
+
+	if !bvh.HitAny(ray, 0.001, math.Inf(1)) {
+		t.Fatal("Expected HitAny to find a hit")
+	}
+	if tests != 1 {
+		t.Errorf("Expected HitAny to stop after the first hit, tested %d shapes", tests)
+	}
+}
+
+// mockOccluder counts the full and boolean intersection tests made against it, and has no holes
+type mockOccluder struct {
+	MockShape
+	hits, hitAnys *int
+}
+
+func (m mockOccluder) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	*m.hits++
+	return m.MockShape.Hit(ray, tMin, tMax)
+}
+
+func (m mockOccluder) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	*m.hitAnys++
+	return true
+}
+
+func (m mockOccluder) AlphaMasked() bool {
+	return false
+}
+
+func TestBVH_HitAnyUsesOccluder(t *testing.T) {
+	var hits, hitAnys int
+	shape := mockOccluder{
+		MockShape: MockShape{
+			boundingBox: NewAABB(core.NewVec3(-1, -1, -1), core.NewVec3(1, 1, 1)),
+			hitFn: func(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+				return &material.SurfaceInteraction{T: 1}, true
+			},
+		},
+		hits:    &hits,
+		hitAnys: &hitAnys,
+	}
+	bvh := NewBVH([]Shape{shape})
+	if !bvh.HitAny(core.NewRay(core.NewVec3(-5, 0, 0), core.NewVec3(1, 0, 0)), 0.001, math.Inf(1)) {
+		t.Fatal("Expected HitAny to find a hit")
+	}
+	if hits != 0 || hitAnys != 1 {
+		t.Errorf("Expected only the occlusion test, got %d Hit and %d HitAny calls", hits, hitAnys)
+	}
+}
+
+func TestOccluders_MatchHit(t *testing.T) {
+	sampler := core.NewSeededSampler(11)
+	shapes := append(batchTestShapes(sampler),
+		NewDisc(core.NewVec3(0, 0, 0), core.NewVec3(0.3, 1, 0.2), 2, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))))
+
+	for _, shape := range shapes {
+		occluder, ok := shape.(Occluder)
+		if !ok {
+			continue
+		}
+		for i := 0; i < 300; i++ {
+			origin := core.NewVec3(sampler.Get1D()*12-6, sampler.Get1D()*12-6, sampler.Get1D()*12-6)
+			// Aim most rays at the shape so both outcomes are tested
+			target := shape.BoundingBox().Center()
+			direction := target.Subtract(origin).Add(core.SampleOnUnitSphere(sampler.Get2D())).Normalize()
+			if i%4 == 0 {
+				direction = core.SampleOnUnitSphere(sampler.Get2D())
+			}
+			ray := core.NewRay(origin, direction)
+			tMax := sampler.Get1D() * 15
+
+			_, isHit := shape.Hit(ray, 0.001, tMax)
+			if anyHit := occluder.HitAny(ray, 0.001, tMax); anyHit != isHit {
+				t.Fatalf("%T: HitAny(%v, tMax=%f) = %v, but Hit reports %v", shape, ray, tMax, anyHit, isHit)
+			}
+		}
+	}
+}
+
+func TestBVH_SAHSeparatesClusters(t *testing.T) {
+	// Two clusters of unit boxes: the cheapest split puts each cluster in its own child
+	var shapes []Shape
+	for i := 0; i < 12; i++ {
+		offset := float64(i%6) * 0.2
+		if i >= 6 {
+			offset += 50
+		}
+		shapes = append(shapes, MockShape{
+			boundingBox: NewAABB(core.NewVec3(offset, float64(i), 0), core.NewVec3(offset+1, float64(i)+1, 1)),
+		})
+	}
+	bvh := NewBVH(shapes)
+
+	left, right := bvh.Root.Left, bvh.Root.Right
+	if left == nil || right == nil {
+		t.Fatal("Expected the root to be split")
+	}
+	if overlapArea(left.BoundingBox, right.BoundingBox) != 0 {
+		t.Errorf("Expected the clusters in separate children, got %v and %v", left.BoundingBox, right.BoundingBox)
+	}
+
+	stats := bvh.Stats()
+	if stats.Overlap != 0 {
+		t.Errorf("Expected no sibling overlap, got %v", stats.Overlap)
+	}
+
+	// Root visit, then each child's probability of being visited times its 6 shapes
+	rootArea := bvh.Root.BoundingBox.SurfaceArea()
+	expected := 1 + 6*(left.BoundingBox.SurfaceArea()+right.BoundingBox.SurfaceArea())/rootArea
+	if math.Abs(stats.SAHCost-expected) > 1e-9 {
+		t.Errorf("SAH cost = %v, want %v", stats.SAHCost, expected)
+	}
+}
+
+func TestClipRef_Triangle(t *testing.T) {
+	triangle := NewTriangle(core.NewVec3(0, 0, 0), core.NewVec3(4, 0, 0), core.NewVec3(0, 4, 0), nil)
+	ref := bvhRef{shape: triangle, bounds: triangle.BoundingBox()}
+
+	// The triangle is only 2 tall for 2 <= x <= 3, so the clipped box is much smaller than the slab
+	box, ok := clipRef(ref, 0, 2, 3)
+	expected := NewAABB(core.NewVec3(2, 0, 0), core.NewVec3(3, 2, 0))
+	if !ok || !box.Min.Equals(expected.Min) || !box.Max.Equals(expected.Max) {
+		t.Errorf("Clipped bounds = %v (ok=%v), want %v", box, ok, expected)
+	}
+
+	// Other shapes have their bounding box cut to the slab
+	sphere := NewSphere(core.NewVec3(0, 0, 0), 1, nil)
+	box, ok = clipRef(bvhRef{shape: sphere, bounds: sphere.BoundingBox()}, 1, 0.5, 5)
+	if !ok || box.Min.Y != 0.5 || box.Max.Y != 1 || box.Min.X != -1 {
+		t.Errorf("Clipped sphere bounds = %v (ok=%v)", box, ok)
+	}
+
+	if _, ok := clipRef(ref, 2, 1, 2); ok {
+		t.Error("Expected no bounds for a slab missing the triangle")
+	}
+}
+
+func TestBVH_SpatialSplitsMatchBruteForce(t *testing.T) {
+	// Long, thin triangles crossing the scene in every direction overlap a lot: the case SBVH is for
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	sampler := core.NewSeededSampler(11)
+	randomPoint := func() core.Vec3 {
+		return core.NewVec3(sampler.Get1D()*20-10, sampler.Get1D()*20-10, sampler.Get1D()*20-10)
+	}
+	var shapes []Shape
+	for i := 0; i < 300; i++ {
+		a, b := randomPoint(), randomPoint()
+		shapes = append(shapes, NewTriangle(a, b, a.Add(core.NewVec3(0.3, 0.3, 0.3)), mat))
+	}
+
+	plain := NewBVH(shapes)
+	split := NewBVHWithOptions(shapes, BVHOptions{SpatialSplits: true})
+
+	plainStats, splitStats := plain.Stats(), split.Stats()
+	if splitStats.TotalShapes <= len(shapes) {
+		t.Errorf("Expected spatial splits to duplicate references, got %d for %d shapes", splitStats.TotalShapes, len(shapes))
+	}
+	if splitStats.SAHCost >= plainStats.SAHCost {
+		t.Errorf("Expected spatial splits to lower the SAH cost, got %.2f vs %.2f", splitStats.SAHCost, plainStats.SAHCost)
+	}
+
+	for i := 0; i < 2000; i++ {
+		ray := core.NewRay(randomPoint().Multiply(1.5), core.SampleOnUnitSphere(sampler.Get2D()))
+
+		var closest *material.SurfaceInteraction
+		for _, shape := range shapes {
+			if hit, isHit := shape.Hit(ray, 0.001, math.Inf(1)); isHit && (closest == nil || hit.T < closest.T) {
+				closest = hit
+			}
+		}
+
+		hit, isHit := split.Hit(ray, 0.001, math.Inf(1))
+		if isHit != (closest != nil) || (isHit && hit.T != closest.T) {
+			t.Fatalf("Ray %v: BVH hit %v, brute force %v", ray, hit, closest)
+		}
+		if split.HitAny(ray, 0.001, math.Inf(1)) != isHit {
+			t.Fatalf("Ray %v: HitAny disagrees with Hit", ray)
+		}
+	}
+}
+
+// cutoutTestScene puts a card, transparent on its left half (x < 0), in front of a wall at z=-2
+func cutoutTestScene(card func(mat material.Material) Shape) *BVH {
+	alpha := material.NewImageTexture(2, 1, []core.Vec3{{}, core.NewVec3(1, 1, 1)})
+	cutout := material.NewCutout(material.NewLambertian(core.NewVec3(0.8, 0.2, 0.2)), alpha)
+	wall := NewQuad(core.NewVec3(-5, -5, -2), core.NewVec3(10, 0, 0), core.NewVec3(0, 10, 0),
+		material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	return NewBVH([]Shape{card(cutout), wall})
+}
+
+func TestBVH_CutoutSkipsTransparentTexels(t *testing.T) {
+	cards := map[string]func(mat material.Material) Shape{
+		"quad": func(mat material.Material) Shape {
+			return NewQuad(core.NewVec3(-1, -1, 0), core.NewVec3(2, 0, 0), core.NewVec3(0, 2, 0), mat)
+		},
+		"mesh": func(mat material.Material) Shape {
+			vertices := []core.Vec3{core.NewVec3(-1, -1, 0), core.NewVec3(1, -1, 0), core.NewVec3(1, 1, 0), core.NewVec3(-1, 1, 0)}
+			uvs := []core.Vec2{core.NewVec2(0, 0), core.NewVec2(1, 0), core.NewVec2(1, 1), core.NewVec2(0, 1)}
+			return NewTriangleMesh(vertices, []int{0, 1, 2, 0, 2, 3}, mat, &TriangleMeshOptions{VertexUVs: uvs})
+		},
+		"float32 mesh": func(mat material.Material) Shape {
+			vertices := []core.Vec3{core.NewVec3(-1, -1, 0), core.NewVec3(1, -1, 0), core.NewVec3(1, 1, 0), core.NewVec3(-1, 1, 0)}
+			uvs := []core.Vec2{core.NewVec2(0, 0), core.NewVec2(1, 0), core.NewVec2(1, 1), core.NewVec2(0, 1)}
+			return NewTriangleMesh(vertices, []int{0, 1, 2, 0, 2, 3}, mat, &TriangleMeshOptions{VertexUVs: uvs, Float32: true})
+		},
+	}
+
+	for name, card := range cards {
+		t.Run(name, func(t *testing.T) {
+			bvh := cutoutTestScene(card)
+
+			through := core.NewRay(core.NewVec3(-0.5, 0.3, 5), core.NewVec3(0, 0, -1))
+			solid := core.NewRay(core.NewVec3(0.5, 0.3, 5), core.NewVec3(0, 0, -1))
+			if hit, isHit := bvh.Hit(through, 0.001, math.Inf(1)); !isHit || math.Abs(hit.T-7) > 1e-9 {
+				t.Errorf("Expected the ray to pass through the transparent half to the wall, got %v", hit)
+			}
+			if hit, isHit := bvh.Hit(solid, 0.001, math.Inf(1)); !isHit || math.Abs(hit.T-5) > 1e-9 {
+				t.Errorf("Expected the ray to stop at the opaque half, got %v", hit)
+			}
+
+			// Transparent texels cast no shadows
+			if bvh.HitAny(through, 0.001, 6) {
+				t.Error("Expected the transparent half not to occlude")
+			}
+			if !bvh.HitAny(solid, 0.001, 6) {
+				t.Error("Expected the opaque half to occlude")
+			}
+
+			// Packets agree with single rays
+			hits := make([]*material.SurfaceInteraction, 2)
+			bvh.HitMany([]core.Ray{through, solid}, 0.001, math.Inf(1), hits)
+			if hits[0] == nil || math.Abs(hits[0].T-7) > 1e-9 || hits[1] == nil || math.Abs(hits[1].T-5) > 1e-9 {
+				t.Errorf("Expected HitMany to match Hit, got %v, %v", hits[0], hits[1])
+			}
+		})
+	}
+}
+
+func TestBVH_CutoutSphereHitsBackFace(t *testing.T) {
+	// A sphere that is transparent where the ray enters is hit where it leaves
+	alpha := material.NewImageTexture(2, 1, []core.Vec3{{}, core.NewVec3(1, 1, 1)})
+	mat := material.NewCutout(material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), alpha)
+	sphere := NewSphere(core.Vec3{}, 1, mat)
+	bvh := NewBVH([]Shape{sphere})
+
+	skipped := 0
+	for _, direction := range []core.Vec3{core.NewVec3(1, 0, 0), core.NewVec3(-1, 0, 0), core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1)} {
+		ray := core.NewRay(direction.Multiply(-5), direction)
+		front, _ := sphere.Hit(ray, 0.001, math.Inf(1))
+		hit, isHit := bvh.Hit(ray, 0.001, math.Inf(1))
+		if material.IsOpaque(front) {
+			if !isHit || hit.T != front.T {
+				t.Errorf("Direction %v: expected the opaque front hit at t=%f, got %v", direction, front.T, hit)
+			}
+			continue
+		}
+		skipped++
+		if isHit && (math.Abs(hit.T-6) > 1e-9 || !material.IsOpaque(hit)) {
+			t.Errorf("Direction %v: expected to skip the transparent front, got t=%f", direction, hit.T)
+		}
+	}
+	if skipped == 0 {
+		t.Error("Expected some rays to enter through a transparent texel")
+	}
+}
+
+func TestBVH_InsertRemoveMatchBruteForce(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	sampler := core.NewSeededSampler(5)
+	randomPoint := func() core.Vec3 {
+		return core.NewVec3(sampler.Get1D()*20-10, sampler.Get1D()*20-10, sampler.Get1D()*20-10)
+	}
+	var shapes []Shape
+	for i := 0; i < 100; i++ {
+		a, b := randomPoint(), randomPoint()
+		shapes = append(shapes, NewTriangle(a, b, a.Add(core.NewVec3(0.3, 0.3, 0.3)), mat))
+	}
+
+	for _, options := range []BVHOptions{{}, {SpatialSplits: true}} {
+		bvh := NewBVHWithOptions(shapes[:50], options)
+		present := append([]Shape(nil), shapes[:50]...)
+
+		// Grow the tree well past its leaves, then remove every other shape, old and new
+		for _, shape := range shapes[50:] {
+			bvh.Insert(shape)
+			present = append(present, shape)
+		}
+		var kept []Shape
+		for i, shape := range present {
+			if i%2 == 0 {
+				if !bvh.Remove(shape) {
+					t.Fatalf("Spatial splits %v: shape %d wasn't found to remove", options.SpatialSplits, i)
+				}
+			} else {
+				kept = append(kept, shape)
+			}
+		}
+		if bvh.Remove(shapes[0]) {
+			t.Errorf("Spatial splits %v: expected removing a shape twice to fail", options.SpatialSplits)
+		}
+		if stats := bvh.Stats(); !options.SpatialSplits && stats.TotalShapes != len(kept) {
+			t.Errorf("Expected %d shapes in the leaves, got %d", len(kept), stats.TotalShapes)
+		}
+		if center, radius := NewBVH(kept).BoundingBox().BoundingSphere(); bvh.Center != center || bvh.Radius != radius {
+			t.Errorf("Spatial splits %v: expected the world bounds refit", options.SpatialSplits)
+		}
+
+		for i := 0; i < 1000; i++ {
+			ray := core.NewRay(randomPoint().Multiply(1.5), core.SampleOnUnitSphere(sampler.Get2D()))
+			var closest *material.SurfaceInteraction
+			for _, shape := range kept {
+				if hit, isHit := shape.Hit(ray, 0.001, math.Inf(1)); isHit && (closest == nil || hit.T < closest.T) {
+					closest = hit
+				}
+			}
+			hit, isHit := bvh.Hit(ray, 0.001, math.Inf(1))
+			if isHit != (closest != nil) || (isHit && hit.T != closest.T) {
+				t.Fatalf("Spatial splits %v, ray %v: BVH hit %v, brute force %v", options.SpatialSplits, ray, hit, closest)
+			}
+		}
+	}
+
+	// Removing the last shape empties the tree, and inserting into an empty tree starts a new one
+	sphere := NewSphere(core.Vec3{}, 1, mat)
+	bvh := NewBVH([]Shape{sphere})
+	if !bvh.Remove(sphere) || bvh.Root != nil || bvh.Radius != 0 {
+		t.Errorf("Expected an empty BVH, got %+v", bvh)
+	}
+	bvh.Insert(sphere)
+	if _, isHit := bvh.Hit(core.NewRay(core.NewVec3(0, 0, 5), core.NewVec3(0, 0, -1)), 0.001, math.Inf(1)); !isHit {
+		t.Error("Expected to hit the sphere inserted into an empty BVH")
+	}
+}
+
+// benchmarkTriangles returns the same scattered small triangles every time, a standard scene for
+// timing BVH builds and traversal
+func benchmarkTriangles(n int) []Shape {
+	sampler := core.NewSeededSampler(11)
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	shapes := make([]Shape, n)
+	for i := range shapes {
+		a := core.NewVec3(sampler.Get1D()*10-5, sampler.Get1D()*10-5, sampler.Get1D()*10-5)
+		e1 := sampler.Get3D().Subtract(core.NewVec3(0.5, 0.5, 0.5)).Multiply(0.5)
+		e2 := sampler.Get3D().Subtract(core.NewVec3(0.5, 0.5, 0.5)).Multiply(0.5)
+		shapes[i] = NewTriangle(a, a.Add(e1), a.Add(e2), mat)
+	}
+	return shapes
+}
+
+func BenchmarkBVH_Build(b *testing.B) {
+	shapes := benchmarkTriangles(4000)
+	b.Run("SAH", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			NewBVH(shapes)
+		}
+	})
+	b.Run("SpatialSplits", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			NewBVHWithOptions(shapes, BVHOptions{SpatialSplits: true})
+		}
+	})
+}
+
+// BenchmarkBVH_Hit times one ray per iteration, from rays aimed through the triangles from all
+// around them
+func BenchmarkBVH_Hit(b *testing.B) {
+	shapes := benchmarkTriangles(4000)
+	sampler := core.NewSeededSampler(12)
+	rays := make([]core.Ray, 1024)
+	for i := range rays {
+		origin := core.SampleOnUnitSphere(sampler.Get2D()).Multiply(20)
+		target := sampler.Get3D().Subtract(core.NewVec3(0.5, 0.5, 0.5)).Multiply(8)
+		rays[i] = core.NewRay(origin, target.Subtract(origin).Normalize())
+	}
+
+	for _, options := range []struct {
+		name string
+		BVHOptions
+	}{{"SAH", BVHOptions{}}, {"SpatialSplits", BVHOptions{SpatialSplits: true}}} {
+		bvh := NewBVHWithOptions(shapes, options.BVHOptions)
+		b.Run(options.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				bvh.Hit(rays[n%len(rays)], 0.001, math.Inf(1))
+			}
+		})
+	}
+}
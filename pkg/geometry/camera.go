@@ -1,12 +1,14 @@
 package geometry
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
+// cameraLog logs camera sampling for t=1 strategies, at debug level
+var cameraLog = core.NewLog("camera")
+
 // CameraSample represents camera sampling result for t=1 strategies
 type CameraSample struct {
 	Ray    core.Ray  // Ray from camera toward reference point
@@ -14,6 +16,37 @@ type CameraSample struct {
 	PDF    float64   // Probability density for this sample
 }
 
+// Projection is how a camera maps directions to its image
+type Projection string
+
+const (
+	// ProjectionPerspective is a pinhole or thin lens camera's view of VFov around LookAt
+	ProjectionPerspective Projection = ""
+	// ProjectionEquirectangular sees every direction: longitude across the image, with LookAt at
+	// its center, and latitude down it, from Up at the top row to straight down at the bottom
+	ProjectionEquirectangular Projection = "equirectangular"
+	// ProjectionCubemap sees every direction as a strip of six square 90° views, facing right,
+	// left, up, down, front (LookAt) and back. The up view has the back at its top, the down view
+	// the front.
+	ProjectionCubemap Projection = "cubemap"
+)
+
+// Panoramas lists the projections that see every direction
+var Panoramas = []Projection{ProjectionEquirectangular, ProjectionCubemap}
+
+// AspectRatio returns the width over height of a panorama's image, or 0 for a perspective view,
+// whose image can have any shape
+func (p Projection) AspectRatio() float64 {
+	switch p {
+	case ProjectionEquirectangular:
+		return 2
+	case ProjectionCubemap:
+		return 6
+	default:
+		return 0
+	}
+}
+
 // CameraConfig contains all camera configuration parameters
 type CameraConfig struct {
 	// Camera positioning
@@ -29,6 +62,9 @@ type CameraConfig struct {
 	// Focus properties
 	Aperture      float64 // Angle of defocus blur (0 = no blur)
 	FocusDistance float64 // Distance to focus plane (0 = auto-calculate from LookAt)
+
+	// Projection of directions to the image; panoramas are pinholes, ignoring VFov and Aperture
+	Projection Projection
 }
 
 // Camera generates rays for rendering with configurable positioning and depth of field
@@ -59,11 +95,18 @@ type Camera struct {
 	cosTotalWidth   float64 // Cosine of half the field of view width
 	cosTotalHeight  float64 // Cosine of half the field of view height
 
+	// Panoramas: the projection, and the six views of a cubemap
+	projection Projection
+	cubeFaces  [6]cubeFace
+
 	// Store configuration for reference
 	config CameraConfig
+}
 
-	// Verbose logging bool
-	verbose bool
+// cubeFace is the view of one of a cubemap's faces: where its center looks, and the directions
+// of its image's right and top
+type cubeFace struct {
+	forward, right, up core.Vec3
 }
 
 // NewCamera creates a camera with the given configuration
@@ -141,7 +184,7 @@ func NewCamera(config CameraConfig) *Camera {
 	cosTotalWidth := cornerDirection.Dot(forwardDirection)
 	cosTotalHeight := cosTotalWidth // Same for both since we check corners
 
-	return &Camera{
+	camera := &Camera{
 		center:          config.Center,
 		pixel00Loc:      pixel00Loc,
 		pixelDeltaU:     pixelDeltaU,
@@ -164,13 +207,33 @@ func NewCamera(config CameraConfig) *Camera {
 		cosTotalWidth:   cosTotalWidth,
 		cosTotalHeight:  cosTotalHeight,
 		cameraForward:   cameraForward,
+		projection:      config.Projection,
 		config:          config,
-		verbose:         false,
 	}
+
+	// Panoramas are pinholes; the cube's faces look along the image's right, up and forward
+	if config.Projection != ProjectionPerspective {
+		camera.lensRadius, camera.lensArea = 0, 1
+		camera.defocusDiskU, camera.defocusDiskV = core.Vec3{}, core.Vec3{}
+		right, up, forward := u, v.Multiply(-1), cameraForward
+		camera.cubeFaces = [6]cubeFace{
+			{forward: right, right: forward.Multiply(-1), up: up},
+			{forward: right.Multiply(-1), right: forward, up: up},
+			{forward: up, right: right, up: forward.Multiply(-1)},
+			{forward: up.Multiply(-1), right: right, up: forward},
+			{forward: forward, right: right, up: up},
+			{forward: forward.Multiply(-1), right: right.Multiply(-1), up: up},
+		}
+	}
+	return camera
 }
 
 // GetRay generates a ray for pixel coordinates (i, j) with sub-pixel sampling using the provided random generator
 func (c *Camera) GetRay(i, j int, samplePoint core.Vec2, sampleJitter core.Vec2) core.Ray {
+	if c.projection != ProjectionPerspective {
+		return c.panoramaRay(float64(i)+sampleJitter.X, float64(j)+sampleJitter.Y)
+	}
+
 	// Add random offset for anti-aliasing
 	jitter := core.NewVec3(sampleJitter.X-0.5, sampleJitter.Y-0.5, 0)
 	pixelSample := c.pixel00Loc.
@@ -185,13 +248,26 @@ func (c *Camera) GetRay(i, j int, samplePoint core.Vec2, sampleJitter core.Vec2)
 		rayOrigin = c.center.Add(offset)
 	}
 
-	rayDirection := pixelSample.Subtract(rayOrigin).Normalize()
-	return core.NewRay(rayOrigin, rayDirection)
+	// The rays through the neighboring pixels leave from the same point on the lens
+	ray := core.NewRay(rayOrigin, pixelSample.Subtract(rayOrigin).Normalize())
+	ray.Differential = &core.RayDifferential{
+		RxOrigin:    rayOrigin,
+		RxDirection: pixelSample.Add(c.pixelDeltaU).Subtract(rayOrigin).Normalize(),
+		RyOrigin:    rayOrigin,
+		RyDirection: pixelSample.Add(c.pixelDeltaV).Subtract(rayOrigin).Normalize(),
+	}
+	return ray
 }
 
 // CalculateRayPDFs calculates the area and direction PDFs for a camera ray
 // This is needed for BDPT to properly balance camera and light path PDFs
 func (c *Camera) CalculateRayPDFs(ray core.Ray) (areaPDF, dirPDF float64) {
+	if c.projection != ProjectionPerspective {
+		if pdf, _ := c.panoramaPDF(ray.Direction); pdf > 0 {
+			return 1, pdf
+		}
+		return 0, 0
+	}
 
 	// Cosine of angle between ray and camera forward direction
 	cosTheta := ray.Direction.Dot(c.cameraForward)
@@ -224,6 +300,10 @@ func (c *Camera) GetCameraForward() core.Vec3 {
 // Camera handles lens sampling internally, returns complete sample
 // Equivalent to pbrt PerspectiveCamera::SampleWi
 func (c *Camera) SampleCameraFromPoint(refPoint core.Vec3, sample core.Vec2) *CameraSample {
+	if c.projection != ProjectionPerspective {
+		return c.samplePanorama(refPoint)
+	}
+
 	// Sample lens coordinates using concentric disk sampling
 	lensCoords := core.SamplePointInUnitDisk(sample).Multiply(c.lensRadius)
 
@@ -241,7 +321,9 @@ func (c *Camera) SampleCameraFromPoint(refPoint core.Vec3, sample core.Vec2) *Ca
 	// This is a solid angle PDF - probability per unit solid angle as seen from the reference point
 	cosTheta := c.cameraForward.AbsDot(ray.Direction)
 	pdf := (distance * distance) / (cosTheta * c.lensArea)
-	//c.logf("SampleCameraFromPoint: lensPoint=%v, direction=%v, distance=%f, cosTheta=%f, pdf=%f\n", lensPoint, direction, distance, cosTheta, pdf)
+	if cameraLog.Enabled(core.LevelDebug) {
+		cameraLog.Logf(core.LevelDebug, "SampleCameraFromPoint: lensPoint=%v, direction=%v, distance=%f, cosTheta=%f, pdf=%f", lensPoint, direction, distance, cosTheta, pdf)
+	}
 
 	// Calculate camera importance weight
 	// 	importance = 1.0 / (c.imagePlaneArea * c.lensArea * cos^4
@@ -261,6 +343,14 @@ func (c *Camera) SampleCameraFromPoint(refPoint core.Vec3, sample core.Vec2) *Ca
 // EvaluateRayImportance calculates the camera importance function for a ray
 // This is the We function in PBRT - represents camera sensor responsivity
 func (c *Camera) EvaluateRayImportance(ray core.Ray) core.Vec3 {
+	if c.projection != ProjectionPerspective {
+		pdf, cosTheta := c.panoramaPDF(ray.Direction)
+		if pdf == 0 {
+			return core.Vec3{}
+		}
+		return core.NewVec3(1, 1, 1).Multiply(pdf / cosTheta)
+	}
+
 	// Check if ray is forward-facing with respect to the camera
 	cosTheta := ray.Direction.Dot(c.cameraForward)
 
@@ -278,6 +368,57 @@ func (c *Camera) EvaluateRayImportance(ray core.Ray) core.Vec3 {
 
 // MapRayToPixel maps a ray back to pixel coordinates (for splat placement)
 func (c *Camera) MapRayToPixel(ray core.Ray) (int, int, bool) {
+	x, y, ok := c.imagePlanePoint(ray)
+	if !ok {
+		return 0, 0, false
+	}
+
+	// Convert to pixel coordinates
+	pixelX := int(x)
+	pixelY := int(y)
+
+	// Check bounds
+	if pixelX >= 0 && pixelX < c.imageWidth && pixelY >= 0 && pixelY < c.imageHeight {
+		return pixelX, pixelY, true
+	}
+
+	return 0, 0, false
+}
+
+// MapRayToFilm maps a ray back to where it crosses the image, in continuous pixel coordinates (for
+// splats spread over several pixels by a reconstruction filter)
+func (c *Camera) MapRayToFilm(ray core.Ray) (core.Vec2, bool) {
+	x, y, ok := c.imagePlanePoint(ray)
+	if !ok || x < 0 || y < 0 || x >= float64(c.imageWidth) || y >= float64(c.imageHeight) {
+		return core.Vec2{}, false
+	}
+	return core.NewVec2(x, y), true
+}
+
+// ProjectPoint returns where a point appears in the image, in continuous pixel coordinates (pixel
+// (i, j) covers [i, i+1) × [j, j+1)), and its depth: its distance in front of the camera along
+// the view direction. Points that aren't in front of the camera report false, but their depth.
+// Panoramas see every point; their depth is its distance from the camera.
+func (c *Camera) ProjectPoint(point core.Vec3) (core.Vec2, float64, bool) {
+	if c.projection != ProjectionPerspective {
+		offset := point.Subtract(c.center)
+		x, y, ok := c.panoramaPoint(offset)
+		return core.NewVec2(x, y), offset.Length(), ok
+	}
+	depth := point.Subtract(c.center).Dot(c.w.Multiply(-1))
+	if depth <= 0 {
+		return core.Vec2{}, depth, false
+	}
+	x, y, ok := c.imagePlanePoint(core.NewRay(c.center, point.Subtract(c.center)))
+	return core.NewVec2(x, y), depth, ok
+}
+
+// imagePlanePoint finds where a ray crosses the image plane, in continuous pixel coordinates
+func (c *Camera) imagePlanePoint(ray core.Ray) (float64, float64, bool) {
+	if c.projection != ProjectionPerspective {
+		return c.panoramaPoint(ray.Direction)
+	}
+
 	// Find intersection with image plane
 	// core.Ray: origin + t * direction
 	// Image plane: center - w * focusDistance
@@ -303,16 +444,7 @@ func (c *Camera) MapRayToPixel(ray core.Ray) (int, int, bool) {
 	normalizedX := (planeX + c.viewportWidth/2) / c.viewportWidth
 	normalizedY := (planeY + c.viewportHeight/2) / c.viewportHeight
 
-	// Convert to pixel coordinates
-	pixelX := int(normalizedX * float64(c.imageWidth))
-	pixelY := int(normalizedY * float64(c.imageHeight))
-
-	// Check bounds
-	if pixelX >= 0 && pixelX < c.imageWidth && pixelY >= 0 && pixelY < c.imageHeight {
-		return pixelX, pixelY, true
-	}
-
-	return 0, 0, false
+	return normalizedX * float64(c.imageWidth), normalizedY * float64(c.imageHeight), true
 }
 
 // MergeCameraConfig merges camera configuration overrides with defaults
@@ -344,17 +476,9 @@ func MergeCameraConfig(defaultConfig CameraConfig, override CameraConfig) Camera
 	if override.FocusDistance != 0 {
 		result.FocusDistance = override.FocusDistance
 	}
+	if override.Projection != ProjectionPerspective {
+		result.Projection = override.Projection
+	}
 
 	return result
 }
-
-// SetVerbose sets the verbose logging bool
-func (c *Camera) SetVerbose(verbose bool) {
-	c.verbose = verbose
-}
-
-func (c *Camera) logf(format string, a ...interface{}) {
-	if c.verbose {
-		fmt.Printf(format, a...)
-	}
-}
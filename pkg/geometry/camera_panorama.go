@@ -0,0 +1,118 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// panoramaRay returns the ray through continuous pixel coordinates (x, y) of a panorama
+func (c *Camera) panoramaRay(x, y float64) core.Ray {
+	ray := core.NewRay(c.center, c.panoramaDirection(x, y))
+	ray.Differential = &core.RayDifferential{
+		RxOrigin:    c.center,
+		RxDirection: c.panoramaDirection(x+1, y),
+		RyOrigin:    c.center,
+		RyDirection: c.panoramaDirection(x, y+1),
+	}
+	return ray
+}
+
+// panoramaDirection returns the unit direction a panorama sees at continuous pixel coordinates
+func (c *Camera) panoramaDirection(x, y float64) core.Vec3 {
+	width, height := float64(c.imageWidth), float64(c.imageHeight)
+	if c.projection == ProjectionEquirectangular {
+		phi := 2 * math.Pi * (x/width - 0.5)
+		theta := math.Pi * y / height
+		sinTheta := math.Sin(theta)
+		return c.cubeFaces[4].forward.Multiply(sinTheta * math.Cos(phi)).
+			Add(c.cubeFaces[4].right.Multiply(sinTheta * math.Sin(phi))).
+			Add(c.cubeFaces[4].up.Multiply(math.Cos(theta)))
+	}
+
+	size := width / 6
+	index := min(max(int(x/size), 0), 5)
+	face := c.cubeFaces[index]
+	a := 2*(x-float64(index)*size)/size - 1
+	b := 2*y/height - 1
+	return face.forward.Add(face.right.Multiply(a)).Subtract(face.up.Multiply(b)).Normalize()
+}
+
+// panoramaPoint returns where a panorama sees a direction, in continuous pixel coordinates
+func (c *Camera) panoramaPoint(direction core.Vec3) (float64, float64, bool) {
+	if direction.LengthSquared() == 0 {
+		return 0, 0, false
+	}
+	direction = direction.Normalize()
+	width, height := float64(c.imageWidth), float64(c.imageHeight)
+	if c.projection == ProjectionEquirectangular {
+		front := c.cubeFaces[4]
+		phi := math.Atan2(direction.Dot(front.right), direction.Dot(front.forward))
+		theta := math.Acos(math.Max(-1, math.Min(1, direction.Dot(front.up))))
+		x := (phi/(2*math.Pi) + 0.5) * width
+		if x >= width {
+			x -= width // The seam behind the camera belongs to the left edge
+		}
+		return x, theta / math.Pi * height, true
+	}
+
+	index, cosTheta := c.cubeFace(direction)
+	face := c.cubeFaces[index]
+	a := direction.Dot(face.right) / cosTheta
+	b := -direction.Dot(face.up) / cosTheta
+	size := width / 6
+	return (float64(index) + (a+1)/2) * size, (b + 1) / 2 * height, true
+}
+
+// cubeFace returns the cubemap face a unit direction passes through, and the cosine between it
+// and the face's view direction
+func (c *Camera) cubeFace(direction core.Vec3) (int, float64) {
+	index, cosTheta := 0, math.Inf(-1)
+	for i, face := range c.cubeFaces {
+		if cos := direction.Dot(face.forward); cos > cosTheta {
+			index, cosTheta = i, cos
+		}
+	}
+	return index, cosTheta
+}
+
+// panoramaPDF returns the solid angle density with which a panorama's rays (uniform over its
+// image) take a direction, and the cosine of the direction to the "sensor" seeing it: that of the
+// cubemap face's view direction, or 1 for an equirectangular panorama, which faces every way.
+// Importance is the density over that cosine, as for a perspective pinhole.
+func (c *Camera) panoramaPDF(direction core.Vec3) (float64, float64) {
+	direction = direction.Normalize()
+	if c.projection == ProjectionEquirectangular {
+		// The image's [0, 1]² maps to 2π by π radians, stretched by 1 / sinθ along the rows
+		cosTheta := direction.Dot(c.cubeFaces[4].up)
+		sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+		if sinTheta == 0 {
+			return 0, 1
+		}
+		return 1 / (2 * math.Pi * math.Pi * sinTheta), 1
+	}
+
+	// Each face is a 90° view, an image plane of area 4 at distance 1, with a sixth of the rays
+	_, cosTheta := c.cubeFace(direction)
+	return 1 / (6 * 4 * cosTheta * cosTheta * cosTheta), cosTheta
+}
+
+// samplePanorama samples a panorama's pinhole from a reference point for t=1 strategies
+func (c *Camera) samplePanorama(refPoint core.Vec3) *CameraSample {
+	toPoint := refPoint.Subtract(c.center)
+	distance := toPoint.Length()
+	if distance == 0 {
+		return nil
+	}
+	ray := core.NewRay(c.center, toPoint.Multiply(1/distance))
+	pdf, cosTheta := c.panoramaPDF(ray.Direction)
+	if pdf == 0 {
+		return nil
+	}
+	importance := pdf / cosTheta
+	return &CameraSample{
+		Ray:    ray,
+		Weight: core.NewVec3(importance, importance, importance),
+		PDF:    distance * distance / cosTheta,
+	}
+}
@@ -0,0 +1,123 @@
+package geometry
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// newPanoramaCamera returns a panorama at the origin looking along -z with y up, 60 pixels tall
+func newPanoramaCamera(projection Projection) *Camera {
+	return NewCamera(CameraConfig{
+		Center:      core.NewVec3(0, 0, 0),
+		LookAt:      core.NewVec3(0, 0, -1),
+		Up:          core.NewVec3(0, 1, 0),
+		Width:       int(60 * projection.AspectRatio()),
+		AspectRatio: projection.AspectRatio(),
+		VFov:        45,
+		Aperture:    0.5, // Ignored
+		Projection:  projection,
+	})
+}
+
+func TestPanoramaDirections(t *testing.T) {
+	center := core.NewVec2(0.5, 0.5)
+	tests := []struct {
+		name       string
+		projection Projection
+		x, y       int
+		expected   core.Vec3
+	}{
+		{"equirectangular center looks ahead", ProjectionEquirectangular, 60, 30, core.NewVec3(0, 0, -1)},
+		{"equirectangular top looks up", ProjectionEquirectangular, 60, 0, core.NewVec3(0, 1, 0)},
+		{"equirectangular edge looks behind", ProjectionEquirectangular, 0, 30, core.NewVec3(0, 0, 1)},
+		{"cubemap front", ProjectionCubemap, 270, 30, core.NewVec3(0, 0, -1)},
+		{"cubemap back", ProjectionCubemap, 330, 30, core.NewVec3(0, 0, 1)},
+		{"cubemap up", ProjectionCubemap, 150, 30, core.NewVec3(0, 1, 0)},
+		{"cubemap down", ProjectionCubemap, 210, 30, core.NewVec3(0, -1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := newPanoramaCamera(tt.projection)
+			direction := camera.GetRay(tt.x, tt.y, center, center).Direction
+			if direction.Subtract(tt.expected).Length() > 0.06 {
+				t.Errorf("Expected pixel (%d, %d) to look along %v, got %v", tt.x, tt.y, tt.expected, direction)
+			}
+		})
+	}
+
+	// The right view looks where the front view's right edge does
+	camera := newPanoramaCamera(ProjectionCubemap)
+	right := camera.GetRay(30, 30, center, center).Direction
+	frontEdge := camera.GetRay(299, 30, center, center).Direction
+	if right.Dot(frontEdge) < 0.6 {
+		t.Errorf("Expected the right view (%v) beside the front view's right edge (%v)", right, frontEdge)
+	}
+}
+
+func TestPanoramaMapRayToFilm(t *testing.T) {
+	for _, projection := range Panoramas {
+		t.Run(string(projection), func(t *testing.T) {
+			camera := newPanoramaCamera(projection)
+			random := rand.New(rand.NewSource(1))
+			for i := 0; i < 1000; i++ {
+				x := random.Float64() * float64(camera.imageWidth)
+				y := random.Float64() * float64(camera.imageHeight)
+				ray := camera.panoramaRay(x, y)
+				film, ok := camera.MapRayToFilm(ray)
+				if !ok || math.Abs(film.X-x) > 1e-6 || math.Abs(film.Y-y) > 1e-6 {
+					t.Fatalf("Expected the ray through (%g, %g) to map back there, got %v (ok %v)", x, y, film, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestPanoramaPDF(t *testing.T) {
+	// Every direction is seen, and the density integrates to 1 over the sphere
+	camera := newPanoramaCamera(ProjectionCubemap)
+	random := rand.New(rand.NewSource(2))
+	const samples = 200000
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		direction := core.SampleOnUnitSphere(core.NewVec2(random.Float64(), random.Float64()))
+		_, pdf := camera.CalculateRayPDFs(core.NewRay(camera.center, direction))
+		sum += pdf * 4 * math.Pi
+	}
+	if integral := sum / samples; math.Abs(integral-1) > 0.01 {
+		t.Errorf("Expected the cubemap's direction PDF to integrate to 1, got %g", integral)
+	}
+
+	// A pixel's rays cover its share of the sphere: density × solid angle = 1 / pixels
+	camera = newPanoramaCamera(ProjectionEquirectangular)
+	_, pdf := camera.CalculateRayPDFs(camera.panoramaRay(40.5, 10.5))
+	theta := math.Pi * 10.5 / 60
+	solidAngle := (2 * math.Pi / 120) * (math.Pi / 60) * math.Sin(theta)
+	if share := pdf * solidAngle * 120 * 60; math.Abs(share-1) > 1e-9 {
+		t.Errorf("Expected a pixel's probability to be 1/pixels, got %g/pixels", share)
+	}
+}
+
+func TestSamplePanorama(t *testing.T) {
+	for _, projection := range Panoramas {
+		t.Run(string(projection), func(t *testing.T) {
+			camera := newPanoramaCamera(projection)
+			point := core.NewVec3(1, 2, 3)
+			sample := camera.SampleCameraFromPoint(point, core.NewVec2(0.3, 0.7))
+			if sample == nil {
+				t.Fatal("Expected a panorama to see a point behind and above it")
+			}
+			if !sample.Ray.Origin.Equals(camera.center) || sample.Ray.Direction.Subtract(point.Normalize()).Length() > 1e-9 {
+				t.Errorf("Expected a ray from the camera toward the point, got %v", sample.Ray)
+			}
+			if importance := camera.EvaluateRayImportance(sample.Ray); !importance.Equals(sample.Weight) {
+				t.Errorf("Expected the sample's weight %v to be its ray's importance %v", sample.Weight, importance)
+			}
+			if _, _, ok := camera.MapRayToPixel(sample.Ray); !ok {
+				t.Error("Expected the sampled ray to map to a pixel")
+			}
+		})
+	}
+}
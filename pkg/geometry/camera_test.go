@@ -242,3 +242,22 @@ func TestCameraCalculateRayPDFs_ScaleConsistency(t *testing.T) {
 		t.Errorf("PDF ratio should equal light area. Expected %f, got %f", expectedRatio, ratio)
 	}
 }
+
+func TestCamera_RayDifferentials(t *testing.T) {
+	camera := NewCamera(CameraConfig{
+		Center: core.NewVec3(0, 1, 2), LookAt: core.NewVec3(0, 0, -1), Up: core.NewVec3(0, 1, 0),
+		Width: 64, AspectRatio: 2, VFov: 40,
+	})
+	sample := core.NewVec2(0.3, 0.7)
+	ray := camera.GetRay(10, 5, core.Vec2{}, sample)
+	if ray.Differential == nil {
+		t.Fatal("Expected camera rays to have differentials")
+	}
+
+	// The offset rays are the rays through the same spot of the next pixels over
+	right, below := camera.GetRay(11, 5, core.Vec2{}, sample), camera.GetRay(10, 6, core.Vec2{}, sample)
+	if ray.Differential.RxDirection.Subtract(right.Direction).Length() > 1e-12 ||
+		ray.Differential.RyDirection.Subtract(below.Direction).Length() > 1e-12 {
+		t.Errorf("Expected differentials %v and %v, got %+v", right.Direction, below.Direction, *ray.Differential)
+	}
+}
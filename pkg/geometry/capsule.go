@@ -0,0 +1,192 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Capsule represents a cylinder with hemispherical end caps (all points at Radius from a segment)
+// Emissive capsules model linear/tube lights such as fluorescent tubes and studio strip lights
+type Capsule struct {
+	Start    core.Vec3 // Center of the start hemisphere
+	End      core.Vec3 // Center of the end hemisphere
+	Radius   float64
+	Material material.Material
+
+	// Cached derived values
+	axis   core.Vec3 // Unit vector from start to end
+	length float64   // Distance between start and end
+}
+
+// NewCapsule creates a new capsule around the segment from start to end
+func NewCapsule(start, end core.Vec3, radius float64, mat material.Material) *Capsule {
+	axisVector := end.Subtract(start)
+	length := axisVector.Length()
+
+	// A zero-length capsule is a sphere; any axis works
+	axis := core.NewVec3(0, 1, 0)
+	if length > 0 {
+		axis = axisVector.Multiply(1.0 / length)
+	}
+
+	return &Capsule{
+		Start:    start,
+		End:      end,
+		Radius:   radius,
+		Material: mat,
+		axis:     axis,
+		length:   length,
+	}
+}
+
+// BoundingBox returns the axis-aligned bounding box for this capsule
+func (c *Capsule) BoundingBox() AABB {
+	return NewAABBFromPoints(c.Start, c.End).Expand(c.Radius)
+}
+
+// Hit tests if a ray intersects with the capsule
+func (c *Capsule) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	closest := tMax
+	found := false
+
+	// Keep the nearest root in range whose point lies on the matching part of the capsule
+	consider := func(t float64, onPart func(h float64) bool) {
+		if t < tMin || t > closest {
+			return
+		}
+		if onPart(ray.At(t).Subtract(c.Start).Dot(c.axis)) {
+			closest = t
+			found = true
+		}
+	}
+
+	// Body: infinite cylinder around the axis, clipped to the segment
+	delta := ray.Origin.Subtract(c.Start)
+	DV := ray.Direction.Dot(c.axis)
+	deltaV := delta.Dot(c.axis)
+	a := ray.Direction.LengthSquared() - DV*DV
+	b := 2.0 * (delta.Dot(ray.Direction) - deltaV*DV)
+	cc := delta.LengthSquared() - deltaV*deltaV - c.Radius*c.Radius
+	if t0, t1, ok := solveQuadratic(a, b, cc); ok {
+		onBody := func(h float64) bool { return h >= 0 && h <= c.length }
+		consider(t0, onBody)
+		consider(t1, onBody)
+	}
+
+	// End caps: spheres at both endpoints, keeping only their outer hemispheres
+	for _, end := range []struct {
+		center core.Vec3
+		onCap  func(h float64) bool
+	}{
+		{c.Start, func(h float64) bool { return h <= 0 }},
+		{c.End, func(h float64) bool { return h >= c.length }},
+	} {
+		oc := ray.Origin.Subtract(end.center)
+		a := ray.Direction.LengthSquared()
+		b := 2.0 * oc.Dot(ray.Direction)
+		cc := oc.LengthSquared() - c.Radius*c.Radius
+		if t0, t1, ok := solveQuadratic(a, b, cc); ok {
+			consider(t0, end.onCap)
+			consider(t1, end.onCap)
+		}
+	}
+
+	if !found {
+		return nil, false
+	}
+
+	point := ray.At(closest)
+	outwardNormal := point.Subtract(c.closestAxisPoint(point)).Multiply(1.0 / c.Radius)
+
+	// U: angle around the axis, V: position along the full capsule including both caps
+	h := point.Subtract(c.Start).Dot(c.axis)
+	radial := point.Subtract(c.Start.Add(c.axis.Multiply(h)))
+	uv := bodyUV(radial, c.axis, h+c.Radius, c.length+2*c.Radius)
+
+	hitRecord := &material.SurfaceInteraction{
+		T:        closest,
+		Point:    point,
+		Material: c.Material,
+		UV:       uv,
+	}
+	hitRecord.SetFaceNormal(ray, outwardNormal)
+
+	return hitRecord, true
+}
+
+// solveQuadratic returns the real roots of at² + bt + c = 0 in ascending order
+func solveQuadratic(a, b, c float64) (float64, float64, bool) {
+	const epsilon = 1e-8
+	if math.Abs(a) < epsilon {
+		return 0, 0, false
+	}
+
+	discriminant := b*b - 4*a*c
+	if discriminant < 0 {
+		return 0, 0, false
+	}
+
+	sqrtD := math.Sqrt(discriminant)
+	t0 := (-b - sqrtD) / (2 * a)
+	t1 := (-b + sqrtD) / (2 * a)
+	if t0 > t1 {
+		t0, t1 = t1, t0
+	}
+	return t0, t1, true
+}
+
+// closestAxisPoint returns the point on the capsule's segment nearest to point
+func (c *Capsule) closestAxisPoint(point core.Vec3) core.Vec3 {
+	h := point.Subtract(c.Start).Dot(c.axis)
+	return c.Start.Add(c.axis.Multiply(math.Max(0, math.Min(c.length, h))))
+}
+
+// SampleSurface implements the SurfaceSampler interface - samples uniformly over body and caps by area
+func (c *Capsule) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	// The body holds 2πrL of the 2πrL + 4πr² total area
+	bodyFraction := c.length / (c.length + 2*c.Radius)
+
+	if sample.X < bodyFraction {
+		tangent, bitangent := axisFrame(c.axis)
+		h := sample.X / bodyFraction * c.length
+		phi := 2.0 * math.Pi * sample.Y
+		normal := tangent.Multiply(math.Cos(phi)).Add(bitangent.Multiply(math.Sin(phi)))
+		point := c.Start.Add(c.axis.Multiply(h)).Add(normal.Multiply(c.Radius))
+		return point, normal
+	}
+
+	// The two hemispheres together form a full sphere split along the axis
+	u := (sample.X - bodyFraction) / (1 - bodyFraction)
+	normal := core.SampleOnUnitSphere(core.NewVec2(u, sample.Y))
+	center := c.Start
+	if normal.Dot(c.axis) >= 0 {
+		center = c.End
+	}
+	return center.Add(normal.Multiply(c.Radius)), normal
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the capsule surface
+func (c *Capsule) AreaPDF(point core.Vec3) float64 {
+	const tolerance = 0.001
+	if math.Abs(point.Subtract(c.closestAxisPoint(point)).Length()-c.Radius) > tolerance {
+		return 0.0
+	}
+	return 1.0 / c.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - returns the outward normal at a point
+func (c *Capsule) NormalAt(point core.Vec3) core.Vec3 {
+	return point.Subtract(c.closestAxisPoint(point)).Normalize()
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns 2πrL + 4πr²
+func (c *Capsule) SurfaceArea() float64 {
+	return 2.0*math.Pi*c.Radius*c.length + 4.0*math.Pi*c.Radius*c.Radius
+}
+
+// SignedDistance implements the SignedDistanceField interface - distance to the axis segment minus the radius
+func (c *Capsule) SignedDistance(point core.Vec3) float64 {
+	return point.Subtract(c.closestAxisPoint(point)).Length() - c.Radius
+}
@@ -0,0 +1,146 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestCapsule_BoundingBox(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	capsule := NewCapsule(core.NewVec3(-1, 0, 0), core.NewVec3(1, 2, 0), 0.5, mat)
+
+	bbox := capsule.BoundingBox()
+	expectedMin := core.NewVec3(-1.5, -0.5, -0.5)
+	expectedMax := core.NewVec3(1.5, 2.5, 0.5)
+	if !approxEqualVec(bbox.Min, expectedMin, 1e-9) || !approxEqualVec(bbox.Max, expectedMax, 1e-9) {
+		t.Errorf("Expected bbox [%v, %v], got [%v, %v]", expectedMin, expectedMax, bbox.Min, bbox.Max)
+	}
+}
+
+func TestCapsule_Hit(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	// Capsule along X from x=-1 to x=1, radius 0.5
+	capsule := NewCapsule(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0), 0.5, mat)
+
+	tests := []struct {
+		name           string
+		origin         core.Vec3
+		dir            core.Vec3
+		expectedPoint  core.Vec3
+		expectedNormal core.Vec3
+		frontFace      bool
+	}{
+		{
+			name:           "body from above",
+			origin:         core.NewVec3(0.3, 5, 0),
+			dir:            core.NewVec3(0, -1, 0),
+			expectedPoint:  core.NewVec3(0.3, 0.5, 0),
+			expectedNormal: core.NewVec3(0, 1, 0),
+			frontFace:      true,
+		},
+		{
+			name:           "end cap along axis",
+			origin:         core.NewVec3(5, 0, 0),
+			dir:            core.NewVec3(-1, 0, 0),
+			expectedPoint:  core.NewVec3(1.5, 0, 0),
+			expectedNormal: core.NewVec3(1, 0, 0),
+			frontFace:      true,
+		},
+		{
+			name:           "start cap off axis",
+			origin:         core.NewVec3(-1.3, 5, 0),
+			dir:            core.NewVec3(0, -1, 0),
+			expectedPoint:  core.NewVec3(-1.3, 0.4, 0),
+			expectedNormal: core.NewVec3(-0.6, 0.8, 0),
+			frontFace:      true,
+		},
+		{
+			name:           "from inside",
+			origin:         core.NewVec3(0, 0, 0),
+			dir:            core.NewVec3(0, 0, 1),
+			expectedPoint:  core.NewVec3(0, 0, 0.5),
+			expectedNormal: core.NewVec3(0, 0, -1), // Flipped to face the ray
+			frontFace:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := capsule.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0)
+			if !isHit {
+				t.Fatal("Expected hit, but got miss")
+			}
+			if !approxEqualVec(hit.Point, tt.expectedPoint, 1e-6) {
+				t.Errorf("Expected hit point %v, got %v", tt.expectedPoint, hit.Point)
+			}
+			if !approxEqualVec(hit.Normal, tt.expectedNormal, 1e-6) {
+				t.Errorf("Expected normal %v, got %v", tt.expectedNormal, hit.Normal)
+			}
+			if hit.FrontFace != tt.frontFace {
+				t.Errorf("Expected FrontFace=%v, got %v", tt.frontFace, hit.FrontFace)
+			}
+			if hit.UV.X < 0 || hit.UV.X > 1 || hit.UV.Y < 0 || hit.UV.Y > 1 {
+				t.Errorf("UV %v out of range", hit.UV)
+			}
+		})
+	}
+}
+
+func TestCapsule_Hit_Miss(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	capsule := NewCapsule(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0), 0.5, mat)
+
+	tests := []struct {
+		name   string
+		origin core.Vec3
+		dir    core.Vec3
+	}{
+		{"passes beyond end cap", core.NewVec3(1.6, 5, 0), core.NewVec3(0, -1, 0)},
+		{"passes above body", core.NewVec3(-5, 0.6, 0), core.NewVec3(1, 0, 0)},
+		{"points away", core.NewVec3(0, 5, 0), core.NewVec3(0, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, isHit := capsule.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0); isHit {
+				t.Error("Expected miss, but got hit")
+			}
+		})
+	}
+}
+
+func TestCapsule_Hit_TBounds(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	capsule := NewCapsule(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0), 0.5, mat)
+	ray := core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0))
+
+	// Near surface at t=4.5, far surface at t=5.5
+	hit, isHit := capsule.Hit(ray, 5.0, 1000.0)
+	if !isHit || math.Abs(hit.T-5.5) > 1e-9 {
+		t.Errorf("Expected far hit at t=5.5, got hit=%v", isHit)
+	}
+	if _, isHit := capsule.Hit(ray, 0.001, 4.0); isHit {
+		t.Error("Expected miss with tMax before the capsule")
+	}
+}
+
+func TestCapsule_ZeroLengthIsSphere(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	center := core.NewVec3(1, 2, 3)
+	capsule := NewCapsule(center, center, 0.75, mat)
+	sphere := NewSphere(center, 0.75, mat)
+
+	if math.Abs(capsule.SurfaceArea()-sphere.SurfaceArea()) > 1e-9 {
+		t.Errorf("Expected sphere area %f, got %f", sphere.SurfaceArea(), capsule.SurfaceArea())
+	}
+
+	ray := core.NewRay(core.NewVec3(1.2, 10, 3.1), core.NewVec3(0, -1, 0))
+	capsuleHit, ok1 := capsule.Hit(ray, 0.001, 1000.0)
+	sphereHit, ok2 := sphere.Hit(ray, 0.001, 1000.0)
+	if !ok1 || !ok2 || math.Abs(capsuleHit.T-sphereHit.T) > 1e-9 {
+		t.Errorf("Expected zero-length capsule to match sphere hit")
+	}
+}
@@ -0,0 +1,309 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// compactMesh is the float32 storage of a TriangleMesh (TriangleMeshOptions.Float32). Faces index
+// shared vertices instead of each keeping a Triangle, and the BVH is a flat array of nodes with
+// float32 bounds. A face's Triangle is only created, in float64, for the closest hit of a ray.
+type compactMesh struct {
+	positions     []float32           // x, y, z per vertex
+	vertexNormals []float32           // x, y, z per vertex; nil for flat shading
+	vertexUVs     []float32           // u, v per vertex; nil for barycentric UVs
+	faces         []int32             // Three vertex indices per face, in BVH leaf order
+	faceNormals   []float32           // x, y, z per face; nil to compute the normals from the vertices
+	materials     []material.Material // Per face; nil when every face has the mesh's material
+	material      material.Material
+	cutout        bool          // Whether any face's material is alpha-masked
+	nodes         []compactNode // Depth first, the root first
+}
+
+// compactNode is a BVH node with float32 bounds, rounded outward so they still contain the faces.
+// An inner node's left child follows it in the array.
+type compactNode struct {
+	min, max [3]float32
+	offset   int32 // Leaves: the first face; inner nodes: the index of the right child
+	count    int32 // Faces in a leaf, -1 for inner nodes
+}
+
+// compactFace is a face of a compactMesh as a Shape, for building the mesh's BVH
+type compactFace struct {
+	mesh  *compactMesh
+	index int32
+}
+
+func (f compactFace) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	return f.mesh.triangle(int(f.index)).Hit(ray, tMin, tMax)
+}
+
+func (f compactFace) BoundingBox() AABB {
+	v0, v1, v2 := f.mesh.faceVertices(int(f.index))
+	return NewAABBFromPoints(v0, v1, v2)
+}
+
+// newCompactMesh stores a mesh's validated data in float32 and builds its BVH
+func newCompactMesh(vertices []core.Vec3, faces []int, vertexNormals []core.Vec3, vertexUVs []core.Vec2,
+	faceNormals []core.Vec3, materials []material.Material, mat material.Material) *compactMesh {
+	if len(vertices) > math.MaxInt32 || len(faces)/3 > math.MaxInt32 {
+		panic("Float32 triangle meshes are limited to 2^31 vertices and faces")
+	}
+
+	m := &compactMesh{
+		positions:     float32Vec3s(vertices),
+		vertexNormals: float32Vec3s(vertexNormals),
+		faceNormals:   float32Vec3s(faceNormals),
+		materials:     materials,
+		material:      mat,
+	}
+	_, m.cutout = mat.(material.AlphaMasked)
+	for _, faceMaterial := range materials {
+		if _, masked := faceMaterial.(material.AlphaMasked); masked {
+			m.cutout = true
+		}
+	}
+	if vertexUVs != nil {
+		m.vertexUVs = make([]float32, 0, 2*len(vertexUVs))
+		for _, uv := range vertexUVs {
+			m.vertexUVs = append(m.vertexUVs, float32(uv.X), float32(uv.Y))
+		}
+	}
+	m.faces = make([]int32, len(faces))
+	for i, index := range faces {
+		m.faces[i] = int32(index)
+	}
+
+	// Build the BVH over the faces, then flatten it with the faces in leaf order
+	shapes := make([]Shape, len(faces)/3)
+	for i := range shapes {
+		shapes[i] = compactFace{mesh: m, index: int32(i)}
+	}
+	bvh := NewBVH(shapes)
+	if bvh.Root == nil {
+		return m
+	}
+	order := make([]int32, 0, len(shapes))
+	m.flatten(bvh.Root, &order)
+	m.reorderFaces(order)
+	return m
+}
+
+// flatten appends a BVH node and its descendants to the node array, and their faces to order
+func (m *compactMesh) flatten(node *BVHNode, order *[]int32) {
+	if node.Shapes == nil && (node.Left == nil || node.Right == nil) {
+		// A node with one child has nothing to test that the child doesn't
+		if node.Left != nil {
+			m.flatten(node.Left, order)
+		} else if node.Right != nil {
+			m.flatten(node.Right, order)
+		}
+		return
+	}
+
+	index := len(m.nodes)
+	m.nodes = append(m.nodes, compactNode{})
+	compact := compactNode{}
+	for axis := 0; axis < 3; axis++ {
+		compact.min[axis] = float32Down(axisValue(node.BoundingBox.Min, axis))
+		compact.max[axis] = float32Up(axisValue(node.BoundingBox.Max, axis))
+	}
+
+	if node.Shapes != nil {
+		compact.offset = int32(len(*order))
+		compact.count = int32(len(node.Shapes))
+		for _, shape := range node.Shapes {
+			*order = append(*order, shape.(compactFace).index)
+		}
+	} else {
+		compact.count = -1
+		m.flatten(node.Left, order)
+		compact.offset = int32(len(m.nodes))
+		m.flatten(node.Right, order)
+	}
+	m.nodes[index] = compact
+}
+
+// reorderFaces puts the faces, and their normals and materials, in the given order
+func (m *compactMesh) reorderFaces(order []int32) {
+	faces := make([]int32, 0, len(m.faces))
+	var faceNormals []float32
+	if m.faceNormals != nil {
+		faceNormals = make([]float32, 0, len(m.faceNormals))
+	}
+	var materials []material.Material
+	if m.materials != nil {
+		materials = make([]material.Material, 0, len(m.materials))
+	}
+	for _, face := range order {
+		faces = append(faces, m.faces[3*face:3*face+3]...)
+		if faceNormals != nil {
+			faceNormals = append(faceNormals, m.faceNormals[3*face:3*face+3]...)
+		}
+		if materials != nil {
+			materials = append(materials, m.materials[face])
+		}
+	}
+	m.faces, m.faceNormals, m.materials = faces, faceNormals, materials
+}
+
+// hit finds the closest face hit by the ray, with the same arithmetic as the face's Triangle
+func (m *compactMesh) hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	if len(m.nodes) == 0 {
+		return nil, false
+	}
+	closest := -1
+	m.closestFace(0, ray, tMin, &tMax, &closest)
+	if closest < 0 {
+		return nil, false
+	}
+	return m.triangle(closest).Hit(ray, tMin, tMax)
+}
+
+// closestFace searches below a node for a face hit closer than tMax, updating tMax and closest
+func (m *compactMesh) closestFace(index int32, ray core.Ray, tMin float64, tMax *float64, closest *int) {
+	node := &m.nodes[index]
+	core.Count(core.BVHNodeVisits, 1)
+	if !node.bounds().Hit(ray, tMin, *tMax) {
+		return
+	}
+	if node.count >= 0 {
+		for face := int(node.offset); face < int(node.offset+node.count); face++ {
+			if t, isHit := m.intersectFace(face, ray, tMin, *tMax); isHit {
+				*tMax = t
+				*closest = face
+			}
+		}
+		return
+	}
+	m.closestFace(index+1, ray, tMin, tMax, closest)
+	m.closestFace(node.offset, ray, tMin, tMax, closest)
+}
+
+// hitAny reports whether the ray hits any face, stopping at the first found
+func (m *compactMesh) hitAny(ray core.Ray, tMin, tMax float64) bool {
+	return len(m.nodes) > 0 && m.hitAnyNode(0, ray, tMin, tMax)
+}
+
+// hitAnyNode looks for any face hit below a node
+func (m *compactMesh) hitAnyNode(index int32, ray core.Ray, tMin, tMax float64) bool {
+	node := &m.nodes[index]
+	core.Count(core.BVHNodeVisits, 1)
+	if !node.bounds().Hit(ray, tMin, tMax) {
+		return false
+	}
+	if node.count >= 0 {
+		for face := int(node.offset); face < int(node.offset+node.count); face++ {
+			if _, isHit := m.intersectFace(face, ray, tMin, tMax); isHit {
+				return true
+			}
+		}
+		return false
+	}
+	return m.hitAnyNode(index+1, ray, tMin, tMax) || m.hitAnyNode(node.offset, ray, tMin, tMax)
+}
+
+// intersectFace returns where the ray crosses a face, as Triangle.Hit computes it
+func (m *compactMesh) intersectFace(face int, ray core.Ray, tMin, tMax float64) (float64, bool) {
+	core.Count(core.ShapeTests, 1)
+	v0, v1, v2 := m.faceVertices(face)
+	triangle := Triangle{V0: v0, V1: v1, V2: v2}
+	t, _, _, isHit := triangle.intersect(ray, tMin, tMax, v1.Subtract(v0), v2.Subtract(v0))
+	if isHit && m.cutout {
+		// Only the full hit has the texture coordinates the alpha texture needs
+		hit, _ := m.triangle(face).Hit(ray, t, t)
+		isHit = hit != nil && material.IsOpaque(hit)
+	}
+	return t, isHit
+}
+
+// faceVertices returns the positions of a face's vertices
+func (m *compactMesh) faceVertices(face int) (core.Vec3, core.Vec3, core.Vec3) {
+	i := m.faces[3*face : 3*face+3]
+	return vec3At(m.positions, int(i[0])), vec3At(m.positions, int(i[1])), vec3At(m.positions, int(i[2]))
+}
+
+// triangle creates a face's Triangle, as NewTriangleMesh would have
+func (m *compactMesh) triangle(face int) *Triangle {
+	v0, v1, v2 := m.faceVertices(face)
+	f := meshFace{vertices: [3]core.Vec3{v0, v1, v2}}
+	i := m.faces[3*face : 3*face+3]
+	if m.vertexUVs != nil {
+		f.uvs = &[3]core.Vec2{vec2At(m.vertexUVs, int(i[0])), vec2At(m.vertexUVs, int(i[1])), vec2At(m.vertexUVs, int(i[2]))}
+	}
+	if m.faceNormals != nil {
+		normal := vec3At(m.faceNormals, face)
+		f.normal = &normal
+	}
+	if m.vertexNormals != nil {
+		f.vertexNormals = &[3]core.Vec3{vec3At(m.vertexNormals, int(i[0])), vec3At(m.vertexNormals, int(i[1])), vec3At(m.vertexNormals, int(i[2]))}
+	}
+	mat := m.material
+	if m.materials != nil {
+		mat = m.materials[face]
+	}
+	return f.triangle(mat)
+}
+
+// faceCount returns the number of faces
+func (m *compactMesh) faceCount() int {
+	return len(m.faces) / 3
+}
+
+// bounds returns the bounds of all the faces
+func (m *compactMesh) bounds() AABB {
+	if len(m.nodes) == 0 {
+		return AABB{}
+	}
+	return m.nodes[0].bounds()
+}
+
+// bounds returns the node's bounds in float64
+func (n *compactNode) bounds() AABB {
+	return AABB{
+		Min: core.NewVec3(float64(n.min[0]), float64(n.min[1]), float64(n.min[2])),
+		Max: core.NewVec3(float64(n.max[0]), float64(n.max[1]), float64(n.max[2])),
+	}
+}
+
+// float32Vec3s packs vectors as consecutive float32 x, y, z values; nil stays nil
+func float32Vec3s(vectors []core.Vec3) []float32 {
+	if vectors == nil {
+		return nil
+	}
+	values := make([]float32, 0, 3*len(vectors))
+	for _, v := range vectors {
+		values = append(values, float32(v.X), float32(v.Y), float32(v.Z))
+	}
+	return values
+}
+
+// vec3At unpacks vector i of values packed by float32Vec3s
+func vec3At(values []float32, i int) core.Vec3 {
+	return core.NewVec3(float64(values[3*i]), float64(values[3*i+1]), float64(values[3*i+2]))
+}
+
+// vec2At unpacks vector i of consecutive float32 u, v values
+func vec2At(values []float32, i int) core.Vec2 {
+	return core.NewVec2(float64(values[2*i]), float64(values[2*i+1]))
+}
+
+// float32Down returns the largest float32 not above x
+func float32Down(x float64) float32 {
+	f := float32(x)
+	if float64(f) > x {
+		f = math.Nextafter32(f, float32(math.Inf(-1)))
+	}
+	return f
+}
+
+// float32Up returns the smallest float32 not below x
+func float32Up(x float64) float32 {
+	f := float32(x)
+	if float64(f) < x {
+		f = math.Nextafter32(f, float32(math.Inf(1)))
+	}
+	return f
+}
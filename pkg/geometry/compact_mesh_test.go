@@ -0,0 +1,161 @@
+package geometry
+
+import (
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// gridMesh returns a bumpy n x n grid of vertices (exact in float32) and its faces
+func gridMesh(n int) ([]core.Vec3, []int) {
+	var vertices []core.Vec3
+	for z := 0; z <= n; z++ {
+		for x := 0; x <= n; x++ {
+			vertices = append(vertices, core.NewVec3(float64(x)-float64(n)/2, float64((x*7+z*3)%5)/8, float64(z)-float64(n)/2))
+		}
+	}
+	var faces []int
+	for z := 0; z < n; z++ {
+		for x := 0; x < n; x++ {
+			i := z*(n+1) + x
+			faces = append(faces, i, i+n+1, i+1, i+1, i+n+1, i+n+2)
+		}
+	}
+	return vertices, faces
+}
+
+func TestTriangleMesh_Float32MatchesFloat64(t *testing.T) {
+	vertices, faces := gridMesh(12)
+	uvs := make([]core.Vec2, len(vertices))
+	for i, v := range vertices {
+		uvs[i] = core.NewVec2(v.X/16+0.5, v.Z/16+0.5) // Exact in float32
+	}
+	materials := make([]material.Material, len(faces)/3)
+	for i := range materials {
+		materials[i] = material.NewLambertian(core.NewVec3(float64(i%4)/4, 0.5, 0.5))
+	}
+	options := TriangleMeshOptions{VertexUVs: uvs, Materials: materials, SmoothNormals: true}
+	reference := NewTriangleMesh(vertices, faces, nil, &options)
+	options.Float32 = true
+	compact := NewTriangleMesh(vertices, faces, nil, &options)
+
+	if compact.GetTriangleCount() != reference.GetTriangleCount() {
+		t.Fatalf("Expected %d triangles, got %d", reference.GetTriangleCount(), compact.GetTriangleCount())
+	}
+	if box := compact.BoundingBox(); box != reference.BoundingBox() {
+		t.Errorf("Expected bounds %v, got %v", reference.BoundingBox(), box)
+	}
+
+	sampler := core.NewSeededSampler(3)
+	hits := 0
+	for i := 0; i < 2000; i++ {
+		origin := core.NewVec3(sampler.Get1D()*16-8, 2+sampler.Get1D()*4, sampler.Get1D()*16-8)
+		target := core.NewVec3(sampler.Get1D()*14-7, sampler.Get1D()*0.6, sampler.Get1D()*14-7)
+		ray := core.NewRay(origin, target.Subtract(origin).Normalize())
+		tMax := 2 + sampler.Get1D()*10
+
+		want, wantHit := reference.Hit(ray, 0.001, tMax)
+		got, gotHit := compact.Hit(ray, 0.001, tMax)
+		if gotHit != wantHit {
+			t.Fatalf("Ray %v: float32 mesh hit=%v, float64 mesh hit=%v", ray, gotHit, wantHit)
+		}
+		if any := compact.HitAny(ray, 0.001, tMax); any != wantHit {
+			t.Fatalf("Ray %v: HitAny=%v, Hit=%v", ray, any, wantHit)
+		}
+		if !wantHit {
+			continue
+		}
+		hits++
+		// Smooth shading normals are rounded to float32
+		if got.T != want.T || got.Point != want.Point || got.UV != want.UV || got.Material != want.Material ||
+			got.Normal.Subtract(want.Normal).Length() > 1e-6 {
+			t.Fatalf("Ray %v: float32 mesh hit %+v, float64 mesh hit %+v", ray, got, want)
+		}
+	}
+	if hits < 500 {
+		t.Errorf("Expected most rays to hit the mesh, got %d hits", hits)
+	}
+
+	// The triangles can still be listed, in some order
+	triangles := compact.GetTriangles()
+	if len(triangles) != len(faces)/3 {
+		t.Errorf("Expected %d triangles, got %d", len(faces)/3, len(triangles))
+	}
+}
+
+func TestTriangleMesh_Float32BoundsContainFaces(t *testing.T) {
+	// Vertices that float32 can't represent exactly
+	sampler := core.NewSeededSampler(8)
+	var vertices []core.Vec3
+	var faces []int
+	for i := 0; i < 300; i++ {
+		a := core.NewVec3(sampler.Get1D()*100, sampler.Get1D()*100, sampler.Get1D()*100).Multiply(1.0 / 3)
+		vertices = append(vertices, a, a.Add(core.NewVec3(0.1, 0, 0)), a.Add(core.NewVec3(0, 0.1, 0.1)))
+		faces = append(faces, 3*i, 3*i+1, 3*i+2)
+	}
+	mesh := NewTriangleMesh(vertices, faces, nil, &TriangleMeshOptions{Float32: true})
+
+	for _, node := range mesh.compact.nodes {
+		box := node.bounds()
+		if node.count < 0 {
+			continue
+		}
+		for face := int(node.offset); face < int(node.offset+node.count); face++ {
+			v0, v1, v2 := mesh.compact.faceVertices(face)
+			for _, v := range []core.Vec3{v0, v1, v2} {
+				if v.X < box.Min.X || v.Y < box.Min.Y || v.Z < box.Min.Z || v.X > box.Max.X || v.Y > box.Max.Y || v.Z > box.Max.Z {
+					t.Fatalf("Vertex %v of face %d outside its leaf's bounds %v", v, face, box)
+				}
+			}
+		}
+	}
+
+	// Every face is hit by a ray through its centroid
+	for face := 0; face < mesh.GetTriangleCount(); face++ {
+		v0, v1, v2 := mesh.compact.faceVertices(face)
+		centroid := v0.Add(v1).Add(v2).Multiply(1.0 / 3)
+		normal := v1.Subtract(v0).Cross(v2.Subtract(v0)).Normalize()
+		ray := core.NewRay(centroid.Add(normal.Multiply(1e-3)), normal.Multiply(-1))
+		if !mesh.HitAny(ray, 0, 1) {
+			t.Fatalf("Ray through the centroid of face %d missed", face)
+		}
+	}
+}
+
+func TestFloat32Rounding(t *testing.T) {
+	for _, x := range []float64{0, 1.0 / 3, -1.0 / 3, 1e10 / 3, -7.1, math.Pi} {
+		down, up := float32Down(x), float32Up(x)
+		if float64(down) > x || float64(up) < x {
+			t.Errorf("%v: rounded to [%v, %v], which doesn't contain it", x, down, up)
+		}
+		if math.Nextafter32(down, float32(math.Inf(1))) < up {
+			t.Errorf("%v: [%v, %v] is wider than one float32 step", x, down, up)
+		}
+	}
+}
+
+// BenchmarkTriangleMesh_Float32Memory reports the heap a mesh keeps per triangle
+func BenchmarkTriangleMesh_Float32Memory(b *testing.B) {
+	vertices, faces := gridMesh(300)
+	for _, float32Storage := range []bool{false, true} {
+		name := "float64"
+		if float32Storage {
+			name = "float32"
+		}
+		b.Run(name, func(b *testing.B) {
+			var before, after runtime.MemStats
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				mesh := NewTriangleMesh(vertices, faces, nil, &TriangleMeshOptions{Float32: float32Storage})
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(mesh)
+			}
+			b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(len(faces)/3), "bytes/triangle")
+		})
+	}
+}
@@ -227,30 +227,12 @@ func (c *Cone) hitBody(ray core.Ray, tMin, tMax float64) *material.SurfaceIntera
 	// Radial vector from axis to point
 	radial := point.Subtract(centerPoint)
 
-	// Normal calculation: normalize(radial + (BaseRadius - TopRadius) / height * axis)
-	normalScale := (c.BaseRadius - c.TopRadius) / c.height
-	outwardNormal := radial.Add(c.axis.Multiply(normalScale)).Normalize()
+	// Normal calculation: normalize(radialUnit + tan(angle) * axis)
+	// The radial must be unit length so the slope term is scaled correctly at any radius
+	outwardNormal := radial.Normalize().Add(c.axis.Multiply(c.tanAngle)).Normalize()
 
 	// Compute UV coordinates
-	// V: height along cone (0 at base, 1 at top)
-	v := h / c.height
-
-	// U: angle around the axis (0 to 1 for full circle)
-	// Project radial onto a perpendicular plane to get angle
-	// Choose an arbitrary perpendicular vector for reference
-	var refVector core.Vec3
-	if math.Abs(c.axis.Y) < 0.9 {
-		refVector = core.NewVec3(0, 1, 0)
-	} else {
-		refVector = core.NewVec3(1, 0, 0)
-	}
-	tangent := c.axis.Cross(refVector).Normalize()
-	bitangent := c.axis.Cross(tangent)
-
-	u := math.Atan2(radial.Dot(bitangent), radial.Dot(tangent))
-	u = (u + math.Pi) / (2.0 * math.Pi) // Map from [-π, π] to [0, 1]
-
-	uv := core.NewVec2(u, v)
+	uv := bodyUV(radial, c.axis, h, c.height)
 
 	// Create hit record
 	hitRecord := &material.SurfaceInteraction{
@@ -348,3 +330,88 @@ func (c *Cone) hitCap(ray core.Ray, center, normal core.Vec3, radius, tMin, tMax
 
 	return hitRecord
 }
+
+// bodyArea returns the lateral area of the cone/frustum: π(R + r) * slant height
+func (c *Cone) bodyArea() float64 {
+	slant := math.Sqrt(c.height*c.height + (c.BaseRadius-c.TopRadius)*(c.BaseRadius-c.TopRadius))
+	return math.Pi * (c.BaseRadius + c.TopRadius) * slant
+}
+
+// SampleSurface implements the SurfaceSampler interface - samples uniformly over body and caps by area
+func (c *Cone) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	tangent, bitangent := axisFrame(c.axis)
+	u := sample.X
+
+	// Pick a cap proportionally to its share of the total area, then reuse the remapped sample
+	if c.Capped {
+		total := c.SurfaceArea()
+		baseFraction := math.Pi * c.BaseRadius * c.BaseRadius / total
+		topFraction := math.Pi * c.TopRadius * c.TopRadius / total
+		switch {
+		case u < baseFraction:
+			return sampleDiscFrame(c.BaseCenter, c.axis.Negate(), tangent, bitangent, c.BaseRadius, core.NewVec2(u/baseFraction, sample.Y))
+		case u < baseFraction+topFraction:
+			return sampleDiscFrame(c.TopCenter, c.axis, tangent, bitangent, c.TopRadius, core.NewVec2((u-baseFraction)/topFraction, sample.Y))
+		default:
+			u = (u - baseFraction - topFraction) / (1 - baseFraction - topFraction)
+		}
+	}
+
+	// Body: area density grows linearly with radius, so invert the CDF in radius²
+	baseR2 := c.BaseRadius * c.BaseRadius
+	radius := math.Sqrt(baseR2 - u*(baseR2-c.TopRadius*c.TopRadius))
+	h := (c.BaseRadius - radius) / c.tanAngle
+
+	phi := 2.0 * math.Pi * sample.Y
+	radial := tangent.Multiply(math.Cos(phi)).Add(bitangent.Multiply(math.Sin(phi)))
+	point := c.BaseCenter.Add(c.axis.Multiply(h)).Add(radial.Multiply(radius))
+	normal := radial.Add(c.axis.Multiply(c.tanAngle)).Normalize()
+	return point, normal
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the sampled surface
+func (c *Cone) AreaPDF(point core.Vec3) float64 {
+	if _, onSurface := c.surfaceNormal(point); !onSurface {
+		return 0.0
+	}
+	return 1.0 / c.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - returns the body or cap normal at a point
+func (c *Cone) NormalAt(point core.Vec3) core.Vec3 {
+	normal, _ := c.surfaceNormal(point)
+	return normal
+}
+
+// SurfaceArea implements the SurfaceSampler interface - lateral area plus caps when capped
+func (c *Cone) SurfaceArea() float64 {
+	area := c.bodyArea()
+	if c.Capped {
+		area += math.Pi * (c.BaseRadius*c.BaseRadius + c.TopRadius*c.TopRadius)
+	}
+	return area
+}
+
+// surfaceNormal classifies a point as lying on the body or a cap and returns its outward normal
+func (c *Cone) surfaceNormal(point core.Vec3) (core.Vec3, bool) {
+	const tolerance = 1e-6
+
+	h := point.Subtract(c.BaseCenter).Dot(c.axis)
+	radial := point.Subtract(c.BaseCenter.Add(c.axis.Multiply(h)))
+	distance := radial.Length()
+	radiusAtHeight := c.BaseRadius - c.tanAngle*h
+
+	// Caps take precedence so points near the rim keep the cap normal
+	if c.Capped {
+		if math.Abs(h) <= tolerance && distance <= c.BaseRadius+tolerance {
+			return c.axis.Negate(), true
+		}
+		if c.TopRadius > 0 && math.Abs(h-c.height) <= tolerance && distance <= c.TopRadius+tolerance {
+			return c.axis, true
+		}
+	}
+	if h >= -tolerance && h <= c.height+tolerance && math.Abs(distance-radiusAtHeight) <= tolerance && distance > 0 {
+		return radial.Multiply(1.0 / distance).Add(c.axis.Multiply(c.tanAngle)).Normalize(), true
+	}
+	return core.Vec3{}, false
+}
@@ -754,3 +754,74 @@ func TestCone_Capped_GlassRefraction_ConeSurface_ThenCap(t *testing.T) {
 		t.Errorf("Expected upward normal for base cap back-face hit, got %v", hit2.Normal)
 	}
 }
+
+func TestCone_Hit_NormalPerpendicularToSurface(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	// Wide frustum so the hit radius is well away from 1
+	cone, err := NewCone(core.NewVec3(0, 0, 0), 3.0, core.NewVec3(0, 2, 0), 1.0, false, mat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+
+	ray := core.NewRay(core.NewVec3(5, 1, 0), core.NewVec3(-1, 0, 0))
+	hit, isHit := cone.Hit(ray, 0.001, 1000.0)
+	if !isHit {
+		t.Fatal("Expected hit, but got miss")
+	}
+
+	// The slant line from base rim (3,0,0) to top rim (1,2,0) lies in the surface
+	slant := core.NewVec3(-2, 2, 0).Normalize()
+	if math.Abs(hit.Normal.Dot(slant)) > 1e-9 {
+		t.Errorf("Normal %v not perpendicular to slant direction %v", hit.Normal, slant)
+	}
+	if !approxEqualVec(hit.Normal, cone.NormalAt(hit.Point), 1e-9) {
+		t.Errorf("Hit normal %v doesn't match NormalAt %v", hit.Normal, cone.NormalAt(hit.Point))
+	}
+}
+
+func TestCone_UV(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cone, err := NewCone(core.NewVec3(0, 0, 0), 2.0, core.NewVec3(0, 2, 0), 1.0, true, mat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		origin   core.Vec3
+		dir      core.Vec3
+		expected core.Vec2
+	}{
+		{
+			// Axis is Y so the frame is tangent=(0,0,-1), bitangent=(-1,0,0): -Z maps to u=0.5, +X to u=0.25
+			name:     "body at +X, mid height",
+			origin:   core.NewVec3(5, 1, 0),
+			dir:      core.NewVec3(-1, 0, 0),
+			expected: core.NewVec2(0.25, 0.5),
+		},
+		{
+			name:     "body at -Z, quarter height",
+			origin:   core.NewVec3(0, 0.5, -5),
+			dir:      core.NewVec3(0, 0, 1),
+			expected: core.NewVec2(0.5, 0.25),
+		},
+		{
+			name:     "top cap center",
+			origin:   core.NewVec3(0, 5, 0),
+			dir:      core.NewVec3(0, -1, 0),
+			expected: core.NewVec2(0.5, 0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := cone.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0)
+			if !isHit {
+				t.Fatal("Expected hit, but got miss")
+			}
+			if math.Abs(hit.UV.X-tt.expected.X) > 1e-6 || math.Abs(hit.UV.Y-tt.expected.Y) > 1e-6 {
+				t.Errorf("Expected UV %v, got %v", tt.expected, hit.UV)
+			}
+		})
+	}
+}
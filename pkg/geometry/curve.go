@@ -0,0 +1,483 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// CurveType selects how a curve's width is turned into a surface
+type CurveType int
+
+const (
+	CurveFlat     CurveType = iota // A ribbon that always faces the ray, for hair and fur
+	CurveCylinder                  // A flat curve shaded with the normals of a tube
+	CurveRibbon                    // A ribbon oriented by normals given along the curve, for grass and feathers
+)
+
+// CurveBasis selects how a curve's control points define it
+type CurveBasis int
+
+const (
+	CurveBezier  CurveBasis = iota // Segments share their end points: 3n+1 cubic (2n+1 quadratic) points for n segments
+	CurveBSpline                   // Uniform B-spline: n+3 cubic (n+2 quadratic) points for n segments
+)
+
+// CurveOptions contains optional parameters for curve creation
+type CurveOptions struct {
+	Type    CurveType
+	Basis   CurveBasis
+	Degree  int         // 2 or 3; 0 means 3
+	Normals []core.Vec3 // Ribbon normals at the start of each segment and the end of the last one
+
+	// SplitDepth splits each segment into 2^SplitDepth pieces with their own bounds, so the
+	// BVH skips most of a long curved strand
+	SplitDepth int
+}
+
+// Curves represents a strand of cubic Bézier segments with a width, such as a hair
+// It uses an internal BVH over the segments, like TriangleMesh. Hits on materials that model
+// fibers, such as Hair, bind the fiber's direction and the offset of the hit across it (see
+// material.FiberMaterial).
+type Curves struct {
+	segments []Shape
+	bvh      *BVH
+	bbox     AABB
+	material material.Material
+}
+
+// curveCommon holds the values shared by the pieces of one strand
+type curveCommon struct {
+	curveType      CurveType
+	width0, width1 float64 // Width at the start and end of the strand, interpolated along it
+	segmentCount   int
+	material       material.Material
+}
+
+// curveSegment is a piece of one cubic Bézier segment of a strand
+type curveSegment struct {
+	cp         [4]core.Vec3 // Control points of the piece
+	uMin, uMax float64      // Range of the piece in its segment's parameter
+	index      int          // Segment of the strand the piece belongs to
+	common     *curveCommon
+
+	// Ribbon normals at the ends of the segment, interpolated spherically
+	normals           [2]core.Vec3
+	normalAngle       float64
+	invSinNormalAngle float64
+}
+
+// curveHit records where a ray met a curve piece
+type curveHit struct {
+	t     float64
+	u     float64      // Parameter in the piece's segment
+	v     float64      // Position across the curve, 0 to 1
+	frame [3]core.Vec3 // Ray space axes the hit was found in
+	dpdu  core.Vec3    // Curve derivative in ray space
+	n     core.Vec3    // Interpolated ribbon normal
+}
+
+// NewCurves creates a strand of curve segments from control points, whose width changes linearly
+// from width0 at its start to width1 at its end
+func NewCurves(points []core.Vec3, width0, width1 float64, mat material.Material, options CurveOptions) (*Curves, error) {
+	degree := options.Degree
+	if degree == 0 {
+		degree = 3
+	}
+	if degree != 2 && degree != 3 {
+		return nil, fmt.Errorf("curve degree must be 2 or 3, got %d", degree)
+	}
+	if width0 < 0 || width1 < 0 || width0+width1 == 0 {
+		return nil, fmt.Errorf("curve widths must be non-negative and not both zero (got %f, %f)", width0, width1)
+	}
+	if options.SplitDepth < 0 || options.SplitDepth > 10 {
+		return nil, fmt.Errorf("curve split depth must be in [0, 10], got %d", options.SplitDepth)
+	}
+
+	beziers, err := curveBeziers(points, options.Basis, degree)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Type == CurveRibbon {
+		if len(options.Normals) != len(beziers)+1 {
+			return nil, fmt.Errorf("ribbon curves need %d normals for %d segments, got %d", len(beziers)+1, len(beziers), len(options.Normals))
+		}
+	} else if options.Normals != nil {
+		return nil, fmt.Errorf("only ribbon curves take normals")
+	}
+
+	common := &curveCommon{
+		curveType:    options.Type,
+		width0:       width0,
+		width1:       width1,
+		segmentCount: len(beziers),
+		material:     mat,
+	}
+	pieces := 1 << options.SplitDepth
+	segments := make([]Shape, 0, len(beziers)*pieces)
+	for i, cp := range beziers {
+		var normals [2]core.Vec3
+		var normalAngle, invSinNormalAngle float64
+		if options.Type == CurveRibbon {
+			normals = [2]core.Vec3{options.Normals[i].Normalize(), options.Normals[i+1].Normalize()}
+			normalAngle = math.Acos(math.Min(math.Max(normals[0].Dot(normals[1]), -1), 1))
+			invSinNormalAngle = 1 / math.Sin(normalAngle)
+		}
+		for piece := 0; piece < pieces; piece++ {
+			uMin := float64(piece) / float64(pieces)
+			uMax := float64(piece+1) / float64(pieces)
+			segments = append(segments, &curveSegment{
+				cp:                blossomBezier(cp, uMin, uMax),
+				uMin:              uMin,
+				uMax:              uMax,
+				index:             i,
+				common:            common,
+				normals:           normals,
+				normalAngle:       normalAngle,
+				invSinNormalAngle: invSinNormalAngle,
+			})
+		}
+	}
+
+	bvh := NewBVH(segments)
+	return &Curves{
+		segments: segments,
+		bvh:      bvh,
+		bbox:     bvh.BoundingBox(),
+		material: mat,
+	}, nil
+}
+
+// curveBeziers converts control points in a basis to cubic Bézier segments
+func curveBeziers(points []core.Vec3, basis CurveBasis, degree int) ([][4]core.Vec3, error) {
+	var beziers [][4]core.Vec3
+	switch basis {
+	case CurveBezier:
+		if len(points) < degree+1 || (len(points)-1)%degree != 0 {
+			return nil, fmt.Errorf("degree %d Bézier curves need %dn+1 control points, got %d", degree, degree, len(points))
+		}
+		for i := 0; i+degree < len(points); i += degree {
+			if degree == 3 {
+				beziers = append(beziers, [4]core.Vec3{points[i], points[i+1], points[i+2], points[i+3]})
+			} else {
+				beziers = append(beziers, elevateQuadratic(points[i], points[i+1], points[i+2]))
+			}
+		}
+	case CurveBSpline:
+		if len(points) < degree+1 {
+			return nil, fmt.Errorf("degree %d B-spline curves need at least %d control points, got %d", degree, degree+1, len(points))
+		}
+		for i := 0; i+degree < len(points); i++ {
+			if degree == 3 {
+				p0, p1, p2, p3 := points[i], points[i+1], points[i+2], points[i+3]
+				beziers = append(beziers, [4]core.Vec3{
+					p0.Add(p1.Multiply(4)).Add(p2).Multiply(1.0 / 6),
+					p1.Multiply(2).Add(p2).Multiply(1.0 / 3),
+					p1.Add(p2.Multiply(2)).Multiply(1.0 / 3),
+					p1.Add(p2.Multiply(4)).Add(p3).Multiply(1.0 / 6),
+				})
+			} else {
+				p0, p1, p2 := points[i], points[i+1], points[i+2]
+				beziers = append(beziers, elevateQuadratic(p0.Add(p1).Multiply(0.5), p1, p1.Add(p2).Multiply(0.5)))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown curve basis %d", basis)
+	}
+	return beziers, nil
+}
+
+// elevateQuadratic returns the cubic Bézier control points of a quadratic Bézier curve
+func elevateQuadratic(q0, q1, q2 core.Vec3) [4]core.Vec3 {
+	return [4]core.Vec3{
+		q0,
+		q0.Add(q1.Subtract(q0).Multiply(2.0 / 3)),
+		q2.Add(q1.Subtract(q2).Multiply(2.0 / 3)),
+		q2,
+	}
+}
+
+// Hit tests if a ray intersects with any segment of the strand
+func (c *Curves) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	return c.bvh.Hit(ray, tMin, tMax)
+}
+
+// AlphaMasked implements the MaskedShape interface: HitAny tests each segment's own holes
+func (c *Curves) AlphaMasked() bool {
+	return false
+}
+
+// HitAny reports whether a ray intersects the strand, stopping at the first segment found
+func (c *Curves) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	return c.bvh.HitAny(ray, tMin, tMax)
+}
+
+// BoundingBox returns the axis-aligned bounding box for the entire strand
+func (c *Curves) BoundingBox() AABB {
+	return c.bbox
+}
+
+// GetSegmentCount returns the number of pieces the strand's BVH holds
+func (c *Curves) GetSegmentCount() int {
+	return len(c.segments)
+}
+
+// BoundingBox returns the control point bounds, widened by half the piece's widest width
+func (s *curveSegment) BoundingBox() AABB {
+	return NewAABBFromPoints(s.cp[0], s.cp[1], s.cp[2], s.cp[3]).Expand(s.maxWidth(s.uMin, s.uMax) / 2)
+}
+
+// Hit tests if a ray intersects with the curve piece
+func (s *curveSegment) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	record, isHit := s.intersect(ray, tMin, tMax, false)
+	if !isHit {
+		return nil, false
+	}
+
+	toWorld := func(v core.Vec3) core.Vec3 {
+		return record.frame[0].Multiply(v.X).Add(record.frame[1].Multiply(v.Y)).Add(record.frame[2].Multiply(v.Z))
+	}
+	tangent := toWorld(record.dpdu).Normalize()
+
+	// The direction across the curve in which v grows, and the normal of the surface there
+	var offsetDirection, outwardNormal core.Vec3
+	h := 2*record.v - 1
+	if s.common.curveType == CurveRibbon {
+		offsetDirection = record.n.Cross(tangent).Normalize()
+		outwardNormal = record.n
+	} else {
+		offsetDirection = toWorld(core.NewVec3(-record.dpdu.Y, record.dpdu.X, 0)).Normalize()
+		facing := offsetDirection.Cross(tangent) // Toward the ray's origin
+		outwardNormal = facing
+		if s.common.curveType == CurveCylinder {
+			outwardNormal = offsetDirection.Multiply(h).Add(facing.Multiply(math.Sqrt(math.Max(0, 1-h*h))))
+		}
+	}
+
+	mat := s.common.material
+	if fiber, ok := mat.(material.FiberMaterial); ok {
+		mat = fiber.AtFiber(tangent, offsetDirection, h)
+	}
+
+	hitRecord := &material.SurfaceInteraction{
+		T:        record.t,
+		Point:    ray.At(record.t),
+		Material: mat,
+		UV:       core.NewVec2(s.strandU(record.u), record.v),
+	}
+	hitRecord.SetFaceNormal(ray, outwardNormal)
+	return hitRecord, true
+}
+
+// AlphaMasked implements the MaskedShape interface
+func (s *curveSegment) AlphaMasked() bool {
+	return hasAlphaMask(s.common.material)
+}
+
+// HitAny reports whether a ray intersects the curve piece, without building the hit record (see Occluder)
+func (s *curveSegment) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, isHit := s.intersect(ray, tMin, tMax, true)
+	return isHit
+}
+
+// intersect finds the ray's nearest intersection with the piece in [tMin, tMax], or any if anyHit
+// As in PBRT, the piece is moved into a frame looking down the ray and subdivided until each part
+// is nearly straight; a part is hit if the ray passes within half its width of the part's line.
+func (s *curveSegment) intersect(ray core.Ray, tMin, tMax float64, anyHit bool) (curveHit, bool) {
+	rayLength := ray.Direction.Length()
+	if rayLength == 0 {
+		return curveHit{}, false
+	}
+
+	// Ray space: the ray starts at the origin and runs along z
+	z := ray.Direction.Multiply(1 / rayLength)
+	x := z.Cross(s.cp[3].Subtract(s.cp[0]))
+	if x.LengthSquared() == 0 {
+		x, _ = axisFrame(z)
+	} else {
+		x = x.Normalize()
+	}
+	y := z.Cross(x)
+	frame := [3]core.Vec3{x, y, z}
+	var cp [4]core.Vec3
+	for i, p := range s.cp {
+		offset := p.Subtract(ray.Origin)
+		cp[i] = core.NewVec3(offset.Dot(x), offset.Dot(y), offset.Dot(z))
+	}
+
+	// Subdivide until the parts are within 5% of the width of straight
+	curvature := 0.0
+	for i := 0; i < 2; i++ {
+		d := cp[i].Subtract(cp[i+1].Multiply(2)).Add(cp[i+2])
+		curvature = math.Max(curvature, math.Max(math.Abs(d.X), math.Max(math.Abs(d.Y), math.Abs(d.Z))))
+	}
+	depth := 0
+	if epsilon := s.maxWidth(s.uMin, s.uMax) * 0.05; curvature > 0 && epsilon > 0 {
+		r0 := math.Log2(math.Sqrt2*6*curvature/(8*epsilon)) / 2
+		depth = int(math.Min(math.Max(r0, 0), 10))
+	}
+
+	record := curveHit{t: tMax}
+	found := s.recursiveIntersect(ray, rayLength, &cp, s.uMin, s.uMax, depth, tMin, anyHit, &record)
+	if found {
+		record.frame = frame
+	}
+	return record, found
+}
+
+// recursiveIntersect tests a part of the piece in ray space, keeping the nearest hit in record
+func (s *curveSegment) recursiveIntersect(ray core.Ray, rayLength float64, cp *[4]core.Vec3, u0, u1 float64, depth int, tMin float64, anyHit bool, record *curveHit) bool {
+	if depth > 0 {
+		split := subdivideBezier(*cp)
+		u := [3]float64{u0, (u0 + u1) / 2, u1}
+		found := false
+		for half := 0; half < 2; half++ {
+			part := [4]core.Vec3{split[3*half], split[3*half+1], split[3*half+2], split[3*half+3]}
+			halfWidth := s.maxWidth(u[half], u[half+1]) / 2
+			box := NewAABBFromPoints(part[0], part[1], part[2], part[3])
+			if box.Max.X+halfWidth < 0 || box.Min.X-halfWidth > 0 ||
+				box.Max.Y+halfWidth < 0 || box.Min.Y-halfWidth > 0 ||
+				box.Max.Z+halfWidth < tMin*rayLength || box.Min.Z-halfWidth > record.t*rayLength {
+				continue
+			}
+			if s.recursiveIntersect(ray, rayLength, &part, u[half], u[half+1], depth-1, tMin, anyHit, record) {
+				found = true
+				if anyHit {
+					return true
+				}
+			}
+		}
+		return found
+	}
+
+	// The ray must pass between the lines through the part's ends, perpendicular to its tangents
+	if (cp[1].Y-cp[0].Y)*-cp[0].Y+cp[0].X*(cp[0].X-cp[1].X) < 0 {
+		return false
+	}
+	if (cp[2].Y-cp[3].Y)*-cp[3].Y+cp[3].X*(cp[3].X-cp[2].X) < 0 {
+		return false
+	}
+
+	// Project the ray onto the part's chord to find where along the part it passes
+	chord := core.NewVec2(cp[3].X-cp[0].X, cp[3].Y-cp[0].Y)
+	denominator := chord.X*chord.X + chord.Y*chord.Y
+	if denominator == 0 {
+		return false
+	}
+	w := math.Min(math.Max((-cp[0].X*chord.X-cp[0].Y*chord.Y)/denominator, 0), 1)
+	u := math.Min(math.Max(u0+w*(u1-u0), u0), u1)
+
+	// Ribbons are narrower seen edge on
+	width := s.widthAt(u)
+	var normal core.Vec3
+	if s.common.curveType == CurveRibbon {
+		normal = s.ribbonNormal(u)
+		width *= normal.AbsDot(ray.Direction) / rayLength
+	}
+
+	point, derivative := evalBezier(*cp, w)
+	distanceSquared := point.X*point.X + point.Y*point.Y
+	if distanceSquared > width*width/4 {
+		return false
+	}
+	// A ray leaving the curve mustn't hit it again: it starts inside the curve's width, where a
+	// flat curve turned to face it would otherwise catch it
+	if point.Z < math.Max(tMin*rayLength, width) || point.Z > record.t*rayLength {
+		return false
+	}
+
+	// v runs across the curve, increasing toward the perpendicular (-y, x) of its tangent
+	offset := math.Sqrt(distanceSquared) / width
+	v := 0.5 - offset
+	if derivative.X*-point.Y+point.X*derivative.Y > 0 {
+		v = 0.5 + offset
+	}
+
+	record.t = point.Z / rayLength
+	record.u = u
+	record.v = v
+	record.dpdu = derivative
+	record.n = normal
+	return true
+}
+
+// strandU maps a parameter in the piece's segment to the parameter along the whole strand
+func (s *curveSegment) strandU(u float64) float64 {
+	return (float64(s.index) + u) / float64(s.common.segmentCount)
+}
+
+// widthAt returns the curve's width at a parameter in the piece's segment
+func (s *curveSegment) widthAt(u float64) float64 {
+	strandU := s.strandU(u)
+	return (1-strandU)*s.common.width0 + strandU*s.common.width1
+}
+
+// maxWidth returns the widest the curve gets between two parameters in the piece's segment
+func (s *curveSegment) maxWidth(u0, u1 float64) float64 {
+	return math.Max(s.widthAt(u0), s.widthAt(u1))
+}
+
+// ribbonNormal interpolates the segment's end normals spherically
+func (s *curveSegment) ribbonNormal(u float64) core.Vec3 {
+	if s.normalAngle < 1e-6 {
+		return s.normals[0]
+	}
+	sin0 := math.Sin((1-u)*s.normalAngle) * s.invSinNormalAngle
+	sin1 := math.Sin(u*s.normalAngle) * s.invSinNormalAngle
+	return s.normals[0].Multiply(sin0).Add(s.normals[1].Multiply(sin1))
+}
+
+// evalBezier returns the point and derivative of a cubic Bézier curve at t
+func evalBezier(cp [4]core.Vec3, t float64) (core.Vec3, core.Vec3) {
+	lerp := func(a, b core.Vec3) core.Vec3 {
+		return a.Multiply(1 - t).Add(b.Multiply(t))
+	}
+	cp1 := [3]core.Vec3{lerp(cp[0], cp[1]), lerp(cp[1], cp[2]), lerp(cp[2], cp[3])}
+	cp2 := [2]core.Vec3{lerp(cp1[0], cp1[1]), lerp(cp1[1], cp1[2])}
+
+	derivative := cp2[1].Subtract(cp2[0]).Multiply(3)
+	if derivative.LengthSquared() == 0 {
+		// The derivative vanishes at an end whose control point coincides with the end point
+		derivative = cp[3].Subtract(cp[0])
+	}
+	return lerp(cp2[0], cp2[1]), derivative
+}
+
+// subdivideBezier splits a cubic Bézier curve in half, returning the seven control points of the halves
+func subdivideBezier(cp [4]core.Vec3) [7]core.Vec3 {
+	mid := func(a, b core.Vec3) core.Vec3 {
+		return a.Add(b).Multiply(0.5)
+	}
+	return [7]core.Vec3{
+		cp[0],
+		mid(cp[0], cp[1]),
+		cp[0].Add(cp[1].Multiply(2)).Add(cp[2]).Multiply(0.25),
+		cp[0].Add(cp[1].Multiply(3)).Add(cp[2].Multiply(3)).Add(cp[3]).Multiply(0.125),
+		cp[1].Add(cp[2].Multiply(2)).Add(cp[3]).Multiply(0.25),
+		mid(cp[2], cp[3]),
+		cp[3],
+	}
+}
+
+// blossomBezier returns the control points of the part of a cubic Bézier curve between u0 and u1
+func blossomBezier(cp [4]core.Vec3, u0, u1 float64) [4]core.Vec3 {
+	return [4]core.Vec3{
+		blossom(cp, u0, u0, u0),
+		blossom(cp, u0, u0, u1),
+		blossom(cp, u0, u1, u1),
+		blossom(cp, u1, u1, u1),
+	}
+}
+
+// blossom evaluates the polar form of a cubic Bézier curve
+func blossom(cp [4]core.Vec3, u0, u1, u2 float64) core.Vec3 {
+	lerp := func(t float64, a, b core.Vec3) core.Vec3 {
+		return a.Multiply(1 - t).Add(b.Multiply(t))
+	}
+	a := [3]core.Vec3{lerp(u0, cp[0], cp[1]), lerp(u0, cp[1], cp[2]), lerp(u0, cp[2], cp[3])}
+	b := [2]core.Vec3{lerp(u1, a[0], a[1]), lerp(u1, a[1], a[2])}
+	return lerp(u2, b[0], b[1])
+}
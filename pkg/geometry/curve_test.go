@@ -0,0 +1,266 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// straightCurve returns a single straight cubic segment along x from -1 to 1
+func straightCurve(t *testing.T, width float64, options CurveOptions) *Curves {
+	t.Helper()
+	points := []core.Vec3{
+		core.NewVec3(-1, 0, 0), core.NewVec3(-1.0/3, 0, 0), core.NewVec3(1.0/3, 0, 0), core.NewVec3(1, 0, 0),
+	}
+	curves, err := NewCurves(points, width, width, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), options)
+	if err != nil {
+		t.Fatalf("NewCurves failed: %v", err)
+	}
+	return curves
+}
+
+func TestNewCurves_Validation(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	p := func(x float64) core.Vec3 { return core.NewVec3(x, 0, 0) }
+	tests := []struct {
+		name        string
+		points      []core.Vec3
+		width0      float64
+		options     CurveOptions
+		expectError bool
+	}{
+		{"one cubic segment", []core.Vec3{p(0), p(1), p(2), p(3)}, 0.1, CurveOptions{}, false},
+		{"two cubic segments", []core.Vec3{p(0), p(1), p(2), p(3), p(4), p(5), p(6)}, 0.1, CurveOptions{}, false},
+		{"wrong cubic point count", []core.Vec3{p(0), p(1), p(2), p(3), p(4)}, 0.1, CurveOptions{}, true},
+		{"quadratic", []core.Vec3{p(0), p(1), p(2), p(3), p(4)}, 0.1, CurveOptions{Degree: 2}, false},
+		{"b-spline", []core.Vec3{p(0), p(1), p(2), p(3), p(4)}, 0.1, CurveOptions{Basis: CurveBSpline}, false},
+		{"too few b-spline points", []core.Vec3{p(0), p(1), p(2)}, 0.1, CurveOptions{Basis: CurveBSpline}, true},
+		{"unsupported degree", []core.Vec3{p(0), p(1)}, 0.1, CurveOptions{Degree: 1}, true},
+		{"zero width", []core.Vec3{p(0), p(1), p(2), p(3)}, 0, CurveOptions{}, true},
+		{"ribbon without normals", []core.Vec3{p(0), p(1), p(2), p(3)}, 0.1, CurveOptions{Type: CurveRibbon}, true},
+		{"normals on a flat curve", []core.Vec3{p(0), p(1), p(2), p(3)}, 0.1, CurveOptions{Normals: []core.Vec3{p(1), p(1)}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCurves(tt.points, tt.width0, tt.width0, mat, tt.options)
+			if tt.expectError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("NewCurves failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestCurves_HitStraight(t *testing.T) {
+	curves := straightCurve(t, 0.2, CurveOptions{})
+
+	tests := []struct {
+		name      string
+		ray       core.Ray
+		shouldHit bool
+		wantT     float64
+		wantU     float64
+		wantV     float64
+	}{
+		{"center from above", core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)), true, 5, 0.5, 0.5},
+		{"within half width", core.NewRay(core.NewVec3(0.5, 5, 0.09), core.NewVec3(0, -1, 0)), true, 5, 0.75, -1},
+		{"beyond half width", core.NewRay(core.NewVec3(0.5, 5, 0.11), core.NewVec3(0, -1, 0)), false, 0, 0, 0},
+		{"past the end", core.NewRay(core.NewVec3(1.1, 5, 0), core.NewVec3(0, -1, 0)), false, 0, 0, 0},
+		{"from the side", core.NewRay(core.NewVec3(0.25, 0, -4), core.NewVec3(0, 0, 1)), true, 4, 0.625, 0.5},
+		{"along the curve", core.NewRay(core.NewVec3(-5, 0, 0), core.NewVec3(1, 0, 0)), false, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := curves.Hit(tt.ray, 0.001, 100)
+			if isHit != tt.shouldHit {
+				t.Fatalf("Expected hit=%v, got %v", tt.shouldHit, isHit)
+			}
+			if curves.HitAny(tt.ray, 0.001, 100) != tt.shouldHit {
+				t.Errorf("HitAny disagrees with Hit")
+			}
+			if !tt.shouldHit {
+				return
+			}
+			if math.Abs(hit.T-tt.wantT) > 1e-6 {
+				t.Errorf("Expected t=%f, got %f", tt.wantT, hit.T)
+			}
+			if math.Abs(hit.UV.X-tt.wantU) > 1e-6 {
+				t.Errorf("Expected u=%f, got %f", tt.wantU, hit.UV.X)
+			}
+			if tt.wantV >= 0 && math.Abs(hit.UV.Y-tt.wantV) > 1e-6 {
+				t.Errorf("Expected v=%f, got %f", tt.wantV, hit.UV.Y)
+			}
+			if hit.Normal.Dot(tt.ray.Direction) >= 0 {
+				t.Errorf("Expected the normal %v to face the ray", hit.Normal)
+			}
+		})
+	}
+}
+
+func TestCurves_HitRespectsRange(t *testing.T) {
+	curves := straightCurve(t, 0.2, CurveOptions{})
+	ray := core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0))
+	if _, isHit := curves.Hit(ray, 0.001, 4.9); isHit {
+		t.Error("Expected no hit before tMax")
+	}
+	if _, isHit := curves.Hit(ray, 5.1, 100); isHit {
+		t.Error("Expected no hit after tMin")
+	}
+
+	// A ray leaving the curve from its surface doesn't hit it again
+	leaving := core.NewRay(core.NewVec3(0, 0, 0.05), core.NewVec3(0, 0.3, 1).Normalize())
+	if curves.HitAny(leaving, 0.001, 100) {
+		t.Error("Expected a ray leaving the curve not to hit it")
+	}
+}
+
+func TestCurves_WidthInterpolation(t *testing.T) {
+	points := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(2, 0, 0), core.NewVec3(3, 0, 0),
+		core.NewVec3(4, 0, 0), core.NewVec3(5, 0, 0), core.NewVec3(6, 0, 0),
+	}
+	curves, err := NewCurves(points, 0.4, 0, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), CurveOptions{})
+	if err != nil {
+		t.Fatalf("NewCurves failed: %v", err)
+	}
+
+	// The strand is 0.2 wide halfway along, across both segments
+	hitAt := func(x, z float64) bool {
+		return curves.HitAny(core.NewRay(core.NewVec3(x, 5, z), core.NewVec3(0, -1, 0)), 0.001, 100)
+	}
+	if !hitAt(3, 0.09) || hitAt(3, 0.11) {
+		t.Error("Expected a half width of 0.1 at the middle of the strand")
+	}
+	if !hitAt(1.5, 0.14) || hitAt(1.5, 0.16) {
+		t.Error("Expected a half width of 0.15 a quarter along the strand")
+	}
+}
+
+func TestCurves_HitCurved(t *testing.T) {
+	// A quarter circle arc of radius 1 in the xz plane, seen from above
+	k := 4.0 / 3 * (math.Sqrt2 - 1)
+	points := []core.Vec3{core.NewVec3(1, 0, 0), core.NewVec3(1, 0, k), core.NewVec3(k, 0, 1), core.NewVec3(0, 0, 1)}
+	for _, splitDepth := range []int{0, 3} {
+		curves, err := NewCurves(points, 0.05, 0.05, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), CurveOptions{SplitDepth: splitDepth})
+		if err != nil {
+			t.Fatalf("NewCurves failed: %v", err)
+		}
+		if curves.GetSegmentCount() != 1<<splitDepth {
+			t.Errorf("Expected %d pieces, got %d", 1<<splitDepth, curves.GetSegmentCount())
+		}
+
+		for _, angle := range []float64{0.1, 0.5, 0.8, 1.4} {
+			onArc := core.NewVec3(math.Cos(angle), 0, math.Sin(angle))
+			hit, isHit := curves.Hit(core.NewRay(onArc.Add(core.NewVec3(0, 3, 0)), core.NewVec3(0, -1, 0)), 0.001, 100)
+			if !isHit {
+				t.Fatalf("Split depth %d: expected a hit on the arc at angle %f", splitDepth, angle)
+			}
+			if math.Abs(hit.T-3) > 1e-3 {
+				t.Errorf("Split depth %d: expected t=3, got %f", splitDepth, hit.T)
+			}
+			// Points inside and outside the arc miss
+			for _, scale := range []float64{0.95, 1.05} {
+				off := onArc.Multiply(scale).Add(core.NewVec3(0, 3, 0))
+				if curves.HitAny(core.NewRay(off, core.NewVec3(0, -1, 0)), 0.001, 100) {
+					t.Errorf("Split depth %d: expected a miss at radius %f", splitDepth, scale)
+				}
+			}
+		}
+	}
+}
+
+func TestCurves_BSplineEndPoints(t *testing.T) {
+	// A uniform cubic B-spline on evenly spaced collinear points runs from the second to the second
+	// to last point
+	points := []core.Vec3{core.NewVec3(-2, 0, 0), core.NewVec3(-1, 0, 0), core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(2, 0, 0)}
+	curves, err := NewCurves(points, 0.1, 0.1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), CurveOptions{Basis: CurveBSpline})
+	if err != nil {
+		t.Fatalf("NewCurves failed: %v", err)
+	}
+	box := curves.BoundingBox()
+	if math.Abs(box.Min.X+1.05) > 1e-9 || math.Abs(box.Max.X-1.05) > 1e-9 {
+		t.Errorf("Expected the strand to span x in [-1, 1] plus half its width, got %v", box)
+	}
+
+	// Quadratic B-splines start and end halfway between their first and last two points
+	quadratic, err := NewCurves(points[:3], 0.1, 0.1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), CurveOptions{Basis: CurveBSpline, Degree: 2})
+	if err != nil {
+		t.Fatalf("NewCurves failed: %v", err)
+	}
+	box = quadratic.BoundingBox()
+	if math.Abs(box.Min.X+1.55) > 1e-9 || math.Abs(box.Max.X+0.45) > 1e-9 {
+		t.Errorf("Expected the quadratic strand to span x in [-1.5, -0.5] plus half its width, got %v", box)
+	}
+}
+
+func TestCurves_CylinderNormals(t *testing.T) {
+	curves := straightCurve(t, 0.2, CurveOptions{Type: CurveCylinder})
+
+	// Looking down -y at a tube along x, the normal tilts toward z with the offset
+	for _, z := range []float64{-0.08, -0.05, 0, 0.05, 0.08} {
+		hit, isHit := curves.Hit(core.NewRay(core.NewVec3(0, 5, z), core.NewVec3(0, -1, 0)), 0.001, 100)
+		if !isHit {
+			t.Fatalf("Expected a hit at z=%f", z)
+		}
+		want := core.NewVec3(0, math.Sqrt(1-(z/0.1)*(z/0.1)), z/0.1)
+		if hit.Normal.Subtract(want).Length() > 1e-6 {
+			t.Errorf("z=%f: expected normal %v, got %v", z, want, hit.Normal)
+		}
+	}
+}
+
+func TestCurves_RibbonNormals(t *testing.T) {
+	// A ribbon along x that twists from facing +y to facing +z
+	normals := []core.Vec3{core.NewVec3(0, 1, 0), core.NewVec3(0, 0, 1)}
+	curves := straightCurve(t, 0.2, CurveOptions{Type: CurveRibbon, Normals: normals})
+
+	hit, isHit := curves.Hit(core.NewRay(core.NewVec3(-1, 5, 0), core.NewVec3(0, -1, 0)), 0.001, 100)
+	if !isHit {
+		t.Fatal("Expected a hit on the start of the ribbon")
+	}
+	if hit.Normal.Subtract(core.NewVec3(0, 1, 0)).Length() > 1e-6 {
+		t.Errorf("Expected normal +y at the start, got %v", hit.Normal)
+	}
+
+	hit, isHit = curves.Hit(core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)), 0.001, 100)
+	if !isHit {
+		t.Fatal("Expected a hit on the middle of the ribbon")
+	}
+	want := core.NewVec3(0, 1, 1).Normalize()
+	if hit.Normal.Subtract(want).Length() > 1e-6 {
+		t.Errorf("Expected normal %v in the middle, got %v", want, hit.Normal)
+	}
+
+	// Seen edge on at the end, the ribbon vanishes
+	if curves.HitAny(core.NewRay(core.NewVec3(0.999, 5, 0.05), core.NewVec3(0, -1, 0)), 0.001, 100) {
+		t.Error("Expected the ribbon to be invisible edge on")
+	}
+}
+
+func TestCurves_BindsFiberMaterials(t *testing.T) {
+	hair := material.NewHair(material.HairAbsorptionFromMelanin(1.3, 0), 1.55, 0.3, 0.3, 2)
+	points := []core.Vec3{
+		core.NewVec3(-1, 0, 0), core.NewVec3(-1.0/3, 0, 0), core.NewVec3(1.0/3, 0, 0), core.NewVec3(1, 0, 0),
+	}
+	curves, err := NewCurves(points, 0.2, 0.2, hair, CurveOptions{})
+	if err != nil {
+		t.Fatalf("NewCurves failed: %v", err)
+	}
+
+	hit, isHit := curves.Hit(core.NewRay(core.NewVec3(0, 5, 0.05), core.NewVec3(0, -1, 0)), 0.001, 100)
+	if !isHit {
+		t.Fatal("Expected a hit")
+	}
+	if hit.Material == material.Material(hair) {
+		t.Error("Expected the hit to bind the hair to its fiber")
+	}
+	if _, ok := hit.Material.(material.Translucent); !ok {
+		t.Error("Expected the bound fiber to be translucent")
+	}
+}
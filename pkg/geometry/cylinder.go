@@ -185,24 +185,7 @@ func (c *Cylinder) hitBody(ray core.Ray, tMin, tMax float64) *material.SurfaceIn
 	outwardNormal := point.Subtract(axisPoint).Normalize()
 
 	// Compute UV coordinates
-	// V: height along cylinder (0 at base, 1 at top)
-	v := h / c.height
-
-	// U: angle around the axis (0 to 1 for full circle)
-	radial := point.Subtract(axisPoint)
-	var refVector core.Vec3
-	if math.Abs(c.axis.Y) < 0.9 {
-		refVector = core.NewVec3(0, 1, 0)
-	} else {
-		refVector = core.NewVec3(1, 0, 0)
-	}
-	tangent := c.axis.Cross(refVector).Normalize()
-	bitangent := c.axis.Cross(tangent)
-
-	u := math.Atan2(radial.Dot(bitangent), radial.Dot(tangent))
-	u = (u + math.Pi) / (2.0 * math.Pi) // Map from [-π, π] to [0, 1]
-
-	uv := core.NewVec2(u, v)
+	uv := bodyUV(point.Subtract(axisPoint), c.axis, h, c.height)
 
 	hitRecord := &material.SurfaceInteraction{
 		T:        t,
@@ -264,3 +247,106 @@ func (c *Cylinder) hitCap(ray core.Ray, center, normal core.Vec3, tMin, tMax flo
 
 	return hitRecord
 }
+
+// axisFrame returns the tangent/bitangent pair perpendicular to an axis
+// Matches the frame used for angular UV mapping on cylinder and cone bodies
+func axisFrame(axis core.Vec3) (core.Vec3, core.Vec3) {
+	var refVector core.Vec3
+	if math.Abs(axis.Y) < 0.9 {
+		refVector = core.NewVec3(0, 1, 0)
+	} else {
+		refVector = core.NewVec3(1, 0, 0)
+	}
+	tangent := axis.Cross(refVector).Normalize()
+	bitangent := axis.Cross(tangent)
+	return tangent, bitangent
+}
+
+// bodyUV maps a point on a cylinder or cone body to UV coordinates
+// U: angle around the axis (0 to 1 for full circle), V: height along the axis (0 at base, 1 at top)
+func bodyUV(radial, axis core.Vec3, h, height float64) core.Vec2 {
+	tangent, bitangent := axisFrame(axis)
+	u := math.Atan2(radial.Dot(bitangent), radial.Dot(tangent))
+	u = (u + math.Pi) / (2.0 * math.Pi) // Map from [-π, π] to [0, 1]
+	return core.NewVec2(u, h/height)
+}
+
+// SampleSurface implements the SurfaceSampler interface - samples uniformly over body and caps by area
+func (c *Cylinder) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	tangent, bitangent := axisFrame(c.axis)
+	u := sample.X
+
+	// Pick a cap proportionally to its share of the total area, then reuse the remapped sample
+	if c.Capped {
+		total := c.SurfaceArea()
+		capFraction := math.Pi * c.Radius * c.Radius / total
+		switch {
+		case u < capFraction:
+			return sampleDiscFrame(c.BaseCenter, c.axis.Negate(), tangent, bitangent, c.Radius, core.NewVec2(u/capFraction, sample.Y))
+		case u < 2*capFraction:
+			return sampleDiscFrame(c.TopCenter, c.axis, tangent, bitangent, c.Radius, core.NewVec2((u-capFraction)/capFraction, sample.Y))
+		default:
+			u = (u - 2*capFraction) / (1 - 2*capFraction)
+		}
+	}
+
+	// Body: height and angle are both uniform since the radius is constant
+	phi := 2.0 * math.Pi * sample.Y
+	radial := tangent.Multiply(math.Cos(phi)).Add(bitangent.Multiply(math.Sin(phi)))
+	point := c.BaseCenter.Add(c.axis.Multiply(u * c.height)).Add(radial.Multiply(c.Radius))
+	return point, radial
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the sampled surface
+func (c *Cylinder) AreaPDF(point core.Vec3) float64 {
+	if _, onSurface := c.surfaceNormal(point); !onSurface {
+		return 0.0
+	}
+	return 1.0 / c.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - returns the body or cap normal at a point
+func (c *Cylinder) NormalAt(point core.Vec3) core.Vec3 {
+	normal, _ := c.surfaceNormal(point)
+	return normal
+}
+
+// SurfaceArea implements the SurfaceSampler interface - body area plus caps when capped
+func (c *Cylinder) SurfaceArea() float64 {
+	area := 2.0 * math.Pi * c.Radius * c.height
+	if c.Capped {
+		area += 2.0 * math.Pi * c.Radius * c.Radius
+	}
+	return area
+}
+
+// surfaceNormal classifies a point as lying on the body or a cap and returns its outward normal
+func (c *Cylinder) surfaceNormal(point core.Vec3) (core.Vec3, bool) {
+	const tolerance = 1e-6
+
+	h := point.Subtract(c.BaseCenter).Dot(c.axis)
+	radial := point.Subtract(c.BaseCenter.Add(c.axis.Multiply(h)))
+	distance := radial.Length()
+
+	// Caps take precedence so points near the rim keep the cap normal
+	if c.Capped && distance <= c.Radius+tolerance {
+		if math.Abs(h) <= tolerance {
+			return c.axis.Negate(), true
+		}
+		if math.Abs(h-c.height) <= tolerance {
+			return c.axis, true
+		}
+	}
+	if math.Abs(distance-c.Radius) <= tolerance && h >= -tolerance && h <= c.height+tolerance {
+		return radial.Multiply(1.0 / distance), true
+	}
+	return core.Vec3{}, false
+}
+
+// sampleDiscFrame samples a point uniformly on a disc spanned by the given tangent frame
+func sampleDiscFrame(center, normal, tangent, bitangent core.Vec3, radius float64, sample core.Vec2) (core.Vec3, core.Vec3) {
+	r := radius * math.Sqrt(sample.X)
+	theta := 2.0 * math.Pi * sample.Y
+	point := center.Add(tangent.Multiply(r * math.Cos(theta))).Add(bitangent.Multiply(r * math.Sin(theta)))
+	return point, normal
+}
@@ -557,3 +557,74 @@ func TestCylinder_Uncapped_NoCapHits(t *testing.T) {
 		})
 	}
 }
+
+func TestCylinder_UV(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cyl := NewCylinder(core.NewVec3(0, 0, 0), core.NewVec3(0, 4, 0), 1.0, true, mat)
+
+	tests := []struct {
+		name     string
+		origin   core.Vec3
+		dir      core.Vec3
+		expected core.Vec2
+	}{
+		{
+			// Axis is Y so the frame is tangent=(0,0,-1), bitangent=(-1,0,0): -Z maps to u=0.5, +X to u=0.25
+			name:     "body at +X, mid height",
+			origin:   core.NewVec3(5, 2, 0),
+			dir:      core.NewVec3(-1, 0, 0),
+			expected: core.NewVec2(0.25, 0.5),
+		},
+		{
+			name:     "body at -Z, quarter height",
+			origin:   core.NewVec3(0, 1, -5),
+			dir:      core.NewVec3(0, 0, 1),
+			expected: core.NewVec2(0.5, 0.25),
+		},
+		{
+			name:     "body at -X, near top",
+			origin:   core.NewVec3(-5, 3.6, 0),
+			dir:      core.NewVec3(1, 0, 0),
+			expected: core.NewVec2(0.75, 0.9),
+		},
+		{
+			name:     "bottom cap center",
+			origin:   core.NewVec3(0, -5, 0),
+			dir:      core.NewVec3(0, 1, 0),
+			expected: core.NewVec2(0.5, 0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := cyl.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0)
+			if !isHit {
+				t.Fatal("Expected hit, but got miss")
+			}
+			// u=0 and u=1 are the same seam
+			du := math.Abs(hit.UV.X - tt.expected.X)
+			if math.Min(du, 1-du) > 1e-6 || math.Abs(hit.UV.Y-tt.expected.Y) > 1e-6 {
+				t.Errorf("Expected UV %v, got %v", tt.expected, hit.UV)
+			}
+		})
+	}
+}
+
+func TestCylinder_UV_CoversUnitSquare(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cyl := NewCylinder(core.NewVec3(1, 0, 2), core.NewVec3(2, 3, 1), 0.5, false, mat)
+
+	// Fire rays inward at sampled surface points and check UVs stay in [0,1]²
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 16; j++ {
+			point, normal := cyl.SampleSurface(core.NewVec2((float64(i)+0.5)/16, (float64(j)+0.5)/16))
+			hit, isHit := cyl.Hit(core.NewRay(point.Add(normal), normal.Negate()), 0.001, 2.0)
+			if !isHit {
+				t.Fatalf("Expected hit at sampled point %v", point)
+			}
+			if hit.UV.X < 0 || hit.UV.X > 1 || hit.UV.Y < 0 || hit.UV.Y > 1 {
+				t.Errorf("UV %v out of range at point %v", hit.UV, point)
+			}
+		}
+	}
+}
@@ -1,20 +1,22 @@
 package geometry
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
 
-// Disc represents a circular disc in 3D space
+// Disc represents a circular disc in 3D space, or an annulus when it has an inner radius
 type Disc struct {
-	Center   core.Vec3         // Center of the disc
-	Normal   core.Vec3         // Normal vector (pointing "up" from the disc)
-	Radius   float64           // Radius of the disc
-	Material material.Material // Material of the disc
-	Right    core.Vec3         // Right vector (perpendicular to normal)
-	Up       core.Vec3         // Up vector (perpendicular to normal and right)
+	Center      core.Vec3         // Center of the disc
+	Normal      core.Vec3         // Normal vector (pointing "up" from the disc)
+	Radius      float64           // Radius of the disc
+	InnerRadius float64           // Radius of the hole in the middle; 0 for a full disc
+	Material    material.Material // Material of the disc
+	Right       core.Vec3         // Right vector (perpendicular to normal)
+	Up          core.Vec3         // Up vector (perpendicular to normal and right)
 }
 
 // NewDisc creates a new disc
@@ -42,29 +44,24 @@ func NewDisc(center, normal core.Vec3, radius float64, material material.Materia
 	}
 }
 
-// Hit implements the Shape interface
-func (d *Disc) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
-	// Check if ray intersects the plane containing the disc
-	denom := d.Normal.Dot(ray.Direction)
-	if math.Abs(denom) < 1e-6 {
-		return nil, false // Ray is parallel to disc
+// NewAnnulus creates a flat ring between innerRadius and outerRadius
+// Panics unless 0 <= innerRadius < outerRadius
+func NewAnnulus(center, normal core.Vec3, innerRadius, outerRadius float64, material material.Material) *Disc {
+	if innerRadius < 0 || innerRadius >= outerRadius {
+		panic(fmt.Sprintf("annulus inner radius must be in [0, %f), got %f", outerRadius, innerRadius))
 	}
+	disc := NewDisc(center, normal, outerRadius, material)
+	disc.InnerRadius = innerRadius
+	return disc
+}
 
-	// Calculate intersection with plane
-	t := d.Normal.Dot(d.Center.Subtract(ray.Origin)) / denom
-	if t < tMin || t > tMax {
+// Hit implements the Shape interface
+func (d *Disc) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	t, hitPoint, isHit := d.intersect(ray, tMin, tMax)
+	if !isHit {
 		return nil, false
 	}
 
-	// Check if intersection point is within disc radius
-	hitPoint := ray.At(t)
-	centerToHit := hitPoint.Subtract(d.Center)
-	distanceSquared := centerToHit.LengthSquared()
-
-	if distanceSquared > d.Radius*d.Radius {
-		return nil, false // Outside disc
-	}
-
 	// Compute UV coordinates
 	// Disc already has Right and Up vectors, use them directly
 	localPoint := hitPoint.Subtract(d.Center)
@@ -86,6 +83,42 @@ func (d *Disc) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteracti
 	return hitRecord, true
 }
 
+// AlphaMasked implements the MaskedShape interface
+func (d *Disc) AlphaMasked() bool {
+	return hasAlphaMask(d.Material)
+}
+
+// HitAny reports whether a ray intersects the disc, without building the hit record (see Occluder)
+func (d *Disc) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, _, isHit := d.intersect(ray, tMin, tMax)
+	return isHit
+}
+
+// intersect returns the ray's t and point where it crosses the disc
+func (d *Disc) intersect(ray core.Ray, tMin, tMax float64) (float64, core.Vec3, bool) {
+	// Check if ray intersects the plane containing the disc
+	denom := d.Normal.Dot(ray.Direction)
+	if math.Abs(denom) < 1e-6 {
+		return 0, core.Vec3{}, false // Ray is parallel to disc
+	}
+
+	// Calculate intersection with plane
+	t := d.Normal.Dot(d.Center.Subtract(ray.Origin)) / denom
+	if t < tMin || t > tMax {
+		return 0, core.Vec3{}, false
+	}
+
+	// Check if intersection point is within disc radius
+	hitPoint := ray.At(t)
+	centerToHit := hitPoint.Subtract(d.Center)
+	distanceSquared := centerToHit.LengthSquared()
+
+	if distanceSquared > d.Radius*d.Radius || distanceSquared < d.InnerRadius*d.InnerRadius {
+		return 0, core.Vec3{}, false // Outside disc, or in its hole
+	}
+	return t, hitPoint, true
+}
+
 // BoundingBox implements the Shape interface
 func (d *Disc) BoundingBox() AABB {
 	// Create a bounding box that encompasses the disc
@@ -119,7 +152,12 @@ func (d *Disc) BoundingBox() AABB {
 // SampleUniform samples a random point uniformly on the disc surface
 func (d *Disc) SampleUniform(sample core.Vec2) (core.Vec3, core.Vec3) {
 	// Sample uniformly on unit disc using polar coordinates
+	// An annulus maps the sample to radii² between the inner and outer radius²
 	r := math.Sqrt(sample.X) * d.Radius
+	if d.InnerRadius > 0 {
+		inner2 := d.InnerRadius * d.InnerRadius
+		r = math.Sqrt(inner2 + sample.X*(d.Radius*d.Radius-inner2))
+	}
 	theta := 2.0 * math.Pi * sample.Y
 
 	// Convert to Cartesian coordinates in disc space
@@ -132,3 +170,34 @@ func (d *Disc) SampleUniform(sample core.Vec2) (core.Vec3, core.Vec3) {
 
 	return point, normal
 }
+
+// SampleSurface implements the SurfaceSampler interface - samples a point uniformly on the disc
+func (d *Disc) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	return d.SampleUniform(sample)
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the disc area
+func (d *Disc) AreaPDF(point core.Vec3) float64 {
+	const tolerance = 0.001
+
+	toPoint := point.Subtract(d.Center)
+	if math.Abs(toPoint.Dot(d.Normal)) > tolerance {
+		return 0.0
+	}
+	if toPoint.LengthSquared() > d.Radius*d.Radius*(1+tolerance) ||
+		toPoint.LengthSquared() < d.InnerRadius*d.InnerRadius*(1-tolerance) {
+		return 0.0
+	}
+
+	return 1.0 / d.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - discs have a constant normal
+func (d *Disc) NormalAt(point core.Vec3) core.Vec3 {
+	return d.Normal
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns πr², less the hole of an annulus
+func (d *Disc) SurfaceArea() float64 {
+	return math.Pi * (d.Radius*d.Radius - d.InnerRadius*d.InnerRadius)
+}
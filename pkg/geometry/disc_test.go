@@ -193,3 +193,79 @@ func TestDiscOrthogonalVectors(t *testing.T) {
 		})
 	}
 }
+
+func TestAnnulusHit(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	annulus := NewAnnulus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 0.5, 1.0, mat)
+
+	tests := []struct {
+		name      string
+		x         float64
+		expectHit bool
+	}{
+		{"center hole", 0.0, false},
+		{"inside hole", 0.4, false},
+		{"on ring", 0.75, true},
+		{"outer edge", 0.99, true},
+		{"outside", 1.1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ray := core.NewRay(core.NewVec3(tt.x, 1, 0), core.NewVec3(0, -1, 0))
+			_, isHit := annulus.Hit(ray, 0.001, 10)
+			if isHit != tt.expectHit {
+				t.Errorf("Expected hit=%v at x=%v, got %v", tt.expectHit, tt.x, isHit)
+			}
+			if annulus.HitAny(ray, 0.001, 10) != tt.expectHit {
+				t.Errorf("Expected HitAny=%v at x=%v", tt.expectHit, tt.x)
+			}
+		})
+	}
+
+	if area, expected := annulus.SurfaceArea(), math.Pi*0.75; math.Abs(area-expected) > 1e-12 {
+		t.Errorf("Expected area %v, got %v", expected, area)
+	}
+	if pdf := annulus.AreaPDF(core.NewVec3(0.2, 0, 0)); pdf != 0 {
+		t.Errorf("Expected zero area PDF in the hole, got %v", pdf)
+	}
+}
+
+func TestAnnulusSampleUniform(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	annulus := NewAnnulus(core.NewVec3(1, 2, 3), core.NewVec3(0, 0, 1), 1.0, 2.0, mat)
+
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	const numSamples = 10000
+	inner := 0
+	for i := 0; i < numSamples; i++ {
+		point, _ := annulus.SampleUniform(sampler.Get2D())
+		distance := point.Subtract(annulus.Center).Length()
+		if distance < 1.0-1e-9 || distance > 2.0+1e-9 {
+			t.Fatalf("Sampled point at distance %v, outside the ring [1, 2]", distance)
+		}
+		if distance < 1.5 {
+			inner++
+		}
+	}
+
+	// Uniform by area: the part within 1.5 holds (1.5² - 1) / (2² - 1) of the area
+	expected := (1.5*1.5 - 1) / 3
+	if got := float64(inner) / numSamples; math.Abs(got-expected) > 0.02 {
+		t.Errorf("Expected fraction %v of samples within radius 1.5, got %v", expected, got)
+	}
+}
+
+func TestNewAnnulus_InvalidRadius(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	for _, inner := range []float64{-0.1, 1.0, 2.0} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for inner radius %v with outer radius 1", inner)
+				}
+			}()
+			NewAnnulus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), inner, 1.0, mat)
+		}()
+	}
+}
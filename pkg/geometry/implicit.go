@@ -0,0 +1,148 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// SignedDistanceField interface for shapes described implicitly by a signed distance function
+// Shapes implementing it can be tessellated into triangle meshes with NewMeshFromSDF
+type SignedDistanceField interface {
+	// SignedDistance returns the distance from point to the surface: negative inside, positive outside
+	// It may underestimate the true distance but must never overestimate it (a Lipschitz bound of 1)
+	SignedDistance(point core.Vec3) float64
+
+	// BoundingBox returns bounds that contain the entire surface
+	BoundingBox() AABB
+}
+
+// Sphere tracing settings, relative to the size of the implicit surface's bounding box
+const (
+	implicitMaxSteps     = 512  // Ray march steps before giving up on a ray
+	implicitMinStepScale = 1e-4 // Smallest march step, so rays starting on the surface move off it
+	implicitBisectSteps  = 32   // Bisection steps refining a bracketed surface crossing
+	implicitNormalScale  = 1e-5 // Central difference offset for the gradient (normal)
+)
+
+// ImplicitSurface is a shape defined by an arbitrary signed distance function
+// It is rendered by sphere tracing; NewMeshFromSDF converts it to triangles for faster tracing or export.
+type ImplicitSurface struct {
+	Distance func(point core.Vec3) float64 // Signed distance function, negative inside
+	Bounds   AABB                          // Bounds containing the whole surface
+	Material material.Material
+}
+
+// NewImplicitSurface creates a new implicit surface from a signed distance function and its bounds
+func NewImplicitSurface(distance func(point core.Vec3) float64, bounds AABB, mat material.Material) *ImplicitSurface {
+	return &ImplicitSurface{
+		Distance: distance,
+		Bounds:   bounds,
+		Material: mat,
+	}
+}
+
+// NewSDFSurface creates an implicit surface from any signed distance field, such as a VoxelSDF
+func NewSDFSurface(sdf SignedDistanceField, mat material.Material) *ImplicitSurface {
+	return NewImplicitSurface(sdf.SignedDistance, sdf.BoundingBox(), mat)
+}
+
+// NewImplicitTorus creates a torus around the Y axis through center as an implicit surface
+// majorRadius is the distance from the center to the middle of the tube, minorRadius the tube radius
+func NewImplicitTorus(center core.Vec3, majorRadius, minorRadius float64, mat material.Material) *ImplicitSurface {
+	extent := core.NewVec3(majorRadius+minorRadius, minorRadius, majorRadius+minorRadius)
+	bounds := NewAABB(center.Subtract(extent), center.Add(extent))
+
+	distance := func(point core.Vec3) float64 {
+		p := point.Subtract(center)
+		ringDistance := math.Sqrt(p.X*p.X+p.Z*p.Z) - majorRadius
+		return math.Sqrt(ringDistance*ringDistance+p.Y*p.Y) - minorRadius
+	}
+	return NewImplicitSurface(distance, bounds, mat)
+}
+
+// SignedDistance implements the SignedDistanceField interface
+func (is *ImplicitSurface) SignedDistance(point core.Vec3) float64 {
+	return is.Distance(point)
+}
+
+// BoundingBox returns the axis-aligned bounding box for this implicit surface
+func (is *ImplicitSurface) BoundingBox() AABB {
+	return is.Bounds
+}
+
+// Hit tests if a ray intersects the implicit surface by sphere tracing
+// The march looks for a change of sign rather than a near-zero distance, so rays leaving the
+// surface (reflected or refracted) don't immediately hit it again, and inside rays work too.
+func (is *ImplicitSurface) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	// Only march the part of the ray inside the bounds
+	tEnter, tExit, inBounds := is.Bounds.HitInterval(ray, tMin, tMax)
+	if !inBounds {
+		return nil, false
+	}
+
+	directionLength := ray.Direction.Length()
+	if directionLength == 0 {
+		return nil, false
+	}
+	minStep := implicitMinStepScale * is.Bounds.Size().Length() / directionLength
+
+	t := tEnter
+	distance := is.Distance(ray.At(t))
+	startInside := distance < 0
+
+	for step := 0; step < implicitMaxSteps && t < tExit; step++ {
+		// Distances are in world units; convert to ray parameter units
+		tNext := math.Min(t+math.Max(math.Abs(distance)/directionLength, minStep), tExit)
+
+		nextDistance := is.Distance(ray.At(tNext))
+		if (nextDistance < 0) != startInside {
+			return is.surfaceInteraction(ray, is.bisect(ray, t, tNext, startInside)), true
+		}
+		t, distance = tNext, nextDistance
+	}
+	return nil, false
+}
+
+// bisect refines a surface crossing bracketed by t0 (on the starting side) and t1 (on the other side)
+func (is *ImplicitSurface) bisect(ray core.Ray, t0, t1 float64, startInside bool) float64 {
+	for i := 0; i < implicitBisectSteps; i++ {
+		mid := 0.5 * (t0 + t1)
+		if (is.Distance(ray.At(mid)) < 0) == startInside {
+			t0 = mid
+		} else {
+			t1 = mid
+		}
+	}
+	return t1
+}
+
+// surfaceInteraction creates the hit record at parameter t along the ray
+func (is *ImplicitSurface) surfaceInteraction(ray core.Ray, t float64) *material.SurfaceInteraction {
+	point := ray.At(t)
+	hitRecord := &material.SurfaceInteraction{
+		T:        t,
+		Point:    point,
+		Material: is.Material,
+	}
+	hitRecord.SetFaceNormal(ray, is.NormalAt(point))
+	return hitRecord
+}
+
+// NormalAt returns the outward surface normal at a point, from the gradient of the distance function
+func (is *ImplicitSurface) NormalAt(point core.Vec3) core.Vec3 {
+	return sdfGradient(is, point, implicitNormalScale*is.Bounds.Size().Length()).Normalize()
+}
+
+// sdfGradient estimates the gradient of a signed distance field with central differences
+func sdfGradient(sdf SignedDistanceField, point core.Vec3, h float64) core.Vec3 {
+	dx := core.NewVec3(h, 0, 0)
+	dy := core.NewVec3(0, h, 0)
+	dz := core.NewVec3(0, 0, h)
+	return core.NewVec3(
+		sdf.SignedDistance(point.Add(dx))-sdf.SignedDistance(point.Subtract(dx)),
+		sdf.SignedDistance(point.Add(dy))-sdf.SignedDistance(point.Subtract(dy)),
+		sdf.SignedDistance(point.Add(dz))-sdf.SignedDistance(point.Subtract(dz)),
+	)
+}
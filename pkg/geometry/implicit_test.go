@@ -0,0 +1,110 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestImplicitSurface_TorusHit(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus := NewImplicitTorus(core.NewVec3(0, 0, 0), 2.0, 0.5, mat)
+
+	tests := []struct {
+		name           string
+		origin         core.Vec3
+		dir            core.Vec3
+		expectedPoint  core.Vec3
+		expectedNormal core.Vec3
+		frontFace      bool
+	}{
+		{"top of tube", core.NewVec3(2, 5, 0), core.NewVec3(0, -1, 0), core.NewVec3(2, 0.5, 0), core.NewVec3(0, 1, 0), true},
+		{"outer rim", core.NewVec3(0, 0, 10), core.NewVec3(0, 0, -2), core.NewVec3(0, 0, 2.5), core.NewVec3(0, 0, 1), true},
+		{"inside tube", core.NewVec3(2, 0, 0), core.NewVec3(0, 1, 0), core.NewVec3(2, 0.5, 0), core.NewVec3(0, -1, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := torus.Hit(core.NewRay(tt.origin, tt.dir), 0.001, math.Inf(1))
+			if !isHit {
+				t.Fatal("Expected hit")
+			}
+			if !approxEqualVec(hit.Point, tt.expectedPoint, 1e-6) {
+				t.Errorf("Expected point %v, got %v", tt.expectedPoint, hit.Point)
+			}
+			if !approxEqualVec(hit.Normal, tt.expectedNormal, 1e-4) {
+				t.Errorf("Expected normal %v, got %v", tt.expectedNormal, hit.Normal)
+			}
+			if hit.FrontFace != tt.frontFace {
+				t.Errorf("Expected FrontFace=%v, got %v", tt.frontFace, hit.FrontFace)
+			}
+		})
+	}
+}
+
+func TestImplicitSurface_Misses(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus := NewImplicitTorus(core.NewVec3(0, 0, 0), 2.0, 0.5, mat)
+
+	// Through the hole, past the bounds, and beyond tMax
+	if _, isHit := torus.Hit(core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)), 0.001, math.Inf(1)); isHit {
+		t.Error("Expected ray through the hole to miss")
+	}
+	if _, isHit := torus.Hit(core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(1, 0, 0)), 0.001, math.Inf(1)); isHit {
+		t.Error("Expected ray above the torus to miss")
+	}
+	if _, isHit := torus.Hit(core.NewRay(core.NewVec3(2, 5, 0), core.NewVec3(0, -1, 0)), 0.001, 4.0); isHit {
+		t.Error("Expected hit beyond tMax to be rejected")
+	}
+}
+
+func TestImplicitSurface_NoSelfIntersection(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	sphere := NewSphere(core.NewVec3(0, 0, 0), 1.0, mat)
+	implicit := NewImplicitSurface(sphere.SignedDistance, sphere.BoundingBox(), mat)
+
+	// A ray leaving the surface outward must not hit it again
+	if _, isHit := implicit.Hit(core.NewRay(core.NewVec3(0, 1, 0), core.NewVec3(0.3, 1, 0)), 0.001, math.Inf(1)); isHit {
+		t.Error("Expected ray leaving the surface not to hit it")
+	}
+
+	// A ray refracted into the surface must hit the far side, like the analytic sphere
+	ray := core.NewRay(core.NewVec3(0, 1, 0), core.NewVec3(0, -1, 0.2))
+	hit, isHit := implicit.Hit(ray, 0.001, math.Inf(1))
+	expected, _ := sphere.Hit(ray, 0.001, math.Inf(1))
+	if !isHit {
+		t.Fatal("Expected ray entering the surface to hit the far side")
+	}
+	if math.Abs(hit.T-expected.T) > 1e-6 || hit.FrontFace != expected.FrontFace {
+		t.Errorf("Expected t=%f FrontFace=%v, got t=%f FrontFace=%v", expected.T, expected.FrontFace, hit.T, hit.FrontFace)
+	}
+}
+
+func TestSignedDistance_SphereAndCapsule(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	sphere := NewSphere(core.NewVec3(1, 0, 0), 2.0, mat)
+	capsule := NewCapsule(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0), 0.5, mat)
+
+	tests := []struct {
+		name     string
+		sdf      SignedDistanceField
+		point    core.Vec3
+		expected float64
+	}{
+		{"sphere outside", sphere, core.NewVec3(1, 3, 0), 1.0},
+		{"sphere center", sphere, core.NewVec3(1, 0, 0), -2.0},
+		{"capsule body", capsule, core.NewVec3(0, 2, 0), 1.5},
+		{"capsule cap", capsule, core.NewVec3(3, 0, 0), 1.5},
+		{"capsule inside", capsule, core.NewVec3(0.5, 0.25, 0), -0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := tt.sdf.SignedDistance(tt.point); math.Abs(d-tt.expected) > 1e-9 {
+				t.Errorf("Expected distance %f, got %f", tt.expected, d)
+			}
+		})
+	}
+}
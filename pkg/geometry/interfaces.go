@@ -11,7 +11,75 @@ type Shape interface {
 	BoundingBox() AABB
 }
 
+// Occluder is implemented by shapes with a cheaper test for whether a ray hits them at all, which
+// skips building the surface interaction (point, normal, UV). BVH.HitAny uses it for shadow rays.
+type Occluder interface {
+	Shape
+
+	// HitAny reports whether the ray intersects the shape where Hit would find an intersection
+	HitAny(ray core.Ray, tMin, tMax float64) bool
+}
+
+// MaskedShape is implemented by occluders that know whether holes cut by an alpha-masked material
+// (see material.AlphaMasked) escape their occlusion test, which doesn't look at materials. Shadow
+// rays use the occlusion test only for shapes that report none; the rest are tested with Hit,
+// which skips the transparent hits. Wrappers report their shape's answer.
+type MaskedShape interface {
+	Shape
+
+	// AlphaMasked reports whether HitAny would miss holes in the shape
+	AlphaMasked() bool
+}
+
 // Preprocessor interface for objects that need scene preprocessing
 type Preprocessor interface {
 	Preprocess(worldCenter core.Vec3, worldRadius float64) error
 }
+
+// SurfaceSampler interface for shapes that support uniform area sampling
+// Any shape implementing it can be promoted to an area light
+type SurfaceSampler interface {
+	Shape
+
+	// SampleSurface samples a point uniformly by area on the shape's surface
+	// Returns the sampled point and the outward surface normal at that point
+	SampleSurface(sample core.Vec2) (point core.Vec3, normal core.Vec3)
+
+	// AreaPDF returns the probability density (per unit area) of SampleSurface generating the point
+	// Returns 0 for points that don't lie on the surface
+	AreaPDF(point core.Vec3) float64
+
+	// NormalAt returns the outward surface normal at a point on the surface
+	NormalAt(point core.Vec3) core.Vec3
+
+	// SurfaceArea returns the total area covered by SampleSurface
+	SurfaceArea() float64
+}
+
+// Intersector interface for the backend that answers ray queries against all of a scene's shapes
+// Integrators only talk to the scene through it, so the local BVH can be swapped for another
+// backend (a native library, a remote service) without touching them. See IntersectorBuilder.
+type Intersector interface {
+	// Hit returns the closest intersection with tMin < t < tMax
+	Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool)
+
+	// HitAny reports whether anything intersects the ray with tMin < t < tMax
+	// Used for shadow rays; backends can stop at the first intersection found
+	HitAny(ray core.Ray, tMin, tMax float64) bool
+
+	// BoundingBox returns the bounds of all the shapes
+	BoundingBox() AABB
+}
+
+// IntersectorBuilder creates an intersection backend for a scene's shapes
+type IntersectorBuilder func(shapes []Shape) (Intersector, error)
+
+// NewBVHIntersector is the IntersectorBuilder for the local BVH, the default backend
+func NewBVHIntersector(shapes []Shape) (Intersector, error) {
+	return NewBVH(shapes), nil
+}
+
+// NewSBVHIntersector is the IntersectorBuilder for a local BVH built with spatial splits
+func NewSBVHIntersector(shapes []Shape) (Intersector, error) {
+	return NewBVHWithOptions(shapes, BVHOptions{SpatialSplits: true}), nil
+}
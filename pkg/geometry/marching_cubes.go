@@ -0,0 +1,198 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// cubeCorners are the offsets of a grid cell's corners, in the usual marching cubes order
+var cubeCorners = [8][3]int{
+	{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0},
+	{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1},
+}
+
+// cubeTetrahedra splits a cell into six tetrahedra around the diagonal from corner 0 to corner 6
+// Every cell uses the same diagonal, so the tetrahedra of neighboring cells share faces and the
+// resulting mesh has no cracks, without the ambiguous cases of the classic 256-entry tables.
+var cubeTetrahedra = [6][4]int{
+	{0, 6, 1, 2}, {0, 6, 2, 3}, {0, 6, 3, 7},
+	{0, 6, 7, 4}, {0, 6, 4, 5}, {0, 6, 5, 1},
+}
+
+// sdfSnapScale is the distance, relative to the cell size, below which a grid point counts as on the surface
+const sdfSnapScale = 1e-6
+
+// NewMeshFromSDF tessellates a signed distance field into a triangle mesh
+// resolution is the number of grid cells along the longest axis of the field's bounding box;
+// the other axes get as many cells as keep them cubic. Higher resolutions follow the surface
+// more closely at the cost of more triangles (roughly quadratic in resolution).
+func NewMeshFromSDF(sdf SignedDistanceField, resolution int, mat material.Material) *TriangleMesh {
+	vertices, faces := PolygonizeSDF(sdf, resolution)
+	return NewTriangleMesh(vertices, faces, mat, nil)
+}
+
+// PolygonizeSDF extracts the zero level set of a signed distance field as an indexed triangle list
+// Vertices are shared between adjacent triangles and faces are wound counter-clockwise seen from
+// outside, so the result can be exported directly. See NewMeshFromSDF for resolution.
+func PolygonizeSDF(sdf SignedDistanceField, resolution int) ([]core.Vec3, []int) {
+	if resolution < 1 {
+		resolution = 1
+	}
+
+	// Pad the bounds by a cell so surfaces touching the bounding box are closed
+	bounds := sdf.BoundingBox()
+	size := bounds.Size()
+	cellSize := math.Max(size.X, math.Max(size.Y, size.Z)) / float64(resolution)
+	if cellSize <= 0 {
+		return nil, nil
+	}
+	bounds = bounds.Expand(cellSize)
+	size = bounds.Size()
+
+	grid := sdfGrid{
+		origin:   bounds.Min,
+		cellSize: cellSize,
+		nx:       int(math.Ceil(size.X/cellSize)) + 1,
+		ny:       int(math.Ceil(size.Y/cellSize)) + 1,
+		nz:       int(math.Ceil(size.Z/cellSize)) + 1,
+		edges:    make(map[[2]int]int),
+	}
+
+	// Sample the field once per grid point. Values within rounding of zero are snapped to it, so a
+	// surface through a grid point gets a single shared vertex there rather than slivers around it
+	grid.values = make([]float64, grid.nx*grid.ny*grid.nz)
+	for k := 0; k < grid.nz; k++ {
+		for j := 0; j < grid.ny; j++ {
+			for i := 0; i < grid.nx; i++ {
+				value := sdf.SignedDistance(grid.point(i, j, k))
+				if math.Abs(value) < sdfSnapScale*cellSize {
+					value = 0
+				}
+				grid.values[grid.index(i, j, k)] = value
+			}
+		}
+	}
+
+	for k := 0; k < grid.nz-1; k++ {
+		for j := 0; j < grid.ny-1; j++ {
+			for i := 0; i < grid.nx-1; i++ {
+				var corners [8]int
+				for c, offset := range cubeCorners {
+					corners[c] = grid.index(i+offset[0], j+offset[1], k+offset[2])
+				}
+				for _, tet := range cubeTetrahedra {
+					grid.polygonizeTetrahedron([4]int{corners[tet[0]], corners[tet[1]], corners[tet[2]], corners[tet[3]]})
+				}
+			}
+		}
+	}
+
+	return grid.vertices, grid.faces
+}
+
+// sdfGrid holds the sampled field and the mesh being built by PolygonizeSDF
+type sdfGrid struct {
+	origin     core.Vec3
+	cellSize   float64
+	nx, ny, nz int       // Number of grid points along each axis
+	values     []float64 // Field value at each grid point
+
+	vertices []core.Vec3
+	faces    []int
+	edges    map[[2]int]int // Grid edge (ordered point indices) to the mesh vertex on it
+}
+
+func (g *sdfGrid) index(i, j, k int) int {
+	return (k*g.ny+j)*g.nx + i
+}
+
+func (g *sdfGrid) point(i, j, k int) core.Vec3 {
+	return g.origin.Add(core.NewVec3(float64(i), float64(j), float64(k)).Multiply(g.cellSize))
+}
+
+func (g *sdfGrid) pointAt(index int) core.Vec3 {
+	i := index % g.nx
+	j := (index / g.nx) % g.ny
+	k := index / (g.nx * g.ny)
+	return g.point(i, j, k)
+}
+
+// polygonizeTetrahedron emits the zero of the field inside one tetrahedron as zero, one or two triangles
+func (g *sdfGrid) polygonizeTetrahedron(tet [4]int) {
+	var inside, outside []int
+	for _, p := range tet {
+		if g.values[p] < 0 {
+			inside = append(inside, p)
+		} else {
+			outside = append(outside, p)
+		}
+	}
+
+	switch len(inside) {
+	case 1:
+		a := inside[0]
+		g.addTriangle(inside, outside, g.edgeVertex(a, outside[0]), g.edgeVertex(a, outside[1]), g.edgeVertex(a, outside[2]))
+	case 3:
+		a := outside[0]
+		g.addTriangle(inside, outside, g.edgeVertex(inside[0], a), g.edgeVertex(inside[1], a), g.edgeVertex(inside[2], a))
+	case 2:
+		// The crossing is a quad with its corners on the four edges between the inside and outside pairs
+		a, b := inside[0], inside[1]
+		c, d := outside[0], outside[1]
+		ac, ad, bd, bc := g.edgeVertex(a, c), g.edgeVertex(a, d), g.edgeVertex(b, d), g.edgeVertex(b, c)
+		g.addTriangle(inside, outside, ac, ad, bd)
+		g.addTriangle(inside, outside, ac, bd, bc)
+	}
+}
+
+// edgeVertex returns the mesh vertex where the field crosses zero on the edge from an inside point to an outside one
+// Vertices are shared by every tetrahedron that uses the edge. A crossing exactly at the outside grid point
+// belongs to all edges meeting there, so it is keyed by the point alone to keep the mesh closed.
+func (g *sdfGrid) edgeVertex(in, out int) int {
+	key := [2]int{in, out}
+	if g.values[out] == 0 {
+		key = [2]int{out, out}
+	} else if in > out {
+		key = [2]int{out, in}
+	}
+	if vertex, exists := g.edges[key]; exists {
+		return vertex
+	}
+
+	v0, v1 := g.values[key[0]], g.values[key[1]]
+	a, b := g.pointAt(key[0]), g.pointAt(key[1])
+	position := a
+	if key[0] != key[1] {
+		position = a.Add(b.Subtract(a).Multiply(v0 / (v0 - v1)))
+	}
+
+	vertex := len(g.vertices)
+	g.vertices = append(g.vertices, position)
+	g.edges[key] = vertex
+	return vertex
+}
+
+// addTriangle appends a triangle wound so its normal points from the inside points toward the outside ones
+// Triangles collapsed onto a grid point the surface passes through (repeated vertices) are dropped.
+func (g *sdfGrid) addTriangle(inside, outside []int, v0, v1, v2 int) {
+	if v0 == v1 || v1 == v2 || v2 == v0 {
+		return
+	}
+	p0, p1, p2 := g.vertices[v0], g.vertices[v1], g.vertices[v2]
+	normal := p1.Subtract(p0).Cross(p2.Subtract(p0))
+
+	var outward core.Vec3
+	for _, p := range outside {
+		outward = outward.Add(g.pointAt(p).Multiply(1.0 / float64(len(outside))))
+	}
+	for _, p := range inside {
+		outward = outward.Subtract(g.pointAt(p).Multiply(1.0 / float64(len(inside))))
+	}
+
+	if normal.Dot(outward) < 0 {
+		v1, v2 = v2, v1
+	}
+	g.faces = append(g.faces, v0, v1, v2)
+}
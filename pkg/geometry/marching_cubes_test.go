@@ -0,0 +1,103 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestPolygonizeSDF_Sphere(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	center := core.NewVec3(1, 2, 3)
+	sphere := NewSphere(center, 1.0, mat)
+
+	vertices, faces := PolygonizeSDF(sphere, 24)
+	if len(faces) == 0 || len(faces)%3 != 0 {
+		t.Fatalf("Expected a triangle list, got %d indices", len(faces))
+	}
+
+	// Vertices lie close to the surface (linear interpolation of an exact distance field)
+	for i, v := range vertices {
+		if d := math.Abs(sphere.SignedDistance(v)); d > 1e-2 {
+			t.Fatalf("Vertex %d is %f from the surface", i, d)
+		}
+	}
+
+	// Faces point outward, the area approximates 4πr², and the mesh is closed (each edge used twice)
+	area := 0.0
+	edgeUses := make(map[[2]int]int)
+	for f := 0; f < len(faces); f += 3 {
+		p0, p1, p2 := vertices[faces[f]], vertices[faces[f+1]], vertices[faces[f+2]]
+		normal := p1.Subtract(p0).Cross(p2.Subtract(p0))
+		centroid := p0.Add(p1).Add(p2).Multiply(1.0 / 3.0)
+		if normal.Dot(centroid.Subtract(center)) <= 0 {
+			t.Fatalf("Face %d faces inward", f/3)
+		}
+		area += 0.5 * normal.Length()
+
+		for e := 0; e < 3; e++ {
+			a, b := faces[f+e], faces[f+(e+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edgeUses[[2]int{a, b}]++
+		}
+	}
+	if expected := sphere.SurfaceArea(); math.Abs(area-expected)/expected > 0.02 {
+		t.Errorf("Expected area near %f, got %f", expected, area)
+	}
+	for edge, uses := range edgeUses {
+		if uses != 2 {
+			t.Fatalf("Edge %v is used by %d faces, expected a closed mesh", edge, uses)
+		}
+	}
+}
+
+func TestPolygonizeSDF_Resolution(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus := NewImplicitTorus(core.NewVec3(0, 0, 0), 2.0, 0.5, mat)
+
+	_, coarse := PolygonizeSDF(torus, 16)
+	_, fine := PolygonizeSDF(torus, 32)
+	if len(coarse) == 0 || len(fine) <= 2*len(coarse) {
+		t.Errorf("Expected doubling the resolution to roughly quadruple the triangles, got %d and %d", len(coarse)/3, len(fine)/3)
+	}
+
+	// Nothing to extract from a field that never crosses zero
+	empty := NewImplicitSurface(func(core.Vec3) float64 { return 1 }, NewAABB(core.NewVec3(-1, -1, -1), core.NewVec3(1, 1, 1)), mat)
+	if vertices, faces := PolygonizeSDF(empty, 8); len(vertices) != 0 || len(faces) != 0 {
+		t.Errorf("Expected empty mesh, got %d vertices and %d faces", len(vertices), len(faces)/3)
+	}
+}
+
+func TestNewMeshFromSDF_MatchesImplicitSurface(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus := NewImplicitTorus(core.NewVec3(0, 0, 0), 2.0, 0.5, mat)
+	mesh := NewMeshFromSDF(torus, 64, mat)
+
+	rays := []core.Ray{
+		core.NewRay(core.NewVec3(2, 5, 0), core.NewVec3(0, -1, 0)),
+		core.NewRay(core.NewVec3(0, 0, 10), core.NewVec3(0, 0, -1)),
+		core.NewRay(core.NewVec3(-5, 0.2, 0.1), core.NewVec3(1, 0, 0)),
+	}
+	for i, ray := range rays {
+		expected, _ := torus.Hit(ray, 0.001, math.Inf(1))
+		hit, isHit := mesh.Hit(ray, 0.001, math.Inf(1))
+		if !isHit {
+			t.Fatalf("Ray %d: expected mesh hit", i)
+		}
+		if math.Abs(hit.T-expected.T) > 0.02 {
+			t.Errorf("Ray %d: expected t near %f, got %f", i, expected.T, hit.T)
+		}
+		if hit.Normal.Dot(expected.Normal) < 0.9 {
+			t.Errorf("Ray %d: expected normal near %v, got %v", i, expected.Normal, hit.Normal)
+		}
+	}
+
+	// Through the hole still misses
+	if _, isHit := mesh.Hit(core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)), 0.001, math.Inf(1)); isHit {
+		t.Error("Expected ray through the hole to miss the mesh")
+	}
+}
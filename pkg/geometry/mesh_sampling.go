@@ -0,0 +1,156 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// meshSampling is the distribution a TriangleMesh samples its surface by: each face in proportion
+// to its area
+type meshSampling struct {
+	faces []*Triangle
+	cdf   []float64 // Running total of the faces' areas
+	area  float64
+}
+
+// surfaceSampling returns the mesh's area distribution, building it on first use
+func (tm *TriangleMesh) surfaceSampling() *meshSampling {
+	tm.samplingOnce.Do(func() {
+		shapes := tm.GetTriangles()
+		tm.sampling.faces = make([]*Triangle, len(shapes))
+		tm.sampling.cdf = make([]float64, len(shapes))
+		for i, shape := range shapes {
+			tm.sampling.faces[i] = shape.(*Triangle)
+			tm.sampling.area += tm.sampling.faces[i].SurfaceArea()
+			tm.sampling.cdf[i] = tm.sampling.area
+		}
+	})
+	return &tm.sampling
+}
+
+// SampleSurface implements the SurfaceSampler interface - picks a face by its share of the area,
+// then reuses the remapped sample to pick a point on it uniformly
+func (tm *TriangleMesh) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	s := tm.surfaceSampling()
+	if s.area == 0 {
+		return core.Vec3{}, core.Vec3{}
+	}
+	u := sample.X * s.area
+	i := sort.SearchFloat64s(s.cdf, u)
+	if i == len(s.cdf) {
+		i--
+	}
+	start := 0.0
+	if i > 0 {
+		start = s.cdf[i-1]
+	}
+	if faceArea := s.cdf[i] - start; faceArea > 0 {
+		sample.X = min(1, max(0, (u-start)/faceArea))
+	}
+	return s.faces[i].SampleSurface(sample)
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the mesh's area
+func (tm *TriangleMesh) AreaPDF(point core.Vec3) float64 {
+	s := tm.surfaceSampling()
+	if s.area == 0 || tm.faceAt(point) == nil {
+		return 0.0
+	}
+	return 1.0 / s.area
+}
+
+// NormalAt implements the SurfaceSampler interface - returns the normal of the face the point lies on
+func (tm *TriangleMesh) NormalAt(point core.Vec3) core.Vec3 {
+	if face := tm.faceAt(point); face != nil {
+		return face.GetNormal()
+	}
+	return core.Vec3{}
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns the total area of the faces
+func (tm *TriangleMesh) SurfaceArea() float64 {
+	return tm.surfaceSampling().area
+}
+
+// faceAt returns the face the point lies on, within Triangle.AreaPDF's tolerance, searching only
+// the BVH nodes whose bounds contain it; nil if there's none. Near an edge, where the tolerance
+// admits the faces on both sides, it prefers the face whose plane is nearest.
+func (tm *TriangleMesh) faceAt(point core.Vec3) *Triangle {
+	found := faceSearch{face: -1}
+	if tm.compact != nil {
+		if len(tm.compact.nodes) > 0 {
+			tm.compact.searchFaces(0, point, &found)
+		}
+		if found.face < 0 {
+			return nil
+		}
+		return tm.compact.triangle(found.face)
+	}
+	if tm.bvh != nil {
+		searchBVHFaces(tm.bvh.Root, point, &found)
+	}
+	return found.triangle
+}
+
+// faceSearch is the face nearest a point found so far by a search of a mesh's BVH
+type faceSearch struct {
+	triangle *Triangle
+	face     int // Index of a compact mesh's face; -1 for none
+	distance float64
+}
+
+// consider keeps a face containing the point if its plane is nearer than the best so far's
+func (s *faceSearch) consider(triangle *Triangle, face int, point core.Vec3) {
+	if triangle.AreaPDF(point) == 0 {
+		return
+	}
+	normal := triangle.V1.Subtract(triangle.V0).Cross(triangle.V2.Subtract(triangle.V0)).Normalize()
+	distance := math.Abs(point.Subtract(triangle.V0).Dot(normal))
+	if s.triangle == nil || distance < s.distance {
+		s.triangle, s.face, s.distance = triangle, face, distance
+	}
+}
+
+// faceAtTolerance is how far outside a node's bounds a point may lie and still be searched for
+const faceAtTolerance = 0.001
+
+// boundsContain reports whether a point lies within bounds grown by faceAtTolerance
+func boundsContain(bounds AABB, point core.Vec3) bool {
+	bounds = bounds.Expand(faceAtTolerance)
+	return point.X >= bounds.Min.X && point.X <= bounds.Max.X &&
+		point.Y >= bounds.Min.Y && point.Y <= bounds.Max.Y &&
+		point.Z >= bounds.Min.Z && point.Z <= bounds.Max.Z
+}
+
+// searchBVHFaces searches below a node of a mesh's BVH for the face the point lies on
+func searchBVHFaces(node *BVHNode, point core.Vec3, found *faceSearch) {
+	if node == nil || !boundsContain(node.BoundingBox, point) {
+		return
+	}
+	for _, shape := range node.Shapes {
+		if triangle, ok := shape.(*Triangle); ok {
+			found.consider(triangle, -1, point)
+		}
+	}
+	searchBVHFaces(node.Left, point, found)
+	searchBVHFaces(node.Right, point, found)
+}
+
+// searchFaces searches below a node for the face the point lies on
+func (m *compactMesh) searchFaces(index int32, point core.Vec3, found *faceSearch) {
+	node := &m.nodes[index]
+	if !boundsContain(node.bounds(), point) {
+		return
+	}
+	if node.count >= 0 {
+		for face := int(node.offset); face < int(node.offset+node.count); face++ {
+			v0, v1, v2 := m.faceVertices(face)
+			found.consider(&Triangle{V0: v0, V1: v1, V2: v2}, face, point)
+		}
+		return
+	}
+	m.searchFaces(index+1, point, found)
+	m.searchFaces(node.offset, point, found)
+}
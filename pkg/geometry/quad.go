@@ -129,54 +129,85 @@ func NewQuad(corner, u, v core.Vec3, material material.Material) *Quad {
 	}
 }
 
+// HitMany tests a packet of rays against the quad (see BatchShape)
+func (q *Quad) HitMany(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	for _, i := range active {
+		// A plane has nothing behind a transparent hit, so it's skipped rather than retried
+		if hit, isHit := q.Hit(rays[i], tMin, closest[i]); isHit && material.IsOpaque(hit) {
+			closest[i] = hit.T
+			hits[i] = hit
+		}
+	}
+}
+
 // Hit tests if a ray intersects with the quad
 func (q *Quad) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	t, hitPoint, alpha, beta, isHit := q.intersect(ray, tMin, tMax)
+	if !isHit {
+		return nil, false
+	}
+
+	// Use barycentric coordinates as UV
+	uv := core.NewVec2(alpha, beta)
+
+	// Create hit record
+	hitRecord := &material.SurfaceInteraction{
+		T:        t,
+		Point:    hitPoint,
+		Material: q.Material,
+		UV:       uv,
+		DpDu:     q.U,
+		DpDv:     q.V,
+	}
+
+	// Set face normal
+	hitRecord.SetFaceNormal(ray, q.Normal)
+
+	return hitRecord, true
+}
+
+// AlphaMasked implements the MaskedShape interface
+func (q *Quad) AlphaMasked() bool {
+	return hasAlphaMask(q.Material)
+}
+
+// HitAny reports whether a ray intersects the quad, without building the hit record (see Occluder)
+func (q *Quad) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, _, _, _, isHit := q.intersect(ray, tMin, tMax)
+	return isHit
+}
+
+// intersect returns the ray's intersection with the quad: its t, point and barycentric coordinates
+func (q *Quad) intersect(ray core.Ray, tMin, tMax float64) (t float64, hitPoint core.Vec3, alpha, beta float64, isHit bool) {
 	// Calculate denominator: dot product of ray direction and quad normal
 	denominator := ray.Direction.Dot(q.Normal)
 
 	// If denominator is close to zero, ray is parallel to quad (no intersection)
 	if math.Abs(denominator) < 1e-8 {
-		return nil, false
+		return
 	}
 
 	// Calculate t parameter for plane intersection
-	t := (q.D - ray.Origin.Dot(q.Normal)) / denominator
+	t = (q.D - ray.Origin.Dot(q.Normal)) / denominator
 
 	// Check if intersection is within valid range
 	if t < tMin || t > tMax {
-		return nil, false
+		return
 	}
 
 	// Calculate intersection point
-	hitPoint := ray.At(t)
+	hitPoint = ray.At(t)
 
 	// Check if hit point is within the quad bounds using barycentric coordinates
 	hitVector := hitPoint.Subtract(q.Corner)
 
 	// Calculate barycentric coordinates
-	alpha := q.W.Dot(hitVector.Cross(q.V))
-	beta := q.W.Dot(q.U.Cross(hitVector))
+	alpha = q.W.Dot(hitVector.Cross(q.V))
+	beta = q.W.Dot(q.U.Cross(hitVector))
 
 	// Check if point is within quad bounds
-	if alpha < 0 || alpha > 1 || beta < 0 || beta > 1 {
-		return nil, false
-	}
-
-	// Use barycentric coordinates as UV
-	uv := core.NewVec2(alpha, beta)
-
-	// Create hit record
-	hitRecord := &material.SurfaceInteraction{
-		T:        t,
-		Point:    hitPoint,
-		Material: q.Material,
-		UV:       uv,
-	}
-
-	// Set face normal
-	hitRecord.SetFaceNormal(ray, q.Normal)
-
-	return hitRecord, true
+	isHit = alpha >= 0 && alpha <= 1 && beta >= 0 && beta <= 1
+	return
 }
 
 // BoundingBox returns the axis-aligned bounding box for this quad
@@ -208,3 +239,39 @@ func (q *Quad) BoundingBox() AABB {
 	// Not axis-aligned - use standard bounding box from all corners
 	return NewAABBFromPoints(corners[0], corners[1], corners[2], corners[3])
 }
+
+// SampleSurface implements the SurfaceSampler interface - samples a point uniformly on the quad
+func (q *Quad) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	point := q.Corner.Add(q.U.Multiply(sample.X)).Add(q.V.Multiply(sample.Y))
+	return point, q.Normal
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the quad area
+func (q *Quad) AreaPDF(point core.Vec3) float64 {
+	const tolerance = 0.001
+
+	// Reject points off the quad's plane
+	if math.Abs(q.Normal.Dot(point)-q.D) > tolerance {
+		return 0.0
+	}
+
+	// Reject points outside the quad bounds using barycentric coordinates
+	hitVector := point.Subtract(q.Corner)
+	alpha := q.W.Dot(hitVector.Cross(q.V))
+	beta := q.W.Dot(q.U.Cross(hitVector))
+	if alpha < 0 || alpha > 1 || beta < 0 || beta > 1 {
+		return 0.0
+	}
+
+	return 1.0 / q.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - quads have a constant normal
+func (q *Quad) NormalAt(point core.Vec3) core.Vec3 {
+	return q.Normal
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns |U × V|
+func (q *Quad) SurfaceArea() float64 {
+	return q.U.Cross(q.V).Length()
+}
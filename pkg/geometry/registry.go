@@ -0,0 +1,42 @@
+package geometry
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Factory builds a shape of a registered type from its parameters, made of mat. The transform
+// places it in the world: PBRT's current transformation, or the identity for JSON and YAML.
+type Factory func(params core.Params, transform core.Matrix4, mat material.Material) (Shape, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register adds a shape type for scene files to use by name: PBRT's Shape statements, and JSON
+// and YAML shapes, whose parameters go under "params". A loader's own types come first, so a
+// name it already knows never reaches the factory. Register panics if the name is empty or
+// taken, since it's meant to be called from an init function.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("geometry: Register needs a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("geometry: shape type %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered for a shape type
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
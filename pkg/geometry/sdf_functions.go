@@ -0,0 +1,71 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// SDFSmoothUnion blends two signed distance functions into one surface, rounding the seam
+// between them over a distance of about k (0 gives the sharp union)
+func SDFSmoothUnion(a, b func(point core.Vec3) float64, k float64) func(point core.Vec3) float64 {
+	return func(point core.Vec3) float64 {
+		da, db := a(point), b(point)
+		if k <= 0 {
+			return math.Min(da, db)
+		}
+		h := math.Min(math.Max(0.5+0.5*(db-da)/k, 0), 1)
+		return db + (da-db)*h - k*h*(1-h)
+	}
+}
+
+// SDFSmoothSubtraction carves the shape b out of a, rounding the cut's edge over a distance of about k
+func SDFSmoothSubtraction(a, b func(point core.Vec3) float64, k float64) func(point core.Vec3) float64 {
+	return func(point core.Vec3) float64 {
+		da, db := a(point), b(point)
+		if k <= 0 {
+			return math.Max(da, -db)
+		}
+		h := math.Min(math.Max(0.5-0.5*(da+db)/k, 0), 1)
+		return da + (-db-da)*h + k*h*(1-h)
+	}
+}
+
+// NewMandelbulb creates the power-n Mandelbulb fractal, scaled to fit a sphere of radius around
+// center, as an implicit surface (power 8 gives the classic shape). More iterations reveal
+// finer detail and cost more per ray march step.
+func NewMandelbulb(center core.Vec3, radius, power float64, iterations int, mat material.Material) *ImplicitSurface {
+	// The set lies within about 1.2 of the origin in its own units
+	scale := radius / 1.2
+	distance := func(point core.Vec3) float64 {
+		return mandelbulbDistance(point.Subtract(center).Multiply(1/scale), power, iterations) * scale
+	}
+	extent := core.NewVec3(radius, radius, radius)
+	return NewImplicitSurface(distance, NewAABB(center.Subtract(extent), center.Add(extent)), mat)
+}
+
+// mandelbulbDistance estimates the distance to the Mandelbulb from the growth of z ↦ zⁿ + c in
+// spherical coordinates. Points whose orbit doesn't escape are inside.
+func mandelbulbDistance(c core.Vec3, power float64, iterations int) float64 {
+	z := c
+	dr := 1.0
+	r := z.Length()
+	for i := 0; i < iterations && r <= 2; i++ {
+		theta := math.Acos(math.Min(math.Max(z.Z/r, -1), 1)) * power
+		phi := math.Atan2(z.Y, z.X) * power
+		dr = math.Pow(r, power-1)*power*dr + 1
+		zr := math.Pow(r, power)
+		z = core.NewVec3(math.Sin(theta)*math.Cos(phi), math.Sin(theta)*math.Sin(phi), math.Cos(theta)).Multiply(zr).Add(c)
+		r = z.Length()
+		if r == 0 {
+			return -1e-9 // The orbit is stuck at the origin, deep inside
+		}
+	}
+
+	estimate := 0.5 * math.Log(r) * r / dr
+	if r <= 2 {
+		return -math.Abs(estimate)
+	}
+	return estimate
+}
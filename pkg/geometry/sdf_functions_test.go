@@ -0,0 +1,71 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestSDFSmoothUnion(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	a := NewSphere(core.NewVec3(-1, 0, 0), 1, mat).SignedDistance
+	b := NewSphere(core.NewVec3(1, 0, 0), 1, mat).SignedDistance
+
+	sharp := SDFSmoothUnion(a, b, 0)
+	smooth := SDFSmoothUnion(a, b, 0.5)
+	for _, p := range []core.Vec3{core.NewVec3(-2.5, 0, 0), core.NewVec3(3, 1, 0), core.NewVec3(-1, 0, 0)} {
+		if d := sharp(p); d != math.Min(a(p), b(p)) {
+			t.Errorf("Sharp union at %v: expected %f, got %f", p, math.Min(a(p), b(p)), d)
+		}
+		// Far from the seam the blend matches the union
+		if d := smooth(p); math.Abs(d-sharp(p)) > 1e-9 {
+			t.Errorf("Smooth union at %v: expected %f away from the seam, got %f", p, sharp(p), d)
+		}
+	}
+
+	// The seam where the spheres touch fills in
+	seam := core.NewVec3(0, 0.3, 0)
+	if sharp(seam) <= 0 || smooth(seam) >= 0 {
+		t.Errorf("Expected the blend to fill the seam: sharp %f, smooth %f", sharp(seam), smooth(seam))
+	}
+}
+
+func TestSDFSmoothSubtraction(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	a := NewSphere(core.NewVec3(0, 0, 0), 1, mat).SignedDistance
+	b := NewSphere(core.NewVec3(1, 0, 0), 0.5, mat).SignedDistance
+
+	carved := SDFSmoothSubtraction(a, b, 0.1)
+	if carved(core.NewVec3(0.9, 0, 0)) <= 0 {
+		t.Error("Expected the carved region to be outside")
+	}
+	if carved(core.NewVec3(-0.5, 0, 0)) >= 0 {
+		t.Error("Expected the rest of the sphere to stay inside")
+	}
+}
+
+func TestMandelbulb(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	bulb := NewMandelbulb(core.NewVec3(0, 1, 0), 1, 8, 8, mat)
+
+	if d := bulb.SignedDistance(core.NewVec3(0, 1, 0)); d >= 0 {
+		t.Errorf("Expected the center to be inside, got distance %f", d)
+	}
+	if d := bulb.SignedDistance(core.NewVec3(0, 1, 5)); d <= 0 {
+		t.Errorf("Expected far points to be outside, got distance %f", d)
+	}
+
+	ray := core.NewRay(core.NewVec3(0.05, 1.02, 5), core.NewVec3(0, 0, -1))
+	hit, isHit := bulb.Hit(ray, 0.001, 100)
+	if !isHit {
+		t.Fatal("Expected a ray at the fractal to hit it")
+	}
+	if hit.T < 3.9 || hit.T > 5 || math.Abs(hit.Normal.Length()-1) > 1e-6 || hit.Normal.Dot(ray.Direction) >= 0 {
+		t.Errorf("Unexpected hit t=%f normal %v", hit.T, hit.Normal)
+	}
+	if _, isHit := bulb.Hit(core.NewRay(core.NewVec3(0, 3, 5), core.NewVec3(0, 0, -1)), 0.001, 100); isHit {
+		t.Error("Expected a ray above the fractal to miss")
+	}
+}
@@ -23,8 +23,69 @@ func NewSphere(center core.Vec3, radius float64, material material.Material) *Sp
 	}
 }
 
+// HitMany tests a packet of rays against the sphere (see BatchShape)
+func (s *Sphere) HitMany(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	for _, i := range active {
+		if hit, isHit := hitShape(s, rays[i], tMin, closest[i]); isHit {
+			closest[i] = hit.T
+			hits[i] = hit
+		}
+	}
+}
+
 // Hit tests if a ray intersects with the sphere
 func (s *Sphere) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	root, isHit := s.intersect(ray, tMin, tMax)
+	if !isHit {
+		return nil, false
+	}
+
+	// Calculate intersection point
+	point := ray.At(root)
+
+	// Calculate outward normal (from center to hit point)
+	outwardNormal := point.Subtract(s.Center).Multiply(1.0 / s.Radius)
+
+	// Compute UV coordinates from spherical coordinates
+	// outwardNormal is (x, y, z) on unit sphere
+	theta := math.Acos(-outwardNormal.Y)                           // Angle from top pole [0, π]
+	phi := math.Atan2(-outwardNormal.Z, outwardNormal.X) + math.Pi // Angle around equator [0, 2π]
+	uv := core.NewVec2(phi/(2.0*math.Pi), theta/math.Pi)
+
+	// Create hit record with material
+	hitRecord := &material.SurfaceInteraction{
+		T:        root,
+		Point:    point,
+		Material: s.Material,
+		UV:       uv,
+	}
+
+	// The point's derivatives in u (around the equator) and v (from the top pole down), which
+	// vanish at the poles
+	n := outwardNormal
+	hitRecord.DpDu = core.NewVec3(n.Z, 0, -n.X).Multiply(2 * math.Pi * s.Radius)
+	if ring := math.Hypot(n.X, n.Z); ring > 0 {
+		hitRecord.DpDv = core.NewVec3(-n.Y*n.X/ring, ring, -n.Y*n.Z/ring).Multiply(math.Pi * s.Radius)
+	}
+
+	hitRecord.SetFaceNormal(ray, outwardNormal)
+
+	return hitRecord, true
+}
+
+// AlphaMasked implements the MaskedShape interface
+func (s *Sphere) AlphaMasked() bool {
+	return hasAlphaMask(s.Material)
+}
+
+// HitAny reports whether a ray intersects the sphere, without building the hit record (see Occluder)
+func (s *Sphere) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, isHit := s.intersect(ray, tMin, tMax)
+	return isHit
+}
+
+// intersect returns the ray's nearest intersection with the sphere in [tMin, tMax]
+func (s *Sphere) intersect(ray core.Ray, tMin, tMax float64) (float64, bool) {
 	// Vector from ray origin to sphere center
 	oc := ray.Origin.Subtract(s.Center)
 
@@ -38,7 +99,7 @@ func (s *Sphere) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInterac
 
 	// No intersection if discriminant is negative
 	if discriminant < 0 {
-		return nil, false
+		return 0, false
 	}
 
 	// Find the nearest intersection point within the valid range
@@ -51,33 +112,10 @@ func (s *Sphere) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInterac
 		root = (-halfB + sqrtD) / a
 		if root < tMin || root > tMax {
 			// Both intersections are outside valid range
-			return nil, false
+			return 0, false
 		}
 	}
-
-	// Calculate intersection point
-	point := ray.At(root)
-
-	// Calculate outward normal (from center to hit point)
-	outwardNormal := point.Subtract(s.Center).Multiply(1.0 / s.Radius)
-
-	// Compute UV coordinates from spherical coordinates
-	// outwardNormal is (x, y, z) on unit sphere
-	theta := math.Acos(-outwardNormal.Y)                           // Angle from top pole [0, π]
-	phi := math.Atan2(-outwardNormal.Z, outwardNormal.X) + math.Pi // Angle around equator [0, 2π]
-	uv := core.NewVec2(phi/(2.0*math.Pi), theta/math.Pi)
-
-	// Create hit record with material
-	hitRecord := &material.SurfaceInteraction{
-		T:        root,
-		Point:    point,
-		Material: s.Material,
-		UV:       uv,
-	}
-
-	hitRecord.SetFaceNormal(ray, outwardNormal)
-
-	return hitRecord, true
+	return root, true
 }
 
 // BoundingBox returns the axis-aligned bounding box for this sphere
@@ -88,3 +126,33 @@ func (s *Sphere) BoundingBox() AABB {
 		s.Center.Add(radius),
 	)
 }
+
+// SampleSurface implements the SurfaceSampler interface - samples a point uniformly on the sphere
+func (s *Sphere) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	normal := core.SampleOnUnitSphere(sample)
+	return s.Center.Add(normal.Multiply(s.Radius)), normal
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the sphere surface
+func (s *Sphere) AreaPDF(point core.Vec3) float64 {
+	const tolerance = 0.001
+	if math.Abs(point.Subtract(s.Center).Length()-s.Radius) > tolerance {
+		return 0.0
+	}
+	return 1.0 / s.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - returns the outward normal at a point
+func (s *Sphere) NormalAt(point core.Vec3) core.Vec3 {
+	return point.Subtract(s.Center).Normalize()
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns 4πr²
+func (s *Sphere) SurfaceArea() float64 {
+	return 4.0 * math.Pi * s.Radius * s.Radius
+}
+
+// SignedDistance implements the SignedDistanceField interface - distance to the center minus the radius
+func (s *Sphere) SignedDistance(point core.Vec3) float64 {
+	return point.Subtract(s.Center).Length() - s.Radius
+}
@@ -128,3 +128,27 @@ func TestSphere_Hit_ClosestIntersection(t *testing.T) {
 		t.Error("Expected closest intersection to be front face")
 	}
 }
+
+func TestSphere_UVDerivatives(t *testing.T) {
+	// Moving the point a little along DpDu and DpDv moves the UVs by as much in u and v
+	sphere := NewSphere(core.NewVec3(1, 2, 3), 2, nil)
+	for _, direction := range []core.Vec3{core.NewVec3(0.3, 0.5, -1), core.NewVec3(-1, -0.2, 0.4), core.NewVec3(0.1, 1, 0.7)} {
+		target := sphere.Center.Add(direction.Normalize().Multiply(2))
+		hit, ok := sphere.Hit(core.NewRayTo(target.Add(direction.Multiply(3)), target), 0.001, math.Inf(1))
+		if !ok {
+			t.Fatalf("Expected a hit at %v", target)
+		}
+		const eps = 1e-5
+		for _, tt := range []struct {
+			dp   core.Vec3
+			want core.Vec2
+		}{{hit.DpDu, core.NewVec2(eps, 0)}, {hit.DpDv, core.NewVec2(0, eps)}} {
+			moved := sphere.Center.Add(hit.Point.Add(tt.dp.Multiply(eps)).Subtract(sphere.Center).Normalize().Multiply(2))
+			probe, _ := sphere.Hit(core.NewRayTo(moved.Add(direction.Multiply(3)), moved), 0.001, math.Inf(1))
+			du, dv := probe.UV.X-hit.UV.X, probe.UV.Y-hit.UV.Y
+			if math.Abs(du-tt.want.X) > 1e-8 || math.Abs(dv-tt.want.Y) > 1e-8 {
+				t.Errorf("At %v: moving along %v changed UV by (%g, %g), want %v", target, tt.dp, du, dv, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// loopEdge is an edge of the mesh being subdivided, with the vertex inserted on it
+type loopEdge struct {
+	a, b     int   // Endpoints, a < b
+	opposite []int // The third vertex of each triangle using the edge
+	index    int   // Index of the new vertex
+}
+
+// refineMesh applies the subdivision and displacement requested in options, returning the refined
+// mesh and the options that go with it: UVs and per-triangle materials follow the triangles they
+// came from, and vertex normals are recomputed since the given ones no longer fit the surface.
+func refineMesh(vertices []core.Vec3, faces []int, options *TriangleMeshOptions) ([]core.Vec3, []int, *TriangleMeshOptions) {
+	if options.Normals != nil {
+		panic("Per-triangle normals can't be combined with subdivision or displacement")
+	}
+
+	refined := *options
+	for level := 0; level < options.SubdivisionLevels; level++ {
+		vertices, faces, refined.VertexUVs = loopSubdivide(vertices, faces, refined.VertexUVs)
+		if refined.Materials != nil {
+			materials := make([]material.Material, 0, 4*len(refined.Materials))
+			for _, mat := range refined.Materials {
+				materials = append(materials, mat, mat, mat, mat)
+			}
+			refined.Materials = materials
+		}
+	}
+
+	if options.Displacement != nil {
+		vertices = displaceVertices(vertices, faces, refined.VertexUVs, options.Displacement, options.DisplacementScale)
+	}
+
+	if refined.VertexNormals != nil {
+		refined.VertexNormals = nil
+		refined.SmoothNormals = true
+	}
+	return vertices, faces, &refined
+}
+
+// loopSubdivide performs one step of Loop subdivision, splitting every triangle into four
+// Edges used by one triangle (or more than two) are treated as boundaries: their vertices follow the
+// cubic B-spline boundary rule, so meshes split along UV seams refine without cracks.
+// UVs, if given, are interpolated linearly.
+func loopSubdivide(vertices []core.Vec3, faces []int, uvs []core.Vec2) ([]core.Vec3, []int, []core.Vec2) {
+	// Collect the edges in face order, so the result doesn't depend on map iteration order
+	edges := make(map[[2]int]*loopEdge)
+	var edgeOrder []*loopEdge
+	for t := 0; t+2 < len(faces); t += 3 {
+		for e := 0; e < 3; e++ {
+			a, b, c := faces[t+e], faces[t+(e+1)%3], faces[t+(e+2)%3]
+			key := [2]int{min(a, b), max(a, b)}
+			edge, exists := edges[key]
+			if !exists {
+				edge = &loopEdge{a: key[0], b: key[1], index: len(vertices) + len(edgeOrder)}
+				edges[key] = edge
+				edgeOrder = append(edgeOrder, edge)
+			}
+			edge.opposite = append(edge.opposite, c)
+		}
+	}
+
+	neighbors := make([][]int, len(vertices))
+	boundaryNeighbors := make([][]int, len(vertices))
+	for _, edge := range edgeOrder {
+		neighbors[edge.a] = append(neighbors[edge.a], edge.b)
+		neighbors[edge.b] = append(neighbors[edge.b], edge.a)
+		if len(edge.opposite) != 2 {
+			boundaryNeighbors[edge.a] = append(boundaryNeighbors[edge.a], edge.b)
+			boundaryNeighbors[edge.b] = append(boundaryNeighbors[edge.b], edge.a)
+		}
+	}
+
+	newVertices := make([]core.Vec3, len(vertices)+len(edgeOrder))
+
+	// Existing vertices move toward their neighbors
+	for i, v := range vertices {
+		switch boundary := boundaryNeighbors[i]; {
+		case len(boundary) == 2:
+			newVertices[i] = v.Multiply(0.75).Add(vertices[boundary[0]].Add(vertices[boundary[1]]).Multiply(0.125))
+		case len(boundary) == 0 && len(neighbors[i]) > 0:
+			n := len(neighbors[i])
+			beta := loopBeta(n)
+			sum := core.Vec3{}
+			for _, neighbor := range neighbors[i] {
+				sum = sum.Add(vertices[neighbor])
+			}
+			newVertices[i] = v.Multiply(1 - float64(n)*beta).Add(sum.Multiply(beta))
+		default:
+			newVertices[i] = v // Corners where several boundaries meet, and unused vertices, stay put
+		}
+	}
+
+	// New vertices on the edges
+	for _, edge := range edgeOrder {
+		a, b := vertices[edge.a], vertices[edge.b]
+		if len(edge.opposite) == 2 {
+			c, d := vertices[edge.opposite[0]], vertices[edge.opposite[1]]
+			newVertices[edge.index] = a.Add(b).Multiply(0.375).Add(c.Add(d).Multiply(0.125))
+		} else {
+			newVertices[edge.index] = a.Add(b).Multiply(0.5)
+		}
+	}
+
+	var newUVs []core.Vec2
+	if uvs != nil {
+		newUVs = make([]core.Vec2, len(newVertices))
+		copy(newUVs, uvs)
+		for _, edge := range edgeOrder {
+			ua, ub := uvs[edge.a], uvs[edge.b]
+			newUVs[edge.index] = core.NewVec2((ua.X+ub.X)/2, (ua.Y+ub.Y)/2)
+		}
+	}
+
+	// Each triangle becomes three corner triangles and a middle one, keeping its winding
+	newFaces := make([]int, 0, 4*len(faces))
+	for t := 0; t+2 < len(faces); t += 3 {
+		v0, v1, v2 := faces[t], faces[t+1], faces[t+2]
+		e01 := edges[[2]int{min(v0, v1), max(v0, v1)}].index
+		e12 := edges[[2]int{min(v1, v2), max(v1, v2)}].index
+		e20 := edges[[2]int{min(v2, v0), max(v2, v0)}].index
+		newFaces = append(newFaces,
+			v0, e01, e20,
+			e01, v1, e12,
+			e20, e12, v2,
+			e01, e12, e20,
+		)
+	}
+
+	return newVertices, newFaces, newUVs
+}
+
+// loopBeta is the weight of each neighbor of an interior vertex with n neighbors (Loop's original rule)
+func loopBeta(n int) float64 {
+	w := 0.375 + 0.25*math.Cos(2*math.Pi/float64(n))
+	return (0.625 - w*w) / float64(n)
+}
+
+// displaceVertices moves each vertex along the surface normal by scale times the luminance of the
+// displacement texture at its UV (zero without UVs) and position. Vertices at the same position,
+// such as along UV seams, are moved along a shared normal so the surface doesn't tear there.
+func displaceVertices(vertices []core.Vec3, faces []int, uvs []core.Vec2, displacement material.ColorSource, scale float64) []core.Vec3 {
+	// Area-weighted normals, accumulated per position rather than per vertex
+	normals := make(map[core.Vec3]core.Vec3)
+	for t := 0; t+2 < len(faces); t += 3 {
+		p0, p1, p2 := vertices[faces[t]], vertices[faces[t+1]], vertices[faces[t+2]]
+		faceNormal := p1.Subtract(p0).Cross(p2.Subtract(p0))
+		for _, p := range [3]core.Vec3{p0, p1, p2} {
+			normals[p] = normals[p].Add(faceNormal)
+		}
+	}
+
+	displaced := make([]core.Vec3, len(vertices))
+	for i, p := range vertices {
+		var uv core.Vec2
+		if uvs != nil {
+			uv = uvs[i]
+		}
+		normal := normals[p].Normalize()
+		height := material.EvaluateOriented(displacement, uv, p, normal).Luminance() * scale
+		displaced[i] = p.Add(normal.Multiply(height))
+	}
+	return displaced
+}
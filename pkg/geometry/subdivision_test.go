@@ -0,0 +1,219 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// tetrahedron returns a closed, outward-wound tetrahedron
+func tetrahedron() ([]core.Vec3, []int) {
+	vertices := []core.Vec3{
+		core.NewVec3(1, 1, 1),
+		core.NewVec3(1, -1, -1),
+		core.NewVec3(-1, 1, -1),
+		core.NewVec3(-1, -1, 1),
+	}
+	faces := []int{0, 1, 2, 0, 3, 1, 0, 2, 3, 1, 3, 2}
+	return vertices, faces
+}
+
+func TestLoopSubdivide_ClosedMesh(t *testing.T) {
+	vertices, faces := tetrahedron()
+	newVertices, newFaces, newUVs := loopSubdivide(vertices, faces, nil)
+
+	if len(newVertices) != 4+6 || len(newFaces) != 4*len(faces) || newUVs != nil {
+		t.Fatalf("Expected 10 vertices and 16 triangles, got %d and %d", len(newVertices), len(newFaces)/3)
+	}
+
+	// Valence 3 vertices keep 1 - 3*(3/16) of themselves and take 3/16 of each neighbor
+	neighborSum := vertices[1].Add(vertices[2]).Add(vertices[3])
+	expected := vertices[0].Multiply(7.0 / 16).Add(neighborSum.Multiply(3.0 / 16))
+	if !newVertices[0].Equals(expected) {
+		t.Errorf("Vertex 0 moved to %v, want %v", newVertices[0], expected)
+	}
+
+	// Edge 0-1 is shared by triangles with opposite vertices 2 and 3
+	edge := vertices[0].Add(vertices[1]).Multiply(0.375).Add(vertices[2].Add(vertices[3]).Multiply(0.125))
+	if !newVertices[4].Equals(edge) {
+		t.Errorf("Edge vertex = %v, want %v", newVertices[4], edge)
+	}
+
+	// The refined mesh stays closed (every edge shared by two triangles) and consistently outward
+	edgeUses := make(map[[2]int]int)
+	volume := 0.0
+	for i := 0; i < len(newFaces); i += 3 {
+		for e := 0; e < 3; e++ {
+			edgeUses[[2]int{newFaces[i+e], newFaces[i+(e+1)%3]}]++
+		}
+		p0, p1, p2 := newVertices[newFaces[i]], newVertices[newFaces[i+1]], newVertices[newFaces[i+2]]
+		volume += p0.Dot(p1.Cross(p2))
+	}
+	for key, uses := range edgeUses {
+		if uses != 1 || edgeUses[[2]int{key[1], key[0]}] != 1 {
+			t.Fatalf("Directed edge %v used %d times, reverse %d times", key, uses, edgeUses[[2]int{key[1], key[0]}])
+		}
+	}
+	if volume <= 0 {
+		t.Errorf("Expected the subdivided mesh to keep outward winding, got signed volume %v", volume)
+	}
+}
+
+func TestLoopSubdivide_BoundaryAndUVs(t *testing.T) {
+	// A flat square of two triangles: everything is on the boundary except the diagonal
+	vertices := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(2, 0, 0), core.NewVec3(2, 2, 0), core.NewVec3(0, 2, 0),
+	}
+	faces := []int{0, 1, 2, 0, 2, 3}
+	uvs := []core.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+
+	newVertices, newFaces, newUVs := loopSubdivide(vertices, faces, uvs)
+	if len(newVertices) != 4+5 || len(newFaces) != 24 || len(newUVs) != len(newVertices) {
+		t.Fatalf("Expected 9 vertices with UVs and 8 triangles, got %d vertices, %d UVs, %d triangles",
+			len(newVertices), len(newUVs), len(newFaces)/3)
+	}
+
+	for i, v := range newVertices {
+		if v.Z != 0 {
+			t.Errorf("Vertex %d left the plane: %v", i, v)
+		}
+	}
+
+	// Corner 1 has boundary neighbors 0 and 2: 3/4 of itself, 1/8 of each
+	if expected := core.NewVec3(1.75, 0.25, 0); !newVertices[1].Equals(expected) {
+		t.Errorf("Boundary vertex = %v, want %v", newVertices[1], expected)
+	}
+
+	// Boundary edge 0-1 gets its midpoint, for both position and UV
+	if !newVertices[4].Equals(core.NewVec3(1, 0, 0)) || newUVs[4] != core.NewVec2(0.5, 0) {
+		t.Errorf("Boundary edge vertex = %v with UV %v, want the midpoint", newVertices[4], newUVs[4])
+	}
+}
+
+func TestLoopSubdivide_ConvergesToSmoothSurface(t *testing.T) {
+	// Subdividing an octahedron approaches a smooth blob: the angle between neighboring faces shrinks
+	vertices := []core.Vec3{
+		core.NewVec3(1, 0, 0), core.NewVec3(-1, 0, 0), core.NewVec3(0, 1, 0),
+		core.NewVec3(0, -1, 0), core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1),
+	}
+	faces := []int{0, 2, 4, 2, 1, 4, 1, 3, 4, 3, 0, 4, 2, 0, 5, 1, 2, 5, 3, 1, 5, 0, 3, 5}
+
+	maxCrease := func(vertices []core.Vec3, faces []int) float64 {
+		normals := make(map[[2]int][]core.Vec3)
+		for i := 0; i < len(faces); i += 3 {
+			p0, p1, p2 := vertices[faces[i]], vertices[faces[i+1]], vertices[faces[i+2]]
+			normal := p1.Subtract(p0).Cross(p2.Subtract(p0)).Normalize()
+			for e := 0; e < 3; e++ {
+				a, b := faces[i+e], faces[i+(e+1)%3]
+				key := [2]int{min(a, b), max(a, b)}
+				normals[key] = append(normals[key], normal)
+			}
+		}
+		crease := 0.0
+		for _, pair := range normals {
+			crease = math.Max(crease, math.Acos(math.Min(1, pair[0].Dot(pair[1]))))
+		}
+		return crease
+	}
+
+	previous := maxCrease(vertices, faces)
+	for level := 1; level <= 3; level++ {
+		vertices, faces, _ = loopSubdivide(vertices, faces, nil)
+		crease := maxCrease(vertices, faces)
+		if crease >= previous*0.75 {
+			t.Errorf("Level %d: largest crease %.3f rad, expected well below %.3f", level, crease, previous)
+		}
+		previous = crease
+	}
+}
+
+func TestTriangleMesh_Subdivision(t *testing.T) {
+	vertices, faces := tetrahedron()
+	red := material.NewLambertian(core.NewVec3(1, 0, 0))
+	blue := material.NewLambertian(core.NewVec3(0, 0, 1))
+
+	mesh := NewTriangleMesh(vertices, faces, red, &TriangleMeshOptions{
+		SubdivisionLevels: 2,
+		Materials:         []material.Material{red, blue, red, red},
+		SmoothNormals:     true,
+	})
+	if mesh.GetTriangleCount() != 4*16 {
+		t.Fatalf("Expected 64 triangles after 2 levels, got %d", mesh.GetTriangleCount())
+	}
+
+	// The second face's 16 descendants keep its material
+	for i, triangle := range mesh.GetTriangles() {
+		expected := material.Material(red)
+		if i >= 16 && i < 32 {
+			expected = blue
+		}
+		if triangle.(*Triangle).Material != expected {
+			t.Fatalf("Triangle %d has the wrong material", i)
+		}
+	}
+
+	// Per-triangle normals don't survive subdivision
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for per-triangle normals with subdivision")
+		}
+	}()
+	NewTriangleMesh(vertices, faces, red, &TriangleMeshOptions{
+		SubdivisionLevels: 1,
+		Normals:           make([]core.Vec3, 4),
+	})
+}
+
+func TestTriangleMesh_Displacement(t *testing.T) {
+	// A flat square facing +Z whose left half is displaced by a two-pixel texture (black, white)
+	vertices := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(2, 0, 0), core.NewVec3(2, 2, 0), core.NewVec3(0, 2, 0),
+	}
+	faces := []int{0, 1, 2, 0, 2, 3}
+	uvs := []core.Vec2{{X: 0.1, Y: 0}, {X: 0.9, Y: 0}, {X: 0.9, Y: 1}, {X: 0.1, Y: 1}}
+	texture := material.NewImageTexture(2, 1, []core.Vec3{core.NewVec3(1, 1, 1), core.NewVec3(0, 0, 0)})
+
+	mesh := NewTriangleMesh(vertices, faces, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), &TriangleMeshOptions{
+		SubdivisionLevels: 1,
+		VertexUVs:         uvs,
+		Displacement:      texture,
+		DisplacementScale: 0.5,
+	})
+
+	for _, shape := range mesh.GetTriangles() {
+		triangle := shape.(*Triangle)
+		for _, v := range [3]core.Vec3{triangle.V0, triangle.V1, triangle.V2} {
+			// UVs are linear in x here, so u < 0.5 exactly when x < 1
+			expected := 0.0
+			if v.X < 1 {
+				expected = 0.5
+			}
+			if math.Abs(v.Z-expected) > 1e-9 {
+				t.Errorf("Vertex at x=%.2f displaced to z=%v, want %v", v.X, v.Z, expected)
+			}
+		}
+	}
+}
+
+func TestDisplaceVertices_SharedNormalAcrossSeam(t *testing.T) {
+	// Two faces at a right angle, split along their shared edge (as a UV seam would): each side's
+	// copy of the edge must move the same way, or the surface would tear
+	vertices := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), core.NewVec3(1, 0, 0), // Floor, facing +Z
+		core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), core.NewVec3(0, 0, 1), // Wall, facing +X
+	}
+	faces := []int{0, 2, 1, 3, 4, 5}
+
+	displaced := displaceVertices(vertices, faces, nil, material.NewSolidColor(core.NewVec3(1, 1, 1)), 1)
+	if !displaced[0].Equals(displaced[3]) || !displaced[1].Equals(displaced[4]) {
+		t.Errorf("Seam vertices separated: %v vs %v, %v vs %v", displaced[0], displaced[3], displaced[1], displaced[4])
+	}
+
+	// The shared normal is halfway between the faces
+	expected := core.NewVec3(1, 0, 1).Normalize()
+	if !displaced[0].Equals(expected) {
+		t.Errorf("Seam vertex moved to %v, want %v", displaced[0], expected)
+	}
+}
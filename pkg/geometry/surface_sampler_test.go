@@ -0,0 +1,147 @@
+package geometry
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func surfaceSamplerTestShapes(t *testing.T) map[string]SurfaceSampler {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cone, err := NewCone(core.NewVec3(0, 0, 0), 1.0, core.NewVec3(0, 2, 0), 0.0, false, mat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+	frustum, err := NewCone(core.NewVec3(1, 0, 0), 1.5, core.NewVec3(1, 0, 3), 0.5, true, mat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+
+	return map[string]SurfaceSampler{
+		"sphere":          NewSphere(core.NewVec3(1, 2, 3), 1.5, mat),
+		"quad":            NewQuad(core.NewVec3(0, 0, 0), core.NewVec3(2, 0, 0), core.NewVec3(0, 1, 1), mat),
+		"disc":            NewDisc(core.NewVec3(0, 1, 0), core.NewVec3(1, 1, 0), 0.75, mat),
+		"triangle":        NewTriangle(core.NewVec3(0, 0, 0), core.NewVec3(3, 0, 0), core.NewVec3(0, 2, 1), mat),
+		"cylinder":        NewCylinder(core.NewVec3(0, 0, 0), core.NewVec3(0, 2, 0), 0.5, false, mat),
+		"capped cylinder": NewCylinder(core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 1), 0.5, true, mat),
+		"cone":            cone,
+		"capped frustum":  frustum,
+		"capsule":         NewCapsule(core.NewVec3(0, 1, 0), core.NewVec3(2, 1, 1), 0.25, mat),
+	}
+}
+
+func TestSurfaceSampler_SurfaceArea(t *testing.T) {
+	shapes := surfaceSamplerTestShapes(t)
+
+	slant := math.Sqrt(9.0 + 1.0)
+	expected := map[string]float64{
+		"sphere":          4 * math.Pi * 1.5 * 1.5,
+		"quad":            2 * math.Sqrt(2),
+		"disc":            math.Pi * 0.75 * 0.75,
+		"triangle":        0.5 * core.NewVec3(3, 0, 0).Cross(core.NewVec3(0, 2, 1)).Length(),
+		"cylinder":        2 * math.Pi * 0.5 * 2,
+		"capped cylinder": 2*math.Pi*0.5*math.Sqrt(3) + 2*math.Pi*0.25,
+		"cone":            math.Pi * 1.0 * math.Sqrt(4+1),
+		"capped frustum":  math.Pi*(1.5+0.5)*slant + math.Pi*(1.5*1.5+0.5*0.5),
+		"capsule":         2*math.Pi*0.25*math.Sqrt(5) + 4*math.Pi*0.25*0.25,
+	}
+
+	for name, shape := range shapes {
+		if got := shape.SurfaceArea(); math.Abs(got-expected[name]) > 1e-9 {
+			t.Errorf("%s: expected area %f, got %f", name, expected[name], got)
+		}
+	}
+}
+
+func TestSurfaceSampler_SamplesLieOnSurface(t *testing.T) {
+	shapes := surfaceSamplerTestShapes(t)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+
+	for name, shape := range shapes {
+		expectedPDF := 1.0 / shape.SurfaceArea()
+		for i := 0; i < 1000; i++ {
+			point, normal := shape.SampleSurface(sampler.Get2D())
+
+			if math.Abs(normal.Length()-1.0) > 1e-9 {
+				t.Fatalf("%s: sampled normal not unit length: %v", name, normal)
+			}
+			if pdf := shape.AreaPDF(point); math.Abs(pdf-expectedPDF) > 1e-9 {
+				t.Fatalf("%s: sampled point %v has AreaPDF %f, expected %f", name, point, pdf, expectedPDF)
+			}
+			if n := shape.NormalAt(point); n.Subtract(normal).Length() > 1e-6 {
+				t.Fatalf("%s: NormalAt(%v) = %v, SampleSurface returned %v", name, point, n, normal)
+			}
+
+			// A ray fired back at the sampled point along the normal must hit the shape there
+			ray := core.NewRay(point.Add(normal.Multiply(0.01)), normal.Negate())
+			hit, isHit := shape.Hit(ray, 0.001, 0.02)
+			if !isHit || hit.Point.Subtract(point).Length() > 1e-6 {
+				t.Fatalf("%s: sampled point %v not found by Hit", name, point)
+			}
+		}
+	}
+}
+
+func TestSurfaceSampler_AreaPDF_OffSurface(t *testing.T) {
+	shapes := surfaceSamplerTestShapes(t)
+
+	for name, shape := range shapes {
+		bbox := shape.BoundingBox()
+		farPoint := bbox.Max.Add(core.NewVec3(5, 5, 5))
+		if pdf := shape.AreaPDF(farPoint); pdf != 0 {
+			t.Errorf("%s: expected AreaPDF 0 for point off the surface, got %f", name, pdf)
+		}
+	}
+}
+
+func TestSurfaceSampler_UniformAcrossParts(t *testing.T) {
+	// Capped shapes must pick each part proportionally to its area
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cylinder := NewCylinder(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 1.0, true, mat)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(7)))
+
+	const numSamples = 100000
+	capCount := 0
+	upperHalf := 0
+	for i := 0; i < numSamples; i++ {
+		point, normal := cylinder.SampleSurface(sampler.Get2D())
+		if math.Abs(normal.Y) > 0.5 {
+			capCount++
+		} else if point.Y > 0.5 {
+			upperHalf++
+		}
+	}
+
+	// Body area 2π, caps 2π: half the samples should land on the caps
+	capFraction := float64(capCount) / numSamples
+	if math.Abs(capFraction-0.5) > 0.01 {
+		t.Errorf("Expected ~50%% of samples on caps, got %.2f%%", capFraction*100)
+	}
+
+	// Body samples should be uniform in height
+	bodyCount := numSamples - capCount
+	upperFraction := float64(upperHalf) / float64(bodyCount)
+	if math.Abs(upperFraction-0.5) > 0.01 {
+		t.Errorf("Expected ~50%% of body samples in the upper half, got %.2f%%", upperFraction*100)
+	}
+
+	// Pointed cone: the lower half (by height) holds 3/4 of the lateral area
+	cone, err := NewCone(core.NewVec3(0, 0, 0), 1.0, core.NewVec3(0, 1, 0), 0.0, false, mat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+	lowerHalf := 0
+	for i := 0; i < numSamples; i++ {
+		point, _ := cone.SampleSurface(sampler.Get2D())
+		if point.Y < 0.5 {
+			lowerHalf++
+		}
+	}
+	lowerFraction := float64(lowerHalf) / numSamples
+	if math.Abs(lowerFraction-0.75) > 0.01 {
+		t.Errorf("Expected ~75%% of cone samples in the lower half, got %.2f%%", lowerFraction*100)
+	}
+}
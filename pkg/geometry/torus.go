@@ -0,0 +1,271 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Torus represents a ring torus: a tube of MinorRadius swept around a circle of MajorRadius
+// Useful for rings, gaskets and handles, which would otherwise need finely tessellated meshes
+type Torus struct {
+	Center      core.Vec3
+	Axis        core.Vec3 // Unit normal of the plane of the ring
+	MajorRadius float64   // Distance from the center to the middle of the tube
+	MinorRadius float64   // Radius of the tube
+	Material    material.Material
+
+	// Cached derived values
+	tangent   core.Vec3 // Local x axis, in the plane of the ring
+	bitangent core.Vec3 // Local y axis, in the plane of the ring
+}
+
+// NewTorus creates a new torus around the axis through center
+func NewTorus(center, axis core.Vec3, majorRadius, minorRadius float64, mat material.Material) (*Torus, error) {
+	if minorRadius <= 0 {
+		return nil, fmt.Errorf("minor radius must be positive, got %f", minorRadius)
+	}
+	if majorRadius <= minorRadius {
+		return nil, fmt.Errorf("major radius must be greater than minor radius (got major=%f, minor=%f)", majorRadius, minorRadius)
+	}
+	if axis.Length() == 0 {
+		return nil, fmt.Errorf("axis must be non-zero")
+	}
+
+	unitAxis := axis.Normalize()
+	tangent, bitangent := axisFrame(unitAxis)
+	return &Torus{
+		Center:      center,
+		Axis:        unitAxis,
+		MajorRadius: majorRadius,
+		MinorRadius: minorRadius,
+		Material:    mat,
+		tangent:     tangent,
+		bitangent:   bitangent,
+	}, nil
+}
+
+// BoundingBox returns the axis-aligned bounding box for this torus
+func (t *Torus) BoundingBox() AABB {
+	// The ring circle extends R·sqrt(1 - axis_i²) along world axis i, and the tube adds r all around
+	extent := func(axisComponent float64) float64 {
+		return t.MajorRadius*math.Sqrt(math.Max(0, 1-axisComponent*axisComponent)) + t.MinorRadius
+	}
+	half := core.NewVec3(extent(t.Axis.X), extent(t.Axis.Y), extent(t.Axis.Z))
+	return NewAABB(t.Center.Subtract(half), t.Center.Add(half))
+}
+
+// Hit tests if a ray intersects with the torus
+func (t *Torus) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	root, isHit := t.intersect(ray, tMin, tMax)
+	if !isHit {
+		return nil, false
+	}
+
+	point := ray.At(root)
+	local := t.toLocal(point)
+
+	// The normal points away from the closest point on the ring circle
+	ringDistance := math.Hypot(local.X, local.Y)
+	ringPoint := core.NewVec3(local.X, local.Y, 0).Multiply(t.MajorRadius / ringDistance)
+	localNormal := local.Subtract(ringPoint).Multiply(1.0 / t.MinorRadius)
+	outwardNormal := t.tangent.Multiply(localNormal.X).
+		Add(t.bitangent.Multiply(localNormal.Y)).
+		Add(t.Axis.Multiply(localNormal.Z)).
+		Normalize()
+
+	// U: angle around the axis, V: angle around the tube (0 at the outer equator)
+	u := (math.Atan2(local.Y, local.X) + math.Pi) / (2.0 * math.Pi)
+	v := math.Atan2(local.Z, ringDistance-t.MajorRadius)
+	if v < 0 {
+		v += 2.0 * math.Pi
+	}
+	uv := core.NewVec2(u, v/(2.0*math.Pi))
+
+	hitRecord := &material.SurfaceInteraction{
+		T:        root,
+		Point:    point,
+		Material: t.Material,
+		UV:       uv,
+	}
+	hitRecord.SetFaceNormal(ray, outwardNormal)
+
+	return hitRecord, true
+}
+
+// AlphaMasked implements the MaskedShape interface
+func (t *Torus) AlphaMasked() bool {
+	return hasAlphaMask(t.Material)
+}
+
+// HitAny reports whether a ray intersects the torus, without building the hit record (see Occluder)
+func (t *Torus) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, isHit := t.intersect(ray, tMin, tMax)
+	return isHit
+}
+
+// intersect returns the ray's nearest intersection with the torus in [tMin, tMax]
+func (t *Torus) intersect(ray core.Ray, tMin, tMax float64) (float64, bool) {
+	directionLength := ray.Direction.Length()
+	if directionLength == 0 {
+		return 0, false
+	}
+
+	// Work in the torus frame with a unit direction, so the quartic's roots are distances
+	origin := t.toLocal(ray.Origin)
+	direction := t.toLocal(t.Center.Add(ray.Direction)).Multiply(1.0 / directionLength)
+
+	// Start from the point on the ray nearest the center: the quartic's coefficients grow with
+	// the distance to the origin, and far away rays would lose their roots to cancellation
+	shift := -origin.Dot(direction)
+	origin = origin.Add(direction.Multiply(shift))
+	bound := t.MajorRadius + t.MinorRadius
+	if origin.LengthSquared() > bound*bound {
+		return 0, false // The ray passes outside the bounding sphere
+	}
+
+	// |p|² + R² - r² = 2R·sqrt(px² + py²), squared, with p = origin + s·direction
+	R2 := t.MajorRadius * t.MajorRadius
+	k := origin.Dot(direction)
+	m := origin.LengthSquared() + R2 - t.MinorRadius*t.MinorRadius
+	a2 := 4*k*k + 2*m - 4*R2*(direction.X*direction.X+direction.Y*direction.Y)
+	a1 := 4*k*m - 8*R2*(origin.X*direction.X+origin.Y*direction.Y)
+	a0 := m*m - 4*R2*(origin.X*origin.X+origin.Y*origin.Y)
+
+	for _, s := range solveQuartic(4*k, a2, a1, a0) {
+		root := (s + shift) / directionLength
+		if root >= tMin && root <= tMax {
+			return root, true
+		}
+	}
+	return 0, false
+}
+
+// toLocal expresses a point in the torus frame: x and y in the plane of the ring, z along the axis
+func (t *Torus) toLocal(point core.Vec3) core.Vec3 {
+	offset := point.Subtract(t.Center)
+	return core.NewVec3(offset.Dot(t.tangent), offset.Dot(t.bitangent), offset.Dot(t.Axis))
+}
+
+// SignedDistance implements the SignedDistanceField interface - distance to the ring circle minus the tube radius
+func (t *Torus) SignedDistance(point core.Vec3) float64 {
+	local := t.toLocal(point)
+	return math.Hypot(math.Hypot(local.X, local.Y)-t.MajorRadius, local.Z) - t.MinorRadius
+}
+
+// solveQuartic returns the real roots of x⁴ + a3x³ + a2x² + a1x + a0 = 0 in ascending order
+// Ferrari's method, reducing to a depressed quartic and factoring it with a resolvent cubic root,
+// then each root is polished with Newton's method
+func solveQuartic(a3, a2, a1, a0 float64) []float64 {
+	// Substitute x = y - a3/4 to eliminate the cubic term: y⁴ + py² + qy + r = 0
+	a3Sq := a3 * a3
+	p := -3.0/8*a3Sq + a2
+	q := 1.0/8*a3Sq*a3 - 1.0/2*a3*a2 + a1
+	r := -3.0/256*a3Sq*a3Sq + 1.0/16*a3Sq*a2 - 1.0/4*a3*a1 + a0
+
+	var roots []float64
+	if isNearZero(r) {
+		// y(y³ + py + q) = 0
+		roots = append(solveCubic(0, p, q), 0)
+	} else {
+		// Any real root z of the resolvent cubic splits the quartic into two quadratics
+		resolvent := solveCubic(-1.0/2*p, -r, 1.0/2*r*p-1.0/8*q*q)
+		z := resolvent[len(resolvent)-1]
+
+		u := z*z - r
+		v := 2*z - p
+		switch {
+		case isNearZero(u):
+			u = 0
+		case u > 0:
+			u = math.Sqrt(u)
+		default:
+			return nil
+		}
+		switch {
+		case isNearZero(v):
+			v = 0
+		case v > 0:
+			v = math.Sqrt(v)
+		default:
+			return nil
+		}
+		if q < 0 {
+			v = -v
+		}
+		roots = append(solveMonicQuadratic(v, z-u), solveMonicQuadratic(-v, z+u)...)
+	}
+
+	// Undo the substitution and polish
+	for i, y := range roots {
+		x := y - a3/4
+		for iteration := 0; iteration < 2; iteration++ {
+			value := (((x+a3)*x+a2)*x+a1)*x + a0
+			slope := ((4*x+3*a3)*x+2*a2)*x + a1
+			if slope == 0 {
+				break
+			}
+			x -= value / slope
+		}
+		roots[i] = x
+	}
+	sort.Float64s(roots)
+	return roots
+}
+
+// solveCubic returns the real roots of x³ + a2x² + a1x + a0 = 0 in ascending order
+func solveCubic(a2, a1, a0 float64) []float64 {
+	// Substitute x = y - a2/3 to eliminate the quadratic term: y³ + 3py + 2q = 0
+	a2Sq := a2 * a2
+	p := 1.0 / 3 * (-1.0/3*a2Sq + a1)
+	q := 1.0 / 2 * (2.0/27*a2*a2Sq - 1.0/3*a2*a1 + a0)
+	pCubed := p * p * p
+	discriminant := q*q + pCubed
+
+	var roots []float64
+	switch {
+	case isNearZero(discriminant):
+		if isNearZero(q) {
+			roots = []float64{0} // Triple root
+		} else {
+			u := math.Cbrt(-q)
+			roots = []float64{2 * u, -u} // A single and a double root
+		}
+	case discriminant < 0:
+		// Three real roots (casus irreducibilis), found trigonometrically
+		phi := 1.0 / 3 * math.Acos(-q/math.Sqrt(-pCubed))
+		t := 2 * math.Sqrt(-p)
+		roots = []float64{t * math.Cos(phi), -t * math.Cos(phi+math.Pi/3), -t * math.Cos(phi-math.Pi/3)}
+	default:
+		sqrtD := math.Sqrt(discriminant)
+		roots = []float64{math.Cbrt(sqrtD-q) - math.Cbrt(sqrtD+q)}
+	}
+
+	for i := range roots {
+		roots[i] -= a2 / 3
+	}
+	sort.Float64s(roots)
+	return roots
+}
+
+// solveMonicQuadratic returns the real roots of x² + bx + c = 0
+func solveMonicQuadratic(b, c float64) []float64 {
+	discriminant := b*b/4 - c
+	switch {
+	case isNearZero(discriminant):
+		return []float64{-b / 2}
+	case discriminant < 0:
+		return nil
+	default:
+		sqrtD := math.Sqrt(discriminant)
+		return []float64{-b/2 - sqrtD, -b/2 + sqrtD}
+	}
+}
+
+// isNearZero reports whether a polynomial solver intermediate is zero within rounding error
+func isNearZero(x float64) bool {
+	return math.Abs(x) < 1e-9
+}
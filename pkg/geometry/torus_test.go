@@ -0,0 +1,368 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestNewTorus_Validation(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	tests := []struct {
+		name        string
+		axis        core.Vec3
+		major       float64
+		minor       float64
+		expectError bool
+	}{
+		{"valid torus", core.NewVec3(0, 1, 0), 2.0, 0.5, false},
+		{"unnormalized axis", core.NewVec3(0, 3, 0), 2.0, 0.5, false},
+		{"zero minor radius", core.NewVec3(0, 1, 0), 2.0, 0.0, true},
+		{"negative minor radius", core.NewVec3(0, 1, 0), 2.0, -0.5, true},
+		{"minor radius equal to major", core.NewVec3(0, 1, 0), 1.0, 1.0, true},
+		{"zero axis", core.NewVec3(0, 0, 0), 2.0, 0.5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			torus, err := NewTorus(core.NewVec3(0, 0, 0), tt.axis, tt.major, tt.minor, mat)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTorus failed: %v", err)
+			}
+			if math.Abs(torus.Axis.Length()-1) > 1e-9 {
+				t.Errorf("Expected a unit axis, got %v", torus.Axis)
+			}
+		})
+	}
+}
+
+func TestTorus_BoundingBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		center  core.Vec3
+		axis    core.Vec3
+		wantMin core.Vec3
+		wantMax core.Vec3
+	}{
+		{
+			name:    "axis-aligned Y",
+			center:  core.NewVec3(0, 0, 0),
+			axis:    core.NewVec3(0, 1, 0),
+			wantMin: core.NewVec3(-2.5, -0.5, -2.5),
+			wantMax: core.NewVec3(2.5, 0.5, 2.5),
+		},
+		{
+			name:    "axis-aligned Z, offset center",
+			center:  core.NewVec3(1, 2, 3),
+			axis:    core.NewVec3(0, 0, 1),
+			wantMin: core.NewVec3(-1.5, -0.5, 2.5),
+			wantMax: core.NewVec3(3.5, 4.5, 3.5),
+		},
+		{
+			// The ring circle reaches 2·cos(45°) along X and Y
+			name:    "tilted 45 degrees about Z",
+			center:  core.NewVec3(0, 0, 0),
+			axis:    core.NewVec3(1, 1, 0),
+			wantMin: core.NewVec3(-math.Sqrt2-0.5, -math.Sqrt2-0.5, -2.5),
+			wantMax: core.NewVec3(math.Sqrt2+0.5, math.Sqrt2+0.5, 2.5),
+		},
+	}
+
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			torus, err := NewTorus(tt.center, tt.axis, 2.0, 0.5, mat)
+			if err != nil {
+				t.Fatalf("NewTorus failed: %v", err)
+			}
+
+			bbox := torus.BoundingBox()
+			if !approxEqualVec(bbox.Min, tt.wantMin, 1e-9) {
+				t.Errorf("Expected min %v, got %v", tt.wantMin, bbox.Min)
+			}
+			if !approxEqualVec(bbox.Max, tt.wantMax, 1e-9) {
+				t.Errorf("Expected max %v, got %v", tt.wantMax, bbox.Max)
+			}
+		})
+	}
+}
+
+func TestTorus_Hit(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	// Ring in the XZ plane: the tube spans 1.5 to 2.5 from the center
+	torus, err := NewTorus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 2.0, 0.5, mat)
+	if err != nil {
+		t.Fatalf("NewTorus failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		origin     core.Vec3
+		dir        core.Vec3
+		wantPoint  core.Vec3
+		wantNormal core.Vec3
+		frontFace  bool
+	}{
+		{
+			name:       "outer equator",
+			origin:     core.NewVec3(5, 0, 0),
+			dir:        core.NewVec3(-1, 0, 0),
+			wantPoint:  core.NewVec3(2.5, 0, 0),
+			wantNormal: core.NewVec3(1, 0, 0),
+			frontFace:  true,
+		},
+		{
+			name:       "inner equator from the hole",
+			origin:     core.NewVec3(0, 0, 0),
+			dir:        core.NewVec3(0, 0, 1),
+			wantPoint:  core.NewVec3(0, 0, 1.5),
+			wantNormal: core.NewVec3(0, 0, -1),
+			frontFace:  true,
+		},
+		{
+			name:       "top of the tube",
+			origin:     core.NewVec3(-2, 5, 0),
+			dir:        core.NewVec3(0, -2, 0), // Unnormalized direction
+			wantPoint:  core.NewVec3(-2, 0.5, 0),
+			wantNormal: core.NewVec3(0, 1, 0),
+			frontFace:  true,
+		},
+		{
+			name:       "inside the tube",
+			origin:     core.NewVec3(0, 0, -2),
+			dir:        core.NewVec3(0, 0, 1),
+			wantPoint:  core.NewVec3(0, 0, -1.5),
+			wantNormal: core.NewVec3(0, 0, -1), // Flipped to face the ray
+			frontFace:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := torus.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0)
+			if !isHit {
+				t.Fatal("Expected hit, but got miss")
+			}
+			if !approxEqualVec(hit.Point, tt.wantPoint, 1e-9) {
+				t.Errorf("Expected hit point %v, got %v", tt.wantPoint, hit.Point)
+			}
+			if !approxEqualVec(hit.Normal, tt.wantNormal, 1e-9) {
+				t.Errorf("Expected normal %v, got %v", tt.wantNormal, hit.Normal)
+			}
+			if hit.FrontFace != tt.frontFace {
+				t.Errorf("Expected FrontFace %v, got %v", tt.frontFace, hit.FrontFace)
+			}
+			if math.Abs(hit.T-hit.Point.Subtract(tt.origin).Length()/tt.dir.Length()) > 1e-9 {
+				t.Errorf("Hit T %f doesn't match the hit point %v", hit.T, hit.Point)
+			}
+		})
+	}
+}
+
+func TestTorus_Hit_Miss(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus, err := NewTorus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 2.0, 0.5, mat)
+	if err != nil {
+		t.Fatalf("NewTorus failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		origin core.Vec3
+		dir    core.Vec3
+	}{
+		{"down the axis through the hole", core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)},
+		{"above the ring", core.NewVec3(5, 0.6, 0), core.NewVec3(-1, 0, 0)},
+		{"pointing away", core.NewVec3(5, 0, 0), core.NewVec3(1, 0, 0)},
+		{"outside the bounding sphere", core.NewVec3(5, 0, 3), core.NewVec3(0, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ray := core.NewRay(tt.origin, tt.dir)
+			if hit, isHit := torus.Hit(ray, 0.001, 1000.0); isHit {
+				t.Errorf("Expected miss, but got hit at %v", hit.Point)
+			}
+			if torus.HitAny(ray, 0.001, 1000.0) {
+				t.Error("Expected HitAny to miss")
+			}
+		})
+	}
+}
+
+func TestTorus_Hit_TBounds(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus, err := NewTorus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 2.0, 0.5, mat)
+	if err != nil {
+		t.Fatalf("NewTorus failed: %v", err)
+	}
+
+	// Along the X axis the ray crosses the surface at x = 2.5, 1.5, -1.5 and -2.5
+	ray := core.NewRay(core.NewVec3(5, 0, 0), core.NewVec3(-1, 0, 0))
+	for _, tt := range []struct {
+		tMin, tMax float64
+		wantT      float64
+	}{
+		{0.001, 100, 2.5},
+		{3, 100, 3.5},
+		{4, 100, 6.5},
+		{7, 100, 7.5},
+	} {
+		hit, isHit := torus.Hit(ray, tt.tMin, tt.tMax)
+		if !isHit {
+			t.Fatalf("Expected hit in [%f, %f], got miss", tt.tMin, tt.tMax)
+		}
+		if math.Abs(hit.T-tt.wantT) > 1e-9 {
+			t.Errorf("Expected T %f in [%f, %f], got %f", tt.wantT, tt.tMin, tt.tMax, hit.T)
+		}
+	}
+
+	if torus.HitAny(ray, 0.001, 2) {
+		t.Error("Expected no hit before t=2")
+	}
+	if _, isHit := torus.Hit(ray, 8, 100); isHit {
+		t.Error("Expected no hit past the far side")
+	}
+}
+
+func TestTorus_Hit_SurfaceConsistency(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus, err := NewTorus(core.NewVec3(1, -2, 0.5), core.NewVec3(0.3, 1, -0.4), 1.2, 0.3, mat)
+	if err != nil {
+		t.Fatalf("NewTorus failed: %v", err)
+	}
+
+	// Rays from all around, including far away ones, aimed near the ring
+	sampler := core.NewSeededSampler(5)
+	hits := 0
+	for i := 0; i < 2000; i++ {
+		distance := 3.0
+		if i%2 == 1 {
+			distance = 1000.0
+		}
+		origin := torus.Center.Add(core.SampleOnUnitSphere(sampler.Get2D()).Multiply(distance))
+		target := torus.Center.Add(core.SampleOnUnitSphere(sampler.Get2D()).Multiply(1.5 * sampler.Get1D()))
+		ray := core.NewRay(origin, target.Subtract(origin).Normalize())
+
+		hit, isHit := torus.Hit(ray, 0.001, math.Inf(1))
+		if torus.HitAny(ray, 0.001, math.Inf(1)) != isHit {
+			t.Fatalf("Ray %v: HitAny disagrees with Hit", ray)
+		}
+		if !isHit {
+			continue
+		}
+		hits++
+
+		if d := torus.SignedDistance(hit.Point); math.Abs(d) > 1e-7 {
+			t.Fatalf("Ray %v: hit point %v is %g from the surface", ray, hit.Point, d)
+		}
+		// The ray can't have passed through the surface before the hit
+		if torus.SignedDistance(ray.Origin) > 0 && torus.HitAny(ray, 0.001, hit.T-1e-6) {
+			t.Fatalf("Ray %v: a closer hit than t=%f exists", ray, hit.T)
+		}
+		// The normal is the gradient of the distance field
+		const h = 1e-6
+		gradient := core.NewVec3(
+			torus.SignedDistance(hit.Point.Add(core.NewVec3(h, 0, 0)))-torus.SignedDistance(hit.Point.Subtract(core.NewVec3(h, 0, 0))),
+			torus.SignedDistance(hit.Point.Add(core.NewVec3(0, h, 0)))-torus.SignedDistance(hit.Point.Subtract(core.NewVec3(0, h, 0))),
+			torus.SignedDistance(hit.Point.Add(core.NewVec3(0, 0, h)))-torus.SignedDistance(hit.Point.Subtract(core.NewVec3(0, 0, h))),
+		).Normalize()
+		if !hit.FrontFace {
+			gradient = gradient.Negate()
+		}
+		if !approxEqualVec(hit.Normal, gradient, 1e-4) {
+			t.Fatalf("Ray %v: normal %v, expected %v", ray, hit.Normal, gradient)
+		}
+		if hit.UV.X < 0 || hit.UV.X > 1 || hit.UV.Y < 0 || hit.UV.Y > 1 {
+			t.Fatalf("Ray %v: UV %v outside [0, 1]", ray, hit.UV)
+		}
+	}
+	if hits < 200 {
+		t.Errorf("Expected many hits, got %d", hits)
+	}
+}
+
+func TestTorus_UV(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	torus, err := NewTorus(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 2.0, 0.5, mat)
+	if err != nil {
+		t.Fatalf("NewTorus failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		origin   core.Vec3
+		dir      core.Vec3
+		expected core.Vec2
+	}{
+		{
+			// Axis is Y so the frame is tangent=(0,0,-1), bitangent=(-1,0,0), as for cones: +X maps to u=0.25
+			name:     "outer equator at +X",
+			origin:   core.NewVec3(5, 0, 0),
+			dir:      core.NewVec3(-1, 0, 0),
+			expected: core.NewVec2(0.25, 0),
+		},
+		{
+			name:     "top of the tube at -Z",
+			origin:   core.NewVec3(0, 5, -2),
+			dir:      core.NewVec3(0, -1, 0),
+			expected: core.NewVec2(0.5, 0.25),
+		},
+		{
+			name:     "inner equator at -Z",
+			origin:   core.NewVec3(0, 0, 0),
+			dir:      core.NewVec3(0, 0, -1),
+			expected: core.NewVec2(0.5, 0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := torus.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 1000.0)
+			if !isHit {
+				t.Fatal("Expected hit, but got miss")
+			}
+			if math.Abs(hit.UV.X-tt.expected.X) > 1e-6 || math.Abs(hit.UV.Y-tt.expected.Y) > 1e-6 {
+				t.Errorf("Expected UV %v, got %v", tt.expected, hit.UV)
+			}
+		})
+	}
+}
+
+func TestSolveQuartic(t *testing.T) {
+	tests := []struct {
+		name           string
+		a3, a2, a1, a0 float64
+		want           []float64
+	}{
+		{"four roots", -10, 35, -50, 24, []float64{1, 2, 3, 4}},           // (x-1)(x-2)(x-3)(x-4)
+		{"two real roots", 1, -1, 1, -2, []float64{-2, 1}},                // (x²+1)(x-1)(x+2)
+		{"no real roots", 0, 5, 0, 4, nil},                                // (x²+1)(x²+4)
+		{"zero constant term", -6, 11, -6, 0, []float64{0, 1, 2, 3}},      // x(x-1)(x-2)(x-3)
+		{"symmetric roots", 0, -5, 0, 4, []float64{-2, -1, 1, 2}},         // (x²-1)(x²-4)
+		{"spread roots", -100.5, 149, -49.5, 0, []float64{0, 0.5, 1, 99}}, // x(x-0.5)(x-1)(x-99)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := solveQuartic(tt.a3, tt.a2, tt.a1, tt.a0)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected roots %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("Expected roots %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
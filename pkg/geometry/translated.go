@@ -0,0 +1,70 @@
+package geometry
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Translated moves a shape by an offset without rebuilding it: rays are moved the other way into
+// the shape's own space and its hits moved back. Scene.MoveShape uses it to move shapes of any
+// kind, meshes included. Solid textures keep their place in the world, so the shape slides
+// through them.
+type Translated struct {
+	Shape
+	Offset core.Vec3
+}
+
+// NewTranslated moves a shape by an offset. Moving a shape that's already translated adds the
+// offsets rather than wrapping it twice.
+func NewTranslated(shape Shape, offset core.Vec3) *Translated {
+	if translated, ok := shape.(*Translated); ok {
+		return &Translated{Shape: translated.Shape, Offset: translated.Offset.Add(offset)}
+	}
+	return &Translated{Shape: shape, Offset: offset}
+}
+
+// Hit returns the wrapped shape's hit, moved by the offset
+func (t *Translated) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	hit, isHit := t.Shape.Hit(t.local(ray), tMin, tMax)
+	if isHit {
+		hit.Point = hit.Point.Add(t.Offset)
+	}
+	return hit, isHit
+}
+
+// AlphaMasked implements the MaskedShape interface with the wrapped shape's answer
+func (t *Translated) AlphaMasked() bool {
+	return alphaMasked(t.Shape)
+}
+
+// HitAny reports whether the ray intersects the moved shape
+func (t *Translated) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	return hitShapeAny(t.Shape, t.local(ray), tMin, tMax)
+}
+
+// BoundingBox returns the wrapped shape's bounds, moved by the offset
+func (t *Translated) BoundingBox() AABB {
+	box := t.Shape.BoundingBox()
+	return AABB{Min: box.Min.Add(t.Offset), Max: box.Max.Add(t.Offset)}
+}
+
+// Preprocess passes scene preprocessing on to the wrapped shape
+func (t *Translated) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	if preprocessor, ok := t.Shape.(Preprocessor); ok {
+		return preprocessor.Preprocess(worldCenter.Subtract(t.Offset), worldRadius)
+	}
+	return nil
+}
+
+// local moves a ray into the wrapped shape's space. Directions, and so t along the ray, don't
+// change.
+func (t *Translated) local(ray core.Ray) core.Ray {
+	ray.Origin = ray.Origin.Subtract(t.Offset)
+	if d := ray.Differential; d != nil {
+		ray.Differential = &core.RayDifferential{
+			RxOrigin: d.RxOrigin.Subtract(t.Offset), RxDirection: d.RxDirection,
+			RyOrigin: d.RyOrigin.Subtract(t.Offset), RyDirection: d.RyDirection,
+		}
+	}
+	return ray
+}
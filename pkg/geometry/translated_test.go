@@ -0,0 +1,29 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestTranslated(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	moved := NewTranslated(NewTranslated(NewSphere(core.Vec3{}, 1, mat), core.NewVec3(2, 0, 0)), core.NewVec3(1, 0, 0))
+	if _, twice := moved.Shape.(*Translated); twice || moved.Offset != core.NewVec3(3, 0, 0) {
+		t.Fatalf("Expected the offsets added, got %+v", moved)
+	}
+
+	ray := core.NewRay(core.NewVec3(3, 0, 5), core.NewVec3(0, 0, -1))
+	hit, isHit := moved.Hit(ray, 0.001, math.Inf(1))
+	if !isHit || math.Abs(hit.T-4) > 1e-9 || hit.Point.Subtract(core.NewVec3(3, 0, 1)).Length() > 1e-9 {
+		t.Errorf("Expected a hit at (3, 0, 1), got %v", hit)
+	}
+	if !moved.HitAny(ray, 0.001, math.Inf(1)) || moved.HitAny(core.NewRay(core.NewVec3(0, 0, 5), core.NewVec3(0, 0, -1)), 0.001, math.Inf(1)) {
+		t.Error("Expected HitAny to see the sphere where it moved to, not where it was")
+	}
+	if box := moved.BoundingBox(); box.Min != core.NewVec3(2, -1, -1) || box.Max != core.NewVec3(4, 1, 1) {
+		t.Errorf("Expected the bounds moved, got %+v", box)
+	}
+}
@@ -1,6 +1,8 @@
 package geometry
 
 import (
+	"math"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
@@ -10,6 +12,8 @@ type Triangle struct {
 	V0, V1, V2    core.Vec3         // The three vertices
 	UV0, UV1, UV2 core.Vec2         // Per-vertex texture coordinates (optional)
 	hasUVs        bool              // Whether per-vertex UVs are provided
+	N0, N1, N2    core.Vec3         // Per-vertex shading normals (optional)
+	hasNormals    bool              // Whether per-vertex normals are provided
 	Material      material.Material // Material of the triangle
 	normal        core.Vec3         // Cached normal vector
 	bbox          AABB              // Cached bounding box
@@ -88,6 +92,25 @@ func NewTriangleWithNormalAndUVs(v0, v1, v2 core.Vec3, uv0, uv1, uv2 core.Vec2,
 	return t
 }
 
+// SetVertexNormals enables smooth shading: hits interpolate the per-vertex normals across the face
+// The normals should point to the same side as the triangle's normal; they're normalized here.
+func (t *Triangle) SetVertexNormals(n0, n1, n2 core.Vec3) {
+	t.N0 = n0.Normalize()
+	t.N1 = n1.Normalize()
+	t.N2 = n2.Normalize()
+	t.hasNormals = true
+}
+
+// HasUVs reports whether the triangle has per-vertex texture coordinates
+func (t *Triangle) HasUVs() bool {
+	return t.hasUVs
+}
+
+// HasVertexNormals reports whether the triangle is smooth shaded with per-vertex normals
+func (t *Triangle) HasVertexNormals() bool {
+	return t.hasNormals
+}
+
 // computeNormal calculates and caches the triangle's normal vector
 func (t *Triangle) computeNormal() {
 	// Calculate two edge vectors
@@ -103,45 +126,44 @@ func (t *Triangle) computeBoundingBox() {
 	t.bbox = NewAABBFromPoints(t.V0, t.V1, t.V2)
 }
 
+// uvDerivatives returns how points on the triangle move with its per-vertex UVs, or zero when
+// the UVs are degenerate
+func (t *Triangle) uvDerivatives() (core.Vec3, core.Vec3) {
+	duv02, duv12 := t.UV0.Add(t.UV2.Multiply(-1)), t.UV1.Add(t.UV2.Multiply(-1))
+	dp02, dp12 := t.V0.Subtract(t.V2), t.V1.Subtract(t.V2)
+	det := duv02.X*duv12.Y - duv02.Y*duv12.X
+	if math.Abs(det) < 1e-12 {
+		return core.Vec3{}, core.Vec3{}
+	}
+	dpdu := dp02.Multiply(duv12.Y).Subtract(dp12.Multiply(duv02.Y)).Multiply(1 / det)
+	dpdv := dp12.Multiply(duv02.X).Subtract(dp02.Multiply(duv12.X)).Multiply(1 / det)
+	return dpdu, dpdv
+}
+
 // Hit tests if a ray intersects with the triangle using the Möller-Trumbore algorithm
 func (t *Triangle) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
-	const epsilon = 1e-8
-
 	// Calculate two edge vectors
 	edge1 := t.V1.Subtract(t.V0)
 	edge2 := t.V2.Subtract(t.V0)
+	return t.hitEdges(ray, tMin, tMax, edge1, edge2)
+}
 
-	// Calculate determinant
-	h := ray.Direction.Cross(edge2)
-	a := edge1.Dot(h)
-
-	// If determinant is near zero, ray lies in plane of triangle
-	if a > -epsilon && a < epsilon {
-		return nil, false
-	}
-
-	f := 1.0 / a
-	s := ray.Origin.Subtract(t.V0)
-	u := f * s.Dot(h)
-
-	// Check if intersection is outside triangle
-	if u < 0.0 || u > 1.0 {
-		return nil, false
-	}
-
-	q := s.Cross(edge1)
-	v := f * ray.Direction.Dot(q)
-
-	// Check if intersection is outside triangle
-	if v < 0.0 || u+v > 1.0 {
-		return nil, false
+// HitMany tests a packet of rays against the triangle, computing its edges once (see BatchShape)
+func (t *Triangle) HitMany(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	edge1 := t.V1.Subtract(t.V0)
+	edge2 := t.V2.Subtract(t.V0)
+	for _, i := range active {
+		if hit, isHit := t.hitEdges(rays[i], tMin, closest[i], edge1, edge2); isHit && material.IsOpaque(hit) {
+			closest[i] = hit.T
+			hits[i] = hit
+		}
 	}
+}
 
-	// Calculate t parameter
-	t_param := f * edge2.Dot(q)
-
-	// Check if intersection is within valid range
-	if t_param < tMin || t_param > tMax {
+// hitEdges is the Möller-Trumbore test with the triangle's edges V1-V0 and V2-V0
+func (t *Triangle) hitEdges(ray core.Ray, tMin, tMax float64, edge1, edge2 core.Vec3) (*material.SurfaceInteraction, bool) {
+	t_param, u, v, isHit := t.intersect(ray, tMin, tMax, edge1, edge2)
+	if !isHit {
 		return nil, false
 	}
 
@@ -149,11 +171,11 @@ func (t *Triangle) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInter
 	hitPoint := ray.At(t_param)
 
 	// Calculate UV coordinates
+	// u and v are barycentric coords, w = 1 - u - v
+	w := 1.0 - u - v
 	var uv core.Vec2
 	if t.hasUVs {
 		// Interpolate per-vertex UVs using barycentric coordinates
-		// u and v are barycentric coords, w = 1 - u - v
-		w := 1.0 - u - v
 		uv = t.UV0.Multiply(w).Add(t.UV1.Multiply(u)).Add(t.UV2.Multiply(v))
 	} else {
 		// Use barycentric coordinates directly as UV
@@ -166,14 +188,83 @@ func (t *Triangle) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInter
 		Point:    hitPoint,
 		Material: t.Material,
 		UV:       uv,
+		DpDu:     edge1,
+		DpDv:     edge2,
+	}
+	if t.hasUVs {
+		hitRecord.DpDu, hitRecord.DpDv = t.uvDerivatives()
 	}
 
 	// Set face normal
 	hitRecord.SetFaceNormal(ray, t.normal)
 
+	// Smooth shading: the side is decided by the true face normal, so only the normal's direction
+	// changes; it is flipped along with the face normal when the back is hit
+	if t.hasNormals {
+		shadingNormal := t.N0.Multiply(w).Add(t.N1.Multiply(u)).Add(t.N2.Multiply(v))
+		if shadingNormal.LengthSquared() > 0 { // Opposing vertex normals can cancel out
+			if !hitRecord.FrontFace {
+				shadingNormal = shadingNormal.Multiply(-1)
+			}
+			hitRecord.Normal = shadingNormal.Normalize()
+		}
+	}
+
 	return hitRecord, true
 }
 
+// AlphaMasked implements the MaskedShape interface
+func (t *Triangle) AlphaMasked() bool {
+	return hasAlphaMask(t.Material)
+}
+
+// HitAny reports whether a ray intersects the triangle, without building the hit record (see Occluder)
+func (t *Triangle) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, _, _, isHit := t.intersect(ray, tMin, tMax, t.V1.Subtract(t.V0), t.V2.Subtract(t.V0))
+	return isHit
+}
+
+// intersect returns the ray's t and barycentric coordinates (u, v) where it crosses the triangle,
+// with the triangle's edges V1-V0 and V2-V0
+func (t *Triangle) intersect(ray core.Ray, tMin, tMax float64, edge1, edge2 core.Vec3) (float64, float64, float64, bool) {
+	const epsilon = 1e-8
+
+	// Calculate determinant
+	h := ray.Direction.Cross(edge2)
+	a := edge1.Dot(h)
+
+	// If determinant is near zero, ray lies in plane of triangle
+	if a > -epsilon && a < epsilon {
+		return 0, 0, 0, false
+	}
+
+	f := 1.0 / a
+	s := ray.Origin.Subtract(t.V0)
+	u := f * s.Dot(h)
+
+	// Check if intersection is outside triangle
+	if u < 0.0 || u > 1.0 {
+		return 0, 0, 0, false
+	}
+
+	q := s.Cross(edge1)
+	v := f * ray.Direction.Dot(q)
+
+	// Check if intersection is outside triangle
+	if v < 0.0 || u+v > 1.0 {
+		return 0, 0, 0, false
+	}
+
+	// Calculate t parameter
+	t_param := f * edge2.Dot(q)
+
+	// Check if intersection is within valid range
+	if t_param < tMin || t_param > tMax {
+		return 0, 0, 0, false
+	}
+	return t_param, u, v, true
+}
+
 // BoundingBox returns the axis-aligned bounding box for this triangle
 func (t *Triangle) BoundingBox() AABB {
 	return t.bbox
@@ -183,3 +274,58 @@ func (t *Triangle) BoundingBox() AABB {
 func (t *Triangle) GetNormal() core.Vec3 {
 	return t.normal
 }
+
+// SampleSurface implements the SurfaceSampler interface - samples a point uniformly on the triangle
+func (t *Triangle) SampleSurface(sample core.Vec2) (core.Vec3, core.Vec3) {
+	// Uniform barycentric sampling (square-root warp keeps density uniform over the area)
+	su0 := math.Sqrt(sample.X)
+	b1 := 1.0 - su0
+	b2 := sample.Y * su0
+	b0 := 1.0 - b1 - b2
+
+	point := t.V0.Multiply(b0).Add(t.V1.Multiply(b1)).Add(t.V2.Multiply(b2))
+	return point, t.normal
+}
+
+// AreaPDF implements the SurfaceSampler interface - uniform density over the triangle area
+func (t *Triangle) AreaPDF(point core.Vec3) float64 {
+	const tolerance = 0.001
+
+	edge1 := t.V1.Subtract(t.V0)
+	edge2 := t.V2.Subtract(t.V0)
+	toPoint := point.Subtract(t.V0)
+
+	// Reject points off the triangle's plane
+	geometricNormal := edge1.Cross(edge2).Normalize()
+	if math.Abs(toPoint.Dot(geometricNormal)) > tolerance {
+		return 0.0
+	}
+
+	// Barycentric coordinates of the projected point
+	d00 := edge1.Dot(edge1)
+	d01 := edge1.Dot(edge2)
+	d11 := edge2.Dot(edge2)
+	d20 := toPoint.Dot(edge1)
+	d21 := toPoint.Dot(edge2)
+	denom := d00*d11 - d01*d01
+	if denom == 0 {
+		return 0.0
+	}
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+	if v < -tolerance || w < -tolerance || v+w > 1+tolerance {
+		return 0.0
+	}
+
+	return 1.0 / t.SurfaceArea()
+}
+
+// NormalAt implements the SurfaceSampler interface - triangles have a constant normal
+func (t *Triangle) NormalAt(point core.Vec3) core.Vec3 {
+	return t.normal
+}
+
+// SurfaceArea implements the SurfaceSampler interface - returns half the edge cross product length
+func (t *Triangle) SurfaceArea() float64 {
+	return 0.5 * t.V1.Subtract(t.V0).Cross(t.V2.Subtract(t.V0)).Length()
+}
@@ -2,6 +2,7 @@ package geometry
 
 import (
 	"math"
+	"sync"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
@@ -12,17 +13,34 @@ import (
 type TriangleMesh struct {
 	triangles []Shape           // Individual triangles as shapes
 	bvh       *BVH              // BVH for fast intersection
+	compact   *compactMesh      // Float32 storage replacing triangles and bvh (TriangleMeshOptions.Float32)
 	bbox      AABB              // Overall bounding box
 	material  material.Material // Default material (can be overridden per triangle)
+
+	samplingOnce sync.Once
+	sampling     meshSampling // Area distribution over the faces, built on first use as a light
 }
 
 // TriangleMeshOptions contains optional parameters for triangle mesh creation
 type TriangleMeshOptions struct {
-	Normals   []core.Vec3         // Optional custom normals (one per triangle)
-	Materials []material.Material // Optional per-triangle materials
-	Rotation  *core.Vec3          // Optional rotation to apply to vertices
-	Center    *core.Vec3          // Optional center point for rotation
-	VertexUVs []core.Vec2         // Optional per-vertex texture coordinates
+	Normals       []core.Vec3         // Optional custom normals (one per triangle)
+	Materials     []material.Material // Optional per-triangle materials
+	Rotation      *core.Vec3          // Optional rotation to apply to vertices
+	Center        *core.Vec3          // Optional center point for rotation
+	VertexUVs     []core.Vec2         // Optional per-vertex texture coordinates
+	VertexNormals []core.Vec3         // Optional per-vertex normals, interpolated across faces (smooth shading)
+	SmoothNormals bool                // Compute area-weighted vertex normals for smooth shading when VertexNormals is nil
+
+	// Float32 stores the vertices and the mesh's BVH bounds as float32 instead of keeping a Triangle per
+	// face, for meshes too large for memory otherwise. Vertices are rounded to float32 precision;
+	// intersection and shading still compute in float64.
+	Float32 bool
+
+	// Refinement applied to the mesh as given, before rotation. Subdivided or displaced meshes can't have
+	// per-triangle Normals, and VertexNormals are replaced by computed smooth normals.
+	SubdivisionLevels int                  // Loop subdivision steps, each splitting every triangle into four
+	Displacement      material.ColorSource // Optional displacement texture; its luminance moves vertices along the normal
+	DisplacementScale float64              // Distance moved for a displacement luminance of 1
 }
 
 // NewTriangleMesh creates a new triangle mesh from vertices and face indices
@@ -48,6 +66,20 @@ func NewTriangleMesh(vertices []core.Vec3, faces []int, material material.Materi
 		if options.VertexUVs != nil && len(options.VertexUVs) != len(vertices) {
 			panic("Number of vertex UVs must match number of vertices")
 		}
+		if options.VertexNormals != nil && len(options.VertexNormals) != len(vertices) {
+			panic("Number of vertex normals must match number of vertices")
+		}
+	}
+
+	// Subdivide and displace before anything else, so later steps see the refined mesh
+	if options != nil && (options.SubdivisionLevels > 0 || options.Displacement != nil) {
+		for _, index := range faces {
+			if index < 0 || index >= len(vertices) {
+				panic("Face index out of bounds")
+			}
+		}
+		vertices, faces, options = refineMesh(vertices, faces, options)
+		numTriangles = len(faces) / 3
 	}
 
 	// Apply rotation if specified
@@ -67,56 +99,65 @@ func NewTriangleMesh(vertices []core.Vec3, faces []int, material material.Materi
 		}
 	}
 
-	triangles := make([]Shape, numTriangles)
-
-	// Create individual triangles
-	for i := 0; i < numTriangles; i++ {
-		i0 := faces[i*3]
-		i1 := faces[i*3+1]
-		i2 := faces[i*3+2]
+	// Vertex normals rotate with the mesh (about the origin, since they're directions)
+	var vertexNormals []core.Vec3
+	if options != nil && options.VertexNormals != nil {
+		vertexNormals = options.VertexNormals
+		if options.Rotation != nil {
+			vertexNormals = make([]core.Vec3, len(options.VertexNormals))
+			for i, normal := range options.VertexNormals {
+				vertexNormals[i] = rotateVertex(normal, *options.Rotation)
+			}
+		}
+	} else if options != nil && options.SmoothNormals {
+		vertexNormals = computeVertexNormals(workingVertices, faces)
+	}
 
-		// Bounds check
-		if i0 >= len(workingVertices) || i1 >= len(workingVertices) || i2 >= len(workingVertices) ||
-			i0 < 0 || i1 < 0 || i2 < 0 {
+	// Bounds check
+	for _, index := range faces {
+		if index < 0 || index >= len(workingVertices) {
 			panic("Face index out of bounds")
 		}
+	}
+
+	var opts TriangleMeshOptions
+	if options != nil {
+		opts = *options
+	}
+
+	// Determine default material
+	defaultMaterial := material
+	if len(opts.Materials) > 0 {
+		defaultMaterial = opts.Materials[0]
+	}
+
+	if opts.Float32 {
+		compact := newCompactMesh(workingVertices, faces, vertexNormals, opts.VertexUVs, opts.Normals, opts.Materials, material)
+		return &TriangleMesh{compact: compact, bbox: compact.bounds(), material: defaultMaterial}
+	}
+
+	// Create individual triangles
+	triangles := make([]Shape, numTriangles)
+	for i := 0; i < numTriangles; i++ {
+		i0, i1, i2 := faces[i*3], faces[i*3+1], faces[i*3+2]
 
 		// Determine material for this triangle
 		triangleMaterial := material
-		if options != nil && options.Materials != nil {
-			triangleMaterial = options.Materials[i]
-		}
-
-		// Get vertex positions
-		v0 := workingVertices[i0]
-		v1 := workingVertices[i1]
-		v2 := workingVertices[i2]
-
-		// Create triangle with appropriate constructor based on available data
-		var triangle Shape
-		hasUVs := options != nil && options.VertexUVs != nil
-		hasNormals := options != nil && options.Normals != nil
-
-		if hasUVs && hasNormals {
-			// Both UVs and normals provided
-			uv0 := options.VertexUVs[i0]
-			uv1 := options.VertexUVs[i1]
-			uv2 := options.VertexUVs[i2]
-			triangle = NewTriangleWithNormalAndUVs(v0, v1, v2, uv0, uv1, uv2, options.Normals[i], triangleMaterial)
-		} else if hasUVs {
-			// Only UVs provided
-			uv0 := options.VertexUVs[i0]
-			uv1 := options.VertexUVs[i1]
-			uv2 := options.VertexUVs[i2]
-			triangle = NewTriangleWithUVs(v0, v1, v2, uv0, uv1, uv2, triangleMaterial)
-		} else if hasNormals {
-			// Only normals provided
-			triangle = NewTriangleWithNormal(v0, v1, v2, options.Normals[i], triangleMaterial)
-		} else {
-			// Neither UVs nor normals provided
-			triangle = NewTriangle(v0, v1, v2, triangleMaterial)
-		}
-		triangles[i] = triangle
+		if opts.Materials != nil {
+			triangleMaterial = opts.Materials[i]
+		}
+
+		face := meshFace{vertices: [3]core.Vec3{workingVertices[i0], workingVertices[i1], workingVertices[i2]}}
+		if opts.VertexUVs != nil {
+			face.uvs = &[3]core.Vec2{opts.VertexUVs[i0], opts.VertexUVs[i1], opts.VertexUVs[i2]}
+		}
+		if opts.Normals != nil {
+			face.normal = &opts.Normals[i]
+		}
+		if vertexNormals != nil {
+			face.vertexNormals = &[3]core.Vec3{vertexNormals[i0], vertexNormals[i1], vertexNormals[i2]}
+		}
+		triangles[i] = face.triangle(triangleMaterial)
 	}
 
 	// Build BVH for fast intersection
@@ -131,12 +172,6 @@ func NewTriangleMesh(vertices []core.Vec3, faces []int, material material.Materi
 		}
 	}
 
-	// Determine default material
-	defaultMaterial := material
-	if options != nil && options.Materials != nil && len(options.Materials) > 0 {
-		defaultMaterial = options.Materials[0]
-	}
-
 	return &TriangleMesh{
 		triangles: triangles,
 		bvh:       bvh,
@@ -145,12 +180,70 @@ func NewTriangleMesh(vertices []core.Vec3, faces []int, material material.Materi
 	}
 }
 
+// meshFace is the data of one face of a mesh, from which its triangle is created
+type meshFace struct {
+	vertices      [3]core.Vec3
+	uvs           *[3]core.Vec2 // Per-vertex UVs, nil for barycentric UVs
+	normal        *core.Vec3    // Custom face normal, nil to compute it from the vertices
+	vertexNormals *[3]core.Vec3 // Per-vertex normals for smooth shading, nil for flat shading
+}
+
+// triangle creates the face's triangle with the appropriate constructor for the available data
+func (f meshFace) triangle(material material.Material) *Triangle {
+	v0, v1, v2 := f.vertices[0], f.vertices[1], f.vertices[2]
+	var triangle *Triangle
+	switch {
+	case f.uvs != nil && f.normal != nil:
+		triangle = NewTriangleWithNormalAndUVs(v0, v1, v2, f.uvs[0], f.uvs[1], f.uvs[2], *f.normal, material)
+	case f.uvs != nil:
+		triangle = NewTriangleWithUVs(v0, v1, v2, f.uvs[0], f.uvs[1], f.uvs[2], material)
+	case f.normal != nil:
+		triangle = NewTriangleWithNormal(v0, v1, v2, *f.normal, material)
+	default:
+		triangle = NewTriangle(v0, v1, v2, material)
+	}
+	if f.vertexNormals != nil {
+		triangle.SetVertexNormals(f.vertexNormals[0], f.vertexNormals[1], f.vertexNormals[2])
+	}
+	return triangle
+}
+
 // Hit tests if a ray intersects with any triangle in the mesh
 func (tm *TriangleMesh) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
 	// Use the BVH for fast intersection
+	if tm.compact != nil {
+		return tm.compact.hit(ray, tMin, tMax)
+	}
 	return tm.bvh.Hit(ray, tMin, tMax)
 }
 
+// AlphaMasked implements the MaskedShape interface: HitAny tests the faces' own holes
+func (tm *TriangleMesh) AlphaMasked() bool {
+	return false
+}
+
+// HitAny reports whether a ray intersects any triangle in the mesh, stopping at the first found
+func (tm *TriangleMesh) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	if tm.compact != nil {
+		return tm.compact.hitAny(ray, tMin, tMax)
+	}
+	return tm.bvh.HitAny(ray, tMin, tMax)
+}
+
+// HitMany traces a packet of rays through the mesh's BVH (see BatchShape)
+func (tm *TriangleMesh) HitMany(rays []core.Ray, active []int, tMin float64, closest []float64, hits []*material.SurfaceInteraction) {
+	if tm.compact != nil {
+		for _, i := range active {
+			if hit, isHit := tm.compact.hit(rays[i], tMin, closest[i]); isHit {
+				closest[i] = hit.T
+				hits[i] = hit
+			}
+		}
+		return
+	}
+	tm.bvh.hitPacket(rays, active, tMin, closest, hits)
+}
+
 // BoundingBox returns the axis-aligned bounding box for the entire mesh
 func (tm *TriangleMesh) BoundingBox() AABB {
 	return tm.bbox
@@ -158,14 +251,50 @@ func (tm *TriangleMesh) BoundingBox() AABB {
 
 // GetTriangleCount returns the number of triangles in this mesh
 func (tm *TriangleMesh) GetTriangleCount() int {
+	if tm.compact != nil {
+		return tm.compact.faceCount()
+	}
 	return len(tm.triangles)
 }
 
+// GetBVH returns the mesh's own BVH over its triangles (for debugging), nil for float32 meshes
+func (tm *TriangleMesh) GetBVH() *BVH {
+	return tm.bvh
+}
+
 // GetTriangles returns the individual triangles (for debugging or special operations)
+// Float32 meshes don't keep their triangles, so they're created on each call.
 func (tm *TriangleMesh) GetTriangles() []Shape {
+	if tm.compact != nil {
+		triangles := make([]Shape, tm.compact.faceCount())
+		for i := range triangles {
+			triangles[i] = tm.compact.triangle(i)
+		}
+		return triangles
+	}
 	return tm.triangles
 }
 
+// computeVertexNormals averages the normals of the faces around each vertex, weighted by face area
+// The unnormalized cross product of a face's edges has length twice its area, so summing them weights by area.
+func computeVertexNormals(vertices []core.Vec3, faces []int) []core.Vec3 {
+	normals := make([]core.Vec3, len(vertices))
+	for i := 0; i+2 < len(faces); i += 3 {
+		i0, i1, i2 := faces[i], faces[i+1], faces[i+2]
+		if min(i0, i1, i2) < 0 || max(i0, i1, i2) >= len(vertices) {
+			continue // Reported when the triangles are built
+		}
+		faceNormal := vertices[i1].Subtract(vertices[i0]).Cross(vertices[i2].Subtract(vertices[i0]))
+		normals[i0] = normals[i0].Add(faceNormal)
+		normals[i1] = normals[i1].Add(faceNormal)
+		normals[i2] = normals[i2].Add(faceNormal)
+	}
+	for i, normal := range normals {
+		normals[i] = normal.Normalize()
+	}
+	return normals
+}
+
 // rotateVertex applies rotation around X, Y, Z axes (in that order)
 func rotateVertex(vertex, rotation core.Vec3) core.Vec3 {
 	// Rotation around X axis
@@ -1,6 +1,8 @@
 package geometry
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -422,3 +424,89 @@ func TestTriangleMesh_EdgeCases(t *testing.T) {
 		NewTriangleMesh(vertices, faces, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), options)
 	})
 }
+
+func TestTriangleMesh_SmoothNormals(t *testing.T) {
+	// A tent: two faces meeting at a ridge along the Z axis, tilted 45° to either side
+	vertices := []core.Vec3{
+		core.NewVec3(0, 1, 0), core.NewVec3(0, 1, 1), // ridge
+		core.NewVec3(-1, 0, 0), core.NewVec3(-1, 0, 1), // left foot
+		core.NewVec3(1, 0, 0), core.NewVec3(1, 0, 1), // right foot
+	}
+	faces := []int{0, 2, 1, 1, 2, 3, 0, 1, 4, 1, 5, 4}
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	ray := core.NewRay(core.NewVec3(-0.01, 5, 0.5), core.NewVec3(0, -1, 0)) // Just left of the ridge
+
+	faceted := NewTriangleMesh(vertices, faces, mat, nil)
+	hit, _ := faceted.Hit(ray, 0.001, 10)
+	if expected := core.NewVec3(-1, 1, 0).Normalize(); hit.Normal.Subtract(expected).Length() > 1e-6 {
+		t.Errorf("Faceted: expected face normal %v, got %v", expected, hit.Normal)
+	}
+
+	// Area-weighted vertex normals make the ridge point straight up
+	smooth := NewTriangleMesh(vertices, faces, mat, &TriangleMeshOptions{SmoothNormals: true})
+	hit, _ = smooth.Hit(ray, 0.001, 10)
+	if hit.Normal.Y < 0.999 {
+		t.Errorf("Smooth: expected normal near (0,1,0) at the ridge, got %v", hit.Normal)
+	}
+
+	// Explicit vertex normals are used as given, and rotate with the mesh
+	up := core.NewVec3(0, 1, 0)
+	rotation := core.NewVec3(0, 0, math.Pi/2) // Rotates +Y to -X
+	explicit := NewTriangleMesh(vertices, faces, mat, &TriangleMeshOptions{
+		VertexNormals: []core.Vec3{up, up, up, up, up, up},
+		Rotation:      &rotation,
+	})
+	hit, isHit := explicit.Hit(core.NewRay(core.NewVec3(-5, 0.01, 0.5), core.NewVec3(1, 0, 0)), 0.001, 10)
+	if !isHit {
+		t.Fatal("Expected hit on rotated mesh")
+	}
+	if expected := core.NewVec3(-1, 0, 0); hit.Normal.Subtract(expected).Length() > 1e-6 {
+		t.Errorf("Rotated: expected normal %v, got %v", expected, hit.Normal)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic for vertex normal count mismatch")
+		}
+	}()
+	NewTriangleMesh(vertices, faces, mat, &TriangleMeshOptions{VertexNormals: []core.Vec3{up}})
+}
+
+func TestTriangleMesh_SurfaceSampling(t *testing.T) {
+	// Two faces in the y = 0 plane, the second three times the area of the first
+	vertices := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1),
+		core.NewVec3(2, 0, 0), core.NewVec3(5, 0, 0), core.NewVec3(2, 0, 2),
+	}
+	faces := []int{0, 2, 1, 3, 5, 4}
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+
+	for _, compact := range []bool{false, true} {
+		mesh := NewTriangleMesh(vertices, faces, mat, &TriangleMeshOptions{Float32: compact})
+		if area := mesh.SurfaceArea(); math.Abs(area-3.5) > 1e-6 {
+			t.Fatalf("float32=%v: expected area 3.5, got %f", compact, area)
+		}
+
+		sampler := core.NewRandomSampler(rand.New(rand.NewSource(1)))
+		const numSamples = 20000
+		onSecond := 0
+		for i := 0; i < numSamples; i++ {
+			point, normal := mesh.SampleSurface(sampler.Get2D())
+			if point.X >= 2 {
+				onSecond++
+			}
+			if !normal.Equals(core.NewVec3(0, 1, 0)) || !mesh.NormalAt(point).Equals(normal) {
+				t.Fatalf("float32=%v: expected normal (0, 1, 0) at %v, got %v and %v", compact, point, normal, mesh.NormalAt(point))
+			}
+			if pdf := mesh.AreaPDF(point); math.Abs(pdf-1/3.5) > 1e-6 {
+				t.Fatalf("float32=%v: expected area PDF %f at %v, got %f", compact, 1/3.5, point, pdf)
+			}
+		}
+		if fraction := float64(onSecond) / numSamples; math.Abs(fraction-3/3.5) > 0.01 {
+			t.Errorf("float32=%v: expected %f of the samples on the larger face, got %f", compact, 3/3.5, fraction)
+		}
+		if pdf := mesh.AreaPDF(core.NewVec3(1.5, 0, 0.5)); pdf != 0 {
+			t.Errorf("float32=%v: expected area PDF 0 between the faces, got %f", compact, pdf)
+		}
+	}
+}
@@ -126,3 +126,55 @@ func TestTriangle_BoundingBox(t *testing.T) {
 		t.Errorf("Expected max %v, got %v", expectedMax, bbox.Max)
 	}
 }
+
+func TestTriangle_VertexNormals(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	triangle := NewTriangle(core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), mat)
+	triangle.SetVertexNormals(core.NewVec3(0, 0, 1), core.NewVec3(1, 0, 1), core.NewVec3(0, 1, 1))
+
+	tests := []struct {
+		name           string
+		origin         core.Vec3
+		dir            core.Vec3
+		expectedNormal core.Vec3
+		frontFace      bool
+	}{
+		{"at vertex 0", core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1), core.NewVec3(0, 0, 1), true},
+		{"at vertex 1", core.NewVec3(1, 0, 1), core.NewVec3(0, 0, -1), core.NewVec3(1, 0, 1).Normalize(), true},
+		{"edge midpoint", core.NewVec3(0.5, 0.5, 1), core.NewVec3(0, 0, -1), core.NewVec3(0.5, 0.5, 1).Normalize(), true},
+		{"back face", core.NewVec3(1, 0, -1), core.NewVec3(0, 0, 1), core.NewVec3(-1, 0, -1).Normalize(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, isHit := triangle.Hit(core.NewRay(tt.origin, tt.dir), 0.001, 10)
+			if !isHit {
+				t.Fatal("Expected hit")
+			}
+			if hit.Normal.Subtract(tt.expectedNormal).Length() > 1e-6 {
+				t.Errorf("Expected normal %v, got %v", tt.expectedNormal, hit.Normal)
+			}
+			if hit.FrontFace != tt.frontFace {
+				t.Errorf("Expected FrontFace=%v, got %v", tt.frontFace, hit.FrontFace)
+			}
+		})
+	}
+
+	// The geometric normal used for sampling is unchanged
+	if triangle.GetNormal() != core.NewVec3(0, 0, 1) {
+		t.Errorf("Expected geometric normal (0,0,1), got %v", triangle.GetNormal())
+	}
+}
+
+func TestTriangle_UVDerivatives(t *testing.T) {
+	// UVs scaled by 2 in u and swapped with v: the point moves half as far per unit of u, along v0-v2
+	tri := NewTriangleWithUVs(core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0),
+		core.NewVec2(0, 0), core.NewVec2(0, 1), core.NewVec2(2, 0), nil)
+	hit, ok := tri.Hit(core.NewRay(core.NewVec3(0.2, 0.2, 1), core.NewVec3(0, 0, -1)), 0.001, math.Inf(1))
+	if !ok {
+		t.Fatal("Expected a hit")
+	}
+	if !hit.DpDu.Equals(core.NewVec3(0, 0.5, 0)) || !hit.DpDv.Equals(core.NewVec3(1, 0, 0)) {
+		t.Errorf("Expected DpDu (0, 0.5, 0) and DpDv (1, 0, 0), got %v and %v", hit.DpDu, hit.DpDv)
+	}
+}
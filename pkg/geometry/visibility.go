@@ -0,0 +1,70 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// maxHiddenLayers bounds the hidden hits that HitVisible passes through
+const maxHiddenLayers = 64
+
+// VisibilityShape wraps a shape to hide it from some kinds of rays, e.g. a light fixture the camera
+// shouldn't see or a shadow-only blocker. Its hits carry the kinds in HiddenFrom for integrators
+// to pass through with HitVisible; shadow rays ignore it in HitAny.
+type VisibilityShape struct {
+	Shape
+	HiddenFrom material.RayKind
+}
+
+// NewVisibilityShape hides a shape from the given kinds of rays
+func NewVisibilityShape(shape Shape, hiddenFrom material.RayKind) *VisibilityShape {
+	return &VisibilityShape{Shape: shape, HiddenFrom: hiddenFrom}
+}
+
+// Hit returns the wrapped shape's hit, marked with the kinds of rays it's hidden from
+func (v *VisibilityShape) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	hit, isHit := v.Shape.Hit(ray, tMin, tMax)
+	if isHit {
+		hit.HiddenFrom |= v.HiddenFrom
+	}
+	return hit, isHit
+}
+
+// AlphaMasked implements the MaskedShape interface with the wrapped shape's answer
+func (v *VisibilityShape) AlphaMasked() bool {
+	return alphaMasked(v.Shape)
+}
+
+// HitAny reports whether the ray intersects the shape, never for shapes hidden from shadow rays
+func (v *VisibilityShape) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	if v.HiddenFrom&material.ShadowRays != 0 {
+		return false
+	}
+	return hitShapeAny(v.Shape, ray, tMin, tMax)
+}
+
+// Preprocess passes scene preprocessing on to the wrapped shape
+func (v *VisibilityShape) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	if preprocessor, ok := v.Shape.(Preprocessor); ok {
+		return preprocessor.Preprocess(worldCenter, worldRadius)
+	}
+	return nil
+}
+
+// HitVisible returns the closest intersection that isn't hidden from rays of the given kind,
+// continuing past hidden ones. Hits of rays with differentials get their pixel footprints.
+func HitVisible(intersector Intersector, ray core.Ray, tMin, tMax float64, kind material.RayKind) (*material.SurfaceInteraction, bool) {
+	for layer := 0; layer < maxHiddenLayers; layer++ {
+		hit, isHit := intersector.Hit(ray, tMin, tMax)
+		if !isHit || hit.HiddenFrom&kind == 0 {
+			if isHit && ray.Differential != nil {
+				hit.SetDifferentials(ray)
+			}
+			return hit, isHit
+		}
+		tMin = math.Nextafter(hit.T, math.Inf(1))
+	}
+	return nil, false
+}
@@ -0,0 +1,64 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestVisibilityShape_HiddenFromRayKinds(t *testing.T) {
+	gray := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	wall := NewQuad(core.NewVec3(-5, -5, -2), core.NewVec3(10, 0, 0), core.NewVec3(0, 10, 0), gray)
+	ray := core.NewRay(core.NewVec3(0, 0, 5), core.NewVec3(0, 0, -1))
+
+	tests := []struct {
+		name       string
+		hiddenFrom material.RayKind
+	}{
+		{"visible", 0},
+		{"camera-invisible", material.CameraRays},
+		{"indirect only", material.CameraRays | material.ShadowRays},
+		{"shadow-only", material.CameraRays | material.IndirectRays},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocker := NewVisibilityShape(NewSphere(core.Vec3{}, 1, gray), tt.hiddenFrom)
+			bvh := NewBVH([]Shape{blocker, wall})
+
+			// The closest hit is always found, marked with the kinds of rays it's hidden from
+			hit, isHit := bvh.Hit(ray, 0.001, math.Inf(1))
+			if !isHit || math.Abs(hit.T-4) > 1e-9 || hit.HiddenFrom != tt.hiddenFrom {
+				t.Fatalf("Expected the sphere at t=4 hidden from %b, got %v", tt.hiddenFrom, hit)
+			}
+
+			for _, kind := range []material.RayKind{material.CameraRays, material.IndirectRays} {
+				hit, isHit := HitVisible(bvh, ray, 0.001, math.Inf(1), kind)
+				wantT := 4.0
+				if tt.hiddenFrom&kind != 0 {
+					wantT = 7 // Passes through both sides of the sphere to the wall
+				}
+				if !isHit || math.Abs(hit.T-wantT) > 1e-9 {
+					t.Errorf("Ray kind %b: expected a hit at t=%v, got %v", kind, wantT, hit)
+				}
+			}
+
+			wantBlocked := tt.hiddenFrom&material.ShadowRays == 0
+			if blocked := bvh.HitAny(ray, 0.001, 6); blocked != wantBlocked {
+				t.Errorf("Expected HitAny %v, got %v", wantBlocked, blocked)
+			}
+		})
+	}
+}
+
+func TestHitVisible_Miss(t *testing.T) {
+	gray := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	bvh := NewBVH([]Shape{NewVisibilityShape(NewSphere(core.Vec3{}, 1, gray), material.CameraRays)})
+	ray := core.NewRay(core.NewVec3(0, 0, 5), core.NewVec3(0, 0, -1))
+
+	if hit, isHit := HitVisible(bvh, ray, 0.001, math.Inf(1), material.CameraRays); isHit {
+		t.Errorf("Expected the camera ray to see nothing, got %v", hit)
+	}
+}
@@ -0,0 +1,206 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// DensityGrid is a density field sampled on a regular grid spanning a box, such as a smoke or
+// cloud simulation, interpolated trilinearly between the samples. The first and last samples
+// along each axis lie on the box's faces; the density is 0 outside the box.
+type DensityGrid struct {
+	Bounds AABB
+
+	nx, ny, nz int       // Number of samples along each axis
+	values     []float64 // Density at each sample, x varying fastest, then y, then z
+	maxDensity float64
+}
+
+// NewDensityGrid creates a density grid from nx*ny*nz samples, x varying fastest
+func NewDensityGrid(bounds AABB, nx, ny, nz int, values []float64) (*DensityGrid, error) {
+	if nx < 2 || ny < 2 || nz < 2 {
+		return nil, fmt.Errorf("grid needs at least 2 samples along each axis, got %dx%dx%d", nx, ny, nz)
+	}
+	if len(values) != nx*ny*nz {
+		return nil, fmt.Errorf("grid of %dx%dx%d needs %d values, got %d", nx, ny, nz, nx*ny*nz, len(values))
+	}
+	size := bounds.Max.Subtract(bounds.Min)
+	if size.X <= 0 || size.Y <= 0 || size.Z <= 0 {
+		return nil, fmt.Errorf("grid bounds %v to %v are empty", bounds.Min, bounds.Max)
+	}
+	grid := &DensityGrid{Bounds: bounds, nx: nx, ny: ny, nz: nz, values: values}
+	for _, value := range values {
+		if value < 0 || math.IsNaN(value) {
+			return nil, fmt.Errorf("densities can't be negative, got %v", value)
+		}
+		grid.maxDensity = math.Max(grid.maxDensity, value)
+	}
+	return grid, nil
+}
+
+// MaxDensity returns the largest density anywhere in the grid
+func (g *DensityGrid) MaxDensity() float64 {
+	return g.maxDensity
+}
+
+// Density returns the trilinearly interpolated density at a point
+func (g *DensityGrid) Density(point core.Vec3) float64 {
+	b := g.Bounds
+	if point.X < b.Min.X || point.Y < b.Min.Y || point.Z < b.Min.Z ||
+		point.X > b.Max.X || point.Y > b.Max.Y || point.Z > b.Max.Z {
+		return 0
+	}
+	cell := func(x, lo, hi float64, n int) (int, float64) {
+		x = (x - lo) / (hi - lo) * float64(n-1)
+		i := min(int(x), n-2)
+		return i, x - float64(i)
+	}
+	i, fx := cell(point.X, b.Min.X, b.Max.X, g.nx)
+	j, fy := cell(point.Y, b.Min.Y, b.Max.Y, g.ny)
+	k, fz := cell(point.Z, b.Min.Z, b.Max.Z, g.nz)
+
+	at := func(di, dj, dk int) float64 {
+		return g.values[((k+dk)*g.ny+j+dj)*g.nx+i+di]
+	}
+	lerp := func(a, b, t float64) float64 {
+		return a + (b-a)*t
+	}
+	c00 := lerp(at(0, 0, 0), at(1, 0, 0), fx)
+	c10 := lerp(at(0, 1, 0), at(1, 1, 0), fx)
+	c01 := lerp(at(0, 0, 1), at(1, 0, 1), fx)
+	c11 := lerp(at(0, 1, 1), at(1, 1, 1), fx)
+	return lerp(lerp(c00, c10, fy), lerp(c01, c11, fy), fz)
+}
+
+// emissionTableSize is the number of temperatures a glowing volume tabulates its radiance at
+const emissionTableSize = 256
+
+// Volume is a heterogeneous participating medium, such as smoke or a cloud, filling the box of
+// a density grid. A ray through it collides with the medium at a rate of Scale times the density
+// per unit length (the extinction coefficient σt), and Hit returns the first collision rather
+// than a surface, with the medium's phase function as its material (see
+// material.HenyeyGreenstein). A ray that gets through without colliding misses the volume, so
+// shadow rays see its transmittance as the chance of passing, and the integrators need no
+// changes to render it.
+//
+// Collisions are found by delta tracking against the densest point of the grid, with random
+// numbers seeded by the ray, since shapes aren't given a sampler: the same ray always collides
+// at the same point, as it would with a surface.
+//
+// A volume given a temperature grid with SetEmission glows, as fire does: each collision's
+// material emits the radiance at the temperature there (see material.MediumMaterial).
+type Volume struct {
+	Grid     *DensityGrid
+	Scale    float64           // Extinction coefficient at density 1
+	Material material.Material // Phase function of the medium's particles
+
+	majorant    float64      // Upper bound of the extinction coefficient, for delta tracking
+	temperature *DensityGrid // Temperature in kelvin, nil unless the medium glows
+	radiance    []core.Vec3  // Emitted radiance at evenly spaced temperatures up to the hottest
+}
+
+// NewVolume creates a volume of a density grid scaled to an extinction coefficient
+func NewVolume(grid *DensityGrid, scale float64, phase material.Material) *Volume {
+	return &Volume{Grid: grid, Scale: scale, Material: phase, majorant: scale * grid.MaxDensity()}
+}
+
+// SetEmission makes the medium glow: a temperature grid, in kelvin, and the radiance the medium
+// emits at a temperature, such as a blackbody's. The radiance is tabulated once, so it may be
+// expensive to compute. The volume's material must be a material.MediumMaterial to emit.
+func (v *Volume) SetEmission(temperature *DensityGrid, radiance func(kelvin float64) core.Vec3) {
+	v.temperature = temperature
+	v.radiance = make([]core.Vec3, emissionTableSize)
+	for i := range v.radiance {
+		v.radiance[i] = radiance(temperature.MaxDensity() * float64(i) / (emissionTableSize - 1))
+	}
+}
+
+// BoundingBox implements the Shape interface
+func (v *Volume) BoundingBox() AABB {
+	return v.Grid.Bounds
+}
+
+// Hit implements the Shape interface, returning the ray's first collision with the medium
+func (v *Volume) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	t, collided := v.track(ray, tMin, tMax)
+	if !collided {
+		return nil, false
+	}
+	point := ray.At(t)
+	mat := v.Material
+	if medium, ok := mat.(material.MediumMaterial); ok && v.temperature != nil {
+		if radiance := v.emission(point); !radiance.IsZero() {
+			mat = medium.AtEmission(radiance)
+		}
+	}
+	// The phase function ignores the normal, but light sampling doesn't: a fixed one keeps the
+	// densities BDPT compares the same however a path reaches the collision
+	return &material.SurfaceInteraction{
+		Point:     point,
+		Normal:    core.NewVec3(0, 1, 0),
+		T:         t,
+		FrontFace: true,
+		Material:  mat,
+	}, true
+}
+
+// emission returns the radiance the medium emits at a point, interpolated from the table
+func (v *Volume) emission(point core.Vec3) core.Vec3 {
+	hottest := v.temperature.MaxDensity()
+	if hottest <= 0 {
+		return core.Vec3{}
+	}
+	x := v.temperature.Density(point) / hottest * (emissionTableSize - 1)
+	i := min(int(x), emissionTableSize-2)
+	f := x - float64(i)
+	return v.radiance[i].Multiply(1 - f).Add(v.radiance[i+1].Multiply(f))
+}
+
+// AlphaMasked implements the MaskedShape interface: a medium has no material to cut holes
+func (v *Volume) AlphaMasked() bool {
+	return false
+}
+
+// HitAny implements the Occluder interface
+func (v *Volume) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, collided := v.track(ray, tMin, tMax)
+	return collided
+}
+
+// track returns the distance along the ray to its first real collision in the medium, if it
+// collides before tMax. Delta tracking samples tentative collisions at the majorant's rate and
+// accepts each with probability σt/majorant, which makes real collisions occur at the rate σt.
+func (v *Volume) track(ray core.Ray, tMin, tMax float64) (float64, bool) {
+	if v.majorant <= 0 {
+		return 0, false
+	}
+	t0, t1, ok := v.Grid.Bounds.HitInterval(ray, tMin, tMax)
+	if !ok {
+		return 0, false
+	}
+
+	// Distances in t are scaled by the direction's length
+	rate := v.majorant * ray.Direction.Length()
+	sampler := core.NewSeededSampler(rayHash(ray))
+	for t := t0; ; {
+		t -= math.Log(1-sampler.Get1D()) / rate
+		if t >= t1 {
+			return 0, false
+		}
+		if sampler.Get1D()*v.majorant < v.Scale*v.Grid.Density(ray.At(t)) {
+			return t, true
+		}
+	}
+}
+
+// rayHash returns a seed determined by a ray's origin and direction
+func rayHash(ray core.Ray) uint64 {
+	h := uint64(0)
+	for _, value := range [...]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z, ray.Direction.X, ray.Direction.Y, ray.Direction.Z} {
+		h = core.MixBits(h ^ math.Float64bits(value))
+	}
+	return h
+}
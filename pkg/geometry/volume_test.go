@@ -0,0 +1,196 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// uniformGrid returns a 2x2x2 grid of one density over the box
+func uniformGrid(t *testing.T, bounds AABB, density float64) *DensityGrid {
+	t.Helper()
+	values := make([]float64, 8)
+	for i := range values {
+		values[i] = density
+	}
+	grid, err := NewDensityGrid(bounds, 2, 2, 2, values)
+	if err != nil {
+		t.Fatalf("NewDensityGrid failed: %v", err)
+	}
+	return grid
+}
+
+func TestNewDensityGrid_Validation(t *testing.T) {
+	bounds := NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 1))
+	if _, err := NewDensityGrid(bounds, 1, 2, 2, make([]float64, 4)); err == nil {
+		t.Error("Expected an error for a single sample along x")
+	}
+	if _, err := NewDensityGrid(bounds, 2, 2, 2, make([]float64, 7)); err == nil {
+		t.Error("Expected an error for too few values")
+	}
+	if _, err := NewDensityGrid(NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 1)), 2, 2, 2, make([]float64, 8)); err == nil {
+		t.Error("Expected an error for flat bounds")
+	}
+	if _, err := NewDensityGrid(bounds, 2, 2, 2, []float64{0, 0, 0, -1, 0, 0, 0, 0}); err == nil {
+		t.Error("Expected an error for a negative density")
+	}
+}
+
+func TestDensityGrid_Density(t *testing.T) {
+	// 3x2x2 samples over [0, 2] x [0, 1] x [0, 1], the density rising along x and z
+	values := []float64{0, 1, 2, 0, 1, 2, 4, 5, 6, 4, 5, 6}
+	grid, err := NewDensityGrid(NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(2, 1, 1)), 3, 2, 2, values)
+	if err != nil {
+		t.Fatalf("NewDensityGrid failed: %v", err)
+	}
+	if grid.MaxDensity() != 6 {
+		t.Errorf("Expected a max density of 6, got %f", grid.MaxDensity())
+	}
+	tests := []struct {
+		point    core.Vec3
+		expected float64
+	}{
+		{core.NewVec3(0, 0, 0), 0},
+		{core.NewVec3(2, 1, 1), 6},
+		{core.NewVec3(1.5, 0.3, 0), 1.5},
+		{core.NewVec3(0.5, 0.7, 0.25), 1.5},
+		{core.NewVec3(2.01, 0.5, 0.5), 0}, // Outside
+	}
+	for _, tt := range tests {
+		if got := grid.Density(tt.point); math.Abs(got-tt.expected) > 1e-12 {
+			t.Errorf("Density at %v: expected %f, got %f", tt.point, tt.expected, got)
+		}
+	}
+}
+
+func TestVolume_Transmittance(t *testing.T) {
+	// In a uniform medium, the share of rays crossing it without colliding is exp(-σt d), and the
+	// collisions are exponentially distributed along the ray
+	const (
+		sigma = 0.8
+		rays  = 20000
+	)
+	bounds := NewAABB(core.NewVec3(-1, -1, -1), core.NewVec3(1, 1, 1))
+	volume := NewVolume(uniformGrid(t, bounds, 2), sigma/2, material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0))
+	sampler := core.NewSeededSampler(5)
+
+	passed, sumDepth, collisions := 0, 0.0, 0
+	for i := 0; i < rays; i++ {
+		// Rays entering the front face at random points, along z with a direction of length 2
+		origin := core.NewVec3(2*sampler.Get1D()-1, 2*sampler.Get1D()-1, -3)
+		hit, ok := volume.Hit(core.NewRay(origin, core.NewVec3(0, 0, 2)), 0.001, math.Inf(1))
+		if !ok {
+			passed++
+			continue
+		}
+		if hit.Point.Z < -1 || hit.Point.Z > 1 || math.Abs(hit.T*2-(hit.Point.Z+3)) > 1e-9 {
+			t.Fatalf("Collision at %v, t = %f, isn't inside the volume along the ray", hit.Point, hit.T)
+		}
+		sumDepth += hit.Point.Z + 1
+		collisions++
+	}
+
+	transmittance := float64(passed) / rays
+	expected := math.Exp(-sigma * 2)
+	if stdErr := math.Sqrt(expected * (1 - expected) / rays); math.Abs(transmittance-expected) > 4*stdErr {
+		t.Errorf("Expected a transmittance of %f, got %f", expected, transmittance)
+	}
+
+	// Mean depth of an exponential distribution truncated to [0, d]
+	d := 2.0
+	expectedDepth := 1/sigma - d*math.Exp(-sigma*d)/(1-math.Exp(-sigma*d))
+	if depth := sumDepth / float64(collisions); math.Abs(depth-expectedDepth) > 0.02 {
+		t.Errorf("Expected collisions at a mean depth of %f, got %f", expectedDepth, depth)
+	}
+}
+
+func TestVolume_Heterogeneous(t *testing.T) {
+	// Density rising linearly from 0 to 2 along x: a ray along x has optical depth σ (0+2)/2 d,
+	// whatever the majorant
+	const rays = 20000
+	values := []float64{0, 2, 0, 2, 0, 2, 0, 2}
+	grid, err := NewDensityGrid(NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 1)), 2, 2, 2, values)
+	if err != nil {
+		t.Fatalf("NewDensityGrid failed: %v", err)
+	}
+	volume := NewVolume(grid, 1.5, material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0))
+	sampler := core.NewSeededSampler(11)
+	passed := 0
+	for i := 0; i < rays; i++ {
+		origin := core.NewVec3(-1, sampler.Get1D(), sampler.Get1D())
+		if !volume.HitAny(core.NewRay(origin, core.NewVec3(1, 0, 0)), 0.001, math.Inf(1)) {
+			passed++
+		}
+	}
+	expected := math.Exp(-1.5)
+	transmittance := float64(passed) / rays
+	if stdErr := math.Sqrt(expected * (1 - expected) / rays); math.Abs(transmittance-expected) > 4*stdErr {
+		t.Errorf("Expected a transmittance of %f, got %f", expected, transmittance)
+	}
+}
+
+func TestVolume_Deterministic(t *testing.T) {
+	bounds := NewAABB(core.NewVec3(-1, -1, -1), core.NewVec3(1, 1, 1))
+	volume := NewVolume(uniformGrid(t, bounds, 1), 1, material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0))
+	ray := core.NewRay(core.NewVec3(0.1, 0.2, -5), core.NewVec3(0, 0, 1))
+
+	first, ok := volume.Hit(ray, 0.001, math.Inf(1))
+	if !ok {
+		ray = core.NewRay(core.NewVec3(0.1, 0.25, -5), core.NewVec3(0, 0, 1))
+		if first, ok = volume.Hit(ray, 0.001, math.Inf(1)); !ok {
+			t.Fatal("Expected one of two rays to collide")
+		}
+	}
+	// Again, and with a closer surface found past the collision, as a BVH would ask
+	second, ok := volume.Hit(ray, 0.001, first.T+0.01)
+	if !ok || second.T != first.T {
+		t.Errorf("Expected the same ray to collide at t = %f again, got %v", first.T, second)
+	}
+	if !volume.HitAny(ray, 0.001, math.Inf(1)) {
+		t.Error("Expected HitAny to agree with Hit")
+	}
+	if _, ok := volume.Hit(ray, 0.001, first.T-0.01); ok {
+		t.Error("Expected no collision before a closer surface")
+	}
+}
+
+func TestVolume_Emission(t *testing.T) {
+	// Dense enough that rays collide right away; cold up to x = 0.5, then hotter up to 1000 K
+	bounds := NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 1))
+	temperature, err := NewDensityGrid(bounds, 3, 2, 2, []float64{0, 0, 1000, 0, 0, 1000, 0, 0, 1000, 0, 0, 1000})
+	if err != nil {
+		t.Fatalf("NewDensityGrid failed: %v", err)
+	}
+	volume := NewVolume(uniformGrid(t, bounds, 1), 1000, material.NewHenyeyGreenstein(core.NewVec3(0.2, 0.6, 1), 0))
+	volume.SetEmission(temperature, func(kelvin float64) core.Vec3 {
+		return core.NewVec3(kelvin, 2*kelvin, kelvin)
+	})
+
+	for _, x := range []float64{0.6, 0.75, 0.9} {
+		hit, ok := volume.Hit(core.NewRay(core.NewVec3(x, 0.5, -1), core.NewVec3(0, 0, 1)), 0.001, math.Inf(1))
+		if !ok {
+			t.Fatalf("Expected a collision at x = %v", x)
+		}
+		emitter, ok := hit.Material.(material.MediumEmitter)
+		if !ok {
+			t.Fatalf("Expected a glowing collision at x = %v, got %T", x, hit.Material)
+		}
+		// Collisions emit the share of the radiance they absorb
+		kelvin := 2000 * (x - 0.5)
+		expected := core.NewVec3(0.8*kelvin, 0.8*kelvin, 0)
+		if emission := emitter.Emit(core.Ray{}, hit); emission.Subtract(expected).Length() > 1e-6*kelvin {
+			t.Errorf("Expected an emission of %v at %v, got %v", expected, hit.Point, emission)
+		}
+	}
+
+	// Where it is cold, collisions only scatter
+	hit, ok := volume.Hit(core.NewRay(core.NewVec3(0.25, 0.5, -1), core.NewVec3(0, 0, 1)), 0.001, math.Inf(1))
+	if !ok {
+		t.Fatal("Expected a collision in the cold half")
+	}
+	if _, glows := hit.Material.(material.MediumEmitter); glows {
+		t.Errorf("Expected no emission at 0 K, got a %T", hit.Material)
+	}
+}
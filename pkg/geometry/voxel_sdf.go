@@ -0,0 +1,83 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// VoxelSDF is a signed distance field sampled on a regular grid, such as one baked from a mesh
+// or a simulation, interpolated trilinearly between the samples
+// Wrap it with NewSDFSurface to render it. The interpolated distances are exact only at the
+// samples, so features finer than a cell are lost.
+type VoxelSDF struct {
+	Origin   core.Vec3 // Position of the first sample
+	CellSize float64   // Spacing between samples along every axis
+
+	nx, ny, nz int       // Number of samples along each axis
+	values     []float64 // Distance at each sample, x varying fastest, then y, then z
+}
+
+// NewVoxelSDF creates a voxel signed distance field from nx*ny*nz samples, x varying fastest
+func NewVoxelSDF(origin core.Vec3, cellSize float64, nx, ny, nz int, values []float64) (*VoxelSDF, error) {
+	if cellSize <= 0 {
+		return nil, fmt.Errorf("cell size must be positive, got %f", cellSize)
+	}
+	if nx < 2 || ny < 2 || nz < 2 {
+		return nil, fmt.Errorf("grid needs at least 2 samples along each axis, got %dx%dx%d", nx, ny, nz)
+	}
+	if len(values) != nx*ny*nz {
+		return nil, fmt.Errorf("grid of %dx%dx%d needs %d values, got %d", nx, ny, nz, nx*ny*nz, len(values))
+	}
+	return &VoxelSDF{Origin: origin, CellSize: cellSize, nx: nx, ny: ny, nz: nz, values: values}, nil
+}
+
+// BoundingBox returns the box spanned by the samples
+func (v *VoxelSDF) BoundingBox() AABB {
+	extent := core.NewVec3(float64(v.nx-1), float64(v.ny-1), float64(v.nz-1)).Multiply(v.CellSize)
+	return NewAABB(v.Origin, v.Origin.Add(extent))
+}
+
+// SignedDistance implements the SignedDistanceField interface
+// Points outside the grid get the distance at the nearest point on its boundary plus the
+// distance to that point.
+func (v *VoxelSDF) SignedDistance(point core.Vec3) float64 {
+	box := v.BoundingBox()
+	clamped := core.NewVec3(
+		math.Min(math.Max(point.X, box.Min.X), box.Max.X),
+		math.Min(math.Max(point.Y, box.Min.Y), box.Max.Y),
+		math.Min(math.Max(point.Z, box.Min.Z), box.Max.Z),
+	)
+	return v.interpolate(clamped) + point.Subtract(clamped).Length()
+}
+
+// interpolate returns the trilinear interpolation of the samples at a point inside the grid
+func (v *VoxelSDF) interpolate(point core.Vec3) float64 {
+	local := point.Subtract(v.Origin).Multiply(1 / v.CellSize)
+	cell := func(x float64, n int) (int, float64) {
+		i := int(math.Floor(x))
+		if i > n-2 {
+			i = n - 2
+		}
+		if i < 0 {
+			i = 0
+		}
+		return i, x - float64(i)
+	}
+	i, fx := cell(local.X, v.nx)
+	j, fy := cell(local.Y, v.ny)
+	k, fz := cell(local.Z, v.nz)
+
+	at := func(di, dj, dk int) float64 {
+		return v.values[((k+dk)*v.ny+j+dj)*v.nx+i+di]
+	}
+	lerp := func(a, b, t float64) float64 {
+		return a + (b-a)*t
+	}
+	c00 := lerp(at(0, 0, 0), at(1, 0, 0), fx)
+	c10 := lerp(at(0, 1, 0), at(1, 1, 0), fx)
+	c01 := lerp(at(0, 0, 1), at(1, 0, 1), fx)
+	c11 := lerp(at(0, 1, 1), at(1, 1, 1), fx)
+	return lerp(lerp(c00, c10, fy), lerp(c01, c11, fy), fz)
+}
@@ -0,0 +1,97 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// sampledSphere samples the distance to a sphere of radius 1 on a grid over [-1.5, 1.5]³
+func sampledSphere(t *testing.T, n int) *VoxelSDF {
+	t.Helper()
+	cellSize := 3.0 / float64(n-1)
+	origin := core.NewVec3(-1.5, -1.5, -1.5)
+	values := make([]float64, 0, n*n*n)
+	for k := 0; k < n; k++ {
+		for j := 0; j < n; j++ {
+			for i := 0; i < n; i++ {
+				p := origin.Add(core.NewVec3(float64(i), float64(j), float64(k)).Multiply(cellSize))
+				values = append(values, p.Length()-1)
+			}
+		}
+	}
+	grid, err := NewVoxelSDF(origin, cellSize, n, n, n, values)
+	if err != nil {
+		t.Fatalf("NewVoxelSDF failed: %v", err)
+	}
+	return grid
+}
+
+func TestNewVoxelSDF_Validation(t *testing.T) {
+	origin := core.NewVec3(0, 0, 0)
+	if _, err := NewVoxelSDF(origin, 0, 2, 2, 2, make([]float64, 8)); err == nil {
+		t.Error("Expected an error for a zero cell size")
+	}
+	if _, err := NewVoxelSDF(origin, 1, 1, 2, 2, make([]float64, 4)); err == nil {
+		t.Error("Expected an error for a single sample along x")
+	}
+	if _, err := NewVoxelSDF(origin, 1, 2, 2, 2, make([]float64, 7)); err == nil {
+		t.Error("Expected an error for too few values")
+	}
+}
+
+func TestVoxelSDF_SignedDistance(t *testing.T) {
+	grid := sampledSphere(t, 31)
+
+	box := grid.BoundingBox()
+	if !approxEqualVec(box.Min, core.NewVec3(-1.5, -1.5, -1.5), 1e-12) || !approxEqualVec(box.Max, core.NewVec3(1.5, 1.5, 1.5), 1e-9) {
+		t.Errorf("Expected bounds [-1.5, 1.5]³, got %v", box)
+	}
+
+	tests := []struct {
+		name     string
+		point    core.Vec3
+		expected float64
+	}{
+		{"sample", core.NewVec3(0, 0, 0), -1},
+		{"between samples", core.NewVec3(0.25, 0.33, -0.41), math.Sqrt(0.25*0.25+0.33*0.33+0.41*0.41) - 1},
+		{"on the surface", core.NewVec3(0.6, 0.8, 0), 0},
+		{"outside the grid", core.NewVec3(0, 3, 0), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := grid.SignedDistance(tt.point); math.Abs(d-tt.expected) > 0.01 {
+				t.Errorf("Expected distance %f, got %f", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestVoxelSDF_SurfaceMatchesSphere(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	surface := NewSDFSurface(sampledSphere(t, 41), mat)
+	sphere := NewSphere(core.NewVec3(0, 0, 0), 1, mat)
+
+	sampler := core.NewSeededSampler(4)
+	for i := 0; i < 200; i++ {
+		origin := core.NewVec3(sampler.Get1D()*4-2, sampler.Get1D()*4-2, 4)
+		ray := core.NewRay(origin, core.NewVec3(0, 0, -1))
+		want, wantHit := sphere.Hit(ray, 0.001, 100)
+		got, gotHit := surface.Hit(ray, 0.001, 100)
+		if !wantHit {
+			continue
+		}
+		if !gotHit {
+			// Grazing rays may slip past the interpolated surface
+			if want.Normal.Z > 0.2 {
+				t.Fatalf("Ray %v missed the sampled sphere", ray)
+			}
+			continue
+		}
+		if math.Abs(got.T-want.T) > 0.01 || got.Normal.Dot(want.Normal) < 0.98 {
+			t.Errorf("Ray %v: expected t=%f normal %v, got t=%f normal %v", ray, want.T, want.Normal, got.T, got.Normal)
+		}
+	}
+}
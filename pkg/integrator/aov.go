@@ -0,0 +1,50 @@
+package integrator
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Strategy identifies a BDPT sampling technique by its light (S) and camera (T) subpath vertex counts
+type Strategy struct {
+	S int // Vertices taken from the light subpath
+	T int // Vertices taken from the camera subpath
+}
+
+// AOVSample records how the radiance of one camera sample splits into light transport components
+type AOVSample struct {
+	Direct     core.Vec3              // Emitters seen directly plus light reaching the camera after one bounce
+	Indirect   core.Vec3              // Light reaching the camera after two or more bounces
+	Strategies map[Strategy]core.Vec3 // MIS-weighted contribution of each BDPT strategy (BDPT only)
+}
+
+// AOVIntegrator is implemented by integrators that can report AOV components alongside the pixel color
+type AOVIntegrator interface {
+	Integrator
+	// RayColorAOV behaves exactly like RayColor and additionally records the sample's light split in aov
+	RayColorAOV(ray core.Ray, scene *scene.Scene, sampler core.Sampler, aov *AOVSample) (core.Vec3, []SplatRay)
+}
+
+// IsDirectPath reports whether a path with the given vertex count (camera and emitter included)
+// carries direct lighting: camera-emitter or camera-surface-emitter
+func IsDirectPath(pathVertices int) bool {
+	return pathVertices <= 3
+}
+
+// AddLight adds radiance carried to the camera by a path with the given number of vertices
+func (a *AOVSample) AddLight(pathVertices int, radiance core.Vec3) {
+	if IsDirectPath(pathVertices) {
+		a.Direct = a.Direct.Add(radiance)
+	} else {
+		a.Indirect = a.Indirect.Add(radiance)
+	}
+}
+
+// AddStrategy adds the weighted contribution of a BDPT strategy to its strategy image and light split
+func (a *AOVSample) AddStrategy(strategy Strategy, radiance core.Vec3) {
+	if a.Strategies == nil {
+		a.Strategies = make(map[Strategy]core.Vec3)
+	}
+	a.Strategies[strategy] = a.Strategies[strategy].Add(radiance)
+	a.AddLight(strategy.S+strategy.T, radiance)
+}
@@ -0,0 +1,98 @@
+package integrator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestAOVSample_AddLight(t *testing.T) {
+	var aov AOVSample
+	aov.AddLight(2, core.NewVec3(1, 0, 0)) // camera -> emitter
+	aov.AddLight(3, core.NewVec3(0, 1, 0)) // camera -> surface -> emitter
+	aov.AddLight(4, core.NewVec3(0, 0, 1)) // two bounces
+
+	if !isClose(aov.Direct, core.NewVec3(1, 1, 0), 1e-12) {
+		t.Errorf("Expected direct (1,1,0), got %v", aov.Direct)
+	}
+	if !isClose(aov.Indirect, core.NewVec3(0, 0, 1), 1e-12) {
+		t.Errorf("Expected indirect (0,0,1), got %v", aov.Indirect)
+	}
+}
+
+func TestRayColorAOV_SplitSumsToColor(t *testing.T) {
+	cornell := createMinimalCornellScene(true)
+	config := scene.SamplingConfig{MaxDepth: 6, RussianRouletteMinBounces: 3}
+
+	integrators := map[string]AOVIntegrator{
+		"path-tracing": NewPathTracingIntegrator(config),
+		"bdpt":         NewBDPTIntegrator(config),
+	}
+
+	for name, integ := range integrators {
+		t.Run(name, func(t *testing.T) {
+			sampler := core.NewRandomSampler(rand.New(rand.NewSource(3)))
+			sawIndirect := false
+
+			for i := 0; i < 200; i++ {
+				ray := cornell.Camera.GetRay(100+i, 150+i/2, sampler.Get2D(), sampler.Get2D())
+
+				var aov AOVSample
+				color, _ := integ.RayColorAOV(ray, cornell, sampler, &aov)
+
+				if split := aov.Direct.Add(aov.Indirect); !isClose(split, color, 1e-9) {
+					t.Fatalf("Sample %d: direct+indirect %v != color %v", i, split, color)
+				}
+				if !aov.Indirect.IsZero() {
+					sawIndirect = true
+				}
+
+				if name == "bdpt" {
+					var strategySum core.Vec3
+					for strategy, c := range aov.Strategies {
+						if strategy.T < 1 || strategy.S < 0 {
+							t.Errorf("Invalid strategy %+v", strategy)
+						}
+						strategySum = strategySum.Add(c)
+					}
+					if !isClose(strategySum, color, 1e-9) {
+						t.Fatalf("Sample %d: strategy sum %v != color %v", i, strategySum, color)
+					}
+				} else if len(aov.Strategies) != 0 {
+					t.Errorf("Path tracing shouldn't record BDPT strategies, got %d", len(aov.Strategies))
+				}
+			}
+
+			if !sawIndirect {
+				t.Error("Expected some indirect lighting inside the Cornell box")
+			}
+		})
+	}
+}
+
+func TestRayColorAOV_MatchesRayColor(t *testing.T) {
+	// Recording AOVs must not change the sample sequence or the result
+	cornell := createMinimalCornellScene(false)
+	config := scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 2}
+	ray := cornell.Camera.GetRay(200, 260, core.NewVec2(0.5, 0.5), core.NewVec2(0.5, 0.5))
+
+	for name, integ := range map[string]AOVIntegrator{
+		"path-tracing": NewPathTracingIntegrator(config),
+		"bdpt":         NewBDPTIntegrator(config),
+	} {
+		plain, plainSplats := integ.RayColor(ray, cornell, core.NewSeededSampler(11))
+		withAOV, aovSplats := integ.RayColorAOV(ray, cornell, core.NewSeededSampler(11), &AOVSample{})
+
+		if plain != withAOV || len(plainSplats) != len(aovSplats) {
+			t.Errorf("%s: RayColorAOV diverged from RayColor: %v (%d splats) vs %v (%d splats)",
+				name, withAOV, len(aovSplats), plain, len(plainSplats))
+		}
+		for _, splat := range aovSplats {
+			if splat.Strategy.S+splat.Strategy.T < 2 || splat.Strategy.T != 1 {
+				t.Errorf("%s: splat tagged with unexpected strategy %+v", name, splat.Strategy)
+			}
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package integrator
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
@@ -35,6 +35,15 @@ type Vertex struct {
 	EmittedLight core.Vec3 // Light emitted from this vertex
 }
 
+// cosineTo returns the cosine weighting light leaving the vertex in a direction, negative below
+// the surface unless its material is translucent (see material.CosineTerm)
+func (v *Vertex) cosineTo(direction core.Vec3) float64 {
+	if v.Material != nil {
+		return material.CosineTerm(v.Material, direction, v.Normal)
+	}
+	return direction.Dot(v.Normal)
+}
+
 // IsOnSurface returns true if this vertex is on a surface with meaningful geometry
 // Matches PBRT's Vertex::IsOnSurface() which checks if geometric normal is non-zero
 // For light vertices: only area lights (not point lights) are considered "on surface"
@@ -54,28 +63,58 @@ type Path struct {
 	Length   int
 }
 
+// bdptLog logs each strategy's MIS weight, at debug level
+var bdptLog = core.NewLog("bdpt")
+
 // BDPTIntegrator implements bidirectional path tracing
 type BDPTIntegrator struct {
-	Config  scene.SamplingConfig
-	Verbose bool
+	Config scene.SamplingConfig
+	Tracer *BDPTTracer // Optional structured trace of the strategy evaluations
 }
 
 // NewBDPTIntegrator creates a new BDPT integrator
 func NewBDPTIntegrator(config scene.SamplingConfig) *BDPTIntegrator {
 	return &BDPTIntegrator{
-		Config:  config,
-		Verbose: false,
+		Config: config,
 	}
 }
 
 // RayColor computes color with support for ray-based splatting
 // Returns (pixel color, splat rays)
 func (bdpt *BDPTIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay) {
+	return bdpt.RayColorAOV(ray, scene, sampler, nil)
+}
+
+// RayColorAOV computes color like RayColor and records each strategy's weighted contribution in aov
+// Splat contributions are tagged with their strategy instead, since they land on other pixels
+func (bdpt *BDPTIntegrator) RayColorAOV(ray core.Ray, scene *scene.Scene, sampler core.Sampler, aov *AOVSample) (core.Vec3, []SplatRay) {
+	var trace *sampleTrace
+	if bdpt.Tracer != nil {
+		trace = bdpt.Tracer.begin(ray, scene.Camera)
+	}
+	totalLight, totalSplats, _, _ := bdpt.traceSample(ray, scene, sampler, aov, trace)
+	if trace != nil {
+		bdpt.Tracer.write(trace)
+	}
+	return totalLight, totalSplats
+}
+
+// traceSample generates a sample's camera and light paths and combines their strategies, recording
+// them in aov and trace if they aren't nil. It returns the paths along with the light.
+func (bdpt *BDPTIntegrator) traceSample(ray core.Ray, scene *scene.Scene, sampler core.Sampler, aov *AOVSample, trace *sampleTrace) (core.Vec3, []SplatRay, Path, Path) {
 
 	// Generate random camera and light paths
 	cameraPath := bdpt.generateCameraPath(ray, scene, sampler, bdpt.Config.MaxDepth)
 	lightPath := bdpt.generateLightPath(scene, sampler, bdpt.Config.MaxDepth)
+	core.CountPathLength(cameraPath.Length - 1) // The camera vertex isn't a bounce
 
+	light, splats := bdpt.combineStrategies(cameraPath, lightPath, scene, sampler, aov, trace)
+	return light, splats, cameraPath, lightPath
+}
+
+// combineStrategies evaluates every combination of a sample's camera and light paths and sums
+// them with MIS weights, recording them in aov and trace if they aren't nil
+func (bdpt *BDPTIntegrator) combineStrategies(cameraPath, lightPath Path, scene *scene.Scene, sampler core.Sampler, aov *AOVSample, trace *sampleTrace) (core.Vec3, []SplatRay) {
 	// Evaluate all combinations of camera and light paths with MIS weighting
 	var totalLight core.Vec3
 	var totalSplats []SplatRay
@@ -88,10 +127,25 @@ func (bdpt *BDPTIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler c
 
 			// apply MIS weight to contribution and splat rays
 			if !light.IsZero() || len(splats) > 0 {
-				misWeight := bdpt.calculateMISWeight(&cameraPath, &lightPath, sample, s, t, scene)
-				totalLight = totalLight.Add(light.Multiply(misWeight))
+				var strategyTrace *StrategyTrace
+				if trace != nil {
+					strategyTrace = trace.addStrategy(s, t, light, splats)
+				}
+				misWeight := bdpt.tracedMISWeight(&cameraPath, &lightPath, sample, s, t, scene, strategyTrace)
+				if strategyTrace != nil {
+					strategyTrace.setWeight(misWeight)
+				}
+				weighted := light.Multiply(misWeight)
+				totalLight = totalLight.Add(weighted)
+				if misWeight > 0 {
+					core.CountStrategy(s, t)
+				}
+				if aov != nil && !weighted.IsZero() {
+					aov.AddStrategy(Strategy{S: s, T: t}, weighted)
+				}
 				for i := range splats {
 					splats[i].Color = splats[i].Color.Multiply(misWeight)
+					splats[i].Strategy = Strategy{S: s, T: t}
 				}
 				totalSplats = append(totalSplats, splats...)
 			}
@@ -128,7 +182,6 @@ func (bdpt *BDPTIntegrator) generateCameraPath(ray core.Ray, scene *scene.Scene,
 
 	// Continue the camera path by tracing the ray through the scene
 	beta := core.Vec3{X: 1, Y: 1, Z: 1}
-	// bdpt.logf("generateCameraSubpath: ray=%v, beta=%v, directionPDF=%0.6g\n", ray, beta, directionPDF)
 	bdpt.extendPath(&path, ray, beta, directionPDF, scene, sampler, maxDepth, true) // Pass maxDepth through because camera doesn't count as a vertex
 
 	return path
@@ -175,7 +228,6 @@ func (bdpt *BDPTIntegrator) generateLightPath(scene *scene.Scene, sampler core.S
 	// PBRT formula: beta = Le * |cos(theta)| / (lightSelectionPdf * areaPdf * pdfDir)
 	ray := core.NewRay(emissionSample.Point, emissionSample.Direction)
 	beta := emissionSample.Emission.Multiply(cosTheta / (lightSelectionPdf * emissionSample.AreaPDF * emissionSample.DirectionPDF))
-	// bdpt.logf("generateLightSubpath: forwardThroughput=%v, cosTheta=%f, lightSelectionPdf=%f, AreaPDF=%f, DirectionPDF=%f\n", beta, cosTheta, lightSelectionPdf, emissionSample.AreaPDF, emissionSample.DirectionPDF)
 	bdpt.extendPath(&path, ray, beta, emissionSample.DirectionPDF, scene, sampler, maxDepth-1, false)
 
 	// PBRT: Correct subpath sampling densities for infinite area lights
@@ -194,8 +246,13 @@ func (bdpt *BDPTIntegrator) generateLightPath(scene *scene.Scene, sampler core.S
 
 		// Set spatial density of path[0] for infinite area light (use directional density)
 		// PBRT: Use InfiniteLightDensity to account for all infinite lights in this direction
-		// Use direct lighting PDF (cosine-weighted) to match what our Sample() function does
-		path.Vertices[0].AreaPdfForward = bdpt.calculateInfiniteLightDensity(emissionSample.Point, emissionSample.Normal, emissionSample.Direction, scene)
+		// Use direct lighting PDF (cosine-weighted) to match what our Sample() function does: it's
+		// the density of direct lighting at the first bounce sampling the direction back to the light
+		path.Vertices[0].AreaPdfForward = 0
+		if path.Length > 1 {
+			firstBounceVertex := &path.Vertices[1]
+			path.Vertices[0].AreaPdfForward = bdpt.calculateInfiniteLightDensity(firstBounceVertex.Point, firstBounceVertex.Normal, emissionSample.Direction.Negate(), scene)
+		}
 	}
 
 	return path
@@ -204,25 +261,21 @@ func (bdpt *BDPTIntegrator) generateLightPath(scene *scene.Scene, sampler core.S
 // extendPath extends a path by tracing a ray through the scene, handling intersections and scattering
 // This is the common logic shared between camera and light path generation after the initial vertex
 func (bdpt *BDPTIntegrator) extendPath(path *Path, currentRay core.Ray, beta core.Vec3, pdfFwd float64, scene *scene.Scene, sampler core.Sampler, maxBounces int, isCameraPath bool) {
+	var lobes lobeBounces
 	for bounces := 0; bounces < maxBounces; bounces++ {
 		vertexPrevIndex := path.Length - 1
 		vertexPrev := &path.Vertices[vertexPrevIndex] // Still need copy for calculations
 
-		// Check for intersections
-		hit, isHit := scene.BVH.Hit(currentRay, 0.001, math.Inf(1))
+		// Check for intersections, passing through shapes hidden from this ray
+		kind := material.IndirectRays
+		if isCameraPath && bounces == 0 {
+			kind = material.CameraRays
+		}
+		hit, isHit := geometry.HitVisible(scene.Intersector, currentRay, 0.001, math.Inf(1), kind)
 		if !isHit {
 			if isCameraPath {
 				// Hit background - check for infinite light emission
-				ls := scene.Lights
-				var totalEmission core.Vec3
-				for _, light := range ls {
-					// Only check infinite lights when we miss all geometry
-					if light.Type() == lights.LightTypeInfinite {
-						emission := light.Emit(currentRay, nil)
-						totalEmission = totalEmission.Add(emission)
-					}
-				}
-
+				totalEmission := lights.EvaluateInfiniteLights(scene.Lights, currentRay)
 				vertex := createBackgroundVertex(currentRay, totalEmission, beta, pdfFwd)
 				path.Vertices = append(path.Vertices, *vertex)
 				path.Length++
@@ -237,9 +290,15 @@ func (bdpt *BDPTIntegrator) extendPath(path *Path, currentRay core.Ray, beta cor
 			Beta:               beta,
 		}
 
-		// Capture emitted light from this vertex
+		// Capture emitted light from this vertex. A glowing medium's collision emits, but it isn't
+		// a light: it goes on to scatter like any other vertex, and no light path starts there.
 		vertex.EmittedLight = getEmittedLight(currentRay, hit)
-		vertex.IsLight = !vertex.EmittedLight.IsZero()
+		_, isMedium := hit.Material.(material.MediumEmitter)
+		vertex.IsLight = !vertex.EmittedLight.IsZero() && !isMedium
+		if vertex.IsLight {
+			// The (s=0) MIS weight needs the light's origin PDF, so identify which light was hit
+			vertex.Light, vertex.LightIndex = lights.FindAreaLight(scene.Lights, hit.Point, vertex.IncomingDirection)
+		}
 
 		// Set forward PDF into this vertex, from the pdf of the previous vertex
 		// pbrt: prev.ConvertDensity(pdf, v)
@@ -268,8 +327,8 @@ func (bdpt *BDPTIntegrator) extendPath(path *Path, currentRay core.Ray, beta cor
 			beta = beta.MultiplyVec(scatter.Attenuation).Multiply(cosTheta / scatter.PDF)
 		}
 
-		// pbrt: Float pdfRev = bsdf.PDF(bs->wi, wo, !mode)
-		pdfRev, isReverseDelta := hit.Material.PDF(scatter.Scattered.Direction, currentRay.Direction.Multiply(-1), hit.Normal)
+		// pbrt: Float pdfRev = bsdf.PDF(bs->wi, wo, !mode), the density of scattering back along the path
+		pdfRev, isReverseDelta := hit.Material.PDF(scatter.Scattered.Direction.Negate(), currentRay.Direction.Negate(), hit.Normal)
 
 		// For delta functions in BDPT, set reverse PDF to 0 (like PBRT)
 		if isReverseDelta {
@@ -285,6 +344,24 @@ func (bdpt *BDPTIntegrator) extendPath(path *Path, currentRay core.Ray, beta cor
 		path.Vertices = append(path.Vertices, vertex)
 		path.Length++
 
+		// The vertex still connects to the other subpath, but a subpath past its lobe's depth
+		// limit isn't extended
+		lobes = lobes.add(scatter.Lobe)
+		if !lobes.within(bdpt.Config) {
+			break
+		}
+
+		// Russian roulette on the throughput carried to the next vertex, compensated in beta.
+		// The survival probability is deliberately kept out of AreaPdfForward/AreaPdfReverse: it depends
+		// on this subpath's own throughput, which the other strategies that generate the same path can't
+		// reproduce, so folding it into the densities would stop the MIS weights from summing to one.
+		if survivalProb := russianRouletteSurvival(bdpt.Config, bounces+1, beta); survivalProb < 1.0 {
+			if sampler.Get1D() >= survivalProb {
+				break
+			}
+			beta = beta.Multiply(1.0 / survivalProb)
+		}
+
 		// Prepare for next bounce
 		currentRay = scatter.Scattered
 	}
@@ -315,8 +392,8 @@ func (bdpt *BDPTIntegrator) evaluateBDPTStrategy(cameraPath, lightPath Path, s,
 // evaluatePathTracingStrategy evaluates the BDPT path tracing strategy
 // This is the camera-only path that accumulates radiance from surface emission and background
 func (bdpt *BDPTIntegrator) evaluatePathTracingStrategy(cameraPath Path, t int) core.Vec3 {
-	// Only evaluate for the complete cameraPath
-	if t == 0 || t < cameraPath.Length {
+	// Only evaluate for the complete cameraPath, or where it collides in a glowing medium
+	if t == 0 || (t < cameraPath.Length && cameraPath.Vertices[t-1].IsLight) {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
@@ -327,7 +404,6 @@ func (bdpt *BDPTIntegrator) evaluatePathTracingStrategy(cameraPath Path, t int)
 	// The contribution is the emitted light at the last vertex weighted by path throughput
 	// pbrt: L = pt.Le(scene, cameraVertices[t - 2]) * pt.beta
 	contribution := lastVertex.EmittedLight.MultiplyVec(lastVertex.Beta)
-	// bdpt.logf(" (s=0,t=%d) evaluatePathTracingStrategy: contribution:%v = lastVertex.EmittedLight:%v * lastVertex.Beta:%v\n", t, contribution, lastVertex.EmittedLight, lastVertex.Beta)
 	return contribution
 }
 
@@ -356,7 +432,7 @@ func (bdpt *BDPTIntegrator) evaluateDirectLightingStrategy(cameraPath Path, t in
 	// pbrt pdfFwd: sampled.PdfLightOrigin(scene, pt, lightDistr, lightToIndex)
 	//          => pdfPos * pdfChoice // Return solid angle density for non-infinite light sources
 	//          => pdfDir for light sample not used
-	brdf := cameraVertex.Material.EvaluateBRDF(cameraVertex.IncomingDirection, lightSample.Direction, cameraVertex.SurfaceInteraction, material.Radiance)
+	brdf := bdpt.evaluateBRDF(cameraVertex, lightSample.Direction, material.Radiance)
 	lightBeta := lightSample.Emission.Multiply(1 / lightSample.PDF) // light sample pdf contains light selection pdf
 	lightContribution := brdf.MultiplyVec(cameraVertex.Beta).MultiplyVec(lightBeta).Multiply(cosTheta)
 
@@ -366,7 +442,7 @@ func (bdpt *BDPTIntegrator) evaluateDirectLightingStrategy(cameraPath Path, t in
 
 	// Check if light is visible (shadow ray)
 	shadowRay := core.NewRay(cameraVertex.Point, lightSample.Direction)
-	_, blocked := scene.BVH.Hit(shadowRay, 0.001, lightSample.Distance-0.001)
+	blocked := scene.Intersector.HitAny(shadowRay, 0.001, lightSample.Distance-0.001)
 	if blocked {
 		// Light is blocked, no direct contribution
 		return core.Vec3{X: 0, Y: 0, Z: 0}, nil
@@ -380,15 +456,17 @@ func (bdpt *BDPTIntegrator) evaluateDirectLightingStrategy(cameraPath Path, t in
 		},
 		Light:           sampledLight, // TODO: remove after cleanup
 		LightIndex:      lightIndex,
-		AreaPdfForward:  lightSample.PDF, // probability of generating this point is the light sampling pdf
-		AreaPdfReverse:  0.0,             // probability of generating this point in reverse is set by MIS weight calculation
+		AreaPdfReverse:  0.0, // probability of generating this point in reverse is set by MIS weight calculation
 		IsLight:         true,
 		IsInfiniteLight: sampledLight.Type() == lights.LightTypeInfinite, // Properly mark infinite lights
 		Beta:            lightBeta,
 		EmittedLight:    lightSample.Emission,
 	}
 
-	//bdpt.logf(" (s=1,t=%d) evaluateDirectLightingStrategy: L=%v => brdf=%v * beta=%v * emission=%v * (cosTheta=%f / pdf=%f)\n", t, lightContribution, brdf, cameraVertex.Beta, lightSample.Emission, cosTheta, lightSample.PDF)
+	// MIS compares strategies by the area density of the light path generating this point, not the
+	// solid angle density lightSample.PDF used for the estimate (their ratio varies with distance,
+	// which made the weights depend on scene scale)
+	sampledVertex.AreaPdfForward = bdpt.calculateLightOriginPdf(sampledVertex, cameraVertex, scene)
 
 	return lightContribution, sampledVertex
 }
@@ -403,8 +481,8 @@ func (bdpt *BDPTIntegrator) evaluateLightTracingStrategy(lightPath Path, s int,
 	// Get the light path vertex we're connecting to the camera
 	lightVertex := &lightPath.Vertices[s-1]
 
-	// Skip specular vertices (can't connect through delta functions)
-	if lightVertex.IsSpecular {
+	// Skip specular vertices (can't connect through delta functions) and those the camera can't see
+	if lightVertex.IsSpecular || lightVertex.HiddenFrom&material.CameraRays != 0 {
 		return nil, nil
 	}
 
@@ -415,8 +493,6 @@ func (bdpt *BDPTIntegrator) evaluateLightTracingStrategy(lightPath Path, s int,
 		return nil, nil
 	}
 
-	// bdpt.logf(" (s=%d,t=1) SampleCameraFromPoint: Weight=%v, PDF=%f\n", s, cameraSample.Weight, cameraSample.PDF)
-
 	// PBRT formula: L = qs.beta * qs.f(sampled, TransportMode::Importance) * sampled.beta;
 	// where sampled.beta = Wi / pdf
 
@@ -428,7 +504,7 @@ func (bdpt *BDPTIntegrator) evaluateLightTracingStrategy(lightPath Path, s int,
 	brdf := bdpt.evaluateBRDF(lightVertex, cameraSample.Ray.Direction.Multiply(-1), material.Importance)
 	cameraBeta := cameraSample.Weight.Multiply(1 / cameraSample.PDF)
 
-	cosine := cameraSample.Ray.Direction.Multiply(-1).Dot(lightVertex.Normal)
+	cosine := lightVertex.cosineTo(cameraSample.Ray.Direction.Multiply(-1))
 	if cosine <= 0 {
 		return nil, nil
 	}
@@ -440,14 +516,20 @@ func (bdpt *BDPTIntegrator) evaluateLightTracingStrategy(lightPath Path, s int,
 	}
 
 	if lightContribution.IsZero() {
-		// bdpt.logf(" Light contribution is <= 0: %v\n", lightContribution)
 		return nil, nil
 	}
 
 	// Visibility test
 	shadowRay := core.NewRay(lightVertex.Point, cameraSample.Ray.Direction.Multiply(-1))
 	distance := lightVertex.Point.Subtract(cameraSample.Ray.Origin).Length()
-	_, blocked := scene.BVH.Hit(shadowRay, 0.001, distance-0.001)
+	var blocked bool
+	if scene.HidesShapes() {
+		// This stands in for a camera ray, which passes through shapes hidden from the camera but
+		// stops at those hidden only from shadow rays
+		_, blocked = geometry.HitVisible(scene.Intersector, shadowRay, 0.001, distance-0.001, material.CameraRays)
+	} else {
+		blocked = scene.Intersector.HitAny(shadowRay, 0.001, distance-0.001)
+	}
 	if blocked {
 		return nil, nil
 	}
@@ -480,8 +562,6 @@ func (bdpt *BDPTIntegrator) evaluateLightTracingStrategy(lightPath Path, s int,
 		Color: lightContribution,
 	}
 
-	// bdpt.logf(" (s=%d,t=1) evaluateLightTracingStrategy: L=%v => brdf=%v * cameraBeta=%v * lightVertex.Beta=%v * cosine=%f\n", s, lightContribution, brdf, cameraBeta, lightVertex.Beta, cosine)
-
 	return []SplatRay{splatRay}, sampledCameraVertex
 }
 
@@ -522,8 +602,8 @@ func (bdpt *BDPTIntegrator) evaluateConnectionStrategy(cameraPath, lightPath Pat
 	direction = direction.Multiply(1.0 / distance)
 
 	// Geometric term: G(x,y) = cos(theta_x) * cos(theta_y) / distance^2
-	cosAtCamera := direction.Dot(cameraVertex.Normal)
-	cosAtLight := direction.Multiply(-1).Dot(lightVertex.Normal)
+	cosAtCamera := cameraVertex.cosineTo(direction)
+	cosAtLight := lightVertex.cosineTo(direction.Multiply(-1))
 	if cosAtCamera <= 0 || cosAtLight <= 0 {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
@@ -543,7 +623,6 @@ func (bdpt *BDPTIntegrator) evaluateConnectionStrategy(cameraPath, lightPath Pat
 	// PBRT formula: L = qs.beta * qs.f(pt, TransportMode::Importance) * pt.f(qs, TransportMode::Radiance) * pt.beta * G
 	// Which translates to: lightThroughput * lightBRDF * cameraBRDF * cameraThroughput * G
 	contribution := lightPathThroughput.MultiplyVec(lightBRDF).MultiplyVec(cameraBRDF).MultiplyVec(cameraPathThroughput).Multiply(geometricTerm)
-	// bdpt.logf(" (s=%d,t=%d) evaluateConnectionStrategy: L=%v => cameraBRDF=%v * lightBRDF=%v * G=%v * cameraThroughput=%v * lightThroughput=%v\n", s, t, contribution, cameraBRDF, lightBRDF, geometricTerm, cameraPathThroughput, lightPathThroughput)
 
 	if contribution.IsZero() {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
@@ -551,9 +630,8 @@ func (bdpt *BDPTIntegrator) evaluateConnectionStrategy(cameraPath, lightPath Pat
 
 	// Visibility test
 	shadowRay := core.NewRay(cameraVertex.Point, direction)
-	_, blocked := scene.BVH.Hit(shadowRay, 0.001, distance-0.001)
+	blocked := scene.Intersector.HitAny(shadowRay, 0.001, distance-0.001)
 	if blocked {
-		// bdpt.logf(" (s=%d,t=%d) evaluateConnectionStrategy: blocked hit=%v\n", s, t, hit)
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
@@ -578,8 +656,8 @@ func (bdpt *BDPTIntegrator) evaluateBRDF(vertex *Vertex, outgoingDirection core.
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
-	// Use the embedded SurfaceInteraction directly
-	return vertex.Material.EvaluateBRDF(vertex.IncomingDirection, outgoingDirection, vertex.SurfaceInteraction, mode)
+	// Materials take the direction the ray arrived along, toward the surface, as path tracing passes it
+	return vertex.Material.EvaluateBRDF(vertex.IncomingDirection.Negate(), outgoingDirection, vertex.SurfaceInteraction, mode)
 }
 
 func createBackgroundVertex(ray core.Ray, bgColor core.Vec3, beta core.Vec3, pdfFwd float64) *Vertex {
@@ -599,9 +677,3 @@ func createBackgroundVertex(ray core.Ray, bgColor core.Vec3, beta core.Vec3, pdf
 		EmittedLight:      bgColor, // Capture background light
 	}
 }
-
-func (bdpt *BDPTIntegrator) logf(format string, a ...interface{}) {
-	if bdpt.Verbose {
-		fmt.Printf(format, a...)
-	}
-}
@@ -1,8 +1,10 @@
 package integrator
 
 import (
+	"image"
 	"math"
 	"math/rand"
+	"os"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
@@ -38,7 +40,9 @@ func TestBDPTvsPathTracingDirectLighting(t *testing.T) {
 	bdptSampler := core.NewRandomSampler(rand.New(rand.NewSource(seed)))
 	bdptConfig := scene.SamplingConfig{MaxDepth: 5}
 	bdptIntegrator := NewBDPTIntegrator(bdptConfig)
-	bdptIntegrator.Verbose = testing.Verbose() // Enable verbose logging to see MIS weights
+	if testing.Verbose() {
+		bdptIntegrator.Tracer = NewBDPTTracer(os.Stdout, image.Rectangle{}) // Trace the strategies to see MIS weights
+	}
 
 	// Get the final result through RayColor for comparison
 	bdptSampler = core.NewRandomSampler(rand.New(rand.NewSource(seed)))
@@ -290,7 +294,7 @@ func TestInfiniteLightEmissionSampling(t *testing.T) {
 		t.Logf("  Direction toward scene center: %f (should be > 0.5)", dotProduct)
 
 		// Test ray intersection with scene
-		hit, isHit := testScene.BVH.Hit(emissionRay, 0.001, math.Inf(1))
+		hit, isHit := testScene.Intersector.Hit(emissionRay, 0.001, math.Inf(1))
 		if isHit {
 			intersectionCount++
 			t.Logf("  HIT: %v (material: %v)", hit.Point, hit.Material != nil)
@@ -319,7 +323,6 @@ func TestBDPTvsPathTracingReflectiveGround(t *testing.T) {
 	testScene, config := SceneWithReflectiveGroundPlane()
 
 	bdpt := NewBDPTIntegrator(config)
-	bdpt.Verbose = false // Disable debug logging for cleaner test output
 	pt := NewPathTracingIntegrator(config)
 
 	// Ray hitting reflective ground at an angle (should reflect to sky)
@@ -769,8 +769,8 @@ func TestEvaluateLightTracingStrategy(t *testing.T) {
 				{
 					SurfaceInteraction: &material.SurfaceInteraction{Point: core.NewVec3(0, 0, -1), Normal: core.NewVec3(0, 0, 1), Material: material.NewMetal(core.NewVec3(0.9, 0.8, 0.7), 0.1)},
 					Beta:               core.Vec3{X: 0.85, Y: 0.75, Z: 0.65},
-					IncomingDirection:  core.NewVec3(0, 0, 1), // same as working tests
-					IsSpecular:         false,                 // roughened metal can be connected
+					IncomingDirection:  core.NewVec3(0, 0, -1), // Metal.EvaluateBRDF reflects the negated incoming direction
+					IsSpecular:         false,                  // roughened metal can be connected
 				},
 			}),
 			s: 2,
@@ -823,7 +823,8 @@ func TestEvaluateLightTracingStrategy(t *testing.T) {
 
 // TestCameraPathBetaPropagation tests beta calculation through actual BDPT methods
 func TestCameraPathBetaPropagation(t *testing.T) {
-	integrator := NewBDPTIntegrator(scene.SamplingConfig{MaxDepth: 5})
+	// Keep Russian roulette out of the way so beta reflects only the material response
+	integrator := NewBDPTIntegrator(scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 5})
 
 	// Create a glancing ray that comes from the camera and hits the sphere at an angle
 	// Camera is at origin, sphere is at (0,0,-2) with radius 1
@@ -11,6 +11,11 @@ import (
 // calculateMISWeight implements zero-allocation MIS weighting using on-demand PDF calculation
 // Directly copies calculateMISWeightAlt2 logic but eliminates intermediate arrays
 func (bdpt *BDPTIntegrator) calculateMISWeight(cameraPath, lightPath *Path, sampledVertex *Vertex, s, t int, scene *scene.Scene) float64 {
+	return bdpt.tracedMISWeight(cameraPath, lightPath, sampledVertex, s, t, scene, nil)
+}
+
+// tracedMISWeight calculates the MIS weight, recording each vertex's terms in trace if it isn't nil
+func (bdpt *BDPTIntegrator) tracedMISWeight(cameraPath, lightPath *Path, sampledVertex *Vertex, s, t int, scene *scene.Scene, trace *StrategyTrace) float64 {
 	disableMISWeight := false
 	if disableMISWeight {
 		//return 1.0 / float64(s+t-1)
@@ -22,6 +27,11 @@ func (bdpt *BDPTIntegrator) calculateMISWeight(cameraPath, lightPath *Path, samp
 		return 1.0
 	}
 
+	// Only camera paths find the light a glowing medium emits: no light path starts in it
+	if s == 0 && !cameraPath.Vertices[t-1].IsLight && !cameraPath.Vertices[t-1].EmittedLight.IsZero() {
+		return 1.0
+	}
+
 	sumRi := 0.0
 
 	// Camera path alternatives: start from connection vertex and work backward
@@ -34,7 +44,7 @@ func (bdpt *BDPTIntegrator) calculateMISWeight(cameraPath, lightPath *Path, samp
 		if isConnectible {
 			sumRi += ri
 		}
-		// bdpt.logf(" (s=%d,t=%d) cameraPath[%d]: fwd=%.3g, rev=%.3g, conn=%v, ri=%.3g, sumRi=%.3g\n", s, t, i, forwardPdf, reversePdf, isConnectible, ri, sumRi)
+		trace.addVertex("camera", i, forwardPdf, reversePdf, isConnectible, ri)
 	}
 
 	// Light path alternatives: start from connection vertex and work backward
@@ -47,12 +57,12 @@ func (bdpt *BDPTIntegrator) calculateMISWeight(cameraPath, lightPath *Path, samp
 		if isConnectible {
 			sumRi += ri
 		}
-
-		// bdpt.logf(" (s=%d,t=%d) lightPath[%d]: fwd=%.3g, rev=%.3g, conn=%v, ri=%.3g, sumRi=%.3g\n", s, t, i, forwardPdf, reversePdf, isConnectible, ri, sumRi)
-
+		trace.addVertex("light", i, forwardPdf, reversePdf, isConnectible, ri)
 	}
 
-	// bdpt.logf(" (s=%d,t=%d) calculateMISWeight: sumRi=%0.3g, weight=%0.3f\n", s, t, sumRi, 1.0/(1.0+sumRi))
+	if bdptLog.Enabled(core.LevelDebug) {
+		bdptLog.Logf(core.LevelDebug, "(s=%d,t=%d) MIS weight: sumRi=%.3g, weight=%.3f", s, t, sumRi, 1.0/(1.0+sumRi))
+	}
 	return 1.0 / (1.0 + sumRi)
 }
 
@@ -70,9 +80,12 @@ func (bdpt *BDPTIntegrator) calculateMISCameraVertexPdfs(cameraIdx int, cameraPa
 	case s == 0:
 		// Path tracing strategy
 		if cameraIdx == t-1 && t > 1 {
-			// Vertex t-1 may be a light, calculate reverse pdf from light origin if so
-			reversePdf = bdpt.calculateLightOriginPdf(&cameraPath.Vertices[t-1], &cameraPath.Vertices[t-2], scene)
-			isConnectible = true
+			// Vertex t-1 may be a light, calculate reverse pdf from light origin if so.
+			// Direct lighting samples infinite lights only above the surface, so it can't sample one
+			// below a translucent vertex: that strategy is left out rather than weighted as if it could.
+			lightVertex := &cameraPath.Vertices[t-1]
+			reversePdf = bdpt.calculateLightOriginPdf(lightVertex, &cameraPath.Vertices[t-2], scene)
+			isConnectible = reversePdf > 0 || !lightVertex.IsInfiniteLight
 		} else if cameraIdx == t-2 && t > 2 {
 			reversePdf = bdpt.calculateVertexPdf(&cameraPath.Vertices[t-1], nil, &cameraPath.Vertices[t-2], scene)
 		}
@@ -177,6 +190,12 @@ func (bdpt *BDPTIntegrator) calculateMISLightVertexPdfs(lightIdx int, cameraPath
 		}
 	}
 
+	// The direct lighting alternative (s=1) samples an infinite light by its origin's forward pdf,
+	// which is 0 where light paths still arrive from below (see calculateMISCameraVertexPdfs)
+	if lightIdx == 1 && lightPath.Vertices[0].IsInfiniteLight && lightPath.Vertices[0].AreaPdfForward == 0 {
+		isConnectible = false
+	}
+
 	return forwardPdf, reversePdf, isConnectible
 }
 
@@ -219,8 +238,8 @@ func (bdpt *BDPTIntegrator) calculateVertexPdf(curr *Vertex, prev *Vertex, next
 			return 0
 		}
 	} else if curr.Material != nil {
-		// pdf = si.bsdf->Pdf(wp, wn);
-		materialPdf, isDelta := curr.Material.PDF(wp, wn, curr.Normal)
+		// pdf = si.bsdf->Pdf(wp, wn); our materials take the incoming direction toward the surface
+		materialPdf, isDelta := curr.Material.PDF(wp.Negate(), wn, curr.Normal)
 		if isDelta {
 			return 0
 		}
@@ -246,7 +265,7 @@ func (bdpt *BDPTIntegrator) calculateLightPdf(curr *Vertex, to *Vertex, scene *s
 		// Handle infinite area lights (background)
 		if curr.IsInfiniteLight {
 			// PBRT: Compute planar sampling density for infinite light sources
-			worldRadius := scene.BVH.Radius
+			worldRadius := scene.WorldRadius
 
 			// Handle zero radius case (scene with no finite geometry)
 			if worldRadius == 0.0 {
@@ -297,9 +316,9 @@ func (bdpt *BDPTIntegrator) calculateLightOriginPdf(lightVertex *Vertex, to *Ver
 		return 0
 	}
 
-	// Use the actual light selection probability from the light sampler
+	// Use the probability that light path generation (SampleLightEmission) selects this light
 	lightSampler := scene.LightSampler
-	pdfChoice := lightSampler.GetLightProbability(lightVertex.LightIndex, lightVertex.Point, lightVertex.Normal)
+	pdfChoice := lightSampler.GetLightEmissionProbability(lightVertex.LightIndex)
 
 	// Get position PDF from the light's PDF_Le (matches PBRT exactly)
 	pdfPos, _ := lightVertex.Light.PDF_Le(lightVertex.Point, w)
@@ -311,27 +330,7 @@ func (bdpt *BDPTIntegrator) calculateLightOriginPdf(lightVertex *Vertex, to *Ver
 // Sums the directional PDFs of all infinite lights in the given direction, weighted by selection probability
 // Uses cosine-weighted hemisphere PDF for consistency with direct lighting sampling
 func (bdpt *BDPTIntegrator) calculateInfiniteLightDensity(point, normal, direction core.Vec3, scene *scene.Scene) float64 {
-	ls := scene.Lights
-	if len(ls) == 0 {
-		return 0
-	}
-
-	var totalPdf float64
-	lightSampler := scene.LightSampler
-
-	// Sum PDFs of all infinite lights in this direction
-	for i, light := range ls {
-		if light.Type() == lights.LightTypeInfinite {
-			// Use cosine-weighted hemisphere PDF (matches light.Sample behavior)
-			// This corresponds to PBRT's light.PDF_Li(Interaction(), direction)
-			directionalPdf := light.PDF(point, normal, direction)
-			// Get the actual light selection probability from the light sampler
-			lightSelectionPdf := lightSampler.GetLightProbability(i, point, normal)
-			totalPdf += directionalPdf * lightSelectionPdf
-		}
-	}
-
-	return totalPdf
+	return lights.InfiniteLightDensity(scene.Lights, scene.LightSampler, point, normal, direction)
 }
 
 // convertSolidAngleToAreaPdf converts a directional PDF to an area PDF
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
@@ -961,6 +962,63 @@ func TestPdfPropagation(t *testing.T) {
 	}
 }
 
+// TestCalculateMISWeight_InfiniteLightBelowTranslucentVertex checks that the strategies able to
+// sample a path from an infinite light below a translucent vertex share it entirely. Direct
+// lighting only samples infinite lights above the vertex, so it mustn't take a share.
+func TestCalculateMISWeight_InfiniteLightBelowTranslucentVertex(t *testing.T) {
+	light := lights.NewUniformInfiniteLight(core.NewVec3(0.5, 0.5, 0.5))
+	testScene := &scene.Scene{
+		Shapes: []geometry.Shape{geometry.NewSphere(core.NewVec3(0, 0, -3), 1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))},
+		Lights: []lights.Light{light},
+		Camera: geometry.NewCamera(geometry.CameraConfig{
+			Center: core.NewVec3(0, 0, 0), LookAt: core.NewVec3(0, 0, -3), Up: core.NewVec3(0, 1, 0),
+			Width: 16, AspectRatio: 1, VFov: 40,
+		}),
+	}
+	if err := testScene.Preprocess(); err != nil {
+		t.Fatal(err)
+	}
+	integrator := NewBDPTIntegrator(scene.SamplingConfig{MaxDepth: 5})
+	phase := material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0.5)
+
+	// Camera path: the camera sees a medium collision x, then escapes downward to the light
+	ray := core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(0.02, 0.01, -1).Normalize())
+	x := &material.SurfaceInteraction{Point: ray.At(3), Normal: core.NewVec3(0, 1, 0), Material: phase}
+	down := core.NewVec3(0.3, -1, 0.2).Normalize()
+	_, cameraPdf := testScene.Camera.CalculateRayPDFs(ray)
+	cameraVertex := Vertex{SurfaceInteraction: &material.SurfaceInteraction{Point: ray.Origin, Normal: ray.Direction.Negate()}, IsCamera: true}
+	xVertex := Vertex{SurfaceInteraction: x, IncomingDirection: ray.Direction.Negate()}
+	xVertex.AreaPdfForward = cameraVertex.convertSolidAngleToAreaPdf(&xVertex, cameraPdf)
+	phasePdf, _ := phase.PDF(ray.Direction, down, x.Normal)
+	background := createBackgroundVertex(core.NewRay(x.Point, down), light.Emission(), core.NewVec3(1, 1, 1), phasePdf)
+	cameraPath := Path{Vertices: []Vertex{cameraVertex, xVertex, *background}, Length: 3}
+
+	// Light path: the light's ray travels up to x, as generateLightPath would set it up
+	lightVertex := Vertex{
+		SurfaceInteraction: &material.SurfaceInteraction{Point: x.Point.Add(down.Multiply(2 * testScene.WorldRadius)), Normal: down.Negate()},
+		Light:              light, IsLight: true, IsInfiniteLight: true,
+		AreaPdfForward: integrator.calculateInfiniteLightDensity(x.Point, x.Normal, down, testScene),
+	}
+	if lightVertex.AreaPdfForward != 0 {
+		t.Fatalf("Expected direct lighting not to sample the light below the vertex, got a pdf of %f", lightVertex.AreaPdfForward)
+	}
+	lightX := Vertex{SurfaceInteraction: x, IncomingDirection: down}
+	lightX.AreaPdfForward = down.AbsDot(x.Normal) / (math.Pi * testScene.WorldRadius * testScene.WorldRadius)
+	lightPath := Path{Vertices: []Vertex{lightVertex, lightX}, Length: 2}
+	cameraSample := testScene.Camera.SampleCameraFromPoint(x.Point, core.NewVec2(0.5, 0.5))
+	sampledCamera := &Vertex{
+		SurfaceInteraction: &material.SurfaceInteraction{Point: cameraSample.Ray.Origin, Normal: cameraSample.Ray.Direction.Negate()},
+		IsCamera:           true,
+		AreaPdfForward:     cameraSample.PDF,
+	}
+
+	pathTracing := integrator.calculateMISWeight(&cameraPath, &lightPath, nil, 0, 3, testScene)
+	lightTracing := integrator.calculateMISWeight(&cameraPath, &lightPath, sampledCamera, 2, 1, testScene)
+	if sum := pathTracing + lightTracing; math.Abs(sum-1) > 1e-9 {
+		t.Errorf("Expected path and light tracing weights to sum to 1, got %f + %f = %f", pathTracing, lightTracing, sum)
+	}
+}
+
 // createSampledLightVertex creates a realistic sampled light vertex for s=1 (direct lighting) strategies
 func createSampledLightVertex() *Vertex {
 	emissive := material.NewEmissive(core.NewVec3(5.0, 5.0, 5.0))
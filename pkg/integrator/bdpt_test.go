@@ -278,44 +278,27 @@ type ExpectedPdfVertex struct {
 
 // createSimpleTestScene creates a minimal scene for unit testing
 func createSimpleTestScene() *scene.Scene {
-	// Create a simple scene with one light and one diffuse surface
-	white := material.NewLambertian(core.NewVec3(0.7, 0.7, 0.7))
-	sphere := geometry.NewSphere(core.NewVec3(0, 0, -1), 0.5, white)
-
-	// Create a simple quad light (easier to predict sampling)
-	emission := core.NewVec3(5.0, 5.0, 5.0)
-	emissive := material.NewEmissive(emission)
-	// Quad light at y=2, centered at (0,2,0), size 1x1 in XZ plane
-	light := lights.NewQuadLight(
-		core.NewVec3(-0.5, 2.0, -0.5), // corner
-		core.NewVec3(1.0, 0.0, 0.0),   // u vector (X direction)
-		core.NewVec3(0.0, 0.0, 1.0),   // v vector (Z direction)
-		emissive,
-	)
-
-	// Use a real camera from the renderer package
-	cameraConfig := geometry.CameraConfig{
-		Center:        core.NewVec3(0, 0, 0),
-		LookAt:        core.NewVec3(0, 0, -1),
-		Up:            core.NewVec3(0, 1, 0),
-		Width:         100,
-		AspectRatio:   1.0,
-		VFov:          45.0,
-		Aperture:      0.0,
-		FocusDistance: 0.0,
-	}
-	camera := geometry.NewCamera(cameraConfig)
-	ls := []lights.Light{light}
+	// One diffuse sphere lit by a 1x1 quad light facing down (easier to predict sampling)
+	return newMicroTestScene(`
+		sphere at 0 0 -1 radius 0.5
+		quadlight corner -0.5 2 -0.5 u 1 0 0 v 0 0 1 emit 5 5 5
+	`, nil)
+}
 
-	scene := &scene.Scene{
-		Shapes: []geometry.Shape{sphere, light.Quad},
-		Lights: ls, LightSampler: lights.NewUniformLightSampler(ls, 10),
-		Camera: camera, SamplingConfig: scene.SamplingConfig{MaxDepth: 5},
+// newMicroTestScene builds a micro scene (see scene.NewMicroScene) with the settings the BDPT tests
+// were written for: the camera at the origin looking down -Z, depth 5 without Russian roulette, and
+// uniform light sampling
+func newMicroTestScene(source string, materials map[string]material.Material) *scene.Scene {
+	s, err := scene.NewMicroScene("sampling depth 5 roulette 0\n"+source, materials)
+	if err != nil {
+		panic(err)
 	}
+	s.SamplingConfig = scene.SamplingConfig{MaxDepth: s.SamplingConfig.MaxDepth}
+	s.LightSampler = lights.NewUniformLightSampler(s.Lights, 10)
 
 	// Initialize BVH and preprocess lights
-	scene.Preprocess()
-	return scene
+	s.Preprocess()
+	return s
 }
 
 // Helper function to create a simple scene with specific lights
@@ -377,33 +360,11 @@ func createTestAreaLight() lights.Light {
 }
 
 func createGlancingTestSceneWithMaterial(mat material.Material) *scene.Scene {
-	// Create sphere with the specified material - positioned for camera ray hit
-	// Sphere is centered at (0, 0, -2) so camera at origin can hit it
-	sphere := geometry.NewSphere(core.NewVec3(0, 0, -2), 1.0, mat)
-
-	// Point light for simple lighting
-	pointLight := lights.NewPointSpotLight(
-		core.NewVec3(0, 3, -2), core.NewVec3(0, -1, 0),
-		core.NewVec3(3, 3, 3), 90.0, 1.0,
-	)
-
-	// Camera setup that matches createSimpleTestScene - at origin looking toward -Z
-	cameraConfig := geometry.CameraConfig{
-		Center: core.NewVec3(0, 0, 0), LookAt: core.NewVec3(0, 0, -1), Up: core.NewVec3(0, 1, 0),
-		Width: 100, AspectRatio: 1.0, VFov: 45.0,
-	}
-	camera := geometry.NewCamera(cameraConfig)
-	ls := []lights.Light{pointLight}
-
-	scene := &scene.Scene{
-		Shapes: []geometry.Shape{sphere},
-		Lights: ls, LightSampler: lights.NewUniformLightSampler(ls, 10),
-		Camera: camera, SamplingConfig: scene.SamplingConfig{MaxDepth: 5},
-	}
-
-	// Initialize BVH and preprocess lights
-	scene.Preprocess()
-	return scene
+	// The sphere is in front of the camera at the origin, lit by a point light above it
+	return newMicroTestScene(`
+		sphere at 0 0 -2 radius 1 material test
+		spotlight at 0 3 -2 look 0 2 -2 emit 3 3 3 angle 90 delta 1
+	`, map[string]material.Material{"test": mat})
 }
 
 func createGlancingTestSceneAndRay(mat material.Material) (*scene.Scene, core.Ray) {
@@ -595,6 +556,14 @@ func TestBDPTCameraPathHitsLight(t *testing.T) {
 			if !vertex.IsLight {
 				t.Errorf("Vertex %d hits light but IsLight=false", i)
 			}
+
+			// Assert: The light must be identified, otherwise the s=0 MIS weight has no origin PDF
+			if vertex.Light == nil || testScene.Lights[vertex.LightIndex] != vertex.Light {
+				t.Errorf("Vertex %d hits light but Light=%v, LightIndex=%d", i, vertex.Light, vertex.LightIndex)
+			}
+			if pdf := bdptIntegrator.calculateLightOriginPdf(&vertex, &cameraPath.Vertices[i-1], testScene); pdf <= 0 {
+				t.Errorf("Vertex %d: expected positive light origin PDF, got %f", i, pdf)
+			}
 			break
 		}
 	}
@@ -987,3 +956,74 @@ func createMinimalCornellScene(includeBoxes bool) *scene.Scene {
 
 	return scene
 }
+
+func TestBDPTRussianRouletteUnbiased(t *testing.T) {
+	// Aggressive roulette must shorten paths without changing the expected radiance
+	cornell := createMinimalCornellScene(false)
+	if err := cornell.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	ray := cornell.Camera.GetRay(200, 260, core.NewVec2(0.5, 0.5), core.NewVec2(0.5, 0.5))
+
+	render := func(config scene.SamplingConfig) (float64, float64) {
+		bdpt := NewBDPTIntegrator(config)
+		sampler := core.NewRandomSampler(rand.New(rand.NewSource(17)))
+		const numSamples = 4000
+
+		sum, vertices := 0.0, 0
+		for i := 0; i < numSamples; i++ {
+			color, _ := bdpt.RayColor(ray, cornell, sampler)
+			sum += color.Luminance()
+			vertices += bdpt.generateCameraPath(ray, cornell, sampler, config.MaxDepth).Length
+		}
+		return sum / numSamples, float64(vertices) / numSamples
+	}
+
+	reference, referenceLength := render(scene.SamplingConfig{MaxDepth: 8, RussianRouletteMinBounces: 100})
+	roulette, rouletteLength := render(scene.SamplingConfig{MaxDepth: 8, RussianRouletteMinBounces: 1, RussianRouletteMinProb: 0.2})
+
+	if rouletteLength >= referenceLength {
+		t.Errorf("Expected roulette to shorten camera paths: %.2f vs %.2f vertices", rouletteLength, referenceLength)
+	}
+	if math.Abs(roulette-reference) > 0.05*reference {
+		t.Errorf("Russian roulette biased the estimate: %f vs reference %f", roulette, reference)
+	}
+}
+
+// BenchmarkBDPT times the Cornell box's samples through one pixel in the middle, from fixed seeds:
+// generating the paths, combining their strategies, and both together
+func BenchmarkBDPT(b *testing.B) {
+	cornell := createMinimalCornellScene(true)
+	bdpt := NewBDPTIntegrator(scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 3, RussianRouletteMinProb: 0.1})
+	ray := cornell.Camera.GetRay(200, 200, core.NewVec2(0.5, 0.5), core.NewVec2(0.5, 0.5))
+
+	b.Run("Paths", func(b *testing.B) {
+		sampler := core.NewSeededSampler(1)
+		for n := 0; n < b.N; n++ {
+			bdpt.generateCameraPath(ray, cornell, sampler, bdpt.Config.MaxDepth)
+			bdpt.generateLightPath(cornell, sampler, bdpt.Config.MaxDepth)
+		}
+	})
+	b.Run("Strategies", func(b *testing.B) {
+		type pathPair struct{ camera, light Path }
+		sampler := core.NewSeededSampler(2)
+		pairs := make([]pathPair, 64)
+		for i := range pairs {
+			pairs[i] = pathPair{
+				camera: bdpt.generateCameraPath(ray, cornell, sampler, bdpt.Config.MaxDepth),
+				light:  bdpt.generateLightPath(cornell, sampler, bdpt.Config.MaxDepth),
+			}
+		}
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			pair := pairs[n%len(pairs)]
+			bdpt.combineStrategies(pair.camera, pair.light, cornell, sampler, nil, nil)
+		}
+	})
+	b.Run("Sample", func(b *testing.B) {
+		sampler := core.NewSeededSampler(3)
+		for n := 0; n < b.N; n++ {
+			bdpt.RayColor(ray, cornell, sampler)
+		}
+	})
+}
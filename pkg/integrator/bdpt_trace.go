@@ -0,0 +1,132 @@
+package integrator
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+// BDPTTracer writes a machine-readable trace of BDPT's strategy evaluations: one JSON line per
+// strategy with a nonzero contribution, for the camera samples of the pixels in Region. Tooling can
+// group the lines by sample and strategy to check, for example, that MIS weights sum to one.
+// A tracer is safe for concurrent use; a sample's lines are written together.
+type BDPTTracer struct {
+	Region image.Rectangle // Pixels to trace; empty traces every sample, even rays that aren't from the camera
+
+	mu      sync.Mutex
+	enc     *json.Encoder
+	samples int
+	err     error
+}
+
+// StrategyTrace is one line of a BDPT trace
+type StrategyTrace struct {
+	Sample       int              `json:"sample"` // Camera sample number, in the order samples were traced (the pixel's, in a renderer.SampleTrace)
+	X            int              `json:"x"`      // Pixel of the camera ray (-1 if it doesn't map to one)
+	Y            int              `json:"y"`
+	S            int              `json:"s"`
+	T            int              `json:"t"`
+	Contribution [3]float64       `json:"contribution"` // Unweighted radiance (summed over splats for t=1)
+	MISWeight    float64          `json:"misWeight"`
+	Weighted     [3]float64       `json:"weighted"` // Contribution times the MIS weight
+	Splats       int              `json:"splats"`   // Splat rays (t=1 strategies land on other pixels)
+	Vertices     []MISVertexTrace `json:"vertices"` // The MIS weight's terms, in evaluation order
+}
+
+// MISVertexTrace is one vertex of the MIS weight calculation: the densities of generating it from
+// either direction with this strategy's connection in place, and the running ratio of the
+// alternative strategy's density to this one's
+type MISVertexTrace struct {
+	Path        string  `json:"path"` // "camera" or "light"
+	Index       int     `json:"index"`
+	PdfForward  float64 `json:"pdfForward"`
+	PdfReverse  float64 `json:"pdfReverse"`
+	Connectible bool    `json:"connectible"`
+	Ratio       float64 `json:"ratio"`
+}
+
+// NewBDPTTracer creates a tracer writing JSON lines to w for the pixels in region
+func NewBDPTTracer(w io.Writer, region image.Rectangle) *BDPTTracer {
+	return &BDPTTracer{Region: region, enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the trace
+func (tr *BDPTTracer) Err() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.err
+}
+
+// sampleTrace collects the strategy lines of one camera sample
+type sampleTrace struct {
+	x, y       int
+	strategies []StrategyTrace
+}
+
+// begin starts tracing a camera sample, returning nil if its pixel is outside the region
+func (tr *BDPTTracer) begin(ray core.Ray, camera *geometry.Camera) *sampleTrace {
+	st := newSampleTrace(ray, camera)
+	if !tr.Region.Empty() && !image.Pt(st.x, st.y).In(tr.Region) {
+		return nil
+	}
+	return st
+}
+
+// newSampleTrace starts tracing a sample with the pixel its ray maps to
+func newSampleTrace(ray core.Ray, camera *geometry.Camera) *sampleTrace {
+	x, y := -1, -1
+	if camera != nil {
+		if px, py, ok := camera.MapRayToPixel(ray); ok {
+			x, y = px, py
+		}
+	}
+	return &sampleTrace{x: x, y: y}
+}
+
+// addStrategy starts the line for strategy (s, t), returning it for the MIS weight terms
+func (st *sampleTrace) addStrategy(s, t int, light core.Vec3, splats []SplatRay) *StrategyTrace {
+	for _, splat := range splats {
+		light = light.Add(splat.Color)
+	}
+	st.strategies = append(st.strategies, StrategyTrace{
+		X: st.x, Y: st.y, S: s, T: t,
+		Contribution: [3]float64{light.X, light.Y, light.Z},
+		Splats:       len(splats),
+	})
+	return &st.strategies[len(st.strategies)-1]
+}
+
+// setWeight completes a strategy's line with its MIS weight
+func (trace *StrategyTrace) setWeight(misWeight float64) {
+	trace.MISWeight = misWeight
+	for i, c := range trace.Contribution {
+		trace.Weighted[i] = c * misWeight
+	}
+}
+
+// addVertex records one term of the MIS weight calculation; safe to call on a nil trace
+func (trace *StrategyTrace) addVertex(path string, index int, pdfForward, pdfReverse float64, connectible bool, ratio float64) {
+	if trace == nil {
+		return
+	}
+	trace.Vertices = append(trace.Vertices, MISVertexTrace{
+		Path: path, Index: index, PdfForward: pdfForward, PdfReverse: pdfReverse, Connectible: connectible, Ratio: ratio,
+	})
+}
+
+// write numbers the sample and writes its lines
+func (tr *BDPTTracer) write(st *sampleTrace) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.samples++
+	for i := range st.strategies {
+		st.strategies[i].Sample = tr.samples
+		if err := tr.enc.Encode(&st.strategies[i]); err != nil && tr.err == nil {
+			tr.err = err
+		}
+	}
+}
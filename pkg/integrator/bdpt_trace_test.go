@@ -0,0 +1,87 @@
+package integrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestBDPTTracerWritesStrategies(t *testing.T) {
+	testScene := createSimpleTestScene()
+	var buf bytes.Buffer
+	bdpt := NewBDPTIntegrator(testScene.SamplingConfig)
+	bdpt.Tracer = NewBDPTTracer(&buf, image.Rect(50, 50, 51, 51))
+
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	const samples = 20
+	for i := 0; i < samples; i++ {
+		ray := testScene.Camera.GetRay(50, 50, sampler.Get2D(), sampler.Get2D())
+		bdpt.RayColor(ray, testScene, sampler)
+	}
+	if err := bdpt.Tracer.Err(); err != nil {
+		t.Fatalf("Trace failed: %v", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	lines := 0
+	sawConnection := false
+	for dec.More() {
+		var line StrategyTrace
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("Line %d is not a strategy trace: %v", lines+1, err)
+		}
+		lines++
+
+		if line.X != 50 || line.Y != 50 {
+			t.Errorf("Line %d traced pixel (%d,%d), expected (50,50)", lines, line.X, line.Y)
+		}
+		if line.Sample < 1 || line.Sample > samples {
+			t.Errorf("Line %d has sample %d, expected 1-%d", lines, line.Sample, samples)
+		}
+		if line.MISWeight < 0 || line.MISWeight > 1+1e-9 {
+			t.Errorf("(s=%d,t=%d) MIS weight %v outside [0,1]", line.S, line.T, line.MISWeight)
+		}
+		for i := range line.Weighted {
+			if math.Abs(line.Weighted[i]-line.Contribution[i]*line.MISWeight) > 1e-9 {
+				t.Errorf("(s=%d,t=%d) weighted %v != contribution %v * weight %v", line.S, line.T, line.Weighted, line.Contribution, line.MISWeight)
+				break
+			}
+		}
+		// Every vertex but the camera's is a term of the weight
+		if want := line.S + line.T - 1; len(line.Vertices) != want {
+			t.Errorf("(s=%d,t=%d) traced %d MIS vertices, expected %d", line.S, line.T, len(line.Vertices), want)
+		}
+		if line.S >= 1 && line.T >= 2 {
+			sawConnection = true
+		}
+	}
+
+	if lines == 0 {
+		t.Fatal("Expected the sphere pixel to trace some strategies")
+	}
+	if !sawConnection {
+		t.Error("Expected light sampling (s>=1, t>=2) strategies in the trace")
+	}
+}
+
+func TestBDPTTracerRegionFilter(t *testing.T) {
+	testScene := createSimpleTestScene()
+	var buf bytes.Buffer
+	bdpt := NewBDPTIntegrator(testScene.SamplingConfig)
+	bdpt.Tracer = NewBDPTTracer(&buf, image.Rect(0, 0, 10, 10))
+
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	for i := 0; i < 10; i++ {
+		ray := testScene.Camera.GetRay(50, 50, sampler.Get2D(), sampler.Get2D())
+		bdpt.RayColor(ray, testScene, sampler)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no trace for a pixel outside the region, got %q", buf.String())
+	}
+}
@@ -0,0 +1,126 @@
+package integrator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Debug shading modes for DebugIntegrator. Each shades the first surface a camera ray hits, and
+// rays that hit nothing are black.
+const (
+	DebugShadingNormal   = "normal"   // Outward shading normal as color, (n+1)/2: flipped mesh normals show on back faces
+	DebugShadingDepth    = "depth"    // Distance from the camera, white at the near side of the scene and black at the far side
+	DebugShadingUV       = "uv"       // Texture coordinates as red and green, repeating outside [0, 1)
+	DebugShadingMaterial = "material" // A distinct color for each material
+)
+
+// DebugShadingModes lists the debug shading modes in the order they're documented
+var DebugShadingModes = []string{DebugShadingNormal, DebugShadingDepth, DebugShadingUV, DebugShadingMaterial}
+
+// DebugIntegrator shades surfaces by a geometric property instead of by light, for diagnosing
+// scenes: flipped normals on imported meshes, UV layouts, which material went where.
+type DebugIntegrator struct {
+	mode string
+
+	materialsOnce sync.Once
+	materials     map[material.Material]int // Material IDs, in the order they appear scanning the image
+}
+
+// NewDebugIntegrator creates a debug integrator with one of DebugShadingModes
+func NewDebugIntegrator(mode string) (*DebugIntegrator, error) {
+	if !slices.Contains(DebugShadingModes, mode) {
+		return nil, fmt.Errorf("unknown debug shading %q (expected %s)", mode, strings.Join(DebugShadingModes, ", "))
+	}
+	return &DebugIntegrator{mode: mode}, nil
+}
+
+// SceneEdited implements SceneCacher: materials are numbered again for the edited scene
+func (d *DebugIntegrator) SceneEdited() {
+	d.materials = nil
+	d.materialsOnce = sync.Once{}
+}
+
+// RayColor shades the first surface a ray hits by the integrator's mode
+func (d *DebugIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay) {
+	if d.mode == DebugShadingMaterial {
+		d.materialsOnce.Do(func() { d.numberMaterials(scene) })
+	}
+	hit, isHit := geometry.HitVisible(scene.Intersector, ray, 0.001, math.Inf(1), material.CameraRays)
+	if !isHit {
+		core.CountPathLength(0)
+		return core.Vec3{X: 0, Y: 0, Z: 0}, nil
+	}
+	core.CountPathLength(1)
+
+	switch d.mode {
+	case DebugShadingNormal:
+		normal := hit.Normal
+		if !hit.FrontFace {
+			normal = normal.Multiply(-1)
+		}
+		return normal.Add(core.Vec3{X: 1, Y: 1, Z: 1}).Multiply(0.5), nil
+	case DebugShadingDepth:
+		// The scene's bounding sphere spans the depths seen from the ray's origin
+		distance := hit.Point.Subtract(ray.Origin).Length()
+		toCenter := scene.WorldCenter.Subtract(ray.Origin).Length()
+		near := math.Max(0, toCenter-scene.WorldRadius)
+		far := toCenter + scene.WorldRadius
+		shade := 1 - math.Max(0, math.Min(1, (distance-near)/(far-near)))
+		return core.Vec3{X: shade, Y: shade, Z: shade}, nil
+	case DebugShadingUV:
+		return core.Vec3{X: hit.UV.X - math.Floor(hit.UV.X), Y: hit.UV.Y - math.Floor(hit.UV.Y), Z: 0}, nil
+	}
+	id, ok := d.materials[hit.Material]
+	if !ok {
+		return core.Vec3{X: 0.5, Y: 0.5, Z: 0.5}, nil // Not found scanning the image
+	}
+	return IDColor(id), nil
+}
+
+// numberMaterials numbers the materials seen at the center of each pixel, row by row, so that
+// each keeps its color however many workers render the image. Materials that can't be map keys
+// aren't numbered.
+func (d *DebugIntegrator) numberMaterials(s *scene.Scene) {
+	d.materials = make(map[material.Material]int)
+	center := core.NewVec2(0.5, 0.5)
+	for y := 0; y < s.SamplingConfig.Height; y++ {
+		for x := 0; x < s.SamplingConfig.Width; x++ {
+			hit, isHit := geometry.HitVisible(s.Intersector, s.Camera.GetRay(x, y, center, center), 0.001, math.Inf(1), material.CameraRays)
+			if !isHit || hit.Material == nil || !reflect.TypeOf(hit.Material).Comparable() {
+				continue
+			}
+			if _, seen := d.materials[hit.Material]; !seen {
+				d.materials[hit.Material] = len(d.materials)
+			}
+		}
+	}
+}
+
+// IDColor returns a saturated color for an ID, with hues spread by the golden ratio so that
+// consecutive IDs differ clearly
+func IDColor(id int) core.Vec3 {
+	hue := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	x := 1 - math.Abs(math.Mod(hue, 2)-1)
+	switch int(hue) {
+	case 0:
+		return core.Vec3{X: 1, Y: x, Z: 0}
+	case 1:
+		return core.Vec3{X: x, Y: 1, Z: 0}
+	case 2:
+		return core.Vec3{X: 0, Y: 1, Z: x}
+	case 3:
+		return core.Vec3{X: 0, Y: x, Z: 1}
+	case 4:
+		return core.Vec3{X: x, Y: 0, Z: 1}
+	}
+	return core.Vec3{X: 1, Y: 0, Z: x}
+}
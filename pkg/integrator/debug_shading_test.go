@@ -0,0 +1,91 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// createDebugScene creates two spheres of different materials side by side, seen from the origin
+// looking down -z
+func createDebugScene() *scene.Scene {
+	camera := geometry.NewCamera(geometry.CameraConfig{
+		Center: core.NewVec3(0, 0, 0),
+		LookAt: core.NewVec3(0, 0, -3),
+		Up:     core.NewVec3(0, 1, 0),
+		Width:  16, AspectRatio: 1, VFov: 40,
+	})
+	s := &scene.Scene{
+		Shapes: []geometry.Shape{
+			geometry.NewSphere(core.NewVec3(-0.5, 0, -3), 0.5, material.NewLambertian(core.NewVec3(1, 0, 0))),
+			geometry.NewSphere(core.NewVec3(0.5, 0, -3), 0.5, material.NewMetal(core.NewVec3(1, 1, 1), 0)),
+		},
+		Camera:         camera,
+		SamplingConfig: scene.SamplingConfig{Width: 16, Height: 16, MaxDepth: 8},
+	}
+	s.Preprocess()
+	return s
+}
+
+func debugColor(t *testing.T, mode string, s *scene.Scene, ray core.Ray) core.Vec3 {
+	t.Helper()
+	debug, err := NewDebugIntegrator(mode)
+	if err != nil {
+		t.Fatalf("NewDebugIntegrator failed: %v", err)
+	}
+	color, _ := debug.RayColor(ray, s, core.NewSeededSampler(1))
+	return color
+}
+
+func TestDebugIntegrator_Normal(t *testing.T) {
+	s := createDebugScene()
+
+	// Facing the camera, the sphere's normal is +z
+	front := debugColor(t, DebugShadingNormal, s, core.NewRay(core.NewVec3(-0.5, 0, 0), core.NewVec3(0, 0, -1)))
+	if math.Abs(front.X-0.5) > 1e-6 || math.Abs(front.Z-1) > 1e-6 {
+		t.Errorf("Expected +z as (0.5, 0.5, 1), got %v", front)
+	}
+	// From inside, the outward normal is shown rather than the one facing the ray
+	inside := debugColor(t, DebugShadingNormal, s, core.NewRay(core.NewVec3(-0.5, 0, -3), core.NewVec3(0, 0, -1)))
+	if math.Abs(inside.Z) > 1e-6 {
+		t.Errorf("Expected the outward normal -z as (0.5, 0.5, 0) from inside, got %v", inside)
+	}
+	if miss := debugColor(t, DebugShadingNormal, s, core.NewRay(core.Vec3{}, core.NewVec3(0, 1, 0))); miss != (core.Vec3{}) {
+		t.Errorf("Expected rays that miss to be black, got %v", miss)
+	}
+}
+
+func TestDebugIntegrator_DepthAndUV(t *testing.T) {
+	s := createDebugScene()
+	center := debugColor(t, DebugShadingDepth, s, core.NewRay(core.NewVec3(-0.5, 0, 0), core.NewVec3(0, 0, -1)))
+	edge := debugColor(t, DebugShadingDepth, s, core.NewRay(core.NewVec3(-0.95, 0, 0), core.NewVec3(0, 0, -1)))
+	if !(center.X > edge.X && edge.X > 0 && center.X < 1) {
+		t.Errorf("Expected the nearer sphere center brighter than its edge, got %v and %v", center, edge)
+	}
+
+	uv := debugColor(t, DebugShadingUV, s, core.NewRay(core.NewVec3(-0.5, 0, 0), core.NewVec3(0, 0, -1)))
+	if uv.X < 0 || uv.X >= 1 || uv.Y < 0 || uv.Y >= 1 || uv.Z != 0 {
+		t.Errorf("Expected UV as red and green in [0, 1), got %v", uv)
+	}
+}
+
+func TestDebugIntegrator_Material(t *testing.T) {
+	s := createDebugScene()
+	left := debugColor(t, DebugShadingMaterial, s, core.NewRay(core.Vec3{}, core.NewVec3(-0.5, 0, -3)))
+	right := debugColor(t, DebugShadingMaterial, s, core.NewRay(core.Vec3{}, core.NewVec3(0.5, 0, -3)))
+	if left == right {
+		t.Errorf("Expected different colors for different materials, got %v for both", left)
+	}
+	// Materials are numbered scanning the image, so each keeps its color from render to render
+	if again := debugColor(t, DebugShadingMaterial, s, core.NewRay(core.Vec3{}, core.NewVec3(-0.5, 0, -3))); again != left {
+		t.Errorf("Expected the same color for a material in every render, got %v and %v", left, again)
+	}
+
+	if _, err := NewDebugIntegrator("wireframe"); err == nil {
+		t.Error("Expected an error for an unknown shading mode")
+	}
+}
@@ -0,0 +1,335 @@
+package integrator
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+const (
+	guideFraction       = 0.5     // Probability of sampling the guide rather than the material at a guided vertex
+	guideSpatialRecords = 4000    // Records a spatial leaf takes before it is split in two
+	guideMaxSpatialHops = 24      // Depth limit of the spatial tree
+	guideDirSplit       = 0.01    // Share of a leaf's light a quadrant holds before it is subdivided
+	guideMaxDirDepth    = 20      // Depth limit of the directional quadtrees
+	guideFixedPoint     = 1 << 16 // Fixed point scale of recorded light, so sums don't depend on the order of records
+	guideMaxRecord      = 1 << 32 // Cap of a record in fixed point units, bounding a record of a tiny PDF
+)
+
+// pathGuide learns where light arrives from during the first passes of a render and importance
+// samples scatter directions with it in the passes after (practical path guiding, Müller et al.
+// 2017). It is an SD-tree: a binary tree over the scene's bounds whose leaves each hold a
+// quadtree over the sphere of directions, refined where light is recorded.
+//
+// Each pass records the light the path tracer finds into a training tree while sampling with the
+// tree the previous pass trained. When the pass ends, the training tree becomes the sampling tree
+// and a finer copy of it, empty, is trained next; after the configured number of passes training
+// stops and the last tree is kept. Records are summed in fixed point, so the trees, and hence the
+// image, are the same for a seed whatever the number of workers.
+type pathGuide struct {
+	passes  int // Passes left to train
+	once    sync.Once
+	sampler *sdTree // Trained in earlier passes, nil until the first pass ends
+	trainer *sdTree // Training in this pass, nil once training is over
+}
+
+// newPathGuide creates a guide that trains over the given number of passes
+func newPathGuide(passes int) *pathGuide {
+	return &pathGuide{passes: passes}
+}
+
+// init creates the first training tree over the scene's bounds
+func (g *pathGuide) init(scene *scene.Scene) {
+	g.once.Do(func() {
+		g.trainer = newSDTree(scene.Intersector.BoundingBox())
+	})
+}
+
+// lookup returns the directional distribution to sample at a point, or nil if none was learned
+func (g *pathGuide) lookup(point core.Vec3) *dirTree {
+	if g == nil || g.sampler == nil {
+		return nil
+	}
+	dir := g.sampler.leaf(point).dir
+	if dir.nodes[0].total() <= 0 {
+		return nil
+	}
+	return dir
+}
+
+// record adds light arriving at a point from a direction, divided by the density it was sampled
+// with, to the training tree
+func (g *pathGuide) record(point, direction core.Vec3, light float64) {
+	if g == nil || g.trainer == nil || !(light > 0) || math.IsInf(light, 0) {
+		return
+	}
+	node := g.trainer.leaf(point)
+	atomic.AddUint64(&node.records, 1)
+	node.dir.record(direction, uint64(math.Min(light*guideFixedPoint, guideMaxRecord)))
+}
+
+// endPass makes what this pass learned the sampling distribution and starts training a finer tree
+func (g *pathGuide) endPass() {
+	if g.trainer == nil {
+		return
+	}
+	g.sampler = g.trainer
+	g.passes--
+	if g.passes <= 0 {
+		g.trainer = nil
+		return
+	}
+	g.trainer = g.sampler.refined()
+}
+
+// sdTree is the spatial binary tree of a guide. Nodes split their box in half along an axis that
+// cycles with depth; leaves hold a directional quadtree.
+type sdTree struct {
+	bounds geometry.AABB
+	nodes  []sdNode
+}
+
+// sdNode is a node of an sdTree
+type sdNode struct {
+	axis    int      // Axis the node's box splits on
+	child   int      // Index of the first of the two children, 0 for a leaf
+	dir     *dirTree // Light arriving in the leaf's box
+	records uint64   // Records the leaf took, updated atomically while training
+}
+
+// newSDTree creates a tree of one leaf over the bounds
+func newSDTree(bounds geometry.AABB) *sdTree {
+	return &sdTree{bounds: bounds, nodes: []sdNode{{axis: bounds.LongestAxis(), dir: newDirTree()}}}
+}
+
+// leaf returns the leaf whose box contains the point; points outside the bounds use the nearest
+func (t *sdTree) leaf(point core.Vec3) *sdNode {
+	lo := [3]float64{t.bounds.Min.X, t.bounds.Min.Y, t.bounds.Min.Z}
+	hi := [3]float64{t.bounds.Max.X, t.bounds.Max.Y, t.bounds.Max.Z}
+	p := [3]float64{point.X, point.Y, point.Z}
+	node := &t.nodes[0]
+	for node.child != 0 {
+		mid := (lo[node.axis] + hi[node.axis]) / 2
+		if p[node.axis] < mid {
+			hi[node.axis] = mid
+			node = &t.nodes[node.child]
+		} else {
+			lo[node.axis] = mid
+			node = &t.nodes[node.child+1]
+		}
+	}
+	return node
+}
+
+// refined returns an empty tree for the next pass to train: leaves that took many records are
+// split, as often as their records allow if they were spread evenly, and every quadtree is refined
+// where it recorded much of its light
+func (t *sdTree) refined() *sdTree {
+	next := &sdTree{bounds: t.bounds, nodes: []sdNode{{}}}
+	next.copyNode(t, 0, 0, 0)
+	return next
+}
+
+// copyNode fills node i of t from node j of old, at the given depth
+func (t *sdTree) copyNode(old *sdTree, i, j, depth int) {
+	from := old.nodes[j]
+	t.nodes[i].axis = from.axis
+	if from.child != 0 {
+		child := t.split(i)
+		t.nodes[i].child = child
+		t.copyNode(old, child, from.child, depth+1)
+		t.copyNode(old, child+1, from.child+1, depth+1)
+		return
+	}
+	t.splitLeaf(i, from.dir.refined(), from.records, depth)
+}
+
+// splitLeaf makes node i a leaf of the directional tree dir, or splits it until the records it
+// took would each be under guideSpatialRecords
+func (t *sdTree) splitLeaf(i int, dir *dirTree, records uint64, depth int) {
+	if records <= guideSpatialRecords || depth >= guideMaxSpatialHops {
+		t.nodes[i].dir = dir
+		return
+	}
+	child := t.split(i)
+	t.nodes[i].child = child
+	t.splitLeaf(child, dir, records/2, depth+1)
+	t.splitLeaf(child+1, dir.clone(), records/2, depth+1)
+}
+
+// split appends the two children of node i, which split on the next axis, and returns the first
+func (t *sdTree) split(i int) int {
+	axis := (t.nodes[i].axis + 1) % 3
+	t.nodes = append(t.nodes, sdNode{axis: axis}, sdNode{axis: axis})
+	return len(t.nodes) - 2
+}
+
+// dirTree is a quadtree over the sphere of directions, mapped to the unit square by cylindrical
+// coordinates (cos θ, φ), which preserve area: a density over the square is 4π times the density
+// over the sphere. Each node holds the light of its four quadrants.
+type dirTree struct {
+	nodes []dirNode
+}
+
+// dirNode is a node of a dirTree. Quadrant q covers the half x = q%2 and y = q/2 of the node.
+type dirNode struct {
+	child  [4]int    // Index of each quadrant's node, 0 for a leaf quadrant
+	energy [4]uint64 // Light recorded in each quadrant, in fixed point, updated atomically
+}
+
+// total returns the light recorded in the node
+func (n *dirNode) total() float64 {
+	return float64(n.energy[0]) + float64(n.energy[1]) + float64(n.energy[2]) + float64(n.energy[3])
+}
+
+// newDirTree creates a quadtree of a single node
+func newDirTree() *dirTree {
+	return &dirTree{nodes: []dirNode{{}}}
+}
+
+// clone returns a copy of the tree
+func (t *dirTree) clone() *dirTree {
+	return &dirTree{nodes: append([]dirNode(nil), t.nodes...)}
+}
+
+// record adds light arriving from a direction to every node containing it
+func (t *dirTree) record(direction core.Vec3, light uint64) {
+	p := directionToSquare(direction)
+	i := 0
+	for {
+		q := quadrant(&p)
+		atomic.AddUint64(&t.nodes[i].energy[q], light)
+		if t.nodes[i].child[q] == 0 {
+			return
+		}
+		i = t.nodes[i].child[q]
+	}
+}
+
+// refined returns an empty tree with the structure of this one, except that quadrants holding
+// more than guideDirSplit of the light are subdivided and those holding less are merged
+func (t *dirTree) refined() *dirTree {
+	next := newDirTree()
+	if total := t.nodes[0].total(); total > 0 {
+		next.refineNode(t, 0, 0, total, 1)
+	}
+	return next
+}
+
+// refineNode builds the quadrants of node i of t from node j of old, at the given depth
+func (t *dirTree) refineNode(old *dirTree, i, j int, total float64, depth int) {
+	for q := 0; q < 4; q++ {
+		if float64(old.nodes[j].energy[q])/total <= guideDirSplit || depth >= guideMaxDirDepth {
+			continue
+		}
+		t.nodes = append(t.nodes, dirNode{})
+		child := len(t.nodes) - 1
+		t.nodes[i].child[q] = child
+		if old.nodes[j].child[q] != 0 {
+			t.refineNode(old, child, old.nodes[j].child[q], total, depth+1)
+		}
+	}
+}
+
+// sample returns a direction sampled in proportion to the recorded light, and its density
+func (t *dirTree) sample(u core.Vec2) (core.Vec3, float64) {
+	var origin core.Vec2
+	size, pdf := 1.0, 1.0
+	i := 0
+	for {
+		n := &t.nodes[i]
+		total := n.total()
+		if total <= 0 {
+			break
+		}
+		// Choose the bottom or top half, then the quadrant within it, reusing the random numbers
+		bottom := (float64(n.energy[0]) + float64(n.energy[1])) / total
+		y := 0
+		if u.Y < bottom {
+			u.Y /= bottom
+		} else {
+			y, u.Y = 1, (u.Y-bottom)/(1-bottom)
+		}
+		row := float64(n.energy[2*y]) + float64(n.energy[2*y+1])
+		left := float64(n.energy[2*y]) / row
+		x := 0
+		if u.X < left {
+			u.X /= left
+		} else {
+			x, u.X = 1, (u.X-left)/(1-left)
+		}
+		q := x + 2*y
+		pdf *= 4 * float64(n.energy[q]) / total
+		size /= 2
+		origin = core.NewVec2(origin.X+float64(x)*size, origin.Y+float64(y)*size)
+		if n.child[q] == 0 {
+			break
+		}
+		i = n.child[q]
+	}
+	return squareToDirection(core.NewVec2(origin.X+u.X*size, origin.Y+u.Y*size)), pdf / (4 * math.Pi)
+}
+
+// pdf returns the density of sampling a direction
+func (t *dirTree) pdf(direction core.Vec3) float64 {
+	p := directionToSquare(direction)
+	pdf := 1.0
+	i := 0
+	for {
+		n := &t.nodes[i]
+		total := n.total()
+		if total <= 0 {
+			break
+		}
+		q := quadrant(&p)
+		pdf *= 4 * float64(n.energy[q]) / total
+		if pdf == 0 || n.child[q] == 0 {
+			break
+		}
+		i = n.child[q]
+	}
+	return pdf / (4 * math.Pi)
+}
+
+// quadrant returns the quadrant of a node containing p, and maps p into the quadrant's square
+func quadrant(p *core.Vec2) int {
+	q := 0
+	if p.X >= 0.5 {
+		q, p.X = 1, p.X-0.5
+	}
+	if p.Y >= 0.5 {
+		q, p.Y = q+2, p.Y-0.5
+	}
+	p.X, p.Y = math.Min(2*p.X, math.Nextafter(1, 0)), math.Min(2*p.Y, math.Nextafter(1, 0))
+	return q
+}
+
+// directionToSquare maps a unit direction to the unit square by cylindrical coordinates
+func directionToSquare(d core.Vec3) core.Vec2 {
+	phi := math.Atan2(d.Y, d.X)
+	if phi < 0 {
+		phi += 2 * math.Pi
+	}
+	return core.NewVec2(math.Max(0, math.Min(1, (d.Z+1)/2)), math.Min(phi/(2*math.Pi), math.Nextafter(1, 0)))
+}
+
+// squareToDirection maps a point of the unit square to a unit direction (see directionToSquare)
+func squareToDirection(p core.Vec2) core.Vec3 {
+	cosTheta := 2*p.X - 1
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	phi := 2 * math.Pi * p.Y
+	return core.NewVec3(sinTheta*math.Cos(phi), sinTheta*math.Sin(phi), cosTheta)
+}
+
+// guidedPDF returns the density of sampling a direction at a guided vertex, where the guide is
+// sampled with probability guideFraction and the material otherwise, given the material's density
+func guidedPDF(materialPDF float64, guide *dirTree, direction core.Vec3) float64 {
+	if guide == nil {
+		return materialPDF
+	}
+	return guideFraction*guide.pdf(direction) + (1-guideFraction)*materialPDF
+}
@@ -0,0 +1,120 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+// trainedDirTree returns a quadtree refined over a few rounds of light arriving mostly from a
+// cone around +z, with a dimmer uniform background
+func trainedDirTree() *dirTree {
+	tree := newDirTree()
+	sampler := core.NewSeededSampler(3)
+	for round := 0; round < 4; round++ {
+		for i := 0; i < 20000; i++ {
+			direction := squareToDirection(sampler.Get2D())
+			light := 0.1
+			if direction.Z > 0.9 {
+				light = 10
+			}
+			tree.record(direction, uint64(light*guideFixedPoint))
+		}
+		if round < 3 {
+			tree = tree.refined()
+		}
+	}
+	return tree
+}
+
+func TestDirectionSquareMapping(t *testing.T) {
+	sampler := core.NewSeededSampler(1)
+	for i := 0; i < 1000; i++ {
+		p := sampler.Get2D()
+		direction := squareToDirection(p)
+		if math.Abs(direction.Length()-1) > 1e-9 {
+			t.Fatalf("Expected a unit direction for %v, got %v", p, direction)
+		}
+		if back := directionToSquare(direction); math.Abs(back.X-p.X) > 1e-9 || math.Abs(back.Y-p.Y) > 1e-9 {
+			t.Fatalf("Expected %v to map back to itself, got %v", p, back)
+		}
+	}
+}
+
+func TestDirTree_LearnsLight(t *testing.T) {
+	tree := trainedDirTree()
+	if len(tree.nodes) < 5 {
+		t.Errorf("Expected the quadtree to be refined where light arrives, got %d nodes", len(tree.nodes))
+	}
+
+	// The cone holds 5% of the sphere but most of the light, so most samples land in it, though the
+	// quadtree's cells don't follow its edge exactly
+	sampler := core.NewSeededSampler(5)
+	inCone := 0
+	const samples = 10000
+	for i := 0; i < samples; i++ {
+		if direction, _ := tree.sample(sampler.Get2D()); direction.Z > 0.9 {
+			inCone++
+		}
+	}
+	if share := float64(inCone) / samples; share < 0.5 {
+		t.Errorf("Expected most samples in the bright cone, got %f", share)
+	}
+}
+
+func TestDirTree_PDFMatchesSampling(t *testing.T) {
+	tree := trainedDirTree()
+
+	// Sampled directions report the density pdf returns, and the density integrates to 1
+	sampler := core.NewSeededSampler(7)
+	for i := 0; i < 1000; i++ {
+		direction, pdf := tree.sample(sampler.Get2D())
+		if expected := tree.pdf(direction); math.Abs(pdf-expected) > 1e-9*expected {
+			t.Fatalf("Sampled density %g differs from pdf %g for %v", pdf, expected, direction)
+		}
+	}
+	const samples = 200000
+	integral := 0.0
+	for i := 0; i < samples; i++ {
+		integral += tree.pdf(squareToDirection(sampler.Get2D())) * 4 * math.Pi
+	}
+	if integral /= samples; math.Abs(integral-1) > 0.02 {
+		t.Errorf("Expected the density to integrate to 1 over the sphere, got %f", integral)
+	}
+}
+
+func TestSDTree_SplitsWhereRecorded(t *testing.T) {
+	bounds := geometry.NewAABB(core.NewVec3(0, 0, 0), core.NewVec3(8, 1, 1))
+	guide := newPathGuide(2)
+	guide.trainer = newSDTree(bounds)
+
+	// Light recorded only near x = 1: leaves there are split, far ones aren't
+	sampler := core.NewSeededSampler(9)
+	for i := 0; i < 10*guideSpatialRecords; i++ {
+		point := core.NewVec3(1+0.5*sampler.Get1D(), sampler.Get1D(), sampler.Get1D())
+		guide.record(point, core.NewVec3(0, 1, 0), 1)
+	}
+	guide.endPass()
+
+	if guide.lookup(core.NewVec3(1.2, 0.5, 0.5)) == nil {
+		t.Fatal("Expected a guide where light was recorded")
+	}
+	trainer := guide.trainer
+	near := trainer.leaf(core.NewVec3(1.2, 0.5, 0.5))
+	far := trainer.leaf(core.NewVec3(7, 0.5, 0.5))
+	if near == far {
+		t.Error("Expected the tree to split where light was recorded")
+	}
+	if len(trainer.nodes) > 64 {
+		t.Errorf("Expected splits only where light was recorded, got %d nodes", len(trainer.nodes))
+	}
+
+	// Training stops after the configured passes
+	guide.endPass()
+	if guide.trainer != nil {
+		t.Error("Expected training to stop after two passes")
+	}
+	guide.record(core.NewVec3(1, 0.5, 0.5), core.NewVec3(0, 1, 0), 1) // Ignored
+}
@@ -0,0 +1,120 @@
+package integrator
+
+import (
+	"fmt"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// SampleInspector is implemented by integrators that can record one sample's paths in full, for
+// debugging a single pixel such as a firefly. InspectSample draws from the sampler exactly as
+// RayColor does, so it traces the same paths RayColor would have.
+type SampleInspector interface {
+	Integrator
+	InspectSample(ray core.Ray, scene *scene.Scene, sampler core.Sampler) *SampleInspection
+}
+
+// SampleInspection is the record of one sample: the paths it traced and how they were combined
+type SampleInspection struct {
+	Color      [3]float64        `json:"color"`                // The sample's estimate for its pixel, splats aside
+	CameraPath []PathVertexTrace `json:"cameraPath"`           // Starting with the camera
+	LightPath  []PathVertexTrace `json:"lightPath,omitempty"`  // BDPT only, starting on the light
+	Strategies []StrategyTrace   `json:"strategies,omitempty"` // BDPT only: the strategies with a contribution, and their MIS weights
+}
+
+// PathVertexTrace is one vertex of an inspected path. BDPT fills in the area densities of
+// generating the vertex; the path tracer fills in the light sampled from it, the density of the
+// direction it scattered in and the MIS weight of what it emits.
+type PathVertexTrace struct {
+	Point      [3]float64 `json:"point"`     // Zero on the background
+	Normal     [3]float64 `json:"normal"`    // The camera's and the background's point back along the ray
+	Direction  [3]float64 `json:"direction"` // Of the ray arriving at the vertex (leaving it, on a light path's first)
+	Material   string     `json:"material,omitempty"`
+	Beta       [3]float64 `json:"beta"`    // Throughput of the path from its start to the vertex
+	Emitted    [3]float64 `json:"emitted"` // Light emitted back along the path, before any MIS weight
+	Specular   bool       `json:"specular,omitempty"`
+	Light      bool       `json:"light,omitempty"`
+	Background bool       `json:"background,omitempty"`
+
+	PdfForward float64 `json:"pdfForward"` // BDPT: density of generating the vertex from the path's start
+	PdfReverse float64 `json:"pdfReverse"` // BDPT: density of generating it from the other end
+
+	Direct         *[3]float64 `json:"direct,omitempty"`         // Path tracing: light sampled from the vertex, MIS weighted
+	ScatterPdf     float64     `json:"scatterPdf,omitempty"`     // Path tracing: solid angle density of the direction scattered to the next vertex
+	EmissionWeight float64     `json:"emissionWeight,omitempty"` // Path tracing: MIS weight of Emitted
+}
+
+// vecArray converts a vector for a trace
+func vecArray(v core.Vec3) [3]float64 {
+	return [3]float64{v.X, v.Y, v.Z}
+}
+
+// materialName names a material in a trace by its type, empty for none
+func materialName(m any) string {
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%T", m)
+}
+
+// InspectSample traces a sample like RayColor, recording the camera and light paths and every
+// strategy's contribution and MIS weight
+func (bdpt *BDPTIntegrator) InspectSample(ray core.Ray, scene *scene.Scene, sampler core.Sampler) *SampleInspection {
+	trace := newSampleTrace(ray, scene.Camera)
+	light, _, cameraPath, lightPath := bdpt.traceSample(ray, scene, sampler, nil, trace)
+	inspection := &SampleInspection{Color: vecArray(light), Strategies: trace.strategies}
+	inspection.CameraPath = bdptPathTrace(cameraPath, ray.Direction)
+	if lightPath.Length > 0 {
+		inspection.LightPath = bdptPathTrace(lightPath, core.Vec3{})
+	}
+	return inspection
+}
+
+// bdptPathTrace converts a BDPT path for an inspection; start is the direction of the first ray,
+// or zero to take it from the second vertex
+func bdptPathTrace(path Path, start core.Vec3) []PathVertexTrace {
+	vertices := make([]PathVertexTrace, path.Length)
+	for i := range vertices {
+		v := &path.Vertices[i]
+		direction := v.IncomingDirection.Negate()
+		if i == 0 {
+			direction = start
+			if direction.IsZero() && path.Length > 1 {
+				direction = path.Vertices[1].IncomingDirection.Negate()
+			}
+		}
+		vertices[i] = PathVertexTrace{
+			Point:      vecArray(v.Point),
+			Normal:     vecArray(v.Normal),
+			Direction:  vecArray(direction),
+			Material:   materialName(v.Material),
+			Beta:       vecArray(v.Beta),
+			Emitted:    vecArray(v.EmittedLight),
+			Specular:   v.IsSpecular,
+			Light:      v.IsLight,
+			Background: v.IsInfiniteLight && i > 0, // A light path starts on an infinite light at a sampled point
+			PdfForward: v.AreaPdfForward,
+			PdfReverse: v.AreaPdfReverse,
+		}
+		if vertices[i].Background {
+			vertices[i].Point = [3]float64{}
+		}
+	}
+	return vertices
+}
+
+// InspectSample traces a sample like RayColor, recording each vertex of its path
+func (pt *PathTracingIntegrator) InspectSample(ray core.Ray, scene *scene.Scene, sampler core.Sampler) *SampleInspection {
+	inspection := &SampleInspection{
+		CameraPath: []PathVertexTrace{{
+			Point:     vecArray(ray.Origin),
+			Normal:    vecArray(ray.Direction.Negate()),
+			Direction: vecArray(ray.Direction),
+			Beta:      [3]float64{1, 1, 1},
+		}},
+	}
+	color := pt.rayColor(ray, scene, sampler, nil, inspection)
+	inspection.Color = vecArray(color)
+	return inspection
+}
@@ -1,14 +1,18 @@
 package integrator
 
 import (
+	"math"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
 )
 
 // SplatRay represents a ray-based color contribution that needs to be mapped to pixels
 type SplatRay struct {
-	Ray   core.Ray  // Ray that should contribute to some pixel
-	Color core.Vec3 // Color contribution
+	Ray      core.Ray  // Ray that should contribute to some pixel
+	Color    core.Vec3 // Color contribution
+	Strategy Strategy  // BDPT strategy that produced the splat (zero value for other integrators)
 }
 
 // Integrator defines the interface for light transport algorithms
@@ -17,3 +21,59 @@ type Integrator interface {
 	// Returns (pixel color, splat rays)
 	RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay)
 }
+
+// PassLearner is implemented by integrators that learn from the passes they render, such as the
+// path tracer with path guiding. The renderer calls EndPass once every sample of a pass is done.
+type PassLearner interface {
+	Integrator
+	EndPass()
+}
+
+// SceneCacher is implemented by integrators that keep what they computed from the scene, such as
+// the path tracer's irradiance cache. The renderer calls SceneEdited between passes after editing
+// the scene, so they compute it again for the edited scene.
+type SceneCacher interface {
+	Integrator
+	SceneEdited()
+}
+
+// defaultRussianRouletteMinProb is the survival probability floor used when the config leaves it unset
+const defaultRussianRouletteMinProb = 0.05
+
+// russianRouletteSurvival returns the probability that a path continues past the given bounce
+// Survival is proportional to the path throughput (including earlier roulette compensation), so
+// paths still carrying full energy always continue while dark paths are culled aggressively.
+// The configured floor bounds the 1/q compensation and hence the variance it can introduce.
+func russianRouletteSurvival(config scene.SamplingConfig, bounce int, throughput core.Vec3) float64 {
+	if bounce < config.RussianRouletteMinBounces {
+		return 1.0
+	}
+
+	minProb := config.RussianRouletteMinProb
+	if minProb <= 0 {
+		minProb = defaultRussianRouletteMinProb
+	}
+	return math.Min(1.0, math.Max(minProb, throughput.MaxComponent()))
+}
+
+// lobeBounces counts a path's scattering events of each material.Lobe, for the per-lobe depth
+// limits of SamplingConfig
+type lobeBounces [3]int
+
+// add returns the counts with one more bounce of the given lobe
+func (b lobeBounces) add(lobe material.Lobe) lobeBounces {
+	b[lobe]++
+	return b
+}
+
+// within reports whether a path with these bounces may continue past its last one. Like
+// MaxDepth, a limit of n lets a lobe's nth bounce gather light from lights but no further.
+func (b lobeBounces) within(config scene.SamplingConfig) bool {
+	limits := lobeBounces{config.MaxDiffuseDepth, config.MaxGlossyDepth, config.MaxTransmissionDepth}
+	for lobe, limit := range limits {
+		if limit > 0 && b[lobe] >= limit {
+			return false
+		}
+	}
+	return true
+}
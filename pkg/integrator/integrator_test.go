@@ -0,0 +1,175 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestRussianRouletteSurvival(t *testing.T) {
+	config := scene.SamplingConfig{RussianRouletteMinBounces: 3, RussianRouletteMinProb: 0.1}
+
+	tests := []struct {
+		name       string
+		config     scene.SamplingConfig
+		bounce     int
+		throughput core.Vec3
+		expected   float64
+	}{
+		{"before start depth", config, 2, core.NewVec3(0.01, 0.01, 0.01), 1.0},
+		{"dark path", config, 3, core.NewVec3(0.3, 0.2, 0.1), 0.3},
+		{"saturated color keeps full energy", config, 5, core.NewVec3(1.0, 0.0, 0.0), 1.0},
+		{"compensated bright path", config, 5, core.NewVec3(2.5, 1.0, 0.5), 1.0},
+		{"floor applies", config, 5, core.NewVec3(0.001, 0.001, 0.001), 0.1},
+		{"default floor", scene.SamplingConfig{}, 5, core.NewVec3(0, 0, 0), defaultRussianRouletteMinProb},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := russianRouletteSurvival(tt.config, tt.bounce, tt.throughput)
+			if math.Abs(got-tt.expected) > 1e-12 {
+				t.Errorf("Expected survival %f, got %f", tt.expected, got)
+			}
+		})
+	}
+}
+
+// countingIntersector wraps a backend and counts the queries it answers
+type countingIntersector struct {
+	geometry.Intersector
+	hits, hitAnys int
+}
+
+func (c *countingIntersector) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	c.hits++
+	return c.Intersector.Hit(ray, tMin, tMax)
+}
+
+func (c *countingIntersector) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	c.hitAnys++
+	return c.Intersector.HitAny(ray, tMin, tMax)
+}
+
+func TestIntegratorsUseSceneIntersector(t *testing.T) {
+	// A swapped-in backend must answer every ray query, and give the same image as the BVH
+	config := scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 2}
+	reference := createMinimalCornellScene(false)
+
+	var backend *countingIntersector
+	swapped := createMinimalCornellScene(false)
+	swapped.IntersectorBuilder = func(shapes []geometry.Shape) (geometry.Intersector, error) {
+		backend = &countingIntersector{Intersector: geometry.NewBVH(shapes)}
+		return backend, nil
+	}
+	if err := swapped.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	ray := reference.Camera.GetRay(200, 260, core.NewVec2(0.5, 0.5), core.NewVec2(0.5, 0.5))
+	for name, integ := range map[string]Integrator{
+		"path-tracing": NewPathTracingIntegrator(config),
+		"bdpt":         NewBDPTIntegrator(config),
+	} {
+		backend.hits, backend.hitAnys = 0, 0
+		expected, _ := integ.RayColor(ray, reference, core.NewSeededSampler(5))
+		got, _ := integ.RayColor(ray, swapped, core.NewSeededSampler(5))
+
+		if got != expected {
+			t.Errorf("%s: color with swapped intersector = %v, want %v", name, got, expected)
+		}
+		if backend.hits == 0 || backend.hitAnys == 0 {
+			t.Errorf("%s: expected closest-hit and shadow queries on the backend, got %d and %d", name, backend.hits, backend.hitAnys)
+		}
+	}
+}
+
+func TestIntegratorsRespectVisibility(t *testing.T) {
+	config := scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 100}
+	mean := func(integ Integrator, s *scene.Scene, ray core.Ray) float64 {
+		const samples = 2000
+		sampler := core.NewSeededSampler(7)
+		sum := 0.0
+		for i := 0; i < samples; i++ {
+			color, _ := integ.RayColor(ray, s, sampler)
+			sum += color.Luminance()
+		}
+		return sum / samples
+	}
+
+	// A light facing away from the camera, lighting the wall behind it: the camera sees its dark
+	// back unless it's hidden from camera rays
+	const lightScene = `
+		quad corner -5 -5 -3 u 10 0 0 v 0 10 0
+		quadlight corner -0.25 -0.25 -1.5 u 0 0.5 0 v 0.5 0 0 emit 5 5 5`
+	visible := newMicroTestScene(lightScene, nil)
+	hidden := newMicroTestScene(lightScene+" hide camera", nil)
+	center := core.NewRay(core.Vec3{}, core.NewVec3(0, 0, -1))
+
+	// A sphere hidden from camera and indirect rays only shadows the floor under it
+	const floorScene = `
+		quad corner -5 -1 -7 u 0 0 10 v 10 0 0
+		quadlight corner -0.5 3 -2.5 u 1 0 0 v 0 0 1 emit 5 5 5`
+	open := newMicroTestScene(floorScene, nil)
+	shadowed := newMicroTestScene(floorScene+"\nsphere at 0 1 -2 radius 0.5 hide camera,indirect", nil)
+	floor := core.NewRay(core.Vec3{}, core.NewVec3(0, -1, -2).Normalize())
+
+	for name, integ := range map[string]Integrator{
+		"path-tracing": NewPathTracingIntegrator(config),
+		"bdpt":         NewBDPTIntegrator(config),
+	} {
+		if got := mean(integ, visible, center); got != 0 {
+			t.Errorf("%s: expected the light's back to be black, got %f", name, got)
+		}
+		if got := mean(integ, hidden, center); got <= 0 {
+			t.Errorf("%s: expected the camera to see the lit wall through the hidden light, got %f", name, got)
+		}
+
+		lit, dark := mean(integ, open, floor), mean(integ, shadowed, floor)
+		if dark <= 0 || dark > 0.5*lit {
+			t.Errorf("%s: expected the shadow-only sphere to shadow the floor, got %f (unshadowed %f)", name, dark, lit)
+		}
+	}
+}
+
+func TestIntegratorsRespectLobeDepths(t *testing.T) {
+	// A light seen through a glass ball: the path refracts in and out before reaching it
+	s := newMicroTestScene(`
+		material glass dielectric ior 1.5
+		sphere at 0 0 -2 radius 0.5 material glass
+		quadlight corner -1 -1 -4 u 2 0 0 v 0 2 0 emit 5 5 5`, nil)
+	ray := core.NewRay(core.Vec3{}, core.NewVec3(0, 0, -1))
+	mean := func(integ Integrator) float64 {
+		sampler := core.NewSeededSampler(3)
+		sum := 0.0
+		for i := 0; i < 500; i++ {
+			color, _ := integ.RayColor(ray, s, sampler)
+			sum += color.Luminance()
+		}
+		return sum / 500
+	}
+
+	for _, limits := range []struct {
+		transmission, diffuse int
+		lit                   bool
+	}{
+		{0, 0, true},
+		{3, 1, true}, // Two refractions reach the light; diffuse limits don't apply to glass
+		{2, 0, false},
+		{1, 0, false},
+	} {
+		config := scene.SamplingConfig{MaxDepth: 5, RussianRouletteMinBounces: 100,
+			MaxTransmissionDepth: limits.transmission, MaxDiffuseDepth: limits.diffuse}
+		for name, integ := range map[string]Integrator{
+			"path-tracing": NewPathTracingIntegrator(config),
+			"bdpt":         NewBDPTIntegrator(config),
+		} {
+			if got := mean(integ); (got > 0.5) != limits.lit {
+				t.Errorf("%s with transmission depth %d: expected lit %v, got %f", name, limits.transmission, limits.lit, got)
+			}
+		}
+	}
+}
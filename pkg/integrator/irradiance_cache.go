@@ -0,0 +1,296 @@
+package integrator
+
+import (
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+const (
+	irradianceThetaStrata = 8     // Strata of the gather rays in elevation
+	irradiancePhiStrata   = 24    // Strata of the gather rays in azimuth, about π times the elevation strata
+	irradianceMinRadius   = 0.002 // Smallest record radius, as a share of the scene's radius
+	irradianceMaxRadius   = 0.1   // Largest record radius, as a share of the scene's radius
+	irradianceBehind      = 0.05  // Records further in front of a point than this share of their radius don't apply to it
+	irradianceStride      = 4     // Pixels between the camera rays that place records
+	irradianceOutlier     = 8     // Gather rays are clamped to this multiple of their mean luminance
+	irradianceMarkerSize  = 0.004 // Radius of a record's marker, as a share of its distance from the camera
+)
+
+// irradianceCache caches the irradiance that diffuse surfaces receive from other surfaces, so the
+// path tracer can interpolate it instead of tracing a path for each sample (Ward's irradiance
+// caching). Each record holds the irradiance at a point, gathered with stratified rays, and its
+// gradients under rotation and translation (Ward and Heckbert), which extrapolate it to points
+// nearby. A record applies within its radius scaled by the accuracy: the harmonic mean distance
+// of its gather rays, since irradiance changes quickly near other surfaces, and no more than the
+// distance over which its translation gradient would change it by its own value.
+//
+// The cache is filled before the first pass by an overture: a grid of camera rays places records
+// where their primary hits aren't covered yet. Rendering only reads it, so images are the same
+// for a seed however many workers render them; points no record covers are path traced.
+type irradianceCache struct {
+	accuracy  float64 // Ward's a: larger values reuse records further away
+	minRadius float64
+	maxRadius float64
+	cellSize  float64                        // Edge of the grid cells records are filed in
+	cells     map[[3]int][]*irradianceRecord // Records whose reach overlaps each cell
+	records   int
+}
+
+// irradianceRecord is the irradiance gathered at a point
+type irradianceRecord struct {
+	point      core.Vec3
+	normal     core.Vec3
+	irradiance core.Vec3
+	radius     float64
+	rotation   [3]core.Vec3 // Rotation gradient of each color channel
+	gradient   [3]core.Vec3 // Translation gradient of each color channel
+}
+
+// newIrradianceCache creates an empty cache for a scene of the given radius
+func newIrradianceCache(accuracy, sceneRadius float64) *irradianceCache {
+	maxRadius := irradianceMaxRadius * sceneRadius
+	return &irradianceCache{
+		accuracy:  accuracy,
+		minRadius: irradianceMinRadius * sceneRadius,
+		maxRadius: maxRadius,
+		cellSize:  2 * accuracy * maxRadius,
+		cells:     make(map[[3]int][]*irradianceRecord),
+	}
+}
+
+// cell returns the grid cell containing a point
+func (c *irradianceCache) cell(point core.Vec3) [3]int {
+	return [3]int{
+		int(math.Floor(point.X / c.cellSize)),
+		int(math.Floor(point.Y / c.cellSize)),
+		int(math.Floor(point.Z / c.cellSize)),
+	}
+}
+
+// insert files a record in every cell its reach overlaps
+func (c *irradianceCache) insert(record *irradianceRecord) {
+	reach := core.NewVec3(1, 1, 1).Multiply(c.accuracy * record.radius)
+	lo, hi := c.cell(record.point.Subtract(reach)), c.cell(record.point.Add(reach))
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				key := [3]int{x, y, z}
+				c.cells[key] = append(c.cells[key], record)
+			}
+		}
+	}
+	c.records++
+}
+
+// weight returns how much a record applies at a point with a normal: Ward's weight, less its value
+// at the edge of the record's reach so that records fade out, or 0 if it doesn't apply
+func (c *irradianceCache) weight(record *irradianceRecord, point, normal core.Vec3) float64 {
+	offset := point.Subtract(record.point)
+	if offset.Dot(normal.Add(record.normal)) < -2*irradianceBehind*record.radius {
+		return 0 // The record is in front of the point, where it may see what the point can't
+	}
+	e := offset.Length()/record.radius + math.Sqrt(math.Max(0, 1-normal.Dot(record.normal)))
+	if e >= c.accuracy {
+		return 0
+	}
+	return 1/math.Max(e, 1e-9) - 1/c.accuracy
+}
+
+// lookup interpolates the irradiance at a point with a normal from the records that apply there
+func (c *irradianceCache) lookup(point, normal core.Vec3) (core.Vec3, bool) {
+	var sum core.Vec3
+	totalWeight := 0.0
+	for _, record := range c.cells[c.cell(point)] {
+		w := c.weight(record, point, normal)
+		if w <= 0 {
+			continue
+		}
+		// Extrapolate the record by its gradients
+		turn := record.normal.Cross(normal)
+		offset := point.Subtract(record.point)
+		value := record.irradiance.Add(core.NewVec3(
+			record.rotation[0].Dot(turn)+record.gradient[0].Dot(offset),
+			record.rotation[1].Dot(turn)+record.gradient[1].Dot(offset),
+			record.rotation[2].Dot(turn)+record.gradient[2].Dot(offset)))
+		sum = sum.Add(value.Multiply(w))
+		totalWeight += w
+	}
+	if totalWeight <= 0 {
+		return core.Vec3{}, false
+	}
+	e := sum.Multiply(1 / totalWeight)
+	return core.NewVec3(math.Max(0, e.X), math.Max(0, e.Y), math.Max(0, e.Z)), true
+}
+
+// nearRecord reports whether a point seen from a viewpoint is on the marker of a record
+func (c *irradianceCache) nearRecord(point, viewpoint core.Vec3) bool {
+	for _, record := range c.cells[c.cell(point)] {
+		size := irradianceMarkerSize * record.point.Subtract(viewpoint).Length()
+		if point.Subtract(record.point).LengthSquared() < size*size {
+			return true
+		}
+	}
+	return false
+}
+
+// gather computes a record at a point with a normal from stratified, cosine weighted rays, where
+// trace returns the radiance arriving along a ray and the distance to what it hit (+Inf if nothing)
+func (c *irradianceCache) gather(point, normal core.Vec3, sampler core.Sampler, trace func(core.Ray) (core.Vec3, float64)) *irradianceRecord {
+	const m, n = irradianceThetaStrata, irradiancePhiStrata
+	var helper core.Vec3
+	if math.Abs(normal.X) > 0.1 {
+		helper = core.NewVec3(0, 1, 0)
+	} else {
+		helper = core.NewVec3(1, 0, 0)
+	}
+	tangent := helper.Cross(normal).Normalize()
+	bitangent := normal.Cross(tangent)
+	inPlane := func(phi float64) core.Vec3 {
+		return tangent.Multiply(math.Cos(phi)).Add(bitangent.Multiply(math.Sin(phi)))
+	}
+
+	var radiance [m][n]core.Vec3
+	var distance [m][n]float64
+	var turn [m][n]core.Vec3 // How each ray's contribution moves as the normal turns
+	var total core.Vec3
+	inverseDistances := 0.0
+	for j := 0; j < m; j++ {
+		for k := 0; k < n; k++ {
+			u := sampler.Get2D()
+			sinTheta := math.Sqrt((float64(j) + u.X) / m)
+			cosTheta := math.Sqrt(math.Max(0, 1-sinTheta*sinTheta))
+			phi := 2 * math.Pi * (float64(k) + u.Y) / n
+			direction := inPlane(phi).Multiply(sinTheta).Add(normal.Multiply(cosTheta))
+			radiance[j][k], distance[j][k] = trace(core.NewRay(point, direction))
+			total = total.Add(radiance[j][k])
+			inverseDistances += 1 / distance[j][k]
+			if cosTheta > 1e-6 {
+				turn[j][k] = inPlane(phi + math.Pi/2).Multiply(-sinTheta / cosTheta)
+			}
+		}
+	}
+
+	// A record is reused across many pixels, so one ray that found a rare bright path (a caustic,
+	// say) would show as a blotch; such rays are clamped to a multiple of the mean
+	limit := irradianceOutlier * total.Luminance() / (m * n)
+	var sum core.Vec3
+	var rotation [3]core.Vec3
+	for j := 0; j < m; j++ {
+		for k := 0; k < n; k++ {
+			if lum := radiance[j][k].Luminance(); lum > limit {
+				radiance[j][k] = radiance[j][k].Multiply(limit / lum)
+			}
+			sum = sum.Add(radiance[j][k])
+			for ch := 0; ch < 3; ch++ {
+				rotation[ch] = rotation[ch].Add(turn[j][k].Multiply(channel(radiance[j][k], ch)))
+			}
+		}
+	}
+	scale := math.Pi / (m * n)
+	for ch := range rotation {
+		rotation[ch] = rotation[ch].Multiply(scale)
+	}
+
+	// Translation gradient: how the stratum boundaries move toward the surfaces behind them
+	var gradient [3]core.Vec3
+	for k := 0; k < n; k++ {
+		u := inPlane(2 * math.Pi * (float64(k) + 0.5) / n)
+		v := inPlane(2*math.Pi*float64(k)/n + math.Pi/2)
+		for j := 0; j < m; j++ {
+			sinLo, sinHi := math.Sqrt(float64(j)/m), math.Sqrt(float64(j+1)/m)
+			if j > 0 {
+				cosLo2 := 1 - float64(j)/m
+				f := 2 * math.Pi / n * sinLo * cosLo2 / math.Min(distance[j][k], distance[j-1][k])
+				for ch := 0; ch < 3; ch++ {
+					gradient[ch] = gradient[ch].Add(u.Multiply(f * (channel(radiance[j][k], ch) - channel(radiance[j-1][k], ch))))
+				}
+			}
+			prev := (k + n - 1) % n
+			f := (sinHi - sinLo) / math.Min(distance[j][k], distance[j][prev])
+			for ch := 0; ch < 3; ch++ {
+				gradient[ch] = gradient[ch].Add(v.Multiply(f * (channel(radiance[j][k], ch) - channel(radiance[j][prev], ch))))
+			}
+		}
+	}
+
+	irradiance := sum.Multiply(scale)
+	radius := math.Inf(1)
+	if inverseDistances > 0 {
+		radius = m * n / inverseDistances
+	}
+	lumGradient := gradient[0].Multiply(0.2126).Add(gradient[1].Multiply(0.7152)).Add(gradient[2].Multiply(0.0722))
+	if change := lumGradient.Length(); change > 0 {
+		radius = math.Min(radius, irradiance.Luminance()/change)
+	}
+	radius = math.Max(c.minRadius, math.Min(c.maxRadius, radius))
+
+	return &irradianceRecord{point: point, normal: normal, irradiance: irradiance, radius: radius, rotation: rotation, gradient: gradient}
+}
+
+// channel returns color channel ch (0 red, 1 green, 2 blue) of a color
+func channel(color core.Vec3, ch int) float64 {
+	switch ch {
+	case 0:
+		return color.X
+	case 1:
+		return color.Y
+	}
+	return color.Z
+}
+
+// fill runs the overture: it places records at the primary hits of a grid of camera rays that no
+// record covers yet, a row of the grid at a time. Each row's records are gathered in parallel and
+// inserted in order, skipping those an earlier one in the row came to cover.
+func (c *irradianceCache) fill(s *scene.Scene, trace func(core.Ray, core.Sampler) (core.Vec3, float64)) {
+	width, height := s.SamplingConfig.Width, s.SamplingConfig.Height
+	center := core.NewVec2(0.5, 0.5)
+	for y := irradianceStride / 2; y < height; y += irradianceStride {
+		var hits []*material.SurfaceInteraction
+		for x := irradianceStride / 2; x < width; x += irradianceStride {
+			ray := s.Camera.GetRay(x, y, center, center)
+			hit, ok := geometry.HitVisible(s.Intersector, ray, 0.001, math.Inf(1), material.CameraRays)
+			if !ok {
+				continue
+			}
+			if _, diffuse := hit.Material.(*material.Lambertian); !diffuse {
+				continue
+			}
+			if _, covered := c.lookup(hit.Point, hit.Normal); !covered {
+				hits = append(hits, hit)
+			}
+		}
+
+		records := make([]*irradianceRecord, len(hits))
+		var wg sync.WaitGroup
+		next := make(chan int, len(hits))
+		for i := range hits {
+			next <- i
+		}
+		close(next)
+		for w := 0; w < runtime.NumCPU(); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					sampler := core.NewSeededSampler(core.MixBits(uint64(y*width + i + 1)))
+					records[i] = c.gather(hits[i].Point, hits[i].Normal, sampler, func(ray core.Ray) (core.Vec3, float64) {
+						return trace(ray, sampler)
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, record := range records {
+			if _, covered := c.lookup(record.point, record.normal); !covered {
+				c.insert(record)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// halfLitCeiling returns a trace function for a point under a ceiling at height 1 that is lit
+// where x > 0, with nothing else around
+func halfLitCeiling(point core.Vec3) func(core.Ray) (core.Vec3, float64) {
+	return func(ray core.Ray) (core.Vec3, float64) {
+		t := (1 - point.Y) / ray.Direction.Y
+		if ray.Direction.Y <= 0 || point.X+t*ray.Direction.X <= 0 {
+			return core.Vec3{}, math.Inf(1)
+		}
+		return core.NewVec3(1, 1, 1), t
+	}
+}
+
+func TestIrradianceCache_GatherUniformSky(t *testing.T) {
+	cache := newIrradianceCache(0.2, 10)
+	up := core.NewVec3(0, 1, 0)
+	record := cache.gather(core.Vec3{}, up, core.NewSeededSampler(1), func(core.Ray) (core.Vec3, float64) {
+		return core.NewVec3(1, 1, 1), math.Inf(1)
+	})
+
+	if math.Abs(record.irradiance.X-math.Pi) > 1e-9 {
+		t.Errorf("Expected irradiance π under a uniform sky, got %v", record.irradiance)
+	}
+	if record.gradient[0].Length() > 1e-9 {
+		t.Errorf("Expected no translation gradient under a uniform sky, got %v", record.gradient[0])
+	}
+	if record.radius != cache.maxRadius {
+		t.Errorf("Expected the largest radius with nothing nearby, got %f", record.radius)
+	}
+}
+
+func TestIrradianceCache_GatherGradient(t *testing.T) {
+	// Under a half-lit ceiling the lit share of the sky is (1 + x/√(1+x²))/2, so the irradiance
+	// grows at π/2 across its edge
+	cache := newIrradianceCache(0.2, 100)
+	record := cache.gather(core.Vec3{}, core.NewVec3(0, 1, 0), core.NewSeededSampler(1), halfLitCeiling(core.Vec3{}))
+	expected := math.Pi / 2
+	if gradient := record.gradient[0].X; math.Abs(gradient-expected) > 0.1*expected {
+		t.Errorf("Expected a gradient along x of about %f, got %f", expected, gradient)
+	}
+	if math.Abs(record.gradient[0].Z) > 0.1*expected {
+		t.Errorf("Expected no gradient along z, got %f", record.gradient[0].Z)
+	}
+	// The radius is the distance over which the gradient would double the irradiance
+	if radius := record.irradiance.X / record.gradient[0].Length(); math.Abs(record.radius-radius) > 1e-9 {
+		t.Errorf("Expected the gradient to limit the radius to %f, got %f", radius, record.radius)
+	}
+}
+
+func TestIrradianceCache_Lookup(t *testing.T) {
+	cache := newIrradianceCache(0.5, 10)
+	up := core.NewVec3(0, 1, 0)
+	record := cache.gather(core.Vec3{}, up, core.NewSeededSampler(1), halfLitCeiling(core.Vec3{}))
+	cache.insert(record)
+
+	if e, ok := cache.lookup(core.Vec3{}, up); !ok || math.Abs(e.X-record.irradiance.X) > 1e-9 {
+		t.Errorf("Expected the record's own irradiance at its point, got %v (%v)", e, ok)
+	}
+	// Nearby, the record is extrapolated by its gradient, brighter toward the lit side
+	near := core.NewVec3(0.1*record.radius, 0, 0)
+	if e, ok := cache.lookup(near, up); !ok || e.X <= record.irradiance.X {
+		t.Errorf("Expected brighter irradiance toward the lit side, got %v (%v)", e, ok)
+	}
+	// Records don't apply far away or to surfaces facing elsewhere
+	if _, ok := cache.lookup(core.NewVec3(record.radius, 0, 0), up); ok {
+		t.Error("Expected no irradiance beyond the record's reach")
+	}
+	if _, ok := cache.lookup(core.Vec3{}, core.NewVec3(1, 0, 0)); ok {
+		t.Error("Expected no irradiance for a surface facing another way")
+	}
+}
+
+func TestIrradianceCache_RefilledAfterSceneEdit(t *testing.T) {
+	sphere := geometry.NewSphere(core.NewVec3(0, 0, -4), 1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	s := &scene.Scene{
+		Shapes: []geometry.Shape{sphere},
+		Lights: []lights.Light{lights.NewUniformInfiniteLight(core.NewVec3(1, 1, 1))},
+		Camera: geometry.NewCamera(geometry.CameraConfig{
+			Center: core.NewVec3(0, 0, 0), LookAt: core.NewVec3(0, 0, -4), Up: core.NewVec3(0, 1, 0),
+			Width: 32, AspectRatio: 1, VFov: 60,
+		}),
+		SamplingConfig: scene.SamplingConfig{Width: 32, Height: 32, MaxDepth: 4, IrradianceCache: 0.3},
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	pt := NewPathTracingIntegrator(s.SamplingConfig)
+	ray := core.NewRay(core.Vec3{}, core.NewVec3(0, 0, -1))
+	pt.RayColor(ray, s, core.NewSeededSampler(1))
+	before := pt.cache
+	if before == nil || before.records == 0 {
+		t.Fatal("Expected records on the sphere")
+	}
+
+	// Once the sphere is gone, no records are left where it was
+	if err := s.RemoveShape(sphere); err != nil {
+		t.Fatalf("RemoveShape failed: %v", err)
+	}
+	pt.SceneEdited()
+	pt.RayColor(ray, s, core.NewSeededSampler(1))
+	if pt.cache == nil || pt.cache == before {
+		t.Fatal("Expected the cache filled again for the edited scene")
+	}
+	if pt.cache.records != 0 {
+		t.Errorf("Expected no records without the sphere, got %d", pt.cache.records)
+	}
+}
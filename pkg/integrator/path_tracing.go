@@ -1,82 +1,229 @@
 package integrator
 
 import (
-	"fmt"
 	"math"
+	"sync"
 
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
 )
 
+// ptLog logs the path tracer's contributions at each bounce, at debug level
+var ptLog = core.NewLog("path-tracing")
+
 // PathTracingIntegrator implements unidirectional path tracing
 type PathTracingIntegrator struct {
-	config  scene.SamplingConfig
-	Verbose bool
+	config scene.SamplingConfig
+	guide  *pathGuide // Path guiding, nil unless config.GuidingPasses is set
+
+	cache     *irradianceCache // Irradiance cache, nil unless config.IrradianceCache is set
+	cacheOnce sync.Once
 }
 
 // NewPathTracingIntegrator creates a new path tracing integrator
 func NewPathTracingIntegrator(config scene.SamplingConfig) *PathTracingIntegrator {
-	return &PathTracingIntegrator{
-		config:  config,
-		Verbose: false,
+	pt := &PathTracingIntegrator{
+		config: config,
 	}
+	if config.GuidingPasses > 0 {
+		pt.guide = newPathGuide(config.GuidingPasses)
+	}
+	return pt
+}
+
+// EndPass implements PassLearner: path guiding samples with what the pass learned from here on
+func (pt *PathTracingIntegrator) EndPass() {
+	if pt.guide != nil {
+		pt.guide.endPass()
+	}
+}
+
+// SceneEdited implements SceneCacher: the irradiance cache is filled again for the edited scene.
+// Path guiding keeps what it learned, which only changes how directions are sampled.
+func (pt *PathTracingIntegrator) SceneEdited() {
+	pt.cache = nil
+	pt.cacheOnce = sync.Once{}
 }
 
 // RayColor computes the color for a single ray using unidirectional path tracing
 func (pt *PathTracingIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay) {
+	return pt.RayColorAOV(ray, scene, sampler, nil)
+}
+
+// RayColorAOV computes color like RayColor and records its direct/indirect split in aov
+func (pt *PathTracingIntegrator) RayColorAOV(ray core.Ray, scene *scene.Scene, sampler core.Sampler, aov *AOVSample) (core.Vec3, []SplatRay) {
+	return pt.rayColor(ray, scene, sampler, aov, nil), nil
+}
+
+// rayColor traces a camera ray, recording its light split in aov and its vertices in inspection
+// when they aren't nil
+func (pt *PathTracingIntegrator) rayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler, aov *AOVSample, inspection *SampleInspection) core.Vec3 {
+	if pt.guide != nil {
+		pt.guide.init(scene)
+	}
+	if pt.config.IrradianceCache > 0 {
+		pt.cacheOnce.Do(func() { pt.fillIrradianceCache(scene) })
+	}
+	depth := pt.config.MaxDepth
+	throughput := core.Vec3{X: 1.0, Y: 1.0, Z: 1.0}
+	path := pathAOV{weight: core.Vec3{X: 1.0, Y: 1.0, Z: 1.0}, aov: aov, inspection: inspection}
+	return pt.rayColorRecursive(ray, scene, sampler, depth, throughput, path, lobeBounces{}, 1)
+}
+
+// ShadeHit returns the light a surface point scatters back along a ray, as if a camera ray had
+// found it there: direct light sampled with MIS plus the light of the paths scattered from it.
+// It gathers the light at points no camera ray reaches, such as lightmap texels. Emission of the
+// point's own material isn't included.
+func (pt *PathTracingIntegrator) ShadeHit(ray core.Ray, hit *material.SurfaceInteraction, scene *scene.Scene, sampler core.Sampler) core.Vec3 {
+	if pt.guide != nil {
+		pt.guide.init(scene)
+	}
+	scatter, didScatter := hit.Material.Scatter(ray, *hit, sampler)
+	if !didScatter {
+		return core.Vec3{}
+	}
 	depth := pt.config.MaxDepth
 	throughput := core.Vec3{X: 1.0, Y: 1.0, Z: 1.0}
-	return pt.rayColorRecursive(ray, scene, sampler, depth, throughput), nil
+	path := pathAOV{weight: throughput}
+	bounces := lobeBounces{}.add(scatter.Lobe)
+	if scatter.IsSpecular() {
+		return pt.calculateSpecularColor(scatter, scene, depth, throughput, sampler, path, bounces)
+	}
+	return pt.calculateDiffuseColor(scatter, hit, scene, depth, throughput, sampler, path, bounces)
 }
 
-func (pt *PathTracingIntegrator) rayColorRecursive(ray core.Ray, scene *scene.Scene, sampler core.Sampler, depth int, throughput core.Vec3) core.Vec3 {
+// pathAOV tracks what's needed to attribute light gathered deep in the recursion to AOVs, and
+// to record the path's vertices when the sample is inspected
+type pathAOV struct {
+	weight     core.Vec3         // Factor (including MIS weights) mapping radiance at this level to the pixel
+	aov        *AOVSample        // Destination for the light split, nil when AOVs aren't recorded
+	inspection *SampleInspection // Destination for the vertices, nil unless the sample is inspected
+}
+
+// record attributes radiance gathered at this level by a path with the given vertex count
+func (p pathAOV) record(pathVertices int, radiance core.Vec3) {
+	if p.aov != nil {
+		p.aov.AddLight(pathVertices, p.weight.MultiplyVec(radiance))
+	}
+}
+
+// scaled returns the tracking state for the next level down the recursion
+func (p pathAOV) scaled(factor core.Vec3) pathAOV {
+	return pathAOV{weight: p.weight.MultiplyVec(factor), aov: p.aov, inspection: p.inspection}
+}
+
+// addVertex records the vertex a ray reached, hit or nil for the background, when the sample is
+// inspected
+func (p pathAOV) addVertex(ray core.Ray, hit *material.SurfaceInteraction, throughput, emitted core.Vec3, emissionWeight float64) {
+	if p.inspection == nil {
+		return
+	}
+	vertex := PathVertexTrace{
+		Direction:      vecArray(ray.Direction),
+		Beta:           vecArray(throughput),
+		Emitted:        vecArray(emitted),
+		Light:          !emitted.IsZero(),
+		EmissionWeight: emissionWeight,
+	}
+	if hit != nil {
+		vertex.Point = vecArray(hit.Point)
+		vertex.Normal = vecArray(hit.Normal)
+		vertex.Material = materialName(hit.Material)
+	} else {
+		vertex.Normal = vecArray(ray.Direction.Negate())
+		vertex.Background = true
+	}
+	p.inspection.CameraPath = append(p.inspection.CameraPath, vertex)
+}
+
+// vertex returns the vertex recorded last, the one being scattered from, or nil when the sample
+// isn't inspected
+func (p pathAOV) vertex() *PathVertexTrace {
+	if p.inspection == nil {
+		return nil
+	}
+	return &p.inspection.CameraPath[len(p.inspection.CameraPath)-1]
+}
+
+// rayColorRecursive returns the light arriving along a ray
+// emissionWeight is the MIS weight of light emitted by what the ray hits (or the environment, if
+// it escapes): the BSDF sampling strategy's share, when light sampling at the previous vertex
+// could have found the same light. The light the hit point scatters is all the ray's.
+// bounces counts the path's scattering events so far, for the per-lobe depth limits.
+func (pt *PathTracingIntegrator) rayColorRecursive(ray core.Ray, scene *scene.Scene, sampler core.Sampler, depth int, throughput core.Vec3, path pathAOV, bounces lobeBounces, emissionWeight float64) core.Vec3 {
+	// Paths reaching this level have the camera plus one vertex per level so far
+	bounce := pt.config.MaxDepth - depth
+
 	// If we've exceeded the ray bounce limit, no more light is gathered
 	if depth <= 0 {
+		core.CountPathLength(bounce)
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
 	// Apply Russian Roulette termination
 	shouldTerminate, rrCompensation := pt.ApplyRussianRoulette(depth, throughput, sampler.Get1D())
 	if shouldTerminate {
+		core.CountPathLength(bounce)
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
-	// Check for intersections with objects using scene's BVH
-	hit, isHit := scene.BVH.Hit(ray, 0.001, math.Inf(1))
+	// Carry the compensation in the throughput so later roulette decisions see the path's true weight
+	throughput = throughput.Multiply(rrCompensation)
+	path = path.scaled(core.Vec3{X: rrCompensation, Y: rrCompensation, Z: rrCompensation})
+
+	// Check for intersections with objects using scene's BVH, passing through shapes hidden from this ray
+	kind := material.IndirectRays
+	if bounce == 0 {
+		kind = material.CameraRays
+	}
+	hit, isHit := geometry.HitVisible(scene.Intersector, ray, 0.001, math.Inf(1), kind)
 	if !isHit {
 		// Check for infinite light emission
-		ls := scene.Lights
-		var totalEmission core.Vec3
-		for _, light := range ls {
-			if light.Type() == lights.LightTypeInfinite {
-				totalEmission = totalEmission.Add(light.Emit(ray, nil))
-			}
-		}
-
+		core.CountPathLength(bounce)
+		emission := lights.EvaluateInfiniteLights(scene.Lights, ray)
+		path.addVertex(ray, nil, throughput, emission, emissionWeight)
+		totalEmission := emission.Multiply(emissionWeight)
+		path.record(bounce+2, totalEmission)
 		return totalEmission.Multiply(rrCompensation)
 	}
 
-	// Start with emitted light from the hit material
+	// Start with emitted light from the hit material. Light sampling never finds a glowing
+	// medium's, so it is all this strategy's.
 	colorEmitted := getEmittedLight(ray, hit)
+	if _, isMedium := hit.Material.(material.MediumEmitter); isMedium {
+		path.addVertex(ray, hit, throughput, colorEmitted, 1)
+	} else {
+		path.addVertex(ray, hit, throughput, colorEmitted, emissionWeight)
+		colorEmitted = colorEmitted.Multiply(emissionWeight)
+	}
+	path.record(bounce+2, colorEmitted)
 
 	// Try to scatter the ray
 	scatter, didScatter := hit.Material.Scatter(ray, *hit, sampler)
 	if !didScatter {
 		// Material absorbed the ray, only return emitted light
-		// pt.logf("      pt[%d]    light: contribution=%v\n", pt.config.MaxDepth-depth, colorEmitted)
+		core.CountPathLength(bounce + 1)
+		if ptLog.Enabled(core.LevelDebug) {
+			ptLog.Logf(core.LevelDebug, "pt[%d]    light: contribution=%v", pt.config.MaxDepth-depth, colorEmitted)
+		}
 
 		return colorEmitted.Multiply(rrCompensation)
 	}
 
 	// Handle scattering based on material type
 	var colorScattered core.Vec3
+	if vertex := path.vertex(); vertex != nil {
+		vertex.Specular = scatter.IsSpecular()
+	}
+	bounces = bounces.add(scatter.Lobe)
 	if scatter.IsSpecular() {
-		colorScattered = pt.calculateSpecularColor(scatter, scene, depth, throughput, sampler)
+		colorScattered = pt.calculateSpecularColor(scatter, scene, depth, throughput, sampler, path, bounces)
 	} else {
-		colorScattered = pt.calculateDiffuseColor(scatter, hit, scene, depth, throughput, sampler)
+		colorScattered = pt.calculateDiffuseColor(scatter, hit, scene, depth, throughput, sampler, path, bounces)
 	}
 
 	// Apply Russian Roulette compensation to the final result
@@ -85,25 +232,126 @@ func (pt *PathTracingIntegrator) rayColorRecursive(ray core.Ray, scene *scene.Sc
 }
 
 // calculateSpecularColor handles specular material scattering with the provided random generator
-func (pt *PathTracingIntegrator) calculateSpecularColor(scatter material.ScatterResult, scene *scene.Scene, depth int, throughput core.Vec3, sampler core.Sampler) core.Vec3 {
+func (pt *PathTracingIntegrator) calculateSpecularColor(scatter material.ScatterResult, scene *scene.Scene, depth int, throughput core.Vec3, sampler core.Sampler, path pathAOV, bounces lobeBounces) core.Vec3 {
+	// A path past its lobe's depth limit gathers nothing more
+	if !bounces.within(pt.config) {
+		core.CountPathLength(pt.config.MaxDepth - depth + 1)
+		return core.Vec3{X: 0, Y: 0, Z: 0}
+	}
+
 	// Update throughput with material attenuation
 	newThroughput := throughput.MultiplyVec(scatter.Attenuation)
-	incomingLight := pt.rayColorRecursive(scatter.Scattered, scene, sampler, depth-1, newThroughput)
+	incomingLight := pt.rayColorRecursive(scatter.Scattered, scene, sampler, depth-1, newThroughput, path.scaled(scatter.Attenuation), bounces, 1)
 	contribution := scatter.Attenuation.MultiplyVec(incomingLight)
 
-	// pt.logf("      pt[%d] specular: contribution=%v = attenuation=%v * incomingLight=%v\n", pt.config.MaxDepth-depth, contribution, scatter.Attenuation, incomingLight)
+	if ptLog.Enabled(core.LevelDebug) {
+		ptLog.Logf(core.LevelDebug, "pt[%d] specular: contribution=%v = attenuation=%v * incomingLight=%v", pt.config.MaxDepth-depth, contribution, scatter.Attenuation, incomingLight)
+	}
 
 	return contribution
 }
 
 // calculateDiffuseColor handles diffuse material scattering with throughput tracking
-func (pt *PathTracingIntegrator) calculateDiffuseColor(scatter material.ScatterResult, hit *material.SurfaceInteraction, scene *scene.Scene, depth int, throughput core.Vec3, sampler core.Sampler) core.Vec3 {
+func (pt *PathTracingIntegrator) calculateDiffuseColor(scatter material.ScatterResult, hit *material.SurfaceInteraction, scene *scene.Scene, depth int, throughput core.Vec3, sampler core.Sampler, path pathAOV, bounces lobeBounces) core.Vec3 {
+	// Primary hits interpolate their indirect light from the irradiance cache where it covers them
+	if pt.cache != nil && depth == pt.config.MaxDepth {
+		if color, cached := pt.cachedDiffuseColor(scatter, hit, scene, sampler, path); cached {
+			return color
+		}
+	}
+
+	// With path guiding, the scatter direction may be sampled from the guide instead
+	guide := pt.guide.lookup(hit.Point)
+	if guide != nil {
+		scatter = pt.guideScatter(scatter, hit, guide, sampler)
+	}
+
 	// Combine direct lighting and indirect lighting using Multiple Importance Sampling
-	directLight := pt.CalculateDirectLighting(scene, scatter, hit, sampler, depth)
-	indirectLight := pt.CalculateIndirectLighting(scene, scatter, hit, depth, throughput, sampler)
+	var directLight core.Vec3
+	var lightSelection []float64
+	if pt.config.PrimaryLightSamples > 0 && depth == pt.config.MaxDepth {
+		directLight, lightSelection = pt.CalculateImportantDirectLighting(scene, scatter, hit, sampler, guide)
+	} else {
+		directLight = pt.CalculateDirectLighting(scene, scatter, hit, sampler, guide)
+	}
+	path.record(pt.config.MaxDepth-depth+3, directLight) // Light sampled from this vertex adds the emitter vertex
+	if vertex := path.vertex(); vertex != nil {
+		direct := vecArray(directLight)
+		vertex.Direct = &direct
+	}
+	indirectLight := pt.CalculateIndirectLighting(scene, scatter, hit, depth, throughput, sampler, path, bounces, lightSelection)
 	return directLight.Add(indirectLight)
 }
 
+// guideScatter samples the scatter direction from the guide with probability guideFraction, and
+// otherwise keeps the material's. Either way the result carries the density of sampling its
+// direction from both (one-sample MIS), so the material keeps paths the guide hasn't learned.
+func (pt *PathTracingIntegrator) guideScatter(scatter material.ScatterResult, hit *material.SurfaceInteraction, guide *dirTree, sampler core.Sampler) material.ScatterResult {
+	direction := scatter.Scattered.Direction.Normalize()
+	if sampler.Get1D() < guideFraction {
+		direction, _ = guide.sample(sampler.Get2D())
+	}
+	materialPDF, isDelta := hit.Material.PDF(scatter.Incoming.Direction, direction, hit.Normal)
+	if isDelta {
+		return scatter
+	}
+	scatter.Scattered = core.NewRay(hit.Point, direction)
+	scatter.Attenuation = hit.Material.EvaluateBRDF(scatter.Incoming.Direction, direction, hit, material.Radiance)
+	scatter.PDF = guidedPDF(materialPDF, guide, direction)
+	return scatter
+}
+
+// fillIrradianceCache creates the irradiance cache and fills it before anything is rendered. The
+// gather rays find the light the surfaces they hit scatter but not what they emit, which the
+// cached points sample lights for.
+func (pt *PathTracingIntegrator) fillIrradianceCache(scene *scene.Scene) {
+	cache := newIrradianceCache(pt.config.IrradianceCache, scene.WorldRadius)
+	cache.fill(scene, func(ray core.Ray, sampler core.Sampler) (core.Vec3, float64) {
+		distance := math.Inf(1)
+		if hit, ok := geometry.HitVisible(scene.Intersector, ray, 0.001, math.Inf(1), material.IndirectRays); ok {
+			distance = hit.T
+		}
+		white := core.Vec3{X: 1, Y: 1, Z: 1}
+		bounces := lobeBounces{}.add(material.LobeDiffuse)
+		return pt.rayColorRecursive(ray, scene, sampler, pt.config.MaxDepth-1, white, pathAOV{weight: white}, bounces, 0), distance
+	})
+	ptLog.Logf(core.LevelDebug, "Irradiance cache: %d records", cache.records)
+	pt.cache = cache
+}
+
+// cachedDiffuseColor returns the light a Lambertian primary hit scatters toward the camera, with
+// the indirect light interpolated from the irradiance cache and direct light from one light
+// sample, which carries all of it since no scattered ray looks for lights. It reports false if
+// the hit isn't Lambertian or no record covers it. With IrradianceCachePoints, hits on a
+// record's marker are green.
+func (pt *PathTracingIntegrator) cachedDiffuseColor(scatter material.ScatterResult, hit *material.SurfaceInteraction, scene *scene.Scene, sampler core.Sampler, path pathAOV) (core.Vec3, bool) {
+	lambertian, ok := hit.Material.(*material.Lambertian)
+	if !ok {
+		return core.Vec3{}, false
+	}
+	if pt.config.IrradianceCachePoints && pt.cache.nearRecord(hit.Point, scatter.Incoming.Origin) {
+		return core.Vec3{X: 0, Y: 1, Z: 0}, true
+	}
+	irradiance, ok := pt.cache.lookup(hit.Point, hit.Normal)
+	if !ok {
+		return core.Vec3{}, false
+	}
+
+	var directLight core.Vec3
+	if lightSample, _, _, hasLight := lights.SampleLight(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, sampler); hasLight {
+		directLight = pt.lightSampleContribution(scene, scatter, hit, lightSample, nil, false)
+	}
+	indirectLight := hit.EvaluateTexture(lambertian.Albedo).Multiply(1 / math.Pi).MultiplyVec(irradiance)
+	path.record(3, directLight)
+	path.record(4, indirectLight)
+	if vertex := path.vertex(); vertex != nil {
+		direct := vecArray(directLight)
+		vertex.Direct = &direct
+	}
+	core.CountPathLength(1)
+	return directLight.Add(indirectLight), true
+}
+
 // getEmittedLight returns the emitted light from a material if it's emissive
 func getEmittedLight(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
 	if emitter, isEmissive := hit.Material.(material.Emitter); isEmissive {
@@ -113,23 +361,47 @@ func getEmittedLight(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
 }
 
 // calculateDirectLighting samples lights directly for direct illumination with the provided random generator
-func (pt *PathTracingIntegrator) CalculateDirectLighting(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, sampler core.Sampler, depth int) core.Vec3 {
+// guide is the vertex's path guiding distribution, nil if it isn't guided
+func (pt *PathTracingIntegrator) CalculateDirectLighting(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, sampler core.Sampler, guide *dirTree) core.Vec3 {
 	// Sample a light
 	lightSample, _, _, hasLight := lights.SampleLight(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, sampler)
-	if !hasLight || lightSample.Emission.Luminance() <= 0 || lightSample.PDF <= 0 {
+	if !hasLight {
+		return core.Vec3{X: 0, Y: 0, Z: 0}
+	}
+	return pt.lightSampleContribution(scene, scatter, hit, lightSample, guide, true)
+}
+
+// CalculateImportantDirectLighting samples each of the PrimaryLightSamples most important lights
+// once, plus one of the rest (see lights.SampleImportantLights), and sums their contributions
+// Returns the direct lighting and each light's selection probability, for the MIS weight of
+// material sampling at this vertex
+func (pt *PathTracingIntegrator) CalculateImportantDirectLighting(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, sampler core.Sampler, guide *dirTree) (core.Vec3, []float64) {
+	lightSamples, selection := lights.SampleImportantLights(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, pt.config.PrimaryLightSamples, sampler)
+	var total core.Vec3
+	for _, lightSample := range lightSamples {
+		total = total.Add(pt.lightSampleContribution(scene, scatter, hit, lightSample, guide, true))
+	}
+	return total, selection
+}
+
+// lightSampleContribution returns the direct lighting from one light sample, MIS weighted against
+// material sampling if weighted is set. At a guided vertex, the scatter direction's density
+// includes the guide's.
+func (pt *PathTracingIntegrator) lightSampleContribution(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, lightSample lights.LightSample, guide *dirTree, weighted bool) core.Vec3 {
+	if lightSample.Emission.Luminance() <= 0 || lightSample.PDF <= 0 {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
 	// Check if light is visible (shadow ray)
 	shadowRay := core.NewRay(hit.Point, lightSample.Direction)
-	_, blocked := scene.BVH.Hit(shadowRay, 0.001, lightSample.Distance-0.001)
+	blocked := scene.Intersector.HitAny(shadowRay, 0.001, lightSample.Distance-0.001)
 	if blocked {
 		// Light is blocked, no direct contribution
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
 	// Calculate the cosine factor
-	cosine := lightSample.Direction.Dot(hit.Normal)
+	cosine := material.CosineTerm(hit.Material, lightSample.Direction, hit.Normal)
 	if cosine <= 0 {
 		return core.Vec3{X: 0, Y: 0, Z: 0} // Light is behind the surface
 	}
@@ -144,79 +416,88 @@ func (pt *PathTracingIntegrator) CalculateDirectLighting(scene *scene.Scene, sca
 	}
 
 	// Calculate MIS weight
-	misWeight := powerHeuristic(1, lightSample.PDF, 1, materialPDF)
+	materialPDF = guidedPDF(materialPDF, guide, lightSample.Direction)
+	misWeight := 1.0
+	if weighted {
+		misWeight = powerHeuristic(1, lightSample.PDF, 1, materialPDF)
+	}
+	pt.guide.record(hit.Point, lightSample.Direction, lightSample.Emission.Luminance()*misWeight/lightSample.PDF)
 
 	// Calculate BRDF for the new outgoing direction
 	brdf := hit.Material.EvaluateBRDF(scatter.Incoming.Direction, lightSample.Direction, hit, material.Radiance)
 
 	// Direct lighting contribution: BRDF * emission * cosine * MIS_weight / light_PDF
 	contribution := brdf.MultiplyVec(lightSample.Emission).Multiply(cosine * misWeight / lightSample.PDF)
-	// pt.logf("      pt[%d]   direct: contribution=%v = brdf=%v * emission=%v * (cosine=%f * misWeight=%f / lightPDF=%f)\n", pt.config.MaxDepth-depth, contribution, brdf, lightSample.Emission, cosine, misWeight, lightSample.PDF)
+	if ptLog.Enabled(core.LevelDebug) {
+		ptLog.Logf(core.LevelDebug, "direct: contribution=%v = brdf=%v * emission=%v * (cosine=%f * misWeight=%f / lightPDF=%f)", contribution, brdf, lightSample.Emission, cosine, misWeight, lightSample.PDF)
+	}
 
 	return contribution
 }
 
 // calculateIndirectLighting handles indirect illumination via material sampling with throughput tracking
-func (pt *PathTracingIntegrator) CalculateIndirectLighting(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, depth int, throughput core.Vec3, sampler core.Sampler) core.Vec3 {
-	if scatter.PDF <= 0 {
+// lightSelection holds the light selection probabilities of this vertex's direct lighting when it
+// didn't use the light sampler's (see CalculateImportantDirectLighting), nil otherwise
+func (pt *PathTracingIntegrator) CalculateIndirectLighting(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction, depth int, throughput core.Vec3, sampler core.Sampler, path pathAOV, bounces lobeBounces, lightSelection []float64) core.Vec3 {
+	if scatter.PDF <= 0 || !bounces.within(pt.config) {
+		core.CountPathLength(pt.config.MaxDepth - depth + 1)
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
 	scatterDirection := scatter.Scattered.Direction.Normalize()
-	cosine := scatterDirection.Dot(hit.Normal)
+	cosine := material.CosineTerm(hit.Material, scatterDirection, hit.Normal)
 	if cosine <= 0 {
+		core.CountPathLength(pt.config.MaxDepth - depth + 1)
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
 
 	// Get light PDF for this direction (for MIS)
-	lightPDF := lights.CalculateLightPDF(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, scatterDirection)
+	var lightPDF float64
+	if lightSelection != nil {
+		lightPDF = lights.CalculateSelectedLightPDF(scene.Lights, lightSelection, hit.Point, hit.Normal, scatterDirection)
+	} else {
+		lightPDF = lights.CalculateLightPDF(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, scatterDirection)
+	}
 
 	// Calculate MIS weight
 	misWeight := powerHeuristic(1, scatter.PDF, 1, lightPDF)
+	if vertex := path.vertex(); vertex != nil {
+		vertex.ScatterPdf = scatter.PDF
+	}
 
 	// Update throughput for the recursive call
 	newThroughput := throughput.MultiplyVec(scatter.Attenuation).Multiply(cosine / scatter.PDF)
 
-	// Get incoming light from the scattered direction with throughput tracking
-	incomingLight := pt.rayColorRecursive(scatter.Scattered, scene, sampler, depth-1, newThroughput)
+	// Get incoming light from the scattered direction with throughput tracking. The MIS weight
+	// only applies to light emitted where the ray lands, which light sampling also finds: the
+	// light scattered there is found by this strategy alone.
+	pathFactor := scatter.Attenuation.Multiply(cosine / scatter.PDF)
+	incomingLight := pt.rayColorRecursive(scatter.Scattered, scene, sampler, depth-1, newThroughput, path.scaled(pathFactor), bounces, misWeight)
 
-	// Indirect lighting contribution with MIS
-	contribution := scatter.Attenuation.Multiply(cosine * misWeight / scatter.PDF).MultiplyVec(incomingLight)
+	// Indirect lighting contribution
+	contribution := pathFactor.MultiplyVec(incomingLight)
+	pt.guide.record(hit.Point, scatterDirection, incomingLight.Luminance()/scatter.PDF)
 
-	// pt.logf("      pt[%d] indirect: contribution=%v = attenuation=%v * incomingLight=%v * (cosine=%f * misWeight=%f / scatterPDF=%f)\n", pt.config.MaxDepth-depth, contribution, scatter.Attenuation, incomingLight, cosine, misWeight, scatter.PDF)
+	if ptLog.Enabled(core.LevelDebug) {
+		ptLog.Logf(core.LevelDebug, "pt[%d] indirect: contribution=%v = attenuation=%v * incomingLight=%v * (cosine=%f / scatterPDF=%f), emission misWeight=%f", pt.config.MaxDepth-depth, contribution, scatter.Attenuation, incomingLight, cosine, scatter.PDF, misWeight)
+	}
 
 	return contribution
 }
 
-// applyRussianRoulette determines if a ray should be terminated and returns the compensation factor
+// ApplyRussianRoulette determines if a ray should be terminated and returns the compensation factor
 // Returns (shouldTerminate, compensationFactor)
 func (pt *PathTracingIntegrator) ApplyRussianRoulette(depth int, throughput core.Vec3, sample float64) (bool, float64) {
-	// Apply Russian Roulette after minimum bounces AND minimum samples per pixel
-	initialDepth := pt.config.MaxDepth
-	currentBounce := initialDepth - depth
-
-	shouldApplyRR := currentBounce >= pt.config.RussianRouletteMinBounces
-
-	if !shouldApplyRR {
-		return false, 1.0 // Don't terminate, no compensation needed
-	}
-
-	// Calculate survival probability based on throughput
-	// Use luminance for perceptually accurate survival probability
-	luminance := throughput.Luminance()
-
-	// Conservative bounds: survivalProb between 0.5 and 0.95
-	// This naturally limits compensation factor to between 1.05x and 2.0x
-	survivalProb := math.Min(0.95, math.Max(0.5, luminance))
+	currentBounce := pt.config.MaxDepth - depth
+	survivalProb := russianRouletteSurvival(pt.config, currentBounce, throughput)
 
 	// Russian Roulette test
-	if sample > survivalProb {
+	if sample >= survivalProb {
 		return true, 0.0 // Terminate ray
 	}
 
-	// Energy-conserving compensation (no artificial cap)
-	compensationFactor := 1.0 / survivalProb
-	return false, compensationFactor
+	// Energy-conserving compensation
+	return false, 1.0 / survivalProb
 }
 
 // PowerHeuristic implements the power heuristic for multiple importance sampling
@@ -232,9 +513,3 @@ func powerHeuristic(nf int, fPdf float64, ng int, gPdf float64) float64 {
 	// Power heuristic with β = 2 (squared)
 	return (f * f) / (f*f + g*g)
 }
-
-func (pt *PathTracingIntegrator) logf(format string, a ...interface{}) {
-	if pt.Verbose {
-		fmt.Printf(format, a...)
-	}
-}
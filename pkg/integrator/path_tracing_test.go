@@ -313,3 +313,42 @@ func TestPowerHeuristic(t *testing.T) {
 		})
 	}
 }
+
+// TestPathTracingPrimaryLightSamples checks that sampling the most important lights at primary hits
+// converges to the same radiance as one light per hit, with less noise
+func TestPathTracingPrimaryLightSamples(t *testing.T) {
+	sc := newMicroTestScene(`
+		quad corner -5 -1 -5 u 10 0 0 v 0 0 10
+		spherelight at -1 0.5 -2 radius 0.3 emit 8 8 8
+		spherelight at 1 1 -2 radius 0.2 emit 20 10 5
+		spherelight at 0 3 -3 radius 0.5 emit 2 2 4
+		sky top 0.2 0.2 0.3 bottom 0 0 0
+	`, nil)
+	ray := core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(0, -1, -2).Normalize())
+
+	const samples = 40000
+	render := func(primaryLights int) (mean, variance float64) {
+		integrator := NewPathTracingIntegrator(scene.SamplingConfig{MaxDepth: 2, RussianRouletteMinBounces: 100, PrimaryLightSamples: primaryLights})
+		sampler := core.NewRandomSampler(rand.New(rand.NewSource(3)))
+		sum, sumSquares := 0.0, 0.0
+		for i := 0; i < samples; i++ {
+			color, _ := integrator.RayColor(ray, sc, sampler)
+			sum += color.Luminance()
+			sumSquares += color.Luminance() * color.Luminance()
+		}
+		mean = sum / samples
+		return mean, sumSquares/samples - mean*mean
+	}
+
+	oneMean, oneVariance := render(0)
+	for _, k := range []int{1, 2, 5} {
+		mean, variance := render(k)
+		t.Logf("PrimaryLightSamples=%d: mean %f variance %f (one light per hit: mean %f variance %f)", k, mean, variance, oneMean, oneVariance)
+		if math.Abs(mean-oneMean) > 0.03*oneMean {
+			t.Errorf("PrimaryLightSamples=%d: mean %f differs from one light per hit %f", k, mean, oneMean)
+		}
+		if variance >= oneVariance {
+			t.Errorf("PrimaryLightSamples=%d: variance %f not below one light per hit %f", k, variance, oneVariance)
+		}
+	}
+}
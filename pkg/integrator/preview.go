@@ -0,0 +1,119 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// aoDistance is how far ambient occlusion looks for occluders, as a share of the scene's radius
+const aoDistance = 0.1
+
+// AmbientOcclusionIntegrator shades each surface the camera sees by how much of the hemisphere
+// above it is open: white where nothing is within reach, darker in creases and corners. It
+// ignores materials and lights, so it shows the geometry quickly while setting up a scene.
+type AmbientOcclusionIntegrator struct {
+	config scene.SamplingConfig
+}
+
+// NewAmbientOcclusionIntegrator creates a new ambient occlusion integrator
+func NewAmbientOcclusionIntegrator(config scene.SamplingConfig) *AmbientOcclusionIntegrator {
+	return &AmbientOcclusionIntegrator{config: config}
+}
+
+// RayColor traces one cosine weighted occlusion ray from the surface the camera ray hits, so the
+// progressive passes average them into the share of the hemisphere left open. Rays that miss
+// everything are black.
+func (ao *AmbientOcclusionIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay) {
+	hit, isHit := geometry.HitVisible(scene.Intersector, ray, 0.001, math.Inf(1), material.CameraRays)
+	if !isHit {
+		core.CountPathLength(0)
+		return core.Vec3{X: 0, Y: 0, Z: 0}, nil
+	}
+	core.CountPathLength(1)
+
+	direction := core.SampleCosineHemisphere(hit.Normal, sampler.Get2D())
+	if scene.Intersector.HitAny(core.NewRay(hit.Point, direction), 0.001, aoDistance*scene.WorldRadius) {
+		return core.Vec3{X: 0, Y: 0, Z: 0}, nil
+	}
+	return core.Vec3{X: 1, Y: 1, Z: 1}, nil
+}
+
+// DirectLightingIntegrator renders only light that reaches the camera from an emitter after at
+// most one bounce off a non-specular surface, following mirrors and glass up to MaxDepth. Light
+// bouncing between surfaces is left out, which makes it much faster than path tracing for
+// placing lights. Each surface combines one light sample and one material sample with MIS.
+type DirectLightingIntegrator struct {
+	config scene.SamplingConfig
+	pt     *PathTracingIntegrator // Light sampling shared with the path tracer
+}
+
+// NewDirectLightingIntegrator creates a new direct lighting integrator
+func NewDirectLightingIntegrator(config scene.SamplingConfig) *DirectLightingIntegrator {
+	config.GuidingPasses = 0
+	config.IrradianceCache = 0
+	return &DirectLightingIntegrator{config: config, pt: NewPathTracingIntegrator(config)}
+}
+
+// RayColor computes the direct light arriving along a camera ray
+func (d *DirectLightingIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []SplatRay) {
+	throughput := core.Vec3{X: 1, Y: 1, Z: 1}
+	var color core.Vec3
+	kind := material.CameraRays
+	for bounce := 0; bounce < d.config.MaxDepth; bounce++ {
+		hit, isHit := geometry.HitVisible(scene.Intersector, ray, 0.001, math.Inf(1), kind)
+		if !isHit {
+			core.CountPathLength(bounce)
+			return color.Add(throughput.MultiplyVec(lights.EvaluateInfiniteLights(scene.Lights, ray))), nil
+		}
+		color = color.Add(throughput.MultiplyVec(getEmittedLight(ray, hit)))
+
+		scatter, didScatter := hit.Material.Scatter(ray, *hit, sampler)
+		if !didScatter {
+			core.CountPathLength(bounce + 1)
+			return color, nil
+		}
+		if scatter.IsSpecular() {
+			throughput = throughput.MultiplyVec(scatter.Attenuation)
+			ray = scatter.Scattered
+			kind = material.IndirectRays
+			continue
+		}
+
+		core.CountPathLength(bounce + 1)
+		direct := d.pt.CalculateDirectLighting(scene, scatter, hit, sampler, nil).Add(d.scatteredEmission(scene, scatter, hit))
+		return color.Add(throughput.MultiplyVec(direct)), nil
+	}
+	core.CountPathLength(d.config.MaxDepth)
+	return color, nil
+}
+
+// scatteredEmission returns the light emitted where the material's sampled direction lands,
+// MIS weighted against light sampling the way the path tracer weights it
+func (d *DirectLightingIntegrator) scatteredEmission(scene *scene.Scene, scatter material.ScatterResult, hit *material.SurfaceInteraction) core.Vec3 {
+	if scatter.PDF <= 0 {
+		return core.Vec3{X: 0, Y: 0, Z: 0}
+	}
+	direction := scatter.Scattered.Direction.Normalize()
+	cosine := material.CosineTerm(hit.Material, direction, hit.Normal)
+	if cosine <= 0 {
+		return core.Vec3{X: 0, Y: 0, Z: 0}
+	}
+	lightPDF := lights.CalculateLightPDF(scene.Lights, scene.LightSampler, hit.Point, hit.Normal, direction)
+	misWeight := powerHeuristic(1, scatter.PDF, 1, lightPDF)
+
+	var emitted core.Vec3
+	if next, isHit := geometry.HitVisible(scene.Intersector, scatter.Scattered, 0.001, math.Inf(1), material.IndirectRays); isHit {
+		emitted = getEmittedLight(scatter.Scattered, next)
+		if _, isMedium := next.Material.(material.MediumEmitter); isMedium {
+			misWeight = 1 // Light sampling never finds a glowing medium's light
+		}
+	} else {
+		emitted = lights.EvaluateInfiniteLights(scene.Lights, scatter.Scattered)
+	}
+	return scatter.Attenuation.Multiply(cosine * misWeight / scatter.PDF).MultiplyVec(emitted)
+}
@@ -0,0 +1,87 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// createCornerScene creates a long floor meeting a wall along x = 0, lit by a uniform sky
+func createCornerScene(floor material.Material) *scene.Scene {
+	gray := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	s := &scene.Scene{
+		Shapes: []geometry.Shape{
+			geometry.NewQuad(core.NewVec3(-100, 0, -5), core.NewVec3(105, 0, 0), core.NewVec3(0, 0, 10), floor),
+			geometry.NewQuad(core.NewVec3(0, 0, -5), core.NewVec3(0, 0, 10), core.NewVec3(0, 5, 0), gray),
+		},
+		Lights:         []lights.Light{lights.NewUniformInfiniteLight(core.NewVec3(1, 1, 1))},
+		Camera:         &geometry.Camera{},
+		SamplingConfig: scene.SamplingConfig{MaxDepth: 8},
+	}
+	s.Preprocess()
+	return s
+}
+
+// meanColor averages an integrator's color for a ray straight down onto the floor at x
+func meanColor(in Integrator, s *scene.Scene, x float64, samples int) core.Vec3 {
+	sampler := core.NewSeededSampler(11)
+	ray := core.NewRay(core.NewVec3(x, 1, 0), core.NewVec3(0, -1, 0))
+	var sum core.Vec3
+	for i := 0; i < samples; i++ {
+		color, _ := in.RayColor(ray, s, sampler)
+		sum = sum.Add(color)
+	}
+	return sum.Multiply(1 / float64(samples))
+}
+
+func TestAmbientOcclusion(t *testing.T) {
+	s := createCornerScene(material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	ao := NewAmbientOcclusionIntegrator(s.SamplingConfig)
+
+	// Far from the wall the floor is open; right next to it half the hemisphere is blocked, but
+	// the cosine weighting counts the part overhead more, leaving (1 + 0)/2 open
+	if open := meanColor(ao, s, -90, 100); open.X != 1 {
+		t.Errorf("Expected an open floor to be white, got %v", open)
+	}
+	if corner := meanColor(ao, s, -0.01, 4000); math.Abs(corner.X-0.5) > 0.05 {
+		t.Errorf("Expected the floor by the wall to be half occluded, got %v", corner)
+	}
+	if miss, _ := ao.RayColor(core.NewRay(core.NewVec3(-1, 1, 0), core.NewVec3(0, 1, 0)), s, core.NewSeededSampler(1)); miss != (core.Vec3{}) {
+		t.Errorf("Expected rays that miss to be black, got %v", miss)
+	}
+}
+
+func TestDirectLighting(t *testing.T) {
+	s := createCornerScene(material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	direct := NewDirectLightingIntegrator(s.SamplingConfig)
+
+	// Where the wall blocks none of the sky, the floor reflects albedo times its radiance
+	if open := meanColor(direct, s, -90, 2000); math.Abs(open.X-0.5) > 0.02 {
+		t.Errorf("Expected the open floor to reflect 0.5 of the sky, got %v", open)
+	}
+
+	// By the wall, the floor sees half the sky directly, and path tracing adds the light the
+	// wall reflects onto it
+	corner := meanColor(direct, s, -0.01, 4000)
+	if math.Abs(corner.X-0.25) > 0.02 {
+		t.Errorf("Expected the floor by the wall to reflect half as much, got %v", corner)
+	}
+	full := meanColor(NewPathTracingIntegrator(s.SamplingConfig), s, -0.01, 4000)
+	if full.X <= corner.X+0.02 {
+		t.Errorf("Expected path tracing to add indirect light to %v, got %v", corner, full)
+	}
+}
+
+func TestDirectLighting_FollowsMirrors(t *testing.T) {
+	// A mirror floor shows the sky above it
+	s := createCornerScene(material.NewMetal(core.NewVec3(1, 1, 1), 0))
+	direct := NewDirectLightingIntegrator(s.SamplingConfig)
+	if sky := meanColor(direct, s, -1, 10); math.Abs(sky.X-1) > 1e-9 {
+		t.Errorf("Expected the mirror to reflect the sky, got %v", sky)
+	}
+}
@@ -0,0 +1,57 @@
+package integrator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Factory creates an integrator for a scene's sampling settings
+type Factory func(config scene.SamplingConfig) Integrator
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"path-tracing": func(config scene.SamplingConfig) Integrator { return NewPathTracingIntegrator(config) },
+		"bdpt":         func(config scene.SamplingConfig) Integrator { return NewBDPTIntegrator(config) },
+		"ao":           func(config scene.SamplingConfig) Integrator { return NewAmbientOcclusionIntegrator(config) },
+		"direct":       func(config scene.SamplingConfig) Integrator { return NewDirectLightingIntegrator(config) },
+	}
+)
+
+// Register adds an integrator for New, and so the command line, web server and pkg/raytracer, to
+// create by name. Register panics if the name is empty or taken (including by the built-in
+// path-tracing, bdpt, ao and direct), since it's meant to be called from an init function.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("integrator: Register needs a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("integrator: %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// New creates the integrator of the given name for a scene's sampling settings
+func New(name string, config scene.SamplingConfig) (Integrator, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown integrator %q (expected %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(config), nil
+}
+
+// Names returns the names New knows, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return slices.Sorted(maps.Keys(registry))
+}
@@ -0,0 +1,60 @@
+package integrator
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestNew(t *testing.T) {
+	config := scene.SamplingConfig{MaxDepth: 5}
+	for name, want := range map[string]Integrator{
+		"path-tracing": &PathTracingIntegrator{},
+		"bdpt":         &BDPTIntegrator{},
+		"ao":           &AmbientOcclusionIntegrator{},
+		"direct":       &DirectLightingIntegrator{},
+	} {
+		got, err := New(name, config)
+		if err != nil {
+			t.Fatalf("%s: New failed: %v", name, err)
+		}
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+			t.Errorf("%s: expected a %T, got %T", name, want, got)
+		}
+	}
+
+	if _, err := New("photon-mapping", config); err == nil {
+		t.Error("Expected an error for an unknown integrator")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var created scene.SamplingConfig
+	Register("test-register", func(config scene.SamplingConfig) Integrator {
+		created = config
+		return NewAmbientOcclusionIntegrator(config)
+	})
+
+	if _, err := New("test-register", scene.SamplingConfig{MaxDepth: 3}); err != nil {
+		t.Fatalf("New failed for a registered integrator: %v", err)
+	}
+	if created.MaxDepth != 3 {
+		t.Errorf("Expected the factory given the sampling config, got %+v", created)
+	}
+	if !slices.Contains(Names(), "test-register") || !slices.IsSorted(Names()) {
+		t.Errorf("Expected the registered name among the sorted names, got %v", Names())
+	}
+
+	for _, name := range []string{"test-register", "bdpt", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q): expected a panic", name)
+				}
+			}()
+			Register(name, func(config scene.SamplingConfig) Integrator { return NewPathTracingIntegrator(config) })
+		}()
+	}
+}
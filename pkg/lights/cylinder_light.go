@@ -0,0 +1,176 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// CylinderLight is a tube light, such as a neon or fluorescent tube or a strip light. Unlike a
+// ShapeLight around the same cylinder, which samples the whole surface and wastes the samples on
+// the far side, it samples only the part of the surface facing the shading point: the arc of the
+// body within acos(r/d) of the direction to it, and the cap on its side when capped. Thin tubes
+// make good line lights.
+type CylinderLight struct {
+	*geometry.Cylinder // Embed cylinder for hit testing and emission sampling
+
+	axis   core.Vec3 // Unit vector from base to top
+	height float64
+}
+
+// NewCylinderLight creates a tube light around the segment from baseCenter to topCenter
+func NewCylinderLight(baseCenter, topCenter core.Vec3, radius float64, capped bool, material material.Material) *CylinderLight {
+	axis := topCenter.Subtract(baseCenter)
+	return &CylinderLight{
+		Cylinder: geometry.NewCylinder(baseCenter, topCenter, radius, capped, material),
+		axis:     axis.Normalize(),
+		height:   axis.Length(),
+	}
+}
+
+func (cl *CylinderLight) Type() LightType {
+	return LightTypeArea
+}
+
+// visibleSurface describes the part of the surface facing a point: the body within halfAngle of
+// the radial direction toward it, and the cap at capCenter when capArea is nonzero
+type visibleSurface struct {
+	radial, tangent core.Vec3 // Unit vectors across the axis, radial toward the point
+	halfAngle       float64
+	bodyArea        float64
+	capCenter       core.Vec3
+	capNormal       core.Vec3
+	capArea         float64
+}
+
+// area returns the visible surface's total area
+func (v visibleSurface) area() float64 {
+	return v.bodyArea + v.capArea
+}
+
+// visible returns the part of the surface facing a point. A point on the body faces it when
+// d cos φ > r, d being the point's distance from the axis and φ the angle between them around it.
+func (cl *CylinderLight) visible(point core.Vec3) visibleSurface {
+	var v visibleSurface
+	toPoint := point.Subtract(cl.BaseCenter)
+	h := toPoint.Dot(cl.axis)
+	across := toPoint.Subtract(cl.axis.Multiply(h))
+	d := across.Length()
+	if d > 0 {
+		v.radial = across.Multiply(1 / d)
+	} else {
+		v.radial = perpendicular(cl.axis)
+	}
+	v.tangent = cl.axis.Cross(v.radial)
+
+	if d > cl.Radius {
+		v.halfAngle = math.Acos(cl.Radius / d)
+		v.bodyArea = 2 * v.halfAngle * cl.Radius * cl.height
+	}
+	if cl.Capped {
+		switch {
+		case h > cl.height:
+			v.capCenter, v.capNormal, v.capArea = cl.TopCenter, cl.axis, math.Pi*cl.Radius*cl.Radius
+		case h < 0:
+			v.capCenter, v.capNormal, v.capArea = cl.BaseCenter, cl.axis.Negate(), math.Pi*cl.Radius*cl.Radius
+		}
+	}
+	return v
+}
+
+// perpendicular returns a unit vector perpendicular to a unit vector
+func perpendicular(w core.Vec3) core.Vec3 {
+	if math.Abs(w.X) > 0.9 {
+		return w.Cross(core.NewVec3(0, 1, 0)).Normalize()
+	}
+	return w.Cross(core.NewVec3(1, 0, 0)).Normalize()
+}
+
+// Sample implements the Light interface - samples a point uniformly over the surface facing the
+// shading point
+func (cl *CylinderLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	v := cl.visible(point)
+	area := v.area()
+	if area == 0 {
+		return LightSample{PDF: 0}
+	}
+
+	// Pick the body or the cap by their shares of the visible area, then reuse the remapped sample
+	var samplePoint, lightNormal core.Vec3
+	if u := sample.X * area; u < v.bodyArea {
+		phi := v.halfAngle * (2*sample.Y - 1)
+		lightNormal = v.radial.Multiply(math.Cos(phi)).Add(v.tangent.Multiply(math.Sin(phi)))
+		samplePoint = cl.BaseCenter.Add(cl.axis.Multiply(u / v.bodyArea * cl.height)).Add(lightNormal.Multiply(cl.Radius))
+	} else {
+		r := cl.Radius * math.Sqrt((u-v.bodyArea)/v.capArea)
+		theta := 2 * math.Pi * sample.Y
+		lightNormal = v.capNormal
+		samplePoint = v.capCenter.Add(v.radial.Multiply(r * math.Cos(theta))).Add(v.tangent.Multiply(r * math.Sin(theta)))
+	}
+
+	toLight := samplePoint.Subtract(point)
+	distance := toLight.Length()
+	if distance == 0 {
+		return LightSample{Point: samplePoint, Normal: lightNormal, PDF: 0}
+	}
+	direction := toLight.Multiply(1.0 / distance)
+	cosTheta := -lightNormal.Dot(direction)
+	if cosTheta < 1e-8 {
+		return LightSample{Point: samplePoint, Normal: lightNormal, Direction: direction, Distance: distance, PDF: 0}
+	}
+
+	return LightSample{
+		Point:     samplePoint,
+		Normal:    lightNormal,
+		Direction: direction,
+		Distance:  distance,
+		Emission:  cl.Emit(core.NewRay(point, direction), nil),
+		PDF:       distance * distance / (area * cosTheta),
+	}
+}
+
+// PDF implements the Light interface - returns the probability density for sampling a given direction
+func (cl *CylinderLight) PDF(point, normal, direction core.Vec3) float64 {
+	hitRecord, hit := cl.Cylinder.Hit(core.NewRay(point, direction), 0.001, math.Inf(1))
+	if !hit || !hitRecord.FrontFace {
+		return 0.0
+	}
+	cosTheta := math.Abs(hitRecord.Normal.Dot(direction))
+	area := cl.visible(point).area()
+	if cosTheta < 1e-8 || area == 0 {
+		return 0.0
+	}
+	return hitRecord.T * hitRecord.T / (area * cosTheta)
+}
+
+// SampleEmission implements the Light interface - samples emission uniformly over the whole surface,
+// as light paths have no point to face
+func (cl *CylinderLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	point, normal := cl.SampleSurface(samplePoint)
+	return SampleEmissionDirection(point, normal, 1.0/cl.SurfaceArea(), cl.Material, sampleDirection)
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (cl *CylinderLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	pdfPos = cl.AreaPDF(point)
+	if pdfPos == 0 {
+		return 0.0, 0.0
+	}
+
+	// Directional PDF: cosine-weighted hemisphere for Lambertian emission
+	cosTheta := direction.Dot(cl.NormalAt(point))
+	if cosTheta <= 0 {
+		return pdfPos, 0.0
+	}
+	return pdfPos, cosTheta / math.Pi
+}
+
+// Emit implements the Light interface - returns material emission
+func (cl *CylinderLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	if emitter, isEmissive := cl.Material.(material.Emitter); isEmissive {
+		return emitter.Emit(ray, hit)
+	}
+	return core.Vec3{X: 0, Y: 0, Z: 0}
+}
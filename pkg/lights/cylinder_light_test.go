@@ -0,0 +1,103 @@
+package lights
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestCylinderLight_SamplePDFConsistency(t *testing.T) {
+	emissiveMat := material.NewEmissive(core.NewVec3(1, 1, 1))
+	light := NewCylinderLight(core.NewVec3(0, 1, 0), core.NewVec3(0, 3, 0), 0.2, true, emissiveMat)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(7)))
+
+	// Beside the tube, and beyond its top cap where both the cap and the body face the point
+	for _, point := range []core.Vec3{core.NewVec3(1, 0, 0.5), core.NewVec3(0.5, 4, 0)} {
+		for i := 0; i < 200; i++ {
+			sample := light.Sample(point, core.NewVec3(0, 1, 0), sampler.Get2D())
+			if sample.PDF == 0 {
+				continue
+			}
+			if sample.Emission.IsZero() {
+				t.Fatalf("Point %v: sampled the back of the tube at %v", point, sample.Point)
+			}
+			if pdf := light.PDF(point, core.NewVec3(0, 1, 0), sample.Direction); math.Abs(pdf-sample.PDF) > 1e-6*sample.PDF {
+				t.Fatalf("Point %v: sample PDF %f, PDF for its direction %f", point, sample.PDF, pdf)
+			}
+		}
+	}
+}
+
+func TestCylinderLight_SolidAnglePDFIntegratesToOne(t *testing.T) {
+	// Sampling only the surface facing the point, the solid angle PDF integrates to one, where a
+	// ShapeLight's integrates to the fraction of the surface visible
+	emissiveMat := material.NewEmissive(core.NewVec3(1, 1, 1))
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(3)))
+	for _, capped := range []bool{false, true} {
+		light := NewCylinderLight(core.NewVec3(-1, 2, 0), core.NewVec3(1, 2, 0), 0.5, capped, emissiveMat)
+		point := core.NewVec3(1.5, 0, 0.5)
+
+		const numSamples = 200000
+		integral := 0.0
+		for i := 0; i < numSamples; i++ {
+			direction := core.SampleOnUnitSphere(sampler.Get2D())
+			integral += light.PDF(point, core.NewVec3(0, 1, 0), direction) * 4 * math.Pi
+		}
+		integral /= numSamples
+
+		if math.Abs(integral-1) > 0.02 {
+			t.Errorf("capped=%v: expected the solid angle PDF to integrate to 1, got %f", capped, integral)
+		}
+	}
+}
+
+func TestCylinderLight_MatchesShapeLight(t *testing.T) {
+	// Both estimate the same irradiance; the cylinder light with less variance
+	emissiveMat := material.NewEmissive(core.NewVec3(1, 1, 1))
+	base, top := core.NewVec3(-1, 2, 0), core.NewVec3(1, 2, 0)
+	cylinderLight := NewCylinderLight(base, top, 0.1, true, emissiveMat)
+	shapeLight := NewShapeLight(geometry.NewCylinder(base, top, 0.1, true, emissiveMat), emissiveMat)
+	point, normal := core.NewVec3(0.3, 0, 0.2), core.NewVec3(0, 1, 0)
+
+	estimate := func(light Light) (mean, variance float64) {
+		sampler := core.NewRandomSampler(rand.New(rand.NewSource(11)))
+		const numSamples = 100000
+		var sum, sumSquares float64
+		for i := 0; i < numSamples; i++ {
+			sample := light.Sample(point, normal, sampler.Get2D())
+			if sample.PDF == 0 {
+				continue
+			}
+			value := sample.Emission.X * math.Max(0, sample.Direction.Dot(normal)) / sample.PDF
+			sum += value
+			sumSquares += value * value
+		}
+		mean = sum / numSamples
+		return mean, sumSquares/numSamples - mean*mean
+	}
+
+	cylinderMean, cylinderVariance := estimate(cylinderLight)
+	shapeMean, shapeVariance := estimate(shapeLight)
+	if math.Abs(cylinderMean-shapeMean) > 0.02*shapeMean {
+		t.Errorf("Expected the same irradiance, got %f from the cylinder light and %f from the shape light", cylinderMean, shapeMean)
+	}
+	if cylinderVariance >= shapeVariance {
+		t.Errorf("Expected less variance than the shape light, got %f vs %f", cylinderVariance, shapeVariance)
+	}
+}
+
+func TestCylinderLight_InsideOpenTube(t *testing.T) {
+	// The inside of an open tube doesn't emit, so a point on its axis has nothing to sample
+	light := NewCylinderLight(core.NewVec3(0, 0, 0), core.NewVec3(0, 2, 0), 0.5, false, material.NewEmissive(core.NewVec3(1, 1, 1)))
+	point := core.NewVec3(0, 1, 0)
+	if sample := light.Sample(point, core.NewVec3(1, 0, 0), core.NewVec2(0.5, 0.5)); sample.PDF != 0 {
+		t.Errorf("Expected no sample from inside the tube, got PDF %f", sample.PDF)
+	}
+	if pdf := light.PDF(point, core.NewVec3(1, 0, 0), core.NewVec3(1, 0, 0)); pdf != 0 {
+		t.Errorf("Expected PDF 0 toward the tube's inside, got %f", pdf)
+	}
+}
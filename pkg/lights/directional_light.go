@@ -0,0 +1,121 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// SunAngularDiameter is the sun's angular diameter seen from the earth, in degrees
+const SunAngularDiameter = 0.53
+
+// minAngularDiameter keeps directional lights from becoming delta lights, which the infinite light
+// code paths don't handle
+const minAngularDiameter = 1e-3
+
+// DirectionalLight is a distant light, like the sun, seen as a disc of uniform radiance covering
+// a small cone of directions. Its angular size gives shadows penumbrae of the right width.
+type DirectionalLight struct {
+	toLight     core.Vec3 // Normalized direction from the scene toward the disc's center
+	radiance    core.Vec3 // Radiance of the disc
+	cosMaxAngle float64   // Cosine of the disc's angular radius
+	worldCenter core.Vec3 // Finite scene center from BVH
+	worldRadius float64   // Finite scene radius from BVH
+}
+
+// NewDirectionalLight creates a directional light
+// direction: direction the light travels, e.g. from the sun toward the scene
+// irradiance: irradiance on a surface facing the light
+// angularDiameterDegrees: the disc's angular diameter (SunAngularDiameter for the sun)
+func NewDirectionalLight(direction, irradiance core.Vec3, angularDiameterDegrees float64) *DirectionalLight {
+	angularRadius := math.Max(angularDiameterDegrees, minAngularDiameter) * math.Pi / 360.0
+	sinMaxAngle := math.Sin(angularRadius)
+
+	return &DirectionalLight{
+		toLight:     direction.Normalize().Multiply(-1),
+		radiance:    irradiance.Multiply(1 / (math.Pi * sinMaxAngle * sinMaxAngle)),
+		cosMaxAngle: math.Cos(angularRadius),
+	}
+}
+
+func (dl *DirectionalLight) Type() LightType {
+	return LightTypeInfinite
+}
+
+// ConeCosine implements the ConeLight interface: the cone is the sun's disc
+func (dl *DirectionalLight) ConeCosine() float64 {
+	return dl.cosMaxAngle
+}
+
+// Direction returns the normalized direction the light travels in
+func (dl *DirectionalLight) Direction() core.Vec3 {
+	return dl.toLight.Multiply(-1)
+}
+
+// Irradiance returns the irradiance on a surface facing the light, as given to NewDirectionalLight
+func (dl *DirectionalLight) Irradiance() core.Vec3 {
+	sin2MaxAngle := 1 - dl.cosMaxAngle*dl.cosMaxAngle
+	return dl.radiance.Multiply(math.Pi * sin2MaxAngle)
+}
+
+// Sample implements the Light interface - samples a direction toward the disc uniformly
+func (dl *DirectionalLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	direction := core.SampleCone(dl.toLight, dl.cosMaxAngle, sample)
+
+	return LightSample{
+		Point:     point.Add(direction.Multiply(1e10)), // Far away point
+		Normal:    direction.Multiply(-1),              // Points toward scene
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Emission:  dl.radiance,
+		PDF:       UniformConePDF(dl.cosMaxAngle),
+	}
+}
+
+// PDF implements the Light interface - returns probability density for direct lighting sampling
+func (dl *DirectionalLight) PDF(point, normal, direction core.Vec3) float64 {
+	if direction.Normalize().Dot(dl.toLight) < dl.cosMaxAngle {
+		return 0.0
+	}
+	return UniformConePDF(dl.cosMaxAngle)
+}
+
+// SampleEmission implements the Light interface - samples parallel rays entering the scene from
+// a direction within the disc
+func (dl *DirectionalLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	direction := core.SampleCone(dl.toLight, dl.cosMaxAngle, sampleDirection).Multiply(-1)
+	origin := infiniteLightOrigin(dl.worldCenter, dl.worldRadius, direction, samplePoint)
+
+	return EmissionSample{
+		Point:        origin,
+		Normal:       direction.Multiply(-1), // Points toward the light
+		Direction:    direction,
+		Emission:     dl.radiance,
+		AreaPDF:      1.0 / (math.Pi * dl.worldRadius * dl.worldRadius),
+		DirectionPDF: UniformConePDF(dl.cosMaxAngle),
+	}
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (dl *DirectionalLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	if dl.worldRadius <= 0 || direction.Normalize().Dot(dl.toLight) > -dl.cosMaxAngle {
+		return 0.0, 0.0
+	}
+	return 1.0 / (math.Pi * dl.worldRadius * dl.worldRadius), UniformConePDF(dl.cosMaxAngle)
+}
+
+// Emit implements the Light interface - rays escaping the scene toward the disc see its radiance
+func (dl *DirectionalLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	if ray.Direction.Normalize().Dot(dl.toLight) < dl.cosMaxAngle {
+		return core.Vec3{}
+	}
+	return dl.radiance
+}
+
+// Preprocess implements the Preprocessor interface - sets world bounds from scene
+func (dl *DirectionalLight) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	dl.worldCenter = worldCenter
+	dl.worldRadius = worldRadius
+	return nil
+}
@@ -0,0 +1,83 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestDirectionalLight_Irradiance(t *testing.T) {
+	// The light delivers its irradiance to a surface facing it, whatever its size
+	for _, diameter := range []float64{SunAngularDiameter, 5, 30} {
+		light := NewDirectionalLight(core.NewVec3(0, -1, 0), core.NewVec3(2, 2, 2), diameter)
+		normal := core.NewVec3(0, 1, 0)
+		sampler := core.NewSeededSampler(3)
+
+		const samples = 10000
+		sum := 0.0
+		for i := 0; i < samples; i++ {
+			sample := light.Sample(core.Vec3{}, normal, sampler.Get2D())
+			if pdf := light.PDF(core.Vec3{}, normal, sample.Direction); math.Abs(pdf-sample.PDF) > 1e-9*pdf {
+				t.Fatalf("Diameter %f: sampled pdf %f, PDF returned %f", diameter, sample.PDF, pdf)
+			}
+			sum += sample.Emission.X * sample.Direction.Dot(normal) / sample.PDF
+		}
+		if irradiance := sum / samples; math.Abs(irradiance-2) > 0.01 {
+			t.Errorf("Diameter %f: expected irradiance 2, got %f", diameter, irradiance)
+		}
+	}
+}
+
+func TestDirectionalLight_Emit(t *testing.T) {
+	light := NewDirectionalLight(core.NewVec3(0, -1, 0), core.NewVec3(1, 1, 1), 10)
+
+	// Rays escaping toward the disc see it; others, and directions outside it, don't
+	inside := core.NewVec3(math.Sin(4*math.Pi/180), math.Cos(4*math.Pi/180), 0)
+	outside := core.NewVec3(math.Sin(6*math.Pi/180), math.Cos(6*math.Pi/180), 0)
+	if light.Emit(core.NewRay(core.Vec3{}, inside), nil).IsZero() {
+		t.Error("Expected a ray 4° from the light's center to see it")
+	}
+	if !light.Emit(core.NewRay(core.Vec3{}, outside), nil).IsZero() {
+		t.Error("Expected a ray 6° from the light's center to miss it")
+	}
+	if light.PDF(core.Vec3{}, core.NewVec3(0, 1, 0), outside) != 0 {
+		t.Error("Expected no density outside the disc")
+	}
+	if !light.Emit(core.NewRay(core.Vec3{}, core.NewVec3(0, -1, 0)), nil).IsZero() {
+		t.Error("Expected a ray away from the light to miss it")
+	}
+
+	// Its cone is the disc, 5° in radius
+	var cone Light = light
+	if c, ok := cone.(ConeLight); !ok || math.Abs(c.ConeCosine()-math.Cos(5*math.Pi/180)) > 1e-12 {
+		t.Errorf("Expected a cone light of the disc's 5° radius, got %#v", cone)
+	}
+}
+
+func TestDirectionalLight_SampleEmission(t *testing.T) {
+	light := NewDirectionalLight(core.NewVec3(1, -1, 0), core.NewVec3(1, 1, 1), 2)
+	if err := light.Preprocess(core.NewVec3(0, 0, 0), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	sampler := core.NewSeededSampler(9)
+	travel := core.NewVec3(1, -1, 0).Normalize()
+	for i := 0; i < 100; i++ {
+		sample := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		if sample.Direction.Dot(travel) < math.Cos(math.Pi/180) {
+			t.Fatalf("Expected rays within 1° of the light's direction, got %v", sample.Direction)
+		}
+		// Rays start outside the scene, heading into it
+		if sample.Point.Length() < 10-1e-9 || sample.Point.Dot(sample.Direction) > 0 {
+			t.Fatalf("Expected the ray to start outside the scene behind its center, got %v", sample.Point)
+		}
+		pdfPos, pdfDir := light.PDF_Le(sample.Point, sample.Direction)
+		if math.Abs(pdfPos-sample.AreaPDF) > 1e-12 || math.Abs(pdfDir-sample.DirectionPDF) > 1e-9*pdfDir {
+			t.Fatalf("PDF_Le gave (%f, %f), sampling (%f, %f)", pdfPos, pdfDir, sample.AreaPDF, sample.DirectionPDF)
+		}
+	}
+	if _, pdfDir := light.PDF_Le(core.Vec3{}, travel.Multiply(-1)); pdfDir != 0 {
+		t.Error("Expected no density for rays traveling toward the light")
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
 
-// DiscLight represents a circular area light
+// DiscLight represents a circular (or ring-shaped, see NewAnnulusLight) area light
 type DiscLight struct {
 	*geometry.Disc // Embed disc for hit testing
 }
@@ -21,6 +21,14 @@ func NewDiscLight(center, normal core.Vec3, radius float64, material material.Ma
 	}
 }
 
+// NewAnnulusLight creates a ring light between innerRadius and outerRadius, like the ring lights
+// used in studio photography. Panics unless 0 <= innerRadius < outerRadius
+func NewAnnulusLight(center, normal core.Vec3, innerRadius, outerRadius float64, material material.Material) *DiscLight {
+	return &DiscLight{
+		Disc: geometry.NewAnnulus(center, normal, innerRadius, outerRadius, material),
+	}
+}
+
 func (dl *DiscLight) Type() LightType {
 	return LightTypeArea
 }
@@ -48,8 +56,8 @@ func (dl *DiscLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2)
 	}
 
 	// Calculate PDF
-	// For uniform sampling on disc: PDF = 1 / (π * radius²)
-	pdf := 1.0 / (math.Pi * dl.Radius * dl.Radius)
+	// For uniform sampling on disc: PDF = 1 / area
+	pdf := 1.0 / dl.SurfaceArea()
 
 	// Convert to solid angle PDF
 	cosTheta := math.Abs(normal.Dot(dirNormalized.Multiply(-1)))
@@ -85,7 +93,7 @@ func (dl *DiscLight) PDF(point, normal, direction core.Vec3) float64 {
 
 	// Calculate solid angle PDF
 	// First get the area PDF
-	areaPDF := 1.0 / (math.Pi * dl.Radius * dl.Radius)
+	areaPDF := 1.0 / dl.SurfaceArea()
 
 	// Convert to solid angle using the actual hit point
 	distance := hitRecord.T
@@ -104,14 +112,14 @@ func (dl *DiscLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.
 	point, normal := dl.Disc.SampleUniform(samplePoint)
 
 	// Use shared emission sampling function
-	areaPDF := 1.0 / (math.Pi * dl.Radius * dl.Radius)
+	areaPDF := 1.0 / dl.SurfaceArea()
 	return SampleEmissionDirection(point, normal, areaPDF, dl.Material, sampleDirection)
 }
 
 // PDF_Le implements the Light interface - returns both position and directional PDFs
 func (dl *DiscLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
 	// Validate point is on disc surface
-	if !validatePointOnDisc(point, dl.Center, dl.Normal, dl.Radius, 0.001) {
+	if !validatePointOnDisc(point, dl.Center, dl.Normal, dl.InnerRadius, dl.Radius, 0.001) {
 		return 0.0, 0.0
 	}
 
@@ -121,7 +129,7 @@ func (dl *DiscLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDi
 	}
 
 	// Position PDF: uniform sampling over disc area
-	pdfPos = 1.0 / (math.Pi * dl.Radius * dl.Radius)
+	pdfPos = 1.0 / dl.SurfaceArea()
 
 	// Directional PDF: cosine-weighted hemisphere for Lambertian emission
 	cosTheta := direction.Dot(dl.Normal)
@@ -139,8 +147,8 @@ func (dl *DiscLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.V
 	return core.Vec3{X: 0, Y: 0, Z: 0}
 }
 
-// ValidatePointOnDisc checks if a point lies on a disc surface within tolerance
-func validatePointOnDisc(point core.Vec3, center core.Vec3, normal core.Vec3, radius float64, tolerance float64) bool {
+// ValidatePointOnDisc checks if a point lies on a disc (or annulus) surface within tolerance
+func validatePointOnDisc(point core.Vec3, center core.Vec3, normal core.Vec3, innerRadius, radius float64, tolerance float64) bool {
 	toPoint := point.Subtract(center)
 
 	// Check distance to plane
@@ -151,5 +159,6 @@ func validatePointOnDisc(point core.Vec3, center core.Vec3, normal core.Vec3, ra
 
 	// Check if within disc radius
 	projectedPoint := toPoint.Subtract(normal.Multiply(toPoint.Dot(normal)))
-	return projectedPoint.Length() <= radius
+	distance := projectedPoint.Length()
+	return distance <= radius && distance >= innerRadius
 }
@@ -408,3 +408,69 @@ func TestDiscLight_EmissionSampling_Coverage(t *testing.T) {
 		t.Errorf("Center region poorly sampled: %f ratio (expected ~%f)", actualCenterRatio, expectedCenterRatio)
 	}
 }
+
+func TestAnnulusLight_SamplePDFConsistency(t *testing.T) {
+	// Ring light facing down toward the origin
+	center := core.NewVec3(0, 2, 0)
+	light := NewAnnulusLight(center, core.NewVec3(0, -1, 0), 0.5, 1.0, material.NewEmissive(core.NewVec3(5, 5, 5)))
+	point := core.NewVec3(0.3, 0, 0.1)
+	normal := core.NewVec3(0, 1, 0)
+
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(7)))
+	const numSamples = 20000
+	solidAngle := 0.0
+	for i := 0; i < numSamples; i++ {
+		sample := light.Sample(point, normal, sampler.Get2D())
+		if distance := sample.Point.Subtract(center).Length(); distance < 0.5-1e-9 || distance > 1.0+1e-9 {
+			t.Fatalf("Sample point at distance %v from the center, outside the ring", distance)
+		}
+		if pdf := light.PDF(point, normal, sample.Direction); math.Abs(pdf-sample.PDF) > 1e-6*sample.PDF {
+			t.Fatalf("Sample PDF %v differs from PDF %v for the same direction", sample.PDF, pdf)
+		}
+		solidAngle += 1.0 / sample.PDF
+	}
+	solidAngle /= numSamples
+
+	// Compare with the solid angle found by shooting uniform directions at the ring
+	const numDirections = 200000
+	hits := 0
+	for i := 0; i < numDirections; i++ {
+		if light.PDF(point, normal, core.SampleOnUnitSphere(sampler.Get2D())) > 0 {
+			hits++
+		}
+	}
+	expected := 4 * math.Pi * float64(hits) / numDirections
+	if math.Abs(solidAngle-expected) > 0.03*expected {
+		t.Errorf("Light sampling estimates solid angle %v, direction sampling %v", solidAngle, expected)
+	}
+
+	// Directions through the hole don't reach the light
+	if pdf := light.PDF(core.NewVec3(0, 0, 0), normal, core.NewVec3(0, 1, 0)); pdf != 0 {
+		t.Errorf("Expected zero PDF through the hole, got %v", pdf)
+	}
+}
+
+func TestAnnulusLight_Emission(t *testing.T) {
+	light := NewAnnulusLight(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), 1.0, 2.0, material.NewEmissive(core.NewVec3(5, 5, 5)))
+	expectedAreaPDF := 1.0 / (3 * math.Pi)
+
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(3)))
+	for i := 0; i < 1000; i++ {
+		sample := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		if distance := sample.Point.Length(); distance < 1.0-1e-9 || distance > 2.0+1e-9 {
+			t.Fatalf("Emission point at distance %v from the center, outside the ring", distance)
+		}
+		if math.Abs(sample.AreaPDF-expectedAreaPDF) > 1e-12 {
+			t.Fatalf("Expected area PDF %v, got %v", expectedAreaPDF, sample.AreaPDF)
+		}
+		pdfPos, pdfDir := light.PDF_Le(sample.Point, sample.Direction)
+		if math.Abs(pdfPos-sample.AreaPDF) > 1e-12 || math.Abs(pdfDir-sample.DirectionPDF) > 1e-12 {
+			t.Fatalf("PDF_Le (%v, %v) differs from the sample's (%v, %v)", pdfPos, pdfDir, sample.AreaPDF, sample.DirectionPDF)
+		}
+	}
+
+	// Points in the hole aren't on the light
+	if pdfPos, _ := light.PDF_Le(core.NewVec3(0.5, 0, 0), core.NewVec3(0, 1, 0)); pdfPos != 0 {
+		t.Errorf("Expected zero position PDF in the hole, got %v", pdfPos)
+	}
+}
@@ -190,6 +190,26 @@ func (dsl *DiscSpotLight) GetDisc() *geometry.Disc {
 	return dsl.discLight.Disc
 }
 
+// Position returns the center of the light's disc
+func (dsl *DiscSpotLight) Position() core.Vec3 {
+	return dsl.position
+}
+
+// Direction returns the normalized direction the light is aimed in
+func (dsl *DiscSpotLight) Direction() core.Vec3 {
+	return dsl.direction
+}
+
+// Emission returns the radiance of the disc inside the cone
+func (dsl *DiscSpotLight) Emission() core.Vec3 {
+	return dsl.emission
+}
+
+// ConeAngles returns the cone's angle and falloff transition angle in degrees, as given to NewDiscSpotLight
+func (dsl *DiscSpotLight) ConeAngles() (coneAngleDegrees, coneDeltaAngleDegrees float64) {
+	return coneAngles(dsl.cosTotalWidth, dsl.cosFalloffStart)
+}
+
 // SampleEmission implements the Light interface - samples emission from the disc spot light surface
 func (dsl *DiscSpotLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
 	// Sample a point on the disc
@@ -67,6 +67,11 @@ func (gil *GradientInfiniteLight) GetMaterial() material.Material {
 	return gil.material
 }
 
+// Colors returns the radiance from straight up and straight down
+func (gil *GradientInfiniteLight) Colors() (top, bottom core.Vec3) {
+	return gil.topColor, gil.bottomColor
+}
+
 // emissionForDirection calculates gradient emission for a given direction
 func (gil *GradientInfiniteLight) emissionForDirection(direction core.Vec3) core.Vec3 {
 	t := 0.5 * (direction.Y + 1.0) // Map Y from [-1,1] to [0,1]
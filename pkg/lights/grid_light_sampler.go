@@ -0,0 +1,217 @@
+package lights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+const (
+	gridImportanceSamples = 12  // Light samples per light and cell when estimating importance
+	gridUniformFraction   = 0.1 // Share of each cell's probability spread evenly over all lights
+)
+
+// gridProbeNormals are the normals light samples are taken with when estimating importance, so
+// lights that only illuminate a hemisphere (infinite lights) are probed from every side
+var gridProbeNormals = []core.Vec3{
+	core.NewVec3(1, 0, 0), core.NewVec3(-1, 0, 0),
+	core.NewVec3(0, 1, 0), core.NewVec3(0, -1, 0),
+	core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1),
+}
+
+// GridLightSampler selects lights by their importance in the region of the shading point. A uniform
+// grid over the scene's bounds caches each cell's light probabilities, estimated from the unoccluded
+// light arriving at the cell's center, so lights near a point are chosen more often without any
+// per-point cost. Part of every cell's probability is spread evenly over all lights so that lights
+// hidden from the center (the estimate ignores occlusion) are still sampled.
+// Emission sampling (BDPT light paths) has no shading point and is uniform.
+type GridLightSampler struct {
+	lights     []Light
+	resolution int // Cells along the longest axis of the scene's bounds
+
+	lightGrid
+	cdfs []float64 // Per cell, the cumulative probabilities of the lights
+}
+
+// lightGrid is a uniform grid of cells over the scene's bounds, shared by the spatial light samplers
+type lightGrid struct {
+	bounds   geometry.AABB
+	cells    [3]int
+	cellSize core.Vec3
+}
+
+// newLightGrid divides bounds into cubic-ish cells, resolution of them along the longest axis
+func newLightGrid(bounds geometry.AABB, resolution int) lightGrid {
+	g := lightGrid{bounds: bounds}
+	size := bounds.Size()
+	longest := math.Max(size.X, math.Max(size.Y, size.Z))
+	for axis, extent := range [3]float64{size.X, size.Y, size.Z} {
+		g.cells[axis] = 1
+		if longest > 0 {
+			g.cells[axis] = max(1, int(math.Ceil(float64(resolution)*extent/longest)))
+		}
+	}
+	g.cellSize = core.NewVec3(size.X/float64(g.cells[0]), size.Y/float64(g.cells[1]), size.Z/float64(g.cells[2]))
+	return g
+}
+
+// cellCount returns the number of cells in the grid
+func (g *lightGrid) cellCount() int {
+	return g.cells[0] * g.cells[1] * g.cells[2]
+}
+
+// cellIndex returns the index of grid cell (x, y, z)
+func (g *lightGrid) cellIndex(x, y, z int) int {
+	return (z*g.cells[1]+y)*g.cells[0] + x
+}
+
+// cellPoint returns the point at fractions offset through the extent of grid cell (x, y, z)
+func (g *lightGrid) cellPoint(x, y, z int, offset core.Vec3) core.Vec3 {
+	return g.bounds.Min.Add(core.NewVec3(
+		(float64(x)+offset.X)*g.cellSize.X, (float64(y)+offset.Y)*g.cellSize.Y, (float64(z)+offset.Z)*g.cellSize.Z))
+}
+
+// cellAt returns the index of the cell containing the point; points outside the bounds use the
+// nearest cell
+func (g *lightGrid) cellAt(point core.Vec3) int {
+	var c [3]int
+	offset := point.Subtract(g.bounds.Min)
+	offsets := [3]float64{offset.X, offset.Y, offset.Z}
+	for axis, size := range [3]float64{g.cellSize.X, g.cellSize.Y, g.cellSize.Z} {
+		if size > 0 { // Flat axes have a single cell
+			c[axis] = int(math.Min(math.Max(offsets[axis]/size, 0), float64(g.cells[axis]-1)))
+		}
+	}
+	return g.cellIndex(c[0], c[1], c[2])
+}
+
+// NewGridLightSampler creates a light sampler with a grid of resolution cells along the longest axis
+// of the scene's bounds. The grid is built when the scene is preprocessed.
+func NewGridLightSampler(lights []Light, resolution int) *GridLightSampler {
+	if resolution < 1 {
+		panic(fmt.Sprintf("grid light sampler resolution must be at least 1, got %d", resolution))
+	}
+	return &GridLightSampler{lights: lights, resolution: resolution}
+}
+
+// Preprocess builds the grid over the scene's bounds (see SpatialLightSampler)
+func (gls *GridLightSampler) Preprocess(bounds geometry.AABB) error {
+	gls.lightGrid = newLightGrid(bounds, gls.resolution)
+	n := len(gls.lights)
+	gls.cdfs = make([]float64, gls.cellCount()*n)
+	if n == 0 {
+		return nil
+	}
+
+	importance := make([]float64, n)
+	for z := 0; z < gls.cells[2]; z++ {
+		for y := 0; y < gls.cells[1]; y++ {
+			for x := 0; x < gls.cells[0]; x++ {
+				center := gls.cellPoint(x, y, z, core.NewVec3(0.5, 0.5, 0.5))
+				total := 0.0
+				for i, light := range gls.lights {
+					importance[i] = estimateLightImportance(light, center)
+					total += importance[i]
+				}
+
+				cdf := gls.cdfs[gls.cellIndex(x, y, z)*n:][:n]
+				cumulative := 0.0
+				for i := range importance {
+					p := 1.0 / float64(n)
+					if total > 0 {
+						p = (1-gridUniformFraction)*importance[i]/total + gridUniformFraction/float64(n)
+					}
+					cumulative += p
+					cdf[i] = cumulative
+				}
+				cdf[n-1] = 1 // Guard against rounding
+			}
+		}
+	}
+	return nil
+}
+
+// estimateLightImportance estimates the luminance of the unoccluded light from a light arriving at
+// a point, from all directions
+func estimateLightImportance(light Light, point core.Vec3) float64 {
+	sampler := core.NewSeededSampler(1)
+	total := 0.0
+	for i := 0; i < gridImportanceSamples; i++ {
+		normal := gridProbeNormals[i%len(gridProbeNormals)]
+		sample := light.Sample(point, normal, sampler.Get2D())
+		if sample.PDF > 0 && !math.IsInf(sample.PDF, 0) {
+			total += sample.Emission.Luminance() / sample.PDF
+		}
+	}
+	return total / gridImportanceSamples
+}
+
+// cellCDF returns the cumulative light probabilities of the cell containing the point; points
+// outside the bounds use the nearest cell
+func (gls *GridLightSampler) cellCDF(point core.Vec3) []float64 {
+	if gls.cdfs == nil {
+		panic("GridLightSampler used before the scene was preprocessed")
+	}
+	n := len(gls.lights)
+	return gls.cdfs[gls.cellAt(point)*n:][:n]
+}
+
+// SampleLight selects a light with the probabilities of the cell containing the point
+// Returns the selected light, its selection probability, and its index
+func (gls *GridLightSampler) SampleLight(point core.Vec3, normal core.Vec3, u float64) (Light, float64, int) {
+	if len(gls.lights) == 0 {
+		return nil, 0.0, -1
+	}
+	cdf := gls.cellCDF(point)
+	i := min(sort.SearchFloat64s(cdf, u), len(cdf)-1)
+	return gls.lights[i], probabilityFromCDF(cdf, i), i
+}
+
+// SampleLightEmission selects a light uniformly for emission sampling
+// Returns the selected light, its selection probability, and its index
+func (gls *GridLightSampler) SampleLightEmission(u float64) (Light, float64, int) {
+	n := len(gls.lights)
+	if n == 0 {
+		return nil, 0.0, -1
+	}
+	i := min(int(u*float64(n)), n-1)
+	return gls.lights[i], 1.0 / float64(n), i
+}
+
+// GetLightProbability returns the probability of the light at the given index in the cell
+// containing the point
+func (gls *GridLightSampler) GetLightProbability(lightIndex int, point core.Vec3, normal core.Vec3) float64 {
+	if lightIndex < 0 || lightIndex >= len(gls.lights) {
+		return 0.0
+	}
+	return probabilityFromCDF(gls.cellCDF(point), lightIndex)
+}
+
+// GetLightEmissionProbability returns the uniform emission selection probability
+func (gls *GridLightSampler) GetLightEmissionProbability(lightIndex int) float64 {
+	if lightIndex < 0 || lightIndex >= len(gls.lights) {
+		return 0.0
+	}
+	return 1.0 / float64(len(gls.lights))
+}
+
+// GetLightCount returns the number of lights in this sampler
+func (gls *GridLightSampler) GetLightCount() int {
+	return len(gls.lights)
+}
+
+// probabilityFromCDF returns the probability of entry i of a cumulative distribution
+func probabilityFromCDF(cdf []float64, i int) float64 {
+	if i == 0 {
+		return cdf[0]
+	}
+	return cdf[i] - cdf[i-1]
+}
+
+// String returns a string representation for debugging
+func (gls *GridLightSampler) String() string {
+	return fmt.Sprintf("GridLightSampler{%d lights, %dx%dx%d cells}", len(gls.lights), gls.cells[0], gls.cells[1], gls.cells[2])
+}
@@ -0,0 +1,127 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// newTwoLightGridSampler returns a preprocessed grid sampler for two equal sphere lights at
+// opposite ends of a 20 unit long box
+func newTwoLightGridSampler(t *testing.T, resolution int) *GridLightSampler {
+	t.Helper()
+	emissive := material.NewEmissive(core.NewVec3(5, 5, 5))
+	lights := []Light{
+		NewSphereLight(core.NewVec3(-9, 0, 0), 0.5, emissive),
+		NewSphereLight(core.NewVec3(9, 0, 0), 0.5, emissive),
+	}
+	gls := NewGridLightSampler(lights, resolution)
+	if err := gls.Preprocess(geometry.NewAABB(core.NewVec3(-10, -2, -2), core.NewVec3(10, 2, 2))); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	return gls
+}
+
+func TestGridLightSampler_Cells(t *testing.T) {
+	gls := newTwoLightGridSampler(t, 10)
+	if gls.cells != [3]int{10, 2, 2} {
+		t.Errorf("Expected 10x2x2 cells for a 20x4x4 box, got %v", gls.cells)
+	}
+}
+
+func TestGridLightSampler_ProbabilitiesSumToOne(t *testing.T) {
+	gls := newTwoLightGridSampler(t, 10)
+	for x := -12.0; x <= 12; x += 1.5 {
+		point := core.NewVec3(x, 0.3, -0.7)
+		sum := 0.0
+		for i := 0; i < gls.GetLightCount(); i++ {
+			sum += gls.GetLightProbability(i, point, core.NewVec3(0, 1, 0))
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("Probabilities at %v sum to %f, expected 1", point, sum)
+		}
+	}
+}
+
+func TestGridLightSampler_FavorsNearbyLight(t *testing.T) {
+	gls := newTwoLightGridSampler(t, 10)
+	normal := core.NewVec3(0, 1, 0)
+
+	near := gls.GetLightProbability(0, core.NewVec3(-8, 0, 0), normal)
+	far := gls.GetLightProbability(1, core.NewVec3(-8, 0, 0), normal)
+	if near <= 0.8 || far >= 0.2 {
+		t.Errorf("Expected the nearby light to dominate near it, got near=%f far=%f", near, far)
+	}
+
+	// Every light keeps its share of the uniform probability
+	if minimum := gridUniformFraction / 2; far < minimum {
+		t.Errorf("Expected the far light's probability to be at least %f, got %f", minimum, far)
+	}
+
+	// The middle of the box sees both lights equally
+	middle := gls.GetLightProbability(0, core.NewVec3(0.5, 0, 0), normal)
+	if math.Abs(middle-0.5) > 0.1 {
+		t.Errorf("Expected about equal probabilities in the middle, got %f", middle)
+	}
+}
+
+func TestGridLightSampler_SampleMatchesProbability(t *testing.T) {
+	gls := newTwoLightGridSampler(t, 10)
+	point := core.NewVec3(-8, 0, 0)
+	normal := core.NewVec3(0, 1, 0)
+
+	counts := make([]int, gls.GetLightCount())
+	const samples = 10000
+	for k := 0; k < samples; k++ {
+		u := (float64(k) + 0.5) / samples
+		light, prob, index := gls.SampleLight(point, normal, u)
+		if light != gls.lights[index] {
+			t.Fatalf("SampleLight returned light %d with a different light", index)
+		}
+		if expected := gls.GetLightProbability(index, point, normal); prob != expected {
+			t.Fatalf("SampleLight probability %f differs from GetLightProbability %f", prob, expected)
+		}
+		counts[index]++
+	}
+	for i, count := range counts {
+		expected := gls.GetLightProbability(i, point, normal)
+		if got := float64(count) / samples; math.Abs(got-expected) > 0.01 {
+			t.Errorf("Light %d selected with frequency %f, expected %f", i, got, expected)
+		}
+	}
+}
+
+func TestGridLightSampler_EmissionIsUniform(t *testing.T) {
+	gls := newTwoLightGridSampler(t, 10)
+	for i := 0; i < gls.GetLightCount(); i++ {
+		if p := gls.GetLightEmissionProbability(i); p != 0.5 {
+			t.Errorf("Expected emission probability 0.5 for light %d, got %f", i, p)
+		}
+	}
+	if _, prob, index := gls.SampleLightEmission(0.75); index != 1 || prob != 0.5 {
+		t.Errorf("Expected light 1 with probability 0.5, got light %d with %f", index, prob)
+	}
+}
+
+func TestGridLightSampler_FlatBounds(t *testing.T) {
+	emissive := material.NewEmissive(core.NewVec3(1, 1, 1))
+	gls := NewGridLightSampler([]Light{NewSphereLight(core.NewVec3(0, 1, 0), 0.5, emissive)}, 4)
+	if err := gls.Preprocess(geometry.NewAABB(core.NewVec3(-1, 0, -1), core.NewVec3(1, 0, 1))); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if p := gls.GetLightProbability(0, core.NewVec3(0, 5, 0), core.NewVec3(0, 1, 0)); p != 1 {
+		t.Errorf("Expected probability 1 for the only light, got %f", p)
+	}
+}
+
+func TestNewGridLightSampler_InvalidResolution(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for resolution 0")
+		}
+	}()
+	NewGridLightSampler(nil, 0)
+}
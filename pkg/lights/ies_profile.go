@@ -0,0 +1,126 @@
+package lights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// IESProfile is a measured luminous intensity distribution, as published by luminaire makers in
+// IES LM-63 files (type C photometry). Vertical angles are measured from the light's aim, and
+// horizontal angles around it from a reference direction.
+type IESProfile struct {
+	verticalAngles   []float64   // Degrees from the aim, increasing
+	horizontalAngles []float64   // Degrees around the aim, increasing from 0
+	candela          [][]float64 // Intensity per horizontal angle, per vertical angle
+	peak             float64     // Largest intensity, in candela
+}
+
+// NewIESProfile creates a profile from intensities in candela, one row per horizontal angle with
+// a value per vertical angle. As in IES files, a last horizontal angle of 0, 90 or 180 degrees
+// means the distribution is symmetric about the aim, in each quadrant, or about the 0-180 plane.
+func NewIESProfile(verticalAngles, horizontalAngles []float64, candela [][]float64) (*IESProfile, error) {
+	if len(verticalAngles) == 0 || len(horizontalAngles) == 0 {
+		return nil, fmt.Errorf("IES profile needs at least one vertical and one horizontal angle")
+	}
+	if !sort.Float64sAreSorted(verticalAngles) || !sort.Float64sAreSorted(horizontalAngles) {
+		return nil, fmt.Errorf("IES profile angles must be increasing")
+	}
+	if len(candela) != len(horizontalAngles) {
+		return nil, fmt.Errorf("IES profile has %d horizontal angles but %d rows of intensities", len(horizontalAngles), len(candela))
+	}
+
+	peak := 0.0
+	for _, row := range candela {
+		if len(row) != len(verticalAngles) {
+			return nil, fmt.Errorf("IES profile has %d vertical angles but a row of %d intensities", len(verticalAngles), len(row))
+		}
+		for _, value := range row {
+			peak = math.Max(peak, value)
+		}
+	}
+	if peak <= 0 {
+		return nil, fmt.Errorf("IES profile emits no light")
+	}
+
+	return &IESProfile{
+		verticalAngles:   verticalAngles,
+		horizontalAngles: horizontalAngles,
+		candela:          candela,
+		peak:             peak,
+	}, nil
+}
+
+// PeakCandela returns the profile's largest intensity
+func (p *IESProfile) PeakCandela() float64 {
+	return p.peak
+}
+
+// Intensity returns the intensity at the given angles in degrees, relative to the peak, by
+// bilinear interpolation. Directions beyond the measured vertical angles get no light.
+func (p *IESProfile) Intensity(vertical, horizontal float64) float64 {
+	last := len(p.verticalAngles) - 1
+	if vertical < p.verticalAngles[0] || vertical > p.verticalAngles[last] {
+		return 0
+	}
+
+	horizontal = p.foldHorizontal(horizontal)
+	h, ht := interpolationIndex(p.horizontalAngles, horizontal)
+	v, vt := interpolationIndex(p.verticalAngles, vertical)
+	at := func(h, v int) float64 {
+		return p.candela[h][v]
+	}
+
+	value := at(h, v)
+	if v < last {
+		value = value*(1-vt) + at(h, v+1)*vt
+	}
+	if h < len(p.horizontalAngles)-1 {
+		next := at(h+1, v)
+		if v < last {
+			next = next*(1-vt) + at(h+1, v+1)*vt
+		}
+		value = value*(1-ht) + next*ht
+	}
+	return value / p.peak
+}
+
+// foldHorizontal maps a horizontal angle into the range the profile covers, using its symmetry
+func (p *IESProfile) foldHorizontal(horizontal float64) float64 {
+	horizontal = math.Mod(horizontal, 360)
+	if horizontal < 0 {
+		horizontal += 360
+	}
+
+	switch p.horizontalAngles[len(p.horizontalAngles)-1] {
+	case 0:
+		return 0
+	case 90:
+		// Symmetric in each quadrant
+		horizontal = math.Mod(horizontal, 180)
+		if horizontal > 90 {
+			horizontal = 180 - horizontal
+		}
+	case 180:
+		// Symmetric about the 0-180 plane
+		if horizontal > 180 {
+			horizontal = 360 - horizontal
+		}
+	}
+	return math.Min(math.Max(horizontal, p.horizontalAngles[0]), p.horizontalAngles[len(p.horizontalAngles)-1])
+}
+
+// interpolationIndex returns the index of the last angle at or below x and how far x is toward the next
+func interpolationIndex(angles []float64, x float64) (int, float64) {
+	i := sort.SearchFloat64s(angles, x)
+	if i < len(angles) && angles[i] == x {
+		return i, 0
+	}
+	if i == 0 {
+		return 0, 0
+	}
+	if i == len(angles) {
+		return len(angles) - 1, 0
+	}
+	return i - 1, (x - angles[i-1]) / (angles[i] - angles[i-1])
+}
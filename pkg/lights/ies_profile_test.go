@@ -0,0 +1,73 @@
+package lights
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIESProfile_Intensity(t *testing.T) {
+	// Quadrant symmetric: 0° and 90° planes measured
+	profile, err := NewIESProfile([]float64{0, 45, 90}, []float64{0, 90}, [][]float64{{200, 160, 0}, {200, 120, 0}})
+	if err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	if profile.PeakCandela() != 200 {
+		t.Errorf("Expected a peak of 200 cd, got %f", profile.PeakCandela())
+	}
+
+	tests := []struct {
+		vertical, horizontal, expected float64
+	}{
+		{0, 0, 1},
+		{45, 0, 0.8},
+		{45, 90, 0.6},
+		{22.5, 0, 0.9}, // Vertical interpolation
+		{45, 45, 0.7},  // Horizontal interpolation
+		{45, 180, 0.8}, // Mirrors the 0° plane
+		{45, 270, 0.6}, // Mirrors the 90° plane
+		{45, -90, 0.6}, // Negative angles wrap
+		{90, 0, 0},     // Horizon
+		{120, 0, 0},    // Beyond the measured angles
+	}
+	for _, tt := range tests {
+		if got := profile.Intensity(tt.vertical, tt.horizontal); math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("Intensity(%v, %v) = %f, expected %f", tt.vertical, tt.horizontal, got, tt.expected)
+		}
+	}
+}
+
+func TestIESProfile_Symmetry(t *testing.T) {
+	vertical := []float64{0, 90}
+	symmetric, _ := NewIESProfile(vertical, []float64{0}, [][]float64{{10, 5}})
+	bilateral, _ := NewIESProfile(vertical, []float64{0, 90, 180}, [][]float64{{10, 10}, {10, 5}, {10, 0}})
+	full, _ := NewIESProfile(vertical, []float64{0, 180, 360}, [][]float64{{10, 10}, {10, 0}, {10, 10}})
+
+	if symmetric.Intensity(90, 123) != 0.5 {
+		t.Error("Expected a profile with one horizontal angle to be the same all around")
+	}
+	if bilateral.Intensity(90, 270) != 0.5 || bilateral.Intensity(90, 200) != bilateral.Intensity(90, 160) {
+		t.Error("Expected a profile to 180° to mirror across the 0-180 plane")
+	}
+	if full.Intensity(90, 180) != 0 || full.Intensity(90, 90) != 0.5 || full.Intensity(90, 450) != 0.5 {
+		t.Error("Expected a full profile to be looked up directly")
+	}
+}
+
+func TestIESProfile_Errors(t *testing.T) {
+	tests := []struct {
+		name                 string
+		vertical, horizontal []float64
+		candela              [][]float64
+	}{
+		{"no angles", nil, []float64{0}, [][]float64{{}}},
+		{"decreasing", []float64{90, 0}, []float64{0}, [][]float64{{1, 1}}},
+		{"missing row", []float64{0, 90}, []float64{0, 90}, [][]float64{{1, 1}}},
+		{"short row", []float64{0, 90}, []float64{0}, [][]float64{{1}}},
+		{"dark", []float64{0, 90}, []float64{0}, [][]float64{{0, 0}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewIESProfile(tt.vertical, tt.horizontal, tt.candela); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package lights
+
+import (
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// ImageInfiniteLight is an environment map: an equirectangular image of the radiance arriving
+// from every direction, laid out as an equirectangular camera looking along -z with y up sees it,
+// so a panorama rendered there lights a scene as it looked. The image's center is -z, its top
+// row +y, and its left and right edges +z. Directions are sampled in proportion to
+// the map's brightness, so small bright areas such as a sun light the scene with little noise.
+type ImageInfiniteLight struct {
+	width, height int
+	pixels        []core.Vec3 // Radiance, row by row
+
+	// Sampling: the chance of each row, then of each pixel within its row, both as running totals
+	rowCDF    []float64 // height+1 entries, from 0 to 1
+	columnCDF []float64 // height rows of width+1 entries, each from 0 to 1
+	rowWeight []float64 // Each row's share of the total weight
+	weights   []float64 // Each pixel's brightness times the sine of its row's polar angle
+	total     float64   // Sum of the weights
+
+	worldCenter core.Vec3 // Finite scene center from BVH
+	worldRadius float64   // Finite scene radius from BVH
+}
+
+// NewImageInfiniteLight creates an environment map from an equirectangular image's pixels, row
+// by row, scaled by a color
+func NewImageInfiniteLight(width, height int, pixels []core.Vec3, scale core.Vec3) *ImageInfiniteLight {
+	light := &ImageInfiniteLight{
+		width:     width,
+		height:    height,
+		pixels:    make([]core.Vec3, len(pixels)),
+		rowCDF:    make([]float64, height+1),
+		columnCDF: make([]float64, height*(width+1)),
+		rowWeight: make([]float64, height),
+		weights:   make([]float64, width*height),
+	}
+	for i, p := range pixels {
+		light.pixels[i] = p.MultiplyVec(scale)
+	}
+
+	// Rows near the poles cover less of the sphere, so weigh in the sine of their angle
+	for y := 0; y < height; y++ {
+		sinTheta := math.Sin(math.Pi * (float64(y) + 0.5) / float64(height))
+		row := light.columnCDF[y*(width+1) : (y+1)*(width+1)]
+		for x := 0; x < width; x++ {
+			weight := light.pixels[y*width+x].Luminance() * sinTheta
+			light.weights[y*width+x] = math.Max(weight, 0)
+			row[x+1] = row[x] + light.weights[y*width+x]
+		}
+		light.rowWeight[y] = row[width]
+		if row[width] > 0 {
+			for x := range row {
+				row[x] /= row[width]
+			}
+		}
+		light.rowCDF[y+1] = light.rowCDF[y] + light.rowWeight[y]
+		light.total += light.rowWeight[y]
+	}
+	if light.total > 0 {
+		for y := range light.rowCDF {
+			light.rowCDF[y] /= light.total
+		}
+	}
+	return light
+}
+
+func (iil *ImageInfiniteLight) Type() LightType {
+	return LightTypeInfinite
+}
+
+// Size returns the width and height of the map's image
+func (iil *ImageInfiniteLight) Size() (int, int) {
+	return iil.width, iil.height
+}
+
+// direction returns the direction of continuous image coordinates (u, v) in [0, 1]², and the
+// sine of its angle from +y
+func (iil *ImageInfiniteLight) direction(u, v float64) (core.Vec3, float64) {
+	phi := 2 * math.Pi * (u - 0.5)
+	theta := math.Pi * v
+	sinTheta := math.Sin(theta)
+	return core.NewVec3(-sinTheta*math.Sin(phi), math.Cos(theta), -sinTheta*math.Cos(phi)), sinTheta
+}
+
+// pixel returns the index of the pixel a direction falls in, and the sine of its angle from +y
+func (iil *ImageInfiniteLight) pixel(direction core.Vec3) (int, float64) {
+	direction = direction.Normalize()
+	phi := math.Atan2(-direction.X, -direction.Z)
+	cosTheta := math.Max(-1, math.Min(1, direction.Y))
+	x := min(int((phi/(2*math.Pi)+0.5)*float64(iil.width)), iil.width-1)
+	y := min(int(math.Acos(cosTheta)/math.Pi*float64(iil.height)), iil.height-1)
+	return y*iil.width + x, math.Sqrt(1 - cosTheta*cosTheta)
+}
+
+// radiance returns the radiance arriving from a direction
+func (iil *ImageInfiniteLight) radiance(direction core.Vec3) core.Vec3 {
+	i, _ := iil.pixel(direction)
+	return iil.pixels[i]
+}
+
+// sampleDirection samples a direction in proportion to the map's weights, returning it with its
+// solid angle density
+func (iil *ImageInfiniteLight) sampleDirection(sample core.Vec2) (core.Vec3, float64) {
+	if iil.total == 0 {
+		return core.SampleOnUnitSphere(sample), 0
+	}
+	y, v := sampleCDF(iil.rowCDF, sample.X)
+	x, u := sampleCDF(iil.columnCDF[y*(iil.width+1):(y+1)*(iil.width+1)], sample.Y)
+	direction, sinTheta := iil.direction((float64(x)+u)/float64(iil.width), (float64(y)+v)/float64(iil.height))
+	return direction, iil.pdf(y*iil.width+x, sinTheta)
+}
+
+// sampleCDF picks the bin of a running total a uniform sample falls in, and where in the bin
+func sampleCDF(cdf []float64, u float64) (int, float64) {
+	bins := len(cdf) - 1
+	i := min(max(sort.SearchFloat64s(cdf[1:], u), 0), bins-1)
+	for cdf[i+1] == cdf[i] && i < bins-1 {
+		i++ // Skip empty bins a sample on their edge lands in
+	}
+	offset := 0.0
+	if width := cdf[i+1] - cdf[i]; width > 0 {
+		offset = math.Min((u-cdf[i])/width, 1)
+	}
+	return i, offset
+}
+
+// pdf converts the chance of a pixel, spread evenly over the image's [0, 1]², to a density over
+// solid angle: the image maps to 2π by π radians, stretched by 1 / sinθ along the rows
+func (iil *ImageInfiniteLight) pdf(pixel int, sinTheta float64) float64 {
+	if iil.total == 0 || sinTheta == 0 {
+		return 0
+	}
+	imagePDF := iil.weights[pixel] / iil.total * float64(iil.width*iil.height)
+	return imagePDF / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+// Sample implements the Light interface - samples a direction by the map's brightness
+func (iil *ImageInfiniteLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	direction, pdf := iil.sampleDirection(sample)
+	return LightSample{
+		Point:     point.Add(direction.Multiply(1e10)), // Far away point
+		Normal:    direction.Multiply(-1),              // Points toward scene
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Emission:  iil.radiance(direction),
+		PDF:       pdf,
+	}
+}
+
+// PDF implements the Light interface - returns probability density for direct lighting sampling
+func (iil *ImageInfiniteLight) PDF(point, normal, direction core.Vec3) float64 {
+	return iil.pdf(iil.pixel(direction))
+}
+
+// SampleEmission implements the Light interface - samples parallel rays arriving from a direction
+// chosen by the map's brightness
+func (iil *ImageInfiniteLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	toLight, _ := iil.sampleDirection(sampleDirection)
+	direction := toLight.Multiply(-1)
+	pdfPos, pdfDir := iil.PDF_Le(core.Vec3{}, direction)
+
+	return EmissionSample{
+		Point:        infiniteLightOrigin(iil.worldCenter, iil.worldRadius, direction, samplePoint),
+		Normal:       toLight, // Points toward the sky
+		Direction:    direction,
+		Emission:     iil.radiance(toLight),
+		AreaPDF:      pdfPos,
+		DirectionPDF: pdfDir,
+	}
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (iil *ImageInfiniteLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	if iil.worldRadius <= 0 {
+		return 0.0, 0.0
+	}
+	return 1.0 / (math.Pi * iil.worldRadius * iil.worldRadius), iil.PDF(point, core.Vec3{}, direction.Multiply(-1))
+}
+
+// Emit implements the Light interface - looks up the map along the ray's direction
+func (iil *ImageInfiniteLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	return iil.radiance(ray.Direction)
+}
+
+// Preprocess implements the Preprocessor interface - sets world bounds from scene
+func (iil *ImageInfiniteLight) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	iil.worldCenter = worldCenter
+	iil.worldRadius = worldRadius
+	return nil
+}
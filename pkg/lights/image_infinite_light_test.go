@@ -0,0 +1,94 @@
+package lights
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// testEnvironment returns an 8x4 map, dim but for one bright pixel above the horizon
+func testEnvironment() *ImageInfiniteLight {
+	pixels := make([]core.Vec3, 8*4)
+	for i := range pixels {
+		pixels[i] = core.NewVec3(0.1, 0.1, 0.1)
+	}
+	pixels[1*8+5] = core.NewVec3(50, 40, 30)
+	return NewImageInfiniteLight(8, 4, pixels, core.NewVec3(2, 2, 2))
+}
+
+func TestImageInfiniteLight_Emit(t *testing.T) {
+	pixels := make([]core.Vec3, 4*2)
+	for i := range pixels {
+		pixels[i] = core.NewVec3(float64(i), 0, 0)
+	}
+	light := NewImageInfiniteLight(4, 2, pixels, core.NewVec3(1, 1, 1))
+
+	tests := []struct {
+		name      string
+		direction core.Vec3
+		pixel     float64
+	}{
+		{"ahead, above the horizon, is right of the center", core.NewVec3(-0.01, 0.1, -1), 2},
+		{"ahead, below the horizon", core.NewVec3(-0.01, -0.1, -1), 6},
+		{"the image's right goes toward -x", core.NewVec3(-1, 0.1, 0.01), 3},
+		{"its left toward +x", core.NewVec3(1, 0.1, 0.01), 0},
+		{"behind is at the edges", core.NewVec3(0.01, -0.1, 1), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := light.Emit(core.NewRay(core.Vec3{}, tt.direction), nil).X; got != tt.pixel {
+				t.Errorf("Expected pixel %g along %v, got %g", tt.pixel, tt.direction, got)
+			}
+		})
+	}
+}
+
+func TestImageInfiniteLight_Sample(t *testing.T) {
+	light := testEnvironment()
+	random := rand.New(rand.NewSource(1))
+
+	// Samples report the density PDF gives them, and importance sampling estimates the map's
+	// integral over the sphere: each pixel's radiance times its solid angle
+	expected := 0.0
+	for y := 0; y < 4; y++ {
+		solidAngle := 2 * math.Pi / 8 * (math.Cos(math.Pi*float64(y)/4) - math.Cos(math.Pi*float64(y+1)/4))
+		for x := 0; x < 8; x++ {
+			expected += light.pixels[y*8+x].Y * solidAngle
+		}
+	}
+	const samples = 50000
+	sum, bright := 0.0, 0
+	for i := 0; i < samples; i++ {
+		sample := light.Sample(core.Vec3{}, core.NewVec3(0, 1, 0), core.NewVec2(random.Float64(), random.Float64()))
+		if pdf := light.PDF(core.Vec3{}, core.NewVec3(0, 1, 0), sample.Direction); math.Abs(pdf-sample.PDF) > 1e-9*pdf {
+			t.Fatalf("Expected the sample's PDF %g to match PDF's %g", sample.PDF, pdf)
+		}
+		sum += sample.Emission.Y / sample.PDF
+		if sample.Emission.Y > 1 {
+			bright++
+		}
+	}
+	if estimate := sum / samples; math.Abs(estimate-expected)/expected > 0.02 {
+		t.Errorf("Expected the estimated integral to be %g, got %g", expected, estimate)
+	}
+	if fraction := float64(bright) / samples; fraction < 0.9 {
+		t.Errorf("Expected most samples toward the bright pixel, got %g", fraction)
+	}
+}
+
+func TestImageInfiniteLight_SampleEmission(t *testing.T) {
+	light := testEnvironment()
+	if err := light.Preprocess(core.NewVec3(0, 0, 0), 10); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	emission := light.SampleEmission(core.NewVec2(0.3, 0.6), core.NewVec2(0.5, 0.5))
+	pdfPos, pdfDir := light.PDF_Le(emission.Point, emission.Direction)
+	if pdfPos != emission.AreaPDF || pdfDir != emission.DirectionPDF || pdfDir == 0 {
+		t.Errorf("Expected PDF_Le (%g, %g) to match the sample's (%g, %g)", pdfPos, pdfDir, emission.AreaPDF, emission.DirectionPDF)
+	}
+	if !emission.Emission.Equals(light.Emit(core.NewRay(core.Vec3{}, emission.Direction.Multiply(-1)), nil)) {
+		t.Errorf("Expected the emission of the direction the light comes from, got %v", emission.Emission)
+	}
+}
@@ -2,6 +2,7 @@ package lights
 
 import (
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
 
@@ -42,6 +43,16 @@ type Light interface {
 	Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3
 }
 
+// ConeLight is implemented by infinite lights that shine from a small cone of directions rather
+// than the whole sky, such as DirectionalLight. They sample within their cone already, so portals
+// can't guide them any better (see Scene.AddPortals).
+type ConeLight interface {
+	Light
+
+	// ConeCosine returns the cosine of the cone's half angle
+	ConeCosine() float64
+}
+
 // LightSample contains information about a sampled point on a light
 type LightSample struct {
 	Point     core.Vec3 // Point on the light source
@@ -73,6 +84,19 @@ type LightSampler interface {
 	// GetLightProbability returns the selection probability for a specific light at a surface point
 	GetLightProbability(lightIndex int, point core.Vec3, normal core.Vec3) float64
 
+	// GetLightEmissionProbability returns the probability that SampleLightEmission selects a specific light
+	GetLightEmissionProbability(lightIndex int) float64
+
 	// GetLightCount returns the number of lights in this sampler
 	GetLightCount() int
 }
+
+// SpatialLightSampler is a LightSampler whose probabilities depend on the scene, such as ones that
+// vary over it and are cached per region. Scene.Preprocess calls Preprocess with the scene's bounds
+// once the lights are preprocessed.
+type SpatialLightSampler interface {
+	LightSampler
+
+	// Preprocess builds the sampler's cache over the given bounds
+	Preprocess(bounds geometry.AABB) error
+}
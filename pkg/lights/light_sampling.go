@@ -2,6 +2,7 @@ package lights
 
 import (
 	"math"
+	"sort"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
@@ -24,6 +25,83 @@ func CalculateLightPDF(lights []Light, lightSampler LightSampler, point, normal,
 	return totalPDF
 }
 
+// CalculateSelectedLightPDF calculates the combined PDF for a given direction toward multiple lights,
+// with each light weighted by the given selection probability (see SampleImportantLights)
+func CalculateSelectedLightPDF(lights []Light, selection []float64, point, normal, direction core.Vec3) float64 {
+	totalPDF := 0.0
+	for i, light := range lights {
+		if selection[i] > 0 {
+			totalPDF += light.PDF(point, normal, direction) * selection[i]
+		}
+	}
+	return totalPDF
+}
+
+// EvaluateInfiniteLights returns the total emission from all infinite lights for a ray that escaped the scene
+// This is the single entry point integrators use for background radiance, so new environment lights
+// only need to implement Light.Emit and report LightTypeInfinite
+func EvaluateInfiniteLights(lights []Light, ray core.Ray) core.Vec3 {
+	var totalEmission core.Vec3
+	for _, light := range lights {
+		if light.Type() == LightTypeInfinite {
+			totalEmission = totalEmission.Add(light.Emit(ray, nil))
+		}
+	}
+	return totalEmission
+}
+
+// InfiniteLightDensity sums the direct lighting PDFs of all infinite lights in the given direction,
+// weighted by their emission selection probability (PBRT's InfiniteLightDensity, which uses lightDistr)
+func InfiniteLightDensity(lights []Light, lightSampler LightSampler, point, normal, direction core.Vec3) float64 {
+	totalPDF := 0.0
+	for i, light := range lights {
+		if light.Type() == LightTypeInfinite {
+			lightPDF := light.PDF(point, normal, direction)
+			lightSelectionPdf := lightSampler.GetLightEmissionProbability(i)
+			totalPDF += lightPDF * lightSelectionPdf
+		}
+	}
+	return totalPDF
+}
+
+// FindAreaLight returns the area light whose surface contains point and emits toward direction
+// Integrators use it to identify the light behind an emissive hit, which is needed for its origin PDF.
+// Returns nil and -1 if the point isn't on any area light (e.g. an emissive material without a light).
+func FindAreaLight(lights []Light, point, direction core.Vec3) (Light, int) {
+	for i, light := range lights {
+		if light.Type() != LightTypeArea {
+			continue
+		}
+		if pdfPos, _ := light.PDF_Le(point, direction); pdfPos > 0 {
+			return light, i
+		}
+	}
+	return nil, -1
+}
+
+// EstimatePower estimates a light's total emitted power (flux) by Monte Carlo integration of its
+// emission sampling: Φ = ∫∫ Le cosθ dA dω. For point lights the emission is an intensity and the
+// estimate is ∫ I dω. Infinite lights report the power crossing the scene's bounding disk, so they
+// must be preprocessed first. The result is deterministic for a given sample count.
+func EstimatePower(light Light, samples int) core.Vec3 {
+	if samples <= 0 {
+		return core.Vec3{}
+	}
+
+	sampler := core.NewSeededSampler(1)
+	var total core.Vec3
+	for i := 0; i < samples; i++ {
+		es := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		pdf := es.AreaPDF * es.DirectionPDF
+		if pdf <= 0 {
+			continue
+		}
+		cosTheta := math.Abs(es.Normal.Dot(es.Direction))
+		total = total.Add(es.Emission.Multiply(cosTheta / pdf))
+	}
+	return total.Multiply(1.0 / float64(samples))
+}
+
 // SampleLight selects and samples a light from the scene using importance sampling
 func SampleLight(lights []Light, lightSampler LightSampler, point core.Vec3, normal core.Vec3, sampler core.Sampler) (LightSample, Light, int, bool) {
 	if len(lights) == 0 {
@@ -37,6 +115,58 @@ func SampleLight(lights []Light, lightSampler LightSampler, point core.Vec3, nor
 	return sample, selectedLight, lightIndex, true
 }
 
+// SampleImportantLights samples each of the k lights the light sampler considers most important at
+// the point once, and one of the remaining lights chosen by the sampler's probabilities renormalized
+// over them. Stratifying over the important lights removes their selection noise.
+// Returns the light samples, whose PDFs include the selection probability (1 for the important
+// lights), and every light's selection probability for CalculateSelectedLightPDF.
+func SampleImportantLights(lights []Light, lightSampler LightSampler, point, normal core.Vec3, k int, sampler core.Sampler) ([]LightSample, []float64) {
+	n := len(lights)
+	selection := make([]float64, n)
+	order := make([]int, n)
+	for i := range lights {
+		selection[i] = lightSampler.GetLightProbability(i, point, normal)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return selection[order[a]] > selection[order[b]] })
+
+	k = min(k, n)
+	samples := make([]LightSample, 0, k+1)
+	for _, i := range order[:k] {
+		selection[i] = 1
+		samples = append(samples, lights[i].Sample(point, normal, sampler.Get2D()))
+	}
+
+	// One sample for the rest, selected in proportion to the sampler's probabilities
+	rest := order[k:]
+	restTotal := 0.0
+	for _, i := range rest {
+		restTotal += selection[i]
+	}
+	if restTotal <= 0 {
+		for _, i := range rest {
+			selection[i] = 0
+		}
+		return samples, selection
+	}
+	for _, i := range rest {
+		selection[i] /= restTotal
+	}
+	u := sampler.Get1D()
+	chosen := rest[len(rest)-1]
+	cumulative := 0.0
+	for _, i := range rest {
+		cumulative += selection[i]
+		if u <= cumulative {
+			chosen = i
+			break
+		}
+	}
+	sample := lights[chosen].Sample(point, normal, sampler.Get2D())
+	sample.PDF *= selection[chosen]
+	return append(samples, sample), selection
+}
+
 // SampleLightEmission selects and samples emission from a light using uniform sampling
 // For emission sampling, we don't have a specific surface point, so use uniform distribution
 func SampleLightEmission(lights []Light, lightSampler LightSampler, sampler core.Sampler) (EmissionSample, bool) {
@@ -89,7 +219,18 @@ func UniformConePDF(cosTotalWidth float64) float64 {
 func SampleInfiniteLight(worldCenter core.Vec3, worldRadius float64, samplePoint core.Vec2, sampleDirection core.Vec2) (core.Ray, float64, float64) {
 	// Sample direction uniformly on sphere
 	direction := core.SampleOnUnitSphere(sampleDirection)
+	emissionPoint := infiniteLightOrigin(worldCenter, worldRadius, direction, samplePoint)
 
+	// PBRT PDF calculations
+	areaPDF := 1.0 / (math.Pi * worldRadius * worldRadius) // Planar density
+	directionPDF := 1.0 / (4.0 * math.Pi)                  // Uniform over sphere
+
+	return core.NewRay(emissionPoint, direction), areaPDF, directionPDF
+}
+
+// infiniteLightOrigin samples the origin of a ray from an infinite light traveling along direction:
+// a point on the disk of the world's radius facing it, moved back outside the world
+func infiniteLightOrigin(worldCenter core.Vec3, worldRadius float64, direction core.Vec3, samplePoint core.Vec2) core.Vec3 {
 	// Create orthonormal basis with direction as one axis
 	var up core.Vec3
 	if math.Abs(direction.X) > 0.9 {
@@ -105,11 +246,5 @@ func SampleInfiniteLight(worldCenter core.Vec3, worldRadius float64, samplePoint
 	diskPoint := worldCenter.Add(right.Multiply(diskSample.X * worldRadius)).Add(up.Multiply(diskSample.Y * worldRadius))
 
 	// Emission point is behind the disk, ray travels in sampled direction (parallel rays)
-	emissionPoint := diskPoint.Add(direction.Multiply(-worldRadius))
-
-	// PBRT PDF calculations
-	areaPDF := 1.0 / (math.Pi * worldRadius * worldRadius) // Planar density
-	directionPDF := 1.0 / (4.0 * math.Pi)                  // Uniform over sphere
-
-	return core.NewRay(emissionPoint, direction), areaPDF, directionPDF
+	return diskPoint.Add(direction.Multiply(-worldRadius))
 }
@@ -533,3 +533,152 @@ func TestSampleEmissionDirection_DifferentNormals(t *testing.T) {
 		}
 	}
 }
+
+func TestEvaluateInfiniteLights_OnlyInfiniteLightsContribute(t *testing.T) {
+	const tolerance = 1e-9
+
+	sphereLight := NewSphereLight(core.NewVec3(0, 5, 0), 1.0, material.NewEmissive(core.NewVec3(10, 10, 10)))
+	uniform := NewUniformInfiniteLight(core.NewVec3(0.2, 0.3, 0.4))
+	gradient := NewGradientInfiniteLight(core.NewVec3(1, 1, 1), core.NewVec3(0, 0, 0))
+
+	lights := []Light{sphereLight, uniform, gradient}
+	ray := core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0)) // Straight up (gradient t=1)
+
+	emission := EvaluateInfiniteLights(lights, ray)
+	expected := core.NewVec3(1.2, 1.3, 1.4)
+	if emission.Subtract(expected).Length() > tolerance {
+		t.Errorf("Expected background emission %v, got %v", expected, emission)
+	}
+
+	if !EvaluateInfiniteLights([]Light{sphereLight}, ray).IsZero() {
+		t.Error("Expected zero background emission when scene has no infinite lights")
+	}
+}
+
+func TestInfiniteLightDensity_WeightedBySelectionProbability(t *testing.T) {
+	const tolerance = 1e-9
+
+	sphereLight := NewSphereLight(core.NewVec3(0, 5, 0), 1.0, material.NewEmissive(core.NewVec3(1, 1, 1)))
+	uniform := NewUniformInfiniteLight(core.NewVec3(1, 1, 1))
+
+	lights := []Light{sphereLight, uniform}
+	sampler := NewWeightedLightSampler(lights, []float64{0.75, 0.25}, 10.0)
+
+	point := core.NewVec3(0, 0, 0)
+	normal := core.NewVec3(0, 1, 0)
+	direction := core.NewVec3(1, 1, 0).Normalize()
+
+	// Only the infinite light contributes, even though the sphere light has a PDF for some directions
+	density := InfiniteLightDensity(lights, sampler, point, normal, direction)
+	expected := uniform.PDF(point, normal, direction) * 0.25
+	if math.Abs(density-expected) > tolerance {
+		t.Errorf("Expected infinite light density %f, got %f", expected, density)
+	}
+
+	// Direction toward the sphere light must not pick up the sphere's PDF
+	up := core.NewVec3(0, 1, 0)
+	density = InfiniteLightDensity(lights, sampler, point, normal, up)
+	expected = uniform.PDF(point, normal, up) * 0.25
+	if math.Abs(density-expected) > tolerance {
+		t.Errorf("Expected infinite light density %f toward sphere light, got %f", expected, density)
+	}
+}
+
+func TestFindAreaLight(t *testing.T) {
+	quad := NewQuadLight(core.NewVec3(-1, 2, -1), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), material.NewEmissive(core.NewVec3(1, 1, 1)))
+	sphere := NewSphereLight(core.NewVec3(5, 0, 0), 1.0, material.NewEmissive(core.NewVec3(1, 1, 1)))
+	lights := []Light{NewUniformInfiniteLight(core.NewVec3(1, 1, 1)), quad, sphere}
+
+	tests := []struct {
+		name      string
+		point     core.Vec3
+		direction core.Vec3
+		wantIndex int
+	}{
+		{"QuadLight", core.NewVec3(0, 2, 0), quad.Normal, 1},
+		{"SphereLight", core.NewVec3(4, 0, 0), core.NewVec3(-1, 0, 0), 2},
+		{"NotOnLight", core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			light, index := FindAreaLight(lights, tt.point, tt.direction)
+			if index != tt.wantIndex {
+				t.Fatalf("Expected light index %d, got %d", tt.wantIndex, index)
+			}
+			if index >= 0 && light != lights[index] {
+				t.Errorf("Returned light doesn't match index %d", index)
+			}
+			if index < 0 && light != nil {
+				t.Errorf("Expected nil light, got %v", light)
+			}
+		})
+	}
+}
+
+func TestEstimatePower(t *testing.T) {
+	emission := core.NewVec3(2, 2, 2)
+	quad := NewQuadLight(core.NewVec3(0, 0, 0), core.NewVec3(3, 0, 0), core.NewVec3(0, 0, 2), material.NewEmissive(emission))
+	sphere := NewSphereLight(core.NewVec3(0, 0, 0), 0.5, material.NewEmissive(emission))
+
+	tests := []struct {
+		name     string
+		light    Light
+		expected float64
+	}{
+		{"QuadLight", quad, math.Pi * 2 * 6},                      // Φ = π L A
+		{"SphereLight", sphere, math.Pi * 2 * 4 * math.Pi * 0.25}, // Φ = π L 4πr²
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			power := EstimatePower(tt.light, 4096)
+			if math.Abs(power.X-tt.expected)/tt.expected > 0.02 {
+				t.Errorf("Expected power %f, got %f", tt.expected, power.X)
+			}
+			if power != EstimatePower(tt.light, 4096) {
+				t.Error("Expected the estimate to be deterministic")
+			}
+		})
+	}
+}
+
+func TestSampleImportantLights(t *testing.T) {
+	emissive := material.NewEmissive(core.NewVec3(1, 1, 1))
+	lights := []Light{
+		NewSphereLight(core.NewVec3(0, 5, 0), 0.5, emissive),
+		NewSphereLight(core.NewVec3(5, 0, 0), 0.5, emissive),
+		NewSphereLight(core.NewVec3(0, 0, 5), 0.5, emissive),
+		NewSphereLight(core.NewVec3(-5, 0, 0), 0.5, emissive),
+	}
+	weighted := NewWeightedLightSampler(lights, []float64{0.1, 0.4, 0.3, 0.2}, 10)
+	point := core.NewVec3(0, 0, 0)
+	normal := core.NewVec3(0, 1, 0)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(1)))
+
+	// The two most important lights are always sampled; the rest share the renormalized probability
+	samples, selection := SampleImportantLights(lights, weighted, point, normal, 2, sampler)
+	expected := []float64{0.1 / 0.3, 1, 1, 0.2 / 0.3}
+	for i := range expected {
+		if math.Abs(selection[i]-expected[i]) > 1e-9 {
+			t.Errorf("Light %d: selection probability %f, expected %f", i, selection[i], expected[i])
+		}
+	}
+	if len(samples) != 3 {
+		t.Fatalf("Expected a sample from each important light and one of the rest, got %d", len(samples))
+	}
+	if pdf := lights[1].PDF(point, normal, samples[0].Direction); math.Abs(samples[0].PDF-pdf) > 1e-9 {
+		t.Errorf("Important light sample PDF %f should not include a selection probability (%f)", samples[0].PDF, pdf)
+	}
+
+	// Asking for every light samples each exactly once
+	samples, selection = SampleImportantLights(lights, weighted, point, normal, 10, sampler)
+	if len(samples) != len(lights) {
+		t.Errorf("Expected %d samples, got %d", len(lights), len(samples))
+	}
+	for i, p := range selection {
+		if p != 1 {
+			t.Errorf("Light %d: selection probability %f, expected 1", i, p)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// sunTopOfAtmosphereIlluminance is the sun's illuminance above the atmosphere, in klux, the unit
+// (with kcd/m² for radiance) of the Preetham sky model
+const sunTopOfAtmosphereIlluminance = 128.0
+
+// PhysicalSkyLight is a clear daytime sky from the Preetham et al. model, "A Practical Analytic
+// Model for Daylight" (1999), with a sun whose color and brightness follow its path through the
+// atmosphere. The world is y-up: the sky covers the directions above the horizon and nothing
+// comes from below it.
+// The model's radiance is in kcd/m², so a sunlit white surface reaches about 30: a scale around
+// 0.03 brings it near 1.
+type PhysicalSkyLight struct {
+	toSun     core.Vec3         // Normalized direction toward the sun
+	sun       *DirectionalLight // The sun disc, nil when the sun is below the horizon
+	sunWeight float64           // Probability of sampling the sun rather than the sky
+
+	thetaSun    float64    // Sun's angle from the zenith
+	zenith      [3]float64 // Zenith luminance Y (kcd/m²) and chromaticity x, y
+	perez       [3][5]float64
+	perezZenith [3]float64 // Perez function at the zenith, per component, for normalization
+	scale       float64
+
+	worldCenter core.Vec3 // Finite scene center from BVH
+	worldRadius float64   // Finite scene radius from BVH
+}
+
+// NewPhysicalSkyLight creates a physical sky
+// turbidity: haziness, from 2 (very clear) to about 10 (hazy)
+// sunElevationDegrees: the sun's height above the horizon
+// sunAzimuthDegrees: the sun's direction around the horizon, from -z (0°) toward +x (90°)
+// scale: multiplies the sky's and sun's radiance
+func NewPhysicalSkyLight(turbidity, sunElevationDegrees, sunAzimuthDegrees, scale float64) *PhysicalSkyLight {
+	turbidity = math.Min(math.Max(turbidity, 1.7), 10)
+	elevation := sunElevationDegrees * math.Pi / 180
+	azimuth := sunAzimuthDegrees * math.Pi / 180
+	toSun := core.NewVec3(math.Sin(azimuth)*math.Cos(elevation), math.Sin(elevation), -math.Cos(azimuth)*math.Cos(elevation))
+
+	// The model holds for the sun between the zenith and the horizon
+	thetaSun := math.Min(math.Max(math.Pi/2-elevation, 0), math.Pi/2)
+	t := turbidity
+
+	sky := &PhysicalSkyLight{
+		toSun:    toSun,
+		thetaSun: thetaSun,
+		scale:    scale,
+		perez: [3][5]float64{
+			{0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703},
+			{-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452},
+			{-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529},
+		},
+	}
+
+	// Zenith luminance and chromaticity
+	chi := (4.0/9.0 - t/120.0) * (math.Pi - 2*thetaSun)
+	sky.zenith[0] = (4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192
+	th, th2, th3 := thetaSun, thetaSun*thetaSun, thetaSun*thetaSun*thetaSun
+	sky.zenith[1] = t*t*(0.00166*th3-0.00375*th2+0.00209*th) +
+		t*(-0.02903*th3+0.06377*th2-0.03202*th+0.00394) +
+		(0.11693*th3 - 0.21196*th2 + 0.06052*th + 0.25886)
+	sky.zenith[2] = t*t*(0.00275*th3-0.00610*th2+0.00317*th) +
+		t*(-0.04214*th3+0.08970*th2-0.04153*th+0.00516) +
+		(0.15346*th3 - 0.26756*th2 + 0.06670*th + 0.26688)
+	for i := range sky.perezZenith {
+		sky.perezZenith[i] = perezFunction(sky.perez[i], 0, thetaSun)
+	}
+
+	if elevation > 0 {
+		irradiance := sunTransmittance(turbidity, thetaSun).Multiply(sunTopOfAtmosphereIlluminance * scale)
+		sky.sun = NewDirectionalLight(toSun.Multiply(-1), irradiance, SunAngularDiameter)
+		sky.sunWeight = 0.5
+	}
+	return sky
+}
+
+// perezFunction is the Perez et al. sky luminance distribution for a direction at angle theta
+// from the zenith and gamma from the sun
+func perezFunction(c [5]float64, theta, gamma float64) float64 {
+	cosGamma := math.Cos(gamma)
+	return (1 + c[0]*math.Exp(c[1]/math.Cos(theta))) * (1 + c[2]*math.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+// sunTransmittance returns the fraction of the sun's light reaching the ground in the red, green
+// and blue bands, from Rayleigh and aerosol (Ångström) scattering along the air mass
+func sunTransmittance(turbidity, thetaSun float64) core.Vec3 {
+	thetaDegrees := thetaSun * 180 / math.Pi
+	airMass := 1 / (math.Cos(thetaSun) + 0.15*math.Pow(93.885-thetaDegrees, -1.253))
+	beta := 0.04608*turbidity - 0.04586
+
+	transmittance := func(wavelength float64) float64 { // Micrometers
+		rayleigh := 0.008735 * math.Pow(wavelength, -4.08)
+		aerosol := beta * math.Pow(wavelength, -1.3)
+		return math.Exp(-airMass * (rayleigh + aerosol))
+	}
+	return core.NewVec3(transmittance(0.680), transmittance(0.550), transmittance(0.440))
+}
+
+func (psl *PhysicalSkyLight) Type() LightType {
+	return LightTypeInfinite
+}
+
+// SunDirection returns the direction toward the sun
+func (psl *PhysicalSkyLight) SunDirection() core.Vec3 {
+	return psl.toSun
+}
+
+// skyRadiance returns the sky's radiance along a direction, without the sun
+func (psl *PhysicalSkyLight) skyRadiance(direction core.Vec3) core.Vec3 {
+	cosTheta := direction.Y
+	if cosTheta <= 0 {
+		return core.Vec3{}
+	}
+	theta := math.Acos(math.Min(cosTheta, 1))
+	gamma := math.Acos(math.Min(math.Max(direction.Dot(psl.toSun), -1), 1))
+
+	var xyY [3]float64
+	for i := range xyY {
+		xyY[i] = psl.zenith[i] * perezFunction(psl.perez[i], theta, gamma) / psl.perezZenith[i]
+	}
+	luminance, x, y := xyY[0]*psl.scale, xyY[1], xyY[2]
+	if luminance <= 0 || y <= 0 {
+		return core.Vec3{}
+	}
+
+	// xyY to XYZ to linear sRGB
+	X := x / y * luminance
+	Z := (1 - x - y) / y * luminance
+	return core.NewVec3(
+		math.Max(0, 3.2406*X-1.5372*luminance-0.4986*Z),
+		math.Max(0, -0.9689*X+1.8758*luminance+0.0415*Z),
+		math.Max(0, 0.0557*X-0.2040*luminance+1.0570*Z),
+	)
+}
+
+// radiance returns the sky's and sun's radiance along a direction
+func (psl *PhysicalSkyLight) radiance(direction core.Vec3) core.Vec3 {
+	direction = direction.Normalize()
+	radiance := psl.skyRadiance(direction)
+	if psl.sun != nil {
+		radiance = radiance.Add(psl.sun.Emit(core.NewRay(core.Vec3{}, direction), nil))
+	}
+	return radiance
+}
+
+// Sample implements the Light interface - samples the sun disc or the visible hemisphere
+func (psl *PhysicalSkyLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	var direction core.Vec3
+	if sample.X < psl.sunWeight {
+		direction = psl.sun.Sample(point, normal, core.NewVec2(sample.X/psl.sunWeight, sample.Y)).Direction
+	} else {
+		u := core.NewVec2((sample.X-psl.sunWeight)/(1-psl.sunWeight), sample.Y)
+		direction = core.SampleCosineHemisphere(normal, u)
+	}
+
+	return LightSample{
+		Point:     point.Add(direction.Multiply(1e10)), // Far away point
+		Normal:    direction.Multiply(-1),              // Points toward scene
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Emission:  psl.radiance(direction),
+		PDF:       psl.PDF(point, normal, direction),
+	}
+}
+
+// PDF implements the Light interface - returns probability density for direct lighting sampling
+func (psl *PhysicalSkyLight) PDF(point, normal, direction core.Vec3) float64 {
+	pdf := 0.0
+	if cosTheta := direction.Dot(normal); cosTheta > 0 {
+		pdf = (1 - psl.sunWeight) * cosTheta / math.Pi
+	}
+	if psl.sun != nil {
+		pdf += psl.sunWeight * psl.sun.PDF(point, normal, direction)
+	}
+	return pdf
+}
+
+// SampleEmission implements the Light interface - samples parallel rays from the sun disc or from
+// any direction
+func (psl *PhysicalSkyLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	var direction core.Vec3
+	if sampleDirection.X < psl.sunWeight {
+		u := core.NewVec2(sampleDirection.X/psl.sunWeight, sampleDirection.Y)
+		direction = core.SampleCone(psl.toSun, psl.sun.cosMaxAngle, u).Multiply(-1)
+	} else {
+		u := core.NewVec2((sampleDirection.X-psl.sunWeight)/(1-psl.sunWeight), sampleDirection.Y)
+		direction = core.SampleOnUnitSphere(u)
+	}
+	pdfPos, pdfDir := psl.PDF_Le(core.Vec3{}, direction)
+
+	return EmissionSample{
+		Point:        infiniteLightOrigin(psl.worldCenter, psl.worldRadius, direction, samplePoint),
+		Normal:       direction.Multiply(-1), // Points toward the sky
+		Direction:    direction,
+		Emission:     psl.radiance(direction.Multiply(-1)),
+		AreaPDF:      pdfPos,
+		DirectionPDF: pdfDir,
+	}
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (psl *PhysicalSkyLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	if psl.worldRadius <= 0 {
+		return 0.0, 0.0
+	}
+	pdfDir = (1 - psl.sunWeight) / (4 * math.Pi)
+	if psl.sun != nil && direction.Normalize().Dot(psl.toSun) <= -psl.sun.cosMaxAngle {
+		pdfDir += psl.sunWeight * UniformConePDF(psl.sun.cosMaxAngle)
+	}
+	return 1.0 / (math.Pi * psl.worldRadius * psl.worldRadius), pdfDir
+}
+
+// Emit implements the Light interface - evaluates the sky and sun along the ray's direction
+func (psl *PhysicalSkyLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	return psl.radiance(ray.Direction)
+}
+
+// Preprocess implements the Preprocessor interface - sets world bounds from scene
+func (psl *PhysicalSkyLight) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	psl.worldCenter = worldCenter
+	psl.worldRadius = worldRadius
+	return nil
+}
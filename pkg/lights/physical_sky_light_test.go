@@ -0,0 +1,99 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestPhysicalSkyLight_Radiance(t *testing.T) {
+	sky := NewPhysicalSkyLight(3, 45, 0, 1)
+	up := core.NewRay(core.Vec3{}, core.NewVec3(0, 1, 0))
+
+	if zenith := sky.Emit(up, nil); zenith.Z <= zenith.X {
+		t.Errorf("Expected a blue sky at the zenith, got %v", zenith)
+	}
+	if below := sky.Emit(core.NewRay(core.Vec3{}, core.NewVec3(0, -1, 0.2)), nil); !below.IsZero() {
+		t.Errorf("Expected nothing from below the horizon, got %v", below)
+	}
+
+	// The sun disc outshines the sky many times over
+	sun := sky.Emit(core.NewRay(core.Vec3{}, sky.SunDirection()), nil)
+	if sun.Luminance() < 1000*sky.Emit(up, nil).Luminance() {
+		t.Errorf("Expected the sun to be far brighter than the sky, got %v", sun)
+	}
+
+	// Near the horizon the sun reddens
+	low := NewPhysicalSkyLight(3, 3, 0, 1).Emit(core.NewRay(core.Vec3{}, core.NewVec3(0, math.Sin(3*math.Pi/180), -1)), nil)
+	if low.X <= low.Z || low.X/low.Z <= sun.X/sun.Z {
+		t.Errorf("Expected a low sun to be redder, got %v (high sun %v)", low, sun)
+	}
+
+	// Scale multiplies everything
+	scaled := NewPhysicalSkyLight(3, 45, 0, 0.5).Emit(up, nil)
+	if math.Abs(scaled.Y-0.5*sky.Emit(up, nil).Y) > 1e-9 {
+		t.Errorf("Expected half the radiance at scale 0.5, got %v", scaled)
+	}
+}
+
+func TestPhysicalSkyLight_SunDirection(t *testing.T) {
+	sky := NewPhysicalSkyLight(3, 30, 90, 1)
+	expected := core.NewVec3(math.Cos(math.Pi/6), 0.5, 0)
+	if sky.SunDirection().Subtract(expected).Length() > 1e-9 {
+		t.Errorf("Expected the sun toward +x 30° up, got %v", sky.SunDirection())
+	}
+}
+
+func TestPhysicalSkyLight_Sampling(t *testing.T) {
+	// Importance sampling the sun and sky gives the irradiance that cosine sampling the sky and
+	// integrating the sun disc give
+	for _, elevation := range []float64{10, 50, -5} {
+		sky := NewPhysicalSkyLight(2.5, elevation, 30, 1)
+		normal := core.NewVec3(0, 1, 0)
+		sampler := core.NewSeededSampler(4)
+
+		const samples = 200000
+		sampled, reference := 0.0, 0.0
+		for i := 0; i < samples; i++ {
+			sample := sky.Sample(core.Vec3{}, normal, sampler.Get2D())
+			if cosTheta := sample.Direction.Dot(normal); cosTheta > 0 {
+				sampled += sample.Emission.Luminance() * cosTheta / sample.PDF
+			}
+			direction := core.SampleCosineHemisphere(normal, sampler.Get2D())
+			reference += sky.skyRadiance(direction).Luminance() * math.Pi
+		}
+		sampled /= samples
+		reference /= samples
+		if sky.sun != nil {
+			sinMax := math.Sin(SunAngularDiameter * math.Pi / 360)
+			reference += sky.sun.radiance.Luminance() * math.Pi * sinMax * sinMax * sky.SunDirection().Y
+		}
+		if math.Abs(sampled-reference) > 0.02*reference {
+			t.Errorf("Elevation %f: sampled irradiance %f, expected %f", elevation, sampled, reference)
+		}
+	}
+}
+
+func TestPhysicalSkyLight_SampleEmission(t *testing.T) {
+	sky := NewPhysicalSkyLight(3, 40, 0, 1)
+	if err := sky.Preprocess(core.Vec3{}, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	sampler := core.NewSeededSampler(8)
+	towardSun := 0
+	for i := 0; i < 1000; i++ {
+		sample := sky.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		pdfPos, pdfDir := sky.PDF_Le(sample.Point, sample.Direction)
+		if math.Abs(pdfPos-sample.AreaPDF) > 1e-12 || math.Abs(pdfDir-sample.DirectionPDF) > 1e-9*pdfDir {
+			t.Fatalf("PDF_Le gave (%f, %f), sampling (%f, %f)", pdfPos, pdfDir, sample.AreaPDF, sample.DirectionPDF)
+		}
+		if sample.Direction.Dot(sky.SunDirection()) < -0.999 {
+			towardSun++
+		}
+	}
+	if towardSun < 400 || towardSun > 600 {
+		t.Errorf("Expected about half the emission samples to come from the sun, got %d of 1000", towardSun)
+	}
+}
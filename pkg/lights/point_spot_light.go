@@ -14,6 +14,9 @@ type PointSpotLight struct {
 	emission        core.Vec3 // Light intensity/color
 	cosTotalWidth   float64   // Cosine of total cone angle (outer edge)
 	cosFalloffStart float64   // Cosine of falloff start angle (inner cone)
+
+	profile   *IESProfile // Measured distribution, or nil for a uniform cone
+	reference core.Vec3   // Direction of the profile's horizontal angle 0, perpendicular to direction
 }
 
 // NewPointSpotLight creates a new point spot light
@@ -38,6 +41,56 @@ func NewPointSpotLight(from, to, emission core.Vec3, coneAngleDegrees, coneDelta
 	}
 }
 
+// SetProfile shapes the light with a measured IES profile, its vertical angle 0 along the light's
+// direction and its horizontal angle 0 toward reference. The emission becomes the intensity at
+// the profile's peak: scale it by the profile's PeakCandela for a photometric light. The cone
+// still applies, so widen it to cover the profile (up to 180 degrees).
+func (sl *PointSpotLight) SetProfile(profile *IESProfile, reference core.Vec3) {
+	reference = reference.Subtract(sl.direction.Multiply(reference.Dot(sl.direction)))
+	if reference.Length() < 1e-9 {
+		// Any perpendicular will do for a reference along the aim
+		reference = core.NewVec3(1, 0, 0)
+		if math.Abs(sl.direction.X) > 0.9 {
+			reference = core.NewVec3(0, 1, 0)
+		}
+		reference = reference.Subtract(sl.direction.Multiply(reference.Dot(sl.direction)))
+	}
+	sl.profile = profile
+	sl.reference = reference.Normalize()
+}
+
+// Position returns the light's position
+func (sl *PointSpotLight) Position() core.Vec3 {
+	return sl.position
+}
+
+// Direction returns the normalized direction the light is aimed in
+func (sl *PointSpotLight) Direction() core.Vec3 {
+	return sl.direction
+}
+
+// Emission returns the light's intensity inside the cone
+func (sl *PointSpotLight) Emission() core.Vec3 {
+	return sl.emission
+}
+
+// ConeAngles returns the cone's angle and falloff transition angle in degrees, as given to NewPointSpotLight
+func (sl *PointSpotLight) ConeAngles() (coneAngleDegrees, coneDeltaAngleDegrees float64) {
+	return coneAngles(sl.cosTotalWidth, sl.cosFalloffStart)
+}
+
+// Profile returns the light's IES profile, or nil for a uniform cone
+func (sl *PointSpotLight) Profile() *IESProfile {
+	return sl.profile
+}
+
+// coneAngles converts a spot light's cone cosines back to its cone and falloff angles in degrees
+func coneAngles(cosTotalWidth, cosFalloffStart float64) (coneAngleDegrees, coneDeltaAngleDegrees float64) {
+	totalWidth := math.Acos(cosTotalWidth) * 180 / math.Pi
+	falloffStart := math.Acos(cosFalloffStart) * 180 / math.Pi
+	return totalWidth, totalWidth - falloffStart
+}
+
 func (sl *PointSpotLight) Type() LightType {
 	return LightTypePoint
 }
@@ -69,11 +122,8 @@ func (sl *PointSpotLight) Sample(point core.Vec3, normal core.Vec3, sample core.
 	// Direction from light to shading point (opposite of toLight)
 	lightToPoint := toLight.Multiply(-1)
 
-	// Angle between light direction and direction to shading point
-	cosAngle := sl.direction.Dot(lightToPoint)
-
 	// Calculate spot light falloff
-	spotAttenuation := sl.falloff(cosAngle)
+	spotAttenuation := sl.attenuation(lightToPoint)
 
 	// Calculate final emission with spot attenuation and distance falloff
 	emission := sl.emission.Multiply(spotAttenuation / (distance * distance))
@@ -113,6 +163,21 @@ func (sl *PointSpotLight) PDF(point, normal, direction core.Vec3) float64 {
 	return 0.0
 }
 
+// attenuation returns the fraction of the emission sent along a direction from the light: the
+// cone's falloff times the profile, if the light has one
+func (sl *PointSpotLight) attenuation(direction core.Vec3) float64 {
+	cosAngle := sl.direction.Dot(direction)
+	spotAttenuation := sl.falloff(cosAngle)
+	if sl.profile == nil || spotAttenuation == 0 {
+		return spotAttenuation
+	}
+
+	bitangent := sl.direction.Cross(sl.reference)
+	vertical := math.Acos(math.Min(math.Max(cosAngle, -1), 1)) * 180 / math.Pi
+	horizontal := math.Atan2(direction.Dot(bitangent), direction.Dot(sl.reference)) * 180 / math.Pi
+	return spotAttenuation * sl.profile.Intensity(vertical, horizontal)
+}
+
 // falloff calculates the spot light falloff
 // Based on the cosine of the angle between light direction and direction to point
 func (sl *PointSpotLight) falloff(cosAngle float64) float64 {
@@ -148,8 +213,7 @@ func (sl *PointSpotLight) GetIntensityAt(point core.Vec3) core.Vec3 {
 	lightToPoint := toLight.Multiply(-1)
 
 	// Calculate spot attenuation using falloff
-	cosAngle := sl.direction.Dot(lightToPoint)
-	spotAttenuation := sl.falloff(cosAngle)
+	spotAttenuation := sl.attenuation(lightToPoint)
 
 	// Return intensity with distance and spot falloff
 	return sl.emission.Multiply(spotAttenuation / (distance * distance))
@@ -164,8 +228,7 @@ func (sl *PointSpotLight) SampleEmission(samplePoint core.Vec2, sampleDirection
 	emissionDir := core.SampleCone(sl.direction, sl.cosTotalWidth, sampleDirection)
 
 	// Calculate spot light falloff
-	cosTheta := emissionDir.Dot(sl.direction)
-	spotAttenuation := sl.falloff(cosTheta)
+	spotAttenuation := sl.attenuation(emissionDir)
 
 	// For point lights, the PDF is over solid angle only (no area component)
 	conePDF := UniformConePDF(sl.cosTotalWidth)
@@ -169,3 +169,40 @@ func TestPointSpotLight_GetIntensityAt(t *testing.T) {
 		t.Errorf("Expected zero intensity for point outside cone, got %v", intensity)
 	}
 }
+
+func TestPointSpotLight_Profile(t *testing.T) {
+	// A downlight brighter toward +x (horizontal 0°) than toward +z (90°)
+	profile, err := NewIESProfile([]float64{0, 45, 90}, []float64{0, 90}, [][]float64{{100, 80, 0}, {100, 40, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	light := NewPointSpotLight(core.NewVec3(0, 1, 0), core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 1), 180, 1)
+	light.SetProfile(profile, core.NewVec3(1, 0, 0))
+
+	// Points on the floor 45° off the aim, at distance √2
+	normal := core.NewVec3(0, 1, 0)
+	tests := []struct {
+		point    core.Vec3
+		expected float64
+	}{
+		{core.NewVec3(0, 0, 0), 1},
+		{core.NewVec3(1, 0, 0), 0.8},
+		{core.NewVec3(-1, 0, 0), 0.8},
+		{core.NewVec3(0, 0, 1), 0.4},
+		{core.NewVec3(0, 0, -1), 0.4},
+	}
+	for _, tt := range tests {
+		sample := light.Sample(tt.point, normal, core.Vec2{})
+		distanceSquared := light.position.Subtract(tt.point).LengthSquared()
+		if got := sample.Emission.X * distanceSquared; math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("Point %v: expected intensity %f, got %f", tt.point, tt.expected, got)
+		}
+	}
+
+	// Emission samples carry the same intensity
+	emission := light.SampleEmission(core.Vec2{}, core.NewVec2(0.3, 0.6))
+	expected := light.GetIntensityAt(light.position.Add(emission.Direction)).X
+	if math.Abs(emission.Emission.X-expected) > 1e-9 {
+		t.Errorf("Expected emission %f along the sampled direction, got %f", expected, emission.Emission.X)
+	}
+}
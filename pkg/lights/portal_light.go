@@ -0,0 +1,189 @@
+package lights
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// environmentSampleFraction is the share of a PortalLight's samples left to the environment's own
+// sampling, which finds small bright features such as the sun that portal sampling would miss
+const environmentSampleFraction = 0.25
+
+// minPortalCosine is the smallest cosine, between the portal's normal and the direction to its
+// center, at which a point samples a portal
+const minPortalCosine = 1e-3
+
+// PortalLight guides an infinite light's sampling through portals: quads marking the openings,
+// such as windows, through which it reaches an interior. Direct lighting mostly samples
+// directions through the portals instead of the whole sky, which most rays inside a room find
+// blocked. The light's radiance is unchanged: rays escaping elsewhere still see the environment.
+type PortalLight struct {
+	environment Light
+	portals     []*geometry.Quad
+}
+
+// NewPortalLight creates a portal light for an infinite light. The portals should cover every
+// opening the light comes through; the quads aren't added to the scene.
+func NewPortalLight(environment Light, portals []*geometry.Quad) (*PortalLight, error) {
+	if environment.Type() != LightTypeInfinite {
+		return nil, fmt.Errorf("portals need an infinite light, got a %s light", environment.Type())
+	}
+	if len(portals) == 0 {
+		return nil, fmt.Errorf("portal light needs at least one portal")
+	}
+	return &PortalLight{environment: environment, portals: portals}, nil
+}
+
+func (pl *PortalLight) Type() LightType {
+	return LightTypeInfinite
+}
+
+// Environment returns the infinite light seen through the portals
+func (pl *PortalLight) Environment() Light {
+	return pl.environment
+}
+
+// Portals returns the light's portals
+func (pl *PortalLight) Portals() []*geometry.Quad {
+	return pl.portals
+}
+
+// portalWeights returns the probability of sampling each portal from a point, proportional to
+// roughly the solid angle it subtends, and their sum before normalization
+func (pl *PortalLight) portalWeights(point core.Vec3) ([]float64, float64) {
+	weights := make([]float64, len(pl.portals))
+	total := 0.0
+	for i, portal := range pl.portals {
+		center := portal.Corner.Add(portal.U.Multiply(0.5)).Add(portal.V.Multiply(0.5))
+		toPortal := center.Subtract(point)
+		distanceSquared := toPortal.LengthSquared()
+		if distanceSquared == 0 {
+			continue
+		}
+		area := portal.SurfaceArea()
+		cosine := math.Abs(toPortal.Dot(portal.Normal)) / math.Sqrt(distanceSquared)
+		if cosine < minPortalCosine {
+			// Sampling a portal seen edge on gives grazing directions whose PDF the hit test in
+			// PDF can't reproduce
+			continue
+		}
+		// The area in the denominator keeps the weight finite close to the portal
+		weights[i] = area * cosine / (distanceSquared + area)
+		total += weights[i]
+	}
+	if total > 0 {
+		for i := range weights {
+			weights[i] /= total
+		}
+	}
+	return weights, total
+}
+
+// Sample implements the Light interface - samples a point on a portal, seeing the environment
+// beyond it, or sometimes the environment itself
+func (pl *PortalLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	weights, total := pl.portalWeights(point)
+	if total == 0 {
+		return pl.environment.Sample(point, normal, sample)
+	}
+
+	var direction core.Vec3
+	if sample.X < environmentSampleFraction {
+		sample.X /= environmentSampleFraction
+		direction = pl.environment.Sample(point, normal, sample).Direction
+	} else {
+		sample.X = (sample.X - environmentSampleFraction) / (1 - environmentSampleFraction)
+		direction = pl.samplePortals(point, weights, sample)
+	}
+
+	pdf := pl.PDF(point, normal, direction)
+	if pdf == 0 {
+		return LightSample{}
+	}
+
+	return LightSample{
+		Point:     point.Add(direction.Multiply(1e10)), // Far away point
+		Normal:    direction.Multiply(-1),              // Points toward scene
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Emission:  pl.environment.Emit(core.NewRay(point, direction), nil),
+		PDF:       pdf,
+	}
+}
+
+// samplePortals samples the direction toward a point on a portal, chosen by the given weights
+func (pl *PortalLight) samplePortals(point core.Vec3, weights []float64, sample core.Vec2) core.Vec3 {
+	// Choose a portal, reusing the sample's first dimension
+	chosen := -1
+	for i, weight := range weights {
+		if weight == 0 {
+			continue
+		}
+		chosen = i
+		if sample.X < weight {
+			sample.X /= weight
+			break
+		}
+		sample.X -= weight
+	}
+	sample.X = math.Min(sample.X, 1) // Rounding can leave a little past the last portal
+
+	portalPoint, _ := pl.portals[chosen].SampleSurface(sample)
+	return portalPoint.Subtract(point).Normalize()
+}
+
+// PDF implements the Light interface - the density of Sample's directions: the environment's,
+// and that of every portal the direction passes through
+func (pl *PortalLight) PDF(point, normal, direction core.Vec3) float64 {
+	weights, total := pl.portalWeights(point)
+	if total == 0 {
+		return pl.environment.PDF(point, normal, direction)
+	}
+
+	ray := core.NewRay(point, direction.Normalize())
+	portalPDF := 0.0
+	for i, portal := range pl.portals {
+		if weights[i] == 0 {
+			continue
+		}
+		hit, isHit := portal.Hit(ray, 1e-9, math.Inf(1))
+		if !isHit {
+			continue
+		}
+		cosine := math.Abs(ray.Direction.Dot(portal.Normal))
+		if cosine < 1e-9 {
+			continue
+		}
+		// Convert the portal's area density to solid angle
+		portalPDF += weights[i] * hit.T * hit.T / (cosine * portal.SurfaceArea())
+	}
+	return environmentSampleFraction*pl.environment.PDF(point, normal, direction) + (1-environmentSampleFraction)*portalPDF
+}
+
+// SampleEmission implements the Light interface with the environment's emission sampling
+// Light paths start outside the scene, where the portals don't help.
+func (pl *PortalLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	return pl.environment.SampleEmission(samplePoint, sampleDirection)
+}
+
+// PDF_Le implements the Light interface with the environment's emission densities
+func (pl *PortalLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	return pl.environment.PDF_Le(point, direction)
+}
+
+// Emit implements the Light interface - rays escaping the scene see the environment
+func (pl *PortalLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	return pl.environment.Emit(ray, hit)
+}
+
+// Preprocess implements the Preprocessor interface - passes the world bounds to the environment
+func (pl *PortalLight) Preprocess(worldCenter core.Vec3, worldRadius float64) error {
+	if preprocessor, ok := pl.environment.(geometry.Preprocessor); ok {
+		return preprocessor.Preprocess(worldCenter, worldRadius)
+	}
+	return nil
+}
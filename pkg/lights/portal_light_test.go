@@ -0,0 +1,105 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+// testPortalLight is a uniform sky seen through a window in the ceiling and one in a wall
+func testPortalLight(t *testing.T) *PortalLight {
+	t.Helper()
+	portals := []*geometry.Quad{
+		geometry.NewQuad(core.NewVec3(-0.5, 2, -0.5), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), nil),
+		geometry.NewQuad(core.NewVec3(2, 0.5, -1), core.NewVec3(0, 0, 2), core.NewVec3(0, 1, 0), nil),
+	}
+	light, err := NewPortalLight(NewUniformInfiniteLight(core.NewVec3(2, 2, 2)), portals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return light
+}
+
+func TestPortalLight_SamplingMatchesPDF(t *testing.T) {
+	light := testPortalLight(t)
+	point, normal := core.NewVec3(0, 0, 0), core.NewVec3(0, 1, 0)
+	sampler := core.NewSeededSampler(6)
+
+	throughPortal := 0
+	for i := 0; i < 1000; i++ {
+		sample := light.Sample(point, normal, sampler.Get2D())
+		if pdf := light.PDF(point, normal, sample.Direction); math.Abs(pdf-sample.PDF) > 1e-9*pdf {
+			t.Fatalf("Sampled pdf %f, PDF returned %f", sample.PDF, pdf)
+		}
+		ray := core.NewRay(point, sample.Direction)
+		for _, portal := range light.Portals() {
+			if portal.HitAny(ray, 1e-9, math.Inf(1)) {
+				throughPortal++
+				break
+			}
+		}
+	}
+	if throughPortal < 750 {
+		t.Errorf("Expected most samples to pass through a portal, got %d of 1000", throughPortal)
+	}
+
+	// The directions' density integrates to one
+	const samples = 400000
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		sum += light.PDF(point, normal, core.SampleOnUnitSphere(sampler.Get2D())) * 4 * math.Pi
+	}
+	if integral := sum / samples; math.Abs(integral-1) > 0.03 {
+		t.Errorf("Expected the density to integrate to 1, got %f", integral)
+	}
+}
+
+func TestPortalLight_Irradiance(t *testing.T) {
+	// With nothing in the way the portals change the sampling, not the light: a uniform sky of
+	// radiance L gives irradiance πL
+	light := testPortalLight(t)
+	point, normal := core.NewVec3(0.3, 0, 0.2), core.NewVec3(0, 1, 0)
+	sampler := core.NewSeededSampler(2)
+
+	const samples = 200000
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		sample := light.Sample(point, normal, sampler.Get2D())
+		if cosTheta := sample.Direction.Dot(normal); cosTheta > 0 && sample.PDF > 0 {
+			sum += sample.Emission.X * cosTheta / sample.PDF
+		}
+	}
+	if irradiance := sum / samples; math.Abs(irradiance-2*math.Pi) > 0.03*2*math.Pi {
+		t.Errorf("Expected irradiance %f, got %f", 2*math.Pi, irradiance)
+	}
+}
+
+func TestNewPortalLight_Errors(t *testing.T) {
+	portal := geometry.NewQuad(core.Vec3{}, core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), nil)
+	if _, err := NewPortalLight(NewPointSpotLight(core.Vec3{}, core.NewVec3(0, -1, 0), core.NewVec3(1, 1, 1), 30, 5), []*geometry.Quad{portal}); err == nil {
+		t.Error("Expected an error for a point light")
+	}
+	if _, err := NewPortalLight(NewUniformInfiniteLight(core.NewVec3(1, 1, 1)), nil); err == nil {
+		t.Error("Expected an error without portals")
+	}
+}
+
+func TestPortalLight_PointInPortalPlane(t *testing.T) {
+	// A point on the wall around a window sees the portal edge on: it falls back to the
+	// environment's sampling rather than grazing directions
+	window := geometry.NewQuad(core.NewVec3(-0.5, 0.5, -3), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), nil)
+	light, err := NewPortalLight(NewUniformInfiniteLight(core.NewVec3(1, 1, 1)), []*geometry.Quad{window})
+	if err != nil {
+		t.Fatal(err)
+	}
+	point, normal := core.NewVec3(1, 1, -3), core.NewVec3(0, 0, 1)
+	sampler := core.NewSeededSampler(4)
+	for i := 0; i < 100; i++ {
+		sample := light.Sample(point, normal, sampler.Get2D())
+		if want := light.Environment().PDF(point, normal, sample.Direction); sample.PDF != want {
+			t.Fatalf("Expected the environment's pdf %f, got %f", want, sample.PDF)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lights
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+// powerSamplerSamples is the number of emission samples PowerLightSampler measures each light with
+const powerSamplerSamples = 1024
+
+// PowerLightSampler selects lights in proportion to their emitted power (see EstimatePower), both
+// for direct lighting and for BDPT light paths, so bright lights are sampled more often than dim
+// fill lights. Power accounts for a light's area and cone; an infinite light's is the power
+// crossing the scene's bounds, which is why the weights are measured when the scene is preprocessed.
+// Lights of no measurable power are never selected, unless no light has any.
+type PowerLightSampler struct {
+	*WeightedLightSampler
+}
+
+// NewPowerLightSampler creates a light sampler that weights lights by their power once the scene
+// is preprocessed
+func NewPowerLightSampler(lights []Light) *PowerLightSampler {
+	return &PowerLightSampler{WeightedLightSampler: &WeightedLightSampler{lights: lights}}
+}
+
+// Preprocess measures the power of the preprocessed lights (see SpatialLightSampler)
+func (pls *PowerLightSampler) Preprocess(bounds geometry.AABB) error {
+	lights := pls.lights
+	weights := make([]float64, len(lights))
+	for i, light := range lights {
+		weights[i] = EstimatePower(light, powerSamplerSamples).Luminance()
+	}
+	_, radius := bounds.BoundingSphere()
+	pls.WeightedLightSampler = NewWeightedLightSampler(lights, weights, radius)
+	return nil
+}
+
+// SampleLight selects a light in proportion to its power, independent of the surface point
+// Returns the selected light, its selection probability, and its index
+func (pls *PowerLightSampler) SampleLight(point core.Vec3, normal core.Vec3, u float64) (Light, float64, int) {
+	pls.checkPreprocessed()
+	return pls.WeightedLightSampler.SampleLight(point, normal, u)
+}
+
+// SampleLightEmission selects a light in proportion to its power for emission sampling
+// Returns the selected light, its selection probability, and its index
+func (pls *PowerLightSampler) SampleLightEmission(u float64) (Light, float64, int) {
+	pls.checkPreprocessed()
+	return pls.WeightedLightSampler.SampleLightEmission(u)
+}
+
+// GetLightProbability returns the power share of the light at the given index
+func (pls *PowerLightSampler) GetLightProbability(lightIndex int, point core.Vec3, normal core.Vec3) float64 {
+	pls.checkPreprocessed()
+	return pls.WeightedLightSampler.GetLightProbability(lightIndex, point, normal)
+}
+
+// GetLightEmissionProbability returns the power share of the light at the given index
+func (pls *PowerLightSampler) GetLightEmissionProbability(lightIndex int) float64 {
+	pls.checkPreprocessed()
+	return pls.WeightedLightSampler.GetLightEmissionProbability(lightIndex)
+}
+
+// checkPreprocessed panics if the weights haven't been measured yet
+func (pls *PowerLightSampler) checkPreprocessed() {
+	if len(pls.lights) > 0 && pls.weights == nil {
+		panic("PowerLightSampler used before the scene was preprocessed")
+	}
+}
+
+// String returns a string representation for debugging
+func (pls *PowerLightSampler) String() string {
+	if pls.weights == nil {
+		return fmt.Sprintf("PowerLightSampler{%d lights, not preprocessed}", len(pls.lights))
+	}
+	return strings.Replace(pls.WeightedLightSampler.String(), "WeightedLightSampler", "PowerLightSampler", 1)
+}
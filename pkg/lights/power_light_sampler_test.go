@@ -0,0 +1,67 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestPowerLightSampler_ProportionalToPower(t *testing.T) {
+	// Power grows with emission and area: the big light has 4x the area, the bright one 9x the
+	// emission of the dim fill light
+	lights := []Light{
+		NewSphereLight(core.NewVec3(-5, 0, 0), 0.5, material.NewEmissive(core.NewVec3(1, 1, 1))),
+		NewSphereLight(core.NewVec3(0, 0, 0), 1, material.NewEmissive(core.NewVec3(1, 1, 1))),
+		NewSphereLight(core.NewVec3(5, 0, 0), 0.5, material.NewEmissive(core.NewVec3(9, 9, 9))),
+	}
+	pls := NewPowerLightSampler(lights)
+	if err := pls.Preprocess(geometry.NewAABB(core.NewVec3(-6, -1, -1), core.NewVec3(6, 1, 1))); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	expected := []float64{1.0 / 14, 4.0 / 14, 9.0 / 14}
+	point, normal := core.NewVec3(0, 5, 0), core.NewVec3(0, 1, 0)
+	for i, want := range expected {
+		if got := pls.GetLightProbability(i, point, normal); math.Abs(got-want) > 0.02 {
+			t.Errorf("Light %d: expected probability %.3f, got %.3f", i, want, got)
+		}
+		// Emission sampling for BDPT light paths uses the same weights
+		if got := pls.GetLightEmissionProbability(i); got != pls.GetLightProbability(i, point, normal) {
+			t.Errorf("Light %d: expected the emission probability to match, got %.3f", i, got)
+		}
+	}
+
+	light, pdf, index := pls.SampleLightEmission(0.99)
+	if index != 2 || light != lights[2] || pdf != pls.GetLightEmissionProbability(2) {
+		t.Errorf("Expected u=0.99 to select the bright light, got index %d with probability %f", index, pdf)
+	}
+}
+
+func TestPowerLightSampler_InfiniteLight(t *testing.T) {
+	// An infinite light's power is what crosses the scene's bounds, so it needs preprocessing
+	sky := NewUniformInfiniteLight(core.NewVec3(1, 1, 1))
+	lights := []Light{sky, NewSphereLight(core.NewVec3(0, 0, 0), 0.5, material.NewEmissive(core.NewVec3(1, 1, 1)))}
+	if err := sky.Preprocess(core.NewVec3(0, 0, 0), 10); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	pls := NewPowerLightSampler(lights)
+	if err := pls.Preprocess(geometry.NewAABB(core.NewVec3(-5, -5, -5), core.NewVec3(5, 5, 5))); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if sky, sphere := pls.GetLightEmissionProbability(0), pls.GetLightEmissionProbability(1); sky <= sphere {
+		t.Errorf("Expected the sky around a large scene to outweigh a small light, got %f vs %f", sky, sphere)
+	}
+}
+
+func TestPowerLightSampler_NotPreprocessed(t *testing.T) {
+	pls := NewPowerLightSampler([]Light{&MockLight{emission: core.NewVec3(1, 1, 1), pdf: 1}})
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic when sampling before preprocessing")
+		}
+	}()
+	pls.SampleLightEmission(0.5)
+}
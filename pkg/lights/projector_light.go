@@ -0,0 +1,149 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// ProjectorLight is a point light that projects an image, like a slide projector or a theatre
+// light with a gobo: the intensity in each direction inside its frustum is the image's color
+// there times the light's intensity
+type ProjectorLight struct {
+	position      core.Vec3 // Light position in world space
+	forward       core.Vec3 // Normalized projection direction
+	right, up     core.Vec3 // Image axes, perpendicular to forward
+	tanHalfWidth  float64   // Half the image's width on a plane at distance 1
+	tanHalfHeight float64   // Half the image's height on a plane at distance 1
+	image         material.ColorSource
+	intensity     core.Vec3 // Intensity where the image is white
+}
+
+// NewProjectorLight creates a projector at from, aimed at to, with the image upright along up
+// fovDegrees: vertical field of view
+// aspectRatio: image width over height
+// intensity: intensity where the image is white
+func NewProjectorLight(from, to, up core.Vec3, fovDegrees, aspectRatio float64, image material.ColorSource, intensity core.Vec3) *ProjectorLight {
+	forward := to.Subtract(from).Normalize()
+	right := forward.Cross(up).Normalize()
+	tanHalfHeight := math.Tan(fovDegrees * math.Pi / 360.0)
+
+	return &ProjectorLight{
+		position:      from,
+		forward:       forward,
+		right:         right,
+		up:            right.Cross(forward),
+		tanHalfWidth:  tanHalfHeight * aspectRatio,
+		tanHalfHeight: tanHalfHeight,
+		image:         image,
+		intensity:     intensity,
+	}
+}
+
+func (pl *ProjectorLight) Type() LightType {
+	return LightTypePoint
+}
+
+// project returns where a direction from the light crosses the image, in texture coordinates
+func (pl *ProjectorLight) project(direction core.Vec3) (core.Vec2, bool) {
+	z := direction.Dot(pl.forward)
+	if z <= 0 {
+		return core.Vec2{}, false
+	}
+	x := direction.Dot(pl.right) / (z * pl.tanHalfWidth)
+	y := direction.Dot(pl.up) / (z * pl.tanHalfHeight)
+	if x < -1 || x > 1 || y < -1 || y > 1 {
+		return core.Vec2{}, false
+	}
+	return core.NewVec2((x+1)/2, (y+1)/2), true
+}
+
+// intensityToward returns the intensity the light sends along a direction
+func (pl *ProjectorLight) intensityToward(direction core.Vec3) core.Vec3 {
+	uv, inside := pl.project(direction)
+	if !inside {
+		return core.Vec3{}
+	}
+	return pl.intensity.MultiplyVec(pl.image.Evaluate(uv, pl.position))
+}
+
+// directionPDF returns the solid angle density of SampleEmission's directions: uniform over the
+// image plane at distance 1, whose area element subtends dA cos θ / r² = dA / r³
+func (pl *ProjectorLight) directionPDF(direction core.Vec3) float64 {
+	if _, inside := pl.project(direction); !inside {
+		return 0
+	}
+	r := 1 / direction.Dot(pl.forward)
+	return r * r * r / (4 * pl.tanHalfWidth * pl.tanHalfHeight)
+}
+
+// Sample implements the Light interface - the light arrives from the projector's position
+func (pl *ProjectorLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	toLightVec := pl.position.Subtract(point)
+	distance := toLightVec.Length()
+	if distance == 0 {
+		return LightSample{
+			Point:     pl.position,
+			Normal:    core.NewVec3(0, 1, 0),
+			Direction: core.NewVec3(0, 1, 0),
+			Emission:  core.NewVec3(0, 0, 0),
+			PDF:       1.0,
+		}
+	}
+
+	toLight := toLightVec.Multiply(1 / distance)
+	return LightSample{
+		Point:     pl.position,
+		Normal:    toLight.Multiply(-1),
+		Direction: toLight,
+		Distance:  distance,
+		Emission:  pl.intensityToward(toLight.Multiply(-1)).Multiply(1 / (distance * distance)),
+		PDF:       1.0, // Delta light
+	}
+}
+
+// PDF implements the Light interface - a delta function, as for PointSpotLight
+func (pl *ProjectorLight) PDF(point, normal, direction core.Vec3) float64 {
+	toLightVec := pl.position.Subtract(point)
+	if toLightVec.Length() == 0 {
+		return 0.0
+	}
+	if direction.Dot(toLightVec.Normalize()) > 0.999 {
+		return 1.0
+	}
+	return 0.0
+}
+
+// SampleEmission implements the Light interface - samples a direction through the image
+func (pl *ProjectorLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	x := (2*sampleDirection.X - 1) * pl.tanHalfWidth
+	y := (2*sampleDirection.Y - 1) * pl.tanHalfHeight
+	direction := pl.forward.Add(pl.right.Multiply(x)).Add(pl.up.Multiply(y)).Normalize()
+
+	return EmissionSample{
+		Point:        pl.position,
+		Normal:       direction,
+		Direction:    direction,
+		Emission:     pl.intensityToward(direction),
+		AreaPDF:      1.0, // Point light has no area
+		DirectionPDF: pl.directionPDF(direction),
+	}
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (pl *ProjectorLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	if point.Subtract(pl.position).Length() > 0.001 {
+		return 0.0, 0.0
+	}
+	pdfDir = pl.directionPDF(direction)
+	if pdfDir == 0 {
+		return 0.0, 0.0
+	}
+	return 1.0, pdfDir
+}
+
+// Emit implements the Light interface - rays never hit a point light
+func (pl *ProjectorLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	return core.Vec3{}
+}
@@ -0,0 +1,76 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// testProjector projects a 2x2 image (red, green above blue, white) down -z from the origin
+func testProjector() *ProjectorLight {
+	image := material.NewImageTexture(2, 2, []core.Vec3{
+		core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0),
+		core.NewVec3(0, 0, 1), core.NewVec3(1, 1, 1),
+	})
+	return NewProjectorLight(core.Vec3{}, core.NewVec3(0, 0, -1), core.NewVec3(0, 1, 0), 90, 2, image, core.NewVec3(4, 4, 4))
+}
+
+func TestProjectorLight_Sample(t *testing.T) {
+	light := testProjector()
+	normal := core.NewVec3(0, 0, 1)
+
+	// On a wall at distance 1 the image spans x in [-2, 2] and y in [-1, 1]
+	tests := []struct {
+		name     string
+		point    core.Vec3
+		expected core.Vec3
+	}{
+		{"top left", core.NewVec3(-1, 0.5, -1), core.NewVec3(1, 0, 0)},
+		{"top right", core.NewVec3(1, 0.5, -1), core.NewVec3(0, 1, 0)},
+		{"bottom left", core.NewVec3(-1, -0.5, -1), core.NewVec3(0, 0, 1)},
+		{"bottom right", core.NewVec3(1, -0.5, -1), core.NewVec3(1, 1, 1)},
+		{"outside the frustum", core.NewVec3(3, 0, -1), core.Vec3{}},
+		{"behind", core.NewVec3(0, 0, 1), core.Vec3{}},
+	}
+	for _, tt := range tests {
+		sample := light.Sample(tt.point, normal, core.Vec2{})
+		expected := tt.expected.Multiply(4 / tt.point.LengthSquared())
+		if sample.Emission.Subtract(expected).Length() > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", tt.name, expected, sample.Emission)
+		}
+		if sample.Direction.Subtract(tt.point.Normalize().Multiply(-1)).Length() > 1e-9 {
+			t.Errorf("%s: expected the direction toward the light, got %v", tt.name, sample.Direction)
+		}
+	}
+}
+
+func TestProjectorLight_EmissionPDF(t *testing.T) {
+	light := testProjector()
+
+	// Emission samples report the density PDF_Le gives their direction
+	for _, u := range []core.Vec2{core.NewVec2(0.5, 0.5), core.NewVec2(0.1, 0.9), core.NewVec2(0.95, 0.2)} {
+		sample := light.SampleEmission(core.Vec2{}, u)
+		pdfPos, pdfDir := light.PDF_Le(sample.Point, sample.Direction)
+		if pdfPos != 1 || math.Abs(pdfDir-sample.DirectionPDF) > 1e-9 {
+			t.Errorf("Sample %v: PDF_Le gave (%f, %f), sampling %f", u, pdfPos, pdfDir, sample.DirectionPDF)
+		}
+	}
+
+	// The density integrates to one over the sphere of directions
+	sampler := core.NewSeededSampler(7)
+	const samples = 200000
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		_, pdf := light.PDF_Le(core.Vec3{}, core.SampleOnUnitSphere(sampler.Get2D()))
+		sum += pdf * 4 * math.Pi
+	}
+	if integral := sum / samples; math.Abs(integral-1) > 0.02 {
+		t.Errorf("Expected the direction density to integrate to 1, got %f", integral)
+	}
+
+	if _, pdfDir := light.PDF_Le(core.Vec3{}, core.NewVec3(0, 0, 1)); pdfDir != 0 {
+		t.Errorf("Expected no density behind the projector, got %f", pdfDir)
+	}
+}
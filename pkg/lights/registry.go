@@ -0,0 +1,42 @@
+package lights
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Factory builds a light of a registered type from its parameters. The transform places it in
+// the world: PBRT's current transformation, or the identity for JSON and YAML.
+type Factory func(params core.Params, transform core.Matrix4) (Light, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register adds a light type for scene files to use by name: PBRT's LightSource statements, and
+// JSON and YAML lights, whose parameters go under "params". A loader's own types come first, so
+// a name it already knows never reaches the factory. The light is only sampled; a light that
+// should also be seen needs a shape of its own. Register panics if the name is empty or taken,
+// since it's meant to be called from an init function.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("lights: Register needs a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("lights: type %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered for a light type
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
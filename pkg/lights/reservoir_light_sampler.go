@@ -0,0 +1,170 @@
+package lights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+)
+
+// reservoirCandidates is the number of lights each reservoir resamples its light from
+const reservoirCandidates = 16
+
+// ReservoirLightSampler selects lights from reservoirs cached in a grid over the scene (ReGIR).
+// Each cell keeps a fixed number of reservoirs, and each reservoir holds one light resampled by
+// weighted reservoir sampling from candidates chosen uniformly, in proportion to the unoccluded
+// light each brings to a random point of the cell. A cell's reservoirs are thus a sample of its
+// important lights that costs the same however many lights the scene has, which suits scenes of
+// hundreds of emitters better than GridLightSampler's per-light probabilities.
+//
+// A point picks one of its cell's reservoirs at random, so a light's probability is the share of
+// the reservoirs holding it. As in GridLightSampler, part of the probability is spread evenly over
+// all lights, so lights no reservoir picked are still sampled. Emission sampling is uniform.
+type ReservoirLightSampler struct {
+	lights     []Light
+	resolution int // Cells along the longest axis of the scene's bounds
+	reservoirs int // Reservoirs per cell
+
+	lightGrid
+	slots []int // Per cell, the light of each reservoir, sorted to count them by binary search
+}
+
+// NewReservoirLightSampler creates a light sampler with a grid of resolution cells along the
+// longest axis of the scene's bounds and the given number of reservoirs per cell. The reservoirs
+// are filled when the scene is preprocessed.
+func NewReservoirLightSampler(lights []Light, resolution, reservoirs int) *ReservoirLightSampler {
+	if resolution < 1 {
+		panic(fmt.Sprintf("reservoir light sampler resolution must be at least 1, got %d", resolution))
+	}
+	if reservoirs < 1 {
+		panic(fmt.Sprintf("reservoir light sampler needs at least 1 reservoir per cell, got %d", reservoirs))
+	}
+	return &ReservoirLightSampler{lights: lights, resolution: resolution, reservoirs: reservoirs}
+}
+
+// Preprocess fills the reservoirs of a grid over the scene's bounds (see SpatialLightSampler)
+func (rls *ReservoirLightSampler) Preprocess(bounds geometry.AABB) error {
+	rls.lightGrid = newLightGrid(bounds, rls.resolution)
+	rls.slots = make([]int, rls.cellCount()*rls.reservoirs)
+	n := len(rls.lights)
+	if n == 0 {
+		return nil
+	}
+
+	sampler := core.NewSeededSampler(1)
+	for z := 0; z < rls.cells[2]; z++ {
+		for y := 0; y < rls.cells[1]; y++ {
+			for x := 0; x < rls.cells[0]; x++ {
+				slots := rls.slots[rls.cellIndex(x, y, z)*rls.reservoirs:][:rls.reservoirs]
+				for r := range slots {
+					slots[r] = rls.resample(x, y, z, sampler)
+				}
+				sort.Ints(slots)
+			}
+		}
+	}
+	return nil
+}
+
+// resample fills one reservoir of cell (x, y, z): it streams candidate lights, each weighted by the
+// light it brings to a random point of the cell over its (uniform) candidate probability, and
+// keeps each with probability its weight over the weights so far. A reservoir whose candidates all
+// bring no light keeps a uniformly chosen one.
+func (rls *ReservoirLightSampler) resample(x, y, z int, sampler core.Sampler) int {
+	n := len(rls.lights)
+	chosen := min(int(sampler.Get1D()*float64(n)), n-1)
+	weightSum := 0.0
+	for c := 0; c < reservoirCandidates; c++ {
+		candidate := min(int(sampler.Get1D()*float64(n)), n-1)
+		point := rls.cellPoint(x, y, z, core.NewVec3(sampler.Get1D(), sampler.Get1D(), sampler.Get1D()))
+		normal := gridProbeNormals[min(int(sampler.Get1D()*float64(len(gridProbeNormals))), len(gridProbeNormals)-1)]
+		sample := rls.lights[candidate].Sample(point, normal, sampler.Get2D())
+		if sample.PDF <= 0 || math.IsInf(sample.PDF, 0) {
+			continue
+		}
+		weight := sample.Emission.Luminance() / sample.PDF * float64(n)
+		if weight <= 0 {
+			continue
+		}
+		weightSum += weight
+		if sampler.Get1D()*weightSum < weight {
+			chosen = candidate
+		}
+	}
+	return chosen
+}
+
+// cellSlots returns the sorted reservoir lights of the cell containing the point
+func (rls *ReservoirLightSampler) cellSlots(point core.Vec3) []int {
+	if rls.slots == nil {
+		panic("ReservoirLightSampler used before the scene was preprocessed")
+	}
+	return rls.slots[rls.cellAt(point)*rls.reservoirs:][:rls.reservoirs]
+}
+
+// SampleLight selects a light from a random reservoir of the cell containing the point, or
+// uniformly for the evenly spread share of the probability
+// Returns the selected light, its selection probability, and its index
+func (rls *ReservoirLightSampler) SampleLight(point core.Vec3, normal core.Vec3, u float64) (Light, float64, int) {
+	n := len(rls.lights)
+	if n == 0 {
+		return nil, 0.0, -1
+	}
+	slots := rls.cellSlots(point)
+	var i int
+	if u < gridUniformFraction {
+		i = min(int(u/gridUniformFraction*float64(n)), n-1)
+	} else {
+		r := (u - gridUniformFraction) / (1 - gridUniformFraction)
+		i = slots[min(int(r*float64(len(slots))), len(slots)-1)]
+	}
+	return rls.lights[i], rls.probability(slots, i), i
+}
+
+// SampleLightEmission selects a light uniformly for emission sampling
+// Returns the selected light, its selection probability, and its index
+func (rls *ReservoirLightSampler) SampleLightEmission(u float64) (Light, float64, int) {
+	n := len(rls.lights)
+	if n == 0 {
+		return nil, 0.0, -1
+	}
+	i := min(int(u*float64(n)), n-1)
+	return rls.lights[i], 1.0 / float64(n), i
+}
+
+// GetLightProbability returns the probability of the light at the given index in the cell
+// containing the point
+func (rls *ReservoirLightSampler) GetLightProbability(lightIndex int, point core.Vec3, normal core.Vec3) float64 {
+	if lightIndex < 0 || lightIndex >= len(rls.lights) {
+		return 0.0
+	}
+	return rls.probability(rls.cellSlots(point), lightIndex)
+}
+
+// probability returns the probability of selecting light i from a cell's sorted reservoirs: its
+// share of the reservoirs, mixed with the uniform share
+func (rls *ReservoirLightSampler) probability(slots []int, i int) float64 {
+	count := sort.SearchInts(slots, i+1) - sort.SearchInts(slots, i)
+	return (1-gridUniformFraction)*float64(count)/float64(len(slots)) + gridUniformFraction/float64(len(rls.lights))
+}
+
+// GetLightEmissionProbability returns the uniform emission selection probability
+func (rls *ReservoirLightSampler) GetLightEmissionProbability(lightIndex int) float64 {
+	if lightIndex < 0 || lightIndex >= len(rls.lights) {
+		return 0.0
+	}
+	return 1.0 / float64(len(rls.lights))
+}
+
+// GetLightCount returns the number of lights in this sampler
+func (rls *ReservoirLightSampler) GetLightCount() int {
+	return len(rls.lights)
+}
+
+// String returns a string representation for debugging
+func (rls *ReservoirLightSampler) String() string {
+	return fmt.Sprintf("ReservoirLightSampler{%d lights, %dx%dx%d cells of %d reservoirs}",
+		len(rls.lights), rls.cells[0], rls.cells[1], rls.cells[2], rls.reservoirs)
+}
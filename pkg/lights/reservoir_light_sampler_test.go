@@ -0,0 +1,107 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// newRowReservoirSampler returns a preprocessed reservoir sampler for a row of count equal sphere
+// lights spaced 2 units apart along x
+func newRowReservoirSampler(t *testing.T, count, resolution, reservoirs int) *ReservoirLightSampler {
+	t.Helper()
+	emissive := material.NewEmissive(core.NewVec3(5, 5, 5))
+	lights := make([]Light, count)
+	for i := range lights {
+		lights[i] = NewSphereLight(core.NewVec3(float64(2*i), 0, 0), 0.25, emissive)
+	}
+	rls := NewReservoirLightSampler(lights, resolution, reservoirs)
+	length := float64(2 * count)
+	if err := rls.Preprocess(geometry.NewAABB(core.NewVec3(-1, -1, -1), core.NewVec3(length-1, 1, 1))); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	return rls
+}
+
+func TestReservoirLightSampler_ProbabilitiesSumToOne(t *testing.T) {
+	rls := newRowReservoirSampler(t, 50, 50, 16)
+	for x := -5.0; x <= 105; x += 7.5 {
+		point := core.NewVec3(x, 0.3, -0.7)
+		sum := 0.0
+		for i := 0; i < rls.GetLightCount(); i++ {
+			sum += rls.GetLightProbability(i, point, core.NewVec3(0, 1, 0))
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("Probabilities at %v sum to %f, expected 1", point, sum)
+		}
+	}
+}
+
+func TestReservoirLightSampler_FavorsNearbyLights(t *testing.T) {
+	// In a row of 50 lights, the reservoirs next to light 20 often hold it or its neighbors,
+	// though each reservoir only sees 16 random candidates
+	rls := newRowReservoirSampler(t, 50, 50, 32)
+	point, normal := core.NewVec3(40, 0.5, 0), core.NewVec3(0, 1, 0)
+	nearby := 0.0
+	for i := 19; i <= 21; i++ {
+		nearby += rls.GetLightProbability(i, point, normal)
+	}
+	if uniform := 3.0 / 50; nearby < 5*uniform {
+		t.Errorf("Expected the three nearest of 50 lights to be chosen far more often than %f, got %f", uniform, nearby)
+	}
+
+	// Every light keeps its share of the uniform probability
+	if far, minimum := rls.GetLightProbability(0, point, normal), gridUniformFraction/50; far < minimum {
+		t.Errorf("Expected a far light's probability to be at least %f, got %f", minimum, far)
+	}
+}
+
+func TestReservoirLightSampler_SampleMatchesProbability(t *testing.T) {
+	rls := newRowReservoirSampler(t, 10, 10, 16)
+	point, normal := core.NewVec3(6, 0, 0), core.NewVec3(0, 1, 0)
+
+	counts := make([]int, rls.GetLightCount())
+	const samples = 20000
+	for k := 0; k < samples; k++ {
+		u := (float64(k) + 0.5) / samples
+		light, prob, index := rls.SampleLight(point, normal, u)
+		if light != rls.lights[index] {
+			t.Fatalf("SampleLight returned light %d with a different light", index)
+		}
+		if expected := rls.GetLightProbability(index, point, normal); prob != expected {
+			t.Fatalf("SampleLight probability %f differs from GetLightProbability %f", prob, expected)
+		}
+		counts[index]++
+	}
+	for i, count := range counts {
+		expected := rls.GetLightProbability(i, point, normal)
+		if got := float64(count) / samples; math.Abs(got-expected) > 0.01 {
+			t.Errorf("Light %d selected with frequency %f, expected %f", i, got, expected)
+		}
+	}
+}
+
+func TestReservoirLightSampler_EmissionIsUniform(t *testing.T) {
+	rls := newRowReservoirSampler(t, 4, 4, 8)
+	for i := 0; i < rls.GetLightCount(); i++ {
+		if p := rls.GetLightEmissionProbability(i); p != 0.25 {
+			t.Errorf("Expected emission probability 0.25 for light %d, got %f", i, p)
+		}
+	}
+}
+
+func TestNewReservoirLightSampler_Invalid(t *testing.T) {
+	for _, args := range [][2]int{{0, 8}, {8, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for resolution %d and %d reservoirs", args[0], args[1])
+				}
+			}()
+			NewReservoirLightSampler(nil, args[0], args[1])
+		}()
+	}
+}
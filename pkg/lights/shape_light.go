@@ -0,0 +1,125 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// ShapeLight promotes any area-sampleable shape to a diffuse area light
+// Sampling and PDFs come from the shape's SurfaceSampler implementation, so new emissive
+// shapes don't need a bespoke light type
+type ShapeLight struct {
+	geometry.SurfaceSampler                   // Embed shape for hit testing and surface sampling
+	Material                material.Material // Emissive material used for light evaluation
+}
+
+// NewShapeLight creates an area light from a shape
+// The shape should use the same emissive material so camera rays see the emission
+func NewShapeLight(shape geometry.SurfaceSampler, material material.Material) *ShapeLight {
+	return &ShapeLight{
+		SurfaceSampler: shape,
+		Material:       material,
+	}
+}
+
+func (sl *ShapeLight) Type() LightType {
+	return LightTypeArea
+}
+
+// Sample implements the Light interface - samples a point on the shape for direct lighting
+func (sl *ShapeLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
+	samplePoint, lightNormal := sl.SampleSurface(sample)
+
+	// Calculate direction from shading point to light sample
+	toLight := samplePoint.Subtract(point)
+	distance := toLight.Length()
+	if distance == 0 {
+		return LightSample{Point: samplePoint, Normal: lightNormal, PDF: 0}
+	}
+	direction := toLight.Multiply(1.0 / distance)
+
+	// Convert area PDF to solid angle PDF: PDF_area * distance² / |cos(θ)|
+	cosTheta := math.Abs(lightNormal.Dot(direction))
+	if cosTheta < 1e-8 {
+		// Light is edge-on, no contribution
+		return LightSample{
+			Point:     samplePoint,
+			Normal:    lightNormal,
+			Direction: direction,
+			Distance:  distance,
+			PDF:       0,
+		}
+	}
+	solidAnglePDF := distance * distance / (sl.SurfaceArea() * cosTheta)
+
+	// Only emit from the front face (ray toward the light opposes the outward normal)
+	var emission core.Vec3
+	if direction.Dot(lightNormal) < 0 {
+		emission = sl.Emit(core.NewRay(point, direction), nil)
+	}
+
+	return LightSample{
+		Point:     samplePoint,
+		Normal:    lightNormal,
+		Direction: direction,
+		Distance:  distance,
+		Emission:  emission,
+		PDF:       solidAnglePDF,
+	}
+}
+
+// PDF implements the Light interface - returns the probability density for sampling a given direction
+func (sl *ShapeLight) PDF(point, normal, direction core.Vec3) float64 {
+	// Check if ray from point in direction hits the shape
+	ray := core.NewRay(point, direction)
+	hitRecord, hit := sl.Hit(ray, 0.001, math.Inf(1))
+	if !hit {
+		return 0.0
+	}
+
+	// The surface's own normal, as SampleSurface returns, rather than the hit's shading normal
+	cosTheta := math.Abs(sl.NormalAt(hitRecord.Point).Dot(direction))
+	if cosTheta < 1e-8 {
+		return 0.0
+	}
+
+	areaPDF := sl.AreaPDF(hitRecord.Point)
+	return areaPDF * hitRecord.T * hitRecord.T / cosTheta
+}
+
+// SampleEmission implements the Light interface - samples emission from the shape surface
+func (sl *ShapeLight) SampleEmission(samplePoint core.Vec2, sampleDirection core.Vec2) EmissionSample {
+	point, normal := sl.SampleSurface(samplePoint)
+	areaPDF := 1.0 / sl.SurfaceArea()
+	return SampleEmissionDirection(point, normal, areaPDF, sl.Material, sampleDirection)
+}
+
+// PDF_Le implements the Light interface - returns both position and directional PDFs
+func (sl *ShapeLight) PDF_Le(point core.Vec3, direction core.Vec3) (pdfPos, pdfDir float64) {
+	pdfPos = sl.AreaPDF(point)
+	if pdfPos == 0 {
+		return 0.0, 0.0
+	}
+
+	// Directional PDF: cosine-weighted hemisphere for Lambertian emission
+	cosTheta := direction.Dot(sl.NormalAt(point))
+	if cosTheta <= 0 {
+		return pdfPos, 0.0
+	}
+	pdfDir = cosTheta / math.Pi
+
+	return pdfPos, pdfDir
+}
+
+// Emit implements the Light interface - returns material emission
+func (sl *ShapeLight) Emit(ray core.Ray, hit *material.SurfaceInteraction) core.Vec3 {
+	// Area lights emit according to their material
+	if emitter, isEmissive := sl.Material.(material.Emitter); isEmissive {
+		return emitter.Emit(ray, hit)
+	}
+	return core.Vec3{X: 0, Y: 0, Z: 0}
+}
@@ -0,0 +1,174 @@
+package lights
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestShapeLight_MatchesQuadLight(t *testing.T) {
+	const tolerance = 1e-9
+
+	emissiveMat := material.NewEmissive(core.NewVec3(5, 5, 5))
+	corner := core.NewVec3(-1, 2, -1)
+	u := core.NewVec3(2, 0, 0)
+	v := core.NewVec3(0, 0, 2)
+
+	quadLight := NewQuadLight(corner, u, v, emissiveMat)
+	shapeLight := NewShapeLight(geometry.NewQuad(corner, u, v, emissiveMat), emissiveMat)
+
+	point := core.NewVec3(0.3, 0, 0.1)
+	normal := core.NewVec3(0, 1, 0)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+
+	for i := 0; i < 100; i++ {
+		sample := sampler.Get2D()
+		expected := quadLight.Sample(point, normal, sample)
+		got := shapeLight.Sample(point, normal, sample)
+
+		if !got.Point.Equals(expected.Point) || !got.Direction.Equals(expected.Direction) {
+			t.Fatalf("Sample geometry mismatch: got point=%v dir=%v, expected point=%v dir=%v", got.Point, got.Direction, expected.Point, expected.Direction)
+		}
+		if math.Abs(got.PDF-expected.PDF) > tolerance || !got.Emission.Equals(expected.Emission) {
+			t.Fatalf("Sample mismatch: got pdf=%f emission=%v, expected pdf=%f emission=%v", got.PDF, got.Emission, expected.PDF, expected.Emission)
+		}
+
+		pdf := shapeLight.PDF(point, normal, got.Direction)
+		if math.Abs(pdf-expected.PDF) > 1e-6 {
+			t.Fatalf("PDF mismatch for sampled direction: got %f, expected %f", pdf, expected.PDF)
+		}
+	}
+
+	emissionPoint := core.NewVec3(0, 2, 0)
+	emissionDir := core.NewVec3(0.2, -1, 0.1).Normalize()
+	qPos, qDir := quadLight.PDF_Le(emissionPoint, emissionDir)
+	sPos, sDir := shapeLight.PDF_Le(emissionPoint, emissionDir)
+	if math.Abs(qPos-sPos) > tolerance || math.Abs(qDir-sDir) > tolerance {
+		t.Errorf("PDF_Le mismatch: got (%f, %f), expected (%f, %f)", sPos, sDir, qPos, qDir)
+	}
+}
+
+func TestShapeLight_SolidAnglePDFIntegratesToVisibleArea(t *testing.T) {
+	// The solid angle PDF only accounts for first hits, so ∫ pdf(ω) dω equals the fraction of
+	// surface area visible from the shading point. For a sphere at distance d that is (1 - r/d) / 2.
+	emissiveMat := material.NewEmissive(core.NewVec3(1, 1, 1))
+	sphere := geometry.NewSphere(core.NewVec3(0, 3, 0), 1.0, emissiveMat)
+	light := NewShapeLight(sphere, emissiveMat)
+
+	point := core.NewVec3(0, 0, 0)
+	normal := core.NewVec3(0, 1, 0)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(3)))
+
+	// Estimate the integral with uniform direction sampling: E[pdf(ω) * 4π]
+	const numSamples = 200000
+	integral := 0.0
+	for i := 0; i < numSamples; i++ {
+		direction := core.SampleOnUnitSphere(sampler.Get2D())
+		integral += light.PDF(point, normal, direction) * 4 * math.Pi
+	}
+	integral /= numSamples
+
+	expected := (1.0 - 1.0/3.0) / 2.0
+	if math.Abs(integral-expected) > 0.01 {
+		t.Errorf("Expected solid angle PDF to integrate to %f, got %f", expected, integral)
+	}
+}
+
+func TestShapeLight_SampleEmission(t *testing.T) {
+	emissiveMat := material.NewEmissive(core.NewVec3(2, 2, 2))
+	sphere := geometry.NewSphere(core.NewVec3(0, 0, 0), 2.0, emissiveMat)
+	light := NewShapeLight(sphere, emissiveMat)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(11)))
+
+	expectedAreaPDF := 1.0 / (4 * math.Pi * 4)
+	for i := 0; i < 100; i++ {
+		sample := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		if math.Abs(sample.AreaPDF-expectedAreaPDF) > 1e-12 {
+			t.Fatalf("Expected area PDF %f, got %f", expectedAreaPDF, sample.AreaPDF)
+		}
+		if sample.Direction.Dot(sample.Normal) <= 0 {
+			t.Fatalf("Emission direction %v not in normal hemisphere %v", sample.Direction, sample.Normal)
+		}
+
+		pdfPos, pdfDir := light.PDF_Le(sample.Point, sample.Direction)
+		if math.Abs(pdfPos-sample.AreaPDF) > 1e-12 || math.Abs(pdfDir-sample.DirectionPDF) > 1e-9 {
+			t.Fatalf("PDF_Le (%f, %f) doesn't match sampled PDFs (%f, %f)", pdfPos, pdfDir, sample.AreaPDF, sample.DirectionPDF)
+		}
+	}
+}
+
+func TestShapeLight_CurvedShapesSamplePDFConsistency(t *testing.T) {
+	// Neon tube, lampshade and capsule strip light: the PDF reported by Sample must match PDF() for the sampled direction
+	emissiveMat := material.NewEmissive(core.NewVec3(3, 3, 3))
+	tube := geometry.NewCylinder(core.NewVec3(-1, 2, 0), core.NewVec3(1, 2, 0), 0.1, false, emissiveMat)
+	shade, err := geometry.NewCone(core.NewVec3(0, 1, 3), 0.8, core.NewVec3(0, 1.6, 3), 0.4, false, emissiveMat)
+	if err != nil {
+		t.Fatalf("NewCone failed: %v", err)
+	}
+
+	point := core.NewVec3(0.2, 0, 1)
+	normal := core.NewVec3(0, 1, 0)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(5)))
+
+	capsule := geometry.NewCapsule(core.NewVec3(-1, 1.5, -1), core.NewVec3(1, 1.5, -1), 0.15, emissiveMat)
+
+	for name, shape := range map[string]geometry.SurfaceSampler{"tube": tube, "lampshade": shade, "capsule": capsule} {
+		light := NewShapeLight(shape, emissiveMat)
+		for i := 0; i < 200; i++ {
+			sample := light.Sample(point, normal, sampler.Get2D())
+			if sample.PDF <= 0 {
+				continue // Sampled point faces away from the shading point
+			}
+
+			// Only points not occluded by the shape itself are found by PDF's hit test
+			hit, isHit := shape.Hit(core.NewRay(point, sample.Direction), 0.001, math.Inf(1))
+			if !isHit || hit.Point.Subtract(sample.Point).Length() > 1e-6 {
+				continue
+			}
+
+			pdf := light.PDF(point, normal, sample.Direction)
+			if math.Abs(pdf-sample.PDF) > 1e-6*sample.PDF {
+				t.Fatalf("%s: PDF mismatch for sampled direction: Sample=%f, PDF=%f", name, sample.PDF, pdf)
+			}
+		}
+	}
+}
+
+func TestShapeLight_Mesh(t *testing.T) {
+	// A smooth-shaded octahedron, in float64 and float32: its light samples it by face area, and
+	// PDF uses the faces' own normals rather than the interpolated shading normals
+	emissiveMat := material.NewEmissive(core.NewVec3(2, 2, 2))
+	vertices := []core.Vec3{
+		core.NewVec3(1, 2, 0), core.NewVec3(-1, 2, 0), core.NewVec3(0, 3, 0),
+		core.NewVec3(0, 1, 0), core.NewVec3(0, 2, 2), core.NewVec3(0, 2, -2),
+	}
+	faces := []int{0, 2, 4, 2, 1, 4, 1, 3, 4, 3, 0, 4, 2, 0, 5, 1, 2, 5, 3, 1, 5, 0, 3, 5}
+	point, normal := core.NewVec3(0.3, 0, 0.4), core.NewVec3(0, 1, 0)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(9)))
+
+	for _, compact := range []bool{false, true} {
+		mesh := geometry.NewTriangleMesh(vertices, faces, emissiveMat, &geometry.TriangleMeshOptions{SmoothNormals: true, Float32: compact})
+		light := NewShapeLight(mesh, emissiveMat)
+		for i := 0; i < 200; i++ {
+			sample := light.Sample(point, normal, sampler.Get2D())
+			if sample.PDF > 0 {
+				hit, isHit := mesh.Hit(core.NewRay(point, sample.Direction), 0.001, math.Inf(1))
+				if isHit && hit.Point.Subtract(sample.Point).Length() < 1e-6 {
+					if pdf := light.PDF(point, normal, sample.Direction); math.Abs(pdf-sample.PDF) > 1e-6*sample.PDF {
+						t.Fatalf("float32=%v: PDF mismatch for sampled direction: Sample=%f, PDF=%f", compact, sample.PDF, pdf)
+					}
+				}
+			}
+
+			emission := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+			pdfPos, pdfDir := light.PDF_Le(emission.Point, emission.Direction)
+			if math.Abs(pdfPos-emission.AreaPDF) > 1e-12 || math.Abs(pdfDir-emission.DirectionPDF) > 1e-9 {
+				t.Fatalf("float32=%v: PDF_Le (%f, %f) doesn't match sampled PDFs (%f, %f)", compact, pdfPos, pdfDir, emission.AreaPDF, emission.DirectionPDF)
+			}
+		}
+	}
+}
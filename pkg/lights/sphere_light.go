@@ -144,8 +144,7 @@ func (sl *SphereLight) sampleVisible(point core.Vec3, sample core.Vec2) LightSam
 func (sl *SphereLight) PDF(point, normal, direction core.Vec3) float64 {
 	// Check if ray from point in direction hits the sphere
 	ray := core.NewRay(point, direction)
-	_, hit := sl.Sphere.Hit(ray, 0.001, math.Inf(1))
-	if !hit {
+	if !sl.Sphere.HitAny(ray, 0.001, math.Inf(1)) {
 		return 0.0
 	}
 
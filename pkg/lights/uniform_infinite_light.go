@@ -62,6 +62,11 @@ func (uil *UniformInfiniteLight) GetMaterial() material.Material {
 	return uil.material
 }
 
+// Emission returns the radiance arriving from every direction
+func (uil *UniformInfiniteLight) Emission() core.Vec3 {
+	return uil.emission
+}
+
 // Sample implements the Light interface - samples the infinite light for direct lighting
 func (uil *UniformInfiniteLight) Sample(point core.Vec3, normal core.Vec3, sample core.Vec2) LightSample {
 	// For infinite lights, sample the visible hemisphere using cosine-weighted sampling
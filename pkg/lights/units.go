@@ -0,0 +1,122 @@
+package lights
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Emission is photometric: its luminance (see core.Vec3.Luminance) is in nits (cd/m²) for the
+// radiance of area and infinite lights, candela for the intensity of point lights and lux for
+// the irradiance of directional lights, with scene lengths in meters. A light's power is then
+// in lumens.
+
+// LuminousEfficacy is the luminous efficacy of 555 nm light, where the eye is most sensitive, in
+// lumens per watt. It converts watts to lumens for lights given by an RGB color.
+const LuminousEfficacy = 683.0
+
+// powerEstimateSamples is the number of emission samples EmissionForPower measures a light with
+const powerEstimateSamples = 16384
+
+// stefanBoltzmann is the Stefan-Boltzmann constant, in W/(m²·K⁴)
+const stefanBoltzmann = 5.670374419e-8
+
+// BlackbodyColor returns the linear sRGB color of a blackbody radiator at a temperature in
+// kelvin, with luminance 1: about 1900 K for candlelight, 2700 K for an incandescent bulb and
+// 6500 K for daylight. Colors outside the sRGB gamut, such as below 1000 K, are clipped.
+func BlackbodyColor(kelvin float64) core.Vec3 {
+	X, Y, Z := blackbodyXYZ(kelvin)
+	if Y <= 0 {
+		return core.Vec3{}
+	}
+	X, Z = X/Y, Z/Y
+	color := core.NewVec3(
+		math.Max(0, 3.2406*X-1.5372-0.4986*Z),
+		math.Max(0, -0.9689*X+1.8758+0.0415*Z),
+		math.Max(0, 0.0557*X-0.2040+1.0570*Z),
+	)
+	return color.Multiply(1 / color.Luminance())
+}
+
+// BlackbodyEfficacy returns the luminous efficacy of a blackbody's radiation, in lumens per watt:
+// the share of its power the eye sees. It peaks near 95 lm/W around 6600 K.
+func BlackbodyEfficacy(kelvin float64) float64 {
+	if kelvin <= 0 {
+		return 0
+	}
+	_, Y, _ := blackbodyXYZ(kelvin)
+	total := stefanBoltzmann * math.Pow(kelvin, 4) / math.Pi // Radiance over all wavelengths
+	return LuminousEfficacy * Y / total
+}
+
+// BlackbodyLuminance returns the luminance of a blackbody radiator at a temperature in kelvin, in
+// nits. It rises steeply with the temperature: about 2e9 at the sun's 5800 K, but 3 at 1000 K.
+func BlackbodyLuminance(kelvin float64) float64 {
+	_, Y, _ := blackbodyXYZ(kelvin)
+	return LuminousEfficacy * Y
+}
+
+// WattsToLumens converts a light's radiant power to luminous power: the power of a blackbody at
+// kelvin, or of 555 nm light when kelvin is 0
+func WattsToLumens(watts, kelvin float64) float64 {
+	if kelvin <= 0 {
+		return watts * LuminousEfficacy
+	}
+	return watts * BlackbodyEfficacy(kelvin)
+}
+
+// blackbodyXYZ integrates Planck's law against the CIE 1931 color matching functions, giving
+// the CIE XYZ radiance of a blackbody with Y unweighted by the 683 lm/W efficacy
+func blackbodyXYZ(kelvin float64) (X, Y, Z float64) {
+	if kelvin <= 0 {
+		return 0, 0, 0
+	}
+	const (
+		h = 6.62607015e-34 // Planck constant, J·s
+		c = 299792458.0    // Speed of light, m/s
+		k = 1.380649e-23   // Boltzmann constant, J/K
+	)
+	for nm := 360.0; nm <= 830; nm++ {
+		lambda := nm * 1e-9
+		radiance := 2 * h * c * c / math.Pow(lambda, 5) / math.Expm1(h*c/(lambda*k*kelvin)) * 1e-9 // Per nm step
+		x, y, z := cieColorMatching(nm)
+		X += x * radiance
+		Y += y * radiance
+		Z += z * radiance
+	}
+	return X, Y, Z
+}
+
+// cieColorMatching returns the CIE 1931 2° color matching functions at a wavelength in nm, from
+// the multi-lobe Gaussian fit of Wyman, Sloan and Shirley, "Simple Analytic Approximations to
+// the CIE XYZ Color Matching Functions" (2013)
+func cieColorMatching(nm float64) (x, y, z float64) {
+	lobe := func(mu, sigmaBelow, sigmaAbove float64) float64 {
+		sigma := sigmaAbove
+		if nm < mu {
+			sigma = sigmaBelow
+		}
+		t := (nm - mu) / sigma
+		return math.Exp(-0.5 * t * t)
+	}
+	x = 1.056*lobe(599.8, 37.9, 31.0) + 0.362*lobe(442.0, 16.0, 26.7) - 0.065*lobe(501.1, 20.4, 26.2)
+	y = 0.821*lobe(568.8, 46.9, 40.5) + 0.286*lobe(530.9, 16.3, 31.1)
+	z = 1.217*lobe(437.0, 11.8, 36.0) + 0.681*lobe(459.0, 26.0, 13.8)
+	return x, y, z
+}
+
+// EmissionForPower returns the emission of the given color that makes the light newLight creates
+// emit lumens of luminous power. The light is built with the color and measured (see
+// EstimatePower), so any finite light shape, cone or profile is accounted for. Infinite lights
+// have no finite power and keep the color.
+func EmissionForPower(color core.Vec3, lumens float64, newLight func(emission core.Vec3) Light) core.Vec3 {
+	light := newLight(color)
+	if light.Type() == LightTypeInfinite {
+		return color
+	}
+	power := EstimatePower(light, powerEstimateSamples).Luminance()
+	if power <= 0 {
+		return core.Vec3{}
+	}
+	return color.Multiply(lumens / power)
+}
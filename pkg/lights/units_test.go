@@ -0,0 +1,94 @@
+package lights
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestBlackbodyColor(t *testing.T) {
+	for _, kelvin := range []float64{1900, 2700, 4000, 6500, 10000} {
+		if luminance := BlackbodyColor(kelvin).Luminance(); math.Abs(luminance-1) > 1e-9 {
+			t.Errorf("BlackbodyColor(%v) luminance = %f, want 1", kelvin, luminance)
+		}
+	}
+
+	// Incandescent light is orange, daylight near white and hotter stars blue
+	if warm := BlackbodyColor(2700); warm.X < 1.5*warm.Z+0.5 || warm.X < warm.Y || warm.Y < warm.Z {
+		t.Errorf("Expected 2700 K to be orange, got %v", warm)
+	}
+	if daylight := BlackbodyColor(6500); math.Abs(daylight.X-daylight.Z) > 0.1 || math.Abs(daylight.X-1) > 0.1 {
+		t.Errorf("Expected 6500 K to be close to white, got %v", daylight)
+	}
+	if cool := BlackbodyColor(10000); cool.Z < 1.3*cool.X {
+		t.Errorf("Expected 10000 K to be blue, got %v", cool)
+	}
+	if black := BlackbodyColor(0); !black.IsZero() {
+		t.Errorf("Expected no color at 0 K, got %v", black)
+	}
+}
+
+func TestBlackbodyEfficacy(t *testing.T) {
+	// A blackbody's efficacy peaks at about 95 lm/W near 6600 K; incandescent filaments manage
+	// about 12-15 lm/W of their radiation
+	if peak := BlackbodyEfficacy(6600); math.Abs(peak-95) > 2 {
+		t.Errorf("BlackbodyEfficacy(6600) = %f, want about 95", peak)
+	}
+	if filament := BlackbodyEfficacy(2700); filament < 10 || filament > 16 {
+		t.Errorf("BlackbodyEfficacy(2700) = %f, want 10-16", filament)
+	}
+	if BlackbodyEfficacy(4000) >= BlackbodyEfficacy(6600) || BlackbodyEfficacy(12000) >= BlackbodyEfficacy(6600) {
+		t.Error("Expected the efficacy to peak between 4000 K and 12000 K")
+	}
+
+	if lumens := WattsToLumens(2, 0); lumens != 2*LuminousEfficacy {
+		t.Errorf("WattsToLumens(2, 0) = %f, want %f", lumens, 2*LuminousEfficacy)
+	}
+	if lumens := WattsToLumens(2, 6600); math.Abs(lumens-2*BlackbodyEfficacy(6600)) > 1e-9 {
+		t.Errorf("WattsToLumens(2, 6600) = %f, want %f", lumens, 2*BlackbodyEfficacy(6600))
+	}
+}
+
+func TestBlackbodyLuminance(t *testing.T) {
+	// The sun's surface, near 5800 K, is about 2e9 nits; luminance grows faster than T⁴ below it
+	if sun := BlackbodyLuminance(5800); sun < 1.5e9 || sun > 2.5e9 {
+		t.Errorf("BlackbodyLuminance(5800) = %g, want about 2e9", sun)
+	}
+	if ratio := BlackbodyLuminance(2000) / BlackbodyLuminance(1000); ratio < 16*1000 {
+		t.Errorf("Expected doubling the temperature from 1000 K to brighten far more than 16 times, got %g", ratio)
+	}
+	if BlackbodyLuminance(0) != 0 {
+		t.Error("Expected no luminance at 0 K")
+	}
+}
+
+func TestEmissionForPower(t *testing.T) {
+	color := BlackbodyColor(3000)
+
+	// A one-sided quad emits π·A·L
+	corner, u, v := core.Vec3{}, core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 0.5)
+	quadEmission := EmissionForPower(color, 800, func(emission core.Vec3) Light {
+		return NewQuadLight(corner, u, v, material.NewEmissive(emission))
+	})
+	if want := 800 / math.Pi; math.Abs(quadEmission.Luminance()-want) > 1e-6*want {
+		t.Errorf("Quad radiance luminance = %f, want %f", quadEmission.Luminance(), want)
+	}
+	if ratio := quadEmission.Multiply(1 / quadEmission.Luminance()); ratio.Subtract(color).Length() > 1e-9 {
+		t.Errorf("Expected the color %v to be kept, got %v", color, ratio)
+	}
+
+	// An unshaded point light emits 4π·I
+	pointEmission := EmissionForPower(color, 800, func(emission core.Vec3) Light {
+		return NewPointSpotLight(core.Vec3{}, core.NewVec3(0, -1, 0), emission, 180, 0)
+	})
+	if want := 800 / (4 * math.Pi); math.Abs(pointEmission.Luminance()-want) > 0.01*want {
+		t.Errorf("Point intensity luminance = %f, want %f", pointEmission.Luminance(), want)
+	}
+
+	// Infinite lights keep their color
+	if sky := EmissionForPower(color, 800, func(emission core.Vec3) Light { return NewUniformInfiniteLight(emission) }); sky != color {
+		t.Errorf("Expected an infinite light's emission unchanged, got %v", sky)
+	}
+}
@@ -127,6 +127,14 @@ func (fls *WeightedLightSampler) GetLightProbability(lightIndex int, point core.
 	return fls.weights[lightIndex]
 }
 
+// GetLightEmissionProbability returns the fixed probability for the light at the given index
+func (fls *WeightedLightSampler) GetLightEmissionProbability(lightIndex int) float64 {
+	if lightIndex < 0 || lightIndex >= len(fls.weights) {
+		return 0.0
+	}
+	return fls.weights[lightIndex]
+}
+
 // GetLightCount returns the number of lights in this sampler
 func (fls *WeightedLightSampler) GetLightCount() int {
 	return len(fls.lights)
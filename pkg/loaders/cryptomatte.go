@@ -0,0 +1,193 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// cryptomatteRanks is how many of each pixel's IDs a cryptomatte layer keeps, two per RGBA channel
+// set, the spec's usual depth
+const cryptomatteRanks = 6
+
+// IDCoverage is how much of a pixel one named object or material covers
+type IDCoverage struct {
+	Name     string
+	Coverage float64 // Share of the pixel's filter weight, 0 to 1
+}
+
+// CryptomatteLayer is one kind of ID of a cryptomatte image, such as objects or materials: each
+// pixel's IDs ranked by coverage, from which compositors pull a matte for any of them
+type CryptomatteLayer struct {
+	Name   string         // e.g. "CryptoObject", which names its channels
+	Pixels [][]IDCoverage // Row by row, the most coverage first
+}
+
+// Matte returns the coverage of the pixels by a name, row by row
+func (l *CryptomatteLayer) Matte(name string) []float64 {
+	matte := make([]float64, len(l.Pixels))
+	for i, ids := range l.Pixels {
+		for _, id := range ids {
+			if id.Name == name {
+				matte[i] += id.Coverage
+			}
+		}
+	}
+	return matte
+}
+
+// Cryptomatte holds ID mattes in the layout of the Cryptomatte spec
+type Cryptomatte struct {
+	Width  int
+	Height int
+	Layers []CryptomatteLayer
+}
+
+// WriteCryptomatteEXR writes ID mattes as an uncompressed OpenEXR scanline image in the Cryptomatte
+// layout: per layer, channels <layer>00 to <layer>02 hold the pixel's six most covering IDs as
+// RGBA pairs of hashed ID and coverage, and the header's metadata holds the manifest from names to
+// hashes, so compositing tools pick mattes by name
+func WriteCryptomatteEXR(w io.Writer, matte *Cryptomatte) error {
+	var channels []cryptomatteChannel
+	for i := range matte.Layers {
+		for rank := 0; rank < cryptomatteRanks; rank++ {
+			name := fmt.Sprintf("%s%02d.", matte.Layers[i].Name, rank/2)
+			components := "RG"
+			if rank%2 == 1 {
+				components = "BA"
+			}
+			channels = append(channels,
+				cryptomatteChannel{name: name + components[:1], layer: &matte.Layers[i], rank: rank},
+				cryptomatteChannel{name: name + components[1:], layer: &matte.Layers[i], rank: rank, coverage: true})
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].name < channels[j].name })
+	channelNames := make([]string, len(channels))
+	for i, channel := range channels {
+		channelNames[i] = channel.name
+	}
+
+	var header bytes.Buffer
+	le := binary.LittleEndian
+	header.Write(le.AppendUint32(nil, exrMagic))
+	header.Write(le.AppendUint32(nil, exrVersion))
+	writeEXRImageAttributes(&header, channelNames, matte.Width, matte.Height)
+	for _, layer := range matte.Layers {
+		manifest := map[string]string{}
+		for _, ids := range layer.Pixels {
+			for _, id := range ids {
+				manifest[id.Name] = fmt.Sprintf("%08x", math.Float32bits(cryptomatteID(id.Name)))
+			}
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		// Layers are keyed by the first 7 hex digits of their name's hash
+		prefix := "cryptomatte/" + fmt.Sprintf("%08x", murmur3([]byte(layer.Name)))[:7] + "/"
+		writeEXRAttribute(&header, prefix+"conversion", "string", []byte("uint32_to_float32"))
+		writeEXRAttribute(&header, prefix+"hash", "string", []byte("MurmurHash3_32"))
+		writeEXRAttribute(&header, prefix+"manifest", "string", manifestJSON)
+		writeEXRAttribute(&header, prefix+"name", "string", []byte(layer.Name))
+	}
+	header.WriteByte(0)
+
+	// One scanline per chunk, each channel's row of values in turn
+	chunks := make([][]byte, matte.Height)
+	offset := uint64(header.Len() + 8*matte.Height)
+	for y := range chunks {
+		var data []byte
+		for _, channel := range channels {
+			for x := 0; x < matte.Width; x++ {
+				data = le.AppendUint32(data, math.Float32bits(channel.value(y*matte.Width+x)))
+			}
+		}
+		chunk := le.AppendUint32(nil, uint32(y))
+		chunk = le.AppendUint32(chunk, uint32(len(data)))
+		chunks[y] = append(chunk, data...)
+
+		header.Write(le.AppendUint64(nil, offset))
+		offset += uint64(len(chunks[y]))
+	}
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cryptomatteChannel is one channel of a cryptomatte layer: the hashed IDs or coverages of a rank
+type cryptomatteChannel struct {
+	name     string
+	layer    *CryptomatteLayer
+	rank     int
+	coverage bool
+}
+
+// value returns the channel's value for a pixel, zero where it has fewer IDs than the rank
+func (c cryptomatteChannel) value(pixel int) float32 {
+	ids := c.layer.Pixels[pixel]
+	switch {
+	case c.rank >= len(ids):
+		return 0
+	case c.coverage:
+		return float32(ids[c.rank].Coverage)
+	default:
+		return cryptomatteID(ids[c.rank].Name)
+	}
+}
+
+// cryptomatteID hashes a name to the float a cryptomatte stores for it: the bits of its 32-bit
+// MurmurHash3, with the exponent nudged off the values that aren't finite normal floats
+func cryptomatteID(name string) float32 {
+	hash := murmur3([]byte(name))
+	if exponent := hash >> 23 & 0xff; exponent == 0 || exponent == 0xff {
+		hash ^= 1 << 23
+	}
+	return math.Float32frombits(hash)
+}
+
+// murmur3 returns the 32-bit MurmurHash3 of data, with seed 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	mix := func(k uint32) uint32 {
+		return bits.RotateLeft32(k*c1, 15) * c2
+	}
+
+	var hash uint32
+	blocks := len(data) / 4 * 4
+	for i := 0; i < blocks; i += 4 {
+		hash ^= mix(binary.LittleEndian.Uint32(data[i:]))
+		hash = bits.RotateLeft32(hash, 13)*5 + 0xe6546b64
+	}
+	var tail uint32
+	switch len(data) - blocks {
+	case 3:
+		tail |= uint32(data[blocks+2]) << 16
+		fallthrough
+	case 2:
+		tail |= uint32(data[blocks+1]) << 8
+		fallthrough
+	case 1:
+		tail |= uint32(data[blocks])
+		hash ^= mix(tail)
+	}
+
+	hash ^= uint32(len(data))
+	hash ^= hash >> 16
+	hash *= 0x85ebca6b
+	hash ^= hash >> 13
+	hash *= 0xc2b2ae35
+	hash ^= hash >> 16
+	return hash
+}
@@ -0,0 +1,142 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMurmur3(t *testing.T) {
+	tests := []struct {
+		data string
+		hash uint32
+	}{
+		{"", 0},
+		{"hello", 0x248bfa47},
+		{"The quick brown fox jumps over the lazy dog", 0x2e4ff723},
+	}
+	for _, tt := range tests {
+		if hash := murmur3([]byte(tt.data)); hash != tt.hash {
+			t.Errorf("murmur3(%q): expected %08x, got %08x", tt.data, tt.hash, hash)
+		}
+	}
+
+	// Stored IDs must be finite normal floats, whatever the hash
+	for _, name := range []string{"", "Sphere.1", "Lambertian.1", "hello"} {
+		id := float64(cryptomatteID(name))
+		if math.IsInf(id, 0) || math.IsNaN(id) || (id != 0 && math.Abs(id) < math.SmallestNonzeroFloat32*(1<<23)) {
+			t.Errorf("cryptomatteID(%q) = %g, expected a finite normal float", name, id)
+		}
+	}
+}
+
+// testCryptomatte is a 2x1 image: one pixel shared by two objects, one all of a third
+func testCryptomatte() *Cryptomatte {
+	return &Cryptomatte{Width: 2, Height: 1, Layers: []CryptomatteLayer{{
+		Name: "CryptoObject",
+		Pixels: [][]IDCoverage{
+			{{Name: "Sphere.1", Coverage: 0.75}, {Name: "Quad.2", Coverage: 0.25}},
+			{{Name: "Quad.3", Coverage: 1}},
+		},
+	}}}
+}
+
+func TestWriteCryptomatteEXR(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCryptomatteEXR(&buf, testCryptomatte()); err != nil {
+		t.Fatalf("WriteCryptomatteEXR failed: %v", err)
+	}
+	data := buf.Bytes()
+	le := binary.LittleEndian
+	if le.Uint32(data) != 20000630 || le.Uint32(data[4:]) != 2 {
+		t.Fatalf("Expected the OpenEXR magic number and a plain version 2, got %x %x", le.Uint32(data), le.Uint32(data[4:]))
+	}
+
+	attributes := map[string][]byte{}
+	pos := 8
+	for data[pos] != 0 {
+		name := string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
+		pos += len(name) + 1
+		typeName := string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
+		pos += len(typeName) + 1
+		size := int(le.Uint32(data[pos:]))
+		attributes[name] = data[pos+4 : pos+4+size]
+		pos += 4 + size
+	}
+	pos++
+
+	// The layer's metadata is keyed by its name's hash, and its manifest names each ID's hash
+	prefix := "cryptomatte/3ae39a5/"
+	if string(attributes[prefix+"name"]) != "CryptoObject" || string(attributes[prefix+"hash"]) != "MurmurHash3_32" ||
+		string(attributes[prefix+"conversion"]) != "uint32_to_float32" {
+		t.Fatalf("Expected the layer's metadata under %s, got attributes %v", prefix, attributes)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(attributes[prefix+"manifest"], &manifest); err != nil {
+		t.Fatalf("Manifest isn't JSON: %v", err)
+	}
+	if len(manifest) != 3 || manifest["Sphere.1"] == "" || manifest["Quad.3"] == "" {
+		t.Errorf("Expected a manifest of the 3 objects, got %v", manifest)
+	}
+
+	var channels []string
+	for list := attributes["channels"]; list[0] != 0; {
+		end := bytes.IndexByte(list, 0)
+		channels = append(channels, string(list[:end]))
+		list = list[end+1+16:]
+	}
+	want := "CryptoObject00.A,CryptoObject00.B,CryptoObject00.G,CryptoObject00.R,CryptoObject01.A,CryptoObject01.B," +
+		"CryptoObject01.G,CryptoObject01.R,CryptoObject02.A,CryptoObject02.B,CryptoObject02.G,CryptoObject02.R"
+	if strings.Join(channels, ",") != want {
+		t.Errorf("Expected channels %s, got %v", want, channels)
+	}
+
+	// The single scanline: each channel's two pixels in turn
+	chunk := data[le.Uint64(data[pos:]):]
+	if le.Uint32(chunk) != 0 || le.Uint32(chunk[4:]) != uint32(4*2*len(channels)) {
+		t.Fatalf("Expected row 0 with %d bytes, got row %d with %d", 4*2*len(channels), le.Uint32(chunk), le.Uint32(chunk[4:]))
+	}
+	value := func(channel string, x int) float32 {
+		for i, name := range channels {
+			if name == channel {
+				return math.Float32frombits(le.Uint32(chunk[8+4*(2*i+x):]))
+			}
+		}
+		t.Fatalf("No channel %s", channel)
+		return 0
+	}
+	checks := []struct {
+		channel string
+		x       int
+		value   float32
+	}{
+		{"CryptoObject00.R", 0, cryptomatteID("Sphere.1")},
+		{"CryptoObject00.G", 0, 0.75},
+		{"CryptoObject00.B", 0, cryptomatteID("Quad.2")},
+		{"CryptoObject00.A", 0, 0.25},
+		{"CryptoObject00.R", 1, cryptomatteID("Quad.3")},
+		{"CryptoObject00.G", 1, 1},
+		{"CryptoObject00.B", 1, 0},
+		{"CryptoObject01.R", 0, 0},
+	}
+	for _, c := range checks {
+		if got := value(c.channel, c.x); got != c.value {
+			t.Errorf("%s of pixel %d: expected %g, got %g", c.channel, c.x, c.value, got)
+		}
+	}
+	if want := fmt.Sprintf("%08x", math.Float32bits(cryptomatteID("Sphere.1"))); manifest["Sphere.1"] != want {
+		t.Errorf("Expected the manifest to give Sphere.1's stored bits %s, got %s", want, manifest["Sphere.1"])
+	}
+}
+
+func TestCryptomatteLayerMatte(t *testing.T) {
+	layer := testCryptomatte().Layers[0]
+	matte := layer.Matte("Sphere.1")
+	if matte[0] != 0.75 || matte[1] != 0 {
+		t.Errorf("Expected Sphere.1's matte to be [0.75 0], got %v", matte)
+	}
+}
@@ -0,0 +1,161 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// exrMagic starts every OpenEXR file; the version field after it is 2, flagged for deep data
+const (
+	exrMagic       = 20000630
+	exrVersion     = 2
+	exrVersionDeep = exrVersion | 0x800
+)
+
+// DeepSample is one depth of a deep pixel: what lies there, premultiplied by how much of what's
+// behind it it hides (Alpha)
+type DeepSample struct {
+	Depth float64   // Distance from the camera
+	Color core.Vec3 // Premultiplied by Alpha
+	Alpha float64
+}
+
+// DeepImage holds a list of samples per pixel, sorted front to back, so images can be merged by
+// depth and composited with correct occlusion
+type DeepImage struct {
+	Width  int
+	Height int
+	Pixels [][]DeepSample // Row by row
+}
+
+// Flatten composites each pixel's samples front to back into a flat image, returning its colors
+// and alphas row by row
+func (img *DeepImage) Flatten() ([]core.Vec3, []float64) {
+	colors := make([]core.Vec3, len(img.Pixels))
+	alphas := make([]float64, len(img.Pixels))
+	for i, samples := range img.Pixels {
+		for _, sample := range samples {
+			colors[i] = colors[i].Add(sample.Color.Multiply(1 - alphas[i]))
+			alphas[i] += sample.Alpha * (1 - alphas[i])
+		}
+	}
+	return colors, alphas
+}
+
+// exrDeepChannels are the channels of a deep image, in the alphabetical order OpenEXR stores them.
+// Samples are points, so ZBack is Z.
+var exrDeepChannels = []string{"A", "B", "G", "R", "Z", "ZBack"}
+
+// WriteDeepEXR writes a deep image as a single part OpenEXR deep scanline file, uncompressed, with
+// 32-bit float R, G, B, A, Z and ZBack channels, as compositors read for deep merging
+func WriteDeepEXR(w io.Writer, img *DeepImage) error {
+	maxSamples := 0
+	for _, samples := range img.Pixels {
+		maxSamples = max(maxSamples, len(samples))
+	}
+
+	var header bytes.Buffer
+	le := binary.LittleEndian
+	header.Write(le.AppendUint32(nil, exrMagic))
+	header.Write(le.AppendUint32(nil, exrVersionDeep))
+
+	writeEXRImageAttributes(&header, exrDeepChannels, img.Width, img.Height)
+	writeEXRAttribute(&header, "chunkCount", "int", le.AppendUint32(nil, uint32(img.Height)))
+	writeEXRAttribute(&header, "maxSamplesPerPixel", "int", le.AppendUint32(nil, uint32(maxSamples)))
+	writeEXRAttribute(&header, "type", "string", []byte("deepscanline"))
+	writeEXRAttribute(&header, "version", "int", le.AppendUint32(nil, 1))
+	header.WriteByte(0)
+
+	// One scanline per chunk: its pixels' running sample counts, then each channel's samples
+	chunks := make([][]byte, img.Height)
+	offset := uint64(header.Len() + 8*img.Height)
+	for y := range chunks {
+		row := img.Pixels[y*img.Width : (y+1)*img.Width]
+		var counts, data []byte
+		total := 0
+		for _, samples := range row {
+			total += len(samples)
+			counts = le.AppendUint32(counts, uint32(total))
+		}
+		for _, channel := range exrDeepChannels {
+			for _, samples := range row {
+				for _, sample := range samples {
+					data = le.AppendUint32(data, math.Float32bits(exrChannelValue(sample, channel)))
+				}
+			}
+		}
+		chunk := le.AppendUint32(nil, uint32(y))
+		chunk = le.AppendUint64(chunk, uint64(len(counts)))
+		chunk = le.AppendUint64(chunk, uint64(len(data)))
+		chunk = le.AppendUint64(chunk, uint64(len(data)))
+		chunks[y] = append(append(chunk, counts...), data...)
+
+		header.Write(le.AppendUint64(nil, offset))
+		offset += uint64(len(chunks[y]))
+	}
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEXRImageAttributes writes the header attributes every OpenEXR image needs: its channels,
+// all 32-bit float and in alphabetical order, uncompressed, and its size
+func writeEXRImageAttributes(header *bytes.Buffer, channelNames []string, width, height int) {
+	le := binary.LittleEndian
+	var channels []byte
+	for _, name := range channelNames {
+		channels = append(channels, name...)
+		channels = append(channels, 0)
+		channels = le.AppendUint32(channels, 2) // FLOAT
+		channels = append(channels, 0, 0, 0, 0) // pLinear and reserved
+		channels = le.AppendUint32(channels, 1) // x and y sampling
+		channels = le.AppendUint32(channels, 1)
+	}
+	window := le.AppendUint32(le.AppendUint32(make([]byte, 8), uint32(width-1)), uint32(height-1))
+	writeEXRAttribute(header, "channels", "chlist", append(channels, 0))
+	writeEXRAttribute(header, "compression", "compression", []byte{0})
+	writeEXRAttribute(header, "dataWindow", "box2i", window)
+	writeEXRAttribute(header, "displayWindow", "box2i", window)
+	writeEXRAttribute(header, "lineOrder", "lineOrder", []byte{0})
+	writeEXRAttribute(header, "pixelAspectRatio", "float", le.AppendUint32(nil, math.Float32bits(1)))
+	writeEXRAttribute(header, "screenWindowCenter", "v2f", make([]byte, 8))
+	writeEXRAttribute(header, "screenWindowWidth", "float", le.AppendUint32(nil, math.Float32bits(1)))
+}
+
+// writeEXRAttribute writes a header attribute: its name, type, size and value
+func writeEXRAttribute(header *bytes.Buffer, name, typeName string, value []byte) {
+	header.WriteString(name)
+	header.WriteByte(0)
+	header.WriteString(typeName)
+	header.WriteByte(0)
+	header.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(value))))
+	header.Write(value)
+}
+
+// exrChannelValue returns a deep sample's value in a channel. Depths beyond what a float holds,
+// such as the sky's, are the largest it does.
+func exrChannelValue(sample DeepSample, channel string) float32 {
+	switch channel {
+	case "A":
+		return float32(sample.Alpha)
+	case "B":
+		return float32(sample.Color.Z)
+	case "G":
+		return float32(sample.Color.Y)
+	case "R":
+		return float32(sample.Color.X)
+	default:
+		return float32(math.Min(sample.Depth, math.MaxFloat32))
+	}
+}
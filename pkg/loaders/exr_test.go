@@ -0,0 +1,137 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// testDeepImage is a 3x2 image: empty pixels, a single opaque sample, a translucent one in front
+// of the sky, and a pixel half covered by a near surface
+func testDeepImage() *DeepImage {
+	return &DeepImage{Width: 3, Height: 2, Pixels: [][]DeepSample{
+		nil,
+		{{Depth: 2, Color: core.NewVec3(0.5, 0.25, 0.125), Alpha: 1}},
+		nil,
+		{{Depth: 1, Color: core.NewVec3(0.1, 0.2, 0.3), Alpha: 0.5}, {Depth: math.Inf(1), Color: core.NewVec3(1, 1, 1), Alpha: 1}},
+		nil,
+		{{Depth: 0.5, Color: core.NewVec3(0.2, 0.2, 0.2), Alpha: 0.5}, {Depth: 3, Color: core.NewVec3(0.4, 0, 0), Alpha: 0.5}},
+	}}
+}
+
+func TestWriteDeepEXR(t *testing.T) {
+	img := testDeepImage()
+	var buf bytes.Buffer
+	if err := WriteDeepEXR(&buf, img); err != nil {
+		t.Fatalf("WriteDeepEXR failed: %v", err)
+	}
+	data := buf.Bytes()
+	le := binary.LittleEndian
+	if le.Uint32(data) != 20000630 || le.Uint32(data[4:]) != 0x802 {
+		t.Fatalf("Expected the OpenEXR magic number and a deep version 2 flag, got %x %x", le.Uint32(data), le.Uint32(data[4:]))
+	}
+
+	// The header's attributes, up to the empty name that ends it
+	attributes := map[string][]byte{}
+	pos := 8
+	for data[pos] != 0 {
+		name := string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
+		pos += len(name) + 1
+		typeName := string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
+		pos += len(typeName) + 1
+		size := int(le.Uint32(data[pos:]))
+		attributes[name] = data[pos+4 : pos+4+size]
+		pos += 4 + size
+	}
+	pos++
+	if string(attributes["type"]) != "deepscanline" || le.Uint32(attributes["chunkCount"]) != 2 || attributes["compression"][0] != 0 {
+		t.Errorf("Expected an uncompressed deep scanline image of 2 chunks, got type %q, %d chunks, compression %d",
+			attributes["type"], le.Uint32(attributes["chunkCount"]), attributes["compression"][0])
+	}
+	if le.Uint32(attributes["maxSamplesPerPixel"]) != 2 {
+		t.Errorf("Expected at most 2 samples per pixel, got %d", le.Uint32(attributes["maxSamplesPerPixel"]))
+	}
+	var channels []string
+	for list := attributes["channels"]; list[0] != 0; {
+		end := bytes.IndexByte(list, 0)
+		channels = append(channels, string(list[:end]))
+		if pixelType := le.Uint32(list[end+1:]); pixelType != 2 {
+			t.Errorf("Expected channel %s to be FLOAT, got type %d", list[:end], pixelType)
+		}
+		list = list[end+1+16:]
+	}
+	if strings.Join(channels, ",") != "A,B,G,R,Z,ZBack" {
+		t.Errorf("Expected channels A,B,G,R,Z,ZBack, got %v", channels)
+	}
+
+	// Read the samples back through the offset table
+	read := make([][]DeepSample, img.Width*img.Height)
+	for y := 0; y < img.Height; y++ {
+		chunk := data[le.Uint64(data[pos+8*y:]):]
+		if int(le.Uint32(chunk)) != y {
+			t.Fatalf("Expected chunk %d to hold row %d, got %d", y, y, le.Uint32(chunk))
+		}
+		countsSize, dataSize := le.Uint64(chunk[4:]), le.Uint64(chunk[12:])
+		if countsSize != uint64(4*img.Width) || le.Uint64(chunk[20:]) != dataSize {
+			t.Fatalf("Row %d: expected %d bytes of counts and equal packed and unpacked sizes", y, 4*img.Width)
+		}
+		counts := chunk[28:]
+		samples := chunk[28+countsSize:]
+		total := int(le.Uint32(counts[4*(img.Width-1):]))
+		if dataSize != uint64(4*len(channels)*total) {
+			t.Fatalf("Row %d: expected %d bytes of samples, got %d", y, 4*len(channels)*total, dataSize)
+		}
+		value := func(channel, sample int) float64 {
+			return float64(math.Float32frombits(le.Uint32(samples[4*(channel*total+sample):])))
+		}
+		start := 0
+		for x := 0; x < img.Width; x++ {
+			end := int(le.Uint32(counts[4*x:]))
+			for s := start; s < end; s++ {
+				if value(4, s) != value(5, s) {
+					t.Errorf("Expected point samples, got Z %g and ZBack %g", value(4, s), value(5, s))
+				}
+				read[y*img.Width+x] = append(read[y*img.Width+x], DeepSample{
+					Depth: value(4, s), Color: core.NewVec3(value(3, s), value(2, s), value(1, s)), Alpha: value(0, s),
+				})
+			}
+			start = end
+		}
+	}
+
+	for i, samples := range img.Pixels {
+		if len(read[i]) != len(samples) {
+			t.Fatalf("Pixel %d: expected %d samples, read %d", i, len(samples), len(read[i]))
+		}
+		for s, want := range samples {
+			got := read[i][s]
+			depth := math.Min(want.Depth, math.MaxFloat32)
+			if math.Abs(got.Depth-depth) > 1e-6*depth || got.Color.Subtract(want.Color).Length() > 1e-6 || math.Abs(got.Alpha-want.Alpha) > 1e-6 {
+				t.Errorf("Pixel %d sample %d: expected %+v, read %+v", i, s, want, got)
+			}
+		}
+	}
+}
+
+func TestDeepImageFlatten(t *testing.T) {
+	colors, alphas := testDeepImage().Flatten()
+	tests := []struct {
+		pixel int
+		color core.Vec3
+		alpha float64
+	}{
+		{0, core.Vec3{}, 0},
+		{1, core.NewVec3(0.5, 0.25, 0.125), 1},
+		{3, core.NewVec3(0.6, 0.7, 0.8), 1},
+		{5, core.NewVec3(0.4, 0.2, 0.2), 0.75},
+	}
+	for _, tt := range tests {
+		if colors[tt.pixel].Subtract(tt.color).Length() > 1e-12 || math.Abs(alphas[tt.pixel]-tt.alpha) > 1e-12 {
+			t.Errorf("Pixel %d: expected %v with alpha %g, got %v with alpha %g", tt.pixel, tt.color, tt.alpha, colors[tt.pixel], alphas[tt.pixel])
+		}
+	}
+}
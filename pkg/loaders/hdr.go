@@ -0,0 +1,181 @@
+package loaders
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// hdrSignature starts every Radiance HDR file
+const hdrSignature = "#?"
+
+// LoadHDR loads a Radiance HDR (.hdr) image, keeping its full range
+func LoadHDR(filename string) (*ImageData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer file.Close()
+	return ReadHDR(file)
+}
+
+// ReadHDR reads a Radiance HDR image of RGBE pixels, stored top row first, flat or run length
+// encoded by channel (the encoding current tools write)
+func ReadHDR(r io.Reader) (*ImageData, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, hdrSignature) {
+		return nil, fmt.Errorf("not a Radiance HDR image")
+	}
+	for {
+		line, err = br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HDR header: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if format, ok := strings.CutPrefix(line, "FORMAT="); ok && format != "32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported HDR format %q (only 32-bit_rle_rgbe)", format)
+		}
+	}
+
+	var width, height int
+	line, err = br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HDR resolution: %w", err)
+	}
+	if _, err := fmt.Sscanf(line, "-Y %d +X %d", &height, &width); err != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("unsupported HDR resolution %q (only -Y height +X width)", strings.TrimSpace(line))
+	}
+
+	pixels := make([]core.Vec3, width*height)
+	scanline := make([][4]byte, width)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(br, scanline); err != nil {
+			return nil, fmt.Errorf("failed to read HDR row %d: %w", y, err)
+		}
+		for x, p := range scanline {
+			pixels[y*width+x] = fromRGBE(p)
+		}
+	}
+	return &ImageData{Width: width, Height: height, Pixels: pixels}, nil
+}
+
+// readHDRScanline reads a row of RGBE pixels, either flat or run length encoded by channel
+func readHDRScanline(br *bufio.Reader, scanline [][4]byte) error {
+	width := len(scanline)
+	var first [4]byte
+	if _, err := io.ReadFull(br, first[:]); err != nil {
+		return err
+	}
+	if width < 8 || width >= 0x8000 || first[0] != 2 || first[1] != 2 || first[2]&0x80 != 0 {
+		// Flat pixels
+		scanline[0] = first
+		for x := 1; x < width; x++ {
+			if _, err := io.ReadFull(br, scanline[x][:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if int(first[2])<<8|int(first[3]) != width {
+		return fmt.Errorf("encoded row width %d, expected %d", int(first[2])<<8|int(first[3]), width)
+	}
+
+	// Each channel in turn: runs of a repeated byte (count over 128) or of literal bytes
+	for ch := 0; ch < 4; ch++ {
+		for x := 0; x < width; {
+			count, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			n := int(count)
+			if n > 128 {
+				n -= 128
+			}
+			if n == 0 || x+n > width {
+				return fmt.Errorf("bad run length %d", n)
+			}
+			if count > 128 {
+				value, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; n > 0; n-- {
+					scanline[x][ch] = value
+					x++
+				}
+				continue
+			}
+			for ; n > 0; n-- {
+				if scanline[x][ch], err = br.ReadByte(); err != nil {
+					return err
+				}
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// WriteHDR writes an image as a Radiance HDR (.hdr) image, which keeps its full range
+func WriteHDR(w io.Writer, img *ImageData) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", img.Height, img.Width)
+
+	// Scanlines are run length encoded by channel where the format allows it; a flat scanline
+	// starting like an encoded one would be misread
+	encoded := img.Width >= 8 && img.Width < 0x8000
+	scanline := make([][4]byte, img.Width)
+	for y := 0; y < img.Height; y++ {
+		for x := range scanline {
+			scanline[x] = toRGBE(img.Pixels[y*img.Width+x])
+		}
+		if !encoded {
+			for _, p := range scanline {
+				bw.Write(p[:])
+			}
+			continue
+		}
+		bw.Write([]byte{2, 2, byte(img.Width >> 8), byte(img.Width)})
+		for ch := 0; ch < 4; ch++ {
+			// Runs of literal bytes, at most 128 each
+			for start := 0; start < img.Width; start += 128 {
+				end := min(start+128, img.Width)
+				bw.WriteByte(byte(end - start))
+				for _, p := range scanline[start:end] {
+					bw.WriteByte(p[ch])
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// toRGBE encodes a color as three mantissas sharing an exponent
+func toRGBE(c core.Vec3) [4]byte {
+	c = core.NewVec3(math.Max(0, c.X), math.Max(0, c.Y), math.Max(0, c.Z))
+	v := c.MaxComponent()
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	mantissa, exponent := math.Frexp(v)
+	scale := mantissa * 256 / v
+	return [4]byte{byte(c.X * scale), byte(c.Y * scale), byte(c.Z * scale), byte(exponent + 128)}
+}
+
+// fromRGBE decodes an RGBE pixel
+func fromRGBE(p [4]byte) core.Vec3 {
+	if p[3] == 0 {
+		return core.Vec3{}
+	}
+	scale := math.Ldexp(1, int(p[3])-(128+8))
+	return core.NewVec3(float64(p[0])*scale, float64(p[1])*scale, float64(p[2])*scale)
+}
@@ -0,0 +1,84 @@
+package loaders
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestWriteHDR(t *testing.T) {
+	img := &ImageData{Width: 8, Height: 2, Pixels: make([]core.Vec3, 16)}
+	img.Pixels[9] = core.NewVec3(1, 0.5, 0.25)
+
+	var buf bytes.Buffer
+	if err := WriteHDR(&buf, img); err != nil {
+		t.Fatalf("WriteHDR failed: %v", err)
+	}
+	header := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 2 +X 8\n"
+	if !strings.HasPrefix(buf.String(), header) {
+		t.Fatalf("Expected the Radiance header, got %q", buf.String())
+	}
+
+	// Each scanline: the 2, 2, width marker, then each channel as one literal run
+	data := buf.Bytes()[len(header):]
+	if len(data) != 2*(4+4*(1+8)) {
+		t.Fatalf("Expected 2 encoded scanlines of 8 pixels, got %d bytes", len(data))
+	}
+	line := data[4+4*(1+8):]
+	var pixel [4]byte
+	for ch := range pixel {
+		pixel[ch] = line[4+ch*9+1+1]
+	}
+	// 1 is 0.5 × 2¹: mantissas 128, 64 and 32 with exponent 1 + 128
+	if pixel != [4]byte{128, 64, 32, 129} {
+		t.Errorf("Expected pixel 1 of row 1 to be RGBE 128 64 32 129, got %v", pixel)
+	}
+}
+
+func TestReadHDR(t *testing.T) {
+	// Round trip, encoded (8 wide) and flat (3 wide)
+	for _, width := range []int{8, 3} {
+		img := &ImageData{Width: width, Height: 2, Pixels: make([]core.Vec3, 2*width)}
+		for i := range img.Pixels {
+			img.Pixels[i] = core.NewVec3(float64(i), 0.5, 1000)
+		}
+		var buf bytes.Buffer
+		if err := WriteHDR(&buf, img); err != nil {
+			t.Fatalf("WriteHDR failed: %v", err)
+		}
+		read, err := ReadHDR(&buf)
+		if err != nil {
+			t.Fatalf("ReadHDR failed: %v", err)
+		}
+		if read.Width != width || read.Height != 2 {
+			t.Fatalf("Expected a %dx2 image, got %dx%d", width, read.Width, read.Height)
+		}
+		for i, p := range read.Pixels {
+			// The shared exponent keeps 8 bits of the largest channel
+			if p.Subtract(img.Pixels[i]).Length() > 1000.0/128 {
+				t.Errorf("Expected pixel %d of the %d wide image to be %v, got %v", i, width, img.Pixels[i], p)
+			}
+		}
+	}
+
+	// Runs of a repeated byte: 8 pixels of RGBE 128 64 32 129
+	data := "#?RADIANCE\n# made by hand\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 8\n" +
+		"\x02\x02\x00\x08" + "\x88\x80" + "\x88\x40" + "\x84\x20\x84\x20" + "\x02\x81\x81\x86\x81"
+	read, err := ReadHDR(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadHDR failed: %v", err)
+	}
+	for i, p := range read.Pixels {
+		if !p.Equals(core.NewVec3(1, 0.5, 0.25)) {
+			t.Errorf("Expected pixel %d to be (1, 0.5, 0.25), got %v", i, p)
+		}
+	}
+
+	for _, bad := range []string{"P6\n", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n", "#?RADIANCE\n\n+X 1 -Y 1\n"} {
+		if _, err := ReadHDR(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error reading %q", bad)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package loaders
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// IESData is the photometric data of an IES LM-63 file
+type IESData struct {
+	LumensPerLamp    float64     // Rated lumens of each lamp, or -1 for absolute photometry
+	PhotometricType  int         // 1 for type C, 2 for type B, 3 for type A
+	VerticalAngles   []float64   // Degrees, increasing
+	HorizontalAngles []float64   // Degrees, increasing
+	Candela          [][]float64 // Intensity per horizontal angle, per vertical angle, with the file's multiplier applied
+}
+
+// LoadIES loads an IES LM-63 photometric file
+func LoadIES(filename string) (*IESData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open IES file: %v", err)
+	}
+	defer file.Close()
+	return ParseIES(file)
+}
+
+// ParseIES parses IES LM-63 photometric data. The keyword header is skipped, as is any lamp tilt
+// data: the intensities are taken as measured with the luminaire level.
+func ParseIES(reader io.Reader) (*IESData, error) {
+	scanner := bufio.NewScanner(reader)
+	tilt := ""
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); strings.HasPrefix(line, "TILT=") {
+			tilt = strings.TrimPrefix(line, "TILT=")
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IES file: %v", err)
+	}
+	if tilt == "" {
+		return nil, fmt.Errorf("IES file has no TILT line")
+	}
+
+	// The rest is numbers, separated by whitespace or, in some files, commas
+	var values []float64
+	for scanner.Scan() {
+		for _, field := range strings.FieldsFunc(scanner.Text(), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		}) {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IES value %q: %v", field, err)
+			}
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IES file: %v", err)
+	}
+
+	next := func(count int, what string) ([]float64, error) {
+		if count < 0 || len(values) < count {
+			return nil, fmt.Errorf("IES file ended before its %s", what)
+		}
+		taken := values[:count]
+		values = values[count:]
+		return taken, nil
+	}
+
+	if tilt == "INCLUDE" {
+		// Lamp to luminaire geometry, the number of tilt angles, then the angles and their factors
+		header, err := next(2, "tilt data")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := next(2*int(header[1]), "tilt data"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Lamp count, lumens per lamp, multiplier, angle counts, photometric type, units and
+	// dimensions, then ballast factor, a reserved value and input watts
+	header, err := next(13, "header")
+	if err != nil {
+		return nil, err
+	}
+	multiplier := header[2]
+	numVertical, numHorizontal := int(header[3]), int(header[4])
+	if numVertical < 1 || numHorizontal < 1 {
+		return nil, fmt.Errorf("IES file has %d vertical and %d horizontal angles", numVertical, numHorizontal)
+	}
+
+	data := &IESData{
+		LumensPerLamp:   header[1],
+		PhotometricType: int(header[5]),
+	}
+	if data.VerticalAngles, err = next(numVertical, "vertical angles"); err != nil {
+		return nil, err
+	}
+	if data.HorizontalAngles, err = next(numHorizontal, "horizontal angles"); err != nil {
+		return nil, err
+	}
+	data.Candela = make([][]float64, numHorizontal)
+	for h := range data.Candela {
+		row, err := next(numVertical, "candela values")
+		if err != nil {
+			return nil, err
+		}
+		for v := range row {
+			row[v] *= multiplier
+		}
+		data.Candela[h] = row
+	}
+	return data, nil
+}
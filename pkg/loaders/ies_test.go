@@ -0,0 +1,79 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testIES = `IESNA:LM-63-2002
+[TEST] downlight
+[MANUFAC] nobody
+TILT=NONE
+1 1000 2 3 2 1 1 0.1 0.1 0.05
+1.0 1.0 20
+0 45 90
+0 90
+100, 80, 0
+100 60 0
+`
+
+func TestParseIES(t *testing.T) {
+	data, err := ParseIES(strings.NewReader(testIES))
+	if err != nil {
+		t.Fatalf("Failed to parse IES data: %v", err)
+	}
+	if data.LumensPerLamp != 1000 || data.PhotometricType != 1 {
+		t.Errorf("Expected 1000 lumens and type C, got %f and %d", data.LumensPerLamp, data.PhotometricType)
+	}
+	if len(data.VerticalAngles) != 3 || data.VerticalAngles[1] != 45 || len(data.HorizontalAngles) != 2 || data.HorizontalAngles[1] != 90 {
+		t.Errorf("Unexpected angles %v, %v", data.VerticalAngles, data.HorizontalAngles)
+	}
+	// The multiplier of 2 applies to every intensity
+	if data.Candela[0][0] != 200 || data.Candela[0][1] != 160 || data.Candela[1][1] != 120 {
+		t.Errorf("Unexpected intensities %v", data.Candela)
+	}
+}
+
+func TestParseIES_TiltInclude(t *testing.T) {
+	content := strings.Replace(testIES, "TILT=NONE\n", "TILT=INCLUDE\n1\n3\n0 45 90\n1 0.9 0.8\n", 1)
+	data, err := ParseIES(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse IES data with tilt: %v", err)
+	}
+	if data.LumensPerLamp != 1000 || data.Candela[1][1] != 120 {
+		t.Errorf("Expected the tilt data to be skipped, got %+v", data)
+	}
+}
+
+func TestParseIES_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"no tilt", "IESNA:LM-63-2002\n1 1000 1 3 2 1 1 0 0 0\n"},
+		{"truncated", strings.TrimSuffix(testIES, "100 60 0\n")},
+		{"not a number", strings.Replace(testIES, "0 45 90", "0 x 90", 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseIES(strings.NewReader(tt.content)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadIES(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "light.ies")
+	if err := os.WriteFile(testFile, []byte(testIES), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIES(testFile); err != nil {
+		t.Errorf("Failed to load IES file: %v", err)
+	}
+	if _, err := LoadIES(filepath.Join(t.TempDir(), "missing.ies")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	_ "image/jpeg" // JPEG decoder
 	_ "image/png"  // PNG decoder
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
@@ -17,8 +19,13 @@ type ImageData struct {
 	Pixels []core.Vec3
 }
 
-// LoadImage loads a PNG or JPEG image and converts it to Vec3 color array
+// LoadImage loads a PNG or JPEG image and converts it to Vec3 color array. Radiance HDR (.hdr)
+// images load with LoadHDR, keeping their full range.
 func LoadImage(filename string) (*ImageData, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".hdr") {
+		return LoadHDR(filename)
+	}
+
 	// Open file
 	file, err := os.Open(filename)
 	if err != nil {
@@ -0,0 +1,292 @@
+package loaders
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// degenerateSine is the smallest sine of a triangle's largest angle that still counts as a triangle
+// Slivers thinner than this have unreliable normals, which render as black speckles.
+const degenerateSine = 1e-10
+
+// MeshCleanupOptions controls CleanupMesh
+type MeshCleanupOptions struct {
+	WeldTolerance float64 // Vertices closer than this are merged (0 = only exact duplicates)
+	OrientFaces   bool    // Flip triangles so windings agree across shared edges (closed parts face outward)
+}
+
+// MeshCleanupStats reports what CleanupMesh changed
+type MeshCleanupStats struct {
+	WeldedVertices      int // Vertices merged into an earlier duplicate
+	DegenerateTriangles int // Triangles removed for repeated vertices, zero area or non-finite positions
+	FlippedTriangles    int // Triangles whose winding was reversed
+	RemovedVertices     int // Vertices dropped because no triangle uses them (including welded ones)
+}
+
+// CleanupMesh repairs a loaded mesh in place: it welds duplicate vertices, removes degenerate
+// triangles and optionally makes the face windings consistent, then drops unused vertices.
+// Vertices are only welded if their normals and texture coordinates (when present) also match,
+// so hard edges and texture seams survive. Per-vertex and per-face attributes are kept in step.
+func CleanupMesh(data *PLYData, options MeshCleanupOptions) MeshCleanupStats {
+	var stats MeshCleanupStats
+	vertexCount := len(data.Vertices)
+	triangleCount := len(data.Faces) / 3
+
+	// Point every vertex at the first vertex it is welded to, and rewrite the faces through it
+	weld := weldVertices(data, options.WeldTolerance)
+	for i, target := range weld {
+		if target != i {
+			stats.WeldedVertices++
+		}
+	}
+
+	faces := make([]int, 0, len(data.Faces))
+	keep := make([]bool, triangleCount)
+	for t := 0; t < triangleCount; t++ {
+		var triangle [3]int
+		valid := true
+		for v := range triangle {
+			index := data.Faces[3*t+v]
+			if index < 0 || index >= vertexCount {
+				valid = false
+				break
+			}
+			triangle[v] = weld[index]
+		}
+		if !valid || isDegenerateTriangle(data.Vertices, triangle) {
+			stats.DegenerateTriangles++
+			continue
+		}
+		keep[t] = true
+		faces = append(faces, triangle[0], triangle[1], triangle[2])
+	}
+	data.FaceColors = keepFaces(data.FaceColors, keep)
+	data.FaceMaterials = keepFaces(data.FaceMaterials, keep)
+
+	if options.OrientFaces {
+		stats.FlippedTriangles = orientFaces(data.Vertices, faces)
+	}
+
+	// Renumber the vertices still in use, in their original order
+	newIndex := make([]int, vertexCount)
+	for i := range newIndex {
+		newIndex[i] = -1
+	}
+	for _, index := range faces {
+		newIndex[index] = 0
+	}
+	var order []int
+	for i := range newIndex {
+		if newIndex[i] == 0 {
+			newIndex[i] = len(order)
+			order = append(order, i)
+		}
+	}
+	for i, index := range faces {
+		faces[i] = newIndex[index]
+	}
+	stats.RemovedVertices = vertexCount - len(order)
+
+	data.Faces = faces
+	data.Vertices = keepVertices(data.Vertices, order, vertexCount)
+	data.Normals = keepVertices(data.Normals, order, vertexCount)
+	data.Colors = keepVertices(data.Colors, order, vertexCount)
+	data.TexCoords = keepVertices(data.TexCoords, order, vertexCount)
+	data.Quality = keepVertices(data.Quality, order, vertexCount)
+	data.Confidence = keepVertices(data.Confidence, order, vertexCount)
+	data.Intensity = keepVertices(data.Intensity, order, vertexCount)
+	for name, values := range data.CustomFloatProps {
+		data.CustomFloatProps[name] = keepVertices(values, order, vertexCount)
+	}
+	for name, values := range data.CustomIntProps {
+		data.CustomIntProps[name] = keepVertices(values, order, vertexCount)
+	}
+
+	return stats
+}
+
+// weldVertices returns, for each vertex, the index of the first vertex within tolerance that has the
+// same normal and texture coordinates (itself if there is none)
+func weldVertices(data *PLYData, tolerance float64) []int {
+	weld := make([]int, len(data.Vertices))
+	hasNormals := len(data.Normals) == len(data.Vertices)
+	hasTexCoords := len(data.TexCoords) == len(data.Vertices)
+	compatible := func(a, b int) bool {
+		return (!hasNormals || data.Normals[a] == data.Normals[b]) &&
+			(!hasTexCoords || data.TexCoords[a] == data.TexCoords[b])
+	}
+
+	// Exact duplicates are found by position; with a tolerance, vertices are bucketed by grid cell
+	// and neighbors can be in the adjacent cells
+	if tolerance <= 0 {
+		positions := make(map[core.Vec3][]int)
+		for i, p := range data.Vertices {
+			weld[i] = i
+			for _, j := range positions[p] {
+				if compatible(i, j) {
+					weld[i] = j
+					break
+				}
+			}
+			if weld[i] == i {
+				positions[p] = append(positions[p], i)
+			}
+		}
+		return weld
+	}
+
+	cells := make(map[[3]int64][]int)
+	cellOf := func(p core.Vec3) [3]int64 {
+		return [3]int64{int64(math.Floor(p.X / tolerance)), int64(math.Floor(p.Y / tolerance)), int64(math.Floor(p.Z / tolerance))}
+	}
+	for i, p := range data.Vertices {
+		weld[i] = i
+		cell := cellOf(p)
+		for dx := int64(-1); dx <= 1 && weld[i] == i; dx++ {
+			for dy := int64(-1); dy <= 1 && weld[i] == i; dy++ {
+				for dz := int64(-1); dz <= 1 && weld[i] == i; dz++ {
+					for _, j := range cells[[3]int64{cell[0] + dx, cell[1] + dy, cell[2] + dz}] {
+						if data.Vertices[j].Subtract(p).Length() <= tolerance && compatible(i, j) {
+							weld[i] = j
+							break
+						}
+					}
+				}
+			}
+		}
+		if weld[i] == i {
+			cells[cell] = append(cells[cell], i)
+		}
+	}
+	return weld
+}
+
+// isDegenerateTriangle reports triangles with repeated vertices, non-finite positions or (nearly) no area
+func isDegenerateTriangle(vertices []core.Vec3, triangle [3]int) bool {
+	if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[2] == triangle[0] {
+		return true
+	}
+	p0, p1, p2 := vertices[triangle[0]], vertices[triangle[1]], vertices[triangle[2]]
+	for _, p := range [3]core.Vec3{p0, p1, p2} {
+		if math.IsNaN(p.X+p.Y+p.Z) || math.IsInf(p.X+p.Y+p.Z, 0) {
+			return true
+		}
+	}
+
+	e0, e1, e2 := p1.Subtract(p0), p2.Subtract(p1), p0.Subtract(p2)
+	longest := math.Max(e0.LengthSquared(), math.Max(e1.LengthSquared(), e2.LengthSquared()))
+	return e0.Cross(e2).Length() <= degenerateSine*longest
+}
+
+// edgeUse is one triangle's use of an edge; forward is true if the triangle runs from the lower vertex index
+type edgeUse struct {
+	triangle int
+	forward  bool
+}
+
+// orientFaces flips triangles so that neighbors traverse their shared edges in opposite directions,
+// and returns the number flipped. Each connected part keeps the winding most of its triangles had,
+// except closed parts, which are turned to face outward (positive volume). Edges shared by more
+// than two triangles don't connect parts, as no winding can agree across them.
+func orientFaces(vertices []core.Vec3, faces []int) int {
+	triangleCount := len(faces) / 3
+	edges := make(map[[2]int][]edgeUse)
+	edgeKey := func(t, e int) ([2]int, bool) {
+		a, b := faces[3*t+e], faces[3*t+(e+1)%3]
+		if a < b {
+			return [2]int{a, b}, true
+		}
+		return [2]int{b, a}, false
+	}
+	for t := 0; t < triangleCount; t++ {
+		for e := 0; e < 3; e++ {
+			key, forward := edgeKey(t, e)
+			edges[key] = append(edges[key], edgeUse{triangle: t, forward: forward})
+		}
+	}
+
+	flip := make([]bool, triangleCount)
+	visited := make([]bool, triangleCount)
+	flipped := 0
+	for seed := 0; seed < triangleCount; seed++ {
+		if visited[seed] {
+			continue
+		}
+
+		// Walk the connected part, choosing each neighbor's flip to oppose the edge direction
+		part := []int{seed}
+		visited[seed] = true
+		closed := true
+		for next := 0; next < len(part); next++ {
+			t := part[next]
+			for e := 0; e < 3; e++ {
+				key, forward := edgeKey(t, e)
+				uses := edges[key]
+				if len(uses) != 2 {
+					closed = false
+					continue
+				}
+				neighbor := uses[0]
+				if neighbor.triangle == t {
+					neighbor = uses[1]
+				}
+				if !visited[neighbor.triangle] {
+					visited[neighbor.triangle] = true
+					flip[neighbor.triangle] = neighbor.forward == (forward != flip[t])
+					part = append(part, neighbor.triangle)
+				}
+			}
+		}
+
+		// Pick the overall orientation: outward for closed parts, fewest flips otherwise
+		partFlips := 0
+		volume := 0.0
+		for _, t := range part {
+			if flip[t] {
+				partFlips++
+			}
+			p0, p1, p2 := vertices[faces[3*t]], vertices[faces[3*t+1]], vertices[faces[3*t+2]]
+			signedVolume := p0.Dot(p1.Cross(p2))
+			if flip[t] {
+				signedVolume = -signedVolume
+			}
+			volume += signedVolume
+		}
+		invert := (closed && volume < 0) || (!closed && 2*partFlips > len(part))
+
+		for _, t := range part {
+			if flip[t] != invert {
+				faces[3*t+1], faces[3*t+2] = faces[3*t+2], faces[3*t+1]
+				flipped++
+			}
+		}
+	}
+	return flipped
+}
+
+// keepFaces filters per-triangle values to the kept triangles; values not covering every triangle are dropped
+func keepFaces[T any](values []T, keep []bool) []T {
+	if len(values) != len(keep) {
+		return nil
+	}
+	var kept []T
+	for t, value := range values {
+		if keep[t] {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// keepVertices reorders per-vertex values to the kept vertices; values not covering every vertex are dropped
+func keepVertices[T any](values []T, order []int, vertexCount int) []T {
+	if len(values) != vertexCount {
+		return nil
+	}
+	kept := make([]T, len(order))
+	for i, index := range order {
+		kept[i] = values[index]
+	}
+	return kept
+}
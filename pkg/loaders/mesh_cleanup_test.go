@@ -0,0 +1,186 @@
+package loaders
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// cubeVertices and cubeFaces describe a unit cube with outward-facing (counter-clockwise) triangles
+var cubeVertices = []core.Vec3{
+	core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(1, 1, 0), core.NewVec3(0, 1, 0),
+	core.NewVec3(0, 0, 1), core.NewVec3(1, 0, 1), core.NewVec3(1, 1, 1), core.NewVec3(0, 1, 1),
+}
+
+var cubeFaces = []int{
+	0, 2, 1, 0, 3, 2, // Back (-Z)
+	4, 5, 6, 4, 6, 7, // Front (+Z)
+	0, 1, 5, 0, 5, 4, // Bottom (-Y)
+	3, 7, 6, 3, 6, 2, // Top (+Y)
+	0, 4, 7, 0, 7, 3, // Left (-X)
+	1, 2, 6, 1, 6, 5, // Right (+X)
+}
+
+// meshVolume returns the signed volume enclosed by a triangle mesh (positive when facing outward)
+func meshVolume(vertices []core.Vec3, faces []int) float64 {
+	volume := 0.0
+	for i := 0; i < len(faces); i += 3 {
+		volume += vertices[faces[i]].Dot(vertices[faces[i+1]].Cross(vertices[faces[i+2]])) / 6
+	}
+	return volume
+}
+
+// directedEdgesUnique reports whether no directed edge is used twice, i.e. neighbors' windings agree
+func directedEdgesUnique(faces []int) bool {
+	seen := make(map[[2]int]bool)
+	for i := 0; i < len(faces); i += 3 {
+		for e := 0; e < 3; e++ {
+			edge := [2]int{faces[i+e], faces[i+(e+1)%3]}
+			if seen[edge] {
+				return false
+			}
+			seen[edge] = true
+		}
+	}
+	return true
+}
+
+func TestCleanupMesh_Weld(t *testing.T) {
+	// A square stored STL-style: each triangle has its own copy of the shared corners
+	data := &PLYData{
+		Vertices: []core.Vec3{
+			core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(1, 1, 0),
+			core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 0), core.NewVec3(0, 1, 0),
+		},
+		Faces:  []int{0, 1, 2, 3, 4, 5},
+		Colors: []core.Vec3{{X: 0}, {X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 5}},
+	}
+
+	stats := CleanupMesh(data, MeshCleanupOptions{})
+
+	if stats.WeldedVertices != 2 || stats.RemovedVertices != 2 {
+		t.Errorf("Expected 2 welded and removed vertices, got %+v", stats)
+	}
+	if len(data.Vertices) != 4 || len(data.Colors) != 4 {
+		t.Fatalf("Expected 4 vertices with colors, got %d and %d", len(data.Vertices), len(data.Colors))
+	}
+	expectedFaces := []int{0, 1, 2, 0, 2, 3}
+	for i, expected := range expectedFaces {
+		if data.Faces[i] != expected {
+			t.Fatalf("Expected faces %v, got %v", expectedFaces, data.Faces)
+		}
+	}
+	// Welded vertices keep the first copy's attributes
+	if data.Colors[3].X != 5 || data.Colors[2].X != 2 {
+		t.Errorf("Colors not kept in step with vertices: %v", data.Colors)
+	}
+}
+
+func TestCleanupMesh_WeldTolerance(t *testing.T) {
+	newData := func() *PLYData {
+		return &PLYData{
+			Vertices: []core.Vec3{
+				core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0),
+				core.NewVec3(1+1e-7, 0, 0), core.NewVec3(1, 1, 0), core.NewVec3(0, 1-1e-7, 0),
+			},
+			Faces: []int{0, 1, 2, 3, 4, 5},
+		}
+	}
+
+	if stats := CleanupMesh(newData(), MeshCleanupOptions{}); stats.WeldedVertices != 0 {
+		t.Errorf("Without a tolerance only exact duplicates should weld, got %+v", stats)
+	}
+	if stats := CleanupMesh(newData(), MeshCleanupOptions{WeldTolerance: 1e-6}); stats.WeldedVertices != 2 {
+		t.Errorf("Expected near duplicates to weld with a tolerance, got %+v", stats)
+	}
+
+	// Different normals mark a hard edge, which must not be welded away
+	data := newData()
+	data.Vertices[3] = data.Vertices[1]
+	data.Normals = []core.Vec3{{Z: 1}, {Z: 1}, {Z: 1}, {X: 1}, {X: 1}, {X: 1}}
+	if stats := CleanupMesh(data, MeshCleanupOptions{WeldTolerance: 1e-6}); stats.WeldedVertices != 0 {
+		t.Errorf("Vertices with different normals should not weld, got %+v", stats)
+	}
+}
+
+func TestCleanupMesh_Degenerate(t *testing.T) {
+	data := &PLYData{
+		Vertices: []core.Vec3{
+			core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0),
+			core.NewVec3(2, 0, 0),          // Collinear with 0 and 1
+			core.NewVec3(math.NaN(), 0, 0), // Broken vertex
+			core.NewVec3(5, 5, 5),          // Unused
+		},
+		Faces: []int{
+			0, 1, 2, // Good
+			0, 1, 1, // Repeated vertex
+			0, 1, 3, // Zero area
+			0, 4, 2, // Non-finite vertex
+			0, 1, 9, // Index out of range
+		},
+		FaceColors: []core.Vec3{{X: 1}, {X: 2}, {X: 3}, {X: 4}, {X: 5}},
+	}
+
+	stats := CleanupMesh(data, MeshCleanupOptions{})
+
+	if stats.DegenerateTriangles != 4 {
+		t.Errorf("Expected 4 degenerate triangles, got %+v", stats)
+	}
+	if len(data.Faces) != 3 || len(data.Vertices) != 3 {
+		t.Errorf("Expected a single triangle over 3 vertices, got faces %v and %d vertices", data.Faces, len(data.Vertices))
+	}
+	if len(data.FaceColors) != 1 || data.FaceColors[0].X != 1 {
+		t.Errorf("Face colors not kept in step with triangles: %v", data.FaceColors)
+	}
+}
+
+func TestCleanupMesh_OrientFaces(t *testing.T) {
+	// A cube with two faces wound the wrong way
+	faces := append([]int(nil), cubeFaces...)
+	faces[1], faces[2] = faces[2], faces[1]
+	faces[19], faces[20] = faces[20], faces[19]
+	data := &PLYData{Vertices: cubeVertices, Faces: faces}
+
+	stats := CleanupMesh(data, MeshCleanupOptions{OrientFaces: true})
+	if stats.FlippedTriangles != 2 {
+		t.Errorf("Expected 2 flipped triangles, got %+v", stats)
+	}
+	if !directedEdgesUnique(data.Faces) || meshVolume(data.Vertices, data.Faces) <= 0 {
+		t.Errorf("Expected a consistently outward cube, got volume %f", meshVolume(data.Vertices, data.Faces))
+	}
+
+	// An entirely inside-out cube is turned outward
+	inverted := append([]int(nil), cubeFaces...)
+	for i := 0; i < len(inverted); i += 3 {
+		inverted[i+1], inverted[i+2] = inverted[i+2], inverted[i+1]
+	}
+	data = &PLYData{Vertices: cubeVertices, Faces: inverted}
+	if stats := CleanupMesh(data, MeshCleanupOptions{OrientFaces: true}); stats.FlippedTriangles != 12 {
+		t.Errorf("Expected all 12 triangles flipped, got %+v", stats)
+	}
+	if volume := meshVolume(data.Vertices, data.Faces); math.Abs(volume-1) > 1e-9 {
+		t.Errorf("Expected an outward unit cube, got volume %f", volume)
+	}
+
+	// An open strip keeps the winding most of its triangles agree on
+	strip := &PLYData{
+		Vertices: []core.Vec3{
+			core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(2, 0, 0),
+			core.NewVec3(0, 1, 0), core.NewVec3(1, 1, 0), core.NewVec3(2, 1, 0),
+		},
+		Faces: []int{0, 1, 4, 0, 4, 3, 1, 2, 5, 1, 4, 5}, // The last triangle is reversed
+	}
+	if stats := CleanupMesh(strip, MeshCleanupOptions{OrientFaces: true}); stats.FlippedTriangles != 1 {
+		t.Errorf("Expected the one reversed strip triangle flipped, got %+v", stats)
+	}
+	if !directedEdgesUnique(strip.Faces) {
+		t.Errorf("Expected consistent strip windings, got %v", strip.Faces)
+	}
+
+	// Without the option windings are left alone
+	data = &PLYData{Vertices: cubeVertices, Faces: append([]int(nil), faces...)}
+	if stats := CleanupMesh(data, MeshCleanupOptions{}); stats.FlippedTriangles != 0 {
+		t.Errorf("Expected no flips without OrientFaces, got %+v", stats)
+	}
+}
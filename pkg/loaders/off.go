@@ -0,0 +1,200 @@
+package loaders
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// LoadOFF loads an ASCII OFF (Object File Format) file into the same representation as LoadPLY
+// The OFF, NOFF (vertex normals), COFF (vertex colors) and CNOFF variants are supported.
+// Polygons are split into triangle fans; face colors, when present, are stored per triangle.
+// Colors may be given as floats in [0,1] or integers in [0,255].
+func LoadOFF(filename string) (*PLYData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OFF file: %v", err)
+	}
+	defer file.Close()
+
+	lines, err := readOFFLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFF file: %v", err)
+	}
+
+	plyData, err := parseOFF(lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OFF data: %v", err)
+	}
+	return plyData, nil
+}
+
+// offLine is a non-empty line of an OFF file split into fields, with comments removed
+type offLine struct {
+	number int
+	fields []string
+}
+
+// readOFFLines reads the fields of each line, dropping comments and blank lines
+func readOFFLines(file *os.File) ([]offLine, error) {
+	var lines []offLine
+	scanner := bufio.NewScanner(file)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+		if comment := strings.IndexByte(text, '#'); comment >= 0 {
+			text = text[:comment]
+		}
+		if fields := strings.Fields(text); len(fields) > 0 {
+			lines = append(lines, offLine{number: number, fields: fields})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseOFF parses the header, vertices and faces of an OFF file
+func parseOFF(lines []offLine) (*PLYData, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+
+	// The keyword's prefixes announce the optional vertex fields: C for colors, N for normals
+	keyword := lines[0].fields[0]
+	if !strings.HasSuffix(keyword, "OFF") {
+		return nil, fmt.Errorf("missing OFF header, got %q", keyword)
+	}
+	hasColors, hasNormals := false, false
+	switch strings.TrimSuffix(keyword, "OFF") {
+	case "":
+	case "C":
+		hasColors = true
+	case "N":
+		hasNormals = true
+	case "CN":
+		hasColors, hasNormals = true, true
+	default:
+		return nil, fmt.Errorf("unsupported OFF variant %q", keyword)
+	}
+
+	// The counts usually follow on the next line, but may share the header line
+	countFields := lines[0].fields[1:]
+	lines = lines[1:]
+	if len(countFields) == 0 {
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("missing vertex and face counts")
+		}
+		countFields = lines[0].fields
+		lines = lines[1:]
+	}
+	if len(countFields) < 2 {
+		return nil, fmt.Errorf("expected vertex and face counts, got %v", countFields)
+	}
+	vertexCount, err1 := strconv.Atoi(countFields[0])
+	faceCount, err2 := strconv.Atoi(countFields[1])
+	if err1 != nil || err2 != nil || vertexCount < 0 || faceCount < 0 {
+		return nil, fmt.Errorf("invalid vertex and face counts %v", countFields)
+	}
+	if len(lines) < vertexCount+faceCount {
+		return nil, fmt.Errorf("expected %d vertices and %d faces, file has %d lines of data", vertexCount, faceCount, len(lines))
+	}
+
+	data := &PLYData{
+		Vertices:         make([]core.Vec3, 0, vertexCount),
+		CustomFloatProps: make(map[string][]float64),
+		CustomIntProps:   make(map[string][]int),
+	}
+
+	// Vertex lines: x y z [nx ny nz] [r g b [a]]
+	for _, line := range lines[:vertexCount] {
+		values, err := parseOFFFloats(line)
+		if err != nil {
+			return nil, err
+		}
+		required := 3
+		if hasNormals {
+			required += 3
+		}
+		if hasColors {
+			required += 3
+		}
+		if len(values) < required {
+			return nil, fmt.Errorf("line %d: expected at least %d values, got %d", line.number, required, len(values))
+		}
+
+		data.Vertices = append(data.Vertices, core.NewVec3(values[0], values[1], values[2]))
+		next := 3
+		if hasNormals {
+			data.Normals = append(data.Normals, core.NewVec3(values[3], values[4], values[5]))
+			next = 6
+		}
+		if hasColors {
+			data.Colors = append(data.Colors, offColor(values[next:next+3]))
+		}
+	}
+
+	// Face lines: n i0 ... i(n-1) [r g b [a]]
+	for _, line := range lines[vertexCount : vertexCount+faceCount] {
+		count, err := strconv.Atoi(line.fields[0])
+		if err != nil || count < 3 || len(line.fields) < 1+count {
+			return nil, fmt.Errorf("line %d: malformed face", line.number)
+		}
+
+		indices := make([]int, count)
+		for i := range indices {
+			index, err := strconv.Atoi(line.fields[1+i])
+			if err != nil || index < 0 || index >= vertexCount {
+				return nil, fmt.Errorf("line %d: invalid vertex index %q", line.number, line.fields[1+i])
+			}
+			indices[i] = index
+		}
+		for i := 1; i+1 < count; i++ {
+			data.Faces = append(data.Faces, indices[0], indices[i], indices[i+1])
+		}
+
+		// A color index into a colormap (one value) isn't supported and is ignored
+		extra := line.fields[1+count:]
+		if len(extra) >= 3 {
+			values, err := parseOFFFloats(offLine{number: line.number, fields: extra})
+			if err != nil {
+				return nil, err
+			}
+			for i := 1; i+1 < count; i++ {
+				data.FaceColors = append(data.FaceColors, offColor(values))
+			}
+		}
+	}
+
+	// Colors must cover every triangle to be usable
+	if len(data.FaceColors) != len(data.Faces)/3 {
+		data.FaceColors = nil
+	}
+
+	return data, nil
+}
+
+// parseOFFFloats parses every field of a line as a number
+func parseOFFFloats(line offLine) ([]float64, error) {
+	values := make([]float64, len(line.fields))
+	for i, field := range line.fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %q", line.number, field)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// offColor converts an OFF color to [0,1] RGB; integer colors (any component above 1) are in [0,255]
+func offColor(values []float64) core.Vec3 {
+	color := core.NewVec3(values[0], values[1], values[2])
+	if values[0] > 1 || values[1] > 1 || values[2] > 1 {
+		color = color.Multiply(1.0 / 255.0)
+	}
+	return color
+}
@@ -0,0 +1,114 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// writeOFF writes content to a temporary OFF file and returns its path
+func writeOFF(t *testing.T, content string) string {
+	t.Helper()
+	testFile := filepath.Join(t.TempDir(), "mesh.off")
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return testFile
+}
+
+func TestLoadOFF_Basic(t *testing.T) {
+	// A unit square as a single quad, with comments and counts on their own line
+	data, err := LoadOFF(writeOFF(t, `OFF
+# unit square
+4 1 0
+
+0 0 0
+1 0 0
+1 1 0  # top right
+0 1 0
+4 0 1 2 3
+`))
+	if err != nil {
+		t.Fatalf("Failed to load OFF: %v", err)
+	}
+
+	expectedVertices := []core.Vec3{
+		core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(1, 1, 0), core.NewVec3(0, 1, 0),
+	}
+	if len(data.Vertices) != len(expectedVertices) {
+		t.Fatalf("Expected %d vertices, got %d", len(expectedVertices), len(data.Vertices))
+	}
+	for i, expected := range expectedVertices {
+		if !data.Vertices[i].Equals(expected) {
+			t.Errorf("Vertex %d: expected %v, got %v", i, expected, data.Vertices[i])
+		}
+	}
+
+	// The quad is split into a fan, matching the PLY layout of 3 indices per triangle
+	expectedFaces := []int{0, 1, 2, 0, 2, 3}
+	if len(data.Faces) != len(expectedFaces) {
+		t.Fatalf("Expected %d face indices, got %d", len(expectedFaces), len(data.Faces))
+	}
+	for i, expected := range expectedFaces {
+		if data.Faces[i] != expected {
+			t.Errorf("Face index %d: expected %d, got %d", i, expected, data.Faces[i])
+		}
+	}
+
+	if len(data.Normals) != 0 || len(data.Colors) != 0 || len(data.FaceColors) != 0 {
+		t.Errorf("Expected no normals or colors, got %d, %d and %d", len(data.Normals), len(data.Colors), len(data.FaceColors))
+	}
+}
+
+func TestLoadOFF_NormalsAndColors(t *testing.T) {
+	// Counts on the header line, normals then colors on each vertex, integer face colors
+	data, err := LoadOFF(writeOFF(t, `CNOFF 3 1 0
+0 0 0  0 0 1  1 0 0 1
+1 0 0  0 0 1  0 1 0 1
+0 1 0  0 0 1  0 0 1 1
+3 0 1 2  255 0 0
+`))
+	if err != nil {
+		t.Fatalf("Failed to load CNOFF: %v", err)
+	}
+
+	if len(data.Normals) != 3 || !data.Normals[1].Equals(core.NewVec3(0, 0, 1)) {
+		t.Errorf("Expected 3 normals of (0,0,1), got %v", data.Normals)
+	}
+	if len(data.Colors) != 3 || !data.Colors[1].Equals(core.NewVec3(0, 1, 0)) {
+		t.Errorf("Expected vertex 1 to be green, got %v", data.Colors)
+	}
+	if len(data.FaceColors) != 1 || !data.FaceColors[0].Equals(core.NewVec3(1, 0, 0)) {
+		t.Errorf("Expected one red face, got %v", data.FaceColors)
+	}
+}
+
+func TestLoadOFF_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", "# nothing here\n"},
+		{"missing header", "3 1 0\n0 0 0\n1 0 0\n0 1 0\n3 0 1 2\n"},
+		{"unsupported variant", "STOFF\n3 1 0\n0 0 0 0 0\n1 0 0 1 0\n0 1 0 0 1\n3 0 1 2\n"},
+		{"bad counts", "OFF\nthree 1 0\n"},
+		{"truncated", "OFF\n3 1 0\n0 0 0\n1 0 0\n"},
+		{"missing normal", "NOFF\n3 1 0\n0 0 0\n1 0 0\n0 1 0\n3 0 1 2\n"},
+		{"index out of range", "OFF\n3 1 0\n0 0 0\n1 0 0\n0 1 0\n3 0 1 3\n"},
+		{"degenerate face", "OFF\n3 1 0\n0 0 0\n1 0 0\n0 1 0\n2 0 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadOFF(writeOFF(t, tt.content)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadOFF(filepath.Join(t.TempDir(), "missing.off")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,8 +17,12 @@ import (
 type PBRTStatement struct {
 	Type          string               // Statement type (Camera, Material, Shape, etc.)
 	Subtype       string               // Subtype (perspective, diffuse, sphere, etc.)
+	Arguments     []string             // Further quoted arguments after the subtype (a Texture's type and class)
 	Parameters    map[string]PBRTParam // Named parameters
 	MaterialIndex int                  // For shapes: index of material to use (-1 = no material)
+	Transform     *core.Matrix4        // For shapes and lights: object-to-world transformation (nil = identity)
+
+	ReverseOrientation bool // For shapes: whether ReverseOrientation flipped the shape's normals inside out
 }
 
 // PBRTParam represents a parameter with type and value(s)
@@ -29,20 +34,39 @@ type PBRTParam struct {
 // PBRTScene contains all parsed PBRT scene data
 type PBRTScene struct {
 	// Pre-WorldBegin statements
-	Camera     *PBRTStatement
-	LookAt     *core.Vec3 // Eye position
-	LookAtTo   *core.Vec3 // Look at target
-	LookAtUp   *core.Vec3 // Up vector
-	Film       *PBRTStatement
-	Sampler    *PBRTStatement
-	Integrator *PBRTStatement
+	Camera      *PBRTStatement
+	LookAt      *core.Vec3 // Eye position
+	LookAtTo    *core.Vec3 // Look at target
+	LookAtUp    *core.Vec3 // Up vector
+	Film        *PBRTStatement
+	PixelFilter *PBRTStatement
+	Sampler     *PBRTStatement
+	Integrator  *PBRTStatement
+
+	CameraTransform *core.Matrix4 // Camera-from-world transformation at the Camera statement
+	Cameras         []PBRTCamera  // Camera statements with a "string name", in order; the last Camera statement is the default
 
 	// World content (inside WorldBegin/WorldEnd)
+	Textures     []PBRTStatement // Named textures in declaration order; the subtype holds the name
 	Materials    []PBRTStatement
 	Shapes       []PBRTStatement
 	LightSources []PBRTStatement
 	Transforms   []PBRTStatement
 	Attributes   []AttributeBlock
+
+	BaseDir string // Directory that file names in the scene are relative to
+}
+
+// PBRTCamera is a named Camera statement with the transformations in effect at it. Each named
+// camera is an extra viewpoint on the same scene, usually placed by a LookAt of its own in a
+// TransformBegin/TransformEnd block.
+type PBRTCamera struct {
+	Name      string
+	Statement *PBRTStatement
+	Transform *core.Matrix4 // Camera-from-world transformation at the statement
+	LookAt    *core.Vec3    // The last LookAt before the statement, if any
+	LookAtTo  *core.Vec3
+	LookAtUp  *core.Vec3
 }
 
 // AttributeBlock represents an AttributeBegin/AttributeEnd block
@@ -57,6 +81,9 @@ type AttributeBlock struct {
 type GraphicsState struct {
 	MaterialIndex   int            // Current material index
 	AreaLightSource *PBRTStatement // Current area light source (nil if none)
+	Transform       core.Matrix4   // Current transformation matrix
+
+	ReverseOrientation bool // Whether shapes' normals are flipped
 }
 
 // PBRTParser encapsulates the state and logic for parsing PBRT files
@@ -65,17 +92,24 @@ type PBRTParser struct {
 	attributeStack       []*AttributeBlock
 	stateStack           []GraphicsState
 	currentMaterialIndex int
+	ctm                  core.Matrix4   // Current transformation matrix
+	transformStack       []core.Matrix4 // Matrices saved by TransformBegin
+	reverseOrientation   bool           // Set by ReverseOrientation until the attribute block ends
 	inWorld              bool
 	statementLines       []string
 }
 
+// maxLineLength is the longest line ParsePBRT reads
+const maxLineLength = 1 << 30
+
 // ParsePBRT parses PBRT content from an io.Reader
 func ParsePBRT(reader io.Reader) (*PBRTScene, error) {
 	// Create parser instance
 	parser := NewPBRTParser()
 
-	// Process each line
+	// Process each line. Meshes often list all their vertices on one line, so lines can be long.
 	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxLineLength)
 	for scanner.Scan() {
 		if err := parser.processLine(scanner.Text()); err != nil {
 			return nil, err
@@ -94,26 +128,32 @@ func ParsePBRT(reader io.Reader) (*PBRTScene, error) {
 	return parser.scene, nil
 }
 
-// LoadPBRT loads and parses a PBRT scene file
+// LoadPBRT loads and parses a PBRT scene file, or one of the bundled scenes (see OpenSceneFile)
 func LoadPBRT(filename string) (*PBRTScene, error) {
 	// Validate file path for security
 	if err := validateFilePath(filename); err != nil {
 		return nil, err
 	}
 
-	file, err := os.Open(filename)
+	file, err := OpenSceneFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open PBRT file: %v", err)
+		return nil, fmt.Errorf("failed to open PBRT file: %w", err)
 	}
 	defer file.Close()
 
-	return ParsePBRT(file)
+	pbrtScene, err := ParsePBRT(file)
+	if err != nil {
+		return nil, err
+	}
+	pbrtScene.BaseDir = filepath.Dir(filename)
+	return pbrtScene, nil
 }
 
 // NewPBRTParser creates a new PBRT parser instance
 func NewPBRTParser() *PBRTParser {
 	return &PBRTParser{
 		scene: &PBRTScene{
+			Textures:     make([]PBRTStatement, 0),
 			Materials:    make([]PBRTStatement, 0),
 			Shapes:       make([]PBRTStatement, 0),
 			LightSources: make([]PBRTStatement, 0),
@@ -123,6 +163,7 @@ func NewPBRTParser() *PBRTParser {
 		attributeStack:       make([]*AttributeBlock, 0),
 		stateStack:           make([]GraphicsState, 0),
 		currentMaterialIndex: -1,
+		ctm:                  core.IdentityMatrix(),
 		inWorld:              false,
 		statementLines:       make([]string, 0),
 	}
@@ -158,6 +199,8 @@ func (p *PBRTParser) processWorldBegin() error {
 		return err
 	}
 	p.inWorld = true
+	p.ctm = core.IdentityMatrix() // World coordinates start over, whatever the camera's transform
+
 	return nil
 }
 
@@ -180,6 +223,9 @@ func (p *PBRTParser) processAttributeBegin() error {
 	currentState := GraphicsState{
 		MaterialIndex:   p.currentMaterialIndex,
 		AreaLightSource: nil, // Area light state is inherited but starts fresh in new block
+		Transform:       p.ctm,
+
+		ReverseOrientation: p.reverseOrientation,
 	}
 
 	// Inherit area light state from parent if we're in nested attribute blocks
@@ -218,11 +264,77 @@ func (p *PBRTParser) processAttributeEnd() error {
 	if len(p.stateStack) > 0 {
 		restoredState := p.stateStack[len(p.stateStack)-1]
 		p.currentMaterialIndex = restoredState.MaterialIndex
+		p.ctm = restoredState.Transform
+		p.reverseOrientation = restoredState.ReverseOrientation
 		p.stateStack = p.stateStack[:len(p.stateStack)-1]
 	}
 	return nil
 }
 
+// processTransformBegin handles TransformBegin directive, saving the current transformation matrix
+func (p *PBRTParser) processTransformBegin() error {
+	if err := p.processAccumulatedStatement("before TransformBegin"); err != nil {
+		return err
+	}
+	p.transformStack = append(p.transformStack, p.ctm)
+	return nil
+}
+
+// processTransformEnd handles TransformEnd directive, restoring the saved transformation matrix
+func (p *PBRTParser) processTransformEnd() error {
+	if err := p.processAccumulatedStatement("before TransformEnd"); err != nil {
+		return err
+	}
+	if len(p.transformStack) == 0 {
+		return fmt.Errorf("TransformEnd without TransformBegin")
+	}
+	p.ctm = p.transformStack[len(p.transformStack)-1]
+	p.transformStack = p.transformStack[:len(p.transformStack)-1]
+	return nil
+}
+
+// applyTransform applies a transform statement to the current transformation matrix. Like PBRT,
+// Transform replaces the matrix and the others compose with it, applying before it.
+func (p *PBRTParser) applyTransform(stmt *PBRTStatement) error {
+	values := make([]float64, len(stmt.Parameters["values"].Values))
+	for i, text := range stmt.Parameters["values"].Values {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s value '%s': %v", stmt.Type, text, err)
+		}
+		values[i] = value
+	}
+	want := map[string]int{"Translate": 3, "Scale": 3, "Rotate": 4, "Transform": 16, "ConcatTransform": 16}[stmt.Type]
+	if len(values) != want {
+		return fmt.Errorf("%s requires %d values, got %d", stmt.Type, want, len(values))
+	}
+
+	switch stmt.Type {
+	case "Translate":
+		p.ctm = p.ctm.Multiply(core.TranslationMatrix(core.NewVec3(values[0], values[1], values[2])))
+	case "Scale":
+		p.ctm = p.ctm.Multiply(core.ScalingMatrix(core.NewVec3(values[0], values[1], values[2])))
+	case "Rotate":
+		axis := core.NewVec3(values[1], values[2], values[3])
+		if axis.IsZero() {
+			return fmt.Errorf("Rotate axis can't be zero")
+		}
+		p.ctm = p.ctm.Multiply(core.RotationMatrix(values[0]*math.Pi/180, axis))
+	case "Transform", "ConcatTransform":
+		// Matrices are given column by column
+		var m core.Matrix4
+		for i, value := range values {
+			m[i%4][i/4] = value
+		}
+		if stmt.Type == "Transform" {
+			p.ctm = m
+		} else {
+			p.ctm = p.ctm.Multiply(m)
+		}
+	}
+	return nil
+}
+
 // processLine processes a single line of PBRT input
 func (p *PBRTParser) processLine(line string) error {
 	line = strings.TrimSpace(line)
@@ -242,6 +354,17 @@ func (p *PBRTParser) processLine(line string) error {
 		return p.processAttributeBegin()
 	case "AttributeEnd":
 		return p.processAttributeEnd()
+	case "TransformBegin":
+		return p.processTransformBegin()
+	case "TransformEnd":
+		return p.processTransformEnd()
+	case "ReverseOrientation":
+		// Surfaces are mostly two-sided, but spheres turned inside out are bubbles inside glass
+		if err := p.processAccumulatedStatement("before ReverseOrientation"); err != nil {
+			return err
+		}
+		p.reverseOrientation = !p.reverseOrientation
+		return nil
 	}
 
 	// Check if this line starts a new statement or continues the previous one
@@ -275,6 +398,45 @@ func (p *PBRTParser) routeStatement(stmt *PBRTStatement) error {
 		if err := parseLookAt(stmt, p.scene); err != nil {
 			return fmt.Errorf("error parsing LookAt: %v", err)
 		}
+		lookAt, err := lookAtMatrix(*p.scene.LookAt, *p.scene.LookAtTo, *p.scene.LookAtUp)
+		if err != nil {
+			return fmt.Errorf("error parsing LookAt: %v", err)
+		}
+		p.ctm = p.ctm.Multiply(lookAt)
+		return nil
+	}
+
+	// Track the current transformation matrix, and record it with what it places
+	switch stmt.Type {
+	case "Translate", "Rotate", "Scale", "Transform", "ConcatTransform":
+		if err := p.applyTransform(stmt); err != nil {
+			return err
+		}
+	case "Camera":
+		ctm := p.ctm
+		p.scene.CameraTransform = &ctm
+		if name, ok := stmt.GetStringParam("name"); ok {
+			for _, camera := range p.scene.Cameras {
+				if camera.Name == name {
+					return fmt.Errorf("duplicate camera name %q", name)
+				}
+			}
+			p.scene.Cameras = append(p.scene.Cameras, PBRTCamera{
+				Name: name, Statement: stmt, Transform: &ctm,
+				LookAt: p.scene.LookAt, LookAtTo: p.scene.LookAtTo, LookAtUp: p.scene.LookAtUp,
+			})
+		}
+	case "Shape", "LightSource", "AreaLightSource":
+		if !p.ctm.IsIdentity() {
+			ctm := p.ctm
+			stmt.Transform = &ctm
+		}
+		stmt.ReverseOrientation = stmt.Type == "Shape" && p.reverseOrientation
+	}
+
+	// Named textures are visible from anywhere after their declaration
+	if stmt.Type == "Texture" && p.inWorld {
+		p.scene.Textures = append(p.scene.Textures, *stmt)
 		return nil
 	}
 
@@ -325,7 +487,7 @@ func (p *PBRTParser) routeStatement(stmt *PBRTStatement) error {
 			}
 			// Also store it in the attribute block for processing
 			currentAttribute.LightSources = append(currentAttribute.LightSources, *stmt)
-		case "Translate", "Rotate", "Scale", "Transform":
+		case "Translate", "Rotate", "Scale", "Transform", "ConcatTransform":
 			currentAttribute.Transforms = append(currentAttribute.Transforms, *stmt)
 		}
 	} else {
@@ -337,6 +499,8 @@ func (p *PBRTParser) routeStatement(stmt *PBRTStatement) error {
 				p.scene.Camera = stmt
 			case "Film":
 				p.scene.Film = stmt
+			case "PixelFilter":
+				p.scene.PixelFilter = stmt
 			case "Sampler":
 				p.scene.Sampler = stmt
 			case "Integrator":
@@ -379,7 +543,7 @@ func (p *PBRTParser) routeStatement(stmt *PBRTStatement) error {
 				}
 				// Also store it for processing
 				p.scene.LightSources = append(p.scene.LightSources, *stmt)
-			case "Translate", "Rotate", "Scale", "Transform":
+			case "Translate", "Rotate", "Scale", "Transform", "ConcatTransform":
 				p.scene.Transforms = append(p.scene.Transforms, *stmt)
 			}
 		}
@@ -488,6 +652,34 @@ func parseLookAt(stmt *PBRTStatement, scene *PBRTScene) error {
 	return nil
 }
 
+// lookAtMatrix returns PBRT's camera-from-world matrix for a camera at eye looking at target. Camera
+// space looks down +z with +y up and, PBRT's coordinates being left-handed, +x to the right.
+func lookAtMatrix(eye, target, up core.Vec3) (core.Matrix4, error) {
+	dir := target.Subtract(eye)
+	if dir.IsZero() {
+		return core.Matrix4{}, fmt.Errorf("eye and look-at point coincide")
+	}
+	dir = dir.Normalize()
+	if up.IsZero() {
+		return core.Matrix4{}, fmt.Errorf("up vector can't be zero")
+	}
+	right := up.Normalize().Cross(dir)
+	if right.Length() < 1e-12 {
+		return core.Matrix4{}, fmt.Errorf("up vector is parallel to the viewing direction")
+	}
+	right = right.Normalize()
+	newUp := dir.Cross(right)
+
+	worldFromCamera := core.Matrix4{
+		{right.X, newUp.X, dir.X, eye.X},
+		{right.Y, newUp.Y, dir.Y, eye.Y},
+		{right.Z, newUp.Z, dir.Z, eye.Z},
+		{0, 0, 0, 1},
+	}
+	cameraFromWorld, _ := worldFromCamera.Inverse() // Orthonormal axes, so always invertible
+	return cameraFromWorld, nil
+}
+
 // tokenizePBRT tokenizes a PBRT line respecting quoted strings and brackets
 func tokenizePBRT(line string) []string {
 	var tokens []string
@@ -568,10 +760,10 @@ func parseStatement(line string) (*PBRTStatement, error) {
 		return stmt, nil
 	}
 
-	// Handle other transform statements (Translate, Rotate, Scale)
-	for _, transform := range []string{"Translate", "Rotate", "Scale", "Transform"} {
-		if strings.HasPrefix(line, transform) {
-			parts := strings.Fields(line[len(transform):])
+	// Handle other transform statements (Translate, Rotate, Scale, Transform [ m00 ... m33 ])
+	for _, transform := range []string{"Translate", "Rotate", "Scale", "Transform", "ConcatTransform"} {
+		if rest, ok := strings.CutPrefix(line, transform); ok && (rest == "" || strings.ContainsAny(rest[:1], " \t[")) {
+			parts := strings.Fields(strings.NewReplacer("[", " ", "]", " ").Replace(rest))
 			stmt := &PBRTStatement{
 				Type: transform,
 				Parameters: map[string]PBRTParam{
@@ -601,6 +793,12 @@ func parseStatement(line string) (*PBRTStatement, error) {
 		parts = parts[1:] // Skip only type
 	}
 
+	// Further single-word quoted strings are arguments, unlike parameter declarations ("type name")
+	for len(parts) > 0 && strings.HasPrefix(parts[0], "\"") && len(strings.Fields(strings.Trim(parts[0], "\""))) == 1 {
+		stmt.Arguments = append(stmt.Arguments, strings.Trim(parts[0], "\""))
+		parts = parts[1:]
+	}
+
 	// Parse parameters
 	i := 0
 	for i < len(parts) {
@@ -694,22 +892,22 @@ func (stmt *PBRTStatement) GetPoint3Param(name string) (*core.Vec3, bool) {
 	return &core.Vec3{X: x, Y: y, Z: z}, true
 }
 
-// GetStringParam extracts a string parameter from a PBRT statement
+// GetStringParam extracts a string parameter from a PBRT statement, without its quotes
 func (stmt *PBRTStatement) GetStringParam(name string) (string, bool) {
 	param, exists := stmt.Parameters[name]
 	if !exists || len(param.Values) == 0 {
 		return "", false
 	}
-	return param.Values[0], true
+	return strings.Trim(param.Values[0], "\""), true
 }
 
 // isStatementStart determines if a line starts a new PBRT statement
 func isStatementStart(line string) bool {
 	// A line starts a statement if it begins with a known PBRT directive
 	statementTypes := []string{
-		"Camera", "Film", "Sampler", "Integrator", "LookAt",
-		"Material", "Shape", "LightSource", "AreaLightSource",
-		"Translate", "Rotate", "Scale", "Transform",
+		"Camera", "Film", "PixelFilter", "Sampler", "Integrator", "LookAt",
+		"Material", "Texture", "Shape", "LightSource", "AreaLightSource",
+		"Translate", "Rotate", "Scale", "Transform", "ConcatTransform",
 		"ReverseOrientation", "Attribute",
 	}
 
@@ -3,6 +3,8 @@ package loaders
 import (
 	"strings"
 	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
 // TestGraphicsStateStack tests that the graphics state stack properly handles material state
@@ -234,3 +236,140 @@ WorldEnd`
 		t.Errorf("Attribute block shape should use local material index 0, got %d", attrBlock.Shapes[0].MaterialIndex)
 	}
 }
+
+// TestTransformStack tests that shapes and lights record the current transformation matrix, which
+// attribute and transform blocks save and restore
+func TestTransformStack(t *testing.T) {
+	pbrtContent := `Scale -1 1 1
+LookAt 0 0 -5  0 0 0  0 1 0
+Camera "perspective" "float fov" 40
+
+WorldBegin
+Shape "sphere"
+Translate 1 0 0
+AttributeBegin
+    Scale 2 2 2
+    AttributeBegin
+        Rotate 90 0 0 1
+        Shape "sphere"
+    AttributeEnd
+    TransformBegin
+        ConcatTransform [ 1 0 0 0  0 1 0 0  0 0 1 0  0 0 3 1 ]
+        LightSource "point"
+    TransformEnd
+    Shape "sphere"
+AttributeEnd
+Transform [ 1 0 0 0
+            0 1 0 0
+            0 0 1 0
+            0 5 0 1 ]
+Shape "sphere"
+WorldEnd`
+
+	scene, err := ParsePBRT(strings.NewReader(pbrtContent))
+	if err != nil {
+		t.Fatalf("Failed to parse scene: %v", err)
+	}
+
+	// Each statement's transform maps its object space origin and x axis to these points
+	apply := func(stmt PBRTStatement, p core.Vec3) core.Vec3 {
+		if stmt.Transform == nil {
+			return p
+		}
+		return stmt.Transform.TransformPoint(p)
+	}
+	tests := []struct {
+		name   string
+		stmt   PBRTStatement
+		origin core.Vec3
+		xAxis  core.Vec3
+	}{
+		{"untransformed shape", scene.Shapes[0], core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0)},
+		{"rotated shape in nested block", scene.Attributes[0].Shapes[0], core.NewVec3(1, 0, 0), core.NewVec3(1, 2, 0)},
+		{"light in transform block", scene.Attributes[1].LightSources[0], core.NewVec3(1, 0, 6), core.NewVec3(3, 0, 6)},
+		{"shape after transform block", scene.Attributes[1].Shapes[0], core.NewVec3(1, 0, 0), core.NewVec3(3, 0, 0)},
+		{"shape after Transform", scene.Shapes[1], core.NewVec3(0, 5, 0), core.NewVec3(1, 5, 0)},
+	}
+	for _, tt := range tests {
+		origin, xAxis := apply(tt.stmt, core.Vec3{}), apply(tt.stmt, core.NewVec3(1, 0, 0))
+		if origin.Subtract(tt.origin).Length() > 1e-9 || xAxis.Subtract(tt.xAxis).Length() > 1e-9 {
+			t.Errorf("%s: expected origin %v and x axis end %v, got %v and %v", tt.name, tt.origin, tt.xAxis, origin, xAxis)
+		}
+	}
+	if scene.Shapes[0].Transform != nil {
+		t.Errorf("Expected no transform for the untransformed shape, got %v", *scene.Shapes[0].Transform)
+	}
+
+	// The camera transform maps the eye to the origin and the view direction to +z, mirrored in x
+	if scene.CameraTransform == nil {
+		t.Fatal("Expected the camera transform to be recorded")
+	}
+	if eye := scene.CameraTransform.TransformPoint(core.NewVec3(0, 0, -5)); eye.Length() > 1e-9 {
+		t.Errorf("Expected the eye at the camera space origin, got %v", eye)
+	}
+	if target := scene.CameraTransform.TransformPoint(core.NewVec3(0, 0, 0)); target.Subtract(core.NewVec3(0, 0, 5)).Length() > 1e-9 {
+		t.Errorf("Expected the target 5 units down +z, got %v", target)
+	}
+	if det := scene.CameraTransform.Determinant3(); det > 0 {
+		t.Errorf("Expected a mirroring camera transform, got determinant %v", det)
+	}
+}
+
+// TestTransformErrors tests malformed transform statements
+func TestReverseOrientation(t *testing.T) {
+	pbrtContent := `WorldBegin
+AttributeBegin
+    ReverseOrientation
+    Shape "sphere"
+    AttributeBegin
+        ReverseOrientation
+        Shape "sphere"
+    AttributeEnd
+    Shape "sphere"
+AttributeEnd
+Shape "sphere"
+WorldEnd`
+
+	scene, err := ParsePBRT(strings.NewReader(pbrtContent))
+	if err != nil {
+		t.Fatalf("Failed to parse scene: %v", err)
+	}
+
+	// ReverseOrientation toggles the orientation until the end of its attribute block
+	tests := []struct {
+		name string
+		stmt PBRTStatement
+		want bool
+	}{
+		{"reversed shape", scene.Attributes[1].Shapes[0], true},
+		{"shape reversed twice", scene.Attributes[0].Shapes[0], false},
+		{"reversed shape after nested block", scene.Attributes[1].Shapes[1], true},
+		{"shape after block", scene.Shapes[0], false},
+	}
+	for _, tt := range tests {
+		if tt.stmt.ReverseOrientation != tt.want {
+			t.Errorf("%s: expected ReverseOrientation %v, got %v", tt.name, tt.want, tt.stmt.ReverseOrientation)
+		}
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Translate 1 2", "Translate requires 3 values, got 2"},
+		{"Rotate 90 0 0 0", "Rotate axis can't be zero"},
+		{"Scale 1 x 1", "invalid Scale value 'x'"},
+		{"Transform [ 1 0 0 1 ]", "Transform requires 16 values, got 4"},
+		{"TransformEnd", "TransformEnd without TransformBegin"},
+		{"LookAt 0 0 0  0 0 0  0 1 0", "eye and look-at point coincide"},
+		{"LookAt 0 0 0  0 1 0  0 1 0", "up vector is parallel to the viewing direction"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePBRT(strings.NewReader("WorldBegin\n" + tt.content + "\nWorldEnd"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
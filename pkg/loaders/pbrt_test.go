@@ -137,6 +137,47 @@ func TestParseStatement(t *testing.T) {
 	}
 }
 
+func TestParseTextures(t *testing.T) {
+	content := `WorldBegin
+Texture "grid" "spectrum" "checkerboard"
+    "float uscale" 8 "float vscale" 8
+    "rgb tex1" [0.9 0.9 0.9] "rgb tex2" [0.1 0.1 0.1]
+AttributeBegin
+Texture "wood" "spectrum" "imagemap" "string filename" "textures/wood.png"
+Material "diffuse" "texture reflectance" "wood"
+Shape "sphere"
+AttributeEnd
+WorldEnd`
+
+	scene, err := ParsePBRT(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParsePBRT() error = %v", err)
+	}
+
+	// Textures declared inside attribute blocks are named globally, like those outside
+	if len(scene.Textures) != 2 {
+		t.Fatalf("Expected 2 textures, got %d", len(scene.Textures))
+	}
+	grid, wood := scene.Textures[0], scene.Textures[1]
+	if grid.Subtype != "grid" || len(grid.Arguments) != 2 || grid.Arguments[0] != "spectrum" || grid.Arguments[1] != "checkerboard" {
+		t.Errorf("Expected the spectrum checkerboard \"grid\", got %q %v", grid.Subtype, grid.Arguments)
+	}
+	if uscale, ok := grid.GetFloatParam("uscale"); !ok || uscale != 8 {
+		t.Errorf("Expected uscale 8, got %v", uscale)
+	}
+	if filename, ok := wood.GetStringParam("filename"); !ok || filename != "textures/wood.png" {
+		t.Errorf("Expected the unquoted file name textures/wood.png, got %q", filename)
+	}
+
+	material := scene.Attributes[0].Materials[0]
+	if reference, ok := material.GetStringParam("reflectance"); !ok || material.Parameters["reflectance"].Type != "texture" || reference != "wood" {
+		t.Errorf("Expected a reference to the texture \"wood\", got %+v", material.Parameters["reflectance"])
+	}
+	if len(material.Arguments) != 0 {
+		t.Errorf("Expected materials to have no extra arguments, got %v", material.Arguments)
+	}
+}
+
 func TestParseLookAt(t *testing.T) {
 	stmt, err := parseStatement("LookAt 1 2 3 4 5 6 7 8 9")
 	if err != nil {
@@ -0,0 +1,58 @@
+package loaders
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/scenes"
+)
+
+// OpenSceneFile opens a scene file from disk or, if there's no such file and the path names one
+// of the bundled scenes ("scenes/cornell.pbrt"), from the copy embedded in the binary. The
+// bundled scenes then load from any working directory, including after go install, and in a
+// browser, where the WebAssembly build has no filesystem.
+func OpenSceneFile(filename string) (fs.File, error) {
+	file, err := os.Open(filename)
+	if err == nil || !(errors.Is(err, fs.ErrNotExist) || errors.Is(err, errors.ErrUnsupported)) {
+		return file, err
+	}
+	if name, ok := embeddedSceneName(filename); ok {
+		if embedded, embeddedErr := scenes.Files.Open(name); embeddedErr == nil {
+			return embedded, nil
+		}
+	}
+	return nil, err
+}
+
+// ReadSceneFile reads a whole scene file, from disk or the bundled scenes like OpenSceneFile
+func ReadSceneFile(filename string) ([]byte, error) {
+	file, err := OpenSceneFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// SceneFileExists reports whether OpenSceneFile would find a scene file
+func SceneFileExists(filename string) bool {
+	if _, err := os.Stat(filename); err == nil {
+		return true
+	}
+	name, ok := embeddedSceneName(filename)
+	if !ok {
+		return false
+	}
+	_, err := fs.Stat(scenes.Files, name)
+	return err == nil
+}
+
+// embeddedSceneName returns the name among the bundled scenes of a path in scenes/
+func embeddedSceneName(filename string) (string, bool) {
+	name, ok := strings.CutPrefix(filepath.ToSlash(filepath.Clean(filename)), "scenes/")
+	return name, ok && fs.ValidPath(name)
+}
@@ -0,0 +1,55 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenSceneFile_Bundled(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	data, err := ReadSceneFile("scenes/sphere-light.micro")
+	if err != nil {
+		t.Fatalf("Expected the bundled scene without a scenes directory: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# Micro scene") {
+		t.Errorf("Expected the bundled file's contents, got %q", data[:min(len(data), 40)])
+	}
+	if !SceneFileExists("scenes/cornell.pbrt") {
+		t.Error("Expected the bundled cornell.pbrt to exist")
+	}
+	if _, err := LoadPBRT("scenes/cornell.pbrt"); err != nil {
+		t.Errorf("Failed to load a bundled PBRT scene: %v", err)
+	}
+
+	// Only paths in scenes/ name bundled files
+	for _, path := range []string{"cornell.pbrt", "other/scenes/cornell.pbrt", "scenes/../cornell.pbrt", "scenes/missing.pbrt"} {
+		if SceneFileExists(path) {
+			t.Errorf("%s: expected no file", path)
+		}
+		if _, err := OpenSceneFile(path); !os.IsNotExist(err) {
+			t.Errorf("%s: expected a not-exist error, got %v", path, err)
+		}
+	}
+}
+
+func TestOpenSceneFile_DiskFirst(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "scenes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scenes", "sphere-light.micro"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	data, err := ReadSceneFile("scenes/sphere-light.micro")
+	if err != nil {
+		t.Fatalf("ReadSceneFile failed: %v", err)
+	}
+	if string(data) != "edited" {
+		t.Errorf("Expected the file on disk to take precedence, got %q", data[:min(len(data), 40)])
+	}
+}
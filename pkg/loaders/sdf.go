@@ -0,0 +1,94 @@
+package loaders
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// SDFData is a signed distance field sampled on a regular grid
+type SDFData struct {
+	Dimensions [3]int    // Number of samples along x, y and z
+	Origin     core.Vec3 // Position of the first sample
+	CellSize   float64   // Spacing between samples
+	Values     []float64 // Distance at each sample, x varying fastest, then y, then z
+}
+
+// LoadSDF loads a signed distance grid in the text format written by SDFGen and similar tools:
+// the sample counts "nx ny nz", the origin "x y z", the cell size, then nx*ny*nz distances with
+// x varying fastest. Values may be separated by any whitespace.
+func LoadSDF(filename string) (*SDFData, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SDF file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+	count := 0
+	next := func(what string) (string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("failed to read SDF file: %v", err)
+			}
+			return "", fmt.Errorf("SDF file ended before its %s", what)
+		}
+		count++
+		return scanner.Text(), nil
+	}
+	nextFloat := func(what string) (float64, error) {
+		text, err := next(what)
+		if err != nil {
+			return 0, err
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid SDF %s %q (value %d): %v", what, text, count, err)
+		}
+		return value, nil
+	}
+
+	data := &SDFData{}
+	for axis := range data.Dimensions {
+		text, err := next("dimensions")
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("invalid SDF dimension %q: need an integer of at least 2", text)
+		}
+		data.Dimensions[axis] = n
+	}
+
+	var origin [3]float64
+	for axis := range origin {
+		if origin[axis], err = nextFloat("origin"); err != nil {
+			return nil, err
+		}
+	}
+	data.Origin = core.NewVec3(origin[0], origin[1], origin[2])
+
+	if data.CellSize, err = nextFloat("cell size"); err != nil {
+		return nil, err
+	}
+	if data.CellSize <= 0 {
+		return nil, fmt.Errorf("invalid SDF cell size %f: must be positive", data.CellSize)
+	}
+
+	total := data.Dimensions[0] * data.Dimensions[1] * data.Dimensions[2]
+	data.Values = make([]float64, total)
+	for i := range data.Values {
+		if data.Values[i], err = nextFloat("distances"); err != nil {
+			return nil, err
+		}
+	}
+	if scanner.Scan() {
+		return nil, fmt.Errorf("SDF file has more than the %d distances its dimensions give", total)
+	}
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package loaders
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// writeSDF writes content to a temporary SDF file and returns its path
+func writeSDF(t *testing.T, content string) string {
+	t.Helper()
+	testFile := filepath.Join(t.TempDir(), "field.sdf")
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return testFile
+}
+
+func TestLoadSDF(t *testing.T) {
+	data, err := LoadSDF(writeSDF(t, "2 2 3\n-1 -1 -1.5\n0.5\n1 2 3 4\n5 6 7 8 9 10\n11 12\n"))
+	if err != nil {
+		t.Fatalf("Failed to load SDF: %v", err)
+	}
+	if data.Dimensions != [3]int{2, 2, 3} {
+		t.Errorf("Expected dimensions 2x2x3, got %v", data.Dimensions)
+	}
+	if !data.Origin.Equals(core.NewVec3(-1, -1, -1.5)) || data.CellSize != 0.5 {
+		t.Errorf("Expected origin (-1, -1, -1.5) and cell size 0.5, got %v and %f", data.Origin, data.CellSize)
+	}
+	if len(data.Values) != 12 || data.Values[0] != 1 || data.Values[11] != 12 {
+		t.Errorf("Expected the 12 distances in order, got %v", data.Values)
+	}
+}
+
+func TestLoadSDF_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"bad dimension", "2 x 2\n0 0 0\n1\n"},
+		{"single sample axis", "1 2 2\n0 0 0\n1\n1 2 3 4\n"},
+		{"zero cell size", "2 2 2\n0 0 0\n0\n1 2 3 4 5 6 7 8\n"},
+		{"too few distances", "2 2 2\n0 0 0\n1\n1 2 3 4 5 6 7\n"},
+		{"too many distances", "2 2 2\n0 0 0\n1\n1 2 3 4 5 6 7 8 9\n"},
+		{"bad distance", "2 2 2\n0 0 0\n1\n1 2 3 4 5 6 7 nan?\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadSDF(writeSDF(t, tt.content)); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+	if _, err := LoadSDF(filepath.Join(t.TempDir(), "missing.sdf")); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
@@ -0,0 +1,190 @@
+package loaders
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+const (
+	stlHeaderSize   = 80 // Binary STL: free-form header before the triangle count
+	stlTriangleSize = 50 // Binary STL: normal, three vertices (12 float32s) and a uint16 attribute
+)
+
+// LoadSTL loads a binary or ASCII STL file into the same representation as LoadPLY
+// STL stores every triangle with its own copy of the vertices; identical positions are merged
+// so the mesh is indexed and adjacent faces share vertices (needed for smooth normals).
+// Facet normals are ignored: they are implied by the counter-clockwise winding, and often wrong.
+func LoadSTL(filename string) (*PLYData, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open STL file: %v", err)
+	}
+
+	// Binary files may also start with "solid", so the size is the reliable test
+	var plyData *PLYData
+	if isBinarySTL(data) {
+		plyData, err = readBinarySTL(data)
+	} else if bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid")) {
+		plyData, err = readASCIISTL(bytes.NewReader(data))
+	} else {
+		return nil, fmt.Errorf("not an STL file: %s", filename)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read STL data: %v", err)
+	}
+	return plyData, nil
+}
+
+// isBinarySTL reports whether data is exactly the size its binary STL triangle count implies
+func isBinarySTL(data []byte) bool {
+	if len(data) < stlHeaderSize+4 {
+		return false
+	}
+	count := binary.LittleEndian.Uint32(data[stlHeaderSize:])
+	return int64(len(data)) == stlHeaderSize+4+int64(count)*stlTriangleSize
+}
+
+// readBinarySTL reads the triangles of a binary STL file
+func readBinarySTL(data []byte) (*PLYData, error) {
+	count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	builder := newMeshBuilder()
+
+	offset := stlHeaderSize + 4
+	for i := 0; i < count; i++ {
+		triangle := data[offset : offset+stlTriangleSize]
+		var corners [3]core.Vec3
+		for v := range corners {
+			corners[v] = readFloat32Vec3(triangle[12*(v+1):]) // The facet normal comes first
+		}
+		if err := builder.addPolygon(corners[:]); err != nil {
+			return nil, fmt.Errorf("triangle %d: %v", i, err)
+		}
+		offset += stlTriangleSize
+	}
+
+	return builder.data(), nil
+}
+
+// readFloat32Vec3 decodes three little-endian float32s
+func readFloat32Vec3(b []byte) core.Vec3 {
+	return core.NewVec3(
+		float64(math.Float32frombits(binary.LittleEndian.Uint32(b[0:]))),
+		float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4:]))),
+		float64(math.Float32frombits(binary.LittleEndian.Uint32(b[8:]))),
+	)
+}
+
+// readASCIISTL reads the facets of an ASCII STL file, which may contain several solids
+func readASCIISTL(reader io.Reader) (*PLYData, error) {
+	builder := newMeshBuilder()
+	var loop []core.Vec3
+	inLoop := false
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "outer":
+			inLoop = true
+			loop = loop[:0]
+		case "vertex":
+			if !inLoop || len(fields) != 4 {
+				return nil, fmt.Errorf("line %d: malformed vertex", lineNumber)
+			}
+			vertex, err := parseVec3Fields(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			loop = append(loop, vertex)
+		case "endloop":
+			if err := builder.addPolygon(loop); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			inLoop = false
+		case "solid", "endsolid", "facet", "endfacet":
+			// Structure only; facet normals are ignored
+		default:
+			return nil, fmt.Errorf("line %d: unexpected keyword %q", lineNumber, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inLoop {
+		return nil, fmt.Errorf("unterminated facet loop")
+	}
+
+	return builder.data(), nil
+}
+
+// parseVec3Fields parses three numeric fields into a vector
+func parseVec3Fields(fields []string) (core.Vec3, error) {
+	var values [3]float64
+	for i := range values {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return core.Vec3{}, fmt.Errorf("invalid number %q", fields[i])
+		}
+		values[i] = value
+	}
+	return core.NewVec3(values[0], values[1], values[2]), nil
+}
+
+// meshBuilder collects polygons given by their corner positions into an indexed triangle mesh,
+// merging corners at identical positions into a single vertex
+type meshBuilder struct {
+	vertices []core.Vec3
+	faces    []int
+	indices  map[core.Vec3]int
+}
+
+func newMeshBuilder() *meshBuilder {
+	return &meshBuilder{indices: make(map[core.Vec3]int)}
+}
+
+// addPolygon adds a convex polygon as a fan of triangles around its first corner
+func (mb *meshBuilder) addPolygon(corners []core.Vec3) error {
+	if len(corners) < 3 {
+		return fmt.Errorf("polygon needs at least 3 vertices, got %d", len(corners))
+	}
+	first := mb.vertexIndex(corners[0])
+	for i := 1; i+1 < len(corners); i++ {
+		mb.faces = append(mb.faces, first, mb.vertexIndex(corners[i]), mb.vertexIndex(corners[i+1]))
+	}
+	return nil
+}
+
+func (mb *meshBuilder) vertexIndex(position core.Vec3) int {
+	if index, exists := mb.indices[position]; exists {
+		return index
+	}
+	index := len(mb.vertices)
+	mb.vertices = append(mb.vertices, position)
+	mb.indices[position] = index
+	return index
+}
+
+func (mb *meshBuilder) data() *PLYData {
+	return &PLYData{
+		Vertices:         mb.vertices,
+		Faces:            mb.faces,
+		CustomFloatProps: make(map[string][]float64),
+		CustomIntProps:   make(map[string][]int),
+	}
+}
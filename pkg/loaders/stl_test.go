@@ -0,0 +1,152 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// stlSquare is a unit square in the XY plane as two triangles, the way STL stores it
+var stlSquare = [][3]core.Vec3{
+	{core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(1, 1, 0)},
+	{core.NewVec3(0, 0, 0), core.NewVec3(1, 1, 0), core.NewVec3(0, 1, 0)},
+}
+
+// createBinarySTL writes triangles as a binary STL file whose header starts with "solid",
+// as many exporters do
+func createBinarySTL(t *testing.T, filename string, triangles [][3]core.Vec3) {
+	var buf bytes.Buffer
+	header := make([]byte, stlHeaderSize)
+	copy(header, "solid exported by a CAD tool")
+	buf.Write(header)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(triangles)))
+
+	for _, triangle := range triangles {
+		values := []float32{0, 0, 1} // Facet normal
+		for _, v := range triangle {
+			values = append(values, float32(v.X), float32(v.Y), float32(v.Z))
+		}
+		binary.Write(&buf, binary.LittleEndian, values)
+		binary.Write(&buf, binary.LittleEndian, uint16(0)) // Attribute byte count
+	}
+
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write STL file: %v", err)
+	}
+}
+
+// checkSquareMesh verifies a loaded unit square: four shared vertices and two triangles
+func checkSquareMesh(t *testing.T, data *PLYData) {
+	t.Helper()
+	if len(data.Vertices) != 4 {
+		t.Fatalf("Expected 4 merged vertices, got %d: %v", len(data.Vertices), data.Vertices)
+	}
+	if len(data.Faces) != 6 {
+		t.Fatalf("Expected 2 triangles, got %d indices", len(data.Faces))
+	}
+
+	for f := 0; f < len(data.Faces); f += 3 {
+		for v := 0; v < 3; v++ {
+			if got := data.Vertices[data.Faces[f+v]]; !got.Equals(stlSquare[f/3][v]) {
+				t.Errorf("Triangle %d vertex %d: expected %v, got %v", f/3, v, stlSquare[f/3][v], got)
+			}
+		}
+	}
+	if len(data.Normals) != 0 {
+		t.Errorf("Expected no vertex normals, got %d", len(data.Normals))
+	}
+}
+
+func TestLoadSTL_Binary(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "square.stl")
+	createBinarySTL(t, testFile, stlSquare)
+
+	data, err := LoadSTL(testFile)
+	if err != nil {
+		t.Fatalf("Failed to load binary STL: %v", err)
+	}
+	checkSquareMesh(t, data)
+}
+
+func TestLoadSTL_ASCII(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "square.stl")
+	content := `solid square
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1.0 1.0 0.0
+    endloop
+  endfacet
+endsolid square
+solid second
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1e0 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid second
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadSTL(testFile)
+	if err != nil {
+		t.Fatalf("Failed to load ASCII STL: %v", err)
+	}
+	checkSquareMesh(t, data)
+}
+
+func TestLoadSTL_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not STL", "ply\nformat ascii 1.0\n"},
+		{"bad number", "solid s\nfacet normal 0 0 1\nouter loop\nvertex 0 0 x\nendloop\nendfacet\nendsolid s\n"},
+		{"too few vertices", "solid s\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nendloop\nendfacet\nendsolid s\n"},
+		{"unterminated loop", "solid s\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\n"},
+		{"unknown keyword", "solid s\nfacet normal 0 0 1\nouter loop\nvertice 0 0 0\n"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(dir, "bad.stl")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadSTL(testFile); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadSTL(filepath.Join(dir, "missing.stl")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestIsBinarySTL(t *testing.T) {
+	data := make([]byte, stlHeaderSize+4+2*stlTriangleSize)
+	binary.LittleEndian.PutUint32(data[stlHeaderSize:], 2)
+	if !isBinarySTL(data) {
+		t.Error("Expected data matching its triangle count to be binary")
+	}
+
+	binary.LittleEndian.PutUint32(data[stlHeaderSize:], math.MaxUint32)
+	if isBinarySTL(data) {
+		t.Error("Expected a size mismatch not to be binary")
+	}
+	if isBinarySTL([]byte("solid")) {
+		t.Error("Expected short data not to be binary")
+	}
+}
@@ -0,0 +1,94 @@
+package loaders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// encodeVol encodes a .vol file with the given header fields and float32 values
+func encodeVol(version byte, encoding int32, dims [3]int32, channels int32, bounds [6]float32, values []float32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("VOL")
+	buf.WriteByte(version)
+	binary.Write(&buf, binary.LittleEndian, encoding)
+	binary.Write(&buf, binary.LittleEndian, dims)
+	binary.Write(&buf, binary.LittleEndian, channels)
+	binary.Write(&buf, binary.LittleEndian, bounds)
+	binary.Write(&buf, binary.LittleEndian, values)
+	return buf.Bytes()
+}
+
+// writeVol writes data to a temporary .vol file and returns its path
+func writeVol(t *testing.T, data []byte) string {
+	t.Helper()
+	testFile := filepath.Join(t.TempDir(), "density.vol")
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return testFile
+}
+
+func TestLoadVolume(t *testing.T) {
+	values := make([]float32, 2*3*4)
+	for i := range values {
+		values[i] = float32(i) / 4
+	}
+	data, err := LoadVolume(writeVol(t, encodeVol(3, 1, [3]int32{2, 3, 4}, 1, [6]float32{-1, 0, -2, 1, 3, 2}, values)))
+	if err != nil {
+		t.Fatalf("Failed to load volume: %v", err)
+	}
+	if data.Dimensions != [3]int{2, 3, 4} {
+		t.Errorf("Expected dimensions 2x3x4, got %v", data.Dimensions)
+	}
+	if !data.Min.Equals(core.NewVec3(-1, 0, -2)) || !data.Max.Equals(core.NewVec3(1, 3, 2)) {
+		t.Errorf("Expected bounds (-1, 0, -2) to (1, 3, 2), got %v to %v", data.Min, data.Max)
+	}
+	if len(data.Values) != 24 || data.Values[1] != 0.25 || data.Values[23] != 5.75 {
+		t.Errorf("Expected the 24 densities in order, got %v", data.Values)
+	}
+}
+
+func TestLoadVolume_FirstChannel(t *testing.T) {
+	values := []float32{1, 9, 9, 2, 9, 9, 3, 9, 9, 4, 9, 9, 5, 9, 9, 6, 9, 9, 7, 9, 9, 8, 9, 9}
+	data, err := LoadVolume(writeVol(t, encodeVol(3, 1, [3]int32{2, 2, 2}, 3, [6]float32{0, 0, 0, 1, 1, 1}, values)))
+	if err != nil {
+		t.Fatalf("Failed to load volume: %v", err)
+	}
+	for i, value := range data.Values {
+		if value != float64(i+1) {
+			t.Fatalf("Expected the first channel's values 1 to 8, got %v", data.Values)
+		}
+	}
+}
+
+func TestLoadVolume_Errors(t *testing.T) {
+	values := make([]float32, 8)
+	bounds := [6]float32{0, 0, 0, 1, 1, 1}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a volume", append([]byte("PLY"), make([]byte, 60)...)},
+		{"old version", encodeVol(2, 1, [3]int32{2, 2, 2}, 1, bounds, values)},
+		{"half float encoding", encodeVol(3, 2, [3]int32{2, 2, 2}, 1, bounds, values)},
+		{"zero resolution", encodeVol(3, 1, [3]int32{2, 0, 2}, 1, bounds, values)},
+		{"zero channels", encodeVol(3, 1, [3]int32{2, 2, 2}, 0, bounds, values)},
+		{"truncated values", encodeVol(3, 1, [3]int32{2, 2, 2}, 1, bounds, values[:7])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadVolume(writeVol(t, tt.data)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+	if _, err := LoadVolume(filepath.Join(t.TempDir(), "missing.vol")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,59 @@
+package loaders
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often WatchFile checks a file for changes
+const DefaultWatchInterval = 250 * time.Millisecond
+
+// WatchFile checks filename every interval and sends on the returned channel after it changes
+// (its modification time or size differs from the last check). Changes between receives are
+// coalesced into one send. The channel is closed when ctx is cancelled. A missing file isn't an
+// error: editors that save by replacing the file briefly remove it, and its return is a change.
+func WatchFile(ctx context.Context, filename string, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := fileVersion(filename)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current := fileVersion(filename)
+			if current == last {
+				continue
+			}
+			last = current
+			if current == (watchedVersion{}) {
+				continue // Removed; wait for it to come back
+			}
+			select {
+			case changes <- struct{}{}:
+			default: // A change is already pending
+			}
+		}
+	}()
+	return changes
+}
+
+// watchedVersion identifies the contents of a watched file, the zero value when it doesn't exist
+type watchedVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func fileVersion(filename string) watchedVersion {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return watchedVersion{}
+	}
+	return watchedVersion{modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,63 @@
+package loaders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "scene.pbrt")
+	if err := os.WriteFile(filename, []byte("WorldBegin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := WatchFile(ctx, filename, 5*time.Millisecond)
+
+	select {
+	case <-changes:
+		t.Fatal("Expected no change before the file is edited")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	// Edits change the size, so they're seen even where modification times are coarse
+	if err := os.WriteFile(filename, []byte("WorldBegin\nShape \"sphere\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change after editing the file")
+	}
+
+	// Removing the file isn't a change; restoring it is
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Fatal("Expected no change while the file is missing")
+	case <-time.After(30 * time.Millisecond):
+	}
+	if err := os.WriteFile(filename, []byte("WorldBegin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change after restoring the file")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("Expected the channel to close after cancelling")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the channel to close after cancelling")
+	}
+}
@@ -0,0 +1,370 @@
+package loaders
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseYAML parses the subset of YAML used for configuration files into the generic values
+// encoding/json decodes into: map[string]interface{}, []interface{}, float64, string, bool and nil.
+// It supports block mappings and sequences nested by indentation, flow collections ([1, 2, 3]
+// and {x: 1}) on one line, plain and quoted scalars, comments and a leading "---". Anchors, tags,
+// multi-line strings and multiple documents are not supported.
+func ParseYAML(data []byte) (interface{}, error) {
+	var lines []yamlLine
+	for i, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(stripYAMLComment(text), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || (len(lines) == 0 && trimmed == "---") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't indent YAML", i+1)
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.pos].number)
+	}
+	return value, nil
+}
+
+// yamlLine is a non-empty line of a YAML document, without its comment and indentation
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// yamlParser walks the lines of a document, one block at a time
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseBlock parses the mapping or sequence whose lines start at indent
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseSequence parses the "- item" lines at indent
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			// The item is the block on the following lines
+			p.pos++
+			item, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// Anything after "- " is read as if it began a line at its own column, so "- x: 1" starts
+		// a mapping that continues on the lines below at that column
+		column := indent + len(line.text) - len(rest)
+		if _, _, isKey := splitYAMLKey(rest); isKey || isYAMLSequenceItem(rest) {
+			p.lines[p.pos] = yamlLine{number: line.number, indent: column, text: rest}
+			item, err := p.parseBlock(column)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		item, err := parseYAMLValue(rest, line.number)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.pos++
+	}
+	return items, nil
+}
+
+// parseMapping parses the "key: value" lines at indent
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		key, rest, isKey := splitYAMLKey(line.text)
+		if !isKey {
+			return nil, fmt.Errorf("line %d: expected \"key: value\", got %q", line.number, line.text)
+		}
+		if _, duplicate := mapping[key]; duplicate {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := parseYAMLValue(rest, line.number)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+			continue
+		}
+
+		// A sequence may sit at the key's own indentation
+		if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+			value, err := p.parseSequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+			continue
+		}
+		value, err := p.parseNested(indent)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+	return mapping, nil
+}
+
+// parseNested parses the block indented deeper than indent, or returns nil when there is none
+func (p *yamlParser) parseNested(indent int) (interface{}, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+		return nil, nil
+	}
+	return p.parseBlock(p.lines[p.pos].indent)
+}
+
+// isYAMLSequenceItem reports whether a line starts a sequence item
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" into the key and the rest of the line
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingYAMLQuote(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		unquoted, err := unquoteYAML(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		rest = text[end+2:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		return unquoted, strings.TrimSpace(rest), true
+	}
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+	if strings.HasSuffix(text, ":") {
+		return text[:len(text)-1], "", true
+	}
+	colon := strings.Index(text, ": ")
+	if colon < 0 {
+		return "", "", false
+	}
+	return text[:colon], strings.TrimSpace(text[colon+2:]), true
+}
+
+// parseYAMLValue parses a value given on one line: a flow collection or a scalar
+func parseYAMLValue(text string, number int) (interface{}, error) {
+	f := &yamlFlow{text: text}
+	value, err := f.parse("")
+	if err == nil {
+		f.skipSpaces()
+		if f.pos < len(f.text) {
+			err = fmt.Errorf("unexpected %q", f.text[f.pos:])
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", number, err)
+	}
+	return value, nil
+}
+
+// yamlFlow parses flow collections and scalars from a single line
+type yamlFlow struct {
+	text string
+	pos  int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// parse parses one value: a flow collection, a quoted string or a plain scalar running up to one
+// of the terminators (those of the enclosing flow collection) or the end of the line
+func (f *yamlFlow) parse(terminators string) (interface{}, error) {
+	f.skipSpaces()
+	if f.pos >= len(f.text) {
+		return nil, nil
+	}
+	switch f.text[f.pos] {
+	case '[':
+		return f.parseFlowSequence()
+	case '{':
+		return f.parseFlowMapping()
+	case '"', '\'':
+		end := closingYAMLQuote(f.text[f.pos:])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %s", f.text[f.pos:])
+		}
+		quoted := f.text[f.pos : f.pos+end+1]
+		f.pos += end + 1
+		return unquoteYAML(quoted)
+	}
+	start := f.pos
+	for f.pos < len(f.text) && !strings.ContainsRune(terminators, rune(f.text[f.pos])) {
+		f.pos++
+	}
+	return yamlScalar(strings.TrimSpace(f.text[start:f.pos])), nil
+}
+
+// parseFlowSequence parses "[a, b, c]"
+func (f *yamlFlow) parseFlowSequence() (interface{}, error) {
+	f.pos++ // [
+	items := []interface{}{}
+	for {
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == ']' {
+			f.pos++
+			return items, nil
+		}
+		item, err := f.parse(",]")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		f.skipSpaces()
+		if f.pos >= len(f.text) {
+			return nil, fmt.Errorf("unterminated sequence")
+		}
+		if f.text[f.pos] == ',' {
+			f.pos++
+		} else if f.text[f.pos] != ']' {
+			return nil, fmt.Errorf("expected ',' or ']' in sequence, got %q", f.text[f.pos:])
+		}
+	}
+}
+
+// parseFlowMapping parses "{a: 1, b: 2}"
+func (f *yamlFlow) parseFlowMapping() (interface{}, error) {
+	f.pos++ // {
+	mapping := map[string]interface{}{}
+	for {
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == '}' {
+			f.pos++
+			return mapping, nil
+		}
+		key, err := f.parse(":,}")
+		if err != nil {
+			return nil, err
+		}
+		if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+			return nil, fmt.Errorf("expected ':' after key %v in mapping", key)
+		}
+		f.pos++
+		value, err := f.parse(",}")
+		if err != nil {
+			return nil, err
+		}
+		mapping[fmt.Sprint(key)] = value
+		f.skipSpaces()
+		if f.pos >= len(f.text) {
+			return nil, fmt.Errorf("unterminated mapping")
+		}
+		if f.text[f.pos] == ',' {
+			f.pos++
+		} else if f.text[f.pos] != '}' {
+			return nil, fmt.Errorf("expected ',' or '}' in mapping, got %q", f.text[f.pos:])
+		}
+	}
+}
+
+// yamlScalar converts a plain scalar to null, a boolean, a number or a string
+func yamlScalar(text string) interface{} {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil {
+		return number
+	}
+	return text
+}
+
+// closingYAMLQuote returns the index of the quote closing the string that text starts with, or -1
+func closingYAMLQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++ // '' is an escaped quote
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unquoteYAML returns the contents of a single or double quoted string
+func unquoteYAML(quoted string) (string, error) {
+	if quoted[0] == '\'' {
+		return strings.ReplaceAll(quoted[1:len(quoted)-1], "''", "'"), nil
+	}
+	unquoted, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", quoted)
+	}
+	return unquoted, nil
+}
+
+// stripYAMLComment removes a comment: a '#' at the start of the line or after a space, outside
+// quoted strings
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" [{:,-", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,92 @@
+package loaders
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	got, err := ParseYAML([]byte(`---
+# A scene
+camera:
+  center: [0, 1, 5]   # flow sequence
+  fov: 40
+materials:
+  glass: {type: dielectric, ior: 1.5}
+  "red wall":
+    type: lambertian
+    albedo: [0.6, 0.05, 0.05]
+shapes:
+- type: sphere
+  center: [0, 0, -1]
+  hide:
+    - camera
+    - shadows
+-   type: 'quad'
+    material: "red wall"
+    label: "a # not a comment"
+lights:
+  -
+    type: sky
+    emit: [0.5, 0.7, 1.0]
+  - type: sun
+    enabled: true
+    note: it's ~sunny: mostly
+empty:
+nested:
+  - [1, [2, 3], {a: b}]
+  - ~
+`))
+	if err != nil {
+		t.Fatalf("ParseYAML failed: %v", err)
+	}
+
+	// The same document in JSON must decode to identical values
+	var want interface{}
+	if err := json.Unmarshal([]byte(`{
+		"camera": {"center": [0, 1, 5], "fov": 40},
+		"materials": {
+			"glass": {"type": "dielectric", "ior": 1.5},
+			"red wall": {"type": "lambertian", "albedo": [0.6, 0.05, 0.05]}
+		},
+		"shapes": [
+			{"type": "sphere", "center": [0, 0, -1], "hide": ["camera", "shadows"]},
+			{"type": "quad", "material": "red wall", "label": "a # not a comment"}
+		],
+		"lights": [
+			{"type": "sky", "emit": [0.5, 0.7, 1.0]},
+			{"type": "sun", "enabled": true, "note": "it's ~sunny: mostly"}
+		],
+		"empty": null,
+		"nested": [[1, [2, 3], {"a": "b"}], null]
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("ParseYAML mismatch, got:\n%s", gotJSON)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"a: 1\n  b: 2", "line 2: unexpected indentation"},
+		{"a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"a: [1, 2", "line 1: unterminated sequence"},
+		{"a: {x 1}", "line 1: expected ':' after key"},
+		{"a: \"open", "line 1: unterminated string"},
+		{"a:\n\t- 1", "line 2: tabs can't indent YAML"},
+		{"just a scalar", `line 1: expected "key: value"`},
+	}
+	for _, test := range tests {
+		_, err := ParseYAML([]byte(test.source))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ParseYAML(%q) error = %v, want %q", test.source, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package material
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Statistical checks every material must pass: it reflects no more light than arrives (and all
+// of it when it absorbs nothing), its sampling matches its PDF, and its BRDF matches what it
+// samples. A new material only needs a case in conservationCases, which also benchmarks it.
+
+// conservationCase is a material set up so that its scattering is known
+type conservationCase struct {
+	name     string
+	material Material
+	lossless bool // Whether it reflects or transmits all the light arriving at it
+	delta    bool // Whether it scatters into delta directions, which have no PDF to check
+}
+
+func conservationCases() []conservationCase {
+	white := core.NewVec3(1, 1, 1)
+	return []conservationCase{
+		{name: "lambertian", material: NewLambertian(white), lossless: true},
+		{name: "lambertian gray", material: NewLambertian(core.NewVec3(0.5, 0.5, 0.5))},
+		{name: "textured lambertian", material: NewTexturedLambertian(NewCheckerTexture(NewSolidColor(white), NewSolidColor(white))), lossless: true},
+		{name: "mirror", material: NewMetal(white, 0), lossless: true, delta: true},
+		{name: "fuzzy metal", material: NewMetal(white, 0.3), delta: true},
+		{name: "glass", material: NewDielectric(1.5), lossless: true, delta: true},
+		{name: "coated lambertian", material: NewLayered(NewDielectric(1.5), NewLambertian(white)), lossless: true, delta: true},
+		{name: "lambertian mix", material: NewMix(NewLambertian(white), NewLambertian(core.NewVec3(0.2, 0.2, 0.2)), 0.25)},
+		{name: "lambertian and mirror mix", material: NewMix(NewLambertian(white), NewMetal(white, 0), 0.5), lossless: true, delta: true},
+		{name: "cutout", material: NewCutout(NewLambertian(white), NewSolidColor(white)), lossless: true},
+		{name: "hair", material: NewHair(core.Vec3{}, 1.55, 0.3, 0.3, 2), lossless: true},
+		{name: "isotropic phase", material: NewHenyeyGreenstein(white, 0), lossless: true},
+		{name: "forward phase", material: NewHenyeyGreenstein(white, 0.7), lossless: true},
+		{name: "backward phase", material: NewHenyeyGreenstein(core.NewVec3(0.6, 0.6, 0.6), -0.4)},
+	}
+}
+
+// conservationHit is the surface the materials are tested on: the xy plane, facing +z
+func conservationHit(m Material) SurfaceInteraction {
+	return SurfaceInteraction{
+		Point:     core.NewVec3(0, 0, 0),
+		Normal:    core.NewVec3(0, 0, 1),
+		FrontFace: true,
+		Material:  m,
+		UV:        core.NewVec2(0.3, 0.6),
+	}
+}
+
+// conservationRays are rays arriving at the test surface from straight above to grazing
+func conservationRays() []core.Ray {
+	var rays []core.Ray
+	for _, degrees := range []float64{0, 30, 60, 85} {
+		theta := degrees * math.Pi / 180
+		direction := core.NewVec3(math.Sin(theta), 0.2*math.Sin(theta), -math.Cos(theta)).Normalize()
+		rays = append(rays, core.NewRay(direction.Multiply(-1), direction))
+	}
+	return rays
+}
+
+// sampleWeight returns the throughput an integrator gives a sampled direction: the attenuation of
+// a delta direction, or the BRDF times the cosine over the PDF
+func sampleWeight(m Material, hit SurfaceInteraction, scatter ScatterResult) core.Vec3 {
+	if scatter.IsSpecular() {
+		return scatter.Attenuation
+	}
+	cosine := CosineTerm(m, scatter.Scattered.Direction.Normalize(), hit.Normal)
+	return scatter.Attenuation.Multiply(math.Max(0, cosine) / scatter.PDF)
+}
+
+// sampledAlbedo estimates the share of light arriving along a ray that a material scatters, by
+// sampling it the way integrators do, along with the standard error of the estimate
+func sampledAlbedo(m Material, ray core.Ray, sampler core.Sampler, samples int) (float64, float64) {
+	hit := conservationHit(m)
+	sum, sumSq := 0.0, 0.0
+	for i := 0; i < samples; i++ {
+		scatter, ok := m.Scatter(ray, hit, sampler)
+		if !ok {
+			continue
+		}
+		weight := sampleWeight(m, hit, scatter).Luminance()
+		sum += weight
+		sumSq += weight * weight
+	}
+	mean := sum / float64(samples)
+	variance := math.Max(0, sumSq/float64(samples)-mean*mean)
+	return mean, math.Sqrt(variance / float64(samples))
+}
+
+func TestMaterials_ConserveEnergy(t *testing.T) {
+	const samples = 50000
+	for _, tc := range conservationCases() {
+		sampler := core.NewSeededSampler(7)
+		for _, ray := range conservationRays() {
+			albedo, stdErr := sampledAlbedo(tc.material, ray, sampler, samples)
+			tolerance := 4*stdErr + 1e-3
+			if albedo > 1+tolerance {
+				t.Errorf("%s, ray %v: reflects %f of the light arriving, more than it receives", tc.name, ray.Direction, albedo)
+			}
+			if tc.lossless && math.Abs(albedo-1) > tolerance+0.01 {
+				t.Errorf("%s, ray %v: expected a white furnace to reflect all the light, got %f ± %f", tc.name, ray.Direction, albedo, stdErr)
+			}
+		}
+	}
+}
+
+// uniformSphereDirection maps a sample to a direction uniformly distributed over the sphere
+func uniformSphereDirection(u core.Vec2) core.Vec3 {
+	z := 1 - 2*u.X
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	return core.NewVec3(r*math.Cos(2*math.Pi*u.Y), r*math.Sin(2*math.Pi*u.Y), z)
+}
+
+func TestMaterials_BRDFMatchesSampling(t *testing.T) {
+	// Integrating the BRDF over the sphere gives the albedo sampling estimates, independently
+	// of the material's sampling code: a BRDF that doesn't match its samples breaks MIS and BDPT
+	const samples = 200000
+	for _, tc := range conservationCases() {
+		if tc.delta {
+			continue
+		}
+		sampler := core.NewSeededSampler(9)
+		hit := conservationHit(tc.material)
+		for _, ray := range conservationRays() {
+			sum := 0.0
+			for i := 0; i < samples; i++ {
+				direction := uniformSphereDirection(sampler.Get2D())
+				cosine := math.Max(0, CosineTerm(tc.material, direction, hit.Normal))
+				sum += tc.material.EvaluateBRDF(ray.Direction, direction, &hit, Radiance).Luminance() * cosine * 4 * math.Pi
+			}
+			integrated := sum / samples
+
+			sampled, stdErr := sampledAlbedo(tc.material, ray, sampler, samples/4)
+			if math.Abs(integrated-sampled) > 4*stdErr+0.02 {
+				t.Errorf("%s, ray %v: the BRDF integrates to an albedo of %f, sampling gives %f ± %f",
+					tc.name, ray.Direction, integrated, sampled, stdErr)
+			}
+		}
+	}
+}
+
+func TestMaterials_PDFMatchesScatter(t *testing.T) {
+	// Integrators weight light samples by PDF and BSDF samples by the PDF Scatter returns, so
+	// for the same direction they must agree, as must the BRDF and the attenuation
+	for _, tc := range conservationCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler := core.NewSeededSampler(3)
+			hit := conservationHit(tc.material)
+			for _, ray := range conservationRays() {
+				for i := 0; i < 2000; i++ {
+					scatter, ok := tc.material.Scatter(ray, hit, sampler)
+					if !ok || scatter.IsSpecular() {
+						continue
+					}
+					direction := scatter.Scattered.Direction
+					pdf, isDelta := tc.material.PDF(ray.Direction, direction, hit.Normal)
+					if isDelta {
+						t.Fatalf("PDF reports a delta for a direction Scatter sampled with PDF %f", scatter.PDF)
+					}
+					if math.Abs(pdf-scatter.PDF) > 1e-6*math.Max(1, pdf) {
+						t.Fatalf("Ray %v: Scatter sampled %v with PDF %f, PDF returns %f", ray.Direction, direction, scatter.PDF, pdf)
+					}
+					brdf := tc.material.EvaluateBRDF(ray.Direction, direction, &hit, Radiance)
+					if brdf.Subtract(scatter.Attenuation).Length() > 1e-6*math.Max(1, brdf.Length()) {
+						t.Fatalf("Ray %v: Scatter attenuated %v by %v, EvaluateBRDF returns %v", ray.Direction, direction, scatter.Attenuation, brdf)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMaterials_SamplingChiSquare(t *testing.T) {
+	// Pearson's χ² test of the directions Scatter samples against the density PDF returns,
+	// binned over the sphere by cos θ and φ about the y axis. The poles, where the bins get
+	// narrow, are in the plane of the surface, away from reflections of the test rays.
+	const (
+		thetaBins = 10
+		phiBins   = 20
+		samples   = 200000
+		subdivide = 8 // Midpoints per bin side when integrating the PDF over a bin
+	)
+	for _, tc := range conservationCases() {
+		if tc.delta {
+			continue
+		}
+		sampler := core.NewSeededSampler(13)
+		hit := conservationHit(tc.material)
+		for _, ray := range conservationRays() {
+			bin := func(direction core.Vec3) int {
+				direction = direction.Normalize()
+				i := min(thetaBins-1, int((direction.Y+1)/2*thetaBins))
+				phi := math.Atan2(direction.Z, direction.X)
+				if phi < 0 {
+					phi += 2 * math.Pi
+				}
+				j := min(phiBins-1, int(phi/(2*math.Pi)*phiBins))
+				return i*phiBins + j
+			}
+
+			observed := make([]float64, thetaBins*phiBins)
+			scattered := 0
+			for n := 0; n < samples; n++ {
+				scatter, ok := tc.material.Scatter(ray, hit, sampler)
+				if !ok {
+					continue
+				}
+				observed[bin(scatter.Scattered.Direction)]++
+				scattered++
+			}
+
+			// Integrate the PDF over each bin: dω = d(cos θ) dφ
+			expected := make([]float64, thetaBins*phiBins)
+			total := 0.0
+			dz, dphi := 2.0/(thetaBins*subdivide), 2*math.Pi/(phiBins*subdivide)
+			for i := 0; i < thetaBins*subdivide; i++ {
+				z := -1 + (float64(i)+0.5)*dz
+				r := math.Sqrt(1 - z*z)
+				for j := 0; j < phiBins*subdivide; j++ {
+					phi := (float64(j) + 0.5) * dphi
+					direction := core.NewVec3(r*math.Cos(phi), z, r*math.Sin(phi))
+					pdf, _ := tc.material.PDF(ray.Direction, direction, hit.Normal)
+					expected[bin(direction)] += pdf * dz * dphi
+					total += pdf * dz * dphi
+				}
+			}
+			if math.Abs(total-1) > 0.02 {
+				t.Errorf("%s, ray %v: expected the PDF to integrate to 1 over the sphere, got %f", tc.name, ray.Direction, total)
+			}
+
+			chiSquare, dof := pearsonChiSquare(observed, expected, float64(scattered)/total)
+			if critical := chiSquareCritical(dof); chiSquare > critical {
+				t.Errorf("%s, ray %v: sampled directions don't follow the PDF: χ² = %.1f with %d degrees of freedom (critical %.1f)",
+					tc.name, ray.Direction, chiSquare, dof, critical)
+			}
+		}
+	}
+}
+
+// pearsonChiSquare returns Pearson's χ² statistic for observed counts against expected
+// probabilities scaled by n, and its degrees of freedom. Bins expecting fewer than 5 samples are
+// pooled, as the χ² distribution is a poor approximation for them.
+func pearsonChiSquare(observed, expected []float64, n float64) (float64, int) {
+	chiSquare := 0.0
+	bins := 0
+	pooledObserved, pooledExpected := 0.0, 0.0
+	for i := range observed {
+		e := expected[i] * n
+		if e < 5 {
+			pooledObserved += observed[i]
+			pooledExpected += e
+			continue
+		}
+		chiSquare += (observed[i] - e) * (observed[i] - e) / e
+		bins++
+	}
+	if pooledExpected >= 5 {
+		chiSquare += (pooledObserved - pooledExpected) * (pooledObserved - pooledExpected) / pooledExpected
+		bins++
+	} else if pooledObserved > 10 {
+		return math.Inf(1), max(1, bins-1) // Samples where the PDF says there should be none
+	}
+	return chiSquare, max(1, bins-1)
+}
+
+// chiSquareCritical returns the χ² value exceeded with probability 1e-4 for dof degrees of
+// freedom, by the Wilson-Hilferty approximation. The tests run many χ² tests, so they fail only
+// on a clear mismatch.
+func chiSquareCritical(dof int) float64 {
+	const z = 3.719 // Standard normal quantile of 1 - 1e-4
+	k := float64(dof)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+// BenchmarkMaterials times each material's sampling and evaluation, at a ray 30 degrees from the
+// normal and directions from fixed seeds
+func BenchmarkMaterials(b *testing.B) {
+	ray := conservationRays()[1]
+	for _, tc := range conservationCases() {
+		hit := conservationHit(tc.material)
+		b.Run(tc.name+"/Scatter", func(b *testing.B) {
+			sampler := core.NewSeededSampler(1)
+			for n := 0; n < b.N; n++ {
+				tc.material.Scatter(ray, hit, sampler)
+			}
+		})
+		b.Run(tc.name+"/EvaluateBRDF", func(b *testing.B) {
+			sampler := core.NewSeededSampler(2)
+			directions := make([]core.Vec3, 256)
+			for i := range directions {
+				directions[i] = uniformSphereDirection(sampler.Get2D())
+			}
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				tc.material.EvaluateBRDF(ray.Direction, directions[n%len(directions)], &hit, Radiance)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package material
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Cutout wraps a material with an opacity mask, for leaf, fence and hair cards: where the mask's
+// luminance is below Threshold the surface isn't there, and rays pass through it untouched
+// The shapes' intersection tests do the skipping (see AlphaMasked), so transparent texels cast no
+// shadows either.
+type Cutout struct {
+	Base      Material
+	Alpha     ColorSource // Opacity: the luminance of the mask, from 0 (transparent) to 1 (opaque)
+	Threshold float64     // Opacity below which hits are skipped
+}
+
+// NewCutout creates a cutout of a material, transparent where the alpha mask is below one half
+func NewCutout(base Material, alpha ColorSource) *Cutout {
+	return &Cutout{
+		Base:      base,
+		Alpha:     alpha,
+		Threshold: 0.5,
+	}
+}
+
+// Opaque implements the AlphaMasked interface
+func (c *Cutout) Opaque(hit *SurfaceInteraction) bool {
+	return hit.EvaluateTexture(c.Alpha).Luminance() >= c.Threshold
+}
+
+// Scatter implements the Material interface with the base material
+func (c *Cutout) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
+	hit.Material = c.Base
+	return c.Base.Scatter(rayIn, hit, sampler)
+}
+
+// EvaluateBRDF implements the Material interface with the base material
+func (c *Cutout) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
+	return c.Base.EvaluateBRDF(incomingDir, outgoingDir, hit, mode)
+}
+
+// PDF implements the Material interface with the base material
+func (c *Cutout) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
+	return c.Base.PDF(incomingDir, outgoingDir, normal)
+}
@@ -0,0 +1,48 @@
+package material
+
+import (
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestCutout_Opaque(t *testing.T) {
+	// Left half transparent, right half opaque
+	alpha := NewImageTexture(2, 1, []core.Vec3{{}, core.NewVec3(1, 1, 1)})
+	base := NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cutout := NewCutout(base, alpha)
+
+	transparent := &SurfaceInteraction{UV: core.NewVec2(0.25, 0.5), Material: cutout}
+	opaque := &SurfaceInteraction{UV: core.NewVec2(0.75, 0.5), Material: cutout}
+	if IsOpaque(transparent) {
+		t.Error("Expected the black texel to be transparent")
+	}
+	if !IsOpaque(opaque) {
+		t.Error("Expected the white texel to be opaque")
+	}
+	if !IsOpaque(&SurfaceInteraction{Material: base}) {
+		t.Error("Expected materials without a mask to be opaque")
+	}
+
+	cutout.Threshold = 0
+	if !IsOpaque(transparent) {
+		t.Error("Expected a zero threshold to make every texel opaque")
+	}
+}
+
+func TestCutout_ScattersAsBase(t *testing.T) {
+	base := NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cutout := NewCutout(base, NewImageTexture(1, 1, []core.Vec3{core.NewVec3(1, 1, 1)}))
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0, 0), Normal: core.NewVec3(0, 1, 0), FrontFace: true, Material: cutout}
+	rayIn := core.NewRay(core.NewVec3(0, 1, 1), core.NewVec3(0, -1, -1).Normalize())
+
+	got, ok := cutout.Scatter(rayIn, hit, core.NewSeededSampler(3))
+	want, wantOK := base.Scatter(rayIn, hit, core.NewSeededSampler(3))
+	if ok != wantOK || got.Scattered.Direction != want.Scattered.Direction || got.Attenuation != want.Attenuation {
+		t.Errorf("Expected the cutout to scatter as its base material, got %+v, want %+v", got, want)
+	}
+	out := core.NewVec3(0, 1, 0)
+	if cutout.EvaluateBRDF(rayIn.Direction, out, &hit, Radiance) != base.EvaluateBRDF(rayIn.Direction, out, &hit, Radiance) {
+		t.Error("Expected the cutout's BRDF to be its base material's")
+	}
+}
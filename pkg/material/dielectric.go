@@ -1,14 +1,38 @@
 package material
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"strings"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
 // Dielectric represents a transparent material like glass that can both reflect and refract
+// A dispersive dielectric's index depends on the wavelength, given by Cauchy's equation
+// n(λ) = A + B/λ² or by a glass's Sellmeier coefficients. Rays refract at the wavelengths of
+// the RGB channels (see channelWavelengths): the first dispersive surface a path meets picks one
+// channel at random, and the path carries only that channel from there on (core.Ray.Channel).
 type Dielectric struct {
-	RefractiveIndex float64 // Index of refraction (e.g., 1.5 for glass)
+	RefractiveIndex float64     // Index of refraction (e.g., 1.5 for glass), at 587.6nm when dispersive
+	CauchyB         float64     // Cauchy B coefficient in μm², A matching RefractiveIndex (0 = no dispersion)
+	Sellmeier       *[6]float64 // Sellmeier B1, B2, B3, C1, C2, C3 (C in μm²), replacing CauchyB (nil = none)
+	Glass           string      // Name of the catalog glass the Sellmeier coefficients are from, if any
+	SigmaA          core.Vec3   // Interior absorption coefficient per unit distance, per channel (zero = clear)
+}
+
+// channelWavelengths are the wavelengths in μm the red, green and blue channels refract at under
+// dispersion: the Fraunhofer C, d and F lines glass catalogs quote their indices at
+var channelWavelengths = [3]float64{0.6563, 0.5876, 0.4861}
+
+// sellmeierGlasses are the Sellmeier coefficients of catalog glasses, by their Schott names
+var sellmeierGlasses = map[string][6]float64{
+	"BK7":   {1.03961212, 0.231792344, 1.01046945, 0.00600069867, 0.0200179144, 103.560653},
+	"BAF10": {1.5851495, 0.143559385, 1.08521269, 0.00926681282, 0.0424489805, 105.613573},
+	"FK51A": {0.971247817, 0.216901417, 0.904651666, 0.00472301995, 0.0153575612, 168.68133},
+	"LASF9": {2.00029547, 0.298926886, 1.80691843, 0.0121426017, 0.0538736236, 156.530829},
+	"F5":    {1.3104463, 0.19603426, 0.96612977, 0.00958633048, 0.0457627627, 115.011883},
 }
 
 // NewDielectric creates a new dielectric material
@@ -16,17 +40,104 @@ func NewDielectric(refractiveIndex float64) *Dielectric {
 	return &Dielectric{RefractiveIndex: refractiveIndex}
 }
 
+// NewDispersiveDielectric creates a dielectric whose index follows Cauchy's equation, with index
+// refractiveIndex at 587.6nm. Crown glass has a B of about 0.004μm², dense flint about 0.01.
+func NewDispersiveDielectric(refractiveIndex, cauchyB float64) *Dielectric {
+	return &Dielectric{RefractiveIndex: refractiveIndex, CauchyB: cauchyB}
+}
+
+// NewGlassDielectric creates a dielectric of a catalog glass (see GlassNames), dispersing light
+// as its Sellmeier coefficients describe
+func NewGlassDielectric(name string) (*Dielectric, error) {
+	coefficients, ok := sellmeierGlasses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown glass %q (known glasses: %s)", name, strings.Join(GlassNames(), ", "))
+	}
+	d := &Dielectric{Sellmeier: &coefficients, Glass: name}
+	d.RefractiveIndex = d.IOR(2)
+	return d, nil
+}
+
+// NewAbsorbingDielectric creates a dielectric of colored glass, which transmits color through
+// each unit of distance inside it (see AbsorptionFromColor)
+func NewAbsorbingDielectric(refractiveIndex float64, color core.Vec3) *Dielectric {
+	return &Dielectric{RefractiveIndex: refractiveIndex, SigmaA: AbsorptionFromColor(color)}
+}
+
+// AbsorptionFromColor returns the absorption coefficient of a medium that transmits color
+// through a unit of distance
+func AbsorptionFromColor(color core.Vec3) core.Vec3 {
+	channel := func(c float64) float64 {
+		return -math.Log(math.Min(math.Max(c, 1e-4), 1))
+	}
+	return core.NewVec3(channel(color.X), channel(color.Y), channel(color.Z))
+}
+
+// GlassNames returns the catalog glasses NewGlassDielectric knows, sorted
+func GlassNames() []string {
+	names := make([]string, 0, len(sellmeierGlasses))
+	for name := range sellmeierGlasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dispersive reports whether the index of refraction depends on the wavelength
+func (d *Dielectric) Dispersive() bool {
+	return d.CauchyB != 0 || d.Sellmeier != nil
+}
+
+// IOR returns the index of refraction for a ray's color channel (see core.Ray.Channel); all
+// channels of a non-dispersive dielectric, and channel 0, refract at RefractiveIndex
+func (d *Dielectric) IOR(channel int) float64 {
+	if channel < 1 || channel > 3 || !d.Dispersive() {
+		return d.RefractiveIndex
+	}
+	lambda := channelWavelengths[channel-1]
+	l2 := lambda * lambda
+	if c := d.Sellmeier; c != nil {
+		return math.Sqrt(1 + c[0]*l2/(l2-c[3]) + c[1]*l2/(l2-c[4]) + c[2]*l2/(l2-c[5]))
+	}
+	d2 := channelWavelengths[1] * channelWavelengths[1]
+	return d.RefractiveIndex + d.CauchyB*(1/l2-1/d2)
+}
+
 // Scatter implements the Material interface for dielectric scattering
 func (d *Dielectric) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
-	// Dielectrics always attenuate by 1.0 (no color absorption for clear glass)
+	// Clear glass attenuates by 1.0 at its surfaces (no color absorption)
 	attenuation := core.NewVec3(1.0, 1.0, 1.0)
 
+	// A dispersive surface splits white light: a path carrying every channel continues with one,
+	// chosen uniformly and weighted by 3 so the channels still average to the same light
+	channel := rayIn.Channel
+	if channel == 0 && d.Dispersive() {
+		channel = 1 + min(int(sampler.Get1D()*3), 2)
+		attenuation = core.Vec3{}
+		switch channel {
+		case 1:
+			attenuation.X = 3
+		case 2:
+			attenuation.Y = 3
+		case 3:
+			attenuation.Z = 3
+		}
+	}
+	ior := d.IOR(channel)
+
+	// Light leaving the interior was absorbed over the distance it traveled inside
+	if !hit.FrontFace && !d.SigmaA.IsZero() {
+		distance := hit.T * rayIn.Direction.Length()
+		attenuation = attenuation.MultiplyVec(core.NewVec3(
+			math.Exp(-d.SigmaA.X*distance), math.Exp(-d.SigmaA.Y*distance), math.Exp(-d.SigmaA.Z*distance)))
+	}
+
 	// Determine if we're entering or exiting the material
 	var refractionRatio float64
 	if hit.FrontFace {
-		refractionRatio = 1.0 / d.RefractiveIndex // Ray is entering the material (from air to glass)
+		refractionRatio = 1.0 / ior // Ray is entering the material (from air to glass)
 	} else {
-		refractionRatio = d.RefractiveIndex // Ray is exiting the material (from glass to air)
+		refractionRatio = ior // Ray is exiting the material (from glass to air)
 	}
 
 	// Normalize the incoming ray direction
@@ -39,22 +150,25 @@ func (d *Dielectric) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler cor
 	// Check for total internal reflection
 	cannotRefract := refractionRatio*sinTheta > 1.0
 
-	var direction core.Vec3
+	// Bend the ray, and its differentials the same way
+	var bend func(direction core.Vec3) core.Vec3
+	lobe := LobeGlossy
 	if cannotRefract || Reflectance(cosTheta, refractionRatio) > sampler.Get1D() {
-		// Reflect
-		direction = reflectVector(unitDirection, hit.Normal)
+		bend = func(d core.Vec3) core.Vec3 { return reflectVector(d, hit.Normal) }
 	} else {
-		// Refract
-		direction = refractVector(unitDirection, hit.Normal, refractionRatio)
+		bend = func(d core.Vec3) core.Vec3 { return refractVector(d, hit.Normal, refractionRatio) }
+		lobe = LobeTransmission
 	}
 
-	scattered := core.Ray{Origin: hit.Point, Direction: direction}
+	scattered := core.Ray{Origin: hit.Point, Direction: bend(unitDirection), Channel: channel,
+		Differential: hit.specularDifferential(rayIn, bend)}
 
 	return ScatterResult{
 		Incoming:    rayIn,
 		Scattered:   scattered,
 		Attenuation: attenuation,
 		PDF:         0, // Specular materials have no PDF
+		Lobe:        lobe,
 	}, true
 }
 
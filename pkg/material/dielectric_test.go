@@ -361,3 +361,86 @@ func TestDielectricPDF_AlwaysZero(t *testing.T) {
 		t.Error("Dielectric should indicate it's a delta function (isDelta=true)")
 	}
 }
+
+func TestDielectric_DispersionIndices(t *testing.T) {
+	cauchy := NewDispersiveDielectric(1.5, 0.01)
+	bk7, err := NewGlassDielectric("BK7")
+	if err != nil {
+		t.Fatalf("NewGlassDielectric failed: %v", err)
+	}
+	if math.Abs(bk7.RefractiveIndex-1.5168) > 1e-4 {
+		t.Errorf("Expected BK7's catalog index 1.5168, got %f", bk7.RefractiveIndex)
+	}
+	for _, d := range []*Dielectric{cauchy, bk7} {
+		// Blue bends more than green, green more than red; green is the quoted index
+		if !(d.IOR(3) > d.IOR(2) && d.IOR(2) > d.IOR(1)) || d.IOR(2) != d.RefractiveIndex || d.IOR(0) != d.RefractiveIndex {
+			t.Errorf("Unexpected indices R %f, G %f, B %f for %+v", d.IOR(1), d.IOR(2), d.IOR(3), d)
+		}
+	}
+	if plain := NewDielectric(1.5); plain.Dispersive() || plain.IOR(3) != 1.5 {
+		t.Error("Expected a plain dielectric not to disperse")
+	}
+	if _, err := NewGlassDielectric("unobtainium"); err == nil {
+		t.Error("Expected an error for an unknown glass")
+	}
+}
+
+func TestDielectric_DispersionPicksChannel(t *testing.T) {
+	prism := NewDispersiveDielectric(1.5, 0.01)
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0, 0), Normal: core.NewVec3(0, 1, 0), FrontFace: true, Material: prism}
+	ray := core.NewRay(core.NewVec3(0, 1, 0), core.NewVec3(1, -1, 0).Normalize())
+
+	// A white path continues with one channel weighted by 3, so the channels average to white
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(7)))
+	var sum core.Vec3
+	const n = 3000
+	for i := 0; i < n; i++ {
+		result, _ := prism.Scatter(ray, hit, sampler)
+		c := result.Scattered.Channel
+		if c < 1 || c > 3 || result.Attenuation.Luminance() == 0 || result.Attenuation.X+result.Attenuation.Y+result.Attenuation.Z != 3 {
+			t.Fatalf("Expected one channel weighted by 3, got channel %d and %v", c, result.Attenuation)
+		}
+		sum = sum.Add(result.Attenuation)
+	}
+	if mean := sum.Multiply(1.0 / n); math.Abs(mean.X-1) > 0.1 || math.Abs(mean.Y-1) > 0.1 || math.Abs(mean.Z-1) > 0.1 {
+		t.Errorf("Expected attenuation averaging white, got %v", mean)
+	}
+
+	// A path already carrying a channel keeps it, and refracts at that channel's index
+	blue := ray
+	blue.Channel = 3
+	for seed := int64(0); seed < 20; seed++ {
+		result, _ := prism.Scatter(blue, hit, core.NewRandomSampler(rand.New(rand.NewSource(seed))))
+		if result.Scattered.Channel != 3 || result.Attenuation != core.NewVec3(1, 1, 1) {
+			t.Fatalf("Expected the blue channel kept with white attenuation, got %d and %v", result.Scattered.Channel, result.Attenuation)
+		}
+		if result.Scattered.Direction.Y < 0 {
+			want := refractVector(blue.Direction, hit.Normal, 1/prism.IOR(3))
+			if result.Scattered.Direction.Subtract(want).Length() > 1e-9 {
+				t.Errorf("Expected refraction at the blue index, got %v want %v", result.Scattered.Direction, want)
+			}
+		}
+	}
+}
+
+func TestDielectric_Absorption(t *testing.T) {
+	// Glass passing half its red through each unit of distance, and all of green and blue
+	glass := NewAbsorbingDielectric(1.5, core.NewVec3(0.5, 1, 1))
+	ray := core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(0, 2, 0)) // Direction of length 2
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 3, 0), Normal: core.NewVec3(0, -1, 0), T: 1.5, Material: glass}
+
+	// Leaving the interior after 3 units of distance, red is halved three times
+	hit.FrontFace = false
+	result, _ := glass.Scatter(ray, hit, core.NewRandomSampler(rand.New(rand.NewSource(1))))
+	if want := core.NewVec3(0.125, 1, 1); result.Attenuation.Subtract(want).Length() > 1e-9 {
+		t.Errorf("Expected attenuation %v leaving the glass, got %v", want, result.Attenuation)
+	}
+
+	// Entering, the ray traveled outside
+	hit.FrontFace = true
+	hit.Normal = core.NewVec3(0, -1, 0)
+	result, _ = glass.Scatter(ray, hit, core.NewRandomSampler(rand.New(rand.NewSource(1))))
+	if result.Attenuation != core.NewVec3(1, 1, 1) {
+		t.Errorf("Expected no absorption entering the glass, got %v", result.Attenuation)
+	}
+}
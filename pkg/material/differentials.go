@@ -0,0 +1,108 @@
+package material
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// FilteredColorSource is implemented by textures that can average themselves over an area, such
+// as mipmapped ImageTextures, so that surfaces far away or seen at grazing angles don't shimmer
+type FilteredColorSource interface {
+	ColorSource
+	// EvaluateFiltered returns the average color over about width around uv, in UV units
+	EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3
+}
+
+// EvaluateFiltered returns a texture's color averaged over width around uv if it can filter
+// itself, and its color at uv otherwise
+func EvaluateFiltered(source ColorSource, uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	if filtered, ok := source.(FilteredColorSource); ok && width > 0 {
+		return filtered.EvaluateFiltered(uv, point, width)
+	}
+	return source.Evaluate(uv, point)
+}
+
+// EvaluateTexture looks a texture up at the hit, as materials do: with the shading normal for
+// OrientedColorSources, and over the hit's pixel footprint for FilteredColorSources
+func (h *SurfaceInteraction) EvaluateTexture(source ColorSource) core.Vec3 {
+	if _, ok := source.(OrientedColorSource); ok {
+		return EvaluateOriented(source, h.UV, h.Point, h.Normal)
+	}
+	return EvaluateFiltered(source, h.UV, h.Point, h.UVFootprint())
+}
+
+// UVFootprint returns the width in texture coordinates of the pixel the hit was seen through
+func (h *SurfaceInteraction) UVFootprint() float64 {
+	return math.Max(h.DUVDx.Length(), h.DUVDy.Length())
+}
+
+// SetDifferentials sets how the point and its texture coordinates move from pixel to pixel, by
+// where the ray's differentials cross the plane tangent to the surface. Without differentials,
+// or when they miss the plane, they're zero.
+func (h *SurfaceInteraction) SetDifferentials(ray core.Ray) {
+	h.DpDx, h.DpDy, h.DUVDx, h.DUVDy = core.Vec3{}, core.Vec3{}, core.Vec2{}, core.Vec2{}
+	d := ray.Differential
+	if d == nil {
+		return
+	}
+	px, okX := h.tangentPlaneHit(d.RxOrigin, d.RxDirection)
+	py, okY := h.tangentPlaneHit(d.RyOrigin, d.RyDirection)
+	if !okX || !okY {
+		return
+	}
+	h.DpDx, h.DpDy = px.Subtract(h.Point), py.Subtract(h.Point)
+
+	// Solve dp = du * DpDu + dv * DpDv in the least squares sense, as dp needn't lie exactly in
+	// the plane DpDu and DpDv span
+	a00, a01, a11 := h.DpDu.Dot(h.DpDu), h.DpDu.Dot(h.DpDv), h.DpDv.Dot(h.DpDv)
+	det := a00*a11 - a01*a01
+	if math.Abs(det) < 1e-20 {
+		return
+	}
+	solve := func(dp core.Vec3) core.Vec2 {
+		b0, b1 := h.DpDu.Dot(dp), h.DpDv.Dot(dp)
+		du, dv := (a11*b0-a01*b1)/det, (a00*b1-a01*b0)/det
+		return core.NewVec2(clampDerivative(du), clampDerivative(dv))
+	}
+	h.DUVDx, h.DUVDy = solve(h.DpDx), solve(h.DpDy)
+}
+
+// clampDerivative keeps texture derivatives finite, as grazing rays cross the tangent plane far
+// from the hit
+func clampDerivative(x float64) float64 {
+	if math.IsNaN(x) {
+		return 0
+	}
+	return math.Max(-1e8, math.Min(1e8, x))
+}
+
+// tangentPlaneHit returns where a ray crosses the plane tangent to the surface at the hit
+func (h *SurfaceInteraction) tangentPlaneHit(origin, direction core.Vec3) (core.Vec3, bool) {
+	denom := h.Normal.Dot(direction)
+	if denom == 0 {
+		return core.Vec3{}, false
+	}
+	t := h.Normal.Dot(h.Point.Subtract(origin)) / denom
+	if math.IsInf(t, 0) || math.IsNaN(t) {
+		return core.Vec3{}, false
+	}
+	return origin.Add(direction.Multiply(t)), true
+}
+
+// specularDifferential returns the differentials of a ray leaving the hit in a direction bent
+// from the incoming ray's by a mirror reflection or a refraction, applying the same bend to the
+// neighboring rays. It treats the surface as flat around the hit, ignoring how curvature spreads
+// or focuses them. It's nil when the incoming ray has none.
+func (h *SurfaceInteraction) specularDifferential(rayIn core.Ray, bend func(direction core.Vec3) core.Vec3) *core.RayDifferential {
+	d := rayIn.Differential
+	if d == nil || (h.DpDx.IsZero() && h.DpDy.IsZero()) {
+		return nil
+	}
+	return &core.RayDifferential{
+		RxOrigin:    h.Point.Add(h.DpDx),
+		RxDirection: bend(d.RxDirection.Normalize()),
+		RyOrigin:    h.Point.Add(h.DpDy),
+		RyDirection: bend(d.RyDirection.Normalize()),
+	}
+}
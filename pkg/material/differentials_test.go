@@ -0,0 +1,87 @@
+package material
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// planeHit is a hit on the plane z = 0 whose texture coordinates span 2 units per unit of u and v
+func planeHit(ray core.Ray) *SurfaceInteraction {
+	t := -ray.Origin.Z / ray.Direction.Z
+	hit := &SurfaceInteraction{T: t, Point: ray.At(t), DpDu: core.NewVec3(2, 0, 0), DpDv: core.NewVec3(0, 2, 0)}
+	hit.SetFaceNormal(ray, core.NewVec3(0, 0, 1))
+	return hit
+}
+
+func TestSetDifferentials(t *testing.T) {
+	ray := core.NewRay(core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1))
+	ray.Differential = &core.RayDifferential{
+		RxOrigin: ray.Origin, RxDirection: core.NewVec3(0.1, 0, -1),
+		RyOrigin: ray.Origin, RyDirection: core.NewVec3(0, 0.2, -2),
+	}
+	hit := planeHit(ray)
+	hit.SetDifferentials(ray)
+	if !hit.DpDx.Equals(core.NewVec3(0.1, 0, 0)) || !hit.DpDy.Equals(core.NewVec3(0, 0.1, 0)) {
+		t.Errorf("Expected DpDx (0.1, 0, 0) and DpDy (0, 0.1, 0), got %v and %v", hit.DpDx, hit.DpDy)
+	}
+	if hit.DUVDx != core.NewVec2(0.05, 0) || hit.DUVDy != core.NewVec2(0, 0.05) || hit.UVFootprint() != 0.05 {
+		t.Errorf("Expected UV derivatives of 0.05, got %v and %v", hit.DUVDx, hit.DUVDy)
+	}
+
+	// At a grazing angle the footprint stretches
+	grazing := core.NewRay(core.NewVec3(0, -10, 1), core.NewVec3(0, 10, -1))
+	grazing.Differential = &core.RayDifferential{
+		RxOrigin: grazing.Origin, RxDirection: core.NewVec3(0.01, 10, -1),
+		RyOrigin: grazing.Origin, RyDirection: core.NewVec3(0, 10, -1.01),
+	}
+	hit = planeHit(grazing)
+	hit.SetDifferentials(grazing)
+	if hit.DUVDy.Length() < 5*hit.DUVDx.Length() {
+		t.Errorf("Expected a footprint stretched along y, got %v and %v", hit.DUVDx, hit.DUVDy)
+	}
+
+	// Rays without differentials have no footprint
+	plain := core.NewRay(ray.Origin, ray.Direction)
+	hit.SetDifferentials(plain)
+	if hit.UVFootprint() != 0 || !hit.DpDx.IsZero() {
+		t.Errorf("Expected no footprint, got %v", hit.UVFootprint())
+	}
+}
+
+func TestSpecularDifferentials(t *testing.T) {
+	ray := core.NewRay(core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1))
+	ray.Differential = &core.RayDifferential{
+		RxOrigin: ray.Origin, RxDirection: core.NewVec3(0.1, 0, -1),
+		RyOrigin: ray.Origin, RyDirection: core.NewVec3(0, 0.1, -1),
+	}
+	hit := planeHit(ray)
+	hit.SetDifferentials(ray)
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(1)))
+
+	// A mirror reflects the neighboring rays too, so they keep diverging
+	scatter, _ := NewMetal(core.NewVec3(1, 1, 1), 0).Scatter(ray, *hit, sampler)
+	d := scatter.Scattered.Differential
+	if d == nil {
+		t.Fatal("Expected a mirror to carry differentials on")
+	}
+	if !d.RxOrigin.Equals(core.NewVec3(0.1, 0, 0)) || d.RxDirection.Subtract(core.NewVec3(0.1, 0, 1).Normalize()).Length() > 1e-12 {
+		t.Errorf("Expected the x ray reflected from (0.1, 0, 0), got %+v", *d)
+	}
+
+	// Glass refracts them
+	scatter, _ = NewDielectric(1.5).Scatter(ray, *hit, sampler)
+	if d := scatter.Scattered.Differential; d == nil || math.Abs(d.RxDirection.Normalize().X) >= 0.1/math.Sqrt(1.01) {
+		t.Errorf("Expected the glass to bend the differentials, got %+v", d)
+	}
+
+	// Fuzzy and diffuse bounces drop them
+	if scatter, _ := NewMetal(core.NewVec3(1, 1, 1), 0.5).Scatter(ray, *hit, sampler); scatter.Scattered.Differential != nil {
+		t.Error("Expected a fuzzy metal to drop differentials")
+	}
+	if scatter, _ := NewLambertian(core.NewVec3(1, 1, 1)).Scatter(ray, *hit, sampler); scatter.Scattered.Differential != nil {
+		t.Error("Expected a Lambertian to drop differentials")
+	}
+}
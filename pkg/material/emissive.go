@@ -7,6 +7,12 @@ import (
 // Emissive represents a light-emitting material
 type Emissive struct {
 	Emission core.Vec3 // Emitted light color/intensity
+
+	// Optional texture scaling the emission channel by channel where rays hit the surface, such as
+	// an asset's emissive map. Light sampling, which has no hit, uses its average over the unit UV
+	// square instead, so the pattern comes from the paths that hit the surface.
+	Texture        ColorSource
+	textureAverage core.Vec3
 }
 
 // NewEmissive creates a new emissive material
@@ -14,6 +20,18 @@ func NewEmissive(emission core.Vec3) *Emissive {
 	return &Emissive{Emission: emission}
 }
 
+// NewTexturedEmissive creates an emissive material whose emission is scaled by a texture
+func NewTexturedEmissive(emission core.Vec3, texture ColorSource) *Emissive {
+	const steps = 16
+	var sum core.Vec3
+	for i := 0; i < steps; i++ {
+		for j := 0; j < steps; j++ {
+			sum = sum.Add(texture.Evaluate(core.NewVec2((float64(i)+0.5)/steps, (float64(j)+0.5)/steps), core.Vec3{}))
+		}
+	}
+	return &Emissive{Emission: emission, Texture: texture, textureAverage: sum.Multiply(1.0 / (steps * steps))}
+}
+
 // Scatter implements the Material interface for emissive materials
 // Emissive materials don't scatter rays - they only emit light
 func (e *Emissive) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
@@ -28,7 +46,14 @@ func (e *Emissive) Emit(rayIn core.Ray, hit *SurfaceInteraction) core.Vec3 {
 	if hit != nil && !hit.FrontFace {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
-	return e.Emission
+	switch {
+	case e.Texture == nil:
+		return e.Emission
+	case hit == nil:
+		return e.Emission.MultiplyVec(e.textureAverage)
+	default:
+		return e.Emission.MultiplyVec(hit.EvaluateTexture(e.Texture))
+	}
 }
 
 // EvaluateBRDF evaluates the BRDF for specific incoming/outgoing directions
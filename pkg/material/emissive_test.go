@@ -115,3 +115,19 @@ func abs(x float64) float64 {
 	}
 	return x
 }
+
+func TestEmissive_Texture(t *testing.T) {
+	// Red on the left half of the UV square, blue on the right
+	texture := NewCheckerTexture(NewSolidColor(core.NewVec3(1, 0, 0)), NewSolidColor(core.NewVec3(0, 0, 1)))
+	emissive := NewTexturedEmissive(core.NewVec3(2, 2, 2), NewUVMappedTexture(texture, core.NewVec2(2, 1), core.Vec2{}))
+	ray := core.NewRay(core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1))
+
+	hit := &SurfaceInteraction{FrontFace: true, UV: core.NewVec2(0.25, 0.5)}
+	if got := emissive.Emit(ray, hit); !got.Equals(core.NewVec3(2, 0, 0)) {
+		t.Errorf("Expected the texture's red at the hit, got %v", got)
+	}
+	// Light sampling has no hit, so it sees the texture's average
+	if got := emissive.Emit(ray, nil); !got.Equals(core.NewVec3(1, 0, 1)) {
+		t.Errorf("Expected the texture's average, got %v", got)
+	}
+}
@@ -0,0 +1,429 @@
+package material
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// hairMaxLobe is the number of explicitly modeled scattering lobes (R, TT, TRT); longer paths
+// inside the fiber are folded into one isotropic remainder lobe
+const hairMaxLobe = 3
+
+// Hair is the Chiang et al. 2016 hair fiber BSDF, as in PBRT. A fiber is a rough dielectric
+// cylinder with an absorbing interior: light reflects off it (R), passes through it (TT), or
+// reflects once inside (TRT). It is meant for curves, which bind each hit's fiber direction and
+// offset across the fiber (see FiberMaterial); on other shapes it behaves as a fiber lying in
+// the surface, hit through its middle.
+type Hair struct {
+	SigmaA core.Vec3 // Absorption coefficient of the interior, per fiber diameter
+	Eta    float64   // Index of refraction of the fiber (1.55 for human hair)
+	BetaM  float64   // Longitudinal roughness, in [0, 1]
+	BetaN  float64   // Azimuthal roughness, in [0, 1]
+	Alpha  float64   // Tilt of the cuticle scales, in degrees (2 for human hair)
+
+	// Cached derived values
+	v          [hairMaxLobe + 1]float64 // Longitudinal variance per lobe
+	s          float64                  // Azimuthal logistic scale
+	sin2kAlpha [3]float64
+	cos2kAlpha [3]float64
+}
+
+// NewHair creates a hair fiber material from the absorption of its interior
+// Use HairAbsorptionFromMelanin or HairAbsorptionFromColor for intuitive colors.
+func NewHair(sigmaA core.Vec3, eta, betaM, betaN, alphaDegrees float64) *Hair {
+	h := &Hair{SigmaA: sigmaA, Eta: eta, BetaM: betaM, BetaN: betaN, Alpha: alphaDegrees}
+
+	// Roughness to lobe variances, fit by Chiang et al.
+	h.v[0] = math.Pow(0.726*betaM+0.812*betaM*betaM+3.7*math.Pow(betaM, 20), 2)
+	h.v[1] = 0.25 * h.v[0]
+	h.v[2] = 4 * h.v[0]
+	for p := 3; p <= hairMaxLobe; p++ {
+		h.v[p] = h.v[2]
+	}
+	h.s = math.Sqrt(math.Pi/8) * (0.265*betaN + 1.194*betaN*betaN + 5.372*math.Pow(betaN, 22))
+
+	// Each lobe's longitudinal shift by the scales, by angle doubling
+	h.sin2kAlpha[0] = math.Sin(alphaDegrees * math.Pi / 180)
+	h.cos2kAlpha[0] = math.Sqrt(math.Max(0, 1-h.sin2kAlpha[0]*h.sin2kAlpha[0]))
+	for i := 1; i < 3; i++ {
+		h.sin2kAlpha[i] = 2 * h.cos2kAlpha[i-1] * h.sin2kAlpha[i-1]
+		h.cos2kAlpha[i] = h.cos2kAlpha[i-1]*h.cos2kAlpha[i-1] - h.sin2kAlpha[i-1]*h.sin2kAlpha[i-1]
+	}
+	return h
+}
+
+// HairAbsorptionFromMelanin returns the absorption of hair with the given concentrations of
+// eumelanin (brown-black pigment, ~0.3 blond, ~1.3 brown, ~8 black) and pheomelanin (red-yellow)
+func HairAbsorptionFromMelanin(eumelanin, pheomelanin float64) core.Vec3 {
+	eumelaninSigmaA := core.NewVec3(0.419, 0.697, 1.37)
+	pheomelaninSigmaA := core.NewVec3(0.187, 0.4, 1.05)
+	return eumelaninSigmaA.Multiply(eumelanin).Add(pheomelaninSigmaA.Multiply(pheomelanin))
+}
+
+// HairAbsorptionFromColor returns the absorption giving roughly the color after multiple
+// scattering in a mass of hair with azimuthal roughness betaN
+func HairAbsorptionFromColor(color core.Vec3, betaN float64) core.Vec3 {
+	denominator := 5.969 - 0.215*betaN + 2.532*betaN*betaN - 10.73*math.Pow(betaN, 3) +
+		5.574*math.Pow(betaN, 4) + 0.245*math.Pow(betaN, 5)
+	channel := func(c float64) float64 {
+		return math.Pow(math.Log(math.Max(c, 1e-4))/denominator, 2)
+	}
+	return core.NewVec3(channel(color.X), channel(color.Y), channel(color.Z))
+}
+
+// AtFiber implements FiberMaterial - binds the fiber frame of a curve hit
+func (h *Hair) AtFiber(tangent, offsetDirection core.Vec3, offset float64) Material {
+	// The model measures h the other way around the fiber from the frame's y axis: a ray hitting
+	// at +y reflects toward +y, which is a change of -2γo in φ = atan2(z, y) only for h = -offset
+	return &hairFiber{hair: h, tangent: tangent, offsetDirection: offsetDirection, h: -offset}
+}
+
+// Scatter implements the Material interface for a fiber lying in the surface
+func (h *Hair) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
+	return h.surfaceFiber(hit.Normal).Scatter(rayIn, hit, sampler)
+}
+
+// EvaluateBRDF implements the Material interface for a fiber lying in the surface
+func (h *Hair) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
+	return h.surfaceFiber(hit.Normal).EvaluateBRDF(incomingDir, outgoingDir, hit, mode)
+}
+
+// PDF implements the Material interface for a fiber lying in the surface
+func (h *Hair) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
+	return h.surfaceFiber(normal).PDF(incomingDir, outgoingDir, normal)
+}
+
+// Translucent implements the Translucent interface
+func (h *Hair) Translucent() {}
+
+// surfaceFiber returns a fiber perpendicular to the normal, hit through its middle
+func (h *Hair) surfaceFiber(normal core.Vec3) *hairFiber {
+	reference := core.NewVec3(1, 0, 0)
+	if math.Abs(normal.X) > 0.9 {
+		reference = core.NewVec3(0, 1, 0)
+	}
+	tangent := normal.Cross(reference).Normalize()
+	return &hairFiber{hair: h, tangent: tangent, offsetDirection: normal.Cross(tangent), h: 0}
+}
+
+// hairFiber is a Hair bound to the fiber of one hit
+// The fiber frame is x along the fiber, y across it (the direction of increasing h), and z = x × y
+type hairFiber struct {
+	hair            *Hair
+	tangent         core.Vec3
+	offsetDirection core.Vec3
+	h               float64 // Offset of the hit across the fiber as the model measures it, in [-1, 1]
+}
+
+// Translucent implements the Translucent interface
+func (f *hairFiber) Translucent() {}
+
+// toLocal expresses a direction in the fiber frame
+func (f *hairFiber) toLocal(direction core.Vec3) core.Vec3 {
+	return core.NewVec3(direction.Dot(f.tangent), direction.Dot(f.offsetDirection), direction.Dot(f.tangent.Cross(f.offsetDirection)))
+}
+
+// toWorld converts a direction from the fiber frame
+func (f *hairFiber) toWorld(local core.Vec3) core.Vec3 {
+	return f.tangent.Multiply(local.X).
+		Add(f.offsetDirection.Multiply(local.Y)).
+		Add(f.tangent.Cross(f.offsetDirection).Multiply(local.Z))
+}
+
+// Scatter implements the Material interface - samples a lobe, then its longitudinal and azimuthal angles
+func (f *hairFiber) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
+	hair := f.hair
+	wo := f.toLocal(rayIn.Direction.Normalize().Negate())
+	sinThetaO := wo.X
+	cosThetaO := safeSqrt(1 - sinThetaO*sinThetaO)
+	phiO := math.Atan2(wo.Z, wo.Y)
+
+	// Choose the lobe in proportion to its attenuation
+	lobeSample := sampler.Get2D()
+	thetaSample := sampler.Get2D()
+	apPdf := f.lobePDFs(cosThetaO)
+	p := 0
+	u := lobeSample.X
+	for ; p < hairMaxLobe; p++ {
+		if u < apPdf[p] {
+			break
+		}
+		u -= apPdf[p]
+	}
+
+	// Sample the longitudinal scattering around the lobe's tilted specular angle
+	sinThetaOp, cosThetaOp := hair.tiltedAngles(p, sinThetaO, cosThetaO)
+	uTheta := math.Max(thetaSample.X, 1e-5)
+	cosTheta := 1 + hair.v[p]*math.Log(uTheta+(1-uTheta)*math.Exp(-2/hair.v[p]))
+	sinTheta := safeSqrt(1 - cosTheta*cosTheta)
+	cosPhi := math.Cos(2 * math.Pi * thetaSample.Y)
+	sinThetaI := -cosTheta*sinThetaOp + sinTheta*cosPhi*cosThetaOp
+	cosThetaI := safeSqrt(1 - sinThetaI*sinThetaI)
+
+	// Sample the azimuthal scattering around the lobe's exit angle
+	gammaO, gammaT := f.gammas(sinThetaO, cosThetaO)
+	var dphi float64
+	if p < hairMaxLobe {
+		dphi = hairPhi(p, gammaO, gammaT) + sampleTrimmedLogistic(lobeSample.Y, hair.s, -math.Pi, math.Pi)
+	} else {
+		dphi = 2 * math.Pi * lobeSample.Y
+	}
+	phiI := phiO + dphi
+	wi := core.NewVec3(sinThetaI, cosThetaI*math.Cos(phiI), cosThetaI*math.Sin(phiI))
+
+	pdf := f.pdfLocal(wo, wi)
+	if pdf <= 0 {
+		return ScatterResult{}, false
+	}
+	direction := f.toWorld(wi)
+
+	// Only the R lobe reflects off the fiber; the others pass through it
+	lobe := LobeTransmission
+	if p == 0 {
+		lobe = LobeGlossy
+	}
+	return ScatterResult{
+		Incoming:    rayIn,
+		Scattered:   core.NewRay(hit.Point, direction),
+		Attenuation: f.EvaluateBRDF(rayIn.Direction, direction, &hit, Radiance),
+		PDF:         pdf,
+		Lobe:        lobe,
+	}, true
+}
+
+// EvaluateBRDF implements the Material interface
+// Integrators weight the BSDF by |cos θ| to the shading normal, which the fiber's scattering
+// doesn't have, so it is divided out here.
+func (f *hairFiber) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
+	cosine := math.Abs(outgoingDir.Normalize().Dot(hit.Normal))
+	if cosine < 1e-6 {
+		return core.Vec3{}
+	}
+	return f.evaluateLocal(f.toLocal(incomingDir.Normalize().Negate()), f.toLocal(outgoingDir.Normalize())).Multiply(1 / cosine)
+}
+
+// PDF implements the Material interface
+func (f *hairFiber) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
+	return f.pdfLocal(f.toLocal(incomingDir.Normalize().Negate()), f.toLocal(outgoingDir.Normalize())), false
+}
+
+// evaluateLocal returns the fiber's scattering (the BSDF times |cos θi|) between local directions
+func (f *hairFiber) evaluateLocal(wo, wi core.Vec3) core.Vec3 {
+	hair := f.hair
+	sinThetaO := wo.X
+	cosThetaO := safeSqrt(1 - sinThetaO*sinThetaO)
+	phiO := math.Atan2(wo.Z, wo.Y)
+	sinThetaI := wi.X
+	cosThetaI := safeSqrt(1 - sinThetaI*sinThetaI)
+	phiI := math.Atan2(wi.Z, wi.Y)
+
+	gammaO, gammaT := f.gammas(sinThetaO, cosThetaO)
+	ap := f.attenuations(cosThetaO, f.transmittance(sinThetaO, cosThetaO))
+	phi := phiI - phiO
+
+	var sum core.Vec3
+	for p := 0; p < hairMaxLobe; p++ {
+		sinThetaOp, cosThetaOp := hair.tiltedAngles(p, sinThetaO, cosThetaO)
+		weight := hairMp(cosThetaI, cosThetaOp, sinThetaI, sinThetaOp, hair.v[p]) * hairNp(phi, p, hair.s, gammaO, gammaT)
+		sum = sum.Add(ap[p].Multiply(weight))
+	}
+	remainder := hairMp(cosThetaI, cosThetaO, sinThetaI, sinThetaO, hair.v[hairMaxLobe]) / (2 * math.Pi)
+	return sum.Add(ap[hairMaxLobe].Multiply(remainder))
+}
+
+// pdfLocal returns the density of Scatter sampling wi, in solid angle
+func (f *hairFiber) pdfLocal(wo, wi core.Vec3) float64 {
+	hair := f.hair
+	sinThetaO := wo.X
+	cosThetaO := safeSqrt(1 - sinThetaO*sinThetaO)
+	phiO := math.Atan2(wo.Z, wo.Y)
+	sinThetaI := wi.X
+	cosThetaI := safeSqrt(1 - sinThetaI*sinThetaI)
+	phiI := math.Atan2(wi.Z, wi.Y)
+
+	gammaO, gammaT := f.gammas(sinThetaO, cosThetaO)
+	apPdf := f.lobePDFs(cosThetaO)
+	phi := phiI - phiO
+
+	pdf := 0.0
+	for p := 0; p < hairMaxLobe; p++ {
+		sinThetaOp, cosThetaOp := hair.tiltedAngles(p, sinThetaO, cosThetaO)
+		pdf += hairMp(cosThetaI, cosThetaOp, sinThetaI, sinThetaOp, hair.v[p]) * apPdf[p] * hairNp(phi, p, hair.s, gammaO, gammaT)
+	}
+	pdf += hairMp(cosThetaI, cosThetaO, sinThetaI, sinThetaO, hair.v[hairMaxLobe]) * apPdf[hairMaxLobe] / (2 * math.Pi)
+	return pdf
+}
+
+// gammas returns the azimuthal angles of the hit offset outside (γo) and refracted inside (γt) the fiber
+func (f *hairFiber) gammas(sinThetaO, cosThetaO float64) (float64, float64) {
+	etap := math.Sqrt(f.hair.Eta*f.hair.Eta-sinThetaO*sinThetaO) / cosThetaO
+	return safeAsin(f.h), safeAsin(f.h / etap)
+}
+
+// transmittance returns the absorption along one pass through the fiber's interior
+func (f *hairFiber) transmittance(sinThetaO, cosThetaO float64) core.Vec3 {
+	sinThetaT := sinThetaO / f.hair.Eta
+	cosThetaT := safeSqrt(1 - sinThetaT*sinThetaT)
+	_, gammaT := f.gammas(sinThetaO, cosThetaO)
+	length := 2 * math.Cos(gammaT) / cosThetaT
+	sigmaA := f.hair.SigmaA
+	return core.NewVec3(math.Exp(-sigmaA.X*length), math.Exp(-sigmaA.Y*length), math.Exp(-sigmaA.Z*length))
+}
+
+// attenuations returns the fraction of light leaving along each lobe
+func (f *hairFiber) attenuations(cosThetaO float64, transmittance core.Vec3) [hairMaxLobe + 1]core.Vec3 {
+	var ap [hairMaxLobe + 1]core.Vec3
+	cosGammaO := safeSqrt(1 - f.h*f.h)
+	fresnel := fresnelDielectric(cosThetaO*cosGammaO, f.hair.Eta)
+	ap[0] = core.NewVec3(fresnel, fresnel, fresnel)
+	ap[1] = transmittance.Multiply((1 - fresnel) * (1 - fresnel))
+	for p := 2; p < hairMaxLobe; p++ {
+		ap[p] = ap[p-1].MultiplyVec(transmittance).Multiply(fresnel)
+	}
+	// The remainder is the geometric series of all longer paths
+	tf := transmittance.Multiply(fresnel)
+	ap[hairMaxLobe] = ap[hairMaxLobe-1].MultiplyVec(tf)
+	ap[hairMaxLobe].X /= 1 - tf.X
+	ap[hairMaxLobe].Y /= 1 - tf.Y
+	ap[hairMaxLobe].Z /= 1 - tf.Z
+	return ap
+}
+
+// lobePDFs returns the probability of sampling each lobe, in proportion to its luminance
+func (f *hairFiber) lobePDFs(cosThetaO float64) [hairMaxLobe + 1]float64 {
+	sinThetaO := safeSqrt(1 - cosThetaO*cosThetaO)
+	ap := f.attenuations(cosThetaO, f.transmittance(sinThetaO, cosThetaO))
+	total := 0.0
+	for _, a := range ap {
+		total += a.Luminance()
+	}
+	var pdfs [hairMaxLobe + 1]float64
+	for p, a := range ap {
+		pdfs[p] = a.Luminance() / total
+	}
+	return pdfs
+}
+
+// tiltedAngles rotates θo by the lobe's shift from the cuticle scales: -2α for R, α for TT, 4α for TRT
+func (h *Hair) tiltedAngles(p int, sinThetaO, cosThetaO float64) (float64, float64) {
+	var sinThetaOp, cosThetaOp float64
+	switch p {
+	case 0:
+		sinThetaOp = sinThetaO*h.cos2kAlpha[1] - cosThetaO*h.sin2kAlpha[1]
+		cosThetaOp = cosThetaO*h.cos2kAlpha[1] + sinThetaO*h.sin2kAlpha[1]
+	case 1:
+		sinThetaOp = sinThetaO*h.cos2kAlpha[0] + cosThetaO*h.sin2kAlpha[0]
+		cosThetaOp = cosThetaO*h.cos2kAlpha[0] - sinThetaO*h.sin2kAlpha[0]
+	case 2:
+		sinThetaOp = sinThetaO*h.cos2kAlpha[2] + cosThetaO*h.sin2kAlpha[2]
+		cosThetaOp = cosThetaO*h.cos2kAlpha[2] - sinThetaO*h.sin2kAlpha[2]
+	default:
+		sinThetaOp, cosThetaOp = sinThetaO, cosThetaO
+	}
+	return sinThetaOp, math.Abs(cosThetaOp)
+}
+
+// hairMp is the longitudinal scattering function, normalized over θi
+func hairMp(cosThetaI, cosThetaO, sinThetaI, sinThetaO, v float64) float64 {
+	a := cosThetaI * cosThetaO / v
+	b := sinThetaI * sinThetaO / v
+	if v <= 0.1 {
+		// Evaluated in log space, where the exponentials would overflow
+		return math.Exp(logI0(a) - b - 1/v + 0.6931 + math.Log(1/(2*v)))
+	}
+	return math.Exp(-b) * besselI0(a) / (math.Sinh(1/v) * 2 * v)
+}
+
+// hairNp is the azimuthal scattering function of lobe p, normalized over φ
+func hairNp(phi float64, p int, s, gammaO, gammaT float64) float64 {
+	dphi := phi - hairPhi(p, gammaO, gammaT)
+	for dphi > math.Pi {
+		dphi -= 2 * math.Pi
+	}
+	for dphi < -math.Pi {
+		dphi += 2 * math.Pi
+	}
+	return trimmedLogistic(dphi, s, -math.Pi, math.Pi)
+}
+
+// hairPhi returns the azimuthal change of a ray leaving the fiber after p internal paths
+func hairPhi(p int, gammaO, gammaT float64) float64 {
+	return 2*float64(p)*gammaT - 2*gammaO + float64(p)*math.Pi
+}
+
+// besselI0 is the modified Bessel function of the first kind, order zero
+func besselI0(x float64) float64 {
+	value := 0.0
+	x2i := 1.0
+	factorial := 1.0
+	pow4 := 1.0
+	for i := 0; i < 10; i++ {
+		if i > 1 {
+			factorial *= float64(i)
+		}
+		value += x2i / (pow4 * factorial * factorial)
+		x2i *= x * x
+		pow4 *= 4
+	}
+	return value
+}
+
+// logI0 returns log(I0(x)), using its asymptotic expansion for large x
+func logI0(x float64) float64 {
+	if x > 12 {
+		return x + 0.5*(-math.Log(2*math.Pi)+math.Log(1/x)+1/(8*x))
+	}
+	return math.Log(besselI0(x))
+}
+
+// logistic is the logistic distribution's density with scale s
+func logistic(x, s float64) float64 {
+	x = math.Abs(x)
+	e := math.Exp(-x / s)
+	return e / (s * (1 + e) * (1 + e))
+}
+
+// logisticCDF is the logistic distribution's cumulative distribution with scale s
+func logisticCDF(x, s float64) float64 {
+	return 1 / (1 + math.Exp(-x/s))
+}
+
+// trimmedLogistic is the logistic density renormalized to [a, b]
+func trimmedLogistic(x, s, a, b float64) float64 {
+	return logistic(x, s) / (logisticCDF(b, s) - logisticCDF(a, s))
+}
+
+// sampleTrimmedLogistic inverts the trimmed logistic's distribution
+func sampleTrimmedLogistic(u, s, a, b float64) float64 {
+	k := logisticCDF(b, s) - logisticCDF(a, s)
+	x := -s * math.Log(1/(u*k+logisticCDF(a, s))-1)
+	return math.Min(math.Max(x, a), b)
+}
+
+// fresnelDielectric returns the unpolarized Fresnel reflectance from outside a dielectric of index eta
+func fresnelDielectric(cosThetaI, eta float64) float64 {
+	cosThetaI = math.Min(math.Max(cosThetaI, -1), 1)
+	if cosThetaI < 0 {
+		eta = 1 / eta
+		cosThetaI = -cosThetaI
+	}
+	sinThetaT := math.Sqrt(math.Max(0, 1-cosThetaI*cosThetaI)) / eta
+	if sinThetaT >= 1 {
+		return 1 // Total internal reflection
+	}
+	cosThetaT := safeSqrt(1 - sinThetaT*sinThetaT)
+	parallel := (eta*cosThetaI - cosThetaT) / (eta*cosThetaI + cosThetaT)
+	perpendicular := (cosThetaI - eta*cosThetaT) / (cosThetaI + eta*cosThetaT)
+	return (parallel*parallel + perpendicular*perpendicular) / 2
+}
+
+// safeSqrt is math.Sqrt clamped at zero for arguments rounded slightly negative
+func safeSqrt(x float64) float64 {
+	return math.Sqrt(math.Max(0, x))
+}
+
+// safeAsin is math.Asin clamped to [-1, 1] for arguments rounded slightly outside
+func safeAsin(x float64) float64 {
+	return math.Asin(math.Min(math.Max(x, -1), 1))
+}
@@ -0,0 +1,124 @@
+package material
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// testFiber returns a hair fiber along x whose offsets run along y, hit at offset h
+func testFiber(hair *Hair, h float64) Material {
+	return hair.AtFiber(core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), h)
+}
+
+func TestHair_WhiteFurnace(t *testing.T) {
+	// A fiber that absorbs nothing scatters all the light arriving from any direction
+	sampler := core.NewSeededSampler(11)
+	for _, roughness := range []float64{0.2, 0.5, 0.9} {
+		hair := NewHair(core.Vec3{}, 1.55, roughness, roughness, 2)
+		for _, h := range []float64{-0.9, -0.3, 0, 0.5, 0.95} {
+			fiber := testFiber(hair, h).(*hairFiber)
+			wo := core.NewVec3(0.3, -0.2, -0.9).Normalize()
+
+			const samples = 100000
+			sum := 0.0
+			for i := 0; i < samples; i++ {
+				u := sampler.Get2D()
+				z := 1 - 2*u.X
+				r := math.Sqrt(math.Max(0, 1-z*z))
+				wi := core.NewVec3(r*math.Cos(2*math.Pi*u.Y), r*math.Sin(2*math.Pi*u.Y), z)
+				sum += fiber.evaluateLocal(wo, wi).Luminance() * 4 * math.Pi
+			}
+			if average := sum / samples; math.Abs(average-1) > 0.05 {
+				t.Errorf("β=%f, h=%f: expected the fiber to scatter all light, got %f", roughness, h, average)
+			}
+		}
+	}
+}
+
+func TestHair_SamplingMatchesPDF(t *testing.T) {
+	// Without absorption the sampling density is exactly the scattering, and Scatter's weights are 1
+	sampler := core.NewSeededSampler(5)
+	hair := NewHair(core.Vec3{}, 1.55, 0.3, 0.3, 2)
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0, 0), Normal: core.NewVec3(0, 0, -1)}
+	rayIn := core.NewRay(core.NewVec3(0, 0, -1), core.NewVec3(-0.2, 0.1, 1).Normalize())
+
+	for i := 0; i < 2000; i++ {
+		fiber := testFiber(hair, 2*sampler.Get1D()-1)
+		scatter, ok := fiber.Scatter(rayIn, hit, sampler)
+		if !ok {
+			continue
+		}
+		direction := scatter.Scattered.Direction
+		pdf, isDelta := fiber.PDF(rayIn.Direction, direction, hit.Normal)
+		if isDelta {
+			t.Fatal("Expected hair not to be a delta material")
+		}
+		if math.Abs(pdf-scatter.PDF) > 1e-9*math.Max(1, pdf) {
+			t.Fatalf("Sampled pdf %f, PDF returned %f", scatter.PDF, pdf)
+		}
+		weight := scatter.Attenuation.Multiply(CosineTerm(fiber, direction, hit.Normal) / scatter.PDF)
+		if math.Abs(weight.Luminance()-1) > 1e-3 {
+			t.Fatalf("Expected a sample weight of 1, got %v", weight)
+		}
+	}
+}
+
+func TestHair_ReflectionSide(t *testing.T) {
+	// An opaque, smooth fiber reflects a ray hitting it off axis toward the side it was hit on
+	hair := NewHair(core.NewVec3(50, 50, 50), 1.55, 0.1, 0.1, 0)
+	fiber := testFiber(hair, 0.5)
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0.5, 0), Normal: core.NewVec3(0, 0, -1)}
+	incoming := core.NewVec3(0, 0, 1)
+
+	// The cross-section's normal at offset 0.5 is 30° from the ray, so the reflection is 60° from it
+	mirror := core.NewVec3(0, math.Sin(math.Pi/3), -math.Cos(math.Pi/3))
+	opposite := core.NewVec3(0, -math.Sin(math.Pi/3), -math.Cos(math.Pi/3))
+	toward := fiber.EvaluateBRDF(incoming, mirror, &hit, Radiance).Luminance()
+	away := fiber.EvaluateBRDF(incoming, opposite, &hit, Radiance).Luminance()
+	if toward <= 10*away {
+		t.Errorf("Expected the reflection toward +y (%f) to dominate the one toward -y (%f)", toward, away)
+	}
+}
+
+func TestHair_Absorption(t *testing.T) {
+	// More melanin makes darker, and eumelanin bluer-absorbing, hair
+	blond := HairAbsorptionFromMelanin(0.3, 0)
+	black := HairAbsorptionFromMelanin(8, 0)
+	if black.X <= blond.X || black.Z <= black.X {
+		t.Errorf("Unexpected absorption: blond %v, black %v", blond, black)
+	}
+
+	// A color's absorption scatters that color in a mass of hair: white absorbs nothing
+	if white := HairAbsorptionFromColor(core.NewVec3(1, 1, 1), 0.3); white.Length() > 1e-12 {
+		t.Errorf("Expected white hair not to absorb, got %v", white)
+	}
+	red := HairAbsorptionFromColor(core.NewVec3(0.8, 0.3, 0.1), 0.3)
+	if !(red.X < red.Y && red.Y < red.Z) {
+		t.Errorf("Expected red hair to absorb blue most, got %v", red)
+	}
+}
+
+func TestHair_OnSurface(t *testing.T) {
+	// Hair on shapes other than curves behaves as a fiber lying in the surface
+	sampler := core.NewSeededSampler(2)
+	hair := NewHair(HairAbsorptionFromMelanin(1.3, 0), 1.55, 0.3, 0.3, 2)
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0, 0), Normal: core.NewVec3(0, 1, 0)}
+	rayIn := core.NewRay(core.NewVec3(0, 1, 1), core.NewVec3(0, -1, -1).Normalize())
+
+	scatter, ok := hair.Scatter(rayIn, hit, sampler)
+	if !ok {
+		t.Fatal("Expected hair to scatter")
+	}
+	pdf, _ := hair.PDF(rayIn.Direction, scatter.Scattered.Direction, hit.Normal)
+	if math.Abs(pdf-scatter.PDF) > 1e-9*math.Max(1, pdf) {
+		t.Errorf("Sampled pdf %f, PDF returned %f", scatter.PDF, pdf)
+	}
+	if CosineTerm(hair, core.NewVec3(0, -1, 0), hit.Normal) != 1 {
+		t.Error("Expected hair to weight directions below the surface by |cos θ|")
+	}
+	if CosineTerm(NewLambertian(core.NewVec3(0.5, 0.5, 0.5)), core.NewVec3(0, -1, 0), hit.Normal) != -1 {
+		t.Error("Expected other materials to keep the signed cosine")
+	}
+}
@@ -1,6 +1,9 @@
 package material
 
 import (
+	"math"
+	"sync"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
@@ -9,6 +12,11 @@ type ImageTexture struct {
 	Width  int
 	Height int
 	Pixels []core.Vec3 // Row-major: Pixels[y*Width + x]
+
+	// Mipmap levels for filtered lookups, each half the size of the one before, built on the
+	// first one
+	mipOnce sync.Once
+	mips    []*ImageTexture
 }
 
 // NewImageTexture creates a new image texture
@@ -53,3 +61,53 @@ func (t *ImageTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
 
 	return t.Pixels[y*t.Width+x]
 }
+
+// EvaluateFiltered averages the texture over about width around uv (in UV units) by looking it
+// up in the mipmap levels whose texels are closest to that width, blending between the two
+func (t *ImageTexture) EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	level := math.Log2(width * float64(max(t.Width, t.Height)))
+	if !(level > 0) {
+		return t.Evaluate(uv, point)
+	}
+	t.mipOnce.Do(t.buildMipmap)
+	if level >= float64(len(t.mips)) {
+		return t.mipLevel(len(t.mips)).Evaluate(uv, point)
+	}
+	lower := int(level)
+	fraction := level - float64(lower)
+	coarse := t.mips[lower].Evaluate(uv, point)
+	return t.mipLevel(lower).Evaluate(uv, point).Multiply(1 - fraction).Add(coarse.Multiply(fraction))
+}
+
+// mipLevel returns mipmap level i, the texture itself at 0
+func (t *ImageTexture) mipLevel(i int) *ImageTexture {
+	if i == 0 {
+		return t
+	}
+	return t.mips[i-1]
+}
+
+// buildMipmap halves the image down to a single texel, each texel the average of the (up to) 2x2
+// it covers in the level before
+func (t *ImageTexture) buildMipmap() {
+	level := t
+	for level.Width > 1 || level.Height > 1 {
+		width, height := (level.Width+1)/2, (level.Height+1)/2
+		pixels := make([]core.Vec3, width*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				var sum core.Vec3
+				n := 0
+				for sy := 2 * y; sy < min(2*y+2, level.Height); sy++ {
+					for sx := 2 * x; sx < min(2*x+2, level.Width); sx++ {
+						sum = sum.Add(level.Pixels[sy*level.Width+sx])
+						n++
+					}
+				}
+				pixels[y*width+x] = sum.Multiply(1 / float64(n))
+			}
+		}
+		level = NewImageTexture(width, height, pixels)
+		t.mips = append(t.mips, level)
+	}
+}
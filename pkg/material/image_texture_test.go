@@ -1,6 +1,7 @@
 package material
 
 import (
+	"math"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -133,3 +134,39 @@ func TestSolidColor(t *testing.T) {
 		}
 	}
 }
+
+func TestImageTextureEvaluateFiltered(t *testing.T) {
+	// An 8x4 checkerboard of single texels: filtering over wider footprints blends towards gray
+	pixels := make([]core.Vec3, 8*4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			if (x+y)%2 == 0 {
+				pixels[y*8+x] = core.NewVec3(1, 1, 1)
+			}
+		}
+	}
+	texture := NewImageTexture(8, 4, pixels)
+	uv := core.NewVec2(0.1, 0.9)
+
+	tests := []struct {
+		width float64
+		want  float64
+	}{
+		{0, 1},                            // A point: the texel itself
+		{1.0 / 16, 1},                     // Smaller than a texel
+		{2.0 / 8, 0.5},                    // Two texels: level 1 averages the checks
+		{1.5 / 8, 1 - 0.5*math.Log2(1.5)}, // Between levels 0 and 1, blended by the log of the width
+		{100, 0.5},                        // Beyond the coarsest level
+	}
+	for _, tt := range tests {
+		if got := texture.EvaluateFiltered(uv, core.Vec3{}, tt.width); math.Abs(got.X-tt.want) > 1e-9 {
+			t.Errorf("Width %v: expected %v, got %v", tt.width, tt.want, got.X)
+		}
+	}
+
+	// Tiling shrinks the texture, so its footprint covers more of it
+	tiled := NewUVMappedTexture(texture, core.NewVec2(2, 2), core.Vec2{})
+	if got := EvaluateFiltered(tiled, core.NewVec2(0.05, 0.95), core.Vec3{}, 1.0/8); math.Abs(got.X-0.5) > 1e-9 {
+		t.Errorf("Expected the tiled texture filtered to gray, got %v", got)
+	}
+}
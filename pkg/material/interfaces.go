@@ -1,6 +1,8 @@
 package material
 
 import (
+	"math"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
@@ -31,14 +33,83 @@ type Emitter interface {
 	Emit(rayIn core.Ray, hit *SurfaceInteraction) core.Vec3
 }
 
+// AlphaMasked is implemented by materials with holes, such as Cutout. Intersection tests call
+// Opaque on each hit and continue the ray past the hits where it returns false.
+type AlphaMasked interface {
+	Material
+	Opaque(hit *SurfaceInteraction) bool
+}
+
+// IsOpaque reports whether a hit is on a solid part of its surface, that is, unless its material
+// is AlphaMasked and transparent there
+func IsOpaque(hit *SurfaceInteraction) bool {
+	if masked, ok := hit.Material.(AlphaMasked); ok {
+		return masked.Opaque(hit)
+	}
+	return true
+}
+
+// Translucent is implemented by non-specular materials that scatter light through the surface
+// as well as off it, such as hair fibers. Integrators weight their directions by |cos θ| to the
+// shading normal (see CosineTerm) instead of discarding the directions below it.
+type Translucent interface {
+	Material
+	Translucent()
+}
+
+// FiberMaterial is implemented by materials that model a fiber rather than a surface, such as
+// Hair. Curves bind each hit's fiber with AtFiber: the direction along the fiber, the direction
+// across it in which the hit is offset, and the offset in [-1, 1] fiber radii.
+type FiberMaterial interface {
+	Material
+	AtFiber(tangent, offsetDirection core.Vec3, offset float64) Material
+}
+
+// MediumMaterial is implemented by the phase functions of media that can glow, such as
+// HenyeyGreenstein. Volumes that emit light bind the radiance emitted at each collision with
+// AtEmission.
+type MediumMaterial interface {
+	Material
+	AtEmission(radiance core.Vec3) Material
+}
+
+// MediumEmitter is implemented by the collisions of a glowing medium (see MediumMaterial). No
+// light represents them, so light sampling never finds what they emit: integrators count it in
+// full where a path collides, rather than sharing it with light sampling by MIS.
+type MediumEmitter interface {
+	Emitter
+	MediumEmitter()
+}
+
+// CosineTerm returns the cosine weighting light scattered by a material in a direction
+// It is negative below the surface, except for Translucent materials, which use |cos θ|.
+func CosineTerm(m Material, direction, normal core.Vec3) float64 {
+	cosine := direction.Dot(normal)
+	if _, translucent := m.(Translucent); translucent {
+		return math.Abs(cosine)
+	}
+	return cosine
+}
+
 // ScatterResult contains the result of material scattering
 type ScatterResult struct {
 	Incoming    core.Ray  // The incoming ray
 	Scattered   core.Ray  // The scattered ray
 	Attenuation core.Vec3 // Color attenuation
 	PDF         float64   // Probability density function (0 for specular materials)
+	Lobe        Lobe      // Kind of scattering sampled, for integrators' per-lobe depth limits
 }
 
+// Lobe classifies a scattering event, so integrators can limit the bounces of each kind
+// separately: a glass scene needs long chains of transmission but few diffuse bounces
+type Lobe int
+
+const (
+	LobeDiffuse      Lobe = iota // Diffuse reflection, and scattering that isn't classified, such as phase functions
+	LobeGlossy                   // Glossy and mirror reflection
+	LobeTransmission             // Light passing through the surface, such as refraction
+)
+
 // IsSpecular returns true if this is specular scattering (no PDF)
 func (s ScatterResult) IsSpecular() bool {
 	return s.PDF <= 0
@@ -46,14 +117,33 @@ func (s ScatterResult) IsSpecular() bool {
 
 // SurfaceInteraction contains information about a ray-object intersection
 type SurfaceInteraction struct {
-	Point     core.Vec3 // Point of intersection
-	Normal    core.Vec3 // Surface normal at intersection
-	T         float64   // Parameter t along the ray
-	FrontFace bool      // Whether ray hit the front face
-	Material  Material  // Material of the hit object
-	UV        core.Vec2 // Texture coordinates
+	Point      core.Vec3 // Point of intersection
+	Normal     core.Vec3 // Surface normal at intersection
+	T          float64   // Parameter t along the ray
+	FrontFace  bool      // Whether ray hit the front face
+	Material   Material  // Material of the hit object
+	UV         core.Vec2 // Texture coordinates
+	HiddenFrom RayKind   // Kinds of rays that pass through the hit object (see geometry.NewVisibilityShape)
+	Object     any       // The scene shape hit, a geometry.Shape set by the BVH holding it (nil from other intersectors)
+
+	// How the point moves with the texture coordinates, set by shapes that have them; zero otherwise
+	DpDu, DpDv core.Vec3
+
+	// How the point and texture coordinates move from pixel to pixel, from the ray's differentials
+	// (see SetDifferentials); zero for rays without them
+	DpDx, DpDy   core.Vec3
+	DUVDx, DUVDy core.Vec2
 }
 
+// RayKind is a set of kinds of rays, by the role they play in rendering
+type RayKind uint8
+
+const (
+	CameraRays   RayKind = 1 << iota // Rays from the camera to the first surface it sees
+	IndirectRays                     // Rays continuing a path after a bounce, including light paths
+	ShadowRays                       // Visibility tests between a surface and a light or another path's vertex
+)
+
 // SetFaceNormal sets the normal vector and determines front/back face
 func (h *SurfaceInteraction) SetFaceNormal(ray core.Ray, outwardNormal core.Vec3) {
 	h.FrontFace = ray.Direction.Dot(outwardNormal) < 0
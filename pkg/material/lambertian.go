@@ -35,7 +35,7 @@ func (l *Lambertian) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler cor
 	pdf := cosTheta / math.Pi
 
 	// Sample texture at UV coordinates to get albedo
-	albedo := l.Albedo.Evaluate(hit.UV, hit.Point)
+	albedo := hit.EvaluateTexture(l.Albedo)
 
 	// BRDF: albedo / π (proper energy conservation)
 	attenuation := albedo.Multiply(1.0 / math.Pi)
@@ -57,7 +57,7 @@ func (l *Lambertian) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *Surfa
 	}
 
 	// Sample texture at UV coordinates to get albedo
-	albedo := l.Albedo.Evaluate(hit.UV, hit.Point)
+	albedo := hit.EvaluateTexture(l.Albedo)
 	return albedo.Multiply(1.0 / math.Pi)
 }
 
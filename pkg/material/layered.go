@@ -70,6 +70,7 @@ func (l *Layered) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.S
 		Scattered:   innerResult.Scattered,
 		Attenuation: combinedAttenuation,
 		PDF:         innerResult.PDF,
+		Lobe:        innerResult.Lobe,
 	}, true
 }
 
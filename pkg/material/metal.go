@@ -1,6 +1,8 @@
 package material
 
 import (
+	"math"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
@@ -8,6 +10,9 @@ import (
 type Metal struct {
 	Albedo   ColorSource // Metal color (can be solid or textured)
 	Fuzzness float64     // 0.0 = perfect mirror, 1.0 = very fuzzy
+
+	// Optional texture whose luminance replaces Fuzzness, varying the roughness over the surface
+	FuzzTexture ColorSource
 }
 
 // NewMetal creates a new metal material with solid color (backward compatibility)
@@ -34,30 +39,42 @@ func NewTexturedMetal(albedoTexture ColorSource, fuzzness float64) *Metal {
 	return &Metal{Albedo: albedoTexture, Fuzzness: fuzzness}
 }
 
+// fuzzness returns the fuzziness at a hit, from FuzzTexture if there is one
+func (m *Metal) fuzzness(hit SurfaceInteraction) float64 {
+	if m.FuzzTexture == nil {
+		return m.Fuzzness
+	}
+	return math.Max(0, math.Min(1, hit.EvaluateTexture(m.FuzzTexture).Luminance()))
+}
+
 // Scatter implements the Material interface for metal scattering
 func (m *Metal) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
 	// Calculate perfect reflection direction
 	reflected := reflect(rayIn.Direction.Normalize(), hit.Normal)
 
-	// Add fuzziness by perturbing the reflection direction
-	if m.Fuzzness > 0 {
-		perturbation := core.SamplePointInUnitSphere(sampler.Get3D()).Multiply(m.Fuzzness)
-		reflected = reflected.Add(perturbation)
-	}
-
 	scattered := core.Ray{Origin: hit.Point, Direction: reflected}
 
+	// Add fuzziness by perturbing the reflection direction; only a mirror carries the ray's
+	// differentials on
+	if fuzzness := m.fuzzness(hit); fuzzness > 0 {
+		perturbation := core.SamplePointInUnitSphere(sampler.Get3D()).Multiply(fuzzness)
+		scattered.Direction = reflected.Add(perturbation)
+	} else {
+		scattered.Differential = hit.specularDifferential(rayIn, func(d core.Vec3) core.Vec3 { return reflect(d, hit.Normal) })
+	}
+
 	// Only scatter if the ray is above the surface (not absorbed)
 	scatters := scattered.Direction.Dot(hit.Normal) > 0
 
 	// Sample texture at UV coordinates to get albedo
-	albedo := m.Albedo.Evaluate(hit.UV, hit.Point)
+	albedo := hit.EvaluateTexture(m.Albedo)
 
 	return ScatterResult{
 		Incoming:    rayIn,
 		Scattered:   scattered,
 		Attenuation: albedo, // No π factor for specular
 		PDF:         0,      // Specular materials have no PDF
+		Lobe:        LobeGlossy,
 	}, scatters
 }
 
@@ -69,7 +86,7 @@ func (m *Metal) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInt
 	// Check if outgoing direction matches perfect reflection (within tolerance)
 	if outgoingDir.Subtract(reflected).Length() < 0.001 {
 		// Sample texture at UV coordinates to get albedo
-		albedo := m.Albedo.Evaluate(hit.UV, hit.Point)
+		albedo := hit.EvaluateTexture(m.Albedo)
 		return albedo // Delta function contribution
 	}
 
@@ -266,3 +266,17 @@ func TestReflectFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestMetal_FuzzTexture(t *testing.T) {
+	// A texture of zero fuzz overrides the metal's own
+	metal := NewMetal(core.NewVec3(0.8, 0.8, 0.8), 1)
+	metal.FuzzTexture = NewSolidColor(core.Vec3{})
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+
+	rayIn := core.NewRay(core.NewVec3(-1, 1, 0), core.NewVec3(1, -1, 0))
+	hit := SurfaceInteraction{Point: core.Vec3{}, Normal: core.NewVec3(0, 1, 0)}
+	scatter, ok := metal.Scatter(rayIn, hit, sampler)
+	if want := core.NewVec3(1, 1, 0).Normalize(); !ok || scatter.Scattered.Direction.Normalize().Subtract(want).Length() > 1e-9 {
+		t.Errorf("Expected a mirror reflection %v, got %v", want, scatter.Scattered.Direction.Normalize())
+	}
+}
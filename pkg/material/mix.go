@@ -11,6 +11,12 @@ type Mix struct {
 	Material1 Material
 	Material2 Material
 	Ratio     float64 // 0.0 = all material1, 1.0 = all material2
+
+	// Optional texture whose luminance replaces Ratio in choosing the material that scatters at a
+	// hit, such as a metallic map choosing between a diffuse base and a metal. PDF has no hit and
+	// keeps to Ratio, which is exact only when the density doesn't depend on the ratio: when
+	// Material2 is specular, as a metal is.
+	RatioTexture ColorSource
 }
 
 // NewMix creates a new mix material
@@ -28,27 +34,77 @@ func NewMix(material1, material2 Material, ratio float64) *Mix {
 // Scatter implements the Material interface for mix material
 func (m *Mix) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
 	// Choose material based on ratio
-	if sampler.Get1D() < m.Ratio {
-		return m.Material2.Scatter(rayIn, hit, sampler)
+	ratio := m.Ratio
+	if m.RatioTexture != nil {
+		ratio = math.Max(0, math.Min(1, hit.EvaluateTexture(m.RatioTexture).Luminance()))
+	}
+	var scatter ScatterResult
+	var scatters bool
+	if sampler.Get1D() < ratio {
+		scatter, scatters = m.Material2.Scatter(rayIn, hit, sampler)
 	} else {
-		return m.Material1.Scatter(rayIn, hit, sampler)
+		scatter, scatters = m.Material1.Scatter(rayIn, hit, sampler)
 	}
+	if !scatters || scatter.IsSpecular() {
+		return scatter, scatters // A delta direction only the chosen material scatters into
+	}
+
+	// Either non-delta material could have sampled the direction, so weight it by the mix's BRDF
+	// and PDF, which integrators also use for light samples and MIS
+	direction := scatter.Scattered.Direction.Normalize()
+	scatter.Attenuation = m.EvaluateBRDF(rayIn.Direction, direction, &hit, Radiance)
+	scatter.PDF, _ = m.PDF(rayIn.Direction, direction, hit.Normal)
+	return scatter, scatter.PDF > 0
 }
 
 // EvaluateBRDF evaluates the BRDF for specific incoming/outgoing directions
+// Like Layered, it's the BRDF of the materials that aren't delta functions, given Scatter chose
+// one of them: integrators only light the vertices of non-specular samples.
 func (m *Mix) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
-	// Combine BRDFs from both materials with appropriate weights
-	brdf1 := m.Material1.EvaluateBRDF(incomingDir, outgoingDir, hit, mode)
-	brdf2 := m.Material2.EvaluateBRDF(incomingDir, outgoingDir, hit, mode)
-	return brdf1.Multiply(1.0 - m.Ratio).Add(brdf2.Multiply(m.Ratio))
+	weight1, weight2 := m.nonDeltaWeights(incomingDir, outgoingDir, hit.Normal)
+	var brdf core.Vec3
+	if weight1 > 0 {
+		brdf = brdf.Add(m.Material1.EvaluateBRDF(incomingDir, outgoingDir, hit, mode).Multiply(weight1))
+	}
+	if weight2 > 0 {
+		brdf = brdf.Add(m.Material2.EvaluateBRDF(incomingDir, outgoingDir, hit, mode).Multiply(weight2))
+	}
+	return brdf
 }
 
 // PDF calculates the probability density function for specific incoming/outgoing directions
+// It's the density of the directions Scatter samples when it chooses a non-delta material.
 func (m *Mix) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
-	// Combine PDFs from both materials with appropriate weights
+	weight1, weight2 := m.nonDeltaWeights(incomingDir, outgoingDir, normal)
+	if weight1 == 0 && weight2 == 0 {
+		return 0, true // Both materials are delta functions
+	}
 	// Treat mix as finite PDF material even if it contains delta components
-	pdf1, _ := m.Material1.PDF(incomingDir, outgoingDir, normal)
-	pdf2, _ := m.Material2.PDF(incomingDir, outgoingDir, normal)
-	combinedPDF := pdf1*(1.0-m.Ratio) + pdf2*m.Ratio
-	return combinedPDF, false // Always treat mix as non-delta
+	pdf := 0.0
+	if weight1 > 0 {
+		pdf1, _ := m.Material1.PDF(incomingDir, outgoingDir, normal)
+		pdf += pdf1 * weight1
+	}
+	if weight2 > 0 {
+		pdf2, _ := m.Material2.PDF(incomingDir, outgoingDir, normal)
+		pdf += pdf2 * weight2
+	}
+	return pdf, false
+}
+
+// nonDeltaWeights returns the probabilities of Scatter choosing each material, given it chooses
+// one that isn't a delta function (both 0 if neither is)
+func (m *Mix) nonDeltaWeights(incomingDir, outgoingDir, normal core.Vec3) (float64, float64) {
+	weight1, weight2 := 1-m.Ratio, m.Ratio
+	if _, isDelta := m.Material1.PDF(incomingDir, outgoingDir, normal); isDelta {
+		weight1 = 0
+	}
+	if _, isDelta := m.Material2.PDF(incomingDir, outgoingDir, normal); isDelta {
+		weight2 = 0
+	}
+	total := weight1 + weight2
+	if total == 0 {
+		return 0, 0
+	}
+	return weight1 / total, weight2 / total
 }
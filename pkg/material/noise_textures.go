@@ -0,0 +1,192 @@
+package material
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// NoisePattern selects the pattern a NoiseTexture draws
+type NoisePattern string
+
+const (
+	NoisePerlin     NoisePattern = "noise"      // Smooth Perlin noise
+	NoiseFBm        NoisePattern = "fbm"        // Octaves of noise summed: fractal Brownian motion
+	NoiseTurbulence NoisePattern = "turbulence" // Octaves of the noise's absolute value, billowy
+	NoiseMarble     NoisePattern = "marble"     // Bands along x, bent by turbulence
+	NoiseWood       NoisePattern = "wood"       // Rings around the y axis, warped by fBm
+)
+
+// NoisePatterns lists the patterns NewNoiseTexture accepts
+var NoisePatterns = []NoisePattern{NoisePerlin, NoiseFBm, NoiseTurbulence, NoiseMarble, NoiseWood}
+
+// NoiseTexture is a solid texture: it blends between two textures by a pattern of noise evaluated
+// at the surface's position in space rather than its UV coordinates, so it needs no UVs and runs
+// through objects as if they were carved from a block. Its luminance can drive anything a texture
+// does, such as a metal's roughness or a mesh's displacement.
+type NoiseTexture struct {
+	Pattern NoisePattern
+	Scale   float64     // Frequency of the pattern: features are about 1/Scale across
+	Octaves int         // Octaves of noise summed by every pattern but noise
+	Gain    float64     // Amplitude of each octave relative to the one before
+	Low     ColorSource // Color where the pattern is 0
+	High    ColorSource // Color where the pattern is 1
+}
+
+// NewNoiseTexture creates a solid noise texture blending from low to high. Octaves default to 6
+// and the gain to 0.5 when they're zero.
+func NewNoiseTexture(pattern NoisePattern, scale float64, octaves int, gain float64, low, high ColorSource) (*NoiseTexture, error) {
+	known := false
+	for _, p := range NoisePatterns {
+		known = known || p == pattern
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown noise pattern %q", pattern)
+	}
+	if scale <= 0 || octaves < 0 || gain < 0 || gain >= 1 {
+		return nil, fmt.Errorf("noise needs scale > 0, octaves >= 0 and gain in [0, 1)")
+	}
+	if octaves == 0 {
+		octaves = 6
+	}
+	if gain == 0 {
+		gain = 0.5
+	}
+	return &NoiseTexture{Pattern: pattern, Scale: scale, Octaves: octaves, Gain: gain, Low: low, High: high}, nil
+}
+
+// Evaluate blends the two textures by the pattern's value at the point
+func (t *NoiseTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	v := t.Value(point)
+	return t.Low.Evaluate(uv, point).Multiply(1 - v).Add(t.High.Evaluate(uv, point).Multiply(v))
+}
+
+// Value returns the pattern at a point, in [0, 1]
+func (t *NoiseTexture) Value(point core.Vec3) float64 {
+	p := point.Multiply(t.Scale)
+	var v float64
+	switch t.Pattern {
+	case NoisePerlin:
+		v = 0.5 * (1 + Perlin(p))
+	case NoiseFBm:
+		v = 0.5 * (1 + FBm(p, t.Octaves, t.Gain))
+	case NoiseTurbulence:
+		v = Turbulence(p, t.Octaves, t.Gain)
+	case NoiseMarble:
+		v = 0.5 * (1 + math.Sin(p.X+5*Turbulence(p, t.Octaves, t.Gain)))
+	case NoiseWood:
+		rings := math.Hypot(p.X, p.Z) + 2*FBm(p, t.Octaves, t.Gain)
+		v = rings - math.Floor(rings)
+	}
+	return math.Max(0, math.Min(1, v))
+}
+
+// FBm sums octaves of Perlin noise, each at twice the frequency and gain times the amplitude of
+// the one before, normalized to about [-1, 1]
+func FBm(p core.Vec3, octaves int, gain float64) float64 {
+	var sum, amplitude, total float64 = 0, 1, 0
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * Perlin(p)
+		total += amplitude
+		p = p.Multiply(2)
+		amplitude *= gain
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// Turbulence sums octaves of the absolute value of Perlin noise as FBm does, giving creases where
+// the noise crosses zero, normalized to about [0, 1]
+func Turbulence(p core.Vec3, octaves int, gain float64) float64 {
+	var sum, amplitude, total float64 = 0, 1, 0
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * math.Abs(Perlin(p))
+		total += amplitude
+		p = p.Multiply(2)
+		amplitude *= gain
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// Perlin returns Ken Perlin's improved gradient noise at a point: a smooth function in about
+// [-1, 1] that is zero at integer lattice points and varies over about a unit. It's deterministic,
+// using Perlin's reference permutation.
+func Perlin(p core.Vec3) float64 {
+	fx, fy, fz := math.Floor(p.X), math.Floor(p.Y), math.Floor(p.Z)
+	x, y, z := p.X-fx, p.Y-fy, p.Z-fz
+	xi, yi, zi := int(fx)&255, int(fy)&255, int(fz)&255
+	u, v, w := perlinFade(x), perlinFade(y), perlinFade(z)
+
+	a := int(perlinPermutation[xi]) + yi
+	aa, ab := int(perlinPermutation[a&255])+zi, int(perlinPermutation[(a+1)&255])+zi
+	b := int(perlinPermutation[(xi+1)&255]) + yi
+	ba, bb := int(perlinPermutation[b&255])+zi, int(perlinPermutation[(b+1)&255])+zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, perlinGrad(aa, x, y, z), perlinGrad(ba, x-1, y, z)),
+			lerp(u, perlinGrad(ab, x, y-1, z), perlinGrad(bb, x-1, y-1, z))),
+		lerp(v,
+			lerp(u, perlinGrad(aa+1, x, y, z-1), perlinGrad(ba+1, x-1, y, z-1)),
+			lerp(u, perlinGrad(ab+1, x, y-1, z-1), perlinGrad(bb+1, x-1, y-1, z-1))))
+}
+
+// perlinFade is Perlin's quintic 6t⁵ - 15t⁴ + 10t³, whose first and second derivatives vanish at 0 and 1
+func perlinFade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// lerp interpolates linearly from a at t = 0 to b at t = 1
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// perlinGrad returns the dot product of the offset (x, y, z) with one of twelve edge gradients
+// chosen by the lattice point's hash
+func perlinGrad(hash int, x, y, z float64) float64 {
+	h := perlinPermutation[hash&255] & 15
+	u, v := y, z
+	if h < 8 {
+		u = x
+	}
+	if h >= 4 {
+		if h == 12 || h == 14 {
+			v = x
+		} else {
+			v = y
+		}
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// perlinPermutation is the permutation of Perlin's reference implementation
+var perlinPermutation = [256]uint8{
+	151, 160, 137, 91, 90, 15, 131, 13, 201, 95, 96, 53, 194, 233, 7, 225,
+	140, 36, 103, 30, 69, 142, 8, 99, 37, 240, 21, 10, 23, 190, 6, 148,
+	247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32,
+	57, 177, 33, 88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175,
+	74, 165, 71, 134, 139, 48, 27, 166, 77, 146, 158, 231, 83, 111, 229, 122,
+	60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244, 102, 143, 54,
+	65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169,
+	200, 196, 135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64,
+	52, 217, 226, 250, 124, 123, 5, 202, 38, 147, 118, 126, 255, 82, 85, 212,
+	207, 206, 59, 227, 47, 16, 58, 17, 182, 189, 28, 42, 223, 183, 170, 213,
+	119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43, 172, 9,
+	129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104,
+	218, 246, 97, 228, 251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241,
+	81, 51, 145, 235, 249, 14, 239, 107, 49, 192, 214, 31, 181, 199, 106, 157,
+	184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254, 138, 236, 205, 93,
+	222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180,
+}
@@ -0,0 +1,68 @@
+package material
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestPerlin(t *testing.T) {
+	// Zero at lattice points, bounded and continuous elsewhere
+	for _, p := range []core.Vec3{{}, core.NewVec3(3, -2, 7), core.NewVec3(-100, 255, 256)} {
+		if v := Perlin(p); v != 0 {
+			t.Errorf("Expected 0 at lattice point %v, got %f", p, v)
+		}
+	}
+	varies := false
+	for i := 0; i < 1000; i++ {
+		p := core.NewVec3(float64(i)*0.137, float64(i)*0.071-20, float64(i)*0.029)
+		v := Perlin(p)
+		if v < -1 || v > 1 {
+			t.Fatalf("Noise at %v is %f, outside [-1, 1]", p, v)
+		}
+		if step := Perlin(p.Add(core.NewVec3(1e-6, 0, 0))) - v; math.Abs(step) > 1e-4 {
+			t.Fatalf("Noise jumps by %f at %v", step, p)
+		}
+		varies = varies || math.Abs(v) > 0.1
+	}
+	if !varies {
+		t.Error("Expected noise to vary")
+	}
+}
+
+func TestNoiseTexture_Patterns(t *testing.T) {
+	black, white := NewSolidColor(core.Vec3{}), NewSolidColor(core.NewVec3(1, 1, 1))
+	for _, pattern := range NoisePatterns {
+		texture, err := NewNoiseTexture(pattern, 2, 0, 0, black, white)
+		if err != nil {
+			t.Fatalf("%s: %v", pattern, err)
+		}
+		lo, hi := 1.0, 0.0
+		for i := 0; i < 500; i++ {
+			p := core.NewVec3(float64(i)*0.0731, math.Sin(float64(i)), float64(i%17)*0.11)
+			v := texture.Value(p)
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+			// The pattern blends the colors, and doesn't depend on UVs
+			if got := texture.Evaluate(core.NewVec2(0.3, 0.9), p); math.Abs(got.Y-v) > 1e-12 {
+				t.Fatalf("%s: expected gray %f at %v, got %v", pattern, v, p, got)
+			}
+		}
+		if lo < 0 || hi > 1 || hi-lo < 0.2 {
+			t.Errorf("%s: expected values spread over [0, 1], got [%f, %f]", pattern, lo, hi)
+		}
+	}
+}
+
+func TestNewNoiseTexture_Errors(t *testing.T) {
+	white := NewSolidColor(core.NewVec3(1, 1, 1))
+	if _, err := NewNoiseTexture("clouds", 1, 0, 0, white, white); err == nil {
+		t.Error("Expected an error for an unknown pattern")
+	}
+	if _, err := NewNoiseTexture(NoiseFBm, 0, 0, 0, white, white); err == nil {
+		t.Error("Expected an error for a zero scale")
+	}
+	if texture, err := NewNoiseTexture(NoiseWood, 1, 0, 0, white, white); err != nil || texture.Octaves != 6 || texture.Gain != 0.5 {
+		t.Errorf("Expected 6 octaves with gain 0.5 by default, got %+v (%v)", texture, err)
+	}
+}
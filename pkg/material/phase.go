@@ -0,0 +1,108 @@
+package material
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// HenyeyGreenstein scatters light the way the particles of a medium such as smoke or cloud do,
+// by the Henyey-Greenstein phase function: g > 0 scatters forward, g < 0 backward and g = 0 in
+// every direction alike. Albedo is the share of the light a particle scatters rather than
+// absorbs. It is the material of the collisions geometry.Volume reports, which have no surface:
+// their normal is arbitrary, and the phase function doesn't depend on it.
+type HenyeyGreenstein struct {
+	Albedo core.Vec3
+	G      float64 // Mean cosine of the scattering angle, in (-1, 1)
+}
+
+// NewHenyeyGreenstein creates a phase function material
+func NewHenyeyGreenstein(albedo core.Vec3, g float64) *HenyeyGreenstein {
+	return &HenyeyGreenstein{Albedo: albedo, G: math.Max(-0.99, math.Min(0.99, g))}
+}
+
+// Scatter implements the Material interface
+func (h *HenyeyGreenstein) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
+	forward := rayIn.Direction.Normalize()
+	u := sampler.Get2D()
+
+	// Invert the phase function's CDF over the cosine to the forward direction
+	var cosTheta float64
+	if math.Abs(h.G) < 1e-3 {
+		cosTheta = 1 - 2*u.X
+	} else {
+		s := (1 - h.G*h.G) / (1 - h.G + 2*h.G*u.X)
+		cosTheta = (1 + h.G*h.G - s*s) / (2 * h.G)
+	}
+	cosTheta = math.Max(-1, math.Min(1, cosTheta))
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	phi := 2 * math.Pi * u.Y
+
+	var axis core.Vec3
+	if math.Abs(forward.X) > 0.1 {
+		axis = core.NewVec3(0, 1, 0)
+	} else {
+		axis = core.NewVec3(1, 0, 0)
+	}
+	tangent := axis.Cross(forward).Normalize()
+	bitangent := forward.Cross(tangent)
+	direction := tangent.Multiply(sinTheta * math.Cos(phi)).Add(bitangent.Multiply(sinTheta * math.Sin(phi))).Add(forward.Multiply(cosTheta))
+
+	return ScatterResult{
+		Incoming:    rayIn,
+		Scattered:   core.NewRay(hit.Point, direction),
+		Attenuation: h.EvaluateBRDF(rayIn.Direction, direction, &hit, Radiance),
+		PDF:         henyeyGreenstein(cosTheta, h.G),
+	}, true
+}
+
+// EvaluateBRDF implements the Material interface
+// Integrators weight the BSDF by |cos θ| to the normal, which a phase function doesn't have, so
+// it is divided out here, as for hair fibers.
+func (h *HenyeyGreenstein) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
+	outgoing := outgoingDir.Normalize()
+	cosine := math.Abs(outgoing.Dot(hit.Normal))
+	if cosine < 1e-6 {
+		return core.Vec3{}
+	}
+	phase := henyeyGreenstein(incomingDir.Normalize().Dot(outgoing), h.G)
+	return h.Albedo.Multiply(phase / cosine)
+}
+
+// PDF implements the Material interface
+func (h *HenyeyGreenstein) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
+	return henyeyGreenstein(incomingDir.Normalize().Dot(outgoingDir.Normalize()), h.G), false
+}
+
+// Translucent implements the Translucent interface
+func (h *HenyeyGreenstein) Translucent() {}
+
+// AtEmission implements MediumMaterial - binds the radiance a glowing medium emits at a collision.
+// A medium emits where it absorbs, so a collision emits the absorbed share, 1 - albedo, of the
+// radiance: a medium that only absorbs shows the full radiance once it is thick enough.
+func (h *HenyeyGreenstein) AtEmission(radiance core.Vec3) Material {
+	absorbed := core.NewVec3(math.Max(0, 1-h.Albedo.X), math.Max(0, 1-h.Albedo.Y), math.Max(0, 1-h.Albedo.Z))
+	return &glowingCollision{HenyeyGreenstein: h, emission: radiance.MultiplyVec(absorbed)}
+}
+
+// glowingCollision is a collision in a glowing medium: it scatters by the medium's phase function
+// and emits light in every direction alike
+type glowingCollision struct {
+	*HenyeyGreenstein
+	emission core.Vec3
+}
+
+// Emit implements the Emitter interface
+func (c *glowingCollision) Emit(rayIn core.Ray, hit *SurfaceInteraction) core.Vec3 {
+	return c.emission
+}
+
+// MediumEmitter implements the MediumEmitter interface
+func (c *glowingCollision) MediumEmitter() {}
+
+// henyeyGreenstein returns the phase function's density for scattering at an angle whose cosine
+// to the forward direction is cosTheta
+func henyeyGreenstein(cosTheta, g float64) float64 {
+	denominator := 1 + g*g - 2*g*cosTheta
+	return (1 - g*g) / (4 * math.Pi * denominator * math.Sqrt(denominator))
+}
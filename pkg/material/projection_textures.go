@@ -0,0 +1,148 @@
+package material
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// OrientedColorSource is implemented by textures that depend on which way the surface faces as
+// well as where it is, such as TriplanarTexture
+type OrientedColorSource interface {
+	ColorSource
+	EvaluateOriented(uv core.Vec2, point, normal core.Vec3) core.Vec3
+}
+
+// EvaluateOriented returns a texture's color at a surface point with the given normal, passing
+// the normal on to textures that are OrientedColorSources. Materials look their textures up with
+// it (see SurfaceInteraction.EvaluateTexture) rather than with Evaluate.
+func EvaluateOriented(source ColorSource, uv core.Vec2, point, normal core.Vec3) core.Vec3 {
+	if oriented, ok := source.(OrientedColorSource); ok {
+		return oriented.EvaluateOriented(uv, point, normal)
+	}
+	return source.Evaluate(uv, point)
+}
+
+// Projection maps points in space to texture coordinates, for surfaces without UVs of their own
+type Projection string
+
+const (
+	ProjectPlanar      Projection = "planar"      // Straight along the axis, onto the plane across it
+	ProjectSpherical   Projection = "spherical"   // Longitude and latitude about the center, poles on the axis
+	ProjectCylindrical Projection = "cylindrical" // Angle around the axis, and height along it
+)
+
+// Projections lists the projections NewProjectedTexture accepts
+var Projections = []Projection{ProjectPlanar, ProjectSpherical, ProjectCylindrical}
+
+// ProjectedTexture looks a texture up at UV coordinates projected from the point in space,
+// ignoring the surface's own UVs
+type ProjectedTexture struct {
+	Texture    ColorSource
+	Projection Projection
+	Center     core.Vec3
+	Scale      float64 // World distance one repeat of the texture spans (planar, and cylindrical along the axis)
+
+	u, v, w core.Vec3 // Orthonormal frame, w along the axis
+}
+
+// NewProjectedTexture creates a texture projected about a center and axis. Scale defaults to 1.
+func NewProjectedTexture(texture ColorSource, projection Projection, center, axis core.Vec3, scale float64) (*ProjectedTexture, error) {
+	known := false
+	for _, p := range Projections {
+		known = known || p == projection
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown projection %q", projection)
+	}
+	if axis.Length() == 0 || scale < 0 {
+		return nil, fmt.Errorf("projection needs a nonzero axis and scale >= 0")
+	}
+	if scale == 0 {
+		scale = 1
+	}
+	u, v, w := projectionFrame(axis.Normalize())
+	return &ProjectedTexture{Texture: texture, Projection: projection, Center: center, Scale: scale, u: u, v: v, w: w}, nil
+}
+
+// projectionFrame completes a right-handed orthonormal frame around the axis w, with v pointing up
+// (along y) as far as the axis allows
+func projectionFrame(w core.Vec3) (core.Vec3, core.Vec3, core.Vec3) {
+	up := core.NewVec3(0, 1, 0)
+	if math.Abs(w.Y) > 0.999 {
+		up = core.NewVec3(0, 0, -1)
+	}
+	u := up.Cross(w).Normalize()
+	return u, w.Cross(u), w
+}
+
+// Evaluate returns the texture's color at the coordinates projected from the point
+func (t *ProjectedTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	d := point.Subtract(t.Center)
+	x, y, z := d.Dot(t.u), d.Dot(t.v), d.Dot(t.w)
+	switch t.Projection {
+	case ProjectPlanar:
+		uv = core.NewVec2(x/t.Scale, y/t.Scale)
+	case ProjectSpherical:
+		r := d.Length()
+		if r == 0 {
+			return t.Texture.Evaluate(core.NewVec2(0.5, 0.5), point)
+		}
+		latitude := math.Asin(math.Max(-1, math.Min(1, z/r)))
+		uv = core.NewVec2(0.5+math.Atan2(y, x)/(2*math.Pi), 0.5+latitude/math.Pi)
+	case ProjectCylindrical:
+		uv = core.NewVec2(0.5+math.Atan2(y, x)/(2*math.Pi), z/t.Scale)
+	}
+	return t.Texture.Evaluate(uv, point)
+}
+
+// TriplanarTexture projects a texture along each of the x, y and z axes and blends the three by
+// how squarely the surface faces each axis, so it textures any shape without UVs and without the
+// stretching a single planar projection has on the surfaces parallel to it
+type TriplanarTexture struct {
+	Texture   ColorSource
+	Scale     float64 // World distance one repeat of the texture spans
+	Sharpness float64 // Exponent on the normal's components: higher blends over narrower seams
+}
+
+// NewTriplanarTexture creates a triplanar projection of a texture. Scale defaults to 1 and
+// sharpness to 4.
+func NewTriplanarTexture(texture ColorSource, scale, sharpness float64) *TriplanarTexture {
+	if scale <= 0 {
+		scale = 1
+	}
+	if sharpness <= 0 {
+		sharpness = 4
+	}
+	return &TriplanarTexture{Texture: texture, Scale: scale, Sharpness: sharpness}
+}
+
+// Evaluate projects the texture from above, as there's no normal to blend by
+func (t *TriplanarTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	return t.EvaluateOriented(uv, point, core.NewVec3(0, 1, 0))
+}
+
+// EvaluateOriented blends the three axis projections by the normal
+func (t *TriplanarTexture) EvaluateOriented(uv core.Vec2, point, normal core.Vec3) core.Vec3 {
+	wx := math.Pow(math.Abs(normal.X), t.Sharpness)
+	wy := math.Pow(math.Abs(normal.Y), t.Sharpness)
+	wz := math.Pow(math.Abs(normal.Z), t.Sharpness)
+	total := wx + wy + wz
+	if total == 0 {
+		wy, total = 1, 1
+	}
+
+	p := point.Multiply(1 / t.Scale)
+	var color core.Vec3
+	if wx > 0 {
+		color = color.Add(t.Texture.Evaluate(core.NewVec2(p.Z, p.Y), point).Multiply(wx / total))
+	}
+	if wy > 0 {
+		color = color.Add(t.Texture.Evaluate(core.NewVec2(p.X, p.Z), point).Multiply(wy / total))
+	}
+	if wz > 0 {
+		color = color.Add(t.Texture.Evaluate(core.NewVec2(p.X, p.Y), point).Multiply(wz / total))
+	}
+	return color
+}
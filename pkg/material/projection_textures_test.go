@@ -0,0 +1,80 @@
+package material
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// uvColor is a texture whose color is its UV coordinates
+type uvColor struct{}
+
+func (uvColor) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	return core.NewVec3(uv.X, uv.Y, 0)
+}
+
+func TestProjectedTexture(t *testing.T) {
+	center := core.NewVec3(1, 2, 3)
+	tests := []struct {
+		projection Projection
+		axis       core.Vec3
+		point      core.Vec3
+		want       core.Vec2
+	}{
+		{ProjectPlanar, core.NewVec3(0, 0, 1), core.NewVec3(2, 4, 9), core.NewVec2(0.5, 1)},         // Along z: x across, y up
+		{ProjectPlanar, core.NewVec3(0, 1, 0), core.NewVec3(3, 7, 2), core.NewVec2(1, 0.5)},         // Along y: x across, -z up
+		{ProjectSpherical, core.NewVec3(0, 1, 0), core.NewVec3(1, 7, 3), core.NewVec2(0.5, 1)},      // The pole
+		{ProjectSpherical, core.NewVec3(0, 0, 1), core.NewVec3(2, 2, 3), core.NewVec2(0.5, 0.5)},    // The equator, along u
+		{ProjectCylindrical, core.NewVec3(0, 0, 1), core.NewVec3(1, 3, 7), core.NewVec2(0.75, 2)},   // A quarter turn around, 4 along
+		{ProjectCylindrical, core.NewVec3(0, 0, -1), core.NewVec3(1, 1, -1), core.NewVec2(0.25, 2)}, // Below the center, along the reversed axis
+	}
+	for _, tt := range tests {
+		texture, err := NewProjectedTexture(uvColor{}, tt.projection, center, tt.axis.Multiply(3), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := texture.Evaluate(core.NewVec2(9, 9), tt.point); math.Abs(got.X-tt.want.X) > 1e-9 || math.Abs(got.Y-tt.want.Y) > 1e-9 {
+			t.Errorf("%s along %v at %v: expected UV %v, got %v", tt.projection, tt.axis, tt.point, tt.want, got)
+		}
+	}
+
+	if _, err := NewProjectedTexture(uvColor{}, "cubic", center, core.NewVec3(0, 1, 0), 1); err == nil {
+		t.Error("Expected an error for an unknown projection")
+	}
+	if _, err := NewProjectedTexture(uvColor{}, ProjectPlanar, center, core.Vec3{}, 1); err == nil {
+		t.Error("Expected an error for a zero axis")
+	}
+}
+
+func TestTriplanarTexture(t *testing.T) {
+	texture := NewTriplanarTexture(uvColor{}, 2, 0)
+	point := core.NewVec3(1, 2, 3)
+
+	// Facing an axis, the projection along it alone
+	for _, tt := range []struct {
+		normal core.Vec3
+		want   core.Vec3
+	}{
+		{core.NewVec3(-1, 0, 0), core.NewVec3(1.5, 1, 0)},
+		{core.NewVec3(0, 1, 0), core.NewVec3(0.5, 1.5, 0)},
+		{core.NewVec3(0, 0, 1), core.NewVec3(0.5, 1, 0)},
+	} {
+		if got := EvaluateOriented(texture, core.Vec2{}, point, tt.normal); !got.Equals(tt.want) {
+			t.Errorf("Normal %v: expected %v, got %v", tt.normal, tt.want, got)
+		}
+	}
+
+	// Facing diagonally, an even blend of all three
+	diagonal := core.NewVec3(1, 1, 1).Normalize()
+	if got, want := EvaluateOriented(texture, core.Vec2{}, point, diagonal), core.NewVec3(2.5/3, 3.5/3, 0); got.Subtract(want).Length() > 1e-9 {
+		t.Errorf("Expected the blend %v, got %v", want, got)
+	}
+
+	// A Lambertian looks it up with its hit's normal
+	lambertian := NewTexturedLambertian(texture)
+	hit := &SurfaceInteraction{Point: point, Normal: core.NewVec3(1, 0, 0)}
+	if got := lambertian.EvaluateBRDF(core.NewVec3(-1, 0, 0), core.NewVec3(1, 0, 0), hit, Radiance).Multiply(math.Pi); !got.Equals(core.NewVec3(1.5, 1, 0)) {
+		t.Errorf("Expected the x projection's albedo, got %v", got)
+	}
+}
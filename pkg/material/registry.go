@@ -0,0 +1,40 @@
+package material
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Factory builds a material of a registered type from its parameters
+type Factory func(params core.Params) (Material, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register adds a material type for scene files to use by name: PBRT's Material statements, and
+// JSON and YAML materials, whose parameters go under "params". A loader's own types come first,
+// so a name it already knows never reaches the factory. Register panics if the name is empty or
+// taken, since it's meant to be called from an init function.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("material: Register needs a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("material: type %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered for a material type
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
@@ -0,0 +1,35 @@
+package material
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// ShadowCatcher marks a surface standing in for one in a photograph, such as the floor a product
+// sits on, so rendered objects can be composited onto it. Light transport treats it as its Base,
+// so the objects get its bounce light and cast their shadows on it; with a transparent background
+// (see renderer.ProgressiveConfig.Transparent), camera rays that see it return only the shadows
+// and reflections of the objects, the photograph providing the rest.
+type ShadowCatcher struct {
+	Base Material // The surface it stands in for, e.g. a Lambertian of the floor's color
+}
+
+// NewShadowCatcher creates a shadow catcher standing in for a surface of the base material
+func NewShadowCatcher(base Material) *ShadowCatcher {
+	return &ShadowCatcher{Base: base}
+}
+
+// Scatter implements the Material interface with the base material
+func (s *ShadowCatcher) Scatter(rayIn core.Ray, hit SurfaceInteraction, sampler core.Sampler) (ScatterResult, bool) {
+	hit.Material = s.Base
+	return s.Base.Scatter(rayIn, hit, sampler)
+}
+
+// EvaluateBRDF implements the Material interface with the base material
+func (s *ShadowCatcher) EvaluateBRDF(incomingDir, outgoingDir core.Vec3, hit *SurfaceInteraction, mode TransportMode) core.Vec3 {
+	return s.Base.EvaluateBRDF(incomingDir, outgoingDir, hit, mode)
+}
+
+// PDF implements the Material interface with the base material
+func (s *ShadowCatcher) PDF(incomingDir, outgoingDir, normal core.Vec3) (float64, bool) {
+	return s.Base.PDF(incomingDir, outgoingDir, normal)
+}
@@ -0,0 +1,24 @@
+package material
+
+import (
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestShadowCatcher_ScattersAsBase(t *testing.T) {
+	base := NewMetal(core.NewVec3(0.8, 0.8, 0.8), 0.2)
+	catcher := NewShadowCatcher(base)
+	hit := SurfaceInteraction{Point: core.NewVec3(0, 0, 0), Normal: core.NewVec3(0, 1, 0), FrontFace: true, Material: catcher}
+	rayIn := core.NewRay(core.NewVec3(0, 1, 1), core.NewVec3(0, -1, -1).Normalize())
+
+	got, ok := catcher.Scatter(rayIn, hit, core.NewSeededSampler(5))
+	want, wantOK := base.Scatter(rayIn, hit, core.NewSeededSampler(5))
+	if ok != wantOK || got.Scattered.Direction != want.Scattered.Direction || got.Attenuation != want.Attenuation || got.PDF != want.PDF {
+		t.Errorf("Expected the shadow catcher to scatter as its base material, got %+v, want %+v", got, want)
+	}
+	out := core.NewVec3(0, 1, 1).Normalize()
+	if catcher.EvaluateBRDF(rayIn.Direction, out, &hit, Radiance) != base.EvaluateBRDF(rayIn.Direction, out, &hit, Radiance) {
+		t.Error("Expected the shadow catcher's BRDF to be its base material's")
+	}
+}
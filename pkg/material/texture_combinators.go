@@ -0,0 +1,116 @@
+package material
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// UVMappedTexture looks a texture up at scaled and offset UV coordinates (u*Scale.X + Offset.X,
+// v*Scale.Y + Offset.Y), e.g. to tile an image several times across a surface
+type UVMappedTexture struct {
+	Texture ColorSource
+	Scale   core.Vec2
+	Offset  core.Vec2
+}
+
+// NewUVMappedTexture creates a texture looked up at scaled and offset UV coordinates
+func NewUVMappedTexture(texture ColorSource, scale, offset core.Vec2) *UVMappedTexture {
+	return &UVMappedTexture{Texture: texture, Scale: scale, Offset: offset}
+}
+
+// Evaluate returns the wrapped texture's color at the mapped UV coordinates
+func (t *UVMappedTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	mapped := core.Vec2{X: uv.X*t.Scale.X + t.Offset.X, Y: uv.Y*t.Scale.Y + t.Offset.Y}
+	return t.Texture.Evaluate(mapped, point)
+}
+
+// EvaluateFiltered filters the wrapped texture over the footprint scaled as the UVs are
+func (t *UVMappedTexture) EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	mapped := core.Vec2{X: uv.X*t.Scale.X + t.Offset.X, Y: uv.Y*t.Scale.Y + t.Offset.Y}
+	return EvaluateFiltered(t.Texture, mapped, point, width*math.Max(math.Abs(t.Scale.X), math.Abs(t.Scale.Y)))
+}
+
+// CheckerTexture alternates between two textures in unit squares of UV space, Even in the square
+// at the origin
+type CheckerTexture struct {
+	Even ColorSource
+	Odd  ColorSource
+}
+
+// NewCheckerTexture creates a checkerboard of two textures with unit squares in UV space
+func NewCheckerTexture(even, odd ColorSource) *CheckerTexture {
+	return &CheckerTexture{Even: even, Odd: odd}
+}
+
+// Evaluate returns the color of the texture whose square contains the UV coordinates
+func (t *CheckerTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	if (int(math.Floor(uv.X))+int(math.Floor(uv.Y)))%2 == 0 {
+		return t.Even.Evaluate(uv, point)
+	}
+	return t.Odd.Evaluate(uv, point)
+}
+
+// EvaluateFiltered filters the texture whose square contains the UV coordinates
+func (t *CheckerTexture) EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	if (int(math.Floor(uv.X))+int(math.Floor(uv.Y)))%2 == 0 {
+		return EvaluateFiltered(t.Even, uv, point, width)
+	}
+	return EvaluateFiltered(t.Odd, uv, point, width)
+}
+
+// ScaledTexture multiplies a texture by another, channel by channel
+type ScaledTexture struct {
+	Texture ColorSource
+	Scale   ColorSource
+}
+
+// NewScaledTexture creates the channel-wise product of two textures
+func NewScaledTexture(texture, scale ColorSource) *ScaledTexture {
+	return &ScaledTexture{Texture: texture, Scale: scale}
+}
+
+// Evaluate returns the product of the two textures' colors
+func (t *ScaledTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	return t.Texture.Evaluate(uv, point).MultiplyVec(t.Scale.Evaluate(uv, point))
+}
+
+// EvaluateFiltered returns the product of the two textures' filtered colors
+func (t *ScaledTexture) EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	return EvaluateFiltered(t.Texture, uv, point, width).MultiplyVec(EvaluateFiltered(t.Scale, uv, point, width))
+}
+
+// ChannelTexture is one channel of a texture as a gray texture, for the single-valued maps that
+// assets pack into the channels of one image, such as glTF's occlusion, roughness and metallic
+// (ORM) maps in red, green and blue
+type ChannelTexture struct {
+	Texture ColorSource
+	Channel int // 0 red, 1 green, 2 blue
+}
+
+// NewChannelTexture creates a gray texture from channel 0 (red), 1 (green) or 2 (blue) of a texture
+func NewChannelTexture(texture ColorSource, channel int) *ChannelTexture {
+	return &ChannelTexture{Texture: texture, Channel: channel}
+}
+
+// Evaluate returns the channel's value as a gray
+func (t *ChannelTexture) Evaluate(uv core.Vec2, point core.Vec3) core.Vec3 {
+	return t.gray(t.Texture.Evaluate(uv, point))
+}
+
+// EvaluateFiltered returns the filtered channel's value as a gray
+func (t *ChannelTexture) EvaluateFiltered(uv core.Vec2, point core.Vec3, width float64) core.Vec3 {
+	return t.gray(EvaluateFiltered(t.Texture, uv, point, width))
+}
+
+// gray returns the channel of a color, in all three channels
+func (t *ChannelTexture) gray(color core.Vec3) core.Vec3 {
+	value := color.X
+	switch t.Channel {
+	case 1:
+		value = color.Y
+	case 2:
+		value = color.Z
+	}
+	return core.NewVec3(value, value, value)
+}
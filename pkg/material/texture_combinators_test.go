@@ -0,0 +1,60 @@
+package material
+
+import (
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestCheckerTexture(t *testing.T) {
+	white := NewSolidColor(core.NewVec3(1, 1, 1))
+	black := NewSolidColor(core.NewVec3(0, 0, 0))
+	checker := NewCheckerTexture(white, black)
+
+	tests := []struct {
+		uv   core.Vec2
+		want core.Vec3
+	}{
+		{core.NewVec2(0.5, 0.5), white.Color},
+		{core.NewVec2(1.5, 0.5), black.Color},
+		{core.NewVec2(1.5, 1.5), white.Color},
+		{core.NewVec2(-0.5, 0.5), black.Color}, // Squares continue below zero
+		{core.NewVec2(-0.5, -0.5), white.Color},
+	}
+	for _, tt := range tests {
+		if got := checker.Evaluate(tt.uv, core.Vec3{}); !got.Equals(tt.want) {
+			t.Errorf("UV %v: expected %v, got %v", tt.uv, tt.want, got)
+		}
+	}
+}
+
+func TestUVMappedTexture(t *testing.T) {
+	checker := NewCheckerTexture(NewSolidColor(core.NewVec3(1, 1, 1)), NewSolidColor(core.NewVec3(0, 0, 0)))
+
+	// Four squares across u, offset by one square
+	mapped := NewUVMappedTexture(checker, core.NewVec2(4, 1), core.NewVec2(1, 0))
+	for _, tt := range []struct {
+		u    float64
+		want float64
+	}{{0.1, 0}, {0.3, 1}, {0.6, 0}, {0.9, 1}} {
+		if got := mapped.Evaluate(core.NewVec2(tt.u, 0.5), core.Vec3{}); got.X != tt.want {
+			t.Errorf("u=%v: expected %v, got %v", tt.u, tt.want, got.X)
+		}
+	}
+}
+
+func TestScaledTexture(t *testing.T) {
+	scaled := NewScaledTexture(NewSolidColor(core.NewVec3(0.5, 1, 0.2)), NewSolidColor(core.NewVec3(2, 0.5, 1)))
+	if got := scaled.Evaluate(core.Vec2{}, core.Vec3{}); !got.Equals(core.NewVec3(1, 0.5, 0.2)) {
+		t.Errorf("Expected (1, 0.5, 0.2), got %v", got)
+	}
+}
+
+func TestChannelTexture(t *testing.T) {
+	orm := NewSolidColor(core.NewVec3(1, 0.25, 0.75))
+	for channel, want := range []float64{1, 0.25, 0.75} {
+		if got := NewChannelTexture(orm, channel).Evaluate(core.Vec2{}, core.Vec3{}); !got.Equals(core.NewVec3(want, want, want)) {
+			t.Errorf("Channel %d: expected gray %v, got %v", channel, want, got)
+		}
+	}
+}
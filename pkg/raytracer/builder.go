@@ -0,0 +1,180 @@
+package raytracer
+
+import (
+	"fmt"
+
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Vec is a point, a direction or an RGB color
+type Vec [3]float64
+
+// V returns the vector (x, y, z)
+func V(x, y, z float64) Vec {
+	return Vec{x, y, z}
+}
+
+func (v Vec) file() *scene.FileVec3 {
+	f := scene.FileVec3(v)
+	return &f
+}
+
+// Material is how a surface scatters or emits light
+type Material struct {
+	file scene.MaterialFile
+}
+
+// Diffuse returns a matte (Lambertian) material of the given albedo
+func Diffuse(albedo Vec) Material {
+	return Material{scene.MaterialFile{Type: "lambertian", Albedo: albedo.file()}}
+}
+
+// Metal returns a metal of the given albedo, from a mirror (fuzz 0) to brushed (fuzz 1)
+func Metal(albedo Vec, fuzz float64) Material {
+	return Material{scene.MaterialFile{Type: "metal", Albedo: albedo.file(), Fuzz: fuzz}}
+}
+
+// Glass returns a clear dielectric of the given index of refraction (1.5 for glass)
+func Glass(ior float64) Material {
+	return Material{scene.MaterialFile{Type: "dielectric", IOR: ior}}
+}
+
+// Emissive returns a material glowing with the given radiance. Shapes made of it light what
+// rays bounce onto them; for lights that are sampled directly, see the builder's lights and
+// AutoLights.
+func Emissive(emit Vec) Material {
+	return Material{scene.MaterialFile{Type: "emissive", Emit: emit.file()}}
+}
+
+// SceneBuilder builds a scene a call at a time. Its methods return the builder, for chaining;
+// any mistake is reported by Build.
+type SceneBuilder struct {
+	file scene.SceneFile
+	err  error
+}
+
+// NewScene starts a scene. Until set otherwise, its camera is at (0, 0, 5) looking at the origin
+// with a 40 degree field of view, rendering 400x300 pixels to 50 samples per pixel and paths of
+// up to 50 bounces.
+func NewScene() *SceneBuilder {
+	return &SceneBuilder{file: scene.SceneFile{
+		Camera:    scene.CameraFile{Center: V(0, 0, 5).file(), LookAt: V(0, 0, 0).file(), FOV: 40, Width: 400, AspectRatio: 4.0 / 3},
+		Sampling:  scene.SamplingFile{Samples: 50, Depth: 50},
+		Materials: map[string]scene.MaterialFile{},
+	}}
+}
+
+// Camera places the camera at from, looking at lookAt, with a vertical field of view in degrees
+func (b *SceneBuilder) Camera(from, lookAt Vec, fov float64) *SceneBuilder {
+	b.file.Camera.Center, b.file.Camera.LookAt, b.file.Camera.FOV = from.file(), lookAt.file(), fov
+	return b
+}
+
+// Size sets the image's width in pixels and its aspect ratio (width over height)
+func (b *SceneBuilder) Size(width int, aspectRatio float64) *SceneBuilder {
+	b.file.Camera.Width, b.file.Camera.AspectRatio = width, aspectRatio
+	return b
+}
+
+// Focus gives the camera a lens of the given aperture (diameter), focused at a distance, for
+// depth of field
+func (b *SceneBuilder) Focus(aperture, distance float64) *SceneBuilder {
+	b.file.Camera.Aperture, b.file.Camera.FocusDistance = aperture, distance
+	return b
+}
+
+// Depth sets how many times paths may bounce
+func (b *SceneBuilder) Depth(bounces int) *SceneBuilder {
+	b.file.Sampling.Depth = bounces
+	return b
+}
+
+// Samples sets the samples per pixel the scene renders to, unless Options.MaxSamples says otherwise
+func (b *SceneBuilder) Samples(samples int) *SceneBuilder {
+	b.file.Sampling.Samples = samples
+	return b
+}
+
+// AutoLights makes the shapes made of Emissive materials lights that are sampled directly, as
+// if they were added as lights, for less noise
+func (b *SceneBuilder) AutoLights() *SceneBuilder {
+	b.file.AutoLights = true
+	return b
+}
+
+// Sphere adds a sphere
+func (b *SceneBuilder) Sphere(center Vec, radius float64, m Material) *SceneBuilder {
+	return b.shape(scene.ShapeFile{Type: "sphere", Center: center.file(), Radius: radius}, m)
+}
+
+// Quad adds a parallelogram with a corner and two edges from it
+func (b *SceneBuilder) Quad(corner, u, v Vec, m Material) *SceneBuilder {
+	return b.shape(scene.ShapeFile{Type: "quad", Corner: corner.file(), U: u.file(), V: v.file()}, m)
+}
+
+// Triangle adds a triangle
+func (b *SceneBuilder) Triangle(p0, p1, p2 Vec, m Material) *SceneBuilder {
+	return b.shape(scene.ShapeFile{Type: "triangle", A: p0.file(), B: p1.file(), C: p2.file()}, m)
+}
+
+// Box adds a box of the given half extents, rotated by degrees about x, y and z
+func (b *SceneBuilder) Box(center, halfSize, rotation Vec, m Material) *SceneBuilder {
+	return b.shape(scene.ShapeFile{Type: "box", Center: center.file(), Size: halfSize.file(), Rotation: rotation.file()}, m)
+}
+
+// Mesh adds a triangle mesh from a PLY, STL or OFF file
+func (b *SceneBuilder) Mesh(filename string, m Material) *SceneBuilder {
+	return b.shape(scene.ShapeFile{Type: "mesh", File: filename}, m)
+}
+
+// QuadLight adds a parallelogram area light emitting the given radiance to the side u × v
+// points to
+func (b *SceneBuilder) QuadLight(corner, u, v, emit Vec) *SceneBuilder {
+	return b.light(scene.LightFile{Type: "quad", Corner: corner.file(), U: u.file(), V: v.file(), Emit: emit.file()})
+}
+
+// SphereLight adds a spherical area light emitting the given radiance
+func (b *SceneBuilder) SphereLight(center Vec, radius float64, emit Vec) *SceneBuilder {
+	return b.light(scene.LightFile{Type: "sphere", Center: center.file(), Radius: radius, Emit: emit.file()})
+}
+
+// Sun adds a distant light shining along direction with the given irradiance
+func (b *SceneBuilder) Sun(direction, emit Vec) *SceneBuilder {
+	return b.light(scene.LightFile{Type: "sun", Direction: direction.file(), Emit: emit.file()})
+}
+
+// Sky surrounds the scene with light of a uniform radiance
+func (b *SceneBuilder) Sky(emit Vec) *SceneBuilder {
+	return b.light(scene.LightFile{Type: "sky", Emit: emit.file()})
+}
+
+// Build returns the scene, or the first mistake in it
+func (b *SceneBuilder) Build() (*Scene, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	s, err := scene.NewFileScene(&b.file, ".")
+	if err != nil {
+		return nil, err
+	}
+	return newScene(s), nil
+}
+
+// shape adds a shape, naming its material after the shape so each shape has its own
+func (b *SceneBuilder) shape(shape scene.ShapeFile, m Material) *SceneBuilder {
+	if m.file.Type == "" {
+		if b.err == nil {
+			b.err = fmt.Errorf("shape %d (%s): no material", len(b.file.Shapes)+1, shape.Type)
+		}
+		return b
+	}
+	shape.Material = fmt.Sprintf("shape%d", len(b.file.Shapes)+1)
+	b.file.Materials[shape.Material] = m.file
+	b.file.Shapes = append(b.file.Shapes, shape)
+	return b
+}
+
+func (b *SceneBuilder) light(light scene.LightFile) *SceneBuilder {
+	b.file.Lights = append(b.file.Lights, light)
+	return b
+}
@@ -0,0 +1,192 @@
+// Package raytracer renders scenes for other Go programs, without the command line's flags and
+// files: build a scene (NewScene) or load one (LoadScene), then Render it.
+//
+//	s, err := raytracer.NewScene().
+//		Camera(raytracer.V(0, 1, 3), raytracer.V(0, 0.5, -1), 40).
+//		Sphere(raytracer.V(0, 0.5, -1), 0.5, raytracer.Glass(1.5)).
+//		Quad(raytracer.V(-5, 0, -6), raytracer.V(10, 0, 0), raytracer.V(0, 0, 10), raytracer.Diffuse(raytracer.V(0.8, 0.8, 0.8))).
+//		Sky(raytracer.V(0.6, 0.7, 0.9)).
+//		Build()
+//	img, stats, err := raytracer.Render(s, raytracer.Options{MaxSamples: 64})
+//
+// Only this package's API is kept stable; the packages under pkg/ that it uses may change.
+package raytracer
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Scene is a scene ready to render. Render one scene at a time: rendering prepares it in place.
+type Scene struct {
+	scene *scene.Scene
+}
+
+// Size returns the image size the scene renders at
+func (s *Scene) Size() (width, height int) {
+	return s.scene.SamplingConfig.Width, s.scene.SamplingConfig.Height
+}
+
+// newScene wraps a scene, sizing its image by its camera
+func newScene(s *scene.Scene) *Scene {
+	s.SamplingConfig.Width = s.CameraConfig.Width
+	s.SamplingConfig.Height = int(float64(s.CameraConfig.Width) / s.CameraConfig.AspectRatio)
+	return &Scene{scene: s}
+}
+
+// LoadScene loads a scene file: PBRT (.pbrt), a micro scene (.micro) or a scene description
+// (.json, .yaml or .yml). The bundled scenes load by their paths in scenes/ from any directory.
+func LoadScene(filename string) (*Scene, error) {
+	var s *scene.Scene
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pbrt":
+		var parsed *loaders.PBRTScene
+		if parsed, err = loaders.LoadPBRT(filename); err == nil {
+			s, err = scene.NewPBRTScene(parsed)
+		}
+	case ".micro":
+		var source []byte
+		if source, err = loaders.ReadSceneFile(filename); err == nil {
+			s, err = scene.NewMicroScene(string(source), nil)
+		}
+	case ".json", ".yaml", ".yml":
+		s, err = scene.LoadSceneFile(filename)
+	default:
+		return nil, fmt.Errorf("%s: unknown scene format (expected .pbrt, .micro, .json, .yaml or .yml)", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+	return newScene(s), nil
+}
+
+// Options controls a render. The zero value renders with path tracing to 50 samples per pixel
+// (or the scene's own sample count) in up to 7 passes, on every CPU.
+type Options struct {
+	Integrator  string        // "path-tracing" (the default), "bdpt", "ao", "direct" or a registered one
+	MaxSamples  int           // Samples per pixel (0 = the scene's, or 50 if it has none)
+	MaxPasses   int           // Progressive passes, each refining the last (0 = 7)
+	Workers     int           // Parallel workers (0 = one per CPU)
+	Seed        uint64        // Renders with the same seed and options are bit-identical
+	MaxTime     time.Duration // Stop before a pass that would end after this long (0 = no limit)
+	TargetError float64       // Stop once the estimated relative error is below this (0 = none)
+	OnPass      func(Pass)    // Called with each pass as it completes (nil = none)
+	Log         io.Writer     // Where the renderer logs its progress (nil = nowhere)
+}
+
+// Pass is a completed pass of a render
+type Pass struct {
+	Number int
+	Image  *image.RGBA
+	Stats  Stats
+	Last   bool
+}
+
+// Stats describes a render so far
+type Stats struct {
+	Passes         int           // Passes completed
+	TotalSamples   int           // Camera samples taken over the whole image
+	AverageSamples float64       // Samples per pixel on average
+	EstimatedError float64       // Estimated relative error of the image
+	Rays           int64         // Rays traced, of every kind
+	Duration       time.Duration // Rendering time, since the first pass started
+}
+
+// Render renders a scene to its final pass
+func Render(s *Scene, opts Options) (*image.RGBA, Stats, error) {
+	return RenderContext(context.Background(), s, opts)
+}
+
+// RenderContext renders a scene to its final pass, stopping early if ctx is cancelled. It then
+// returns the last complete pass (a nil image if there was none) with ctx's error.
+func RenderContext(ctx context.Context, s *Scene, opts Options) (*image.RGBA, Stats, error) {
+	integratorInst, err := newIntegrator(opts.Integrator, s.scene.SamplingConfig)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	config := renderer.DefaultProgressiveConfig()
+	config.MaxSamplesPerPixel = opts.MaxSamples
+	if config.MaxSamplesPerPixel <= 0 {
+		config.MaxSamplesPerPixel = s.scene.SamplingConfig.SamplesPerPixel
+	}
+	if config.MaxSamplesPerPixel <= 0 {
+		config.MaxSamplesPerPixel = renderer.DefaultProgressiveConfig().MaxSamplesPerPixel
+	}
+	if opts.MaxPasses > 0 {
+		config.MaxPasses = opts.MaxPasses
+	}
+	config.NumWorkers = opts.Workers
+	config.Seed = opts.Seed
+	config.MaxTime = opts.MaxTime
+	config.TargetError = opts.TargetError
+
+	log := opts.Log
+	if log == nil {
+		log = io.Discard
+	}
+	raytracer, err := renderer.NewProgressiveRaytracer(s.scene, config, integratorInst, writerLogger{log})
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	start := time.Now()
+	var img *image.RGBA
+	var stats Stats
+	finished := false
+	passes, _, errs := raytracer.RenderProgressive(ctx, renderer.RenderOptions{})
+	for pass := range passes {
+		if pass.PreviewProfile {
+			continue
+		}
+		img = pass.Image
+		stats = Stats{
+			Passes:         pass.PassNumber,
+			TotalSamples:   pass.Stats.TotalSamples,
+			AverageSamples: pass.Stats.AverageSamples,
+			EstimatedError: pass.Stats.EstimatedError,
+			Rays:           pass.Stats.Rays.Total(),
+			Duration:       time.Since(start),
+		}
+		finished = pass.IsLast
+		if opts.OnPass != nil {
+			opts.OnPass(Pass{Number: pass.PassNumber, Image: pass.Image, Stats: stats, Last: pass.IsLast})
+		}
+	}
+	if err := <-errs; err != nil {
+		return img, stats, err
+	}
+	if !finished {
+		// Cancelled while handing over a pass, which ends the render without an error
+		return img, stats, ctx.Err()
+	}
+	return img, stats, nil
+}
+
+// newIntegrator creates the integrator an Options.Integrator names
+func newIntegrator(name string, config scene.SamplingConfig) (integrator.Integrator, error) {
+	if name == "" {
+		name = "path-tracing"
+	}
+	return integrator.New(name, config)
+}
+
+// writerLogger writes the renderer's log to a writer
+type writerLogger struct {
+	w io.Writer
+}
+
+func (l writerLogger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format, args...)
+}
@@ -0,0 +1,106 @@
+package raytracer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// testScene builds a small scene lit by a quad and the sky
+func testScene(t *testing.T) *Scene {
+	t.Helper()
+	s, err := NewScene().
+		Camera(V(0, 1, 3), V(0, 0.5, -1), 40).
+		Size(32, 2).
+		Depth(4).
+		Sphere(V(0, 0.5, -1), 0.5, Metal(V(0.8, 0.6, 0.2), 0.1)).
+		Quad(V(-5, 0, -6), V(10, 0, 0), V(0, 0, 10), Diffuse(V(0.8, 0.8, 0.8))).
+		QuadLight(V(-0.5, 2, -1.5), V(1, 0, 0), V(0, 0, 1), V(4, 4, 4)).
+		Sky(V(0.2, 0.3, 0.4)).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	return s
+}
+
+func TestRender_Deterministic(t *testing.T) {
+	render := func() []byte {
+		var passes int
+		img, stats, err := Render(testScene(t), Options{
+			MaxSamples: 4,
+			MaxPasses:  2,
+			Workers:    2,
+			Seed:       7,
+			OnPass:     func(Pass) { passes++ },
+		})
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 16 {
+			t.Errorf("Expected a 32x16 image, got %v", img.Bounds())
+		}
+		if stats.Passes != 2 || passes != 2 {
+			t.Errorf("Expected 2 passes reported, got %d (%d callbacks)", stats.Passes, passes)
+		}
+		// Adaptive sampling may stop converged pixels short of the maximum
+		if stats.AverageSamples <= 0 || stats.AverageSamples > 4 || stats.Rays == 0 {
+			t.Errorf("Expected up to 4 samples per pixel and rays counted, got %+v", stats)
+		}
+		return img.Pix
+	}
+
+	if !bytes.Equal(render(), render()) {
+		t.Error("Expected identical images from renders with the same seed")
+	}
+}
+
+func TestRender_Integrators(t *testing.T) {
+	for _, name := range []string{"path-tracing", "bdpt", "ao", "direct"} {
+		if _, _, err := Render(testScene(t), Options{Integrator: name, MaxSamples: 1, MaxPasses: 1}); err != nil {
+			t.Errorf("%s: Render failed: %v", name, err)
+		}
+	}
+
+	if _, _, err := Render(testScene(t), Options{Integrator: "photon-mapping"}); err == nil {
+		t.Error("Expected an error for an unknown integrator")
+	}
+}
+
+func TestRenderContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := RenderContext(ctx, testScene(t), Options{MaxSamples: 4})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLoadScene(t *testing.T) {
+	// Bundled, so it loads from the package's directory too
+	s, err := LoadScene("scenes/cornell-empty.pbrt")
+	if err != nil {
+		t.Fatalf("LoadScene failed: %v", err)
+	}
+	if width, height := s.Size(); width <= 0 || height <= 0 {
+		t.Errorf("Expected the scene sized by its camera, got %dx%d", width, height)
+	}
+
+	if _, err := LoadScene("scenes/cornell-empty.obj"); err == nil {
+		t.Error("Expected an error for an unknown scene format")
+	}
+	if _, err := LoadScene("scenes/missing.pbrt"); err == nil {
+		t.Error("Expected an error for a missing scene")
+	}
+}
+
+func TestSceneBuilder_Errors(t *testing.T) {
+	if _, err := NewScene().Sphere(V(0, 0, 0), 1, Material{}).Build(); err == nil {
+		t.Error("Expected an error for a shape without a material")
+	}
+	if _, err := NewScene().Mesh("missing.ply", Diffuse(V(1, 1, 1))).Build(); err == nil {
+		t.Error("Expected an error for a missing mesh file")
+	}
+}
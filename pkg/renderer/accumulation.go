@@ -0,0 +1,187 @@
+package renderer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// accumulationMagic starts every accumulation file, followed by the format version
+const (
+	accumulationMagic   = "RTACCUM"
+	accumulationVersion = 2 // Version 1 had no weight or splat sums, its splats being in the color sums
+)
+
+// Accumulation is the raw state of a render's film: the sums of its pixels' samples, weights and
+// splats and the sample counts, before averaging. Renders of the same scene made independently (on other machines, or
+// with other seeds) merge by adding their accumulations, which weights each pixel by the samples
+// every render actually took there, just like one render with all the samples.
+type Accumulation struct {
+	Width  int
+	Height int
+	Pixels []PixelStats // Row by row; AOVs are not included
+	Splats []core.Vec3  // Sums of the light tracing splats each pixel received, row by row
+}
+
+// Colors returns the pixels' color estimates, row by row, normalizing the splats by the samples
+// of every pixel like the film does
+func (acc *Accumulation) Colors() []core.Vec3 {
+	samples := 0
+	for i := range acc.Pixels {
+		samples += acc.Pixels[i].SampleCount
+	}
+	colors := make([]core.Vec3, len(acc.Pixels))
+	for i := range acc.Pixels {
+		colors[i] = acc.Pixels[i].GetColor()
+		if acc.Splats != nil && samples > 0 {
+			colors[i] = colors[i].Add(acc.Splats[i].Multiply(float64(len(acc.Pixels)) / float64(samples)))
+		}
+	}
+	return colors
+}
+
+// Accumulation returns a copy of the pixel statistics accumulated so far at full resolution
+func (pr *ProgressiveRaytracer) Accumulation() *Accumulation {
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	acc := &Accumulation{Width: width, Height: height, Pixels: make([]PixelStats, 0, width*height), Splats: make([]core.Vec3, 0, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ps := pr.film.pixels[y][x]
+			ps.AOV = nil
+			ps.Deep = nil
+			ps.IDs = nil
+			ps.Alpha = nil
+			acc.Pixels = append(acc.Pixels, ps)
+			acc.Splats = append(acc.Splats, pr.film.splats[y][x])
+		}
+	}
+	return acc
+}
+
+// Merge adds another render's samples to the accumulation
+func (acc *Accumulation) Merge(other *Accumulation) error {
+	if other.Width != acc.Width || other.Height != acc.Height {
+		return fmt.Errorf("cannot merge a %dx%d render into a %dx%d one", other.Width, other.Height, acc.Width, acc.Height)
+	}
+	for i := range acc.Pixels {
+		p, q := &acc.Pixels[i], &other.Pixels[i]
+		p.ColorAccum = p.ColorAccum.Add(q.ColorAccum)
+		p.WeightSum += q.WeightSum
+		p.LuminanceAccum += q.LuminanceAccum
+		p.LuminanceSqAccum += q.LuminanceSqAccum
+		p.SampleCount += q.SampleCount
+	}
+	if other.Splats != nil {
+		if acc.Splats == nil {
+			acc.Splats = make([]core.Vec3, len(acc.Pixels))
+		}
+		for i := range acc.Splats {
+			acc.Splats[i] = acc.Splats[i].Add(other.Splats[i])
+		}
+	}
+	return nil
+}
+
+// Image averages each pixel's samples into a displayable image, like a render pass does
+func (acc *Accumulation) Image() (*image.RGBA, RenderStats) {
+	img := image.NewRGBA(image.Rect(0, 0, acc.Width, acc.Height))
+	stats := RenderStats{TotalPixels: len(acc.Pixels)}
+	for i, color := range acc.Colors() {
+		ps := &acc.Pixels[i]
+		img.SetRGBA(i%acc.Width, i/acc.Width, displayColor(color))
+
+		stats.TotalSamples += ps.SampleCount
+		if i == 0 || ps.SampleCount < stats.MinSamples {
+			stats.MinSamples = ps.SampleCount
+		}
+		stats.MaxSamplesUsed = max(stats.MaxSamplesUsed, ps.SampleCount)
+	}
+	if stats.TotalPixels > 0 {
+		stats.AverageSamples = float64(stats.TotalSamples) / float64(stats.TotalPixels)
+	}
+	return img, stats
+}
+
+// WriteAccumulation writes an accumulation in a little endian binary format: the magic and version,
+// the width and height, then per pixel the RGB sum, weight sum, luminance sum, luminance squared
+// sum, splat RGB sum (float64s) and sample count (uint64)
+func WriteAccumulation(w io.Writer, acc *Accumulation) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(accumulationMagic); err != nil {
+		return err
+	}
+	header := []uint32{accumulationVersion, uint32(acc.Width), uint32(acc.Height)}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	var buf [80]byte
+	for i, ps := range acc.Pixels {
+		var splat core.Vec3
+		if acc.Splats != nil {
+			splat = acc.Splats[i]
+		}
+		fields := [9]float64{ps.ColorAccum.X, ps.ColorAccum.Y, ps.ColorAccum.Z, ps.WeightSum, ps.LuminanceAccum, ps.LuminanceSqAccum, splat.X, splat.Y, splat.Z}
+		for j, f := range fields {
+			binary.LittleEndian.PutUint64(buf[8*j:], math.Float64bits(f))
+		}
+		binary.LittleEndian.PutUint64(buf[72:], uint64(ps.SampleCount))
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadAccumulation reads an accumulation written by WriteAccumulation, or by version 1 of it,
+// whose pixels' weights are their sample counts
+func ReadAccumulation(r io.Reader) (*Accumulation, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(accumulationMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != accumulationMagic {
+		return nil, fmt.Errorf("not an accumulation file")
+	}
+	var header [3]uint32
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read accumulation header: %w", err)
+	}
+	version := header[0]
+	if version != 1 && version != accumulationVersion {
+		return nil, fmt.Errorf("unsupported accumulation version %d", version)
+	}
+
+	acc := &Accumulation{Width: int(header[1]), Height: int(header[2])}
+	acc.Pixels = make([]PixelStats, acc.Width*acc.Height)
+	acc.Splats = make([]core.Vec3, acc.Width*acc.Height)
+	var buf [80]byte
+	record := buf[:]
+	if version == 1 {
+		record = buf[:48]
+	}
+	for i := range acc.Pixels {
+		if _, err := io.ReadFull(br, record); err != nil {
+			return nil, fmt.Errorf("accumulation truncated at pixel %d of %d: %w", i, len(acc.Pixels), err)
+		}
+		fields := make([]float64, len(record)/8-1)
+		for j := range fields {
+			fields[j] = math.Float64frombits(binary.LittleEndian.Uint64(record[8*j:]))
+		}
+		ps := &acc.Pixels[i]
+		ps.SampleCount = int(binary.LittleEndian.Uint64(record[len(record)-8:]))
+		ps.ColorAccum.X, ps.ColorAccum.Y, ps.ColorAccum.Z = fields[0], fields[1], fields[2]
+		if version == 1 {
+			ps.WeightSum = float64(ps.SampleCount)
+			ps.LuminanceAccum, ps.LuminanceSqAccum = fields[3], fields[4]
+			continue
+		}
+		ps.WeightSum, ps.LuminanceAccum, ps.LuminanceSqAccum = fields[3], fields[4], fields[5]
+		acc.Splats[i] = core.NewVec3(fields[6], fields[7], fields[8])
+	}
+	return acc, nil
+}
@@ -0,0 +1,135 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// newTestAccumulation returns a 2x1 accumulation with the given samples in its first pixel
+func newTestAccumulation(samples ...core.Vec3) *Accumulation {
+	acc := &Accumulation{Width: 2, Height: 1, Pixels: make([]PixelStats, 2), Splats: make([]core.Vec3, 2)}
+	for _, sample := range samples {
+		acc.Pixels[0].AddSample(sample)
+	}
+	acc.Pixels[1].AddSample(core.NewVec3(0.5, 0.5, 0.5))
+	acc.Splats[1] = core.NewVec3(0.25, 0, 0)
+	return acc
+}
+
+func TestAccumulation_RoundTrip(t *testing.T) {
+	acc := newTestAccumulation(core.NewVec3(1, 0.5, 0.125), core.NewVec3(0.1, 0.2, 0.3))
+
+	var buf bytes.Buffer
+	if err := WriteAccumulation(&buf, acc); err != nil {
+		t.Fatalf("WriteAccumulation failed: %v", err)
+	}
+	if buf.Len() != len(accumulationMagic)+12+2*80 {
+		t.Errorf("Unexpected file size %d", buf.Len())
+	}
+
+	read, err := ReadAccumulation(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAccumulation failed: %v", err)
+	}
+	if read.Width != 2 || read.Height != 1 {
+		t.Fatalf("Expected a 2x1 accumulation, got %dx%d", read.Width, read.Height)
+	}
+	for i := range acc.Pixels {
+		if read.Pixels[i] != acc.Pixels[i] || read.Splats[i] != acc.Splats[i] {
+			t.Errorf("Pixel %d: read %+v and splats %v, wrote %+v and %v", i, read.Pixels[i], read.Splats[i], acc.Pixels[i], acc.Splats[i])
+		}
+	}
+
+	// Truncated files and other files are rejected
+	if _, err := ReadAccumulation(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("Expected a truncation error, got %v", err)
+	}
+	if _, err := ReadAccumulation(strings.NewReader("\x89PNG\r\n\x1a\n")); err == nil {
+		t.Error("Expected an error for a file that isn't an accumulation")
+	}
+}
+
+func TestReadAccumulation_Version1(t *testing.T) {
+	// A version 1 pixel: RGB sum, luminance sum, luminance squared sum and sample count
+	var buf bytes.Buffer
+	buf.WriteString(accumulationMagic)
+	binary.Write(&buf, binary.LittleEndian, []uint32{1, 1, 1})
+	binary.Write(&buf, binary.LittleEndian, []float64{1, 2, 3, 4, 5})
+	binary.Write(&buf, binary.LittleEndian, uint64(2))
+
+	acc, err := ReadAccumulation(&buf)
+	if err != nil {
+		t.Fatalf("ReadAccumulation failed: %v", err)
+	}
+	want := PixelStats{ColorAccum: core.NewVec3(1, 2, 3), WeightSum: 2, LuminanceAccum: 4, LuminanceSqAccum: 5, SampleCount: 2}
+	if acc.Pixels[0] != want || !acc.Splats[0].IsZero() {
+		t.Errorf("Expected %+v without splats, got %+v and %v", want, acc.Pixels[0], acc.Splats[0])
+	}
+}
+
+func TestAccumulation_MergeWeightsBySamples(t *testing.T) {
+	// One white sample merged with three black ones averages to a quarter, not a half
+	acc := newTestAccumulation(core.NewVec3(1, 1, 1))
+	other := newTestAccumulation(core.Vec3{}, core.Vec3{}, core.Vec3{})
+	if err := acc.Merge(other); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if acc.Pixels[0].SampleCount != 4 || !acc.Pixels[0].GetColor().Equals(core.NewVec3(0.25, 0.25, 0.25)) {
+		t.Errorf("Merged pixel has %d samples averaging %v, want 4 averaging 0.25", acc.Pixels[0].SampleCount, acc.Pixels[0].GetColor())
+	}
+	if acc.Pixels[0].LuminanceSqAccum != 1 {
+		t.Errorf("Expected the variance statistics to merge too, got luminance² sum %v", acc.Pixels[0].LuminanceSqAccum)
+	}
+
+	// Splats merge too, and are normalized by the samples of the whole image: 6 over 2 pixels
+	if color := acc.Colors()[1]; !color.Equals(core.NewVec3(0.5+0.5/3, 0.5, 0.5)) {
+		t.Errorf("Merged splatted pixel = %v", color)
+	}
+
+	img, stats := acc.Image()
+	if stats.TotalSamples != 6 || stats.MinSamples != 2 || stats.MaxSamplesUsed != 4 || stats.AverageSamples != 3 {
+		t.Errorf("Unexpected merged stats %+v", stats)
+	}
+	if img.RGBAAt(0, 0) != displayColor(core.NewVec3(0.25, 0.25, 0.25)) {
+		t.Errorf("Merged image pixel = %v", img.RGBAAt(0, 0))
+	}
+
+	if err := acc.Merge(&Accumulation{Width: 1, Height: 2, Pixels: make([]PixelStats, 2)}); err == nil {
+		t.Error("Expected an error merging renders of different sizes")
+	}
+}
+
+func TestProgressiveRaytracer_Accumulation(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 8
+	s.SamplingConfig.Height = 4
+	config := ProgressiveConfig{TileSize: 4, InitialSamples: 2, MaxSamplesPerPixel: 2, MaxPasses: 1, NumWorkers: 1, AOVs: true}
+	raytracer, err := NewProgressiveRaytracer(s, config, &MockIntegrator{}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+
+	_, stats, err := raytracer.RenderPass(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("RenderPass failed: %v", err)
+	}
+
+	acc := raytracer.Accumulation()
+	if acc.Width != 8 || acc.Height != 4 || len(acc.Pixels) != 32 {
+		t.Fatalf("Expected an 8x4 accumulation, got %dx%d with %d pixels", acc.Width, acc.Height, len(acc.Pixels))
+	}
+	_, accStats := acc.Image()
+	if accStats.TotalSamples != stats.TotalSamples {
+		t.Errorf("Accumulation has %d samples, the pass took %d", accStats.TotalSamples, stats.TotalSamples)
+	}
+	if acc.Pixels[0].AOV != nil {
+		t.Error("Expected the accumulation to leave out AOVs")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package renderer
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// cpuSet is the kernel's CPU mask, big enough for 1024 CPUs
+type cpuSet [16]uint64
+
+// allowedCPUs returns the CPUs the calling thread may run on, in order
+func allowedCPUs() ([]int, error) {
+	var set cpuSet
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0, unsafe.Sizeof(set), uintptr(unsafe.Pointer(&set)))
+	if errno != 0 {
+		return nil, fmt.Errorf("failed to read the CPU affinity: %w", errno)
+	}
+	var cpus []int
+	for i := range len(set) * 64 {
+		if set[i/64]&(1<<(i%64)) != 0 {
+			cpus = append(cpus, i)
+		}
+	}
+	return cpus, nil
+}
+
+// pinThread restricts the calling thread to one CPU. Lock the goroutine to its thread first.
+func pinThread(cpu int) error {
+	var set cpuSet
+	set[cpu/64] = 1 << (cpu % 64)
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(set), uintptr(unsafe.Pointer(&set)))
+	if errno != 0 {
+		return fmt.Errorf("failed to pin to CPU %d: %w", cpu, errno)
+	}
+	return nil
+}
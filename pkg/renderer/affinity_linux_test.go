@@ -0,0 +1,113 @@
+//go:build linux
+
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestPinThread(t *testing.T) {
+	cpus, err := allowedCPUs()
+	if err != nil {
+		t.Fatalf("allowedCPUs failed: %v", err)
+	}
+	if len(cpus) == 0 {
+		t.Fatal("Expected at least one allowed CPU")
+	}
+
+	// On a thread of its own, which exits with the goroutine still pinned
+	done := make(chan []int)
+	go func() {
+		runtime.LockOSThread()
+		if err := pinThread(cpus[len(cpus)-1]); err != nil {
+			t.Errorf("pinThread failed: %v", err)
+		}
+		pinned, _ := allowedCPUs()
+		done <- pinned
+	}()
+	if pinned := <-done; !slices.Equal(pinned, cpus[len(cpus)-1:]) {
+		t.Errorf("Expected the thread pinned to CPU %d, it may run on %v", cpus[len(cpus)-1], pinned)
+	}
+
+	// Other threads keep every CPU
+	if after, _ := allowedCPUs(); !slices.Equal(after, cpus) {
+		t.Errorf("Expected the test's thread to keep CPUs %v, got %v", cpus, after)
+	}
+}
+
+// recordingLogger keeps what's logged to it
+type recordingLogger struct {
+	lines []string
+}
+
+func (rl *recordingLogger) Printf(format string, args ...interface{}) {
+	rl.lines = append(rl.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWorkerPool_WarnsOnceWhenPinningFails(t *testing.T) {
+	s := createTestScene()
+	pool := NewWorkerPool(s, integrator.NewPathTracingIntegrator(s.SamplingConfig), 16, 16, 8, 3)
+	logger := &recordingLogger{}
+	if err := pool.PinWorkers(logger); err != nil {
+		t.Fatalf("PinWorkers failed: %v", err)
+	}
+	pool.cpus = []int{len(cpuSet{})*64 - 1} // Beyond any CPU the process may use
+	pool.Start()
+	pool.Stop()
+
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "runs unpinned") {
+		t.Errorf("Expected one warning that workers run unpinned, got %q", logger.lines)
+	}
+}
+
+func TestRenderPass_PinnedWorkersMatchUnpinned(t *testing.T) {
+	render := func(pin bool) []core.Vec3 {
+		s := createTestScene()
+		s.SamplingConfig.Width = 16
+		s.SamplingConfig.Height = 16
+
+		config := ProgressiveConfig{
+			TileSize:           8,
+			InitialSamples:     1,
+			MaxSamplesPerPixel: 4,
+			MaxPasses:          2,
+			NumWorkers:         3,
+			PinWorkers:         pin,
+			Seed:               5,
+		}
+		raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+		if err != nil {
+			t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+		}
+		defer raytracer.workerPool.Stop()
+		if pin && len(raytracer.workerPool.cpus) == 0 {
+			t.Fatal("Expected the workers to be pinned")
+		}
+
+		for pass := 1; pass <= config.MaxPasses; pass++ {
+			if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+				t.Fatalf("RenderPass failed: %v", err)
+			}
+		}
+
+		var colors []core.Vec3
+		for _, row := range raytracer.film.pixels {
+			for _, ps := range row {
+				colors = append(colors, ps.GetColor())
+			}
+		}
+		return colors
+	}
+
+	if !slices.Equal(render(false), render(true)) {
+		t.Error("Expected bit-identical pixel colors with and without pinning")
+	}
+}
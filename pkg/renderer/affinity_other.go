@@ -0,0 +1,13 @@
+//go:build !linux
+
+package renderer
+
+import "errors"
+
+func allowedCPUs() ([]int, error) {
+	return nil, errors.New("pinning workers to CPUs is only supported on Linux")
+}
+
+func pinThread(cpu int) error {
+	return errors.New("pinning workers to CPUs is only supported on Linux")
+}
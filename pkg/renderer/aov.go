@@ -0,0 +1,239 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// AOV names of the images produced when ProgressiveConfig.AOVs is enabled
+// Per-strategy BDPT images are additionally named by StrategyAOVName
+const (
+	AOVDepth    = "depth"    // Camera distance to the first hit, nearer is brighter
+	AOVNormal   = "normal"   // World-space normal at the first hit, mapped from [-1,1] to [0,1]
+	AOVAlbedo   = "albedo"   // Material reflectance at the first hit
+	AOVDirect   = "direct"   // Emitters seen directly plus one-bounce lighting
+	AOVIndirect = "indirect" // Lighting after two or more bounces
+)
+
+// aovSeedSalt decorrelates the albedo sampler from the integrator's sampler for the same pixel pass
+const aovSeedSalt = 0xbb67ae8584caa73b
+
+// AOVStats accumulates arbitrary output variables for a single pixel
+// Like PixelStats.ColorAccum these are sums, divided by the pixel's sample count when assembled;
+// the splat sums are normalized like the film's splats instead (see Film).
+type AOVStats struct {
+	DepthAccum     float64   // Sum of first-hit distances
+	HitCount       int       // Number of samples whose camera ray hit geometry
+	NormalAccum    core.Vec3 // Sum of first-hit normals
+	AlbedoAccum    core.Vec3 // Sum of first-hit reflectances
+	DirectAccum    core.Vec3 // Sum of direct lighting
+	IndirectAccum  core.Vec3 // Sum of indirect lighting
+	DirectSplats   core.Vec3 // Sum of direct lighting splats
+	IndirectSplats core.Vec3 // Sum of indirect lighting splats
+
+	StrategyAccum  map[integrator.Strategy]core.Vec3 // Sum of each BDPT strategy's weighted contribution
+	StrategySplats map[integrator.Strategy]core.Vec3 // Sum of each BDPT strategy's weighted splats
+}
+
+// AddSample adds the lighting split recorded by the integrator for one sample
+func (as *AOVStats) AddSample(sample *integrator.AOVSample) {
+	as.DirectAccum = as.DirectAccum.Add(sample.Direct)
+	as.IndirectAccum = as.IndirectAccum.Add(sample.Indirect)
+	for strategy, c := range sample.Strategies {
+		as.addStrategy(strategy, c)
+	}
+}
+
+// AddSplat adds a splat contribution to its strategy image and the direct/indirect split
+func (as *AOVStats) AddSplat(strategy integrator.Strategy, c core.Vec3) {
+	if integrator.IsDirectPath(strategy.S + strategy.T) {
+		as.DirectSplats = as.DirectSplats.Add(c)
+	} else {
+		as.IndirectSplats = as.IndirectSplats.Add(c)
+	}
+	if as.StrategySplats == nil {
+		as.StrategySplats = make(map[integrator.Strategy]core.Vec3)
+	}
+	as.StrategySplats[strategy] = as.StrategySplats[strategy].Add(c)
+}
+
+// AddSurface adds the first-hit geometry of one camera sample
+func (as *AOVStats) AddSurface(depth float64, normal, albedo core.Vec3) {
+	as.DepthAccum += depth
+	as.HitCount++
+	as.NormalAccum = as.NormalAccum.Add(normal)
+	as.AlbedoAccum = as.AlbedoAccum.Add(albedo)
+}
+
+func (as *AOVStats) addStrategy(strategy integrator.Strategy, c core.Vec3) {
+	if as.StrategyAccum == nil {
+		as.StrategyAccum = make(map[integrator.Strategy]core.Vec3)
+	}
+	as.StrategyAccum[strategy] = as.StrategyAccum[strategy].Add(c)
+}
+
+// StrategyAOVName returns the AOV name for a BDPT strategy's image, e.g. "bdpt_s1_t2"
+func StrategyAOVName(strategy integrator.Strategy) string {
+	return fmt.Sprintf("bdpt_s%d_t%d", strategy.S, strategy.T)
+}
+
+// StrategyGridGap is the width of the black border between images in a strategy grid
+const StrategyGridGap = 4
+
+// StrategyGrid arranges the per-strategy BDPT AOV images in the classic Veach pyramid
+// Row n holds the strategies for paths of n vertices (s+t = n), ordered by s from left to right,
+// and rows are centered so strategies with the same s line up diagonally. Because the images are
+// MIS-weighted, each row sums to that path length's contribution: a bright or noisy cell shows which
+// technique carries the light, and a cell that shouldn't contribute but does points to a weighting bug.
+// Returns nil if there are no strategy images.
+func StrategyGrid(aovs map[string]*image.RGBA) *image.RGBA {
+	var cellBounds image.Rectangle
+	maxVertices := 0
+	for name, img := range aovs {
+		var strategy integrator.Strategy
+		if n, _ := fmt.Sscanf(name, "bdpt_s%d_t%d", &strategy.S, &strategy.T); n != 2 || name != StrategyAOVName(strategy) {
+			continue
+		}
+		maxVertices = max(maxVertices, strategy.S+strategy.T)
+		cellBounds = img.Bounds()
+	}
+	if maxVertices < 2 {
+		return nil
+	}
+
+	// Paths have at least two vertices (camera and emitter), and a path of n vertices has n strategies (t >= 1)
+	rows := maxVertices - 1
+	columns := maxVertices
+	cellWidth := cellBounds.Dx() + StrategyGridGap
+	cellHeight := cellBounds.Dy() + StrategyGridGap
+	grid := image.NewRGBA(image.Rect(0, 0, columns*cellWidth+StrategyGridGap, rows*cellHeight+StrategyGridGap))
+	draw.Draw(grid, grid.Bounds(), image.NewUniform(color.RGBA{A: 255}), image.Point{}, draw.Src)
+
+	for vertices := 2; vertices <= maxVertices; vertices++ {
+		row := vertices - 2
+		indent := (columns - vertices) * cellWidth / 2
+		for s := 0; s < vertices; s++ {
+			img := aovs[StrategyAOVName(integrator.Strategy{S: s, T: vertices - s})]
+			if img == nil {
+				continue // Strategy never contributed (e.g. blocked by specular surfaces)
+			}
+			origin := image.Pt(StrategyGridGap+indent+s*cellWidth, StrategyGridGap+row*cellHeight)
+			draw.Draw(grid, img.Bounds().Add(origin), img, img.Bounds().Min, draw.Src)
+		}
+	}
+	return grid
+}
+
+// recordSurfaceAOVs traces the camera ray's first hit for the geometric AOVs
+// It uses its own sampler so enabling AOVs doesn't perturb the integrator's random sequence.
+func (tr *TileRenderer) recordSurfaceAOVs(ray core.Ray, as *AOVStats, sampler core.Sampler) {
+	hit, isHit := geometry.HitVisible(tr.scene.Intersector, ray, 0.001, math.Inf(1), material.CameraRays)
+	if !isHit {
+		return
+	}
+
+	// Non-specular attenuation is a BRDF value; weighting the sampled direction by cos/pdf gives a
+	// one-sample estimate of the reflectance (exact for cosine-sampled Lambertian surfaces)
+	var albedo core.Vec3
+	if scatter, didScatter := hit.Material.Scatter(ray, *hit, sampler); didScatter {
+		albedo = scatter.Attenuation
+		if !scatter.IsSpecular() {
+			cosine := scatter.Scattered.Direction.Normalize().AbsDot(hit.Normal)
+			albedo = albedo.Multiply(cosine / scatter.PDF)
+		}
+	}
+	as.AddSurface(hit.T*ray.Direction.Length(), hit.Normal, albedo)
+}
+
+// assembleAOVImages creates one image per AOV from the current pixel stats
+// Returns nil when AOVs aren't enabled.
+func (pr *ProgressiveRaytracer) assembleAOVImages() map[string]*image.RGBA {
+	if !pr.config.AOVs {
+		return nil
+	}
+
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	bounds := image.Rect(0, 0, width, height)
+
+	// Depth is normalized by the farthest average hit distance, and strategy images
+	// exist for every strategy that contributed anywhere in the image
+	maxDepth := 0.0
+	strategies := make(map[integrator.Strategy]bool)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			as := pr.film.pixels[y][x].AOV
+			if as.HitCount > 0 {
+				maxDepth = math.Max(maxDepth, as.DepthAccum/float64(as.HitCount))
+			}
+			for strategy := range as.StrategyAccum {
+				strategies[strategy] = true
+			}
+			for strategy := range as.StrategySplats {
+				strategies[strategy] = true
+			}
+		}
+	}
+
+	images := map[string]*image.RGBA{
+		AOVDepth:    image.NewRGBA(bounds),
+		AOVNormal:   image.NewRGBA(bounds),
+		AOVAlbedo:   image.NewRGBA(bounds),
+		AOVDirect:   image.NewRGBA(bounds),
+		AOVIndirect: image.NewRGBA(bounds),
+	}
+	for strategy := range strategies {
+		images[StrategyAOVName(strategy)] = image.NewRGBA(bounds)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ps := &pr.film.pixels[y][x]
+			if ps.SampleCount == 0 {
+				continue
+			}
+			as := ps.AOV
+			scale := 1.0 / float64(ps.SampleCount)
+
+			if as.HitCount > 0 && maxDepth > 0 {
+				depth := 1.0 - as.DepthAccum/float64(as.HitCount)/maxDepth
+				images[AOVDepth].SetRGBA(x, y, linearToColor(core.NewVec3(depth, depth, depth)))
+			} else {
+				images[AOVDepth].SetRGBA(x, y, color.RGBA{A: 255})
+			}
+
+			normal := as.NormalAccum.Multiply(scale)
+			images[AOVNormal].SetRGBA(x, y, linearToColor(normal.Add(core.NewVec3(1, 1, 1)).Multiply(0.5)))
+			images[AOVAlbedo].SetRGBA(x, y, pr.vec3ToColor(as.AlbedoAccum.Multiply(scale)))
+			splatScale := pr.film.splatScale
+			images[AOVDirect].SetRGBA(x, y, pr.vec3ToColor(as.DirectAccum.Multiply(scale).Add(as.DirectSplats.Multiply(splatScale))))
+			images[AOVIndirect].SetRGBA(x, y, pr.vec3ToColor(as.IndirectAccum.Multiply(scale).Add(as.IndirectSplats.Multiply(splatScale))))
+
+			for strategy := range strategies {
+				c := as.StrategyAccum[strategy].Multiply(scale).Add(as.StrategySplats[strategy].Multiply(splatScale))
+				images[StrategyAOVName(strategy)].SetRGBA(x, y, pr.vec3ToColor(c))
+			}
+		}
+	}
+
+	return images
+}
+
+// linearToColor converts a [0,1] data value (depth, normals) to RGBA without gamma correction
+func linearToColor(v core.Vec3) color.RGBA {
+	v = v.Clamp(0.0, 1.0)
+	return color.RGBA{
+		R: uint8(255 * v.X),
+		G: uint8(255 * v.Y),
+		B: uint8(255 * v.Z),
+		A: 255,
+	}
+}
@@ -0,0 +1,204 @@
+package renderer
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+// renderAOVTestScene renders the small test scene with a quad light and returns the raytracer and last pass
+func renderAOVTestScene(t *testing.T, integratorInst func(*ProgressiveRaytracer) integrator.Integrator, aovs bool) (*ProgressiveRaytracer, PassResult) {
+	t.Helper()
+
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 16
+	s.Camera = geometry.NewCamera(geometry.CameraConfig{
+		Center:      core.NewVec3(0, 0, 0),
+		LookAt:      core.NewVec3(0, 0, -1),
+		Up:          core.NewVec3(0, 1, 0),
+		Width:       16,
+		AspectRatio: 1.0,
+		VFov:        45.0,
+	})
+	s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+	config := ProgressiveConfig{
+		TileSize:           8,
+		InitialSamples:     1,
+		MaxSamplesPerPixel: 4,
+		MaxPasses:          2,
+		NumWorkers:         2,
+		Seed:               5,
+		AOVs:               aovs,
+	}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewBDPTIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	if integratorInst != nil {
+		raytracer.workerPool = NewWorkerPool(s, integratorInst(raytracer), 16, 16, config.TileSize, config.NumWorkers)
+	}
+	defer raytracer.workerPool.Stop()
+
+	var result PassResult
+	for pass := 1; pass <= config.MaxPasses; pass++ {
+		img, stats, err := raytracer.RenderPass(context.Background(), pass, nil)
+		if err != nil {
+			t.Fatalf("RenderPass failed: %v", err)
+		}
+		result = PassResult{PassNumber: pass, Image: img, AOVs: raytracer.assembleAOVImages(), Stats: stats}
+	}
+	return raytracer, result
+}
+
+func TestAOVs_LightSplitMatchesBeauty(t *testing.T) {
+	raytracer, result := renderAOVTestScene(t, nil, true)
+
+	for _, name := range []string{AOVDepth, AOVNormal, AOVAlbedo, AOVDirect, AOVIndirect} {
+		if result.AOVs[name] == nil {
+			t.Errorf("Missing %s AOV image", name)
+		}
+	}
+
+	sawStrategy := false
+	for y, row := range raytracer.film.pixels {
+		for x, ps := range row {
+			as := ps.AOV
+			if split := as.DirectAccum.Add(as.IndirectAccum); !vecClose(split, ps.ColorAccum, 1e-9) {
+				t.Fatalf("Pixel (%d,%d): direct+indirect %v != color %v", x, y, split, ps.ColorAccum)
+			}
+			splats := raytracer.film.splats[y][x]
+			if split := as.DirectSplats.Add(as.IndirectSplats); !vecClose(split, splats, 1e-9) {
+				t.Fatalf("Pixel (%d,%d): direct+indirect splats %v != splats %v", x, y, split, splats)
+			}
+
+			var strategySum core.Vec3
+			for strategy, c := range as.StrategyAccum {
+				strategySum = strategySum.Add(c)
+				if result.AOVs[StrategyAOVName(strategy)] == nil {
+					t.Errorf("Missing image for strategy %+v", strategy)
+				}
+				sawStrategy = true
+			}
+			if !vecClose(strategySum, ps.ColorAccum, 1e-9) {
+				t.Fatalf("Pixel (%d,%d): strategy sum %v != color %v", x, y, strategySum, ps.ColorAccum)
+			}
+			var strategySplats core.Vec3
+			for _, c := range as.StrategySplats {
+				strategySplats = strategySplats.Add(c)
+			}
+			if !vecClose(strategySplats, splats, 1e-9) {
+				t.Fatalf("Pixel (%d,%d): strategy splat sum %v != splats %v", x, y, strategySplats, splats)
+			}
+		}
+	}
+	if !sawStrategy {
+		t.Error("Expected BDPT strategy contributions")
+	}
+
+	// The sphere fills the center of the view: it should be near, and its normal faces the camera
+	center := raytracer.film.pixels[8][8].AOV
+	if center.HitCount == 0 {
+		t.Fatal("Expected center pixel to hit the sphere")
+	}
+	if depth := center.DepthAccum / float64(center.HitCount); depth < 0.4 || depth > 0.6 {
+		t.Errorf("Expected center depth near 0.5, got %f", depth)
+	}
+	if normal := center.NormalAccum.Normalize(); normal.Z < 0.9 {
+		t.Errorf("Expected center normal to face the camera, got %v", normal)
+	}
+	if albedo := center.AlbedoAccum.Multiply(1.0 / float64(center.HitCount)); !vecClose(albedo, core.NewVec3(0.5, 0.5, 0.5), 1e-9) {
+		t.Errorf("Expected sphere albedo 0.5, got %v", albedo)
+	}
+}
+
+func TestAOVs_DoNotChangeBeauty(t *testing.T) {
+	plain, _ := renderAOVTestScene(t, nil, false)
+	withAOVs, result := renderAOVTestScene(t, nil, true)
+
+	for y := range plain.film.pixels {
+		for x := range plain.film.pixels[y] {
+			if plain.film.pixels[y][x].ColorAccum != withAOVs.film.pixels[y][x].ColorAccum {
+				t.Fatalf("Pixel (%d,%d): enabling AOVs changed the beauty image", x, y)
+			}
+		}
+	}
+
+	if plain.assembleAOVImages() != nil {
+		t.Error("Expected no AOV images when AOVs are disabled")
+	}
+	if len(result.AOVs) == 0 {
+		t.Error("Expected AOV images when AOVs are enabled")
+	}
+}
+
+func TestAOVs_NonAOVIntegratorStillRecordsSurfaces(t *testing.T) {
+	mock := &MockIntegrator{returnColor: core.NewVec3(0.25, 0.5, 0.75)}
+	raytracer, _ := renderAOVTestScene(t, func(*ProgressiveRaytracer) integrator.Integrator { return mock }, true)
+
+	as := raytracer.film.pixels[8][8].AOV
+	if as.HitCount == 0 {
+		t.Error("Expected geometric AOVs to be recorded with any integrator")
+	}
+	if !as.DirectAccum.IsZero() || !as.IndirectAccum.IsZero() || len(as.StrategyAccum) != 0 {
+		t.Error("Expected no light split from an integrator without AOV support")
+	}
+}
+
+func vecClose(a, b core.Vec3, tolerance float64) bool {
+	return a.Subtract(b).Length() <= tolerance*(1+b.Length())
+}
+
+func TestStrategyGrid_Layout(t *testing.T) {
+	_, result := renderAOVTestScene(t, nil, true)
+
+	grid := StrategyGrid(result.AOVs)
+	if grid == nil {
+		t.Fatal("Expected a strategy grid from BDPT AOVs")
+	}
+
+	// Find the longest path among the strategy images to know the grid's shape
+	maxVertices := 0
+	for strategy := range collectStrategies(result.AOVs) {
+		maxVertices = max(maxVertices, strategy.S+strategy.T)
+	}
+	cell := 16 + StrategyGridGap
+	if grid.Bounds().Dx() != maxVertices*cell+StrategyGridGap || grid.Bounds().Dy() != (maxVertices-1)*cell+StrategyGridGap {
+		t.Fatalf("Unexpected grid size %v for paths up to %d vertices", grid.Bounds(), maxVertices)
+	}
+
+	// Each strategy image is copied into its cell: row by path length, column by s, rows centered
+	for strategy := range collectStrategies(result.AOVs) {
+		vertices := strategy.S + strategy.T
+		x0 := StrategyGridGap + (maxVertices-vertices)*cell/2 + strategy.S*cell
+		y0 := StrategyGridGap + (vertices-2)*cell
+		img := result.AOVs[StrategyAOVName(strategy)]
+		for _, p := range [][2]int{{0, 0}, {8, 8}, {15, 15}} {
+			if got, want := grid.RGBAAt(x0+p[0], y0+p[1]), img.RGBAAt(p[0], p[1]); got != want {
+				t.Errorf("Strategy %+v pixel %v: grid has %v, image has %v", strategy, p, got, want)
+			}
+		}
+	}
+
+	if StrategyGrid(map[string]*image.RGBA{AOVDepth: result.AOVs[AOVDepth]}) != nil {
+		t.Error("Expected no grid without strategy images")
+	}
+}
+
+func collectStrategies(aovs map[string]*image.RGBA) map[integrator.Strategy]bool {
+	strategies := make(map[integrator.Strategy]bool)
+	for s := 0; s < 10; s++ {
+		for tt := 1; tt < 10; tt++ {
+			if aovs[StrategyAOVName(integrator.Strategy{S: s, T: tt})] != nil {
+				strategies[integrator.Strategy{S: s, T: tt}] = true
+			}
+		}
+	}
+	return strategies
+}
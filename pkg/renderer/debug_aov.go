@@ -0,0 +1,166 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Debug heatmaps selectable with ProgressiveConfig.DebugAOVs. Each is a false-color image (blue
+// is low, red is high) in PassResult.AOVs, named with DebugAOVPrefix: "debug_samples" and so on.
+const (
+	DebugAOVSamples    = "samples"    // Samples taken, red at the maximum samples per pixel
+	DebugAOVVariance   = "variance"   // Relative error of the pixel's mean (see PixelStats.RelativeError)
+	DebugAOVPathLength = "pathlength" // Closest-hit rays per sample: the camera ray and its bounces (and light subpaths for BDPT)
+	DebugAOVBVHCost    = "bvh"        // Time spent intersecting rays per sample: BVH traversal and shape tests
+
+	DebugAOVPrefix = "debug_"
+)
+
+// DebugAOVNames lists the debug heatmaps in the order they're documented
+var DebugAOVNames = []string{DebugAOVSamples, DebugAOVVariance, DebugAOVPathLength, DebugAOVBVHCost}
+
+// debugAOVPercentile is the share of pixels below the red end of heatmaps scaled to the image,
+// keeping a few extreme pixels (fireflies, a light's edge) from turning the rest blue
+const debugAOVPercentile = 0.99
+
+// DebugStats accumulates what the debug heatmaps show besides the sample counts and variance,
+// over all of a pixel's samples
+type DebugStats struct {
+	Rays          int64         // Closest-hit rays traced
+	IntersectTime time.Duration // Time spent in the scene's intersector
+}
+
+// ParseDebugAOVs parses a comma-separated list of debug heatmap names ("all" for every one)
+func ParseDebugAOVs(list string) ([]string, error) {
+	return parseNames(list, DebugAOVNames, "debug AOV")
+}
+
+// parseNames parses a comma-separated list of the valid names, or "all" for every one, dropping
+// repeats. kind names what the names are in errors.
+func parseNames(list string, valid []string, kind string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "all":
+			return slices.Clone(valid), nil
+		case !slices.Contains(valid, name):
+			return nil, fmt.Errorf("unknown %s %q (expected %s or all)", kind, name, strings.Join(valid, ", "))
+		case !slices.Contains(names, name):
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// assembleDebugImages creates the requested debug heatmaps from the current pixel stats, adding
+// them to images (which may be nil) under their prefixed names. It logs the value each heatmap's
+// red end stands for.
+func (pr *ProgressiveRaytracer) assembleDebugImages(images map[string]*image.RGBA) map[string]*image.RGBA {
+	if len(pr.config.DebugAOVs) == 0 {
+		return images
+	}
+	if images == nil {
+		images = make(map[string]*image.RGBA)
+	}
+
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	values := make([]float64, width*height)
+	for _, name := range pr.config.DebugAOVs {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				values[y*width+x] = debugValue(name, &pr.film.pixels[y][x])
+			}
+		}
+
+		// Sample counts have a natural scale; the others are scaled to the image
+		scale := float64(pr.config.MaxSamplesPerPixel)
+		if name != DebugAOVSamples {
+			scale = percentile(values, debugAOVPercentile)
+		}
+		images[DebugAOVPrefix+name] = heatmap(values, width, height, scale)
+
+		switch name {
+		case DebugAOVBVHCost:
+			pr.logger.Printf("Debug heatmap %s: red is %v per sample\n", name, time.Duration(scale).Round(time.Nanosecond))
+		case DebugAOVVariance:
+			pr.logger.Printf("Debug heatmap %s: red is a relative error of %.1f%%\n", name, 100*scale)
+		case DebugAOVPathLength:
+			pr.logger.Printf("Debug heatmap %s: red is %.1f rays per sample\n", name, scale)
+		default:
+			pr.logger.Printf("Debug heatmap %s: red is %.0f samples\n", name, scale)
+		}
+	}
+	return images
+}
+
+// debugValue returns a pixel's value in a debug heatmap
+func debugValue(name string, ps *PixelStats) float64 {
+	if name == DebugAOVSamples {
+		return float64(ps.SampleCount)
+	}
+	if ps.SampleCount == 0 {
+		return 0
+	}
+	switch name {
+	case DebugAOVVariance:
+		return ps.RelativeError()
+	case DebugAOVPathLength:
+		return float64(ps.Debug.Rays) / float64(ps.SampleCount)
+	default:
+		return float64(ps.Debug.IntersectTime) / float64(ps.SampleCount)
+	}
+}
+
+// percentile returns the value that the fraction p of the values are at or below
+func percentile(values []float64, p float64) float64 {
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+}
+
+// heatmap colors values (one per pixel, row by row) from blue at 0 to red at scale
+func heatmap(values []float64, width, height int, scale float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := 0.0
+			if scale > 0 {
+				t = values[y*width+x] / scale
+			}
+			img.SetRGBA(x, y, falseColor(t))
+		}
+	}
+	return img
+}
+
+// falseColorStops are the colors of a heatmap from low to high
+var falseColorStops = []color.RGBA{
+	{R: 0, G: 0, B: 128, A: 255},   // Dark blue
+	{R: 0, G: 96, B: 255, A: 255},  // Blue
+	{R: 0, G: 224, B: 224, A: 255}, // Cyan
+	{R: 64, G: 224, B: 64, A: 255}, // Green
+	{R: 255, G: 224, B: 0, A: 255}, // Yellow
+	{R: 255, G: 0, B: 0, A: 255},   // Red
+}
+
+// falseColor maps t in [0,1] (clamped) to a color between the stops
+func falseColor(t float64) color.RGBA {
+	t = max(0, min(1, t)) * float64(len(falseColorStops)-1)
+	i := min(int(t), len(falseColorStops)-2)
+	f := t - float64(i)
+	a, b := falseColorStops[i], falseColorStops[i+1]
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + f*(float64(b)-float64(a)) + 0.5)
+	}
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: 255}
+}
@@ -0,0 +1,86 @@
+package renderer
+
+import (
+	"context"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestParseDebugAOVs(t *testing.T) {
+	names, err := ParseDebugAOVs(" bvh,samples,bvh ")
+	if err != nil || !slices.Equal(names, []string{DebugAOVBVHCost, DebugAOVSamples}) {
+		t.Errorf("Expected bvh and samples once each, got %v (%v)", names, err)
+	}
+	if names, err := ParseDebugAOVs("all"); err != nil || !slices.Equal(names, DebugAOVNames) {
+		t.Errorf("Expected every heatmap for all, got %v (%v)", names, err)
+	}
+	if names, err := ParseDebugAOVs(""); err != nil || names != nil {
+		t.Errorf("Expected no heatmaps for an empty list, got %v (%v)", names, err)
+	}
+	if _, err := ParseDebugAOVs("samples,bogus"); err == nil {
+		t.Error("Expected an error for an unknown heatmap")
+	}
+}
+
+func TestFalseColor(t *testing.T) {
+	if c := falseColor(-1); c != (color.RGBA{R: 0, G: 0, B: 128, A: 255}) {
+		t.Errorf("Expected dark blue at 0, got %v", c)
+	}
+	if c := falseColor(2); c != (color.RGBA{R: 255, G: 0, B: 0, A: 255}) {
+		t.Errorf("Expected red at 1, got %v", c)
+	}
+	if c := falseColor(0.5); c.R == 0 || c.G == 0 {
+		t.Errorf("Expected green toward the middle, got %v", c)
+	}
+}
+
+func TestRenderProgressive_DebugAOVs(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 8
+
+	config := ProgressiveConfig{
+		TileSize:           8,
+		InitialSamples:     1,
+		MaxSamplesPerPixel: 4,
+		MaxPasses:          2,
+		NumWorkers:         1,
+		DebugAOVs:          DebugAOVNames,
+	}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+
+	passChan, _, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{})
+	var last PassResult
+	for result := range passChan {
+		last = result
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, name := range DebugAOVNames {
+		img := last.AOVs[DebugAOVPrefix+name]
+		if img == nil {
+			t.Errorf("Expected a %s heatmap, got AOVs %v", name, last.AOVs)
+			continue
+		}
+		if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 8 {
+			t.Errorf("Expected the %s heatmap to match the image size, got %v", name, img.Bounds())
+		}
+	}
+
+	// Every pixel traced at least its camera ray
+	for y := range raytracer.film.pixels {
+		for x, ps := range raytracer.film.pixels[y] {
+			if ps.SampleCount > 0 && ps.Debug.Rays < int64(ps.SampleCount) {
+				t.Fatalf("Pixel (%d,%d): expected a ray per sample, got %d rays for %d samples", x, y, ps.Debug.Rays, ps.SampleCount)
+			}
+		}
+	}
+}
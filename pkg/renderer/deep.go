@@ -0,0 +1,145 @@
+package renderer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+const (
+	deepBinTolerance = 0.01 // How far from a bin's depth, relative to it, samples of the same surface land
+	maxDeepBins      = 16   // Most bins a pixel keeps; further depths join the nearest bin
+)
+
+// DeepStats sorts a pixel's samples into bins by the depth of what their camera ray hits, for deep
+// images (see ProgressiveConfig.Deep). Samples within deepBinTolerance of a bin's depth join it,
+// so each surface the pixel sees gets a bin; samples that hit nothing share a bin at infinity.
+type DeepStats struct {
+	Bins []DeepBin
+}
+
+// DeepBin accumulates the samples of one depth
+type DeepBin struct {
+	DepthAccum float64   // Sum of the samples' depths
+	Count      int       // Number of samples
+	ColorAccum core.Vec3 // Filter-weighted sum of the samples' colors, like PixelStats.ColorAccum
+	WeightSum  float64   // Sum of the samples' filter weights, the pixel's coverage by the bin
+}
+
+// Depth returns the bin's average depth
+func (b *DeepBin) Depth() float64 {
+	return b.DepthAccum / float64(b.Count)
+}
+
+// AddSample adds a sample of color, weighted by the reconstruction filter, whose camera ray saw
+// something at depth (+Inf for nothing)
+func (ds *DeepStats) AddSample(depth float64, color core.Vec3, weight float64) {
+	bin := ds.bin(depth)
+	if bin == nil {
+		ds.Bins = append(ds.Bins, DeepBin{})
+		bin = &ds.Bins[len(ds.Bins)-1]
+	}
+	bin.DepthAccum += depth
+	bin.Count++
+	bin.ColorAccum = bin.ColorAccum.Add(color.Multiply(weight))
+	bin.WeightSum += weight
+}
+
+// bin returns the bin a sample at depth joins, or nil for a new one
+func (ds *DeepStats) bin(depth float64) *DeepBin {
+	nearest, nearestDistance := -1, math.Inf(1)
+	for i := range ds.Bins {
+		binDepth := ds.Bins[i].Depth()
+		if math.IsInf(binDepth, 1) != math.IsInf(depth, 1) {
+			continue
+		}
+		distance := math.Abs(depth - binDepth)
+		if math.IsInf(depth, 1) || distance <= deepBinTolerance*binDepth {
+			return &ds.Bins[i]
+		}
+		if distance < nearestDistance {
+			nearest, nearestDistance = i, distance
+		}
+	}
+	if len(ds.Bins) < maxDeepBins {
+		return nil
+	}
+	if nearest < 0 {
+		nearest = len(ds.Bins) - 1 // The first sample to see nothing once the bins are full
+	}
+	return &ds.Bins[nearest]
+}
+
+// recordDeepSample adds a sample to its pixel's depth bins, tracing the camera ray's first hit
+// again (as the surface AOVs do) to find its depth along the view direction
+func (tr *TileRenderer) recordDeepSample(ray core.Ray, color core.Vec3, weight float64, ds *DeepStats) {
+	depth := math.Inf(1)
+	if hit, isHit := geometry.HitVisible(tr.scene.Intersector, ray, cameraRayTMin, math.Inf(1), material.CameraRays); isHit {
+		_, depth, _ = tr.scene.Camera.ProjectPoint(hit.Point)
+	}
+	ds.AddSample(depth, color, weight)
+}
+
+// DeepImage returns the render so far as a deep image, or nil unless ProgressiveConfig.Deep is
+// set. Each pixel has a sample per depth bin, front to back, whose alpha is the bin's share of the
+// coverage left by the bins in front of it, so compositing the samples over each other gives the
+// image. Light tracing splats have no depth of their own, so a pixel's surfaces share them by
+// coverage.
+func (pr *ProgressiveRaytracer) DeepImage() *loaders.DeepImage {
+	if !pr.config.Deep {
+		return nil
+	}
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	img := &loaders.DeepImage{Width: width, Height: height, Pixels: make([][]loaders.DeepSample, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Pixels[y*width+x] = pr.film.deepSamples(x, y)
+		}
+	}
+	return img
+}
+
+// deepSamples returns a pixel's deep samples, front to back
+func (f *Film) deepSamples(x, y int) []loaders.DeepSample {
+	ps := &f.pixels[y][x]
+	if ps.Deep == nil || ps.WeightSum <= 0 {
+		return nil
+	}
+	bins := append([]DeepBin(nil), ps.Deep.Bins...)
+	sort.Slice(bins, func(i, j int) bool { return bins[i].Depth() < bins[j].Depth() })
+
+	// The splats, in the units of the color sums, go to the surfaces rather than the sky if any
+	splats := f.splats[y][x].Multiply(f.splatScale * ps.WeightSum)
+	surfaces := 0.0
+	for _, bin := range bins {
+		if !math.IsInf(bin.Depth(), 1) {
+			surfaces += bin.WeightSum
+		}
+	}
+
+	samples := make([]loaders.DeepSample, 0, len(bins))
+	remaining := ps.WeightSum
+	for _, bin := range bins {
+		if remaining <= 0 {
+			break // Negative filter lobes can leave a bin covering the rest
+		}
+		color := bin.ColorAccum
+		if surfaces > 0 && !math.IsInf(bin.Depth(), 1) {
+			color = color.Add(splats.Multiply(bin.WeightSum / surfaces))
+		} else if surfaces == 0 {
+			color = color.Add(splats)
+		}
+		samples = append(samples, loaders.DeepSample{
+			Depth: bin.Depth(),
+			Color: color.Multiply(1 / remaining),
+			Alpha: math.Max(0, math.Min(1, bin.WeightSum/remaining)),
+		})
+		remaining -= bin.WeightSum
+	}
+	return samples
+}
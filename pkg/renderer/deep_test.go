@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestDeepStats_Bins(t *testing.T) {
+	var ds DeepStats
+	white := core.NewVec3(1, 1, 1)
+	ds.AddSample(2, white, 1)
+	ds.AddSample(2.01, white, 0.5) // The same surface
+	ds.AddSample(3, white, 1)
+	ds.AddSample(math.Inf(1), white, 1)
+	ds.AddSample(math.Inf(1), white, 1)
+	if len(ds.Bins) != 3 {
+		t.Fatalf("Expected bins at 2, 3 and infinity, got %+v", ds.Bins)
+	}
+	if bin := ds.Bins[0]; bin.Count != 2 || bin.WeightSum != 1.5 || math.Abs(bin.Depth()-2.005) > 1e-12 {
+		t.Errorf("Expected two samples of weight 1.5 at depth 2.005, got %+v", bin)
+	}
+	if bin := ds.Bins[2]; bin.Count != 2 || !math.IsInf(bin.Depth(), 1) {
+		t.Errorf("Expected the samples that hit nothing together at infinity, got %+v", bin)
+	}
+
+	// Once the bins are full, samples join the nearest
+	for i := 0; i < 2*maxDeepBins; i++ {
+		ds.AddSample(10+float64(i), white, 1)
+	}
+	if len(ds.Bins) != maxDeepBins {
+		t.Errorf("Expected at most %d bins, got %d", maxDeepBins, len(ds.Bins))
+	}
+}
+
+func TestDeepImage_FlattensToImage(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 16
+	s.SamplingConfig.AdaptiveMinSamples = 1 // Every sample, so the edges see both sides
+	s.Camera = geometry.NewCamera(geometry.CameraConfig{
+		Center:      core.NewVec3(0, 0, 0),
+		LookAt:      core.NewVec3(0, 0, -1),
+		Up:          core.NewVec3(0, 1, 0),
+		Width:       16,
+		AspectRatio: 1.0,
+		VFov:        90.0, // Wide enough to see past the sphere's edges
+	})
+	s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+	// BDPT's light tracing splats are shared among the surfaces
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 8, MaxPasses: 2, NumWorkers: 2, Seed: 3, Deep: true}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewBDPTIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	for pass := 1; pass <= config.MaxPasses; pass++ {
+		if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+			t.Fatalf("RenderPass failed: %v", err)
+		}
+	}
+
+	deep := raytracer.DeepImage()
+	colors, alphas := deep.Flatten()
+	edges := 0
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			samples := deep.Pixels[y*16+x]
+			for i := 1; i < len(samples); i++ {
+				if samples[i].Depth <= samples[i-1].Depth {
+					t.Fatalf("Pixel (%d,%d): expected samples front to back, got %+v", x, y, samples)
+				}
+			}
+			if len(samples) > 1 && math.IsInf(samples[len(samples)-1].Depth, 1) {
+				edges++ // Sees both the sphere and past it
+			}
+			if want := raytracer.film.color(x, y); !vecClose(colors[y*16+x], want, 1e-9) || math.Abs(alphas[y*16+x]-1) > 1e-9 {
+				t.Fatalf("Pixel (%d,%d): expected the deep samples to composite to %v, opaque, got %v with alpha %g", x, y, want, colors[y*16+x], alphas[y*16+x])
+			}
+		}
+	}
+	if edges == 0 {
+		t.Error("Expected pixels on the sphere's edge to have samples on it and beyond it")
+	}
+
+	// The sphere's front is 0.5 in front of its center, 1 away. A pixel this large spans more than
+	// a bin's tolerance of its curve, so it may have a few bins there, but none behind.
+	center := deep.Pixels[8*16+8]
+	if len(center) == 0 || math.Abs(center[0].Depth-0.5) > 0.01 || center[len(center)-1].Depth > 0.55 {
+		t.Errorf("Expected the center pixel to see only the sphere's front at depth 0.5, got %+v", center)
+	}
+
+	raytracer.config.Deep = false
+	if raytracer.DeepImage() != nil {
+		t.Error("Expected no deep image unless enabled")
+	}
+}
@@ -0,0 +1,104 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+const (
+	exposureMinStop = -12  // Luminance 2^-12, the darkest histogram bin
+	exposureMaxStop = 4    // Luminance 2^4, the brightest
+	exposureBins    = 64   // Quarter stops
+	middleGray      = 0.18 // Luminance an average scene is exposed to
+	clipAllowance   = 0.01 // Fraction of the pixels a suggested exposure may clip, for lights and highlights
+	exposureEVLimit = 8.0  // Largest adjustment suggested, for images that are nearly black
+)
+
+// Exposure describes how an image's linear luminance is distributed, for exposure meters: a
+// histogram over stops, how much of the image the display clips, and the exposure adjustment
+// that would bring its key to middle gray
+type Exposure struct {
+	Histogram []int   `json:"histogram"` // Nonblack pixels by log2 luminance in equal bins from MinStop to MaxStop; pixels beyond fall in the end bins
+	MinStop   float64 `json:"minStop"`
+	MaxStop   float64 `json:"maxStop"`
+
+	Pixels          int     `json:"pixels"`
+	Black           int     `json:"black"`           // Pixels with no (or no finite) luminance, left out of the histogram
+	Clipped         int     `json:"clipped"`         // Pixels with a channel above 1, which the display clamps
+	ClippedFraction float64 `json:"clippedFraction"` // Clipped over Pixels
+	Key             float64 `json:"key"`             // Log-average (geometric mean) luminance of the nonblack pixels
+
+	// Stops to scale the image by (positive brightens) to bring the key to middle gray, held back
+	// so no more than 1% of the pixels clip, within ±8
+	SuggestedEV float64 `json:"suggestedEv"`
+}
+
+// AnalyzeExposure measures the exposure of an image's linear colors
+func AnalyzeExposure(colors []core.Vec3) Exposure {
+	e := Exposure{
+		Histogram: make([]int, exposureBins),
+		MinStop:   exposureMinStop,
+		MaxStop:   exposureMaxStop,
+		Pixels:    len(colors),
+	}
+	bin := func(value float64) int {
+		stop := math.Log2(value)
+		i := int(math.Floor((stop - exposureMinStop) / (exposureMaxStop - exposureMinStop) * exposureBins))
+		return max(0, min(exposureBins-1, i))
+	}
+
+	// The brightest channels are binned too, to find the exposure at which the allowance clips
+	brightest := make([]int, exposureBins)
+	logSum := 0.0
+	for _, c := range colors {
+		if c.MaxComponent() > 1 {
+			e.Clipped++
+		}
+		luminance := c.Luminance()
+		if !(luminance > 0) || math.IsInf(luminance, 0) {
+			e.Black++
+			continue
+		}
+		e.Histogram[bin(luminance)]++
+		brightest[bin(c.MaxComponent())]++
+		logSum += math.Log(luminance)
+	}
+	if e.Pixels == 0 {
+		return e
+	}
+	e.ClippedFraction = float64(e.Clipped) / float64(e.Pixels)
+	lit := e.Pixels - e.Black
+	if lit == 0 {
+		return e
+	}
+	e.Key = math.Exp(logSum / float64(lit))
+
+	// Scaling by 2^-stop brings a channel at 2^stop to the clip level of 1. The stop is the upper
+	// edge of the bin holding the pixel at the allowance from the top.
+	ev := math.Log2(middleGray / e.Key)
+	allowance := int(clipAllowance * float64(e.Pixels))
+	above := 0
+	for i := exposureBins - 1; i >= 0; i-- {
+		above += brightest[i]
+		if above > allowance {
+			upper := exposureMinStop + float64(i+1)*(exposureMaxStop-exposureMinStop)/exposureBins
+			ev = math.Min(ev, -upper)
+			break
+		}
+	}
+	e.SuggestedEV = math.Max(-exposureEVLimit, math.Min(exposureEVLimit, ev))
+	return e
+}
+
+// Exposure measures the exposure of the image accumulated so far, splats included. Like
+// Accumulation, it reads the film, so it's only safe between passes.
+func (pr *ProgressiveRaytracer) Exposure() Exposure {
+	colors := make([]core.Vec3, 0, pr.film.width*pr.film.height)
+	for y := 0; y < pr.film.height; y++ {
+		for x := 0; x < pr.film.width; x++ {
+			colors = append(colors, pr.film.color(x, y))
+		}
+	}
+	return AnalyzeExposure(colors)
+}
@@ -0,0 +1,57 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestAnalyzeExposure(t *testing.T) {
+	gray := func(v float64) core.Vec3 { return core.NewVec3(v, v, v) }
+
+	// A dim image: its key is two stops under middle gray
+	colors := make([]core.Vec3, 100)
+	for i := range colors {
+		colors[i] = gray(0.045)
+	}
+	colors[0] = core.Vec3{}
+	e := AnalyzeExposure(colors)
+	if e.Pixels != 100 || e.Black != 1 || e.Clipped != 0 {
+		t.Errorf("Expected 100 pixels, 1 black and none clipped, got %+v", e)
+	}
+	if math.Abs(e.Key-0.045) > 1e-9 || math.Abs(e.SuggestedEV-2) > 1e-9 {
+		t.Errorf("Expected key 0.045 and +2 EV, got key %f and %+.2f EV", e.Key, e.SuggestedEV)
+	}
+	total := 0
+	for _, n := range e.Histogram {
+		total += n
+	}
+	if total != 99 || e.Histogram[int((math.Log2(0.045)-e.MinStop)/(e.MaxStop-e.MinStop)*float64(len(e.Histogram)))] != 99 {
+		t.Errorf("Expected the 99 lit pixels in the bin of luminance 0.045, got %v", e.Histogram)
+	}
+
+	// Brightening the dim pixels to middle gray would clip the 5% of highlights at 0.5, so the
+	// suggestion is held back to bring them just to 1
+	for i := 1; i <= 5; i++ {
+		colors[i] = gray(0.5)
+	}
+	if e := AnalyzeExposure(colors); e.SuggestedEV > 1+1e-9 || e.SuggestedEV < 0.75 {
+		t.Errorf("Expected about +1 EV, held back by the highlights, got %+.2f", e.SuggestedEV)
+	}
+
+	// An overexposed image with a light: the light clips, but is under the allowance
+	for i := range colors {
+		colors[i] = gray(2)
+	}
+	colors[0] = gray(100)
+	e = AnalyzeExposure(colors)
+	key := math.Exp((99*math.Log(2) + math.Log(100)) / 100)
+	if e.Clipped != 100 || e.ClippedFraction != 1 || math.Abs(e.SuggestedEV-math.Log2(0.18/key)) > 1e-9 {
+		t.Errorf("Expected every pixel clipped and %.2f EV, got %+v", math.Log2(0.18/key), e)
+	}
+
+	if e := AnalyzeExposure([]core.Vec3{{}, {}}); e.Black != 2 || e.SuggestedEV != 0 {
+		t.Errorf("Expected no suggestion for a black image, got %+v", e)
+	}
+}
@@ -0,0 +1,65 @@
+package renderer
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Film accumulates a render's image. Camera samples go into each pixel's PixelStats, weighted by
+// the reconstruction filter and normalized by the pixel's own weight sum. Light tracing splats
+// (BDPT's t=1 strategies) land on the film wherever their light paths reach it, not where the
+// pixel being sampled is, so they go into a separate buffer normalized by the samples taken over
+// the whole film: dividing them by the receiving pixel's sample count would over-weight the
+// light reaching pixels that adaptive sampling stopped early, and under-weight the rest.
+type Film struct {
+	width  int
+	height int
+	pixels [][]PixelStats // Camera sample statistics (global image coordinates)
+	splats [][]core.Vec3  // Sums of the splats each pixel received
+
+	splatScale float64 // Pixels per sample taken, the splats' normalization (0 before any sample)
+}
+
+// newFilm creates an empty width x height film
+func newFilm(width, height int) *Film {
+	f := &Film{width: width, height: height, pixels: make([][]PixelStats, height), splats: make([][]core.Vec3, height)}
+	for y := range f.pixels {
+		f.pixels[y] = make([]PixelStats, width)
+		f.splats[y] = make([]core.Vec3, width)
+	}
+	return f
+}
+
+// addSplats adds a pass's splats, in canonical order so the sums don't depend on which worker
+// produced which. Splats are addressed in image pixels; scale maps them to the film's pixels.
+// Call it once the pass's samples are in, as it updates the splats' normalization.
+func (f *Film) addSplats(splats []SplatXY, scale int) {
+	sortSplats(splats)
+	for _, splat := range splats {
+		if splat.X < 0 || splat.Y < 0 {
+			continue
+		}
+		x, y := splat.X/scale, splat.Y/scale
+		if y < f.height && x < f.width {
+			f.splats[y][x] = f.splats[y][x].Add(splat.Color)
+			if aov := f.pixels[y][x].AOV; aov != nil {
+				aov.AddSplat(splat.Strategy, splat.Color)
+			}
+		}
+	}
+
+	samples := 0
+	for y := range f.pixels {
+		for x := range f.pixels[y] {
+			samples += f.pixels[y][x].SampleCount
+		}
+	}
+	f.splatScale = 0
+	if samples > 0 {
+		f.splatScale = float64(f.width*f.height) / float64(samples)
+	}
+}
+
+// color returns a pixel's current color estimate, splats included
+func (f *Film) color(x, y int) core.Vec3 {
+	return f.pixels[y][x].GetColor().Add(f.splats[y][x].Multiply(f.splatScale))
+}
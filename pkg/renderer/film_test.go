@@ -0,0 +1,35 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestFilm_SplatsNormalizedByAllSamples(t *testing.T) {
+	// Pixel 0 stopped after one sample while pixel 1 took seven: 8 samples over 2 pixels
+	film := newFilm(2, 1)
+	film.pixels[0][0].AddSample(core.NewVec3(1, 1, 1))
+	for i := 0; i < 7; i++ {
+		film.pixels[0][1].AddSample(core.NewVec3(1, 1, 1))
+	}
+	splat := core.NewVec3(0.5, 0, 0)
+	film.addSplats([]SplatXY{{X: 0, Y: 0, Color: splat}, {X: 1, Y: 0, Color: splat}, {X: 5, Y: 0, Color: splat}}, 1)
+
+	// The same light reaches both pixels, however many samples each took
+	want := core.NewVec3(1.125, 1, 1)
+	for x := 0; x < 2; x++ {
+		if got := film.color(x, 0); !got.Equals(want) {
+			t.Errorf("Pixel %d: expected %v, got %v", x, want, got)
+		}
+	}
+}
+
+func TestPixelStats_WeightedSamples(t *testing.T) {
+	var ps PixelStats
+	ps.AddWeightedSample(core.NewVec3(1, 1, 1), 1.5)
+	ps.AddWeightedSample(core.NewVec3(3, 3, 3), 0.5)
+	if ps.SampleCount != 2 || ps.WeightSum != 2 || !ps.GetColor().Equals(core.NewVec3(1.5, 1.5, 1.5)) {
+		t.Errorf("Expected 2 samples weighing 2 averaging 1.5, got %d weighing %v averaging %v", ps.SampleCount, ps.WeightSum, ps.GetColor())
+	}
+}
@@ -0,0 +1,139 @@
+package renderer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// filterTableSize is the number of bins the sampling distribution of a filter's axis is tabulated in
+const filterTableSize = 64
+
+// pixelFilter is a separable reconstruction filter. Rather than spread every sample over the
+// pixels around it, which would tie neighboring tiles together, each pixel draws its camera
+// samples' offsets from the filter's shape (filter importance sampling) and weighs each by the
+// filter over the sampling density, so a pixel's samples still average to its filtered value.
+// Light tracing splats, which land wherever their paths reach the image, are spread over the
+// pixels in the filter's reach instead.
+type pixelFilter struct {
+	radius   float64
+	eval     func(x float64) float64 // The filter along one axis, x in pixels from the pixel center
+	integral float64                 // Integral of eval over [-radius, radius]
+	cdf      []float64               // Cumulative distribution of |eval| over the bins, cdf[0] = 0
+	pdf      []float64               // Density of the bins, integrating to 1
+}
+
+// newPixelFilter creates the reconstruction filter a sampling config selects. The box filter of
+// radius 0.5 is what pixels do unfiltered, and gives nil.
+func newPixelFilter(config scene.SamplingConfig) (*pixelFilter, error) {
+	name, radius, err := config.PixelFilter()
+	if err != nil {
+		return nil, err
+	}
+	if name == "box" && radius == 0.5 {
+		return nil, nil
+	}
+
+	f := &pixelFilter{radius: radius}
+	switch name {
+	case "box":
+		f.eval = func(x float64) float64 { return 1 }
+	case "tent":
+		f.eval = func(x float64) float64 { return math.Max(0, radius-math.Abs(x)) }
+	case "gaussian":
+		const sigma = 0.5
+		edge := math.Exp(-radius * radius / (2 * sigma * sigma))
+		f.eval = func(x float64) float64 { return math.Max(0, math.Exp(-x*x/(2*sigma*sigma))-edge) }
+	case "mitchell":
+		f.eval = func(x float64) float64 { return mitchell(2 * x / radius) }
+	case "blackman-harris":
+		f.eval = func(x float64) float64 {
+			t := 2 * math.Pi * (x/(2*radius) + 0.5)
+			return 0.35875 - 0.48829*math.Cos(t) + 0.14128*math.Cos(2*t) - 0.01168*math.Cos(3*t)
+		}
+	}
+
+	// Tabulate |eval| for sampling, and integrate eval finely enough that weights average to 1
+	const steps = 4096
+	for k := 0; k < steps; k++ {
+		f.integral += f.eval(-radius+(float64(k)+0.5)*2*radius/steps) * 2 * radius / steps
+	}
+	f.cdf = make([]float64, filterTableSize+1)
+	f.pdf = make([]float64, filterTableSize)
+	binWidth := 2 * radius / filterTableSize
+	for k := range f.pdf {
+		f.pdf[k] = math.Abs(f.eval(-radius + (float64(k)+0.5)*binWidth))
+		f.cdf[k+1] = f.cdf[k] + f.pdf[k]*binWidth
+	}
+	total := f.cdf[filterTableSize]
+	for k := range f.pdf {
+		f.pdf[k] /= total
+		f.cdf[k+1] /= total
+	}
+	return f, nil
+}
+
+// mitchell is the Mitchell-Netravali cubic with B = C = 1/3, over [-2, 2]
+func mitchell(x float64) float64 {
+	const b, c = 1.0 / 3, 1.0 / 3
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	default:
+		return 0
+	}
+}
+
+// sample maps a uniform sample in [0, 1)² to a position within a pixel (possibly outside it, by up
+// to the radius), and the weight of a camera sample there
+func (f *pixelFilter) sample(u core.Vec2) (core.Vec2, float64) {
+	x, wx := f.sample1D(u.X)
+	y, wy := f.sample1D(u.Y)
+	return core.NewVec2(x+0.5, y+0.5), wx * wy
+}
+
+// sample1D samples an offset from the pixel center along one axis, with its weight
+func (f *pixelFilter) sample1D(u float64) (float64, float64) {
+	k := min(sort.SearchFloat64s(f.cdf[1:], u), filterTableSize-1)
+	for k < filterTableSize-1 && f.pdf[k] == 0 { // Bins where the filter is zero cover no probability
+		k++
+	}
+	binWidth := 2 * f.radius / filterTableSize
+	x := -f.radius + (float64(k)+(u-f.cdf[k])/(f.cdf[k+1]-f.cdf[k]))*binWidth
+	return x, f.eval(x) / (f.pdf[k] * f.integral)
+}
+
+// splat spreads a light tracing contribution reaching the image at a continuous position over
+// the pixels within the filter's radius, in proportion to the filter, so that it adds its full
+// color to the image
+func (f *pixelFilter) splat(queue *SplatQueue, position core.Vec2, color core.Vec3, strategy integrator.Strategy, width, height int) {
+	x0, x1 := int(math.Ceil(position.X-0.5-f.radius)), int(math.Floor(position.X-0.5+f.radius))
+	y0, y1 := int(math.Ceil(position.Y-0.5-f.radius)), int(math.Floor(position.Y-0.5+f.radius))
+	var total float64
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			total += f.weight(position, x, y)
+		}
+	}
+	if total <= 0 {
+		return
+	}
+	for y := max(y0, 0); y <= min(y1, height-1); y++ {
+		for x := max(x0, 0); x <= min(x1, width-1); x++ {
+			if w := f.weight(position, x, y); w != 0 {
+				queue.AddStrategySplat(x, y, color.Multiply(w/total), strategy)
+			}
+		}
+	}
+}
+
+// weight evaluates the filter for pixel (x, y) at a continuous image position
+func (f *pixelFilter) weight(position core.Vec2, x, y int) float64 {
+	return f.eval(position.X-float64(x)-0.5) * f.eval(position.Y-float64(y)-0.5)
+}
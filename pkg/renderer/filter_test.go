@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestPixelFilter_WeightsAverageToOne(t *testing.T) {
+	for _, name := range scene.PixelFilters {
+		f, err := newPixelFilter(scene.SamplingConfig{Filter: name, FilterRadius: 1.5})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// A stratified grid of samples integrates the weights: each pixel's samples average to its
+		// filtered value only if they sum to one on average
+		const n = 256
+		var sum float64
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				position, weight := f.sample(core.NewVec2((float64(i)+0.5)/n, (float64(j)+0.5)/n))
+				if math.Abs(position.X-0.5) > 1.5 || math.Abs(position.Y-0.5) > 1.5 {
+					t.Fatalf("%s: sample at %v is outside the radius", name, position)
+				}
+				sum += weight
+			}
+		}
+		if mean := sum / (n * n); math.Abs(mean-1) > 0.01 {
+			t.Errorf("%s: expected weights averaging 1, got %f", name, mean)
+		}
+	}
+}
+
+func TestPixelFilter_Defaults(t *testing.T) {
+	if f, err := newPixelFilter(scene.SamplingConfig{}); f != nil || err != nil {
+		t.Errorf("Expected no filter for the default box, got %v (%v)", f, err)
+	}
+	if f, err := newPixelFilter(scene.SamplingConfig{Filter: "gaussian"}); err != nil || f.radius != 1.5 {
+		t.Errorf("Expected the Gaussian's default radius 1.5, got %v (%v)", f, err)
+	}
+	for _, config := range []scene.SamplingConfig{{Filter: "sinc"}, {Filter: "tent", FilterRadius: 8}} {
+		if _, err := newPixelFilter(config); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestPixelFilter_SplatKeepsItsColor(t *testing.T) {
+	f, err := newPixelFilter(scene.SamplingConfig{Filter: "mitchell"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewSplatQueue()
+	color := core.NewVec3(1, 2, 3)
+	f.splat(queue, core.NewVec2(10.3, 7.8), color, integrator.Strategy{S: 2, T: 1}, 20, 20)
+
+	var sum core.Vec3
+	splats := queue.GetAllSplats()
+	for _, splat := range splats {
+		sum = sum.Add(splat.Color)
+		if splat.Strategy.S != 2 {
+			t.Errorf("Expected the splat's strategy kept, got %+v", splat.Strategy)
+		}
+	}
+	if len(splats) < 9 || sum.Subtract(color).Length() > 1e-9 {
+		t.Errorf("Expected the color spread over the pixels around (10, 7), got %d splats summing to %v", len(splats), sum)
+	}
+}
+
+func TestRenderPass_Filtered(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 8
+	s.SamplingConfig.AdaptiveMinSamples = 1 // Every pixel takes its maximum
+	s.SamplingConfig.Filter = "blackman-harris"
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 4, MaxPasses: 1, NumWorkers: 2}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	if _, _, err := raytracer.RenderPass(context.Background(), 1, nil); err != nil {
+		t.Fatalf("RenderPass failed: %v", err)
+	}
+	if raytracer.film.pixels[0][0].SampleCount != 4 {
+		t.Errorf("Expected 4 samples, got %d", raytracer.film.pixels[0][0].SampleCount)
+	}
+
+	s.SamplingConfig.Filter = "lanczos"
+	if _, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{}); err == nil {
+		t.Error("Expected an error for an unknown filter")
+	}
+}
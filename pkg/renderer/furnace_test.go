@@ -0,0 +1,207 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// furnaceRadiance is the radiance of the environment in the white furnace tests
+const furnaceRadiance = 0.5
+
+// createFurnaceScene creates a white furnace: spheres of a material that absorbs nothing, lit by
+// a uniform environment. However the light bounces between them, every pixel sees the
+// environment's radiance. The scene is scaled by scale, which mustn't change that.
+func createFurnaceScene(m material.Material, scale float64) *scene.Scene {
+	// Touching spheres, so light reflects between them too
+	return createFurnaceSceneWith([]geometry.Shape{
+		geometry.NewSphere(core.NewVec3(-0.5*scale, 0, -3*scale), 0.5*scale, m),
+		geometry.NewSphere(core.NewVec3(0.5*scale, 0, -3*scale), 0.5*scale, m),
+		geometry.NewSphere(core.NewVec3(0, 0.8*scale, -3.3*scale), 0.45*scale, m),
+	}, scale)
+}
+
+// createFurnaceSceneWith creates a white furnace of the given shapes, viewed from the origin
+// looking down -z at 3*scale
+func createFurnaceSceneWith(shapes []geometry.Shape, scale float64) *scene.Scene {
+	samplingConfig := scene.SamplingConfig{
+		Width: 16, Height: 16,
+		MaxDepth: 64, SamplesPerPixel: 64,
+		RussianRouletteMinBounces: 3,
+		AdaptiveMinSamples:        1, // Every pixel takes every sample
+	}
+	camera := geometry.NewCamera(geometry.CameraConfig{
+		Center: core.NewVec3(0, 0, 0),
+		LookAt: core.NewVec3(0, 0, -3*scale),
+		Up:     core.NewVec3(0, 1, 0),
+		Width:  samplingConfig.Width, AspectRatio: 1, VFov: 40,
+	})
+	s := &scene.Scene{
+		Shapes:         shapes,
+		Lights:         []lights.Light{lights.NewUniformInfiniteLight(core.NewVec3(furnaceRadiance, furnaceRadiance, furnaceRadiance))},
+		Camera:         camera,
+		SamplingConfig: samplingConfig,
+	}
+	s.Preprocess()
+	return s
+}
+
+func TestWhiteFurnace(t *testing.T) {
+	white := core.NewVec3(1, 1, 1)
+	materials := []struct {
+		name     string
+		material material.Material
+	}{
+		{"lambertian", material.NewLambertian(white)},
+		{"mirror", material.NewMetal(white, 0)},
+		{"glass", material.NewDielectric(1.5)},
+		{"coated lambertian", material.NewLayered(material.NewDielectric(1.5), material.NewLambertian(white))},
+		{"lambertian and mirror mix", material.NewMix(material.NewLambertian(white), material.NewMetal(white, 0), 0.5)},
+	}
+	for _, m := range materials {
+		for _, in := range furnaceIntegrators {
+			for _, scale := range []float64{1, 100} {
+				t.Run(fmt.Sprintf("%s/%s/scale %g", m.name, in.name, scale), func(t *testing.T) {
+					checkFurnace(t, createFurnaceScene(m.material, scale), in.new)
+				})
+			}
+		}
+	}
+}
+
+func TestWhiteFurnace_Volume(t *testing.T) {
+	// A volume that absorbs nothing, dense enough that most light scatters in it many times
+	values := make([]float64, 4*4*4)
+	for i := range values {
+		values[i] = float64(i%3+1) / 3
+	}
+	for _, in := range furnaceIntegrators {
+		for _, scale := range []float64{1, 100} {
+			t.Run(fmt.Sprintf("%s/scale %g", in.name, scale), func(t *testing.T) {
+				bounds := geometry.NewAABB(core.NewVec3(-0.8, -0.8, -3.8).Multiply(scale), core.NewVec3(0.8, 0.8, -2.2).Multiply(scale))
+				grid, err := geometry.NewDensityGrid(bounds, 4, 4, 4, values)
+				if err != nil {
+					t.Fatalf("NewDensityGrid failed: %v", err)
+				}
+				phase := material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0.5)
+				volume := geometry.NewVolume(grid, 4/scale, phase)
+				checkFurnace(t, createFurnaceSceneWith([]geometry.Shape{volume}, scale), in.new)
+			})
+		}
+	}
+}
+
+func TestWhiteFurnace_GlowingVolume(t *testing.T) {
+	// A medium that glows with the environment's radiance is in equilibrium with it: what each
+	// collision absorbs it emits again, so the furnace still shows the environment everywhere.
+	// Weighting the emission by MIS, as if light sampling could find it too, would darken it.
+	values := make([]float64, 4*4*4)
+	for i := range values {
+		values[i] = float64(i%3+1) / 3
+	}
+	for _, in := range furnaceIntegrators {
+		t.Run(in.name, func(t *testing.T) {
+			bounds := geometry.NewAABB(core.NewVec3(-0.8, -0.8, -3.8), core.NewVec3(0.8, 0.8, -2.2))
+			grid, err := geometry.NewDensityGrid(bounds, 4, 4, 4, values)
+			if err != nil {
+				t.Fatalf("NewDensityGrid failed: %v", err)
+			}
+			volume := geometry.NewVolume(grid, 4, material.NewHenyeyGreenstein(core.NewVec3(0.5, 0.5, 0.5), 0.5))
+			volume.SetEmission(grid, func(kelvin float64) core.Vec3 {
+				return core.NewVec3(furnaceRadiance, furnaceRadiance, furnaceRadiance)
+			})
+			checkFurnace(t, createFurnaceSceneWith([]geometry.Shape{volume}, 1), in.new)
+		})
+	}
+}
+
+func TestWhiteFurnace_PathGuiding(t *testing.T) {
+	// Guided scatter directions are weighted by both densities, so the furnace is unchanged once
+	// the guide learned the light in the first passes
+	white := core.NewVec3(1, 1, 1)
+	materials := []struct {
+		name     string
+		material material.Material
+	}{
+		{"lambertian", material.NewLambertian(white)},
+		{"coated lambertian", material.NewLayered(material.NewDielectric(1.5), material.NewLambertian(white))},
+		{"lambertian and mirror mix", material.NewMix(material.NewLambertian(white), material.NewMetal(white, 0), 0.5)},
+	}
+	for _, m := range materials {
+		t.Run(m.name, func(t *testing.T) {
+			s := createFurnaceScene(m.material, 1)
+			s.SamplingConfig.GuidingPasses = 2
+			checkFurnacePasses(t, s, func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewPathTracingIntegrator(c) }, 4)
+		})
+	}
+}
+
+// furnaceIntegrators are the integrators the white furnace tests run
+var furnaceIntegrators = []struct {
+	name string
+	new  func(scene.SamplingConfig) integrator.Integrator
+}{
+	{"pt", func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewPathTracingIntegrator(c) }},
+	{"bdpt", func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewBDPTIntegrator(c) }},
+}
+
+// checkFurnace renders a white furnace scene and checks that it shows the environment everywhere
+func checkFurnace(t *testing.T, s *scene.Scene, newIntegrator func(scene.SamplingConfig) integrator.Integrator) {
+	t.Helper()
+	checkFurnacePasses(t, s, newIntegrator, 1)
+}
+
+// checkFurnacePasses checks a white furnace like checkFurnace, rendering it in the given number of passes
+func checkFurnacePasses(t *testing.T, s *scene.Scene, newIntegrator func(scene.SamplingConfig) integrator.Integrator, passes int) {
+	t.Helper()
+	config := DefaultProgressiveConfig()
+	config.InitialSamples = 1
+	config.MaxSamplesPerPixel = s.SamplingConfig.SamplesPerPixel
+	config.MaxPasses = passes
+	config.TileSize = s.SamplingConfig.Width
+	config.NumWorkers = 1
+	config.Seed = 17
+
+	raytracer, err := NewProgressiveRaytracer(s, config, newIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	for pass := 1; pass <= passes; pass++ {
+		if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+	}
+
+	// The image's mean is within a few standard errors of the environment
+	var sum, sumSq float64
+	n := float64(s.SamplingConfig.Width * s.SamplingConfig.Height)
+	for y := 0; y < s.SamplingConfig.Height; y++ {
+		for x := 0; x < s.SamplingConfig.Width; x++ {
+			value := raytracer.PixelColor(x, y).Luminance()
+			sum += value
+			sumSq += value * value
+		}
+	}
+	mean := sum / n
+	stdErr := math.Sqrt(math.Max(0, sumSq/n-mean*mean) / n)
+	if math.Abs(mean-furnaceRadiance) > 4*stdErr+0.005 {
+		t.Errorf("Expected the furnace to render the environment's %g everywhere, got a mean of %f ± %f",
+			furnaceRadiance, mean, stdErr)
+	}
+}
+
+func TestWhiteFurnace_IrradianceCache(t *testing.T) {
+	// Cached points take their direct light from light sampling and the light between the spheres
+	// from the cache, which together still show the environment
+	s := createFurnaceScene(material.NewLambertian(core.NewVec3(1, 1, 1)), 1)
+	s.SamplingConfig.IrradianceCache = 0.3
+	checkFurnace(t, s, func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewPathTracingIntegrator(c) })
+}
@@ -0,0 +1,192 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// ID AOV names of the images produced when ProgressiveConfig.IDs is enabled. Each pixel blends
+// the colors of what it sees by coverage, so edges show how much of each it holds.
+const (
+	AOVObjectID   = "object_id"   // A color per scene shape
+	AOVMaterialID = "material_id" // A color per material
+)
+
+// Cryptomatte layer names, as compositing tools know them
+const (
+	CryptoObject   = "CryptoObject"
+	CryptoMaterial = "CryptoMaterial"
+)
+
+// IDStats accumulates how much of a pixel each object and material covers: the filter weights of
+// the samples whose camera ray hit it first. Samples that hit nothing add to neither, so the
+// pixel's coverage by the background is what's left of PixelStats.WeightSum.
+type IDStats struct {
+	Objects   []IDWeight
+	Materials []IDWeight
+}
+
+// IDWeight is the filter weight of a pixel's samples that saw one object or material
+type IDWeight struct {
+	Key    any // The geometry.Shape or material.Material
+	Weight float64
+}
+
+// AddSample adds a sample whose camera ray saw an object (nil when the intersector doesn't mark
+// hits with theirs) of a material
+func (is *IDStats) AddSample(object any, mat material.Material, weight float64) {
+	is.Objects = addIDWeight(is.Objects, object, weight)
+	is.Materials = addIDWeight(is.Materials, mat, weight)
+}
+
+// addIDWeight adds weight to a key's entry, skipping keys that can't be compared
+func addIDWeight(weights []IDWeight, key any, weight float64) []IDWeight {
+	if key == nil || !reflect.TypeOf(key).Comparable() {
+		return weights
+	}
+	for i := range weights {
+		if weights[i].Key == key {
+			weights[i].Weight += weight
+			return weights
+		}
+	}
+	return append(weights, IDWeight{Key: key, Weight: weight})
+}
+
+// recordIDSample adds the object and material a camera ray hits first to its pixel's coverage,
+// tracing the hit again as the surface AOVs do
+func (tr *TileRenderer) recordIDSample(ray core.Ray, weight float64, is *IDStats) {
+	if hit, isHit := geometry.HitVisible(tr.scene.Intersector, ray, cameraRayTMin, math.Inf(1), material.CameraRays); isHit {
+		is.AddSample(hit.Object, hit.Material, weight)
+	}
+}
+
+// sceneIDs numbers the objects and materials the pixels saw. Objects are numbered from 1 in the
+// order the scene holds them, so their IDs stay the same from render to render of a scene;
+// materials, and any objects the scene doesn't hold, in the order they're first seen scanning the
+// image row by row.
+type sceneIDs struct {
+	objects   map[any]int
+	materials map[any]int
+}
+
+// numberIDs numbers what the pixels have seen so far
+func (pr *ProgressiveRaytracer) numberIDs() *sceneIDs {
+	ids := &sceneIDs{objects: make(map[any]int), materials: make(map[any]int)}
+	for i, shape := range pr.scene.Shapes {
+		if reflect.TypeOf(shape).Comparable() {
+			ids.objects[shape] = i + 1
+		}
+	}
+	objects := len(pr.scene.Shapes)
+	for y := range pr.film.pixels {
+		for x := range pr.film.pixels[y] {
+			is := pr.film.pixels[y][x].IDs
+			for _, object := range is.Objects {
+				if _, ok := ids.objects[object.Key]; !ok {
+					objects++
+					ids.objects[object.Key] = objects
+				}
+			}
+			for _, mat := range is.Materials {
+				if _, ok := ids.materials[mat.Key]; !ok {
+					ids.materials[mat.Key] = len(ids.materials) + 1
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// idName names an object or material by its type and ID, e.g. "Sphere.3"
+func idName(key any, id int) string {
+	name := fmt.Sprintf("%T", key)
+	return fmt.Sprintf("%s.%d", name[strings.LastIndex(name, ".")+1:], id)
+}
+
+// assembleIDImages adds the object and material ID images to images (which may be nil)
+func (pr *ProgressiveRaytracer) assembleIDImages(images map[string]*image.RGBA) map[string]*image.RGBA {
+	if !pr.config.IDs {
+		return images
+	}
+	if images == nil {
+		images = make(map[string]*image.RGBA)
+	}
+
+	ids := pr.numberIDs()
+	bounds := image.Rect(0, 0, pr.scene.SamplingConfig.Width, pr.scene.SamplingConfig.Height)
+	objects, materials := image.NewRGBA(bounds), image.NewRGBA(bounds)
+	blend := func(weights []IDWeight, numbers map[any]int, total float64) color.RGBA {
+		var c core.Vec3
+		for _, w := range weights {
+			c = c.Add(integrator.IDColor(numbers[w.Key]).Multiply(w.Weight / total))
+		}
+		return linearToColor(c)
+	}
+	for y := range pr.film.pixels {
+		for x := range pr.film.pixels[y] {
+			ps := &pr.film.pixels[y][x]
+			if ps.WeightSum <= 0 {
+				objects.SetRGBA(x, y, color.RGBA{A: 255})
+				materials.SetRGBA(x, y, color.RGBA{A: 255})
+				continue
+			}
+			objects.SetRGBA(x, y, blend(ps.IDs.Objects, ids.objects, ps.WeightSum))
+			materials.SetRGBA(x, y, blend(ps.IDs.Materials, ids.materials, ps.WeightSum))
+		}
+	}
+	images[AOVObjectID] = objects
+	images[AOVMaterialID] = materials
+	return images
+}
+
+// Cryptomatte returns the render so far as object and material mattes in the Cryptomatte layout,
+// or nil unless ProgressiveConfig.IDs is set. Each pixel lists what it saw by coverage, named by
+// type and ID as in idName, so compositing tools can pull a matte for any object or material.
+func (pr *ProgressiveRaytracer) Cryptomatte() *loaders.Cryptomatte {
+	if !pr.config.IDs {
+		return nil
+	}
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	ids := pr.numberIDs()
+	objects := loaders.CryptomatteLayer{Name: CryptoObject, Pixels: make([][]loaders.IDCoverage, width*height)}
+	materials := loaders.CryptomatteLayer{Name: CryptoMaterial, Pixels: make([][]loaders.IDCoverage, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ps := &pr.film.pixels[y][x]
+			if ps.WeightSum <= 0 {
+				continue
+			}
+			objects.Pixels[y*width+x] = rankCoverage(ps.IDs.Objects, ids.objects, ps.WeightSum)
+			materials.Pixels[y*width+x] = rankCoverage(ps.IDs.Materials, ids.materials, ps.WeightSum)
+		}
+	}
+	return &loaders.Cryptomatte{Width: width, Height: height, Layers: []loaders.CryptomatteLayer{objects, materials}}
+}
+
+// rankCoverage names a pixel's objects or materials and orders them by coverage, most first
+func rankCoverage(weights []IDWeight, numbers map[any]int, total float64) []loaders.IDCoverage {
+	coverage := make([]loaders.IDCoverage, len(weights))
+	for i, w := range weights {
+		coverage[i] = loaders.IDCoverage{Name: idName(w.Key, numbers[w.Key]), Coverage: w.Weight / total}
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		if coverage[i].Coverage != coverage[j].Coverage {
+			return coverage[i].Coverage > coverage[j].Coverage
+		}
+		return coverage[i].Name < coverage[j].Name
+	})
+	return coverage
+}
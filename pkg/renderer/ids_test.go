@@ -0,0 +1,136 @@
+package renderer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestIDStats_AddSample(t *testing.T) {
+	var is IDStats
+	red := material.NewLambertian(core.NewVec3(1, 0, 0))
+	sphere := geometry.NewSphere(core.Vec3{}, 1, red)
+	quad := geometry.NewQuad(core.Vec3{}, core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), red)
+	is.AddSample(sphere, red, 1)
+	is.AddSample(quad, red, 0.5)
+	is.AddSample(sphere, red, 0.25)
+	is.AddSample(nil, red, 1) // An intersector that doesn't mark hits
+
+	if len(is.Objects) != 2 || is.Objects[0].Weight != 1.25 || is.Objects[1].Weight != 0.5 {
+		t.Errorf("Expected the sphere with weight 1.25 and the quad with 0.5, got %+v", is.Objects)
+	}
+	if len(is.Materials) != 1 || is.Materials[0].Weight != 2.75 {
+		t.Errorf("Expected one material with weight 2.75, got %+v", is.Materials)
+	}
+}
+
+// The columns of the centers of renderIDTestScene's spheres
+const (
+	firstX  = 12
+	secondX = 3
+)
+
+// renderIDTestScene renders two spheres of different materials side by side under a quad light
+func renderIDTestScene(t *testing.T) *ProgressiveRaytracer {
+	t.Helper()
+	s := &scene.Scene{
+		Shapes: []geometry.Shape{
+			geometry.NewSphere(core.NewVec3(-0.6, 0, -2), 0.5, material.NewLambertian(core.NewVec3(0.8, 0.2, 0.2))),
+			geometry.NewSphere(core.NewVec3(0.6, 0, -2), 0.5, material.NewLambertian(core.NewVec3(0.2, 0.2, 0.8))),
+		},
+		Camera: geometry.NewCamera(geometry.CameraConfig{
+			Center:      core.NewVec3(0, 0, 0),
+			LookAt:      core.NewVec3(0, 0, -1),
+			Up:          core.NewVec3(0, 1, 0),
+			Width:       16,
+			AspectRatio: 1.0,
+			VFov:        45.0,
+		}),
+		SamplingConfig: scene.SamplingConfig{Width: 16, Height: 16, MaxDepth: 4, AdaptiveMinSamples: 1},
+	}
+	s.AddQuadLight(core.NewVec3(-1, 2, -3), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 8, MaxPasses: 1, NumWorkers: 2, Seed: 7, IDs: true}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	if _, _, err := raytracer.RenderPass(context.Background(), 1, nil); err != nil {
+		t.Fatalf("RenderPass failed: %v", err)
+	}
+	return raytracer
+}
+
+func TestCryptomatte_CoversObjectsAndMaterials(t *testing.T) {
+	raytracer := renderIDTestScene(t)
+	matte := raytracer.Cryptomatte()
+	if matte == nil || len(matte.Layers) != 2 || matte.Layers[0].Name != CryptoObject || matte.Layers[1].Name != CryptoMaterial {
+		t.Fatalf("Expected object and material layers, got %+v", matte)
+	}
+	objects, materials := matte.Layers[0], matte.Layers[1]
+
+	// Objects are named by their place in the scene, materials by when they're first seen
+	first, second := objects.Matte("Sphere.1"), objects.Matte("Sphere.2")
+	if first[8*16+firstX] != 1 || second[8*16+secondX] != 1 {
+		t.Fatalf("Expected the spheres to cover the pixels at their centers, got %g and %g", first[8*16+firstX], second[8*16+secondX])
+	}
+	firstName, secondName := materials.Pixels[8*16+firstX][0].Name, materials.Pixels[8*16+secondX][0].Name
+	if firstName == secondName || !strings.HasPrefix(firstName, "Lambertian.") {
+		t.Fatalf("Expected the spheres' own Lambertian materials, got %s and %s", firstName, secondName)
+	}
+	firstMaterial := materials.Matte(firstName)
+
+	edges := 0
+	for i := range objects.Pixels {
+		total := 0.0
+		for k, id := range objects.Pixels[i] {
+			total += id.Coverage
+			if k > 0 && id.Coverage > objects.Pixels[i][k-1].Coverage {
+				t.Fatalf("Pixel %d: expected IDs ranked by coverage, got %+v", i, objects.Pixels[i])
+			}
+		}
+		if total > 1+1e-9 {
+			t.Errorf("Pixel %d: expected at most full coverage, got %g", i, total)
+		}
+		if first[i] > 0 && first[i] < 1 {
+			edges++
+		}
+		if firstMaterial[i] != first[i] {
+			t.Errorf("Pixel %d: expected the first sphere's material to cover what it does, %g, got %g", i, first[i], firstMaterial[i])
+		}
+	}
+	if edges == 0 {
+		t.Error("Expected pixels on a sphere's edge to be partly covered")
+	}
+}
+
+func TestIDImages(t *testing.T) {
+	raytracer := renderIDTestScene(t)
+	images := raytracer.assembleIDImages(nil)
+	objects, materials := images[AOVObjectID], images[AOVMaterialID]
+	if objects == nil || materials == nil {
+		t.Fatalf("Expected object and material ID images, got %v", images)
+	}
+	first, second := objects.RGBAAt(firstX, 8), objects.RGBAAt(secondX, 8)
+	if first == second || first == objects.RGBAAt(0, 0) {
+		t.Errorf("Expected the spheres and the background in different colors, got %v, %v and %v", first, second, objects.RGBAAt(0, 0))
+	}
+	if materials.RGBAAt(firstX, 8) == materials.RGBAAt(secondX, 8) {
+		t.Error("Expected the spheres' materials in different colors")
+	}
+
+	raytracer.config.IDs = false
+	if raytracer.assembleIDImages(nil) != nil || raytracer.Cryptomatte() != nil {
+		t.Error("Expected no ID output unless enabled")
+	}
+}
@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+// SampleTrace is one sample of a pixel, traced again by TraceSample. Integrators that implement
+// integrator.SampleInspector record its paths in full; for others it has the camera ray and color.
+type SampleTrace struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Sample int `json:"sample"` // The pixel's sample number, counting from 0
+	Pass   int `json:"pass"`   // The pass that took it, counting from 1
+
+	*integrator.SampleInspection
+}
+
+// sampleInspect asks a tile renderer to record one sample of the pixels it renders
+type sampleInspect struct {
+	sample     int // Number of samples the pixel has before the one to record
+	inspection *integrator.SampleInspection
+}
+
+// TraceSample traces one sample of a pixel again, exactly as the render takes it, and records its
+// paths. The pixel's passes are replayed alone from the start: its random sequences depend only on
+// the seed, its coordinates and its sample count, and splats from other pixels don't steer its
+// adaptive sampling. The replay follows the pass schedule of MaxPasses and MaxSamplesPerPixel, so
+// it doesn't match passes shortened to fit MaxTime; nor does it match what integrators that learn
+// from passes (path guiding) render once they've learned. It fails if adaptive sampling stops the
+// pixel before the sample, and for renders with a resolution pyramid, whose estimates steer it.
+func (pr *ProgressiveRaytracer) TraceSample(x, y, sample int) (*SampleTrace, error) {
+	width, height := pr.scene.SamplingConfig.Width, pr.scene.SamplingConfig.Height
+	if !image.Pt(x, y).In(image.Rect(0, 0, width, height)) {
+		return nil, fmt.Errorf("pixel (%d, %d) is outside the %dx%d image", x, y, width, height)
+	}
+	if sample < 0 || sample >= pr.config.MaxSamplesPerPixel {
+		return nil, fmt.Errorf("sample %d is outside the %d samples per pixel", sample, pr.config.MaxSamplesPerPixel)
+	}
+	if len(pr.levels) > 0 {
+		return nil, fmt.Errorf("samples can't be traced again in a render with a resolution pyramid")
+	}
+
+	// Only the pixel's own statistics are needed, at its place in the image
+	pixelStats := make([][]PixelStats, y+1)
+	pixelStats[y] = make([]PixelStats, x+1)
+	splatQueue := NewSplatQueue()
+
+	tr := NewTileRenderer(pr.scene, pr.integrator)
+	tr.inspect = &sampleInspect{sample: sample}
+	tr.mask = pr.sampleMask
+	tr.filter = pr.filter
+	for pass := 1; pass <= pr.config.MaxPasses; pass++ {
+		tr.RenderTileBounds(image.Rect(x, y, x+1, y+1), pixelStats, splatQueue, pr.config.Seed, pr.getSamplesForPass(pass))
+		splatQueue.Clear()
+		if tr.inspect.inspection != nil {
+			return &SampleTrace{X: x, Y: y, Sample: sample, Pass: pass, SampleInspection: tr.inspect.inspection}, nil
+		}
+	}
+	return nil, fmt.Errorf("adaptive sampling stops pixel (%d, %d) after %d samples", x, y, pixelStats[y][x].SampleCount)
+}
+
+// inspectSample traces the camera ray of the sample being inspected and records it
+func (tr *TileRenderer) inspectSample(ray core.Ray, sampler core.Sampler) core.Vec3 {
+	batchScene := &tr.primary.scene
+	inspector, ok := tr.integrator.(integrator.SampleInspector)
+	if ok {
+		tr.inspect.inspection = inspector.InspectSample(ray, batchScene, sampler)
+	} else {
+		color, _ := tr.integrator.RayColor(ray, batchScene, sampler)
+		tr.inspect.inspection = &integrator.SampleInspection{
+			Color: [3]float64{color.X, color.Y, color.Z},
+			CameraPath: []integrator.PathVertexTrace{{
+				Point:     [3]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z},
+				Direction: [3]float64{ray.Direction.X, ray.Direction.Y, ray.Direction.Z},
+			}},
+		}
+	}
+	for i := range tr.inspect.inspection.Strategies {
+		tr.inspect.inspection.Strategies[i].Sample = tr.inspect.sample
+	}
+	color := tr.inspect.inspection.Color
+	return core.Vec3{X: color[0], Y: color[1], Z: color[2]}
+}
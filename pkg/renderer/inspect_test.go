@@ -0,0 +1,144 @@
+package renderer
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// renderInspectScene renders the sphere under a quad light in three passes of up to 12 samples
+func renderInspectScene(t *testing.T, newIntegrator func(scene.SamplingConfig) integrator.Integrator) *ProgressiveRaytracer {
+	t.Helper()
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 16
+	s.SamplingConfig.AdaptiveMinSamples = 0.5 // Pixels stop after between 6 and 12 samples
+	s.Camera = geometry.NewCamera(geometry.CameraConfig{
+		Center: core.NewVec3(0, 0, 0),
+		LookAt: core.NewVec3(0, 0, -1),
+		Up:     core.NewVec3(0, 1, 0),
+		Width:  16, AspectRatio: 1, VFov: 45,
+	})
+	s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 12, MaxPasses: 3, NumWorkers: 2, Seed: 3}
+	raytracer, err := NewProgressiveRaytracer(s, config, newIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	for pass := 1; pass <= config.MaxPasses; pass++ {
+		if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+			t.Fatalf("RenderPass failed: %v", err)
+		}
+	}
+	return raytracer
+}
+
+func TestTraceSample_ReplaysRenderedSamples(t *testing.T) {
+	raytracer := renderInspectScene(t, func(config scene.SamplingConfig) integrator.Integrator {
+		return integrator.NewPathTracingIntegrator(config)
+	})
+
+	// The path tracer doesn't splat, so a pixel's samples sum to what the render accumulated. The
+	// first pixel takes every sample; adaptive sampling stops the second early.
+	for _, pixel := range [][2]int{{0, 0}, {8, 8}} {
+		x, y := pixel[0], pixel[1]
+		ps := raytracer.film.pixels[y][x]
+		var sum core.Vec3
+		for sample := 0; sample < ps.SampleCount; sample++ {
+			trace, err := raytracer.TraceSample(x, y, sample)
+			if err != nil {
+				t.Fatalf("TraceSample(%d, %d, %d) failed: %v", x, y, sample, err)
+			}
+			if trace.Sample != sample || trace.Pass < 1 || trace.Pass > 3 {
+				t.Errorf("Expected sample %d in passes 1-3, got sample %d in pass %d", sample, trace.Sample, trace.Pass)
+			}
+			sum = sum.Add(core.Vec3{X: trace.Color[0], Y: trace.Color[1], Z: trace.Color[2]})
+		}
+		if sum != ps.ColorAccum {
+			t.Errorf("Pixel (%d, %d): traced samples sum to %v, rendered %v", x, y, sum, ps.ColorAccum)
+		}
+		if ps.SampleCount < 12 {
+			if _, err := raytracer.TraceSample(x, y, ps.SampleCount); err == nil {
+				t.Errorf("Expected an error tracing a sample after adaptive sampling stopped pixel (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestTraceSample_PathTracerVertices(t *testing.T) {
+	raytracer := renderInspectScene(t, func(config scene.SamplingConfig) integrator.Integrator {
+		return integrator.NewPathTracingIntegrator(config)
+	})
+
+	trace, err := raytracer.TraceSample(8, 8, 0)
+	if err != nil {
+		t.Fatalf("TraceSample failed: %v", err)
+	}
+	path := trace.CameraPath
+	if len(path) < 2 {
+		t.Fatalf("Expected the camera and the sphere in the path, got %d vertices", len(path))
+	}
+	if path[0].Point != [3]float64{} || path[0].Beta != [3]float64{1, 1, 1} {
+		t.Errorf("Expected the path to start at the camera with unit throughput, got %+v", path[0])
+	}
+	if path[1].Material != "*material.Lambertian" || path[1].ScatterPdf <= 0 {
+		t.Errorf("Expected the sphere's Lambertian vertex with a scatter density, got %+v", path[1])
+	}
+}
+
+func TestTraceSample_BDPTStrategies(t *testing.T) {
+	raytracer := renderInspectScene(t, func(config scene.SamplingConfig) integrator.Integrator {
+		return integrator.NewBDPTIntegrator(config)
+	})
+
+	trace, err := raytracer.TraceSample(8, 8, 5)
+	if err != nil {
+		t.Fatalf("TraceSample failed: %v", err)
+	}
+	again, _ := raytracer.TraceSample(8, 8, 5)
+	if !reflect.DeepEqual(trace, again) {
+		t.Error("Expected tracing the same sample twice to record the same paths")
+	}
+	if len(trace.CameraPath) < 2 || len(trace.LightPath) < 1 || !trace.LightPath[0].Light {
+		t.Fatalf("Expected camera and light paths, got %d and %d vertices", len(trace.CameraPath), len(trace.LightPath))
+	}
+
+	// Strategies that don't splat make up the sample's color
+	var weighted [3]float64
+	for _, strategy := range trace.Strategies {
+		if strategy.Sample != 5 || strategy.X != 8 || strategy.Y != 8 {
+			t.Errorf("Expected strategies of sample 5 at (8, 8), got sample %d at (%d, %d)", strategy.Sample, strategy.X, strategy.Y)
+		}
+		if strategy.T > 1 {
+			for i := range weighted {
+				weighted[i] += strategy.Weighted[i]
+			}
+		}
+	}
+	for i := range weighted {
+		if math.Abs(weighted[i]-trace.Color[i]) > 1e-9*math.Max(1, trace.Color[i]) {
+			t.Errorf("Expected the strategies' weighted contributions %v to sum to the color %v", weighted, trace.Color)
+			break
+		}
+	}
+}
+
+func TestTraceSample_Errors(t *testing.T) {
+	raytracer := renderInspectScene(t, func(config scene.SamplingConfig) integrator.Integrator {
+		return integrator.NewPathTracingIntegrator(config)
+	})
+	for _, args := range [][3]int{{-1, 0, 0}, {16, 0, 0}, {0, 0, 12}, {0, 0, -1}} {
+		if _, err := raytracer.TraceSample(args[0], args[1], args[2]); err == nil {
+			t.Errorf("Expected an error tracing sample %d of pixel (%d, %d)", args[2], args[0], args[1])
+		}
+	}
+}
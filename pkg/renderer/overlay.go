@@ -0,0 +1,214 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Overlays selectable with ProgressiveConfig.Overlays. Each is drawn over the pass image in
+// PassResult.AOVs, named with OverlayPrefix: "overlay_wireframe" and so on. DrawOverlay draws
+// one alone, on a transparent image.
+const (
+	OverlayWireframe = "wireframe" // Edges of triangles and triangle meshes, hidden behind surfaces in front of them
+	OverlayBVH       = "bvh"       // Bounds of the BVH's top levels, through meshes' own BVHs, blue at the root to red deepest
+
+	OverlayPrefix = "overlay_"
+)
+
+// OverlayNames lists the overlays in the order they're documented
+var OverlayNames = []string{OverlayWireframe, OverlayBVH}
+
+const (
+	overlayBVHLevels      = 8    // BVH levels drawn, counting the root as the first
+	overlayDepthTolerance = 0.01 // Share of a surface's depth a wireframe line may be behind it and still show
+)
+
+// overlayWireColor is the color of wireframe lines
+var overlayWireColor = color.RGBA{R: 255, G: 0, B: 255, A: 255}
+
+// ParseOverlays parses a comma-separated list of overlay names ("all" for every one)
+func ParseOverlays(list string) ([]string, error) {
+	return parseNames(list, OverlayNames, "overlay")
+}
+
+// DrawOverlay draws an overlay's lines for a preprocessed scene as its camera sees it, on a
+// transparent image the size of the render
+func DrawOverlay(s *scene.Scene, name string) *image.RGBA {
+	width, height := s.SamplingConfig.Width, s.SamplingConfig.Height
+	canvas := &overlayCanvas{
+		image:  image.NewRGBA(image.Rect(0, 0, width, height)),
+		camera: s.Camera,
+		near:   1e-4 * s.WorldRadius,
+	}
+	switch name {
+	case OverlayWireframe:
+		canvas.depths = surfaceDepths(s)
+		for _, shape := range s.Shapes {
+			drawWireframe(canvas, shape)
+		}
+	case OverlayBVH:
+		if s.BVH != nil {
+			drawBVH(canvas, s.BVH.Root, 0)
+		}
+	}
+	return canvas.image
+}
+
+// assembleOverlays adds the requested overlays, drawn over the pass image, to images (which may
+// be nil) under their prefixed names. The lines are drawn once and kept for later passes.
+func (pr *ProgressiveRaytracer) assembleOverlays(img *image.RGBA, images map[string]*image.RGBA) map[string]*image.RGBA {
+	if len(pr.config.Overlays) == 0 {
+		return images
+	}
+	if images == nil {
+		images = make(map[string]*image.RGBA)
+	}
+	if pr.overlays == nil {
+		pr.overlays = make(map[string]*image.RGBA)
+		for _, name := range pr.config.Overlays {
+			pr.overlays[name] = DrawOverlay(pr.scene, name)
+		}
+	}
+	for _, name := range pr.config.Overlays {
+		composite := image.NewRGBA(img.Bounds())
+		draw.Draw(composite, composite.Bounds(), img, image.Point{}, draw.Src)
+		draw.Draw(composite, composite.Bounds(), pr.overlays[name], image.Point{}, draw.Over)
+		images[OverlayPrefix+name] = composite
+	}
+	return images
+}
+
+// surfaceDepths returns the depth of the surface seen through the center of each pixel, row by
+// row (+Inf where there is none)
+func surfaceDepths(s *scene.Scene) []float64 {
+	width, height := s.SamplingConfig.Width, s.SamplingConfig.Height
+	depths := make([]float64, width*height)
+	center := core.NewVec2(0.5, 0.5)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			depths[y*width+x] = math.Inf(1)
+			ray := s.Camera.GetRay(x, y, center, center)
+			if hit, isHit := geometry.HitVisible(s.Intersector, ray, 0.001, math.Inf(1), material.CameraRays); isHit {
+				_, depths[y*width+x], _ = s.Camera.ProjectPoint(hit.Point)
+			}
+		}
+	}
+	return depths
+}
+
+// drawWireframe draws the edges of a shape's triangles; other shapes have none
+func drawWireframe(canvas *overlayCanvas, shape geometry.Shape) {
+	switch s := shape.(type) {
+	case *geometry.Triangle:
+		canvas.line(s.V0, s.V1, overlayWireColor)
+		canvas.line(s.V1, s.V2, overlayWireColor)
+		canvas.line(s.V2, s.V0, overlayWireColor)
+	case *geometry.TriangleMesh:
+		for _, triangle := range s.GetTriangles() {
+			drawWireframe(canvas, triangle)
+		}
+	}
+}
+
+// drawBVH draws the bounds of a BVH node at a level and of its children, continuing into the BVHs
+// of the meshes in its leaves
+func drawBVH(canvas *overlayCanvas, node *geometry.BVHNode, level int) {
+	if node == nil || level >= overlayBVHLevels {
+		return
+	}
+	canvas.box(node.BoundingBox, falseColor(float64(level)/float64(overlayBVHLevels-1)))
+	drawBVH(canvas, node.Left, level+1)
+	drawBVH(canvas, node.Right, level+1)
+	for _, shape := range node.Shapes {
+		if mesh, ok := shape.(*geometry.TriangleMesh); ok && mesh.GetBVH() != nil {
+			drawBVH(canvas, mesh.GetBVH().Root, level+1)
+		}
+	}
+}
+
+// overlayCanvas draws lines in the scene onto an image as the camera sees them
+type overlayCanvas struct {
+	image  *image.RGBA
+	camera *geometry.Camera
+	near   float64   // Depth lines are clipped at, just in front of the camera
+	depths []float64 // Depths of the surfaces lines are hidden behind, nil to draw every line whole
+}
+
+// box draws the edges of a bounding box
+func (c *overlayCanvas) box(box geometry.AABB, col color.RGBA) {
+	corner := func(i int) core.Vec3 {
+		p := box.Min
+		if i&1 != 0 {
+			p.X = box.Max.X
+		}
+		if i&2 != 0 {
+			p.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			p.Z = box.Max.Z
+		}
+		return p
+	}
+	// Corners whose indices differ in one bit share an edge
+	for i := 0; i < 8; i++ {
+		for bit := 1; bit < 8; bit <<= 1 {
+			if i&bit == 0 {
+				c.line(corner(i), corner(i|bit), col)
+			}
+		}
+	}
+}
+
+// line draws the line between two points, clipped to the part in front of the camera
+func (c *overlayCanvas) line(a, b core.Vec3, col color.RGBA) {
+	_, depthA, _ := c.camera.ProjectPoint(a)
+	_, depthB, _ := c.camera.ProjectPoint(b)
+	if depthA < c.near && depthB < c.near {
+		return
+	}
+	if depthA < c.near {
+		a = a.Add(b.Subtract(a).Multiply((c.near - depthA) / (depthB - depthA)))
+	} else if depthB < c.near {
+		b = b.Add(a.Subtract(b).Multiply((c.near - depthB) / (depthA - depthB)))
+	}
+
+	// Step along the line in 3D, about half a pixel at a time, so that hidden parts are found
+	// however the perspective stretches it
+	pa, _, _ := c.camera.ProjectPoint(a)
+	pb, _, _ := c.camera.ProjectPoint(b)
+	bounds := c.image.Bounds()
+	length := math.Max(math.Abs(pb.X-pa.X), math.Abs(pb.Y-pa.Y))
+	steps := int(math.Min(2*length, float64(4*(bounds.Dx()+bounds.Dy())))) + 1
+	for i := 0; i <= steps; i++ {
+		p, depth, ok := c.camera.ProjectPoint(a.Add(b.Subtract(a).Multiply(float64(i) / float64(steps))))
+		x, y := int(math.Floor(p.X)), int(math.Floor(p.Y))
+		if ok && x >= 0 && x < bounds.Dx() && y >= 0 && y < bounds.Dy() && !c.hidden(x, y, depth) {
+			c.image.SetRGBA(x, y, col)
+		}
+	}
+}
+
+// hidden reports whether a point at a depth in a pixel is behind the surfaces seen around it.
+// The farthest surface in the 3×3 pixels around it counts, so lines along a silhouette show.
+func (c *overlayCanvas) hidden(x, y int, depth float64) bool {
+	if c.depths == nil {
+		return false
+	}
+	width, height := c.image.Bounds().Dx(), c.image.Bounds().Dy()
+	farthest := 0.0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if nx, ny := x+dx, y+dy; nx >= 0 && nx < width && ny >= 0 && ny < height {
+				farthest = math.Max(farthest, c.depths[ny*width+nx])
+			}
+		}
+	}
+	return depth > farthest*(1+overlayDepthTolerance)
+}
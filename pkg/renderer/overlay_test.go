@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// createOverlayScene creates a triangle facing a camera at the origin looking down -z, and
+// optionally a wall in front of its right half
+func createOverlayScene(wall bool) *scene.Scene {
+	gray := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	shapes := []geometry.Shape{
+		geometry.NewTriangle(core.NewVec3(-1, -1, -4), core.NewVec3(1, -1, -4), core.NewVec3(0, 1, -4), gray),
+	}
+	if wall {
+		shapes = append(shapes, geometry.NewQuad(core.NewVec3(0, -2, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 4, 0), gray))
+	}
+	s := &scene.Scene{
+		Shapes: shapes,
+		Camera: geometry.NewCamera(geometry.CameraConfig{
+			Center: core.NewVec3(0, 0, 0),
+			LookAt: core.NewVec3(0, 0, -4),
+			Up:     core.NewVec3(0, 1, 0),
+			Width:  64, AspectRatio: 1, VFov: 45,
+		}),
+		SamplingConfig: scene.SamplingConfig{Width: 64, Height: 64},
+	}
+	s.Preprocess()
+	return s
+}
+
+// drawnPixels returns how many pixels an overlay drew on in the half of the image where the wall
+// is (+x) and in the other half
+func drawnPixels(s *scene.Scene, img *image.RGBA) (wallSide, otherSide int) {
+	wall, _, _ := s.Camera.ProjectPoint(core.NewVec3(1, 0, -4))
+	bounds := img.Bounds()
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if img.RGBAAt(x, y).A == 0 {
+				continue
+			}
+			if (x < bounds.Dx()/2) == (wall.X < float64(bounds.Dx())/2) {
+				wallSide++
+			} else {
+				otherSide++
+			}
+		}
+	}
+	return wallSide, otherSide
+}
+
+func TestDrawOverlay_Wireframe(t *testing.T) {
+	s := createOverlayScene(false)
+	img := DrawOverlay(s, OverlayWireframe)
+
+	// The triangle's edges are drawn on both sides, its inside isn't
+	wallSide, otherSide := drawnPixels(s, img)
+	if wallSide < 20 || otherSide < 20 {
+		t.Errorf("Expected the triangle's edges drawn on both sides, got %d and %d pixels", wallSide, otherSide)
+	}
+	if center := img.RGBAAt(32, 36); center.A != 0 {
+		t.Errorf("Expected nothing drawn inside the triangle, got %v", center)
+	}
+
+	// Edges behind the wall are hidden, but for a few pixels along its edge, and the wall's own
+	// edges aren't drawn: it isn't triangles
+	walled := createOverlayScene(true)
+	hiddenWallSide, hiddenOtherSide := drawnPixels(walled, DrawOverlay(walled, OverlayWireframe))
+	if hiddenWallSide > 4 || hiddenOtherSide < otherSide-4 {
+		t.Errorf("Expected only the edges beside the wall, got %d and %d pixels", hiddenWallSide, hiddenOtherSide)
+	}
+}
+
+func TestDrawOverlay_BVH(t *testing.T) {
+	// The root's bounds are the triangle's, drawn whole
+	s := createOverlayScene(false)
+	if wallSide, otherSide := drawnPixels(s, DrawOverlay(s, OverlayBVH)); wallSide == 0 || otherSide == 0 {
+		t.Errorf("Expected the BVH's bounds drawn on both sides, got %d and %d pixels", wallSide, otherSide)
+	}
+}
+
+func TestParseOverlays(t *testing.T) {
+	if names, err := ParseOverlays("bvh, wireframe,bvh"); err != nil || !slices.Equal(names, []string{OverlayBVH, OverlayWireframe}) {
+		t.Errorf("Expected bvh and wireframe, got %v (%v)", names, err)
+	}
+	if names, err := ParseOverlays("all"); err != nil || !slices.Equal(names, OverlayNames) {
+		t.Errorf("Expected every overlay, got %v (%v)", names, err)
+	}
+	if _, err := ParseOverlays("normals"); err == nil {
+		t.Error("Expected an error for an unknown overlay")
+	}
+}
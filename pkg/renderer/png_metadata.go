@@ -0,0 +1,154 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"unicode/utf8"
+)
+
+// MetadataEntry is a keyword and its text, saved in a PNG as a text chunk
+type MetadataEntry struct {
+	Key   string
+	Value string
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// EncodePNG writes an image as a PNG with the metadata in text chunks after its header, where
+// image viewers and tools such as exiftool find them. Values that aren't plain ASCII are saved
+// as UTF-8 iTXt chunks, the rest as tEXt chunks.
+func EncodePNG(w io.Writer, img image.Image, metadata []MetadataEntry) error {
+	var chunks bytes.Buffer
+	for _, entry := range metadata {
+		if err := checkPNGKeyword(entry.Key); err != nil {
+			return err
+		}
+		if isASCII(entry.Value) {
+			writePNGChunk(&chunks, "tEXt", []byte(entry.Key+"\x00"+entry.Value))
+		} else {
+			// Uncompressed, with no language tag or translated keyword
+			writePNGChunk(&chunks, "iTXt", []byte(entry.Key+"\x00\x00\x00\x00\x00"+entry.Value))
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return err
+	}
+	// The header chunk (IHDR, 13 bytes of data) always comes first, right after the signature
+	headerEnd := len(pngSignature) + 8 + 13 + 4
+	data := encoded.Bytes()
+	for _, part := range [][]byte{data[:headerEnd], chunks.Bytes(), data[headerEnd:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPNGMetadata returns the text chunks of a PNG (tEXt, and uncompressed iTXt), in file order
+func ReadPNGMetadata(r io.Reader) ([]MetadataEntry, error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	var metadata []MetadataEntry
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("truncated PNG: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:])
+		if chunkType == "IEND" {
+			return metadata, nil
+		}
+		if chunkType != "tEXt" && chunkType != "iTXt" {
+			// Skip the chunk's data and CRC
+			if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+				return nil, fmt.Errorf("truncated PNG: %w", err)
+			}
+			continue
+		}
+
+		data := make([]byte, int64(length)+4)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("truncated PNG: %w", err)
+		}
+		if crc32.ChecksumIEEE(append(header[4:], data[:length]...)) != binary.BigEndian.Uint32(data[length:]) {
+			return nil, fmt.Errorf("PNG %s chunk fails its checksum", chunkType)
+		}
+		if entry, ok := parsePNGText(chunkType, data[:length]); ok {
+			metadata = append(metadata, entry)
+		}
+	}
+}
+
+// parsePNGText decodes a text chunk's data, returning false for compressed or malformed ones
+func parsePNGText(chunkType string, data []byte) (MetadataEntry, bool) {
+	key, text, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return MetadataEntry{}, false
+	}
+	if chunkType == "tEXt" {
+		// Latin-1, which maps byte for byte onto the first 256 code points
+		runes := make([]rune, len(text))
+		for i, b := range text {
+			runes[i] = rune(b)
+		}
+		return MetadataEntry{Key: string(key), Value: string(runes)}, true
+	}
+
+	// iTXt: compression flag and method, then the language tag and translated keyword
+	if len(text) < 2 || text[0] != 0 {
+		return MetadataEntry{}, false
+	}
+	_, text, ok = bytes.Cut(text[2:], []byte{0})
+	if !ok {
+		return MetadataEntry{}, false
+	}
+	_, text, ok = bytes.Cut(text, []byte{0})
+	if !ok || !utf8.Valid(text) {
+		return MetadataEntry{}, false
+	}
+	return MetadataEntry{Key: string(key), Value: string(text)}, true
+}
+
+// checkPNGKeyword returns an error unless a key is a valid PNG keyword: 1 to 79 printable
+// ASCII characters, without leading, trailing or consecutive spaces
+func checkPNGKeyword(key string) error {
+	valid := len(key) >= 1 && len(key) <= 79 && key[0] != ' ' && key[len(key)-1] != ' '
+	for i := 0; valid && i < len(key); i++ {
+		valid = key[i] >= 32 && key[i] < 127 && !(key[i] == ' ' && key[i-1] == ' ')
+	}
+	if !valid {
+		return fmt.Errorf("invalid PNG metadata key %q", key)
+	}
+	return nil
+}
+
+// isASCII returns whether a string is printable ASCII, which reads the same as Latin-1 in a tEXt chunk
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < 32 && s[i] != '\n') || s[i] >= 127 {
+			return false
+		}
+	}
+	return true
+}
+
+// writePNGChunk writes a chunk: its data's length, type, data and the CRC of the type and data
+func writePNGChunk(w *bytes.Buffer, chunkType string, data []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	start := w.Len()
+	w.WriteString(chunkType)
+	w.Write(data)
+	binary.Write(w, binary.BigEndian, crc32.ChecksumIEEE(w.Bytes()[start:]))
+}
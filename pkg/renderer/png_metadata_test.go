@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestEncodePNG_MetadataRoundTrip(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.SetRGBA(1, 1, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	metadata := []MetadataEntry{
+		{Key: "Scene", Value: "scenes/cornell.pbrt"},
+		{Key: "Seed", Value: "42"},
+		{Key: "Title", Value: "Café — ñ"}, // Not ASCII, so saved as iTXt
+		{Key: "Comment", Value: ""},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, img, metadata); err != nil {
+		t.Fatalf("EncodePNG failed: %v", err)
+	}
+
+	// The image still decodes, unchanged
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected a valid PNG, got %v", err)
+	}
+	if got := color.RGBAModel.Convert(decoded.At(1, 1)); got != img.At(1, 1) {
+		t.Errorf("Expected the pixel to survive, got %v", got)
+	}
+
+	read, err := ReadPNGMetadata(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadPNGMetadata failed: %v", err)
+	}
+	if !reflect.DeepEqual(read, metadata) {
+		t.Errorf("Expected %v, got %v", metadata, read)
+	}
+}
+
+func TestEncodePNG_InvalidKeys(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	for _, key := range []string{"", " Scene", "Scene ", "Render  Time", "Scène", string(make([]byte, 80))} {
+		if err := EncodePNG(&bytes.Buffer{}, img, []MetadataEntry{{Key: key, Value: "x"}}); err == nil {
+			t.Errorf("Expected an error for key %q", key)
+		}
+	}
+}
+
+func TestReadPNGMetadata_Errors(t *testing.T) {
+	if _, err := ReadPNGMetadata(bytes.NewReader([]byte("not a png"))); err == nil {
+		t.Error("Expected an error for a file that isn't a PNG")
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1)), []MetadataEntry{{Key: "Seed", Value: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if _, err := ReadPNGMetadata(bytes.NewReader(data[:len(data)-20])); err == nil {
+		t.Error("Expected an error for a truncated PNG")
+	}
+	corrupt := bytes.Replace(data, []byte("Seed\x001"), []byte("Seed\x002"), 1)
+	if _, err := ReadPNGMetadata(bytes.NewReader(corrupt)); err == nil {
+		t.Error("Expected an error for a text chunk that fails its checksum")
+	}
+}
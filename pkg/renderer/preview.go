@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"image"
+	"image/draw"
+	"time"
+)
+
+// Preview is a partial image of a pass in progress: the tiles of the pass completed so far, over
+// the previous pass's image. RenderProgressive sends them at RenderOptions.PreviewInterval, so long
+// passes don't leave a display unchanged until they end.
+type Preview struct {
+	Pass       int         // Pass being rendered (1-based, pyramid levels included)
+	TilesDone  int         // Tiles of the pass drawn into the image
+	TotalTiles int         // Tiles in the pass
+	Image      *image.RGBA // The receiver's own copy, which the renderer never touches again
+}
+
+// previewer double buffers pass images for previews: as a pass's tiles complete, they're drawn
+// into a back buffer holding the previous pass's image, and each preview sends a copy of it
+type previewer struct {
+	report   func(Preview) // Preview callback (nil = none)
+	interval time.Duration // Time between previews within a pass
+	last     time.Time     // When the pass started or the last preview was sent
+	back     *image.RGBA   // Back buffer, nil until the first pass starts
+}
+
+// enabled returns whether previews are wanted, so tile images are worth extracting for them
+func (p *previewer) enabled() bool {
+	return p.report != nil
+}
+
+// startPass starts the interval to the pass's first preview
+func (p *previewer) startPass(width, height int) {
+	if !p.enabled() {
+		return
+	}
+	if p.back == nil {
+		p.back = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	p.last = time.Now()
+}
+
+// addTile draws a completed tile into the back buffer and sends a preview if the interval is up.
+// The pass's last tile sends none, as the pass's own image follows.
+func (p *previewer) addTile(pass int, tile *Tile, tileImage *image.RGBA, tilesDone, totalTiles int) {
+	if !p.enabled() {
+		return
+	}
+	// Pixels the pass hasn't sampled are transparent in the tile image, and keep their old color
+	draw.Draw(p.back, tile.Bounds, tileImage, image.Point{}, draw.Over)
+	if tilesDone == totalTiles || time.Since(p.last) < p.interval {
+		return
+	}
+	front := image.NewRGBA(p.back.Bounds())
+	copy(front.Pix, p.back.Pix)
+	p.report(Preview{Pass: pass, TilesDone: tilesDone, TotalTiles: totalTiles, Image: front})
+	p.last = time.Now()
+}
+
+// finishPass replaces the back buffer's contents with the pass's finished image, splats included,
+// for the next pass's tiles to be drawn over
+func (p *previewer) finishPass(img *image.RGBA) {
+	if !p.enabled() || img == nil || p.back == nil {
+		return
+	}
+	draw.Draw(p.back, p.back.Bounds(), img, img.Bounds().Min, draw.Src)
+}
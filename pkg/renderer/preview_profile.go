@@ -0,0 +1,62 @@
+package renderer
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+// previewProfile renders passes with the preview sampling profile (see
+// scene.SamplingConfig.PreviewProfile) while it's on. Its samples are biased by the clamped
+// depth, so they go to a film of their own: when the profile is switched off, or for the last
+// pass, the image's film takes over, and the pass tops it up to its sample target with the full
+// profile.
+type previewProfile struct {
+	integrator integrator.Integrator // Integrator with the preview sampling profile, nil until enabled
+	film       *Film                 // The preview passes' film
+	image      *Film                 // The image's film
+	on         atomic.Bool           // Whether passes other than the last use the profile; set from any goroutine
+	current    bool                  // Whether the pass being rendered (or the last one) uses it
+}
+
+// EnablePreviewProfile sets the integrator of the preview sampling profile, created with the
+// scene's SamplingConfig.PreviewProfile, and switches the profile on. Call it before rendering.
+func (pr *ProgressiveRaytracer) EnablePreviewProfile(preview integrator.Integrator) {
+	pr.profile.integrator = preview
+	pr.profile.film = newImageFilm(pr.film.width, pr.film.height, pr.config)
+	pr.profile.image = pr.film
+	pr.profile.on.Store(true)
+}
+
+// SetPreviewProfile switches the preview sampling profile on or off from the next pass on. It's
+// safe to call from any goroutine while rendering, and fails if the profile was never enabled.
+func (pr *ProgressiveRaytracer) SetPreviewProfile(on bool) error {
+	if on && pr.profile.integrator == nil {
+		return fmt.Errorf("the preview profile isn't enabled for this render")
+	}
+	pr.profile.on.Store(on)
+	return nil
+}
+
+// PreviewProfile returns whether passes before the last use the preview sampling profile
+func (pr *ProgressiveRaytracer) PreviewProfile() bool {
+	return pr.profile.on.Load()
+}
+
+// selectProfile switches to the film of the sampling profile an image pass uses and returns
+// its integrator. The last pass always uses the full profile, so the final image has no preview
+// samples.
+func (pr *ProgressiveRaytracer) selectProfile(passNumber int) integrator.Integrator {
+	p := &pr.profile
+	if p.integrator == nil {
+		return pr.integrator
+	}
+	p.current = p.on.Load() && passNumber < pr.TotalPasses()
+	if p.current {
+		pr.film = p.film
+		return p.integrator
+	}
+	pr.film = p.image
+	return pr.integrator
+}
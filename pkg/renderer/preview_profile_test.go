@@ -0,0 +1,62 @@
+package renderer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestRenderProgressive_PreviewProfile(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 16
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 6, MaxPasses: 3, NumWorkers: 2, PyramidLevels: 1}
+	render := func(profile bool) ([]PassResult, *ProgressiveRaytracer) {
+		raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+		if err != nil {
+			t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+		}
+		if err := raytracer.SetPreviewProfile(true); err == nil {
+			t.Error("Expected an error switching on a preview profile that wasn't enabled")
+		}
+		raytracer.EnablePreviewProfile(integrator.NewPathTracingIntegrator(s.SamplingConfig.PreviewProfile()))
+		if err := raytracer.SetPreviewProfile(profile); err != nil {
+			t.Fatalf("SetPreviewProfile failed: %v", err)
+		}
+		passChan, _, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{})
+		var results []PassResult
+		for result := range passChan {
+			results = append(results, result)
+		}
+		if err := <-errChan; err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return results, raytracer
+	}
+
+	// The image passes but the last render the preview, the pyramid level and the last the image
+	results, raytracer := render(true)
+	for i, result := range results {
+		if want := i == 1 || i == 2; result.PreviewProfile != want {
+			t.Errorf("Pass %d: expected preview profile %v", result.PassNumber, want)
+		}
+	}
+	if raytracer.film != raytracer.profile.image || raytracer.profile.film.pixels[0][0].SampleCount == 0 {
+		t.Error("Expected the preview passes in a film of their own, and the image's film in use at the end")
+	}
+
+	// The last pass tops the image's film up to the full sample target on its own
+	last := results[len(results)-1].Stats
+	if last.MaxSamplesUsed > config.MaxSamplesPerPixel || last.AverageSamples < 1 {
+		t.Errorf("Expected the final image to hold up to %d samples of its own, got %+v", config.MaxSamplesPerPixel, last)
+	}
+
+	// Switched off, every pass renders the image
+	results, _ = render(false)
+	for _, result := range results {
+		if result.PreviewProfile {
+			t.Errorf("Pass %d: expected the full profile", result.PassNumber)
+		}
+	}
+}
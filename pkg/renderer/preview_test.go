@@ -0,0 +1,78 @@
+package renderer
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestRenderProgressive_SendsPreviews(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 24
+	s.SamplingConfig.Height = 16
+	s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 6, MaxPasses: 3, NumWorkers: 2, PyramidLevels: 1}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+
+	// With no interval, a preview follows every tile of the image passes but their last
+	var previews []Preview
+	passChan, _, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{
+		OnPreview: func(p Preview) { previews = append(previews, p) },
+	})
+	passImages := map[int]*image.RGBA{}
+	for result := range passChan {
+		passImages[result.PassNumber] = result.Image
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if len(previews) != 3*5 {
+		t.Fatalf("Expected 15 previews, got %d", len(previews))
+	}
+	for i, p := range previews {
+		if p.Pass != 2+i/5 || p.TilesDone != 1+i%5 || p.TotalTiles != 6 {
+			t.Errorf("Preview %d: expected pass %d with %d of 6 tiles, got %+v", i, 2+i/5, 1+i%5, p)
+		}
+		if i > 0 && p.Image == previews[i-1].Image {
+			t.Errorf("Preview %d: expected its own copy of the image", i)
+		}
+	}
+
+	// Each preview holds the tiles of its pass done so far over the previous pass's image, which
+	// the first preview of a pass still shows elsewhere. Pass 1 is the pyramid level.
+	for _, i := range []int{0, 5} {
+		p := previews[i]
+		done := 0
+		for _, tile := range raytracer.tiles {
+			if sameTile(p.Image, passImages[p.Pass], tile.Bounds) {
+				done++
+			} else if !sameTile(p.Image, passImages[p.Pass-1], tile.Bounds) {
+				t.Errorf("Preview %d, tile %v: expected pass %d's or pass %d's pixels", i, tile.Bounds, p.Pass-1, p.Pass)
+			}
+		}
+		if done == 0 {
+			t.Errorf("Preview %d: expected a tile of pass %d", i, p.Pass)
+		}
+	}
+}
+
+// sameTile returns whether two images have the same pixels within bounds
+func sameTile(a, b *image.RGBA, bounds image.Rectangle) bool {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if a.RGBAAt(x, y) != b.RGBAAt(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
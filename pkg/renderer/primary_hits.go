@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"math"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+const (
+	primaryBatchSize = 8     // Most camera rays of a pixel traced together
+	cameraRayTMin    = 0.001 // The integrators' tMin for intersecting the camera ray
+)
+
+// primaryHits traces a batch of a pixel's camera rays together with geometry.HitMany and answers
+// the integrator's query for each camera ray from the batch, passing every other query (bounces,
+// shadow rays) on to the scene's intersector. The camera rays don't depend on the integrator's
+// random numbers, so they can be traced ahead; the hits are exactly those the integrator would
+// have found, so images don't change. Each worker has its own, which also counts the rays traced.
+type primaryHits struct {
+	geometry.Intersector
+	scene   scene.Scene // The scene given to the integrator, with this as its intersector
+	rays    []core.Ray
+	hits    []*material.SurfaceInteraction
+	current int       // Batch index of the camera ray being integrated, -1 once its hit was used
+	traced  RayCounts // Rays traced by this worker
+
+	timed         bool          // Whether to time the intersector, for the BVH cost heatmap
+	intersectTime time.Duration // Time spent in the intersector while timed
+}
+
+// newPrimaryHits wraps the scene's intersector
+func newPrimaryHits(s *scene.Scene) *primaryHits {
+	p := &primaryHits{
+		Intersector: s.Intersector,
+		scene:       *s,
+		hits:        make([]*material.SurfaceInteraction, primaryBatchSize),
+		current:     -1,
+	}
+	p.scene.Intersector = p
+	return p
+}
+
+// trace finds the closest hits of a batch of camera rays
+func (p *primaryHits) trace(rays []core.Ray) {
+	p.rays = rays
+	if p.Intersector == nil {
+		return // Scenes that aren't preprocessed, for integrators that don't intersect anything
+	}
+	p.traced.Primary += int64(len(rays))
+	if !p.timed {
+		geometry.HitMany(p.Intersector, rays, cameraRayTMin, math.Inf(1), p.hits[:len(rays)])
+		return
+	}
+	start := time.Now()
+	geometry.HitMany(p.Intersector, rays, cameraRayTMin, math.Inf(1), p.hits[:len(rays)])
+	p.intersectTime += time.Since(start)
+}
+
+// use makes the hit of camera ray k the answer to the next query for that ray
+func (p *primaryHits) use(k int) {
+	p.current = k
+}
+
+// Hit answers the query for the current camera ray from the batch, once
+func (p *primaryHits) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	if p.current >= 0 && ray == p.rays[p.current] && tMin == cameraRayTMin && math.IsInf(tMax, 1) {
+		hit := p.hits[p.current]
+		p.current = -1
+		return hit, hit != nil
+	}
+	p.traced.Scatter++
+	if !p.timed {
+		return p.Intersector.Hit(ray, tMin, tMax)
+	}
+	start := time.Now()
+	hit, isHit := p.Intersector.Hit(ray, tMin, tMax)
+	p.intersectTime += time.Since(start)
+	return hit, isHit
+}
+
+// HitAny passes shadow rays on to the scene's intersector, counting them
+func (p *primaryHits) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	p.traced.Shadow++
+	if !p.timed {
+		return p.Intersector.HitAny(ray, tMin, tMax)
+	}
+	start := time.Now()
+	isHit := p.Intersector.HitAny(ray, tMin, tMax)
+	p.intersectTime += time.Since(start)
+	return isHit
+}
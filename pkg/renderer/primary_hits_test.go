@@ -0,0 +1,56 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// countingIntersector counts the queries that reach the scene's intersector
+type countingIntersector struct {
+	geometry.Intersector
+	hits int
+}
+
+func (c *countingIntersector) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	c.hits++
+	return c.Intersector.Hit(ray, tMin, tMax)
+}
+
+func TestPrimaryHitsAnswersCameraRayOnce(t *testing.T) {
+	testScene := createTestScene()
+	counter := &countingIntersector{Intersector: testScene.Intersector}
+	testScene.Intersector = counter
+	primary := newPrimaryHits(testScene)
+	if primary.scene.Intersector != primary {
+		t.Fatal("Expected the integrator's scene to query the batch")
+	}
+
+	rays := []core.Ray{
+		core.NewRay(core.Vec3{}, core.NewVec3(0, 0, -1)), // Hits the sphere
+		core.NewRay(core.Vec3{}, core.NewVec3(0, 1, 0)),  // Misses
+	}
+	primary.trace(rays)
+	traced := counter.hits
+
+	primary.use(0)
+	hit, isHit := primary.Hit(rays[0], cameraRayTMin, math.Inf(1))
+	if !isHit || math.Abs(hit.T-0.5) > 1e-9 || counter.hits != traced {
+		t.Errorf("Expected the batched hit at t=0.5 without a query, got %v, %d queries", hit, counter.hits-traced)
+	}
+
+	// The hit is used once; later queries (even for the same ray) and bounces go to the scene
+	primary.Hit(rays[0], cameraRayTMin, math.Inf(1))
+	primary.Hit(core.NewRay(core.NewVec3(0, 0, -0.5), core.NewVec3(0, 0, 1)), cameraRayTMin, math.Inf(1))
+	if counter.hits != traced+2 {
+		t.Errorf("Expected 2 queries to reach the scene, got %d", counter.hits-traced)
+	}
+
+	primary.use(1)
+	if _, isHit := primary.Hit(rays[1], cameraRayTMin, math.Inf(1)); isHit || counter.hits != traced+2 {
+		t.Errorf("Expected the batched miss without a query")
+	}
+}
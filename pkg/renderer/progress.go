@@ -0,0 +1,131 @@
+package renderer
+
+import (
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+// Progress reports how far a progressive render has come. RenderProgressive sends one after each
+// tile of a pass completes and one after each pass.
+type Progress struct {
+	Pass        int  // Pass being rendered (1-based, pyramid levels included)
+	TotalPasses int  // Passes planned; a stopping criterion may end the render sooner
+	TilesDone   int  // Tiles of the pass completed
+	TotalTiles  int  // Tiles in the pass
+	PassDone    bool // Whether the pass is complete, its image assembled
+	Done        bool // Whether the render is complete: this was its last pass
+
+	Fraction         float64         // Estimated fraction of the render done, from the samples planned for each pass (0-1)
+	Elapsed          time.Duration   // Time since the render started
+	ETA              time.Duration   // Estimated time remaining (0 when done or before anything completes)
+	Samples          int64           // Camera samples taken so far, pyramid levels included
+	Rays             int64           // Rays traced so far: camera, bounce and shadow rays
+	SamplesPerSecond float64         // Samples over the elapsed time
+	RaysPerSecond    float64         // Rays over the elapsed time
+	PassTimes        []time.Duration // Durations of the completed passes, in order
+}
+
+// progressTracker accumulates a render's progress as its tiles complete
+type progressTracker struct {
+	report    func(Progress) // Progress callback (nil = none)
+	start     time.Time      // Start of the render
+	counters  *core.Counters // Counters at the start of the render (nil unless compiled in)
+	samples   int64
+	rays      RayCounts
+	passTimes []time.Duration
+}
+
+// passWork returns the camera samples planned for a pass (1-based, pyramid levels included),
+// the measure of work progress is estimated from. Adaptive sampling may take fewer.
+func (pr *ProgressiveRaytracer) passWork(pass int) float64 {
+	if pass <= len(pr.levels) {
+		level := pr.levels[pass-1]
+		return float64(min(pyramidLevelSamples, pr.config.MaxSamplesPerPixel) * level.width * level.height)
+	}
+	imagePass := pass - len(pr.levels)
+	target := func(imagePass int) int {
+		if imagePass < 1 {
+			return 0
+		}
+		samples := pr.getSamplesForPass(imagePass)
+		if pr.budgetSamples > 0 {
+			samples = min(samples, pr.budgetSamples) // Passes after a shortened one have nothing left to do
+		}
+		return samples
+	}
+	pixels := pr.scene.SamplingConfig.Width * pr.scene.SamplingConfig.Height
+	return float64(max(0, target(imagePass)-target(imagePass-1)) * pixels)
+}
+
+// addRenderCounts adds the rays and counters of the render so far to a pass's statistics
+func (pr *ProgressiveRaytracer) addRenderCounts(stats *RenderStats) {
+	stats.Rays = pr.progress.rays
+	stats.Counters = core.ReadCounters().Since(pr.progress.counters)
+}
+
+// recordTile adds a completed tile's samples and rays to the progress and reports it
+func (pr *ProgressiveRaytracer) recordTile(pass, tilesDone, totalTiles int, stats RenderStats) {
+	pr.progress.samples += int64(stats.TotalSamples)
+	pr.progress.rays = pr.progress.rays.Add(stats.Rays)
+	if pr.progress.report != nil {
+		pr.progress.report(pr.currentProgress(pass, tilesDone, totalTiles))
+	}
+}
+
+// recordPass adds a completed pass's time to the progress and reports it
+func (pr *ProgressiveRaytracer) recordPass(pass int, passTime time.Duration, last bool) {
+	pr.progress.passTimes = append(pr.progress.passTimes, passTime)
+	if pr.progress.report != nil {
+		tiles := len(pr.tiles)
+		if pass <= len(pr.levels) {
+			tiles = len(pr.levels[pass-1].tiles)
+		}
+		p := pr.currentProgress(pass, tiles, tiles)
+		p.PassDone = true
+		if last {
+			p.Done, p.Fraction, p.ETA = true, 1, 0
+		}
+		pr.progress.report(p)
+	}
+}
+
+// currentProgress estimates the progress with tilesDone of the pass's tiles complete
+func (pr *ProgressiveRaytracer) currentProgress(pass, tilesDone, totalTiles int) Progress {
+	elapsed := time.Since(pr.progress.start)
+	p := Progress{
+		Pass:        pass,
+		TotalPasses: pr.TotalPasses(),
+		TilesDone:   tilesDone,
+		TotalTiles:  totalTiles,
+		Elapsed:     elapsed,
+		Samples:     pr.progress.samples,
+		Rays:        pr.progress.rays.Total(),
+		PassTimes:   pr.progress.passTimes[:len(pr.progress.passTimes):len(pr.progress.passTimes)],
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		p.SamplesPerSecond = float64(p.Samples) / seconds
+		p.RaysPerSecond = float64(p.Rays) / seconds
+	}
+
+	var done, total float64
+	for i := 1; i <= p.TotalPasses; i++ {
+		work := pr.passWork(i)
+		total += work
+		if i < pass {
+			done += work
+		} else if i == pass {
+			done += work * float64(tilesDone) / float64(max(1, totalTiles))
+		}
+	}
+	if total > 0 {
+		p.Fraction = min(1, done/total)
+	}
+	if p.Fraction > 0 {
+		p.ETA = time.Duration(float64(elapsed) * (1 - p.Fraction) / p.Fraction)
+	}
+	if pr.config.MaxTime > 0 {
+		p.ETA = max(0, min(p.ETA, pr.config.MaxTime-elapsed))
+	}
+	return p
+}
@@ -0,0 +1,89 @@
+package renderer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestRenderProgressive_ReportsProgress(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 24
+	s.SamplingConfig.Height = 16
+	s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+	s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+	config := ProgressiveConfig{
+		TileSize:           8,
+		InitialSamples:     1,
+		MaxSamplesPerPixel: 6,
+		MaxPasses:          3,
+		NumWorkers:         2,
+	}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+
+	var reports []Progress
+	passChan, _, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{
+		OnProgress: func(p Progress) { reports = append(reports, p) },
+	})
+	var last PassResult
+	for result := range passChan {
+		last = result
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// 6 tiles and the pass itself, for each of the 3 passes
+	if len(reports) != 3*7 {
+		t.Fatalf("Expected 21 progress reports, got %d", len(reports))
+	}
+	for i, p := range reports {
+		if i > 0 && (p.Fraction < reports[i-1].Fraction || p.Samples < reports[i-1].Samples || p.Rays < reports[i-1].Rays) {
+			t.Errorf("Report %d: expected progress not to go backwards, got %+v after %+v", i, p, reports[i-1])
+		}
+		if p.TotalPasses != 3 || p.TotalTiles != 6 {
+			t.Errorf("Report %d: expected 3 passes of 6 tiles, got %+v", i, p)
+		}
+		if p.PassDone != (i%7 == 6) || len(p.PassTimes) != i/7+btoi(p.PassDone) {
+			t.Errorf("Report %d: expected a pass report after every 6 tiles, got %+v", i, p)
+		}
+	}
+
+	final := reports[len(reports)-1]
+	if !final.Done || final.Fraction != 1 || final.ETA != 0 {
+		t.Errorf("Expected the last report to finish the render, got %+v", final)
+	}
+	if final.Samples != int64(last.Stats.TotalSamples) {
+		t.Errorf("Expected %d samples, got %d", last.Stats.TotalSamples, final.Samples)
+	}
+	// Every sample traces a camera ray, and the path tracer traces shadow rays and bounces besides
+	if final.Rays <= final.Samples || final.Rays != last.Stats.Rays.Total() {
+		t.Errorf("Expected more rays than the %d samples, matching the image's %d, got %d", final.Samples, last.Stats.Rays.Total(), final.Rays)
+	}
+
+	// Camera rays are traced in batches, so some may go unused
+	if rays := last.Stats.Rays; rays.Primary < final.Samples || rays.Scatter == 0 || rays.Shadow == 0 {
+		t.Errorf("Expected primary rays for every sample plus scatter and shadow rays, got %+v", rays)
+	}
+	if (last.Stats.Counters != nil) != core.CountersEnabled {
+		t.Errorf("Expected counters only when compiled in, got %+v", last.Stats.Counters)
+	}
+
+	// Halfway through the second pass, the first pass (1 of 6 samples) and half the second (2 more) are done
+	if p := reports[7+2]; p.Pass != 2 || p.TilesDone != 3 || p.Fraction != 2.0/6 {
+		t.Errorf("Expected pass 2 with 3 tiles done to be 1/3 of the render, got %+v", p)
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
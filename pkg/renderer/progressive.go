@@ -2,10 +2,10 @@ package renderer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
-	"math/rand"
 	"time"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -13,25 +13,32 @@ import (
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
 )
 
-// DefaultLogger implements core.Logger by writing to stdout
-type DefaultLogger struct{}
-
-func (dl *DefaultLogger) Printf(format string, args ...interface{}) {
-	fmt.Printf(format, args...)
-}
-
-// NewDefaultLogger creates a new default logger
+// NewDefaultLogger returns the renderer's log, which writes to stdout unless core.ConfigureLogging
+// says otherwise
 func NewDefaultLogger() core.Logger {
-	return &DefaultLogger{}
+	return core.NewLog("renderer")
 }
 
 // ProgressiveConfig contains configuration for progressive rendering
 type ProgressiveConfig struct {
-	TileSize           int // Size of each tile (64x64 recommended)
-	InitialSamples     int // Samples for first pass (1 recommended)
-	MaxSamplesPerPixel int // Maximum total samples per pixel
-	MaxPasses          int // Maximum number of passes
-	NumWorkers         int // Number of parallel workers (0 = use CPU count)
+	TileSize           int      // Size of each tile (64x64 recommended)
+	InitialSamples     int      // Samples for first pass (1 recommended)
+	MaxSamplesPerPixel int      // Maximum total samples per pixel
+	MaxPasses          int      // Maximum number of passes
+	NumWorkers         int      // Number of parallel workers (0 = use CPU count)
+	PinWorkers         bool     // Run each worker on its own CPU (Linux only; elsewhere a warning is logged)
+	Seed               uint64   // Render seed; identical seeds produce bit-identical images
+	AOVs               bool     // Also accumulate AOVs (depth, normal, albedo, light split, BDPT strategies)
+	PyramidLevels      int      // Reduced resolution previews (1/2, 1/4, ... 1/2^n) rendered coarsest first before the passes (0 = none)
+	DebugAOVs          []string // Debug heatmaps to add to the AOV images (see DebugAOVNames)
+	Overlays           []string // Wireframe and BVH overlays to add to the AOV images, drawn over the pass image (see OverlayNames)
+	Deep               bool     // Also sort each pixel's samples by depth, for a deep image (see DeepImage)
+	IDs                bool     // Also record the objects and materials each pixel sees, for ID AOVs and mattes (see Cryptomatte)
+	Transparent        bool     // Render the background transparent, and shadow catchers as the shadows and reflections they receive (see material.ShadowCatcher)
+
+	// Stopping criteria besides the pass and sample counts, checked after each pass
+	MaxTime     time.Duration // Wall-clock budget: no pass starts that's predicted to end after it (0 = none)
+	TargetError float64       // Stop once the image's estimated relative error (RenderStats.EstimatedError) is below this (0 = none)
 }
 
 // DefaultProgressiveConfig returns sensible default values
@@ -42,6 +49,7 @@ func DefaultProgressiveConfig() ProgressiveConfig {
 		MaxSamplesPerPixel: 50, // Match original raytracer max samples
 		MaxPasses:          7,  // 1, 2, 4, 8, 16, 32, then adaptive up to 50
 		NumWorkers:         0,  // Auto-detect CPU count
+		Seed:               0,
 	}
 }
 
@@ -51,11 +59,22 @@ type ProgressiveRaytracer struct {
 	config      ProgressiveConfig
 	tiles       []*Tile               // Tile management
 	currentPass int                   // Progressive state
-	pixelStats  [][]PixelStats        // Shared pixel statistics array (global image coordinates)
-	splatQueue  *SplatQueue           // Shared splat queue for BDPT t=1 strategies
+	film        *Film                 // Shared accumulated image (global image coordinates), or the preview profile's during its passes
+	levels      []*pyramidLevel       // Resolution pyramid rendered before the image, coarsest first
 	integrator  integrator.Integrator // Light transport integrator for actual rendering
 	workerPool  *WorkerPool           // Worker pool for parallel processing
 	logger      core.Logger           // Logger for rendering output
+	sampleMask  *sampleMask           // Importance mask scaling each pixel's samples, nil for none
+	filter      *pixelFilter          // Pixel reconstruction filter, nil for box
+
+	budgetSamples int             // Target samples of a last pass shortened to fit the time budget (0 = none)
+	progress      progressTracker // Samples, rays and pass times so far
+	previews      previewer       // Partial pass images sent as tiles complete
+	profile       previewProfile  // Sampling profile for look development previews, off unless enabled
+	edits         sceneEdits      // Scene edits waiting for the next pass (see EditScene)
+	started       bool            // Whether the worker pool has been started
+
+	overlays map[string]*image.RGBA // Overlay lines by name, drawn for the first pass that shows them
 }
 
 // NewProgressiveRaytracer creates a new progressive raytracer with a specific integrator
@@ -64,36 +83,84 @@ func NewProgressiveRaytracer(scene *scene.Scene, config ProgressiveConfig, integ
 	if err := scene.Preprocess(); err != nil {
 		return nil, fmt.Errorf("failed to preprocess scene: %w", err)
 	}
+	if scene.BVH != nil {
+		stats := scene.BVH.Stats()
+		logger.Printf("BVH: %d nodes (%d leaves, %d shape references), depth max %d / avg %.1f, SAH cost %.2f, sibling overlap %.2f\n",
+			stats.TotalNodes, stats.LeafNodes, stats.TotalShapes, stats.MaxDepth, stats.AvgDepth, stats.SAHCost, stats.Overlap)
+	}
+	// Warn about the mistakes that most often render a black image
+	if _, err := scene.Validate(logger); err != nil {
+		return nil, fmt.Errorf("failed to validate scene: %w", err)
+	}
 	// Create tile grid
 	width := scene.SamplingConfig.Width
 	height := scene.SamplingConfig.Height
 	tiles := NewTileGrid(width, height, config.TileSize)
 
-	// Initialize shared pixel statistics array (global image coordinates)
-	pixelStats := make([][]PixelStats, height)
-	for y := range pixelStats {
-		pixelStats[y] = make([]PixelStats, width)
+	// Initialize the shared film (global image coordinates)
+	film := newImageFilm(width, height, config)
+
+	var mask *sampleMask
+	if scene.SamplingConfig.SampleMask != "" {
+		var err error
+		if mask, err = loadSampleMask(scene.SamplingConfig.SampleMask, width, height); err != nil {
+			return nil, fmt.Errorf("failed to load sample mask: %w", err)
+		}
 	}
 
-	// Create shared splat queue for BDPT t=1 strategies
-	splatQueue := NewSplatQueue()
+	filter, err := newPixelFilter(scene.SamplingConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create worker pool
 	workerPool := NewWorkerPool(scene, integratorInst, width, height, config.TileSize, config.NumWorkers)
+	if config.PinWorkers {
+		if err := workerPool.PinWorkers(logger); err != nil {
+			logger.Printf("Warning: workers not pinned to CPUs: %v\n", err)
+		}
+	}
 
 	return &ProgressiveRaytracer{
 		scene:       scene,
 		config:      config,
 		tiles:       tiles,
 		currentPass: 0,
-		pixelStats:  pixelStats,
-		splatQueue:  splatQueue,
+		film:        film,
+		levels:      newPyramidLevels(width, height, config.PyramidLevels, config.TileSize),
 		integrator:  integratorInst,
 		workerPool:  workerPool,
 		logger:      logger,
+		sampleMask:  mask,
+		filter:      filter,
 	}, nil
 }
 
+// newImageFilm creates a full resolution film with the per-pixel statistics the config asks for
+func newImageFilm(width, height int, config ProgressiveConfig) *Film {
+	film := newFilm(width, height)
+	for y := range film.pixels {
+		for x := range film.pixels[y] {
+			if config.AOVs {
+				film.pixels[y][x].AOV = &AOVStats{}
+			}
+			if len(config.DebugAOVs) > 0 {
+				film.pixels[y][x].Debug = &DebugStats{}
+			}
+			if config.Deep {
+				film.pixels[y][x].Deep = &DeepStats{}
+			}
+			if config.IDs {
+				film.pixels[y][x].IDs = &IDStats{}
+			}
+			if config.Transparent {
+				film.pixels[y][x].Alpha = &AlphaStats{}
+			}
+		}
+	}
+	return film
+}
+
 // getSamplesForPass calculates the target total samples for a given pass
 func (pr *ProgressiveRaytracer) getSamplesForPass(passNumber int) int {
 	// Special case: if only 1 pass, use all samples
@@ -123,57 +190,113 @@ func (pr *ProgressiveRaytracer) getSamplesForPass(passNumber int) int {
 }
 
 // RenderPass renders a single progressive pass using parallel processing
-func (pr *ProgressiveRaytracer) RenderPass(passNumber int, tileCallback func(TileCompletionResult)) (*image.RGBA, RenderStats, error) {
+// With a resolution pyramid, the first passes render its levels and the image passes follow.
+// Cancelling ctx abandons the pass between pixels and returns ctx's error; the pixels then hold
+// part of the pass, so the raytracer shouldn't render further passes.
+func (pr *ProgressiveRaytracer) RenderPass(ctx context.Context, passNumber int, tileCallback func(TileCompletionResult)) (*image.RGBA, RenderStats, error) {
 	pr.currentPass = passNumber
+	passIntegrator := pr.integrator
+	if passNumber > len(pr.levels) {
+		passIntegrator = pr.selectProfile(passNumber)
+	}
 
-	// Calculate target samples for this pass
-	targetSamples := pr.getSamplesForPass(passNumber)
-
-	pr.logger.Printf("Pass %d: Target %d samples per pixel (using %d workers)...\n",
-		passNumber, targetSamples, pr.workerPool.GetNumWorkers())
-
-	// Target samples are handled by the worker pool task system
+	// Integrators that learn from what they render, such as path guiding, do so once the pass is done
+	if learner, ok := passIntegrator.(integrator.PassLearner); ok {
+		defer learner.EndPass()
+	}
 
 	// Start worker pool if not already started
-	if passNumber == 1 {
+	if !pr.started {
+		pr.started = true
 		pr.workerPool.Start()
+		pr.progress.start = time.Now()
+		pr.progress.counters = core.ReadCounters()
+	}
+
+	// Pyramid levels are quick, so they're only previewed by their finished images
+	pr.previews.startPass(pr.film.width, pr.film.height)
+	if passNumber <= len(pr.levels) {
+		img, stats, err := pr.renderPyramidLevel(ctx, passNumber, tileCallback)
+		pr.previews.finishPass(img)
+		return img, stats, err
 	}
 
-	// No need to clear tile callbacks since we handle them internally now
+	// Calculate target samples for this pass
+	targetSamples := pr.getSamplesForPass(passNumber - len(pr.levels))
+	if pr.budgetSamples > 0 {
+		targetSamples = min(targetSamples, pr.budgetSamples)
+	}
 
-	// Submit all tiles as tasks
-	taskID := 0
-	for _, tile := range pr.tiles {
+	profile := ""
+	if pr.profile.current {
+		profile = " with the preview profile"
+	}
+	pr.logger.Printf("Pass %d: Target %d samples per pixel%s (using %d workers)...\n",
+		passNumber, targetSamples, profile, pr.workerPool.GetNumWorkers())
+
+	// Submit the tiles as tasks, split and ordered by what they cost last pass
+	parts := scheduleTiles(pr.tiles, pr.workerPool.GetNumWorkers(), pr.workerPool.maxTasks())
+	remaining := make(map[*Tile]int, len(pr.tiles)) // Parts of each tile still rendering
+	for taskID, part := range parts {
+		remaining[part.tile]++
 		task := TileTask{
-			Tile:          tile,
+			Ctx:           ctx,
+			Tile:          part.tile,
+			Bounds:        part.bounds,
 			PassNumber:    passNumber,
 			TargetSamples: targetSamples,
 			TaskID:        taskID,
-			PixelStats:    pr.pixelStats, // Pass shared pixel stats array
-			SplatQueue:    pr.splatQueue, // Pass shared splat queue
+			Seed:          pr.config.Seed,
+			PixelStats:    pr.film.pixels, // Pass shared pixel stats array
+			Prior:         pr.finestLevelStats(),
+			Mask:          pr.sampleMask,
+			Filter:        pr.filter,
+			Integrator:    passIntegrator,
 		}
 		pr.workerPool.SubmitTask(task)
-		taskID++
 	}
 
 	// Wait for all tiles to complete and dispatch tile callbacks in thread-safe manner
-	for i := 0; i < len(pr.tiles); i++ {
+	var err error
+	costs := make(map[*Tile]time.Duration, len(pr.tiles))
+	tilesDone := 0
+	for i := 0; i < len(parts); i++ {
 		result, ok := pr.workerPool.GetResult()
 		if !ok {
 			return nil, RenderStats{}, fmt.Errorf("worker pool closed unexpectedly")
 		}
-		if result.Error != nil {
-			return nil, RenderStats{}, result.Error
+		// After an error, the other tiles' results are only collected, leaving none in the pool
+		if err == nil {
+			err = result.Error
+		}
+		if err != nil {
+			continue
 		}
 
+		// A tile is complete once all its parts are
+		tile := parts[result.TaskID].tile
+		costs[tile] += result.Duration
+		remaining[tile]--
+		if remaining[tile] > 0 {
+			pr.recordTile(passNumber, tilesDone, len(pr.tiles), result.Stats)
+			continue
+		}
+		tilesDone++
+		tile.cost = costs[tile]
+
 		// Increment completed passes for the corresponding tile
-		tile := pr.tiles[result.TaskID]
 		tile.PassesCompleted++
+		pr.recordTile(passNumber, tilesDone, len(pr.tiles), result.Stats)
+
+		// Extract tile image from the shared pixel stats for the callback and previews
+		var tileImage *image.RGBA
+		if tileCallback != nil || pr.previews.enabled() {
+			tileImage = pr.extractTileImage(tile)
+		}
+		pr.previews.addTile(passNumber, tile, tileImage, tilesDone, len(pr.tiles))
 
 		// Dispatch tile completion callback if provided (thread-safe, single-threaded dispatch)
 		if tileCallback != nil {
-			// Extract tile image from the shared pixel stats
-			tileImage := pr.extractTileImage(tile)
 			tileX := tile.Bounds.Min.X / pr.config.TileSize
 			tileY := tile.Bounds.Min.Y / pr.config.TileSize
 
@@ -184,18 +307,23 @@ func (pr *ProgressiveRaytracer) RenderPass(passNumber int, tileCallback func(Til
 				PassNumber: passNumber,
 
 				// Progress information
-				TileNumber:  i + 1,
+				TileNumber:  tilesDone,
 				TotalTiles:  len(pr.tiles),
-				TotalPasses: pr.config.MaxPasses,
+				TotalPasses: pr.TotalPasses(),
 			})
 		}
 	}
+	if err != nil {
+		return nil, RenderStats{}, err
+	}
 
 	// Process all accumulated splats in a single deterministic phase
-	pr.processSplats()
+	pr.processSplats(pr.film, 1)
 
 	// Assemble image and calculate final stats from actual pixel data
 	img, stats := pr.assembleCurrentImage(targetSamples)
+	pr.addRenderCounts(&stats)
+	pr.previews.finishPass(img)
 
 	// Send all tiles again with splats applied
 	if tileCallback != nil {
@@ -214,7 +342,7 @@ func (pr *ProgressiveRaytracer) RenderPass(passNumber int, tileCallback func(Til
 				// Progress information - send as additional tiles
 				TileNumber:  len(pr.tiles) + i + 1,
 				TotalTiles:  len(pr.tiles),
-				TotalPasses: pr.config.MaxPasses,
+				TotalPasses: pr.TotalPasses(),
 			})
 		}
 	}
@@ -222,6 +350,11 @@ func (pr *ProgressiveRaytracer) RenderPass(passNumber int, tileCallback func(Til
 	return img, stats, nil
 }
 
+// PixelColor returns the current linear (pre-gamma, unclamped) color estimate of a pixel, splats included
+func (pr *ProgressiveRaytracer) PixelColor(x, y int) core.Vec3 {
+	return pr.film.color(x, y)
+}
+
 // extractTileImage extracts a tile image from the shared pixel stats array
 func (pr *ProgressiveRaytracer) extractTileImage(tile *Tile) *image.RGBA {
 	bounds := tile.Bounds
@@ -235,15 +368,15 @@ func (pr *ProgressiveRaytracer) extractTileImage(tile *Tile) *image.RGBA {
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			// Skip if coordinates are out of bounds
-			if y >= len(pr.pixelStats) || x >= len(pr.pixelStats[y]) {
+			if y >= pr.film.height || x >= pr.film.width {
 				continue
 			}
 
-			stats := &pr.pixelStats[y][x]
+			stats := &pr.film.pixels[y][x]
 			if stats.SampleCount > 0 {
-				// Get averaged color using existing method
-				colorVec := stats.GetColor()
-				pixelColor := pr.vec3ToColor(colorVec)
+				// Get averaged color, splats included
+				colorVec := pr.film.color(x, y)
+				pixelColor := pr.vec3ToColorAlpha(colorVec, pr.film.alpha(x, y))
 
 				// Set pixel in tile image (relative coordinates)
 				tileImage.SetRGBA(x-bounds.Min.X, y-bounds.Min.Y, pixelColor)
@@ -258,8 +391,14 @@ func (pr *ProgressiveRaytracer) extractTileImage(tile *Tile) *image.RGBA {
 type PassResult struct {
 	PassNumber int
 	Image      *image.RGBA
+	AOVs       map[string]*image.RGBA // AOV images by name, nil unless AOVs, debug AOVs or overlays are enabled
+	Exposure   *Exposure              // Luminance histogram and clipping of the image, nil for pyramid levels
 	Stats      RenderStats
 	IsLast     bool
+
+	// Rendered with the preview sampling profile into a film of its own: a look development
+	// preview, whose samples the final image doesn't include (see EnablePreviewProfile)
+	PreviewProfile bool
 }
 
 // TileCompletionResult contains information about a completed tile for callbacks
@@ -277,7 +416,13 @@ type TileCompletionResult struct {
 
 // RenderOptions configures progressive rendering behavior
 type RenderOptions struct {
-	TileUpdates bool // Whether to generate tile completion events
+	TileUpdates bool           // Whether to generate tile completion events
+	OnProgress  func(Progress) // Called after each tile and pass from the rendering goroutine, which it holds up (nil = none)
+
+	// Partial pass images, sent from the rendering goroutine at most once per interval while a
+	// pass's tiles complete, besides the finished passes (nil = none; interval 0 = after every tile)
+	OnPreview       func(Preview)
+	PreviewInterval time.Duration
 }
 
 // RenderProgressive renders with channel-based communication (idiomatic Go)
@@ -300,10 +445,14 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 		}
 		defer close(errChan)
 		defer pr.workerPool.Stop()
+		defer pr.edits.end()
 
-		pr.logger.Printf("Starting progressive rendering with %d passes...\n", pr.config.MaxPasses)
+		pr.logger.Printf("Starting progressive rendering with %d passes...\n", pr.TotalPasses())
+		renderStart := time.Now()
+		pr.progress.report = options.OnProgress
+		pr.previews.report, pr.previews.interval = options.OnPreview, options.PreviewInterval
 
-		for pass := 1; pass <= pr.config.MaxPasses; pass++ {
+		for pass := 1; pass <= pr.TotalPasses(); pass++ {
 			// Check if client disconnected before starting this pass
 			select {
 			case <-ctx.Done():
@@ -313,6 +462,11 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 			default:
 			}
 
+			// Edits change the scene the image shows, so it starts over
+			if pr.applySceneEdits() {
+				pass = 1
+			}
+
 			startTime := time.Now()
 
 			// Create tile callback only if tile updates are enabled
@@ -329,8 +483,16 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 				}
 			}
 
-			img, stats, err := pr.RenderPass(pass, tileCallback)
+			img, stats, err := pr.RenderPass(pr.edits.startPass(ctx), pass, tileCallback)
+			pr.edits.endPass()
+			if errors.Is(err, context.Canceled) && ctx.Err() == nil && pr.edits.waiting() {
+				pr.logger.Printf("Pass %d abandoned for scene edits\n", pass)
+				continue
+			}
 			if err != nil {
+				if ctx.Err() != nil {
+					pr.logger.Printf("Rendering cancelled during pass %d\n", pass)
+				}
 				errChan <- err
 				return
 			}
@@ -338,16 +500,33 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 			passTime := time.Since(startTime)
 			actualSamples := int(stats.AverageSamples)
 
-			pr.logger.Printf("Pass %d completed in %v (actual: %d samples/pixel)\n",
-				pass, passTime, actualSamples)
-
-			// Send pass completion event
-			isLast := pass == pr.config.MaxPasses || actualSamples >= pr.config.MaxSamplesPerPixel
+			// Send pass completion event (pyramid levels have no AOVs and never finish the render)
+			var stopReason string
+			if pass > len(pr.levels) && pr.profile.current {
+				pr.logger.Printf("Pass %d completed in %v with the preview profile (actual: %d samples/pixel)\n",
+					pass, passTime, actualSamples)
+			} else if pass > len(pr.levels) {
+				pr.logger.Printf("Pass %d completed in %v (actual: %d samples/pixel, estimated error %.2f%%)\n",
+					pass, passTime, actualSamples, 100*stats.EstimatedError)
+				stopReason = pr.checkStopping(pass-len(pr.levels), stats, time.Since(renderStart), passTime)
+			} else {
+				pr.logger.Printf("Pass %d completed in %v (actual: %d samples/pixel)\n",
+					pass, passTime, actualSamples)
+			}
+			isLast := pass == pr.TotalPasses() || stopReason != ""
+			pr.recordPass(pass, passTime, isLast)
 			result := PassResult{
 				PassNumber: pass,
 				Image:      img,
 				Stats:      stats,
 				IsLast:     isLast,
+
+				PreviewProfile: pr.profile.current,
+			}
+			if pass > len(pr.levels) {
+				result.AOVs = pr.assembleOverlays(img, pr.assembleIDImages(pr.assembleDebugImages(pr.assembleAOVImages())))
+				exposure := pr.Exposure()
+				result.Exposure = &exposure
 			}
 
 			select {
@@ -356,9 +535,9 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 				return
 			}
 
-			// Check if we've reached maximum samples
-			if actualSamples >= pr.config.MaxSamplesPerPixel {
-				pr.logger.Printf("Reached maximum samples per pixel (%d), stopping.\n", pr.config.MaxSamplesPerPixel)
+			// Check if we've reached maximum samples, the target error or the time budget
+			if stopReason != "" {
+				pr.logger.Printf("%s, stopping.\n", stopReason)
 				break
 			}
 		}
@@ -367,26 +546,57 @@ func (pr *ProgressiveRaytracer) RenderProgressive(ctx context.Context, options R
 	return passChan, tileChan, errChan
 }
 
-// processSplats applies all pending splats to the pixel stats in a single deterministic phase
-func (pr *ProgressiveRaytracer) processSplats() {
-	startTime := time.Now()
-	splats := pr.splatQueue.GetAllSplats()
-
-	// Apply all splats to their target pixels
-	for _, splat := range splats {
-		// Bounds check to ensure we don't write outside the image
-		if splat.Y >= 0 && splat.Y < len(pr.pixelStats) &&
-			splat.X >= 0 && splat.X < len(pr.pixelStats[splat.Y]) {
-			pr.pixelStats[splat.Y][splat.X].AddSplat(splat.Color)
+// checkStopping returns why the render should end after the given image pass (1-based, after any
+// pyramid levels) before its last, or "" to go on. elapsed is the time since the render started.
+// When the next pass wouldn't fit in the time budget, it's shortened to the samples the remaining
+// time affords, and the render ends after it.
+func (pr *ProgressiveRaytracer) checkStopping(imagePass int, stats RenderStats, elapsed, passTime time.Duration) string {
+	if int(stats.AverageSamples) >= pr.config.MaxSamplesPerPixel {
+		return fmt.Sprintf("Reached maximum samples per pixel (%d)", pr.config.MaxSamplesPerPixel)
+	}
+	if pr.config.TargetError > 0 && stats.EstimatedError <= pr.config.TargetError {
+		return fmt.Sprintf("Reached target error of %.2f%% (estimated %.2f%%)", 100*pr.config.TargetError, 100*stats.EstimatedError)
+	}
+	if pr.budgetSamples > 0 {
+		return fmt.Sprintf("Time budget of %v spent (%v)", pr.config.MaxTime, elapsed.Round(time.Millisecond))
+	}
+	if pr.config.MaxTime > 0 && imagePass < pr.config.MaxPasses {
+		// Predict the next pass's time from this pass's time per sample
+		previous := 0
+		if imagePass > 1 {
+			previous = pr.getSamplesForPass(imagePass - 1)
+		}
+		current := pr.getSamplesForPass(imagePass)
+		if elapsed >= pr.config.MaxTime {
+			return fmt.Sprintf("Time budget of %v spent (%v)", pr.config.MaxTime, elapsed.Round(time.Millisecond))
+		}
+		perSample := passTime / time.Duration(max(1, current-previous))
+		if elapsed+perSample*time.Duration(pr.getSamplesForPass(imagePass+1)-current) > pr.config.MaxTime {
+			affordable := 0
+			if perSample > 0 {
+				affordable = int((pr.config.MaxTime - elapsed) / perSample)
+			}
+			if affordable < 1 {
+				return fmt.Sprintf("Time budget of %v would run out during the next pass (%v spent)", pr.config.MaxTime, elapsed.Round(time.Millisecond))
+			}
+			pr.budgetSamples = current + affordable
+			pr.logger.Printf("Shortening the next pass to %d samples per pixel to fit the time budget of %v\n", pr.budgetSamples, pr.config.MaxTime)
 		}
 	}
+	return ""
+}
 
-	// Clear the splat queue for the next pass
-	pr.splatQueue.Clear()
+// processSplats adds the splats the workers collected in a pass to a film in a single
+// deterministic phase. Splats are addressed in image pixels; scale maps them to the pixels of a
+// pyramid level.
+func (pr *ProgressiveRaytracer) processSplats(film *Film, scale int) {
+	startTime := time.Now()
+	splats := pr.workerPool.TakeSplats()
+	film.addSplats(splats, scale)
 
 	duration := time.Since(startTime)
 	if len(splats) > 0 {
-		pr.logger.Printf("Processed %d splats in %v\n", len(splats), duration)
+		core.Logf(pr.logger, core.LevelDebug, "Processed %d splats in %v\n", len(splats), duration)
 	}
 }
 
@@ -410,22 +620,24 @@ func (pr *ProgressiveRaytracer) assembleCurrentImage(targetSamples int) (*image.
 	// Single pass: create image and calculate stats
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
-			pixel := &pr.pixelStats[y][x]
+			pixel := &pr.film.pixels[y][x]
 
 			// Create image pixel
-			colorVec := pixel.GetColor()
-			pixelColor := pr.vec3ToColor(colorVec)
+			colorVec := pr.film.color(x, y)
+			pixelColor := pr.vec3ToColorAlpha(colorVec, pr.film.alpha(x, y))
 			img.SetRGBA(x, y, pixelColor)
 
 			// Update statistics
 			stats.TotalSamples += pixel.SampleCount
 			stats.MinSamples = min(stats.MinSamples, pixel.SampleCount)
 			stats.MaxSamplesUsed = max(stats.MaxSamplesUsed, pixel.SampleCount)
+			stats.EstimatedError += pixel.RelativeError()
 		}
 	}
 
 	// Finalize statistics
 	stats.AverageSamples = float64(stats.TotalSamples) / float64(stats.TotalPixels)
+	stats.EstimatedError /= float64(stats.TotalPixels)
 
 	return img, stats
 }
@@ -435,19 +647,15 @@ type Tile struct {
 	ID              int             // Unique tile identifier
 	Bounds          image.Rectangle // Pixel bounds (x0,y0,x1,y1)
 	PassesCompleted int             // Number of passes completed for this tile
-	Sampler         core.Sampler    // Tile-specific random generator for deterministic results
+	cost            time.Duration   // How long the tile's last pass took to render (0 = not yet measured)
 }
 
 // NewTile creates a new tile with the specified bounds
 func NewTile(id int, bounds image.Rectangle) *Tile {
-	// Create deterministic random generator based on tile ID
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(int64(id + 42)))) // +42 to avoid seed 0
-
 	return &Tile{
 		ID:              id,
 		Bounds:          bounds,
 		PassesCompleted: 0,
-		Sampler:         sampler,
 	}
 }
 
@@ -480,6 +688,11 @@ func NewTileGrid(width, height, tileSize int) []*Tile {
 
 // vec3ToColor converts a Vec3 color to RGBA with proper clamping and gamma correction
 func (pr *ProgressiveRaytracer) vec3ToColor(colorVec core.Vec3) color.RGBA {
+	return displayColor(colorVec)
+}
+
+// displayColor converts a linear color to gamma corrected, clamped RGBA
+func displayColor(colorVec core.Vec3) color.RGBA {
 	// Apply gamma correction (gamma = 2.0)
 	colorVec = colorVec.GammaCorrect(2.0)
 
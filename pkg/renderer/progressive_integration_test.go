@@ -1,6 +1,7 @@
 package renderer
 
 import (
+	"context"
 	"image"
 	"image/png"
 	"math"
@@ -499,7 +500,7 @@ func TestIntegratorLuminanceComparison(t *testing.T) {
 				t.Fatalf("Failed to create path tracing renderer: %v", err)
 			}
 
-			pathImage, _, err := pathRenderer.RenderPass(1, nil)
+			pathImage, _, err := pathRenderer.RenderPass(context.Background(), 1, nil)
 			if err != nil {
 				t.Fatalf("Path tracing render failed: %v", err)
 			}
@@ -513,7 +514,7 @@ func TestIntegratorLuminanceComparison(t *testing.T) {
 				t.Fatalf("Failed to create BDPT renderer: %v", err)
 			}
 
-			bdptImage, _, err := bdptRenderer.RenderPass(1, nil)
+			bdptImage, _, err := bdptRenderer.RenderPass(context.Background(), 1, nil)
 			if err != nil {
 				t.Fatalf("BDPT render failed: %v", err)
 			}
@@ -1,8 +1,14 @@
 package renderer
 
 import (
-	"image"
+	"context"
+	"errors"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
 )
 
 func TestProgressiveSampleCalculation(t *testing.T) {
@@ -33,6 +39,47 @@ func TestProgressiveSampleCalculation(t *testing.T) {
 	}
 }
 
+func TestCheckStopping(t *testing.T) {
+	config := DefaultProgressiveConfig()
+	config.MaxSamplesPerPixel = 50
+	config.MaxPasses = 7 // 1, 9, 17, 25, 33, 41, 50 samples per pixel
+
+	tests := []struct {
+		name          string
+		pass          int
+		stats         RenderStats
+		maxTime       time.Duration
+		targetError   float64
+		elapsed       time.Duration
+		stop          bool
+		budgetSamples int // Target samples of a shortened next pass
+	}{
+		{"no criteria", 2, RenderStats{AverageSamples: 9, EstimatedError: 0.2}, 0, 0, time.Hour, false, 0},
+		{"all samples", 7, RenderStats{AverageSamples: 50}, 0, 0, 0, true, 0},
+		{"target error met", 2, RenderStats{AverageSamples: 9, EstimatedError: 0.009}, 0, 0.01, 0, true, 0},
+		{"target error not met", 2, RenderStats{AverageSamples: 9, EstimatedError: 0.02}, 0, 0.01, 0, false, 0},
+		// Pass 2 added 8 samples in a second, so pass 3's 8 more should take another second
+		{"next pass fits the budget", 2, RenderStats{AverageSamples: 9}, 3500 * time.Millisecond, 0, 2 * time.Second, false, 0},
+		{"next pass shortened to the budget", 2, RenderStats{AverageSamples: 9}, 2500 * time.Millisecond, 0, 2 * time.Second, false, 9 + 4},
+		{"no time for a sample", 2, RenderStats{AverageSamples: 9}, 2100 * time.Millisecond, 0, 2 * time.Second, true, 0},
+		{"budget spent", 1, RenderStats{AverageSamples: 1}, time.Second, 0, 2 * time.Second, true, 0},
+	}
+	for _, tt := range tests {
+		config.MaxTime, config.TargetError = tt.maxTime, tt.targetError
+		pr := &ProgressiveRaytracer{config: config, logger: &testLogger{}}
+		if reason := pr.checkStopping(tt.pass, tt.stats, tt.elapsed, time.Second); (reason != "") != tt.stop {
+			t.Errorf("%s: expected stopping %v, got reason %q", tt.name, tt.stop, reason)
+		}
+		if pr.budgetSamples != tt.budgetSamples {
+			t.Errorf("%s: expected a shortened pass of %d samples, got %d", tt.name, tt.budgetSamples, pr.budgetSamples)
+		}
+		// The shortened pass is the last
+		if pr.budgetSamples > 0 && pr.checkStopping(tt.pass+1, tt.stats, tt.elapsed, time.Second) == "" {
+			t.Errorf("%s: expected to stop after the shortened pass", tt.name)
+		}
+	}
+}
+
 func TestProgressiveConfig(t *testing.T) {
 	// Test default configuration
 	config := DefaultProgressiveConfig()
@@ -103,25 +150,137 @@ func TestNewTileGrid(t *testing.T) {
 	}
 }
 
-func TestTileDeterministicRandom(t *testing.T) {
-	// Create two tiles with the same ID
-	bounds := image.Rect(0, 0, 64, 64)
-	tile1 := NewTile(42, bounds)
-	tile2 := NewTile(42, bounds)
+func TestPixelPassSeedDeterministic(t *testing.T) {
+	// Same inputs must give the same seed
+	if pixelPassSeed(42, 3, 7, 0) != pixelPassSeed(42, 3, 7, 0) {
+		t.Error("pixelPassSeed should be deterministic")
+	}
+
+	// Changing any input should give a different seed
+	base := pixelPassSeed(42, 3, 7, 0)
+	variants := map[string]uint64{
+		"seed":        pixelPassSeed(43, 3, 7, 0),
+		"x":           pixelPassSeed(42, 4, 7, 0),
+		"y":           pixelPassSeed(42, 3, 8, 0),
+		"pass offset": pixelPassSeed(42, 3, 7, 1),
+		"swapped x/y": pixelPassSeed(42, 7, 3, 0),
+	}
+	for name, variant := range variants {
+		if variant == base {
+			t.Errorf("Changing %s should change the pixel seed", name)
+		}
+	}
+}
+
+func TestProgressiveRender_IndependentOfWorkerCount(t *testing.T) {
+	// Compare the accumulated floating point colors, which are stricter than the 8-bit image
+	render := func(numWorkers int, seed uint64) []core.Vec3 {
+		s := createTestScene()
+		s.SamplingConfig.Width = 24
+		s.SamplingConfig.Height = 24
+		s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+		s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+		config := ProgressiveConfig{
+			TileSize:           8,
+			InitialSamples:     1,
+			MaxSamplesPerPixel: 6,
+			MaxPasses:          3,
+			NumWorkers:         numWorkers,
+			Seed:               seed,
+		}
+		raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewBDPTIntegrator(s.SamplingConfig), &testLogger{})
+		if err != nil {
+			t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+		}
+		defer raytracer.workerPool.Stop() // RenderPass starts the pool on pass 1
+
+		for pass := 1; pass <= config.MaxPasses; pass++ {
+			if _, _, err = raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+				t.Fatalf("RenderPass failed: %v", err)
+			}
+		}
+
+		var colors []core.Vec3
+		for _, row := range raytracer.film.pixels {
+			for _, ps := range row {
+				colors = append(colors, ps.GetColor())
+			}
+		}
+		return colors
+	}
+
+	single := render(1, 7)
+	parallel := render(4, 7)
+	if !slices.Equal(single, parallel) {
+		t.Error("Expected bit-identical pixel colors with 1 and 4 workers for the same seed")
+	}
+
+	if slices.Equal(single, render(4, 8)) {
+		t.Error("Expected different seeds to produce different images")
+	}
+}
+
+func TestRenderPass_Cancelled(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 24
+	s.SamplingConfig.Height = 24
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 4, MaxPasses: 2, NumWorkers: 2}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
 
-	// They should have the same random seed and produce the same sequence
-	val1 := tile1.Sampler.Get1D()
-	val2 := tile2.Sampler.Get1D()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tiles := 0
+	img, _, err := raytracer.RenderPass(ctx, 1, func(TileCompletionResult) { tiles++ })
+	if !errors.Is(err, context.Canceled) || img != nil || tiles != 0 {
+		t.Fatalf("Expected the pass cancelled without an image or tiles, got %v and %d tiles", err, tiles)
+	}
+	for _, row := range raytracer.film.pixels {
+		for _, ps := range row {
+			if ps.SampleCount != 0 {
+				t.Fatalf("Expected no samples taken in a cancelled pass, got %d", ps.SampleCount)
+			}
+		}
+	}
 
-	if val1 != val2 {
-		t.Errorf("Tiles with same ID should produce same random values: %f != %f", val1, val2)
+	// Every tile's result was collected, so none is left over for the next pass
+	if img, _, err := raytracer.RenderPass(context.Background(), 2, nil); err != nil || img == nil {
+		t.Errorf("Expected the next pass to render, got %v", err)
 	}
+}
 
-	// Different tile IDs should produce different sequences
-	tile3 := NewTile(43, bounds)
-	val3 := tile3.Sampler.Get1D()
+func TestRenderProgressive_CancelledDuringPass(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 32
+	s.SamplingConfig.Height = 32
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 1 << 20, MaxPasses: 2, NumWorkers: 2}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
 
-	if val1 == val3 {
-		t.Error("Tiles with different IDs should produce different random values")
+	// The second pass would take minutes; cancelling it must end the render promptly
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	passChan, _, errChan := raytracer.RenderProgressive(ctx, RenderOptions{})
+	if pass := <-passChan; pass.PassNumber != 1 || pass.Image == nil {
+		t.Fatalf("Expected the first pass, got pass %d", pass.PassNumber)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the render cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Render didn't stop within 5s of being cancelled")
+	}
+	if pass, ok := <-passChan; ok {
+		t.Errorf("Expected no pass after cancelling, got pass %d", pass.PassNumber)
 	}
 }
@@ -0,0 +1,163 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+const (
+	// pyramidLevelSamples is the sample target for every pixel of a pyramid level. A level pixel
+	// covers scale² image pixels, so even the half resolution level costs about one image sample.
+	pyramidLevelSamples = 4
+
+	// pyramidPriorWeight caps how many samples a parent pixel's statistics count as in the adaptive
+	// convergence test, so a pixel's own samples soon outweigh the (blurrier) parent estimate
+	pyramidPriorWeight = 4
+
+	pyramidSeedSalt = 0x3c6ef372fe94f82b // Separates the levels' random sequences from the image's
+)
+
+// pyramidLevel is a reduced resolution copy of the image rendered before the image itself
+// Each of its pixels covers a scale x scale block of image pixels (less at the right and bottom edges).
+type pyramidLevel struct {
+	scale  int
+	width  int
+	height int
+	film   *Film
+	tiles  []*Tile
+}
+
+// newPyramidLevels creates the levels rendered before full resolution, coarsest first
+// Each level has half the resolution of the next, so every level pixel has a parent at (x/2, y/2).
+func newPyramidLevels(width, height, levels, tileSize int) []*pyramidLevel {
+	var pyramid []*pyramidLevel
+	for level := levels; level >= 1; level-- {
+		scale := 1 << level
+		levelWidth := (width + scale - 1) / scale
+		levelHeight := (height + scale - 1) / scale
+
+		pyramid = append(pyramid, &pyramidLevel{
+			scale:  scale,
+			width:  levelWidth,
+			height: levelHeight,
+			film:   newFilm(levelWidth, levelHeight),
+			tiles:  NewTileGrid(levelWidth, levelHeight, tileSize),
+		})
+	}
+	return pyramid
+}
+
+// TotalPasses returns the number of passes RenderProgressive makes: the pyramid levels, then MaxPasses
+func (pr *ProgressiveRaytracer) TotalPasses() int {
+	return len(pr.levels) + pr.config.MaxPasses
+}
+
+// finestLevelStats returns the statistics of the last pyramid level, the prior for the image itself
+func (pr *ProgressiveRaytracer) finestLevelStats() [][]PixelStats {
+	if len(pr.levels) == 0 {
+		return nil
+	}
+	return pr.levels[len(pr.levels)-1].film.pixels
+}
+
+// renderPyramidLevel renders one pyramid level, seeding its adaptive sampling with the coarser level
+// before it, and returns it upscaled to the full image size
+func (pr *ProgressiveRaytracer) renderPyramidLevel(ctx context.Context, passNumber int, tileCallback func(TileCompletionResult)) (*image.RGBA, RenderStats, error) {
+	level := pr.levels[passNumber-1]
+	var prior [][]PixelStats
+	if passNumber > 1 {
+		prior = pr.levels[passNumber-2].film.pixels
+	}
+	targetSamples := min(pyramidLevelSamples, pr.config.MaxSamplesPerPixel)
+
+	pr.logger.Printf("Pass %d: Preview at 1/%d resolution (%dx%d), target %d samples per pixel (using %d workers)...\n",
+		passNumber, level.scale, level.width, level.height, targetSamples, pr.workerPool.GetNumWorkers())
+
+	for taskID, tile := range level.tiles {
+		pr.workerPool.SubmitTask(TileTask{
+			Ctx:           ctx,
+			Tile:          tile,
+			PassNumber:    passNumber,
+			TargetSamples: targetSamples,
+			TaskID:        taskID,
+			Seed:          pr.config.Seed,
+			PixelStats:    level.film.pixels,
+			Scale:         level.scale,
+			Prior:         prior,
+			Mask:          pr.sampleMask,
+		})
+	}
+	var err error
+	for i := range level.tiles {
+		result, ok := pr.workerPool.GetResult()
+		if !ok {
+			return nil, RenderStats{}, fmt.Errorf("worker pool closed unexpectedly")
+		}
+		if err == nil {
+			err = result.Error
+		}
+		if err == nil {
+			pr.recordTile(passNumber, i+1, len(level.tiles), result.Stats)
+		}
+	}
+	if err != nil {
+		return nil, RenderStats{}, err
+	}
+
+	pr.processSplats(level.film, level.scale)
+	img, stats := pr.assembleLevelImage(level, targetSamples)
+	pr.addRenderCounts(&stats)
+
+	// The image's tiles are sent once the whole level is done, as each covers many level tiles
+	if tileCallback != nil {
+		for i, tile := range pr.tiles {
+			tileImage := image.NewRGBA(image.Rect(0, 0, tile.Bounds.Dx(), tile.Bounds.Dy()))
+			draw.Draw(tileImage, tileImage.Bounds(), img, tile.Bounds.Min, draw.Src)
+
+			tileCallback(TileCompletionResult{
+				TileX:       tile.Bounds.Min.X / pr.config.TileSize,
+				TileY:       tile.Bounds.Min.Y / pr.config.TileSize,
+				TileImage:   tileImage,
+				PassNumber:  passNumber,
+				TileNumber:  i + 1,
+				TotalTiles:  len(pr.tiles),
+				TotalPasses: pr.TotalPasses(),
+			})
+		}
+	}
+
+	return img, stats, nil
+}
+
+// assembleLevelImage upscales a pyramid level to the full image size, each level pixel filling its
+// block, and calculates render statistics over the level's pixels
+func (pr *ProgressiveRaytracer) assembleLevelImage(level *pyramidLevel, targetSamples int) (*image.RGBA, RenderStats) {
+	width := pr.scene.SamplingConfig.Width
+	height := pr.scene.SamplingConfig.Height
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	stats := RenderStats{
+		TotalPixels: level.width * level.height,
+		MaxSamples:  targetSamples,
+		MinSamples:  targetSamples,
+	}
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			pixel := &level.film.pixels[y][x]
+			stats.TotalSamples += pixel.SampleCount
+			stats.MinSamples = min(stats.MinSamples, pixel.SampleCount)
+			stats.MaxSamplesUsed = max(stats.MaxSamplesUsed, pixel.SampleCount)
+		}
+	}
+	stats.AverageSamples = float64(stats.TotalSamples) / float64(stats.TotalPixels)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, pr.vec3ToColor(level.film.color(x/level.scale, y/level.scale)))
+		}
+	}
+
+	return img, stats
+}
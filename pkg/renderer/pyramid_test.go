@@ -0,0 +1,232 @@
+package renderer
+
+import (
+	"context"
+	"image"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// directionIntegrator returns white for rays with a positive X direction and black otherwise
+type directionIntegrator struct{}
+
+func (directionIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []integrator.SplatRay) {
+	if ray.Direction.X > 0 {
+		return core.NewVec3(1, 1, 1), nil
+	}
+	return core.Vec3{}, nil
+}
+
+func TestNewPyramidLevels(t *testing.T) {
+	levels := newPyramidLevels(100, 37, 3, 8)
+
+	expected := []struct{ scale, width, height int }{{8, 13, 5}, {4, 25, 10}, {2, 50, 19}}
+	if len(levels) != len(expected) {
+		t.Fatalf("Expected %d levels, got %d", len(expected), len(levels))
+	}
+	for i, want := range expected {
+		level := levels[i]
+		if level.scale != want.scale || level.width != want.width || level.height != want.height {
+			t.Errorf("Level %d: expected scale %d at %dx%d, got scale %d at %dx%d",
+				i, want.scale, want.width, want.height, level.scale, level.width, level.height)
+		}
+		if len(level.film.pixels) != level.height || len(level.film.pixels[0]) != level.width {
+			t.Errorf("Level %d: pixel stats don't match the level size", i)
+		}
+
+		// Every pixel's parent at (x/2, y/2) must exist in the coarser level
+		if i > 0 {
+			parent := levels[i-1]
+			if (level.width-1)/2 >= parent.width || (level.height-1)/2 >= parent.height {
+				t.Errorf("Level %d: pixels have parents outside level %d", i, i-1)
+			}
+		}
+	}
+
+	if len(newPyramidLevels(100, 37, 0, 8)) != 0 {
+		t.Error("Expected no levels when the pyramid is off")
+	}
+}
+
+func TestBlockSample_CoversBlock(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 10
+	s.SamplingConfig.Height = 10
+	tr := NewTileRenderer(s, &MockIntegrator{})
+
+	// Scale 4 pixel (2, 0) covers image columns 8-9 only, as the image ends there
+	hit := make(map[image.Point]bool)
+	for _, sample := range []core.Vec2{{X: 0, Y: 0}, {X: 0.49, Y: 0.3}, {X: 0.5, Y: 0.6}, {X: 0.999, Y: 0.999}} {
+		x, y, offset := tr.blockSample(2, 0, 4, sample)
+		if x < 8 || x > 9 || y < 0 || y > 3 {
+			t.Errorf("Sample %v mapped to pixel (%d, %d), outside the block", sample, x, y)
+		}
+		if offset.X < 0 || offset.X >= 1 || offset.Y < 0 || offset.Y >= 1 {
+			t.Errorf("Sample %v mapped to offset %v, outside the pixel", sample, offset)
+		}
+		hit[image.Pt(x, y)] = true
+	}
+	if !hit[image.Pt(8, 0)] || !hit[image.Pt(9, 3)] {
+		t.Errorf("Expected the block's corner pixels to be reachable, got %v", hit)
+	}
+}
+
+func TestShouldStopSampling_Prior(t *testing.T) {
+	tr := &TileRenderer{}
+	config := scene.SamplingConfig{AdaptiveMinSamples: 0.1, AdaptiveThreshold: 0.05}
+
+	// Two identical samples look converged on their own
+	var pixel PixelStats
+	pixel.AddSample(core.NewVec3(0.5, 0.5, 0.5))
+	pixel.AddSample(core.NewVec3(0.5, 0.5, 0.5))
+	if !tr.shouldStopSampling(&pixel, nil, 20, config) {
+		t.Fatal("Expected identical samples without a prior to stop sampling")
+	}
+
+	// A noisy parent keeps the pixel sampling
+	var noisy PixelStats
+	for i := 0; i < 8; i++ {
+		noisy.AddSample(core.NewVec3(float64(i%2), float64(i%2), float64(i%2)))
+	}
+	if tr.shouldStopSampling(&pixel, &noisy, 20, config) {
+		t.Error("Expected a noisy prior to keep the pixel sampling")
+	}
+
+	// A smooth parent agreeing with the pixel lets it stop
+	var smooth PixelStats
+	for i := 0; i < 8; i++ {
+		smooth.AddSample(core.NewVec3(0.5, 0.5, 0.5))
+	}
+	if !tr.shouldStopSampling(&pixel, &smooth, 20, config) {
+		t.Error("Expected an agreeing prior to let the pixel stop")
+	}
+
+	// The prior never overrides the minimum sample count
+	var single PixelStats
+	single.AddSample(core.NewVec3(0.5, 0.5, 0.5))
+	if tr.shouldStopSampling(&single, &smooth, 40, config) {
+		t.Error("Expected the minimum sample count to apply with a prior")
+	}
+}
+
+func TestPyramidRender_PassesAndPreviews(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 40
+	s.SamplingConfig.Height = 24
+	s.Camera = geometry.NewCamera(geometry.CameraConfig{
+		LookAt:      core.NewVec3(0, 0, -1),
+		Up:          core.NewVec3(0, 1, 0),
+		Width:       40,
+		AspectRatio: 40.0 / 24.0,
+		VFov:        45.0,
+	})
+
+	config := ProgressiveConfig{
+		TileSize:           8,
+		InitialSamples:     1,
+		MaxSamplesPerPixel: 4,
+		MaxPasses:          2,
+		NumWorkers:         2,
+		PyramidLevels:      2,
+	}
+	raytracer, err := NewProgressiveRaytracer(s, config, directionIntegrator{}, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	if raytracer.TotalPasses() != 4 {
+		t.Fatalf("Expected 2 pyramid passes and 2 image passes, got %d", raytracer.TotalPasses())
+	}
+
+	passChan, tileChan, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{TileUpdates: true})
+	go func() {
+		for range tileChan {
+		}
+	}()
+	var results []PassResult
+	for result := range passChan {
+		results = append(results, result)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 passes, got %d", len(results))
+	}
+	expectedPixels := []int{10 * 6, 20 * 12, 40 * 24, 40 * 24}
+	for i, result := range results {
+		if result.Image.Bounds() != image.Rect(0, 0, 40, 24) {
+			t.Errorf("Pass %d: expected a full size image, got %v", i+1, result.Image.Bounds())
+		}
+		if result.Stats.TotalPixels != expectedPixels[i] {
+			t.Errorf("Pass %d: expected stats over %d pixels, got %d", i+1, expectedPixels[i], result.Stats.TotalPixels)
+		}
+		if result.IsLast != (i == 3) {
+			t.Errorf("Pass %d: IsLast = %v", i+1, result.IsLast)
+		}
+	}
+
+	// The coarsest preview (1/4 resolution) is black on one side of the camera axis and white on the
+	// other, and each 4x4 block of the image shares its level pixel's color
+	preview := results[0].Image
+	for y := 0; y < 24; y++ {
+		left, right := preview.RGBAAt(0, y).R, preview.RGBAAt(39, y).R
+		if min(left, right) != 0 || max(left, right) != 255 {
+			t.Errorf("Row %d: expected opposite black and white edges in the preview, got %d and %d", y, left, right)
+		}
+		if preview.RGBAAt(16, y) != preview.RGBAAt(19, y) || preview.RGBAAt(20, y) != preview.RGBAAt(23, y) {
+			t.Errorf("Row %d: expected pixels of the same block to share a color", y)
+		}
+	}
+}
+
+func TestPyramidRender_IndependentOfWorkerCount(t *testing.T) {
+	// BDPT exercises the splats, which pyramid levels map to their own pixels
+	render := func(numWorkers int) ([]core.Vec3, []PixelStats) {
+		s := createTestScene()
+		s.SamplingConfig.Width = 24
+		s.SamplingConfig.Height = 24
+		s.AddQuadLight(core.NewVec3(-1, 1, -2), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(4, 4, 4))
+		s.LightSampler = nil // Rebuilt with the new light during preprocessing
+
+		config := ProgressiveConfig{
+			TileSize:           8,
+			InitialSamples:     1,
+			MaxSamplesPerPixel: 6,
+			MaxPasses:          2,
+			NumWorkers:         numWorkers,
+			Seed:               3,
+			PyramidLevels:      2,
+		}
+		raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewBDPTIntegrator(s.SamplingConfig), &testLogger{})
+		if err != nil {
+			t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+		}
+		defer raytracer.workerPool.Stop() // RenderPass starts the pool on pass 1
+
+		for pass := 1; pass <= raytracer.TotalPasses(); pass++ {
+			if _, _, err = raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+				t.Fatalf("RenderPass failed: %v", err)
+			}
+		}
+
+		var colors []core.Vec3
+		for _, row := range raytracer.film.pixels {
+			for _, ps := range row {
+				colors = append(colors, ps.GetColor())
+			}
+		}
+		return colors, slices.Concat(raytracer.finestLevelStats()...)
+	}
+
+	singleColors, singleLevel := render(1)
+	parallelColors, parallelLevel := render(4)
+	if !slices.Equal(singleColors, parallelColors) || !slices.Equal(singleLevel, parallelLevel) {
+		t.Error("Expected bit-identical pyramid renders with 1 and 4 workers")
+	}
+}
@@ -0,0 +1,46 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+)
+
+// sampleMask scales each pixel's samples by the gray level of an importance mask image (see
+// scene.SamplingConfig.SampleMask): white pixels take every sample of a pass, mid-gray half,
+// black one. It's stretched over the image, so it needn't have the render's resolution.
+type sampleMask struct {
+	width, height int
+	weights       []float64 // Gray level at each image pixel, row by row
+}
+
+// loadSampleMask loads a PNG or JPEG mask and samples it at the centers of a width x height
+// image's pixels
+func loadSampleMask(filename string, width, height int) (*sampleMask, error) {
+	data, err := loaders.LoadImage(filename)
+	if err != nil {
+		return nil, err
+	}
+	if data.Width == 0 || data.Height == 0 {
+		return nil, fmt.Errorf("sample mask %s is empty", filename)
+	}
+	mask := &sampleMask{width: width, height: height, weights: make([]float64, width*height)}
+	for y := 0; y < height; y++ {
+		my := (2*y + 1) * data.Height / (2 * height)
+		for x := 0; x < width; x++ {
+			mx := (2*x + 1) * data.Width / (2 * width)
+			mask.weights[y*width+x] = math.Min(1, data.Pixels[my*data.Width+mx].Luminance())
+		}
+	}
+	return mask, nil
+}
+
+// maxSamples returns the samples a pixel takes in a pass whose target is samples. Pixel (i, j)
+// covers the scale x scale block of image pixels starting at (i*scale, j*scale), and is weighted
+// by the one in its middle.
+func (m *sampleMask) maxSamples(i, j, scale, samples int) int {
+	x := min(i*scale+scale/2, m.width-1)
+	y := min(j*scale+scale/2, m.height-1)
+	return max(1, int(math.Ceil(m.weights[y*m.width+x]*float64(samples))))
+}
@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+// writeMask writes a 4x2 mask, white on the left half, mid-gray and black on the right
+func writeMask(t *testing.T) string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		img.SetGray(0, y, color.Gray{Y: 255})
+		img.SetGray(1, y, color.Gray{Y: 255})
+		img.SetGray(2, y, color.Gray{Y: 128})
+	}
+	filename := filepath.Join(t.TempDir(), "mask.png")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestSampleMask_ScalesPixelSamples(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 8
+	s.SamplingConfig.AdaptiveMinSamples = 1 // Every pixel takes its maximum
+	s.SamplingConfig.SampleMask = writeMask(t)
+
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 16, MaxPasses: 2, NumWorkers: 2}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	for pass := 1; pass <= config.MaxPasses; pass++ {
+		if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+			t.Fatalf("RenderPass failed: %v", err)
+		}
+	}
+
+	// The mask is stretched over the image: each of its columns covers four
+	for x, want := range map[int]int{0: 16, 7: 16, 8: 9, 11: 9, 12: 1, 15: 1} {
+		for y := 0; y < 8; y++ {
+			if got := raytracer.film.pixels[y][x].SampleCount; got != want {
+				t.Fatalf("Pixel (%d, %d): expected %d samples, got %d", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestSampleMask_MissingFile(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.SampleMask = filepath.Join(t.TempDir(), "missing.png")
+	if _, err := NewProgressiveRaytracer(s, ProgressiveConfig{TileSize: 8, MaxSamplesPerPixel: 4, MaxPasses: 1}, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{}); err == nil {
+		t.Error("Expected an error for a missing sample mask")
+	}
+}
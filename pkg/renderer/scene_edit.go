@@ -0,0 +1,144 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// sceneEdits holds the edits to the scene of a render in progress until the rendering goroutine
+// applies them between passes
+type sceneEdits struct {
+	mu         sync.Mutex
+	pending    []sceneEdit
+	cancelPass context.CancelFunc // Abandons the pass in progress, nil between passes
+	over       bool               // Whether the render has ended, so edits would never be applied
+}
+
+// sceneEdit is an edit waiting to be applied, and where to send its error
+type sceneEdit struct {
+	edit func(*scene.Scene) error
+	done chan error
+}
+
+// EditScene changes the scene of the render in progress with edit, which calls the scene's
+// editing methods such as Scene.AddShape, and returns edit's error. The pass in progress is
+// abandoned and edit is applied from the rendering goroutine; the render then starts over from
+// its first pass with an empty image, as the samples so far show the old scene. What integrators
+// computed from the old scene, such as the irradiance cache, is computed again for the new one
+// (see integrator.SceneCacher); path guiding keeps what it learned, which only steers sampling.
+// It's safe to call from any goroutine while RenderProgressive runs, and fails once the render
+// is over or ctx is done first.
+func (pr *ProgressiveRaytracer) EditScene(ctx context.Context, edit func(*scene.Scene) error) error {
+	done := make(chan error, 1)
+	e := &pr.edits
+	e.mu.Lock()
+	if e.over {
+		e.mu.Unlock()
+		return fmt.Errorf("the render is over")
+	}
+	e.pending = append(e.pending, sceneEdit{edit: edit, done: done})
+	if e.cancelPass != nil {
+		e.cancelPass()
+	}
+	e.mu.Unlock()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startPass returns the context of a pass, which EditScene cancels to abandon it. A pass started
+// with edits waiting is abandoned at once.
+func (e *sceneEdits) startPass(ctx context.Context) context.Context {
+	passCtx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cancelPass = cancel
+	if len(e.pending) > 0 {
+		cancel()
+	}
+	return passCtx
+}
+
+// endPass releases a pass's context
+func (e *sceneEdits) endPass() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cancelPass()
+	e.cancelPass = nil
+}
+
+// waiting reports whether edits are waiting to be applied
+func (e *sceneEdits) waiting() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.pending) > 0
+}
+
+// take returns the edits waiting to be applied
+func (e *sceneEdits) take() []sceneEdit {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	pending := e.pending
+	e.pending = nil
+	return pending
+}
+
+// end fails the edits still waiting when the render ends, and any made later
+func (e *sceneEdits) end() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.over = true
+	for _, edit := range e.pending {
+		edit.done <- fmt.Errorf("the render is over")
+	}
+	e.pending = nil
+}
+
+// applySceneEdits applies the edits waiting, if any, and starts the image over for the edited
+// scene. It reports whether there were any.
+func (pr *ProgressiveRaytracer) applySceneEdits() bool {
+	pending := pr.edits.take()
+	if len(pending) == 0 {
+		return false
+	}
+	for _, edit := range pending {
+		edit.done <- edit.edit(pr.scene)
+	}
+	pr.logger.Printf("Applied %d scene edits, starting the image over\n", len(pending))
+	pr.resetAccumulation()
+	return true
+}
+
+// resetAccumulation discards everything rendered so far, for a render starting over from its
+// first pass
+func (pr *ProgressiveRaytracer) resetAccumulation() {
+	width, height := pr.film.width, pr.film.height
+	pr.film = newImageFilm(width, height, pr.config)
+	if pr.profile.integrator != nil {
+		pr.profile.film = newImageFilm(width, height, pr.config)
+		pr.profile.image = pr.film
+	}
+	pr.levels = newPyramidLevels(width, height, pr.config.PyramidLevels, pr.config.TileSize)
+	for _, tile := range pr.tiles {
+		tile.PassesCompleted = 0
+	}
+	pr.budgetSamples = 0
+	pr.overlays = nil
+	pr.workerPool.TakeSplats() // Of the abandoned pass
+	pr.workerPool.sceneEdited(pr.profile.integrator)
+
+	pr.progress.start = time.Now()
+	pr.progress.counters = core.ReadCounters()
+	pr.progress.samples = 0
+	pr.progress.rays = RayCounts{}
+	pr.progress.passTimes = nil
+}
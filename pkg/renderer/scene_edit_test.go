@@ -0,0 +1,89 @@
+package renderer
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// editCountingIntegrator counts the scene edits the renderer tells its path tracer about
+type editCountingIntegrator struct {
+	*integrator.PathTracingIntegrator
+	edits int
+}
+
+func (e *editCountingIntegrator) SceneEdited() {
+	e.edits++
+	e.PathTracingIntegrator.SceneEdited()
+}
+
+func TestEditScene(t *testing.T) {
+	s := createTestScene()
+	s.SamplingConfig.Width = 16
+	s.SamplingConfig.Height = 16
+	s.SamplingConfig.IrradianceCache = 0.3
+	config := ProgressiveConfig{TileSize: 8, InitialSamples: 1, MaxSamplesPerPixel: 64, MaxPasses: 4, NumWorkers: 2}
+	pathTracer := &editCountingIntegrator{PathTracingIntegrator: integrator.NewPathTracingIntegrator(s.SamplingConfig)}
+	raytracer, err := NewProgressiveRaytracer(s, config, pathTracer, &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+
+	added := geometry.NewSphere(core.NewVec3(0.5, 0, -2), 0.5, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	passChan, _, errChan := raytracer.RenderProgressive(context.Background(), RenderOptions{})
+	first := <-passChan
+	editErr := make(chan error, 1)
+	go func() {
+		editErr <- raytracer.EditScene(context.Background(), func(s *scene.Scene) error { return s.AddShape(added) })
+	}()
+	// Hold the passes back until the edit is queued or applied, so the render can't end before it
+	for !raytracer.edits.waiting() && len(editErr) == 0 {
+		runtime.Gosched()
+	}
+
+	results := []PassResult{first}
+	for result := range passChan {
+		results = append(results, result)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if err := <-editErr; err != nil {
+		t.Fatalf("EditScene failed: %v", err)
+	}
+	if len(s.Shapes) != 2 {
+		t.Errorf("Expected the added shape in the scene, got %d shapes", len(s.Shapes))
+	}
+	// What the path tracer computed from the old scene, its irradiance cache, is computed again
+	if pathTracer.edits == 0 {
+		t.Error("Expected the path tracer told of the edit")
+	}
+
+	// The render starts over from its first pass, with none of the old samples
+	restart := -1
+	for i := 1; i < len(results); i++ {
+		if results[i].PassNumber <= results[i-1].PassNumber {
+			restart = i
+		}
+	}
+	if restart < 0 {
+		t.Fatalf("Expected the passes to start over after the edit, got %d passes", len(results))
+	}
+	if results[restart].PassNumber != 1 || results[restart].Stats.AverageSamples != first.Stats.AverageSamples {
+		t.Errorf("Expected pass 1 again with %.1f samples/pixel, got pass %d with %.1f",
+			first.Stats.AverageSamples, results[restart].PassNumber, results[restart].Stats.AverageSamples)
+	}
+	if last := results[len(results)-1]; last.PassNumber != config.MaxPasses || !last.IsLast {
+		t.Errorf("Expected the render to go on to its last pass, ended with pass %d", last.PassNumber)
+	}
+
+	if err := raytracer.EditScene(context.Background(), func(*scene.Scene) error { return nil }); err == nil {
+		t.Error("Expected an error editing the scene of a render that's over")
+	}
+}
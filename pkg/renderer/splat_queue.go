@@ -1,79 +1,70 @@
 package renderer
 
 import (
-	"sync"
-	"sync/atomic"
+	"cmp"
+	"slices"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
 )
 
 // SplatXY represents a splat with pre-computed pixel coordinates
 type SplatXY struct {
-	X, Y  int       // Pixel coordinates (computed when enqueuing)
-	Color core.Vec3 // Color contribution
+	X, Y     int                 // Pixel coordinates (computed when enqueuing)
+	Color    core.Vec3           // Color contribution
+	Strategy integrator.Strategy // BDPT strategy that produced the splat, for AOV output
 }
 
-// SplatQueue provides mostly lock-free accumulation of splat contributions for BDPT t=1 strategies
+// SplatQueue collects the splat contributions of BDPT t=1 strategies. It is not safe for concurrent
+// use: each worker owns one and appends to it without contention, and the pool gathers them once
+// the pass's tiles are done (see WorkerPool.TakeSplats). A shared queue would have to serialize the
+// writes, or race with growing its buffer.
 type SplatQueue struct {
-	splats []SplatXY  // Pre-allocated buffer for lock-free appends
-	length int64      // Atomic counter for current length
-	mu     sync.Mutex // Mutex only for growing the buffer when full
+	splats []SplatXY
 }
 
-// NewSplatQueue creates a new splat queue with pre-allocated buffer
+// NewSplatQueue creates an empty splat queue
 func NewSplatQueue() *SplatQueue {
-	return &SplatQueue{
-		splats: make([]SplatXY, 50000), // Start with reasonable buffer size
-		length: 0,
-	}
+	return &SplatQueue{splats: make([]SplatXY, 0, 1024)}
 }
 
-// AddSplat adds a splat contribution to the queue with lock-free fast path
+// AddSplat adds a splat contribution to the queue
 func (sq *SplatQueue) AddSplat(x, y int, color core.Vec3) {
-	// Fast path: try to append lock-free
-	index := atomic.AddInt64(&sq.length, 1) - 1
-
-	if int(index) < len(sq.splats) {
-		// Fast path: write directly to pre-allocated buffer
-		sq.splats[index] = SplatXY{X: x, Y: y, Color: color}
-	} else {
-		// Slow path: buffer is full, need to grow
-		sq.mu.Lock()
-		defer sq.mu.Unlock()
-
-		// Double-check after acquiring lock (another thread might have grown it)
-		if int(index) >= len(sq.splats) {
-			// Grow buffer by 2x
-			newSize := len(sq.splats) * 2
-			newSplats := make([]SplatXY, newSize)
-			copy(newSplats, sq.splats)
-			sq.splats = newSplats
-		}
+	sq.AddStrategySplat(x, y, color, integrator.Strategy{})
+}
 
-		// Now write to the buffer
-		sq.splats[index] = SplatXY{X: x, Y: y, Color: color}
-	}
+// AddStrategySplat adds a splat contribution tagged with the BDPT strategy that produced it
+func (sq *SplatQueue) AddStrategySplat(x, y int, color core.Vec3, strategy integrator.Strategy) {
+	sq.splats = append(sq.splats, SplatXY{X: x, Y: y, Color: color, Strategy: strategy})
 }
 
 // GetAllSplats returns a copy of all pending splats without removing them
 func (sq *SplatQueue) GetAllSplats() []SplatXY {
-	// Need to lock to ensure consistent view of length and slice
-	sq.mu.Lock()
-	defer sq.mu.Unlock()
-
-	currentLength := atomic.LoadInt64(&sq.length)
-	result := make([]SplatXY, currentLength)
-	copy(result, sq.splats[:currentLength])
-	return result
+	return append([]SplatXY(nil), sq.splats...)
 }
 
 // GetSplatCount returns the current number of pending splats (for debugging/monitoring)
 func (sq *SplatQueue) GetSplatCount() int {
-	return int(atomic.LoadInt64(&sq.length))
+	return len(sq.splats)
 }
 
-// Clear removes all pending splats (useful for cleanup between frames)
+// Clear removes all pending splats, keeping the buffer for the next pass
 func (sq *SplatQueue) Clear() {
-	atomic.StoreInt64(&sq.length, 0) // Reset length atomically
-	// Note: We don't need to zero out the array since length controls access
+	sq.splats = sq.splats[:0]
+}
+
+// sortSplats orders splats by pixel and then by color, giving a canonical order for accumulation
+// Splats with equal keys are identical, so their relative order doesn't affect the result
+func sortSplats(splats []SplatXY) {
+	slices.SortFunc(splats, func(a, b SplatXY) int {
+		return cmp.Or(
+			cmp.Compare(a.Y, b.Y),
+			cmp.Compare(a.X, b.X),
+			cmp.Compare(a.Color.X, b.Color.X),
+			cmp.Compare(a.Color.Y, b.Color.Y),
+			cmp.Compare(a.Color.Z, b.Color.Z),
+			cmp.Compare(a.Strategy.S, b.Strategy.S),
+			cmp.Compare(a.Strategy.T, b.Strategy.T),
+		)
+	})
 }
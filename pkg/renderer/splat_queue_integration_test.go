@@ -1,8 +1,8 @@
 package renderer
 
 import (
+	"context"
 	"image"
-	"math/rand"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -78,10 +78,10 @@ func TestTileRendererWithSplats(t *testing.T) {
 	splatQueue := NewSplatQueue()
 
 	// Create random generator
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	seed := uint64(42)
 
 	// Render the tile
-	stats := tileRenderer.RenderTileBounds(bounds, pixelStats, splatQueue, sampler, 2)
+	stats := tileRenderer.RenderTileBounds(bounds, pixelStats, splatQueue, seed, 2)
 
 	// Verify render stats
 	if stats.TotalPixels != width*height {
@@ -212,7 +212,7 @@ func TestSplatSystemIntegration(t *testing.T) {
 	}
 
 	// Render one pass
-	img, stats, err := raytracer.RenderPass(1, nil)
+	img, stats, err := raytracer.RenderPass(context.Background(), 1, nil)
 
 	if err != nil {
 		t.Fatalf("Render failed: %v", err)
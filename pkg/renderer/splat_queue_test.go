@@ -82,28 +82,20 @@ func TestSplatQueueClear(t *testing.T) {
 	}
 }
 
-func TestSplatQueueConcurrency(t *testing.T) {
-	queue := NewSplatQueue()
-
-	// Test concurrent adds
-	done := make(chan bool, 10)
-
-	for i := 0; i < 10; i++ {
-		go func(id int) {
-			for j := 0; j < 10; j++ {
-				queue.AddSplat(id*10+j, id*10+j, core.Vec3{X: float64(id), Y: float64(j), Z: 0.5})
-			}
-			done <- true
-		}(i)
+func TestWorkerPool_TakeSplats(t *testing.T) {
+	s := createTestScene()
+	pool := NewWorkerPool(s, &MockIntegrator{}, 8, 8, 4, 3)
+
+	// Each worker splats into its own queue; the pool gathers them all
+	for i, worker := range pool.workers {
+		for j := 0; j < 10; j++ {
+			worker.splats.AddSplat(i, j, core.Vec3{X: float64(i), Y: float64(j), Z: 0.5})
+		}
 	}
-
-	// Wait for all goroutines
-	for i := 0; i < 10; i++ {
-		<-done
+	if splats := pool.TakeSplats(); len(splats) != 30 {
+		t.Errorf("Expected 30 splats from 3 workers, got %d", len(splats))
 	}
-
-	// Should have 100 splats total
-	if count := queue.GetSplatCount(); count != 100 {
-		t.Errorf("Expected 100 splats from concurrent adds, got %d", count)
+	if splats := pool.TakeSplats(); len(splats) != 0 {
+		t.Errorf("Expected the workers' queues emptied, got %d splats", len(splats))
 	}
 }
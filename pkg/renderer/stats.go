@@ -2,56 +2,103 @@ package renderer
 
 import (
 	"image"
+	"math"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
 // RenderStats contains statistics about the rendering process
 type RenderStats struct {
-	TotalPixels    int     // Total number of pixels rendered
-	TotalSamples   int     // Total number of samples taken
-	AverageSamples float64 // Average samples per pixel
-	MaxSamples     int     // Maximum samples allowed per pixel
-	MinSamples     int     // Minimum samples taken per pixel
-	MaxSamplesUsed int     // Maximum samples actually used by any pixel
+	TotalPixels    int            `json:"totalPixels"`    // Total number of pixels rendered
+	TotalSamples   int            `json:"totalSamples"`   // Total number of samples taken
+	AverageSamples float64        `json:"averageSamples"` // Average samples per pixel
+	MaxSamples     int            `json:"maxSamples"`     // Maximum samples allowed per pixel
+	MinSamples     int            `json:"minSamples"`     // Minimum samples taken per pixel
+	MaxSamplesUsed int            `json:"maxSamplesUsed"` // Maximum samples actually used by any pixel
+	EstimatedError float64        `json:"estimatedError"` // Average of the pixels' relative errors (see PixelStats.RelativeError)
+	Rays           RayCounts      `json:"rays"`           // Rays traced: by a tile in its pass, or by a render so far
+	Counters       *core.Counters `json:"counters"`       // BVH traversal and path statistics of the render so far, nil unless built with -tags rtstats
 }
 
-// PixelStats tracks sampling statistics for a single pixel
-type PixelStats struct {
-	ColorAccum       core.Vec3 // RGB accumulator for final result
-	LuminanceAccum   float64   // Luminance accumulator for convergence
-	LuminanceSqAccum float64   // Luminance squared for variance
-	SampleCount      int       // Number of samples taken
+// RayCounts counts the rays traced by kind
+type RayCounts struct {
+	Primary int64 `json:"primary"` // Camera rays, including any traced ahead in a batch but not used
+	Scatter int64 `json:"scatter"` // Other closest hit rays: bounces, and BDPT light subpaths
+	Shadow  int64 `json:"shadow"`  // Visibility tests
 }
 
-// AddSplat adds light from bidirectional path connections without affecting sampling statistics
-// Splats represent deterministic light contributions discovered through BDPT connections,
-// not stochastic samples from the primary sampling distribution. Including them in luminance
-// statistics would corrupt variance calculations since:
-// 1. Splats arrive after samples are processed, making attribution timing unclear
-// 2. Multiple splats would be incorrectly attributed to the "last sample"
-// 3. Race conditions make sample-to-splat mapping unreliable
-// This may cause adaptive sampling to think pixels have "converged" prematurely when
-// splats contribute significant light, but this is preferable to corrupted variance stats.
-func (ps *PixelStats) AddSplat(color core.Vec3) {
-	ps.ColorAccum = ps.ColorAccum.Add(color)
+// Total returns the number of rays of every kind
+func (r RayCounts) Total() int64 {
+	return r.Primary + r.Scatter + r.Shadow
+}
+
+// Add returns the sum of two counts
+func (r RayCounts) Add(other RayCounts) RayCounts {
+	return RayCounts{Primary: r.Primary + other.Primary, Scatter: r.Scatter + other.Scatter, Shadow: r.Shadow + other.Shadow}
+}
+
+// Sub returns the counts added since an earlier count
+func (r RayCounts) Sub(earlier RayCounts) RayCounts {
+	return RayCounts{Primary: r.Primary - earlier.Primary, Scatter: r.Scatter - earlier.Scatter, Shadow: r.Shadow - earlier.Shadow}
+}
+
+// PixelStats tracks sampling statistics for a single pixel
+type PixelStats struct {
+	ColorAccum       core.Vec3   // Filter-weighted RGB accumulator for final result
+	WeightSum        float64     // Sum of the samples' filter weights, the color's normalization
+	LuminanceAccum   float64     // Luminance accumulator for convergence
+	LuminanceSqAccum float64     // Luminance squared for variance
+	SampleCount      int         // Number of samples taken
+	AOV              *AOVStats   // Optional AOV accumulators, nil unless AOVs are enabled
+	Debug            *DebugStats // Optional debug heatmap accumulators, nil unless debug AOVs are enabled
+	Deep             *DeepStats  // Optional depth bins, nil unless deep output is enabled
+	IDs              *IDStats    // Optional object and material coverage, nil unless ID output is enabled
+	Alpha            *AlphaStats // Optional coverage, nil unless the background is transparent
 }
 
 // AddSample adds a new color sample to the pixel statistics
 func (ps *PixelStats) AddSample(color core.Vec3) {
+	ps.AddWeightedSample(color, 1)
+}
+
+// AddWeightedSample adds a color sample weighted by the reconstruction filter at its position
+// Luminance statistics track the weighted color, which averages to the pixel's value.
+func (ps *PixelStats) AddWeightedSample(color core.Vec3, weight float64) {
+	if weight != 1 {
+		color = color.Multiply(weight)
+	}
 	ps.ColorAccum = ps.ColorAccum.Add(color)
+	ps.WeightSum += weight
 	luminance := color.Luminance()
 	ps.LuminanceAccum += luminance
 	ps.LuminanceSqAccum += luminance * luminance
 	ps.SampleCount++
 }
 
-// GetColor returns the current average color for this pixel
+// perceptualErrorFloor is the luminance that darker pixels' errors are measured relative to, as
+// noise in near-black pixels isn't visible however large it is compared to their luminance
+const perceptualErrorFloor = 0.01
+
+// RelativeError estimates the relative standard error of the pixel's mean luminance: how far the
+// estimate is likely to be from the converged value, as a fraction of it. Pixels with fewer than
+// two samples can't estimate their variance and count as entirely uncertain (1).
+func (ps *PixelStats) RelativeError() float64 {
+	if ps.SampleCount < 2 {
+		return 1
+	}
+	n := float64(ps.SampleCount)
+	mean := ps.LuminanceAccum / n
+	variance := math.Max(0, ps.LuminanceSqAccum/n-mean*mean) * n / (n - 1) // Unbiased sample variance
+	return math.Sqrt(variance/n) / math.Max(mean, perceptualErrorFloor)
+}
+
+// GetColor returns the current weighted average color of the pixel's samples (splats are the
+// film's, see Film.color)
 func (ps *PixelStats) GetColor() core.Vec3 {
-	if ps.SampleCount == 0 {
+	if ps.WeightSum == 0 {
 		return core.Vec3{X: 0, Y: 0, Z: 0}
 	}
-	return ps.ColorAccum.Multiply(1.0 / float64(ps.SampleCount))
+	return ps.ColorAccum.Multiply(1.0 / ps.WeightSum)
 }
 
 // CalculateAverageLuminance calculates the average luminance of an image
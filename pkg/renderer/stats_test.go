@@ -3,7 +3,10 @@ package renderer
 import (
 	"image"
 	"image/color"
+	"math"
 	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
 func TestCalculateAverageLuminance(t *testing.T) {
@@ -43,3 +46,29 @@ func TestCalculateAverageLuminance_White(t *testing.T) {
 		t.Errorf("Expected average luminosity %f, got %f", expected, avgLum)
 	}
 }
+
+func TestPixelStats_RelativeError(t *testing.T) {
+	gray := func(l float64) core.Vec3 { return core.NewVec3(l, l, l) }
+	tests := []struct {
+		name    string
+		samples []float64
+		want    float64
+	}{
+		{"no samples", nil, 1},
+		{"one sample", []float64{0.5}, 1},
+		{"constant", []float64{0.5, 0.5, 0.5}, 0},
+		// Mean 2, sample variance 2, so the mean's standard error is sqrt(2/2) = 1
+		{"noisy", []float64{1, 3}, 0.5},
+		// Near-black pixels are measured against the perceptual floor instead of their mean
+		{"dark", []float64{0, 0.002}, 0.001 / perceptualErrorFloor},
+	}
+	for _, tt := range tests {
+		var ps PixelStats
+		for _, l := range tt.samples {
+			ps.AddSample(gray(l))
+		}
+		if got := ps.RelativeError(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
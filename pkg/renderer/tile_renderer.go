@@ -14,6 +14,16 @@ import (
 type TileRenderer struct {
 	scene      *scene.Scene
 	integrator integrator.Integrator
+	primary    *primaryHits // Batched camera ray hits, created when the first tile is rendered
+	batchRays  []core.Ray   // Buffer for a batch of camera rays
+	weights    []float64    // Reconstruction filter weights of the batch's samples
+
+	inspect *sampleInspect  // Sample to record in full, nil for none (see ProgressiveRaytracer.TraceSample)
+	done    <-chan struct{} // Closed to abandon the tile being rendered between pixels, nil for never
+	mask    *sampleMask     // Scales each pixel's target samples, nil for none
+	filter  *pixelFilter    // Reconstruction filter of full resolution pixels, nil for box
+
+	catcherTracer *integrator.PathTracingIntegrator // Gathers what objects reflect onto shadow catchers, created when first needed
 }
 
 // NewTileRenderer creates a new tile renderer with the given scene and integrator
@@ -25,17 +35,52 @@ func NewTileRenderer(scene *scene.Scene, integratorInst integrator.Integrator) *
 }
 
 // RenderTileBounds renders pixels within the specified bounds using the integrator
-func (tr *TileRenderer) RenderTileBounds(bounds image.Rectangle, pixelStats [][]PixelStats, splatQueue *SplatQueue, sampler core.Sampler, targetSamples int) RenderStats {
+// Every pixel derives its own random sequence from seed, its coordinates and its current sample
+// count, so results don't depend on tile size, worker count or scheduling order.
+func (tr *TileRenderer) RenderTileBounds(bounds image.Rectangle, pixelStats [][]PixelStats, splatQueue *SplatQueue, seed uint64, targetSamples int) RenderStats {
+	return tr.RenderLevelTileBounds(bounds, pixelStats, nil, 1, splatQueue, seed, targetSamples)
+}
+
+// RenderLevelTileBounds renders pixels of one level of a resolution pyramid, where each pixel covers
+// a scale x scale block of image pixels (scale 1 is the image itself). If prior is not nil it holds
+// the next coarser level, at half the resolution, whose estimates inform the adaptive sampling.
+func (tr *TileRenderer) RenderLevelTileBounds(bounds image.Rectangle, pixelStats, prior [][]PixelStats, scale int, splatQueue *SplatQueue, seed uint64, targetSamples int) RenderStats {
 	camera := tr.scene.Camera
 	samplingConfig := tr.scene.SamplingConfig
+	if tr.primary == nil || tr.primary.Intersector != tr.scene.Intersector {
+		tr.primary = newPrimaryHits(tr.scene) // The scene is preprocessed by now
+		tr.batchRays = make([]core.Ray, 0, primaryBatchSize)
+		tr.weights = make([]float64, 0, primaryBatchSize)
+		tr.catcherTracer = nil // An edited scene may sample differently
+	}
+
+	// Levels get their own random sequences, independent of the image's
+	if scale > 1 {
+		seed = core.MixBits(seed ^ pyramidSeedSalt ^ uint64(scale))
+	}
 
 	// Initialize statistics tracking for this specific bounds
 	stats := tr.initRenderStatsForBounds(bounds, targetSamples)
+	tracedBefore := tr.primary.traced
 
 	// Regular tile processing with splat generation
+rows:
 	for j := bounds.Min.Y; j < bounds.Max.Y; j++ {
 		for i := bounds.Min.X; i < bounds.Max.X; i++ {
-			samplesUsed := tr.adaptiveSamplePixelWithSplats(camera, i, j, &pixelStats[j][i], splatQueue, sampler, targetSamples, samplingConfig)
+			select {
+			case <-tr.done:
+				break rows
+			default:
+			}
+			var parent *PixelStats
+			if prior != nil {
+				parent = &prior[j/2][i/2]
+			}
+			maxSamples := targetSamples
+			if tr.mask != nil {
+				maxSamples = tr.mask.maxSamples(i, j, scale, targetSamples)
+			}
+			samplesUsed := tr.adaptiveSamplePixelWithSplats(camera, i, j, scale, &pixelStats[j][i], parent, splatQueue, seed, maxSamples, samplingConfig)
 			tr.updateStats(&stats, samplesUsed)
 		}
 	}
@@ -44,37 +89,166 @@ func (tr *TileRenderer) RenderTileBounds(bounds image.Rectangle, pixelStats [][]
 
 	// Finalize statistics
 	tr.finalizeStats(&stats)
+	stats.Rays = tr.primary.traced.Sub(tracedBefore)
 	return stats
 }
 
 // adaptiveSamplePixelWithSplats uses adaptive sampling with the integrator and handles splat contributions
-func (tr *TileRenderer) adaptiveSamplePixelWithSplats(camera *geometry.Camera, i, j int, ps *PixelStats, splatQueue *SplatQueue, sampler core.Sampler, maxSamples int, samplingConfig scene.SamplingConfig) int {
+// Pixel (i, j) covers the scale x scale block of image pixels starting at (i*scale, j*scale).
+func (tr *TileRenderer) adaptiveSamplePixelWithSplats(camera *geometry.Camera, i, j, scale int, ps, prior *PixelStats, splatQueue *SplatQueue, seed uint64, maxSamples int, samplingConfig scene.SamplingConfig) int {
 	initialSampleCount := ps.SampleCount
 
+	// Seed this pixel's pass from its coordinates and starting sample count
+	passSeed := pixelPassSeed(seed, i, j, initialSampleCount)
+	sampler := core.NewSeededSampler(passSeed)
+
+	// Camera samples for this pass come from correlated multi-jittered patterns sized to the
+	// pass, so each pass is stratified on its own and passes accumulate stratified sets.
+	passSamples := maxSamples - initialSampleCount
+	pixelPattern := uint32(passSeed)
+	lensPattern := uint32(passSeed >> 32)
+
+	var aovSampler core.Sampler
+	if ps.AOV != nil {
+		aovSampler = core.NewSeededSampler(passSeed ^ aovSeedSalt)
+	}
+
+	// Debug heatmaps measure the rays and intersection time of this pixel's samples
+	tr.primary.timed = ps.Debug != nil
+	scatterBefore, timeBefore := tr.primary.traced.Scatter, tr.primary.intersectTime
+
+	// Pyramid levels preview the image unfiltered
+	filter := tr.filter
+	if scale > 1 {
+		filter = nil
+	}
+
+	// The convergence test needs more than one sample's worth of evidence, so the coarsest level of
+	// a pyramid (which has no prior) takes all its samples
+	adaptive := scale == 1 || prior != nil
+
 	// Take samples until we reach convergence or max samples
-	for ps.SampleCount < maxSamples && !tr.shouldStopSampling(ps, maxSamples, samplingConfig) {
-		ray := camera.GetRay(i, j, sampler.Get2D(), sampler.Get2D())
+	batch := tr.batchRays[:0]
+	batchIndex := 0
+	for ps.SampleCount < maxSamples && !(adaptive && tr.shouldStopSampling(ps, prior, maxSamples, samplingConfig)) {
+		passIndex := ps.SampleCount - initialSampleCount
+
+		// Trace the next few camera rays together; if sampling converges first, the extra hits go unused
+		if batchIndex == len(batch) {
+			batch = batch[:0]
+			tr.weights = tr.weights[:0]
+			for k := passIndex; k < passSamples && len(batch) < primaryBatchSize; k++ {
+				lensSample := core.CMJSample(k, passSamples, lensPattern)
+				pixelSample := core.CMJSample(k, passSamples, pixelPattern)
+				x, y, weight := i, j, 1.0
+				if scale > 1 {
+					x, y, pixelSample = tr.blockSample(i, j, scale, pixelSample)
+				} else if filter != nil {
+					pixelSample, weight = filter.sample(pixelSample)
+				}
+				batch = append(batch, camera.GetRay(x, y, lensSample, pixelSample))
+				tr.weights = append(tr.weights, weight)
+			}
+			tr.primary.trace(batch)
+			batchIndex = 0
+		}
+		ray, weight := batch[batchIndex], tr.weights[batchIndex]
+		tr.primary.use(batchIndex)
+		batchIndex++
 
 		// Use enhanced integrator with splat support
-		pixelColor, splatRays := tr.integrator.RayColor(ray, tr.scene, sampler)
+		var pixelColor core.Vec3
+		var splatRays []integrator.SplatRay
+		if tr.inspect != nil && tr.inspect.sample == ps.SampleCount {
+			pixelColor = tr.inspectSample(ray, sampler)
+		} else {
+			pixelColor, splatRays = tr.rayColor(ray, ps.AOV, sampler, aovSampler)
+		}
+		if ps.Alpha != nil {
+			var coverage float64
+			pixelColor, coverage = tr.transparentSample(ray, pixelColor, sampler)
+			ps.Alpha.AddSample(coverage, weight)
+			splatRays = tr.catcherSplats(splatRays)
+		}
 
 		// Add regular contribution
-		ps.AddSample(pixelColor)
+		ps.AddWeightedSample(pixelColor, weight)
+		if ps.Deep != nil {
+			tr.recordDeepSample(ray, pixelColor, weight, ps.Deep)
+		}
+		if ps.IDs != nil {
+			tr.recordIDSample(ray, weight, ps.IDs)
+		}
 
 		// Process splat contributions
 		for _, splatRay := range splatRays {
+			if filter != nil {
+				if position, ok := camera.MapRayToFilm(splatRay.Ray); ok {
+					filter.splat(splatQueue, position, splatRay.Color, splatRay.Strategy, samplingConfig.Width, samplingConfig.Height)
+				}
+				continue
+			}
 			// Map ray to pixel coordinates and add to queue
 			if x, y, ok := camera.MapRayToPixel(splatRay.Ray); ok {
-				splatQueue.AddSplat(x, y, splatRay.Color)
+				splatQueue.AddStrategySplat(x, y, splatRay.Color, splatRay.Strategy)
 			}
 		}
 	}
 
-	return ps.SampleCount - initialSampleCount
+	samplesUsed := ps.SampleCount - initialSampleCount
+	if ps.Debug != nil {
+		ps.Debug.Rays += int64(samplesUsed) + tr.primary.traced.Scatter - scatterBefore // One camera ray per sample
+		ps.Debug.IntersectTime += tr.primary.intersectTime - timeBefore
+	}
+	return samplesUsed
+}
+
+// blockSample spreads a pixel sample over the image pixels covered by pyramid level pixel (i, j),
+// returning the image pixel it falls in and the position within that pixel
+func (tr *TileRenderer) blockSample(i, j, scale int, sample core.Vec2) (int, int, core.Vec2) {
+	// Blocks on the right and bottom edges are cut off by the image
+	blockWidth := min(scale, tr.scene.SamplingConfig.Width-i*scale)
+	blockHeight := min(scale, tr.scene.SamplingConfig.Height-j*scale)
+
+	u := sample.X * float64(blockWidth)
+	v := sample.Y * float64(blockHeight)
+	x, y := min(int(u), blockWidth-1), min(int(v), blockHeight-1)
+	return i*scale + x, j*scale + y, core.NewVec2(u-float64(x), v-float64(y))
+}
+
+// rayColor evaluates one camera ray, also recording AOVs when the pixel accumulates them
+func (tr *TileRenderer) rayColor(ray core.Ray, as *AOVStats, sampler, aovSampler core.Sampler) (core.Vec3, []integrator.SplatRay) {
+	// The integrator gets the scene whose intersector answers camera rays from the batch
+	batchScene := &tr.primary.scene
+	if as == nil {
+		return tr.integrator.RayColor(ray, batchScene, sampler)
+	}
+
+	tr.recordSurfaceAOVs(ray, as, aovSampler)
+
+	aovIntegrator, ok := tr.integrator.(integrator.AOVIntegrator)
+	if !ok {
+		return tr.integrator.RayColor(ray, batchScene, sampler)
+	}
+
+	var sample integrator.AOVSample
+	pixelColor, splatRays := aovIntegrator.RayColorAOV(ray, batchScene, sampler, &sample)
+	as.AddSample(&sample)
+	return pixelColor, splatRays
+}
+
+// pixelPassSeed hashes the render seed, pixel coordinates and the pass's starting sample count
+// into an independent seed for that pixel's samples in the pass
+func pixelPassSeed(seed uint64, i, j, passOffset int) uint64 {
+	h := core.MixBits(seed ^ 0x6a09e667f3bcc909)
+	h = core.MixBits(h ^ uint64(uint32(i)) ^ uint64(uint32(j))<<32)
+	return core.MixBits(h ^ uint64(passOffset))
 }
 
 // shouldStopSampling determines if adaptive sampling should stop based on perceptual relative error
-func (tr *TileRenderer) shouldStopSampling(ps *PixelStats, maxSamples int, samplingConfig scene.SamplingConfig) bool {
+// A prior (the pixel's parent in a resolution pyramid, or nil) adds its luminance statistics to the
+// pixel's own, counting as at most pyramidPriorWeight samples.
+func (tr *TileRenderer) shouldStopSampling(ps, prior *PixelStats, maxSamples int, samplingConfig scene.SamplingConfig) bool {
 	// Calculate minimum samples as percentage of max samples, but ensure at least 1 sample
 	minSamples := max(1, int(float64(maxSamples)*samplingConfig.AdaptiveMinSamples))
 
@@ -84,8 +258,17 @@ func (tr *TileRenderer) shouldStopSampling(ps *PixelStats, maxSamples int, sampl
 	}
 
 	// Calculate variance from accumulated statistics
-	mean := ps.LuminanceAccum / float64(ps.SampleCount)
-	meanSq := ps.LuminanceSqAccum / float64(ps.SampleCount)
+	count := float64(ps.SampleCount)
+	luminanceAccum := ps.LuminanceAccum
+	luminanceSqAccum := ps.LuminanceSqAccum
+	if prior != nil && prior.SampleCount > 0 {
+		weight := math.Min(float64(prior.SampleCount), pyramidPriorWeight)
+		luminanceAccum += prior.LuminanceAccum * weight / float64(prior.SampleCount)
+		luminanceSqAccum += prior.LuminanceSqAccum * weight / float64(prior.SampleCount)
+		count += weight
+	}
+	mean := luminanceAccum / count
+	meanSq := luminanceSqAccum / count
 	variance := math.Max(0, meanSq-mean*mean)
 
 	// Avoid division by zero for black pixels
@@ -3,7 +3,6 @@ package renderer
 import (
 	"image"
 	"math"
-	"math/rand"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -93,12 +92,12 @@ func TestTileRendererPixelSampling(t *testing.T) {
 		pixelStats[i] = make([]PixelStats, 2)
 	}
 
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	seed := uint64(42)
 	targetSamples := 4
 
 	// Render the tile
 	queue := NewSplatQueue()
-	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, sampler, targetSamples)
+	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, seed, targetSamples)
 
 	// Check that integrator was called
 	if mockIntegrator.callCount == 0 {
@@ -130,6 +129,38 @@ func TestTileRendererPixelSampling(t *testing.T) {
 	}
 }
 
+// cancellingIntegrator closes done the first time it's asked for a color
+type cancellingIntegrator struct {
+	MockIntegrator
+	done chan struct{}
+}
+
+func (c *cancellingIntegrator) RayColor(ray core.Ray, scene *scene.Scene, sampler core.Sampler) (core.Vec3, []integrator.SplatRay) {
+	if c.callCount == 0 {
+		close(c.done)
+	}
+	return c.MockIntegrator.RayColor(ray, scene, sampler)
+}
+
+func TestTileRendererAbandonsTile(t *testing.T) {
+	cancelling := &cancellingIntegrator{MockIntegrator: MockIntegrator{returnColor: core.NewVec3(0.5, 0.5, 0.5)}, done: make(chan struct{})}
+	renderer := NewTileRenderer(createTestScene(), cancelling)
+	renderer.done = cancelling.done
+
+	pixelStats := [][]PixelStats{make([]PixelStats, 2), make([]PixelStats, 2)}
+	renderer.RenderTileBounds(image.Rect(0, 0, 2, 2), pixelStats, NewSplatQueue(), 42, 4)
+
+	// The pixel being sampled is finished; the rest are left alone
+	if pixelStats[0][0].SampleCount == 0 {
+		t.Error("Expected the first pixel sampled")
+	}
+	for _, ps := range []PixelStats{pixelStats[0][1], pixelStats[1][0], pixelStats[1][1]} {
+		if ps.SampleCount != 0 {
+			t.Errorf("Expected the pixels after the first abandoned, got %d samples", ps.SampleCount)
+		}
+	}
+}
+
 // TestTileRendererAdaptiveSampling tests adaptive sampling behavior
 func TestTileRendererAdaptiveSampling(t *testing.T) {
 	scene := createTestScene()
@@ -147,11 +178,11 @@ func TestTileRendererAdaptiveSampling(t *testing.T) {
 	pixelStats := make([][]PixelStats, 1)
 	pixelStats[0] = make([]PixelStats, 1)
 
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	seed := uint64(42)
 	targetSamples := 100 // High target
 
 	queue := NewSplatQueue()
-	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, sampler, targetSamples)
+	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, seed, targetSamples)
 
 	// With consistent color, adaptive sampling should stop early
 	actualSamples := pixelStats[0][0].SampleCount
@@ -184,11 +215,11 @@ func TestTileRendererStatistics(t *testing.T) {
 		pixelStats[i] = make([]PixelStats, 3)
 	}
 
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	seed := uint64(42)
 	targetSamples := 5
 
 	queue := NewSplatQueue()
-	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, sampler, targetSamples)
+	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, seed, targetSamples)
 
 	// Check basic statistics
 	expectedPixels := 6
@@ -235,18 +266,18 @@ func TestTileRendererDeterministic(t *testing.T) {
 	for i := range pixelStats1 {
 		pixelStats1[i] = make([]PixelStats, 2)
 	}
-	sampler1 := core.NewRandomSampler(rand.New(rand.NewSource(123)))
+	seed1 := uint64(123)
 	queue1 := NewSplatQueue()
-	stats1 := renderer.RenderTileBounds(bounds, pixelStats1, queue1, sampler1, targetSamples)
+	stats1 := renderer.RenderTileBounds(bounds, pixelStats1, queue1, seed1, targetSamples)
 
 	// Second render with same seed
 	pixelStats2 := make([][]PixelStats, 2)
 	for i := range pixelStats2 {
 		pixelStats2[i] = make([]PixelStats, 2)
 	}
-	sampler2 := core.NewRandomSampler(rand.New(rand.NewSource(123)))
+	seed2 := uint64(123)
 	queue2 := NewSplatQueue()
-	stats2 := renderer.RenderTileBounds(bounds, pixelStats2, queue2, sampler2, targetSamples)
+	stats2 := renderer.RenderTileBounds(bounds, pixelStats2, queue2, seed2, targetSamples)
 
 	// Results should be identical
 	if stats1.TotalSamples != stats2.TotalSamples {
@@ -279,9 +310,9 @@ func TestTileRendererBoundsClipping(t *testing.T) {
 
 	// Only render a 2x2 subset
 	bounds := image.Rect(1, 1, 3, 3)
-	sampler := core.NewRandomSampler(rand.New(rand.NewSource(42)))
+	seed := uint64(42)
 	queue := NewSplatQueue()
-	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, sampler, 2)
+	stats := renderer.RenderTileBounds(bounds, pixelStats, queue, seed, 2)
 
 	// Should only have processed 4 pixels
 	if stats.TotalPixels != 4 {
@@ -303,3 +334,56 @@ func TestTileRendererBoundsClipping(t *testing.T) {
 		}
 	}
 }
+
+// BenchmarkTileRenderer times rendering a 16x16 tile in the middle of standard scenes to 4
+// samples per pixel from a fixed seed, end to end: camera rays, integrator, pixel statistics.
+// It also reports the time per sample, comparable across scenes and integrators.
+func BenchmarkTileRenderer(b *testing.B) {
+	scenes := []struct {
+		name  string
+		scene func() *scene.Scene
+	}{
+		{"cornell", func() *scene.Scene { return scene.NewCornellScene(scene.CornellBoxes, scene.CornellQuadLight) }},
+		{"default", func() *scene.Scene { return scene.NewDefaultScene() }},
+	}
+	integrators := []struct {
+		name       string
+		integrator func(scene.SamplingConfig) integrator.Integrator
+	}{
+		{"path-tracing", func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewPathTracingIntegrator(c) }},
+		{"bdpt", func(c scene.SamplingConfig) integrator.Integrator { return integrator.NewBDPTIntegrator(c) }},
+	}
+	const tileSize, samples = 16, 4
+
+	for _, sc := range scenes {
+		s := sc.scene()
+		s.SamplingConfig.Width = s.CameraConfig.Width
+		s.SamplingConfig.Height = int(float64(s.CameraConfig.Width) / s.CameraConfig.AspectRatio)
+		if err := s.Preprocess(); err != nil {
+			b.Fatalf("Preprocess failed: %v", err)
+		}
+		x0, y0 := (s.SamplingConfig.Width-tileSize)/2, (s.SamplingConfig.Height-tileSize)/2
+		bounds := image.Rect(x0, y0, x0+tileSize, y0+tileSize)
+
+		for _, ic := range integrators {
+			b.Run(sc.name+"/"+ic.name, func(b *testing.B) {
+				renderer := NewTileRenderer(s, ic.integrator(s.SamplingConfig))
+				queue := NewSplatQueue()
+				var sampled int
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					pixelStats := make([][]PixelStats, s.SamplingConfig.Height)
+					for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+						pixelStats[y] = make([]PixelStats, s.SamplingConfig.Width)
+					}
+					queue.Clear()
+					b.StartTimer()
+
+					stats := renderer.RenderTileBounds(bounds, pixelStats, queue, 1, samples)
+					sampled += stats.TotalSamples
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(max(1, sampled)), "ns/sample")
+			})
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package renderer
+
+import (
+	"cmp"
+	"image"
+	"slices"
+	"time"
+)
+
+// partsPerWorker is how many tasks' worth of a pass's measured cost each worker should get. The
+// queue is shared, so the workers balance themselves; what's left idle at the end of a pass is
+// the longest task still running, which smaller tasks shorten.
+const partsPerWorker = 4
+
+// tilePart is a band of a tile's rows, rendered as one task
+type tilePart struct {
+	tile   *Tile
+	bounds image.Rectangle
+	cost   time.Duration // Expected render time, from the tile's last pass
+}
+
+// scheduleTiles splits a pass's tiles into the tasks to submit, at most maxParts of them.
+//
+// Until every tile's cost has been measured (the first pass), each tile is one task in grid
+// order. After that, tiles costing more than their share of the pass (its measured total over
+// partsPerWorker tasks per worker) are split into bands of rows of about that share, and tasks
+// are submitted most expensive first, so a dense tile doesn't start last and hold up the pass
+// while the other workers wait. Pixels render the same whichever task renders them, so the
+// schedule doesn't change the image.
+func scheduleTiles(tiles []*Tile, numWorkers, maxParts int) []tilePart {
+	var total time.Duration
+	measured := true
+	whole := make([]tilePart, len(tiles))
+	for i, tile := range tiles {
+		whole[i] = tilePart{tile: tile, bounds: tile.Bounds, cost: tile.cost}
+		total += tile.cost
+		measured = measured && tile.cost > 0
+	}
+	if !measured {
+		return whole
+	}
+
+	share := total / time.Duration(max(1, numWorkers*partsPerWorker))
+	parts := make([]tilePart, 0, len(tiles))
+	for _, tile := range tiles {
+		bands := min(int((tile.cost+share-1)/max(1, share)), tile.Bounds.Dy())
+		parts = appendBands(parts, tile, max(1, bands))
+	}
+	if len(parts) > maxParts {
+		parts = whole
+	}
+
+	// Stable, so tiles of equal cost keep their grid order
+	slices.SortStableFunc(parts, func(a, b tilePart) int {
+		return cmp.Compare(b.cost, a.cost)
+	})
+	return parts
+}
+
+// appendBands appends a tile split into bands of (as near as possible) equal rows
+func appendBands(parts []tilePart, tile *Tile, bands int) []tilePart {
+	b := tile.Bounds
+	for k := 0; k < bands; k++ {
+		y0 := b.Min.Y + b.Dy()*k/bands
+		y1 := b.Min.Y + b.Dy()*(k+1)/bands
+		parts = append(parts, tilePart{
+			tile:   tile,
+			bounds: image.Rect(b.Min.X, y0, b.Max.X, y1),
+			cost:   tile.cost * time.Duration(y1-y0) / time.Duration(b.Dy()),
+		})
+	}
+	return parts
+}
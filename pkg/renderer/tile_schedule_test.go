@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+)
+
+func TestScheduleTiles(t *testing.T) {
+	// Tiles in a row, costing the given milliseconds
+	newTiles := func(costs ...time.Duration) []*Tile {
+		tiles := NewTileGrid(16*len(costs), 16, 16)
+		for i, cost := range costs {
+			tiles[i].cost = cost * time.Millisecond
+		}
+		return tiles
+	}
+
+	t.Run("Unmeasured", func(t *testing.T) {
+		tiles := newTiles(0, 5, 1)
+		parts := scheduleTiles(tiles, 4, 100)
+		if len(parts) != len(tiles) {
+			t.Fatalf("Expected one task per tile before costs are known, got %d", len(parts))
+		}
+		for i, part := range parts {
+			if part.tile != tiles[i] || part.bounds != tiles[i].Bounds {
+				t.Errorf("Task %d: expected tile %d whole, in grid order", i, i)
+			}
+		}
+	})
+
+	t.Run("Measured", func(t *testing.T) {
+		// 2 workers get 8 tasks' worth of the 20 units, 2.5 each: the tile costing 16 is split
+		tiles := newTiles(1, 16, 2, 1)
+		parts := scheduleTiles(tiles, 2, 100)
+
+		if parts[0].tile != tiles[1] {
+			t.Error("Expected the most expensive tile's parts first")
+		}
+		for i := 1; i < len(parts); i++ {
+			if parts[i].cost > parts[i-1].cost {
+				t.Errorf("Expected tasks by decreasing cost, task %d costs %v after %v", i, parts[i].cost, parts[i-1].cost)
+			}
+		}
+		if parts[len(parts)-2].tile != tiles[0] || parts[len(parts)-1].tile != tiles[3] {
+			t.Error("Expected tiles of equal cost in grid order")
+		}
+
+		// Every tile's parts are bands covering its pixels exactly once
+		for _, tile := range tiles {
+			covered := 0
+			bands := 0
+			for _, part := range parts {
+				if part.tile != tile {
+					continue
+				}
+				bands++
+				if part.bounds.Intersect(tile.Bounds) != part.bounds || part.bounds.Dx() != tile.Bounds.Dx() {
+					t.Errorf("Tile %d: part %v isn't a band of rows of %v", tile.ID, part.bounds, tile.Bounds)
+				}
+				covered += part.bounds.Dx() * part.bounds.Dy()
+			}
+			if covered != tile.Bounds.Dx()*tile.Bounds.Dy() {
+				t.Errorf("Tile %d: parts cover %d pixels, expected %d", tile.ID, covered, tile.Bounds.Dx()*tile.Bounds.Dy())
+			}
+			want := 1
+			if tile == tiles[1] {
+				want = 7 // ceil(16 / 2.5)
+			}
+			if bands != want {
+				t.Errorf("Tile %d: expected %d parts, got %d", tile.ID, want, bands)
+			}
+		}
+	})
+
+	t.Run("TooManyParts", func(t *testing.T) {
+		tiles := newTiles(1, 16, 2, 1)
+		parts := scheduleTiles(tiles, 2, 5)
+		if len(parts) != len(tiles) {
+			t.Fatalf("Expected whole tiles when the split ones don't fit, got %d tasks", len(parts))
+		}
+		if parts[0].tile != tiles[1] || parts[0].bounds != tiles[1].Bounds {
+			t.Error("Expected the most expensive tile first")
+		}
+	})
+
+	t.Run("RowsLimitBands", func(t *testing.T) {
+		tiles := NewTileGrid(16, 2, 8)
+		tiles[0].cost = time.Second
+		tiles[1].cost = time.Nanosecond
+		if parts := scheduleTiles(tiles, 8, 100); len(parts) != 3 {
+			t.Errorf("Expected a 2 row tile split into at most 2 bands, got %d tasks", len(parts))
+		}
+	})
+}
+
+func TestRenderPass_SplitTilesMatchWholeTiles(t *testing.T) {
+	render := func(split bool) []core.Vec3 {
+		s := createTestScene()
+		s.SamplingConfig.Width = 32
+		s.SamplingConfig.Height = 16
+
+		config := ProgressiveConfig{
+			TileSize:           16,
+			InitialSamples:     1,
+			MaxSamplesPerPixel: 4,
+			MaxPasses:          2,
+			NumWorkers:         1,
+			Seed:               3,
+		}
+		raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+		if err != nil {
+			t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+		}
+		defer raytracer.workerPool.Stop()
+
+		for pass := 1; pass <= config.MaxPasses; pass++ {
+			// Costs as measured would split tiles by timing; fix them instead
+			for _, tile := range raytracer.tiles {
+				tile.cost = time.Millisecond
+				if split && tile.ID == 0 {
+					tile.cost = time.Second
+				}
+			}
+			parts := scheduleTiles(raytracer.tiles, config.NumWorkers, raytracer.workerPool.maxTasks())
+			if split && len(parts) == len(raytracer.tiles) {
+				t.Fatal("Expected the first tile split")
+			}
+			if _, _, err := raytracer.RenderPass(context.Background(), pass, nil); err != nil {
+				t.Fatalf("RenderPass failed: %v", err)
+			}
+		}
+
+		for _, tile := range raytracer.tiles {
+			if tile.PassesCompleted != config.MaxPasses {
+				t.Errorf("Tile %d: expected %d passes completed, got %d", tile.ID, config.MaxPasses, tile.PassesCompleted)
+			}
+			if tile.cost <= 0 {
+				t.Errorf("Tile %d: expected its cost measured", tile.ID)
+			}
+		}
+
+		var colors []core.Vec3
+		for _, row := range raytracer.film.pixels {
+			for _, ps := range row {
+				colors = append(colors, ps.GetColor())
+			}
+		}
+		return colors
+	}
+
+	if !slices.Equal(render(false), render(true)) {
+		t.Error("Expected bit-identical pixel colors whether or not tiles are split")
+	}
+}
@@ -0,0 +1,173 @@
+package renderer
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// catcherLightSamples is the number of light samples a camera sample takes to measure how much of
+// the light reaching a shadow catcher the objects block
+const catcherLightSamples = 4
+
+// AlphaStats accumulates a pixel's coverage when the background is transparent: the filter
+// weights of its samples, each scaled by how opaque the sample is. Samples that see objects are
+// opaque, those that see nothing transparent, and those that see a shadow catcher as dark as the
+// objects' shadow on it.
+type AlphaStats struct {
+	CoverageAccum float64
+}
+
+// AddSample adds a sample's coverage, from 0 (transparent) to 1 (opaque)
+func (as *AlphaStats) AddSample(coverage, weight float64) {
+	as.CoverageAccum += coverage * weight
+}
+
+// alpha returns a pixel's coverage, 1 unless the background is transparent
+func (f *Film) alpha(x, y int) float64 {
+	ps := &f.pixels[y][x]
+	if ps.Alpha == nil {
+		return 1
+	}
+	if ps.WeightSum <= 0 {
+		return 0
+	}
+	return ps.Alpha.CoverageAccum / ps.WeightSum
+}
+
+// vec3ToColorAlpha converts a pixel's linear color and coverage to RGBA, premultiplied as
+// image.RGBA holds it: the display color of the covered part, scaled by the coverage
+func (pr *ProgressiveRaytracer) vec3ToColorAlpha(colorVec core.Vec3, alpha float64) color.RGBA {
+	if alpha >= 1 {
+		return pr.vec3ToColor(colorVec)
+	}
+	if alpha <= 0 {
+		return color.RGBA{}
+	}
+	c := pr.vec3ToColor(colorVec.Multiply(1 / alpha))
+	return color.RGBA{
+		R: uint8(float64(c.R) * alpha),
+		G: uint8(float64(c.G) * alpha),
+		B: uint8(float64(c.B) * alpha),
+		A: uint8(255 * alpha),
+	}
+}
+
+// transparentSample returns what a camera sample of color sampleColor adds over a transparent
+// background: its color and coverage. Samples that see nothing add neither; those that see a
+// shadow catcher add the light the objects reflect onto it, covering it as far as they shade it.
+// The first hit is traced again, as the surface AOVs do.
+func (tr *TileRenderer) transparentSample(ray core.Ray, sampleColor core.Vec3, sampler core.Sampler) (core.Vec3, float64) {
+	hit, isHit := geometry.HitVisible(tr.scene.Intersector, ray, cameraRayTMin, math.Inf(1), material.CameraRays)
+	if !isHit {
+		return core.Vec3{}, 0
+	}
+	if _, isCatcher := hit.Material.(*material.ShadowCatcher); !isCatcher {
+		return sampleColor, 1
+	}
+	return tr.catcherReflection(ray, hit, sampler), tr.catcherShadow(ray, hit, sampler)
+}
+
+// catcherShadow returns the share of the light reaching a shadow catcher's hit that the scene
+// blocks, weighting each light sample by what it would reflect toward the camera. The photograph
+// already shows the light, so this is all the catcher needs of it.
+func (tr *TileRenderer) catcherShadow(ray core.Ray, hit *material.SurfaceInteraction, sampler core.Sampler) float64 {
+	var blocked, total float64
+	for range catcherLightSamples {
+		lightSample, _, _, hasLight := lights.SampleLight(tr.scene.Lights, tr.scene.LightSampler, hit.Point, hit.Normal, sampler)
+		if !hasLight || lightSample.PDF <= 0 {
+			continue
+		}
+		cosine := material.CosineTerm(hit.Material, lightSample.Direction, hit.Normal)
+		if cosine <= 0 {
+			continue
+		}
+		brdf := hit.Material.EvaluateBRDF(ray.Direction, lightSample.Direction, hit, material.Radiance)
+		light := brdf.MultiplyVec(lightSample.Emission).Luminance() * cosine / lightSample.PDF
+		total += light
+		if tr.catcherShadowed(core.NewRay(hit.Point, lightSample.Direction), lightSample.Distance-0.001) {
+			blocked += light
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	return blocked / total
+}
+
+// catcherShadowed reports whether an object blocks a shadow ray from a shadow catcher. It passes
+// through the shapes of lights, which shine in the photograph already.
+func (tr *TileRenderer) catcherShadowed(ray core.Ray, tMax float64) bool {
+	tMin := 0.001
+	for {
+		hit, isHit := geometry.HitVisible(tr.scene.Intersector, ray, tMin, tMax, material.ShadowRays)
+		if !isHit {
+			return false
+		}
+		if _, isEmitter := hit.Material.(material.Emitter); !isEmitter {
+			return true
+		}
+		tMin = math.Nextafter(hit.T, math.Inf(1))
+	}
+}
+
+// catcherReflection returns the light a shadow catcher's hit reflects from the scene's objects,
+// sampling one direction from its base material. The photograph shows what it reflects of the
+// environment, the lights and the surfaces other catchers stand in for, so those add nothing.
+// What the objects send it is gathered by a path tracer of the worker's own, as a bake does.
+func (tr *TileRenderer) catcherReflection(ray core.Ray, hit *material.SurfaceInteraction, sampler core.Sampler) core.Vec3 {
+	scatter, didScatter := hit.Material.Scatter(ray, *hit, sampler)
+	if !didScatter {
+		return core.Vec3{}
+	}
+	weight := scatter.Attenuation
+	if !scatter.IsSpecular() {
+		cosine := material.CosineTerm(hit.Material, scatter.Scattered.Direction, hit.Normal)
+		if scatter.PDF <= 0 || cosine <= 0 {
+			return core.Vec3{}
+		}
+		weight = weight.Multiply(cosine / scatter.PDF)
+	}
+
+	seen, isHit := geometry.HitVisible(tr.scene.Intersector, scatter.Scattered, 0.001, math.Inf(1), material.IndirectRays)
+	if !isHit {
+		return core.Vec3{}
+	}
+	if _, isCatcher := seen.Material.(*material.ShadowCatcher); isCatcher {
+		return core.Vec3{}
+	}
+	if _, isEmitter := seen.Material.(material.Emitter); isEmitter {
+		return core.Vec3{}
+	}
+
+	if tr.catcherTracer == nil {
+		config := tr.scene.SamplingConfig
+		config.IrradianceCache = 0
+		config.IrradianceCachePoints = false
+		config.GuidingPasses = 0
+		tr.catcherTracer = integrator.NewPathTracingIntegrator(config)
+	}
+	radiance, _ := tr.catcherTracer.RayColor(scatter.Scattered, tr.scene, sampler)
+	return weight.MultiplyVec(radiance)
+}
+
+// catcherSplats drops the light tracing splats landing on shadow catchers, whose pixels show only
+// what transparentSample gives them. Splat rays run from the camera to the point they light.
+func (tr *TileRenderer) catcherSplats(splatRays []integrator.SplatRay) []integrator.SplatRay {
+	kept := splatRays[:0]
+	for _, splatRay := range splatRays {
+		hit, isHit := geometry.HitVisible(tr.scene.Intersector, splatRay.Ray, cameraRayTMin, math.Inf(1), material.CameraRays)
+		if isHit {
+			if _, isCatcher := hit.Material.(*material.ShadowCatcher); isCatcher {
+				continue
+			}
+		}
+		kept = append(kept, splatRay)
+	}
+	return kept
+}
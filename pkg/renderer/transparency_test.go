@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestTransparent_ShadowCatcher(t *testing.T) {
+	// A sphere on a shadow catcher floor, lit from the left so its shadow falls to the right
+	cameraCenter := core.NewVec3(0, 1, 0)
+	s := &scene.Scene{
+		Shapes: []geometry.Shape{
+			geometry.NewSphere(core.NewVec3(0, 0, -3), 0.5, material.NewLambertian(core.NewVec3(0.8, 0.2, 0.2))),
+			geometry.NewQuad(core.NewVec3(-5, -0.5, 1), core.NewVec3(10, 0, 0), core.NewVec3(0, 0, -10),
+				material.NewShadowCatcher(material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))),
+		},
+		Camera: geometry.NewCamera(geometry.CameraConfig{
+			Center:      cameraCenter,
+			LookAt:      core.NewVec3(0, -0.5, -3),
+			Up:          core.NewVec3(0, 1, 0),
+			Width:       32,
+			AspectRatio: 1.0,
+			VFov:        60.0,
+		}),
+		SamplingConfig: scene.SamplingConfig{Width: 32, Height: 32, MaxDepth: 4, AdaptiveMinSamples: 1},
+	}
+	s.AddQuadLight(core.NewVec3(-3.5, 2, -3.5), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), core.NewVec3(8, 8, 8))
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	config := ProgressiveConfig{TileSize: 16, InitialSamples: 1, MaxSamplesPerPixel: 16, MaxPasses: 1, NumWorkers: 2, Seed: 5, Transparent: true}
+	raytracer, err := NewProgressiveRaytracer(s, config, integrator.NewPathTracingIntegrator(s.SamplingConfig), &testLogger{})
+	if err != nil {
+		t.Fatalf("NewProgressiveRaytracer failed: %v", err)
+	}
+	defer raytracer.workerPool.Stop()
+	img, _, err := raytracer.RenderPass(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("RenderPass failed: %v", err)
+	}
+
+	pixel := func(p core.Vec3) (int, int) {
+		x, y, ok := s.Camera.MapRayToPixel(core.NewRay(cameraCenter, p.Subtract(cameraCenter).Normalize()))
+		if !ok {
+			t.Fatalf("Expected %v in view", p)
+		}
+		return x, y
+	}
+	if alpha := raytracer.film.alpha(0, 0); alpha != 0 || img.RGBAAt(0, 0).A != 0 {
+		t.Errorf("Expected the sky to be transparent, got alpha %g and %v", alpha, img.RGBAAt(0, 0))
+	}
+	x, y := pixel(core.NewVec3(0, 0, -3))
+	if alpha := raytracer.film.alpha(x, y); alpha != 1 || img.RGBAAt(x, y).A != 255 {
+		t.Errorf("Expected the sphere to be opaque, got alpha %g and %v", alpha, img.RGBAAt(x, y))
+	}
+
+	// The catcher is as opaque as the shadow on it, and shows no more than the sphere reflects
+	x, y = pixel(core.NewVec3(0.75, -0.5, -3))
+	if alpha := raytracer.film.alpha(x, y); alpha < 0.5 || alpha > 1 {
+		t.Errorf("Expected the shadow to cover most of its pixel, got alpha %g", alpha)
+	}
+	x, y = pixel(core.NewVec3(-1.5, -0.5, -3))
+	if alpha := raytracer.film.alpha(x, y); alpha > 0.05 {
+		t.Errorf("Expected the lit floor to be transparent, got alpha %g", alpha)
+	}
+	if c := raytracer.film.color(x, y); c.Luminance() > 0.05 {
+		t.Errorf("Expected the lit floor to show little but the sphere's reflection, got %v", c)
+	}
+	if c := img.RGBAAt(x, y); c.R > c.A || c.G > c.A || c.B > c.A {
+		t.Errorf("Expected premultiplied colors, got %v", c)
+	}
+
+	raytracer.config.Transparent = false
+	if film := newImageFilm(2, 2, raytracer.config); film.alpha(0, 0) != 1 {
+		t.Error("Expected opaque pixels unless the background is transparent")
+	}
+}
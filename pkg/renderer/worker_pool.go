@@ -1,28 +1,40 @@
 package renderer
 
 import (
+	"context"
+	"image"
 	"runtime"
 	"sync"
+	"time"
 
+	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/integrator"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
 )
 
 // TileTask represents a tile rendering task for the worker pool
 type TileTask struct {
+	Ctx           context.Context // Cancelling it abandons the tile between pixels (nil = never)
 	Tile          *Tile
+	Bounds        image.Rectangle // The part of the tile to render (empty = all of it)
 	PassNumber    int
 	TargetSamples int
-	TaskID        int            // For deterministic ordering
-	PixelStats    [][]PixelStats // Shared pixel stats array to write to
-	SplatQueue    *SplatQueue    // Shared splat queue for cross-tile contributions
+	TaskID        int                   // For deterministic ordering
+	Seed          uint64                // Render seed for per-pixel random sequences
+	PixelStats    [][]PixelStats        // Shared pixel stats array to write to
+	Scale         int                   // Image pixels per PixelStats pixel along each axis (0 or 1 = full resolution)
+	Prior         [][]PixelStats        // Next coarser pyramid level, informing adaptive sampling (nil = none)
+	Mask          *sampleMask           // Scales each pixel's target samples (nil = none)
+	Filter        *pixelFilter          // Reconstruction filter of full resolution pixels (nil = box)
+	Integrator    integrator.Integrator // Integrator to render with (nil = the pool's)
 }
 
 // TileResult contains the result from rendering a tile
 type TileResult struct {
-	TaskID int
-	Stats  RenderStats
-	Error  error
+	TaskID   int
+	Stats    RenderStats
+	Duration time.Duration // How long the task took to render
+	Error    error
 }
 
 // WorkerPool manages parallel tile rendering
@@ -33,16 +45,22 @@ type WorkerPool struct {
 	numWorkers  int
 	wg          sync.WaitGroup
 	stopChan    chan bool
+	cpus        []int // CPUs the workers are pinned to, worker i to cpus[i%len(cpus)] (nil = unpinned)
+	logger      core.Logger
+	pinFailed   sync.Once // Warns of the first worker that couldn't be pinned
 }
 
 // Worker handles individual tile rendering tasks
 type Worker struct {
 	ID           int
+	integrator   integrator.Integrator // The pool's integrator, for tasks that don't name their own
 	tileRenderer *TileRenderer
+	splats       *SplatQueue // The worker's own splats, gathered by TakeSplats after each pass
 	taskQueue    chan TileTask
 	resultQueue  chan TileResult
 	stopChan     chan bool
 	pool         *WorkerPool // Reference to parent pool for callback access
+	yielded      time.Time   // When the worker last let JavaScript run (WebAssembly only)
 }
 
 // NewWorkerPool creates a worker pool with the specified number of workers
@@ -67,7 +85,9 @@ func NewWorkerPool(scene *scene.Scene, integratorInst integrator.Integrator, wid
 		tileRenderer := NewTileRenderer(scene, integratorInst)
 		worker := &Worker{
 			ID:           i,
+			integrator:   integratorInst,
 			tileRenderer: tileRenderer,
+			splats:       NewSplatQueue(),
 			taskQueue:    wp.taskQueue,
 			resultQueue:  wp.resultQueue,
 			stopChan:     wp.stopChan,
@@ -79,6 +99,20 @@ func NewWorkerPool(scene *scene.Scene, integratorInst integrator.Integrator, wid
 	return wp
 }
 
+// PinWorkers makes each worker run on its own CPU once started (Linux only), taking the CPUs the
+// process may use in order and wrapping around if there are more workers. Workers that can't be
+// pinned, such as in a container that forbids it, run unpinned, and the first is logged to
+// logger. Call it before Start.
+func (wp *WorkerPool) PinWorkers(logger core.Logger) error {
+	cpus, err := allowedCPUs()
+	if err != nil {
+		return err
+	}
+	wp.cpus = cpus
+	wp.logger = logger
+	return nil
+}
+
 // Start begins all workers
 func (wp *WorkerPool) Start() {
 	for _, worker := range wp.workers {
@@ -94,11 +128,31 @@ func (wp *WorkerPool) Stop() {
 	close(wp.resultQueue)
 }
 
+// sceneEdited makes the workers pick up the scene's edits with their next tasks, and the
+// integrators that keep what they computed from the scene (see integrator.SceneCacher) forget it:
+// the pool's, the workers' and those given. Call it only while no tasks are in flight.
+func (wp *WorkerPool) sceneEdited(integrators ...integrator.Integrator) {
+	for _, worker := range wp.workers {
+		worker.tileRenderer.primary = nil
+		integrators = append(integrators, worker.integrator, worker.tileRenderer.integrator)
+	}
+	for _, integratorInst := range integrators {
+		if cacher, ok := integratorInst.(integrator.SceneCacher); ok {
+			cacher.SceneEdited()
+		}
+	}
+}
+
 // SubmitTask submits a tile task to the worker pool
 func (wp *WorkerPool) SubmitTask(task TileTask) {
 	wp.taskQueue <- task
 }
 
+// maxTasks returns how many tasks can be submitted at once without waiting for results
+func (wp *WorkerPool) maxTasks() int {
+	return cap(wp.taskQueue)
+}
+
 // GetResult retrieves a completed tile result
 func (wp *WorkerPool) GetResult() (TileResult, bool) {
 	result, ok := <-wp.resultQueue
@@ -110,22 +164,68 @@ func (wp *WorkerPool) GetNumWorkers() int {
 	return wp.numWorkers
 }
 
+// TakeSplats returns the splats every worker added since the last call, emptying their queues
+// Call it only while no tasks are in flight, e.g. once a pass's results are all in: the result
+// channel orders the workers' writes before it.
+func (wp *WorkerPool) TakeSplats() []SplatXY {
+	var splats []SplatXY
+	for _, worker := range wp.workers {
+		splats = append(splats, worker.splats.splats...)
+		worker.splats.Clear()
+	}
+	return splats
+}
+
 // run is the main worker loop
 func (w *Worker) run(wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	// A pinned worker keeps its thread to itself, and never unlocks it: the thread then exits with
+	// the worker rather than going back to the runtime still pinned
+	if cpus := w.pool.cpus; len(cpus) > 0 {
+		runtime.LockOSThread()
+		if err := pinThread(cpus[w.ID%len(cpus)]); err != nil {
+			runtime.UnlockOSThread() // Run unpinned
+			w.pool.pinFailed.Do(func() {
+				w.pool.logger.Printf("Warning: worker %d runs unpinned: %v\n", w.ID, err)
+			})
+		}
+	}
+
 	for task := range w.taskQueue {
-		// Render the tile using the tile renderer
+		ctx := task.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		// Render the tile using the tile renderer, unless the pass was abandoned while it waited
 		// Each tile has non-overlapping bounds, so this is thread-safe
-		stats := w.tileRenderer.RenderTileBounds(task.Tile.Bounds, task.PixelStats, task.SplatQueue, task.Tile.Sampler, task.TargetSamples)
+		bounds := task.Tile.Bounds
+		if !task.Bounds.Empty() {
+			bounds = task.Bounds
+		}
+		var stats RenderStats
+		start := time.Now()
+		if ctx.Err() == nil {
+			w.tileRenderer.done = ctx.Done()
+			w.tileRenderer.mask = task.Mask
+			w.tileRenderer.filter = task.Filter
+			w.tileRenderer.integrator = w.integrator
+			if task.Integrator != nil {
+				w.tileRenderer.integrator = task.Integrator
+			}
+			stats = w.tileRenderer.RenderLevelTileBounds(bounds, task.PixelStats, task.Prior, max(1, task.Scale), w.splats, task.Seed, task.TargetSamples)
+		}
 
-		// Send result back with just the stats
+		// Send result back with just the stats; a cancelled tile may be incomplete
 		result := TileResult{
-			TaskID: task.TaskID,
-			Stats:  stats,
-			Error:  nil,
+			TaskID:   task.TaskID,
+			Stats:    stats,
+			Duration: time.Since(start),
+			Error:    ctx.Err(),
 		}
 
 		w.resultQueue <- result
+		yieldToHost(&w.yielded)
 	}
 }
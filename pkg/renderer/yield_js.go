@@ -0,0 +1,31 @@
+//go:build js
+
+package renderer
+
+import (
+	"syscall/js"
+	"time"
+)
+
+// yieldInterval is how long a worker renders before letting JavaScript run
+const yieldInterval = 100 * time.Millisecond
+
+// yieldToHost lets JavaScript run, at most once per yieldInterval. On WebAssembly, Go runs on the
+// page's (or Web Worker's) only thread and hands it back only once every goroutine is blocked; a
+// worker with tiles queued never blocks, so events such as a request to stop would wait for the
+// render to end. The worker instead blocks until a timeout queued behind them has run.
+func yieldToHost(last *time.Time) {
+	if time.Since(*last) < yieldInterval {
+		return
+	}
+	resumed := make(chan struct{})
+	var resume js.Func
+	resume = js.FuncOf(func(js.Value, []js.Value) any {
+		resume.Release()
+		close(resumed)
+		return nil
+	})
+	js.Global().Call("setTimeout", resume, 0)
+	<-resumed
+	*last = time.Now()
+}
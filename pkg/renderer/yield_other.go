@@ -0,0 +1,8 @@
+//go:build !js
+
+package renderer
+
+import "time"
+
+// yieldToHost does nothing: outside WebAssembly, workers have threads of their own
+func yieldToHost(last *time.Time) {}
@@ -3,6 +3,7 @@ package scene
 import (
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -100,6 +101,10 @@ func addCausticGlassLighting(s *Scene, lightType lights.LightType) {
 	s.AddUniformInfiniteLight(core.NewVec3(0.1, 0.1, 0.1))
 }
 
+// causticGlassDispersion is the Cauchy B coefficient (μm²) of the glass, strong enough (about
+// dense flint) for the caustics to show spectral fringes. The PBRT scene's glass doesn't disperse.
+const causticGlassDispersion = 0.01
+
 // addCausticGlassMeshes loads the PLY files and adds them to the scene
 func addCausticGlassMeshes(s *Scene, logger core.Logger) {
 	// Try multiple possible paths for the PLY files
@@ -136,10 +141,11 @@ func addCausticGlassMesh(s *Scene, filename, materialType string, refractiveInde
 	}
 
 	if !found {
-		logger.Printf("Warning: %s not found at any of these locations:\n", filename)
-		for _, basePath := range possibleBasePaths {
-			logger.Printf("  - %s\n", basePath+filename)
+		paths := make([]string, len(possibleBasePaths))
+		for i, basePath := range possibleBasePaths {
+			paths[i] = basePath + filename
 		}
+		core.Logf(logger, core.LevelWarn, "%s not found at any of these locations: %s\n", filename, strings.Join(paths, ", "))
 		return
 	}
 
@@ -147,7 +153,7 @@ func addCausticGlassMesh(s *Scene, filename, materialType string, refractiveInde
 	var meshMaterial material.Material
 	switch materialType {
 	case "glass":
-		meshMaterial = material.NewDielectric(refractiveIndex)
+		meshMaterial = material.NewDispersiveDielectric(refractiveIndex, causticGlassDispersion)
 	case "uber":
 		// Approximate PBRT uber material as lambertian (matches rendered appearance better)
 		// Use the diffuse component: "rgb Kd" [ 0.6399999857 0.6399999857 0.6399999857 ]
@@ -164,13 +170,18 @@ func addCausticGlassMesh(s *Scene, filename, materialType string, refractiveInde
 	plyData, err := loaders.LoadPLY(meshPath)
 	plyLoadTime := time.Since(plyStart)
 	if err != nil {
-		logger.Printf("Error loading %s: %v\n", filename, err)
+		core.Logf(logger, core.LevelError, "Error loading %s: %v\n", filename, err)
 		return
 	}
 
 	logger.Printf("PLY data loaded: %d vertices, %d triangles in %v\n",
 		len(plyData.Vertices), len(plyData.Faces)/3, plyLoadTime)
 
+	// Weld duplicate vertices and drop degenerate triangles, whose normals render as black speckles
+	cleanup := loaders.CleanupMesh(plyData, loaders.MeshCleanupOptions{})
+	logger.Printf("Mesh cleanup: welded %d vertices, removed %d degenerate triangles\n",
+		cleanup.WeldedVertices, cleanup.DegenerateTriangles)
+
 	// Create mesh options (no rotation needed - using PBRT coordinates as-is)
 	// Note: PLY normals are per-vertex, but TriangleMesh expects per-triangle normals
 	// So we skip the normals and let the mesh calculate them automatically
@@ -0,0 +1,158 @@
+package scene
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+const (
+	lightPowerSamples = 4096 // Emission samples used to estimate each light's power
+	texelBytes        = 24   // Memory per image texture pixel (a Vec3 of three float64s)
+)
+
+// SceneStats summarizes a scene's contents without rendering it
+type SceneStats struct {
+	Shapes          map[string]int        `json:"shapes"`          // Top-level shape count per type
+	Triangles       int                   `json:"triangles"`       // Triangles, including those inside meshes
+	TotalPrimitives int                   `json:"totalPrimitives"` // Primitives after expanding meshes
+	BVH             geometry.BVHStats     `json:"bvh"`
+	Lights          []LightStats          `json:"lights"`
+	TotalLightPower core.Vec3             `json:"totalLightPower"` // Sum of the lights' estimated power (RGB)
+	Textures        int                   `json:"textures"`        // Distinct image textures
+	TextureBytes    int                   `json:"textureBytes"`    // Memory held by image texture pixels
+	Camera          geometry.CameraConfig `json:"camera"`
+	Height          int                   `json:"height"` // Image height derived from width and aspect ratio
+	Sampling        SamplingConfig        `json:"sampling"`
+}
+
+// LightStats describes a single light
+type LightStats struct {
+	Type  string    `json:"type"`  // Go type of the light, e.g. "QuadLight"
+	Kind  string    `json:"kind"`  // Light category: area, point or infinite
+	Power core.Vec3 `json:"power"` // Estimated emitted power (RGB)
+}
+
+// Describe collects statistics about the scene: primitive counts, BVH shape, light power,
+// texture memory and camera settings. It preprocesses the scene (building the BVH) if needed.
+// BVH statistics are left empty when the scene uses another intersection backend.
+func Describe(s *Scene) (SceneStats, error) {
+	if s.Intersector == nil {
+		if err := s.Preprocess(); err != nil {
+			return SceneStats{}, err
+		}
+	}
+
+	stats := SceneStats{
+		Shapes:          make(map[string]int),
+		TotalPrimitives: s.GetPrimitiveCount(),
+		Camera:          s.CameraConfig,
+		Sampling:        s.SamplingConfig,
+	}
+	if s.BVH != nil {
+		stats.BVH = s.BVH.Stats() // Other intersection backends don't expose their structure
+	}
+	if s.CameraConfig.AspectRatio > 0 {
+		stats.Height = int(float64(s.CameraConfig.Width) / s.CameraConfig.AspectRatio)
+	}
+
+	// Walk shapes for counts and the materials they use; textures are counted once however often they're shared
+	textures := make(map[*material.ImageTexture]bool)
+	for _, shape := range s.Shapes {
+		if visibility, ok := shape.(*geometry.VisibilityShape); ok {
+			shape = visibility.Shape
+		}
+		if translated, ok := shape.(*geometry.Translated); ok {
+			shape = translated.Shape
+		}
+		stats.Shapes[typeName(shape)]++
+
+		if mesh, ok := shape.(*geometry.TriangleMesh); ok {
+			stats.Triangles += mesh.GetTriangleCount()
+			for _, triangle := range mesh.GetTriangles() {
+				collectTextures(shapeMaterial(triangle), textures)
+			}
+			continue
+		}
+		if _, ok := shape.(*geometry.Triangle); ok {
+			stats.Triangles++
+		}
+		collectTextures(shapeMaterial(shape), textures)
+	}
+
+	for texture := range textures {
+		stats.Textures++
+		stats.TextureBytes += len(texture.Pixels) * texelBytes
+	}
+
+	for _, light := range s.Lights {
+		power := lights.EstimatePower(light, lightPowerSamples)
+		stats.Lights = append(stats.Lights, LightStats{
+			Type:  typeName(light),
+			Kind:  string(light.Type()),
+			Power: power,
+		})
+		stats.TotalLightPower = stats.TotalLightPower.Add(power)
+	}
+
+	return stats, nil
+}
+
+// typeName returns the unqualified Go type name of a value, e.g. "Sphere" for *geometry.Sphere
+func typeName(v interface{}) string {
+	name := fmt.Sprintf("%T", v)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// shapeMaterial returns the material of the built-in shapes, or nil for shapes without one
+func shapeMaterial(shape geometry.Shape) material.Material {
+	switch s := shape.(type) {
+	case *geometry.Sphere:
+		return s.Material
+	case *geometry.Quad:
+		return s.Material
+	case *geometry.Triangle:
+		return s.Material
+	case *geometry.Box:
+		return s.Material
+	case *geometry.Disc:
+		return s.Material
+	case *geometry.Cylinder:
+		return s.Material
+	case *geometry.Cone:
+		return s.Material
+	case *geometry.Capsule:
+		return s.Material
+	case *geometry.Torus:
+		return s.Material
+	case *geometry.ImplicitSurface:
+		return s.Material
+	default:
+		return nil
+	}
+}
+
+// collectTextures adds the image textures referenced by a material (including nested layers) to textures
+func collectTextures(mat material.Material, textures map[*material.ImageTexture]bool) {
+	var source material.ColorSource
+	switch m := mat.(type) {
+	case *material.Lambertian:
+		source = m.Albedo
+	case *material.Metal:
+		source = m.Albedo
+	case *material.Layered:
+		collectTextures(m.Outer, textures)
+		collectTextures(m.Inner, textures)
+	case *material.Mix:
+		collectTextures(m.Material1, textures)
+		collectTextures(m.Material2, textures)
+	}
+
+	if texture, ok := source.(*material.ImageTexture); ok {
+		textures[texture] = true
+	}
+}
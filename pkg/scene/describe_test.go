@@ -0,0 +1,59 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestDescribe(t *testing.T) {
+	texture := material.NewImageTexture(4, 2, make([]core.Vec3, 8))
+	textured := material.NewTexturedLambertian(texture)
+
+	s := &Scene{
+		CameraConfig:   geometry.CameraConfig{Width: 200, AspectRatio: 2},
+		SamplingConfig: SamplingConfig{MaxDepth: 7},
+	}
+	s.Shapes = append(s.Shapes,
+		geometry.NewSphere(core.NewVec3(0, 0, 0), 1, textured),
+		geometry.NewSphere(core.NewVec3(3, 0, 0), 1, material.NewMix(textured, material.NewLambertian(core.NewVec3(1, 1, 1)), 0.5)),
+		geometry.NewTriangleMesh(
+			[]core.Vec3{core.NewVec3(0, 0, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 1, 0), core.NewVec3(1, 1, 0)},
+			[]int{0, 1, 2, 1, 3, 2}, material.NewLambertian(core.NewVec3(1, 1, 1)), nil),
+	)
+	s.AddQuadLight(core.NewVec3(0, 5, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), core.NewVec3(3, 3, 3))
+
+	stats, err := Describe(s)
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+
+	if stats.Shapes["Sphere"] != 2 || stats.Shapes["TriangleMesh"] != 1 || stats.Shapes["Quad"] != 1 {
+		t.Errorf("Unexpected shape counts %v", stats.Shapes)
+	}
+	if stats.Triangles != 2 || stats.TotalPrimitives != 5 {
+		t.Errorf("Expected 2 triangles and 5 primitives, got %d and %d", stats.Triangles, stats.TotalPrimitives)
+	}
+	if stats.BVH.TotalShapes != 4 {
+		t.Errorf("Expected 4 shapes in the BVH, got %d", stats.BVH.TotalShapes)
+	}
+
+	// The shared texture counts once, even when reached through a mix material
+	if stats.Textures != 1 || stats.TextureBytes != 8*texelBytes {
+		t.Errorf("Expected 1 texture of %d bytes, got %d of %d bytes", 8*texelBytes, stats.Textures, stats.TextureBytes)
+	}
+
+	if len(stats.Lights) != 1 || stats.Lights[0].Type != "QuadLight" || stats.Lights[0].Kind != "area" {
+		t.Fatalf("Unexpected lights %+v", stats.Lights)
+	}
+	if expected := math.Pi * 3; math.Abs(stats.TotalLightPower.X-expected)/expected > 0.02 {
+		t.Errorf("Expected light power %f, got %f", expected, stats.TotalLightPower.X)
+	}
+
+	if stats.Height != 100 || stats.Sampling.MaxDepth != 7 {
+		t.Errorf("Expected height 100 and max depth 7, got %d and %d", stats.Height, stats.Sampling.MaxDepth)
+	}
+}
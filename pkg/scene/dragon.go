@@ -3,6 +3,7 @@ package scene
 import (
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -14,7 +15,9 @@ import (
 // NewDragonScene creates a scene with the dragon PLY mesh
 // If loadMesh is false, creates the scene structure without loading the PLY file
 // This is useful for getting scene configuration without the expensive mesh loading
-func NewDragonScene(loadMesh bool, materialFinish string, logger core.Logger, cameraOverrides ...geometry.CameraConfig) *Scene {
+// float32Mesh stores the mesh in float32 (see geometry.TriangleMeshOptions.Float32), using a
+// fraction of the memory
+func NewDragonScene(loadMesh bool, materialFinish string, float32Mesh bool, logger core.Logger, cameraOverrides ...geometry.CameraConfig) *Scene {
 	// Setup camera for dragon viewing
 	cameraConfig := setupDragonCamera(cameraOverrides...)
 	camera := geometry.NewCamera(cameraConfig)
@@ -35,7 +38,7 @@ func NewDragonScene(loadMesh bool, materialFinish string, logger core.Logger, ca
 
 	// Load and add dragon mesh only if requested
 	if loadMesh {
-		addDragonMesh(s, materialFinish, logger)
+		addDragonMesh(s, materialFinish, float32Mesh, logger)
 	} else {
 		// Add a placeholder for configuration purposes
 		logger.Printf("Dragon scene created without mesh for configuration\n")
@@ -112,7 +115,7 @@ func addDragonGround(s *Scene) {
 }
 
 // addDragonMesh loads the dragon PLY file and adds it to the scene
-func addDragonMesh(s *Scene, materialFinish string, logger core.Logger) {
+func addDragonMesh(s *Scene, materialFinish string, float32Mesh bool, logger core.Logger) {
 	// Try multiple possible paths for the dragon PLY file
 	// This allows the scene to work from both command line and web server contexts
 	possiblePaths := []string{
@@ -133,10 +136,7 @@ func addDragonMesh(s *Scene, materialFinish string, logger core.Logger) {
 	}
 
 	if !found {
-		logger.Printf("Warning: Dragon PLY file not found at any of these locations:\n")
-		for _, path := range possiblePaths {
-			logger.Printf("  - %s\n", path)
-		}
+		core.Logf(logger, core.LevelWarn, "Dragon PLY file not found at any of these locations: %s\n", strings.Join(possiblePaths, ", "))
 		return
 	}
 
@@ -175,8 +175,7 @@ func addDragonMesh(s *Scene, materialFinish string, logger core.Logger) {
 	plyData, err := loaders.LoadPLY(dragonPath)
 	plyLoadTime := time.Since(plyStart)
 	if err != nil {
-		logger.Printf("Error loading dragon PLY data: %v\n", err)
-		logger.Printf("Adding placeholder sphere instead\n")
+		core.Logf(logger, core.LevelWarn, "Error loading dragon PLY data, adding placeholder sphere instead: %v\n", err)
 
 		// Add placeholder sphere
 		placeholder := geometry.NewSphere(
@@ -191,6 +190,11 @@ func addDragonMesh(s *Scene, materialFinish string, logger core.Logger) {
 	logger.Printf("PLY data loaded: %d vertices, %d triangles in %v\n",
 		len(plyData.Vertices), len(plyData.Faces)/3, plyLoadTime)
 
+	// Weld duplicate vertices and drop degenerate triangles, whose normals render as black speckles
+	cleanup := loaders.CleanupMesh(plyData, loaders.MeshCleanupOptions{})
+	logger.Printf("Mesh cleanup: welded %d vertices, removed %d degenerate triangles\n",
+		cleanup.WeldedVertices, cleanup.DegenerateTriangles)
+
 	// Create triangle mesh with rotation
 	// Apply the exact rotation from PBRT scene: "Rotate -53 0 1 0"
 	// This means -53 degrees around Y axis (0 1 0)
@@ -198,19 +202,15 @@ func addDragonMesh(s *Scene, materialFinish string, logger core.Logger) {
 	rotation := core.NewVec3(0, rotationY, 0)  // Rotate around Y axis exactly like PBRT
 	center := core.NewVec3(0, 0, 0)            // Rotate around origin
 
-	// Create mesh options
-	var meshOptions *geometry.TriangleMeshOptions
+	// Shade smoothly: use the PLY's per-vertex normals when present, otherwise compute them
+	meshOptions := &geometry.TriangleMeshOptions{
+		Rotation:      &rotation,
+		Center:        &center,
+		SmoothNormals: true,
+		Float32:       float32Mesh,
+	}
 	if len(plyData.Normals) > 0 {
-		meshOptions = &geometry.TriangleMeshOptions{
-			Normals:  plyData.Normals,
-			Rotation: &rotation,
-			Center:   &center,
-		}
-	} else {
-		meshOptions = &geometry.TriangleMeshOptions{
-			Rotation: &rotation,
-			Center:   &center,
-		}
+		meshOptions.VertexNormals = plyData.Normals
 	}
 
 	// Create triangle mesh with timing
@@ -0,0 +1,165 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Scene edits change the shapes of a scene without building it again, for editors. Before
+// Preprocess they only change Shapes. After, they update the BVH in place, touching only the
+// nodes the shape is in (other intersection backends, and a BVH shared with an earlier version
+// of a PBRT file, are rebuilt), and preprocess the lights again if the world bounds change. They
+// aren't safe while the scene renders: a ProgressiveRaytracer applies them between passes with
+// EditScene. The next version a PBRTReloader loads after an edit is built from the file again.
+
+// AddShape adds a shape to the scene
+func (s *Scene) AddShape(shape geometry.Shape) error {
+	if s.Intersector != nil {
+		if preprocessor, ok := shape.(geometry.Preprocessor); ok {
+			if err := preprocessor.Preprocess(s.WorldCenter, s.WorldRadius); err != nil {
+				return err
+			}
+		}
+	}
+	s.Shapes = append(slices.Clip(s.Shapes), shape)
+	s.edited = true
+	if s.Intersector == nil {
+		return nil
+	}
+	if s.editsBVH() {
+		s.BVH.Insert(shape)
+	}
+	return s.shapesEdited()
+}
+
+// RemoveShape removes one of the scene's shapes. Area lights' shapes can't be removed, as the
+// lights would still shine.
+func (s *Scene) RemoveShape(shape geometry.Shape) error {
+	i, err := s.editableShape(shape)
+	if err != nil {
+		return err
+	}
+	removed := s.Shapes[i]
+	s.Shapes = slices.Delete(slices.Clone(s.Shapes), i, i+1)
+	s.edited = true
+	if s.Intersector == nil {
+		return nil
+	}
+	if s.editsBVH() {
+		s.BVH.Remove(removed)
+	}
+	return s.shapesEdited()
+}
+
+// MoveShape moves one of the scene's shapes by an offset, wrapping it in a geometry.Translated,
+// and returns the moved shape. It takes the shape's place in Shapes, so later edits should pass
+// it rather than the original. Area lights' shapes can't be moved.
+func (s *Scene) MoveShape(shape geometry.Shape, offset core.Vec3) (geometry.Shape, error) {
+	i, err := s.editableShape(shape)
+	if err != nil {
+		return nil, err
+	}
+	old := s.Shapes[i]
+	var moved geometry.Shape
+	if visibility, ok := old.(*geometry.VisibilityShape); ok {
+		// Visibility stays outermost, where Preprocess and SetVisibility look for it
+		moved = geometry.NewVisibilityShape(geometry.NewTranslated(visibility.Shape, offset), visibility.HiddenFrom)
+	} else {
+		moved = geometry.NewTranslated(old, offset)
+	}
+	s.Shapes = slices.Clone(s.Shapes)
+	s.Shapes[i] = moved
+	s.edited = true
+	if s.Intersector == nil {
+		return moved, nil
+	}
+	if s.editsBVH() {
+		s.BVH.Remove(old)
+		s.BVH.Insert(moved)
+	}
+	return moved, s.shapesEdited()
+}
+
+// ShapeAt returns the shape of Shapes seen at the center of pixel (x, y), for picking shapes to
+// edit, and where it's seen. The scene must be preprocessed.
+func (s *Scene) ShapeAt(x, y int) (geometry.Shape, *material.SurfaceInteraction, error) {
+	ray, hit, err := s.pixelHit(x, y)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The intersector doesn't say which shape it hit, so the shapes are tried for the same hit
+	for _, shape := range s.Shapes {
+		if shapeHit, isHit := shape.Hit(ray, hit.T*(1-1e-9), math.Nextafter(hit.T, math.Inf(1))); isHit && shapeHit.T == hit.T {
+			return shape, hit, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no shape found at pixel (%d, %d)", x, y)
+}
+
+// editableShape returns the index in Shapes of a shape edits may change: a shape of the scene,
+// or the shape a VisibilityShape of the scene hides, that isn't an area light's
+func (s *Scene) editableShape(shape geometry.Shape) (int, error) {
+	i := slices.IndexFunc(s.Shapes, func(existing geometry.Shape) bool {
+		if existing == shape {
+			return true
+		}
+		visibility, ok := existing.(*geometry.VisibilityShape)
+		return ok && visibility.Shape == shape
+	})
+	if i < 0 {
+		return 0, fmt.Errorf("shape is not in the scene")
+	}
+	existing := s.Shapes[i]
+	if visibility, ok := existing.(*geometry.VisibilityShape); ok {
+		existing = visibility.Shape
+	}
+	for _, light := range s.Lights {
+		if lightShape(light) == existing {
+			return 0, fmt.Errorf("shape is the %s's; lights can't be edited", typeName(light))
+		}
+	}
+	return i, nil
+}
+
+// shapesEdited brings what depends on the shapes of a preprocessed scene up to date after an
+// edit changed Shapes and the BVH
+func (s *Scene) shapesEdited() error {
+	if !s.editsBVH() {
+		build := s.IntersectorBuilder
+		if build == nil {
+			build = geometry.NewBVHIntersector
+		}
+		intersector, err := build(s.Shapes)
+		if err != nil {
+			return fmt.Errorf("failed to build intersector: %v", err)
+		}
+		s.Intersector = intersector
+		s.BVH, _ = intersector.(*geometry.BVH)
+		s.prebuilt = false
+	}
+
+	center, radius := s.Intersector.BoundingBox().BoundingSphere()
+	if center == s.WorldCenter && radius == s.WorldRadius {
+		s.hidesShapes = slices.ContainsFunc(s.Shapes, isHidden)
+		return nil
+	}
+	s.WorldCenter, s.WorldRadius = center, radius
+	return s.preprocessWorld()
+}
+
+// editsBVH reports whether edits update the scene's BVH in place, rather than rebuild the
+// intersector: the scene has a BVH of its own
+func (s *Scene) editsBVH() bool {
+	return s.BVH != nil && !s.prebuilt
+}
+
+// isHidden reports whether a shape is hidden from some kinds of rays (see SetVisibility)
+func isHidden(shape geometry.Shape) bool {
+	_, ok := shape.(*geometry.VisibilityShape)
+	return ok
+}
@@ -0,0 +1,116 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestShapeEdits(t *testing.T) {
+	s := NewDefaultScene(geometry.CameraConfig{Width: 160})
+	s.SamplingConfig.Width, s.SamplingConfig.Height = 160, 90
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	distance := func() float64 {
+		d, err := s.FocusDistanceAt(80, 45)
+		if err != nil {
+			return math.Inf(1)
+		}
+		return d
+	}
+
+	// The center pixel sees the center sphere
+	center, _, err := s.ShapeAt(80, 45)
+	if err != nil {
+		t.Fatalf("ShapeAt failed: %v", err)
+	}
+	if sphere, ok := center.(*geometry.Sphere); !ok || sphere.Center != core.NewVec3(0, 0.5, -1) {
+		t.Fatalf("Expected the center sphere at the center pixel, got %T", center)
+	}
+	before := distance()
+
+	// Moved away and back, it's seen again at the same distance
+	moved, err := s.MoveShape(center, core.NewVec3(0, 0, -20))
+	if err != nil {
+		t.Fatalf("MoveShape failed: %v", err)
+	}
+	if shape, _, _ := s.ShapeAt(80, 45); shape == moved || math.Abs(distance()-before) < 0.1 {
+		t.Error("Expected the moved sphere out of the center pixel's sight")
+	}
+	if _, err := s.MoveShape(center, core.NewVec3(0, 0, 20)); err == nil {
+		t.Error("Expected an error moving a shape that was replaced by its move")
+	}
+	moved, err = s.MoveShape(moved, core.NewVec3(0, 0, 20))
+	if err != nil {
+		t.Fatalf("MoveShape failed: %v", err)
+	}
+	if shape, _, _ := s.ShapeAt(80, 45); shape != moved || math.Abs(distance()-before) > 1e-9 {
+		t.Errorf("Expected the sphere back at %.3f, got %.3f", before, distance())
+	}
+	if translated, ok := moved.(*geometry.Translated); !ok || translated.Shape != center || translated.Offset != (core.Vec3{}) {
+		t.Errorf("Expected moves to add up to a single offset, got %+v", moved)
+	}
+
+	// Removed, the pixel sees past it; added back, the world grows to take in what's added
+	shapes := len(s.Shapes)
+	if err := s.RemoveShape(moved); err != nil {
+		t.Fatalf("RemoveShape failed: %v", err)
+	}
+	if shape, _, _ := s.ShapeAt(80, 45); len(s.Shapes) != shapes-1 || shape == moved {
+		t.Error("Expected the removed sphere gone")
+	}
+	radius := s.WorldRadius
+	far := geometry.NewSphere(core.NewVec3(0, 0, -5000), 1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	if err := s.AddShape(moved); err != nil {
+		t.Fatalf("AddShape failed: %v", err)
+	}
+	if err := s.AddShape(far); err != nil {
+		t.Fatalf("AddShape failed: %v", err)
+	}
+	if shape, _, _ := s.ShapeAt(80, 45); shape != moved || s.WorldRadius <= radius {
+		t.Errorf("Expected the added sphere seen and the world grown from radius %.1f, got %.1f", radius, s.WorldRadius)
+	}
+
+	// Lights' shapes and shapes from elsewhere can't be edited
+	if err := s.RemoveShape(lightShape(s.Lights[0])); err == nil {
+		t.Error("Expected an error removing a light's shape")
+	}
+	if err := s.RemoveShape(center); err == nil {
+		t.Error("Expected an error removing a shape that isn't in the scene")
+	}
+}
+
+func TestShapeEdits_BeforePreprocess(t *testing.T) {
+	s := NewDefaultScene()
+	shapes := len(s.Shapes)
+	sphere := geometry.NewSphere(core.NewVec3(0, 3, -1), 0.5, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	if err := s.AddShape(sphere); err != nil {
+		t.Fatalf("AddShape failed: %v", err)
+	}
+	moved, err := s.MoveShape(sphere, core.NewVec3(1, 0, 0))
+	if err != nil {
+		t.Fatalf("MoveShape failed: %v", err)
+	}
+	if len(s.Shapes) != shapes+1 || s.Shapes[shapes] != moved || s.Intersector != nil {
+		t.Fatal("Expected the edits to only change Shapes before Preprocess")
+	}
+
+	// Preprocess builds from the edited shapes, with a backend other than the BVH rebuilt per edit
+	s.IntersectorBuilder = func(shapes []geometry.Shape) (geometry.Intersector, error) {
+		return &countingIntersector{Intersector: geometry.NewBVH(shapes)}, nil
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	intersector := s.Intersector
+	if err := s.RemoveShape(moved); err != nil {
+		t.Fatalf("RemoveShape failed: %v", err)
+	}
+	if s.Intersector == intersector || len(s.Shapes) != shapes {
+		t.Error("Expected the intersector rebuilt without the removed shape")
+	}
+}
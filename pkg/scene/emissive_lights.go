@@ -0,0 +1,110 @@
+package scene
+
+import (
+	"reflect"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// addEmissiveLights makes each shape with an emissive material that isn't a light yet into an
+// area light (see Scene.AutoLights). Shapes already lights are skipped, so it can run again after
+// more shapes are added.
+func (s *Scene) addEmissiveLights() {
+	lit := s.lightShapes()
+	for _, shape := range s.Shapes {
+		// Lights can be hidden from camera and shadow rays but not from indirect ones (see
+		// SetLightVisibility), and a translated shape's light would sample the untranslated one
+		if visibility, ok := shape.(*geometry.VisibilityShape); ok {
+			if visibility.HiddenFrom&material.IndirectRays != 0 {
+				continue
+			}
+			shape = visibility.Shape
+		}
+		sampler, ok := shape.(geometry.SurfaceSampler)
+		if !ok || lit.has(shape) {
+			continue
+		}
+		if emissive := emissiveMaterial(shape); emissive != nil {
+			light := lights.NewShapeLight(sampler, emissive)
+			s.Lights = append(s.Lights, light)
+			lit.add(shape)
+		}
+	}
+}
+
+// emissiveMaterial returns a shape's material if it's emissive, nil if not. A mesh is emissive
+// when all its faces share one emissive material.
+func emissiveMaterial(shape geometry.Shape) *material.Emissive {
+	mesh, ok := shape.(*geometry.TriangleMesh)
+	if !ok {
+		emissive, _ := shapeMaterial(shape).(*material.Emissive)
+		return emissive
+	}
+	var shared *material.Emissive
+	for _, triangle := range mesh.GetTriangles() {
+		emissive, ok := shapeMaterial(triangle).(*material.Emissive)
+		if !ok || (shared != nil && emissive != shared) {
+			return nil
+		}
+		shared = emissive
+	}
+	return shared
+}
+
+// lightShapes holds the shapes of a scene's lights. A light whose shape is one of the scene's is
+// matched to it by identity, so coincident shapes are each a light of their own; a PBRT area light
+// is a light and a separate shape in one place, so it's matched to its shape by their bounds, as
+// are shapes that can't be compared, such as a registered type holding a slice by value.
+type lightShapes struct {
+	shapes map[geometry.Shape]bool // Only shapes that can be compared, which map keys must be
+	bounds map[geometry.AABB]bool
+}
+
+// lightShapes returns the shapes of the scene's lights
+func (s *Scene) lightShapes() *lightShapes {
+	inScene := make(map[geometry.Shape]bool, len(s.Shapes))
+	for _, shape := range s.Shapes {
+		if shape = unwrapShape(shape); comparableShape(shape) {
+			inScene[shape] = true
+		}
+	}
+
+	lit := &lightShapes{shapes: make(map[geometry.Shape]bool), bounds: make(map[geometry.AABB]bool)}
+	for _, light := range s.Lights {
+		shape := lightShape(light)
+		switch {
+		case shape == nil:
+		case comparableShape(shape) && inScene[shape]:
+			lit.shapes[shape] = true
+		default:
+			lit.bounds[shape.BoundingBox()] = true
+		}
+	}
+	return lit
+}
+
+// add records a shape made a light
+func (l *lightShapes) add(shape geometry.Shape) {
+	if shape = unwrapShape(shape); comparableShape(shape) {
+		l.shapes[shape] = true
+	} else {
+		l.bounds[shape.BoundingBox()] = true
+	}
+}
+
+// has reports whether a shape, or the one a VisibilityShape or Translated wraps, is a light's
+func (l *lightShapes) has(shape geometry.Shape) bool {
+	if l == nil {
+		return false
+	}
+	shape = unwrapShape(shape)
+	return (comparableShape(shape) && l.shapes[shape]) || l.bounds[shape.BoundingBox()]
+}
+
+// comparableShape reports whether a shape can be compared with ==, and so be a map key, without
+// panicking: pointers can, while values holding slices, maps or functions can't
+func comparableShape(shape geometry.Shape) bool {
+	return reflect.ValueOf(shape).Comparable()
+}
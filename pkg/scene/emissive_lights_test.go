@@ -0,0 +1,162 @@
+package scene
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestAutoLights(t *testing.T) {
+	glow := material.NewEmissive(core.NewVec3(4, 4, 4))
+	white := material.NewLambertian(core.NewVec3(0.8, 0.8, 0.8))
+	square := []core.Vec3{core.NewVec3(0, 2, 0), core.NewVec3(1, 2, 0), core.NewVec3(0, 2, 1), core.NewVec3(1, 2, 1)}
+
+	sphere := geometry.NewSphere(core.NewVec3(0, 1, 0), 0.5, glow)
+	quad := geometry.NewQuad(core.NewVec3(2, 3, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), glow)
+	mesh := geometry.NewTriangleMesh(square, []int{0, 1, 2, 1, 3, 2}, glow, nil)
+	mixed := geometry.NewTriangleMesh(square, []int{0, 1, 2, 1, 3, 2}, glow, &geometry.TriangleMeshOptions{Materials: []material.Material{glow, white}})
+	hidden := geometry.NewSphere(core.NewVec3(3, 1, 0), 0.5, glow)
+
+	s := &Scene{AutoLights: true}
+	s.Shapes = append(s.Shapes, sphere, quad, mesh, mixed, hidden, geometry.NewSphere(core.NewVec3(0, -100, 0), 99, white))
+	s.AddQuadLight(core.NewVec3(-1, 4, -1), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), core.NewVec3(1, 1, 1))
+	if err := s.SetVisibility(hidden, material.IndirectRays); err != nil {
+		t.Fatalf("SetVisibility failed: %v", err)
+	}
+
+	// Running again, as rendering a validated scene does, adds nothing more
+	for range 2 {
+		if err := s.Preprocess(); err != nil {
+			t.Fatalf("Preprocess failed: %v", err)
+		}
+	}
+
+	// The quad light stays the only light of its quad; the sphere, quad and single-material mesh
+	// become lights, not the mesh of two materials, the shape hidden from indirect rays or the
+	// white sphere
+	expected := []geometry.Shape{sphere, quad, mesh}
+	if len(s.Lights) != 1+len(expected) {
+		t.Fatalf("Expected %d lights, got %d", 1+len(expected), len(s.Lights))
+	}
+	for i, shape := range expected {
+		light, ok := s.Lights[1+i].(*lights.ShapeLight)
+		if !ok || light.SurfaceSampler != shape || light.Material != glow {
+			t.Errorf("Expected light %d to be the emissive %T, got %#v", 2+i, shape, s.Lights[1+i])
+		}
+	}
+	if warnings, _ := s.Validate(nil); len(warnings) != 1 || warnings[0].Check != "unsampled-emitter" || !strings.HasPrefix(warnings[0].Message, "shape 5 ") {
+		t.Errorf("Expected only the hidden sphere reported as an emitter that isn't a light, got %+v", warnings)
+	}
+
+	// Off by default
+	s = &Scene{Shapes: []geometry.Shape{geometry.NewSphere(core.NewVec3(0, 1, 0), 0.5, glow)}}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if len(s.Lights) != 0 {
+		t.Errorf("Expected no lights without AutoLights, got %d", len(s.Lights))
+	}
+}
+
+func TestAutoLights_SceneFile(t *testing.T) {
+	file, err := ParseSceneFile([]byte(`
+camera: {width: 40, aspectRatio: 1, fov: 40}
+sampling: {samples: 4, depth: 4}
+autoLights: true
+materials:
+  glow: {type: emissive, emit: [4, 4, 4]}
+shapes:
+  - {type: sphere, center: [0, 1, 0], radius: 0.5, material: glow}
+`), true)
+	if err != nil {
+		t.Fatalf("ParseSceneFile failed: %v", err)
+	}
+	s, err := NewFileScene(file, "")
+	if err != nil {
+		t.Fatalf("NewFileScene failed: %v", err)
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if len(s.Lights) != 1 {
+		t.Errorf("Expected the emissive sphere made a light, got %d lights", len(s.Lights))
+	}
+}
+
+func TestAutoLights_CoincidentShapes(t *testing.T) {
+	// Two emissive quads in one place, one of them a quad light's, are each a light of their own
+	glow := material.NewEmissive(core.NewVec3(4, 4, 4))
+	s := &Scene{AutoLights: true}
+	s.AddQuadLight(core.NewVec3(0, 2, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), core.NewVec3(4, 4, 4))
+	first := geometry.NewQuad(core.NewVec3(0, 2, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), glow)
+	second := geometry.NewQuad(core.NewVec3(0, 2, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), glow)
+	s.Shapes = append(s.Shapes, first, second)
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if len(s.Lights) != 3 {
+		t.Fatalf("Expected the quad light and both emissive quads as lights, got %d lights", len(s.Lights))
+	}
+	for i, shape := range []geometry.Shape{first, second} {
+		if light, ok := s.Lights[1+i].(*lights.ShapeLight); !ok || light.SurfaceSampler != shape {
+			t.Errorf("Expected light %d to be emissive quad %d, got %#v", 2+i, 1+i, s.Lights[1+i])
+		}
+	}
+
+	// A PBRT area light's shape is separate from its light, so it's matched by its bounds
+	parsed, err := loaders.ParsePBRT(strings.NewReader(`
+WorldBegin
+AttributeBegin
+  Material "diffuse" "rgb reflectance" [0 0 0]
+  AreaLightSource "diffuse" "rgb L" [4 4 4]
+  Shape "bilinearPatch" "point3 P00" [0 2 0] "point3 P01" [1 2 0] "point3 P10" [0 2 1] "point3 P11" [1 2 1]
+AttributeEnd
+`))
+	if err != nil {
+		t.Fatalf("ParsePBRT failed: %v", err)
+	}
+	s, err = NewPBRTScene(parsed)
+	if err != nil {
+		t.Fatalf("NewPBRTScene failed: %v", err)
+	}
+	s.AutoLights = true
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if len(s.Lights) != 1 {
+		t.Errorf("Expected the area light to stay the only light of its shape, got %d lights", len(s.Lights))
+	}
+}
+
+// taggedQuad is a shape of a value type holding a slice, so it can't be compared or be a map key
+type taggedQuad struct {
+	*geometry.Quad
+	tags []string
+}
+
+func TestAutoLights_IncomparableShape(t *testing.T) {
+	glow := material.NewEmissive(core.NewVec3(4, 4, 4))
+	s := &Scene{AutoLights: true}
+	s.AddShapeLight(taggedQuad{geometry.NewQuad(core.NewVec3(0, 2, 0), core.NewVec3(1, 0, 0), core.NewVec3(0, 0, 1), glow), []string{"strip"}}, glow)
+	s.Shapes = append(s.Shapes, geometry.NewSphere(core.NewVec3(3, 1, 0), 0.5, glow))
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if len(s.Lights) != 2 {
+		t.Errorf("Expected the tagged quad's light and the emissive sphere's, got %d lights", len(s.Lights))
+	}
+	warnings, err := s.Validate(nil)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	for _, warning := range warnings {
+		if warning.Check == "unsampled-emitter" {
+			t.Errorf("Expected the emitters found as lights, got %+v", warning)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package scene
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// NewMicroScene builds a scene from a few lines of the micro scene language, for tests and for
+// sharing small repro cases. Each line is a statement followed by named parameters, all optional:
+//
+//	# Comments run to the end of the line
+//	camera at 0 0 0 look 0 0 -1 up 0 1 0 fov 45 width 100 aspect 1
+//	sampling depth 5 samples 16 roulette 5  (roulette: bounces before Russian roulette)
+//	material glass dielectric ior 1.5     (also: dispersion b, in μm², sigma r g b for absorption; lambertian albedo r g b, metal albedo r g b fuzz f, emissive emit r g b)
+//	sphere at 0 0 -1 radius 0.5 material glass
+//	quad corner -1 -0.5 -2 u 2 0 0 v 0 0 2
+//	triangle a 0 0 0 b 1 0 0 c 0 1 0
+//	box at 0 0 -3 size 1 1 1
+//	quadlight corner -0.5 2 -0.5 u 1 0 0 v 0 0 1 emit 5 5 5
+//	spherelight at 0 3 0 radius 0.5 emit 10 10 10
+//	spotlight at 0 3 -2 look 0 0 -2 emit 3 3 3 angle 90 delta 1
+//	sky emit 0.5 0.7 1                    (or: sky top r g b bottom r g b)
+//	daylight turbidity 3 elevation 40 azimuth 30 scale 0.03  (physical sky with a sun)
+//	portal corner -1 0 -3 u 2 0 0 v 0 1 0   (a window the skies defined above it light a room through)
+//	sun dir -1 -2 -1 emit 3 3 3 angle 0.53  (dir: the light's travel; emit: irradiance)
+//
+// Shapes and the quad and sphere lights can be hidden from kinds of rays with a comma-separated
+// list, "hide camera" or "hide camera,indirect" for a shadow-only blocker (kinds: camera,
+// indirect, shadows).
+//
+// A light's emit can be tinted by a color temperature, "kelvin 2700". The quad, sphere and spot
+// lights can be given their power instead, "lumens 800" or "watts 60", with emit as the color.
+//
+// Shapes without a material are gray Lambertian (albedo 0.7). Materials the language can't express
+// can be passed in by name. Like the other scene constructors, the scene isn't preprocessed.
+func NewMicroScene(source string, materials map[string]material.Material) (*Scene, error) {
+	cameraConfig := geometry.CameraConfig{
+		Center:      core.NewVec3(0, 0, 0),
+		LookAt:      core.NewVec3(0, 0, -1),
+		Up:          core.NewVec3(0, 1, 0),
+		Width:       100,
+		AspectRatio: 1.0,
+		VFov:        45.0,
+	}
+	s := &Scene{
+		Shapes: make([]geometry.Shape, 0),
+		Lights: make([]lights.Light, 0),
+		SamplingConfig: SamplingConfig{
+			SamplesPerPixel:           16,
+			MaxDepth:                  5,
+			RussianRouletteMinBounces: 5,
+			AdaptiveMinSamples:        0.15,
+			AdaptiveThreshold:         0.01,
+		},
+	}
+
+	named := map[string]material.Material{"default": material.NewLambertian(core.NewVec3(0.7, 0.7, 0.7))}
+	for name, mat := range materials {
+		named[name] = mat
+	}
+
+	for i, line := range strings.Split(source, "\n") {
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := s.addMicroStatement(fields, named, &cameraConfig); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+
+	s.CameraConfig = cameraConfig
+	s.Camera = geometry.NewCamera(cameraConfig)
+	s.SamplingConfig.Width = cameraConfig.Width
+	s.SamplingConfig.Height = int(float64(cameraConfig.Width) / cameraConfig.AspectRatio)
+	return s, nil
+}
+
+// microParamArity is the number of values each named parameter takes
+var microParamArity = map[string]int{
+	"at": 3, "look": 3, "up": 3, "corner": 3, "u": 3, "v": 3, "a": 3, "b": 3, "c": 3, "size": 3,
+	"emit": 3, "albedo": 3, "sigma": 3, "top": 3, "bottom": 3, "dir": 3,
+	"radius": 1, "fov": 1, "width": 1, "aspect": 1, "depth": 1, "samples": 1, "fuzz": 1, "ior": 1, "dispersion": 1,
+	"angle": 1, "delta": 1, "roulette": 1, "material": 1, "hide": 1,
+	"turbidity": 1, "elevation": 1, "azimuth": 1, "scale": 1, "kelvin": 1, "lumens": 1, "watts": 1,
+}
+
+// microParams are the named parameters of one statement. The accessors return the default for
+// missing parameters and remember the first malformed value in err.
+type microParams struct {
+	values map[string][]string
+	err    error
+}
+
+// parseMicroParams splits a statement's arguments into named parameters
+func parseMicroParams(args []string) (*microParams, error) {
+	params := &microParams{values: make(map[string][]string)}
+	for len(args) > 0 {
+		name := args[0]
+		arity, known := microParamArity[name]
+		if !known {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		if len(args) < 1+arity {
+			return nil, fmt.Errorf("parameter %q needs %d values", name, arity)
+		}
+		params.values[name] = args[1 : 1+arity]
+		args = args[1+arity:]
+	}
+	return params, nil
+}
+
+// float returns a single number parameter
+func (p *microParams) float(name string, def float64) float64 {
+	values, ok := p.values[name]
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(values[0], 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("parameter %q: %q is not a number", name, values[0])
+	}
+	return f
+}
+
+// vec3 returns a three number parameter
+func (p *microParams) vec3(name string, def core.Vec3) core.Vec3 {
+	values, ok := p.values[name]
+	if !ok {
+		return def
+	}
+	var xyz [3]float64
+	for i, value := range values {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil && p.err == nil {
+			p.err = fmt.Errorf("parameter %q: %q is not a number", name, value)
+		}
+		xyz[i] = f
+	}
+	return core.NewVec3(xyz[0], xyz[1], xyz[2])
+}
+
+// rayKinds returns a parameter listing kinds of rays, separated by commas
+func (p *microParams) rayKinds(name string) material.RayKind {
+	values, ok := p.values[name]
+	if !ok {
+		return 0
+	}
+	kinds, err := parseRayKinds(strings.Split(values[0], ","))
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("parameter %q: %v", name, err)
+	}
+	return kinds
+}
+
+// emission returns a light's emission: emit, tinted by the kelvin color temperature, and scaled
+// to the lumens or watts of power of the light newLight creates when given and newLight isn't nil
+func (p *microParams) emission(newLight func(emission core.Vec3) lights.Light) core.Vec3 {
+	kelvin := p.float("kelvin", 0)
+	lumens := p.float("lumens", 0)
+	if _, ok := p.values["watts"]; ok {
+		lumens = lights.WattsToLumens(p.float("watts", 0), kelvin)
+	}
+	return lightEmission(p.vec3("emit", core.NewVec3(1, 1, 1)), kelvin, lumens, newLight)
+}
+
+// addMicroStatement applies one statement to the scene under construction
+func (s *Scene) addMicroStatement(fields []string, materials map[string]material.Material, camera *geometry.CameraConfig) error {
+	statement, args := fields[0], fields[1:]
+
+	// Materials are named first: "material <name> <type> params..."
+	if statement == "material" {
+		if len(args) < 2 {
+			return fmt.Errorf("material needs a name and a type")
+		}
+		params, err := parseMicroParams(args[2:])
+		if err != nil {
+			return err
+		}
+		mat, err := newMicroMaterial(args[1], params)
+		if err != nil {
+			return err
+		}
+		materials[args[0]] = mat
+		return params.err
+	}
+
+	params, err := parseMicroParams(args)
+	if err != nil {
+		return err
+	}
+	mat := materials["default"]
+	if values, ok := params.values["material"]; ok {
+		if mat, ok = materials[values[0]]; !ok {
+			return fmt.Errorf("unknown material %q", values[0])
+		}
+	}
+	zero := core.Vec3{}
+
+	switch statement {
+	case "camera":
+		camera.Center = params.vec3("at", camera.Center)
+		camera.LookAt = params.vec3("look", camera.LookAt)
+		camera.Up = params.vec3("up", camera.Up)
+		camera.VFov = params.float("fov", camera.VFov)
+		camera.Width = int(params.float("width", float64(camera.Width)))
+		camera.AspectRatio = params.float("aspect", camera.AspectRatio)
+		if camera.Width <= 0 || camera.AspectRatio <= 0 {
+			return fmt.Errorf("camera width and aspect must be positive")
+		}
+	case "sampling":
+		s.SamplingConfig.MaxDepth = int(params.float("depth", float64(s.SamplingConfig.MaxDepth)))
+		s.SamplingConfig.SamplesPerPixel = int(params.float("samples", float64(s.SamplingConfig.SamplesPerPixel)))
+		s.SamplingConfig.RussianRouletteMinBounces = int(params.float("roulette", float64(s.SamplingConfig.RussianRouletteMinBounces)))
+	case "sphere":
+		s.Shapes = append(s.Shapes, geometry.NewSphere(params.vec3("at", zero), params.float("radius", 1), mat))
+	case "quad":
+		s.Shapes = append(s.Shapes, geometry.NewQuad(params.vec3("corner", zero),
+			params.vec3("u", core.NewVec3(1, 0, 0)), params.vec3("v", core.NewVec3(0, 0, 1)), mat))
+	case "triangle":
+		s.Shapes = append(s.Shapes, geometry.NewTriangle(params.vec3("a", zero),
+			params.vec3("b", core.NewVec3(1, 0, 0)), params.vec3("c", core.NewVec3(0, 1, 0)), mat))
+	case "box":
+		s.Shapes = append(s.Shapes, geometry.NewAxisAlignedBox(params.vec3("at", zero), params.vec3("size", core.NewVec3(1, 1, 1)), mat))
+	case "quadlight":
+		corner, u, v := params.vec3("corner", zero), params.vec3("u", core.NewVec3(1, 0, 0)), params.vec3("v", core.NewVec3(0, 0, 1))
+		s.AddQuadLight(corner, u, v, params.emission(func(emission core.Vec3) lights.Light {
+			return lights.NewQuadLight(corner, u, v, material.NewEmissive(emission))
+		}))
+	case "spherelight":
+		center, radius := params.vec3("at", zero), params.float("radius", 1)
+		s.AddSphereLight(center, radius, params.emission(func(emission core.Vec3) lights.Light {
+			return lights.NewSphereLight(center, radius, material.NewEmissive(emission))
+		}))
+	case "spotlight":
+		from, to := params.vec3("at", zero), params.vec3("look", core.NewVec3(0, -1, 0))
+		angle, delta := params.float("angle", 45), params.float("delta", 5)
+		s.AddPointSpotLight(from, to, params.emission(func(emission core.Vec3) lights.Light {
+			return lights.NewPointSpotLight(from, to, emission, angle, delta)
+		}), angle, delta, 0)
+	case "sun":
+		s.AddDirectionalLight(params.vec3("dir", core.NewVec3(0, -1, 0)), params.emission(nil),
+			params.float("angle", lights.SunAngularDiameter))
+	case "daylight":
+		s.AddPhysicalSkyLight(params.float("turbidity", 3), params.float("elevation", 45),
+			params.float("azimuth", 0), params.float("scale", 0.03))
+	case "portal":
+		portal := geometry.NewQuad(params.vec3("corner", zero), params.vec3("u", core.NewVec3(1, 0, 0)),
+			params.vec3("v", core.NewVec3(0, 1, 0)), nil)
+		if params.err != nil {
+			return params.err
+		}
+		return s.AddPortals(portal)
+	case "sky":
+		if _, gradient := params.values["top"]; gradient {
+			s.AddGradientInfiniteLight(params.vec3("top", core.NewVec3(1, 1, 1)), params.vec3("bottom", zero))
+		} else {
+			s.AddUniformInfiniteLight(params.emission(nil))
+		}
+	default:
+		return fmt.Errorf("unknown statement %q", statement)
+	}
+
+	if _, ok := params.values["hide"]; ok {
+		hiddenFrom := params.rayKinds("hide")
+		switch statement {
+		case "sphere", "quad", "triangle", "box":
+			err = s.SetVisibility(s.Shapes[len(s.Shapes)-1], hiddenFrom)
+		case "quadlight", "spherelight":
+			err = s.SetLightVisibility(s.Lights[len(s.Lights)-1], hiddenFrom)
+		default:
+			err = fmt.Errorf("%s can't be hidden", statement)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return params.err
+}
+
+// newMicroMaterial creates a material from its type name and parameters
+func newMicroMaterial(kind string, params *microParams) (material.Material, error) {
+	switch kind {
+	case "lambertian":
+		return material.NewLambertian(params.vec3("albedo", core.NewVec3(0.7, 0.7, 0.7))), nil
+	case "metal":
+		return material.NewMetal(params.vec3("albedo", core.NewVec3(0.8, 0.8, 0.8)), params.float("fuzz", 0)), nil
+	case "dielectric":
+		glass := material.NewDispersiveDielectric(params.float("ior", 1.5), params.float("dispersion", 0))
+		glass.SigmaA = params.vec3("sigma", core.Vec3{})
+		return glass, nil
+	case "emissive":
+		return material.NewEmissive(params.vec3("emit", core.NewVec3(1, 1, 1))), nil
+	default:
+		return nil, fmt.Errorf("unknown material type %q", kind)
+	}
+}
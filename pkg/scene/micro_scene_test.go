@@ -0,0 +1,125 @@
+package scene
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestNewMicroScene(t *testing.T) {
+	custom := material.NewMix(material.NewLambertian(core.NewVec3(1, 0, 0)), material.NewMetal(core.NewVec3(1, 1, 1), 0), 0.5)
+	s, err := NewMicroScene(`
+		# A glass sphere on a floor under a quad light
+		camera at 0 1 3 look 0 0 -1 fov 30 width 64 aspect 2
+		sampling depth 7 samples 32
+		material glass dielectric ior 1.33
+		sphere at 0 0 -1 radius 0.5 material glass
+		quad corner -2 -0.5 -3 u 4 0 0 v 0 0 4   # Floor, default material
+		box at 1 0 -2 size 0.5 0.5 0.5 material custom
+		quadlight corner -0.5 2 -1.5 u 1 0 0 v 0 0 1 emit 5 5 5
+		sky top 0.5 0.7 1 bottom 1 1 1
+		sun dir -1 -2 -1 emit 3 3 3 angle 1
+		daylight turbidity 4 elevation 20
+	`, map[string]material.Material{"custom": custom})
+	if err != nil {
+		t.Fatalf("NewMicroScene failed: %v", err)
+	}
+
+	if s.CameraConfig.Center != core.NewVec3(0, 1, 3) || s.CameraConfig.VFov != 30 || s.Camera == nil {
+		t.Errorf("Camera not configured: %+v", s.CameraConfig)
+	}
+	if s.SamplingConfig.Width != 64 || s.SamplingConfig.Height != 32 || s.SamplingConfig.MaxDepth != 7 || s.SamplingConfig.SamplesPerPixel != 32 {
+		t.Errorf("Unexpected sampling config %+v", s.SamplingConfig)
+	}
+
+	// Sphere, floor, box and the light's quad; the quad light, the sky, the sun and the daylight
+	if len(s.Shapes) != 4 || len(s.Lights) != 4 {
+		t.Fatalf("Expected 4 shapes and 4 lights, got %d and %d", len(s.Shapes), len(s.Lights))
+	}
+	if sphere := s.Shapes[0].(*geometry.Sphere); sphere.Radius != 0.5 {
+		t.Errorf("Sphere radius = %v, want 0.5", sphere.Radius)
+	} else if glass, ok := sphere.Material.(*material.Dielectric); !ok || glass.RefractiveIndex != 1.33 {
+		t.Errorf("Expected the sphere to be glass with IOR 1.33, got %#v", sphere.Material)
+	}
+	if floor := s.Shapes[1].(*geometry.Quad); floor.Material.(*material.Lambertian) == nil {
+		t.Error("Expected the floor to use the default material")
+	}
+	if _, ok := s.Lights[1].(*lights.GradientInfiniteLight); !ok {
+		t.Errorf("Expected a gradient sky, got %T", s.Lights[1])
+	}
+	if _, ok := s.Lights[2].(*lights.DirectionalLight); !ok {
+		t.Errorf("Expected a sun, got %T", s.Lights[2])
+	}
+	if _, ok := s.Lights[3].(*lights.PhysicalSkyLight); !ok {
+		t.Errorf("Expected a physical sky, got %T", s.Lights[3])
+	}
+
+	// The scene renders like any other
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if _, hit := s.Intersector.Hit(core.NewRay(core.NewVec3(0, 0, 0), core.NewVec3(0, 0, -1)), 0.001, 100); !hit {
+		t.Error("Expected a ray down -Z to hit the sphere")
+	}
+}
+
+func TestNewMicroScene_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"sphere at 0 0", `line 1: parameter "at" needs 3 values`},
+		{"\nsphere at 0 0 x", `line 2: parameter "at": "x" is not a number`},
+		{"sphere centre 0 0 0", `unknown parameter "centre"`},
+		{"sphere material gold", `unknown material "gold"`},
+		{"cylinder at 0 0 0", `unknown statement "cylinder"`},
+		{"material gold plastic", `unknown material type "plastic"`},
+		{"material gold", "material needs a name and a type"},
+		{"camera width 0", "camera width and aspect must be positive"},
+		{"portal corner 0 1 0", "portals need an infinite light"},
+		{"sphere hide mirrors", `parameter "hide": unknown ray kind "mirrors"`},
+		{"quadlight hide indirect", "lights can't be hidden from indirect rays"},
+		{"sky hide camera", "sky can't be hidden"},
+	}
+	for _, test := range tests {
+		_, err := NewMicroScene(test.source, nil)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("NewMicroScene(%q) error = %v, want %q", test.source, err, test.want)
+		}
+	}
+}
+
+func TestNewMicroScene_PhysicalUnits(t *testing.T) {
+	s, err := NewMicroScene(`
+		quadlight corner 0 2 0 u 0.5 0 0 v 0 0 0.4 kelvin 2700 lumens 800
+		spherelight radius 0.1 watts 60 kelvin 2700
+		sky emit 0.5 0.5 0.5 kelvin 6500
+	`, nil)
+	if err != nil {
+		t.Fatalf("NewMicroScene failed: %v", err)
+	}
+
+	quad := s.Lights[0].(*lights.QuadLight).Material.(*material.Emissive).Emission
+	if want := 800 / (math.Pi * 0.2); math.Abs(quad.Luminance()-want) > 1e-6*want {
+		t.Errorf("Quad light radiance luminance = %f, want %f", quad.Luminance(), want)
+	}
+	if quad.X <= quad.Z {
+		t.Errorf("Expected a warm quad light, got %v", quad)
+	}
+
+	sphere := s.Lights[1].(*lights.SphereLight).Material.(*material.Emissive).Emission
+	lumens := 60 * lights.BlackbodyEfficacy(2700)
+	if want := lumens / (math.Pi * 4 * math.Pi * 0.01); math.Abs(sphere.Luminance()-want) > 0.01*want {
+		t.Errorf("Sphere light radiance luminance = %f, want %f", sphere.Luminance(), want)
+	}
+
+	sky := s.Lights[2].Emit(core.NewRay(core.Vec3{}, core.NewVec3(0, 1, 0)), nil)
+	if math.Abs(sky.Luminance()-0.5) > 1e-9 {
+		t.Errorf("Sky luminance = %f, want 0.5", sky.Luminance())
+	}
+}
@@ -0,0 +1,622 @@
+package scene
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Tessellation of the shapes pbrt-v4 doesn't have
+const (
+	exportRingSegments  = 64 // Segments around cones and tori
+	exportTubeSegments  = 32 // Segments around a torus's tube
+	exportSDFResolution = 96 // Grid cells along the longest axis of implicit surfaces
+	exportTextureSteps  = 16 // Samples along u and v when averaging a texture to a single color
+)
+
+// ExportPBRT writes the scene as a pbrt-v4 scene file, so that renders can be compared with pbrt's.
+// Quads and boxes become bilinear patches, shapes pbrt doesn't have (cones, tori, implicit surfaces) become triangle meshes,
+// and emissive shapes become diffuse area lights. Parts of the scene pbrt can't represent exactly
+// (textures, mixed materials, gradient skies, ...) are approximated or left out: the returned
+// warnings describe them, and are also written as comments at the top of the file.
+// The file loads back with loaders.LoadPBRT and NewPBRTScene.
+func ExportPBRT(s *Scene, w io.Writer) ([]string, error) {
+	e := &pbrtExporter{warned: make(map[string]bool), spotDiscs: make(map[geometry.Shape]bool)}
+
+	// Disc spot lights are exported as spot lights rather than as their discs
+	for _, light := range s.Lights {
+		if spot, ok := light.(*lights.DiscSpotLight); ok {
+			e.spotDiscs[spot.GetDisc()] = true
+		}
+	}
+
+	e.writeCamera(s)
+	e.printf("\nWorldBegin\n")
+	for _, light := range s.Lights {
+		e.writeLight(light)
+	}
+	for _, shape := range s.Shapes {
+		e.writeShape(shape)
+	}
+
+	header := "# Exported from go-progressive-raytracer\n"
+	for _, warning := range e.warnings {
+		header += "# Warning: " + warning + "\n"
+	}
+	if _, err := io.WriteString(w, header+"\n"+e.out.String()); err != nil {
+		return nil, fmt.Errorf("failed to write PBRT scene: %v", err)
+	}
+	return e.warnings, nil
+}
+
+// pbrtExporter accumulates the statements of the scene file and the warnings about it
+type pbrtExporter struct {
+	out       strings.Builder
+	warnings  []string
+	warned    map[string]bool
+	spotDiscs map[geometry.Shape]bool // Discs of disc spot lights, exported with their lights
+}
+
+func (e *pbrtExporter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&e.out, format, args...)
+}
+
+// warn records an approximation once, however many times it's made
+func (e *pbrtExporter) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	if !e.warned[warning] {
+		e.warned[warning] = true
+		e.warnings = append(e.warnings, warning)
+	}
+}
+
+// pbrtFloats formats numbers as a PBRT parameter value list, exactly enough to read back the same values
+func pbrtFloats(values ...float64) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return "[ " + strings.Join(formatted, " ") + " ]"
+}
+
+func pbrtVec3(v core.Vec3) string {
+	return pbrtFloats(v.X, v.Y, v.Z)
+}
+
+// writeCamera writes the camera, film, sampler and integrator, which precede WorldBegin
+func (e *pbrtExporter) writeCamera(s *Scene) {
+	config := s.CameraConfig
+	e.printf("LookAt %s\n       %s\n       %s\n",
+		strings.Trim(pbrtVec3(config.Center), "[ ]"), strings.Trim(pbrtVec3(config.LookAt), "[ ]"), strings.Trim(pbrtVec3(config.Up), "[ ]"))
+
+	// PBRT's field of view spans the shorter image axis
+	fov := config.VFov
+	if config.AspectRatio < 1 {
+		fov = math.Atan(math.Tan(fov*math.Pi/360)*config.AspectRatio) * 360 / math.Pi
+	}
+	e.printf("Camera \"perspective\" \"float fov\" %s\n", pbrtFloats(fov))
+	if config.Aperture > 0 {
+		focusDistance := config.FocusDistance
+		if focusDistance <= 0 {
+			focusDistance = config.Center.Subtract(config.LookAt).Length()
+		}
+		e.printf("    \"float lensradius\" %s \"float focaldistance\" %s\n", pbrtFloats(config.Aperture/2), pbrtFloats(focusDistance))
+	}
+
+	height := s.SamplingConfig.Height
+	if config.AspectRatio > 0 {
+		height = int(float64(config.Width) / config.AspectRatio)
+	}
+	e.printf("Film \"rgb\" \"integer xresolution\" [ %d ] \"integer yresolution\" [ %d ]\n", config.Width, height)
+	if filter, radius, err := s.SamplingConfig.PixelFilter(); err == nil {
+		switch filter {
+		case "tent":
+			filter = "triangle"
+		case "blackman-harris":
+			e.warn("PBRT has no Blackman-Harris pixel filter; exported a Gaussian")
+			filter = "gaussian"
+		}
+		e.printf("PixelFilter \"%s\" \"float xradius\" %s \"float yradius\" %s\n", filter, pbrtFloats(radius), pbrtFloats(radius))
+	}
+	if s.SamplingConfig.SamplesPerPixel > 0 {
+		e.printf("Sampler \"zsobol\" \"integer pixelsamples\" [ %d ]\n", s.SamplingConfig.SamplesPerPixel)
+	}
+	if s.SamplingConfig.MaxDepth > 0 {
+		e.printf("Integrator \"path\" \"integer maxdepth\" [ %d ]\n", s.SamplingConfig.MaxDepth)
+	}
+}
+
+// writeLight writes the lights that aren't emissive shapes: those are written with their shapes
+func (e *pbrtExporter) writeLight(light lights.Light) {
+	switch l := light.(type) {
+	case *lights.PointSpotLight:
+		if l.Profile() != nil {
+			e.warn("spot lights' IES profiles aren't exported")
+		}
+		coneAngle, coneDelta := l.ConeAngles()
+		e.writeSpotLight(l.Position(), l.Direction(), l.Emission(), coneAngle, coneDelta)
+
+	case *lights.DiscSpotLight:
+		// The disc's on-axis intensity is its radiance times its area
+		e.warn("disc spot lights are exported as point spot lights")
+		disc := l.GetDisc()
+		area := math.Pi * (disc.Radius*disc.Radius - disc.InnerRadius*disc.InnerRadius)
+		coneAngle, coneDelta := l.ConeAngles()
+		e.writeSpotLight(l.Position(), l.Direction(), l.Emission().Multiply(area), coneAngle, coneDelta)
+
+	case *lights.DirectionalLight:
+		// PBRT's distant light is a delta light, so its shadows are hard
+		e.printf("LightSource \"distant\" \"point3 from\" %s \"point3 to\" %s \"rgb L\" %s\n",
+			pbrtVec3(core.Vec3{}), pbrtVec3(l.Direction()), pbrtVec3(l.Irradiance()))
+
+	case *lights.PortalLight:
+		portals := l.Portals()
+		if len(portals) > 1 {
+			e.warn("only the first of a portal light's %d portals is exported", len(portals))
+		}
+		radiance, ok := e.environmentRadiance(l.Environment())
+		if !ok {
+			return
+		}
+		portal := portals[0]
+		corners := []core.Vec3{portal.Corner, portal.Corner.Add(portal.U), portal.Corner.Add(portal.U).Add(portal.V), portal.Corner.Add(portal.V)}
+		values := make([]float64, 0, 12)
+		for _, corner := range corners {
+			values = append(values, corner.X, corner.Y, corner.Z)
+		}
+		e.printf("LightSource \"infinite\" \"rgb L\" %s\n    \"point3 portal\" %s\n", pbrtVec3(radiance), pbrtFloats(values...))
+
+	case *lights.QuadLight, *lights.SphereLight, *lights.DiscLight, *lights.CylinderLight, *lights.ShapeLight:
+		// Area lights are written as their emissive shapes
+
+	default:
+		if radiance, ok := e.environmentRadiance(light); ok {
+			e.printf("LightSource \"infinite\" \"rgb L\" %s\n", pbrtVec3(radiance))
+		}
+	}
+}
+
+// environmentRadiance returns the radiance of an infinite light for a uniform PBRT infinite light,
+// or false (with a warning) for lights that can't be exported
+func (e *pbrtExporter) environmentRadiance(light lights.Light) (core.Vec3, bool) {
+	switch l := light.(type) {
+	case *lights.UniformInfiniteLight:
+		return l.Emission(), true
+	case *lights.GradientInfiniteLight:
+		// The gradient is linear in the direction's height, so its average over the sphere is the middle color
+		e.warn("gradient skies are exported as their average color")
+		top, bottom := l.Colors()
+		return top.Add(bottom).Multiply(0.5), true
+	default:
+		e.warn("%s lights aren't exported", typeName(light))
+		return core.Vec3{}, false
+	}
+}
+
+func (e *pbrtExporter) writeSpotLight(position, direction, intensity core.Vec3, coneAngle, coneDelta float64) {
+	e.printf("LightSource \"spot\" \"point3 from\" %s \"point3 to\" %s \"rgb I\" %s\n    \"float coneangle\" %s \"float conedelta\" %s\n",
+		pbrtVec3(position), pbrtVec3(position.Add(direction)), pbrtVec3(intensity), pbrtFloats(coneAngle), pbrtFloats(coneDelta))
+}
+
+// writeShape writes a shape in its own attribute block with its material, or area light for
+// emissive shapes
+func (e *pbrtExporter) writeShape(shape geometry.Shape) {
+	if comparableShape(shape) && e.spotDiscs[shape] {
+		return
+	}
+
+	switch s := shape.(type) {
+	case *geometry.VisibilityShape:
+		e.warn("shapes' visibility flags aren't exported")
+		e.writeShape(s.Shape)
+
+	case *geometry.Translated:
+		// The shape's own blocks nest in one that moves them
+		e.printf("\nAttributeBegin\n    Translate %s\n", strings.Trim(pbrtVec3(s.Offset), "[ ]"))
+		e.writeShape(s.Shape)
+		e.printf("AttributeEnd\n")
+
+	case *geometry.Sphere:
+		e.writeBlock(s.Material, func() { e.writeSphere(s.Center, s.Radius) })
+
+	case *geometry.Disc:
+		// Disks face +z; Right and Up span the disc's plane
+		frame := frameMatrix(s.Center, s.Right, s.Normal)
+		e.writeBlock(s.Material, func() { e.writeDisk(frame, s.Radius, s.InnerRadius) })
+
+	case *geometry.Cylinder:
+		// PBRT's cylinders are open, so caps are disks of their own
+		axis := s.TopCenter.Subtract(s.BaseCenter)
+		e.writeBlock(s.Material, func() { e.writeCylinder(s.BaseCenter, axis, s.Radius) })
+		if s.Capped {
+			e.writeBlock(s.Material, func() { e.writeDisk(frameMatrix(s.TopCenter, perpendicular(axis), axis), s.Radius, 0) })
+			e.writeBlock(s.Material, func() { e.writeDisk(frameMatrix(s.BaseCenter, perpendicular(axis), axis.Multiply(-1)), s.Radius, 0) })
+		}
+
+	case *geometry.Capsule:
+		// An open cylinder between two spheres
+		e.writeBlock(s.Material, func() { e.writeCylinder(s.Start, s.End.Subtract(s.Start), s.Radius) })
+		e.writeBlock(s.Material, func() { e.writeSphere(s.Start, s.Radius) })
+		e.writeBlock(s.Material, func() { e.writeSphere(s.End, s.Radius) })
+
+	case *geometry.Quad:
+		e.writeBlock(s.Material, func() { e.writeQuad(s) })
+
+	case *geometry.Box:
+		e.writeBlock(s.Material, func() {
+			for _, face := range s.Faces() {
+				e.writeQuad(face)
+			}
+		})
+
+	case *geometry.Triangle:
+		e.writeTriangles([]*geometry.Triangle{s})
+
+	case *geometry.TriangleMesh:
+		shapes := s.GetTriangles()
+		triangles := make([]*geometry.Triangle, 0, len(shapes))
+		for _, shape := range shapes {
+			if triangle, ok := shape.(*geometry.Triangle); ok {
+				triangles = append(triangles, triangle)
+			}
+		}
+		e.writeTriangles(triangles)
+
+	case *geometry.Cone:
+		e.writeTriangles(coneTriangles(s))
+		if s.Capped {
+			axis := s.TopCenter.Subtract(s.BaseCenter)
+			e.writeBlock(s.Material, func() {
+				e.writeDisk(frameMatrix(s.BaseCenter, perpendicular(axis), axis.Multiply(-1)), s.BaseRadius, 0)
+			})
+			if s.TopRadius > 0 {
+				e.writeBlock(s.Material, func() { e.writeDisk(frameMatrix(s.TopCenter, perpendicular(axis), axis), s.TopRadius, 0) })
+			}
+		}
+
+	case *geometry.Torus:
+		e.writeTriangles(torusTriangles(s))
+
+	case *geometry.ImplicitSurface:
+		vertices, faces := geometry.PolygonizeSDF(implicitField{s}, exportSDFResolution)
+		triangles := make([]*geometry.Triangle, 0, len(faces)/3)
+		for i := 0; i+2 < len(faces); i += 3 {
+			triangles = append(triangles, geometry.NewTriangle(vertices[faces[i]], vertices[faces[i+1]], vertices[faces[i+2]], s.Material))
+		}
+		e.writeTriangles(triangles)
+
+	default:
+		e.warn("%s shapes aren't exported", typeName(shape))
+	}
+}
+
+// writeBlock writes an attribute block with the material's statements followed by the shape written by body
+func (e *pbrtExporter) writeBlock(mat material.Material, body func()) {
+	e.printf("\nAttributeBegin\n")
+	if emissive, ok := mat.(*material.Emissive); ok {
+		// PBRT's diffuse area light emits from the front face only, like ours
+		emission := emissive.Emission
+		if emissive.Texture != nil {
+			emission = emission.MultiplyVec(e.averageColor(emissive.Texture))
+		}
+		e.printf("    AreaLightSource \"diffuse\" \"rgb L\" %s\n", pbrtVec3(emission))
+		e.printf("    Material \"diffuse\" \"rgb reflectance\" %s\n", pbrtVec3(core.Vec3{}))
+	} else {
+		e.printf("    Material %s\n", e.materialParams(mat))
+	}
+	body()
+	e.printf("AttributeEnd\n")
+}
+
+// materialParams returns a PBRT material's type and parameters
+func (e *pbrtExporter) materialParams(mat material.Material) string {
+	switch m := mat.(type) {
+	case *material.Lambertian:
+		return fmt.Sprintf("\"diffuse\" \"rgb reflectance\" %s", pbrtVec3(e.averageColor(m.Albedo)))
+
+	case *material.Metal:
+		return fmt.Sprintf("\"conductor\" \"rgb reflectance\" %s \"float roughness\" %s", pbrtVec3(e.averageColor(m.Albedo)), pbrtFloats(e.metalRoughness(m)))
+
+	case *material.Dielectric:
+		if !m.SigmaA.IsZero() {
+			e.warn("dielectric absorption is not exported (PBRT gives glass an interior medium instead)")
+		}
+		if m.Glass != "" {
+			return fmt.Sprintf("\"dielectric\" \"spectrum eta\" \"glass-%s\"", m.Glass)
+		}
+		if m.Dispersive() {
+			e.warn("dispersive dielectrics other than catalog glasses are exported without dispersion")
+		}
+		return fmt.Sprintf("\"dielectric\" \"float eta\" %s", pbrtFloats(m.RefractiveIndex))
+
+	case *material.Hair:
+		return fmt.Sprintf("\"hair\" \"rgb sigma_a\" %s \"float eta\" %s \"float beta_m\" %s \"float beta_n\" %s \"float alpha\" %s",
+			pbrtVec3(m.SigmaA), pbrtFloats(m.Eta), pbrtFloats(m.BetaM), pbrtFloats(m.BetaN), pbrtFloats(m.Alpha))
+
+	case *material.Layered:
+		// A clear coat over a diffuse or metal base is one of PBRT's coated materials
+		if coat, ok := m.Outer.(*material.Dielectric); ok {
+			switch inner := m.Inner.(type) {
+			case *material.Lambertian:
+				return fmt.Sprintf("\"coateddiffuse\" \"rgb reflectance\" %s \"float roughness\" [ 0 ] \"float eta\" %s",
+					pbrtVec3(e.averageColor(inner.Albedo)), pbrtFloats(coat.RefractiveIndex))
+			case *material.Metal:
+				return fmt.Sprintf("\"coatedconductor\" \"rgb reflectance\" %s \"float conductor.roughness\" %s \"float interface.roughness\" [ 0 ] \"float interface.eta\" %s",
+					pbrtVec3(e.averageColor(inner.Albedo)), pbrtFloats(e.metalRoughness(inner)), pbrtFloats(coat.RefractiveIndex))
+			}
+		}
+		e.warn("layered materials other than a dielectric over a diffuse or metal base are exported as their inner layer")
+		return e.materialParams(m.Inner)
+
+	case *material.Mix:
+		e.warn("mixed materials are exported as their dominant material")
+		ratio := m.Ratio
+		if m.RatioTexture != nil {
+			ratio = e.averageColor(m.RatioTexture).Luminance()
+		}
+		if ratio > 0.5 {
+			return e.materialParams(m.Material2)
+		}
+		return e.materialParams(m.Material1)
+
+	case *material.Cutout:
+		e.warn("cutout materials are exported without their alpha masks")
+		return e.materialParams(m.Base)
+
+	default:
+		e.warn("%s materials are exported as gray diffuse", typeName(mat))
+		return fmt.Sprintf("\"diffuse\" \"rgb reflectance\" %s", pbrtVec3(core.NewVec3(0.5, 0.5, 0.5)))
+	}
+}
+
+// metalRoughness returns a metal's fuzziness, averaged over its texture if it has one
+func (e *pbrtExporter) metalRoughness(m *material.Metal) float64 {
+	if m.FuzzTexture == nil {
+		return m.Fuzzness
+	}
+	return e.averageColor(m.FuzzTexture).Luminance()
+}
+
+// averageColor returns a solid color's value, or a texture's average over the unit UV square
+func (e *pbrtExporter) averageColor(source material.ColorSource) core.Vec3 {
+	if solid, ok := source.(*material.SolidColor); ok {
+		return solid.Color
+	}
+	e.warn("textures are exported as their average color")
+	var sum core.Vec3
+	for i := 0; i < exportTextureSteps; i++ {
+		for j := 0; j < exportTextureSteps; j++ {
+			uv := core.NewVec2((float64(i)+0.5)/exportTextureSteps, (float64(j)+0.5)/exportTextureSteps)
+			sum = sum.Add(source.Evaluate(uv, core.Vec3{}))
+		}
+	}
+	return sum.Multiply(1.0 / (exportTextureSteps * exportTextureSteps))
+}
+
+// writeSphere writes a sphere; negative radii turn spheres inside out, as ReverseOrientation does
+func (e *pbrtExporter) writeSphere(center core.Vec3, radius float64) {
+	e.printf("    Translate %s\n", strings.Trim(pbrtVec3(center), "[ ]"))
+	if radius < 0 {
+		e.printf("    ReverseOrientation\n")
+		radius = -radius
+	}
+	e.printf("    Shape \"sphere\" \"float radius\" %s\n", pbrtFloats(radius))
+}
+
+// writeQuad writes a quad as a PBRT bilinear patch, which has the same UV coordinates and faces
+// the same side
+func (e *pbrtExporter) writeQuad(q *geometry.Quad) {
+	p00, p10, p01 := q.Corner, q.Corner.Add(q.U), q.Corner.Add(q.V)
+	p11 := p10.Add(q.V)
+	e.printf("    Shape \"bilinearmesh\" \"point3 P\" %s \"integer indices\" [ 0 1 2 3 ]\n",
+		pbrtFloats(p00.X, p00.Y, p00.Z, p10.X, p10.Y, p10.Z, p01.X, p01.Y, p01.Z, p11.X, p11.Y, p11.Z))
+}
+
+// writeDisk writes a PBRT disk, which lies in the xy plane of frame facing +z
+func (e *pbrtExporter) writeDisk(frame core.Matrix4, radius, innerRadius float64) {
+	e.printf("    ConcatTransform %s\n", columnMajor(frame))
+	e.printf("    Shape \"disk\" \"float radius\" %s \"float innerradius\" %s\n", pbrtFloats(radius), pbrtFloats(innerRadius))
+}
+
+// writeCylinder writes an open PBRT cylinder from base along axis
+func (e *pbrtExporter) writeCylinder(base, axis core.Vec3, radius float64) {
+	e.printf("    ConcatTransform %s\n", columnMajor(frameMatrix(base, perpendicular(axis), axis)))
+	e.printf("    Shape \"cylinder\" \"float radius\" %s \"float zmin\" [ 0 ] \"float zmax\" %s\n", pbrtFloats(radius), pbrtFloats(axis.Length()))
+}
+
+// writeTriangles writes triangles as one PBRT triangle mesh per material, sharing identical vertices
+func (e *pbrtExporter) writeTriangles(triangles []*geometry.Triangle) {
+	type vertex struct {
+		position, normal core.Vec3
+		uv               core.Vec2
+	}
+	type mesh struct {
+		vertices           []vertex
+		indices            []int
+		lookup             map[vertex]int
+		hasUVs, hasNormals bool
+	}
+
+	var materials []material.Material
+	meshes := make(map[material.Material]*mesh)
+	for _, triangle := range triangles {
+		m, ok := meshes[triangle.Material]
+		if !ok {
+			m = &mesh{lookup: make(map[vertex]int), hasUVs: true, hasNormals: true}
+			meshes[triangle.Material] = m
+			materials = append(materials, triangle.Material)
+		}
+		m.hasUVs = m.hasUVs && triangle.HasUVs()
+		m.hasNormals = m.hasNormals && triangle.HasVertexNormals()
+
+		corners := []vertex{
+			{triangle.V0, triangle.N0, triangle.UV0},
+			{triangle.V1, triangle.N1, triangle.UV1},
+			{triangle.V2, triangle.N2, triangle.UV2},
+		}
+		// PBRT's triangles face the side their vertices wind counter-clockwise around, like ours
+		// by default; triangles given another normal are wound to face it
+		winding := triangle.V1.Subtract(triangle.V0).Cross(triangle.V2.Subtract(triangle.V0))
+		if winding.Dot(triangle.GetNormal()) < 0 {
+			corners[1], corners[2] = corners[2], corners[1]
+		}
+		for _, corner := range corners {
+			index, ok := m.lookup[corner]
+			if !ok {
+				index = len(m.vertices)
+				m.lookup[corner] = index
+				m.vertices = append(m.vertices, corner)
+			}
+			m.indices = append(m.indices, index)
+		}
+	}
+
+	for _, mat := range materials {
+		m := meshes[mat]
+		e.writeBlock(mat, func() {
+			positions := make([]float64, 0, 3*len(m.vertices))
+			normals := make([]float64, 0, 3*len(m.vertices))
+			uvs := make([]float64, 0, 2*len(m.vertices))
+			for _, v := range m.vertices {
+				positions = append(positions, v.position.X, v.position.Y, v.position.Z)
+				normals = append(normals, v.normal.X, v.normal.Y, v.normal.Z)
+				uvs = append(uvs, v.uv.X, v.uv.Y)
+			}
+			indices := make([]string, len(m.indices))
+			for i, index := range m.indices {
+				indices[i] = strconv.Itoa(index)
+			}
+
+			e.printf("    Shape \"trianglemesh\"\n")
+			e.printf("        \"integer indices\" [ %s ]\n", strings.Join(indices, " "))
+			e.printf("        \"point3 P\" %s\n", pbrtFloats(positions...))
+			if m.hasNormals {
+				e.printf("        \"normal N\" %s\n", pbrtFloats(normals...))
+			}
+			if m.hasUVs {
+				e.printf("        \"point2 uv\" %s\n", pbrtFloats(uvs...))
+			}
+		})
+	}
+}
+
+// coneTriangles tessellates a cone's side into smooth shaded triangles
+func coneTriangles(c *geometry.Cone) []*geometry.Triangle {
+	axis := c.TopCenter.Subtract(c.BaseCenter)
+	height := axis.Length()
+	axis = axis.Normalize()
+	x := perpendicular(axis)
+	y := axis.Cross(x)
+	// The side's normal leans towards the axis by the cone's slope
+	slope := (c.BaseRadius - c.TopRadius) / height
+
+	ring := func(i int) (direction, normal core.Vec3) {
+		angle := 2 * math.Pi * float64(i) / exportRingSegments
+		direction = x.Multiply(math.Cos(angle)).Add(y.Multiply(math.Sin(angle)))
+		return direction, direction.Add(axis.Multiply(slope)).Normalize()
+	}
+
+	triangles := make([]*geometry.Triangle, 0, 2*exportRingSegments)
+	for i := 0; i < exportRingSegments; i++ {
+		d0, n0 := ring(i)
+		d1, n1 := ring(i + 1)
+		b0, b1 := c.BaseCenter.Add(d0.Multiply(c.BaseRadius)), c.BaseCenter.Add(d1.Multiply(c.BaseRadius))
+		t0, t1 := c.TopCenter.Add(d0.Multiply(c.TopRadius)), c.TopCenter.Add(d1.Multiply(c.TopRadius))
+
+		bottom := geometry.NewTriangle(b0, b1, t1, c.Material)
+		bottom.SetVertexNormals(n0, n1, n1)
+		triangles = append(triangles, bottom)
+		if c.TopRadius > 0 {
+			top := geometry.NewTriangle(b0, t1, t0, c.Material)
+			top.SetVertexNormals(n0, n1, n0)
+			triangles = append(triangles, top)
+		}
+	}
+	return triangles
+}
+
+// torusTriangles tessellates a torus into smooth shaded triangles
+func torusTriangles(t *geometry.Torus) []*geometry.Triangle {
+	axis := t.Axis.Normalize()
+	x := perpendicular(axis)
+	y := axis.Cross(x)
+
+	point := func(i, j int) (position, normal core.Vec3) {
+		ringAngle := 2 * math.Pi * float64(i) / exportRingSegments
+		tubeAngle := 2 * math.Pi * float64(j) / exportTubeSegments
+		outward := x.Multiply(math.Cos(ringAngle)).Add(y.Multiply(math.Sin(ringAngle)))
+		normal = outward.Multiply(math.Cos(tubeAngle)).Add(axis.Multiply(math.Sin(tubeAngle)))
+		position = t.Center.Add(outward.Multiply(t.MajorRadius)).Add(normal.Multiply(t.MinorRadius))
+		return position, normal
+	}
+
+	triangles := make([]*geometry.Triangle, 0, 2*exportRingSegments*exportTubeSegments)
+	for i := 0; i < exportRingSegments; i++ {
+		for j := 0; j < exportTubeSegments; j++ {
+			p00, n00 := point(i, j)
+			p10, n10 := point(i+1, j)
+			p11, n11 := point(i+1, j+1)
+			p01, n01 := point(i, j+1)
+
+			first := geometry.NewTriangle(p00, p10, p11, t.Material)
+			first.SetVertexNormals(n00, n10, n11)
+			second := geometry.NewTriangle(p00, p11, p01, t.Material)
+			second.SetVertexNormals(n00, n11, n01)
+			triangles = append(triangles, first, second)
+		}
+	}
+	return triangles
+}
+
+// implicitField adapts an implicit surface to the SignedDistanceField interface for tessellation
+type implicitField struct {
+	surface *geometry.ImplicitSurface
+}
+
+func (f implicitField) SignedDistance(point core.Vec3) float64 {
+	return f.surface.Distance(point)
+}
+
+func (f implicitField) BoundingBox() geometry.AABB {
+	return f.surface.Bounds
+}
+
+// perpendicular returns a unit vector perpendicular to v
+func perpendicular(v core.Vec3) core.Vec3 {
+	v = v.Normalize()
+	other := core.NewVec3(1, 0, 0)
+	if math.Abs(v.X) > 0.9 {
+		other = core.NewVec3(0, 1, 0)
+	}
+	return other.Subtract(v.Multiply(other.Dot(v))).Normalize()
+}
+
+// frameMatrix returns the right-handed transformation placing the origin at origin, the z axis
+// along z and the x axis along x (made perpendicular to z), with unit scale
+func frameMatrix(origin, x, z core.Vec3) core.Matrix4 {
+	z = z.Normalize()
+	x = x.Subtract(z.Multiply(x.Dot(z))).Normalize()
+	y := z.Cross(x)
+	return core.Matrix4{
+		{x.X, y.X, z.X, origin.X},
+		{x.Y, y.Y, z.Y, origin.Y},
+		{x.Z, y.Z, z.Z, origin.Z},
+		{0, 0, 0, 1},
+	}
+}
+
+// columnMajor formats a matrix as the 16 values of a PBRT Transform or ConcatTransform
+func columnMajor(m core.Matrix4) string {
+	values := make([]float64, 16)
+	for i := range values {
+		values[i] = m[i%4][i/4]
+	}
+	return pbrtFloats(values...)
+}
@@ -0,0 +1,250 @@
+package scene
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// roundTripPBRT exports a scene and loads the file back
+func roundTripPBRT(t *testing.T, s *Scene) (*Scene, string, []string) {
+	t.Helper()
+	var out strings.Builder
+	warnings, err := ExportPBRT(s, &out)
+	if err != nil {
+		t.Fatalf("ExportPBRT() error = %v", err)
+	}
+	pbrtScene, err := loaders.ParsePBRT(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatalf("ParsePBRT() error = %v\n%s", err, out.String())
+	}
+	loaded, err := NewPBRTScene(pbrtScene)
+	if err != nil {
+		t.Fatalf("NewPBRTScene() error = %v\n%s", err, out.String())
+	}
+	return loaded, out.String(), warnings
+}
+
+func TestExportPBRT_RoundTrip(t *testing.T) {
+	s := &Scene{
+		CameraConfig: geometry.CameraConfig{
+			Center: core.NewVec3(0, 1, 5), LookAt: core.NewVec3(0, 1, 0), Up: core.NewVec3(0, 1, 0),
+			Width: 300, AspectRatio: 0.75, VFov: 40, Aperture: 0.1, FocusDistance: 4,
+		},
+		SamplingConfig: SamplingConfig{SamplesPerPixel: 64, MaxDepth: 8},
+	}
+	s.Shapes = append(s.Shapes,
+		geometry.NewSphere(core.NewVec3(1, 2, 3), 0.5, material.NewLambertian(core.NewVec3(0.8, 0.2, 0.1))),
+		geometry.NewSphere(core.NewVec3(1, 2, 3), -0.4, material.NewDielectric(1.5)), // A bubble inside the sphere
+		geometry.NewQuad(core.NewVec3(-1, 0, -1), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), material.NewMetal(core.NewVec3(0.9, 0.9, 0.9), 0.1)),
+		geometry.NewCylinder(core.NewVec3(2, 0, 0), core.NewVec3(2, 1, 0), 0.25, true, material.NewDielectric(1.5)),
+		geometry.NewBox(core.NewVec3(0, 0.5, 0), core.NewVec3(0.5, 0.5, 0.5), core.NewVec3(0, 0.3, 0),
+			material.NewLayered(material.NewDielectric(1.4), material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))),
+	)
+	s.AddDiscLight(core.NewVec3(0, 3, 0), core.NewVec3(0, -1, 0), 0.2, 0.5, core.NewVec3(5, 5, 5))
+	s.Lights = append(s.Lights,
+		lights.NewPointSpotLight(core.NewVec3(0, 4, 0), core.NewVec3(0, 0, 0), core.NewVec3(10, 10, 10), 25, 5),
+		lights.NewUniformInfiniteLight(core.NewVec3(0.1, 0.2, 0.3)),
+	)
+
+	loaded, _, warnings := roundTripPBRT(t, s)
+	if len(warnings) != 0 {
+		t.Errorf("Expected an exact export, got warnings %v", warnings)
+	}
+
+	// The camera, including the portrait image's field of view
+	config := loaded.CameraConfig
+	if !config.Center.Equals(s.CameraConfig.Center) || !config.LookAt.Equals(s.CameraConfig.LookAt) ||
+		math.Abs(config.VFov-40) > 1e-9 || config.Width != 300 || loaded.SamplingConfig.Height != 400 ||
+		math.Abs(config.Aperture-0.1) > 1e-12 || config.FocusDistance != 4 {
+		t.Errorf("Camera didn't round trip: %+v", config)
+	}
+
+	// Cylinder caps and box faces are shapes of their own
+	counts := make(map[string]int)
+	for _, shape := range loaded.Shapes {
+		counts[typeName(shape)]++
+	}
+	want := map[string]int{"Sphere": 2, "Quad": 7, "Cylinder": 1, "Disc": 3}
+	for shapeType, count := range want {
+		if counts[shapeType] != count {
+			t.Errorf("Expected %d %s shapes, got %d (%v)", count, shapeType, counts[shapeType], counts)
+		}
+	}
+
+	sphere := loaded.Shapes[0].(*geometry.Sphere)
+	if !sphere.Center.Equals(core.NewVec3(1, 2, 3)) || sphere.Radius != 0.5 {
+		t.Errorf("Expected the sphere at (1,2,3) of radius 0.5, got %v and %v", sphere.Center, sphere.Radius)
+	}
+	if albedo := sphere.Material.(*material.Lambertian).Albedo.Evaluate(core.Vec2{}, core.Vec3{}); !albedo.Equals(core.NewVec3(0.8, 0.2, 0.1)) {
+		t.Errorf("Expected the sphere's albedo to round trip, got %v", albedo)
+	}
+	if bubble := loaded.Shapes[1].(*geometry.Sphere); bubble.Radius != -0.4 {
+		t.Errorf("Expected the bubble's negative radius to round trip, got %v", bubble.Radius)
+	}
+	cylinder := loaded.Shapes[3].(*geometry.Cylinder)
+	if !cylinder.BaseCenter.Equals(core.NewVec3(2, 0, 0)) || !cylinder.TopCenter.Equals(core.NewVec3(2, 1, 0)) || math.Abs(cylinder.Radius-0.25) > 1e-9 {
+		t.Errorf("Expected the cylinder from (2,0,0) to (2,1,0), got %v to %v", cylinder.BaseCenter, cylinder.TopCenter)
+	}
+	if _, ok := loaded.Shapes[len(loaded.Shapes)-2].(*geometry.Quad).Material.(*material.Layered); !ok {
+		t.Error("Expected the box's coated material to load back as a layered material")
+	}
+
+	// The disc light faces down with its hole
+	var discLight *lights.DiscLight
+	for _, light := range loaded.Lights {
+		if l, ok := light.(*lights.DiscLight); ok {
+			discLight = l
+		}
+	}
+	if discLight == nil {
+		t.Fatalf("Expected the disc light to load back, got %v", loaded.Lights)
+	}
+	if !discLight.Center.Equals(core.NewVec3(0, 3, 0)) || !discLight.Normal.Equals(core.NewVec3(0, -1, 0)) ||
+		math.Abs(discLight.InnerRadius-0.2) > 1e-9 || math.Abs(discLight.Radius-0.5) > 1e-9 {
+		t.Errorf("Disc light didn't round trip: center %v, normal %v, radii %v and %v",
+			discLight.Center, discLight.Normal, discLight.InnerRadius, discLight.Radius)
+	}
+
+	spot, ok := loaded.Lights[0].(*lights.PointSpotLight)
+	if !ok {
+		t.Fatalf("Expected the spot light first, got %T", loaded.Lights[0])
+	}
+	coneAngle, coneDelta := spot.ConeAngles()
+	if !spot.Position().Equals(core.NewVec3(0, 4, 0)) || !spot.Direction().Equals(core.NewVec3(0, -1, 0)) ||
+		math.Abs(coneAngle-25) > 1e-9 || math.Abs(coneDelta-5) > 1e-9 {
+		t.Errorf("Spot light didn't round trip: %v, %v, %v, %v", spot.Position(), spot.Direction(), coneAngle, coneDelta)
+	}
+	if sky, ok := loaded.Lights[1].(*lights.UniformInfiniteLight); !ok || !sky.Emission().Equals(core.NewVec3(0.1, 0.2, 0.3)) {
+		t.Errorf("Expected the uniform sky to round trip, got %v", loaded.Lights[1])
+	}
+}
+
+func TestExportPBRT_Tessellation(t *testing.T) {
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	cone, err := geometry.NewCone(core.NewVec3(0, 0, 0), 1, core.NewVec3(0, 2, 0), 0.5, true, mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torus, err := geometry.NewTorus(core.NewVec3(0, 0, 0), core.NewVec3(0, 0, 1), 1, 0.25, mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Scene{CameraConfig: geometry.CameraConfig{Center: core.NewVec3(0, 0, 5), Up: core.NewVec3(0, 1, 0), Width: 100, AspectRatio: 1, VFov: 40}}
+
+	// Rays hit the tessellations where they hit the shapes, to within the tessellation error
+	tests := []struct {
+		shape geometry.Shape
+		rays  []core.Ray
+	}{
+		{cone, []core.Ray{
+			core.NewRay(core.NewVec3(5, 0.5, 0), core.NewVec3(-1, 0, 0)),   // The side
+			core.NewRay(core.NewVec3(0.2, -5, 0), core.NewVec3(0, 1, 0)),   // The base cap
+			core.NewRay(core.NewVec3(0.1, 5, 0.1), core.NewVec3(0, -1, 0)), // The top cap
+		}},
+		{torus, []core.Ray{
+			core.NewRay(core.NewVec3(1, 0, 5), core.NewVec3(0, 0, -1)), // The top of the tube
+			core.NewRay(core.NewVec3(0, 5, 0), core.NewVec3(0, -1, 0)), // Across the ring through the tube
+			core.NewRay(core.NewVec3(0, 0, 5), core.NewVec3(0, 0, -1)), // Through the hole
+		}},
+		{geometry.NewTranslated(cone, core.NewVec3(3, 0, 0)), []core.Ray{
+			core.NewRay(core.NewVec3(8, 0.5, 0), core.NewVec3(-1, 0, 0)), // The moved side
+			core.NewRay(core.NewVec3(0, -5, 0), core.NewVec3(0, 1, 0)),   // Where it was
+		}},
+	}
+	for _, tt := range tests {
+		s.Shapes = []geometry.Shape{tt.shape}
+		loaded, _, _ := roundTripPBRT(t, s)
+		if err := loaded.Preprocess(); err != nil {
+			t.Fatal(err)
+		}
+		for _, ray := range tt.rays {
+			want, wantHit := tt.shape.Hit(ray, 0.001, math.Inf(1))
+			got, gotHit := loaded.Intersector.Hit(ray, 0.001, math.Inf(1))
+			if wantHit != gotHit {
+				t.Errorf("%T, ray %v: expected hit %v, got %v", tt.shape, ray, wantHit, gotHit)
+				continue
+			}
+			if wantHit && (math.Abs(got.T-want.T) > 0.01 || got.Normal.Dot(want.Normal) < 0.99) {
+				t.Errorf("%T, ray %v: expected t %v and normal %v, got %v and %v", tt.shape, ray, want.T, want.Normal, got.T, got.Normal)
+			}
+		}
+	}
+}
+
+func TestExportPBRT_Warnings(t *testing.T) {
+	lambertian := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	checker := material.NewUVMappedTexture(material.NewCheckerTexture(material.NewSolidColor(core.NewVec3(1, 1, 1)),
+		material.NewSolidColor(core.NewVec3(0, 0, 0))), core.NewVec2(4, 4), core.Vec2{})
+	s := &Scene{CameraConfig: geometry.CameraConfig{Center: core.NewVec3(0, 0, 5), Up: core.NewVec3(0, 1, 0), Width: 100, AspectRatio: 1, VFov: 40}}
+	s.Shapes = []geometry.Shape{
+		geometry.NewSphere(core.Vec3{}, 1, material.NewMix(lambertian, material.NewDielectric(1.5), 0.8)),
+		geometry.NewSphere(core.Vec3{}, 2, material.NewTexturedLambertian(checker)),
+		geometry.NewSphere(core.Vec3{}, 3, lambertian),
+	}
+	s.Lights = []lights.Light{lights.NewGradientInfiniteLight(core.NewVec3(0, 0, 1), core.NewVec3(1, 1, 1))}
+
+	loaded, file, warnings := roundTripPBRT(t, s)
+	for _, want := range []string{"mixed materials", "textures", "gradient skies"} {
+		found := false
+		for _, warning := range warnings {
+			found = found || strings.Contains(warning, want)
+		}
+		if !found {
+			t.Errorf("Expected a warning about %s, got %v", want, warnings)
+		}
+		if !strings.Contains(file, "# Warning: ") {
+			t.Error("Expected the warnings in the file's comments")
+		}
+	}
+
+	// The mix exports its dominant material, the checkerboard its average and the gradient its middle color
+	if _, ok := loaded.Shapes[0].(*geometry.Sphere).Material.(*material.Dielectric); !ok {
+		t.Errorf("Expected the mostly dielectric mix to export as dielectric, got %T", loaded.Shapes[0].(*geometry.Sphere).Material)
+	}
+	albedo := loaded.Shapes[1].(*geometry.Sphere).Material.(*material.Lambertian).Albedo.Evaluate(core.Vec2{}, core.Vec3{})
+	if !albedo.Equals(core.NewVec3(0.5, 0.5, 0.5)) {
+		t.Errorf("Expected the checkerboard's average gray, got %v", albedo)
+	}
+	if sky := loaded.Lights[0].(*lights.UniformInfiniteLight); !sky.Emission().Equals(core.NewVec3(0.5, 0.5, 1)) {
+		t.Errorf("Expected the gradient's middle color, got %v", sky.Emission())
+	}
+}
+
+func TestExportPBRT_IncomparableShape(t *testing.T) {
+	// A shape of a value type holding a slice can't be looked up among the spot lights' discs
+	s := &Scene{CameraConfig: geometry.CameraConfig{Center: core.NewVec3(0, 0, 5), Up: core.NewVec3(0, 1, 0), Width: 100, AspectRatio: 1, VFov: 40}}
+	quad := geometry.NewQuad(core.NewVec3(-1, -1, 0), core.NewVec3(2, 0, 0), core.NewVec3(0, 2, 0), material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5)))
+	s.Shapes = []geometry.Shape{taggedQuad{quad, []string{"card"}}}
+	var out strings.Builder
+	if _, err := ExportPBRT(s, &out); err != nil {
+		t.Fatalf("ExportPBRT() error = %v", err)
+	}
+}
+
+func TestExportPBRT_BuiltInScenes(t *testing.T) {
+	scenes := map[string]*Scene{
+		"default":       NewDefaultScene(),
+		"cornell":       NewCornellScene(CornellSpheres, CornellQuadLight),
+		"cornell-boxes": NewCornellScene(CornellBoxes, CornellQuadLight),
+		"cylinders":     NewCylinderTestScene(),
+		"cones":         NewConeTestScene(),
+	}
+	for name, s := range scenes {
+		t.Run(name, func(t *testing.T) {
+			loaded, _, _ := roundTripPBRT(t, s)
+			if len(loaded.Lights) == 0 {
+				t.Error("Expected the scene's lights to load back")
+			}
+			if !loaded.CameraConfig.Center.Equals(s.CameraConfig.Center) {
+				t.Errorf("Expected the camera at %v, got %v", s.CameraConfig.Center, loaded.CameraConfig.Center)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package scene
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// PBRTChange is how much of a scene an edit to its PBRT file changed
+type PBRTChange int
+
+const (
+	PBRTUnchanged     PBRTChange = iota // Nothing that affects the image, such as comments or the sampler
+	PBRTCameraChanged                   // Only the camera or film: the shapes, lights and BVH can be reused
+	PBRTWorldChanged                    // Shapes, materials, textures or lights: the scene is rebuilt
+)
+
+// String returns the name of the change
+func (c PBRTChange) String() string {
+	switch c {
+	case PBRTUnchanged:
+		return "unchanged"
+	case PBRTCameraChanged:
+		return "camera"
+	default:
+		return "world"
+	}
+}
+
+// DiffPBRT compares two parses of a PBRT file and returns how much of the scene differs. The
+// Sampler and Integrator statements aren't converted, so changing them changes nothing.
+func DiffPBRT(old, new *loaders.PBRTScene) PBRTChange {
+	if old == nil || new == nil {
+		return PBRTWorldChanged
+	}
+	if old.BaseDir != new.BaseDir ||
+		!reflect.DeepEqual(old.Textures, new.Textures) ||
+		!reflect.DeepEqual(old.Materials, new.Materials) ||
+		!reflect.DeepEqual(old.Shapes, new.Shapes) ||
+		!reflect.DeepEqual(old.LightSources, new.LightSources) ||
+		!reflect.DeepEqual(old.Attributes, new.Attributes) {
+		return PBRTWorldChanged
+	}
+	if !reflect.DeepEqual(old.Camera, new.Camera) ||
+		!reflect.DeepEqual(old.LookAt, new.LookAt) ||
+		!reflect.DeepEqual(old.LookAtTo, new.LookAtTo) ||
+		!reflect.DeepEqual(old.LookAtUp, new.LookAtUp) ||
+		!reflect.DeepEqual(old.CameraTransform, new.CameraTransform) ||
+		!reflect.DeepEqual(old.Cameras, new.Cameras) ||
+		!reflect.DeepEqual(old.Film, new.Film) ||
+		!reflect.DeepEqual(old.PixelFilter, new.PixelFilter) {
+		return PBRTCameraChanged
+	}
+	return PBRTUnchanged
+}
+
+// PBRTReloader creates the scenes of successive versions of a PBRT file as it's edited, redoing
+// only the work an edit affects: a camera move keeps the shapes, lights and BVH, and a world
+// edit that leaves the textures alone keeps the loaded texture images. It isn't safe for
+// concurrent use.
+type PBRTReloader struct {
+	parsed   *loaders.PBRTScene
+	textures map[string]material.ColorSource
+	scene    *Scene
+}
+
+// NewPBRTReloader creates a reloader with no previous version, so its first Load builds the
+// whole scene
+func NewPBRTReloader() *PBRTReloader {
+	return &PBRTReloader{}
+}
+
+// Load creates the scene of the next version of the file and returns what changed since the
+// previous one. Each call returns a new Scene, so callers may adjust its settings while an
+// earlier one is still rendering, but the versions share shapes and lights when the world is
+// unchanged. After an error the previous version remains the one later loads compare with.
+func (r *PBRTReloader) Load(parsed *loaders.PBRTScene, cameraOverrides ...geometry.CameraConfig) (*Scene, PBRTChange, error) {
+	change := DiffPBRT(r.parsed, parsed)
+	if r.scene != nil && r.scene.edited {
+		// The previous version's shapes were edited, so they're no longer the file's
+		change = PBRTWorldChanged
+	}
+	if change == PBRTWorldChanged {
+		textures := r.textures
+		if r.parsed == nil || r.parsed.BaseDir != parsed.BaseDir || !reflect.DeepEqual(r.parsed.Textures, parsed.Textures) {
+			var err error
+			if textures, err = convertTextures(parsed.Textures, parsed.BaseDir); err != nil {
+				return nil, change, fmt.Errorf("failed to convert texture: %v", err)
+			}
+		}
+		s, err := newPBRTScene(parsed, textures, cameraOverrides...)
+		if err != nil {
+			return nil, change, err
+		}
+		r.parsed, r.textures, r.scene = parsed, textures, s
+		return s, change, nil
+	}
+
+	// Same world: share it, with the camera converted again (the overrides may differ, too). The
+	// light sampler isn't shared, as callers may replace it, and the default one is cheap to create.
+	previous := r.scene
+	s := &Scene{
+		Shapes:             previous.Shapes,
+		Lights:             previous.Lights,
+		SamplingConfig:     createDefaultPBRTSamplingConfig(),
+		Intersector:        previous.Intersector,
+		IntersectorBuilder: previous.IntersectorBuilder,
+		BVH:                previous.BVH,
+		WorldCenter:        previous.WorldCenter,
+		WorldRadius:        previous.WorldRadius,
+		hidesShapes:        previous.hidesShapes,
+		prebuilt:           previous.Intersector != nil,
+	}
+	if err := convertCamera(parsed, s, cameraOverrides...); err != nil {
+		return nil, change, fmt.Errorf("failed to convert camera: %v", err)
+	}
+	r.parsed, r.scene = parsed, s
+	return s, change, nil
+}
@@ -0,0 +1,142 @@
+package scene
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+)
+
+const reloadTestScene = `LookAt 0 0 5  0 0 0  0 1 0
+Camera "perspective" "float fov" 40
+Film "rgb" "integer xresolution" 64 "integer yresolution" 48
+Sampler "zsobol" "integer pixelsamples" 16
+WorldBegin
+Texture "grid" "spectrum" "checkerboard" "float uscale" 4 "float vscale" 4
+Material "diffuse" "texture reflectance" "grid"
+Shape "sphere" "float radius" 1
+AttributeBegin
+  AreaLightSource "diffuse" "rgb L" [ 4 4 4 ]
+  Shape "bilinearmesh" "point3 P" [ -1 3 -1  1 3 -1  -1 3 1  1 3 1 ] "integer indices" [ 0 1 2 3 ]
+AttributeEnd
+`
+
+func parseReloadTestScene(t *testing.T, source string) *loaders.PBRTScene {
+	t.Helper()
+	parsed, err := loaders.ParsePBRT(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("Failed to parse scene: %v", err)
+	}
+	return parsed
+}
+
+func TestDiffPBRT(t *testing.T) {
+	original := parseReloadTestScene(t, reloadTestScene)
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want PBRTChange
+	}{
+		{"comment", "WorldBegin", "# Moved the sphere\nWorldBegin", PBRTUnchanged},
+		{"sampler", `"integer pixelsamples" 16`, `"integer pixelsamples" 1024`, PBRTUnchanged},
+		{"camera position", "LookAt 0 0 5", "LookAt 1 0 5", PBRTCameraChanged},
+		{"field of view", `"float fov" 40`, `"float fov" 50`, PBRTCameraChanged},
+		{"resolution", "xresolution\" 64", "xresolution\" 128", PBRTCameraChanged},
+		{"named camera", "WorldBegin", "LookAt 0 5 0  0 0 0  0 0 -1\nCamera \"perspective\" \"string name\" \"top\"\nWorldBegin", PBRTCameraChanged},
+		{"shape", `"float radius" 1`, `"float radius" 2`, PBRTWorldChanged},
+		{"material", `"texture reflectance" "grid"`, `"rgb reflectance" [ 0.5 0.5 0.5 ]`, PBRTWorldChanged},
+		{"texture", `"float uscale" 4`, `"float uscale" 8`, PBRTWorldChanged},
+		{"light", "[ 4 4 4 ]", "[ 8 8 8 ]", PBRTWorldChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := parseReloadTestScene(t, strings.Replace(reloadTestScene, tt.old, tt.new, 1))
+			if got := DiffPBRT(original, edited); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := DiffPBRT(nil, original); got != PBRTWorldChanged {
+		t.Errorf("Expected the first version to change the world, got %v", got)
+	}
+}
+
+func TestPBRTReloader(t *testing.T) {
+	reloader := NewPBRTReloader()
+	load := func(source string, want PBRTChange, overrides ...geometry.CameraConfig) *Scene {
+		t.Helper()
+		s, change, err := reloader.Load(parseReloadTestScene(t, source), overrides...)
+		if err != nil {
+			t.Fatalf("Failed to load scene: %v", err)
+		}
+		if change != want {
+			t.Errorf("Expected change %v, got %v", want, change)
+		}
+		if err := s.Preprocess(); err != nil {
+			t.Fatalf("Failed to preprocess scene: %v", err)
+		}
+		return s
+	}
+
+	first := load(reloadTestScene, PBRTWorldChanged)
+	if first.BVH == nil || len(first.Shapes) != 2 || len(first.Lights) != 1 {
+		t.Fatalf("Expected a sphere and a quad light in a BVH, got %d shapes and %d lights", len(first.Shapes), len(first.Lights))
+	}
+	textures := reflect.ValueOf(reloader.textures).Pointer()
+
+	// Moving the camera keeps the BVH but not the camera or sampling settings
+	moved := load(strings.Replace(reloadTestScene, "LookAt 0 0 5", "LookAt 2 0 5", 1), PBRTCameraChanged)
+	if moved == first || moved.BVH != first.BVH || moved.Lights[0] != first.Lights[0] {
+		t.Error("Expected a new scene sharing the BVH and lights")
+	}
+	if shift := moved.CameraConfig.Center.Subtract(first.CameraConfig.Center); math.Abs(shift.X-2) > 1e-9 {
+		t.Errorf("Expected the new scene's camera to move 2 along x, got %v", shift)
+	}
+	if moved.LightSampler == nil || moved.LightSampler == first.LightSampler {
+		t.Error("Expected the new scene to have its own light sampler")
+	}
+
+	// Overrides apply to reused worlds, too
+	resized := load(strings.Replace(reloadTestScene, "LookAt 0 0 5", "LookAt 2 0 5", 1), PBRTUnchanged,
+		geometry.CameraConfig{Width: 32, AspectRatio: 2})
+	if resized.BVH != first.BVH || resized.SamplingConfig.Width != 32 || resized.SamplingConfig.Height != 16 {
+		t.Errorf("Expected the overridden 32x16 image over the same BVH, got %dx%d",
+			resized.SamplingConfig.Width, resized.SamplingConfig.Height)
+	}
+
+	// Shape edits to a version sharing the BVH leave it to the others, and the next version comes
+	// from the file again
+	if err := resized.RemoveShape(resized.Shapes[0]); err != nil {
+		t.Fatalf("RemoveShape failed: %v", err)
+	}
+	if resized.BVH == first.BVH || len(first.Shapes) != 2 || first.BVH.Stats().TotalShapes != 2 {
+		t.Error("Expected the edited scene to build a BVH of its own")
+	}
+	if again := load(strings.Replace(reloadTestScene, "LookAt 0 0 5", "LookAt 2 0 5", 1), PBRTWorldChanged); len(again.Shapes) != 2 {
+		t.Errorf("Expected the file's shapes after an edit, got %d", len(again.Shapes))
+	}
+
+	// Editing the world rebuilds it, keeping the textures when they didn't change
+	grown := load(strings.Replace(reloadTestScene, `"float radius" 1`, `"float radius" 2`, 1), PBRTWorldChanged)
+	if grown.BVH == first.BVH {
+		t.Error("Expected a new BVH after changing a shape")
+	}
+	if reflect.ValueOf(reloader.textures).Pointer() != textures {
+		t.Error("Expected the unchanged textures to be reused")
+	}
+	load(strings.Replace(reloadTestScene, `"float uscale" 4`, `"float uscale" 8`, 1), PBRTWorldChanged)
+	if reflect.ValueOf(reloader.textures).Pointer() == textures {
+		t.Error("Expected the edited textures to be converted again")
+	}
+
+	// A broken edit leaves the last good version to compare with
+	if _, _, err := reloader.Load(parseReloadTestScene(t, strings.Replace(reloadTestScene, `"float fov" 40`, `"float fov" 400`, 1))); err == nil {
+		t.Error("Expected an error for an invalid field of view")
+	}
+	load(strings.Replace(reloadTestScene, `"float uscale" 4`, `"float uscale" 8`, 1), PBRTUnchanged)
+}
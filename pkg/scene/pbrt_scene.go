@@ -2,7 +2,10 @@ package scene
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/geometry"
@@ -13,7 +16,16 @@ import (
 
 // NewPBRTScene creates a scene from a PBRT file
 func NewPBRTScene(pbrtScene *loaders.PBRTScene, cameraOverrides ...geometry.CameraConfig) (*Scene, error) {
+	// Convert named textures, which the materials refer to
+	textures, err := convertTextures(pbrtScene.Textures, pbrtScene.BaseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert texture: %v", err)
+	}
+	return newPBRTScene(pbrtScene, textures, cameraOverrides...)
+}
 
+// newPBRTScene creates a scene from a PBRT file whose named textures are already converted
+func newPBRTScene(pbrtScene *loaders.PBRTScene, textures map[string]material.ColorSource, cameraOverrides ...geometry.CameraConfig) (*Scene, error) {
 	// Create scene
 	scene := &Scene{
 		Shapes:         make([]geometry.Shape, 0),
@@ -26,10 +38,10 @@ func NewPBRTScene(pbrtScene *loaders.PBRTScene, cameraOverrides ...geometry.Came
 		return nil, fmt.Errorf("failed to convert camera: %v", err)
 	}
 
-	// Convert all materials first
+	// Convert materials, looking up the textures they refer to
 	materials := make([]material.Material, len(pbrtScene.Materials))
 	for i, matStmt := range pbrtScene.Materials {
-		mat, err := convertMaterial(&matStmt)
+		mat, err := convertMaterial(&matStmt, textures)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert material: %v", err)
 		}
@@ -67,7 +79,7 @@ func NewPBRTScene(pbrtScene *loaders.PBRTScene, cameraOverrides ...geometry.Came
 
 	// Process attribute blocks
 	for _, attrBlock := range pbrtScene.Attributes {
-		if err := processAttributeBlock(&attrBlock, scene, materials); err != nil {
+		if err := processAttributeBlock(&attrBlock, scene, materials, textures); err != nil {
 			return nil, fmt.Errorf("failed to process attribute block: %v", err)
 		}
 	}
@@ -89,8 +101,90 @@ func createDefaultPBRTSamplingConfig() SamplingConfig {
 	}
 }
 
-// convertCamera converts PBRT camera to our camera system
+// convertCamera converts PBRT camera to our camera system, along with the named cameras the scene
+// can switch to (see Scene.UseCamera)
 func convertCamera(pbrtScene *loaders.PBRTScene, scene *Scene, cameraOverrides ...geometry.CameraConfig) error {
+	cameraConfig, err := pbrtCameraConfig(loaders.PBRTCamera{
+		Statement: pbrtScene.Camera,
+		LookAt:    pbrtScene.LookAt,
+		LookAtTo:  pbrtScene.LookAtTo,
+		LookAtUp:  pbrtScene.LookAtUp,
+		Transform: pbrtScene.CameraTransform,
+	}, pbrtScene.Film)
+	if err != nil {
+		return err
+	}
+
+	// The film's resolution is the image's
+	if pbrtScene.Film != nil {
+		if _, ok := pbrtScene.Film.GetFloatParam("xresolution"); ok {
+			scene.SamplingConfig.Width = cameraConfig.Width
+		}
+		if height, ok := pbrtScene.Film.GetFloatParam("yresolution"); ok {
+			scene.SamplingConfig.Height = int(height)
+		}
+	}
+
+	if err := convertPixelFilter(pbrtScene.PixelFilter, &scene.SamplingConfig); err != nil {
+		return err
+	}
+
+	// Apply camera overrides if provided
+	if len(cameraOverrides) > 0 {
+		cameraConfig = geometry.MergeCameraConfig(cameraConfig, cameraOverrides[0])
+		// Update sampling config dimensions if width/aspect ratio were overridden
+		if cameraOverrides[0].Width > 0 {
+			scene.SamplingConfig.Width = cameraOverrides[0].Width
+			scene.SamplingConfig.Height = int(float64(cameraOverrides[0].Width) / cameraConfig.AspectRatio)
+		}
+	}
+
+	scene.CameraConfig = cameraConfig
+	scene.Camera = geometry.NewCamera(cameraConfig)
+
+	scene.Cameras = nil
+	for _, camera := range pbrtScene.Cameras {
+		config, err := pbrtCameraConfig(camera, pbrtScene.Film)
+		if err != nil {
+			return fmt.Errorf("camera %q: %v", camera.Name, err)
+		}
+		if len(cameraOverrides) > 0 {
+			config = geometry.MergeCameraConfig(config, cameraOverrides[0])
+		}
+		if scene.Cameras == nil {
+			scene.Cameras = make(map[string]geometry.CameraConfig)
+		}
+		scene.Cameras[camera.Name] = config
+	}
+
+	return nil
+}
+
+// convertPixelFilter converts a PixelFilter statement to the sampling config's reconstruction
+// filter. Without one, pixels keep our box filter rather than PBRT's default Gaussian.
+func convertPixelFilter(stmt *loaders.PBRTStatement, config *SamplingConfig) error {
+	if stmt == nil {
+		return nil
+	}
+	switch stmt.Subtype {
+	case "box", "gaussian", "mitchell":
+		config.Filter = stmt.Subtype
+	case "triangle":
+		config.Filter = "tent"
+		config.FilterRadius = 2 // PBRT's default
+	default:
+		return fmt.Errorf("unsupported pixel filter: %s", stmt.Subtype)
+	}
+	if radius, ok := stmt.GetFloatParam("xradius"); ok {
+		config.FilterRadius = radius
+	}
+	_, _, err := config.PixelFilter()
+	return err
+}
+
+// pbrtCameraConfig converts a Camera statement (nil for PBRT's default camera) with the LookAt
+// before it and the transform at it, for an image of the film's resolution
+func pbrtCameraConfig(camera loaders.PBRTCamera, film *loaders.PBRTStatement) (geometry.CameraConfig, error) {
 	// Default camera config
 	cameraConfig := geometry.CameraConfig{
 		Center:        core.NewVec3(0, 0, 0),
@@ -104,75 +198,102 @@ func convertCamera(pbrtScene *loaders.PBRTScene, scene *Scene, cameraOverrides .
 	}
 
 	// Apply LookAt if present
-	if pbrtScene.LookAt != nil && pbrtScene.LookAtTo != nil && pbrtScene.LookAtUp != nil {
-		cameraConfig.Center = *pbrtScene.LookAt
-		cameraConfig.LookAt = *pbrtScene.LookAtTo
-		cameraConfig.Up = *pbrtScene.LookAtUp
+	if camera.LookAt != nil && camera.LookAtTo != nil && camera.LookAtUp != nil {
+		cameraConfig.Center = *camera.LookAt
+		cameraConfig.LookAt = *camera.LookAtTo
+		cameraConfig.Up = *camera.LookAtUp
+	}
+
+	// The transform at the Camera statement places the camera, which looks down its +z with +y up.
+	// Our camera works out its own right vector, so a mirroring transform doesn't mirror the image.
+	if camera.Transform != nil {
+		worldFromCamera, ok := camera.Transform.Inverse()
+		if !ok {
+			return cameraConfig, fmt.Errorf("camera transform is singular")
+		}
+		// Keep LookAt's target distance, which focusing defaults to
+		distance := cameraConfig.LookAt.Subtract(cameraConfig.Center).Length()
+		cameraConfig.Center = worldFromCamera.TransformPoint(core.NewVec3(0, 0, 0))
+		cameraConfig.LookAt = worldFromCamera.TransformPoint(core.NewVec3(0, 0, distance))
+		cameraConfig.Up = worldFromCamera.TransformVector(core.NewVec3(0, 1, 0))
 	}
 
 	// Apply camera parameters if present
-	if pbrtScene.Camera != nil {
-		if pbrtScene.Camera.Subtype == "perspective" {
-			if fov, ok := pbrtScene.Camera.GetFloatParam("fov"); ok {
+	if camera.Statement != nil {
+		if camera.Statement.Subtype == "perspective" {
+			if fov, ok := camera.Statement.GetFloatParam("fov"); ok {
 				if fov <= 0 || fov >= 180 {
-					return fmt.Errorf("invalid camera FOV %f: must be between 0 and 180 degrees", fov)
+					return cameraConfig, fmt.Errorf("invalid camera FOV %f: must be between 0 and 180 degrees", fov)
 				}
 				cameraConfig.VFov = fov
 			}
+			// A thin lens of "lensradius", focused at "focaldistance"
+			if lensRadius, ok := camera.Statement.GetFloatParam("lensradius"); ok {
+				if lensRadius < 0 {
+					return cameraConfig, fmt.Errorf("invalid camera lens radius %f: must not be negative", lensRadius)
+				}
+				cameraConfig.Aperture = 2 * lensRadius
+			}
+			if focalDistance, ok := camera.Statement.GetFloatParam("focaldistance"); ok {
+				cameraConfig.FocusDistance = focalDistance
+			}
 		}
 	}
 
 	// Apply film parameters if present
-	if pbrtScene.Film != nil {
-		if width, ok := pbrtScene.Film.GetFloatParam("xresolution"); ok {
+	if film != nil {
+		if width, ok := film.GetFloatParam("xresolution"); ok {
 			if width <= 0 || width > 8192 {
-				return fmt.Errorf("invalid image width %f: must be between 1 and 8192", width)
+				return cameraConfig, fmt.Errorf("invalid image width %f: must be between 1 and 8192", width)
 			}
 			cameraConfig.Width = int(width)
-			scene.SamplingConfig.Width = int(width)
 		}
-		if height, ok := pbrtScene.Film.GetFloatParam("yresolution"); ok {
+		if height, ok := film.GetFloatParam("yresolution"); ok {
 			if height <= 0 || height > 8192 {
-				return fmt.Errorf("invalid image height %f: must be between 1 and 8192", height)
+				return cameraConfig, fmt.Errorf("invalid image height %f: must be between 1 and 8192", height)
 			}
-			scene.SamplingConfig.Height = int(height)
 			cameraConfig.AspectRatio = float64(cameraConfig.Width) / height
 		}
 	}
 
-	// Apply camera overrides if provided
-	if len(cameraOverrides) > 0 {
-		cameraConfig = geometry.MergeCameraConfig(cameraConfig, cameraOverrides[0])
-		// Update sampling config dimensions if width/aspect ratio were overridden
-		if cameraOverrides[0].Width > 0 {
-			scene.SamplingConfig.Width = cameraOverrides[0].Width
-			scene.SamplingConfig.Height = int(float64(cameraOverrides[0].Width) / cameraConfig.AspectRatio)
-		}
+	// PBRT's field of view spans the shorter image axis, which is the horizontal one in portrait images
+	if cameraConfig.AspectRatio < 1 {
+		halfTan := math.Tan(cameraConfig.VFov*math.Pi/360) / cameraConfig.AspectRatio
+		cameraConfig.VFov = math.Atan(halfTan) * 360 / math.Pi
 	}
 
-	scene.CameraConfig = cameraConfig
-	scene.Camera = geometry.NewCamera(cameraConfig)
-
-	return nil
+	return cameraConfig, nil
 }
 
-// convertMaterial converts a PBRT material to our material system
-func convertMaterial(stmt *loaders.PBRTStatement) (material.Material, error) {
+// convertMaterial converts a PBRT material to our material system, looking up the named textures
+// its parameters refer to in textures
+func convertMaterial(stmt *loaders.PBRTStatement, textures map[string]material.ColorSource) (material.Material, error) {
 	switch stmt.Subtype {
 	case "diffuse":
-		// Get reflectance (albedo)
-		if rgb, ok := stmt.GetRGBParam("reflectance"); ok {
-			return material.NewLambertian(*rgb), nil
+		// Get reflectance (albedo), a color or a texture
+		reflectance, err := getTextureParam(stmt, "reflectance", textures)
+		if err != nil {
+			return nil, err
+		}
+		if reflectance != nil {
+			return material.NewTexturedLambertian(reflectance), nil
 		}
 		// Default white diffuse
 		return material.NewLambertian(core.NewVec3(0.7, 0.7, 0.7)), nil
 
 	case "conductor":
-		// Metal material
+		// Metal material, colored by its "reflectance" (a color or a texture) or else its "eta"
 		albedo := core.NewVec3(0.7, 0.6, 0.5) // Default metal color
 		if rgb, ok := stmt.GetRGBParam("eta"); ok {
 			albedo = *rgb
 		}
+		reflectance, err := getTextureParam(stmt, "reflectance", textures)
+		if err != nil {
+			return nil, err
+		}
+		if reflectance == nil {
+			reflectance = material.NewSolidColor(albedo)
+		}
 
 		fuzz := 0.0
 		if roughness, ok := stmt.GetFloatParam("roughness"); ok {
@@ -182,10 +303,42 @@ func convertMaterial(stmt *loaders.PBRTStatement) (material.Material, error) {
 			fuzz = roughness
 		}
 
-		return material.NewMetal(albedo, fuzz), nil
+		return material.NewTexturedMetal(reflectance, fuzz), nil
+
+	case "coateddiffuse", "coatedconductor":
+		// A dielectric coating of index "eta" ("interface.eta" for coatedconductor) over a diffuse or
+		// metal base; the coating's roughness and thickness aren't modeled
+		etaName, base := "eta", "diffuse"
+		if stmt.Subtype == "coatedconductor" {
+			etaName, base = "interface.eta", "conductor"
+		}
+		ior := 1.5 // PBRT's default
+		if eta, ok := stmt.GetFloatParam(etaName); ok {
+			if eta <= 0 {
+				return nil, fmt.Errorf("invalid %s IOR %f: must be positive", stmt.Subtype, eta)
+			}
+			ior = eta
+		}
+		baseStmt := &loaders.PBRTStatement{Type: stmt.Type, Subtype: base, Parameters: stmt.Parameters}
+		if stmt.Subtype == "coatedconductor" {
+			baseStmt.Parameters = make(map[string]loaders.PBRTParam)
+			for name, param := range stmt.Parameters {
+				baseStmt.Parameters[strings.TrimPrefix(name, "conductor.")] = param
+			}
+		}
+		inner, err := convertMaterial(baseStmt, textures)
+		if err != nil {
+			return nil, err
+		}
+		return material.NewLayered(material.NewDielectric(ior), inner), nil
 
 	case "dielectric":
-		// Glass material
+		// Glass material, dispersive when eta is a named glass spectrum like "glass-BK7"
+		if eta, ok := stmt.GetStringParam("eta"); ok && stmt.Parameters["eta"].Type == "spectrum" {
+			if name, ok := strings.CutPrefix(eta, "glass-"); ok {
+				return material.NewGlassDielectric(name)
+			}
+		}
 		ior := 1.5 // Default glass IOR
 		if eta, ok := stmt.GetFloatParam("eta"); ok {
 			if eta <= 0 {
@@ -195,16 +348,238 @@ func convertMaterial(stmt *loaders.PBRTStatement) (material.Material, error) {
 		}
 		return material.NewDielectric(ior), nil
 
+	case "hair":
+		// Hair fiber BSDF. The interior's absorption comes from "sigma_a", a "reflectance" color or
+		// melanin concentrations, in that order, as in PBRT (brown hair by default)
+		eta, betaM, betaN, alpha := 1.55, 0.3, 0.3, 2.0
+		if v, ok := stmt.GetFloatParam("eta"); ok {
+			eta = v
+		}
+		if v, ok := stmt.GetFloatParam("beta_m"); ok {
+			betaM = v
+		}
+		if v, ok := stmt.GetFloatParam("beta_n"); ok {
+			betaN = v
+		}
+		if v, ok := stmt.GetFloatParam("alpha"); ok {
+			alpha = v
+		}
+		if eta <= 0 || betaM < 0 || betaM > 1 || betaN < 0 || betaN > 1 {
+			return nil, fmt.Errorf("invalid hair parameters: need eta > 0 and beta_m, beta_n in [0, 1]")
+		}
+
+		var sigmaA core.Vec3
+		if rgb, ok := stmt.GetRGBParam("sigma_a"); ok {
+			sigmaA = *rgb
+		} else if rgb, ok := stmt.GetRGBParam("reflectance"); ok {
+			sigmaA = material.HairAbsorptionFromColor(*rgb, betaN)
+		} else {
+			eumelanin, pheomelanin := 1.3, 0.0
+			if v, ok := stmt.GetFloatParam("eumelanin"); ok {
+				eumelanin = v
+			}
+			if v, ok := stmt.GetFloatParam("pheomelanin"); ok {
+				pheomelanin = v
+			}
+			sigmaA = material.HairAbsorptionFromMelanin(eumelanin, pheomelanin)
+		}
+		return material.NewHair(sigmaA, eta, betaM, betaN, alpha), nil
+
 	default:
+		if factory, ok := material.Lookup(stmt.Subtype); ok {
+			return factory(pbrtParams{stmt})
+		}
 		return nil, fmt.Errorf("unsupported material type: %s", stmt.Subtype)
 	}
 }
 
-// convertShape converts a PBRT shape to our shape system
+// getTextureParam returns a color parameter that may refer to a named texture, as a texture: nil
+// when the parameter isn't given
+func getTextureParam(stmt *loaders.PBRTStatement, name string, textures map[string]material.ColorSource) (material.ColorSource, error) {
+	param, exists := stmt.Parameters[name]
+	if !exists {
+		return nil, nil
+	}
+	switch param.Type {
+	case "texture":
+		textureName, _ := stmt.GetStringParam(name)
+		texture, ok := textures[textureName]
+		if !ok {
+			return nil, fmt.Errorf("unknown texture %q", textureName)
+		}
+		return texture, nil
+	case "float":
+		if value, ok := stmt.GetFloatParam(name); ok {
+			return material.NewSolidColor(core.NewVec3(value, value, value)), nil
+		}
+	default:
+		if color, ok := getSpectrumParam(stmt, name); ok {
+			return material.NewSolidColor(color), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s parameter %q", param.Type, name)
+}
+
+// convertTextures converts PBRT's named textures, in declaration order so that textures can refer to
+// earlier ones. Image file names are relative to baseDir.
+func convertTextures(stmts []loaders.PBRTStatement, baseDir string) (map[string]material.ColorSource, error) {
+	textures := make(map[string]material.ColorSource, len(stmts))
+	for i := range stmts {
+		stmt := &stmts[i]
+		if len(stmt.Arguments) != 2 {
+			return nil, fmt.Errorf("texture %q needs a type and a class", stmt.Subtype)
+		}
+		texture, err := convertTexture(stmt, stmt.Arguments[1], baseDir, textures)
+		if err != nil {
+			return nil, fmt.Errorf("texture %q: %v", stmt.Subtype, err)
+		}
+		textures[stmt.Subtype] = texture
+	}
+	return textures, nil
+}
+
+// convertTexture converts one PBRT texture of the given class. Float textures become gray ones.
+func convertTexture(stmt *loaders.PBRTStatement, class, baseDir string, textures map[string]material.ColorSource) (material.ColorSource, error) {
+	// textureOr returns a texture parameter or a solid gray when it isn't given
+	textureOr := func(name string, gray float64) (material.ColorSource, error) {
+		texture, err := getTextureParam(stmt, name, textures)
+		if texture == nil && err == nil {
+			texture = material.NewSolidColor(core.NewVec3(gray, gray, gray))
+		}
+		return texture, err
+	}
+
+	switch class {
+	case "imagemap":
+		filename, ok := stmt.GetStringParam("filename")
+		if !ok {
+			return nil, fmt.Errorf("imagemap missing filename")
+		}
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(baseDir, filename)
+		}
+		image, err := loaders.LoadImage(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// 8-bit images are sRGB encoded unless "encoding" says otherwise, approximated by gamma 2.2
+		gamma := 2.2
+		if encoding, ok := stmt.GetStringParam("encoding"); ok {
+			switch {
+			case encoding == "linear":
+				gamma = 1
+			case strings.HasPrefix(encoding, "gamma "):
+				gamma, err = strconv.ParseFloat(strings.TrimPrefix(encoding, "gamma "), 64)
+				if err != nil || gamma <= 0 {
+					return nil, fmt.Errorf("invalid image encoding %q", encoding)
+				}
+			case encoding != "sRGB":
+				return nil, fmt.Errorf("unsupported image encoding %q", encoding)
+			}
+		}
+		scale := 1.0
+		if value, ok := stmt.GetFloatParam("scale"); ok {
+			scale = value
+		}
+		invert := stmt.Parameters["invert"].Values
+		for i, pixel := range image.Pixels {
+			if gamma != 1 {
+				pixel = pixel.GammaCorrect(1 / gamma)
+			}
+			pixel = pixel.Multiply(scale)
+			if len(invert) > 0 && invert[0] == "true" {
+				pixel = core.NewVec3(1, 1, 1).Subtract(pixel).Clamp(0, 1)
+			}
+			image.Pixels[i] = pixel
+		}
+		return textureMapping(stmt, material.NewImageTexture(image.Width, image.Height, image.Pixels))
+
+	case "checkerboard":
+		if dimension, ok := stmt.GetFloatParam("dimension"); ok && dimension != 2 {
+			return nil, fmt.Errorf("unsupported checkerboard dimension %v", dimension)
+		}
+		even, err := textureOr("tex1", 1)
+		if err != nil {
+			return nil, err
+		}
+		odd, err := textureOr("tex2", 0)
+		if err != nil {
+			return nil, err
+		}
+		return textureMapping(stmt, material.NewCheckerTexture(even, odd))
+
+	case "scale":
+		texture, err := textureOr("tex", 1)
+		if err != nil {
+			return nil, err
+		}
+		scale, err := textureOr("scale", 1)
+		if err != nil {
+			return nil, err
+		}
+		return material.NewScaledTexture(texture, scale), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported texture class: %s", class)
+	}
+}
+
+// textureMapping applies a texture's "uv" mapping parameters (uscale, vscale, udelta, vdelta)
+func textureMapping(stmt *loaders.PBRTStatement, texture material.ColorSource) (material.ColorSource, error) {
+	if mapping, ok := stmt.GetStringParam("mapping"); ok && mapping != "uv" {
+		return nil, fmt.Errorf("unsupported texture mapping %q", mapping)
+	}
+	scale, offset := core.NewVec2(1, 1), core.Vec2{}
+	for _, param := range []struct {
+		name  string
+		value *float64
+	}{{"uscale", &scale.X}, {"vscale", &scale.Y}, {"udelta", &offset.X}, {"vdelta", &offset.Y}} {
+		if value, ok := stmt.GetFloatParam(param.name); ok {
+			*param.value = value
+		}
+	}
+	if scale == core.NewVec2(1, 1) && offset == (core.Vec2{}) {
+		return texture, nil
+	}
+	return material.NewUVMappedTexture(texture, scale, offset), nil
+}
+
+// objectToWorld returns the transformation placing a PBRT shape or light in the world
+func objectToWorld(stmt *loaders.PBRTStatement) core.Matrix4 {
+	if stmt.Transform == nil {
+		return core.IdentityMatrix()
+	}
+	return *stmt.Transform
+}
+
+// roundShapeScale returns how a shape's transform scales its radii, which must be the same in
+// every direction for spheres, discs and the other round shapes
+func roundShapeScale(stmt *loaders.PBRTStatement, xf core.Matrix4) (float64, error) {
+	scale, ok := xf.UniformScale()
+	if !ok {
+		return 0, fmt.Errorf("%s can't be scaled unevenly or sheared by its transform", stmt.Subtype)
+	}
+	return scale, nil
+}
+
+// eulerAngles returns the rotation, in the order Vec3.Rotate applies it (x, then y, then z), of a
+// matrix whose linear part is a rotation
+func eulerAngles(m core.Matrix4) core.Vec3 {
+	y := math.Asin(math.Max(-1, math.Min(1, -m[2][0])))
+	if math.Cos(y) < 1e-9 {
+		// Gimbal lock: x and z rotate about the same axis, so z takes it all
+		return core.NewVec3(0, y, math.Atan2(-m[0][1], m[1][1]))
+	}
+	return core.NewVec3(math.Atan2(m[2][1], m[2][2]), y, math.Atan2(m[1][0], m[0][0]))
+}
+
+// convertShape converts a PBRT shape to our shape system, placed by its transform
 func convertShape(stmt *loaders.PBRTStatement, mat material.Material) (geometry.Shape, error) {
 	if mat == nil {
 		return nil, fmt.Errorf("shape has no material")
 	}
+	xf := objectToWorld(stmt)
 
 	switch stmt.Subtype {
 	case "sphere":
@@ -220,7 +595,69 @@ func convertShape(stmt *loaders.PBRTStatement, mat material.Material) (geometry.
 		if c, ok := stmt.GetPoint3Param("center"); ok {
 			center = *c
 		}
-		return geometry.NewSphere(center, radius, mat), nil
+		scale, err := roundShapeScale(stmt, xf)
+		if err != nil {
+			return nil, err
+		}
+		if stmt.ReverseOrientation {
+			radius = -radius // Our spheres of negative radius have inward normals
+		}
+		return geometry.NewSphere(xf.TransformPoint(center), radius*scale, mat), nil
+
+	case "cylinder":
+		// PBRT cylinder: open tube of "radius" around the z axis, from z = "zmin" to "zmax"
+		radius, zMin, zMax := 1.0, -1.0, 1.0
+		if r, ok := stmt.GetFloatParam("radius"); ok {
+			radius = r
+		}
+		if z, ok := stmt.GetFloatParam("zmin"); ok {
+			zMin = z
+		}
+		if z, ok := stmt.GetFloatParam("zmax"); ok {
+			zMax = z
+		}
+		if radius <= 0 || zMin == zMax {
+			return nil, fmt.Errorf("invalid cylinder radius %f or height %f to %f", radius, zMin, zMax)
+		}
+		scale, err := roundShapeScale(stmt, xf)
+		if err != nil {
+			return nil, err
+		}
+		base := xf.TransformPoint(core.NewVec3(0, 0, math.Min(zMin, zMax)))
+		top := xf.TransformPoint(core.NewVec3(0, 0, math.Max(zMin, zMax)))
+		return geometry.NewCylinder(base, top, radius*scale, false, mat), nil
+
+	case "disk":
+		// PBRT disk: faces +z at z = "height", with an optional hole of "innerradius"
+		// Our extensions "center" and "normal" place it without a transform
+		radius := 1.0
+		if r, ok := stmt.GetFloatParam("radius"); ok {
+			radius = r
+		}
+		innerRadius := 0.0
+		if r, ok := stmt.GetFloatParam("innerradius"); ok {
+			innerRadius = r
+		}
+		if radius <= 0 || innerRadius < 0 || innerRadius >= radius {
+			return nil, fmt.Errorf("invalid disk radii %f and inner %f: need 0 <= innerradius < radius", radius, innerRadius)
+		}
+
+		center := core.NewVec3(0, 0, 0)
+		if height, ok := stmt.GetFloatParam("height"); ok {
+			center.Z = height
+		}
+		if c, ok := stmt.GetPoint3Param("center"); ok {
+			center = *c
+		}
+		normal := core.NewVec3(0, 0, 1)
+		if n, ok := stmt.GetPoint3Param("normal"); ok {
+			normal = *n
+		}
+		scale, err := roundShapeScale(stmt, xf)
+		if err != nil {
+			return nil, err
+		}
+		return geometry.NewAnnulus(xf.TransformPoint(center), xf.TransformNormal(normal).Normalize(), innerRadius*scale, radius*scale, mat), nil
 
 	case "bilinearPatch":
 		// PBRT bilinear patch -> our Quad
@@ -235,49 +672,237 @@ func convertShape(stmt *loaders.PBRTStatement, mat material.Material) (geometry.
 
 		// Convert to our quad format: corner + two edge vectors
 		// P00 is corner, u = P01-P00, v = P10-P00
-		corner := *p00
-		u := p01.Subtract(*p00)
-		v := p10.Subtract(*p00)
+		corner := xf.TransformPoint(*p00)
+		u := xf.TransformPoint(*p01).Subtract(corner)
+		v := xf.TransformPoint(*p10).Subtract(corner)
 
 		return geometry.NewQuad(corner, u, v, mat), nil
 
-	case "trianglemesh":
+	case "bilinearmesh":
+		// PBRT bilinear mesh: patches of four vertices p00, p10, p01 and p11. A single flat
+		// parallelogram patch is our Quad
+		param, exists := stmt.Parameters["P"]
+		if !exists || len(param.Values) != 12 {
+			return nil, fmt.Errorf("bilinearmesh must have a single patch of 4 vertices")
+		}
+		points, err := parseVec3Values(param.Values, "vertex")
+		if err != nil {
+			return nil, err
+		}
+		if indicesParam, exists := stmt.Parameters["indices"]; exists && strings.Join(indicesParam.Values, " ") != "0 1 2 3" {
+			return nil, fmt.Errorf("bilinearmesh indices must be 0 1 2 3, got %v", indicesParam.Values)
+		}
+		for i, point := range points {
+			points[i] = xf.TransformPoint(point)
+		}
+		corner := points[0]
+		u, v := points[1].Subtract(corner), points[2].Subtract(corner)
+		if gap := corner.Add(u).Add(v).Subtract(points[3]).Length(); gap > 1e-6*(u.Length()+v.Length()) {
+			return nil, fmt.Errorf("bilinearmesh patch must be a parallelogram")
+		}
+		return geometry.NewQuad(corner, u, v, mat), nil
+
+	case "trianglemesh", "loopsubdiv":
 		// Get vertices
 		param, exists := stmt.Parameters["P"]
 		if !exists || len(param.Values)%3 != 0 {
-			return nil, fmt.Errorf("trianglemesh missing or invalid vertices")
+			return nil, fmt.Errorf("%s missing or invalid vertices", stmt.Subtype)
+		}
+		vertices, err := parseVec3Values(param.Values, "vertex")
+		if err != nil {
+			return nil, err
+		}
+		for i, vertex := range vertices {
+			vertices[i] = xf.TransformPoint(vertex)
 		}
 
-		vertices := make([]core.Vec3, 0, len(param.Values)/3)
-		for i := 0; i < len(param.Values); i += 3 {
-			x, err1 := strconv.ParseFloat(param.Values[i], 64)
-			if err1 != nil {
-				return nil, fmt.Errorf("invalid vertex X coordinate '%s': %v", param.Values[i], err1)
+		// Optional per-vertex normals for smooth shading
+		options := &geometry.TriangleMeshOptions{}
+		if normalParam, exists := stmt.Parameters["N"]; exists {
+			if len(normalParam.Values) != len(param.Values) {
+				return nil, fmt.Errorf("%s has %d normal values for %d vertex values", stmt.Subtype, len(normalParam.Values), len(param.Values))
+			}
+			normals, err := parseVec3Values(normalParam.Values, "normal")
+			if err != nil {
+				return nil, err
+			}
+			for i, normal := range normals {
+				normals[i] = xf.TransformNormal(normal).Normalize()
 			}
-			y, err2 := strconv.ParseFloat(param.Values[i+1], 64)
-			if err2 != nil {
-				return nil, fmt.Errorf("invalid vertex Y coordinate '%s': %v", param.Values[i+1], err2)
+			options.VertexNormals = normals
+		}
+
+		// Optional per-vertex texture coordinates
+		if uvParam, exists := stmt.Parameters["uv"]; exists {
+			if len(uvParam.Values) != len(param.Values)/3*2 {
+				return nil, fmt.Errorf("%s has %d uv values for %d vertices", stmt.Subtype, len(uvParam.Values), len(vertices))
 			}
-			z, err3 := strconv.ParseFloat(param.Values[i+2], 64)
-			if err3 != nil {
-				return nil, fmt.Errorf("invalid vertex Z coordinate '%s': %v", param.Values[i+2], err3)
+			options.VertexUVs = make([]core.Vec2, len(vertices))
+			for i := range options.VertexUVs {
+				u, errU := strconv.ParseFloat(uvParam.Values[2*i], 64)
+				v, errV := strconv.ParseFloat(uvParam.Values[2*i+1], 64)
+				if errU != nil || errV != nil {
+					return nil, fmt.Errorf("%s has invalid uv %q %q", stmt.Subtype, uvParam.Values[2*i], uvParam.Values[2*i+1])
+				}
+				options.VertexUVs[i] = core.NewVec2(u, v)
 			}
-			vertices = append(vertices, core.NewVec3(x, y, z))
 		}
 
 		// Get indices
 		indicesParam, exists := stmt.Parameters["indices"]
 		if !exists || len(indicesParam.Values)%3 != 0 {
-			return nil, fmt.Errorf("trianglemesh missing or invalid indices")
+			return nil, fmt.Errorf("%s missing or invalid indices", stmt.Subtype)
 		}
 
 		indices := make([]int, 0, len(indicesParam.Values))
 		for _, idxStr := range indicesParam.Values {
-			idx, _ := strconv.Atoi(idxStr)
+			idx, err := strconv.Atoi(idxStr)
+			if err != nil || idx < 0 || idx >= len(vertices) {
+				return nil, fmt.Errorf("%s has invalid vertex index %q", stmt.Subtype, idxStr)
+			}
 			indices = append(indices, idx)
 		}
 
-		return geometry.NewTriangleMesh(vertices, indices, mat, nil), nil
+		// Loop subdivision surface, refined "levels" times (3 by default, as in PBRT) and smooth shaded
+		if stmt.Subtype == "loopsubdiv" {
+			options.SubdivisionLevels = 3
+			if levels, ok := stmt.GetFloatParam("levels"); ok {
+				if levels < 0 || levels != math.Trunc(levels) {
+					return nil, fmt.Errorf("invalid loopsubdiv levels %v: must be a non-negative integer", levels)
+				}
+				options.SubdivisionLevels = int(levels)
+			}
+			options.SmoothNormals = true
+		}
+
+		return geometry.NewTriangleMesh(vertices, indices, mat, options), nil
+
+	case "capsule":
+		// Custom shape: cylinder with hemispherical ends around the segment p0-p1
+		radius := 1.0
+		if r, ok := stmt.GetFloatParam("radius"); ok {
+			if r <= 0 {
+				return nil, fmt.Errorf("invalid capsule radius %f: must be positive", r)
+			}
+			radius = r
+		}
+
+		p0, ok1 := stmt.GetPoint3Param("p0")
+		p1, ok2 := stmt.GetPoint3Param("p1")
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("capsule missing endpoints p0 and p1")
+		}
+
+		scale, err := roundShapeScale(stmt, xf)
+		if err != nil {
+			return nil, err
+		}
+		return geometry.NewCapsule(xf.TransformPoint(*p0), xf.TransformPoint(*p1), radius*scale, mat), nil
+
+	case "torus":
+		// Custom shape: ring of "majorradius" around the "axis" through "center", with a tube of
+		// "minorradius". The axis defaults to z, as for PBRT's other quadrics
+		center := core.NewVec3(0, 0, 0)
+		if c, ok := stmt.GetPoint3Param("center"); ok {
+			center = *c
+		}
+		axis := core.NewVec3(0, 0, 1)
+		if a, ok := stmt.GetPoint3Param("axis"); ok {
+			axis = *a
+		}
+		majorRadius := 1.0
+		if r, ok := stmt.GetFloatParam("majorradius"); ok {
+			majorRadius = r
+		}
+		minorRadius := 0.25
+		if r, ok := stmt.GetFloatParam("minorradius"); ok {
+			minorRadius = r
+		}
+
+		scale, err := roundShapeScale(stmt, xf)
+		if err != nil {
+			return nil, err
+		}
+		torus, err := geometry.NewTorus(xf.TransformPoint(center), xf.TransformVector(axis), majorRadius*scale, minorRadius*scale, mat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid torus: %v", err)
+		}
+		return torus, nil
+
+	case "curve":
+		// PBRT curve: a strand of cubic (or quadratic) segments through the control points "P", whose
+		// width goes from "width0" to "width1" ("width" sets both)
+		param, exists := stmt.Parameters["P"]
+		if !exists || len(param.Values)%3 != 0 {
+			return nil, fmt.Errorf("curve missing or invalid control points")
+		}
+		points, err := parseVec3Values(param.Values, "control point")
+		if err != nil {
+			return nil, err
+		}
+		for i, point := range points {
+			points[i] = xf.TransformPoint(point)
+		}
+
+		width0, width1 := 1.0, 1.0
+		if w, ok := stmt.GetFloatParam("width"); ok {
+			width0, width1 = w, w
+		}
+		if w, ok := stmt.GetFloatParam("width0"); ok {
+			width0 = w
+		}
+		if w, ok := stmt.GetFloatParam("width1"); ok {
+			width1 = w
+		}
+		// Widths scale by the transform's average scale
+		widthScale := math.Cbrt(math.Abs(xf.Determinant3()))
+		width0, width1 = width0*widthScale, width1*widthScale
+
+		options := geometry.CurveOptions{SplitDepth: 3}
+		if degree, ok := stmt.GetFloatParam("degree"); ok {
+			options.Degree = int(degree)
+		}
+		if splitDepth, ok := stmt.GetFloatParam("splitdepth"); ok {
+			options.SplitDepth = int(splitDepth)
+		}
+		if basis, ok := stmt.GetStringParam("basis"); ok {
+			switch basis {
+			case "bezier":
+				options.Basis = geometry.CurveBezier
+			case "bspline":
+				options.Basis = geometry.CurveBSpline
+			default:
+				return nil, fmt.Errorf("unsupported curve basis %q", basis)
+			}
+		}
+		if curveType, ok := stmt.GetStringParam("type"); ok {
+			switch curveType {
+			case "flat":
+				options.Type = geometry.CurveFlat
+			case "cylinder":
+				options.Type = geometry.CurveCylinder
+			case "ribbon":
+				options.Type = geometry.CurveRibbon
+			default:
+				return nil, fmt.Errorf("unsupported curve type %q", curveType)
+			}
+		}
+		if normalParam, exists := stmt.Parameters["N"]; exists {
+			normals, err := parseVec3Values(normalParam.Values, "normal")
+			if err != nil {
+				return nil, err
+			}
+			for i, normal := range normals {
+				normals[i] = xf.TransformNormal(normal).Normalize()
+			}
+			options.Normals = normals
+		}
+
+		curves, err := geometry.NewCurves(points, width0, width1, mat, options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid curve: %v", err)
+		}
+		return curves, nil
 
 	case "box":
 		// Box shape - use our NewBox function
@@ -296,29 +921,120 @@ func convertShape(stmt *loaders.PBRTStatement, mat material.Material) (geometry.
 			rotation = *r
 		}
 
+		if stmt.Transform != nil {
+			// The transform's rotation composes with the box's own. A box mirrored along its own
+			// x axis is the same box, so a mirroring transform gets that flip to leave a rotation.
+			scale, err := roundShapeScale(stmt, xf)
+			if err != nil {
+				return nil, err
+			}
+			linear := xf
+			linear[0][3], linear[1][3], linear[2][3] = 0, 0, 0
+			combined := linear.Multiply(core.ScalingMatrix(core.NewVec3(1/scale, 1/scale, 1/scale))).
+				Multiply(core.RotationMatrix(rotation.Z, core.NewVec3(0, 0, 1))).
+				Multiply(core.RotationMatrix(rotation.Y, core.NewVec3(0, 1, 0))).
+				Multiply(core.RotationMatrix(rotation.X, core.NewVec3(1, 0, 0)))
+			if xf.Determinant3() < 0 {
+				combined = combined.Multiply(core.ScalingMatrix(core.NewVec3(-1, 1, 1)))
+			}
+			center = xf.TransformPoint(center)
+			size = size.Multiply(scale)
+			rotation = eulerAngles(combined)
+		}
+
 		return geometry.NewBox(center, size, rotation, mat), nil
 
 	default:
+		if factory, ok := geometry.Lookup(stmt.Subtype); ok {
+			return factory(pbrtParams{stmt}, xf, mat)
+		}
 		return nil, fmt.Errorf("unsupported shape type: %s", stmt.Subtype)
 	}
 }
 
+// parseVec3Values parses a flat list of numbers (x y z x y z ...) into vectors; kind names them in errors
+func parseVec3Values(values []string, kind string) ([]core.Vec3, error) {
+	vectors := make([]core.Vec3, 0, len(values)/3)
+	for i := 0; i+2 < len(values); i += 3 {
+		var components [3]float64
+		for axis, name := range []string{"X", "Y", "Z"} {
+			value, err := strconv.ParseFloat(values[i+axis], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s coordinate '%s': %v", kind, name, values[i+axis], err)
+			}
+			components[axis] = value
+		}
+		vectors = append(vectors, core.NewVec3(components[0], components[1], components[2]))
+	}
+	return vectors, nil
+}
+
+// getSpectrumParam extracts a light's spectrum parameter, given as an RGB color or as a
+// blackbody temperature in kelvin (with luminance 1)
+func getSpectrumParam(stmt *loaders.PBRTStatement, name string) (core.Vec3, bool) {
+	if rgb, ok := stmt.GetRGBParam(name); ok {
+		return *rgb, true
+	}
+	if stmt.Parameters[name].Type == "blackbody" {
+		if kelvin, ok := stmt.GetFloatParam(name); ok {
+			return lights.BlackbodyColor(kelvin), true
+		}
+	}
+	return core.Vec3{}, false
+}
+
+// areaLightEmission returns the radiance of a PBRT shape marked as an area light: its "L",
+// scaled so the light emits "power" when that's given
+func areaLightEmission(stmt *loaders.PBRTStatement) (core.Vec3, error) {
+	emission, ok := getSpectrumParam(stmt, "L")
+	if !ok {
+		return core.Vec3{}, fmt.Errorf("area light missing emission parameter 'L'")
+	}
+	power, ok := stmt.GetFloatParam("power")
+	if !ok {
+		return emission, nil
+	}
+
+	shape, err := areaLightShape(stmt)
+	if err != nil {
+		return core.Vec3{}, err
+	}
+	if _, err := newAreaLight(shape, emission); err != nil {
+		return core.Vec3{}, err
+	}
+	return lights.EmissionForPower(emission, power, func(emission core.Vec3) lights.Light {
+		light, _ := newAreaLight(shape, emission) // The shape is supported, checked above
+		return light
+	}), nil
+}
+
 // convertAreaLight converts a PBRT shape marked as an area light to a Light object
 func convertAreaLight(stmt *loaders.PBRTStatement) (lights.Light, error) {
-	// Extract emission parameters
-	emission, ok := stmt.GetRGBParam("L")
-	if !ok {
-		return nil, fmt.Errorf("area light missing emission parameter 'L'")
+	emission, err := areaLightEmission(stmt)
+	if err != nil {
+		return nil, err
 	}
-	emissiveMat := material.NewEmissive(*emission)
+	shape, err := areaLightShape(stmt)
+	if err != nil {
+		return nil, err
+	}
+	return newAreaLight(shape, emission)
+}
 
-	// Use convertShape to parse the shape, then create appropriate Light
+// areaLightShape parses the geometry of a PBRT shape marked as an area light
+func areaLightShape(stmt *loaders.PBRTStatement) (geometry.Shape, error) {
 	// We pass a dummy material since we only need the shape geometry
 	dummyMat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
 	shape, err := convertShape(stmt, dummyMat)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse area light shape: %v", err)
 	}
+	return shape, nil
+}
+
+// newAreaLight creates the Light for an area light's shape
+func newAreaLight(shape geometry.Shape, emission core.Vec3) (lights.Light, error) {
+	emissiveMat := material.NewEmissive(emission)
 
 	// Create Light object based on the actual shape type
 	switch s := shape.(type) {
@@ -327,7 +1043,12 @@ func convertAreaLight(stmt *loaders.PBRTStatement) (lights.Light, error) {
 	case *geometry.Sphere:
 		return lights.NewSphereLight(s.Center, s.Radius, emissiveMat), nil
 	case *geometry.Disc:
-		return lights.NewDiscLight(s.Center, s.Normal, s.Radius, emissiveMat), nil
+		return lights.NewAnnulusLight(s.Center, s.Normal, s.InnerRadius, s.Radius, emissiveMat), nil
+	case *geometry.Cylinder:
+		return lights.NewCylinderLight(s.BaseCenter, s.TopCenter, s.Radius, s.Capped, emissiveMat), nil
+	case geometry.SurfaceSampler:
+		// Any other area-sampleable shape (e.g. capsule tube lights) uses the generic shape light
+		return lights.NewShapeLight(s, emissiveMat), nil
 	default:
 		return nil, fmt.Errorf("shape type %T is not supported as an area light", shape)
 	}
@@ -339,36 +1060,106 @@ func convertLight(stmt *loaders.PBRTStatement, associatedShapes []loaders.PBRTSt
 	switch stmt.Subtype {
 	case "point":
 		intensity := core.NewVec3(10, 10, 10) // Default intensity
-		if rgb, ok := stmt.GetRGBParam("I"); ok {
-			intensity = *rgb
+		if spectrum, ok := getSpectrumParam(stmt, "I"); ok {
+			intensity = spectrum
+		}
+		if scale, ok := stmt.GetFloatParam("scale"); ok {
+			intensity = intensity.Multiply(scale)
 		}
 
 		position := core.NewVec3(0, 5, 0) // Default position
 		if pos, ok := stmt.GetPoint3Param("from"); ok {
 			position = *pos
 		}
+		position = objectToWorld(stmt).TransformPoint(position)
 
 		// Use sphere light as point light approximation with emissive material
-		emissiveMat := material.NewEmissive(intensity)
-		return lights.NewSphereLight(position, 0.1, emissiveMat), nil
+		newLight := func(emission core.Vec3) lights.Light {
+			return lights.NewSphereLight(position, 0.1, material.NewEmissive(emission))
+		}
+		if power, ok := stmt.GetFloatParam("power"); ok {
+			intensity = lights.EmissionForPower(intensity, power, newLight)
+		}
+		return newLight(intensity), nil
+
+	case "spot":
+		intensity := core.NewVec3(1, 1, 1) // PBRT's default
+		if spectrum, ok := getSpectrumParam(stmt, "I"); ok {
+			intensity = spectrum
+		}
+		if scale, ok := stmt.GetFloatParam("scale"); ok {
+			intensity = intensity.Multiply(scale)
+		}
+
+		from, to := core.NewVec3(0, 0, 0), core.NewVec3(0, 0, 1)
+		if p, ok := stmt.GetPoint3Param("from"); ok {
+			from = *p
+		}
+		if p, ok := stmt.GetPoint3Param("to"); ok {
+			to = *p
+		}
+		from, to = objectToWorld(stmt).TransformPoint(from), objectToWorld(stmt).TransformPoint(to)
+		if from == to {
+			return nil, fmt.Errorf("spot light's from and to points coincide")
+		}
+
+		coneAngle, coneDelta := 30.0, 5.0
+		if angle, ok := stmt.GetFloatParam("coneangle"); ok {
+			coneAngle = angle
+		}
+		if delta, ok := stmt.GetFloatParam("conedelta"); ok {
+			coneDelta = delta
+		}
+		return lights.NewPointSpotLight(from, to, intensity, coneAngle, coneDelta), nil
 
 	case "distant":
-		radiance := core.NewVec3(3, 3, 3) // Default radiance
-		if rgb, ok := stmt.GetRGBParam("L"); ok {
-			radiance = *rgb
+		irradiance := core.NewVec3(1, 1, 1) // PBRT's default
+		if spectrum, ok := getSpectrumParam(stmt, "L"); ok {
+			irradiance = spectrum
 		}
+		if scale, ok := stmt.GetFloatParam("scale"); ok {
+			irradiance = irradiance.Multiply(scale)
+		}
+		if lux, ok := stmt.GetFloatParam("illuminance"); ok && irradiance.Luminance() > 0 {
+			irradiance = irradiance.Multiply(lux / irradiance.Luminance())
+		}
+
+		from, to := core.NewVec3(0, 0, 0), core.NewVec3(0, 0, 1)
+		if p, ok := stmt.GetPoint3Param("from"); ok {
+			from = *p
+		}
+		if p, ok := stmt.GetPoint3Param("to"); ok {
+			to = *p
+		}
+		from, to = objectToWorld(stmt).TransformPoint(from), objectToWorld(stmt).TransformPoint(to)
 
-		// Use uniform infinite light as distant light approximation
-		// (Direction is ignored since we don't have directional lights)
-		return lights.NewUniformInfiniteLight(radiance), nil
+		// PBRT's distant light is a delta light; ours has the sun's size, so its shadows are soft
+		return lights.NewDirectionalLight(to.Subtract(from), irradiance, lights.SunAngularDiameter), nil
 
 	case "infinite":
 		radiance := core.NewVec3(1, 1, 1) // Default background
-		if rgb, ok := stmt.GetRGBParam("L"); ok {
-			radiance = *rgb
+		if spectrum, ok := getSpectrumParam(stmt, "L"); ok {
+			radiance = spectrum
 		}
+		environment := lights.NewUniformInfiniteLight(radiance)
 
-		return lights.NewUniformInfiniteLight(radiance), nil
+		// A portal is the four corners of a window the light enters an interior through
+		param, ok := stmt.Parameters["portal"]
+		if !ok {
+			return environment, nil
+		}
+		corners, err := parseVec3Values(param.Values, "portal")
+		if err != nil {
+			return nil, err
+		}
+		if len(corners) != 4 {
+			return nil, fmt.Errorf("infinite light portal needs 4 points, got %d", len(corners))
+		}
+		for i, corner := range corners {
+			corners[i] = objectToWorld(stmt).TransformPoint(corner)
+		}
+		portal := geometry.NewQuad(corners[0], corners[1].Subtract(corners[0]), corners[3].Subtract(corners[0]), nil)
+		return lights.NewPortalLight(environment, []*geometry.Quad{portal})
 
 	case "infinite-gradient":
 		topColor := core.NewVec3(0.5, 0.7, 1.0)    // Default blue sky
@@ -389,16 +1180,40 @@ func convertLight(stmt *loaders.PBRTStatement, associatedShapes []loaders.PBRTSt
 		return nil, fmt.Errorf("AreaLightSource should be handled as graphics state, not converted as standalone light")
 
 	default:
+		if factory, ok := lights.Lookup(stmt.Subtype); ok {
+			return factory(pbrtParams{stmt}, objectToWorld(stmt))
+		}
 		return nil, fmt.Errorf("unsupported light type: %s", stmt.Subtype)
 	}
 }
 
+// pbrtParams are a statement's parameters, for the factories of registered types. Colors and
+// points are any parameter of three numbers, or a blackbody temperature.
+type pbrtParams struct {
+	stmt *loaders.PBRTStatement
+}
+
+func (p pbrtParams) Float(name string) (float64, bool) {
+	return p.stmt.GetFloatParam(name)
+}
+
+func (p pbrtParams) Vec3(name string) (core.Vec3, bool) {
+	return getSpectrumParam(p.stmt, name)
+}
+
+func (p pbrtParams) String(name string) (string, bool) {
+	if p.stmt.Parameters[name].Type != "string" {
+		return "", false
+	}
+	return p.stmt.GetStringParam(name)
+}
+
 // processAttributeBlock processes an AttributeBegin/AttributeEnd block
-func processAttributeBlock(block *loaders.AttributeBlock, scene *Scene, globalMaterials []material.Material) error {
+func processAttributeBlock(block *loaders.AttributeBlock, scene *Scene, globalMaterials []material.Material, textures map[string]material.ColorSource) error {
 	// Convert local materials in this block
 	localMaterials := make([]material.Material, len(block.Materials))
 	for i, matStmt := range block.Materials {
-		mat, err := convertMaterial(&matStmt)
+		mat, err := convertMaterial(&matStmt, textures)
 		if err != nil {
 			return fmt.Errorf("failed to convert material in attribute block: %v", err)
 		}
@@ -424,8 +1239,8 @@ func processAttributeBlock(block *loaders.AttributeBlock, scene *Scene, globalMa
 		// Check if this shape is marked as an area light
 		if shapeStmt.IsAreaLight() {
 			// This shape is an area light - check for emission parameters
-			if rgb, ok := shapeStmt.GetRGBParam("L"); ok {
-				shapeMaterial = material.NewEmissive(*rgb)
+			if emission, err := areaLightEmission(&shapeStmt); err == nil {
+				shapeMaterial = material.NewEmissive(emission)
 			}
 		}
 
@@ -2,11 +2,19 @@ package scene
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
 	"github.com/df07/go-progressive-raytracer/pkg/loaders"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
@@ -51,11 +59,23 @@ func TestConvertMaterial(t *testing.T) {
 			},
 			expected: "*material.Dielectric",
 		},
+		{
+			name: "hair material",
+			stmt: &loaders.PBRTStatement{
+				Type:    "Material",
+				Subtype: "hair",
+				Parameters: map[string]loaders.PBRTParam{
+					"eumelanin": {Type: "float", Values: []string{"8"}},
+					"beta_m":    {Type: "float", Values: []string{"0.25"}},
+				},
+			},
+			expected: "*material.Hair",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mat, err := convertMaterial(tt.stmt)
+			mat, err := convertMaterial(tt.stmt, nil)
 			if err != nil {
 				t.Fatalf("convertMaterial() error = %v", err)
 			}
@@ -68,6 +88,27 @@ func TestConvertMaterial(t *testing.T) {
 	}
 }
 
+func TestConvertMaterial_GlassSpectrum(t *testing.T) {
+	parsed, err := loaders.ParsePBRT(strings.NewReader("WorldBegin\n" + `Material "dielectric" "spectrum eta" "glass-BK7"`))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	mat, err := convertMaterial(&parsed.Materials[0], nil)
+	if err != nil {
+		t.Fatalf("convertMaterial() error = %v", err)
+	}
+	if glass, ok := mat.(*material.Dielectric); !ok || glass.Glass != "BK7" || math.Abs(glass.RefractiveIndex-1.5168) > 1e-4 {
+		t.Errorf("Expected dispersive BK7 with index 1.5168, got %+v", mat)
+	}
+
+	stmt := &loaders.PBRTStatement{Type: "Material", Subtype: "dielectric", Parameters: map[string]loaders.PBRTParam{
+		"eta": {Type: "spectrum", Values: []string{`"glass-unobtainium"`}},
+	}}
+	if _, err := convertMaterial(stmt, nil); err == nil || !strings.Contains(err.Error(), "unknown glass") {
+		t.Errorf("Expected an unknown glass error, got %v", err)
+	}
+}
+
 func TestConvertShape(t *testing.T) {
 	// Test sphere conversion
 	sphereStmt := &loaders.PBRTStatement{
@@ -108,6 +149,380 @@ func TestConvertShape(t *testing.T) {
 	if fmt.Sprintf("%T", shape) != "*geometry.Quad" {
 		t.Errorf("convertShape(bilinearPatch) type = %T, want *geometry.Quad", shape)
 	}
+	// Test capsule conversion
+	capsuleStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "capsule",
+		Parameters: map[string]loaders.PBRTParam{
+			"p0":     {Type: "point3", Values: []string{"-1", "2", "0"}},
+			"p1":     {Type: "point3", Values: []string{"1", "2", "0"}},
+			"radius": {Type: "float", Values: []string{"0.1"}},
+		},
+	}
+
+	shape, err = convertShape(capsuleStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(capsule) error = %v", err)
+	}
+
+	if fmt.Sprintf("%T", shape) != "*geometry.Capsule" {
+		t.Errorf("convertShape(capsule) type = %T, want *geometry.Capsule", shape)
+	}
+
+	// Capsule endpoints are required
+	delete(capsuleStmt.Parameters, "p1")
+	if _, err := convertShape(capsuleStmt, mat); err == nil {
+		t.Error("convertShape(capsule) without p1 should fail")
+	}
+
+	// Test disk conversion: a ring light facing down from the ceiling
+	diskStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "disk",
+		Parameters: map[string]loaders.PBRTParam{
+			"center":      {Type: "point3", Values: []string{"0", "3", "0"}},
+			"normal":      {Type: "normal", Values: []string{"0", "-1", "0"}},
+			"radius":      {Type: "float", Values: []string{"1"}},
+			"innerradius": {Type: "float", Values: []string{"0.5"}},
+		},
+	}
+
+	shape, err = convertShape(diskStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(disk) error = %v", err)
+	}
+	if disc, ok := shape.(*geometry.Disc); !ok || disc.InnerRadius != 0.5 || disc.Radius != 1 || disc.Center.Y != 3 {
+		t.Errorf("convertShape(disk) = %+v, want an annulus of radii 0.5 to 1 at y=3", shape)
+	}
+
+	diskStmt.Parameters["innerradius"] = loaders.PBRTParam{Type: "float", Values: []string{"1"}}
+	if _, err := convertShape(diskStmt, mat); err == nil {
+		t.Error("convertShape(disk) with innerradius equal to radius should fail")
+	}
+
+	// Test torus conversion
+	torusStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "torus",
+		Parameters: map[string]loaders.PBRTParam{
+			"center":      {Type: "point3", Values: []string{"0", "1", "0"}},
+			"axis":        {Type: "normal", Values: []string{"0", "1", "0"}},
+			"majorradius": {Type: "float", Values: []string{"2"}},
+			"minorradius": {Type: "float", Values: []string{"0.5"}},
+		},
+	}
+
+	shape, err = convertShape(torusStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(torus) error = %v", err)
+	}
+	if bbox := shape.BoundingBox(); fmt.Sprintf("%T", shape) != "*geometry.Torus" || bbox.Min.Y != 0.5 || bbox.Max.X != 2.5 {
+		t.Errorf("convertShape(torus) = %T with bounds %v, want a *geometry.Torus in [-2.5, 0.5, -2.5] to [2.5, 1.5, 2.5]", shape, bbox)
+	}
+
+	// The tube must be thinner than the ring
+	torusStmt.Parameters["minorradius"] = loaders.PBRTParam{Type: "float", Values: []string{"3"}}
+	if _, err := convertShape(torusStmt, mat); err == nil {
+		t.Error("convertShape(torus) with minor radius above the major radius should fail")
+	}
+
+	// Test curve conversion: a B-spline strand narrowing from 0.2 to 0.1 wide
+	curveStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "curve",
+		Parameters: map[string]loaders.PBRTParam{
+			"P":      {Type: "point3", Values: []string{"-2", "0", "0", "-1", "0", "0", "0", "0", "0", "1", "0", "0", "2", "0", "0"}},
+			"basis":  {Type: "string", Values: []string{"bspline"}},
+			"width0": {Type: "float", Values: []string{"0.2"}},
+			"width1": {Type: "float", Values: []string{"0.1"}},
+		},
+	}
+	shape, err = convertShape(curveStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(curve) error = %v", err)
+	}
+	if curves, ok := shape.(*geometry.Curves); !ok || curves.GetSegmentCount() != 16 {
+		t.Errorf("convertShape(curve) = %T, want *geometry.Curves split into 16 pieces", shape)
+	}
+	if _, isHit := shape.Hit(core.NewRay(core.NewVec3(0, 1, 0), core.NewVec3(0, -1, 0)), 0.001, 10); !isHit {
+		t.Error("convertShape(curve) expected hit")
+	}
+
+	curveStmt.Parameters["type"] = loaders.PBRTParam{Type: "string", Values: []string{"ribbon"}}
+	if _, err := convertShape(curveStmt, mat); err == nil {
+		t.Error("convertShape(curve) ribbon without normals should fail")
+	}
+
+	// Test trianglemesh conversion with per-vertex normals (smooth shading)
+	meshStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "trianglemesh",
+		Parameters: map[string]loaders.PBRTParam{
+			"P":       {Type: "point3", Values: []string{"0", "0", "0", "1", "0", "0", "0", "1", "0"}},
+			"N":       {Type: "normal", Values: []string{"0", "0", "1", "1", "0", "1", "0", "0", "1"}},
+			"indices": {Type: "integer", Values: []string{"0", "1", "2"}},
+		},
+	}
+
+	shape, err = convertShape(meshStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(trianglemesh) error = %v", err)
+	}
+
+	// At vertex 1 the shading normal is that vertex's tilted normal, not the face normal
+	hit, isHit := shape.Hit(core.NewRay(core.NewVec3(0.999, 0.0005, 1), core.NewVec3(0, 0, -1)), 0.001, 10)
+	if !isHit {
+		t.Fatal("convertShape(trianglemesh) expected hit")
+	}
+	if expected := core.NewVec3(1, 0, 1).Normalize(); hit.Normal.Subtract(expected).Length() > 0.01 {
+		t.Errorf("convertShape(trianglemesh) normal = %v, want %v", hit.Normal, expected)
+	}
+
+	// Normals must match the vertices
+	meshStmt.Parameters["N"] = loaders.PBRTParam{Type: "normal", Values: []string{"0", "0", "1"}}
+	if _, err := convertShape(meshStmt, mat); err == nil {
+		t.Error("convertShape(trianglemesh) with too few normals should fail")
+	}
+
+	// Test loopsubdiv conversion: a tetrahedron refined twice
+	subdivStmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "loopsubdiv",
+		Parameters: map[string]loaders.PBRTParam{
+			"levels":  {Type: "integer", Values: []string{"2"}},
+			"P":       {Type: "point3", Values: []string{"1", "1", "1", "1", "-1", "-1", "-1", "1", "-1", "-1", "-1", "1"}},
+			"indices": {Type: "integer", Values: []string{"0", "1", "2", "0", "3", "1", "0", "2", "3", "1", "3", "2"}},
+		},
+	}
+	shape, err = convertShape(subdivStmt, mat)
+	if err != nil {
+		t.Fatalf("convertShape(loopsubdiv) error = %v", err)
+	}
+	if mesh, ok := shape.(*geometry.TriangleMesh); !ok || mesh.GetTriangleCount() != 64 {
+		t.Errorf("convertShape(loopsubdiv) expected a 64 triangle mesh, got %T", shape)
+	}
+
+	// Subdivision pulls the surface inside the control mesh, away from its corners
+	if _, isHit := shape.Hit(core.NewRay(core.NewVec3(5, 5, 5), core.NewVec3(-1, -1, -1).Normalize()), 0.001, 100); !isHit {
+		t.Error("convertShape(loopsubdiv) expected hit")
+	} else if bbox := shape.BoundingBox(); bbox.Max.X >= 1 {
+		t.Errorf("convertShape(loopsubdiv) bounds %v should shrink inside the control mesh", bbox)
+	}
+
+	subdivStmt.Parameters["levels"] = loaders.PBRTParam{Type: "integer", Values: []string{"-1"}}
+	if _, err := convertShape(subdivStmt, mat); err == nil {
+		t.Error("convertShape(loopsubdiv) with negative levels should fail")
+	}
+}
+
+func TestNewPBRTScene_Transforms(t *testing.T) {
+	content := `Translate 0 1 0
+LookAt 0 0 -5  0 0 0  0 1 0
+Camera "perspective"
+WorldBegin
+Material "diffuse"
+AttributeBegin
+    Translate 0 2 0
+    Scale 3 3 3
+    Shape "sphere" "float radius" 0.5
+AttributeEnd
+AttributeBegin
+    Translate 1 0 0
+    Rotate 90 1 0 0
+    Shape "bilinearPatch" "point3 P00" [0 0 0] "point3 P01" [1 0 0] "point3 P10" [0 1 0] "point3 P11" [1 1 0]
+    Shape "trianglemesh" "point3 P" [0 0 0 1 0 0 0 1 0] "integer indices" [0 1 2]
+    Shape "disk" "float radius" 2
+AttributeEnd
+AttributeBegin
+    Translate 0 3 0
+    LightSource "point" "point3 from" [1 0 0]
+AttributeEnd
+WorldEnd`
+	pbrtScene, err := loaders.ParsePBRT(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParsePBRT() error = %v", err)
+	}
+	scene, err := NewPBRTScene(pbrtScene)
+	if err != nil {
+		t.Fatalf("NewPBRTScene() error = %v", err)
+	}
+
+	// Transforms before LookAt move the camera in its own space: down 1 here
+	if !scene.CameraConfig.Center.Equals(core.NewVec3(0, -1, -5)) || !scene.CameraConfig.LookAt.Equals(core.NewVec3(0, -1, 0)) {
+		t.Errorf("Expected the camera at (0,-1,-5) looking at (0,-1,0), got %v and %v", scene.CameraConfig.Center, scene.CameraConfig.LookAt)
+	}
+
+	sphere := scene.Shapes[0].(*geometry.Sphere)
+	if !sphere.Center.Equals(core.NewVec3(0, 2, 0)) || math.Abs(sphere.Radius-1.5) > 1e-9 {
+		t.Errorf("Expected a sphere of radius 1.5 at (0,2,0), got %v and %v", sphere.Center, sphere.Radius)
+	}
+	quad := scene.Shapes[1].(*geometry.Quad)
+	if !quad.Corner.Equals(core.NewVec3(1, 0, 0)) || !quad.U.Equals(core.NewVec3(1, 0, 0)) || !quad.V.Equals(core.NewVec3(0, 0, 1)) {
+		t.Errorf("Expected the patch turned flat at x=1, got corner %v, u %v, v %v", quad.Corner, quad.U, quad.V)
+	}
+	mesh := scene.Shapes[2].(*geometry.TriangleMesh)
+	if hit, isHit := mesh.Hit(core.NewRay(core.NewVec3(1.2, 5, 0.2), core.NewVec3(0, -1, 0)), 0.001, math.Inf(1)); !isHit || math.Abs(hit.T-5) > 1e-9 {
+		t.Errorf("Expected the mesh turned flat on y=0, got %v", hit)
+	}
+	disc := scene.Shapes[3].(*geometry.Disc)
+	if !disc.Center.Equals(core.NewVec3(1, 0, 0)) || math.Abs(math.Abs(disc.Normal.Y)-1) > 1e-9 {
+		t.Errorf("Expected the disk facing along y at (1,0,0), got %v and %v", disc.Center, disc.Normal)
+	}
+	light := scene.Lights[0].(*lights.SphereLight)
+	if !light.Center.Equals(core.NewVec3(1, 3, 0)) {
+		t.Errorf("Expected the point light at (1,3,0), got %v", light.Center)
+	}
+}
+
+func TestConvertShape_TransformedBox(t *testing.T) {
+	boxStmt := func(transform core.Matrix4) *loaders.PBRTStatement {
+		return &loaders.PBRTStatement{
+			Type:    "Shape",
+			Subtype: "box",
+			Parameters: map[string]loaders.PBRTParam{
+				"center":   {Type: "point3", Values: []string{"1", "0", "0"}},
+				"size":     {Type: "point3", Values: []string{"1", "2", "3"}},
+				"rotation": {Type: "point3", Values: []string{"0.2", "0.3", "0"}},
+			},
+			Transform: &transform,
+		}
+	}
+	corners := func(box *geometry.Box) []core.Vec3 {
+		var corners []core.Vec3
+		for _, sign := range []core.Vec3{{X: 1, Y: 1, Z: 1}, {X: -1, Y: 1, Z: 1}, {X: 1, Y: -1, Z: 1}, {X: 1, Y: 1, Z: -1},
+			{X: -1, Y: -1, Z: 1}, {X: -1, Y: 1, Z: -1}, {X: 1, Y: -1, Z: -1}, {X: -1, Y: -1, Z: -1}} {
+			corners = append(corners, box.Center.Add(box.Size.MultiplyVec(sign).Rotate(box.Rotation)))
+		}
+		return corners
+	}
+
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+	untransformed, err := convertShape(boxStmt(core.IdentityMatrix()), mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, transform := range map[string]core.Matrix4{
+		"rotated":  core.TranslationMatrix(core.NewVec3(0, 1, 2)).Multiply(core.RotationMatrix(1, core.NewVec3(1, 1, 0))),
+		"scaled":   core.ScalingMatrix(core.NewVec3(2, 2, 2)).Multiply(core.RotationMatrix(math.Pi/2, core.NewVec3(0, 0, 1))),
+		"mirrored": core.ScalingMatrix(core.NewVec3(-1, 1, 1)).Multiply(core.RotationMatrix(0.5, core.NewVec3(0, 1, 0))),
+	} {
+		shape, err := convertShape(boxStmt(transform), mat)
+		if err != nil {
+			t.Fatalf("%s: convertShape(box) error = %v", name, err)
+		}
+		// The box has the transformed box's corners, in some order
+		got := corners(shape.(*geometry.Box))
+		for _, corner := range corners(untransformed.(*geometry.Box)) {
+			want := transform.TransformPoint(corner)
+			found := false
+			for _, c := range got {
+				found = found || c.Subtract(want).Length() < 1e-9
+			}
+			if !found {
+				t.Errorf("%s: expected a corner at %v, got %v", name, want, got)
+			}
+		}
+	}
+}
+
+func TestConvertShape_UnevenScale(t *testing.T) {
+	stretch := core.ScalingMatrix(core.NewVec3(1, 2, 1))
+	mat := material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))
+
+	// Meshes and patches take any affine transform
+	patch := &loaders.PBRTStatement{Type: "Shape", Subtype: "bilinearPatch", Transform: &stretch, Parameters: map[string]loaders.PBRTParam{
+		"P00": {Type: "point3", Values: []string{"0", "0", "0"}},
+		"P01": {Type: "point3", Values: []string{"1", "0", "0"}},
+		"P10": {Type: "point3", Values: []string{"0", "1", "0"}},
+		"P11": {Type: "point3", Values: []string{"1", "1", "0"}},
+	}}
+	if shape, err := convertShape(patch, mat); err != nil || !shape.(*geometry.Quad).V.Equals(core.NewVec3(0, 2, 0)) {
+		t.Errorf("Expected a stretched patch, got %v, %v", shape, err)
+	}
+
+	// Round shapes can't be stretched
+	for _, subtype := range []string{"sphere", "disk", "torus", "box"} {
+		stmt := &loaders.PBRTStatement{Type: "Shape", Subtype: subtype, Transform: &stretch, Parameters: map[string]loaders.PBRTParam{}}
+		if _, err := convertShape(stmt, mat); err == nil || !strings.Contains(err.Error(), "can't be scaled unevenly") {
+			t.Errorf("%s: expected an uneven scale error, got %v", subtype, err)
+		}
+	}
+}
+
+func TestConvertAreaLight_Annulus(t *testing.T) {
+	stmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "disk",
+		Parameters: map[string]loaders.PBRTParam{
+			"height":      {Type: "float", Values: []string{"2"}},
+			"radius":      {Type: "float", Values: []string{"1"}},
+			"innerradius": {Type: "float", Values: []string{"0.25"}},
+			"L":           {Type: "rgb", Values: []string{"5", "5", "5"}},
+		},
+	}
+
+	light, err := convertAreaLight(stmt)
+	if err != nil {
+		t.Fatalf("convertAreaLight(disk) error = %v", err)
+	}
+	if fmt.Sprintf("%T", light) != "*lights.DiscLight" {
+		t.Fatalf("convertAreaLight(disk) type = %T, want *lights.DiscLight", light)
+	}
+
+	// Rays through the hole miss the light
+	shape := light.(geometry.Shape)
+	if _, isHit := shape.Hit(core.NewRay(core.NewVec3(0.1, 0, 0), core.NewVec3(0, 0, 1)), 0.001, 10); isHit {
+		t.Error("convertAreaLight(disk) expected rays through the hole to miss")
+	}
+	if hit, isHit := shape.Hit(core.NewRay(core.NewVec3(0.5, 0, 0), core.NewVec3(0, 0, 1)), 0.001, 10); !isHit || hit.T != 2 {
+		t.Error("convertAreaLight(disk) expected rays through the ring to hit at z=2")
+	}
+}
+
+func TestConvertAreaLight_Capsule(t *testing.T) {
+	stmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "capsule",
+		Parameters: map[string]loaders.PBRTParam{
+			"p0":     {Type: "point3", Values: []string{"-1", "2", "0"}},
+			"p1":     {Type: "point3", Values: []string{"1", "2", "0"}},
+			"radius": {Type: "float", Values: []string{"0.1"}},
+			"L":      {Type: "rgb", Values: []string{"10", "10", "10"}},
+		},
+	}
+
+	light, err := convertAreaLight(stmt)
+	if err != nil {
+		t.Fatalf("convertAreaLight(capsule) error = %v", err)
+	}
+	if fmt.Sprintf("%T", light) != "*lights.ShapeLight" {
+		t.Errorf("convertAreaLight(capsule) type = %T, want *lights.ShapeLight", light)
+	}
+}
+
+func TestConvertAreaLight_BlackbodyPower(t *testing.T) {
+	// The PBRT book's example: a 100 W sphere at 6500 K
+	stmt := &loaders.PBRTStatement{
+		Type:    "Shape",
+		Subtype: "sphere",
+		Parameters: map[string]loaders.PBRTParam{
+			"radius": {Type: "float", Values: []string{"0.25"}},
+			"L":      {Type: "blackbody", Values: []string{"6500"}},
+			"power":  {Type: "float", Values: []string{"100"}},
+		},
+	}
+
+	light, err := convertAreaLight(stmt)
+	if err != nil {
+		t.Fatalf("convertAreaLight(sphere) error = %v", err)
+	}
+	emission := light.(*lights.SphereLight).Material.(*material.Emissive).Emission
+	if want := 100 / (math.Pi * 4 * math.Pi * 0.25 * 0.25); math.Abs(emission.Luminance()-want) > 0.01*want {
+		t.Errorf("Radiance luminance = %f, want %f", emission.Luminance(), want)
+	}
+	if color := lights.BlackbodyColor(6500).Multiply(emission.Luminance()); color.Subtract(emission).Length() > 1e-9 {
+		t.Errorf("Expected the 6500 K color %v, got %v", color, emission)
+	}
 }
 
 func TestConvertLight(t *testing.T) {
@@ -139,7 +554,7 @@ func TestConvertLight(t *testing.T) {
 					"to":   {Type: "point3", Values: []string{"0", "0", "1"}},
 				},
 			},
-			expected: "*lights.UniformInfiniteLight",
+			expected: "*lights.DirectionalLight",
 		},
 		{
 			name: "infinite light",
@@ -152,6 +567,18 @@ func TestConvertLight(t *testing.T) {
 			},
 			expected: "*lights.UniformInfiniteLight",
 		},
+		{
+			name: "infinite light with a portal",
+			stmt: &loaders.PBRTStatement{
+				Type:    "LightSource",
+				Subtype: "infinite",
+				Parameters: map[string]loaders.PBRTParam{
+					"L":      {Type: "rgb", Values: []string{"1", "1", "1"}},
+					"portal": {Type: "point3", Values: []string{"-1", "0", "-3", "1", "0", "-3", "1", "1", "-3", "-1", "1", "-3"}},
+				},
+			},
+			expected: "*lights.PortalLight",
+		},
 	}
 
 	for _, tt := range tests {
@@ -226,6 +653,99 @@ func TestConvertCamera(t *testing.T) {
 	}
 }
 
+func TestNewPBRTScene_NamedCameras(t *testing.T) {
+	content := `TransformBegin
+  LookAt 0 0 5  0 0 0  0 1 0
+  Camera "perspective" "string name" "front" "float fov" 30
+TransformEnd
+TransformBegin
+  LookAt 0 5 0  0 0 0  0 0 -1
+  Camera "perspective" "string name" "top" "float lensradius" 0.1
+TransformEnd
+LookAt 3 0 0  0 0 0  0 1 0
+Camera "perspective" "float fov" 40
+Film "rgb" "integer xresolution" 80 "integer yresolution" 40
+WorldBegin
+Material "diffuse"
+Shape "sphere" "float radius" 1
+`
+	pbrtScene, err := loaders.ParsePBRT(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse PBRT content: %v", err)
+	}
+	scene, err := NewPBRTScene(pbrtScene, geometry.CameraConfig{Width: 60, AspectRatio: 1.5})
+	if err != nil {
+		t.Fatalf("NewPBRTScene() error = %v", err)
+	}
+
+	// The unnamed camera, last, is the default; the named ones wait in Cameras
+	if !scene.CameraConfig.Center.Equals(core.NewVec3(3, 0, 0)) || scene.CameraConfig.VFov != 40 {
+		t.Errorf("Expected the default camera at (3, 0, 0) with fov 40, got %+v", scene.CameraConfig)
+	}
+	if names := scene.CameraNames(); !reflect.DeepEqual(names, []string{"front", "top"}) {
+		t.Fatalf("Expected cameras front and top, got %v", names)
+	}
+
+	if err := scene.UseCamera("top"); err != nil {
+		t.Fatalf("UseCamera(top) error = %v", err)
+	}
+	top := scene.CameraConfig
+	if !top.Center.Equals(core.NewVec3(0, 5, 0)) || !top.LookAt.Equals(core.NewVec3(0, 0, 0)) || top.Aperture != 0.2 {
+		t.Errorf("Expected the top camera at (0, 5, 0) looking down with aperture 0.2, got %+v", top)
+	}
+	// Switching cameras keeps the overridden image size
+	if top.Width != 60 || top.AspectRatio != 1.5 {
+		t.Errorf("Expected the 60 wide 1.5 aspect image kept, got %d and %v", top.Width, top.AspectRatio)
+	}
+
+	if err := scene.UseCamera("side"); err == nil || !strings.Contains(err.Error(), "front, top") {
+		t.Errorf("Expected an unknown camera error listing the cameras, got %v", err)
+	}
+
+	_, err = loaders.ParsePBRT(strings.NewReader(`Camera "perspective" "string name" "a"
+Camera "perspective" "string name" "a"
+WorldBegin
+`))
+	if err == nil || !strings.Contains(err.Error(), `duplicate camera name "a"`) {
+		t.Errorf("Expected a duplicate camera name error, got %v", err)
+	}
+}
+
+func TestNewPBRTScene_PixelFilter(t *testing.T) {
+	tests := []struct {
+		statement string
+		filter    string
+		radius    float64
+	}{
+		{"", "", 0},
+		{`PixelFilter "mitchell"`, "mitchell", 0},
+		{`PixelFilter "gaussian" "float xradius" 1 "float yradius" 1`, "gaussian", 1},
+		{`PixelFilter "triangle"`, "tent", 2},
+	}
+	for _, tt := range tests {
+		parsed, err := loaders.ParsePBRT(strings.NewReader(tt.statement + "\nWorldBegin\n"))
+		if err != nil {
+			t.Fatalf("%q: failed to parse: %v", tt.statement, err)
+		}
+		scene, err := NewPBRTScene(parsed)
+		if err != nil {
+			t.Fatalf("%q: NewPBRTScene() error = %v", tt.statement, err)
+		}
+		if scene.SamplingConfig.Filter != tt.filter || scene.SamplingConfig.FilterRadius != tt.radius {
+			t.Errorf("%q: expected filter %q radius %v, got %q radius %v", tt.statement, tt.filter, tt.radius,
+				scene.SamplingConfig.Filter, scene.SamplingConfig.FilterRadius)
+		}
+	}
+
+	parsed, err := loaders.ParsePBRT(strings.NewReader("PixelFilter \"sinc\"\nWorldBegin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPBRTScene(parsed); err == nil || !strings.Contains(err.Error(), "unsupported pixel filter") {
+		t.Errorf("Expected an unsupported pixel filter error, got %v", err)
+	}
+}
+
 func TestNewPBRTSceneIntegration(t *testing.T) {
 	// Create a complete PBRT scene file for integration testing
 	content := `# Integration test PBRT scene
@@ -322,6 +842,102 @@ WorldEnd
 	}
 }
 
+func TestNewPBRTScene_Textures(t *testing.T) {
+	dir := t.TempDir()
+	pixels := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	pixels.Set(0, 0, color.RGBA{R: 255, G: 128, A: 255})
+	pixels.Set(1, 0, color.RGBA{B: 255, A: 255})
+	imageFile, err := os.Create(filepath.Join(dir, "wood.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(imageFile, pixels); err != nil {
+		t.Fatal(err)
+	}
+	imageFile.Close()
+
+	content := `WorldBegin
+Texture "wood" "spectrum" "imagemap" "string filename" "wood.png"
+Texture "grid" "spectrum" "checkerboard" "float uscale" 2 "float vscale" 2
+    "texture tex1" "wood" "rgb tex2" [0 0 1]
+Texture "dim" "spectrum" "scale" "texture tex" "grid" "float scale" 0.5
+Material "diffuse" "texture reflectance" "dim"
+Shape "trianglemesh" "point3 P" [0 0 0 1 0 0 0 1 0] "integer indices" [0 1 2] "point2 uv" [0 0 1 0 0 1]
+AttributeBegin
+    Material "conductor" "texture reflectance" "wood" "float roughness" 0.2
+    Shape "sphere"
+AttributeEnd
+WorldEnd`
+	filename := filepath.Join(dir, "textured.pbrt")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pbrtScene, err := loaders.LoadPBRT(filename)
+	if err != nil {
+		t.Fatalf("LoadPBRT() error = %v", err)
+	}
+	scene, err := NewPBRTScene(pbrtScene)
+	if err != nil {
+		t.Fatalf("NewPBRTScene() error = %v", err)
+	}
+
+	// The mesh carries its uvs to hits
+	mesh := scene.Shapes[0].(*geometry.TriangleMesh)
+	hit, isHit := mesh.Hit(core.NewRay(core.NewVec3(0.25, 0.5, 1), core.NewVec3(0, 0, -1)), 0.001, math.Inf(1))
+	if !isHit || math.Abs(hit.UV.X-0.25) > 1e-9 || math.Abs(hit.UV.Y-0.5) > 1e-9 {
+		t.Fatalf("Expected a hit at uv (0.25, 0.5), got %v", hit)
+	}
+
+	// Half-scaled checks of the sRGB-decoded image and of blue, two squares across the mesh
+	woodLeft := core.NewVec3(1, math.Pow(128.0/255, 2.2), 0)
+	albedo := hit.Material.(*material.Lambertian).Albedo
+	for _, tt := range []struct {
+		uv   core.Vec2
+		want core.Vec3
+	}{
+		{core.NewVec2(0.1, 0.1), woodLeft.Multiply(0.5)},
+		{core.NewVec2(0.6, 0.1), core.NewVec3(0, 0, 0.5)},
+		{core.NewVec2(0.8, 0.6), core.NewVec3(0, 0, 0.5)}, // An even square, on the image's blue right half
+	} {
+		if got := albedo.Evaluate(tt.uv, core.Vec3{}); !got.Equals(tt.want) {
+			t.Errorf("UV %v: expected %v, got %v", tt.uv, tt.want, got)
+		}
+	}
+
+	metal, ok := scene.Shapes[1].(*geometry.Sphere).Material.(*material.Metal)
+	if !ok {
+		t.Fatalf("Expected a metal sphere, got %T", scene.Shapes[1].(*geometry.Sphere).Material)
+	}
+	if got := metal.Albedo.Evaluate(core.NewVec2(0.1, 0.5), core.Vec3{}); !got.Equals(woodLeft) {
+		t.Errorf("Expected the metal's reflectance from the image, got %v", got)
+	}
+}
+
+func TestNewPBRTScene_TextureErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`Material "diffuse" "texture reflectance" "missing"`, `unknown texture "missing"`},
+		{`Texture "t" "spectrum"`, `texture "t" needs a type and a class`},
+		{`Texture "t" "spectrum" "marble"`, "unsupported texture class: marble"},
+		{`Texture "t" "spectrum" "imagemap" "string filename" "missing.png"`, "failed to open image file"},
+		{`Texture "t" "spectrum" "checkerboard" "string mapping" "spherical"`, `unsupported texture mapping "spherical"`},
+		{`Texture "t" "spectrum" "checkerboard" "integer dimension" 3`, "unsupported checkerboard dimension 3"},
+		{`Texture "t" "spectrum" "scale" "texture tex" "t"`, `unknown texture "t"`},
+		{`Shape "trianglemesh" "point3 P" [0 0 0 1 0 0 0 1 0] "integer indices" [0 1 2] "point2 uv" [0 0 1 0]`, "trianglemesh has 4 uv values for 3 vertices"},
+	}
+	for _, tt := range tests {
+		pbrtScene, err := loaders.ParsePBRT(strings.NewReader("WorldBegin\nMaterial \"diffuse\"\n" + tt.content + "\nShape \"sphere\"\nWorldEnd"))
+		if err != nil {
+			t.Fatalf("%s: ParsePBRT() error = %v", tt.content, err)
+		}
+		if _, err := NewPBRTScene(pbrtScene); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
+
 func TestPBRTSceneErrorHandling(t *testing.T) {
 	// Test with invalid PBRT content
 	content := `# Invalid PBRT - missing WorldBegin
@@ -409,6 +1025,17 @@ WorldEnd`,
 			expectError: true,
 			errorMsg:    "invalid image width",
 		},
+		{
+			name: "invalid portal - three points",
+			content: `LookAt 0 0 5  0 0 0  0 1 0
+Camera "perspective" "float fov" 40
+Film "rgb" "integer xresolution" 100 "integer yresolution" 100
+WorldBegin
+LightSource "infinite" "rgb L" [1 1 1] "point3 portal" [-1 0 -3  1 0 -3  1 1 -3]
+WorldEnd`,
+			expectError: true,
+			errorMsg:    "portal needs 4 points",
+		},
 		{
 			name: "valid parameters",
 			content: `LookAt 0 0 5  0 0 0  0 1 0
@@ -474,7 +1101,7 @@ func TestConvertLightTypes(t *testing.T) {
 					"L": {Type: "rgb", Values: []string{"3", "3", "3"}},
 				},
 			},
-			expectedType: "*lights.UniformInfiniteLight",
+			expectedType: "*lights.DirectionalLight",
 		},
 		{
 			name: "infinite light",
@@ -0,0 +1,79 @@
+package scene
+
+import (
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// Photometric validation scene: a single spherical light above a diffuse plane, with nothing else
+// to bounce off, so the radiance leaving the plane has a closed form (see PhotometricRadiance).
+// All lengths are multiplied by a scale factor that must not change the image, which catches
+// light PDFs and normalizations that are only correct at one scene size.
+const (
+	photometricLightRadius = 1.0 // Sphere light radius before scaling
+	photometricLightHeight = 3.0 // Height of the light's center above the plane before scaling
+	photometricAlbedo      = 0.5 // Lambertian reflectance of the plane
+	photometricPlaneSize   = 200 // Ground quad size before scaling, large enough to fill the view
+)
+
+// photometricEmission is the radiance of the sphere light
+var photometricEmission = core.NewVec3(4.0, 4.0, 4.0)
+
+// NewPhotometricScene creates the sphere-light-over-plane validation scene at the given scale
+func NewPhotometricScene(scale float64, cameraOverrides ...geometry.CameraConfig) *Scene {
+	defaultCameraConfig := geometry.CameraConfig{
+		Center:        core.NewVec3(0, 4, 8).Multiply(scale), // Looking down at the plane past the light
+		LookAt:        core.NewVec3(0, 0, 0),
+		Up:            core.NewVec3(0, 1, 0),
+		Width:         400,
+		AspectRatio:   1.0,
+		VFov:          50.0,
+		Aperture:      0.0,
+		FocusDistance: 0.0,
+	}
+
+	cameraConfig := defaultCameraConfig
+	if len(cameraOverrides) > 0 {
+		cameraConfig = geometry.MergeCameraConfig(defaultCameraConfig, cameraOverrides[0])
+	}
+
+	camera := geometry.NewCamera(cameraConfig)
+
+	// Only direct lighting exists (the plane can't see itself and the light doesn't scatter),
+	// and every pixel takes all its samples so adaptive stopping can't bias the estimate
+	samplingConfig := SamplingConfig{
+		SamplesPerPixel:           256,
+		MaxDepth:                  4,
+		RussianRouletteMinBounces: 4,
+		AdaptiveMinSamples:        1.0,
+		AdaptiveThreshold:         0.0,
+	}
+
+	s := &Scene{
+		Camera:         camera,
+		Shapes:         make([]geometry.Shape, 0),
+		SamplingConfig: samplingConfig,
+		CameraConfig:   cameraConfig,
+	}
+
+	plane := NewGroundQuad(core.NewVec3(0, 0, 0), photometricPlaneSize*scale, material.NewLambertian(core.NewVec3(photometricAlbedo, photometricAlbedo, photometricAlbedo)))
+	s.Shapes = append(s.Shapes, plane)
+
+	s.AddSphereLight(core.NewVec3(0, photometricLightHeight*scale, 0), photometricLightRadius*scale, photometricEmission)
+
+	return s
+}
+
+// PhotometricRadiance returns the exact radiance leaving the plane of NewPhotometricScene(scale) at point
+// A uniform spherical emitter of radiance L, radius R and center distance D that lies entirely above the
+// horizon gives irradiance E = π L (R/D)² cos θ, where θ is the angle between the plane normal and the
+// direction to the center. A Lambertian plane of albedo ρ reflects L_o = ρ E / π in every direction.
+func PhotometricRadiance(scale float64, point core.Vec3) core.Vec3 {
+	toCenter := core.NewVec3(0, photometricLightHeight*scale, 0).Subtract(point)
+	distance := toCenter.Length()
+	cosTheta := toCenter.Y / distance
+	radius := photometricLightRadius * scale
+
+	return photometricEmission.Multiply(photometricAlbedo * (radius * radius) / (distance * distance) * cosTheta)
+}
@@ -0,0 +1,39 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+)
+
+func TestPhotometricRadiance_BelowLight(t *testing.T) {
+	// Directly below the light: L_o = ρ L (R/D)² with D = light height
+	got := PhotometricRadiance(1.0, core.NewVec3(0, 0, 0))
+	want := photometricEmission.X * photometricAlbedo / (photometricLightHeight * photometricLightHeight)
+	if math.Abs(got.X-want) > 1e-12 {
+		t.Errorf("Expected radiance %f below the light, got %f", want, got.X)
+	}
+}
+
+func TestPhotometricRadiance_ScaleInvariant(t *testing.T) {
+	point := core.NewVec3(1.5, 0, -2)
+	reference := PhotometricRadiance(1.0, point)
+
+	for _, scale := range []float64{0.01, 100} {
+		got := PhotometricRadiance(scale, point.Multiply(scale))
+		if math.Abs(got.X-reference.X) > 1e-12*reference.X {
+			t.Errorf("Scale %g: expected radiance %f, got %f", scale, reference.X, got.X)
+		}
+	}
+}
+
+func TestNewPhotometricScene_Scaled(t *testing.T) {
+	s := NewPhotometricScene(100)
+	if len(s.Lights) != 1 {
+		t.Fatalf("Expected a single light, got %d", len(s.Lights))
+	}
+	if s.CameraConfig.Center.Y != 400 {
+		t.Errorf("Expected camera height scaled to 400, got %f", s.CameraConfig.Center.Y)
+	}
+}
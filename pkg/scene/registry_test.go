@@ -0,0 +1,157 @@
+package scene
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// A material, shape and light of registered types, built from parameters of every kind
+func init() {
+	material.Register("test-tinted", func(params core.Params) (material.Material, error) {
+		tint, ok := params.Vec3("tint")
+		if !ok {
+			return nil, fmt.Errorf("test-tinted needs a tint")
+		}
+		return material.NewLambertian(tint), nil
+	})
+	geometry.Register("test-ball", func(params core.Params, transform core.Matrix4, mat material.Material) (geometry.Shape, error) {
+		radius, _ := params.Float("size")
+		if kind, _ := params.String("kind"); kind == "large" {
+			radius *= 2
+		}
+		return geometry.NewSphere(transform.TransformPoint(core.Vec3{}), radius, mat), nil
+	})
+	lights.Register("test-bulb", func(params core.Params, transform core.Matrix4) (lights.Light, error) {
+		power, _ := params.Float("power")
+		return lights.NewSphereLight(transform.TransformPoint(core.Vec3{}), 0.1, material.NewEmissive(core.NewVec3(power, power, power))), nil
+	})
+	geometry.Register("test-card", func(params core.Params, transform core.Matrix4, mat material.Material) (geometry.Shape, error) {
+		corner := transform.TransformPoint(core.NewVec3(-1, -1, 0))
+		return &testCard{quad: geometry.NewQuad(corner, core.NewVec3(2, 0, 0), core.NewVec3(0, 2, 0), mat)}, nil
+	})
+}
+
+// testCard is a registered shape with an occlusion test of its own that knows nothing of
+// materials, as one written outside the geometry package would
+type testCard struct {
+	quad *geometry.Quad
+}
+
+func (c *testCard) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	return c.quad.Hit(ray, tMin, tMax)
+}
+
+func (c *testCard) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	_, isHit := c.quad.Hit(ray, tMin, tMax)
+	return isHit
+}
+
+func (c *testCard) BoundingBox() geometry.AABB {
+	return c.quad.BoundingBox()
+}
+
+// checkRegisteredTypes checks a scene holds one each of the test's registered types: a tinted
+// ball of radius 2 at center and a bulb of power 5
+func checkRegisteredTypes(t *testing.T, s *Scene, center core.Vec3) {
+	t.Helper()
+	if len(s.Shapes) != 1 || len(s.Lights) != 1 {
+		t.Fatalf("Expected a shape and a light, got %d and %d", len(s.Shapes), len(s.Lights))
+	}
+	ball, ok := s.Shapes[0].(*geometry.Sphere)
+	if !ok || ball.Center != center || ball.Radius != 2 {
+		t.Errorf("Expected a ball of radius 2 at %v, got %#v", center, s.Shapes[0])
+	}
+	if lambertian, ok := ball.Material.(*material.Lambertian); !ok || lambertian.Albedo.Evaluate(core.Vec2{}, core.Vec3{}) != core.NewVec3(0.1, 0.2, 0.3) {
+		t.Errorf("Expected the ball's tinted material, got %#v", ball.Material)
+	}
+	bulb, ok := s.Lights[0].(*lights.SphereLight)
+	if !ok || bulb.Center != center {
+		t.Errorf("Expected a bulb at %v, got %#v", center, s.Lights[0])
+	}
+}
+
+func TestRegisteredTypes_SceneFile(t *testing.T) {
+	file, err := ParseSceneFile([]byte(`
+materials:
+  tinted: {type: test-tinted, params: {tint: [0.1, 0.2, 0.3]}}
+shapes:
+  - {type: test-ball, material: tinted, params: {size: 1, kind: large}}
+lights:
+  - {type: test-bulb, params: {power: 5}}
+`), true)
+	if err != nil {
+		t.Fatalf("ParseSceneFile failed: %v", err)
+	}
+	s, err := NewFileScene(file, "")
+	if err != nil {
+		t.Fatalf("NewFileScene failed: %v", err)
+	}
+	checkRegisteredTypes(t, s, core.Vec3{})
+
+	// The factories' errors are the scene's
+	file.Materials["tinted"] = MaterialFile{Type: "test-tinted", Params: fileParams{"tint": "red"}}
+	if _, err := NewFileScene(file, ""); err == nil || !strings.Contains(err.Error(), "needs a tint") {
+		t.Errorf("Expected the factory's error, got %v", err)
+	}
+	file.Materials["tinted"] = MaterialFile{Type: "test-unregistered"}
+	if _, err := NewFileScene(file, ""); err == nil {
+		t.Error("Expected an error for a type that isn't registered")
+	}
+}
+
+func TestRegisteredTypes_PBRT(t *testing.T) {
+	parsed, err := loaders.ParsePBRT(strings.NewReader(`
+WorldBegin
+Material "test-tinted" "rgb tint" [0.1 0.2 0.3]
+AttributeBegin
+  Translate 1 2 3
+  Shape "test-ball" "float size" 1 "string kind" "large"
+  LightSource "test-bulb" "float power" 5
+AttributeEnd
+`))
+	if err != nil {
+		t.Fatalf("ParsePBRT failed: %v", err)
+	}
+	s, err := NewPBRTScene(parsed)
+	if err != nil {
+		t.Fatalf("NewPBRTScene failed: %v", err)
+	}
+	checkRegisteredTypes(t, s, core.NewVec3(1, 2, 3))
+}
+
+func TestRegisteredTypes_CutoutShadows(t *testing.T) {
+	factory, ok := geometry.Lookup("test-card")
+	if !ok {
+		t.Fatal("Expected test-card to be registered")
+	}
+	alpha := material.NewImageTexture(2, 1, []core.Vec3{{}, core.NewVec3(1, 1, 1)})
+	cutout := material.NewCutout(material.NewLambertian(core.NewVec3(0.8, 0.2, 0.2)), alpha)
+	card, err := factory(nil, core.IdentityMatrix(), cutout)
+	if err != nil {
+		t.Fatalf("Factory failed: %v", err)
+	}
+
+	// Shadow rays see through the card's transparent half, whether it's placed directly or wrapped
+	through := core.NewRay(core.NewVec3(-0.5, 0.3, 5), core.NewVec3(0, 0, -1))
+	solid := core.NewRay(core.NewVec3(0.5, 0.3, 5), core.NewVec3(0, 0, -1))
+	shapes := map[string]geometry.Shape{
+		"direct":  card,
+		"wrapped": geometry.NewTranslated(card, core.Vec3{}),
+	}
+	for name, shape := range shapes {
+		bvh := geometry.NewBVH([]geometry.Shape{shape})
+		if bvh.HitAny(through, 0.001, 6) {
+			t.Errorf("%s: expected the transparent half not to occlude", name)
+		}
+		if !bvh.HitAny(solid, 0.001, 6) {
+			t.Errorf("%s: expected the opaque half to occlude", name)
+		}
+	}
+}
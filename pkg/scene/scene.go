@@ -1,9 +1,16 @@
 package scene
 
 import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
 	"github.com/df07/go-progressive-raytracer/pkg/material"
 )
 
@@ -15,7 +22,20 @@ type Scene struct {
 	LightSampler   lights.LightSampler // Light sampler
 	SamplingConfig SamplingConfig
 	CameraConfig   geometry.CameraConfig
-	BVH            *geometry.BVH // Acceleration structure for ray-object intersection
+	Cameras        map[string]geometry.CameraConfig // Named viewpoints UseCamera switches to, such as a product shot's front and top
+	AutoLights     bool                             // Preprocess makes shapes with emissive materials into area lights, unless they're lights already
+
+	// Ray intersection backend, built from Shapes by Preprocess. IntersectorBuilder selects the
+	// backend (nil = local BVH); BVH is set when the backend is the local BVH.
+	Intersector        geometry.Intersector
+	IntersectorBuilder geometry.IntersectorBuilder
+	BVH                *geometry.BVH
+	WorldCenter        core.Vec3 // Center of the bounding sphere of all shapes, for infinite lights
+	WorldRadius        float64   // Radius of the bounding sphere of all shapes, for infinite lights
+
+	hidesShapes bool // Whether any shape is hidden from some kinds of rays, found by Preprocess
+	prebuilt    bool // Whether the shapes, lights and Intersector are an earlier version's, already preprocessed
+	edited      bool // Whether edits changed the shapes, so later versions can't share them
 }
 
 // SamplingConfig contains rendering configuration
@@ -24,9 +44,68 @@ type SamplingConfig struct {
 	Height                    int     // Image height
 	SamplesPerPixel           int     // Number of rays per pixel
 	MaxDepth                  int     // Maximum ray bounce depth
+	MaxDiffuseDepth           int     // Maximum diffuse bounces on a path (0 = only MaxDepth limits them)
+	MaxGlossyDepth            int     // Maximum glossy and mirror reflections on a path (0 = only MaxDepth limits them)
+	MaxTransmissionDepth      int     // Maximum transmissions, such as refractions, on a path (0 = only MaxDepth limits them)
 	RussianRouletteMinBounces int     // Minimum bounces before Russian Roulette can activate
+	RussianRouletteMinProb    float64 // Lower bound on Russian Roulette survival probability (0 = default 0.05)
 	AdaptiveMinSamples        float64 // Minimum samples as percentage of max samples (0.0-1.0)
 	AdaptiveThreshold         float64 // Relative error threshold for adaptive convergence (0.01 = 1%)
+	PrimaryLightSamples       int     // Path tracing: at primary hits, sample each of this many most important lights once (0 = one light per hit)
+	GuidingPasses             int     // Path tracing: learn a path guiding distribution over this many passes and sample scatter directions with it (0 = no guiding)
+	IrradianceCache           float64 // Path tracing: interpolate primary hits' indirect diffuse light from an irradiance cache of this accuracy, e.g. 0.2 (0 = no cache)
+	IrradianceCachePoints     bool    // Path tracing: show the irradiance cache's records as green dots
+	SampleMask                string  // Grayscale image scaling each pixel's samples per pass: white takes all, black one (empty = none)
+	Filter                    string  // Pixel reconstruction filter: box, tent, gaussian, mitchell or blackman-harris (empty = box)
+	FilterRadius              float64 // Filter radius in pixels (0 = the filter's default, see PixelFilters)
+}
+
+// PixelFilters lists the pixel reconstruction filters, and pixelFilterRadii their default radii
+var PixelFilters = []string{"box", "tent", "gaussian", "mitchell", "blackman-harris"}
+
+var pixelFilterRadii = map[string]float64{
+	"box":             0.5,
+	"tent":            1,
+	"gaussian":        1.5,
+	"mitchell":        2,
+	"blackman-harris": 1.5,
+}
+
+// PixelFilter returns the pixel reconstruction filter and its radius in pixels, with the defaults
+// filled in: box, and the filter's default radius
+func (c SamplingConfig) PixelFilter() (string, float64, error) {
+	name := c.Filter
+	if name == "" {
+		name = "box"
+	}
+	radius, ok := pixelFilterRadii[name]
+	if !ok {
+		return "", 0, fmt.Errorf("unknown pixel filter %q (expected %s)", name, strings.Join(PixelFilters, ", "))
+	}
+	if c.FilterRadius != 0 {
+		radius = c.FilterRadius
+	}
+	if radius < 0.5 || radius > 4 {
+		return "", 0, fmt.Errorf("pixel filter radius %g must be between 0.5 and 4", radius)
+	}
+	return name, radius, nil
+}
+
+// previewMaxDepth is the bounce limit of the preview sampling profile
+const previewMaxDepth = 4
+
+// PreviewProfile returns the sampling settings for stable, low-noise look development previews:
+// no Russian roulette, so every path of a pixel sample runs its full length and the image
+// doesn't flicker from pass to pass, at most 4 bounces, and one light sample per hit. Path
+// guiding and the irradiance cache are off, as they would spend the preview learning.
+func (c SamplingConfig) PreviewProfile() SamplingConfig {
+	c.MaxDepth = min(c.MaxDepth, previewMaxDepth)
+	c.RussianRouletteMinBounces = c.MaxDepth + 1
+	c.PrimaryLightSamples = 0
+	c.GuidingPasses = 0
+	c.IrradianceCache = 0
+	c.IrradianceCachePoints = false
+	return c
 }
 
 // NewGroundQuad creates a large quad to replace infinite ground planes
@@ -43,20 +122,66 @@ func NewGroundQuad(center core.Vec3, size float64, material material.Material) *
 
 // Preprocess prepares the scene for rendering by preprocessing all objects that need it
 func (s *Scene) Preprocess() error {
-	// Create the BVH
-	s.BVH = geometry.NewBVH(s.Shapes)
+	// A scene sharing the shapes, lights and intersector of an earlier version of itself has them
+	// preprocessed already and only needs its own light sampler
+	if s.prebuilt {
+		return s.preprocessLightSampler()
+	}
+
+	// Area lights for emissive shapes must be in place before the light sampler is built from the
+	// lights; a LightSampler the scene set up itself doesn't see them
+	if s.AutoLights {
+		s.addEmissiveLights()
+	}
+
+	// Build the intersection backend
+	build := s.IntersectorBuilder
+	if build == nil {
+		build = geometry.NewBVHIntersector
+	}
+	intersector, err := build(s.Shapes)
+	if err != nil {
+		return fmt.Errorf("failed to build intersector: %v", err)
+	}
+	s.Intersector = intersector
+	s.BVH, _ = intersector.(*geometry.BVH)
+	s.WorldCenter, s.WorldRadius = intersector.BoundingBox().BoundingSphere()
+	return s.preprocessWorld()
+}
 
+// preprocessWorld preprocesses the lights, light sampler and shapes for the world bounds
+func (s *Scene) preprocessWorld() error {
 	// Preprocess all lights that implement the Preprocessor interface
 	for _, light := range s.Lights {
 		if preprocessor, ok := light.(geometry.Preprocessor); ok {
-			if err := preprocessor.Preprocess(s.BVH.Center, s.BVH.Radius); err != nil {
+			if err := preprocessor.Preprocess(s.WorldCenter, s.WorldRadius); err != nil {
 				return err
 			}
 		}
 	}
 
 	// Create the light sampler after lights are preprocessed
-	sceneRadius := s.BVH.Radius
+	if err := s.preprocessLightSampler(); err != nil {
+		return err
+	}
+
+	// Could also preprocess shapes here in the future if needed
+	s.hidesShapes = slices.ContainsFunc(s.Shapes, isHidden)
+	for _, shape := range s.Shapes {
+		if preprocessor, ok := shape.(geometry.Preprocessor); ok {
+			if err := preprocessor.Preprocess(s.WorldCenter, s.WorldRadius); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// preprocessLightSampler creates the default light sampler if the scene has none and builds the
+// caches of spatially varying ones
+func (s *Scene) preprocessLightSampler() error {
+	sceneRadius := s.WorldRadius
 
 	// Use uniform light sampling
 	if s.LightSampler == nil {
@@ -65,18 +190,98 @@ func (s *Scene) Preprocess() error {
 	// Alternative: weighted sampling
 	//s.LightSampler = core.NewWeightedLightSampler(s.Lights, []float64{0.9, 0.1}, sceneRadius)
 
-	// Could also preprocess shapes here in the future if needed
-	for _, shape := range s.Shapes {
-		if preprocessor, ok := shape.(geometry.Preprocessor); ok {
-			if err := preprocessor.Preprocess(s.BVH.Center, s.BVH.Radius); err != nil {
-				return err
-			}
+	// Build the caches of spatially varying samplers over the scene
+	if spatial, ok := s.LightSampler.(lights.SpatialLightSampler); ok {
+		if err := spatial.Preprocess(s.Intersector.BoundingBox()); err != nil {
+			return fmt.Errorf("failed to preprocess light sampler: %v", err)
 		}
 	}
+	return nil
+}
+
+// CameraNames lists the names of the scene's named cameras, sorted
+func (s *Scene) CameraNames() []string {
+	names := make([]string, 0, len(s.Cameras))
+	for name := range s.Cameras {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
+// UseCamera switches the scene to one of its named cameras. The image keeps its size: the named
+// camera only moves the viewpoint and changes the lens.
+func (s *Scene) UseCamera(name string) error {
+	config, ok := s.Cameras[name]
+	if !ok {
+		if len(s.Cameras) == 0 {
+			return fmt.Errorf("unknown camera %q: the scene has no named cameras", name)
+		}
+		return fmt.Errorf("unknown camera %q (the scene has %s)", name, strings.Join(s.CameraNames(), ", "))
+	}
+	config.Width = s.CameraConfig.Width
+	config.AspectRatio = s.CameraConfig.AspectRatio
+	s.CameraConfig = config
+	s.Camera = geometry.NewCamera(config)
+	return nil
+}
+
+// UsePanorama switches the scene to a panorama (see geometry.Projection) seen from center, with
+// the front view along -z and y up, and an image height pixels tall. Panoramas rendered this way
+// light other scenes as environment maps (see AddImageInfiniteLight).
+func (s *Scene) UsePanorama(projection geometry.Projection, center core.Vec3, height int) error {
+	if !slices.Contains(geometry.Panoramas, projection) {
+		return fmt.Errorf("unknown panorama %q (expected equirectangular or cubemap)", projection)
+	}
+	if height <= 0 {
+		return fmt.Errorf("panorama height must be positive, got %d", height)
+	}
+	config := s.CameraConfig
+	config.Center = center
+	config.LookAt = center.Add(core.NewVec3(0, 0, -1))
+	config.Up = core.NewVec3(0, 1, 0)
+	config.AspectRatio = projection.AspectRatio()
+	config.Width = int(float64(height) * config.AspectRatio)
+	config.Aperture = 0
+	config.Projection = projection
+	s.CameraConfig = config
+	s.Camera = geometry.NewCamera(config)
+	s.SamplingConfig.Width, s.SamplingConfig.Height = config.Width, height
 	return nil
 }
 
+// FocusDistanceAt returns the focus distance that brings what pixel (x, y) shows into focus: the
+// depth along the view direction of the surface the ray through the pixel's center hits. The
+// scene must be preprocessed. It fails for pixels outside the image or that see nothing.
+func (s *Scene) FocusDistanceAt(x, y int) (float64, error) {
+	_, hit, err := s.pixelHit(x, y)
+	if err != nil {
+		return 0, err
+	}
+	_, depth, _ := s.Camera.ProjectPoint(hit.Point)
+	return depth, nil
+}
+
+// pixelHit returns the ray through the center of pixel (x, y) and the surface it sees
+func (s *Scene) pixelHit(x, y int) (core.Ray, *material.SurfaceInteraction, error) {
+	if x < 0 || x >= s.SamplingConfig.Width || y < 0 || y >= s.SamplingConfig.Height {
+		return core.Ray{}, nil, fmt.Errorf("pixel (%d, %d) is outside the %dx%d image", x, y, s.SamplingConfig.Width, s.SamplingConfig.Height)
+	}
+	center := core.NewVec2(0.5, 0.5)
+	ray := s.Camera.GetRay(x, y, center, center)
+	hit, isHit := geometry.HitVisible(s.Intersector, ray, 0.001, math.Inf(1), material.CameraRays)
+	if !isHit {
+		return core.Ray{}, nil, fmt.Errorf("nothing to see at pixel (%d, %d)", x, y)
+	}
+	return ray, hit, nil
+}
+
+// SetFocusDistance focuses the camera at a distance along its view direction
+func (s *Scene) SetFocusDistance(distance float64) {
+	s.CameraConfig.FocusDistance = distance
+	s.Camera = geometry.NewCamera(s.CameraConfig)
+}
+
 // GetPrimitiveCount returns the total number of primitive objects in the scene
 func (s *Scene) GetPrimitiveCount() int {
 	count := 0
@@ -89,9 +294,16 @@ func (s *Scene) GetPrimitiveCount() int {
 // countPrimitivesInShape counts primitives in a single shape, handling complex objects
 func (s *Scene) countPrimitivesInShape(shape geometry.Shape) int {
 	switch obj := shape.(type) {
+	case *geometry.VisibilityShape:
+		return s.countPrimitivesInShape(obj.Shape)
+	case *geometry.Translated:
+		return s.countPrimitivesInShape(obj.Shape)
 	case *geometry.TriangleMesh:
 		// Triangle meshes contain multiple triangles
 		return obj.GetTriangleCount()
+	case *geometry.Curves:
+		// Curves are split into pieces with their own bounds
+		return obj.GetSegmentCount()
 	default:
 		// Regular shapes count as 1 primitive each
 		return 1
@@ -114,6 +326,47 @@ func (s *Scene) AddQuadLight(corner, u, v core.Vec3, emission core.Vec3) {
 	s.Shapes = append(s.Shapes, quadLight.Quad)
 }
 
+// AddDiscLight adds a disc area light, or a ring light when innerRadius > 0, facing along normal
+func (s *Scene) AddDiscLight(center, normal core.Vec3, innerRadius, radius float64, emission core.Vec3) {
+	emissiveMat := material.NewEmissive(emission)
+	discLight := lights.NewAnnulusLight(center, normal, innerRadius, radius, emissiveMat)
+	s.Lights = append(s.Lights, discLight)
+	s.Shapes = append(s.Shapes, discLight.Disc)
+}
+
+// AddShapeLight promotes an area-sampleable shape to an area light and adds it to the scene
+// The shape should be constructed with the same emissive material
+func (s *Scene) AddShapeLight(shape geometry.SurfaceSampler, emissiveMat material.Material) {
+	shapeLight := lights.NewShapeLight(shape, emissiveMat)
+	s.Lights = append(s.Lights, shapeLight)
+	s.Shapes = append(s.Shapes, shape)
+}
+
+// AddCylinderLight adds an emissive cylinder (e.g. a neon tube) to the scene
+func (s *Scene) AddCylinderLight(baseCenter, topCenter core.Vec3, radius float64, capped bool, emission core.Vec3) {
+	cylinderLight := lights.NewCylinderLight(baseCenter, topCenter, radius, capped, material.NewEmissive(emission))
+	s.Lights = append(s.Lights, cylinderLight)
+	s.Shapes = append(s.Shapes, cylinderLight.Cylinder)
+}
+
+// AddConeLight adds an emissive cone or frustum (e.g. a lampshade) to the scene
+func (s *Scene) AddConeLight(baseCenter core.Vec3, baseRadius float64, topCenter core.Vec3, topRadius float64, capped bool, emission core.Vec3) error {
+	emissiveMat := material.NewEmissive(emission)
+	cone, err := geometry.NewCone(baseCenter, baseRadius, topCenter, topRadius, capped, emissiveMat)
+	if err != nil {
+		return err
+	}
+	s.AddShapeLight(cone, emissiveMat)
+	return nil
+}
+
+// AddCapsuleLight adds a linear tube light (a capsule around the segment from start to end)
+func (s *Scene) AddCapsuleLight(start, end core.Vec3, radius float64, emission core.Vec3) {
+	emissiveMat := material.NewEmissive(emission)
+	capsule := geometry.NewCapsule(start, end, radius, emissiveMat)
+	s.AddShapeLight(capsule, emissiveMat)
+}
+
 // AddSpotLight adds a disc spot light with custom cone angle and falloff
 func (s *Scene) AddSpotLight(from, to, emission core.Vec3, coneAngleDegrees, coneDeltaAngleDegrees, radius float64) {
 	spotLight := lights.NewDiscSpotLight(from, to, emission, coneAngleDegrees, coneDeltaAngleDegrees, radius)
@@ -128,12 +381,149 @@ func (s *Scene) AddPointSpotLight(from, to, emission core.Vec3, coneAngleDegrees
 	s.Lights = append(s.Lights, spotLight)
 }
 
+// AddIESLight adds a point light with the measured distribution of an IES photometric file,
+// aimed from from at to. The file's intensities in candela are scaled by color.
+func (s *Scene) AddIESLight(filename string, from, to, color core.Vec3) error {
+	data, err := loaders.LoadIES(filename)
+	if err != nil {
+		return err
+	}
+	if data.PhotometricType != 1 {
+		return fmt.Errorf("IES file %s uses type %d photometry; only type C is supported", filename, data.PhotometricType)
+	}
+	profile, err := lights.NewIESProfile(data.VerticalAngles, data.HorizontalAngles, data.Candela)
+	if err != nil {
+		return fmt.Errorf("IES file %s: %v", filename, err)
+	}
+
+	// Point the profile's horizontal angle 0 along the scene's x axis where possible
+	spotLight := lights.NewPointSpotLight(from, to, color.Multiply(profile.PeakCandela()), 180, 0)
+	spotLight.SetProfile(profile, core.NewVec3(1, 0, 0))
+	s.Lights = append(s.Lights, spotLight)
+	return nil
+}
+
+// AddProjectorLight adds a light projecting an image, aimed from from at to with the image
+// upright along up
+func (s *Scene) AddProjectorLight(from, to, up core.Vec3, fovDegrees, aspectRatio float64, image material.ColorSource, intensity core.Vec3) {
+	s.Lights = append(s.Lights, lights.NewProjectorLight(from, to, up, fovDegrees, aspectRatio, image, intensity))
+}
+
+// AddDirectionalLight adds a distant light, like the sun, traveling along direction with the
+// given irradiance and angular diameter in degrees
+func (s *Scene) AddDirectionalLight(direction, irradiance core.Vec3, angularDiameterDegrees float64) {
+	s.Lights = append(s.Lights, lights.NewDirectionalLight(direction, irradiance, angularDiameterDegrees))
+}
+
+// AddPhysicalSkyLight adds a daylight sky and sun (see lights.PhysicalSkyLight) to the scene
+func (s *Scene) AddPhysicalSkyLight(turbidity, sunElevationDegrees, sunAzimuthDegrees, scale float64) {
+	s.Lights = append(s.Lights, lights.NewPhysicalSkyLight(turbidity, sunElevationDegrees, sunAzimuthDegrees, scale))
+}
+
+// AddPortals guides the sampling of the scene's environment lights (infinite lights other than
+// those shining from a small cone, see lights.ConeLight) through portals, quads marking the
+// openings through which they light an interior (see lights.PortalLight). The portals aren't
+// added as shapes; light through the openings themselves is unaffected.
+func (s *Scene) AddPortals(portals ...*geometry.Quad) error {
+	found := false
+	for i, light := range s.Lights {
+		// Lights of a small cone, such as directional ones, sample it already
+		if _, cone := light.(lights.ConeLight); cone || light.Type() != lights.LightTypeInfinite {
+			continue
+		}
+		environment, all := light, portals
+		if portal, ok := light.(*lights.PortalLight); ok {
+			environment = portal.Environment()
+			all = append(append([]*geometry.Quad{}, portal.Portals()...), portals...)
+		}
+		portalLight, err := lights.NewPortalLight(environment, all)
+		if err != nil {
+			return err
+		}
+		s.Lights[i] = portalLight
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("portals need an infinite light")
+	}
+	return nil
+}
+
+// HidesShapes reports whether any of the scene's shapes is hidden from some kinds of rays (see
+// SetVisibility), so integrators can skip the extra visibility work otherwise. Set by Preprocess.
+func (s *Scene) HidesShapes() bool {
+	return s.hidesShapes
+}
+
+// SetVisibility hides one of the scene's shapes from the given kinds of rays: a shape hidden from
+// camera rays doesn't appear in the image but still shows in reflections and casts shadows, one
+// hidden from shadow rays casts no shadows, and one hidden from both camera and indirect rays only
+// casts shadows. Call it before Preprocess.
+func (s *Scene) SetVisibility(shape geometry.Shape, hiddenFrom material.RayKind) error {
+	for i, existing := range s.Shapes {
+		if visibility, ok := existing.(*geometry.VisibilityShape); ok {
+			existing = visibility.Shape
+		}
+		if existing == shape {
+			s.Shapes[i] = geometry.NewVisibilityShape(shape, hiddenFrom)
+			return nil
+		}
+	}
+	return fmt.Errorf("shape is not in the scene")
+}
+
+// SetLightVisibility hides an area light's shape from the given kinds of rays, e.g. from camera
+// rays to light a scene with a fixture that isn't seen. The light still illuminates the scene.
+// Area lights can't be hidden from indirect rays: their light sampling is weighted against the
+// bounces that hit them, which would then be missing.
+func (s *Scene) SetLightVisibility(light lights.Light, hiddenFrom material.RayKind) error {
+	if hiddenFrom&material.IndirectRays != 0 {
+		return fmt.Errorf("lights can't be hidden from indirect rays")
+	}
+	shape := lightShape(light)
+	if shape == nil {
+		return fmt.Errorf("%s has no shape to hide", typeName(light))
+	}
+	return s.SetVisibility(shape, hiddenFrom)
+}
+
+// lightShape returns the shape an area light adds to the scene, nil for lights without one
+func lightShape(light lights.Light) geometry.Shape {
+	switch l := light.(type) {
+	case *lights.QuadLight:
+		return l.Quad
+	case *lights.SphereLight:
+		return l.Sphere
+	case *lights.DiscLight:
+		return l.Disc
+	case *lights.CylinderLight:
+		return l.Cylinder
+	case *lights.ShapeLight:
+		return l.SurfaceSampler
+	case *lights.DiscSpotLight:
+		return l.GetDisc()
+	default:
+		return nil
+	}
+}
+
 // AddUniformInfiniteLight adds a uniform infinite light to the scene
 func (s *Scene) AddUniformInfiniteLight(emission core.Vec3) {
 	infiniteLight := lights.NewUniformInfiniteLight(emission)
 	s.Lights = append(s.Lights, infiniteLight)
 }
 
+// AddImageInfiniteLight adds an environment map lighting the scene from an equirectangular image
+// (see lights.ImageInfiniteLight), such as an .hdr panorama, its radiance scaled by scale
+func (s *Scene) AddImageInfiniteLight(filename string, scale core.Vec3) error {
+	image, err := loaders.LoadImage(filename)
+	if err != nil {
+		return err
+	}
+	s.Lights = append(s.Lights, lights.NewImageInfiniteLight(image.Width, image.Height, image.Pixels, scale))
+	return nil
+}
+
 // AddGradientInfiniteLight adds a gradient infinite light to the scene
 func (s *Scene) AddGradientInfiniteLight(topColor, bottomColor core.Vec3) {
 	infiniteLight := lights.NewGradientInfiniteLight(topColor, bottomColor)
@@ -3,12 +3,20 @@ package scene
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/scenes"
 )
 
+// sceneLog logs problems found while discovering scenes
+var sceneLog = core.NewLog("scene")
+
 // SceneInfo represents a discovered scene with its metadata
 type SceneInfo struct {
 	ID          string `json:"id"`          // Unique identifier
@@ -32,7 +40,8 @@ type ScenesResponse struct {
 	Groups []SceneGroup `json:"groups"`
 }
 
-// ListPBRTScenes scans the /scenes directory and returns discovered PBRT scenes
+// ListPBRTScenes scans the /scenes directory, or the bundled scenes if there's none, and returns
+// discovered PBRT scenes
 func ListPBRTScenes() ([]SceneInfo, error) {
 	// Try different possible paths for scenes directory
 	possiblePaths := []string{"scenes", "../scenes"}
@@ -45,14 +54,18 @@ func ListPBRTScenes() ([]SceneInfo, error) {
 		}
 	}
 
-	if scenesDir == "" {
-		// No scenes directory found, return empty list
-		return []SceneInfo{}, nil
+	// Find all .pbrt files in the scenes directory, or without one the bundled scenes, which
+	// load from the copy embedded in the binary
+	var files []string
+	var err error
+	if scenesDir != "" {
+		files, err = filepath.Glob(filepath.Join(scenesDir, "*.pbrt"))
+	} else {
+		files, err = fs.Glob(scenes.Files, "*.pbrt")
+		for i, name := range files {
+			files[i] = filepath.Join("scenes", name)
+		}
 	}
-
-	// Find all .pbrt files in the scenes directory
-	pattern := filepath.Join(scenesDir, "*.pbrt")
-	files, err := filepath.Glob(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan scenes directory: %v", err)
 	}
@@ -62,7 +75,7 @@ func ListPBRTScenes() ([]SceneInfo, error) {
 		sceneInfo, err := ParsePBRTMetadata(filePath)
 		if err != nil {
 			// Log warning but continue processing other files
-			fmt.Printf("Warning: failed to parse metadata for %s: %v\n", filePath, err)
+			sceneLog.Logf(core.LevelWarn, "Failed to parse metadata for %s: %v", filePath, err)
 			continue
 		}
 		scenes = append(scenes, sceneInfo)
@@ -95,7 +108,7 @@ func ParsePBRTMetadata(filePath string) (SceneInfo, error) {
 	}
 
 	// Open file to read header comments
-	file, err := os.Open(filePath)
+	file, err := loaders.OpenSceneFile(filePath)
 	if err != nil {
 		// If we can't read the file, return with fallback values
 		return sceneInfo, nil
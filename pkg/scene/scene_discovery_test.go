@@ -2,8 +2,11 @@ package scene
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
 )
 
 func TestTitleCase(t *testing.T) {
@@ -137,9 +140,7 @@ LookAt 0 0 5  0 0 0  0 1 0`,
 }
 
 func TestListPBRTScenes_EmptyDirectory(t *testing.T) {
-	// Test with non-existent scenes directory
-	// We can't easily test this without changing the working directory,
-	// so we'll test the basic functionality with existing files
+	// Whichever scenes are found from the test's directory, the list isn't nil
 	scenes, err := ListPBRTScenes()
 	if err != nil {
 		t.Errorf("ListPBRTScenes() error: %v", err)
@@ -151,6 +152,51 @@ func TestListPBRTScenes_EmptyDirectory(t *testing.T) {
 	}
 }
 
+func TestListPBRTScenes_Bundled(t *testing.T) {
+	// Without a scenes directory, the scenes embedded in the binary are listed, and load
+	t.Chdir(t.TempDir())
+	scenes, err := ListPBRTScenes()
+	if err != nil {
+		t.Fatalf("ListPBRTScenes() error: %v", err)
+	}
+
+	var found *SceneInfo
+	for i := range scenes {
+		if scenes[i].ID == "pbrt:cornell-empty" {
+			found = &scenes[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("Expected the bundled cornell-empty scene, got %v", scenes)
+	}
+	if found.FilePath != filepath.Join("scenes", "cornell-empty.pbrt") || found.Variant != "Empty Room" {
+		t.Errorf("Expected the bundled file's path and metadata, got %+v", *found)
+	}
+	if _, err := loaders.LoadPBRT(found.FilePath); err != nil {
+		t.Errorf("Failed to load the bundled scene: %v", err)
+	}
+}
+
+func TestListPBRTScenes_DirectoryOnDisk(t *testing.T) {
+	// A scenes directory replaces the bundled scenes
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "scenes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scenes", "mine.pbrt"), []byte("# Scene: Mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	scenes, err := ListPBRTScenes()
+	if err != nil {
+		t.Fatalf("ListPBRTScenes() error: %v", err)
+	}
+	if len(scenes) != 1 || scenes[0].ID != "pbrt:mine" || scenes[0].Name != "Mine" {
+		t.Errorf("Expected only the scene on disk, got %+v", scenes)
+	}
+}
+
 func TestListAllScenes(t *testing.T) {
 	response, err := ListAllScenes()
 	if err != nil {
@@ -0,0 +1,1304 @@
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// SceneFile is the declarative scene description read by LoadSceneFile from JSON or YAML. Keys
+// are the JSON names of the fields below; vectors and colors are [x, y, z] arrays. Materials are
+// defined once by name and referenced by shapes ("default" is gray Lambertian). Unknown keys are
+// errors, so typos don't go unnoticed.
+//
+//	camera:   {center: [0, 1, 5], lookAt: [0, 1, 0], fov: 40, width: 400}
+//	cameras:  {top: {center: [0, 6, 0.1], lookAt: [0, 0, 0]}}
+//	sampling: {samples: 64, depth: 8}
+//	materials:
+//	  glass: {type: dielectric, ior: 1.5}
+//	shapes:
+//	  - {type: sphere, center: [0, 1, 0], radius: 1, material: glass}
+//	lights:
+//	  - {type: quad, corner: [-1, 3, -1], u: [2, 0, 0], v: [0, 0, 2], emit: [1, 1, 1], lumens: 1000}
+type SceneFile struct {
+	Camera    CameraFile              `json:"camera"`
+	Cameras   map[string]CameraFile   `json:"cameras"` // Named viewpoints (see Scene.UseCamera); unset vectors and fov are the camera's
+	Sampling  SamplingFile            `json:"sampling"`
+	Materials map[string]MaterialFile `json:"materials"`
+	Shapes    []ShapeFile             `json:"shapes"`
+	Lights    []LightFile             `json:"lights"`
+
+	AutoLights bool `json:"autoLights"` // Make shapes with emissive materials area lights (see Scene.AutoLights)
+}
+
+// FileVec3 is a vector or color in a scene file, written as an [x, y, z] array
+type FileVec3 [3]float64
+
+// UnmarshalJSON requires exactly three numbers
+func (v *FileVec3) UnmarshalJSON(data []byte) error {
+	var values []float64
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != 3 {
+		return fmt.Errorf("expected [x, y, z], got %s", data)
+	}
+	copy(v[:], values)
+	return nil
+}
+
+// vec returns the vector, or def when it wasn't given
+func (v *FileVec3) vec(def core.Vec3) core.Vec3 {
+	if v == nil {
+		return def
+	}
+	return core.NewVec3(v[0], v[1], v[2])
+}
+
+// fileParams are the parameters of a registered type of material, shape or light: numbers,
+// strings and [x, y, z] arrays by name
+type fileParams map[string]any
+
+func (p fileParams) Float(name string) (float64, bool) {
+	value, ok := p[name].(float64)
+	return value, ok
+}
+
+func (p fileParams) Vec3(name string) (core.Vec3, bool) {
+	values, ok := p[name].([]any)
+	if !ok || len(values) != 3 {
+		return core.Vec3{}, false
+	}
+	var v [3]float64
+	for i, value := range values {
+		if v[i], ok = value.(float64); !ok {
+			return core.Vec3{}, false
+		}
+	}
+	return core.NewVec3(v[0], v[1], v[2]), true
+}
+
+func (p fileParams) String(name string) (string, bool) {
+	value, ok := p[name].(string)
+	return value, ok
+}
+
+// CameraFile describes the camera (see geometry.CameraConfig)
+type CameraFile struct {
+	Center        *FileVec3 `json:"center"`
+	LookAt        *FileVec3 `json:"lookAt"`
+	Up            *FileVec3 `json:"up"`
+	Width         int       `json:"width"`
+	AspectRatio   float64   `json:"aspectRatio"`
+	FOV           float64   `json:"fov"` // Vertical field of view in degrees
+	Aperture      float64   `json:"aperture"`
+	FocusDistance float64   `json:"focusDistance"`
+}
+
+// SamplingFile describes the sampling settings (see SamplingConfig)
+type SamplingFile struct {
+	Samples             int     `json:"samples"`
+	Depth               int     `json:"depth"`
+	DiffuseDepth        int     `json:"diffuseDepth"`
+	GlossyDepth         int     `json:"glossyDepth"`
+	TransmissionDepth   int     `json:"transmissionDepth"`
+	RouletteBounces     int     `json:"rouletteBounces"`
+	RouletteMinProb     float64 `json:"rouletteMinProb"`
+	AdaptiveMinSamples  float64 `json:"adaptiveMinSamples"`
+	AdaptiveThreshold   float64 `json:"adaptiveThreshold"`
+	PrimaryLightSamples int     `json:"primaryLightSamples"`
+	GuidingPasses       int     `json:"guidingPasses"`
+	IrradianceCache     float64 `json:"irradianceCache"`
+	SampleMask          string  `json:"sampleMask"` // Grayscale image scaling each pixel's samples
+	Filter              string  `json:"filter"`     // Pixel reconstruction filter (see PixelFilters)
+	FilterRadius        float64 `json:"filterRadius"`
+}
+
+// MaterialFile describes a material. Type is one of:
+//
+//	lambertian: albedo, or texture
+//	metal:      albedo or texture, fuzz or fuzzTexture (whose luminance is the fuzz)
+//	dielectric: ior, dispersion (Cauchy B in μm², e.g. 0.004 for crown glass), or glass (a catalog
+//	            glass, see material.GlassNames, e.g. BK7); sigmaA, or color (transmitted through a
+//	            unit of distance inside), for colored glass
+//	emissive:   emit, texture (scaling emit, as an emissive map does)
+//	layered:    outer, inner (material names)
+//	mix:        first, second (material names), ratio of the second or ratioTexture (whose
+//	            luminance is the ratio)
+//	pbr:        a glTF-style metallic-roughness material: albedo or texture for the base color,
+//	            roughness and metallic, and orm (a texture packing roughness in its green channel
+//	            and metallic in its blue, which roughness and metallic then scale, defaulting to 1);
+//	            without orm, roughness defaults to 0.5 and metallic to 0
+//	cutout:     base (material name), mask (texture whose red channel is the opacity)
+//	shadowcatcher: base (material name), or albedo (a lambertian, 0.8 gray by default): a surface
+//	            standing in for one in a photograph, which renders only the shadows and
+//	            reflections it receives when the background is transparent
+//	hair:       sigmaA, or color, or eumelanin and pheomelanin; eta, betaM, betaN, alpha
+//	medium:     albedo, g (the phase function's mean cosine): the particles of a volume
+//
+// Any other type is one registered with material.Register, built from params.
+type MaterialFile struct {
+	Type         string       `json:"type"`
+	Albedo       *FileVec3    `json:"albedo"`
+	Texture      *TextureFile `json:"texture"`
+	Fuzz         float64      `json:"fuzz"`
+	FuzzTexture  *TextureFile `json:"fuzzTexture"`
+	IOR          float64      `json:"ior"`
+	Dispersion   float64      `json:"dispersion"`
+	Glass        string       `json:"glass"`
+	Emit         *FileVec3    `json:"emit"`
+	Outer        string       `json:"outer"`
+	Inner        string       `json:"inner"`
+	First        string       `json:"first"`
+	Second       string       `json:"second"`
+	Ratio        float64      `json:"ratio"`
+	RatioTexture *TextureFile `json:"ratioTexture"`
+	Roughness    *float64     `json:"roughness"`
+	Metallic     *float64     `json:"metallic"`
+	ORM          *TextureFile `json:"orm"`
+	Base         string       `json:"base"`
+	Mask         *TextureFile `json:"mask"`
+	SigmaA       *FileVec3    `json:"sigmaA"`
+	Color        *FileVec3    `json:"color"`
+	Eumelanin    *float64     `json:"eumelanin"`
+	Pheomelanin  float64      `json:"pheomelanin"`
+	Eta          float64      `json:"eta"`
+	BetaM        *float64     `json:"betaM"`
+	BetaN        *float64     `json:"betaN"`
+	Alpha        *float64     `json:"alpha"`
+	G            float64      `json:"g"`
+
+	Params fileParams `json:"params"`
+}
+
+// TextureFile describes a texture. Type is image (file, a PNG or JPEG), checkerboard (width,
+// height, checkSize, color1, color2), gradient (width, height, color1, color2) or uv; or a solid
+// noise pattern (see material.NoisePatterns: noise, fbm, turbulence, marble or wood) blending from
+// color1 (black by default) to color2 (white) by position in space, with scale (its frequency,
+// default 1), octaves and gain.
+//
+// A projection looks the texture up at coordinates projected from the point in space instead of
+// the surface's UVs, for shapes without them: planar, spherical or cylindrical about center and
+// axis (default y), or triplanar with its sharpness (default 4). size is the world distance one
+// repeat of the texture spans (default 1).
+//
+// channel (r, g or b) takes one channel of the texture as a gray, for maps packed into one image.
+type TextureFile struct {
+	Type      string    `json:"type"`
+	File      string    `json:"file"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CheckSize int       `json:"checkSize"`
+	Color1    *FileVec3 `json:"color1"`
+	Color2    *FileVec3 `json:"color2"`
+	Scale     float64   `json:"scale"`
+	Octaves   int       `json:"octaves"`
+	Gain      float64   `json:"gain"`
+	Channel   string    `json:"channel"`
+
+	Projection string    `json:"projection"`
+	Center     *FileVec3 `json:"center"`
+	Axis       *FileVec3 `json:"axis"`
+	Size       float64   `json:"size"`
+	Sharpness  float64   `json:"sharpness"`
+}
+
+// ShapeFile describes a shape. Type is one of:
+//
+//	sphere:     center, radius
+//	quad:       corner, u, v
+//	triangle:   a, b, c
+//	box:        center, size (half extents), rotation (degrees about x, y, z)
+//	disc:       center, normal, radius, innerRadius
+//	cylinder:   base, top, radius, capped
+//	cone:       base, radius, top, topRadius, capped
+//	capsule:    start, end, radius
+//	torus:      center, axis, majorRadius, minorRadius
+//	mesh:       file (PLY, STL or OFF), or vertices and indices; normals, uvs, smooth,
+//	            subdivision, displacement (a texture whose luminance moves the vertices along
+//	            their normals by up to displacementScale), float32, and rotation (degrees) about
+//	            center
+//	curve:      points, width0, width1, curve (flat, cylinder or ribbon), basis (bezier or bspline),
+//	            degree, normals
+//	mandelbulb: center, radius, power, iterations
+//	sdf:        file (a signed distance grid, see loaders.LoadSDF)
+//	volume:     file (a .vol density grid, see loaders.LoadVolume), density (the extinction at a
+//	            grid density of 1), and center and size (half extents) to move it from the file's
+//	            box; its material is a medium, white and isotropic by default. To glow like fire:
+//	            temperature (a .vol grid over the same box), temperatureScale (kelvin at a grid
+//	            value of 1, default 1) and emission (the luminance where it is hottest, default 1),
+//	            cooler parts dimming and reddening as a blackbody's do
+//
+// Any shape can name its material and hide from kinds of rays: camera, indirect and shadows. A
+// shape with an emissive material glows where rays hit it; with light set, it's also an area light
+// sampled by area for direct lighting and light paths, such as a mesh shaped like a lamp's bulb or
+// sign (mesh, sphere, quad, triangle, disc, cylinder, cone and capsule).
+//
+// Any other type is one registered with geometry.Register, built from params.
+type ShapeFile struct {
+	Type        string       `json:"type"`
+	Material    string       `json:"material"`
+	Hide        []string     `json:"hide"`
+	Light       bool         `json:"light"`
+	Center      *FileVec3    `json:"center"`
+	Corner      *FileVec3    `json:"corner"`
+	U           *FileVec3    `json:"u"`
+	V           *FileVec3    `json:"v"`
+	A           *FileVec3    `json:"a"`
+	B           *FileVec3    `json:"b"`
+	C           *FileVec3    `json:"c"`
+	Size        *FileVec3    `json:"size"`
+	Rotation    *FileVec3    `json:"rotation"`
+	Normal      *FileVec3    `json:"normal"`
+	Axis        *FileVec3    `json:"axis"`
+	Base        *FileVec3    `json:"base"`
+	Top         *FileVec3    `json:"top"`
+	Start       *FileVec3    `json:"start"`
+	End         *FileVec3    `json:"end"`
+	Radius      float64      `json:"radius"`
+	InnerRadius float64      `json:"innerRadius"`
+	TopRadius   float64      `json:"topRadius"`
+	MajorRadius float64      `json:"majorRadius"`
+	MinorRadius float64      `json:"minorRadius"`
+	Capped      bool         `json:"capped"`
+	File        string       `json:"file"`
+	Vertices    []FileVec3   `json:"vertices"`
+	Indices     []int        `json:"indices"`
+	Normals     []FileVec3   `json:"normals"`
+	UVs         [][2]float64 `json:"uvs"`
+	Smooth      bool         `json:"smooth"`
+	Subdivision int          `json:"subdivision"`
+	Displace    *TextureFile `json:"displacement"`
+	DisplaceBy  float64      `json:"displacementScale"`
+	Float32     bool         `json:"float32"`
+	Points      []FileVec3   `json:"points"`
+	Width0      float64      `json:"width0"`
+	Width1      float64      `json:"width1"`
+	Curve       string       `json:"curve"`
+	Basis       string       `json:"basis"`
+	Degree      int          `json:"degree"`
+	Power       float64      `json:"power"`
+	Iterations  int          `json:"iterations"`
+	Density     float64      `json:"density"`
+	Temperature string       `json:"temperature"`
+	TempScale   float64      `json:"temperatureScale"`
+	Emission    float64      `json:"emission"`
+
+	Params fileParams `json:"params"`
+}
+
+// LightFile describes a light. Type is one of:
+//
+//	quad:      corner, u, v
+//	sphere:    center, radius
+//	disc:      center, normal, radius, innerRadius
+//	cylinder:  base, top, radius, capped
+//	cone:      base, radius, top, topRadius, capped
+//	capsule:   start, end, radius
+//	line:      start, end, radius (default 0.01): a thin open tube, such as a neon or strip light
+//	spot:      from, to, angle, delta, radius (a disc; 0 for a point)
+//	ies:       file, from, to (emit scales the profile's candela)
+//	projector: file (the image), from, to, up, fov, aspect
+//	sun:       direction (of travel), angle (angular diameter in degrees); emit is irradiance
+//	sky:       emit, or top and bottom colors for a gradient, or file, an equirectangular image
+//	           (such as an .hdr panorama) scaled by emit
+//	daylight:  turbidity, elevation, azimuth, scale
+//	portal:    corner, u, v: a window the sky lights the interior through (after the sky)
+//
+// Emit is the radiance, intensity or irradiance, tinted by kelvin; finite lights may instead be
+// given their power in lumens or watts, with emit as the color. Area lights can be hidden from
+// camera and shadow rays.
+//
+// Any other type is one registered with lights.Register, built from params.
+type LightFile struct {
+	Type        string    `json:"type"`
+	Emit        *FileVec3 `json:"emit"`
+	Kelvin      float64   `json:"kelvin"`
+	Lumens      float64   `json:"lumens"`
+	Watts       float64   `json:"watts"`
+	Hide        []string  `json:"hide"`
+	Corner      *FileVec3 `json:"corner"`
+	U           *FileVec3 `json:"u"`
+	V           *FileVec3 `json:"v"`
+	Center      *FileVec3 `json:"center"`
+	Normal      *FileVec3 `json:"normal"`
+	Base        *FileVec3 `json:"base"`
+	Top         *FileVec3 `json:"top"`
+	Bottom      *FileVec3 `json:"bottom"`
+	Start       *FileVec3 `json:"start"`
+	End         *FileVec3 `json:"end"`
+	From        *FileVec3 `json:"from"`
+	To          *FileVec3 `json:"to"`
+	Up          *FileVec3 `json:"up"`
+	Direction   *FileVec3 `json:"direction"`
+	Radius      float64   `json:"radius"`
+	InnerRadius float64   `json:"innerRadius"`
+	TopRadius   float64   `json:"topRadius"`
+	Capped      bool      `json:"capped"`
+	Angle       float64   `json:"angle"`
+	Delta       float64   `json:"delta"`
+	FOV         float64   `json:"fov"`
+	Aspect      float64   `json:"aspect"`
+	File        string    `json:"file"`
+	Turbidity   float64   `json:"turbidity"`
+	Elevation   float64   `json:"elevation"`
+	Azimuth     float64   `json:"azimuth"`
+	Scale       float64   `json:"scale"`
+
+	Params fileParams `json:"params"`
+}
+
+// LoadSceneFile loads a scene description from a .json, .yaml or .yml file (see SceneFile).
+// Files the description refers to (meshes, textures, IES profiles) are relative to its directory.
+// It may also be one of the bundled scenes (see loaders.OpenSceneFile).
+func LoadSceneFile(filename string) (*Scene, error) {
+	data, err := loaders.ReadSceneFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scene file: %v", err)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	file, err := ParseSceneFile(data, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	s, err := NewFileScene(file, filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return s, nil
+}
+
+// ParseSceneFile parses a scene description from JSON, or YAML when yaml is set, starting from
+// the default camera and sampling settings
+func ParseSceneFile(data []byte, yaml bool) (*SceneFile, error) {
+	if yaml {
+		document, err := loaders.ParseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, err
+		}
+	}
+
+	file := &SceneFile{
+		Camera: CameraFile{Width: 400, AspectRatio: 1, FOV: 45},
+		Sampling: SamplingFile{
+			Samples:            100,
+			Depth:              8,
+			RouletteBounces:    5,
+			AdaptiveMinSamples: 0.15,
+			AdaptiveThreshold:  0.01,
+		},
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(file); err != nil {
+		return nil, fmt.Errorf("invalid scene description: %v", err)
+	}
+	return file, nil
+}
+
+// NewFileScene builds a scene from its description; relative file names are resolved against
+// baseDir. Like the other scene constructors, the scene isn't preprocessed.
+func NewFileScene(file *SceneFile, baseDir string) (*Scene, error) {
+	b := &fileSceneBuilder{
+		file:      file,
+		baseDir:   baseDir,
+		materials: map[string]material.Material{"default": material.NewLambertian(core.NewVec3(0.7, 0.7, 0.7))},
+		resolving: map[string]bool{},
+	}
+
+	camera := geometry.CameraConfig{
+		Center:        file.Camera.Center.vec(core.NewVec3(0, 0, 0)),
+		LookAt:        file.Camera.LookAt.vec(core.NewVec3(0, 0, -1)),
+		Up:            file.Camera.Up.vec(core.NewVec3(0, 1, 0)),
+		Width:         file.Camera.Width,
+		AspectRatio:   file.Camera.AspectRatio,
+		VFov:          file.Camera.FOV,
+		Aperture:      file.Camera.Aperture,
+		FocusDistance: file.Camera.FocusDistance,
+	}
+	if camera.Width <= 0 || camera.AspectRatio <= 0 || camera.VFov <= 0 {
+		return nil, fmt.Errorf("camera width, aspectRatio and fov must be positive")
+	}
+
+	var cameras map[string]geometry.CameraConfig
+	for name, named := range file.Cameras {
+		config := camera
+		config.Center = named.Center.vec(camera.Center)
+		config.LookAt = named.LookAt.vec(camera.LookAt)
+		config.Up = named.Up.vec(camera.Up)
+		if named.FOV > 0 {
+			config.VFov = named.FOV
+		}
+		config.Aperture = named.Aperture
+		config.FocusDistance = named.FocusDistance
+		if cameras == nil {
+			cameras = make(map[string]geometry.CameraConfig)
+		}
+		cameras[name] = config
+	}
+
+	sampling := file.Sampling
+	s := &Scene{
+		Shapes: make([]geometry.Shape, 0, len(file.Shapes)),
+		Lights: make([]lights.Light, 0, len(file.Lights)),
+		SamplingConfig: SamplingConfig{
+			Width:                     camera.Width,
+			Height:                    int(float64(camera.Width) / camera.AspectRatio),
+			SamplesPerPixel:           sampling.Samples,
+			MaxDepth:                  sampling.Depth,
+			MaxDiffuseDepth:           sampling.DiffuseDepth,
+			MaxGlossyDepth:            sampling.GlossyDepth,
+			MaxTransmissionDepth:      sampling.TransmissionDepth,
+			RussianRouletteMinBounces: sampling.RouletteBounces,
+			RussianRouletteMinProb:    sampling.RouletteMinProb,
+			AdaptiveMinSamples:        sampling.AdaptiveMinSamples,
+			AdaptiveThreshold:         sampling.AdaptiveThreshold,
+			PrimaryLightSamples:       sampling.PrimaryLightSamples,
+			GuidingPasses:             sampling.GuidingPasses,
+			IrradianceCache:           sampling.IrradianceCache,
+			SampleMask:                b.path(sampling.SampleMask),
+			Filter:                    sampling.Filter,
+			FilterRadius:              sampling.FilterRadius,
+		},
+		CameraConfig: camera,
+		Camera:       geometry.NewCamera(camera),
+		Cameras:      cameras,
+		AutoLights:   file.AutoLights,
+	}
+	if sampling.Samples <= 0 || sampling.Depth <= 0 {
+		return nil, fmt.Errorf("sampling samples and depth must be positive")
+	}
+	if sampling.DiffuseDepth < 0 || sampling.GlossyDepth < 0 || sampling.TransmissionDepth < 0 {
+		return nil, fmt.Errorf("sampling diffuseDepth, glossyDepth and transmissionDepth must not be negative")
+	}
+	if _, _, err := s.SamplingConfig.PixelFilter(); err != nil {
+		return nil, err
+	}
+
+	for i, shapeFile := range file.Shapes {
+		if err := b.addShape(s, shapeFile); err != nil {
+			return nil, fmt.Errorf("shape %d (%s): %v", i+1, shapeFile.Type, err)
+		}
+	}
+	for i, lightFile := range file.Lights {
+		if err := b.addLight(s, lightFile); err != nil {
+			return nil, fmt.Errorf("light %d (%s): %v", i+1, lightFile.Type, err)
+		}
+	}
+	return s, nil
+}
+
+// fileSceneBuilder holds the state of building a scene from a SceneFile
+type fileSceneBuilder struct {
+	file      *SceneFile
+	baseDir   string
+	materials map[string]material.Material // Materials built so far, by name
+	resolving map[string]bool              // Materials being built, to catch cycles
+}
+
+// path resolves a file name from the description against its directory
+func (b *fileSceneBuilder) path(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(b.baseDir, name)
+}
+
+// material returns the named material, building it (and the materials it's made of) on first use
+func (b *fileSceneBuilder) material(name string) (material.Material, error) {
+	if name == "" {
+		name = "default"
+	}
+	if mat, ok := b.materials[name]; ok {
+		return mat, nil
+	}
+	desc, ok := b.file.Materials[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown material %q", name)
+	}
+	if b.resolving[name] {
+		return nil, fmt.Errorf("material %q is made of itself", name)
+	}
+	b.resolving[name] = true
+	mat, err := b.newMaterial(desc)
+	if err != nil {
+		return nil, fmt.Errorf("material %q: %v", name, err)
+	}
+	b.materials[name] = mat
+	return mat, nil
+}
+
+// newMaterial builds a material from its description
+func (b *fileSceneBuilder) newMaterial(desc MaterialFile) (material.Material, error) {
+	switch desc.Type {
+	case "lambertian":
+		if desc.Texture != nil {
+			texture, err := b.texture(desc.Texture)
+			if err != nil {
+				return nil, err
+			}
+			return material.NewTexturedLambertian(texture), nil
+		}
+		return material.NewLambertian(desc.Albedo.vec(core.NewVec3(0.7, 0.7, 0.7))), nil
+	case "metal":
+		if desc.Fuzz < 0 || desc.Fuzz > 1 {
+			return nil, fmt.Errorf("fuzz %v must be between 0 and 1", desc.Fuzz)
+		}
+		metal := material.NewMetal(desc.Albedo.vec(core.NewVec3(0.8, 0.8, 0.8)), desc.Fuzz)
+		if desc.Texture != nil {
+			texture, err := b.texture(desc.Texture)
+			if err != nil {
+				return nil, err
+			}
+			metal.Albedo = texture
+		}
+		if desc.FuzzTexture != nil {
+			texture, err := b.texture(desc.FuzzTexture)
+			if err != nil {
+				return nil, err
+			}
+			metal.FuzzTexture = texture
+		}
+		return metal, nil
+	case "dielectric":
+		var sigmaA core.Vec3
+		switch {
+		case desc.SigmaA != nil:
+			sigmaA = desc.SigmaA.vec(core.Vec3{})
+		case desc.Color != nil:
+			sigmaA = material.AbsorptionFromColor(desc.Color.vec(core.Vec3{}))
+		}
+		if sigmaA.X < 0 || sigmaA.Y < 0 || sigmaA.Z < 0 {
+			return nil, fmt.Errorf("sigmaA %v must not be negative", sigmaA)
+		}
+		if desc.Glass != "" {
+			glass, err := material.NewGlassDielectric(desc.Glass)
+			if err != nil {
+				return nil, err
+			}
+			glass.SigmaA = sigmaA
+			return glass, nil
+		}
+		ior := desc.IOR
+		if ior == 0 {
+			ior = 1.5
+		}
+		if ior < 0 {
+			return nil, fmt.Errorf("ior %v must be positive", ior)
+		}
+		if desc.Dispersion < 0 {
+			return nil, fmt.Errorf("dispersion %v must not be negative", desc.Dispersion)
+		}
+		glass := material.NewDispersiveDielectric(ior, desc.Dispersion)
+		glass.SigmaA = sigmaA
+		return glass, nil
+	case "emissive":
+		emission := desc.Emit.vec(core.NewVec3(1, 1, 1))
+		if desc.Texture != nil {
+			texture, err := b.texture(desc.Texture)
+			if err != nil {
+				return nil, err
+			}
+			return material.NewTexturedEmissive(emission, texture), nil
+		}
+		return material.NewEmissive(emission), nil
+	case "layered":
+		outer, err := b.material(desc.Outer)
+		if err != nil {
+			return nil, err
+		}
+		inner, err := b.material(desc.Inner)
+		if err != nil {
+			return nil, err
+		}
+		return material.NewLayered(outer, inner), nil
+	case "mix":
+		if desc.Ratio < 0 || desc.Ratio > 1 {
+			return nil, fmt.Errorf("ratio %v must be between 0 and 1", desc.Ratio)
+		}
+		first, err := b.material(desc.First)
+		if err != nil {
+			return nil, err
+		}
+		second, err := b.material(desc.Second)
+		if err != nil {
+			return nil, err
+		}
+		mix := material.NewMix(first, second, desc.Ratio)
+		if desc.RatioTexture != nil {
+			if mix.RatioTexture, err = b.texture(desc.RatioTexture); err != nil {
+				return nil, err
+			}
+		}
+		return mix, nil
+	case "pbr":
+		return b.newPBRMaterial(desc)
+	case "cutout":
+		if desc.Mask == nil {
+			return nil, fmt.Errorf("cutout needs a mask texture")
+		}
+		base, err := b.material(desc.Base)
+		if err != nil {
+			return nil, err
+		}
+		mask, err := b.texture(desc.Mask)
+		if err != nil {
+			return nil, err
+		}
+		return material.NewCutout(base, mask), nil
+	case "shadowcatcher":
+		if desc.Base == "" {
+			return material.NewShadowCatcher(material.NewLambertian(desc.Albedo.vec(core.NewVec3(0.8, 0.8, 0.8)))), nil
+		}
+		base, err := b.material(desc.Base)
+		if err != nil {
+			return nil, err
+		}
+		return material.NewShadowCatcher(base), nil
+	case "hair":
+		// The same defaults as PBRT's hair material: brown hair
+		eta, betaM, betaN, alpha := desc.Eta, 0.3, 0.3, 2.0
+		if eta == 0 {
+			eta = 1.55
+		}
+		if desc.BetaM != nil {
+			betaM = *desc.BetaM
+		}
+		if desc.BetaN != nil {
+			betaN = *desc.BetaN
+		}
+		if desc.Alpha != nil {
+			alpha = *desc.Alpha
+		}
+		if eta <= 0 || betaM < 0 || betaM > 1 || betaN < 0 || betaN > 1 {
+			return nil, fmt.Errorf("hair needs eta > 0 and betaM, betaN in [0, 1]")
+		}
+		var sigmaA core.Vec3
+		switch {
+		case desc.SigmaA != nil:
+			sigmaA = desc.SigmaA.vec(core.Vec3{})
+		case desc.Color != nil:
+			sigmaA = material.HairAbsorptionFromColor(desc.Color.vec(core.Vec3{}), betaN)
+		default:
+			eumelanin := 1.3
+			if desc.Eumelanin != nil {
+				eumelanin = *desc.Eumelanin
+			}
+			sigmaA = material.HairAbsorptionFromMelanin(eumelanin, desc.Pheomelanin)
+		}
+		return material.NewHair(sigmaA, eta, betaM, betaN, alpha), nil
+	case "medium":
+		if desc.G <= -1 || desc.G >= 1 {
+			return nil, fmt.Errorf("g %v must be between -1 and 1", desc.G)
+		}
+		return material.NewHenyeyGreenstein(desc.Albedo.vec(core.NewVec3(1, 1, 1)), desc.G), nil
+	default:
+		if factory, ok := material.Lookup(desc.Type); ok {
+			return factory(desc.Params)
+		}
+		return nil, fmt.Errorf("unknown material type %q", desc.Type)
+	}
+}
+
+// newPBRMaterial builds a metallic-roughness material as a mix of a diffuse base and a metal of the
+// same color, by metallic, the metal's fuzz being the roughness
+func (b *fileSceneBuilder) newPBRMaterial(desc MaterialFile) (material.Material, error) {
+	roughness, metallic := 0.5, 0.0
+	if desc.ORM != nil {
+		roughness, metallic = 1, 1
+	}
+	if desc.Roughness != nil {
+		roughness = *desc.Roughness
+	}
+	if desc.Metallic != nil {
+		metallic = *desc.Metallic
+	}
+	if roughness < 0 || roughness > 1 || metallic < 0 || metallic > 1 {
+		return nil, fmt.Errorf("roughness and metallic must be between 0 and 1")
+	}
+
+	var base material.ColorSource = material.NewSolidColor(desc.Albedo.vec(core.NewVec3(0.8, 0.8, 0.8)))
+	if desc.Texture != nil {
+		texture, err := b.texture(desc.Texture)
+		if err != nil {
+			return nil, err
+		}
+		base = texture
+	}
+	diffuse := material.NewTexturedLambertian(base)
+	metal := material.NewMetal(core.Vec3{}, roughness)
+	metal.Albedo = base
+	mix := material.NewMix(diffuse, metal, metallic)
+	if desc.ORM != nil {
+		orm, err := b.texture(desc.ORM)
+		if err != nil {
+			return nil, err
+		}
+		metal.FuzzTexture = material.NewScaledTexture(material.NewChannelTexture(orm, 1), material.NewSolidColor(core.NewVec3(roughness, roughness, roughness)))
+		mix.RatioTexture = material.NewScaledTexture(material.NewChannelTexture(orm, 2), material.NewSolidColor(core.NewVec3(metallic, metallic, metallic)))
+	}
+	return mix, nil
+}
+
+// texture builds a texture from its description, taking the channel and projecting it if it asks to
+func (b *fileSceneBuilder) texture(desc *TextureFile) (material.ColorSource, error) {
+	texture, err := b.unprojectedTexture(desc)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Channel != "" {
+		channel := strings.Index("rgb", desc.Channel)
+		if len(desc.Channel) != 1 || channel < 0 {
+			return nil, fmt.Errorf("unknown texture channel %q (r, g or b)", desc.Channel)
+		}
+		texture = material.NewChannelTexture(texture, channel)
+	}
+	if desc.Projection == "" {
+		return texture, nil
+	}
+	if desc.Projection == "triplanar" {
+		return material.NewTriplanarTexture(texture, desc.Size, desc.Sharpness), nil
+	}
+	return material.NewProjectedTexture(texture, material.Projection(desc.Projection),
+		desc.Center.vec(core.Vec3{}), desc.Axis.vec(core.NewVec3(0, 1, 0)), desc.Size)
+}
+
+// unprojectedTexture builds a texture from its description, ignoring its projection
+func (b *fileSceneBuilder) unprojectedTexture(desc *TextureFile) (material.ColorSource, error) {
+	width, height := desc.Width, desc.Height
+	if width <= 0 {
+		width = 256
+	}
+	if height <= 0 {
+		height = width
+	}
+	switch desc.Type {
+	case "image":
+		image, err := loaders.LoadImage(b.path(desc.File))
+		if err != nil {
+			return nil, err
+		}
+		return material.NewImageTexture(image.Width, image.Height, image.Pixels), nil
+	case "checkerboard":
+		checkSize := desc.CheckSize
+		if checkSize <= 0 {
+			checkSize = width / 8
+		}
+		return material.NewCheckerboardTexture(width, height, checkSize,
+			desc.Color1.vec(core.NewVec3(1, 1, 1)), desc.Color2.vec(core.Vec3{})), nil
+	case "gradient":
+		return material.NewGradientTexture(width, height, desc.Color1.vec(core.Vec3{}), desc.Color2.vec(core.NewVec3(1, 1, 1))), nil
+	case "uv":
+		return material.NewUVDebugTexture(width, height), nil
+	case "noise", "fbm", "turbulence", "marble", "wood":
+		scale := desc.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		return material.NewNoiseTexture(material.NoisePattern(desc.Type), scale, desc.Octaves, desc.Gain,
+			material.NewSolidColor(desc.Color1.vec(core.Vec3{})), material.NewSolidColor(desc.Color2.vec(core.NewVec3(1, 1, 1))))
+	default:
+		return nil, fmt.Errorf("unknown texture type %q", desc.Type)
+	}
+}
+
+// addShape builds a shape from its description and adds it to the scene
+func (b *fileSceneBuilder) addShape(s *Scene, desc ShapeFile) error {
+	mat, err := b.material(desc.Material)
+	if err != nil {
+		return err
+	}
+	shape, err := b.newShape(desc, mat)
+	if err != nil {
+		return err
+	}
+	if desc.Light {
+		sampler, ok := shape.(geometry.SurfaceSampler)
+		if !ok {
+			return fmt.Errorf("%s shapes can't be lights", desc.Type)
+		}
+		if _, ok := mat.(material.Emitter); !ok {
+			return fmt.Errorf("light shape material %q must be emissive", desc.Material)
+		}
+		s.AddShapeLight(sampler, mat)
+	} else {
+		s.Shapes = append(s.Shapes, shape)
+	}
+	if len(desc.Hide) > 0 {
+		hiddenFrom, err := parseRayKinds(desc.Hide)
+		if err != nil {
+			return err
+		}
+		return s.SetVisibility(shape, hiddenFrom)
+	}
+	return nil
+}
+
+// newShape builds a shape from its description
+func (b *fileSceneBuilder) newShape(desc ShapeFile, mat material.Material) (geometry.Shape, error) {
+	zero := core.Vec3{}
+	switch desc.Type {
+	case "sphere":
+		radius, err := positive("radius", desc.Radius, 1)
+		if err != nil {
+			return nil, err
+		}
+		return geometry.NewSphere(desc.Center.vec(zero), radius, mat), nil
+	case "quad":
+		return geometry.NewQuad(desc.Corner.vec(zero), desc.U.vec(core.NewVec3(1, 0, 0)), desc.V.vec(core.NewVec3(0, 0, 1)), mat), nil
+	case "triangle":
+		return geometry.NewTriangle(desc.A.vec(zero), desc.B.vec(core.NewVec3(1, 0, 0)), desc.C.vec(core.NewVec3(0, 1, 0)), mat), nil
+	case "box":
+		return geometry.NewBox(desc.Center.vec(zero), desc.Size.vec(core.NewVec3(1, 1, 1)), desc.Rotation.vec(zero).Multiply(math.Pi/180), mat), nil
+	case "disc":
+		radius, err := positive("radius", desc.Radius, 1)
+		if err != nil {
+			return nil, err
+		}
+		if desc.InnerRadius < 0 || desc.InnerRadius >= radius {
+			return nil, fmt.Errorf("innerRadius %v must be in [0, radius)", desc.InnerRadius)
+		}
+		return geometry.NewAnnulus(desc.Center.vec(zero), desc.Normal.vec(core.NewVec3(0, 1, 0)), desc.InnerRadius, radius, mat), nil
+	case "cylinder", "cone", "capsule":
+		return newRoundShape(desc.Type, desc.Base, desc.Top, desc.Start, desc.End, desc.Radius, desc.TopRadius, desc.Capped, mat)
+	case "torus":
+		majorRadius, err := positive("majorRadius", desc.MajorRadius, 1)
+		if err != nil {
+			return nil, err
+		}
+		minorRadius, err := positive("minorRadius", desc.MinorRadius, 0.25)
+		if err != nil {
+			return nil, err
+		}
+		return geometry.NewTorus(desc.Center.vec(zero), desc.Axis.vec(core.NewVec3(0, 1, 0)), majorRadius, minorRadius, mat)
+	case "mesh":
+		return b.newMesh(desc, mat)
+	case "curve":
+		if len(desc.Points) == 0 {
+			return nil, fmt.Errorf("curve needs points")
+		}
+		width0, width1 := desc.Width0, desc.Width1
+		if width0 == 0 {
+			width0 = 0.01
+		}
+		if width1 == 0 {
+			width1 = width0
+		}
+		options := geometry.CurveOptions{Degree: desc.Degree, Normals: fileVectors(desc.Normals), SplitDepth: 3}
+		switch desc.Curve {
+		case "", "flat":
+			options.Type = geometry.CurveFlat
+		case "cylinder":
+			options.Type = geometry.CurveCylinder
+		case "ribbon":
+			options.Type = geometry.CurveRibbon
+		default:
+			return nil, fmt.Errorf("unknown curve type %q", desc.Curve)
+		}
+		switch desc.Basis {
+		case "", "bezier":
+			options.Basis = geometry.CurveBezier
+		case "bspline":
+			options.Basis = geometry.CurveBSpline
+		default:
+			return nil, fmt.Errorf("unknown curve basis %q", desc.Basis)
+		}
+		return geometry.NewCurves(fileVectors(desc.Points), width0, width1, mat, options)
+	case "mandelbulb":
+		radius, err := positive("radius", desc.Radius, 1)
+		if err != nil {
+			return nil, err
+		}
+		power, iterations := desc.Power, desc.Iterations
+		if power == 0 {
+			power = 8
+		}
+		if iterations <= 0 {
+			iterations = 12
+		}
+		return geometry.NewMandelbulb(desc.Center.vec(zero), radius, power, iterations, mat), nil
+	case "sdf":
+		data, err := loaders.LoadSDF(b.path(desc.File))
+		if err != nil {
+			return nil, err
+		}
+		sdf, err := geometry.NewVoxelSDF(data.Origin, data.CellSize, data.Dimensions[0], data.Dimensions[1], data.Dimensions[2], data.Values)
+		if err != nil {
+			return nil, err
+		}
+		return geometry.NewSDFSurface(sdf, mat), nil
+	case "volume":
+		return b.newVolume(desc, mat)
+	default:
+		if factory, ok := geometry.Lookup(desc.Type); ok {
+			return factory(desc.Params, core.IdentityMatrix(), mat)
+		}
+		return nil, fmt.Errorf("unknown shape type %q", desc.Type)
+	}
+}
+
+// newVolume builds a volume from a density grid file
+func (b *fileSceneBuilder) newVolume(desc ShapeFile, mat material.Material) (geometry.Shape, error) {
+	if desc.Material == "" {
+		mat = material.NewHenyeyGreenstein(core.NewVec3(1, 1, 1), 0)
+	} else if _, ok := mat.(*material.HenyeyGreenstein); !ok {
+		return nil, fmt.Errorf("volume material %q must be a medium", desc.Material)
+	}
+	density, err := positive("density", desc.Density, 1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := loaders.LoadVolume(b.path(desc.File))
+	if err != nil {
+		return nil, err
+	}
+	bounds := geometry.NewAABB(data.Min, data.Max)
+	if desc.Center != nil || desc.Size != nil {
+		center := desc.Center.vec(bounds.Center())
+		size := desc.Size.vec(data.Max.Subtract(data.Min).Multiply(0.5))
+		bounds = geometry.NewAABB(center.Subtract(size), center.Add(size))
+	}
+	grid, err := geometry.NewDensityGrid(bounds, data.Dimensions[0], data.Dimensions[1], data.Dimensions[2], data.Values)
+	if err != nil {
+		return nil, err
+	}
+	volume := geometry.NewVolume(grid, density, mat)
+	if desc.Temperature == "" {
+		return volume, nil
+	}
+
+	scale, err := positive("temperatureScale", desc.TempScale, 1)
+	if err != nil {
+		return nil, err
+	}
+	emission, err := positive("emission", desc.Emission, 1)
+	if err != nil {
+		return nil, err
+	}
+	data, err = loaders.LoadVolume(b.path(desc.Temperature))
+	if err != nil {
+		return nil, err
+	}
+	for i := range data.Values {
+		data.Values[i] *= scale
+	}
+	temperature, err := geometry.NewDensityGrid(bounds, data.Dimensions[0], data.Dimensions[1], data.Dimensions[2], data.Values)
+	if err != nil {
+		return nil, fmt.Errorf("temperature: %v", err)
+	}
+	volume.SetEmission(temperature, blackbodyEmission(emission, temperature.MaxDensity()))
+	return volume, nil
+}
+
+// blackbodyEmission returns the radiance of a blackbody at a temperature, scaled to luminance at
+// the hottest temperature: cooler temperatures are dimmer and redder, as they are in a flame
+func blackbodyEmission(luminance, hottest float64) func(kelvin float64) core.Vec3 {
+	peak := lights.BlackbodyLuminance(hottest)
+	return func(kelvin float64) core.Vec3 {
+		if peak <= 0 {
+			return core.Vec3{}
+		}
+		return lights.BlackbodyColor(kelvin).Multiply(luminance * lights.BlackbodyLuminance(kelvin) / peak)
+	}
+}
+
+// newMesh builds a triangle mesh from a mesh file or inline vertices and indices
+func (b *fileSceneBuilder) newMesh(desc ShapeFile, mat material.Material) (geometry.Shape, error) {
+	vertices, faces := fileVectors(desc.Vertices), desc.Indices
+	options := &geometry.TriangleMeshOptions{
+		VertexNormals:     fileVectors(desc.Normals),
+		SmoothNormals:     desc.Smooth,
+		SubdivisionLevels: desc.Subdivision,
+		Float32:           desc.Float32,
+	}
+	for _, uv := range desc.UVs {
+		options.VertexUVs = append(options.VertexUVs, core.NewVec2(uv[0], uv[1]))
+	}
+
+	if desc.File != "" {
+		var data *loaders.PLYData
+		var err error
+		switch strings.ToLower(filepath.Ext(desc.File)) {
+		case ".ply":
+			data, err = loaders.LoadPLY(b.path(desc.File))
+		case ".stl":
+			data, err = loaders.LoadSTL(b.path(desc.File))
+		case ".off":
+			data, err = loaders.LoadOFF(b.path(desc.File))
+		default:
+			return nil, fmt.Errorf("unsupported mesh file %q: use PLY, STL or OFF", desc.File)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vertices, faces = data.Vertices, data.Faces
+		if options.VertexNormals == nil && len(data.Normals) == len(vertices) {
+			options.VertexNormals = data.Normals
+		}
+		if options.VertexUVs == nil && len(data.TexCoords) == len(vertices) {
+			options.VertexUVs = data.TexCoords
+		}
+	}
+
+	if len(vertices) == 0 || len(faces) == 0 || len(faces)%3 != 0 {
+		return nil, fmt.Errorf("mesh needs a file, or vertices and indices (3 per triangle)")
+	}
+	for _, index := range faces {
+		if index < 0 || index >= len(vertices) {
+			return nil, fmt.Errorf("mesh index %d out of range for %d vertices", index, len(vertices))
+		}
+	}
+	if options.VertexNormals != nil && len(options.VertexNormals) != len(vertices) {
+		return nil, fmt.Errorf("mesh has %d normals for %d vertices", len(options.VertexNormals), len(vertices))
+	}
+	if options.VertexUVs != nil && len(options.VertexUVs) != len(vertices) {
+		return nil, fmt.Errorf("mesh has %d uvs for %d vertices", len(options.VertexUVs), len(vertices))
+	}
+	if desc.Displace != nil {
+		texture, err := b.texture(desc.Displace)
+		if err != nil {
+			return nil, fmt.Errorf("displacement: %v", err)
+		}
+		options.Displacement, options.DisplacementScale = texture, desc.DisplaceBy
+	}
+	if desc.Rotation != nil {
+		rotation := desc.Rotation.vec(core.Vec3{}).Multiply(math.Pi / 180)
+		center := desc.Center.vec(core.Vec3{})
+		options.Rotation, options.Center = &rotation, &center
+	}
+	return geometry.NewTriangleMesh(vertices, faces, mat, options), nil
+}
+
+// addLight builds a light from its description and adds it to the scene
+func (b *fileSceneBuilder) addLight(s *Scene, desc LightFile) error {
+	zero := core.Vec3{}
+	kelvin, lumens := desc.Kelvin, desc.Lumens
+	if desc.Watts > 0 {
+		lumens = lights.WattsToLumens(desc.Watts, kelvin)
+	}
+	emission := func(newLight func(emission core.Vec3) lights.Light) core.Vec3 {
+		return lightEmission(desc.Emit.vec(core.NewVec3(1, 1, 1)), kelvin, lumens, newLight)
+	}
+
+	switch desc.Type {
+	case "quad":
+		corner, u, v := desc.Corner.vec(zero), desc.U.vec(core.NewVec3(1, 0, 0)), desc.V.vec(core.NewVec3(0, 0, 1))
+		s.AddQuadLight(corner, u, v, emission(func(emission core.Vec3) lights.Light {
+			return lights.NewQuadLight(corner, u, v, material.NewEmissive(emission))
+		}))
+	case "sphere":
+		center := desc.Center.vec(zero)
+		radius, err := positive("radius", desc.Radius, 1)
+		if err != nil {
+			return err
+		}
+		s.AddSphereLight(center, radius, emission(func(emission core.Vec3) lights.Light {
+			return lights.NewSphereLight(center, radius, material.NewEmissive(emission))
+		}))
+	case "disc":
+		center, normal := desc.Center.vec(zero), desc.Normal.vec(core.NewVec3(0, -1, 0))
+		radius, err := positive("radius", desc.Radius, 1)
+		if err != nil {
+			return err
+		}
+		if desc.InnerRadius < 0 || desc.InnerRadius >= radius {
+			return fmt.Errorf("innerRadius %v must be in [0, radius)", desc.InnerRadius)
+		}
+		s.AddDiscLight(center, normal, desc.InnerRadius, radius, emission(func(emission core.Vec3) lights.Light {
+			return lights.NewAnnulusLight(center, normal, desc.InnerRadius, radius, material.NewEmissive(emission))
+		}))
+	case "cylinder", "line":
+		// A line is a thin open tube
+		base, top, capped, defaultRadius := desc.Base.vec(zero), desc.Top.vec(core.NewVec3(0, 1, 0)), desc.Capped, 1.0
+		if desc.Type == "line" {
+			base, top, capped, defaultRadius = desc.Start.vec(zero), desc.End.vec(core.NewVec3(0, 1, 0)), false, 0.01
+		}
+		radius, err := positive("radius", desc.Radius, defaultRadius)
+		if err != nil {
+			return err
+		}
+		s.AddCylinderLight(base, top, radius, capped, emission(func(emission core.Vec3) lights.Light {
+			return lights.NewCylinderLight(base, top, radius, capped, material.NewEmissive(emission))
+		}))
+	case "cone", "capsule":
+		newShape := func(mat material.Material) (geometry.SurfaceSampler, error) {
+			shape, err := newRoundShape(desc.Type, desc.Base, desc.Top, desc.Start, desc.End, desc.Radius, desc.TopRadius, desc.Capped, mat)
+			if err != nil {
+				return nil, err
+			}
+			return shape.(geometry.SurfaceSampler), nil
+		}
+		if _, err := newShape(nil); err != nil {
+			return err
+		}
+		emissiveMat := material.NewEmissive(emission(func(emission core.Vec3) lights.Light {
+			mat := material.NewEmissive(emission)
+			shape, _ := newShape(mat)
+			return lights.NewShapeLight(shape, mat)
+		}))
+		shape, _ := newShape(emissiveMat)
+		s.AddShapeLight(shape, emissiveMat)
+	case "spot":
+		from, to := desc.From.vec(zero), desc.To.vec(core.NewVec3(0, -1, 0))
+		angle, delta := desc.Angle, desc.Delta
+		if angle == 0 {
+			angle = 45
+		}
+		if desc.Radius > 0 {
+			s.AddSpotLight(from, to, emission(func(emission core.Vec3) lights.Light {
+				return lights.NewDiscSpotLight(from, to, emission, angle, delta, desc.Radius)
+			}), angle, delta, desc.Radius)
+		} else {
+			s.AddPointSpotLight(from, to, emission(func(emission core.Vec3) lights.Light {
+				return lights.NewPointSpotLight(from, to, emission, angle, delta)
+			}), angle, delta, 0)
+		}
+	case "ies":
+		if err := s.AddIESLight(b.path(desc.File), desc.From.vec(zero), desc.To.vec(core.NewVec3(0, -1, 0)), emission(nil)); err != nil {
+			return err
+		}
+	case "projector":
+		image, err := loaders.LoadImage(b.path(desc.File))
+		if err != nil {
+			return err
+		}
+		fov, aspect := desc.FOV, desc.Aspect
+		if fov == 0 {
+			fov = 30
+		}
+		if aspect == 0 {
+			aspect = float64(image.Width) / float64(image.Height)
+		}
+		texture := material.NewImageTexture(image.Width, image.Height, image.Pixels)
+		s.AddProjectorLight(desc.From.vec(zero), desc.To.vec(core.NewVec3(0, -1, 0)), desc.Up.vec(core.NewVec3(0, 1, 0)),
+			fov, aspect, texture, emission(nil))
+	case "sun":
+		angle := desc.Angle
+		if angle == 0 {
+			angle = lights.SunAngularDiameter
+		}
+		s.AddDirectionalLight(desc.Direction.vec(core.NewVec3(0, -1, 0)), emission(nil), angle)
+	case "sky":
+		if desc.File != "" {
+			if err := s.AddImageInfiniteLight(b.path(desc.File), emission(nil)); err != nil {
+				return err
+			}
+		} else if desc.Top != nil || desc.Bottom != nil {
+			s.AddGradientInfiniteLight(desc.Top.vec(core.NewVec3(1, 1, 1)), desc.Bottom.vec(zero))
+		} else {
+			s.AddUniformInfiniteLight(emission(nil))
+		}
+	case "daylight":
+		turbidity, scale := desc.Turbidity, desc.Scale
+		if turbidity == 0 {
+			turbidity = 3
+		}
+		if scale == 0 {
+			scale = 0.03
+		}
+		s.AddPhysicalSkyLight(turbidity, desc.Elevation, desc.Azimuth, scale)
+	case "portal":
+		return s.AddPortals(geometry.NewQuad(desc.Corner.vec(zero), desc.U.vec(core.NewVec3(1, 0, 0)), desc.V.vec(core.NewVec3(0, 1, 0)), nil))
+	default:
+		factory, ok := lights.Lookup(desc.Type)
+		if !ok {
+			return fmt.Errorf("unknown light type %q", desc.Type)
+		}
+		light, err := factory(desc.Params, core.IdentityMatrix())
+		if err != nil {
+			return err
+		}
+		s.Lights = append(s.Lights, light)
+	}
+
+	if len(desc.Hide) > 0 {
+		hiddenFrom, err := parseRayKinds(desc.Hide)
+		if err != nil {
+			return err
+		}
+		return s.SetLightVisibility(s.Lights[len(s.Lights)-1], hiddenFrom)
+	}
+	return nil
+}
+
+// newRoundShape builds the cylinder, cone or capsule shared by shapes and lights
+func newRoundShape(kind string, base, top, start, end *FileVec3, radius, topRadius float64, capped bool, mat material.Material) (geometry.Shape, error) {
+	radius, err := positive("radius", radius, 1)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "cylinder":
+		return geometry.NewCylinder(base.vec(core.Vec3{}), top.vec(core.NewVec3(0, 1, 0)), radius, capped, mat), nil
+	case "cone":
+		if topRadius < 0 {
+			return nil, fmt.Errorf("topRadius %v must not be negative", topRadius)
+		}
+		return geometry.NewCone(base.vec(core.Vec3{}), radius, top.vec(core.NewVec3(0, 1, 0)), topRadius, capped, mat)
+	default:
+		return geometry.NewCapsule(start.vec(core.Vec3{}), end.vec(core.NewVec3(0, 1, 0)), radius, mat), nil
+	}
+}
+
+// lightEmission tints a light's color by a color temperature in kelvin, when given, and scales it
+// to lumens of power, when given, for the light newLight creates. Infinite and directional lights
+// (nil newLight) keep their scale.
+func lightEmission(color core.Vec3, kelvin, lumens float64, newLight func(emission core.Vec3) lights.Light) core.Vec3 {
+	if kelvin > 0 {
+		color = color.MultiplyVec(lights.BlackbodyColor(kelvin))
+	}
+	if lumens > 0 && newLight != nil {
+		color = lights.EmissionForPower(color, lumens, newLight)
+	}
+	return color
+}
+
+// parseRayKinds converts the names camera, indirect and shadows to a set of ray kinds
+func parseRayKinds(names []string) (material.RayKind, error) {
+	var kinds material.RayKind
+	for _, name := range names {
+		switch name {
+		case "camera":
+			kinds |= material.CameraRays
+		case "indirect":
+			kinds |= material.IndirectRays
+		case "shadows":
+			kinds |= material.ShadowRays
+		default:
+			return 0, fmt.Errorf("unknown ray kind %q", name)
+		}
+	}
+	return kinds, nil
+}
+
+// positive returns value, or def when it's zero, and fails for negative values
+func positive(name string, value, def float64) (float64, error) {
+	if value == 0 {
+		return def, nil
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("%s %v must be positive", name, value)
+	}
+	return value, nil
+}
+
+// fileVectors converts a list of scene file vectors, keeping nil for an empty list
+func fileVectors(vectors []FileVec3) []core.Vec3 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	result := make([]core.Vec3, len(vectors))
+	for i := range vectors {
+		result[i] = vectors[i].vec(core.Vec3{})
+	}
+	return result
+}
@@ -0,0 +1,350 @@
+package scene
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestParseSceneFile_YAMLMatchesJSON(t *testing.T) {
+	yamlScene, err := ParseSceneFile([]byte(`
+camera: {center: [0, 1, 5], lookAt: [0, 1, 0], fov: 40, width: 200, aspectRatio: 2}
+sampling:
+  samples: 32
+materials:
+  red: {type: lambertian, albedo: [0.6, 0.1, 0.1]}
+shapes:
+  - type: sphere
+    center: [0, 1, 0]
+    material: red
+lights:
+  - {type: sky, emit: [0.5, 0.5, 0.5]}
+`), true)
+	if err != nil {
+		t.Fatalf("ParseSceneFile(YAML) failed: %v", err)
+	}
+	jsonScene, err := ParseSceneFile([]byte(`{
+		"camera": {"center": [0, 1, 5], "lookAt": [0, 1, 0], "fov": 40, "width": 200, "aspectRatio": 2},
+		"sampling": {"samples": 32},
+		"materials": {"red": {"type": "lambertian", "albedo": [0.6, 0.1, 0.1]}},
+		"shapes": [{"type": "sphere", "center": [0, 1, 0], "material": "red"}],
+		"lights": [{"type": "sky", "emit": [0.5, 0.5, 0.5]}]
+	}`), false)
+	if err != nil {
+		t.Fatalf("ParseSceneFile(JSON) failed: %v", err)
+	}
+
+	for name, file := range map[string]*SceneFile{"yaml": yamlScene, "json": jsonScene} {
+		s, err := NewFileScene(file, "")
+		if err != nil {
+			t.Fatalf("%s: NewFileScene failed: %v", name, err)
+		}
+		if s.CameraConfig.Center != core.NewVec3(0, 1, 5) || s.CameraConfig.VFov != 40 || s.SamplingConfig.Height != 100 {
+			t.Errorf("%s: camera not applied: %+v, height %d", name, s.CameraConfig, s.SamplingConfig.Height)
+		}
+		// Unset sampling settings keep their defaults
+		if s.SamplingConfig.SamplesPerPixel != 32 || s.SamplingConfig.MaxDepth != 8 {
+			t.Errorf("%s: expected 32 samples and the default depth 8, got %+v", name, s.SamplingConfig)
+		}
+		sphere, ok := s.Shapes[0].(*geometry.Sphere)
+		if !ok || sphere.Center != core.NewVec3(0, 1, 0) || sphere.Radius != 1 {
+			t.Fatalf("%s: expected a unit sphere at (0,1,0), got %#v", name, s.Shapes[0])
+		}
+		if albedo := sphere.Material.(*material.Lambertian).Albedo.Evaluate(core.Vec2{}, core.Vec3{}); albedo != core.NewVec3(0.6, 0.1, 0.1) {
+			t.Errorf("%s: expected the red material, got albedo %v", name, albedo)
+		}
+		if _, ok := s.Lights[0].(*lights.UniformInfiniteLight); !ok {
+			t.Errorf("%s: expected a uniform sky, got %T", name, s.Lights[0])
+		}
+	}
+}
+
+func TestLoadSceneFile_AllTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("tetra.off", "OFF\n4 4 0\n0 0 0\n1 0 0\n0 1 0\n0 0 1\n3 0 2 1\n3 0 1 3\n3 0 3 2\n3 1 2 3\n")
+	writeFile("ball.sdf", "2 2 2\n-1 -1 -1\n2\n1 1 1 1 1 1 1 -1\n")
+	var vol bytes.Buffer
+	vol.WriteString("VOL\x03")
+	binary.Write(&vol, binary.LittleEndian, [5]int32{1, 2, 2, 2, 1})
+	binary.Write(&vol, binary.LittleEndian, [14]float32{0, 0, 0, 1, 1, 1, 0, 1, 0, 1, 0, 1, 0, 1})
+	writeFile("smoke.vol", vol.String())
+	pixels := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range pixels.Pix {
+		pixels.Pix[i] = 255
+	}
+	pixels.Set(0, 0, color.RGBA{A: 255})
+	imageFile, err := os.Create(filepath.Join(dir, "mask.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(imageFile, pixels); err != nil {
+		t.Fatal(err)
+	}
+	imageFile.Close()
+
+	writeFile("scene.yaml", `
+materials:
+  glass: {type: dielectric}
+  gold: {type: metal, albedo: [0.8, 0.6, 0.2], fuzz: 0.1}
+  checker: {type: metal, texture: {type: checkerboard, width: 16}}
+  glow: {type: emissive, emit: [2, 2, 2]}
+  glazed: {type: layered, outer: glass, inner: checker}
+  blend: {type: mix, first: gold, second: glazed, ratio: 0.3}
+  leaf: {type: cutout, base: blend, mask: {type: image, file: mask.png}}
+  hair: {type: hair, color: [0.3, 0.2, 0.1]}
+  smoke: {type: medium, albedo: [0.9, 0.9, 0.9], g: 0.3}
+  tinted: {type: dielectric, glass: BK7, color: [0.8, 0.9, 1]}
+  prism: {type: dielectric, ior: 1.6, dispersion: 0.01}
+  scan: {type: lambertian, texture: {type: checkerboard, width: 16, projection: triplanar, size: 0.5}}
+  globe: {type: lambertian, texture: {type: image, file: mask.png, projection: spherical, axis: [0, 0, 1]}}
+  brushed: {type: metal, texture: {type: marble, scale: 4, color1: [0.3, 0.3, 0.3]}, fuzzTexture: {type: fbm, octaves: 3}}
+  worn: {type: pbr, texture: {type: checkerboard, width: 16}, orm: {type: image, file: mask.png}, roughness: 0.5}
+  neon: {type: emissive, emit: [4, 4, 4], texture: {type: image, file: mask.png, channel: r}}
+  streaked: {type: mix, first: gold, second: scan, ratioTexture: {type: turbulence}}
+  ground: {type: shadowcatcher, albedo: [0.5, 0.5, 0.5]}
+shapes:
+  - {type: sphere, material: glass}
+  - {type: quad, material: gold}
+  - {type: triangle, material: leaf, hide: [camera, indirect]}
+  - {type: box, size: [1, 2, 3], rotation: [0, 90, 0], material: ground}
+  - {type: disc, radius: 2, innerRadius: 1, material: glow}
+  - {type: cylinder, capped: true, material: tinted}
+  - {type: cone, topRadius: 0.5, material: prism}
+  - {type: capsule, material: globe}
+  - {type: torus, material: worn}
+  - {type: mesh, file: tetra.off, smooth: true, material: brushed, displacement: {type: wood, scale: 3}, displacementScale: 0.05}
+  - {type: mesh, vertices: [[0, 0, 0], [1, 0, 0], [0, 1, 0]], indices: [0, 1, 2], rotation: [0, 0, 90], material: scan}
+  - {type: curve, points: [[0, 0, 0], [0, 1, 0], [1, 1, 0], [1, 2, 0]], material: hair}
+  - {type: mandelbulb, material: neon}
+  - {type: sdf, file: ball.sdf, material: streaked}
+  - {type: volume, file: smoke.vol, density: 4, material: smoke, center: [0, 1, 0], size: [2, 1, 1]}
+lights:
+  - {type: quad, corner: [0, 3, 0], u: [1, 0, 0], v: [0, 0, 1], watts: 10, kelvin: 4000, hide: [camera]}
+  - {type: sphere, center: [0, 3, 0], radius: 0.1, lumens: 100}
+  - {type: disc, center: [0, 3, 0], lumens: 100}
+  - {type: cylinder, base: [0, 3, 0], top: [1, 3, 0], radius: 0.05, lumens: 100}
+  - {type: cone, base: [0, 3, 0], top: [0, 3.5, 0]}
+  - {type: capsule, start: [0, 3, 0], end: [1, 3, 0], radius: 0.05}
+  - {type: line, start: [0, 3, 0], end: [1, 3, 0], lumens: 100}
+  - {type: spot, from: [0, 3, 0], to: [0, 0, 0], angle: 30, radius: 0.1}
+  - {type: spot, from: [0, 3, 0], to: [0, 0, 0], lumens: 500}
+  - {type: projector, file: mask.png, from: [0, 3, 0], to: [0, 0, 0]}
+  - {type: sun, direction: [-1, -2, -1], emit: [3, 3, 3]}
+  - {type: sky, top: [0.5, 0.7, 1], bottom: [0, 0, 0]}
+  - {type: daylight, elevation: 40}
+  - {type: portal, corner: [-1, 0, -3], u: [2, 0, 0], v: [0, 1, 0]}
+`)
+	s, err := LoadSceneFile(filepath.Join(dir, "scene.yaml"))
+	if err != nil {
+		t.Fatalf("LoadSceneFile failed: %v", err)
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	// Area lights add their shapes after the scene's own
+	shapeTypes := []string{"Sphere", "Quad", "VisibilityShape", "Box", "Disc", "Cylinder", "Cone", "Capsule", "Torus",
+		"TriangleMesh", "TriangleMesh", "Curves", "ImplicitSurface", "ImplicitSurface", "Volume",
+		"VisibilityShape", "Sphere", "Disc", "Cylinder", "Cone", "Capsule", "Cylinder", "Disc"}
+	if len(s.Shapes) != len(shapeTypes) {
+		t.Fatalf("Expected %d shapes, got %d", len(shapeTypes), len(s.Shapes))
+	}
+	for i, want := range shapeTypes {
+		if got := typeName(s.Shapes[i]); got != want {
+			t.Errorf("Shape %d: expected %s, got %s", i, want, got)
+		}
+	}
+	lightTypes := []string{"QuadLight", "SphereLight", "DiscLight", "CylinderLight", "ShapeLight", "ShapeLight", "CylinderLight",
+		"DiscSpotLight", "PointSpotLight", "ProjectorLight", "DirectionalLight", "PortalLight", "PortalLight"}
+	if len(s.Lights) != len(lightTypes) {
+		t.Fatalf("Expected %d lights, got %d", len(lightTypes), len(s.Lights))
+	}
+	for i, want := range lightTypes {
+		if got := typeName(s.Lights[i]); got != want {
+			t.Errorf("Light %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	// Box sizes are half extents and rotations are in degrees
+	box := s.Shapes[3].(*geometry.Box)
+	if box.Size != core.NewVec3(1, 2, 3) || math.Abs(box.Rotation.Y-math.Pi/2) > 1e-12 {
+		t.Errorf("Expected a box of half extents (1,2,3) turned 90 degrees, got %v and %v", box.Size, box.Rotation)
+	}
+	if catcher, ok := box.Material.(*material.ShadowCatcher); !ok || typeName(catcher.Base) != "Lambertian" {
+		t.Errorf("Expected the box's shadow catcher standing in for a lambertian, got %#v", box.Material)
+	}
+
+	// Powers are measured on the light as built
+	for i, want := range []float64{lights.WattsToLumens(10, 4000), 100, 100, 100} {
+		if got := lights.EstimatePower(s.Lights[i], 1<<16).Luminance(); math.Abs(got-want) > 0.03*want {
+			t.Errorf("Light %d: expected %.1f lumens, got %.1f", i, want, got)
+		}
+	}
+	// Volumes can be moved from the box in their file
+	volume := s.Shapes[14].(*geometry.Volume)
+	if volume.Grid.Bounds != geometry.NewAABB(core.NewVec3(-2, 0, -1), core.NewVec3(2, 2, 1)) || volume.Scale != 4 {
+		t.Errorf("Expected the volume moved to (-2,0,-1)-(2,2,1) with density 4, got %v and %f", volume.Grid.Bounds, volume.Scale)
+	}
+	if mat := s.Shapes[2].(*geometry.VisibilityShape).Shape.(*geometry.Triangle).Material; typeName(mat) != "Cutout" {
+		t.Errorf("Expected the triangle's cutout material, got %T", mat)
+	}
+	// PBR materials mix a diffuse base with a metal by the blue channel of the ORM texture
+	if mix, ok := s.Shapes[8].(*geometry.Torus).Material.(*material.Mix); !ok || mix.RatioTexture == nil || mix.Material2.(*material.Metal).FuzzTexture == nil {
+		t.Errorf("Expected the torus's ORM-textured mix, got %#v", s.Shapes[8].(*geometry.Torus).Material)
+	}
+}
+
+func TestLoadSceneFile_GlowingVolume(t *testing.T) {
+	dir := t.TempDir()
+	writeVol := func(name string, values [8]float32) {
+		var vol bytes.Buffer
+		vol.WriteString("VOL\x03")
+		binary.Write(&vol, binary.LittleEndian, [5]int32{1, 2, 2, 2, 1})
+		binary.Write(&vol, binary.LittleEndian, [6]float32{-1, -1, -1, 1, 1, 1})
+		binary.Write(&vol, binary.LittleEndian, values)
+		if err := os.WriteFile(filepath.Join(dir, name), vol.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeVol("density.vol", [8]float32{1, 1, 1, 1, 1, 1, 1, 1})
+	writeVol("heat.vol", [8]float32{1, 1, 1, 1, 1, 1, 1, 1})
+	if err := os.WriteFile(filepath.Join(dir, "scene.yaml"), []byte(`
+materials:
+  fire: {type: medium, albedo: [0.25, 0.25, 0.25]}
+shapes:
+  - {type: volume, file: density.vol, density: 100, material: fire, temperature: heat.vol, temperatureScale: 1500, emission: 8}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSceneFile(filepath.Join(dir, "scene.yaml"))
+	if err != nil {
+		t.Fatalf("LoadSceneFile failed: %v", err)
+	}
+
+	// Dense enough that a ray collides right away, where it is as hot as anywhere: the collision
+	// emits the absorbed share of the emission, colored like a 1500 K blackbody
+	hit, ok := s.Shapes[0].Hit(core.NewRay(core.NewVec3(0, 0, -5), core.NewVec3(0, 0, 1)), 0.001, math.Inf(1))
+	if !ok {
+		t.Fatal("Expected the ray to collide in the volume")
+	}
+	emitter, ok := hit.Material.(material.MediumEmitter)
+	if !ok {
+		t.Fatalf("Expected a glowing collision, got %T", hit.Material)
+	}
+	emission := emitter.Emit(core.Ray{}, hit)
+	want := lights.BlackbodyColor(1500).Multiply(0.75 * 8)
+	if emission.Subtract(want).Length() > 0.01*want.Length() {
+		t.Errorf("Expected an emission of %v, got %v", want, emission)
+	}
+}
+
+func TestNewFileScene_NamedCameras(t *testing.T) {
+	file, err := ParseSceneFile([]byte(`
+camera: {center: [0, 1, 5], lookAt: [0, 1, 0], fov: 40, width: 200, aspectRatio: 2, aperture: 0.1}
+cameras:
+  top: {center: [0, 6, 0], up: [0, 0, -1]}
+`), true)
+	if err != nil {
+		t.Fatalf("ParseSceneFile failed: %v", err)
+	}
+	s, err := NewFileScene(file, "")
+	if err != nil {
+		t.Fatalf("NewFileScene failed: %v", err)
+	}
+	if err := s.UseCamera("top"); err != nil {
+		t.Fatalf("UseCamera failed: %v", err)
+	}
+
+	// Unset vectors and fov are the main camera's; the lens is the named camera's own
+	want := geometry.CameraConfig{
+		Center: core.NewVec3(0, 6, 0), LookAt: core.NewVec3(0, 1, 0), Up: core.NewVec3(0, 0, -1),
+		Width: 200, AspectRatio: 2, VFov: 40,
+	}
+	if s.CameraConfig != want {
+		t.Errorf("Expected camera %+v, got %+v", want, s.CameraConfig)
+	}
+}
+
+func TestNewFileScene_MeshLight(t *testing.T) {
+	file, err := ParseSceneFile([]byte(`
+materials:
+  glow: {type: emissive, emit: [4, 4, 4]}
+shapes:
+  - {type: mesh, vertices: [[0, 2, 0], [1, 2, 0], [0, 2, 1], [1, 2, 1]], indices: [0, 1, 2, 1, 3, 2], material: glow, light: true, hide: [camera]}
+`), true)
+	if err != nil {
+		t.Fatalf("ParseSceneFile failed: %v", err)
+	}
+	s, err := NewFileScene(file, "")
+	if err != nil {
+		t.Fatalf("NewFileScene failed: %v", err)
+	}
+	if len(s.Lights) != 1 || len(s.Shapes) != 1 {
+		t.Fatalf("Expected the mesh as one light and one shape, got %d lights and %d shapes", len(s.Lights), len(s.Shapes))
+	}
+	light, ok := s.Lights[0].(*lights.ShapeLight)
+	if !ok || light.SurfaceArea() != 1 {
+		t.Fatalf("Expected a shape light of area 1, got %#v", s.Lights[0])
+	}
+	if typeName(s.Shapes[0]) != "VisibilityShape" {
+		t.Errorf("Expected the light's mesh hidden from camera rays, got %s", typeName(s.Shapes[0]))
+	}
+}
+
+func TestParseSceneFile_Errors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`shapes: [{type: sphere, radus: 1}]`, `unknown field "radus"`},
+		{`shapes: [{type: sphere, center: [0, 1]}]`, "expected [x, y, z]"},
+		{`shapes: [{type: blob}]`, `shape 1 (blob): unknown shape type "blob"`},
+		{`shapes: [{type: sphere, radius: -1}]`, "radius -1 must be positive"},
+		{`shapes: [{type: sphere, material: gold}]`, `unknown material "gold"`},
+		{`shapes: [{type: sphere, hide: [mirrors]}]`, `unknown ray kind "mirrors"`},
+		{"materials: {a: {type: layered, outer: b, inner: a}, b: {type: dielectric}}\nshapes: [{type: sphere, material: a}]", `material "a" is made of itself`},
+		{`materials: {a: {type: plastic}}` + "\nshapes: [{type: quad, material: a}]", `unknown material type "plastic"`},
+		{`shapes: [{type: mesh, vertices: [[0, 0, 0]], indices: [0, 1, 2]}]`, "mesh index 1 out of range"},
+		{`shapes: [{type: mesh, file: missing.ply}]`, "failed to open PLY file"},
+		{`materials: {a: {type: lambertian, texture: {type: uv, projection: cubic}}}` + "\nshapes: [{type: quad, material: a}]", `unknown projection "cubic"`},
+		{`materials: {a: {type: lambertian, texture: {type: uv, channel: alpha}}}` + "\nshapes: [{type: quad, material: a}]", `unknown texture channel "alpha"`},
+		{`materials: {a: {type: pbr, metallic: 2}}` + "\nshapes: [{type: quad, material: a}]", "roughness and metallic must be between 0 and 1"},
+		{`materials: {a: {type: metal, fuzzTexture: {type: fbm, gain: 1}}}` + "\nshapes: [{type: quad, material: a}]", "gain in [0, 1)"},
+		{`shapes: [{type: torus, light: true}]`, "torus shapes can't be lights"},
+		{`shapes: [{type: sphere, light: true}]`, `light shape material "" must be emissive`},
+		{`shapes: [{type: volume, material: default}]`, `volume material "default" must be a medium`},
+		{`shapes: [{type: volume, file: missing.vol}]`, "failed to open volume file"},
+		{`materials: {a: {type: medium, g: 1}}` + "\nshapes: [{type: volume, material: a}]", "g 1 must be between -1 and 1"},
+		{`lights: [{type: torch}]`, `light 1 (torch): unknown light type "torch"`},
+		{`lights: [{type: portal}]`, "portals need an infinite light"},
+		{`lights: [{type: quad, hide: [indirect]}]`, "lights can't be hidden from indirect rays"},
+		{`camera: {width: 0}`, "camera width, aspectRatio and fov must be positive"},
+		{`sampling: {samples: 0}`, "sampling samples and depth must be positive"},
+	}
+	for _, test := range tests {
+		file, err := ParseSceneFile([]byte(test.source), true)
+		if err == nil {
+			_, err = NewFileScene(file, t.TempDir())
+		}
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error = %v, want %q", test.source, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package scene
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+// countingIntersector wraps a backend and counts the queries it answers
+type countingIntersector struct {
+	geometry.Intersector
+	hits, hitAnys int
+}
+
+func (c *countingIntersector) Hit(ray core.Ray, tMin, tMax float64) (*material.SurfaceInteraction, bool) {
+	c.hits++
+	return c.Intersector.Hit(ray, tMin, tMax)
+}
+
+func (c *countingIntersector) HitAny(ray core.Ray, tMin, tMax float64) bool {
+	c.hitAnys++
+	return c.Intersector.HitAny(ray, tMin, tMax)
+}
+
+func TestPreprocess_DefaultIntersector(t *testing.T) {
+	s := &Scene{Shapes: []geometry.Shape{
+		geometry.NewSphere(core.NewVec3(0, 0, 0), 1, material.NewLambertian(core.NewVec3(0.5, 0.5, 0.5))),
+	}}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	if s.BVH == nil || s.Intersector != geometry.Intersector(s.BVH) {
+		t.Errorf("Expected the local BVH as the default intersector, got %T", s.Intersector)
+	}
+	if s.WorldCenter != core.NewVec3(0, 0, 0) || math.Abs(s.WorldRadius-math.Sqrt(3)) > 1e-9 {
+		t.Errorf("Expected world bounds (0,0,0) radius √3, got %v radius %f", s.WorldCenter, s.WorldRadius)
+	}
+}
+
+func TestPreprocess_IntersectorBuilder(t *testing.T) {
+	var backend *countingIntersector
+	s := NewDefaultScene()
+	s.IntersectorBuilder = func(shapes []geometry.Shape) (geometry.Intersector, error) {
+		backend = &countingIntersector{Intersector: geometry.NewBVH(shapes)}
+		return backend, nil
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	if s.Intersector != geometry.Intersector(backend) {
+		t.Fatalf("Expected the builder's intersector, got %T", s.Intersector)
+	}
+	if s.BVH != nil {
+		t.Error("BVH should only be set when it is the intersector")
+	}
+	if s.WorldRadius <= 0 {
+		t.Errorf("Expected world bounds from the custom backend, got radius %f", s.WorldRadius)
+	}
+
+	// Builder errors are reported by Preprocess
+	s.IntersectorBuilder = func(shapes []geometry.Shape) (geometry.Intersector, error) {
+		return nil, errors.New("backend unavailable")
+	}
+	if err := s.Preprocess(); err == nil {
+		t.Error("Expected Preprocess to fail when the intersector can't be built")
+	}
+}
+
+func TestPreprocess_SpatialLightSampler(t *testing.T) {
+	s := NewCornellScene(CornellEmpty, CornellQuadLight)
+	grid := lights.NewGridLightSampler(s.Lights, 4)
+	s.LightSampler = grid
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	if s.LightSampler != lights.LightSampler(grid) {
+		t.Fatalf("Expected the scene's own light sampler to be kept, got %T", s.LightSampler)
+	}
+	// The grid was built, so it answers queries anywhere in the scene
+	if p := grid.GetLightProbability(0, s.WorldCenter, core.NewVec3(0, 1, 0)); p <= 0 {
+		t.Errorf("Expected a positive light probability after Preprocess, got %f", p)
+	}
+}
+
+func TestAddIESLight(t *testing.T) {
+	// A rotationally symmetric downlight of 500 cd straight down, half that at 45°
+	content := "IESNA:LM-63-2002\nTILT=NONE\n1 -1 1 3 1 1 1 0 0 0\n1 1 10\n0 45 90\n0\n500 250 0\n"
+	filename := filepath.Join(t.TempDir(), "downlight.ies")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Scene{}
+	if err := s.AddIESLight(filename, core.NewVec3(0, 2, 0), core.Vec3{}, core.NewVec3(1, 1, 1)); err != nil {
+		t.Fatalf("Failed to add IES light: %v", err)
+	}
+	light := s.Lights[0]
+	normal := core.NewVec3(0, 1, 0)
+	if below := light.Sample(core.Vec3{}, normal, core.Vec2{}).Emission.X; math.Abs(below-500.0/4) > 1e-9 {
+		t.Errorf("Expected 500 cd at distance 2 below the light, got %f", below*4)
+	}
+	if diagonal := light.Sample(core.NewVec3(2, 0, 0), normal, core.Vec2{}).Emission.X; math.Abs(diagonal-250.0/8) > 1e-9 {
+		t.Errorf("Expected 250 cd at 45°, got %f", diagonal*8)
+	}
+
+	if err := s.AddIESLight(filepath.Join(t.TempDir(), "missing.ies"), core.Vec3{}, core.NewVec3(0, -1, 0), core.NewVec3(1, 1, 1)); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestAddPortals(t *testing.T) {
+	s := &Scene{}
+	window := geometry.NewQuad(core.NewVec3(-1, 0, -3), core.NewVec3(2, 0, 0), core.NewVec3(0, 1, 0), nil)
+	if err := s.AddPortals(window); err == nil {
+		t.Error("Expected an error without an infinite light")
+	}
+
+	s.AddUniformInfiniteLight(core.NewVec3(1, 1, 1))
+	s.AddPointSpotLight(core.NewVec3(0, 2, 0), core.Vec3{}, core.NewVec3(1, 1, 1), 45, 5, 0)
+	s.AddDirectionalLight(core.NewVec3(0, -1, 0), core.NewVec3(1, 1, 1), lights.SunAngularDiameter)
+	skylight := geometry.NewQuad(core.NewVec3(-1, 3, -1), core.NewVec3(2, 0, 0), core.NewVec3(0, 0, 2), nil)
+	if err := s.AddPortals(window); err != nil {
+		t.Fatalf("AddPortals failed: %v", err)
+	}
+	if err := s.AddPortals(skylight); err != nil {
+		t.Fatalf("AddPortals failed: %v", err)
+	}
+
+	// The sky gathers both portals; the spot light is left alone
+	portal, ok := s.Lights[0].(*lights.PortalLight)
+	if !ok {
+		t.Fatalf("Expected a portal light, got %T", s.Lights[0])
+	}
+	if len(portal.Portals()) != 2 {
+		t.Errorf("Expected 2 portals, got %d", len(portal.Portals()))
+	}
+	if _, ok := portal.Environment().(*lights.UniformInfiniteLight); !ok {
+		t.Errorf("Expected the portals to guide the uniform sky, got %T", portal.Environment())
+	}
+	if _, ok := s.Lights[1].(*lights.PointSpotLight); !ok {
+		t.Errorf("Expected the spot light unchanged, got %T", s.Lights[1])
+	}
+	if _, ok := s.Lights[2].(*lights.DirectionalLight); !ok {
+		t.Errorf("Expected the directional light unchanged, got %T", s.Lights[2])
+	}
+}
+
+func TestSetLightVisibility(t *testing.T) {
+	s, err := NewMicroScene(`
+		quadlight corner -0.5 2 -2.5 u 1 0 0 v 0 0 1 emit 5 5 5 hide camera
+		sphere at 0 0 -3 radius 0.5 hide camera,indirect
+		spotlight at 0 3 0
+	`, nil)
+	if err != nil {
+		t.Fatalf("NewMicroScene failed: %v", err)
+	}
+	if s.HidesShapes() {
+		t.Error("Expected HidesShapes to be false before Preprocess")
+	}
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if !s.HidesShapes() {
+		t.Error("Expected HidesShapes after Preprocess")
+	}
+
+	for i, want := range []material.RayKind{material.CameraRays, material.CameraRays | material.IndirectRays} {
+		shape, ok := s.Shapes[i].(*geometry.VisibilityShape)
+		if !ok || shape.HiddenFrom != want {
+			t.Errorf("Shape %d: expected a shape hidden from %b, got %#v", i, want, s.Shapes[i])
+		}
+	}
+	if _, ok := s.Shapes[0].(*geometry.VisibilityShape).Shape.(*geometry.Quad); !ok {
+		t.Error("Expected the quad light's quad to be hidden")
+	}
+
+	// Hiding a shape again replaces its flags rather than wrapping it twice
+	sphere := s.Shapes[1].(*geometry.VisibilityShape).Shape
+	if err := s.SetVisibility(sphere, material.ShadowRays); err != nil {
+		t.Fatalf("SetVisibility failed: %v", err)
+	}
+	if shape := s.Shapes[1].(*geometry.VisibilityShape); shape.Shape != sphere || shape.HiddenFrom != material.ShadowRays {
+		t.Errorf("Expected the sphere hidden from shadow rays only, got %#v", shape)
+	}
+
+	if err := s.SetLightVisibility(s.Lights[1], material.CameraRays); err == nil {
+		t.Error("Expected an error hiding a point light")
+	}
+	if err := s.SetVisibility(geometry.NewSphere(core.Vec3{}, 1, nil), material.CameraRays); err == nil {
+		t.Error("Expected an error hiding a shape that isn't in the scene")
+	}
+	if stats, err := Describe(s); err != nil || stats.Shapes["Quad"] != 1 || stats.Shapes["Sphere"] != 1 {
+		t.Errorf("Expected Describe to count the hidden shapes by their own types, got %v (%v)", stats.Shapes, err)
+	}
+}
+
+func TestSamplingConfigPreviewProfile(t *testing.T) {
+	full := SamplingConfig{MaxDepth: 50, RussianRouletteMinBounces: 3, PrimaryLightSamples: 2, GuidingPasses: 4, IrradianceCache: 0.2, SamplesPerPixel: 100}
+	preview := full.PreviewProfile()
+	if preview.MaxDepth != 4 || preview.RussianRouletteMinBounces <= preview.MaxDepth {
+		t.Errorf("Expected depth 4 with no roulette, got depth %d and roulette after %d", preview.MaxDepth, preview.RussianRouletteMinBounces)
+	}
+	if preview.PrimaryLightSamples != 0 || preview.GuidingPasses != 0 || preview.IrradianceCache != 0 {
+		t.Errorf("Expected one light sample and no guiding or cache, got %+v", preview)
+	}
+	if preview.SamplesPerPixel != 100 || full.MaxDepth != 50 {
+		t.Errorf("Expected the other settings kept and the full profile unchanged, got %+v and %+v", preview, full)
+	}
+	if shallow := (SamplingConfig{MaxDepth: 2}).PreviewProfile(); shallow.MaxDepth != 2 {
+		t.Errorf("Expected a shallower depth kept, got %d", shallow.MaxDepth)
+	}
+}
+
+func TestFocusDistanceAt(t *testing.T) {
+	s := NewDefaultScene(geometry.CameraConfig{Width: 160})
+	s.SamplingConfig.Width, s.SamplingConfig.Height = 160, 90
+	if err := s.Preprocess(); err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	// The center pixel sees the front of the center sphere, its radius short of the look-at point
+	distance, err := s.FocusDistanceAt(80, 45)
+	if err != nil {
+		t.Fatalf("FocusDistanceAt failed: %v", err)
+	}
+	want := s.CameraConfig.LookAt.Subtract(s.CameraConfig.Center).Length() - 0.5
+	if math.Abs(distance-want) > 0.02 {
+		t.Errorf("Expected focus distance about %.3f, got %.3f", want, distance)
+	}
+
+	if _, err := s.FocusDistanceAt(0, 0); err == nil {
+		t.Error("Expected an error for a pixel that sees only the sky")
+	}
+	if _, err := s.FocusDistanceAt(160, 45); err == nil {
+		t.Error("Expected an error for a pixel outside the image")
+	}
+
+	camera := s.Camera
+	s.SetFocusDistance(distance)
+	if s.CameraConfig.FocusDistance != distance || s.Camera == camera {
+		t.Errorf("Expected the camera rebuilt focused at %.3f, got %+v", distance, s.CameraConfig)
+	}
+}
+
+func TestUsePanorama(t *testing.T) {
+	s := NewDefaultScene(geometry.CameraConfig{Width: 160})
+	center := core.NewVec3(1, 0.5, 2)
+	if err := s.UsePanorama(geometry.ProjectionEquirectangular, center, 32); err != nil {
+		t.Fatalf("UsePanorama failed: %v", err)
+	}
+	if s.SamplingConfig.Width != 64 || s.SamplingConfig.Height != 32 || s.CameraConfig.Width != 64 {
+		t.Errorf("Expected a 64x32 image, got %dx%d", s.SamplingConfig.Width, s.SamplingConfig.Height)
+	}
+
+	// A panorama's pixels light a scene from the directions they were seen in
+	pixels := make([]core.Vec3, 64*32)
+	for i := range pixels {
+		pixels[i] = core.NewVec3(float64(i), 0, 0)
+	}
+	environment := lights.NewImageInfiniteLight(64, 32, pixels, core.NewVec3(1, 1, 1))
+	half := core.NewVec2(0.5, 0.5)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			ray := s.Camera.GetRay(x, y, half, half)
+			if !ray.Origin.Equals(center) {
+				t.Fatalf("Expected rays from %v, got %v", center, ray.Origin)
+			}
+			if got := environment.Emit(ray, nil).X; got != float64(y*64+x) {
+				t.Fatalf("Expected pixel (%d, %d) to light the scene along %v, got pixel %g", x, y, ray.Direction, got)
+			}
+		}
+	}
+
+	if err := s.UsePanorama("fisheye", center, 32); err == nil {
+		t.Error("Expected an error for an unknown projection")
+	}
+}
+
+func TestAddImageInfiniteLight(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sky.hdr")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loaders.WriteHDR(file, &loaders.ImageData{Width: 2, Height: 1, Pixels: []core.Vec3{core.NewVec3(1, 1, 1), core.NewVec3(4, 4, 4)}})
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Scene{}
+	if err := s.AddImageInfiniteLight(filename, core.NewVec3(0.5, 0.5, 0.5)); err != nil {
+		t.Fatalf("Failed to add image infinite light: %v", err)
+	}
+	// The image's right half is toward -x
+	if got := s.Lights[0].Emit(core.NewRay(core.Vec3{}, core.NewVec3(-1, 0, 0)), nil); !got.Equals(core.NewVec3(2, 2, 2)) {
+		t.Errorf("Expected the scaled radiance of the right pixel, got %v", got)
+	}
+	if err := s.AddImageInfiniteLight(filepath.Join(t.TempDir(), "missing.hdr"), core.NewVec3(1, 1, 1)); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
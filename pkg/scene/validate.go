@@ -0,0 +1,196 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+const (
+	validationLightRays = 64    // Emission rays traced from each light to see what it lights
+	validationEpsilon   = 1e-4  // Offset of rays leaving a surface, so they don't hit it again
+	maxVertexWarnings   = 5     // Shapes reported by name per kind of bad vertex, before a count
+	containmentNudge    = 1e-6  // tMin of the rays testing whether a point is inside a shape
+	minQuadArea         = 1e-12 // Quads with less area than this are treated as having none
+)
+
+// Warning is a likely mistake in a scene found by Validate
+type Warning struct {
+	Check   string `json:"check"`   // The check that found it, e.g. "camera-inside"
+	Message string `json:"message"` // What's wrong and what to do about it
+}
+
+// Validate checks a scene for the mistakes that most often render a black or wrong image, logs
+// each as a warning (unless logger is nil) and returns them. The checks, named by Warning.Check:
+//
+//	no-lights:         the scene has no lights
+//	light-unseen:      none of a light's emission rays reach any geometry: it's outside the scene or
+//	                   faces away from it
+//	light-enclosed:    a light is inside a closed opaque shape, which blocks its light
+//	camera-inside:     the camera is inside a closed opaque shape, so it sees only the inside
+//	bad-vertex:        a sphere, quad or triangle has NaN or infinite coordinates
+//	degenerate-quad:   a quad has zero area, so rays never hit it (nor light from it)
+//	unsampled-emitter: a shape has an emissive material but isn't a light, so it's only found by
+//	                   rays that happen to hit it
+//
+// It preprocesses the scene if that hasn't been done, to trace rays through it.
+func (s *Scene) Validate(logger core.Logger) ([]Warning, error) {
+	if s.Intersector == nil {
+		if err := s.Preprocess(); err != nil {
+			return nil, err
+		}
+	}
+
+	var warnings []Warning
+	warn := func(check, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	lit := s.lightShapes()
+	if len(s.Lights) == 0 {
+		warn("no-lights", "the scene has no lights; only emissive surfaces that rays happen to hit light it, or nothing does")
+	}
+
+	// Lights that light nothing, or are shut inside something
+	for i, light := range s.Lights {
+		if light.Type() == lights.LightTypeInfinite {
+			continue
+		}
+		name := fmt.Sprintf("light %d (%s)", i+1, typeName(light))
+		traced, reached, origin := s.traceLight(light)
+		if traced > 0 && reached == 0 {
+			warn("light-unseen", "%s near %v lights nothing: it's outside the geometry or faces away from it", name, origin)
+		}
+		if traced > 0 {
+			if enclosing := s.enclosingShape(origin, lit); enclosing != nil {
+				warn("light-enclosed", "%s at %v is inside a closed %s, which blocks its light", name, origin, typeName(enclosing))
+			}
+		}
+	}
+
+	if enclosing := s.enclosingShape(s.CameraConfig.Center, nil); enclosing != nil {
+		warn("camera-inside", "the camera at %v is inside a closed %s, so it sees only its inside; move the camera or the shape", s.CameraConfig.Center, typeName(enclosing))
+	}
+
+	// Shapes built from bad numbers, and emitters that aren't lights
+	badVertices := 0
+	for i, shape := range s.Shapes {
+		shape = unwrapShape(shape)
+		name := fmt.Sprintf("shape %d (%s)", i+1, typeName(shape))
+		if !finiteShape(shape) {
+			if badVertices++; badVertices <= maxVertexWarnings {
+				warn("bad-vertex", "%s has NaN or infinite coordinates, so it's never hit", name)
+			}
+		}
+		if quad, ok := shape.(*geometry.Quad); ok && quad.U.Cross(quad.V).Length() < minQuadArea {
+			warn("degenerate-quad", "%s at %v has zero area (its edges %v and %v are parallel or zero), so it's never hit", name, quad.Corner, quad.U, quad.V)
+		}
+		if emissiveMaterial(shape) != nil && !lit.has(shape) {
+			warn("unsampled-emitter", "%s is emissive but not a light, so it lights the scene only through the paths that happen to hit it; add it as a light (AddShapeLight, light: true in a scene file, or Scene.AutoLights)", name)
+		}
+	}
+	if badVertices > maxVertexWarnings {
+		warn("bad-vertex", "%d more shapes have NaN or infinite coordinates", badVertices-maxVertexWarnings)
+	}
+
+	for _, w := range warnings {
+		if logger != nil {
+			core.Logf(logger, core.LevelWarn, "Scene check %s: %s\n", w.Check, w.Message)
+		}
+	}
+	return warnings, nil
+}
+
+// traceLight traces emission rays from a light, returning how many it traced, how many reached
+// geometry, and the first ray's origin
+func (s *Scene) traceLight(light lights.Light) (traced, reached int, origin core.Vec3) {
+	sampler := core.NewSeededSampler(1)
+	for i := 0; i < validationLightRays; i++ {
+		es := light.SampleEmission(sampler.Get2D(), sampler.Get2D())
+		if es.AreaPDF*es.DirectionPDF <= 0 || es.Emission.IsZero() {
+			continue
+		}
+		if traced == 0 {
+			origin = es.Point
+		}
+		traced++
+		if s.Intersector.HitAny(core.NewRay(es.Point, es.Direction), validationEpsilon, math.Inf(1)) {
+			reached++
+		}
+	}
+	return traced, reached, origin
+}
+
+// enclosingShape returns a closed, opaque shape that a point is inside, other than the lights'
+// shapes in skip: one the point sees only the back of, whichever way it looks. Clear dielectrics
+// and participating media let light through, so they don't count.
+func (s *Scene) enclosingShape(point core.Vec3, skip *lightShapes) geometry.Shape {
+	directions := []core.Vec3{
+		core.NewVec3(1, 0, 0), core.NewVec3(-1, 0, 0),
+		core.NewVec3(0, 1, 0), core.NewVec3(0, -1, 0),
+		core.NewVec3(0, 0, 1), core.NewVec3(0, 0, -1),
+	}
+shapes:
+	for _, shape := range s.Shapes {
+		box := shape.BoundingBox()
+		if skip.has(shape) || !contains(box, point) {
+			continue
+		}
+		for _, direction := range directions {
+			hit, ok := shape.Hit(core.NewRay(point, direction), containmentNudge, math.Inf(1))
+			if !ok || hit.FrontFace {
+				continue shapes
+			}
+			switch hit.Material.(type) {
+			case *material.Dielectric, *material.HenyeyGreenstein:
+				continue shapes
+			}
+		}
+		return unwrapShape(shape)
+	}
+	return nil
+}
+
+// contains reports whether a point is in a box
+func contains(box geometry.AABB, p core.Vec3) bool {
+	return p.X >= box.Min.X && p.X <= box.Max.X && p.Y >= box.Min.Y && p.Y <= box.Max.Y && p.Z >= box.Min.Z && p.Z <= box.Max.Z
+}
+
+// unwrapShape returns the shape inside the visibility and translation wrappers scenes add
+func unwrapShape(shape geometry.Shape) geometry.Shape {
+	if visibility, ok := shape.(*geometry.VisibilityShape); ok {
+		shape = visibility.Shape
+	}
+	if translated, ok := shape.(*geometry.Translated); ok {
+		shape = translated.Shape
+	}
+	return shape
+}
+
+// finiteShape reports whether a sphere, quad or triangle's coordinates are all finite numbers;
+// other shapes aren't checked
+func finiteShape(shape geometry.Shape) bool {
+	switch s := shape.(type) {
+	case *geometry.Sphere:
+		return finite(s.Center) && !math.IsNaN(s.Radius) && !math.IsInf(s.Radius, 0)
+	case *geometry.Quad:
+		return finite(s.Corner) && finite(s.U) && finite(s.V)
+	case *geometry.Triangle:
+		return finite(s.V0) && finite(s.V1) && finite(s.V2)
+	default:
+		return true
+	}
+}
+
+func finite(v core.Vec3) bool {
+	for _, x := range []float64{v.X, v.Y, v.Z} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package scene
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+)
+
+func TestValidate(t *testing.T) {
+	white := material.NewLambertian(core.NewVec3(0.8, 0.8, 0.8))
+	floor := func() geometry.Shape {
+		return geometry.NewQuad(core.NewVec3(-10, 0, -10), core.NewVec3(0, 0, 20), core.NewVec3(20, 0, 0), white)
+	}
+
+	tests := []struct {
+		name   string
+		build  func(s *Scene)
+		checks []string
+	}{
+		{"lit floor", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor())
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, nil},
+		{"no lights", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor())
+		}, []string{"no-lights"}},
+		{"light facing away", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor())
+			s.AddQuadLight(core.NewVec3(-1, 3, -1), core.NewVec3(0, 0, 2), core.NewVec3(2, 0, 0), core.NewVec3(5, 5, 5))
+		}, []string{"light-unseen"}},
+		{"light in a box", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewBox(core.NewVec3(0, 3, 0), core.NewVec3(1, 1, 1), core.Vec3{}, white))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, []string{"light-enclosed"}},
+		{"light in glass", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewSphere(core.NewVec3(0, 3, 0), 1, material.NewDielectric(1.5)))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, nil},
+		{"camera in a sphere", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewSphere(core.NewVec3(0, 1, 5), 2, white))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, []string{"camera-inside"}},
+		{"NaN sphere", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewSphere(core.NewVec3(math.NaN(), 0, 0), 1, white))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, []string{"bad-vertex"}},
+		{"flat quad", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewQuad(core.NewVec3(0, 1, 0), core.NewVec3(1, 0, 0), core.NewVec3(2, 0, 0), white))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, []string{"degenerate-quad"}},
+		{"emissive shape", func(s *Scene) {
+			s.Shapes = append(s.Shapes, floor(), geometry.NewSphere(core.NewVec3(0, 1, 0), 0.5, material.NewEmissive(core.NewVec3(5, 5, 5))))
+			s.AddSphereLight(core.NewVec3(0, 3, 0), 0.5, core.NewVec3(5, 5, 5))
+		}, []string{"unsampled-emitter"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scene{CameraConfig: geometry.CameraConfig{Center: core.NewVec3(0, 1, 5), LookAt: core.NewVec3(0, 1, 0)}}
+			tt.build(s)
+
+			warnings, err := s.Validate(nil)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			var checks []string
+			for _, w := range warnings {
+				checks = append(checks, w.Check)
+			}
+			if !slices.Equal(checks, tt.checks) {
+				t.Errorf("Expected warnings %v, got %+v", tt.checks, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+const (
+	progressBarWidth    = 24                     // Characters of the bar itself
+	progressRedrawDelay = 100 * time.Millisecond // Shortest time between redraws, besides at the end of passes
+)
+
+// progressBar draws a render's progress on one terminal line, redrawn as tiles complete. It's
+// also where the log goes while it's shown: it clears the bar before each message and draws it
+// again after.
+type progressBar struct {
+	out      io.Writer // Terminal the bar is drawn on
+	log      io.Writer // Where log messages go (stdout)
+	mu       sync.Mutex
+	last     *renderer.Progress // Progress last drawn (nil = none, or cleared)
+	lastDraw time.Time
+}
+
+// newProgressBar returns a bar drawn on stderr, or nil if stderr isn't a terminal
+func newProgressBar() *progressBar {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{out: os.Stderr, log: os.Stdout}
+}
+
+// Write writes log messages without leaving the bar in the middle of them, so that the bar can be
+// the log's output (see core.SetLogOutput)
+func (b *progressBar) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	last := b.last
+	b.erase()
+	n, err := b.log.Write(p)
+	if last != nil {
+		b.draw(*last)
+	}
+	return n, err
+}
+
+// Update redraws the bar with new progress. Updates within progressRedrawDelay of the last are
+// skipped, except at the end of a pass; the end of the render clears the bar.
+func (b *progressBar) Update(p renderer.Progress) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case p.Done:
+		b.erase()
+	case p.PassDone || time.Since(b.lastDraw) >= progressRedrawDelay:
+		b.draw(p)
+	}
+}
+
+// Clear removes the bar, such as when the render is interrupted
+func (b *progressBar) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.erase()
+}
+
+func (b *progressBar) draw(p renderer.Progress) {
+	fmt.Fprintf(b.out, "\r\033[K%s", formatProgress(p))
+	b.last, b.lastDraw = &p, time.Now()
+}
+
+func (b *progressBar) erase() {
+	if b.last != nil {
+		fmt.Fprint(b.out, "\r\033[K")
+		b.last = nil
+	}
+}
+
+// formatProgress returns the line of a progress bar, such as
+// "[######------------------]  25.0% pass 3/7, tile 12/40 | 1.2M samples/s, 8.4M rays/s | ETA 1m5s"
+func formatProgress(p renderer.Progress) string {
+	filled := int(p.Fraction * progressBarWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+	eta := "ETA -"
+	if p.ETA > 0 {
+		eta = "ETA " + p.ETA.Round(time.Second).String()
+	}
+	return fmt.Sprintf("[%s] %5.1f%% pass %d/%d, tile %d/%d | %s samples/s, %s rays/s | %s",
+		bar, 100*p.Fraction, p.Pass, p.TotalPasses, p.TilesDone, p.TotalTiles,
+		formatRate(p.SamplesPerSecond), formatRate(p.RaysPerSecond), eta)
+}
+
+// formatRate abbreviates a rate with a metric suffix, such as 1.2k or 8.4M
+func formatRate(rate float64) string {
+	switch {
+	case rate >= 1e9:
+		return fmt.Sprintf("%.1fG", rate/1e9)
+	case rate >= 1e6:
+		return fmt.Sprintf("%.1fM", rate/1e6)
+	case rate >= 1e3:
+		return fmt.Sprintf("%.1fk", rate/1e3)
+	default:
+		return fmt.Sprintf("%.0f", rate)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+func TestFormatProgress(t *testing.T) {
+	line := formatProgress(renderer.Progress{
+		Pass: 3, TotalPasses: 7, TilesDone: 12, TotalTiles: 40, Fraction: 0.25,
+		SamplesPerSecond: 1234, RaysPerSecond: 8.4e6, ETA: 65 * time.Second,
+	})
+	want := "[######------------------]  25.0% pass 3/7, tile 12/40 | 1.2k samples/s, 8.4M rays/s | ETA 1m5s"
+	if line != want {
+		t.Errorf("Expected %q, got %q", want, line)
+	}
+
+	if line := formatProgress(renderer.Progress{Pass: 1, TotalPasses: 1}); !strings.HasSuffix(line, "0 samples/s, 0 rays/s | ETA -") {
+		t.Errorf("Expected no rates or ETA before anything completes, got %q", line)
+	}
+}
+
+func TestProgressBar_ClearsWhenDone(t *testing.T) {
+	var out bytes.Buffer
+	bar := &progressBar{out: &out}
+	bar.Update(renderer.Progress{Pass: 1, TotalPasses: 2, PassDone: true})
+	if !strings.Contains(out.String(), "pass 1/2") {
+		t.Fatalf("Expected the bar to be drawn at the end of a pass, got %q", out.String())
+	}
+
+	// Updates right after a redraw are skipped, except at the end of a pass
+	out.Reset()
+	bar.Update(renderer.Progress{Pass: 2, TotalPasses: 2, TilesDone: 1, TotalTiles: 4})
+	if out.Len() != 0 {
+		t.Errorf("Expected a tile's update right after a redraw to be skipped, got %q", out.String())
+	}
+
+	bar.Update(renderer.Progress{Pass: 2, TotalPasses: 2, PassDone: true, Done: true})
+	if out.String() != "\r\033[K" || bar.last != nil {
+		t.Errorf("Expected the end of the render to erase the bar, got %q", out.String())
+	}
+}
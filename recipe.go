@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// recipeFormatVersion is bumped whenever the recipe layout changes incompatibly
+const recipeFormatVersion = 1
+
+// Recipe records everything needed to reproduce a render: the scene reference, every setting that
+// affects the image, the seed and the code version. It is saved next to the final image as
+// render_<timestamp>.recipe.json and replayed with --from-recipe.
+type Recipe struct {
+	FormatVersion int       `json:"formatVersion"`
+	CodeVersion   string    `json:"codeVersion"` // VCS revision of the binary ("-dirty" if modified), or "unknown"
+	CreatedAt     time.Time `json:"createdAt"`
+
+	Scene          string                     `json:"scene"`                // Built-in scene name or PBRT file path, as passed to --scene
+	CameraName     string                     `json:"cameraName,omitempty"` // Named camera rendered from (--camera)
+	FocusAt        string                     `json:"focusAt,omitempty"`    // Pixel the camera was focused on (--focus-at)
+	Panorama       string                     `json:"panorama,omitempty"`   // Panorama projection rendered (--panorama)
+	PanoramaAt     string                     `json:"panoramaAt,omitempty"` // Point the panorama was seen from (--panorama-at)
+	PanoramaSize   int                        `json:"panoramaSize,omitempty"`
+	Integrator     string                     `json:"integrator"`
+	Progressive    renderer.ProgressiveConfig `json:"progressive"` // Includes the seed and worker count
+	SaveAOVs       bool                       `json:"saveAovs"`    // AOV images were written (--aov)
+	StrategyGrid   bool                       `json:"strategyGrid"`
+	PreviewProfile bool                       `json:"previewProfile,omitempty"` // Passes before the last used the preview sampling profile (--preview-profile)
+	Float32        bool                       `json:"float32Meshes,omitempty"`  // Meshes were stored in float32 (--float32-meshes)
+	DebugShading   string                     `json:"debugShading,omitempty"`   // What the debug integrator shaded by (--debug-shading)
+
+	// The scene's own settings at render time, to detect scene definitions that changed since
+	Sampling scene.SamplingConfig  `json:"sampling"`
+	Camera   geometry.CameraConfig `json:"camera"`
+
+	// The result, so a rerun can confirm it reproduced the same image
+	Image          string  `json:"image"`       // File name of the final image
+	ImageSHA256    string  `json:"imageSha256"` // Hash of the final image's pixels
+	AverageSamples float64 `json:"averageSamples"`
+}
+
+// newRecipe builds the recipe for a finished render
+func newRecipe(config Config, sceneObj *scene.Scene, imageFile string, result RenderResult) Recipe {
+	var debugShading string
+	if config.IntegratorType == "debug" {
+		debugShading = config.DebugShading
+	}
+	return Recipe{
+		FormatVersion:  recipeFormatVersion,
+		CodeVersion:    codeVersion(),
+		CreatedAt:      time.Now().UTC(),
+		Scene:          config.SceneType,
+		CameraName:     config.Camera,
+		FocusAt:        config.FocusAt,
+		Panorama:       config.Panorama,
+		PanoramaAt:     config.PanoramaAt,
+		PanoramaSize:   config.PanoramaSize,
+		Integrator:     config.IntegratorType,
+		Progressive:    result.Config,
+		SaveAOVs:       config.AOVs,
+		StrategyGrid:   config.StrategyGrid,
+		PreviewProfile: config.PreviewProfile,
+		Float32:        config.Float32Meshes,
+		DebugShading:   debugShading,
+		Sampling:       sceneObj.SamplingConfig,
+		Camera:         sceneObj.CameraConfig,
+		Image:          imageFile,
+		ImageSHA256:    imageHash(result.Image),
+		AverageSamples: result.Stats.AverageSamples,
+	}
+}
+
+// saveRecipe writes a recipe as indented JSON
+func saveRecipe(recipe Recipe, filename string) error {
+	data, err := json.MarshalIndent(recipe, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// loadRecipe reads a recipe written by saveRecipe
+func loadRecipe(filename string) (Recipe, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var recipe Recipe
+	if err := json.Unmarshal(data, &recipe); err != nil {
+		return Recipe{}, fmt.Errorf("invalid recipe %s: %v", filename, err)
+	}
+	if recipe.FormatVersion != recipeFormatVersion {
+		return Recipe{}, fmt.Errorf("recipe %s has format version %d, this build reads version %d",
+			filename, recipe.FormatVersion, recipeFormatVersion)
+	}
+	if recipe.Scene == "" {
+		return Recipe{}, fmt.Errorf("recipe %s has no scene", filename)
+	}
+	return recipe, nil
+}
+
+// apply replaces the render settings in config with the recipe's
+// Flags that don't affect the image (such as --cpuprofile) are kept.
+func (r Recipe) apply(config *Config) {
+	config.SceneType = r.Scene
+	config.Camera = r.CameraName
+	config.FocusAt = r.FocusAt
+	config.Panorama = r.Panorama
+	config.PanoramaAt = r.PanoramaAt
+	if r.PanoramaSize > 0 {
+		config.PanoramaSize = r.PanoramaSize
+	}
+	config.IntegratorType = r.Integrator
+	if r.DebugShading != "" {
+		config.DebugShading = r.DebugShading
+	}
+	config.MaxPasses = r.Progressive.MaxPasses
+	config.MaxSamples = r.Progressive.MaxSamplesPerPixel
+	config.NumWorkers = r.Progressive.NumWorkers
+	config.Seed = r.Progressive.Seed
+	config.PyramidLevels = r.Progressive.PyramidLevels
+	config.MaxTime = r.Progressive.MaxTime
+	config.TargetError = r.Progressive.TargetError
+	config.PrimaryLights = r.Sampling.PrimaryLightSamples
+	config.GuidingPasses = r.Sampling.GuidingPasses
+	config.IrradianceGI = r.Sampling.IrradianceCache
+	config.CachePoints = r.Sampling.IrradianceCachePoints
+	config.SampleMask = r.Sampling.SampleMask
+	config.Filter = r.Sampling.Filter
+	config.FilterRadius = r.Sampling.FilterRadius
+	config.AOVs = r.SaveAOVs
+	config.DebugAOVs = strings.Join(r.Progressive.DebugAOVs, ",")
+	config.Deep = r.Progressive.Deep
+	config.IDs = r.Progressive.IDs
+	config.Transparent = r.Progressive.Transparent
+	config.StrategyGrid = r.StrategyGrid
+	config.PreviewProfile = r.PreviewProfile
+	config.Float32Meshes = r.Float32
+	config.Recipe = &r
+}
+
+// checkScene reports the ways the loaded scene differs from the one the recipe was made with
+func (r Recipe) checkScene(sceneObj *scene.Scene) []string {
+	var warnings []string
+	if !reflect.DeepEqual(r.Sampling, sceneObj.SamplingConfig) {
+		warnings = append(warnings, fmt.Sprintf("scene sampling settings changed: recipe %+v, now %+v", r.Sampling, sceneObj.SamplingConfig))
+	}
+	camera := sceneObj.CameraConfig
+	if r.FocusAt != "" {
+		// The recipe's camera was focused after loading, and will be again
+		camera.FocusDistance = r.Camera.FocusDistance
+	}
+	if !reflect.DeepEqual(r.Camera, camera) {
+		warnings = append(warnings, fmt.Sprintf("scene camera changed: recipe %+v, now %+v", r.Camera, camera))
+	}
+	if version := codeVersion(); version != r.CodeVersion {
+		warnings = append(warnings, fmt.Sprintf("code version differs: recipe %s, now %s", r.CodeVersion, version))
+	}
+	return warnings
+}
+
+// codeVersion identifies the source the binary was built from, using the VCS information Go embeds
+func codeVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	var revision string
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+
+	switch {
+	case revision != "" && modified:
+		return revision + "-dirty"
+	case revision != "":
+		return revision
+	case info.Main.Version != "" && info.Main.Version != "(devel)":
+		return info.Main.Version
+	default:
+		return "unknown"
+	}
+}
+
+// imageHash returns the hex SHA-256 of an image's size and pixels
+func imageHash(img *image.RGBA) string {
+	hash := sha256.New()
+	bounds := img.Bounds()
+	fmt.Fprintf(hash, "%dx%d:", bounds.Dx(), bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := img.PixOffset(bounds.Min.X, y)
+		hash.Write(img.Pix[offset : offset+4*bounds.Dx()])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+func TestRecipe_RoundTrip(t *testing.T) {
+	sceneObj, err := createScene("cornell", false)
+	if err != nil {
+		t.Fatalf("createScene failed: %v", err)
+	}
+
+	config := Config{SceneType: "cornell", IntegratorType: "bdpt", StrategyGrid: true, Seed: 42}
+	progressiveConfig := renderer.DefaultProgressiveConfig()
+	progressiveConfig.TileSize = 32
+	progressiveConfig.MaxSamplesPerPixel = 8
+	progressiveConfig.MaxPasses = 3
+	progressiveConfig.NumWorkers = 2
+	progressiveConfig.Seed = 42
+	progressiveConfig.AOVs = true
+	progressiveConfig.DebugAOVs = []string{renderer.DebugAOVSamples, renderer.DebugAOVBVHCost}
+
+	result := RenderResult{Image: image.NewRGBA(image.Rect(0, 0, 4, 4)), Config: progressiveConfig}
+	recipe := newRecipe(config, sceneObj, "render.png", result)
+
+	filename := filepath.Join(t.TempDir(), "render.recipe.json")
+	if err := saveRecipe(recipe, filename); err != nil {
+		t.Fatalf("saveRecipe failed: %v", err)
+	}
+	loaded, err := loadRecipe(filename)
+	if err != nil {
+		t.Fatalf("loadRecipe failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Progressive, progressiveConfig) {
+		t.Errorf("Progressive config = %+v, want %+v", loaded.Progressive, progressiveConfig)
+	}
+	if loaded.ImageSHA256 != recipe.ImageSHA256 {
+		t.Errorf("Image hash = %s, want %s", loaded.ImageSHA256, recipe.ImageSHA256)
+	}
+
+	// Applying the recipe restores the flags, keeping the AOVs forced on for the grid out of --aov
+	replay := Config{SceneType: "default", IntegratorType: "path-tracing", MaxSamples: 100, CPUProfile: "cpu.prof"}
+	loaded.apply(&replay)
+	want := Config{SceneType: "cornell", IntegratorType: "bdpt", MaxPasses: 3, MaxSamples: 8, NumWorkers: 2,
+		Seed: 42, StrategyGrid: true, DebugAOVs: "samples,bvh", CPUProfile: "cpu.prof", Recipe: replay.Recipe}
+	if replay != want {
+		t.Errorf("apply() config = %+v, want %+v", replay, want)
+	}
+	if replay.Recipe == nil || replay.Recipe.Progressive.TileSize != 32 {
+		t.Errorf("apply() should keep the recipe for settings without flags, got %+v", replay.Recipe)
+	}
+
+	// The same scene definition raises no warnings; a changed one does
+	if warnings := loaded.checkScene(sceneObj); len(warnings) != 0 {
+		t.Errorf("checkScene() on the same scene = %v, want no warnings", warnings)
+	}
+	sceneObj.CameraConfig.VFov++
+	if warnings := loaded.checkScene(sceneObj); len(warnings) != 1 {
+		t.Errorf("checkScene() after changing the camera = %v, want one warning", warnings)
+	}
+}
+
+func TestLoadRecipe_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed JSON", `{"formatVersion": 1,`},
+		{"future format", `{"formatVersion": 99, "scene": "cornell"}`},
+		{"missing scene", `{"formatVersion": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, tt.name+".json")
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadRecipe(filename); err == nil {
+				t.Errorf("loadRecipe(%s) should fail", tt.content)
+			}
+		})
+	}
+
+	if _, err := loadRecipe(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("loadRecipe of a missing file should fail")
+	}
+}
+
+func TestImageHash(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	b := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	if imageHash(a) != imageHash(b) {
+		t.Error("Identical images should have the same hash")
+	}
+
+	b.Set(2, 1, color.RGBA{R: 1, A: 255})
+	if imageHash(a) == imageHash(b) {
+		t.Error("Images differing in one pixel should have different hashes")
+	}
+
+	// Same pixel data in a different shape must not collide
+	if imageHash(image.NewRGBA(image.Rect(0, 0, 2, 3))) == imageHash(a) {
+		t.Error("Images of different sizes should have different hashes")
+	}
+
+	// Only the pixels inside the bounds count, so sub-images hash by content
+	if imageHash(b.SubImage(image.Rect(0, 0, 2, 1)).(*image.RGBA)) != imageHash(image.NewRGBA(image.Rect(0, 0, 2, 1))) {
+		t.Error("A sub-image should hash like an image with the same pixels")
+	}
+}
@@ -0,0 +1,11 @@
+// Package scenes embeds the scene files bundled with the raytracer, so they load wherever the
+// binary runs from (see loaders.OpenSceneFile). Files in a scenes/ directory on disk take
+// precedence, so edited copies are picked up without rebuilding.
+package scenes
+
+import "embed"
+
+// Files holds the bundled scenes by file name ("cornell.pbrt", "still-life.yaml", ...)
+//
+//go:embed *.pbrt *.micro *.yaml
+var Files embed.FS
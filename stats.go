@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// printRayStats prints the rays a render traced and, when the counters are compiled in, how
+// much BVH traversal they took and how long the camera paths were
+func printRayStats(stats renderer.RenderStats) {
+	rays := stats.Rays
+	fmt.Printf("Rays: %d (%d primary, %d scatter, %d shadow), %.1f per sample\n",
+		rays.Total(), rays.Primary, rays.Scatter, rays.Shadow, float64(rays.Total())/float64(max(1, stats.TotalSamples)))
+
+	counters := stats.Counters
+	if counters == nil {
+		return
+	}
+	perRay := float64(max(1, rays.Total()))
+	fmt.Printf("BVH: %.1f node visits and %.1f shape tests per ray\n",
+		float64(counters.BVHNodeVisits)/perRay, float64(counters.ShapeTests)/perRay)
+
+	var paths, bounces int64
+	for length, count := range counters.PathLengths {
+		paths += count
+		bounces += int64(length) * count
+	}
+	if paths > 0 {
+		fmt.Printf("Camera paths: %d, %.2f bounces on average\n", paths, float64(bounces)/float64(paths))
+	}
+}
+
+// saveRenderStats writes a render's statistics as JSON
+func saveRenderStats(stats renderer.RenderStats, filename string) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	if !core.CountersEnabled {
+		fmt.Println("Note: build with -tags rtstats for BVH traversal and path length statistics")
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
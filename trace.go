@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// parseTraceRegion parses a pixel region "x0,y0,x1,y1" (x1 and y1 exclusive); "" is every pixel
+func parseTraceRegion(region string) (image.Rectangle, error) {
+	if region == "" {
+		return image.Rectangle{}, nil
+	}
+	coords, err := parseCoords(region, 4)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("trace region %q should be x0,y0,x1,y1: %v", region, err)
+	}
+	rect := image.Rect(coords[0], coords[1], coords[2], coords[3])
+	if rect.Empty() {
+		return image.Rectangle{}, fmt.Errorf("trace region %q contains no pixels", region)
+	}
+	return rect, nil
+}
+
+// parseTraceSample parses a pixel's sample "x,y,sample"
+func parseTraceSample(spec string) (x, y, sample int, err error) {
+	coords, err := parseCoords(spec, 3)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("trace sample %q should be x,y,sample: %v", spec, err)
+	}
+	return coords[0], coords[1], coords[2], nil
+}
+
+// parseCoords parses a comma-separated list of n integers
+func parseCoords(list string, n int) ([]int, error) {
+	parts := strings.Split(list, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d numbers", n)
+	}
+	coords := make([]int, n)
+	for i, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a whole number", part)
+		}
+		coords[i] = value
+	}
+	return coords, nil
+}
+
+// openBDPTTrace creates a BDPT tracer writing JSON lines to filename. The returned function flushes
+// and closes the file, reporting any error writing the trace.
+func openBDPTTrace(filename string, region image.Rectangle) (*integrator.BDPTTracer, func() error, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := bufio.NewWriter(file)
+	tracer := integrator.NewBDPTTracer(w, region)
+	closeTrace := func() error {
+		err := tracer.Err()
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+	return tracer, closeTrace, nil
+}
+
+// traceSample traces one sample of a pixel again, as a render with these flags takes it, and saves
+// its paths as JSON in the scene's output directory instead of rendering
+func traceSample(config Config, sceneObj *scene.Scene) {
+	x, y, sample, err := parseTraceSample(config.TraceSample)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	configureAcceleration(config, sceneObj)
+	raytracer, err := renderer.NewProgressiveRaytracer(sceneObj, newProgressiveConfig(config), newIntegrator(config, sceneObj), renderer.NewDefaultLogger())
+	if err != nil {
+		fmt.Printf("Error creating progressive raytracer: %v\n", err)
+		os.Exit(1)
+	}
+	trace, err := raytracer.TraceSample(x, y, sample)
+	if err != nil {
+		fmt.Printf("Error tracing sample: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding sample trace: %v\n", err)
+		os.Exit(1)
+	}
+	filename := filepath.Join(createOutputDir(config.SceneType), fmt.Sprintf("sample_%d_%d_%d.json", x, y, sample))
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		fmt.Printf("Error saving sample trace: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sample %d of pixel (%d, %d) from pass %d: color %.4g\n", sample, x, y, trace.Pass, trace.Color)
+	fmt.Printf("Sample trace saved as %s\n", filename)
+}
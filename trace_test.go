@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"image"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestParseTraceRegion(t *testing.T) {
+	region, err := parseTraceRegion("10, 20,12,25")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := image.Rect(10, 20, 12, 25); region != want {
+		t.Errorf("Expected %v, got %v", want, region)
+	}
+
+	if region, err := parseTraceRegion(""); err != nil || !region.Empty() {
+		t.Errorf("Expected an empty region for every pixel, got %v, %v", region, err)
+	}
+
+	for _, bad := range []string{"1,2,3", "a,0,1,1", "5,5,5,6"} {
+		if _, err := parseTraceRegion(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestParseTraceSample(t *testing.T) {
+	x, y, sample, err := parseTraceSample("10, 20,7")
+	if err != nil || x != 10 || y != 20 || sample != 7 {
+		t.Errorf("Expected sample 7 of (10, 20), got %d of (%d, %d), %v", sample, x, y, err)
+	}
+	for _, bad := range []string{"", "1,2", "1,2,3,4", "1,b,3"} {
+		if _, _, _, err := parseTraceSample(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestOpenBDPTTrace(t *testing.T) {
+	sceneObj, err := scene.NewMicroScene(`
+		camera width 8
+		sphere at 0 0 -2 radius 1
+		sky emit 1 1 1
+	`, nil)
+	if err != nil {
+		t.Fatalf("Failed to create scene: %v", err)
+	}
+	sceneObj.Preprocess()
+
+	filename := filepath.Join(t.TempDir(), "trace.jsonl")
+	tracer, closeTrace, err := openBDPTTrace(filename, image.Rect(4, 4, 5, 5))
+	if err != nil {
+		t.Fatalf("Failed to open trace: %v", err)
+	}
+	bdpt := integrator.NewBDPTIntegrator(sceneObj.SamplingConfig)
+	bdpt.Tracer = tracer
+	sampler := core.NewRandomSampler(rand.New(rand.NewSource(1)))
+	for i := 0; i < 4; i++ {
+		bdpt.RayColor(sceneObj.Camera.GetRay(4, 4, sampler.Get2D(), sampler.Get2D()), sceneObj, sampler)
+	}
+	if err := closeTrace(); err != nil {
+		t.Fatalf("Failed to close trace: %v", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Failed to read trace: %v", err)
+	}
+	defer file.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var line integrator.StrategyTrace
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Line %d is not a strategy trace: %v", lines+1, err)
+		}
+	}
+	if lines == 0 {
+		t.Error("Expected the traced pixel to have strategy lines")
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// Photometric validation settings: the scene is rendered at several scales with every integrator,
+// and the plane's radiance is compared to the closed-form solution in blocks of pixels
+var (
+	photometricScales      = []float64{0.01, 1, 100}
+	photometricIntegrators = []string{"path-tracing", "bdpt"}
+)
+
+const (
+	photometricWidth       = 96   // Image width for validation renders
+	photometricBlockSize   = 8    // Pixels are compared in blocks of this size to average out noise
+	photometricMeanTol     = 0.02 // Allowed relative error of the mean over all plane pixels
+	photometricBlockTol    = 0.10 // Allowed relative error of any single block
+	photometricMinRadiance = 1e-3 // Blocks darker than this are too noisy to compare
+)
+
+// photometricFootprint holds the pixel-relative points checked to keep blocks away from the light's silhouette
+var photometricFootprint = []core.Vec2{
+	core.NewVec2(0.5, 0.5), core.NewVec2(0, 0), core.NewVec2(1, 0), core.NewVec2(0, 1), core.NewVec2(1, 1),
+}
+
+// photometricResult summarizes how one render of the photometric scene compares to the closed form
+type photometricResult struct {
+	Integrator      string
+	Scale           float64
+	Rendered        float64 // Mean rendered luminance over plane pixels
+	Expected        float64 // Mean closed-form luminance over the same pixels
+	WorstBlockError float64 // Largest relative error among compared blocks
+	Blocks          int     // Number of blocks compared
+}
+
+// MeanError returns the relative error of the rendered mean luminance
+func (r photometricResult) MeanError() float64 {
+	return math.Abs(r.Rendered-r.Expected) / r.Expected
+}
+
+// Passed reports whether the render is within tolerance of the closed-form solution
+func (r photometricResult) Passed() bool {
+	return r.Blocks > 0 && r.MeanError() <= photometricMeanTol && r.WorstBlockError <= photometricBlockTol
+}
+
+// quietLogger discards renderer output so validation results stay readable
+type quietLogger struct{}
+
+func (quietLogger) Printf(format string, args ...interface{}) {}
+
+// renderQuietly renders all passes of a scene without progress output and returns the raytracer
+// holding the result
+func renderQuietly(sceneObj *scene.Scene, integratorType string, progressiveConfig renderer.ProgressiveConfig) (*renderer.ProgressiveRaytracer, error) {
+	selectedIntegrator := createIntegrator(integratorType, sceneObj.SamplingConfig)
+	progressiveRT, err := renderer.NewProgressiveRaytracer(sceneObj, progressiveConfig, selectedIntegrator, quietLogger{})
+	if err != nil {
+		return nil, err
+	}
+
+	passChan, _, errChan := progressiveRT.RenderProgressive(context.Background(), renderer.RenderOptions{TileUpdates: false})
+	for range passChan {
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+	return progressiveRT, nil
+}
+
+// runPhotometricValidation renders the photometric scene with each integrator and scale and prints
+// the comparison against the analytic solution. Returns false if any combination is out of tolerance.
+func runPhotometricValidation(config Config) bool {
+	fmt.Println("Photometric validation: sphere light over a diffuse plane")
+	fmt.Printf("Tolerances: mean %.0f%%, per %dx%d block %.0f%%\n",
+		photometricMeanTol*100, photometricBlockSize, photometricBlockSize, photometricBlockTol*100)
+
+	allPassed := true
+	for _, integratorType := range photometricIntegrators {
+		for _, scale := range photometricScales {
+			result, err := validatePhotometric(integratorType, scale, config.MaxSamples, config.NumWorkers, config.Seed)
+			if err != nil {
+				fmt.Printf("  %-12s scale %-6g ERROR: %v\n", integratorType, scale, err)
+				allPassed = false
+				continue
+			}
+
+			status := "PASS"
+			if !result.Passed() {
+				status = "FAIL"
+				allPassed = false
+			}
+			fmt.Printf("  %-12s scale %-6g rendered %.5f expected %.5f mean error %5.2f%% worst block %5.2f%% (%d blocks)  %s\n",
+				integratorType, scale, result.Rendered, result.Expected,
+				result.MeanError()*100, result.WorstBlockError*100, result.Blocks, status)
+		}
+	}
+
+	if allPassed {
+		fmt.Println("All photometric checks passed")
+	} else {
+		fmt.Println("Photometric validation FAILED")
+	}
+	return allPassed
+}
+
+// validatePhotometric renders the photometric scene at one scale and compares it to the closed form
+func validatePhotometric(integratorType string, scale float64, samples, numWorkers int, seed uint64) (photometricResult, error) {
+	sceneObj := scene.NewPhotometricScene(scale, geometry.CameraConfig{Width: photometricWidth})
+	width := sceneObj.CameraConfig.Width
+	height := int(float64(width) / sceneObj.CameraConfig.AspectRatio)
+	sceneObj.SamplingConfig.Width = width
+	sceneObj.SamplingConfig.Height = height
+
+	progressiveConfig := renderer.DefaultProgressiveConfig()
+	progressiveConfig.MaxPasses = 1
+	progressiveConfig.MaxSamplesPerPixel = samples
+	progressiveConfig.NumWorkers = numWorkers
+	progressiveConfig.Seed = seed
+
+	progressiveRT, err := renderQuietly(sceneObj, integratorType, progressiveConfig)
+	if err != nil {
+		return photometricResult{}, err
+	}
+
+	result := photometricResult{Integrator: integratorType, Scale: scale, WorstBlockError: 0}
+	pixels := 0
+	for by := 0; by+photometricBlockSize <= height; by += photometricBlockSize {
+		for bx := 0; bx+photometricBlockSize <= width; bx += photometricBlockSize {
+			rendered, expected, ok := comparePhotometricBlock(sceneObj, progressiveRT, scale, bx, by)
+			if !ok {
+				continue
+			}
+			result.Rendered += rendered
+			result.Expected += expected
+			pixels += photometricBlockSize * photometricBlockSize
+
+			if expected/float64(photometricBlockSize*photometricBlockSize) < photometricMinRadiance {
+				continue
+			}
+			result.WorstBlockError = math.Max(result.WorstBlockError, math.Abs(rendered-expected)/expected)
+			result.Blocks++
+		}
+	}
+	if pixels == 0 {
+		return photometricResult{}, fmt.Errorf("no pixels of the plane were visible")
+	}
+
+	result.Rendered /= float64(pixels)
+	result.Expected /= float64(pixels)
+	return result, nil
+}
+
+// comparePhotometricBlock sums rendered and expected luminance over a block of pixels
+// Returns ok=false if any pixel's footprint sees something other than the plane (the light or the sky).
+func comparePhotometricBlock(sceneObj *scene.Scene, progressiveRT *renderer.ProgressiveRaytracer, scale float64, bx, by int) (float64, float64, bool) {
+	rendered, expected := 0.0, 0.0
+	for y := by; y < by+photometricBlockSize; y++ {
+		for x := bx; x < bx+photometricBlockSize; x++ {
+			for _, offset := range photometricFootprint {
+				ray := sceneObj.Camera.GetRay(x, y, offset, offset)
+				hit, isHit := sceneObj.Intersector.Hit(ray, 0.001, math.Inf(1))
+				if !isHit {
+					return 0, 0, false
+				}
+				if _, isLight := hit.Material.(material.Emitter); isLight {
+					return 0, 0, false
+				}
+			}
+
+			// The closed form is smooth, so its value at the pixel center stands in for the pixel average
+			ray := sceneObj.Camera.GetRay(x, y, photometricFootprint[0], photometricFootprint[0])
+			hit, _ := sceneObj.Intersector.Hit(ray, 0.001, math.Inf(1))
+			rendered += progressiveRT.PixelColor(x, y).Luminance()
+			expected += scene.PhotometricRadiance(scale, hit.Point).Luminance()
+		}
+	}
+	return rendered, expected, true
+}
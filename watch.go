@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// runWatch renders a PBRT scene and renders it again each time its file is saved, until
+// interrupted. An edit cancels the render in progress; edits that only move the camera or
+// change the film reuse the shapes, lights and BVH, and edits that change nothing the image
+// depends on (comments, the sampler) don't restart it.
+func runWatch(config Config) error {
+	var path string
+	for _, candidate := range pbrtScenePaths(config.SceneType) {
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("--watch needs a PBRT scene file, not %q", config.SceneType)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	changes := loaders.WatchFile(ctx, path, loaders.DefaultWatchInterval)
+
+	fmt.Printf("Loading PBRT scene: %s...\n", path)
+	reloader := scene.NewPBRTReloader()
+	sceneObj, _, err := loadWatchedScene(reloader, path, config.Camera)
+	if err != nil {
+		return err
+	}
+
+	// The render in progress, if any
+	var rendering chan RenderResult
+	cancel := func() {}
+	start := func(sceneObj *scene.Scene) {
+		if config.PrimaryLights > 0 {
+			sceneObj.SamplingConfig.PrimaryLightSamples = config.PrimaryLights
+		}
+		if config.GuidingPasses > 0 {
+			sceneObj.SamplingConfig.GuidingPasses = config.GuidingPasses
+		}
+		if config.IrradianceGI > 0 {
+			sceneObj.SamplingConfig.IrradianceCache = config.IrradianceGI
+			sceneObj.SamplingConfig.IrradianceCachePoints = config.CachePoints
+		}
+		if config.SampleMask != "" {
+			sceneObj.SamplingConfig.SampleMask = config.SampleMask
+		}
+		if config.Filter != "" {
+			sceneObj.SamplingConfig.Filter = config.Filter
+			sceneObj.SamplingConfig.FilterRadius = config.FilterRadius
+		}
+		var renderCtx context.Context
+		renderCtx, cancel = context.WithCancel(ctx)
+		done := make(chan RenderResult, 1)
+		go func() {
+			done <- renderProgressive(renderCtx, config, sceneObj)
+		}()
+		rendering = done
+	}
+	finish := func() {
+		cancel()
+		if rendering != nil {
+			<-rendering
+			rendering = nil
+		}
+	}
+
+	outputDir := createOutputDir(config.SceneType)
+	startTime := time.Now()
+	start(sceneObj)
+	fmt.Printf("Watching %s for changes (Ctrl+C to stop)\n", path)
+	for {
+		select {
+		case <-ctx.Done():
+			finish()
+			fmt.Println("Stopped watching")
+			return nil
+
+		case result := <-rendering:
+			rendering = nil
+			if result.Image == nil {
+				continue // Interrupted before the first pass
+			}
+			fmt.Printf("Render completed in %v: %.1f samples per pixel, average luminosity %.4f\n",
+				time.Since(startTime), result.Stats.AverageSamples, renderer.CalculateAverageLuminance(result.Image))
+			fmt.Printf("Render saved as %s\n", filepath.Join(outputDir, fmt.Sprintf("render_%s.png", result.Timestamp)))
+			fmt.Printf("Watching %s for changes\n", path)
+
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil // Interrupted; ctx.Done is ready too
+				continue
+			}
+			next, change, err := loadWatchedScene(reloader, path, config.Camera)
+			if err != nil {
+				fmt.Printf("Error reloading %s: %v; keeping the previous version\n", path, err)
+				continue
+			}
+			switch change {
+			case scene.PBRTUnchanged:
+				fmt.Printf("%s changed nothing that affects the image\n", path)
+				continue
+			case scene.PBRTCameraChanged:
+				fmt.Printf("%s changed the camera; rendering again with the same shapes, lights and BVH\n", path)
+			default:
+				fmt.Printf("%s changed the scene; rebuilding it\n", path)
+			}
+			finish()
+			startTime = time.Now()
+			start(next)
+		}
+	}
+}
+
+// loadWatchedScene parses the current version of a watched PBRT file and creates its scene, seen
+// from the named camera if one is given
+func loadWatchedScene(reloader *scene.PBRTReloader, path, camera string) (*scene.Scene, scene.PBRTChange, error) {
+	parsed, err := loaders.LoadPBRT(path)
+	if err != nil {
+		return nil, scene.PBRTUnchanged, err
+	}
+	sceneObj, change, err := reloader.Load(parsed)
+	if err != nil || camera == "" {
+		return sceneObj, change, err
+	}
+	return sceneObj, change, sceneObj.UseCamera(camera)
+}
@@ -2,25 +2,41 @@ package main
 
 import (
 	"flag"
-	"log"
+	"fmt"
 	"os"
 
+	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/web/server"
 )
 
 func main() {
 	// Parse command line flags
 	port := flag.Int("port", 8080, "Port to serve on")
+	watch := flag.Bool("watch", false, "Watch PBRT scene files and render them again in the page each time they're saved")
+	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error), optionally followed by levels for subsystems such as 'warn,web=info,bdpt=debug'")
+	logJSON := flag.Bool("log-json", false, "Write the log as JSON lines (time, level, subsystem, msg)")
 	flag.Parse()
 
+	level, subsystems, err := core.ParseLogLevels(*logLevel)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	core.ConfigureLogging(core.LogConfig{Level: level, Subsystems: subsystems, JSON: *logJSON})
+	log := core.NewLog("web")
+
 	// Create and start web server
 	webServer := server.NewServer(*port)
+	if *watch {
+		webServer.EnableWatch()
+		log.Printf("Watching PBRT scene files for changes")
+	}
 
 	log.Printf("Progressive Raytracer Web Server")
 	log.Printf("Visit http://localhost:%d to start rendering", *port)
 
 	if err := webServer.Start(); err != nil {
-		log.Printf("Error starting server: %v", err)
+		log.Logf(core.LevelError, "Error starting server: %v", err)
 		os.Exit(1)
 	}
 }
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
@@ -11,13 +12,15 @@ import (
 type ConsoleMessage struct {
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
-	Level     string    `json:"level"` // "info", "warning", "error"
+	Level     string    `json:"level"` // "debug", "info", "warning", "error"
 }
 
-// WebLogger implements core.Logger by sending messages to a console channel
+// WebLogger implements core.LevelLogger by sending messages to a console channel. Messages go to the
+// server's log too, and are dropped at levels the renderer's log doesn't write.
 type WebLogger struct {
 	renderID    string
 	consoleChan chan<- ConsoleMessage
+	log         *core.Log
 }
 
 // NewWebLogger creates a new web logger for a specific render
@@ -25,15 +28,24 @@ func NewWebLogger(renderID string, consoleChan chan<- ConsoleMessage) core.Logge
 	return &WebLogger{
 		renderID:    renderID,
 		consoleChan: consoleChan,
+		log:         core.NewLog("renderer"),
 	}
 }
 
 // Printf implements core.Logger interface
 func (wl *WebLogger) Printf(format string, args ...interface{}) {
+	wl.Logf(core.LevelInfo, format, args...)
+}
+
+// Logf implements core.LevelLogger interface
+func (wl *WebLogger) Logf(level slog.Level, format string, args ...interface{}) {
+	if !wl.log.Enabled(level) {
+		return
+	}
 	message := fmt.Sprintf(format, args...)
 
-	// Also write to stdout for server logs
-	fmt.Print(message)
+	// Also write to the server log
+	wl.log.Logf(level, "%s", message)
 
 	// Send to web console if channel is available (non-blocking)
 	if wl.consoleChan != nil {
@@ -41,10 +53,24 @@ func (wl *WebLogger) Printf(format string, args ...interface{}) {
 		case wl.consoleChan <- ConsoleMessage{
 			Message:   message,
 			Timestamp: time.Now(),
-			Level:     "info",
+			Level:     consoleLevel(level),
 		}:
 		default:
 			// Channel full, skip (don't block)
 		}
 	}
 }
+
+// consoleLevel names a log level for the browser console
+func consoleLevel(level slog.Level) string {
+	switch {
+	case level < core.LevelInfo:
+		return "debug"
+	case level < core.LevelWarn:
+		return "info"
+	case level < core.LevelError:
+		return "warning"
+	default:
+		return "error"
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"testing"
 	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
 )
 
 func TestWebLogger_BasicLogging(t *testing.T) {
@@ -136,3 +138,23 @@ func TestConsoleMessage_JSONSerialization(t *testing.T) {
 		t.Error("Timestamp should not be zero")
 	}
 }
+
+func TestWebLogger_Levels(t *testing.T) {
+	messageChan := make(chan ConsoleMessage, 10)
+	logger := NewWebLogger("test-render-levels", messageChan).(*WebLogger)
+
+	// Debug messages are dropped unless the renderer's log is at debug
+	logger.Logf(core.LevelDebug, "Processed %d splats\n", 12)
+	logger.Logf(core.LevelWarn, "Mesh not found\n")
+	select {
+	case msg := <-messageChan:
+		if msg.Level != "warning" || msg.Message != "Mesh not found\n" {
+			t.Errorf("Expected the warning, got %q at %s", msg.Message, msg.Level)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Timeout waiting for the warning")
+	}
+	if len(messageChan) != 0 {
+		t.Errorf("Expected the debug message dropped, got %d more messages", len(messageChan))
+	}
+}
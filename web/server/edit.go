@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/material"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// maxSceneEdits bounds the edits a request may carry
+const maxSceneEdits = 100
+
+// defaultAddedRadius is the radius of the spheres "add" edits add when they don't give one
+const defaultAddedRadius = 0.25
+
+// SceneEdit is an edit the page's basic editor made to the scene, picking the shape by the pixel
+// it's seen at. The page keeps its edits and sends them with each render, which applies them in
+// order to the scene as built, so pixels pick from the scene the edits before them left.
+type SceneEdit struct {
+	Op     string     `json:"op"`               // "add", "remove" or "move"
+	X      int        `json:"x"`                // Pixel of the shape to remove or move, or of the surface to add a sphere on
+	Y      int        `json:"y"`                //
+	Offset [3]float64 `json:"offset,omitempty"` // How far "move" moves the shape
+	Radius float64    `json:"radius,omitempty"` // Radius of the sphere "add" adds (0 = 0.25)
+}
+
+// applySceneEdit applies an edit to a preprocessed scene whose sampling size is the image's
+func applySceneEdit(sceneObj *scene.Scene, edit SceneEdit) error {
+	shape, hit, err := sceneObj.ShapeAt(edit.X, edit.Y)
+	if err != nil {
+		return err
+	}
+	switch edit.Op {
+	case "add":
+		// A gray sphere resting on the surface picked
+		radius := edit.Radius
+		if radius <= 0 {
+			radius = defaultAddedRadius
+		}
+		center := hit.Point.Add(hit.Normal.Multiply(radius))
+		return sceneObj.AddShape(geometry.NewSphere(center, radius, material.NewLambertian(core.NewVec3(0.7, 0.7, 0.7))))
+	case "remove":
+		return sceneObj.RemoveShape(shape)
+	case "move":
+		_, err := sceneObj.MoveShape(shape, core.NewVec3(edit.Offset[0], edit.Offset[1], edit.Offset[2]))
+		return err
+	default:
+		return fmt.Errorf("unknown edit %q", edit.Op)
+	}
+}
+
+// applySceneEdits applies a request's edits in order
+func applySceneEdits(sceneObj *scene.Scene, edits []SceneEdit) error {
+	for i, edit := range edits {
+		if err := applySceneEdit(sceneObj, edit); err != nil {
+			return fmt.Errorf("scene edit %d (%s at %d,%d): %v", i+1, edit.Op, edit.X, edit.Y, err)
+		}
+	}
+	return nil
+}
+
+// handleEdit applies the SceneEdit in the request body to the latest render while it runs: it
+// starts over from its first pass showing the edited scene. It answers 409 Conflict when the
+// render is over, for the page to render again with the edit among its edits.
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+	}
+
+	var edit SceneEdit
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		fail(http.StatusBadRequest, "Invalid scene edit: "+err.Error())
+		return
+	}
+
+	s.renderMu.Lock()
+	raytracer := s.render
+	s.renderMu.Unlock()
+	if raytracer == nil {
+		fail(http.StatusConflict, "No render is running")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	err := raytracer.EditScene(ctx, func(sceneObj *scene.Scene) error {
+		if err := applySceneEdit(sceneObj, edit); err != nil {
+			return sceneEditError{err}
+		}
+		return nil
+	})
+	var editErr sceneEditError
+	switch {
+	case errors.As(err, &editErr):
+		fail(http.StatusUnprocessableEntity, editErr.Error())
+	case err != nil:
+		fail(http.StatusConflict, err.Error())
+	default:
+		json.NewEncoder(w).Encode(map[string]bool{"applied": true})
+	}
+}
+
+// sceneEditError is an edit that couldn't be applied, told apart from a render that couldn't take it
+type sceneEditError struct{ error }
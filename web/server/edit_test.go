@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSceneEditsInRequests(t *testing.T) {
+	s := NewServer(0)
+	focus := func(edits string) (int, FocusResponse) {
+		recorder := httptest.NewRecorder()
+		s.handleFocus(recorder, httptest.NewRequest("GET", "/api/focus?scene=basic&width=160&height=100&x=80&y=50&edits="+url.QueryEscape(edits), nil))
+		var response FocusResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		return recorder.Code, response
+	}
+	_, before := focus("")
+
+	// Moving the center sphere back and adding a sphere on the ground in front of it
+	code, moved := focus(`[{"op":"move","x":80,"y":50,"offset":[0,0,-1]}]`)
+	if code != http.StatusOK || moved.FocusDistance < before.FocusDistance+0.5 {
+		t.Errorf("Expected the moved sphere further than %.2f, got %d %+v", before.FocusDistance, code, moved)
+	}
+	code, added := focus(`[{"op":"move","x":80,"y":50,"offset":[0,0,-1]},{"op":"add","x":80,"y":90,"radius":0.5}]`)
+	if code != http.StatusOK || added.FocusDistance >= moved.FocusDistance {
+		t.Errorf("Expected the added sphere in front of the moved one, got %d %+v", code, added)
+	}
+
+	for _, edits := range []string{`[{"op":"paint","x":80,"y":50}]`, `[{"op":"remove","x":0,"y":0}]`, `{"op":"remove"}`} {
+		if code, _ := focus(edits); code == http.StatusOK {
+			t.Errorf("Expected edits %s to fail", edits)
+		}
+	}
+}
+
+func TestHandleEdit(t *testing.T) {
+	s := NewServer(0)
+	post := func(body string) (int, string) {
+		recorder := httptest.NewRecorder()
+		s.handleEdit(recorder, httptest.NewRequest("POST", "/api/edit", strings.NewReader(body)))
+		return recorder.Code, recorder.Body.String()
+	}
+	if code, body := post(`{"op":"remove","x":32,"y":16}`); code != http.StatusConflict {
+		t.Errorf("Expected a conflict before any render, got %d %s", code, body)
+	}
+
+	req := NewDefaultRenderRequest()
+	req.Scene, req.Width, req.Height, req.MaxSamples, req.MaxPasses = "basic", 64, 32, 10000, 100
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan SSEEvent, 1000)
+	done := make(chan struct{})
+	go func() {
+		s.StreamRender(ctx, req, events)
+		close(done)
+	}()
+	nextPass := func() int {
+		for {
+			select {
+			case event := <-events:
+				if event.Type == "passComplete" {
+					var pass struct {
+						PassNumber int `json:"passNumber"`
+					}
+					json.Unmarshal([]byte(event.Data), &pass)
+					return pass.PassNumber
+				}
+			case <-done:
+				return 0
+			}
+		}
+	}
+
+	// An edit during the render starts it over from its first pass
+	nextPass()
+	if code, body := post(`{"op":"move","x":32,"y":16,"offset":[0,0.5,0]}`); code != http.StatusOK {
+		t.Fatalf("Expected the edit applied, got %d %s", code, body)
+	}
+	for pass := nextPass(); pass != 1; pass = nextPass() {
+		if pass == 0 {
+			t.Fatal("Expected the passes to start over after the edit")
+		}
+	}
+	if code, body := post(`{"op":"remove","x":0,"y":0}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected an edit of the sky to fail, got %d %s", code, body)
+	}
+
+	cancel()
+	<-done
+	if code, body := post(`{"op":"remove","x":32,"y":16}`); code != http.StatusConflict {
+		t.Errorf("Expected a conflict once the render is over, got %d %s", code, body)
+	}
+}
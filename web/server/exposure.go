@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// ExposureResponse is the exposure of the latest pass the server rendered, for the page's
+// exposure meter
+type ExposureResponse struct {
+	Scene      string `json:"scene"`
+	PassNumber int    `json:"passNumber"`
+	renderer.Exposure
+}
+
+// recordExposure keeps a finished pass's exposure for /api/exposure
+func (s *Server) recordExposure(sceneName string, passResult renderer.PassResult) {
+	if passResult.Exposure == nil {
+		return
+	}
+	s.exposureMu.Lock()
+	defer s.exposureMu.Unlock()
+	s.exposure = &ExposureResponse{Scene: sceneName, PassNumber: passResult.PassNumber, Exposure: *passResult.Exposure}
+}
+
+// handleExposure serves the luminance histogram, clipping and suggested exposure adjustment of the
+// image accumulated by the latest render, as of its latest finished pass
+func (s *Server) handleExposure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.exposureMu.Lock()
+	exposure := s.exposure
+	s.exposureMu.Unlock()
+	if exposure == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No pass has finished rendering yet"})
+		return
+	}
+	json.NewEncoder(w).Encode(exposure)
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleExposure(t *testing.T) {
+	s := NewServer(0)
+	recorder := httptest.NewRecorder()
+	s.handleExposure(recorder, httptest.NewRequest("GET", "/api/exposure", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any render, got %d", recorder.Code)
+	}
+
+	req := NewDefaultRenderRequest()
+	req.Scene, req.Width, req.Height, req.MaxSamples, req.MaxPasses = "basic", 64, 32, 2, 2
+	s.StreamRender(context.Background(), req, make(chan SSEEvent, 1000))
+
+	recorder = httptest.NewRecorder()
+	s.handleExposure(recorder, httptest.NewRequest("GET", "/api/exposure", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the exposure after the render, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var exposure ExposureResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &exposure); err != nil {
+		t.Fatal(err)
+	}
+	total := exposure.Black
+	for _, n := range exposure.Histogram {
+		total += n
+	}
+	if exposure.Scene != "basic" || exposure.PassNumber != 2 || exposure.Pixels != 64*32 || total != exposure.Pixels || exposure.Key <= 0 {
+		t.Errorf("Expected the last pass's exposure of every pixel, got %+v", exposure)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FocusResponse is the focus distance that brings a pixel into focus, for click-to-focus
+type FocusResponse struct {
+	FocusDistance float64 `json:"focusDistance"`
+}
+
+// handleFocus finds the focus distance of what a pixel shows, by casting the ray through its
+// center. It takes the scene parameters of /api/render, edits included, plus x and y; the page
+// renders again with the distance as focusDistance.
+func (s *Server) handleFocus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+	}
+
+	req := &RenderRequest{}
+	if err := s.parseCommonSceneParams(r, req); err != nil {
+		fail(http.StatusBadRequest, "Invalid scene parameters: "+err.Error())
+		return
+	}
+	query := r.URL.Query()
+	x, err := parseIntParam(query, "x", 0, 0, req.Width-1)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	y, err := parseIntParam(query, "y", 0, 0, req.Height-1)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sceneObj := s.createScene(req, false, nil)
+	if sceneObj == nil {
+		fail(http.StatusBadRequest, "Unknown scene: "+req.Scene)
+		return
+	}
+	sceneObj.SamplingConfig.Width = req.Width
+	sceneObj.SamplingConfig.Height = req.Height
+	if err := sceneObj.Preprocess(); err != nil {
+		fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := applySceneEdits(sceneObj, req.Edits); err != nil {
+		fail(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	distance, err := sceneObj.FocusDistanceAt(x, y)
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(FocusResponse{FocusDistance: distance})
+}
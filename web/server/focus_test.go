@@ -0,0 +1,32 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleFocus(t *testing.T) {
+	s := NewServer(0)
+	recorder := httptest.NewRecorder()
+	s.handleFocus(recorder, httptest.NewRequest("GET", "/api/focus?scene=basic&width=160&height=100&x=80&y=50", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected a focus distance, got %d: %s", recorder.Code, recorder.Body)
+	}
+	var focus FocusResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &focus); err != nil {
+		t.Fatal(err)
+	}
+	if focus.FocusDistance < 2 || focus.FocusDistance > 3 {
+		t.Errorf("Expected to focus on the center sphere about 2.5 away, got %f", focus.FocusDistance)
+	}
+
+	for _, query := range []string{"x=0&y=0", "x=160&y=50"} {
+		recorder = httptest.NewRecorder()
+		s.handleFocus(recorder, httptest.NewRequest("GET", "/api/focus?scene=basic&width=160&height=100&"+query, nil))
+		if recorder.Code == http.StatusOK {
+			t.Errorf("Expected %s to fail, got %s", query, recorder.Body)
+		}
+	}
+}
@@ -128,7 +128,7 @@ func inspectPixel(sceneObj *scene.Scene, width, height, pixelX, pixelY int) Insp
 	ray := camera.GetRay(pixelX, pixelY, sampler.Get2D(), sampler.Get2D())
 
 	// Cast the ray and find the first intersection using scene's BVH
-	hit, isHit := sceneObj.BVH.Hit(ray, 0.001, math.Inf(1))
+	hit, isHit := sceneObj.Intersector.Hit(ray, 0.001, math.Inf(1))
 	if !isHit {
 		return InspectResult{Hit: false}
 	}
@@ -215,6 +215,22 @@ func (s *Server) extractGeometryInfo(shape geometry.Shape) (string, map[string]i
 		}
 		return "cone", properties
 
+	case *geometry.Torus:
+		properties["center"] = [3]float64{geom.Center.X, geom.Center.Y, geom.Center.Z}
+		properties["axis"] = [3]float64{geom.Axis.X, geom.Axis.Y, geom.Axis.Z}
+		properties["majorRadius"] = geom.MajorRadius
+		properties["minorRadius"] = geom.MinorRadius
+		return "torus", properties
+
+	case *geometry.Curves:
+		properties["segmentCount"] = geom.GetSegmentCount()
+		bbox := geom.BoundingBox()
+		properties["boundingBox"] = map[string]interface{}{
+			"min": [3]float64{bbox.Min.X, bbox.Min.Y, bbox.Min.Z},
+			"max": [3]float64{bbox.Max.X, bbox.Max.Y, bbox.Max.Z},
+		}
+		return "curves", properties
+
 	default:
 		return "unknown", properties
 	}
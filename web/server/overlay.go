@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"net/http"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// handleOverlay serves the requested overlays (wireframe, bvh) of a scene as a transparent PNG
+// the size of the render, for the page to lay over the canvas. It takes the scene parameters of
+// /api/inspect plus overlay, a comma-separated list of overlay names.
+func (s *Server) handleOverlay(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	fail := func(status int, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+	}
+
+	req := &RenderRequest{}
+	if err := s.parseCommonSceneParams(r, req); err != nil {
+		fail(http.StatusBadRequest, "Invalid scene parameters: "+err.Error())
+		return
+	}
+	overlays, err := renderer.ParseOverlays(r.URL.Query().Get("overlay"))
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	const configOnly = true
+	sceneObj := s.createScene(req, configOnly, nil)
+	if sceneObj == nil {
+		fail(http.StatusBadRequest, "Unknown scene: "+req.Scene)
+		return
+	}
+	sceneObj.SamplingConfig.Width = req.Width
+	sceneObj.SamplingConfig.Height = req.Height
+	if err := sceneObj.Preprocess(); err != nil {
+		fail(http.StatusInternalServerError, "Failed to preprocess scene: "+err.Error())
+		return
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, req.Width, req.Height))
+	for _, name := range overlays {
+		draw.Draw(img, img.Bounds(), renderer.DrawOverlay(sceneObj, name), image.Point{}, draw.Over)
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
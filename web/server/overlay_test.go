@@ -0,0 +1,29 @@
+package server
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleOverlay(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer(0).handleOverlay(recorder, httptest.NewRequest("GET", "/api/overlay?scene=basic&width=160&height=120&overlay=wireframe,bvh", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected a PNG, got %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(recorder.Body)
+	if err != nil {
+		t.Fatalf("Invalid PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 160 || size.Y != 120 {
+		t.Errorf("Expected the render's size, got %v", size)
+	}
+
+	recorder = httptest.NewRecorder()
+	NewServer(0).handleOverlay(recorder, httptest.NewRequest("GET", "/api/overlay?scene=basic&overlay=normals", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown overlay, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// setCurrentRender makes a render the one /api/preview-profile switches and /api/edit edits
+func (s *Server) setCurrentRender(raytracer *renderer.ProgressiveRaytracer) {
+	s.renderMu.Lock()
+	defer s.renderMu.Unlock()
+	s.render = raytracer
+}
+
+// handlePreviewProfile switches the latest render between the preview and the full sampling
+// profile from its next pass on, when given enabled=true or false, and reports which it uses. The
+// last pass uses the full profile either way.
+func (s *Server) handlePreviewProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.renderMu.Lock()
+	raytracer := s.render
+	s.renderMu.Unlock()
+	if raytracer == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No render has started yet"})
+		return
+	}
+
+	if r.URL.Query().Has("enabled") {
+		enabled, err := parseBoolParam(r.URL.Query(), "enabled", false)
+		if err == nil {
+			err = raytracer.SetPreviewProfile(enabled)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"previewProfile": raytracer.PreviewProfile()})
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlePreviewProfile(t *testing.T) {
+	s := NewServer(0)
+	get := func(query string) (int, map[string]any) {
+		recorder := httptest.NewRecorder()
+		s.handlePreviewProfile(recorder, httptest.NewRequest("POST", "/api/preview-profile"+query, nil))
+		var body map[string]any
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		return recorder.Code, body
+	}
+	if code, _ := get(""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any render, got %d", code)
+	}
+
+	// A render with the preview profile marks its passes but the last
+	req := NewDefaultRenderRequest()
+	req.Scene, req.Width, req.Height, req.MaxSamples, req.MaxPasses = "basic", 32, 16, 4, 3
+	req.PreviewProfile = true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	events := make(chan SSEEvent, 1000)
+	s.StreamRender(ctx, req, events)
+
+	// The console goroutine may still send, so drain without closing, as collectEvents does
+	var profiles []bool
+	for drained := false; !drained; {
+		select {
+		case event := <-events:
+			if event.Type == "passComplete" {
+				var pass struct {
+					PreviewProfile bool `json:"previewProfile"`
+				}
+				json.Unmarshal([]byte(event.Data), &pass)
+				profiles = append(profiles, pass.PreviewProfile)
+			}
+		default:
+			drained = true
+		}
+	}
+	if len(profiles) != 3 || !profiles[0] || !profiles[1] || profiles[2] {
+		t.Errorf("Expected the preview profile for passes 1 and 2, got %v", profiles)
+	}
+
+	if code, body := get("?enabled=false"); code != http.StatusOK || body["previewProfile"] != false {
+		t.Errorf("Expected the profile switched off, got %d %v", code, body)
+	}
+	if code, body := get(""); code != http.StatusOK || body["previewProfile"] != false {
+		t.Errorf("Expected the profile reported off, got %d %v", code, body)
+	}
+	if code, body := get("?enabled=maybe"); code != http.StatusBadRequest || !strings.Contains(body["error"].(string), "enabled") {
+		t.Errorf("Expected a bad request, got %d %v", code, body)
+	}
+}
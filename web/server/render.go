@@ -8,12 +8,15 @@ import (
 	"fmt"
 	"image"
 	"image/png"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
 	"github.com/df07/go-progressive-raytracer/pkg/integrator"
+	"github.com/df07/go-progressive-raytracer/pkg/lights"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
 	"github.com/df07/go-progressive-raytracer/pkg/renderer"
 	"github.com/df07/go-progressive-raytracer/pkg/scene"
 )
@@ -29,9 +32,34 @@ type TileUpdate struct {
 	TotalPasses int    `json:"totalPasses"` // Total number of passes planned
 }
 
+// PreviewUpdate is the data of a preview event: the whole image as the pass in progress has it
+type PreviewUpdate struct {
+	ImageData  string `json:"imageData"` // Base64 encoded PNG of the whole image
+	PassNumber int    `json:"passNumber"`
+	TileNumber int    `json:"tileNumber"` // Tiles of the pass drawn into the image
+	TotalTiles int    `json:"totalTiles"`
+}
+
+// ProgressUpdate is the data of a progress event, sent as tiles and passes complete
+type ProgressUpdate struct {
+	PassNumber       int     `json:"passNumber"`
+	TotalPasses      int     `json:"totalPasses"`
+	TileNumber       int     `json:"tileNumber"` // Tiles of the pass completed
+	TotalTiles       int     `json:"totalTiles"`
+	Percent          float64 `json:"percent"` // Estimated percentage of the render done
+	ElapsedMs        int64   `json:"elapsedMs"`
+	EtaMs            int64   `json:"etaMs"` // Estimated time remaining (0 when done or unknown)
+	Samples          int64   `json:"samples"`
+	Rays             int64   `json:"rays"` // Camera, bounce and shadow rays traced
+	SamplesPerSecond float64 `json:"samplesPerSecond"`
+	RaysPerSecond    float64 `json:"raysPerSecond"`
+	PassTimesMs      []int64 `json:"passTimesMs"` // Durations of the completed passes
+	Done             bool    `json:"done"`
+}
+
 // SSEEvent represents a unified SSE event for thread-safe writing
 type SSEEvent struct {
-	Type string `json:"type"` // "console", "tile", "passComplete", "error", "complete"
+	Type string `json:"type"` // "console", "tile", "progress", "passComplete", "error", "complete"
 	Data string `json:"data"` // JSON-encoded data
 }
 
@@ -61,11 +89,18 @@ func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.StreamRender(ctx, req, sseEventChan)
+}
+
+// StreamRender renders the requested scene and sends its events (console, tile, progress, preview, passComplete, then
+// complete or error) to sseEventChan. It returns when rendering ends or ctx is cancelled.
+// The HTTP handler forwards the events as SSE; the WebAssembly build passes them to JavaScript.
+func (s *Server) StreamRender(ctx context.Context, req *RenderRequest, sseEventChan chan SSEEvent) {
 	// Setup console logging and streaming
 	consoleChan, webLogger := s.setupConsoleLogging()
 	go s.streamConsoleMessages(ctx, consoleChan, sseEventChan)
 
-	pipeline, err := s.setupRenderingPipeline(req, webLogger)
+	pipeline, err := s.setupRenderingPipeline(ctx, req, webLogger)
 	if err != nil {
 		s.handleError(ctx, sseEventChan, err.Error())
 		return
@@ -73,7 +108,15 @@ func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
 
 	// Start rendering and stream events
 	startTime := time.Now()
-	renderOptions := renderer.RenderOptions{TileUpdates: true}
+	renderOptions := renderer.RenderOptions{
+		TileUpdates: true,
+		OnProgress:  func(p renderer.Progress) { s.sendProgress(ctx, sseEventChan, p) },
+	}
+	if req.PreviewInterval > 0 {
+		renderOptions.PreviewInterval = time.Duration(req.PreviewInterval * float64(time.Second))
+		renderOptions.OnPreview = func(p renderer.Preview) { s.sendPreview(ctx, sseEventChan, p) }
+	}
+	s.setCurrentRender(pipeline.Raytracer)
 	passChan, tileChan, errChan := pipeline.Raytracer.RenderProgressive(ctx, renderOptions)
 
 	// Handle rendering events and send to unified channel
@@ -101,7 +144,7 @@ func (s *Server) writeSSEEvents(w http.ResponseWriter, ctx context.Context, sseE
 	defer func() {
 		if r := recover(); r != nil {
 			// Client disconnected during write, this is expected behavior when stopping renders
-			log.Printf("SSE writer recovered from panic (client disconnected): %v", r)
+			webLog.Logf(core.LevelWarn, "SSE writer recovered from panic (client disconnected): %v", r)
 		}
 	}()
 
@@ -151,7 +194,7 @@ func (s *Server) streamConsoleMessages(ctx context.Context, consoleChan chan Con
 			// Send console message as SSE event
 			data, err := json.Marshal(consoleMsg)
 			if err != nil {
-				log.Printf("Error marshaling console message: %v", err)
+				webLog.Logf(core.LevelError, "Error marshaling console message: %v", err)
 				continue
 			}
 
@@ -179,11 +222,17 @@ func (s *Server) streamConsoleMessages(ctx context.Context, consoleChan chan Con
 	}
 }
 
-// setupRenderingPipeline creates and configures the scene and raytracer
-func (s *Server) setupRenderingPipeline(req *RenderRequest, logger core.Logger) (*RenderingPipeline, error) {
+// setupRenderingPipeline creates and configures the scene and raytracer. If ctx is cancelled
+// while the scene loads, it returns ctx's error rather than build the scene's BVH.
+func (s *Server) setupRenderingPipeline(ctx context.Context, req *RenderRequest, logger core.Logger) (*RenderingPipeline, error) {
 	// Create scene (logging will now go through WebLogger)
-	sceneObj := s.createScene(req, false, logger)
-	if sceneObj == nil {
+	var sceneObj *scene.Scene
+	if req.PBRTSource != "" {
+		var err error
+		if sceneObj, err = s.createInlinePBRTScene(req); err != nil {
+			return nil, err
+		}
+	} else if sceneObj = s.createScene(req, false, logger); sceneObj == nil {
 		return nil, fmt.Errorf("Unknown scene: %s", req.Scene)
 	}
 
@@ -191,8 +240,16 @@ func (s *Server) setupRenderingPipeline(req *RenderRequest, logger core.Logger)
 	sceneObj.SamplingConfig.Width = req.Width
 	sceneObj.SamplingConfig.Height = req.Height
 	sceneObj.SamplingConfig.RussianRouletteMinBounces = req.RRMinBounces
+	sceneObj.SamplingConfig.RussianRouletteMinProb = req.RRMinProb
 	sceneObj.SamplingConfig.AdaptiveMinSamples = req.AdaptiveMinSamples
 	sceneObj.SamplingConfig.AdaptiveThreshold = req.AdaptiveThreshold
+	if req.Filter != "" {
+		sceneObj.SamplingConfig.Filter = req.Filter
+		sceneObj.SamplingConfig.FilterRadius = 0
+	}
+	if req.FocusDistance > 0 {
+		sceneObj.SetFocusDistance(req.FocusDistance)
+	}
 
 	// Create progressive raytracer
 	config := renderer.ProgressiveConfig{
@@ -200,31 +257,40 @@ func (s *Server) setupRenderingPipeline(req *RenderRequest, logger core.Logger)
 		InitialSamples:     1,
 		MaxSamplesPerPixel: req.MaxSamples,
 		MaxPasses:          req.MaxPasses,
+		PyramidLevels:      req.PyramidLevels,
 		NumWorkers:         0, // Auto-detect
 	}
 
-	// Create the appropriate integrator based on request
-	var selectedIntegrator integrator.Integrator
-	switch req.Integrator {
-	case "bdpt":
-		selectedIntegrator = integrator.NewBDPTIntegrator(sceneObj.SamplingConfig)
-	case "path-tracing":
-		selectedIntegrator = integrator.NewPathTracingIntegrator(sceneObj.SamplingConfig)
-	default:
-		// Default to path tracing for unknown integrator types
-		selectedIntegrator = integrator.NewPathTracingIntegrator(sceneObj.SamplingConfig)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-
-	raytracer, err := renderer.NewProgressiveRaytracer(sceneObj, config, selectedIntegrator, logger)
+	raytracer, err := renderer.NewProgressiveRaytracer(sceneObj, config, newIntegrator(req.Integrator, sceneObj.SamplingConfig), logger)
 	if err != nil {
 		return nil, fmt.Errorf("error creating progressive raytracer: %w", err)
 	}
+
+	if err := applySceneEdits(sceneObj, req.Edits); err != nil {
+		return nil, err
+	}
+
+	// The preview profile is always available, so the page can switch it while rendering
+	raytracer.EnablePreviewProfile(newIntegrator(req.Integrator, sceneObj.SamplingConfig.PreviewProfile()))
+	raytracer.SetPreviewProfile(req.PreviewProfile)
 	return &RenderingPipeline{
 		Scene:     sceneObj,
 		Raytracer: raytracer,
 	}, nil
 }
 
+// newIntegrator creates the integrator a request names, built in or registered, path tracing for
+// unknown names
+func newIntegrator(name string, samplingConfig scene.SamplingConfig) integrator.Integrator {
+	if integratorInst, err := integrator.New(name, samplingConfig); err == nil {
+		return integratorInst
+	}
+	return integrator.NewPathTracingIntegrator(samplingConfig)
+}
+
 // handleRenderingEvents processes the main rendering event loop
 func (s *Server) handleRenderingEvents(ctx context.Context, sseEventChan chan SSEEvent,
 	passChan <-chan renderer.PassResult, tileChan <-chan renderer.TileCompletionResult, errChan <-chan error,
@@ -281,6 +347,8 @@ func (s *Server) handlePassComplete(ctx context.Context, sseEventChan chan SSEEv
 	default:
 	}
 
+	s.recordExposure(req.Scene, passResult)
+
 	// Create pass completion data
 	elapsed := time.Since(startTime)
 	primitiveCount := scene.GetPrimitiveCount()
@@ -301,10 +369,11 @@ func (s *Server) handlePassComplete(ctx context.Context, sseEventChan chan SSEEv
 		MaxSamplesUsed   int     `json:"maxSamplesUsed"`
 		PrimitiveCount   int     `json:"primitiveCount"`
 		AverageLuminance float64 `json:"averageLuminance"`
+		PreviewProfile   bool    `json:"previewProfile"` // Rendered with the preview sampling profile
 	}{
 		Event:            "passComplete",
 		PassNumber:       passResult.PassNumber,
-		TotalPasses:      req.MaxPasses,
+		TotalPasses:      req.PyramidLevels + req.MaxPasses,
 		ElapsedMs:        elapsed.Milliseconds(),
 		TotalPixels:      passResult.Stats.TotalPixels,
 		TotalSamples:     passResult.Stats.TotalSamples,
@@ -314,11 +383,12 @@ func (s *Server) handlePassComplete(ctx context.Context, sseEventChan chan SSEEv
 		MaxSamplesUsed:   passResult.Stats.MaxSamplesUsed,
 		PrimitiveCount:   primitiveCount,
 		AverageLuminance: avgLuminance,
+		PreviewProfile:   passResult.PreviewProfile,
 	}
 
 	data, err := json.Marshal(passUpdate)
 	if err != nil {
-		log.Printf("Error marshaling pass update: %v", err)
+		webLog.Logf(core.LevelError, "Error marshaling pass update: %v", err)
 		return
 	}
 
@@ -340,7 +410,7 @@ func (s *Server) handleTileUpdate(ctx context.Context, sseEventChan chan SSEEven
 	// Convert tile image to base64 PNG
 	tileData, err := s.imageToBase64PNG(tileResult.TileImage)
 	if err != nil {
-		log.Printf("Error encoding tile image (%d, %d): %v", tileResult.TileX, tileResult.TileY, err)
+		webLog.Logf(core.LevelError, "Error encoding tile image (%d, %d): %v", tileResult.TileX, tileResult.TileY, err)
 		return
 	}
 
@@ -357,7 +427,7 @@ func (s *Server) handleTileUpdate(ctx context.Context, sseEventChan chan SSEEven
 
 	data, err := json.Marshal(update)
 	if err != nil {
-		log.Printf("Error marshaling tile update: %v", err)
+		webLog.Logf(core.LevelError, "Error marshaling tile update: %v", err)
 		return
 	}
 
@@ -367,6 +437,123 @@ func (s *Server) handleTileUpdate(ctx context.Context, sseEventChan chan SSEEven
 	}
 }
 
+// sendProgress sends a progress event. It runs on the rendering goroutine, so a tile's progress is
+// dropped rather than hold up rendering when the events back up; a pass's progress is always sent.
+func (s *Server) sendProgress(ctx context.Context, sseEventChan chan SSEEvent, p renderer.Progress) {
+	update := ProgressUpdate{
+		PassNumber:       p.Pass,
+		TotalPasses:      p.TotalPasses,
+		TileNumber:       p.TilesDone,
+		TotalTiles:       p.TotalTiles,
+		Percent:          100 * p.Fraction,
+		ElapsedMs:        p.Elapsed.Milliseconds(),
+		EtaMs:            p.ETA.Milliseconds(),
+		Samples:          p.Samples,
+		Rays:             p.Rays,
+		SamplesPerSecond: p.SamplesPerSecond,
+		RaysPerSecond:    p.RaysPerSecond,
+		PassTimesMs:      make([]int64, len(p.PassTimes)),
+		Done:             p.Done,
+	}
+	for i, passTime := range p.PassTimes {
+		update.PassTimesMs[i] = passTime.Milliseconds()
+	}
+
+	data, err := json.Marshal(update)
+	if err != nil {
+		webLog.Logf(core.LevelError, "Error marshaling progress update: %v", err)
+		return
+	}
+
+	event := SSEEvent{Type: "progress", Data: string(data)}
+	if p.PassDone {
+		select {
+		case sseEventChan <- event:
+		case <-ctx.Done():
+		}
+		return
+	}
+	select {
+	case sseEventChan <- event:
+	default:
+	}
+}
+
+// sendPreview sends a preview event. Like a tile's progress, it's dropped rather than hold up
+// rendering when the events back up; tile events and the next preview bring the display up to date.
+func (s *Server) sendPreview(ctx context.Context, sseEventChan chan SSEEvent, p renderer.Preview) {
+	imageData, err := s.imageToBase64PNG(p.Image)
+	if err != nil {
+		webLog.Logf(core.LevelError, "Error encoding preview image: %v", err)
+		return
+	}
+	data, err := json.Marshal(PreviewUpdate{
+		ImageData:  imageData,
+		PassNumber: p.Pass,
+		TileNumber: p.TilesDone,
+		TotalTiles: p.TotalTiles,
+	})
+	if err != nil {
+		webLog.Logf(core.LevelError, "Error marshaling preview update: %v", err)
+		return
+	}
+
+	select {
+	case sseEventChan <- SSEEvent{Type: "preview", Data: string(data)}:
+	case <-ctx.Done():
+	default:
+	}
+}
+
+// NewDefaultRenderRequest returns a render request with the same defaults as /api/render
+// Clients sending JSON (the WebAssembly build) decode into it so omitted fields keep their defaults.
+func NewDefaultRenderRequest() *RenderRequest {
+	return &RenderRequest{
+		Scene:                "cornell-box",
+		Width:                400,
+		Height:               400,
+		MaxSamples:           50,
+		MaxPasses:            7,
+		RRMinBounces:         5,
+		RRMinProb:            0.05,
+		AdaptiveMinSamples:   0.15,
+		AdaptiveThreshold:    0.01,
+		Integrator:           "path-tracing",
+		CornellGeometry:      "boxes",
+		CornellLight:         "quad",
+		SphereGridSize:       20,
+		MaterialFinish:       "metallic",
+		SphereComplexity:     32,
+		DragonMaterialFinish: "gold",
+		LightType:            lights.LightTypeArea,
+	}
+}
+
+// createInlinePBRTScene creates a scene from the PBRT source sent with the request
+func (s *Server) createInlinePBRTScene(req *RenderRequest) (*scene.Scene, error) {
+	parsedScene, err := loaders.ParsePBRT(strings.NewReader(req.PBRTSource))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PBRT scene: %v", err)
+	}
+	cameraOverride := geometry.CameraConfig{
+		Width:       req.Width,
+		AspectRatio: float64(req.Width) / float64(req.Height),
+	}
+	pbrtScene, err := scene.NewPBRTScene(parsedScene, cameraOverride)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PBRT scene: %v", err)
+	}
+	if req.Camera != "" {
+		if err := pbrtScene.UseCamera(req.Camera); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.usePanorama(pbrtScene); err != nil {
+		return nil, err
+	}
+	return pbrtScene, nil
+}
+
 // parseRenderRequest parses request parameters
 func (s *Server) parseRenderRequest(r *http.Request) (*RenderRequest, error) {
 	// Initialize request
@@ -385,9 +572,24 @@ func (s *Server) parseRenderRequest(r *http.Request) (*RenderRequest, error) {
 	if req.MaxPasses, err = parseIntParam(r.URL.Query(), "maxPasses", 7, 1, 10000); err != nil {
 		return nil, err
 	}
+	if req.PyramidLevels, err = parseIntParam(r.URL.Query(), "pyramidLevels", 0, 0, maxPyramidLevels); err != nil {
+		return nil, err
+	}
+	if req.PreviewInterval, err = parseFloatParam(r.URL.Query(), "previewInterval", 0, 0, 3600); err != nil {
+		return nil, err
+	}
+	if req.PreviewProfile, err = parseBoolParam(r.URL.Query(), "previewProfile", false); err != nil {
+		return nil, err
+	}
+	if req.FocusDistance, err = parseFloatParam(r.URL.Query(), "focusDistance", 0, 0, 1e6); err != nil {
+		return nil, err
+	}
 	if req.RRMinBounces, err = parseIntParam(r.URL.Query(), "rrMinBounces", 5, 1, 1000); err != nil {
 		return nil, err
 	}
+	if req.RRMinProb, err = parseFloatParam(r.URL.Query(), "rrMinProb", 0.05, 0.01, 1.0); err != nil {
+		return nil, err
+	}
 	if req.AdaptiveMinSamples, err = parseFloatParam(r.URL.Query(), "adaptiveMinSamples", 0.15, 0.01, 1.0); err != nil {
 		return nil, err
 	}
@@ -397,7 +599,7 @@ func (s *Server) parseRenderRequest(r *http.Request) (*RenderRequest, error) {
 
 	// Performance warning
 	if req.Width*req.Height > 800*600 && req.MaxSamples > 100 {
-		log.Printf("Render warning: Large image with high samples may render slowly")
+		webLog.Logf(core.LevelWarn, "Large image with high samples may render slowly")
 	}
 
 	return req, nil
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// collectEvents runs StreamRender and returns the event types it sent, in order (console messages excluded)
+func collectEvents(t *testing.T, req *RenderRequest) []SSEEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	events := make(chan SSEEvent, 1000)
+	NewServer(0).StreamRender(ctx, req, events)
+
+	var collected []SSEEvent
+	for {
+		select {
+		case event := <-events:
+			if event.Type != "console" {
+				collected = append(collected, event)
+			}
+		default:
+			return collected
+		}
+	}
+}
+
+func TestStreamRender_BuiltInScene(t *testing.T) {
+	// JSON requests decode over the defaults, so only the overrides need to be sent
+	req := NewDefaultRenderRequest()
+	if err := json.Unmarshal([]byte(`{"scene": "basic", "width": 128, "height": 64, "maxSamples": 2, "maxPasses": 2}`), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Integrator != "path-tracing" || req.RRMinBounces != 5 {
+		t.Errorf("Expected defaults for omitted fields, got %+v", req)
+	}
+
+	events := collectEvents(t, req)
+
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.Type]++
+	}
+	if counts["passComplete"] != 2 || counts["tile"] == 0 || counts["progress"] == 0 || counts["preview"] != 0 || counts["error"] != 0 {
+		t.Errorf("Unexpected events %v", counts)
+	}
+	if last := events[len(events)-1]; last.Type != "complete" {
+		t.Errorf("Expected the stream to end with complete, got %s", last.Type)
+	}
+
+	// The last progress event finishes the render and times both passes
+	var progress ProgressUpdate
+	for _, event := range events {
+		if event.Type == "progress" {
+			if err := json.Unmarshal([]byte(event.Data), &progress); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if !progress.Done || progress.Percent != 100 || len(progress.PassTimesMs) != 2 || progress.Rays < progress.Samples {
+		t.Errorf("Expected a final progress of 100%% after 2 passes, got %+v", progress)
+	}
+}
+
+func TestStreamRender_Previews(t *testing.T) {
+	// An interval shorter than any tile previews the pass after each of its tiles but the last
+	req := NewDefaultRenderRequest()
+	req.Scene, req.Width, req.Height, req.MaxSamples, req.MaxPasses = "basic", 192, 64, 2, 2
+	req.PreviewInterval = 1e-9
+
+	var previews []PreviewUpdate
+	for _, event := range collectEvents(t, req) {
+		if event.Type == "preview" {
+			var preview PreviewUpdate
+			if err := json.Unmarshal([]byte(event.Data), &preview); err != nil {
+				t.Fatal(err)
+			}
+			previews = append(previews, preview)
+		}
+	}
+	if len(previews) != 2*2 {
+		t.Fatalf("Expected 2 previews of each of the 2 passes, got %d", len(previews))
+	}
+	for i, preview := range previews {
+		if preview.PassNumber != 1+i/2 || preview.TileNumber != 1+i%2 || preview.TotalTiles != 3 || preview.ImageData == "" {
+			t.Errorf("Preview %d: expected pass %d with %d of 3 tiles, got %+v", i, 1+i/2, 1+i%2, preview)
+		}
+	}
+}
+
+func TestStreamRender_InlinePBRT(t *testing.T) {
+	req := NewDefaultRenderRequest()
+	req.Width, req.Height, req.MaxSamples, req.MaxPasses = 32, 32, 1, 1
+	req.PBRTSource = `
+TransformBegin
+LookAt 0 5 0  0 0 0  0 0 -1
+Camera "perspective" "string name" "top"
+TransformEnd
+LookAt 0 0 5  0 0 0  0 1 0
+Camera "perspective" "float fov" [45]
+WorldBegin
+LightSource "infinite" "rgb L" [0.5 0.5 0.5]
+Material "diffuse" "rgb reflectance" [0.8 0.2 0.2]
+Shape "sphere" "float radius" [1]
+`
+
+	events := collectEvents(t, req)
+	if len(events) == 0 || events[len(events)-1].Type != "complete" {
+		t.Errorf("Expected the inline PBRT scene to render, got %v", events)
+	}
+
+	req.Camera = "top"
+	events = collectEvents(t, req)
+	if len(events) == 0 || events[len(events)-1].Type != "complete" {
+		t.Errorf("Expected the inline PBRT scene to render from its top camera, got %v", events)
+	}
+	req.Camera = "side"
+	events = collectEvents(t, req)
+	if len(events) != 1 || events[0].Type != "error" {
+		t.Errorf("Expected a single error for an unknown camera, got %v", events)
+	}
+	req.Camera = ""
+
+	req.PBRTSource = "LookAt 0 0 5"
+	events = collectEvents(t, req)
+	if len(events) != 1 || events[0].Type != "error" {
+		t.Errorf("Expected a single error for invalid PBRT source, got %v", events)
+	}
+}
+
+func TestStreamRender_Panorama(t *testing.T) {
+	// A panorama is as wide as its projection makes it, whatever width was requested
+	req := NewDefaultRenderRequest()
+	req.Scene, req.Width, req.Height, req.MaxSamples, req.MaxPasses = "basic", 400, 32, 1, 1
+	req.Projection = "cubemap"
+
+	counts := make(map[string]int)
+	for _, event := range collectEvents(t, req) {
+		counts[event.Type]++
+	}
+	if counts["passComplete"] != 1 || counts["error"] != 0 {
+		t.Errorf("Unexpected events %v", counts)
+	}
+	if req.Width != 6*32 {
+		t.Errorf("Expected a cubemap 6 faces wide, got width %d", req.Width)
+	}
+
+	req.Projection = "fisheye"
+	if events := collectEvents(t, req); len(events) == 0 || events[len(events)-1].Type != "error" {
+		t.Errorf("Expected an error for an unknown projection, got %v", events)
+	}
+}
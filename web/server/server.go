@@ -3,11 +3,12 @@ package server
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/df07/go-progressive-raytracer/pkg/core"
 	"github.com/df07/go-progressive-raytracer/pkg/geometry"
@@ -22,11 +23,30 @@ const (
 	DefaultTileSize          = 64   // Size of each tile in pixels
 	TileUpdateChannelBuffer  = 100  // Buffer size for tile update channel
 	MaxConcurrentTileUpdates = 1000 // Maximum tiles that can be queued
+
+	maxPyramidLevels = 4 // Coarsest preview at 1/16 resolution
 )
 
+// webLog is the server's log, apart from each render's (see WebLogger)
+var webLog = core.NewLog("web")
+
 // Server handles web requests for the progressive raytracer
 type Server struct {
 	port int
+
+	// Watching PBRT scene files, enabled by EnableWatch: a reloader per file, so renders after an
+	// edit reuse what the edit didn't change
+	watch     bool
+	reloadMu  sync.Mutex
+	reloaders map[string]*scene.PBRTReloader
+
+	// Exposure of the latest pass rendered, served by /api/exposure
+	exposureMu sync.Mutex
+	exposure   *ExposureResponse
+
+	// The latest render, whose sampling profile /api/preview-profile switches and scene /api/edit edits
+	renderMu sync.Mutex
+	render   *renderer.ProgressiveRaytracer
 }
 
 // NewServer creates a new web server
@@ -34,17 +54,36 @@ func NewServer(port int) *Server {
 	return &Server{port: port}
 }
 
+// EnableWatch makes the server watch PBRT scene files: /api/watch streams a sceneChanged event
+// each time the file of a scene is saved, for the page to render it again, and renders of an
+// edited file reuse the shapes, lights and BVH of the previous version when only the camera changed
+func (s *Server) EnableWatch() {
+	s.watch = true
+	s.reloaders = make(map[string]*scene.PBRTReloader)
+}
+
 // RenderRequest represents a render request from the client
 type RenderRequest struct {
 	Scene              string  `json:"scene"`              // Scene name (e.g., "cornell-box")
+	Camera             string  `json:"camera"`             // Named camera to render from, or empty for the scene's default
+	Projection         string  `json:"projection"`         // Panorama seen from the camera's position (see geometry.Panoramas), or empty for its view
 	Width              int     `json:"width"`              // Image width
 	Height             int     `json:"height"`             // Image height
 	MaxSamples         int     `json:"maxSamples"`         // Maximum samples per pixel
 	MaxPasses          int     `json:"maxPasses"`          // Maximum number of passes
+	PyramidLevels      int     `json:"pyramidLevels"`      // Reduced resolution previews rendered before the passes
+	PreviewInterval    float64 `json:"previewInterval"`    // Seconds between preview events of the pass in progress (0 = none)
+	PreviewProfile     bool    `json:"previewProfile"`     // Render passes before the last with the preview sampling profile
+	FocusDistance      float64 `json:"focusDistance"`      // Camera focus distance, from /api/focus (0 = the scene's)
 	RRMinBounces       int     `json:"rrMinBounces"`       // Russian Roulette minimum bounces
+	RRMinProb          float64 `json:"rrMinProb"`          // Russian Roulette minimum survival probability
 	AdaptiveMinSamples float64 `json:"adaptiveMinSamples"` // Adaptive sampling minimum samples as percentage (0.0-1.0)
 	AdaptiveThreshold  float64 `json:"adaptiveThreshold"`  // Adaptive sampling relative error threshold
-	Integrator         string  `json:"integrator"`         // Integrator type: "path-tracing" or "bdpt"
+	Integrator         string  `json:"integrator"`         // Integrator type: "path-tracing", "bdpt", "ao" or "direct"
+	Filter             string  `json:"filter"`             // Pixel reconstruction filter (see scene.PixelFilters), or empty for the scene's
+
+	// Edits the page's editor made to the scene, applied in order once it's built (JSON in the query's edits)
+	Edits []SceneEdit `json:"edits"`
 
 	// Scene-specific configuration
 	CornellGeometry      string           `json:"cornellGeometry"`      // Cornell box geometry type: "spheres", "boxes", "empty"
@@ -54,6 +93,10 @@ type RenderRequest struct {
 	SphereComplexity     int              `json:"sphereComplexity"`     // Triangle mesh sphere complexity
 	DragonMaterialFinish string           `json:"dragonMaterialFinish"` // Dragon material finish: "gold", "plastic", "matte", "mirror", "glass", "copper"
 	LightType            lights.LightType `json:"lightType"`            // Light type: "area", "point"
+
+	// PBRT scene source to render instead of Scene. Only set by the WebAssembly build, where the
+	// browser supplies the file; the HTTP API only renders scenes from the server's own files.
+	PBRTSource string `json:"pbrtSource"`
 }
 
 // Stats represents render statistics
@@ -79,9 +122,16 @@ func (s *Server) Start() error {
 	http.HandleFunc("/api/scene-config", s.handleSceneConfig)
 	http.HandleFunc("/api/scenes", s.handleScenes) // Scene discovery
 	http.HandleFunc("/api/inspect", s.handleInspect)
+	http.HandleFunc("/api/overlay", s.handleOverlay)
+	http.HandleFunc("/api/trace-sample", s.handleTraceSample)
+	http.HandleFunc("/api/focus", s.handleFocus)                    // Focus distance of what a pixel shows
+	http.HandleFunc("/api/exposure", s.handleExposure)              // Latest pass's luminance histogram and clipping
+	http.HandleFunc("/api/preview-profile", s.handlePreviewProfile) // Switch the latest render's sampling profile
+	http.HandleFunc("/api/edit", s.handleEdit)                      // Edit the scene of the render in progress
+	http.HandleFunc("/api/watch", s.handleWatch)                    // Scene file changes, with EnableWatch
 
 	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("Starting web server on http://localhost%s", addr)
+	webLog.Printf("Starting web server on http://localhost%s", addr)
 	return http.ListenAndServe(addr, nil)
 }
 
@@ -113,7 +163,7 @@ func (s *Server) handleScenes(w http.ResponseWriter, r *http.Request) {
 	// Get all scenes using the scene discovery service
 	scenes, err := scene.ListAllScenes()
 	if err != nil {
-		log.Printf("Error listing scenes: %v", err)
+		webLog.Logf(core.LevelError, "Error listing scenes: %v", err)
 		http.Error(w, "Failed to list scenes", http.StatusInternalServerError)
 		return
 	}
@@ -138,6 +188,18 @@ func parseIntParam(values url.Values, key string, defaultValue, min, max int) (i
 	return defaultValue, nil
 }
 
+// parseBoolParam parses a boolean parameter from URL query
+func parseBoolParam(values url.Values, key string, defaultValue bool) (bool, error) {
+	if value := values.Get(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s: %s", key, value)
+		}
+		return parsed, nil
+	}
+	return defaultValue, nil
+}
+
 // parseFloatParam parses a float parameter from URL query with validation
 func parseFloatParam(values url.Values, key string, defaultValue, min, max float64) (float64, error) {
 	if value := values.Get(key); value != "" {
@@ -163,15 +225,33 @@ func (s *Server) parseCommonSceneParams(r *http.Request, req *RenderRequest) err
 	} else {
 		req.Scene = "cornell-box" // Default scene
 	}
+	req.Camera = r.URL.Query().Get("camera")
 
-	// Parse width and height
-	if req.Width, err = parseIntParam(r.URL.Query(), "width", 400, 100, 2000); err != nil {
+	// Parse width and height; panoramas are as wide as their projection makes them
+	req.Projection = r.URL.Query().Get("projection")
+	if req.Height, err = parseIntParam(r.URL.Query(), "height", 400, 100, 2000); err != nil {
 		return err
 	}
-	if req.Height, err = parseIntParam(r.URL.Query(), "height", 400, 100, 2000); err != nil {
+	if req.Projection != "" {
+		projection := geometry.Projection(req.Projection)
+		if !slices.Contains(geometry.Panoramas, projection) {
+			return fmt.Errorf("unknown projection: %s", req.Projection)
+		}
+		req.Width = int(float64(req.Height) * projection.AspectRatio())
+	} else if req.Width, err = parseIntParam(r.URL.Query(), "width", 400, 100, 2000); err != nil {
 		return err
 	}
 
+	// Parse the page's scene edits
+	if edits := r.URL.Query().Get("edits"); edits != "" {
+		if err := json.Unmarshal([]byte(edits), &req.Edits); err != nil {
+			return fmt.Errorf("invalid edits: %v", err)
+		}
+		if len(req.Edits) > maxSceneEdits {
+			return fmt.Errorf("%d edits is more than the %d allowed", len(req.Edits), maxSceneEdits)
+		}
+	}
+
 	// Parse Cornell geometry type
 	req.CornellGeometry = r.URL.Query().Get("cornellGeometry")
 	if req.CornellGeometry == "" {
@@ -222,12 +302,46 @@ func (s *Server) parseCommonSceneParams(r *http.Request, req *RenderRequest) err
 	if req.Integrator == "" {
 		req.Integrator = "path-tracing" // Default integrator
 	}
+	req.Filter = r.URL.Query().Get("filter")
 
 	return nil
 }
 
-// createScene creates a scene based on the scene name and optionally updates camera for requested dimensions
+// createScene creates a scene based on the scene name and optionally updates camera for requested
+// dimensions, seen from the requested named camera
 func (s *Server) createScene(req *RenderRequest, configOnly bool, logger core.Logger) *scene.Scene {
+	sceneObj := s.createSceneFromDefaultCamera(req, configOnly, logger)
+	if sceneObj == nil {
+		return nil
+	}
+	if req.Camera != "" {
+		if err := sceneObj.UseCamera(req.Camera); err != nil {
+			webLog.Logf(core.LevelError, "Scene %s: %v", req.Scene, err)
+			return nil
+		}
+	}
+	if err := req.usePanorama(sceneObj); err != nil {
+		webLog.Logf(core.LevelError, "Scene %s: %v", req.Scene, err)
+		return nil
+	}
+	return sceneObj
+}
+
+// usePanorama switches the scene to the requested panorama, if any, seen from its camera's
+// position and as tall as the requested image, which becomes as wide as the panorama
+func (req *RenderRequest) usePanorama(sceneObj *scene.Scene) error {
+	if req.Projection == "" {
+		return nil
+	}
+	if err := sceneObj.UsePanorama(geometry.Projection(req.Projection), sceneObj.CameraConfig.Center, req.Height); err != nil {
+		return err
+	}
+	req.Width = sceneObj.SamplingConfig.Width
+	return nil
+}
+
+// createSceneFromDefaultCamera creates a scene as createScene does, seen from its default camera
+func (s *Server) createSceneFromDefaultCamera(req *RenderRequest, configOnly bool, logger core.Logger) *scene.Scene {
 	// Use default logger if none provided
 	if logger == nil {
 		logger = renderer.NewDefaultLogger()
@@ -243,36 +357,26 @@ func (s *Server) createScene(req *RenderRequest, configOnly bool, logger core.Lo
 
 	// Handle PBRT scenes first (they start with "pbrt:")
 	if strings.HasPrefix(req.Scene, "pbrt:") {
-		// Security: Validate scene ID against known PBRT scenes to prevent path traversal
-		pbrtScenes, err := scene.ListPBRTScenes()
+		scenePath, err := pbrtScenePath(req.Scene)
 		if err != nil {
-			log.Printf("Failed to list PBRT scenes for validation: %v", err)
+			webLog.Logf(core.LevelError, "%v", err)
 			return nil
 		}
 
-		// Find the scene with matching ID
-		var scenePath string
-		for _, pbrtScene := range pbrtScenes {
-			if pbrtScene.ID == req.Scene {
-				scenePath = pbrtScene.FilePath
-				break
-			}
-		}
-
-		if scenePath == "" {
-			log.Printf("Invalid PBRT scene ID: %s", req.Scene)
-			return nil // Unknown scene ID
-		}
-
 		// Load actual PBRT scene with camera override using validated path
 		parsedScene, err := loaders.LoadPBRT(scenePath)
 		if err != nil {
-			log.Printf("Failed to load PBRT file %s: %v", scenePath, err)
+			webLog.Logf(core.LevelError, "Failed to load PBRT file %s: %v", scenePath, err)
 			return nil // Return nil to trigger proper error response
 		}
-		pbrtScene, err := scene.NewPBRTScene(parsedScene, cameraOverride)
+		var pbrtScene *scene.Scene
+		if s.watch {
+			pbrtScene, err = s.reloadPBRTScene(scenePath, parsedScene, cameraOverride, logger)
+		} else {
+			pbrtScene, err = scene.NewPBRTScene(parsedScene, cameraOverride)
+		}
 		if err != nil {
-			log.Printf("Failed to create PBRT scene %s: %v", scenePath, err)
+			webLog.Logf(core.LevelError, "Failed to create PBRT scene %s: %v", scenePath, err)
 			return nil // Return nil to trigger proper error response
 		}
 		return pbrtScene
@@ -312,7 +416,7 @@ func (s *Server) createScene(req *RenderRequest, configOnly bool, logger core.Lo
 		return scene.NewTriangleMeshScene(req.SphereComplexity, cameraOverride)
 	case "dragon":
 		loadMesh := !configOnly
-		return scene.NewDragonScene(loadMesh, req.DragonMaterialFinish, logger, cameraOverride)
+		return scene.NewDragonScene(loadMesh, req.DragonMaterialFinish, false, logger, cameraOverride)
 	case "caustic-glass":
 		loadMesh := !configOnly
 		return scene.NewCausticGlassScene(loadMesh, req.LightType, logger, cameraOverride)
@@ -330,12 +434,12 @@ func (s *Server) createScene(req *RenderRequest, configOnly bool, logger core.Lo
 		// Load actual PBRT scene
 		parsedScene, err := loaders.LoadPBRT("scenes/cornell-empty.pbrt")
 		if err != nil {
-			log.Printf("Failed to load PBRT file: %v", err)
+			webLog.Logf(core.LevelError, "Failed to load PBRT file: %v", err)
 			return scene.NewCornellScene(scene.CornellEmpty, scene.CornellQuadLight, cameraOverride)
 		}
 		pbrtScene, err := scene.NewPBRTScene(parsedScene, cameraOverride)
 		if err != nil {
-			log.Printf("Failed to create PBRT scene: %v", err)
+			webLog.Logf(core.LevelError, "Failed to create PBRT scene: %v", err)
 			return scene.NewCornellScene(scene.CornellEmpty, scene.CornellQuadLight, cameraOverride)
 		}
 		return pbrtScene
@@ -392,6 +496,12 @@ func (s *Server) handleSceneConfig(w http.ResponseWriter, r *http.Request) {
 		webMaxPasses = 1000   // More passes for progressive feedback
 	}
 
+	// Scenes leave the roulette floor unset to use the integrator default
+	rrMinProb := config.RussianRouletteMinProb
+	if rrMinProb <= 0 {
+		rrMinProb = 0.05
+	}
+
 	response := map[string]interface{}{
 		"scene": sceneName,
 		"defaults": map[string]interface{}{
@@ -399,8 +509,10 @@ func (s *Server) handleSceneConfig(w http.ResponseWriter, r *http.Request) {
 			"height":                    defaultHeight,
 			"samplesPerPixel":           webMaxSamples,
 			"maxPasses":                 webMaxPasses,
+			"pyramidLevels":             0,
 			"maxDepth":                  config.MaxDepth,
 			"russianRouletteMinBounces": config.RussianRouletteMinBounces,
+			"russianRouletteMinProb":    rrMinProb,
 			"adaptiveMinSamples":        config.AdaptiveMinSamples,
 			"adaptiveThreshold":         config.AdaptiveThreshold,
 			"cornellGeometry":           "boxes",
@@ -427,10 +539,18 @@ func (s *Server) handleSceneConfig(w http.ResponseWriter, r *http.Request) {
 				"min": 1,
 				"max": 10000,
 			},
+			"pyramidLevels": map[string]int{
+				"min": 0,
+				"max": maxPyramidLevels,
+			},
 			"russianRouletteMinBounces": map[string]int{
 				"min": 1,
 				"max": 1000,
 			},
+			"russianRouletteMinProb": map[string]float64{
+				"min": 0.01,
+				"max": 1.0,
+			},
 			"adaptiveMinSamples": map[string]float64{
 				"min": 0.01,
 				"max": 1.0,
@@ -507,6 +627,20 @@ func (s *Server) handleSceneConfig(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Scenes with named cameras offer them, after their default camera ("")
+	if len(sceneObj.Cameras) > 0 {
+		sceneOptions, _ := response["sceneOptions"].(map[string]interface{})
+		if sceneOptions == nil {
+			sceneOptions = map[string]interface{}{}
+			response["sceneOptions"] = sceneOptions
+		}
+		sceneOptions["camera"] = map[string]interface{}{
+			"type":    "select",
+			"options": append([]string{""}, sceneObj.CameraNames()...),
+			"default": "",
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
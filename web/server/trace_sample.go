@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+// handleTraceSample traces one sample of a pixel again, exactly as /api/render with the same
+// parameters takes it, and returns its paths (vertices, PDFs, throughputs, BDPT strategies and MIS
+// weights) as JSON. It takes the parameters of /api/render plus x, y and sample (counting from 0).
+func (s *Server) handleTraceSample(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
+	}
+
+	req, err := s.parseRenderRequest(r)
+	if err != nil {
+		fail(http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	query := r.URL.Query()
+	x, err := parseIntParam(query, "x", 0, 0, req.Width-1)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	y, err := parseIntParam(query, "y", 0, 0, req.Height-1)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	sample, err := parseIntParam(query, "sample", 0, 0, req.MaxSamples-1)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pipeline, err := s.setupRenderingPipeline(r.Context(), req, renderer.NewDefaultLogger())
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	trace, err := pipeline.Raytracer.TraceSample(x, y, sample)
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(trace)
+}
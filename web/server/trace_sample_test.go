@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+)
+
+func TestHandleTraceSample(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	url := "/api/trace-sample?scene=basic&width=160&height=120&integrator=bdpt&maxSamples=4&maxPasses=2&adaptiveMinSamples=1&x=80&y=60&sample=2"
+	NewServer(0).handleTraceSample(recorder, httptest.NewRequest("GET", url, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var trace renderer.SampleTrace
+	if err := json.NewDecoder(recorder.Body).Decode(&trace); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if trace.X != 80 || trace.Y != 60 || trace.Sample != 2 || trace.Pass != 2 {
+		t.Errorf("Expected sample 2 of (80, 60) in pass 2, got sample %d of (%d, %d) in pass %d", trace.Sample, trace.X, trace.Y, trace.Pass)
+	}
+	if trace.SampleInspection == nil || len(trace.CameraPath) < 2 {
+		t.Errorf("Expected the sample's camera path, got %+v", trace.SampleInspection)
+	}
+
+	recorder = httptest.NewRecorder()
+	NewServer(0).handleTraceSample(recorder, httptest.NewRequest("GET", "/api/trace-sample?scene=basic&maxSamples=4&sample=4", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a sample past maxSamples, got %d", recorder.Code)
+	}
+}
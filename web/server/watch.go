@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/df07/go-progressive-raytracer/pkg/core"
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+// SceneChange is the data of a sceneChanged event
+type SceneChange struct {
+	Scene  string `json:"scene"`  // Scene ID, such as "pbrt:cornell-empty"
+	Change string `json:"change"` // "camera" or "world" (see scene.PBRTChange)
+}
+
+// pbrtScenePath returns the file of a PBRT scene ID ("pbrt:<name>"). Only scenes found by scene
+// discovery are allowed, which keeps requests from naming arbitrary files.
+func pbrtScenePath(sceneID string) (string, error) {
+	pbrtScenes, err := scene.ListPBRTScenes()
+	if err != nil {
+		return "", fmt.Errorf("failed to list PBRT scenes for validation: %v", err)
+	}
+	for _, pbrtScene := range pbrtScenes {
+		if pbrtScene.ID == sceneID {
+			return pbrtScene.FilePath, nil
+		}
+	}
+	return "", fmt.Errorf("invalid PBRT scene ID: %s", sceneID)
+}
+
+// reloadPBRTScene creates the scene of the current version of a watched PBRT file with the
+// file's reloader, reusing what hasn't changed since the previous render
+func (s *Server) reloadPBRTScene(scenePath string, parsed *loaders.PBRTScene, cameraOverride geometry.CameraConfig, logger core.Logger) (*scene.Scene, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	reloader, ok := s.reloaders[scenePath]
+	if !ok {
+		reloader = scene.NewPBRTReloader()
+		s.reloaders[scenePath] = reloader
+	}
+	sceneObj, change, err := reloader.Load(parsed, cameraOverride)
+	if err != nil {
+		return nil, err
+	}
+	if ok && change != scene.PBRTWorldChanged {
+		logger.Printf("Reusing the shapes, lights and BVH of %s, whose world is unchanged\n", scenePath)
+	}
+	return sceneObj, nil
+}
+
+// handleWatch streams an SSE event each time the file of the requested PBRT scene changes:
+// sceneChanged when an edit changes the image, or sceneError when the file doesn't load. It runs
+// until the client disconnects, and only when the server was started with EnableWatch.
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if !s.watch {
+		http.Error(w, "Not watching scene files (start the server with --watch)", http.StatusNotFound)
+		return
+	}
+	sceneID := r.URL.Query().Get("scene")
+	scenePath, err := pbrtScenePath(sceneID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Send the headers now, as events may be a long time coming
+	s.setSSEHeaders(w)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	ctx := r.Context()
+	sseEventChan := make(chan SSEEvent, 10)
+	go s.writeSSEEvents(w, ctx, sseEventChan)
+
+	// Compare each version with the last one that loaded (nil if none did, so any that loads is a change)
+	last, _ := loaders.LoadPBRT(scenePath)
+	webLog.Printf("Watching %s", scenePath)
+	for range loaders.WatchFile(ctx, scenePath, loaders.DefaultWatchInterval) {
+		event := SSEEvent{Type: "sceneError"}
+		if parsed, err := loaders.LoadPBRT(scenePath); err != nil {
+			event.Data = fmt.Sprintf("Failed to load %s: %v", scenePath, err)
+		} else {
+			change := scene.DiffPBRT(last, parsed)
+			last = parsed
+			if change == scene.PBRTUnchanged {
+				continue
+			}
+			data, err := json.Marshal(SceneChange{Scene: sceneID, Change: change.String()})
+			if err != nil {
+				webLog.Logf(core.LevelError, "Error marshaling scene change: %v", err)
+				continue
+			}
+			event = SSEEvent{Type: "sceneChanged", Data: string(data)}
+		}
+
+		select {
+		case sseEventChan <- event:
+		case <-ctx.Done():
+		}
+	}
+}
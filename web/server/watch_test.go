@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/df07/go-progressive-raytracer/pkg/geometry"
+	"github.com/df07/go-progressive-raytracer/pkg/loaders"
+	"github.com/df07/go-progressive-raytracer/pkg/renderer"
+	"github.com/df07/go-progressive-raytracer/pkg/scene"
+)
+
+func TestHandleWatch_Rejects(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer(0).handleWatch(recorder, httptest.NewRequest("GET", "/api/watch?scene=pbrt:cornell-empty", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without EnableWatch, got %d", recorder.Code)
+	}
+
+	watching := NewServer(0)
+	watching.EnableWatch()
+	recorder = httptest.NewRecorder()
+	watching.handleWatch(recorder, httptest.NewRequest("GET", "/api/watch?scene=pbrt:../../etc/passwd", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a scene that isn't discovered, got %d", recorder.Code)
+	}
+}
+
+func TestReloadPBRTScene(t *testing.T) {
+	s := NewServer(0)
+	s.EnableWatch()
+	source := `LookAt 0 0 5  0 0 0  0 1 0
+Camera "perspective" "float fov" 40
+WorldBegin
+LightSource "infinite" "rgb L" [ 1 1 1 ]
+Material "diffuse" "rgb reflectance" [ 0.5 0.5 0.5 ]
+Shape "sphere" "float radius" 1
+`
+	load := func(source string, width int) *scene.Scene {
+		t.Helper()
+		parsed, err := loaders.ParsePBRT(strings.NewReader(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sceneObj, err := s.reloadPBRTScene("scenes/test.pbrt", parsed,
+			geometry.CameraConfig{Width: width, AspectRatio: 1}, renderer.NewDefaultLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sceneObj.Preprocess(); err != nil {
+			t.Fatal(err)
+		}
+		return sceneObj
+	}
+
+	first := load(source, 64)
+	resized := load(strings.Replace(source, "LookAt 0 0 5", "LookAt 0 1 5", 1), 32)
+	if resized.BVH != first.BVH {
+		t.Error("Expected a camera edit to reuse the BVH")
+	}
+	if resized.SamplingConfig.Width != 32 {
+		t.Errorf("Expected the request's width of 32, got %d", resized.SamplingConfig.Width)
+	}
+	if edited := load(strings.Replace(source, `"float radius" 1`, `"float radius" 2`, 1), 32); edited.BVH == first.BVH {
+		t.Error("Expected a shape edit to rebuild the BVH")
+	}
+}
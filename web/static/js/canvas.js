@@ -85,6 +85,22 @@ class RenderCanvas {
         }
     }
     
+    // Replace the whole image, such as with a preview of the pass in progress. Tiles still fading
+    // in are newer, and keep drawing over it.
+    updateImage(imageDataUrl) {
+        const renderID = this.currentRenderID;
+        const img = new Image();
+        img.onload = () => {
+            if (renderID === this.currentRenderID) {
+                this.ctx.drawImage(img, 0, 0);
+            }
+        };
+        img.onerror = () => {
+            console.warn('Failed to load preview image');
+        };
+        img.src = imageDataUrl;
+    }
+    
     // Start fade-in animation for a tile
     startTileAnimation(tileID, image, x, y) {
         const now = performance.now();
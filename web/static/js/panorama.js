@@ -0,0 +1,146 @@
+// Looks around a rendered 360° panorama: drag to turn, scroll to zoom, or turn a phone or headset
+// that reports its orientation. Views are drawn by looking up, for each pixel, where the panorama
+// saw its direction, with the same mapping the renderer's equirectangular and cubemap cameras use.
+class PanoramaViewer {
+    constructor(sourceCanvas, viewCanvas, projection) {
+        this.source = sourceCanvas;
+        this.view = viewCanvas;
+        this.ctx = viewCanvas.getContext('2d');
+        this.projection = projection;
+
+        this.yaw = 0;   // Radians to the right of the panorama's front
+        this.pitch = 0; // Radians above its horizon
+        this.fov = 75;  // Vertical field of view in degrees
+        this.dragging = null;
+
+        // Cubemap faces in image order, as forward, right and up in the panorama's own frame of
+        // right (x), up (y) and front (z)
+        this.faces = [
+            { forward: [1, 0, 0], right: [0, 0, -1], up: [0, 1, 0] },  // Right
+            { forward: [-1, 0, 0], right: [0, 0, 1], up: [0, 1, 0] },  // Left
+            { forward: [0, 1, 0], right: [1, 0, 0], up: [0, 0, -1] },  // Up
+            { forward: [0, -1, 0], right: [1, 0, 0], up: [0, 0, 1] },  // Down
+            { forward: [0, 0, 1], right: [1, 0, 0], up: [0, 1, 0] },   // Front
+            { forward: [0, 0, -1], right: [-1, 0, 0], up: [0, 1, 0] }, // Back
+        ];
+
+        this.onPointerDown = (event) => {
+            this.dragging = { x: event.clientX, y: event.clientY, yaw: this.yaw, pitch: this.pitch };
+            this.view.setPointerCapture(event.pointerId);
+        };
+        this.onPointerMove = (event) => {
+            if (!this.dragging) return;
+            // Dragging moves the scene with the pointer, a view's height turning it by its field of view
+            const radiansPerPixel = (this.fov * Math.PI / 180) / this.view.clientHeight;
+            this.yaw = this.dragging.yaw - (event.clientX - this.dragging.x) * radiansPerPixel;
+            this.setPitch(this.dragging.pitch + (event.clientY - this.dragging.y) * radiansPerPixel);
+            this.draw();
+        };
+        this.onPointerUp = () => { this.dragging = null; };
+        this.onWheel = (event) => {
+            event.preventDefault();
+            this.fov = Math.min(120, Math.max(20, this.fov + event.deltaY * 0.05));
+            this.draw();
+        };
+        this.onOrientation = (event) => {
+            if (event.alpha === null || event.beta === null) return;
+            // Upright in portrait, the device looks along its back: alpha turns it, beta tilts it
+            this.yaw = -event.alpha * Math.PI / 180;
+            this.setPitch((event.beta - 90) * Math.PI / 180);
+            this.draw();
+        };
+
+        this.view.addEventListener('pointerdown', this.onPointerDown);
+        this.view.addEventListener('pointermove', this.onPointerMove);
+        this.view.addEventListener('pointerup', this.onPointerUp);
+        this.view.addEventListener('wheel', this.onWheel, { passive: false });
+        window.addEventListener('deviceorientation', this.onOrientation);
+    }
+
+    // Stop listening for input
+    close() {
+        this.view.removeEventListener('pointerdown', this.onPointerDown);
+        this.view.removeEventListener('pointermove', this.onPointerMove);
+        this.view.removeEventListener('pointerup', this.onPointerUp);
+        this.view.removeEventListener('wheel', this.onWheel);
+        window.removeEventListener('deviceorientation', this.onOrientation);
+    }
+
+    setPitch(pitch) {
+        const limit = Math.PI / 2 - 0.001;
+        this.pitch = Math.min(limit, Math.max(-limit, pitch));
+    }
+
+    // Draw the view from the panorama as it is now, which may still be rendering
+    draw() {
+        const width = this.view.width;
+        const height = this.view.height;
+        const sourceWidth = this.source.width;
+        const sourceHeight = this.source.height;
+        if (sourceWidth === 0 || sourceHeight === 0) return;
+        const source = this.source.getContext('2d').getImageData(0, 0, sourceWidth, sourceHeight).data;
+        const output = this.ctx.createImageData(width, height);
+
+        // The view's basis in the panorama's frame
+        const cosPitch = Math.cos(this.pitch);
+        const forward = [Math.sin(this.yaw) * cosPitch, Math.sin(this.pitch), Math.cos(this.yaw) * cosPitch];
+        const right = [Math.cos(this.yaw), 0, -Math.sin(this.yaw)];
+        const up = cross(forward, right);
+        const halfHeight = Math.tan(this.fov * Math.PI / 360);
+        const halfWidth = halfHeight * width / height;
+
+        for (let j = 0; j < height; j++) {
+            const b = (1 - 2 * (j + 0.5) / height) * halfHeight;
+            for (let i = 0; i < width; i++) {
+                const a = (2 * (i + 0.5) / width - 1) * halfWidth;
+                const direction = [
+                    forward[0] + right[0] * a + up[0] * b,
+                    forward[1] + right[1] * a + up[1] * b,
+                    forward[2] + right[2] * a + up[2] * b,
+                ];
+                const [x, y] = this.panoramaPoint(direction, sourceWidth, sourceHeight);
+                const from = 4 * (Math.min(sourceHeight - 1, Math.floor(y)) * sourceWidth + Math.min(sourceWidth - 1, Math.floor(x)));
+                const to = 4 * (j * width + i);
+                output.data[to] = source[from];
+                output.data[to + 1] = source[from + 1];
+                output.data[to + 2] = source[from + 2];
+                output.data[to + 3] = 255;
+            }
+        }
+        this.ctx.putImageData(output, 0, 0);
+    }
+
+    // Where the panorama saw a direction, in pixel coordinates
+    panoramaPoint(direction, width, height) {
+        const length = Math.hypot(direction[0], direction[1], direction[2]);
+        const [x, y, z] = direction.map(c => c / length);
+        if (this.projection === 'equirectangular') {
+            const phi = Math.atan2(x, z);
+            const theta = Math.acos(Math.max(-1, Math.min(1, y)));
+            return [(phi / (2 * Math.PI) + 0.5) * width, theta / Math.PI * height];
+        }
+
+        let index = 0;
+        let cosTheta = -Infinity;
+        this.faces.forEach((face, i) => {
+            const cos = dot([x, y, z], face.forward);
+            if (cos > cosTheta) {
+                index = i;
+                cosTheta = cos;
+            }
+        });
+        const face = this.faces[index];
+        const a = dot([x, y, z], face.right) / cosTheta;
+        const b = -dot([x, y, z], face.up) / cosTheta;
+        const size = width / 6;
+        return [(index + (a + 1) / 2) * size, (b + 1) / 2 * height];
+    }
+}
+
+function dot(a, b) {
+    return a[0] * b[0] + a[1] * b[1] + a[2] * b[2];
+}
+
+function cross(a, b) {
+    return [a[1] * b[2] - a[2] * b[1], a[2] * b[0] - a[0] * b[2], a[0] * b[1] - a[1] * b[0]];
+}
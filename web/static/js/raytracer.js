@@ -4,6 +4,12 @@ class ProgressiveRaytracer {
       this.isRendering = false;
       this.renderCompleted = false; // Track completion state
       this.limits = null; // Store server-provided limits
+      this.watchSource = null; // Scene file changes, when the server watches them (--watch)
+      this.watchedScene = null;
+      this.rerenderOnChange = false; // Render again when the watched scene file changes, until stopped
+      this.sceneEdits = []; // Edits made by clicking the image, sent with every render
+      this.panoramaProjection = ''; // Projection of the panorama rendered, if the image is one
+      this.panoramaViewer = null; // Looking around the panorama, while View 360° is open
       
       this.initializeTheme();
       this.bindEvents();
@@ -37,8 +43,24 @@ class ProgressiveRaytracer {
 
   bindEvents() {
       document.getElementById('startBtn').addEventListener('click', () => this.startRendering());
-      document.getElementById('stopBtn').addEventListener('click', () => this.stopRendering());
-      document.getElementById('scene').addEventListener('change', () => this.loadSceneDefaults());
+      document.getElementById('stopBtn').addEventListener('click', () => {
+          this.rerenderOnChange = false;
+          this.stopRendering();
+      });
+      document.getElementById('scene').addEventListener('change', () => {
+          document.getElementById('focusDistance').value = '';
+          this.setSceneEdits([]);
+          this.loadSceneDefaults();
+          this.watchScene();
+      });
+      document.getElementById('overlay').addEventListener('change', () => this.updateOverlay());
+      document.getElementById('previewProfile').addEventListener('change', () => this.switchPreviewProfile());
+      document.getElementById('undoEditBtn').addEventListener('click', () => this.undoSceneEdit());
+      document.getElementById('panoramaBtn').addEventListener('click', () => this.togglePanoramaView());
+      // Edits pick objects by pixel, so they only hold for the image size and projection they were made at
+      ['width', 'height', 'projection'].forEach(id => {
+          document.getElementById(id).addEventListener('change', () => this.setSceneEdits([]));
+      });
       
       // Canvas click handler is set up in initializeTileStreaming
       
@@ -144,6 +166,7 @@ class ProgressiveRaytracer {
               document.getElementById('maxSamples').value = config.defaults.samplesPerPixel;
               document.getElementById('maxPasses').value = config.defaults.maxPasses;
               document.getElementById('rrMinBounces').value = config.defaults.russianRouletteMinBounces;
+              document.getElementById('rrMinProb').value = config.defaults.russianRouletteMinProb;
               document.getElementById('adaptiveMinSamples').value = config.defaults.adaptiveMinSamples;
               document.getElementById('adaptiveThreshold').value = config.defaults.adaptiveThreshold;
               
@@ -172,6 +195,7 @@ class ProgressiveRaytracer {
           { id: 'maxSamples', limits: limits.maxSamples },
           { id: 'maxPasses', limits: limits.maxPasses },
           { id: 'rrMinBounces', limits: limits.russianRouletteMinBounces },
+          { id: 'rrMinProb', limits: limits.russianRouletteMinProb },
           { id: 'adaptiveMinSamples', limits: limits.adaptiveMinSamples },
           { id: 'adaptiveThreshold', limits: limits.adaptiveThreshold },
       ];
@@ -242,6 +266,7 @@ class ProgressiveRaytracer {
           case 'materialFinish': return 'Material Finish';
           case 'dragonMaterialFinish': return 'Dragon Material';
           case 'lightType': return 'Light Type';
+          case 'camera': return 'Camera';
           default: return key.charAt(0).toUpperCase() + key.slice(1);
       }
   }
@@ -261,6 +286,7 @@ class ProgressiveRaytracer {
           case 'gold': return 'Gold';
           case 'plastic': return 'Plastic';
           case 'copper': return 'Copper';
+          case '': return 'Scene Default';
           default: return value.charAt(0).toUpperCase() + value.slice(1);
       }
   }
@@ -290,6 +316,18 @@ class ProgressiveRaytracer {
           url += url.includes('?') ? '&' : '?';
           url += `lightType=${params.lightType}`;
       }
+      if (params.camera) {
+          url += url.includes('?') ? '&' : '?';
+          url += `camera=${encodeURIComponent(params.camera)}`;
+      }
+      if (params.projection) {
+          url += url.includes('?') ? '&' : '?';
+          url += `projection=${params.projection}`;
+      }
+      if (params.edits) {
+          url += url.includes('?') ? '&' : '?';
+          url += `edits=${encodeURIComponent(params.edits)}`;
+      }
       
       return url;
   }
@@ -316,6 +354,13 @@ class ProgressiveRaytracer {
       // Initialize tile streaming
       this.initializeTileStreaming(params);
 
+      if (document.getElementById('renderTarget').value === 'browser') {
+          this.startBrowserRendering(params);
+          return;
+      }
+
+      this.rerenderOnChange = true;
+      this.watchScene();
       this.eventSource = new EventSource(url);
 
       this.eventSource.onopen = () => {
@@ -329,12 +374,22 @@ class ProgressiveRaytracer {
           this.updateTile(data);
       });
 
+      // Preview of the pass in progress, the whole image at once
+      this.eventSource.addEventListener('preview', (event) => {
+          this.updatePreview(JSON.parse(event.data));
+      });
+
       // Pass completion handler
       this.eventSource.addEventListener('passComplete', (event) => {
           const data = JSON.parse(event.data);
           this.updatePassComplete(data);
       });
 
+      // Progress handler (ETA, throughput, pass times)
+      this.eventSource.addEventListener('progress', (event) => {
+          this.updateProgress(JSON.parse(event.data));
+      });
+
       // Console message handler
       this.eventSource.addEventListener('console', (event) => {
           const data = JSON.parse(event.data);
@@ -342,22 +397,7 @@ class ProgressiveRaytracer {
       });
 
       this.eventSource.addEventListener('complete', (event) => {
-          // Mark as completed FIRST to prevent error handlers from firing
-          this.renderCompleted = true;
-          this.isRendering = false;
-          
-          // Ensure progress bar shows 100% completion
-          const progressFill = document.getElementById('progressFill');
-          progressFill.style.width = '100%';
-          this.setStatus('complete', 'Rendering completed!');
-          
-          // Don't stop animations immediately - let them finish naturally
-          // Close the event source but keep animations running
-          if (this.eventSource) {
-              this.eventSource.close();
-              this.eventSource = null;
-          }
-          this.updateButtons();
+          this.completeRendering();
       });
 
       this.eventSource.addEventListener('error', (event) => {
@@ -383,11 +423,103 @@ class ProgressiveRaytracer {
       };
   }
 
+  // Render with the WebAssembly build in a worker; it sends the same events as the server's SSE stream
+  startBrowserRendering(params) {
+      if (!this.wasmWorker) {
+          this.wasmWorker = new Worker('js/wasm-worker.js');
+      }
+
+      this.wasmWorker.onmessage = (message) => {
+          const { type, data } = message.data;
+          switch (type) {
+              case 'tile':
+                  this.updateTile(JSON.parse(data));
+                  break;
+              case 'preview':
+                  this.updatePreview(JSON.parse(data));
+                  break;
+              case 'passComplete':
+                  this.updatePassComplete(JSON.parse(data));
+                  break;
+              case 'progress':
+                  this.updateProgress(JSON.parse(data));
+                  break;
+              case 'console':
+                  this.handleConsoleMessage(JSON.parse(data));
+                  break;
+              case 'complete':
+                  this.completeRendering();
+                  break;
+              case 'error':
+              case 'unavailable':
+                  console.error('Browser render error:', data);
+                  if (!this.renderCompleted) {
+                      this.setStatus('error', `Error: ${data}`);
+                      this.stopRendering();
+                  }
+                  break;
+          }
+      };
+
+      this.wasmWorker.postMessage({ type: 'render', request: this.toRenderRequest(params) });
+      this.setStatus('rendering', 'Rendering in browser...');
+  }
+
+  // Convert form parameters (strings) to a typed RenderRequest for the WebAssembly build
+  // Empty fields are left out so the renderer's defaults apply
+  toRenderRequest(params) {
+      const integerFields = ['width', 'height', 'maxSamples', 'maxPasses', 'pyramidLevels', 'rrMinBounces', 'sphereGridSize', 'sphereComplexity'];
+      const floatFields = ['previewInterval', 'focusDistance', 'rrMinProb', 'adaptiveMinSamples', 'adaptiveThreshold'];
+      const booleanFields = ['previewProfile'];
+      const jsonFields = ['edits'];
+
+      const request = {};
+      for (const [key, value] of Object.entries(params)) {
+          if (value === '' || value === undefined) {
+              continue;
+          }
+          if (integerFields.includes(key)) {
+              request[key] = parseInt(value);
+          } else if (floatFields.includes(key)) {
+              request[key] = parseFloat(value);
+          } else if (booleanFields.includes(key)) {
+              request[key] = value === 'true';
+          } else if (jsonFields.includes(key)) {
+              request[key] = JSON.parse(value);
+          } else {
+              request[key] = value;
+          }
+      }
+      return request;
+  }
+
+  completeRendering() {
+      // Mark as completed FIRST to prevent error handlers from firing
+      this.renderCompleted = true;
+      this.isRendering = false;
+      
+      // Ensure progress bar shows 100% completion
+      const progressFill = document.getElementById('progressFill');
+      progressFill.style.width = '100%';
+      this.setStatus('complete', 'Rendering completed!');
+      
+      // Don't stop animations immediately - let them finish naturally
+      // Close the event source but keep animations running
+      if (this.eventSource) {
+          this.eventSource.close();
+          this.eventSource = null;
+      }
+      this.updateButtons();
+  }
+
   stopRendering() {
       if (this.eventSource) {
           this.eventSource.close();
           this.eventSource = null;
       }
+      if (this.wasmWorker && this.isRendering) {
+          this.wasmWorker.postMessage({ type: 'stop' });
+      }
       
       // Force stop all canvas animations immediately
       if (this.renderCanvas) {
@@ -402,6 +534,38 @@ class ProgressiveRaytracer {
       }
   }
 
+  // Follow changes to the selected PBRT scene's file, rendering it again after each edit. The
+  // server only streams them when started with --watch; otherwise the request fails and is dropped.
+  watchScene() {
+      const sceneName = document.getElementById('scene').value;
+      if (sceneName === this.watchedScene) return;
+
+      if (this.watchSource) {
+          this.watchSource.close();
+          this.watchSource = null;
+      }
+      this.watchedScene = sceneName;
+      if (!sceneName.startsWith('pbrt:')) return;
+
+      this.watchSource = new EventSource(`/api/watch?scene=${encodeURIComponent(sceneName)}`);
+      this.watchSource.addEventListener('sceneChanged', (event) => {
+          const data = JSON.parse(event.data);
+          console.log(`Scene file changed (${data.change})`);
+          if (this.rerenderOnChange && data.scene === document.getElementById('scene').value) {
+              this.stopRendering();
+              this.startRendering();
+          }
+      });
+      this.watchSource.addEventListener('sceneError', (event) => {
+          this.setStatus('error', `Error: ${event.data}`);
+      });
+      this.watchSource.onerror = () => {
+          // Not watching, or the server went away; don't keep retrying
+          this.watchSource.close();
+          this.watchSource = null;
+      };
+  }
+
   getParameters() {
       const params = {
           scene: document.getElementById('scene').value,
@@ -409,12 +573,26 @@ class ProgressiveRaytracer {
           height: document.getElementById('height').value,
           maxSamples: document.getElementById('maxSamples').value,
           maxPasses: document.getElementById('maxPasses').value,
+          pyramidLevels: document.getElementById('pyramidLevels').value,
+          previewInterval: document.getElementById('previewInterval').value,
+          previewProfile: document.getElementById('previewProfile').value,
           rrMinBounces: document.getElementById('rrMinBounces').value,
+          rrMinProb: document.getElementById('rrMinProb').value,
           adaptiveMinSamples: document.getElementById('adaptiveMinSamples').value,
           adaptiveThreshold: document.getElementById('adaptiveThreshold').value,
-          integrator: document.getElementById('integrator').value
+          integrator: document.getElementById('integrator').value,
+          filter: document.getElementById('filter').value,
+          focusDistance: document.getElementById('focusDistance').value,
+          projection: document.getElementById('projection').value,
+          edits: this.sceneEdits.length > 0 ? JSON.stringify(this.sceneEdits) : ''
       };
 
+      // Panoramas are as wide as their projection makes them
+      if (params.projection) {
+          const aspect = params.projection === 'cubemap' ? 6 : 2;
+          params.width = String(parseInt(params.height) * aspect);
+      }
+
       // Dynamically add all scene-specific parameters from the sceneOptions container
       const sceneOptionsContainer = document.getElementById('sceneOptions');
       if (sceneOptionsContainer) {
@@ -438,6 +616,7 @@ class ProgressiveRaytracer {
           maxSamples: { min: 1, max: 10000 },
           maxPasses: { min: 1, max: 100 },
           russianRouletteMinBounces: { min: 1, max: 50 },
+          russianRouletteMinProb: { min: 0.01, max: 1.0 },
           adaptiveMinSamples: { min: 0.01, max: 1.0 },
           adaptiveThreshold: { min: 0.001, max: 0.5 },
       };
@@ -447,10 +626,12 @@ class ProgressiveRaytracer {
       const maxSamples = parseInt(document.getElementById('maxSamples').value);
       const maxPasses = parseInt(document.getElementById('maxPasses').value);
       const rrMinBounces = parseInt(document.getElementById('rrMinBounces').value);
+      const rrMinProb = parseFloat(document.getElementById('rrMinProb').value);
       const adaptiveMinSamples = parseFloat(document.getElementById('adaptiveMinSamples').value);
       const adaptiveThreshold = parseFloat(document.getElementById('adaptiveThreshold').value);
 
-      if (isNaN(width) || width < limits.width.min || width > limits.width.max) {
+      const projection = document.getElementById('projection').value;
+      if (!projection && (isNaN(width) || width < limits.width.min || width > limits.width.max)) {
           return { isValid: false, error: `Width must be between ${limits.width.min} and ${limits.width.max}` };
       }
       if (isNaN(height) || height < limits.height.min || height > limits.height.max) {
@@ -465,6 +646,9 @@ class ProgressiveRaytracer {
       if (isNaN(rrMinBounces) || rrMinBounces < limits.russianRouletteMinBounces.min || rrMinBounces > limits.russianRouletteMinBounces.max) {
           return { isValid: false, error: `RR Min Bounces must be between ${limits.russianRouletteMinBounces.min} and ${limits.russianRouletteMinBounces.max}` };
       }
+      if (isNaN(rrMinProb) || rrMinProb < limits.russianRouletteMinProb.min || rrMinProb > limits.russianRouletteMinProb.max) {
+          return { isValid: false, error: `RR Min Probability must be between ${limits.russianRouletteMinProb.min} and ${limits.russianRouletteMinProb.max}` };
+      }
       if (isNaN(adaptiveMinSamples) || adaptiveMinSamples < limits.adaptiveMinSamples.min || adaptiveMinSamples > limits.adaptiveMinSamples.max) {
           return { isValid: false, error: `Adaptive Min Samples must be between ${limits.adaptiveMinSamples.min} and ${limits.adaptiveMinSamples.max} (percentage of max samples)` };
       }
@@ -490,6 +674,11 @@ class ProgressiveRaytracer {
       document.getElementById('primitiveCount').textContent = '-';
       document.getElementById('elapsed').textContent = '-';
       document.getElementById('avgLuminance').textContent = '-';
+      document.getElementById('exposure').textContent = '-';
+      document.getElementById('eta').textContent = '-';
+      document.getElementById('samplesPerSecond').textContent = '-';
+      document.getElementById('raysPerSecond').textContent = '-';
+      document.getElementById('passTimes').textContent = '-';
   }
 
   setStatus(type, message) {
@@ -509,6 +698,31 @@ class ProgressiveRaytracer {
   updateButtons() {
       document.getElementById('startBtn').disabled = this.isRendering;
       document.getElementById('stopBtn').disabled = !this.isRendering;
+      document.getElementById('panoramaBtn').disabled = !this.panoramaProjection;
+  }
+
+  // Switch between the rendered panorama and looking around it
+  togglePanoramaView() {
+      const canvas = document.getElementById('renderCanvas');
+      const view = document.getElementById('panoramaView');
+      const button = document.getElementById('panoramaBtn');
+      if (this.panoramaViewer) {
+          this.panoramaViewer.close();
+          this.panoramaViewer = null;
+          view.style.display = 'none';
+          canvas.style.display = 'block';
+          button.textContent = 'View 360°';
+          this.updateOverlay();
+          return;
+      }
+      if (!this.panoramaProjection) return;
+
+      this.panoramaViewer = new PanoramaViewer(canvas, view, this.panoramaProjection);
+      canvas.style.display = 'none';
+      document.getElementById('overlayImage').style.display = 'none';
+      view.style.display = 'block';
+      button.textContent = 'Back to Image';
+      this.panoramaViewer.draw();
   }
 
   displayInspectResult(result, pixelX, pixelY) {
@@ -702,6 +916,13 @@ class ProgressiveRaytracer {
       const canvas = document.getElementById('renderCanvas');
       const noImage = document.getElementById('noImage');
       
+      // A new render replaces the panorama being looked around, if any
+      if (this.panoramaViewer) {
+          this.togglePanoramaView();
+      }
+      this.panoramaProjection = params.projection;
+      this.updateButtons();
+
       // Initialize render canvas
       this.renderCanvas = new RenderCanvas(canvas, 64);
       this.renderCanvas.initCanvas(parseInt(params.width), parseInt(params.height));
@@ -711,6 +932,45 @@ class ProgressiveRaytracer {
       
       // Add click handler for canvas
       canvas.onclick = (event) => this.handleCanvasClick(event);
+
+      this.updateOverlay();
+  }
+
+  // Lay the selected overlay's lines over the canvas, fetched for the rendered scene and size
+  async updateOverlay() {
+      const overlayImage = document.getElementById('overlayImage');
+      const overlay = document.getElementById('overlay').value;
+      if (!overlay || !this.renderCanvas || this.panoramaViewer) {
+          overlayImage.style.display = 'none';
+          return;
+      }
+
+      const params = this.getParameters();
+      const baseUrl = `/api/overlay?scene=${params.scene}&width=${params.width}&height=${params.height}&overlay=${overlay}`;
+      try {
+          const response = await fetch(this.buildUrlWithSceneParams(baseUrl, params));
+          if (!response.ok) {
+              const error = await response.json();
+              console.warn('Overlay failed:', error.error);
+              overlayImage.style.display = 'none';
+              return;
+          }
+          if (overlayImage.src.startsWith('blob:')) {
+              URL.revokeObjectURL(overlayImage.src);
+          }
+          overlayImage.src = URL.createObjectURL(await response.blob());
+          overlayImage.style.display = 'block';
+      } catch (error) {
+          console.error('Overlay error:', error);
+          overlayImage.style.display = 'none';
+      }
+  }
+
+  // Handle previews of the pass in progress, which replace the whole image
+  updatePreview(data) {
+      if (this.renderCanvas) {
+          this.renderCanvas.updateImage(`data:image/png;base64,${data.imageData}`);
+      }
   }
 
   // Handle tile updates in streaming mode
@@ -719,23 +979,36 @@ class ProgressiveRaytracer {
           this.renderCanvas.updateTile(data.tileX, data.tileY, `data:image/png;base64,${data.imageData}`);
       }
       
-      // Only update progress/status if still actively rendering
+      // Only update status if still actively rendering (progress events move the progress bar)
       if (this.isRendering && data.totalPasses && data.totalTiles) {
-          // Calculate progress based on completed passes + current pass progress
-          const completedPasses = Math.max(0, data.passNumber - 1);
-          const currentPassProgress = data.tileNumber / data.totalTiles;
-          const totalProgress = (completedPasses + currentPassProgress) / data.totalPasses;
-          
-          // Update progress bar
-          const progressPercent = Math.min(totalProgress * 100, 100);
-          document.getElementById('progressFill').style.width = `${progressPercent.toFixed(1)}%`;
-          
           // Update status with detailed tile progress
           this.setStatus('rendering', 
               `Pass ${data.passNumber}/${data.totalPasses} - Tile ${data.tileNumber}/${data.totalTiles}`);
       }
   }
 
+  // Handle progress events: the progress bar, ETA, throughput and pass times
+  updateProgress(data) {
+      if (!this.isRendering) return;
+
+      document.getElementById('progressFill').style.width = `${Math.min(data.percent, 100).toFixed(1)}%`;
+      document.getElementById('elapsed').textContent = `${(data.elapsedMs / 1000).toFixed(1)}s`;
+      document.getElementById('eta').textContent = data.done ? '-' : (data.etaMs > 0 ? `${(data.etaMs / 1000).toFixed(1)}s` : '-');
+      document.getElementById('samplesPerSecond').textContent = this.formatRate(data.samplesPerSecond);
+      document.getElementById('raysPerSecond').textContent = this.formatRate(data.raysPerSecond);
+      document.getElementById('passTimes').textContent = data.passTimesMs.length > 0
+          ? data.passTimesMs.map(ms => `${(ms / 1000).toFixed(2)}s`).join(', ')
+          : '-';
+  }
+
+  // Abbreviate a rate with a metric suffix, such as 1.2k or 8.4M
+  formatRate(rate) {
+      if (rate >= 1e9) return `${(rate / 1e9).toFixed(1)}G`;
+      if (rate >= 1e6) return `${(rate / 1e6).toFixed(1)}M`;
+      if (rate >= 1e3) return `${(rate / 1e3).toFixed(1)}k`;
+      return rate.toFixed(0);
+  }
+
   // Handle pass completion in streaming mode
   updatePassComplete(data) {
       // Update stats
@@ -759,20 +1032,137 @@ class ProgressiveRaytracer {
       if (data.averageLuminance !== undefined) {
           document.getElementById('avgLuminance').textContent = data.averageLuminance.toFixed(4);
       }
+      if (this.eventSource) {
+          this.updateExposure(); // The server meters the passes it renders
+      }
+      if (this.panoramaViewer) {
+          this.panoramaViewer.draw(); // Look around the latest pass
+      }
       
       // Update status for pass completion (tile updates will handle in-progress status)
-      this.setStatus('rendering', `Pass ${data.passNumber}/${data.totalPasses} completed`);
+      const profile = data.previewProfile ? ' (preview profile)' : '';
+      this.setStatus('rendering', `Pass ${data.passNumber}/${data.totalPasses} completed${profile}`);
   }
 
-  // Handle canvas clicks for pixel inspection
+  // Switch the render in progress between the preview and full sampling profiles from its next
+  // pass on. Only the server can; browser renders keep the profile they started with.
+  async switchPreviewProfile() {
+      if (!this.eventSource || !this.isRendering) {
+          return;
+      }
+      const enabled = document.getElementById('previewProfile').value;
+      try {
+          const response = await fetch(`/api/preview-profile?enabled=${enabled}`, { method: 'POST' });
+          if (!response.ok) {
+              console.error('Preview profile error:', (await response.json()).error);
+          }
+      } catch (error) {
+          console.error('Preview profile error:', error);
+      }
+  }
+
+  // Show the exposure meter of the latest pass: how much clips and the suggested adjustment
+  async updateExposure() {
+      try {
+          const response = await fetch('/api/exposure');
+          if (!response.ok) {
+              return;
+          }
+          const exposure = await response.json();
+          const ev = exposure.suggestedEv;
+          document.getElementById('exposure').textContent =
+              `${(100 * exposure.clippedFraction).toFixed(1)}% clipped, ${ev >= 0 ? '+' : ''}${ev.toFixed(1)} EV suggested`;
+      } catch (error) {
+          console.error('Exposure error:', error);
+      }
+  }
+
+  // Handle canvas clicks for pixel inspection, focusing or editing the scene, as Canvas Click says
   handleCanvasClick(event) {
       if (!this.renderCanvas) return;
       
       this.renderCanvas.handleClick(event, (x, y) => {
-          this.handlePixelInspection(x, y);
+          const action = document.getElementById('canvasClick').value;
+          if (action === 'focus') {
+              this.focusAt(x, y);
+          } else if (action === 'remove' || action === 'move' || action === 'add') {
+              this.editScene(action, x, y);
+          } else {
+              this.handlePixelInspection(x, y);
+          }
       });
   }
 
+  // Edit the scene at a pixel. A server render in progress takes the edit at once and starts over;
+  // otherwise the scene renders again with the edit among its edits.
+  async editScene(op, pixelX, pixelY) {
+      const edit = { op, x: pixelX, y: pixelY };
+      if (op === 'move') {
+          edit.offset = document.getElementById('editOffset').value.split(',').map(parseFloat);
+          if (edit.offset.length !== 3 || edit.offset.some(isNaN)) {
+              this.setStatus('error', 'Move Offset should be x,y,z');
+              return;
+          }
+      } else if (op === 'add') {
+          edit.radius = parseFloat(document.getElementById('editRadius').value) || 0;
+      }
+
+      if (this.eventSource && this.isRendering) {
+          try {
+              const response = await fetch('/api/edit', { method: 'POST', body: JSON.stringify(edit) });
+              if (response.ok) {
+                  this.setSceneEdits([...this.sceneEdits, edit]);
+                  return;
+              }
+              // A conflict means the render ended first, so render again with the edit
+              if (response.status !== 409) {
+                  this.setStatus('error', `Edit failed: ${(await response.json()).error}`);
+                  return;
+              }
+          } catch (error) {
+              console.error('Edit error:', error);
+              this.setStatus('error', 'Network error while editing');
+              return;
+          }
+      }
+      this.setSceneEdits([...this.sceneEdits, edit]);
+      this.stopRendering();
+      this.startRendering();
+  }
+
+  // Undo the last scene edit and render again without it
+  undoSceneEdit() {
+      this.setSceneEdits(this.sceneEdits.slice(0, -1));
+      this.stopRendering();
+      this.startRendering();
+  }
+
+  setSceneEdits(edits) {
+      this.sceneEdits = edits;
+      document.getElementById('sceneEdits').textContent = edits.length > 0 ? edits.length : 'none';
+      document.getElementById('undoEditBtn').disabled = edits.length === 0;
+  }
+
+  // Focus the camera on what a pixel shows and render again at the new focus distance
+  async focusAt(pixelX, pixelY) {
+      const params = this.getParameters();
+      const baseUrl = `/api/focus?scene=${params.scene}&width=${params.width}&height=${params.height}&x=${pixelX}&y=${pixelY}`;
+      try {
+          const response = await fetch(this.buildUrlWithSceneParams(baseUrl, params));
+          const result = await response.json();
+          if (!response.ok) {
+              this.setStatus('error', `Focus failed: ${result.error}`);
+              return;
+          }
+          document.getElementById('focusDistance').value = result.focusDistance.toFixed(3);
+          this.stopRendering();
+          this.startRendering();
+      } catch (error) {
+          console.error('Focus error:', error);
+          this.setStatus('error', 'Network error while focusing');
+      }
+  }
+
   async handlePixelInspection(pixelX, pixelY) {
       // Get current render parameters
       const params = this.getParameters();
@@ -822,6 +1212,9 @@ class ProgressiveRaytracer {
           case 'warning':
               console.warn(message);
               break;
+          case 'debug':
+              console.debug(message);
+              break;
           case 'info':
           default:
               console.log(message);
@@ -0,0 +1,40 @@
+// Web Worker hosting the WebAssembly renderer (built by ./build-wasm.sh), so rendering doesn't block the page.
+// Messages in:  { type: 'render', request: {...RenderRequest fields} } or { type: 'stop' }
+// Messages out: { type, data } with the same event types and payloads as the /api/render SSE stream,
+//               plus { type: 'unavailable', data } if the module can't be loaded.
+importScripts('wasm_exec.js');
+
+const go = new Go();
+const ready = WebAssembly.instantiateStreaming(fetch('../raytracer.wasm'), go.importObject)
+    .then(result => {
+        go.run(result.instance); // Resolves only when the Go program exits, so don't wait on it
+    });
+
+let cancelRender = null;
+
+self.onmessage = async (message) => {
+    const { type, request } = message.data;
+
+    if (type === 'stop') {
+        if (cancelRender) {
+            cancelRender();
+            cancelRender = null;
+        }
+        return;
+    }
+
+    if (type === 'render') {
+        try {
+            await ready;
+        } catch (error) {
+            self.postMessage({ type: 'unavailable', data: `WebAssembly renderer not available: ${error}` });
+            return;
+        }
+        cancelRender = self.goRaytracerRender(JSON.stringify(request), (eventType, data) => {
+            if (eventType === 'complete' || eventType === 'error') {
+                cancelRender = null;
+            }
+            self.postMessage({ type: eventType, data });
+        });
+    }
+};
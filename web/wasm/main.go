@@ -0,0 +1,76 @@
+//go:build js && wasm
+
+// Command wasm is the WebAssembly build of the renderer, so small scenes can render entirely in the
+// browser. It registers goRaytracerRender(requestJSON, onEvent) on the global object; the request is
+// the web server's RenderRequest as JSON (optionally with pbrtSource holding a PBRT scene), and
+// onEvent(type, data) receives the same events as the /api/render stream. The call returns a
+// function that cancels the render.
+//
+// Go's WebAssembly port runs on the calling thread, so load it from a Web Worker (see
+// static/js/wasm-worker.js) to keep the page responsive. Build with ./build-wasm.sh.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/df07/go-progressive-raytracer/web/server"
+)
+
+func main() {
+	js.Global().Set("goRaytracerRender", js.FuncOf(render))
+	select {} // Keep the runtime alive to serve calls from JavaScript
+}
+
+// render starts a render in the background and returns its cancel function
+func render(this js.Value, args []js.Value) any {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeFunction {
+		panic("usage: goRaytracerRender(requestJSON, onEvent)")
+	}
+	onEvent := args[1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan server.SSEEvent, 100)
+	done := make(chan struct{})
+
+	req := server.NewDefaultRenderRequest()
+	if err := json.Unmarshal([]byte(args[0].String()), req); err != nil {
+		onEvent.Invoke("error", "Invalid request: "+err.Error())
+		cancel()
+		return js.FuncOf(func(js.Value, []js.Value) any { return nil })
+	}
+
+	go func() {
+		server.NewServer(0).StreamRender(ctx, req, events)
+		close(done)
+	}()
+
+	// Forward events to JavaScript until the render ends, then flush what's left
+	go func() {
+		defer cancel() // Stops the console stream
+		for {
+			select {
+			case event := <-events:
+				onEvent.Invoke(event.Type, event.Data)
+			case <-done:
+				for {
+					select {
+					case event := <-events:
+						onEvent.Invoke(event.Type, event.Data)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var cancelFunc js.Func
+	cancelFunc = js.FuncOf(func(js.Value, []js.Value) any {
+		cancel()
+		cancelFunc.Release()
+		return nil
+	})
+	return cancelFunc
+}